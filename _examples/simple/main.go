@@ -9,6 +9,7 @@ import (
 	"github.com/oklahomer/go-kasumi/logger"
 	"github.com/oklahomer/go-sarah/v4"
 	"github.com/oklahomer/go-sarah/v4/alerter/line"
+	"github.com/oklahomer/go-sarah/v4/secrets"
 	"github.com/oklahomer/go-sarah/v4/slack"
 	"github.com/oklahomer/go-sarah/v4/watchers"
 	"gopkg.in/yaml.v2"
@@ -20,6 +21,7 @@ import (
 	_ "simple/plugins/fixedtimer"
 	_ "simple/plugins/guess"
 	_ "simple/plugins/hello"
+	"simple/plugins/karma"
 	_ "simple/plugins/morning"
 	_ "simple/plugins/timer"
 	"simple/plugins/todo"
@@ -32,6 +34,7 @@ type myConfig struct {
 	Runner          *sarah.Config      `yaml:"runner"`
 	LineAlerter     *line.Config       `yaml:"line_alerter"`
 	PluginConfigDir string             `yaml:"plugin_config_dir"`
+	KarmaDir        string             `yaml:"karma_dir"`
 }
 
 func newMyConfig() *myConfig {
@@ -54,6 +57,15 @@ func main() {
 	// Read configuration file.
 	config := readConfig(*path)
 
+	// Replace the secret references such as "env:SLACK_TOKEN" with the actual values.
+	// The references in the plugin configuration files are also resolved on every (re)load.
+	resolver := secrets.NewResolver()
+	err := sarah.ResolveSecrets(context.Background(), config, resolver)
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterSecretResolver(resolver)
+
 	// When Bot encounters critical states, send alert to LINE.
 	// Any number of Alerter implementation can be registered.
 	sarah.RegisterAlerter(line.New(config.LineAlerter))
@@ -68,6 +80,16 @@ func main() {
 	todoCmd := todo.BuildCommand(&todo.DummyStorage{})
 	sarah.RegisterCommand(slack.SLACK, todoCmd)
 
+	// Setup karma command that keeps the scores in a directory so they survive a restart.
+	karmaStore := karma.NewMemoryStore()
+	if config.KarmaDir != "" {
+		karmaStore, err = karma.NewFileStore(config.KarmaDir)
+		if err != nil {
+			panic(err)
+		}
+	}
+	sarah.RegisterCommand(slack.SLACK, karma.NewCommand(karmaStore))
+
 	// Directly add Command to Bot.
 	// This Command is not subject to config file supervision.
 	sarah.RegisterCommand(slack.SLACK, echo.Command)
@@ -82,7 +104,7 @@ func main() {
 	}
 
 	// Run
-	err := sarah.Run(ctx, config.Runner)
+	err = sarah.Run(ctx, config.Runner)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,175 @@
+/*
+Package karma is an example of a command that keeps its state in a persistent key-value storage.
+
+Input "gopher++" or "gopher--" anywhere in a message to give or take a point.
+Input ".karma" to see the leaderboard, or ".karma gopher" to see the score of gopher.
+The scores are kept per channel, so each channel has its own leaderboard.
+
+The scores are stored via Store, so any storage can be plugged in by implementing the interface:
+
+	store, err := karma.NewFileStore("/var/lib/sarah/karma.json")
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterCommand(slack.SLACK, karma.NewCommand(store))
+
+NewStore adapts any sarah.Store such as the ones in the kvstore packages.
+*/
+package karma
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Identifier is the identifier of the karma command.
+const Identifier = "karma"
+
+// leaderboardSize is the maximum number of entries shown on the leaderboard.
+const leaderboardSize = 10
+
+var (
+	commandPattern = regexp.MustCompile(`^\.karma(\s|$)`)
+	votePattern    = regexp.MustCompile(`^(\S+?)(\+\+|--)$`)
+)
+
+type vote struct {
+	key   string
+	delta int
+}
+
+// parseVotes returns the votes in the given message such as "gopher++" and "bug--".
+// A key voted more than once in the same message is counted only once.
+func parseVotes(message string) []*vote {
+	var votes []*vote
+	seen := map[string]bool{}
+	for _, field := range strings.Fields(message) {
+		match := votePattern.FindStringSubmatch(field)
+		if match == nil {
+			continue
+		}
+
+		key := strings.ToLower(match[1])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		delta := 1
+		if match[2] == "--" {
+			delta = -1
+		}
+		votes = append(votes, &vote{key: key, delta: delta})
+	}
+	return votes
+}
+
+// scope returns the key to group the scores. The scores are grouped by the destination the Input came from, which typically is a channel.
+func scope(input sarah.Input) string {
+	return fmt.Sprint(input.ReplyTo())
+}
+
+// NewCommand creates and returns a karma command that stores the scores in the given Store.
+func NewCommand(store Store) sarah.Command {
+	return &command{
+		store: store,
+	}
+}
+
+type command struct {
+	store Store
+}
+
+var _ sarah.Command = (*command)(nil)
+
+func (cmd *command) Identifier() string {
+	return Identifier
+}
+
+func (cmd *command) Instruction(_ *sarah.HelpInput) string {
+	return `Input "gopher++" or "gopher--" to give or take a point. Input ".karma" to see the leaderboard.`
+}
+
+func (cmd *command) Match(input sarah.Input) bool {
+	message := strings.TrimSpace(input.Message())
+	return commandPattern.MatchString(message) || len(parseVotes(message)) > 0
+}
+
+func (cmd *command) Execute(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	message := strings.TrimSpace(input.Message())
+
+	var text string
+	var err error
+	if commandPattern.MatchString(message) {
+		key := strings.ToLower(sarah.StripMessage(commandPattern, message))
+		if key == "" {
+			text, err = cmd.leaderboard(input)
+		} else {
+			text, err = cmd.score(input, key)
+		}
+	} else {
+		text, err = cmd.vote(input, parseVotes(message))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}, nil
+}
+
+func (cmd *command) vote(input sarah.Input, votes []*vote) (string, error) {
+	var lines []string
+	for _, v := range votes {
+		score, err := cmd.store.Add(scope(input), v.key, v.delta)
+		if err != nil {
+			return "", fmt.Errorf("failed to update score of %s: %w", v.key, err)
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d", v.key, score))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (cmd *command) score(input sarah.Input, key string) (string, error) {
+	score, err := cmd.store.Get(scope(input), key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get score of %s: %w", key, err)
+	}
+	return fmt.Sprintf("%s: %d", key, score), nil
+}
+
+func (cmd *command) leaderboard(input sarah.Input) (string, error) {
+	scores, err := cmd.store.Scores(scope(input))
+	if err != nil {
+		return "", fmt.Errorf("failed to get scores: %w", err)
+	}
+	if len(scores) == 0 {
+		return "No one has karma yet.", nil
+	}
+
+	var keys []string
+	for key := range scores {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if scores[keys[i]] != scores[keys[j]] {
+			return scores[keys[i]] > scores[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > leaderboardSize {
+		keys = keys[:leaderboardSize]
+	}
+
+	var lines []string
+	for i, key := range keys {
+		lines = append(lines, fmt.Sprintf("%d. %s: %d", i+1, key, scores[key]))
+	}
+	return strings.Join(lines, "\n"), nil
+}
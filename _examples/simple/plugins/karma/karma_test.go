@@ -0,0 +1,168 @@
+package karma
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	MessageValue string
+	ReplyToValue sarah.OutputDestination
+}
+
+var _ sarah.Input = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return "sender"
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+type DummyStore struct {
+	AddFunc    func(string, string, int) (int, error)
+	GetFunc    func(string, string) (int, error)
+	ScoresFunc func(string) (map[string]int, error)
+}
+
+var _ Store = (*DummyStore)(nil)
+
+func (s *DummyStore) Add(scope string, key string, delta int) (int, error) {
+	return s.AddFunc(scope, key, delta)
+}
+
+func (s *DummyStore) Get(scope string, key string) (int, error) {
+	return s.GetFunc(scope, key)
+}
+
+func (s *DummyStore) Scores(scope string) (map[string]int, error) {
+	return s.ScoresFunc(scope)
+}
+
+func execute(t *testing.T, cmd sarah.Command, channel string, message string) string {
+	res, err := cmd.Execute(context.TODO(), &DummyInput{MessageValue: message, ReplyToValue: channel})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return res.Content.(string)
+}
+
+func TestParseVotes(t *testing.T) {
+	votes := parseVotes("Gopher++ thanks to go-sarah++ and gopher++ again, but bug-- ++ --")
+
+	expected := []*vote{
+		{key: "gopher", delta: 1},
+		{key: "go-sarah", delta: 1},
+		{key: "bug", delta: -1},
+	}
+	if len(votes) != len(expected) {
+		t.Fatalf("Unexpected votes are returned: %#v.", votes)
+	}
+	for i, v := range votes {
+		if *v != *expected[i] {
+			t.Errorf("Unexpected vote is returned at %d: %#v.", i, v)
+		}
+	}
+}
+
+func TestCommand_Match(t *testing.T) {
+	cmd := NewCommand(NewMemoryStore())
+	tests := []struct {
+		message string
+		matched bool
+	}{
+		{message: ".karma", matched: true},
+		{message: ".karma gopher", matched: true},
+		{message: "thanks, gopher++", matched: true},
+		{message: ".karmas", matched: false},
+		{message: "hello", matched: false},
+	}
+
+	for _, tt := range tests {
+		if cmd.Match(&DummyInput{MessageValue: tt.message}) != tt.matched {
+			t.Errorf("Unexpected match result for %s.", tt.message)
+		}
+	}
+}
+
+func TestCommand_Execute(t *testing.T) {
+	cmd := NewCommand(NewMemoryStore())
+
+	text := execute(t, cmd, "general", ".karma")
+	if text != "No one has karma yet." {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	text = execute(t, cmd, "general", "gopher++ bug--")
+	if text != "gopher: 1\nbug: -1" {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	execute(t, cmd, "general", "gopher++ sarah++")
+	execute(t, cmd, "random", "bug++")
+
+	text = execute(t, cmd, "general", ".karma")
+	if text != "1. gopher: 2\n2. sarah: 1\n3. bug: -1" {
+		t.Errorf("Unexpected leaderboard is returned: %s.", text)
+	}
+
+	text = execute(t, cmd, "general", ".karma Gopher")
+	if text != "gopher: 2" {
+		t.Errorf("Unexpected score is returned: %s.", text)
+	}
+
+	// Scores are kept per channel.
+	text = execute(t, cmd, "random", ".karma")
+	if text != "1. bug: 1" {
+		t.Errorf("Unexpected leaderboard is returned: %s.", text)
+	}
+}
+
+func TestCommand_Execute_LeaderboardSize(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < leaderboardSize+5; i++ {
+		_, _ = store.Add("general", string(rune('a'+i)), i)
+	}
+	cmd := NewCommand(store)
+
+	text := execute(t, cmd, "general", ".karma")
+	lines := len(strings.Split(text, "\n"))
+	if lines != leaderboardSize {
+		t.Errorf("Unexpected number of entries are shown: %d.", lines)
+	}
+}
+
+func TestCommand_Execute_StoreError(t *testing.T) {
+	expectedErr := errors.New("unavailable")
+	cmd := NewCommand(&DummyStore{
+		AddFunc: func(_ string, _ string, _ int) (int, error) {
+			return 0, expectedErr
+		},
+		GetFunc: func(_ string, _ string) (int, error) {
+			return 0, expectedErr
+		},
+		ScoresFunc: func(_ string) (map[string]int, error) {
+			return nil, expectedErr
+		},
+	})
+
+	for _, message := range []string{"gopher++", ".karma gopher", ".karma"} {
+		_, err := cmd.Execute(context.TODO(), &DummyInput{MessageValue: message, ReplyToValue: "general"})
+		if !errors.Is(err, expectedErr) {
+			t.Errorf("Expected error is not returned for %s: %#v.", message, err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package karma
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// Store defines an interface of a key-value storage that persists the scores.
+// The scores are grouped by scope so each channel has its own leaderboard.
+// Implement this with a database such as Redis to share the scores among multiple processes.
+type Store interface {
+	// Add adds the given delta to the score of the given key in the given scope and returns the new score.
+	Add(scope string, key string, delta int) (int, error)
+
+	// Get returns the score of the given key in the given scope. Zero is returned for an unknown key.
+	Get(scope string, key string) (int, error)
+
+	// Scores returns all scores in the given scope.
+	Scores(scope string) (map[string]int, error)
+}
+
+// memoryStore is a Store that keeps the scores in memory.
+type memoryStore struct {
+	scores map[string]map[string]int
+	mutex  sync.RWMutex
+}
+
+var _ Store = (*memoryStore)(nil)
+
+// NewMemoryStore creates and returns a Store that keeps the scores in memory.
+// The scores are lost when the process stops.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		scores: map[string]map[string]int{},
+	}
+}
+
+func (s *memoryStore) Add(scope string, key string, delta int) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return add(s.scores, scope, key, delta), nil
+}
+
+func (s *memoryStore) Get(scope string, key string) (int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.scores[scope][key], nil
+}
+
+func (s *memoryStore) Scores(scope string) (map[string]int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return copyScores(s.scores[scope]), nil
+}
+
+// kvStore is a Store that stores each score as a value in sarah.Store.
+type kvStore struct {
+	store sarah.Store
+	mutex sync.Mutex
+}
+
+var _ Store = (*kvStore)(nil)
+
+// NewStore creates and returns a Store that stores each score as a value in the given sarah.Store with "{scope}/{key}" as the key.
+// Give the sarah.Store namespaced with sarah.NewNamespacedStore so the keys do not conflict with other plugins'.
+// Add reads and writes the score in two steps, so the sarah.Store must not be shared with another process that also adds the scores.
+func NewStore(store sarah.Store) Store {
+	return &kvStore{
+		store: store,
+	}
+}
+
+// scopePrefix returns the key prefix for the given scope.
+// The scope is escaped so a scope containing the separator does not list the scores of another scope.
+func scopePrefix(scope string) string {
+	return url.PathEscape(scope) + "/"
+}
+
+func (s *kvStore) Add(scope string, key string, delta int) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	score, err := s.get(scopePrefix(scope) + key)
+	if err != nil {
+		return 0, err
+	}
+
+	score += delta
+	err = s.store.Set(context.Background(), scopePrefix(scope)+key, []byte(strconv.Itoa(score)))
+	if err != nil {
+		return 0, err
+	}
+	return score, nil
+}
+
+func (s *kvStore) Get(scope string, key string) (int, error) {
+	return s.get(scopePrefix(scope) + key)
+}
+
+func (s *kvStore) Scores(scope string) (map[string]int, error) {
+	prefix := scopePrefix(scope)
+	keys, err := s.store.List(context.Background(), prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := map[string]int{}
+	for _, key := range keys {
+		score, err := s.get(key)
+		if err != nil {
+			return nil, err
+		}
+		scores[key[len(prefix):]] = score
+	}
+	return scores, nil
+}
+
+func (s *kvStore) get(key string) (int, error) {
+	b, err := s.store.Get(context.Background(), key)
+	if errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	score, err := strconv.Atoi(string(b))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse score of %s: %w", key, err)
+	}
+	return score, nil
+}
+
+// NewFileStore creates and returns a Store that stores each score as a file in the given directory.
+// The directory is created when it does not exist.
+// This is a shorthand for NewStore with the sarah.Store sarah.NewFileStore returns.
+func NewFileStore(dir string) (Store, error) {
+	store, err := sarah.NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(store), nil
+}
+
+func add(scores map[string]map[string]int, scope string, key string, delta int) int {
+	if _, ok := scores[scope]; !ok {
+		scores[scope] = map[string]int{}
+	}
+	scores[scope][key] += delta
+	return scores[scope][key]
+}
+
+func copyScores(scores map[string]int) map[string]int {
+	copied := map[string]int{}
+	for key, score := range scores {
+		copied[key] = score
+	}
+	return copied
+}
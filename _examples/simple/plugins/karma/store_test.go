@@ -0,0 +1,113 @@
+package karma
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, store Store) {
+	score, err := store.Add("general", "gopher", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if score != 1 {
+		t.Errorf("Unexpected score is returned: %d.", score)
+	}
+
+	score, _ = store.Add("general", "gopher", 2)
+	if score != 3 {
+		t.Errorf("Unexpected score is returned: %d.", score)
+	}
+	_, _ = store.Add("random", "gopher", -1)
+
+	score, err = store.Get("general", "gopher")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if score != 3 {
+		t.Errorf("Unexpected score is returned: %d.", score)
+	}
+
+	score, _ = store.Get("general", "unknown")
+	if score != 0 {
+		t.Errorf("Zero must be returned for unknown key: %d.", score)
+	}
+
+	scores, err := store.Scores("random")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(scores) != 1 || scores["gopher"] != -1 {
+		t.Errorf("Unexpected scores are returned: %#v.", scores)
+	}
+
+	// Modifying the returned map must not affect the store.
+	scores["gopher"] = 100
+	score, _ = store.Get("random", "gopher")
+	if score != -1 {
+		t.Errorf("Stored score is modified: %d.", score)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestStore(t *testing.T) {
+	testStore(t, NewStore(sarah.NewMemoryStore()))
+}
+
+func TestStore_ScopeWithSeparator(t *testing.T) {
+	store := NewStore(sarah.NewMemoryStore())
+	_, _ = store.Add("a", "gopher", 1)
+	_, _ = store.Add("a/b", "gopher", 2)
+
+	scores, err := store.Scores("a")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(scores) != 1 || scores["gopher"] != 1 {
+		t.Errorf("Scores of another scope are returned: %#v.", scores)
+	}
+}
+
+func TestStore_Get_BrokenValue(t *testing.T) {
+	store := sarah.NewMemoryStore()
+	_ = store.Set(context.TODO(), "general/gopher", []byte("NaN"))
+
+	_, err := NewStore(store).Get("general", "gopher")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "karma")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	testStore(t, store)
+
+	// The scores are persisted to the directory.
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	score, _ := reopened.Get("general", "gopher")
+	if score != 3 {
+		t.Errorf("Stored score is not loaded: %d.", score)
+	}
+}
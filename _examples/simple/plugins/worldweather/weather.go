@@ -58,7 +58,7 @@ var SlackProps = sarah.NewCommandPropsBuilder().
 
 // CommandConfig contains some configuration variables for weather command.
 type CommandConfig struct {
-	APIKey string `yaml:"api_key"`
+	APIKey sarah.Secret `yaml:"api_key"`
 }
 
 // NewCommandConfig creates and returns CommandConfig with default settings.
@@ -76,7 +76,7 @@ func SlackCommandFunc(ctx context.Context, input sarah.Input, config sarah.Comma
 
 	// Share client instance with later execution
 	conf, _ := config.(*CommandConfig)
-	client := NewClient(NewConfig(conf.APIKey))
+	client := NewClient(NewConfig(conf.APIKey.Reveal()))
 	resp, err := client.LocalWeather(ctx, strippedMessage)
 
 	// If error is returned with HTTP request level, just let it know and quit.
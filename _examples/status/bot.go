@@ -17,7 +17,11 @@ func (*nullBot) Respond(context.Context, sarah.Input) error {
 	panic("implement me")
 }
 
-func (*nullBot) SendMessage(context.Context, sarah.Output) {
+func (*nullBot) SendMessage(context.Context, sarah.Output) (*sarah.SendResult, error) {
+	panic("implement me")
+}
+
+func (*nullBot) SendMessages(context.Context, []sarah.Output) ([]*sarah.SendResult, error) {
 	panic("implement me")
 }
 
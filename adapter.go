@@ -20,5 +20,20 @@ type Adapter interface {
 	// SendMessage sends message to corresponding service provider.
 	// This can be called by scheduled task or in response to input from service provider.
 	// Be advised: this method may be called simultaneously from multiple workers.
-	SendMessage(context.Context, Output)
+	//
+	// Return an error when the message is not delivered so the Bot publishes SendFailed and the Outbox retries the delivery.
+	// Return ErrUnsupportedContent, or an error that wraps it, when the content is not supported.
+	// On success, return SendResult with the message's identifier if the service provider tells one.
+	SendMessage(context.Context, Output) (*SendResult, error)
+}
+
+// BatchSender is an optional interface that an Adapter may satisfy to send multiple messages at once.
+// When an Adapter satisfies this, Bot.SendMessages of the Bot created by NewBot passes all messages to SendMessages
+// so the results of a ScheduledTask do not cost one round-trip per message.
+// Implement this only when the service provider offers such a bulk API or the messages can be sent over one connection.
+type BatchSender interface {
+	// SendMessages sends the given messages and returns their results in the same order.
+	// Return an error when any of the messages is not delivered. The results of the delivered messages can still be returned
+	// along with the error, leaving nil for the undelivered ones.
+	SendMessages(context.Context, []Output) ([]*SendResult, error)
 }
@@ -5,7 +5,7 @@ import "context"
 type DummyAdapter struct {
 	BotTypeValue    BotType
 	RunFunc         func(context.Context, func(Input) error, func(error))
-	SendMessageFunc func(context.Context, Output)
+	SendMessageFunc func(context.Context, Output) (*SendResult, error)
 }
 
 func (adapter *DummyAdapter) BotType() BotType {
@@ -16,6 +16,14 @@ func (adapter *DummyAdapter) Run(ctx context.Context, enqueueInput func(Input) e
 	adapter.RunFunc(ctx, enqueueInput, notifyErr)
 }
 
-func (adapter *DummyAdapter) SendMessage(ctx context.Context, output Output) {
-	adapter.SendMessageFunc(ctx, output)
+func (adapter *DummyAdapter) SendMessage(ctx context.Context, output Output) (*SendResult, error) {
+	return adapter.SendMessageFunc(ctx, output)
+}
+
+type DummyBatchSender struct {
+	SendMessagesFunc func(context.Context, []Output) ([]*SendResult, error)
+}
+
+func (sender *DummyBatchSender) SendMessages(ctx context.Context, outputs []Output) ([]*SendResult, error) {
+	return sender.SendMessagesFunc(ctx, outputs)
 }
@@ -0,0 +1,510 @@
+package adapterkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/breaker"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/retry"
+	"github.com/oklahomer/go-sarah/v4/tracing"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPStatusError tells the response status is worth retrying.
+// This is given to retry.Policy's Retryable and OnRetry so the caller can tell which response triggered the retrial.
+type HTTPStatusError struct {
+	StatusCode int
+
+	// RetryAfter is the duration specified by the Retry-After header. Zero when the header is absent.
+	RetryAfter time.Duration
+}
+
+// Error returns the stringified form of the error.
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("response status %d %s is returned", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// HTTPExchange describes a single round trip that HTTPClient made.
+// When a request is retried, each trial is described by its own HTTPExchange.
+type HTTPExchange struct {
+	Request  *http.Request
+	Response *http.Response // Nil on a transport error.
+	Err      error
+	Attempt  uint
+	Elapsed  time.Duration
+}
+
+// HTTPStats is the snapshot of HTTPClient's counters. Each value is accumulated since the client's construction.
+type HTTPStats struct {
+	// Requests is the number of the round trips including the retrials.
+	Requests uint64
+
+	// Retries is the number of the retrials.
+	Retries uint64
+
+	// RateLimited is the number of the responses with 429 status.
+	RateLimited uint64
+
+	// Failures is the number of the transport errors and the responses with 5xx status.
+	Failures uint64
+}
+
+// HTTPClientOption defines function signature that HTTPClient's functional option must satisfy.
+type HTTPClientOption func(*HTTPClient)
+
+// WithBaseClient creates an HTTPClientOption that sets the *http.Client to send each request.
+// Without this option, http.DefaultClient at the time of sending is used.
+func WithBaseClient(client *http.Client) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.base = client
+	}
+}
+
+// WithTimeout creates an HTTPClientOption that sets the time limit for each trial including the time to read the response body.
+func WithTimeout(timeout time.Duration) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy creates an HTTPClientOption that sets the policy to retry a failed request.
+// A response with 429 status is retried regardless of the method since the server did not process the request.
+// A transport error and a response with 500, 502, 503 or 504 status are retried only for an idempotent method
+// so a message is not posted twice. Set retry.Policy's Retryable to further restrict the retrial.
+// When the response has Retry-After header, the longer one of the header value and the policy's interval is used.
+func WithRetryPolicy(policy *retry.Policy) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithRateLimit creates an HTTPClientOption that limits the number of requests to the given count per the given duration.
+// Requests exceeding the limit wait for their turn instead of failing.
+func WithRateLimit(count int, per time.Duration) HTTPClientOption {
+	return func(c *HTTPClient) {
+		if count <= 0 {
+			c.minInterval = 0
+			return
+		}
+		c.minInterval = per / time.Duration(count)
+	}
+}
+
+// WithCircuitBreaker creates an HTTPClientOption that protects each trial with the given breaker.Breaker.
+// A transport error and a response with 5xx status are counted as failures.
+// While the circuit is open, the request fails immediately with breaker.ErrOpen.
+func WithCircuitBreaker(b *breaker.Breaker) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.breaker = b
+	}
+}
+
+// WithRequestHook creates an HTTPClientOption that adds a function to be called right before each trial.
+// This is handy to log outgoing requests or to add headers.
+func WithRequestHook(fnc func(*http.Request)) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.requestHooks = append(c.requestHooks, fnc)
+	}
+}
+
+// WithResponseHook creates an HTTPClientOption that adds a function to be called right after each trial.
+// This is handy to log responses or to feed a metrics system. Do not read or close the response body in this function.
+func WithResponseHook(fnc func(*HTTPExchange)) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.responseHooks = append(c.responseHooks, fnc)
+	}
+}
+
+// WithSpanName creates an HTTPClientOption that sets the name of the tracing span that covers each request.
+func WithSpanName(name string) HTTPClientOption {
+	return func(c *HTTPClient) {
+		c.spanName = name
+	}
+}
+
+// HTTPClient sends HTTP requests to a chat service's REST API with retrials, rate limiting and instrumentation
+// so each Adapter does not have to reinvent them:
+//
+//  client := adapterkit.NewHTTPClient(
+//    adapterkit.WithTimeout(10*time.Second),
+//    adapterkit.WithRateLimit(1, time.Second),
+//  )
+//  resp, err := client.Do(req)
+//
+// Use NewHTTPClient to construct one. The instance is safe for concurrent use.
+type HTTPClient struct {
+	base          *http.Client
+	timeout       time.Duration
+	retryPolicy   *retry.Policy
+	minInterval   time.Duration
+	breaker       *breaker.Breaker
+	requestHooks  []func(*http.Request)
+	responseHooks []func(*HTTPExchange)
+	spanName      string
+
+	// mutex guards next and pausedUntil.
+	mutex sync.Mutex
+	// next is the earliest time the next request is allowed to be sent under the rate limit.
+	next time.Time
+	// pausedUntil is set when the server responds with 429 and Retry-After so other requests also wait.
+	pausedUntil time.Time
+
+	requests    uint64
+	retries     uint64
+	rateLimited uint64
+	failures    uint64
+}
+
+// NewHTTPClient creates a new HTTPClient with zero or more HTTPClientOption.
+// By default, a failed request is tried up to three times with exponential backoff.
+func NewHTTPClient(options ...HTTPClientOption) *HTTPClient {
+	client := &HTTPClient{
+		retryPolicy: &retry.Policy{
+			Trial:       3,
+			Interval:    500 * time.Millisecond,
+			Multiplier:  2,
+			MaxInterval: 10 * time.Second,
+			Jitter:      retry.JitterFull,
+		},
+		spanName: "adapterkit.http",
+	}
+
+	for _, opt := range options {
+		opt(client)
+	}
+
+	return client
+}
+
+// Stats returns the snapshot of the counters.
+func (c *HTTPClient) Stats() *HTTPStats {
+	return &HTTPStats{
+		Requests:    atomic.LoadUint64(&c.requests),
+		Retries:     atomic.LoadUint64(&c.retries),
+		RateLimited: atomic.LoadUint64(&c.rateLimited),
+		Failures:    atomic.LoadUint64(&c.failures),
+	}
+}
+
+// Do sends the given request and returns the response just like http.Client.Do does.
+// A failed request is retried with the retry policy, so the request body is buffered in memory when the request has no GetBody.
+// When all trials fail with retryable responses, the last response is returned without an error so the caller can inspect it.
+func (c *HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Start(req.Context(), c.spanName, tracing.A("http.method", req.Method), tracing.A("http.url", redactedURL(req)))
+	defer span.End()
+
+	err := bufferBody(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(tracing.A("http.status_code", resp.StatusCode))
+	return resp, nil
+}
+
+func (c *HTTPClient) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	policy := c.retryPolicy
+	if policy == nil {
+		policy = &retry.Policy{Trial: 1}
+	}
+
+	started := time.Now()
+	for attempt := uint(1); ; attempt++ {
+		err := c.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.send(ctx, req, attempt)
+		retryErr := c.retryable(ctx, req, resp, err)
+		if retryErr == nil || attempt >= policy.Trial {
+			return resp, err
+		}
+
+		interval := policy.NextInterval(attempt)
+		var statusErr *HTTPStatusError
+		if errors.As(retryErr, &statusErr) && statusErr.RetryAfter > interval {
+			interval = statusErr.RetryAfter
+		}
+		if policy.MaxElapsedTime > 0 && time.Since(started)+interval > policy.MaxElapsedTime {
+			return resp, err
+		}
+		if c.breaker != nil && c.breaker.State() == breaker.StateOpen {
+			// The next trial fails immediately with breaker.ErrOpen anyway.
+			return resp, err
+		}
+
+		if resp != nil {
+			// Read the remaining body so the underlying connection can be reused.
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, retryErr, interval)
+		}
+		logging.FromContext(ctx).Warn("Retrying HTTP request", logging.F("url", redactedURL(req)), logging.F("attempt", attempt), logging.F("next", interval), logging.Err(retryErr))
+		atomic.AddUint64(&c.retries, 1)
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+
+		case <-timer.C:
+			// Proceed to the next trial.
+
+		}
+	}
+}
+
+// errServerFailure is recorded by the circuit breaker for a response with 5xx status.
+var errServerFailure = errors.New("server failure")
+
+func (c *HTTPClient) send(ctx context.Context, req *http.Request, attempt uint) (*http.Response, error) {
+	r := req.WithContext(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		r.Body = body
+	}
+	tracing.InjectHTTP(ctx, r)
+	for _, hook := range c.requestHooks {
+		hook(r)
+	}
+
+	started := time.Now()
+	var resp *http.Response
+	var err error
+	if c.breaker == nil {
+		resp, err = c.client().Do(r)
+	} else {
+		err = c.breaker.Execute(func() error {
+			var e error
+			resp, e = c.client().Do(r)
+			if e != nil {
+				return e
+			}
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return errServerFailure
+			}
+			return nil
+		})
+		if err == errServerFailure {
+			// Let the caller inspect the response.
+			err = nil
+		}
+	}
+	elapsed := time.Since(started)
+
+	atomic.AddUint64(&c.requests, 1)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		atomic.AddUint64(&c.failures, 1)
+	}
+
+	log := logging.FromContext(ctx)
+	if log.Enabled(logging.LevelDebug) {
+		fields := []logging.Field{logging.F("method", r.Method), logging.F("url", redactedURL(r)), logging.F("attempt", attempt), logging.F("elapsed", elapsed)}
+		if err != nil {
+			fields = append(fields, logging.Err(err))
+		} else {
+			fields = append(fields, logging.F("status", resp.StatusCode))
+		}
+		log.Debug("HTTP request is sent", fields...)
+	}
+
+	exchange := &HTTPExchange{
+		Request:  r,
+		Response: resp,
+		Err:      err,
+		Attempt:  attempt,
+		Elapsed:  elapsed,
+	}
+	for _, hook := range c.responseHooks {
+		hook(exchange)
+	}
+
+	return resp, err
+}
+
+func (c *HTTPClient) client() *http.Client {
+	base := c.base
+	if base == nil {
+		base = http.DefaultClient
+	}
+	if c.timeout <= 0 {
+		return base
+	}
+
+	client := *base
+	client.Timeout = c.timeout
+	return &client
+}
+
+// retryable returns a non-nil error when the trial is worth retrying.
+func (c *HTTPClient) retryable(ctx context.Context, req *http.Request, resp *http.Response, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	var retryErr error
+	if err != nil {
+		if errors.Is(err, breaker.ErrOpen) || !idempotent(req.Method) {
+			return nil
+		}
+		retryErr = err
+
+	} else if resp.StatusCode == http.StatusTooManyRequests {
+		atomic.AddUint64(&c.rateLimited, 1)
+		after := retryAfter(resp, time.Now())
+		c.pause(after)
+		retryErr = &HTTPStatusError{StatusCode: resp.StatusCode, RetryAfter: after}
+
+	} else if retryableStatus(resp.StatusCode) && idempotent(req.Method) {
+		retryErr = &HTTPStatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp, time.Now())}
+
+	} else {
+		return nil
+
+	}
+
+	if c.retryPolicy != nil && c.retryPolicy.Retryable != nil && !c.retryPolicy.Retryable(retryErr) {
+		return nil
+	}
+	return retryErr
+}
+
+// wait blocks til the request is allowed to be sent under the rate limit and the server's Retry-After.
+func (c *HTTPClient) wait(ctx context.Context) error {
+	c.mutex.Lock()
+	now := time.Now()
+	sendAt := now
+	if c.minInterval > 0 {
+		if c.next.After(sendAt) {
+			sendAt = c.next
+		}
+		c.next = sendAt.Add(c.minInterval)
+	}
+	if c.pausedUntil.After(sendAt) {
+		sendAt = c.pausedUntil
+	}
+	c.mutex.Unlock()
+
+	if !sendAt.After(now) {
+		return nil
+	}
+
+	timer := time.NewTimer(sendAt.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-timer.C:
+		return nil
+
+	}
+}
+
+// pause holds off all requests for the given duration.
+func (c *HTTPClient) pause(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	until := time.Now().Add(d)
+	if until.After(c.pausedUntil) {
+		c.pausedUntil = until
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+
+	default:
+		return false
+
+	}
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+
+	default:
+		return false
+
+	}
+}
+
+// retryAfter parses Retry-After header in either delay-seconds or HTTP-date form.
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
+
+// bufferBody reads the request body into memory and sets GetBody so the body can be sent again on a retrial.
+func bufferBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	return nil
+}
+
+// redactedURL returns the URL without the query string that may contain an API key.
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.User = nil
+	return u.String()
+}
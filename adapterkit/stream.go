@@ -0,0 +1,449 @@
+/*
+Package adapterkit provides building blocks for sarah.Adapter implementations.
+
+Most chat services deliver messages over a long-lived streaming connection such as a chunked HTTP response or a WebSocket.
+Receiving messages from such a connection requires the same chores regardless of the chat service:
+reconnecting with backoff when the connection drops, skipping keep-alive and malformed payloads,
+detecting a wedged connection that neither delivers data nor reports an error, and stopping on the context cancellation.
+Stream takes care of these so an Adapter author only needs to tell how to connect and how to decode a payload:
+
+	stream := adapterkit.NewStream(
+		func(ctx context.Context) (adapterkit.Connection, error) {
+			return client.Connect(ctx)
+		},
+		adapterkit.WithDecoder(func(payload interface{}) (sarah.Input, error) {
+			return toInput(payload.([]byte))
+		}),
+		adapterkit.WithHeartbeatTimeout(3*time.Minute),
+	)
+	stream.Run(ctx, enqueueInput) // Blocks til the context is canceled.
+
+Calling a chat service's REST API also involves common chores: timeouts, retrials on rate limiting or server errors,
+and logging and metrics of each request. HTTPClient covers them.
+*/
+package adapterkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/retry"
+	"time"
+)
+
+// Connection represents a streaming connection to a chat service.
+type Connection interface {
+	// Receive blocks til a payload arrives and returns the payload.
+	// Return ErrKeepAlive for a keep-alive payload and *MalformedPayloadError for a payload that can not be parsed
+	// so the payload is skipped without reconnecting. Any other error is treated as a broken connection.
+	Receive() (interface{}, error)
+
+	// Close closes the connection. A blocking Receive must return an error.
+	Close() error
+}
+
+// HeartbeatReceiver defines an optional interface that a Connection may satisfy to tell when the last data was received.
+// The data includes keep-alive payloads. Stream closes and reconnects the connection when nothing is received within the heartbeat timeout.
+type HeartbeatReceiver interface {
+	LastReceived() time.Time
+}
+
+// ConnectFunc establishes a new Connection.
+type ConnectFunc func(ctx context.Context) (Connection, error)
+
+// DecodeFunc converts a payload returned by Connection.Receive to sarah.Input.
+// Return ErrKeepAlive or *MalformedPayloadError to skip the payload.
+type DecodeFunc func(payload interface{}) (sarah.Input, error)
+
+// BackfillFunc fetches the messages posted after the given Input while the connection was down, in the posted order.
+// The given Input is the last one received before the disconnection.
+// Return the Inputs that satisfy sarah.CatchUpInput so a Command can tell them from the ones received in real time.
+type BackfillFunc func(ctx context.Context, last sarah.Input) ([]sarah.Input, error)
+
+// ErrKeepAlive tells the received payload is a keep-alive signal and is not passed to go-sarah's core.
+var ErrKeepAlive = errors.New("keep-alive payload is received")
+
+// ErrHeartbeatTimeout is returned when no data, including keep-alive payloads, is received within the heartbeat timeout.
+var ErrHeartbeatTimeout = errors.New("no data is received within the heartbeat timeout")
+
+// MalformedPayloadError tells the received payload can not be parsed.
+// Stream logs and skips such a payload instead of reconnecting.
+type MalformedPayloadError struct {
+	Err error
+}
+
+// Error returns the stringified form of the error.
+func (e *MalformedPayloadError) Error() string {
+	return fmt.Sprintf("malformed payload: %s", e.Err.Error())
+}
+
+// Unwrap returns the cause.
+func (e *MalformedPayloadError) Unwrap() error {
+	return e.Err
+}
+
+// OutageError is given to the function registered with WithOutageNotification
+// when the connection can not be established for the threshold or longer.
+// Stream keeps reconnecting after this is given.
+type OutageError struct {
+	Since    time.Time
+	Failures uint
+	Err      error
+}
+
+// Error returns the stringified form of the error.
+func (e *OutageError) Error() string {
+	return fmt.Sprintf("unreachable since %s after %d attempts: %s", e.Since.Format(time.RFC3339), e.Failures, e.Err.Error())
+}
+
+// Unwrap returns the last connection error.
+func (e *OutageError) Unwrap() error {
+	return e.Err
+}
+
+// StreamOption defines function signature that Stream's functional option must satisfy.
+type StreamOption func(*Stream)
+
+// WithDecoder creates a StreamOption that sets the function to convert each payload to sarah.Input.
+// Without this option, the payload itself must be sarah.Input.
+func WithDecoder(decode DecodeFunc) StreamOption {
+	return func(s *Stream) {
+		s.decode = decode
+	}
+}
+
+// WithReconnectPolicy creates a StreamOption that sets the interval to reconnect.
+// The reconnection is retried til the context is canceled, so only Interval, Multiplier, MaxInterval and Jitter are used.
+func WithReconnectPolicy(policy *retry.Policy) StreamOption {
+	return func(s *Stream) {
+		s.reconnectPolicy = policy
+	}
+}
+
+// WithHeartbeatTimeout creates a StreamOption that sets the duration to wait for any data before a wedged connection is
+// closed and reconnected. This only works with a Connection that satisfies HeartbeatReceiver. Zero disables the detection.
+func WithHeartbeatTimeout(timeout time.Duration) StreamOption {
+	return func(s *Stream) {
+		s.heartbeatTimeout = timeout
+	}
+}
+
+// WithStableDuration creates a StreamOption that sets the duration for a connection to last to be considered stable.
+// When a stable connection drops, Stream reconnects with the initial interval; otherwise, Stream keeps backing off.
+func WithStableDuration(d time.Duration) StreamOption {
+	return func(s *Stream) {
+		s.stableDuration = d
+	}
+}
+
+// WithOutageNotification creates a StreamOption that sets the function to be called once
+// when the connection can not be established for the given threshold or longer.
+func WithOutageNotification(threshold time.Duration, fnc func(*OutageError)) StreamOption {
+	return func(s *Stream) {
+		s.outageThreshold = threshold
+		s.notifyOutage = fnc
+	}
+}
+
+// WithBackfill creates a StreamOption that sets the function to fetch the messages posted while the connection was down.
+// On each reconnection, Stream calls the function with the last received sarah.Input before receiving payloads from the new connection,
+// and passes the returned Inputs to go-sarah's core. Nothing is fetched when no Input has been received yet.
+// A received Input that is already passed by the backfill is skipped when the Input satisfies sarah.MessageIDInput.
+func WithBackfill(fnc BackfillFunc) StreamOption {
+	return func(s *Stream) {
+		s.backfill = fnc
+	}
+}
+
+// WithConnectionName creates a StreamOption that sets the name to identify the connection in the connection events
+// such as sarah.BotConnected. Give one when the Adapter holds multiple Streams, e.g. the ID of the chat room.
+func WithConnectionName(name string) StreamOption {
+	return func(s *Stream) {
+		s.connectionName = name
+	}
+}
+
+// Stream receives payloads from a streaming connection and passes them to go-sarah's core.
+// Stream publishes sarah.BotConnected, sarah.BotDisconnected and sarah.BotReconnecting to the EventBus carried by the context given to Run.
+// Use NewStream to construct one.
+type Stream struct {
+	connect          ConnectFunc
+	decode           DecodeFunc
+	reconnectPolicy  *retry.Policy
+	heartbeatTimeout time.Duration
+	stableDuration   time.Duration
+	outageThreshold  time.Duration
+	notifyOutage     func(*OutageError)
+	backfill         BackfillFunc
+	connectionName   string
+}
+
+// NewStream creates a new Stream with the given function to establish a connection and zero or more StreamOption.
+func NewStream(connect ConnectFunc, options ...StreamOption) *Stream {
+	stream := &Stream{
+		connect: connect,
+		decode:  passThrough,
+		reconnectPolicy: &retry.Policy{
+			Interval:    500 * time.Millisecond,
+			Multiplier:  2,
+			MaxInterval: 1 * time.Minute,
+			Jitter:      retry.JitterFull,
+		},
+		stableDuration: 1 * time.Minute,
+	}
+
+	for _, opt := range options {
+		opt(stream)
+	}
+
+	return stream
+}
+
+func passThrough(payload interface{}) (sarah.Input, error) {
+	input, ok := payload.(sarah.Input)
+	if !ok {
+		return nil, &MalformedPayloadError{Err: fmt.Errorf("payload is not sarah.Input: %T", payload)}
+	}
+	return input, nil
+}
+
+// Run connects and receives payloads til the given context is canceled.
+// Each decoded sarah.Input is passed to enqueueInput. When the connection drops, Stream reconnects with the reconnect policy.
+// Logs are written with the Logger carried by the context, so set the Adapter's logger with logging.NewContext beforehand.
+func (s *Stream) Run(ctx context.Context, enqueueInput func(sarah.Input) error) {
+	log := logging.FromContext(ctx)
+
+	// The last received Input and the identifiers of the backfilled messages to skip on the current connection.
+	var last sarah.Input
+	var backfilled map[string]struct{}
+	receive := func(input sarah.Input) error {
+		if id := messageID(input); id != "" {
+			if _, ok := backfilled[id]; ok {
+				return nil
+			}
+		}
+		last = input
+		return enqueueInput(input)
+	}
+
+	// The number of consecutive failures, which determines the interval before the next connection.
+	var failures uint
+	var outageSince time.Time
+	outageReported := false
+	// The time when the last connection was lost, which is zero til the initial connection is lost.
+	var lostAt time.Time
+	// The number of connection attempts since the connection was lost.
+	var attempts uint
+	for {
+		if failures > 0 {
+			interval := s.reconnectPolicy.NextInterval(failures)
+			log.Warn("Reconnecting", logging.F("attempt", failures), logging.F("next", interval))
+
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+
+			case <-timer.C:
+				// Proceed to reconnect.
+
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !lostAt.IsZero() {
+			attempts++
+			sarah.PublishEvent(ctx, &sarah.BotReconnecting{
+				BotType:    sarah.BotTypeFromContext(ctx),
+				Connection: s.connectionName,
+				Attempt:    attempts,
+				Time:       time.Now(),
+			})
+		}
+
+		log.Info("Connecting")
+		conn, err := s.connect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warn("Could not connect", logging.Err(err))
+
+			failures++
+			if outageSince.IsZero() {
+				outageSince = time.Now()
+			}
+			if !outageReported && s.notifyOutage != nil && s.outageThreshold > 0 && time.Since(outageSince) >= s.outageThreshold {
+				outageReported = true
+				s.notifyOutage(&OutageError{
+					Since:    outageSince,
+					Failures: failures,
+					Err:      err,
+				})
+			}
+			continue
+		}
+
+		if outageReported {
+			log.Info("Reachable again", logging.F("outage", time.Since(outageSince)))
+		}
+		outageSince = time.Time{}
+		outageReported = false
+
+		connected := &sarah.BotConnected{
+			BotType:    sarah.BotTypeFromContext(ctx),
+			Connection: s.connectionName,
+			Time:       time.Now(),
+		}
+		if !lostAt.IsZero() {
+			connected.Outage = connected.Time.Sub(lostAt)
+		}
+		sarah.PublishEvent(ctx, connected)
+		lostAt = time.Time{}
+		attempts = 0
+
+		if s.backfill != nil && last != nil {
+			backfilled = map[string]struct{}{}
+			inputs, err := s.backfill(ctx, last)
+			if err != nil {
+				log.Warn("Failed to backfill missed messages", logging.Err(err))
+			}
+			if len(inputs) > 0 {
+				log.Info("Backfilling missed messages", logging.F("count", len(inputs)))
+			}
+			for _, input := range inputs {
+				if id := messageID(input); id != "" {
+					backfilled[id] = struct{}{}
+				}
+				last = input
+				_ = enqueueInput(input)
+			}
+		}
+
+		connectedAt := time.Now()
+		stopWatching := WatchHeartbeat(conn, s.heartbeatTimeout)
+		connErr := Receive(log, conn, s.decode, receive)
+		if stopWatching() {
+			connErr = fmt.Errorf("%w: %s", ErrHeartbeatTimeout, connErr.Error())
+		}
+		_ = conn.Close()
+
+		disconnected := &sarah.BotDisconnected{
+			BotType:    sarah.BotTypeFromContext(ctx),
+			Connection: s.connectionName,
+			Time:       time.Now(),
+		}
+		if DisconnectedIntentionally(ctx, connErr) {
+			log.Info("Disconnected", logging.F("reason", connErr))
+			sarah.PublishEvent(ctx, disconnected)
+			return
+		}
+		log.Error("Disconnected", logging.Err(connErr))
+		disconnected.Err = connErr
+		sarah.PublishEvent(ctx, disconnected)
+		lostAt = disconnected.Time
+
+		// Reconnect with the initial interval when the connection was stable,
+		// or keep backing off when the connection drops right after being established.
+		if time.Since(connectedAt) >= s.stableDuration {
+			failures = 0
+		}
+		failures++
+	}
+}
+
+func messageID(input sarah.Input) string {
+	if identified, ok := input.(sarah.MessageIDInput); ok {
+		return identified.MessageID()
+	}
+	return ""
+}
+
+// Receive receives payloads from the given connection til the connection returns an error.
+// Keep-alive and malformed payloads are skipped. This always returns a non-nil error that tells why the reception stopped.
+// An Adapter with its own reconnection logic may use this instead of Stream.
+func Receive(log logging.Logger, conn Connection, decode DecodeFunc, enqueueInput func(sarah.Input) error) error {
+	log.Info("Start receiving message")
+	for {
+		payload, err := conn.Receive()
+		if err == nil {
+			var input sarah.Input
+			input, err = decode(payload)
+			if err == nil {
+				_ = enqueueInput(input)
+				continue
+			}
+		}
+
+		var malformedErr *MalformedPayloadError
+		if errors.Is(err, ErrKeepAlive) {
+			continue
+
+		} else if errors.As(err, &malformedErr) {
+			log.Warn("Skipping malformed input", logging.Err(err))
+			continue
+
+		}
+
+		// At this point, assume connection is unstable or is closed.
+		// Let caller proceed to reconnect or quit.
+		return fmt.Errorf("failed to receive input: %w", err)
+	}
+}
+
+// WatchHeartbeat closes the given connection when no data is received within the given timeout so the blocking Receive returns.
+// The returned function stops watching and tells if the connection is closed due to the timeout.
+// Nothing is watched when the timeout is zero or when the connection does not satisfy HeartbeatReceiver.
+func WatchHeartbeat(conn Connection, timeout time.Duration) func() bool {
+	receiver, ok := conn.(HeartbeatReceiver)
+	if !ok || timeout <= 0 {
+		return func() bool { return false }
+	}
+
+	stop := make(chan struct{})
+	timedOut := make(chan bool, 1)
+	go func() {
+		interval := timeout / 4
+		if interval <= 0 {
+			interval = timeout
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				timedOut <- false
+				return
+
+			case <-ticker.C:
+				if time.Since(receiver.LastReceived()) >= timeout {
+					// The connection is wedged. Close the connection to let the blocking Receive return.
+					_ = conn.Close()
+					timedOut <- true
+					return
+				}
+
+			}
+		}
+	}()
+
+	return func() bool {
+		close(stop)
+		return <-timedOut
+	}
+}
+
+// DisconnectedIntentionally tells if the connection is closed due to the context cancellation rather than a connection error.
+func DisconnectedIntentionally(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
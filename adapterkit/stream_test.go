@@ -0,0 +1,579 @@
+package adapterkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/retry"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	MessageValue string
+}
+
+func (i *DummyInput) SenderKey() string {
+	return "sender"
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "destination"
+}
+
+type DummyMessageIDInput struct {
+	DummyInput
+	MessageIDValue string
+}
+
+func (i *DummyMessageIDInput) MessageID() string {
+	return i.MessageIDValue
+}
+
+type DummyConnection struct {
+	ReceiveFunc func() (interface{}, error)
+	CloseFunc   func() error
+}
+
+func (c *DummyConnection) Receive() (interface{}, error) {
+	return c.ReceiveFunc()
+}
+
+func (c *DummyConnection) Close() error {
+	if c.CloseFunc == nil {
+		return nil
+	}
+	return c.CloseFunc()
+}
+
+type DummyHeartbeatConnection struct {
+	DummyConnection
+	LastReceivedFunc func() time.Time
+}
+
+func (c *DummyHeartbeatConnection) LastReceived() time.Time {
+	return c.LastReceivedFunc()
+}
+
+// blockingConnection returns a Connection that blocks on Receive til closed.
+func blockingConnection() Connection {
+	closed := make(chan struct{})
+	var once sync.Once
+	return &DummyConnection{
+		ReceiveFunc: func() (interface{}, error) {
+			<-closed
+			return nil, errors.New("connection is closed")
+		},
+		CloseFunc: func() error {
+			once.Do(func() {
+				close(closed)
+			})
+			return nil
+		},
+	}
+}
+
+func TestMalformedPayloadError(t *testing.T) {
+	cause := errors.New("unexpected EOF")
+	err := &MalformedPayloadError{Err: cause}
+
+	if !strings.Contains(err.Error(), cause.Error()) {
+		t.Errorf("Cause is not included: %s.", err.Error())
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("Cause is not wrapped.")
+	}
+}
+
+func TestOutageError(t *testing.T) {
+	cause := errors.New("connection error")
+	err := &OutageError{
+		Since:    time.Now(),
+		Failures: 3,
+		Err:      cause,
+	}
+
+	if !strings.Contains(err.Error(), "3 attempts") {
+		t.Errorf("Failures are not included: %s.", err.Error())
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("Cause is not wrapped.")
+	}
+}
+
+func TestNewStream(t *testing.T) {
+	policy := &retry.Policy{Interval: 1 * time.Second}
+	decode := func(_ interface{}) (sarah.Input, error) { return nil, nil }
+	notify := func(_ *OutageError) {}
+
+	stream := NewStream(
+		func(_ context.Context) (Connection, error) { return nil, nil },
+		WithDecoder(decode),
+		WithReconnectPolicy(policy),
+		WithHeartbeatTimeout(3*time.Minute),
+		WithStableDuration(10*time.Second),
+		WithOutageNotification(5*time.Minute, notify),
+		WithConnectionName("room"),
+	)
+
+	if stream.decode == nil {
+		t.Error("Decoder is not set.")
+	}
+
+	if stream.reconnectPolicy != policy {
+		t.Errorf("Expected policy is not set: %#v.", stream.reconnectPolicy)
+	}
+
+	if stream.heartbeatTimeout != 3*time.Minute {
+		t.Errorf("Expected heartbeat timeout is not set: %s.", stream.heartbeatTimeout)
+	}
+
+	if stream.stableDuration != 10*time.Second {
+		t.Errorf("Expected stable duration is not set: %s.", stream.stableDuration)
+	}
+
+	if stream.outageThreshold != 5*time.Minute || stream.notifyOutage == nil {
+		t.Error("Outage notification is not set.")
+	}
+
+	if stream.connectionName != "room" {
+		t.Errorf("Expected connection name is not set: %s.", stream.connectionName)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	type value struct {
+		payload interface{}
+		err     error
+	}
+	values := []value{
+		{payload: &DummyInput{MessageValue: "hello"}},
+		{err: ErrKeepAlive},
+		{err: &MalformedPayloadError{Err: errors.New("invalid")}},
+		{payload: "undecodable"},
+		{err: errors.New("random error")},
+	}
+
+	conn := &DummyConnection{
+		ReceiveFunc: func() (interface{}, error) {
+			var v value
+			v, values = values[0], values[1:]
+			return v.payload, v.err
+		},
+	}
+	enqueueCnt := 0
+	enqueuer := func(_ sarah.Input) error {
+		enqueueCnt++
+		return nil
+	}
+	err := Receive(logging.GetLogger(), conn, passThrough, enqueuer)
+
+	if enqueueCnt != 1 {
+		t.Errorf("Enqueued %d times. Should enqueue only if no error is returned.", enqueueCnt)
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "random error") {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestReceive_Decoder(t *testing.T) {
+	payloads := []interface{}{"hello", "", "bye"}
+	conn := &DummyConnection{
+		ReceiveFunc: func() (interface{}, error) {
+			if len(payloads) == 0 {
+				return nil, errors.New("closed")
+			}
+			var p interface{}
+			p, payloads = payloads[0], payloads[1:]
+			return p, nil
+		},
+	}
+	decode := func(payload interface{}) (sarah.Input, error) {
+		if payload.(string) == "" {
+			return nil, ErrKeepAlive
+		}
+		return &DummyInput{MessageValue: payload.(string)}, nil
+	}
+
+	var messages []string
+	_ = Receive(logging.GetLogger(), conn, decode, func(input sarah.Input) error {
+		messages = append(messages, input.Message())
+		return nil
+	})
+
+	if len(messages) != 2 || messages[0] != "hello" || messages[1] != "bye" {
+		t.Errorf("Unexpected messages are enqueued: %#v.", messages)
+	}
+}
+
+func TestWatchHeartbeat(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		closed := make(chan struct{})
+		conn := &DummyHeartbeatConnection{
+			DummyConnection: DummyConnection{
+				CloseFunc: func() error {
+					close(closed)
+					return nil
+				},
+			},
+			LastReceivedFunc: func() time.Time {
+				return time.Now().Add(-1 * time.Hour)
+			},
+		}
+
+		stop := WatchHeartbeat(conn, 10*time.Millisecond)
+
+		select {
+		case <-closed:
+			// O.K.
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("Wedged connection is not closed.")
+
+		}
+
+		if !stop() {
+			t.Error("Timeout is not reported.")
+		}
+	})
+
+	t.Run("alive", func(t *testing.T) {
+		conn := &DummyHeartbeatConnection{
+			DummyConnection: DummyConnection{
+				CloseFunc: func() error {
+					t.Error("Live connection must not be closed.")
+					return nil
+				},
+			},
+			LastReceivedFunc: time.Now,
+		}
+
+		stop := WatchHeartbeat(conn, 10*time.Millisecond)
+		time.Sleep(30 * time.Millisecond)
+
+		if stop() {
+			t.Error("Timeout is reported for live connection.")
+		}
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		stop := WatchHeartbeat(&DummyConnection{}, 10*time.Millisecond)
+
+		if stop() {
+			t.Error("Timeout is reported for the connection without heartbeat support.")
+		}
+	})
+}
+
+func TestDisconnectedIntentionally(t *testing.T) {
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		ctx         context.Context
+		err         error
+		intentional bool
+	}{
+		{
+			ctx:         context.Background(),
+			err:         errors.New("connection reset"),
+			intentional: false,
+		},
+		{
+			ctx:         canceledCtx,
+			err:         errors.New("net/http: request canceled"),
+			intentional: true,
+		},
+		{
+			ctx:         context.Background(),
+			err:         fmt.Errorf("failed to receive input: %w", context.Canceled),
+			intentional: true,
+		},
+		{
+			ctx:         context.Background(),
+			err:         fmt.Errorf("failed to receive input: %w", context.DeadlineExceeded),
+			intentional: true,
+		},
+	}
+
+	for i, tt := range tests {
+		if DisconnectedIntentionally(tt.ctx, tt.err) != tt.intentional {
+			t.Errorf("Unexpected result is returned on test #%d.", i)
+		}
+	}
+}
+
+func TestStream_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connections := make(chan Connection, 2)
+	stream := NewStream(
+		func(_ context.Context) (Connection, error) {
+			conn := blockingConnection()
+			connections <- conn
+			return conn, nil
+		},
+	)
+
+	finished := make(chan struct{})
+	go func() {
+		stream.Run(ctx, func(_ sarah.Input) error { return nil })
+		close(finished)
+	}()
+
+	conn := <-connections
+	cancel()
+	// Let the blocking Receive return as a real connection does on the context cancellation.
+	_ = conn.Close()
+
+	select {
+	case <-finished:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Run does not return on context cancellation.")
+
+	}
+
+	if len(connections) != 0 {
+		t.Errorf("Connection must not be re-established on context cancellation: %d.", len(connections))
+	}
+}
+
+func TestStream_Run_Reconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var intervals []time.Time
+	var notified *OutageError
+	stream := NewStream(
+		func(_ context.Context) (Connection, error) {
+			intervals = append(intervals, time.Now())
+			if len(intervals) == 4 {
+				// Keep retrying til the context is canceled.
+				cancel()
+			}
+			return nil, errors.New("connection error")
+		},
+		WithReconnectPolicy(&retry.Policy{
+			Interval:   10 * time.Millisecond,
+			Multiplier: 2,
+		}),
+		WithOutageNotification(1*time.Millisecond, func(err *OutageError) {
+			notified = err
+		}),
+	)
+
+	stream.Run(ctx, func(_ sarah.Input) error { return nil })
+
+	if len(intervals) != 4 {
+		t.Fatalf("Unexpected number of connection attempts: %d.", len(intervals))
+	}
+
+	// The interval grows exponentially: 10ms, 20ms, 40ms.
+	if elapsed := intervals[3].Sub(intervals[2]); elapsed < 40*time.Millisecond {
+		t.Errorf("Interval is not backed off: %s.", elapsed)
+	}
+
+	if notified == nil || notified.Failures == 0 {
+		t.Errorf("Expected error is not notified: %#v.", notified)
+	}
+}
+
+func TestStream_Run_HeartbeatTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connected := 0
+	stream := NewStream(
+		func(_ context.Context) (Connection, error) {
+			connected++
+			if connected > 1 {
+				// Reconnected after the wedged connection is closed.
+				cancel()
+				return nil, ctx.Err()
+			}
+
+			conn := blockingConnection()
+			return &DummyHeartbeatConnection{
+				DummyConnection: *conn.(*DummyConnection),
+				LastReceivedFunc: func() time.Time {
+					return time.Now().Add(-1 * time.Hour)
+				},
+			}, nil
+		},
+		WithReconnectPolicy(&retry.Policy{Interval: 1 * time.Millisecond}),
+		WithHeartbeatTimeout(10*time.Millisecond),
+	)
+
+	finished := make(chan struct{})
+	go func() {
+		stream.Run(ctx, func(_ sarah.Input) error { return nil })
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Run did not finish.")
+
+	}
+
+	if connected != 2 {
+		t.Errorf("Expected to reconnect once, but connected %d times.", connected)
+	}
+}
+
+func TestStream_Run_Backfill(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newInput := func(id string) sarah.Input {
+		return &DummyMessageIDInput{MessageIDValue: id}
+	}
+	// Each connection delivers the given inputs and then drops.
+	payloads := [][]sarah.Input{
+		{newInput("1")},
+		{newInput("3"), newInput("4")},
+	}
+	connected := 0
+	var lastGiven []sarah.Input
+	stream := NewStream(
+		func(_ context.Context) (Connection, error) {
+			connected++
+			if connected > len(payloads) {
+				cancel()
+				return nil, ctx.Err()
+			}
+
+			inputs := payloads[connected-1]
+			return &DummyConnection{
+				ReceiveFunc: func() (interface{}, error) {
+					if len(inputs) == 0 {
+						return nil, errors.New("connection is closed")
+					}
+					input := inputs[0]
+					inputs = inputs[1:]
+					return input, nil
+				},
+			}, nil
+		},
+		WithReconnectPolicy(&retry.Policy{Interval: 1 * time.Millisecond}),
+		WithBackfill(func(_ context.Context, last sarah.Input) ([]sarah.Input, error) {
+			lastGiven = append(lastGiven, last)
+			return []sarah.Input{newInput("2"), newInput("3")}, nil
+		}),
+	)
+
+	var ids []string
+	stream.Run(ctx, func(input sarah.Input) error {
+		ids = append(ids, input.(*DummyMessageIDInput).MessageID())
+		return nil
+	})
+
+	// No backfill on the first connection.
+	if len(lastGiven) != 1 {
+		t.Fatalf("Unexpected number of backfills: %d.", len(lastGiven))
+	}
+	if lastGiven[0].(*DummyMessageIDInput).MessageID() != "1" {
+		t.Errorf("Unexpected last input is given: %#v.", lastGiven[0])
+	}
+
+	// "3" is received in real time after being backfilled, so the duplicate is skipped.
+	if strings.Join(ids, ",") != "1,2,3,4" {
+		t.Errorf("Unexpected inputs are enqueued: %s.", strings.Join(ids, ","))
+	}
+}
+
+func TestStream_Run_ConnectionEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events []sarah.Event
+	bus := sarah.NewEventBus()
+	bus.Subscribe(func(e sarah.Event) {
+		events = append(events, e)
+	})
+	ctx = sarah.NewEventBusContext(sarah.WithBotType(ctx, "dummy"), bus)
+
+	// The first and the third connections drop right away, and the second one fails.
+	connected := 0
+	stream := NewStream(
+		func(_ context.Context) (Connection, error) {
+			connected++
+			switch connected {
+			case 2:
+				return nil, errors.New("connection error")
+
+			case 4:
+				cancel()
+				return nil, ctx.Err()
+
+			default:
+				return &DummyConnection{
+					ReceiveFunc: func() (interface{}, error) {
+						return nil, errors.New("connection is closed")
+					},
+				}, nil
+
+			}
+		},
+		WithReconnectPolicy(&retry.Policy{Interval: 1 * time.Millisecond}),
+		WithConnectionName("room"),
+	)
+
+	stream.Run(ctx, func(_ sarah.Input) error { return nil })
+
+	var kinds []string
+	for _, e := range events {
+		switch ev := e.(type) {
+		case *sarah.BotConnected:
+			if ev.BotType != "dummy" || ev.Connection != "room" {
+				t.Errorf("Unexpected event is published: %#v.", ev)
+			}
+			if len(kinds) == 0 && ev.Outage != 0 {
+				t.Errorf("Outage must be zero on the initial connection: %s.", ev.Outage)
+			}
+			if len(kinds) > 0 && ev.Outage == 0 {
+				t.Error("Outage is not set on reconnection.")
+			}
+			kinds = append(kinds, "connected")
+
+		case *sarah.BotDisconnected:
+			if ev.Err == nil {
+				t.Error("Cause of the disconnection is not set.")
+			}
+			kinds = append(kinds, "disconnected")
+
+		case *sarah.BotReconnecting:
+			kinds = append(kinds, fmt.Sprintf("reconnecting:%d", ev.Attempt))
+
+		default:
+			t.Errorf("Unexpected event is published: %#v.", e)
+
+		}
+	}
+
+	expected := "connected,disconnected,reconnecting:1,reconnecting:2,connected,disconnected,reconnecting:1"
+	if strings.Join(kinds, ",") != expected {
+		t.Errorf("Unexpected events are published: %s.", strings.Join(kinds, ","))
+	}
+}
@@ -0,0 +1,487 @@
+/*
+Package admin provides an optional sarah.Command that lets administrators inspect and control the running bots from a chat.
+
+The command responds to below inputs:
+
+	.admin bots                          the registered bots and their running states
+	.admin commands                      the registered commands and their enabled states
+	.admin enable <bot type> <command>   enables the command disabled by .admin disable
+	.admin disable <bot type> <command>  disables the command til it is enabled again
+	.admin tasks                         the scheduled tasks and their next run times
+	.admin run <bot type> <task>         runs the scheduled task right away
+	.admin reload                        reloads the configurations of the commands and the scheduled tasks
+	.admin configs command|task <bot type> <id>
+	                                     the kept configuration generations; see sarah.RegisterConfigHistory
+	.admin rollback command|task <bot type> <id> <generation>
+	                                     rebuilds the command or the scheduled task with the configuration generation
+	.admin maintenance                   the bots in maintenance mode
+	.admin maintenance on <bot type>|all [queue] [pause]
+	                                     puts the bot into maintenance mode; see sarah.MaintenanceConfig for the options
+	.admin maintenance off <bot type>|all
+	                                     ends the maintenance mode and handles the queued inputs
+	.admin pause <bot type>              stops handling the inputs without closing the connection; see sarah.PauseBot
+	.admin resume <bot type>             resumes the bot paused by .admin pause and handles the kept inputs
+	.admin deadletters                   the inputs the bots failed to respond to; see sarah.RegisterDeadLetterQueue
+	.admin replay <id>                   handles the input of the dead letter again
+	.admin discard <id>                  forgets the dead letter without handling it
+	.admin version                       the version and the build information of the process
+
+Because the command changes the bot's behavior, only the senders listed in Config.AdminKeys or allowed by WithAuthorizer
+can use the command. For other senders, the command does not match and is not listed in the help.
+
+	config := admin.NewConfig()
+	config.AdminKeys = []string{"C12345|U12345"} // Input.SenderKey() of the administrator
+	sarah.RegisterScopedCommand(sarah.AllBots(), admin.NewCommand(config))
+
+The version sub-command shows ops.VersionInfo, so set ops.Version at build time to tell which release is running.
+*/
+package admin
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/ops"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Identifier is the identifier of the Command this package provides.
+const Identifier = "admin"
+
+var matchPattern = regexp.MustCompile(`^\.admin(\s|$)`)
+
+// These are variables so tests can replace them.
+var (
+	currentStatus      = sarah.CurrentStatus
+	listCommands       = sarah.ListCommands
+	enableCommand      = sarah.EnableCommand
+	disableCommand     = sarah.DisableCommand
+	listScheduledTasks = sarah.ListScheduledTasks
+	runScheduledTask   = sarah.RunScheduledTask
+	reloadConfigs      = sarah.ReloadConfigs
+	commandConfigs     = sarah.CommandConfigHistory
+	taskConfigs        = sarah.ScheduledTaskConfigHistory
+	rollbackCommand    = sarah.RollbackCommandConfig
+	rollbackTask       = sarah.RollbackScheduledTaskConfig
+	listMaintenances   = sarah.ListMaintenances
+	startMaintenance   = sarah.StartMaintenance
+	endMaintenance     = sarah.EndMaintenance
+	pauseBot           = sarah.PauseBot
+	resumeBot          = sarah.ResumeBot
+	listDeadLetters    = sarah.ListDeadLetters
+	replayDeadLetter   = sarah.ReplayDeadLetter
+	discardDeadLetter  = sarah.DiscardDeadLetter
+)
+
+// Config contains some configuration variables for the admin command.
+type Config struct {
+	// AdminKeys is the list of Input.SenderKey() values that are allowed to use the command.
+	AdminKeys []string `json:"admin_keys" yaml:"admin_keys"`
+
+	// TimeFormat is the layout to show the scheduled tasks' next run times.
+	TimeFormat string `json:"time_format" yaml:"time_format"`
+
+	// MaintenanceMessage is the reply to the inputs during maintenance. See sarah.MaintenanceConfig.
+	MaintenanceMessage string `json:"maintenance_message" yaml:"maintenance_message"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+// When the Config's AdminKeys is left empty, no one can use the command unless WithAuthorizer is given.
+func NewConfig() *Config {
+	return &Config{
+		AdminKeys:          []string{},
+		TimeFormat:         time.RFC3339,
+		MaintenanceMessage: sarah.NewMaintenanceConfig().Message,
+	}
+}
+
+// CommandOption defines a function signature that NewCommand's functional option must satisfy.
+type CommandOption func(*command)
+
+// WithAuthorizer creates a CommandOption that sets a sarah.Authorizer to judge if the sender of the given Input is an administrator.
+// This takes precedence over Config.AdminKeys.
+func WithAuthorizer(authorizer sarah.Authorizer) CommandOption {
+	return func(c *command) {
+		c.authorize = authorizer
+	}
+}
+
+type command struct {
+	config    *Config
+	authorize sarah.Authorizer
+}
+
+var _ sarah.Command = (*command)(nil)
+
+// NewCommand creates and returns a new sarah.Command that provides the administrative features.
+func NewCommand(config *Config, options ...CommandOption) sarah.Command {
+	c := &command{
+		config: config,
+	}
+	c.authorize = sarah.SenderKeyAuthorizer(config.AdminKeys)
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// Identifier returns the command ID.
+func (c *command) Identifier() string {
+	return Identifier
+}
+
+// Instruction provides the input instruction only for the administrators.
+func (c *command) Instruction(input *sarah.HelpInput) string {
+	if input.OriginalInput == nil || !c.authorize(input.OriginalInput) {
+		return ""
+	}
+	return ".admin bots|commands|enable <bot type> <command>|disable <bot type> <command>|tasks|run <bot type> <task>|reload|configs command|task <bot type> <id>|rollback command|task <bot type> <id> <generation>|maintenance [on|off <bot type>|all]|pause <bot type>|resume <bot type>|deadletters|replay <id>|discard <id>|version"
+}
+
+// Match checks if the input is an admin command sent by an administrator.
+func (c *command) Match(input sarah.Input) bool {
+	return matchPattern.Copy().MatchString(input.Message()) && c.authorize(input)
+}
+
+// Execute runs the given sub-command and returns its result.
+func (c *command) Execute(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	args := strings.Fields(sarah.StripMessage(matchPattern, input.Message()))
+	if len(args) == 0 {
+		return respond("Usage: " + c.Instruction(&sarah.HelpInput{OriginalInput: input})), nil
+	}
+
+	switch args[0] {
+	case "bots":
+		return respond(bots()), nil
+
+	case "commands":
+		return respond(commands()), nil
+
+	case "enable", "disable":
+		if len(args) != 3 {
+			return respond(fmt.Sprintf("Usage: .admin %s <bot type> <command>", args[0])), nil
+		}
+		return respond(switchCommand(args[0] == "enable", sarah.BotType(args[1]), args[2])), nil
+
+	case "tasks":
+		return respond(c.tasks()), nil
+
+	case "run":
+		if len(args) != 3 {
+			return respond("Usage: .admin run <bot type> <task>"), nil
+		}
+		return respond(runTask(sarah.BotType(args[1]), args[2])), nil
+
+	case "reload":
+		return respond(reload()), nil
+
+	case "configs":
+		if len(args) != 4 || (args[1] != "command" && args[1] != "task") {
+			return respond("Usage: .admin configs command|task <bot type> <id>"), nil
+		}
+		return respond(c.configs(args[1], sarah.BotType(args[2]), args[3])), nil
+
+	case "rollback":
+		generation, err := strconv.Atoi(args[len(args)-1])
+		if len(args) != 5 || (args[1] != "command" && args[1] != "task") || err != nil {
+			return respond("Usage: .admin rollback command|task <bot type> <id> <generation>"), nil
+		}
+		return respond(rollback(args[1], sarah.BotType(args[2]), args[3], generation)), nil
+
+	case "maintenance":
+		return respond(c.maintenance(args[1:])), nil
+
+	case "pause", "resume":
+		if len(args) != 2 {
+			return respond(fmt.Sprintf("Usage: .admin %s <bot type>", args[0])), nil
+		}
+		return respond(switchBot(args[0] == "pause", sarah.BotType(args[1]))), nil
+
+	case "deadletters":
+		return respond(c.deadLetters()), nil
+
+	case "replay", "discard":
+		if len(args) != 2 {
+			return respond(fmt.Sprintf("Usage: .admin %s <id>", args[0])), nil
+		}
+		return respond(handleDeadLetter(args[0] == "replay", args[1])), nil
+
+	case "version":
+		return respond(ops.VersionInfo()), nil
+
+	default:
+		return respond(fmt.Sprintf("Unknown sub-command: %s", args[0])), nil
+
+	}
+}
+
+func respond(text string) *sarah.CommandResponse {
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}
+}
+
+func bots() string {
+	status := currentStatus()
+	if len(status.Bots) == 0 {
+		return "No bot is registered."
+	}
+
+	var lines []string
+	for _, bot := range status.Bots {
+		state := "running"
+		if !bot.Running {
+			state = "stopped"
+		} else if bot.Paused {
+			state = "paused"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", bot.Type, state))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func commands() string {
+	infos := listCommands()
+	if len(infos) == 0 {
+		return "No command is registered."
+	}
+
+	var lines []string
+	for _, info := range infos {
+		state := "enabled"
+		if !info.Enabled {
+			state = "disabled"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s", info.BotType, info.Identifier, state))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func switchCommand(enable bool, botType sarah.BotType, id string) string {
+	if enable {
+		err := enableCommand(botType, id)
+		if err != nil {
+			return fmt.Sprintf("Failed to enable %s: %s", id, err.Error())
+		}
+		return fmt.Sprintf("%s is enabled for %s.", id, botType)
+	}
+
+	if id == Identifier {
+		// Disabling this command would leave no way to enable it again.
+		return fmt.Sprintf("%s can not be disabled.", Identifier)
+	}
+
+	err := disableCommand(botType, id)
+	if err != nil {
+		return fmt.Sprintf("Failed to disable %s: %s", id, err.Error())
+	}
+	return fmt.Sprintf("%s is disabled for %s.", id, botType)
+}
+
+func switchBot(pause bool, botType sarah.BotType) string {
+	if pause {
+		err := pauseBot(botType)
+		if err != nil {
+			return fmt.Sprintf("Failed to pause %s: %s", botType, err.Error())
+		}
+		return fmt.Sprintf("%s is paused.", botType)
+	}
+
+	err := resumeBot(botType)
+	if err != nil {
+		return fmt.Sprintf("Failed to resume %s: %s", botType, err.Error())
+	}
+	return fmt.Sprintf("%s is resumed.", botType)
+}
+
+func (c *command) tasks() string {
+	infos := listScheduledTasks()
+	if len(infos) == 0 {
+		return "No task is scheduled."
+	}
+
+	var lines []string
+	for _, info := range infos {
+		next := "unknown"
+		if !info.Next.IsZero() {
+			next = info.Next.Format(c.config.TimeFormat)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s (%s): next run at %s", info.BotType, info.Identifier, info.Schedule, next))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func runTask(botType sarah.BotType, id string) string {
+	err := runScheduledTask(botType, id)
+	if err != nil {
+		return fmt.Sprintf("Failed to run %s: %s", id, err.Error())
+	}
+	return fmt.Sprintf("%s is started for %s.", id, botType)
+}
+
+func reload() string {
+	err := reloadConfigs()
+	if err != nil {
+		return err.Error()
+	}
+	return "Configurations are reloaded."
+}
+
+func (c *command) configs(kind string, botType sarah.BotType, id string) string {
+	history := commandConfigs
+	if kind == "task" {
+		history = taskConfigs
+	}
+	generations, err := history(botType, id)
+	if err != nil {
+		return fmt.Sprintf("Failed to list configurations of %s: %s", id, err.Error())
+	}
+
+	var lines []string
+	for i, generation := range generations {
+		line := fmt.Sprintf("%d: applied at %s", generation.Generation, generation.Time.Format(c.config.TimeFormat))
+		if generation.RollbackOf > 0 {
+			line += fmt.Sprintf(" (rollback to %d)", generation.RollbackOf)
+		}
+		if i == len(generations)-1 {
+			line += " current"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func rollback(kind string, botType sarah.BotType, id string, generation int) string {
+	fnc := rollbackCommand
+	if kind == "task" {
+		fnc = rollbackTask
+	}
+	err := fnc(botType, id, generation)
+	if err != nil {
+		return fmt.Sprintf("Failed to roll back %s: %s", id, err.Error())
+	}
+	return fmt.Sprintf("%s is rolled back to generation %d for %s.", id, generation, botType)
+}
+
+func (c *command) maintenance(args []string) string {
+	if len(args) == 0 {
+		return c.maintenances()
+	}
+
+	usage := "Usage: .admin maintenance on <bot type>|all [queue] [pause] or .admin maintenance off <bot type>|all"
+	if len(args) < 2 || (args[0] != "on" && args[0] != "off") {
+		return usage
+	}
+
+	var botTypes []sarah.BotType
+	if args[1] == "all" {
+		for _, bot := range currentStatus().Bots {
+			if bot.Running {
+				botTypes = append(botTypes, bot.Type)
+			}
+		}
+		if len(botTypes) == 0 {
+			return "No bot is running."
+		}
+	} else {
+		botTypes = append(botTypes, sarah.BotType(args[1]))
+	}
+
+	config := sarah.NewMaintenanceConfig()
+	config.Message = c.config.MaintenanceMessage
+	// Keep this command available so the maintenance can be ended from a chat.
+	config.ExemptCommands = []string{Identifier}
+	for _, option := range args[2:] {
+		switch option {
+		case "queue":
+			config.QueueInputs = true
+
+		case "pause":
+			config.PauseScheduledTasks = true
+
+		default:
+			return usage
+
+		}
+	}
+
+	var lines []string
+	for _, botType := range botTypes {
+		if args[0] == "on" {
+			err := startMaintenance(botType, config)
+			if err != nil {
+				lines = append(lines, fmt.Sprintf("Failed to start maintenance of %s: %s", botType, err.Error()))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s is in maintenance.", botType))
+			continue
+		}
+
+		err := endMaintenance(botType)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("Failed to end maintenance of %s: %s", botType, err.Error()))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s is back from maintenance.", botType))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *command) maintenances() string {
+	infos := listMaintenances()
+	if len(infos) == 0 {
+		return "No bot is in maintenance."
+	}
+
+	var lines []string
+	for _, info := range infos {
+		var options []string
+		if info.Config.QueueInputs {
+			options = append(options, fmt.Sprintf("%d inputs queued", info.Queued))
+		}
+		if info.Config.PauseScheduledTasks {
+			options = append(options, "tasks paused")
+		}
+		line := fmt.Sprintf("%s: in maintenance since %s", info.BotType, info.Since.Format(c.config.TimeFormat))
+		if len(options) > 0 {
+			line += fmt.Sprintf(" (%s)", strings.Join(options, ", "))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *command) deadLetters() string {
+	letters := listDeadLetters()
+	if len(letters) == 0 {
+		return "No dead letter is kept."
+	}
+
+	var lines []string
+	for _, letter := range letters {
+		lines = append(lines, fmt.Sprintf("%s %s at %s from %s: %s", letter.ID, letter.BotType, letter.Time.Format(c.config.TimeFormat), letter.Input.SenderKey(), letter.Err.Error()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func handleDeadLetter(replay bool, id string) string {
+	if replay {
+		err := replayDeadLetter(id)
+		if err != nil {
+			return fmt.Sprintf("Failed to replay %s: %s", id, err.Error())
+		}
+		return fmt.Sprintf("%s is replayed.", id)
+	}
+
+	err := discardDeadLetter(id)
+	if err != nil {
+		return fmt.Sprintf("Failed to discard %s: %s", id, err.Error())
+	}
+	return fmt.Sprintf("%s is discarded.", id)
+}
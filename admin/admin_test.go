@@ -0,0 +1,387 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+}
+
+var _ sarah.Input = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "dummy"
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.TimeFormat != time.RFC3339 {
+		t.Errorf("Unexpected default time format is set: %s.", config.TimeFormat)
+	}
+
+	if len(config.AdminKeys) != 0 {
+		t.Errorf("No admin must be set by default: %#v.", config.AdminKeys)
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	cmd := NewCommand(NewConfig(), WithAuthorizer(func(_ sarah.Input) bool {
+		return true
+	}))
+
+	typed, ok := cmd.(*command)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", cmd)
+	}
+
+	if !typed.authorize(&DummyInput{}) {
+		t.Error("Given authorizer is not set.")
+	}
+
+	if cmd.Identifier() != Identifier {
+		t.Errorf("Unexpected identifier is returned: %s.", cmd.Identifier())
+	}
+}
+
+func TestCommand_Match(t *testing.T) {
+	config := NewConfig()
+	config.AdminKeys = []string{"admin"}
+	cmd := NewCommand(config)
+
+	tests := []struct {
+		input   *DummyInput
+		matched bool
+	}{
+		{
+			input:   &DummyInput{SenderKeyValue: "admin", MessageValue: ".admin bots"},
+			matched: true,
+		},
+		{
+			input:   &DummyInput{SenderKeyValue: "admin", MessageValue: ".admin"},
+			matched: true,
+		},
+		{
+			input:   &DummyInput{SenderKeyValue: "admin", MessageValue: ".administrator"},
+			matched: false,
+		},
+		{
+			input:   &DummyInput{SenderKeyValue: "someone", MessageValue: ".admin bots"},
+			matched: false,
+		},
+	}
+
+	for i, tt := range tests {
+		if cmd.Match(tt.input) != tt.matched {
+			t.Errorf("Unexpected result is returned on test #%d.", i)
+		}
+	}
+}
+
+func TestCommand_Instruction(t *testing.T) {
+	config := NewConfig()
+	config.AdminKeys = []string{"admin"}
+	cmd := NewCommand(config)
+
+	if cmd.Instruction(&sarah.HelpInput{OriginalInput: &DummyInput{SenderKeyValue: "admin"}}) == "" {
+		t.Error("Instruction must be returned for an administrator.")
+	}
+
+	if cmd.Instruction(&sarah.HelpInput{OriginalInput: &DummyInput{SenderKeyValue: "someone"}}) != "" {
+		t.Error("Instruction must not be returned for a non-administrator.")
+	}
+}
+
+func TestCommand_Execute(t *testing.T) {
+	var called []string
+	currentStatus = func() sarah.Status {
+		return sarah.Status{
+			Running: true,
+			Bots: []sarah.BotStatus{
+				{Type: "slack", Running: true},
+				{Type: "gitter", Running: false},
+			},
+		}
+	}
+	listCommands = func() []*sarah.CommandInfo {
+		return []*sarah.CommandInfo{
+			{BotType: "slack", Identifier: "echo", Enabled: true},
+			{BotType: "slack", Identifier: "weather", Enabled: false},
+		}
+	}
+	enableCommand = func(botType sarah.BotType, id string) error {
+		called = append(called, "enable:"+botType.String()+":"+id)
+		return nil
+	}
+	disableCommand = func(botType sarah.BotType, id string) error {
+		called = append(called, "disable:"+botType.String()+":"+id)
+		if id == "unknown" {
+			return sarah.ErrCommandNotFound
+		}
+		return nil
+	}
+	listScheduledTasks = func() []*sarah.ScheduledTaskInfo {
+		return []*sarah.ScheduledTaskInfo{
+			{BotType: "slack", Identifier: "report", Schedule: "@daily", Next: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+			{BotType: "slack", Identifier: "pending", Schedule: "@hourly"},
+		}
+	}
+	runScheduledTask = func(botType sarah.BotType, id string) error {
+		called = append(called, "run:"+botType.String()+":"+id)
+		return nil
+	}
+	reloadConfigs = func() error {
+		called = append(called, "reload")
+		return errors.New("failed to reload configurations: slack command:echo: broken")
+	}
+	commandConfigs = func(_ sarah.BotType, _ string) ([]*sarah.ConfigGeneration, error) {
+		return []*sarah.ConfigGeneration{
+			{Generation: 1, Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Generation: 2, RollbackOf: 1, Time: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		}, nil
+	}
+	taskConfigs = func(_ sarah.BotType, _ string) ([]*sarah.ConfigGeneration, error) {
+		return nil, sarah.ErrScheduledTaskNotFound
+	}
+	rollbackCommand = func(botType sarah.BotType, id string, generation int) error {
+		called = append(called, fmt.Sprintf("rollback:%s:%s:%d", botType, id, generation))
+		return nil
+	}
+	rollbackTask = func(_ sarah.BotType, _ string, _ int) error {
+		return sarah.ErrConfigGenerationNotFound
+	}
+	listMaintenances = func() []*sarah.MaintenanceInfo {
+		return []*sarah.MaintenanceInfo{
+			{BotType: "slack", Config: &sarah.MaintenanceConfig{QueueInputs: true, PauseScheduledTasks: true}, Since: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Queued: 3},
+		}
+	}
+	startMaintenance = func(botType sarah.BotType, config *sarah.MaintenanceConfig) error {
+		called = append(called, fmt.Sprintf("start:%s:%t:%t:%s", botType, config.QueueInputs, config.PauseScheduledTasks, strings.Join(config.ExemptCommands, "|")))
+		return nil
+	}
+	endMaintenance = func(botType sarah.BotType) error {
+		called = append(called, "end:"+botType.String())
+		if botType == "unknown" {
+			return sarah.ErrBotNotRunning
+		}
+		return nil
+	}
+	pauseBot = func(botType sarah.BotType) error {
+		called = append(called, "pause:"+botType.String())
+		return nil
+	}
+	resumeBot = func(botType sarah.BotType) error {
+		called = append(called, "resume:"+botType.String())
+		return sarah.ErrBotNotRunning
+	}
+	listDeadLetters = func() []*sarah.DeadLetter {
+		return []*sarah.DeadLetter{
+			{ID: "abc", BotType: "slack", Input: &DummyInput{SenderKeyValue: "C123|U123"}, Err: errors.New("broken"), Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}
+	}
+	replayDeadLetter = func(id string) error {
+		called = append(called, "replay:"+id)
+		return nil
+	}
+	discardDeadLetter = func(id string) error {
+		called = append(called, "discard:"+id)
+		return sarah.ErrDeadLetterNotFound
+	}
+	defer func() {
+		currentStatus = sarah.CurrentStatus
+		listCommands = sarah.ListCommands
+		enableCommand = sarah.EnableCommand
+		disableCommand = sarah.DisableCommand
+		listScheduledTasks = sarah.ListScheduledTasks
+		runScheduledTask = sarah.RunScheduledTask
+		reloadConfigs = sarah.ReloadConfigs
+		commandConfigs = sarah.CommandConfigHistory
+		taskConfigs = sarah.ScheduledTaskConfigHistory
+		rollbackCommand = sarah.RollbackCommandConfig
+		rollbackTask = sarah.RollbackScheduledTaskConfig
+		listMaintenances = sarah.ListMaintenances
+		startMaintenance = sarah.StartMaintenance
+		endMaintenance = sarah.EndMaintenance
+		pauseBot = sarah.PauseBot
+		resumeBot = sarah.ResumeBot
+		listDeadLetters = sarah.ListDeadLetters
+		replayDeadLetter = sarah.ReplayDeadLetter
+		discardDeadLetter = sarah.DiscardDeadLetter
+	}()
+
+	cmd := NewCommand(NewConfig(), WithAuthorizer(func(_ sarah.Input) bool {
+		return true
+	}))
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{
+			message:  ".admin",
+			expected: "Usage: .admin bots|",
+		},
+		{
+			message:  ".admin bots",
+			expected: "slack: running\ngitter: stopped",
+		},
+		{
+			message:  ".admin commands",
+			expected: "slack echo: enabled\nslack weather: disabled",
+		},
+		{
+			message:  ".admin enable slack weather",
+			expected: "weather is enabled for slack.",
+		},
+		{
+			message:  ".admin disable slack echo",
+			expected: "echo is disabled for slack.",
+		},
+		{
+			message:  ".admin disable slack unknown",
+			expected: "Failed to disable unknown: command is not found",
+		},
+		{
+			message:  ".admin disable slack admin",
+			expected: "admin can not be disabled.",
+		},
+		{
+			message:  ".admin disable slack",
+			expected: "Usage: .admin disable <bot type> <command>",
+		},
+		{
+			message:  ".admin tasks",
+			expected: "slack report (@daily): next run at 2020-01-02T00:00:00Z\nslack pending (@hourly): next run at unknown",
+		},
+		{
+			message:  ".admin run slack report",
+			expected: "report is started for slack.",
+		},
+		{
+			message:  ".admin reload",
+			expected: "failed to reload configurations: slack command:echo: broken",
+		},
+		{
+			message:  ".admin configs command slack echo",
+			expected: "1: applied at 2020-01-01T00:00:00Z\n2: applied at 2020-01-02T00:00:00Z (rollback to 1) current",
+		},
+		{
+			message:  ".admin configs task slack report",
+			expected: "Failed to list configurations of report: scheduled task is not found",
+		},
+		{
+			message:  ".admin configs foo slack echo",
+			expected: "Usage: .admin configs command|task <bot type> <id>",
+		},
+		{
+			message:  ".admin rollback command slack echo 1",
+			expected: "echo is rolled back to generation 1 for slack.",
+		},
+		{
+			message:  ".admin rollback task slack report 1",
+			expected: "Failed to roll back report: config generation is not found",
+		},
+		{
+			message:  ".admin rollback command slack echo latest",
+			expected: "Usage: .admin rollback command|task <bot type> <id> <generation>",
+		},
+		{
+			message:  ".admin maintenance",
+			expected: "slack: in maintenance since 2020-01-01T00:00:00Z (3 inputs queued, tasks paused)",
+		},
+		{
+			message:  ".admin maintenance on slack queue pause",
+			expected: "slack is in maintenance.",
+		},
+		{
+			message:  ".admin maintenance on all",
+			expected: "slack is in maintenance.",
+		},
+		{
+			message:  ".admin maintenance off unknown",
+			expected: "Failed to end maintenance of unknown: bot is not running",
+		},
+		{
+			message:  ".admin maintenance on slack foo",
+			expected: "Usage: .admin maintenance on",
+		},
+		{
+			message:  ".admin pause slack",
+			expected: "slack is paused.",
+		},
+		{
+			message:  ".admin resume gitter",
+			expected: "Failed to resume gitter: bot is not running",
+		},
+		{
+			message:  ".admin pause",
+			expected: "Usage: .admin pause <bot type>",
+		},
+		{
+			message:  ".admin deadletters",
+			expected: "abc slack at 2020-01-01T00:00:00Z from C123|U123: broken",
+		},
+		{
+			message:  ".admin replay abc",
+			expected: "abc is replayed.",
+		},
+		{
+			message:  ".admin discard xyz",
+			expected: "Failed to discard xyz: dead letter is not found",
+		},
+		{
+			message:  ".admin replay",
+			expected: "Usage: .admin replay <id>",
+		},
+		{
+			message:  ".admin version",
+			expected: "Version: ",
+		},
+		{
+			message:  ".admin foo",
+			expected: "Unknown sub-command: foo",
+		},
+	}
+
+	for i, tt := range tests {
+		res, err := cmd.Execute(context.TODO(), &DummyInput{MessageValue: tt.message})
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+
+		text, ok := res.Content.(string)
+		if !ok {
+			t.Errorf("Unexpected content is returned on test #%d: %#v.", i, res.Content)
+			continue
+		}
+
+		if !strings.HasPrefix(text, tt.expected) {
+			t.Errorf("Unexpected text is returned on test #%d: %s.", i, text)
+		}
+	}
+
+	expected := "enable:slack:weather,disable:slack:echo,disable:slack:unknown,run:slack:report,reload,rollback:slack:echo:1," +
+		"start:slack:true:true:admin,start:slack:false:false:admin,end:unknown,pause:slack,resume:gitter,replay:abc,discard:xyz"
+	if strings.Join(called, ",") != expected {
+		t.Errorf("Unexpected calls: %s.", strings.Join(called, ","))
+	}
+}
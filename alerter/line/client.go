@@ -18,7 +18,7 @@ var Endpoint = "https://notify-api.line.me/api/notify"
 
 // Config contains some configuration variables for gitter Adapter.
 type Config struct {
-	Token          string        `json:"token" yaml:"token"`
+	Token          sarah.Secret  `json:"token" yaml:"token"`
 	RequestTimeout time.Duration `json:"timeout" yaml:"timeout"`
 }
 
@@ -70,7 +70,7 @@ func (c *Client) Alert(ctx context.Context, botType sarah.BotType, err error) er
 	if err != nil {
 		return fmt.Errorf("failed to construct HTTP request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("Authorization", "Bearer "+c.config.Token.Reveal())
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	reqCtx, cancel := context.WithTimeout(ctx, c.config.RequestTimeout)
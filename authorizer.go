@@ -0,0 +1,19 @@
+package sarah
+
+// Authorizer defines a function signature that judges if the sender of the given Input is allowed to use a Command.
+// The administrative Commands such as the ones in the admin and debug packages accept this to restrict their usage.
+type Authorizer func(Input) bool
+
+// SenderKeyAuthorizer returns an Authorizer that allows the senders whose Input.SenderKey() is listed in the given keys.
+// When no key is given, no sender is allowed.
+func SenderKeyAuthorizer(keys []string) Authorizer {
+	return func(input Input) bool {
+		senderKey := input.SenderKey()
+		for _, key := range keys {
+			if key == senderKey {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,37 @@
+package sarah
+
+import (
+	"testing"
+)
+
+func TestSenderKeyAuthorizer(t *testing.T) {
+	tests := []struct {
+		keys    []string
+		sender  string
+		allowed bool
+	}{
+		{
+			keys:    []string{"C12345|U12345", "C12345|U67890"},
+			sender:  "C12345|U67890",
+			allowed: true,
+		},
+		{
+			keys:    []string{"C12345|U12345"},
+			sender:  "C12345|U67890",
+			allowed: false,
+		},
+		{
+			keys:    nil,
+			sender:  "",
+			allowed: false,
+		},
+	}
+
+	for i, tt := range tests {
+		authorize := SenderKeyAuthorizer(tt.keys)
+		allowed := authorize(&DummyInput{SenderKeyValue: tt.sender})
+		if allowed != tt.allowed {
+			t.Errorf("Unexpected result is returned on test #%d: %t.", i, allowed)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package sarah
 
 import (
 	"context"
-	"github.com/oklahomer/go-kasumi/logger"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/tracing"
+	"time"
 )
 
 // Bot provides an interface that each bot implementation must satisfy.
@@ -19,7 +23,14 @@ type Bot interface {
 	// SendMessage sends given message to the destination depending on the Bot implementation.
 	// This is mainly used to send scheduled task's result.
 	// Be advised: this method may be called simultaneously from multiple workers.
-	SendMessage(context.Context, Output)
+	//
+	// SendResult is returned on success, and an error is returned when the message is not delivered.
+	SendMessage(context.Context, Output) (*SendResult, error)
+
+	// SendMessages sends given messages at once. This is mainly used to send the results of a scheduled task.
+	// The results are returned in the same order of the messages with nil for the undelivered ones,
+	// and an error is returned when any of the messages is not delivered.
+	SendMessages(context.Context, []Output) ([]*SendResult, error)
 
 	// AppendCommand appends given Command implementation to Bot internal stash.
 	// Stashed commands are checked against user input in Bot.Respond, and if Command.Match returns true, the
@@ -45,9 +56,21 @@ type Bot interface {
 type defaultBot struct {
 	botType            BotType
 	runFunc            func(context.Context, func(Input) error, func(error))
-	sendMessageFunc    func(context.Context, Output)
+	sendMessageFunc    func(context.Context, Output) (*SendResult, error)
+	batchSender        BatchSender
+	healthCheckFunc    func(context.Context) error
+	verifyFunc         func(context.Context) error
+	editor             MessageEditor
+	maxMessageLength   int
+	outbox             *Outbox
 	commands           *Commands
+	fallbackCommand    Command
+	intentMatcher      IntentMatcher
 	userContextStorage UserContextStorage
+	history            *History
+	errorReply         *ErrorReplyConfig
+	sendQueue          *destinationQueue
+	replyInThread      bool
 }
 
 // NewBot creates and returns new defaultBot instance with given Adapter.
@@ -58,6 +81,8 @@ type defaultBot struct {
 //     - if so, execute the next step with given Input
 //     - if not, find corresponding Command for given Input and execute it
 //   - call Adapter.SendMessage to send output
+//   - publish MessageSent or SendFailed with the result of Adapter.SendMessage
+//
 // The aim of defaultBot is to lessen the tasks of Adapter developer by providing some common tasks' implementations, and achieve easier creation of Bot implementation.
 // Hence this method returns Bot interface instead of any concrete instance so this can be ONLY treated as Bot implementation to be fed to Runner.RegisterBot.
 //
@@ -74,8 +99,40 @@ func NewBot(adapter Adapter, options ...DefaultBotOption) Bot {
 		botType:            adapter.BotType(),
 		runFunc:            adapter.Run,
 		sendMessageFunc:    adapter.SendMessage,
+		batchSender:        nil,
+		healthCheckFunc:    nil,
+		verifyFunc:         nil,
+		editor:             nil,
+		maxMessageLength:   0,
+		outbox:             nil,
 		commands:           NewCommands(),
+		fallbackCommand:    nil,
+		intentMatcher:      nil,
 		userContextStorage: nil,
+		history:            nil,
+		errorReply:         nil,
+		sendQueue:          nil,
+		replyInThread:      false,
+	}
+
+	if sender, ok := adapter.(BatchSender); ok {
+		bot.batchSender = sender
+	}
+
+	if checker, ok := adapter.(HealthChecker); ok {
+		bot.healthCheckFunc = checker.HealthCheck
+	}
+
+	if verifier, ok := adapter.(Verifier); ok {
+		bot.verifyFunc = verifier.Verify
+	}
+
+	if editor, ok := adapter.(MessageEditor); ok {
+		bot.editor = editor
+	}
+
+	if limiter, ok := adapter.(MessageLengthLimiter); ok {
+		bot.maxMessageLength = limiter.MaxMessageLength()
 	}
 
 	for _, opt := range options {
@@ -101,12 +158,123 @@ func BotWithStorage(storage UserContextStorage) DefaultBotOption {
 	}
 }
 
+// BotWithMaxMessageLength creates and returns DefaultBotOption to set the maximum number of characters a message can contain.
+// A text message longer than this is split into multiple messages with SplitMessage.
+// By default, the value is given by the Adapter when the Adapter satisfies MessageLengthLimiter.
+// Give zero to disable the splitting.
+func BotWithMaxMessageLength(length int) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.maxMessageLength = length
+	}
+}
+
+// BotWithOutbox creates and returns DefaultBotOption to send messages via the given Outbox.
+// Bot.SendMessage then queues the message and returns immediately, and the Outbox delivers the message with retries while the Bot runs.
+// Give a dedicated Outbox to each Bot.
+//
+//  outbox := sarah.NewOutbox(sarah.NewOutboxConfig(), sarah.OutboxWithDeadLetterHandler(func(ctx context.Context, output sarah.Output, err error) {
+//  	// Notify the administrator.
+//  }))
+//  bot := sarah.NewBot(myAdapter, sarah.BotWithOutbox(outbox))
+func BotWithOutbox(outbox *Outbox) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.outbox = outbox
+	}
+}
+
+// BotWithReplyInThread creates and returns DefaultBotOption to reply in a thread by default.
+// When this is given with true, a response to a message that is not in a thread starts a new thread on the message.
+// This requires the Input to satisfy MessageIDInput and the Adapter to handle ThreadDestination;
+// otherwise, the response is sent as usual.
+//
+// Regardless of this option, a response to a message in a thread is sent to the same thread when the Input satisfies ThreadInput.
+func BotWithReplyInThread(replyInThread bool) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.replyInThread = replyInThread
+	}
+}
+
+// BotWithInstanceID creates and returns DefaultBotOption to distinguish the Bot from other Bots of the same Adapter type.
+// The Bot's BotType becomes "<Adapter's BotType>/<id>" such as "slack/workspace-a", so two Slack workspaces can be served from one process:
+//
+//  botA := sarah.NewBot(adapterA, sarah.BotWithInstanceID("workspace-a"))
+//  botB := sarah.NewBot(adapterB, sarah.BotWithInstanceID("workspace-b"))
+//
+// Commands and ScheduledTasks registered with the Adapter's BotType are shared by all instances, while the ones registered with BotType.Instance are only for the instance.
+// Each instance reads the configuration with its own BotType, so each instance has its own configuration directory such as "slack/workspace-a/" with watchers.NewFileWatcher.
+func BotWithInstanceID(id string) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.botType = bot.botType.Instance(id)
+	}
+}
+
+// BotWithFallbackCommand creates and returns DefaultBotOption to set a Command that is executed when no registered Command matches an Input.
+// The Command's Match is still called so it can ignore some Inputs, e.g. an empty message.
+// This does not apply to a user in the middle of a conversational context since the stored UserContext handles the Input.
+//
+//  responder := llm.NewResponder(llm.NewConfig())
+//  bot := sarah.NewBot(myAdapter, sarah.BotWithFallbackCommand(responder))
+func BotWithFallbackCommand(command Command) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.fallbackCommand = command
+	}
+}
+
+// BotWithCommandPrefixIndex creates and returns DefaultBotOption to index the registered Commands by the literal prefixes of their patterns.
+// By default, the Bot checks the Commands one by one for each Input.
+// With this option, the Commands with anchored patterns such as `^\.deploy\b` are held in a trie,
+// and only the ones whose prefixes the Input's message begins with are checked along with the Commands that can not be indexed,
+// e.g. the ones built with MatchFunc.
+// The Commands are still checked in the order of registration, so the matching Command is the same as the one without this option.
+//
+// This pays off for hundreds of Commands; the linear scan is fast enough for a handful of Commands.
+func BotWithCommandPrefixIndex() DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.commands.prefixIndexed = true
+	}
+}
+
+// BotWithIntentMatcher creates and returns DefaultBotOption to set an IntentMatcher.
+// When no Command matches an Input with its pattern, the IntentMatcher maps the Input to an Intent
+// and the Command built with CommandPropsBuilder.MatchIntent for the intent is executed.
+// The IntentMatcher is not called for an Input that a Command matches, so a typical command with a pattern costs no NLU request.
+//
+//  matcher := nlu.NewRasaMatcher(nlu.NewRasaConfig())
+//  bot := sarah.NewBot(myAdapter, sarah.BotWithIntentMatcher(matcher))
+func BotWithIntentMatcher(matcher IntentMatcher) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.intentMatcher = matcher
+	}
+}
+
+// BotWithHistory creates and returns DefaultBotOption to record the messages the Bot receives and sends with the given History.
+// A Command obtains the History with HistoryFromContext to refer to the recent conversation.
+// Give a dedicated History to each Bot.
+//
+//  history := sarah.NewHistory(sarah.NewHistoryConfig())
+//  bot := sarah.NewBot(myAdapter, sarah.BotWithHistory(history))
+func BotWithHistory(history *History) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.history = history
+	}
+}
+
 func (bot *defaultBot) BotType() BotType {
 	return bot.botType
 }
 
 func (bot *defaultBot) Respond(ctx context.Context, input Input) error {
+	// Let the Command schedule a delayed message with SendMessageAt or SendMessageAfter.
+	ctx = newDelayedSenderContext(ctx, bot)
+
 	senderKey := input.SenderKey()
+	log := contextLogger(ctx).With(logging.F(logging.KeyBotType, bot.BotType()))
+
+	if bot.history != nil {
+		// Record before the execution so the Command can refer to the history including this Input.
+		bot.recordInput(ctx, input)
+		ctx = withHistory(ctx, bot.history)
+	}
 
 	// See if any conversational context is stored.
 	var nextFunc ContextualFunc
@@ -129,23 +297,55 @@ func (bot *defaultBot) Respond(ctx context.Context, input Input) error {
 				UserContext: nil,
 			}
 		default:
-			res, err = bot.commands.ExecuteFirstMatched(ctx, input)
+			command := bot.commands.FindFirstMatched(input)
+			if command == nil && bot.intentMatcher != nil {
+				command, ctx = bot.findIntentMatched(ctx, input)
+			}
+			if command == nil && bot.fallbackCommand != nil && bot.fallbackCommand.Match(input) {
+				command = bot.fallbackCommand
+			}
+			if command == nil {
+				return nil
+			}
+
+			log.Debug(
+				"Execute command",
+				logging.F(logging.KeyCommandID, command.Identifier()),
+				logging.F(logging.KeyDestination, bot.replyTo(input)),
+			)
+			cmdCtx, span := tracing.Start(ctx, "sarah.execute_command", tracing.A(logging.KeyCommandID, command.Identifier()))
+			started := time.Now()
+			res, err = command.Execute(cmdCtx, input)
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+			publishCommandEvent(ctx, bot.BotType(), command.Identifier(), time.Since(started), err)
 		}
 	} else {
 		e := bot.userContextStorage.Delete(senderKey)
 		if e != nil {
-			logger.Warnf("Failed to delete UserContext: BotType: %s. SenderKey: %s. Error: %+v", bot.BotType(), senderKey, e)
+			log.Warn("Failed to delete UserContext", logging.F("sender_key", senderKey), logging.Err(e))
 		}
 
 		switch input.(type) {
 		case *AbortInput:
 			return nil
 		default:
-			res, err = nextFunc(ctx, input)
+			nextCtx, span := tracing.Start(ctx, "sarah.execute_context")
+			res, err = nextFunc(nextCtx, input)
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
 		}
 	}
 
 	if err != nil {
+		// Tell the sender that the request failed instead of going silent.
+		if reply, ok := errorReplyText(ctx, bot.errorReply, bot.BotType(), err); ok {
+			_, _ = bot.SendMessage(ctx, NewOutputMessage(bot.replyTo(input), reply))
+		}
 		return err
 	}
 
@@ -158,19 +358,456 @@ func (bot *defaultBot) Respond(ctx context.Context, input Input) error {
 	// This may damage user experience since user is left in conversational context set by CommandResponse without any sort of notification.
 	if res.UserContext != nil && bot.userContextStorage != nil {
 		if err := bot.userContextStorage.Set(senderKey, res.UserContext); err != nil {
-			logger.Errorf("Failed to store UserContext. BotType: %s. SenderKey: %s. UserContext: %#v. Error: %+v", bot.BotType(), senderKey, res.UserContext, err)
+			log.Error("Failed to store UserContext", logging.F("sender_key", senderKey), logging.Err(err))
 		}
 	}
-	if res.Content != nil {
-		message := NewOutputMessage(input.ReplyTo(), res.Content)
-		bot.SendMessage(ctx, message)
+	destination := bot.replyTo(input)
+	if res.Private && res.Content != nil {
+		destination, err = privateDestination(input, destination)
+		if err != nil {
+			return err
+		}
+	}
+	switch content := res.Content.(type) {
+	case nil:
+		// Nothing to send
+
+	case *StreamContent:
+		// Send each partial content as it arrives.
+		// This blocks til the stream finishes so the worker keeps tracking the long-running operation.
+		send := func(c interface{}) {
+			_, _ = bot.SendMessage(ctx, NewOutputMessage(destination, c))
+		}
+		if content.inPlace && bot.editor != nil {
+			send = bot.inPlaceSender(ctx, destination)
+		}
+		return content.Stream(ctx, send)
+
+	default:
+		message := NewOutputMessage(destination, content)
+		_, _ = bot.SendMessage(ctx, message)
 	}
 
 	return nil
 }
 
-func (bot *defaultBot) SendMessage(ctx context.Context, output Output) {
-	bot.sendMessageFunc(ctx, output)
+// findIntentMatched asks the IntentMatcher for the Input's intent and returns the Command that handles the intent.
+// The returned context carries the detected Intent.
+// When the IntentMatcher fails, the error is logged and nil is returned so the fallback Command, if any, can still handle the Input.
+func (bot *defaultBot) findIntentMatched(ctx context.Context, input Input) (Command, context.Context) {
+	intent, err := bot.intentMatcher.MatchIntent(ctx, input)
+	if err != nil {
+		contextLogger(ctx).Error("Failed to match intent", logging.F(logging.KeyBotType, bot.BotType()), logging.Err(err))
+		return nil, ctx
+	}
+
+	if intent == nil {
+		return nil, ctx
+	}
+
+	command := bot.commands.FindIntentMatched(intent.Name)
+	if command == nil {
+		return nil, ctx
+	}
+
+	return command, WithIntent(ctx, intent)
+}
+
+// inPlaceSender returns a function that sends the first content as a new message and updates the message with the succeeding contents.
+// When the update fails, the content is sent as a new message and the new message is updated afterwards.
+func (bot *defaultBot) inPlaceSender(ctx context.Context, destination OutputDestination) func(interface{}) {
+	var handle *MessageHandle
+	return func(content interface{}) {
+		if handle != nil {
+			err := bot.UpdateMessage(ctx, handle, content)
+			if err == nil {
+				return
+			}
+			contextLogger(ctx).Warn("Failed to update message", logging.F(logging.KeyDestination, destination), logging.Err(err))
+		}
+
+		output := NewOutputMessage(destination, content)
+		h, err := bot.SendMessageWithHandle(ctx, output)
+		if errors.Is(err, ErrMessageEditingNotSupported) {
+			// e.g. the destination or the content does not support editing.
+			_, _ = bot.SendMessage(ctx, output)
+			return
+		}
+		if err != nil {
+			contextLogger(ctx).Error("Failed to send message", logging.F(logging.KeyDestination, destination), logging.Err(err))
+			return
+		}
+		handle = h
+	}
+}
+
+// replyTo returns the destination of the response to the given Input.
+// When the Input is sent in a thread, or a new thread should be started, the destination is wrapped with ThreadDestination.
+func (bot *defaultBot) replyTo(input Input) OutputDestination {
+	destination := input.ReplyTo()
+
+	if threadInput, ok := input.(ThreadInput); ok {
+		if id := threadInput.ThreadID(); id != "" {
+			return NewThreadDestination(destination, id)
+		}
+	}
+
+	if bot.replyInThread {
+		if messageInput, ok := input.(MessageIDInput); ok {
+			if id := messageInput.MessageID(); id != "" {
+				return NewThreadDestination(destination, id)
+			}
+		}
+	}
+
+	return destination
+}
+
+// privateDestination wraps the given destination with PrivateDestination so that only the sender of the given Input can see the response.
+// When the Input is sent in a one-to-one conversation, the destination is returned as-is since the response is already private.
+func privateDestination(input Input, destination OutputDestination) (OutputDestination, error) {
+	if dm, ok := input.(DirectMessageInput); ok && dm.IsDirectMessage() {
+		return destination, nil
+	}
+
+	if sender, ok := input.(SenderIDInput); ok {
+		if id := sender.SenderID(); id != "" {
+			return NewPrivateDestination(destination, id), nil
+		}
+	}
+
+	// Sending to the original destination may expose sensitive data to others.
+	return nil, ErrSenderNotIdentified
+}
+
+// publishCommandEvent publishes CommandExecuted or CommandFailed depending on the result of the Command execution.
+func publishCommandEvent(ctx context.Context, botType BotType, commandID string, elapsed time.Duration, err error) {
+	if err != nil {
+		PublishEvent(ctx, &CommandFailed{
+			BotType:       botType,
+			CommandID:     commandID,
+			CorrelationID: CorrelationID(ctx),
+			Elapsed:       elapsed,
+			Err:           err,
+			Time:          time.Now(),
+		})
+		return
+	}
+
+	PublishEvent(ctx, &CommandExecuted{
+		BotType:       botType,
+		CommandID:     commandID,
+		CorrelationID: CorrelationID(ctx),
+		Elapsed:       elapsed,
+		Time:          time.Now(),
+	})
+}
+
+// SendMessage sends the given message via the Adapter.
+// A text message longer than the maximum length is split into multiple messages with SplitMessage.
+// In that case, the SendResult of the last message is returned, and the rest are not sent once one of them fails.
+// With BotWithOutbox, the message is queued and delivered by the Outbox, so the returned SendResult only tells the message is queued.
+func (bot *defaultBot) SendMessage(ctx context.Context, output Output) (*SendResult, error) {
+	ctx, span := tracing.Start(ctx, "sarah.send_message", tracing.A(logging.KeyDestination, output.Destination()))
+	defer span.End()
+
+	release, err := bot.sendQueue.acquire(ctx, output.Destination())
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer release()
+
+	if bot.history != nil {
+		bot.recordOutput(ctx, output)
+	}
+
+	var result *SendResult
+	for _, o := range bot.split(output) {
+		result, err = bot.send(ctx, o)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// SendMessages sends the given messages at once via the Adapter when the Adapter satisfies BatchSender.
+// Otherwise, or when BotWithOutbox or BotWithOrderedSend is given, the messages are sent one by one with SendMessage,
+// and the succeeding messages are still sent after a failure.
+// As SendMessage does, a long message is split and MessageSent or SendFailed is published for each delivery.
+// The SendResult of a split message is the one of its last part.
+func (bot *defaultBot) SendMessages(ctx context.Context, outputs []Output) ([]*SendResult, error) {
+	results := make([]*SendResult, len(outputs))
+	if _, dry := dryRunFromContext(ctx); dry || bot.batchSender == nil || bot.outbox != nil || bot.sendQueue != nil {
+		var firstErr error
+		for i, output := range outputs {
+			result, err := bot.SendMessage(ctx, output)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			results[i] = result
+		}
+		return results, firstErr
+	}
+
+	ctx, span := tracing.Start(ctx, "sarah.send_messages", tracing.A("count", len(outputs)))
+	defer span.End()
+
+	var batch []Output
+	var owners []int // Index of the given message that each batched message belongs to
+	for i, output := range outputs {
+		if bot.history != nil {
+			bot.recordOutput(ctx, output)
+		}
+		for _, o := range bot.split(output) {
+			batch = append(batch, o)
+			owners = append(owners, i)
+		}
+	}
+
+	sent, err := bot.batchSender.SendMessages(ctx, batch)
+	if err != nil {
+		span.RecordError(err)
+		contextLogger(ctx).Error("Failed to send messages", logging.F(logging.KeyBotType, bot.BotType()), logging.Err(err))
+	}
+
+	failed := map[int]bool{}
+	for i, o := range batch {
+		var result *SendResult
+		if i < len(sent) {
+			result = sent[i]
+		}
+
+		owner := owners[i]
+		if err != nil && result == nil {
+			publishSendFailed(ctx, bot.BotType(), o.Destination(), err)
+			failed[owner] = true
+			results[owner] = nil
+			continue
+		}
+		result = bot.delivered(ctx, o, result)
+		if !failed[owner] {
+			results[owner] = result
+		}
+	}
+	return results, err
+}
+
+// split splits the given message into the messages that fit BotWithMaxMessageLength.
+func (bot *defaultBot) split(output Output) []Output {
+	text, ok := output.Content().(string)
+	if !ok || bot.maxMessageLength <= 0 {
+		return []Output{output}
+	}
+
+	var outputs []Output
+	for _, chunk := range SplitMessage(text, bot.maxMessageLength) {
+		outputs = append(outputs, NewOutputMessage(output.Destination(), chunk))
+	}
+	return outputs
+}
+
+// recordInput records the given Input with the History.
+// A failure is only logged since the history must not prevent the Bot from responding.
+func (bot *defaultBot) recordInput(ctx context.Context, input Input) {
+	entry := &HistoryEntry{
+		ThreadID:  ThreadID(bot.replyTo(input)),
+		SenderKey: input.SenderKey(),
+		Text:      input.Message(),
+		Outgoing:  false,
+		Time:      input.SentAt(),
+	}
+	if named, ok := input.(SenderDisplayNameInput); ok {
+		entry.SenderName = named.SenderDisplayName()
+	}
+
+	err := bot.history.Record(ctx, input.ReplyTo(), entry)
+	if err != nil {
+		contextLogger(ctx).Error("Failed to record input", logging.F(logging.KeyBotType, bot.BotType()), logging.Err(err))
+	}
+}
+
+// recordOutput records the given Output with the History when its content has a text form.
+// A private message is not recorded since the history is shared by everyone in the destination.
+func (bot *defaultBot) recordOutput(ctx context.Context, output Output) {
+	if _, ok := output.Destination().(*PrivateDestination); ok {
+		return
+	}
+
+	text, ok := historyText(output.Content())
+	if !ok {
+		return
+	}
+
+	entry := &HistoryEntry{
+		ThreadID: ThreadID(output.Destination()),
+		Text:     text,
+		Outgoing: true,
+		Time:     clock.FromContext(ctx).Now(),
+	}
+	err := bot.history.Record(ctx, output.Destination(), entry)
+	if err != nil {
+		contextLogger(ctx).Error("Failed to record output", logging.F(logging.KeyBotType, bot.BotType()), logging.F(logging.KeyDestination, output.Destination()), logging.Err(err))
+	}
+}
+
+// send passes the given message to the Outbox when BotWithOutbox is given, or to the Adapter otherwise.
+func (bot *defaultBot) send(ctx context.Context, output Output) (*SendResult, error) {
+	if bot.outbox != nil {
+		bot.outbox.Enqueue(ctx, output)
+		return &SendResult{
+			Destination: output.Destination(),
+			Queued:      true,
+		}, nil
+	}
+
+	return bot.deliver(ctx, output)
+}
+
+// deliver sends the given message via the Adapter and publishes MessageSent or SendFailed depending on the result.
+// A failure is logged here so the callers that can not do anything about the failure may simply ignore the error.
+func (bot *defaultBot) deliver(ctx context.Context, output Output) (*SendResult, error) {
+	if d, ok := dryRunFromContext(ctx); ok {
+		d.record(ctx, bot.BotType(), output)
+		return bot.delivered(ctx, output, nil), nil
+	}
+
+	result, err := bot.sendMessageFunc(ctx, output)
+	if err != nil {
+		contextLogger(ctx).Error("Failed to send message", logging.F(logging.KeyBotType, bot.BotType()), logging.F(logging.KeyDestination, output.Destination()), logging.Err(err))
+		publishSendFailed(ctx, bot.BotType(), output.Destination(), err)
+		return nil, err
+	}
+
+	return bot.delivered(ctx, output, result), nil
+}
+
+// delivered publishes MessageSent for the given message and returns the given SendResult.
+// Destination and SentAt of the SendResult are filled when the Adapter leaves them empty.
+func (bot *defaultBot) delivered(ctx context.Context, output Output, result *SendResult) *SendResult {
+	if result == nil {
+		result = &SendResult{}
+	}
+	if result.Destination == nil {
+		result.Destination = output.Destination()
+	}
+	if result.SentAt.IsZero() {
+		result.SentAt = clock.FromContext(ctx).Now()
+	}
+
+	PublishEvent(ctx, &MessageSent{
+		BotType:     bot.BotType(),
+		Destination: result.Destination,
+		MessageID:   result.MessageID,
+		Time:        result.SentAt,
+	})
+	return result
+}
+
+func publishSendFailed(ctx context.Context, botType BotType, destination OutputDestination, err error) {
+	PublishEvent(ctx, &SendFailed{
+		BotType:     botType,
+		Destination: destination,
+		Err:         err,
+		Time:        time.Now(),
+	})
+}
+
+// SendMessageWithHandle sends the given message via the Adapter and returns the handle to the sent message.
+// This returns ErrMessageEditingNotSupported when the Adapter does not satisfy MessageEditor.
+func (bot *defaultBot) SendMessageWithHandle(ctx context.Context, output Output) (*MessageHandle, error) {
+	if bot.editor == nil {
+		return nil, ErrMessageEditingNotSupported
+	}
+
+	if d, ok := dryRunFromContext(ctx); ok {
+		d.record(ctx, bot.BotType(), output)
+		return &MessageHandle{Destination: output.Destination()}, nil
+	}
+
+	ctx, span := tracing.Start(ctx, "sarah.send_message", tracing.A(logging.KeyDestination, output.Destination()))
+	defer span.End()
+
+	handle, err := bot.editor.SendMessageWithHandle(ctx, output)
+	if err != nil {
+		span.RecordError(err)
+		if !errors.Is(err, ErrMessageEditingNotSupported) {
+			publishSendFailed(ctx, bot.BotType(), output.Destination(), err)
+		}
+		return nil, err
+	}
+
+	PublishEvent(ctx, &MessageSent{
+		BotType:     bot.BotType(),
+		Destination: handle.Destination,
+		MessageID:   handle.MessageID,
+		Time:        clock.FromContext(ctx).Now(),
+	})
+	return handle, nil
+}
+
+// UpdateMessage updates the message identified by the given handle via the Adapter.
+// This returns ErrMessageEditingNotSupported when the Adapter does not satisfy MessageEditor.
+func (bot *defaultBot) UpdateMessage(ctx context.Context, handle *MessageHandle, content interface{}) error {
+	if bot.editor == nil {
+		return ErrMessageEditingNotSupported
+	}
+
+	if d, ok := dryRunFromContext(ctx); ok {
+		d.record(ctx, bot.BotType(), NewOutputMessage(handle.Destination, content))
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "sarah.update_message", tracing.A(logging.KeyDestination, handle.Destination))
+	defer span.End()
+
+	err := bot.editor.UpdateMessage(ctx, handle, content)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// DeleteMessage deletes the message identified by the given handle via the Adapter.
+// This returns ErrMessageEditingNotSupported when the Adapter does not satisfy MessageEditor.
+func (bot *defaultBot) DeleteMessage(ctx context.Context, handle *MessageHandle) error {
+	if bot.editor == nil {
+		return ErrMessageEditingNotSupported
+	}
+
+	if _, ok := dryRunFromContext(ctx); ok {
+		contextLogger(ctx).Info("Dry run: message is not deleted", logging.F(logging.KeyBotType, bot.BotType()), logging.F(logging.KeyDestination, handle.Destination))
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "sarah.delete_message", tracing.A(logging.KeyDestination, handle.Destination))
+	defer span.End()
+
+	err := bot.editor.DeleteMessage(ctx, handle)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// HealthCheck delegates the health check to the Adapter.
+// This always returns nil when the Adapter does not satisfy HealthChecker.
+func (bot *defaultBot) HealthCheck(ctx context.Context) error {
+	if bot.healthCheckFunc == nil {
+		return nil
+	}
+	return bot.healthCheckFunc(ctx)
+}
+
+// Verify delegates the verification to the Adapter.
+// This always returns nil when the Adapter does not satisfy Verifier.
+func (bot *defaultBot) Verify(ctx context.Context) error {
+	if bot.verifyFunc == nil {
+		return nil
+	}
+	return bot.verifyFunc(ctx)
 }
 
 func (bot *defaultBot) AppendCommand(command Command) {
@@ -178,6 +815,10 @@ func (bot *defaultBot) AppendCommand(command Command) {
 }
 
 func (bot *defaultBot) Run(ctx context.Context, enqueueInput func(Input) error, notifyErr func(error)) {
+	if bot.outbox != nil {
+		go bot.outbox.Run(ctx, bot.deliver)
+	}
+
 	bot.runFunc(ctx, enqueueInput, notifyErr)
 }
 
@@ -3,7 +3,10 @@ package sarah
 import (
 	"context"
 	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/tracing"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -11,7 +14,8 @@ import (
 type DummyBot struct {
 	BotTypeValue      BotType
 	RespondFunc       func(context.Context, Input) error
-	SendMessageFunc   func(context.Context, Output)
+	SendMessageFunc   func(context.Context, Output) (*SendResult, error)
+	SendMessagesFunc  func(context.Context, []Output) ([]*SendResult, error)
 	AppendCommandFunc func(Command)
 	RunFunc           func(context.Context, func(Input) error, func(error))
 }
@@ -24,8 +28,12 @@ func (bot *DummyBot) Respond(ctx context.Context, input Input) error {
 	return bot.RespondFunc(ctx, input)
 }
 
-func (bot *DummyBot) SendMessage(ctx context.Context, output Output) {
-	bot.SendMessageFunc(ctx, output)
+func (bot *DummyBot) SendMessage(ctx context.Context, output Output) (*SendResult, error) {
+	return bot.SendMessageFunc(ctx, output)
+}
+
+func (bot *DummyBot) SendMessages(ctx context.Context, outputs []Output) ([]*SendResult, error) {
+	return bot.SendMessagesFunc(ctx, outputs)
 }
 
 func (bot *DummyBot) AppendCommand(command Command) {
@@ -55,6 +63,43 @@ func TestNewBot(t *testing.T) {
 	}
 }
 
+func TestNewBot_WithMessageEditor(t *testing.T) {
+	adapter := &struct {
+		*DummyAdapter
+		*DummyMessageEditor
+	}{
+		DummyAdapter:       &DummyAdapter{},
+		DummyMessageEditor: &DummyMessageEditor{},
+	}
+	myBot := NewBot(adapter)
+
+	if myBot.(*defaultBot).editor == nil {
+		t.Error("MessageEditor is not set.")
+	}
+}
+
+func TestNewBot_WithMessageLengthLimiter(t *testing.T) {
+	adapter := &struct {
+		*DummyAdapter
+		*DummyMessageLengthLimiter
+	}{
+		DummyAdapter:              &DummyAdapter{},
+		DummyMessageLengthLimiter: &DummyMessageLengthLimiter{MaxMessageLengthValue: 100},
+	}
+	myBot := NewBot(adapter)
+
+	if myBot.(*defaultBot).maxMessageLength != 100 {
+		t.Errorf("Unexpected maximum length is set: %d.", myBot.(*defaultBot).maxMessageLength)
+	}
+
+	// The option takes precedence over the Adapter's value.
+	myBot = NewBot(adapter, BotWithMaxMessageLength(0))
+
+	if myBot.(*defaultBot).maxMessageLength != 0 {
+		t.Errorf("Unexpected maximum length is set: %d.", myBot.(*defaultBot).maxMessageLength)
+	}
+}
+
 func TestDefaultBot_BotType(t *testing.T) {
 	var botType BotType = "slack"
 	myBot := &defaultBot{botType: botType}
@@ -127,6 +172,78 @@ func TestDefaultBot_Respond_WithCommandError(t *testing.T) {
 	}
 }
 
+func TestDefaultBot_Respond_WithUserFacingCommandError(t *testing.T) {
+	expectedErr := NewCommandError(ErrBadArgs, "", errors.New("invalid date"))
+	commands := &Commands{
+		collection: []Command{
+			&DummyCommand{
+				MatchFunc: func(_ Input) bool {
+					return true
+				},
+				ExecuteFunc: func(_ context.Context, input Input) (*CommandResponse, error) {
+					return nil, expectedErr
+				},
+			},
+		},
+	}
+	var sent []Output
+	myBot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent = append(sent, output)
+			return nil, nil
+		},
+		commands: commands,
+	}
+
+	err := myBot.Respond(context.TODO(), &DummyInput{ReplyToValue: "replyTo"})
+	if err != expectedErr {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of messages are sent: %d.", len(sent))
+	}
+	if sent[0].Content() != expectedErr.Reply() || sent[0].Destination() != "replyTo" {
+		t.Errorf("Unexpected message is sent: %#v.", sent[0])
+	}
+}
+
+func TestDefaultBot_Respond_WithErrorReply(t *testing.T) {
+	commands := &Commands{
+		collection: []Command{
+			&DummyCommand{
+				MatchFunc: func(_ Input) bool {
+					return true
+				},
+				ExecuteFunc: func(_ context.Context, input Input) (*CommandResponse, error) {
+					return nil, errors.New("unexpected")
+				},
+			},
+		},
+	}
+	var sent []Output
+	myBot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent = append(sent, output)
+			return nil, nil
+		},
+		commands:   commands,
+		errorReply: &ErrorReplyConfig{Internal: "Failed: {{.CorrelationID}}"},
+	}
+
+	err := myBot.Respond(WithCorrelationID(context.TODO(), "abc"), &DummyInput{ReplyToValue: "replyTo"})
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of messages are sent: %d.", len(sent))
+	}
+	if sent[0].Content() != "Failed: abc" {
+		t.Errorf("Unexpected message is sent: %#v.", sent[0].Content())
+	}
+}
+
 func TestDefaultBot_Respond_WithoutContext(t *testing.T) {
 	dummyStorage := &DummyUserContextStorage{
 		GetFunc: func(_ string) (ContextualFunc, error) {
@@ -150,6 +267,148 @@ func TestDefaultBot_Respond_WithoutContext(t *testing.T) {
 	}
 }
 
+func TestDefaultBot_Respond_WithFallbackCommand(t *testing.T) {
+	executed := []string{}
+	newCommand := func(id string, match bool) *DummyCommand {
+		return &DummyCommand{
+			IdentifierValue: id,
+			MatchFunc: func(_ Input) bool {
+				return match
+			},
+			ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+				executed = append(executed, id)
+				return nil, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		command  *DummyCommand
+		fallback *DummyCommand
+		expected string
+	}{
+		{
+			command:  newCommand("matching", true),
+			fallback: newCommand("fallback", true),
+			expected: "matching",
+		},
+		{
+			command:  newCommand("unmatching", false),
+			fallback: newCommand("fallback", true),
+			expected: "fallback",
+		},
+		{
+			command:  newCommand("unmatching", false),
+			fallback: newCommand("fallback", false),
+			expected: "",
+		},
+	}
+
+	for i, tt := range tests {
+		executed = []string{}
+		myBot := NewBot(&DummyAdapter{}, BotWithFallbackCommand(tt.fallback))
+		myBot.AppendCommand(tt.command)
+
+		err := myBot.Respond(context.TODO(), &DummyInput{MessageValue: ".foo"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %#v.", i, err)
+		}
+
+		if strings.Join(executed, ",") != tt.expected {
+			t.Errorf("Unexpected command is executed on test #%d: %#v.", i, executed)
+		}
+	}
+}
+
+func TestDefaultBot_Respond_WithIntentMatcher(t *testing.T) {
+	tests := []struct {
+		matchPattern bool
+		intent       *Intent
+		err          error
+		expected     string
+	}{
+		{
+			matchPattern: true,
+			expected:     "pattern",
+		},
+		{
+			intent:   &Intent{Name: "greet", Entities: []*IntentEntity{{Name: "name", Value: "Alice"}}},
+			expected: "intent:Alice",
+		},
+		{
+			intent:   &Intent{Name: "unknown"},
+			expected: "fallback",
+		},
+		{
+			expected: "fallback",
+		},
+		{
+			err:      errors.New("NLU backend is not available"),
+			expected: "fallback",
+		},
+	}
+
+	for i, tt := range tests {
+		executed := ""
+		matcherCalled := false
+		matcher := &DummyIntentMatcher{
+			MatchIntentFunc: func(_ context.Context, _ Input) (*Intent, error) {
+				matcherCalled = true
+				return tt.intent, tt.err
+			},
+		}
+		fallback := &DummyCommand{
+			IdentifierValue: "fallback",
+			MatchFunc: func(_ Input) bool {
+				return true
+			},
+			ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+				executed = "fallback"
+				return nil, nil
+			},
+		}
+		myBot := NewBot(&DummyAdapter{}, BotWithIntentMatcher(matcher), BotWithFallbackCommand(fallback))
+		matchPattern := tt.matchPattern
+		myBot.AppendCommand(&DummyCommand{
+			IdentifierValue: "pattern",
+			MatchFunc: func(_ Input) bool {
+				return matchPattern
+			},
+			ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+				executed = "pattern"
+				return nil, nil
+			},
+		})
+		myBot.AppendCommand(&DummyIntentCommand{
+			DummyCommand: DummyCommand{
+				IdentifierValue: "greeting",
+				MatchFunc: func(_ Input) bool {
+					return false
+				},
+				ExecuteFunc: func(ctx context.Context, _ Input) (*CommandResponse, error) {
+					intent, _ := IntentFromContext(ctx)
+					executed = "intent:" + intent.Entity("name")
+					return nil, nil
+				},
+			},
+			IntentNameValue: "greet",
+		})
+
+		err := myBot.Respond(context.TODO(), &DummyInput{MessageValue: "Hi, I am Alice."})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %#v.", i, err)
+		}
+
+		if executed != tt.expected {
+			t.Errorf("Unexpected command is executed on test #%d: %s.", i, executed)
+		}
+
+		if matcherCalled == tt.matchPattern {
+			t.Errorf("IntentMatcher must be called only when no pattern matches on test #%d.", i)
+		}
+	}
+}
+
 func TestDefaultBot_Respond_WithContextButMessage(t *testing.T) {
 	var givenNext ContextualFunc
 	dummyStorage := &DummyUserContextStorage{
@@ -181,8 +440,9 @@ func TestDefaultBot_Respond_WithContextButMessage(t *testing.T) {
 	myBot := &defaultBot{
 		userContextStorage: dummyStorage,
 		commands:           &Commands{collection: []Command{command}},
-		sendMessageFunc: func(_ context.Context, output Output) {
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
 			isSent = true
+			return nil, nil
 		},
 	}
 	err := myBot.Respond(context.TODO(), &DummyInput{})
@@ -227,9 +487,10 @@ func TestDefaultBot_Respond_WithContext(t *testing.T) {
 	var passedContent interface{}
 	var passedDestination OutputDestination
 	myBot := &defaultBot{
-		sendMessageFunc: func(_ context.Context, output Output) {
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
 			passedContent = output.Content()
 			passedDestination = output.Destination()
+			return nil, nil
 		},
 		userContextStorage: dummyStorage,
 		commands:           NewCommands(),
@@ -293,8 +554,9 @@ func TestDefaultBot_Respond_WithContextStorageSetError(t *testing.T) {
 
 	sendMessageCalled := false
 	myBot := &defaultBot{
-		sendMessageFunc: func(_ context.Context, output Output) {
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
 			sendMessageCalled = true
+			return nil, nil
 		},
 		userContextStorage: dummyStorage,
 		commands:           &Commands{collection: []Command{cmd}},
@@ -333,8 +595,9 @@ func TestDefaultBot_Respond_WithContextStorageDeleteError(t *testing.T) {
 
 	sendMessageCalled := false
 	myBot := &defaultBot{
-		sendMessageFunc: func(_ context.Context, output Output) {
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
 			sendMessageCalled = true
+			return nil, nil
 		},
 		userContextStorage: dummyStorage,
 	}
@@ -371,8 +634,9 @@ func TestDefaultBot_Respond_UserContextWithoutStorage(t *testing.T) {
 
 	sendMessageCalled := false
 	myBot := &defaultBot{
-		sendMessageFunc: func(_ context.Context, output Output) {
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
 			sendMessageCalled = true
+			return nil, nil
 		},
 		commands:           &Commands{collection: []Command{cmd}},
 		userContextStorage: nil,
@@ -436,8 +700,9 @@ func TestDefaultBot_Respond_Help(t *testing.T) {
 	myBot := &defaultBot{
 		userContextStorage: dummyStorage,
 		commands:           &Commands{collection: []Command{cmd}},
-		sendMessageFunc: func(_ context.Context, output Output) {
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
 			givenOutput = output
+			return nil, nil
 		},
 	}
 
@@ -490,8 +755,9 @@ func TestDefaultBot_Run(t *testing.T) {
 func TestDefaultBot_SendMessage(t *testing.T) {
 	adapterProcessed := false
 	bot := &defaultBot{
-		sendMessageFunc: func(_ context.Context, _ Output) {
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
 			adapterProcessed = true
+			return nil, nil
 		},
 	}
 
@@ -503,6 +769,583 @@ func TestDefaultBot_SendMessage(t *testing.T) {
 	}
 }
 
+func TestDefaultBot_SendMessage_Result(t *testing.T) {
+	bus := NewEventBus()
+	var events []Event
+	bus.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := clock.WithContext(NewEventBusContext(context.Background(), bus), clock.NewFake(now))
+
+	bot := &defaultBot{
+		botType: "dummy",
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
+			return &SendResult{MessageID: "message"}, nil
+		},
+	}
+
+	result, err := bot.SendMessage(ctx, NewOutputMessage("#general", "hello"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if result.MessageID != "message" || result.Destination != "#general" || !result.SentAt.Equal(now) || result.Queued {
+		t.Errorf("Unexpected result is returned: %#v.", result)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Unexpected number of events are published: %d.", len(events))
+	}
+	sent, ok := events[0].(*MessageSent)
+	if !ok {
+		t.Fatalf("Unexpected event is published: %#v.", events[0])
+	}
+	if sent.BotType != "dummy" || sent.MessageID != "message" || sent.Destination != "#general" || !sent.Time.Equal(now) {
+		t.Errorf("Unexpected event is published: %#v.", sent)
+	}
+}
+
+func TestDefaultBot_SendMessage_Error(t *testing.T) {
+	bus := NewEventBus()
+	var events []Event
+	bus.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	ctx := NewEventBusContext(context.Background(), bus)
+
+	sendErr := errors.New("dummy")
+	sentContents := 0
+	bot := &defaultBot{
+		botType:          "dummy",
+		maxMessageLength: 30,
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
+			sentContents++
+			return nil, sendErr
+		},
+	}
+
+	result, err := bot.SendMessage(ctx, NewOutputMessage("#general", "first line\nsecond line\nthird line"))
+	if err != sendErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+	if result != nil {
+		t.Errorf("Unexpected result is returned: %#v.", result)
+	}
+	if sentContents != 1 {
+		t.Errorf("The rest of the split messages must not be sent: %d.", sentContents)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Unexpected number of events are published: %d.", len(events))
+	}
+	failed, ok := events[0].(*SendFailed)
+	if !ok {
+		t.Fatalf("Unexpected event is published: %#v.", events[0])
+	}
+	if failed.BotType != "dummy" || failed.Destination != "#general" || failed.Err != sendErr {
+		t.Errorf("Unexpected event is published: %#v.", failed)
+	}
+}
+
+func TestNewBot_WithBatchSender(t *testing.T) {
+	adapter := &struct {
+		*DummyAdapter
+		*DummyBatchSender
+	}{
+		DummyAdapter:     &DummyAdapter{},
+		DummyBatchSender: &DummyBatchSender{},
+	}
+
+	bot := NewBot(adapter).(*defaultBot)
+	if bot.batchSender == nil {
+		t.Error("BatchSender is not set.")
+	}
+}
+
+func TestDefaultBot_SendMessages(t *testing.T) {
+	bus := NewEventBus()
+	var events []Event
+	bus.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	ctx := NewEventBusContext(context.Background(), bus)
+
+	sendErr := errors.New("dummy")
+	var batch []Output
+	bot := &defaultBot{
+		botType:          "dummy",
+		maxMessageLength: 30,
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
+			t.Error("SendMessage must not be called when the Adapter satisfies BatchSender.")
+			return nil, nil
+		},
+		batchSender: &DummyBatchSender{
+			SendMessagesFunc: func(_ context.Context, outputs []Output) ([]*SendResult, error) {
+				batch = outputs
+				// The last message fails.
+				return []*SendResult{{MessageID: "1"}, {MessageID: "2"}, {MessageID: "3"}, nil}, sendErr
+			},
+		},
+	}
+
+	results, err := bot.SendMessages(ctx, []Output{
+		NewOutputMessage("#general", "first"),
+		NewOutputMessage("#random", "first line\nsecond line\nthird line"),
+		NewOutputMessage("#ops", "alert"),
+	})
+	if err != sendErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if len(batch) != 4 {
+		t.Fatalf("Split messages must be sent at once: %d.", len(batch))
+	}
+	if len(results) != 3 {
+		t.Fatalf("Unexpected number of results are returned: %d.", len(results))
+	}
+	if results[0].MessageID != "1" || results[0].Destination != "#general" {
+		t.Errorf("Unexpected result is returned: %#v.", results[0])
+	}
+	if results[1].MessageID != "3" {
+		t.Errorf("The result of the last part must be returned: %#v.", results[1])
+	}
+	if results[2] != nil {
+		t.Errorf("The result of the undelivered message must be nil: %#v.", results[2])
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("Unexpected number of events are published: %d.", len(events))
+	}
+	if failed, ok := events[3].(*SendFailed); !ok || failed.Destination != "#ops" {
+		t.Errorf("Unexpected event is published: %#v.", events[3])
+	}
+}
+
+func TestDefaultBot_SendMessages_OneByOne(t *testing.T) {
+	sendErr := errors.New("dummy")
+	var sent []Output
+	bot := &defaultBot{
+		botType: "dummy",
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent = append(sent, output)
+			if output.Destination() == "#general" {
+				return nil, sendErr
+			}
+			return nil, nil
+		},
+	}
+
+	results, err := bot.SendMessages(context.TODO(), []Output{
+		NewOutputMessage("#general", "first"),
+		NewOutputMessage("#random", "second"),
+	})
+	if err != sendErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("The succeeding message must be sent after a failure: %d.", len(sent))
+	}
+	if len(results) != 2 || results[0] != nil || results[1] == nil {
+		t.Errorf("Unexpected results are returned: %#v.", results)
+	}
+}
+
+func TestDefaultBot_SendMessage_Split(t *testing.T) {
+	var contents []interface{}
+	bot := &defaultBot{
+		maxMessageLength: 30,
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			if output.Destination() != "dummy" {
+				t.Errorf("Unexpected destination is given: %#v.", output.Destination())
+			}
+			contents = append(contents, output.Content())
+			return nil, nil
+		},
+	}
+
+	bot.SendMessage(context.TODO(), NewOutputMessage("dummy", "first line\nsecond line\nthird line"))
+
+	if len(contents) != 2 {
+		t.Fatalf("Unexpected number of messages are sent: %#v.", contents)
+	}
+	if contents[0] != "first line\nsecond line\n(1/2)" {
+		t.Errorf("Unexpected content is sent: %#v.", contents[0])
+	}
+
+	// Non-text content is sent as-is.
+	contents = nil
+	bot.SendMessage(context.TODO(), NewOutputMessage("dummy", struct{}{}))
+
+	if len(contents) != 1 {
+		t.Errorf("Unexpected number of messages are sent: %#v.", contents)
+	}
+}
+
+func TestDefaultBot_SendMessage_WithOutbox(t *testing.T) {
+	bus := NewEventBus()
+	ctx, cancel := context.WithCancel(NewEventBusContext(context.Background(), bus))
+	defer cancel()
+
+	sent := make(chan Output, 1)
+	bot := &defaultBot{
+		runFunc: func(_ context.Context, _ func(Input) error, _ func(error)) {},
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent <- output
+			return nil, nil
+		},
+	}
+	BotWithOutbox(NewOutbox(NewOutboxConfig()))(bot)
+
+	result, err := bot.SendMessage(ctx, NewOutputMessage("dummy", "message"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !result.Queued || result.Destination != "dummy" {
+		t.Errorf("Unexpected result is returned: %#v.", result)
+	}
+
+	select {
+	case <-sent:
+		t.Fatal("Message is sent before the Bot runs.")
+
+	default:
+		// O.K.
+
+	}
+
+	bot.Run(ctx, func(_ Input) error { return nil }, func(_ error) {})
+
+	select {
+	case output := <-sent:
+		if output.Content() != "message" {
+			t.Errorf("Unexpected content is sent: %#v.", output.Content())
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Queued message is not sent.")
+
+	}
+}
+
+type DummySpan struct {
+	Name       string
+	Attributes []tracing.Attribute
+	Err        error
+	Ended      bool
+}
+
+func (s *DummySpan) SetAttributes(attrs ...tracing.Attribute) {
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+func (s *DummySpan) RecordError(err error) {
+	s.Err = err
+}
+
+func (s *DummySpan) End() {
+	s.Ended = true
+}
+
+type DummyTracer struct {
+	StartFunc func(context.Context, string, ...tracing.Attribute) (context.Context, tracing.Span)
+}
+
+func (t *DummyTracer) Start(ctx context.Context, name string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	return t.StartFunc(ctx, name, attrs...)
+}
+
+// spanRecorder returns a DummyTracer that stores the started spans to the given slice.
+func spanRecorder(spans *[]*DummySpan) *DummyTracer {
+	return &DummyTracer{
+		StartFunc: func(ctx context.Context, name string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+			span := &DummySpan{Name: name, Attributes: attrs}
+			*spans = append(*spans, span)
+			return ctx, span
+		},
+	}
+}
+
+func TestDefaultBot_SendMessage_WithTracer(t *testing.T) {
+	var spans []*DummySpan
+	tracer := spanRecorder(&spans)
+	bot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
+			if len(spans) != 1 || spans[0].Ended {
+				t.Error("Span must be started before Adapter.SendMessage is called.")
+			}
+			return nil, nil
+		},
+	}
+
+	output := NewOutputMessage("dummy", struct{}{})
+	bot.SendMessage(tracing.NewContext(context.TODO(), tracer), output)
+
+	if len(spans) != 1 {
+		t.Fatalf("Unexpected number of spans are started: %d.", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "sarah.send_message" {
+		t.Errorf("Unexpected span name is given: %s.", span.Name)
+	}
+
+	if !span.Ended {
+		t.Error("Span is not ended.")
+	}
+}
+
+func TestDefaultBot_Respond_WithTracer(t *testing.T) {
+	expectedErr := errors.New("expected")
+	commands := &Commands{
+		collection: []Command{
+			&DummyCommand{
+				IdentifierValue: "dummy",
+				MatchFunc: func(_ Input) bool {
+					return true
+				},
+				ExecuteFunc: func(_ context.Context, input Input) (*CommandResponse, error) {
+					return nil, expectedErr
+				},
+			},
+		},
+	}
+	myBot := &defaultBot{
+		commands: commands,
+	}
+	var spans []*DummySpan
+	tracer := spanRecorder(&spans)
+
+	_ = myBot.Respond(tracing.NewContext(context.TODO(), tracer), &DummyInput{})
+
+	if len(spans) != 1 {
+		t.Fatalf("Unexpected number of spans are started: %d.", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "sarah.execute_command" {
+		t.Errorf("Unexpected span name is given: %s.", span.Name)
+	}
+
+	if len(span.Attributes) != 1 || span.Attributes[0].Value != "dummy" {
+		t.Errorf("Command ID is not given: %#v.", span.Attributes)
+	}
+
+	if span.Err != expectedErr {
+		t.Errorf("Error is not recorded: %#v.", span.Err)
+	}
+
+	if !span.Ended {
+		t.Error("Span is not ended.")
+	}
+}
+
+func TestDefaultBot_Respond_WithEventBus(t *testing.T) {
+	expectedErr := errors.New("expected")
+	tests := []struct {
+		err error
+	}{
+		{
+			err: nil,
+		},
+		{
+			err: expectedErr,
+		},
+	}
+
+	for i, tt := range tests {
+		var events []Event
+		bus := NewEventBus()
+		bus.Subscribe(func(e Event) {
+			events = append(events, e)
+		})
+		myBot := &defaultBot{
+			botType: "dummy",
+			commands: &Commands{
+				collection: []Command{
+					&DummyCommand{
+						IdentifierValue: "dummy",
+						MatchFunc: func(_ Input) bool {
+							return true
+						},
+						ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+							return nil, tt.err
+						},
+					},
+				},
+			},
+		}
+		ctx := WithCorrelationID(NewEventBusContext(context.TODO(), bus), "abc")
+
+		_ = myBot.Respond(ctx, &DummyInput{})
+
+		if len(events) != 1 {
+			t.Fatalf("Unexpected number of events are published on test #%d: %d.", i, len(events))
+		}
+
+		switch e := events[0].(type) {
+		case *CommandExecuted:
+			if tt.err != nil {
+				t.Errorf("CommandFailed must be published on test #%d.", i)
+			}
+			if e.CommandID != "dummy" || e.CorrelationID != "abc" || e.BotType != "dummy" {
+				t.Errorf("Unexpected event is published on test #%d: %#v.", i, e)
+			}
+
+		case *CommandFailed:
+			if tt.err == nil {
+				t.Errorf("CommandExecuted must be published on test #%d.", i)
+			}
+			if e.CommandID != "dummy" || e.Err != tt.err {
+				t.Errorf("Unexpected event is published on test #%d: %#v.", i, e)
+			}
+
+		default:
+			t.Errorf("Unexpected event is published on test #%d: %#v.", i, e)
+
+		}
+	}
+}
+
+type DummyThreadInput struct {
+	DummyInput
+	ThreadIDValue  string
+	MessageIDValue string
+}
+
+func (i *DummyThreadInput) ThreadID() string {
+	return i.ThreadIDValue
+}
+
+func (i *DummyThreadInput) MessageID() string {
+	return i.MessageIDValue
+}
+
+func TestBotWithReplyInThread(t *testing.T) {
+	bot := &defaultBot{}
+
+	BotWithReplyInThread(true)(bot)
+
+	if !bot.replyInThread {
+		t.Error("Option is not applied.")
+	}
+}
+
+func TestBotWithMaxMessageLength(t *testing.T) {
+	bot := &defaultBot{}
+
+	BotWithMaxMessageLength(100)(bot)
+
+	if bot.maxMessageLength != 100 {
+		t.Errorf("Option is not applied: %d.", bot.maxMessageLength)
+	}
+}
+
+func TestBotWithOutbox(t *testing.T) {
+	bot := &defaultBot{}
+	outbox := NewOutbox(NewOutboxConfig())
+
+	BotWithOutbox(outbox)(bot)
+
+	if bot.outbox != outbox {
+		t.Error("Option is not applied.")
+	}
+}
+
+func TestBotWithHistory(t *testing.T) {
+	bot := &defaultBot{}
+	history := NewHistory(NewHistoryConfig())
+
+	BotWithHistory(history)(bot)
+
+	if bot.history != history {
+		t.Error("Option is not applied.")
+	}
+}
+
+func TestDefaultBot_Respond_WithHistory(t *testing.T) {
+	history := NewHistory(NewHistoryConfig())
+	var sent []Output
+	bot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent = append(sent, output)
+			return nil, nil
+		},
+		commands: NewCommands(),
+		history:  history,
+	}
+	bot.AppendCommand(&DummyCommand{
+		MatchFunc: func(_ Input) bool {
+			return true
+		},
+		ExecuteFunc: func(ctx context.Context, _ Input) (*CommandResponse, error) {
+			h, err := HistoryFromContext(ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+			entries, _ := h.Recent(ctx, "#general", time.Time{})
+			if len(entries) != 1 || entries[0].Text != ".ping" || entries[0].SenderKey != "user" || entries[0].Outgoing {
+				t.Errorf("Input is not recorded before execution: %#v.", entries)
+			}
+			return &CommandResponse{Content: "pong"}, nil
+		},
+	})
+
+	err := bot.Respond(context.TODO(), &DummyInput{SenderKeyValue: "user", MessageValue: ".ping", SentAtValue: time.Now(), ReplyToValue: "#general"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	bot.SendMessage(context.TODO(), NewOutputMessage(NewPrivateDestination("#general", "user"), "secret"))
+	if len(sent) != 2 {
+		t.Fatalf("Messages are not sent: %#v.", sent)
+	}
+
+	entries, err := history.Recent(context.TODO(), "#general", time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(entries) != 2 || entries[1].Text != "pong" || !entries[1].Outgoing {
+		t.Errorf("Unexpected entries are recorded: %#v.", entries)
+	}
+}
+
+func TestDefaultBot_replyTo(t *testing.T) {
+	tests := []struct {
+		replyInThread bool
+		input         Input
+		expected      OutputDestination
+	}{
+		{
+			replyInThread: true,
+			input:         &DummyInput{ReplyToValue: "#general"},
+			expected:      "#general",
+		},
+		{
+			replyInThread: false,
+			input:         &DummyThreadInput{DummyInput: DummyInput{ReplyToValue: "#general"}, ThreadIDValue: "parent", MessageIDValue: "message"},
+			expected:      NewThreadDestination("#general", "parent"),
+		},
+		{
+			replyInThread: false,
+			input:         &DummyThreadInput{DummyInput: DummyInput{ReplyToValue: "#general"}, MessageIDValue: "message"},
+			expected:      "#general",
+		},
+		{
+			replyInThread: true,
+			input:         &DummyThreadInput{DummyInput: DummyInput{ReplyToValue: "#general"}, MessageIDValue: "message"},
+			expected:      NewThreadDestination("#general", "message"),
+		},
+	}
+
+	for i, tt := range tests {
+		bot := &defaultBot{replyInThread: tt.replyInThread}
+		dest := bot.replyTo(tt.input)
+		if !reflect.DeepEqual(dest, tt.expected) {
+			t.Errorf("Unexpected destination is returned on test #%d: %#v.", i, dest)
+		}
+	}
+}
+
 func TestNewSuppressedResponseWithNext(t *testing.T) {
 	nextFunc := func(_ context.Context, input Input) (*CommandResponse, error) {
 		return nil, nil
@@ -521,3 +1364,289 @@ func TestNewSuppressedResponseWithNext(t *testing.T) {
 		t.Errorf("Unexpected ContextualFunc is set %T.", res.UserContext.Next)
 	}
 }
+
+func TestDefaultBot_Respond_WithStreamContent(t *testing.T) {
+	contents := make(chan interface{}, 2)
+	contents <- "foo"
+	contents <- "bar"
+	close(contents)
+
+	command := &DummyCommand{
+		MatchFunc: func(_ Input) bool {
+			return true
+		},
+		ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return &CommandResponse{
+				Content: NewStreamContent(contents, StreamWithInterval(0)),
+			}, nil
+		},
+	}
+
+	var sent []Output
+	myBot := &defaultBot{
+		commands: &Commands{collection: []Command{command}},
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent = append(sent, output)
+			return nil, nil
+		},
+	}
+
+	input := &DummyInput{ReplyToValue: "replyTo"}
+	err := myBot.Respond(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("Unexpected number of messages are sent: %d.", len(sent))
+	}
+
+	for _, output := range sent {
+		if output.Destination() != input.ReplyToValue {
+			t.Errorf("Unexpected destination is set: %#v.", output.Destination())
+		}
+	}
+}
+
+func TestDefaultBot_Respond_WithStreamContentInPlace(t *testing.T) {
+	contents := make(chan interface{}, 3)
+	contents <- "foo"
+	contents <- "bar"
+	contents <- "baz"
+	close(contents)
+
+	command := &DummyCommand{
+		MatchFunc: func(_ Input) bool {
+			return true
+		},
+		ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return &CommandResponse{
+				Content: NewStreamContent(contents, StreamWithInterval(0), StreamInPlace()),
+			}, nil
+		},
+	}
+
+	var sent []interface{}
+	var updated []interface{}
+	handle := &MessageHandle{Destination: "replyTo", MessageID: "id"}
+	myBot := &defaultBot{
+		commands: &Commands{collection: []Command{command}},
+		editor: &DummyMessageEditor{
+			SendMessageWithHandleFunc: func(_ context.Context, output Output) (*MessageHandle, error) {
+				sent = append(sent, output.Content())
+				return handle, nil
+			},
+			UpdateMessageFunc: func(_ context.Context, h *MessageHandle, content interface{}) error {
+				if h != handle {
+					t.Errorf("Unexpected handle is given: %#v.", h)
+				}
+				updated = append(updated, content)
+				if content == "baz" {
+					return errors.New("update error")
+				}
+				return nil
+			},
+		},
+	}
+
+	err := myBot.Respond(context.TODO(), &DummyInput{ReplyToValue: "replyTo"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The content that failed to be updated is sent as a new message.
+	if !reflect.DeepEqual(sent, []interface{}{"foo", "baz"}) {
+		t.Errorf("Unexpected messages are sent: %#v.", sent)
+	}
+
+	if !reflect.DeepEqual(updated, []interface{}{"bar", "baz"}) {
+		t.Errorf("Unexpected messages are updated: %#v.", updated)
+	}
+}
+
+func TestDefaultBot_MessageEditor(t *testing.T) {
+	t.Run("Not supported", func(t *testing.T) {
+		myBot := &defaultBot{}
+		handle := &MessageHandle{Destination: "dest", MessageID: "id"}
+
+		_, err := myBot.SendMessageWithHandle(context.TODO(), NewOutputMessage("dest", "text"))
+		if err != ErrMessageEditingNotSupported {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		err = myBot.UpdateMessage(context.TODO(), handle, "text")
+		if err != ErrMessageEditingNotSupported {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		err = myBot.DeleteMessage(context.TODO(), handle)
+		if err != ErrMessageEditingNotSupported {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("Supported", func(t *testing.T) {
+		handle := &MessageHandle{Destination: "dest", MessageID: "id"}
+		var updated interface{}
+		var deleted *MessageHandle
+		myBot := &defaultBot{
+			editor: &DummyMessageEditor{
+				SendMessageWithHandleFunc: func(_ context.Context, _ Output) (*MessageHandle, error) {
+					return handle, nil
+				},
+				UpdateMessageFunc: func(_ context.Context, _ *MessageHandle, content interface{}) error {
+					updated = content
+					return nil
+				},
+				DeleteMessageFunc: func(_ context.Context, h *MessageHandle) error {
+					deleted = h
+					return nil
+				},
+			},
+		}
+
+		h, err := myBot.SendMessageWithHandle(context.TODO(), NewOutputMessage("dest", "text"))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if h != handle {
+			t.Errorf("Unexpected handle is returned: %#v.", h)
+		}
+
+		err = myBot.UpdateMessage(context.TODO(), handle, "updated")
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+		if updated != "updated" {
+			t.Errorf("Unexpected content is given: %#v.", updated)
+		}
+
+		err = myBot.DeleteMessage(context.TODO(), handle)
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+		if deleted != handle {
+			t.Errorf("Unexpected handle is given: %#v.", deleted)
+		}
+	})
+}
+
+type DummySenderIDInput struct {
+	DummyInput
+	SenderIDValue      string
+	DirectMessageValue bool
+}
+
+func (i *DummySenderIDInput) SenderID() string {
+	return i.SenderIDValue
+}
+
+func (i *DummySenderIDInput) IsDirectMessage() bool {
+	return i.DirectMessageValue
+}
+
+func TestDefaultBot_Respond_WithPrivateResponse(t *testing.T) {
+	tests := []struct {
+		input    Input
+		expected OutputDestination
+		err      error
+	}{
+		{
+			input:    &DummySenderIDInput{DummyInput: DummyInput{ReplyToValue: "channel"}, SenderIDValue: "user"},
+			expected: NewPrivateDestination("channel", "user"),
+		},
+		{
+			input:    &DummySenderIDInput{DummyInput: DummyInput{ReplyToValue: "dm"}, SenderIDValue: "user", DirectMessageValue: true},
+			expected: "dm",
+		},
+		{
+			input: &DummyInput{ReplyToValue: "channel"},
+			err:   ErrSenderNotIdentified,
+		},
+	}
+
+	for i, tt := range tests {
+		command := &DummyCommand{
+			MatchFunc: func(_ Input) bool {
+				return true
+			},
+			ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+				return &CommandResponse{Content: "secret", Private: true}, nil
+			},
+		}
+
+		var sent Output
+		myBot := &defaultBot{
+			commands: &Commands{collection: []Command{command}},
+			sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+				sent = output
+				return nil, nil
+			},
+		}
+
+		err := myBot.Respond(context.TODO(), tt.input)
+		if tt.err != nil {
+			if err != tt.err {
+				t.Errorf("Expected error is not returned on test #%d: %#v.", i, err)
+			}
+			if sent != nil {
+				t.Errorf("Message must not be sent on test #%d: %#v.", i, sent)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+
+		if !reflect.DeepEqual(sent.Destination(), tt.expected) {
+			t.Errorf("Unexpected destination is set on test #%d: %#v.", i, sent.Destination())
+		}
+	}
+}
+
+func TestDefaultBot_inPlaceSender_NotSupported(t *testing.T) {
+	var sent []interface{}
+	myBot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent = append(sent, output.Content())
+			return nil, nil
+		},
+		editor: &DummyMessageEditor{
+			SendMessageWithHandleFunc: func(_ context.Context, _ Output) (*MessageHandle, error) {
+				return nil, ErrMessageEditingNotSupported
+			},
+		},
+	}
+
+	send := myBot.inPlaceSender(context.TODO(), "dest")
+	send("foo")
+	send("bar")
+
+	if !reflect.DeepEqual(sent, []interface{}{"foo", "bar"}) {
+		t.Errorf("Unexpected messages are sent: %#v.", sent)
+	}
+}
+
+func TestBotWithInstanceID(t *testing.T) {
+	adapter := &DummyAdapter{
+		BotTypeValue: "slack",
+	}
+
+	bot := NewBot(adapter, BotWithInstanceID("workspace-a"))
+
+	if bot.BotType() != "slack/workspace-a" {
+		t.Errorf("Unexpected BotType is returned: %s.", bot.BotType())
+	}
+}
+
+func TestBotWithCommandPrefixIndex(t *testing.T) {
+	bot := &defaultBot{commands: NewCommands()}
+
+	BotWithCommandPrefixIndex()(bot)
+
+	if !bot.commands.prefixIndexed {
+		t.Error("Option is not applied.")
+	}
+}
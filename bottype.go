@@ -1,9 +1,58 @@
 package sarah
 
+import (
+	"context"
+	"strings"
+)
+
 // BotType indicates what bot implementation a particular Bot/Plugin is corresponding to.
+//
+// When multiple Bots of the same type run in one process, e.g. to serve two Slack workspaces, each Bot is distinguished by an instance ID.
+// Such a Bot's BotType is formed as "<BotType>/<instance ID>" such as "slack/workspace-a", and BotType.Instance returns one.
 type BotType string
 
+// instanceSeparator separates the BotType and the instance ID in an instance-scoped BotType.
+const instanceSeparator = "/"
+
 // String returns a stringified form of BotType
 func (botType BotType) String() string {
 	return string(botType)
 }
+
+// Instance returns a BotType that identifies the Bot instance with the given ID, e.g. "slack/workspace-a" for SLACK.Instance("workspace-a").
+// Pass the returned BotType to RegisterCommand, RegisterScheduledTask or CommandPropsBuilder.BotType to scope the registration to the Bot created with BotWithInstanceID.
+func (botType BotType) Instance(id string) BotType {
+	return BotType(string(botType.Base()) + instanceSeparator + id)
+}
+
+// Base returns the BotType without the instance ID. A BotType without an instance ID is returned as-is.
+func (botType BotType) Base() BotType {
+	if i := strings.Index(string(botType), instanceSeparator); i >= 0 {
+		return botType[:i]
+	}
+	return botType
+}
+
+// InstanceID returns the instance ID, or an empty string when the BotType has none.
+func (botType BotType) InstanceID() string {
+	if i := strings.Index(string(botType), instanceSeparator); i >= 0 {
+		return string(botType[i+1:])
+	}
+	return ""
+}
+
+type botTypeKey struct{}
+
+// WithBotType returns a copy of the given context that carries the given BotType.
+// go-sarah's core passes a context with the running Bot's BotType to Bot.Run(), so this is rarely needed except in tests.
+func WithBotType(ctx context.Context, botType BotType) context.Context {
+	return context.WithValue(ctx, botTypeKey{}, botType)
+}
+
+// BotTypeFromContext returns the BotType carried by the given context.
+// An Adapter can tell the BotType of the running Bot including the instance ID given with BotWithInstanceID.
+// An empty BotType is returned when the context carries none.
+func BotTypeFromContext(ctx context.Context) BotType {
+	botType, _ := ctx.Value(botTypeKey{}).(BotType)
+	return botType
+}
@@ -1,6 +1,9 @@
 package sarah
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestBotType_String(t *testing.T) {
 	var BAR BotType = "myNewBotType"
@@ -8,3 +11,39 @@ func TestBotType_String(t *testing.T) {
 		t.Errorf("Expected BotType was 'myNewBotType,' but was %s", BAR.String())
 	}
 }
+
+func TestBotType_Instance(t *testing.T) {
+	var botType BotType = "slack"
+
+	instance := botType.Instance("workspace-a")
+	if instance != "slack/workspace-a" {
+		t.Errorf("Unexpected BotType is returned: %s.", instance)
+	}
+
+	if instance.Base() != botType {
+		t.Errorf("Unexpected base BotType is returned: %s.", instance.Base())
+	}
+
+	if instance.InstanceID() != "workspace-a" {
+		t.Errorf("Unexpected instance ID is returned: %s.", instance.InstanceID())
+	}
+
+	if instance.Instance("workspace-b") != "slack/workspace-b" {
+		t.Errorf("Instance ID is not replaced: %s.", instance.Instance("workspace-b"))
+	}
+
+	if botType.Base() != botType || botType.InstanceID() != "" {
+		t.Errorf("BotType without instance ID is not handled: %s, %s.", botType.Base(), botType.InstanceID())
+	}
+}
+
+func TestBotTypeFromContext(t *testing.T) {
+	if botType := BotTypeFromContext(context.TODO()); botType != "" {
+		t.Errorf("Unexpected BotType is returned: %s.", botType)
+	}
+
+	ctx := WithBotType(context.TODO(), "slack/workspace-a")
+	if botType := BotTypeFromContext(ctx); botType != "slack/workspace-a" {
+		t.Errorf("Unexpected BotType is returned: %s.", botType)
+	}
+}
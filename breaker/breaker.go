@@ -0,0 +1,270 @@
+/*
+Package breaker provides a circuit breaker implementation to protect a caller from a failing downstream service.
+
+When a remote API is down, each call may take a long time til it times out.
+Breaker counts the consecutive failures and, once the count reaches the threshold, fails the succeeding calls immediately with ErrOpen
+so the caller can give up quickly instead of waiting for the timeout.
+After Config.ResetTimeout passes, Breaker lets a trial call through to see if the downstream service is back.
+*/
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned when the circuit is open and the call is not executed.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State represents the state of the circuit.
+type State int
+
+const (
+	// StateClosed lets every call through while counting the consecutive failures.
+	StateClosed State = iota
+
+	// StateOpen rejects every call with ErrOpen til Config.ResetTimeout passes.
+	StateOpen
+
+	// StateHalfOpen lets a single trial call through.
+	// The circuit is closed when Config.SuccessThreshold trials succeed in a row; is opened again when a trial fails.
+	StateHalfOpen
+)
+
+// String returns a stringified form of the State.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+
+	case StateOpen:
+		return "open"
+
+	case StateHalfOpen:
+		return "half-open"
+
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+
+	}
+}
+
+// Config contains some configuration variables for Breaker.
+type Config struct {
+	FailureThreshold uint          `json:"failure_threshold" yaml:"failure_threshold"`
+	SuccessThreshold uint          `json:"success_threshold" yaml:"success_threshold"`
+	ResetTimeout     time.Duration `json:"reset_timeout" yaml:"reset_timeout"`
+}
+
+// NewConfig returns a Config instance with default configuration values.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewConfig() *Config {
+	return &Config{
+		FailureThreshold: 5,
+		SuccessThreshold: 1,
+		ResetTimeout:     30 * time.Second,
+	}
+}
+
+// Validate checks the configuration values and returns an error that describes all invalid values.
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.FailureThreshold == 0 {
+		errs = append(errs, "failure_threshold must be greater than zero")
+	}
+
+	if c.SuccessThreshold == 0 {
+		errs = append(errs, "success_threshold must be greater than zero")
+	}
+
+	if c.ResetTimeout <= 0 {
+		errs = append(errs, "reset_timeout must be greater than zero")
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// BreakerOption defines a function signature that NewBreaker's functional option must satisfy.
+type BreakerOption func(*Breaker)
+
+// WithStateChangeCallback creates a BreakerOption that registers a function to be called on every state change.
+// This is handy to log the state change or to alert the administrators.
+// The function is called while Breaker is locked, so calling Breaker's methods in the function results in a deadlock.
+func WithStateChangeCallback(fnc func(from State, to State)) BreakerOption {
+	return func(b *Breaker) {
+		b.onStateChange = fnc
+	}
+}
+
+// WithFailurePredicate creates a BreakerOption that replaces the default judgement of a failure.
+// By default, any non-nil error except context.Canceled is counted as a failure.
+// Use this to exclude errors that do not indicate the downstream service's health such as an authentication error.
+func WithFailurePredicate(fnc func(error) bool) BreakerOption {
+	return func(b *Breaker) {
+		b.isFailure = fnc
+	}
+}
+
+// WithClock creates a BreakerOption that replaces the clock.Clock to measure Config.ResetTimeout.
+// Pass clock.Fake in a test to let the open circuit reset without waiting.
+func WithClock(c clock.Clock) BreakerOption {
+	return func(b *Breaker) {
+		b.now = c.Now
+	}
+}
+
+// Breaker is a circuit breaker.
+// Calls to its methods are thread-safe.
+type Breaker struct {
+	config        *Config
+	mutex         sync.Mutex
+	state         State
+	failures      uint
+	successes     uint
+	openedAt      time.Time
+	trying        bool
+	onStateChange func(State, State)
+	isFailure     func(error) bool
+	now           func() time.Time
+}
+
+// NewBreaker creates and returns a new Breaker instance with the closed state.
+func NewBreaker(config *Config, options ...BreakerOption) *Breaker {
+	b := &Breaker{
+		config: config,
+		state:  StateClosed,
+		isFailure: func(err error) bool {
+			return err != nil && !errors.Is(err, context.Canceled)
+		},
+		now: time.Now,
+	}
+
+	for _, opt := range options {
+		opt(b)
+	}
+
+	return b
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.currentState()
+}
+
+// Execute calls the given function when the circuit allows and records the result.
+// When the circuit is open, ErrOpen is returned without calling the function.
+// The error returned by the function is returned as-is.
+func (b *Breaker) Execute(fnc func() error) error {
+	err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fnc()
+	b.record(err)
+	return err
+}
+
+func (b *Breaker) allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.currentState() {
+	case StateOpen:
+		return ErrOpen
+
+	case StateHalfOpen:
+		if b.trying {
+			// Only one trial is allowed at a time.
+			return ErrOpen
+		}
+		b.setState(StateHalfOpen)
+		b.trying = true
+		return nil
+
+	default:
+		return nil
+
+	}
+}
+
+func (b *Breaker) record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	failed := b.isFailure(err)
+	switch b.state {
+	case StateHalfOpen:
+		b.trying = false
+		if failed {
+			b.open()
+			return
+		}
+
+		b.successes++
+		if b.successes >= b.config.SuccessThreshold {
+			b.close()
+		}
+
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.open()
+		}
+
+	default:
+		// The result of a call that started before the circuit was opened is ignored.
+
+	}
+}
+
+// currentState returns the state with the reset timeout taken into account.
+// The caller must hold the lock.
+func (b *Breaker) currentState() State {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.config.ResetTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.failures = 0
+	b.successes = 0
+	b.setState(StateOpen)
+}
+
+func (b *Breaker) close() {
+	b.failures = 0
+	b.successes = 0
+	b.setState(StateClosed)
+}
+
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+	if b.onStateChange != nil {
+		b.onStateChange(from, state)
+	}
+}
@@ -0,0 +1,209 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config == nil {
+		t.Fatal("Expected *Config is not returned.")
+	}
+
+	if config.FailureThreshold == 0 {
+		t.Error("Default FailureThreshold is not set.")
+	}
+
+	if config.ResetTimeout == 0 {
+		t.Error("Default ResetTimeout is not set.")
+	}
+}
+
+func TestState_String(t *testing.T) {
+	tests := []struct {
+		state    State
+		expected string
+	}{
+		{state: StateClosed, expected: "closed"},
+		{state: StateOpen, expected: "open"},
+		{state: StateHalfOpen, expected: "half-open"},
+		{state: State(100), expected: "unknown(100)"},
+	}
+
+	for _, tt := range tests {
+		if tt.state.String() != tt.expected {
+			t.Errorf("Unexpected string is returned: %s.", tt.state.String())
+		}
+	}
+}
+
+func TestWithStateChangeCallback(t *testing.T) {
+	b := &Breaker{}
+	WithStateChangeCallback(func(_ State, _ State) {})(b)
+
+	if b.onStateChange == nil {
+		t.Error("Callback is not set.")
+	}
+}
+
+func TestWithFailurePredicate(t *testing.T) {
+	b := &Breaker{}
+	WithFailurePredicate(func(_ error) bool { return true })(b)
+
+	if b.isFailure == nil || !b.isFailure(nil) {
+		t.Error("Given predicate is not set.")
+	}
+}
+
+func TestNewBreaker(t *testing.T) {
+	config := NewConfig()
+	b := NewBreaker(config)
+
+	if b.config != config {
+		t.Errorf("Given config is not set: %#v.", b.config)
+	}
+
+	if b.State() != StateClosed {
+		t.Errorf("Unexpected initial state: %s.", b.State())
+	}
+
+	if b.isFailure(context.Canceled) {
+		t.Error("Context cancellation should not be treated as a failure by default.")
+	}
+}
+
+func TestBreaker_Execute(t *testing.T) {
+	now := time.Now()
+	var transitions []State
+	b := NewBreaker(
+		&Config{FailureThreshold: 2, SuccessThreshold: 1, ResetTimeout: 10 * time.Second},
+		WithStateChangeCallback(func(_ State, to State) {
+			transitions = append(transitions, to)
+		}),
+	)
+	b.now = func() time.Time {
+		return now
+	}
+
+	failure := errors.New("failure")
+	fail := func() error { return failure }
+	succeed := func() error { return nil }
+
+	// A success resets the failure count.
+	_ = b.Execute(fail)
+	_ = b.Execute(succeed)
+	_ = b.Execute(fail)
+	if b.State() != StateClosed {
+		t.Fatalf("Unexpected state: %s.", b.State())
+	}
+
+	err := b.Execute(fail)
+	if err != failure {
+		t.Errorf("Error returned by the function should be returned as-is: %#v.", err)
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("Unexpected state: %s.", b.State())
+	}
+
+	called := false
+	err = b.Execute(func() error {
+		called = true
+		return nil
+	})
+	if err != ErrOpen {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+	if called {
+		t.Error("Function is called while the circuit is open.")
+	}
+
+	// Reset timeout passes.
+	now = now.Add(10 * time.Second)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("Unexpected state: %s.", b.State())
+	}
+
+	// A failed trial opens the circuit again.
+	_ = b.Execute(fail)
+	if b.State() != StateOpen {
+		t.Fatalf("Unexpected state: %s.", b.State())
+	}
+
+	now = now.Add(10 * time.Second)
+	err = b.Execute(succeed)
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("Unexpected state: %s.", b.State())
+	}
+
+	expected := []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}
+	if len(transitions) != len(expected) {
+		t.Fatalf("Unexpected transitions: %v.", transitions)
+	}
+	for i, e := range expected {
+		if transitions[i] != e {
+			t.Errorf("Unexpected transitions: %v.", transitions)
+		}
+	}
+}
+
+func TestBreaker_Execute_SingleTrial(t *testing.T) {
+	b := NewBreaker(&Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: 0})
+	_ = b.Execute(func() error { return errors.New("failure") })
+
+	trying := make(chan struct{})
+	finish := make(chan struct{})
+	go func() {
+		_ = b.Execute(func() error {
+			close(trying)
+			<-finish
+			return nil
+		})
+	}()
+	<-trying
+
+	err := b.Execute(func() error { return nil })
+	if err != ErrOpen {
+		t.Errorf("Concurrent trial should be rejected: %#v.", err)
+	}
+	close(finish)
+}
+
+func TestWithClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	b := NewBreaker(&Config{FailureThreshold: 1, SuccessThreshold: 1, ResetTimeout: 10 * time.Second}, WithClock(fake))
+
+	_ = b.Execute(func() error { return errors.New("failure") })
+	if b.State() != StateOpen {
+		t.Fatalf("Unexpected state: %s.", b.State())
+	}
+
+	fake.Advance(10 * time.Second)
+	if b.State() != StateHalfOpen {
+		t.Errorf("Circuit is not reset with the given clock: %s.", b.State())
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := NewConfig().Validate(); err != nil {
+		t.Errorf("Unexpected error is returned for the default config: %s.", err.Error())
+	}
+
+	err := (&Config{}).Validate()
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	for _, key := range []string{"failure_threshold", "success_threshold", "reset_timeout"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Error does not describe %s: %s.", key, err.Error())
+		}
+	}
+}
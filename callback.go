@@ -0,0 +1,104 @@
+package sarah
+
+import (
+	"context"
+	"time"
+)
+
+// CallbackInput is an Input that an Adapter passes to go-sarah's core when a user interacts with an interactive component
+// such as a RichButton or a RichMenu with a callback ID.
+// The input is routed to the Command built by NewCallbackCommand with the same callback ID.
+type CallbackInput struct {
+	// CallbackID is the callback ID of the component that the user interacted with.
+	CallbackID string
+
+	// Value is the value of the clicked button or the selected option.
+	Value string
+
+	// Event is the chat service specific payload that the Adapter received.
+	Event interface{}
+
+	senderKey string
+	sentAt    time.Time
+	replyTo   OutputDestination
+}
+
+var _ Input = (*CallbackInput)(nil)
+
+// NewCallbackInput creates and returns a new CallbackInput.
+// An Adapter that supports interactive components calls this on a user interaction and passes the input to go-sarah's core.
+func NewCallbackInput(callbackID string, value string, senderKey string, sentAt time.Time, replyTo OutputDestination) *CallbackInput {
+	return &CallbackInput{
+		CallbackID: callbackID,
+		Value:      value,
+		senderKey:  senderKey,
+		sentAt:     sentAt,
+		replyTo:    replyTo,
+	}
+}
+
+// SenderKey returns a string representing the user who interacted with the component.
+func (ci *CallbackInput) SenderKey() string {
+	return ci.senderKey
+}
+
+// Message returns an empty string since the interaction is not a text message.
+// This prevents a Command that matches against a text from handling the interaction by mistake.
+func (ci *CallbackInput) Message() string {
+	return ""
+}
+
+// SentAt returns the time when the user interacted with the component.
+func (ci *CallbackInput) SentAt() time.Time {
+	return ci.sentAt
+}
+
+// ReplyTo returns the destination of the response to the interaction.
+func (ci *CallbackInput) ReplyTo() OutputDestination {
+	return ci.replyTo
+}
+
+// CallbackFunc defines a function signature that handles a CallbackInput.
+type CallbackFunc func(context.Context, *CallbackInput) (*CommandResponse, error)
+
+type callbackCommand struct {
+	callbackID string
+	fnc        CallbackFunc
+}
+
+var _ Command = (*callbackCommand)(nil)
+
+// NewCallbackCommand creates and returns a Command that handles the CallbackInput with the given callback ID.
+// Register the returned Command with RegisterCommand along with the Command that sends the interactive component:
+//
+//  sarah.RegisterCommand(slack.SLACK, sarah.NewCallbackCommand("deploy_confirm", func(_ context.Context, input *sarah.CallbackInput) (*sarah.CommandResponse, error) {
+//  	if input.Value != "yes" {
+//  		return &sarah.CommandResponse{Content: "Canceled."}, nil
+//  	}
+//  	return &sarah.CommandResponse{Content: "Deploying..."}, nil
+//  }))
+//
+// The Command is not listed in the help since this is not meant to be called with a text message.
+func NewCallbackCommand(callbackID string, fnc CallbackFunc) Command {
+	return &callbackCommand{
+		callbackID: callbackID,
+		fnc:        fnc,
+	}
+}
+
+func (c *callbackCommand) Identifier() string {
+	return "callback:" + c.callbackID
+}
+
+func (c *callbackCommand) Execute(ctx context.Context, input Input) (*CommandResponse, error) {
+	return c.fnc(ctx, input.(*CallbackInput))
+}
+
+func (c *callbackCommand) Instruction(_ *HelpInput) string {
+	return ""
+}
+
+func (c *callbackCommand) Match(input Input) bool {
+	callback, ok := input.(*CallbackInput)
+	return ok && callback.CallbackID == c.callbackID
+}
@@ -0,0 +1,90 @@
+package sarah
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewCallbackInput(t *testing.T) {
+	sentAt := time.Now()
+	destination := "C123"
+	input := NewCallbackInput("deploy", "yes", "U123", sentAt, destination)
+
+	if input.CallbackID != "deploy" {
+		t.Errorf("Unexpected callback ID is set: %s.", input.CallbackID)
+	}
+
+	if input.Value != "yes" {
+		t.Errorf("Unexpected value is set: %s.", input.Value)
+	}
+
+	if input.SenderKey() != "U123" {
+		t.Errorf("Unexpected sender key is returned: %s.", input.SenderKey())
+	}
+
+	if input.Message() != "" {
+		t.Errorf("Message must be empty: %s.", input.Message())
+	}
+
+	if !input.SentAt().Equal(sentAt) {
+		t.Errorf("Unexpected time is returned: %s.", input.SentAt())
+	}
+
+	if input.ReplyTo() != destination {
+		t.Errorf("Unexpected destination is returned: %#v.", input.ReplyTo())
+	}
+}
+
+func TestNewCallbackCommand(t *testing.T) {
+	fnc := func(_ context.Context, input *CallbackInput) (*CommandResponse, error) {
+		return &CommandResponse{Content: input.Value}, nil
+	}
+	command := NewCallbackCommand("deploy", fnc)
+
+	if command.Identifier() != "callback:deploy" {
+		t.Errorf("Unexpected identifier is returned: %s.", command.Identifier())
+	}
+
+	if command.Instruction(&HelpInput{}) != "" {
+		t.Errorf("Instruction must be empty: %s.", command.Instruction(&HelpInput{}))
+	}
+
+	res, err := command.Execute(context.TODO(), NewCallbackInput("deploy", "yes", "U123", time.Now(), "C123"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res.Content != "yes" {
+		t.Errorf("Unexpected content is returned: %#v.", res.Content)
+	}
+}
+
+func Test_callbackCommand_Match(t *testing.T) {
+	tests := []struct {
+		input   Input
+		matched bool
+	}{
+		{
+			input:   NewCallbackInput("deploy", "yes", "U123", time.Now(), "C123"),
+			matched: true,
+		},
+		{
+			input:   NewCallbackInput("other", "yes", "U123", time.Now(), "C123"),
+			matched: false,
+		},
+		{
+			input:   &DummyInput{MessageValue: "deploy"},
+			matched: false,
+		},
+	}
+
+	command := NewCallbackCommand("deploy", func(_ context.Context, _ *CallbackInput) (*CommandResponse, error) {
+		return nil, nil
+	})
+	for i, tt := range tests {
+		if matched := command.Match(tt.input); matched != tt.matched {
+			t.Errorf("Unexpected result is returned on test #%d: %t.", i, matched)
+		}
+	}
+}
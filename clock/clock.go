@@ -0,0 +1,98 @@
+/*
+Package clock provides an abstraction of the current time and timers so time-dependent logic can be tested without sleeping.
+
+go-sarah's scheduler, delayed messages, retrials and TTL-based storages refer to Clock instead of the time package.
+Production code uses the Clock returned by Real, which simply delegates to the time package.
+Tests can pass Fake via sarah.Config.Clock or WithContext and advance the virtual time deterministically:
+
+	fake := clock.NewFake(time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC))
+	config := sarah.NewConfig()
+	config.Clock = fake
+	go sarah.Run(ctx, config)
+
+	// Let the scheduled task run without waiting for an hour.
+	fake.Advance(1 * time.Hour)
+*/
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the current time and creates timers.
+// Implementations must be safe for concurrent use.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a new Timer that sends the current time on its channel after at least the given duration.
+	NewTimer(d time.Duration) Timer
+
+	// AfterFunc waits for the given duration to elapse and then calls the given function.
+	// The returned Timer's channel is nil.
+	AfterFunc(d time.Duration, fnc func()) Timer
+}
+
+// Timer represents a single event just like time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing.
+	// This returns false when the Timer has already expired or been stopped.
+	Stop() bool
+}
+
+// Real returns a Clock that delegates to the time package.
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+var _ Clock = realClock{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, fnc func()) Timer {
+	return &realTimer{timer: time.AfterFunc(d, fnc)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+var _ Timer = (*realTimer)(nil)
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+type clockKey struct{}
+
+// WithContext returns a copy of the given context that carries the given Clock.
+// go-sarah's core passes a context with sarah.Config.Clock to each Bot, Command and ScheduledTask.
+func WithContext(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// FromContext returns the Clock carried by the given context.
+// The Clock returned by Real is returned when none is carried.
+func FromContext(ctx context.Context) Clock {
+	c, ok := ctx.Value(clockKey{}).(Clock)
+	if !ok || c == nil {
+		return Real()
+	}
+	return c
+}
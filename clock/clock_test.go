@@ -0,0 +1,56 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReal(t *testing.T) {
+	c := Real()
+
+	before := time.Now()
+	now := c.Now()
+	if now.Before(before) {
+		t.Errorf("Unexpected time is returned: %s.", now)
+	}
+
+	timer := c.NewTimer(10 * time.Millisecond)
+	select {
+	case <-timer.C():
+		// O.K.
+
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timer does not fire.")
+
+	}
+
+	called := make(chan struct{})
+	c.AfterFunc(10*time.Millisecond, func() {
+		close(called)
+	})
+	select {
+	case <-called:
+		// O.K.
+
+	case <-time.After(3 * time.Second):
+		t.Fatal("Function is not called.")
+
+	}
+
+	if c.NewTimer(time.Hour).Stop() != true {
+		t.Error("Pending timer is not stopped.")
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()).(realClock); !ok {
+		t.Error("Real clock must be returned when none is carried.")
+	}
+
+	fake := NewFake(time.Now())
+	ctx := WithContext(context.Background(), fake)
+	if FromContext(ctx) != fake {
+		t.Error("Carried clock is not returned.")
+	}
+}
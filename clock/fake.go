@@ -0,0 +1,197 @@
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when Advance or Set is called.
+// Use NewFake to construct one.
+//
+// A timer fires when the virtual time reaches its deadline.
+// A function given to AfterFunc is called synchronously from Advance or Set so the test can check its result right after the call.
+// A timer whose deadline is already reached on its creation fires immediately; the function given to AfterFunc then runs in a new goroutine.
+type Fake struct {
+	mutex   sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	changed chan struct{}
+}
+
+var _ Clock = (*Fake)(nil)
+
+// NewFake creates a new Fake that starts at the given time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{
+		now:     now,
+		changed: make(chan struct{}),
+	}
+}
+
+// Now returns the virtual time.
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.now
+}
+
+// NewTimer creates a new Timer that fires when the virtual time reaches the deadline.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.addTimer(d, nil)
+}
+
+// AfterFunc creates a new Timer that calls the given function when the virtual time reaches the deadline.
+func (f *Fake) AfterFunc(d time.Duration, fnc func()) Timer {
+	return f.addTimer(d, fnc)
+}
+
+// Advance moves the virtual time forward by the given duration and fires the timers that reach their deadlines in the order of the deadlines.
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	now := f.now.Add(d)
+	f.mutex.Unlock()
+
+	f.Set(now)
+}
+
+// Set moves the virtual time to the given time and fires the timers that reach their deadlines in the order of the deadlines.
+// Moving the time backward fires nothing.
+func (f *Fake) Set(now time.Time) {
+	f.mutex.Lock()
+	f.now = now
+
+	var expired []*fakeTimer
+	var pending []*fakeTimer
+	for _, timer := range f.timers {
+		if timer.deadline.After(now) {
+			pending = append(pending, timer)
+		} else {
+			expired = append(expired, timer)
+		}
+	}
+	f.timers = pending
+	f.notify()
+	f.mutex.Unlock()
+
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].deadline.Before(expired[j].deadline)
+	})
+	for _, timer := range expired {
+		timer.fire(now)
+	}
+}
+
+// Timers returns the number of the timers that are not yet fired nor stopped.
+func (f *Fake) Timers() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return len(f.timers)
+}
+
+// WaitTimers blocks til the number of the pending timers reaches the given number or the given context is canceled.
+// Call this before Advance to make sure the code under test is waiting for the virtual time to pass.
+func (f *Fake) WaitTimers(ctx context.Context, count int) error {
+	for {
+		f.mutex.Lock()
+		pending := len(f.timers)
+		changed := f.changed
+		f.mutex.Unlock()
+
+		if pending >= count {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-changed:
+			// Check again.
+
+		}
+	}
+}
+
+func (f *Fake) addTimer(d time.Duration, fnc func()) *fakeTimer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	timer := &fakeTimer{
+		clock:    f,
+		deadline: f.now.Add(d),
+		fnc:      fnc,
+	}
+	if fnc == nil {
+		timer.c = make(chan time.Time, 1)
+	}
+
+	if d <= 0 {
+		if fnc == nil {
+			timer.c <- f.now
+		} else {
+			go fnc()
+		}
+		return timer
+	}
+
+	f.timers = append(f.timers, timer)
+	f.notify()
+	return timer
+}
+
+func (f *Fake) removeTimer(timer *fakeTimer) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for i, t := range f.timers {
+		if t == timer {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			f.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// notify wakes up the goroutines in WaitTimers. This must be called while f.mutex is locked.
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	deadline time.Time
+	c        chan time.Time
+	fnc      func()
+}
+
+var _ Timer = (*fakeTimer)(nil)
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.clock.removeTimer(t)
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	if t.fnc != nil {
+		t.fnc()
+		return
+	}
+
+	select {
+	case t.c <- now:
+		// Sent.
+
+	default:
+		// The buffered value is not yet received.
+
+	}
+}
@@ -0,0 +1,114 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFake_Advance(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	var called []string
+	fake.AfterFunc(2*time.Second, func() {
+		called = append(called, "second")
+	})
+	fake.AfterFunc(1*time.Second, func() {
+		called = append(called, "first")
+	})
+	timer := fake.NewTimer(3 * time.Second)
+
+	if fake.Timers() != 3 {
+		t.Fatalf("Unexpected number of timers: %d.", fake.Timers())
+	}
+
+	fake.Advance(2 * time.Second)
+	if !fake.Now().Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Unexpected time is returned: %s.", fake.Now())
+	}
+	if len(called) != 2 || called[0] != "first" || called[1] != "second" {
+		t.Errorf("Functions are not called in the order of deadlines: %#v.", called)
+	}
+
+	select {
+	case <-timer.C():
+		t.Fatal("Timer fires before its deadline.")
+
+	default:
+		// O.K.
+
+	}
+
+	fake.Advance(1 * time.Second)
+	select {
+	case fired := <-timer.C():
+		if !fired.Equal(start.Add(3 * time.Second)) {
+			t.Errorf("Unexpected time is sent: %s.", fired)
+		}
+
+	default:
+		t.Fatal("Timer does not fire on its deadline.")
+
+	}
+
+	if fake.Timers() != 0 {
+		t.Errorf("Fired timers remain: %d.", fake.Timers())
+	}
+}
+
+func TestFake_Stop(t *testing.T) {
+	fake := NewFake(time.Now())
+	called := false
+	timer := fake.AfterFunc(time.Second, func() {
+		called = true
+	})
+
+	if !timer.Stop() {
+		t.Error("Pending timer is not stopped.")
+	}
+	if timer.Stop() {
+		t.Error("Stopped timer must not be stopped again.")
+	}
+
+	fake.Advance(time.Second)
+	if called {
+		t.Error("Stopped timer fires.")
+	}
+}
+
+func TestFake_NewTimer_Expired(t *testing.T) {
+	fake := NewFake(time.Now())
+	timer := fake.NewTimer(0)
+
+	select {
+	case <-timer.C():
+		// O.K.
+
+	default:
+		t.Fatal("Expired timer does not fire immediately.")
+
+	}
+}
+
+func TestFake_WaitTimers(t *testing.T) {
+	fake := NewFake(time.Now())
+
+	go func() {
+		<-fake.NewTimer(time.Second).C()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	err := fake.WaitTimers(ctx, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = fake.WaitTimers(canceled, 2)
+	if err != context.Canceled {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
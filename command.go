@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/oklahomer/go-kasumi/logger"
+	"github.com/oklahomer/go-sarah/v4/logging"
 	"reflect"
 	"regexp"
 	"strings"
@@ -21,6 +21,11 @@ var (
 type CommandResponse struct {
 	Content     interface{}
 	UserContext *UserContext
+
+	// Private tells the Bot to send the Content so that only the user who sent the Input can see it.
+	// Set this for a response with sensitive data such as tokens or personal schedules.
+	// The Content is sent with PrivateDestination, and is never sent when the sender can not be identified.
+	Private bool
 }
 
 // Command defines interface that all command MUST satisfy.
@@ -45,11 +50,14 @@ type commandConfigWrapper struct {
 }
 
 type defaultCommand struct {
-	identifier      string
-	matchFunc       func(Input) bool
-	instructionFunc func(*HelpInput) string
-	commandFunc     commandFunc
-	configWrapper   *commandConfigWrapper
+	identifier       string
+	matchFunc        func(Input) bool
+	instructionFunc  func(*HelpInput) string
+	commandFunc      commandFunc
+	configWrapper    *commandConfigWrapper
+	intent           string
+	concurrencyLimit *ConcurrencyLimit
+	pattern          *regexp.Regexp
 }
 
 func (command *defaultCommand) Identifier() string {
@@ -64,6 +72,18 @@ func (command *defaultCommand) Match(input Input) bool {
 	return command.matchFunc(input)
 }
 
+func (command *defaultCommand) IntentName() string {
+	return command.intent
+}
+
+func (command *defaultCommand) ConcurrencyLimit() *ConcurrencyLimit {
+	return command.concurrencyLimit
+}
+
+func (command *defaultCommand) MatchPattern() *regexp.Regexp {
+	return command.pattern
+}
+
 func (command *defaultCommand) Execute(ctx context.Context, input Input) (*CommandResponse, error) {
 	wrapper := command.configWrapper
 	if wrapper == nil {
@@ -77,14 +97,28 @@ func (command *defaultCommand) Execute(ctx context.Context, input Input) (*Comma
 	return command.commandFunc(ctx, input, wrapper.value)
 }
 
+// BuildCommand builds a Command from the given CommandProps just like Runner does on its start.
+// When the CommandProps is configurable, the configuration is read via the given ConfigWatcher.
+// When nil is given as the ConfigWatcher, the configuration value given to CommandPropsBuilder.ConfigurableFunc is used as-is.
+// This is handy to test a Command without running Runner.
+func BuildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatcher) (Command, error) {
+	if watcher == nil {
+		watcher = &nullConfigWatcher{}
+	}
+	return buildCommand(ctx, props, watcher)
+}
+
 func buildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatcher) (Command, error) {
 	if props.config == nil {
 		return &defaultCommand{
-			identifier:      props.identifier,
-			matchFunc:       props.matchFunc,
-			instructionFunc: props.instructionFunc,
-			commandFunc:     props.commandFunc,
-			configWrapper:   nil,
+			identifier:       props.identifier,
+			matchFunc:        props.matchFunc,
+			instructionFunc:  props.instructionFunc,
+			commandFunc:      props.commandFunc,
+			configWrapper:    nil,
+			intent:           props.intent,
+			concurrencyLimit: props.concurrencyLimit,
+			pattern:          props.pattern,
 		}, nil
 	}
 
@@ -98,7 +132,11 @@ func buildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatche
 
 		rv := reflect.ValueOf(cfg)
 		if rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Map {
-			return watcher.Read(ctx, props.botType, props.identifier, cfg)
+			e := watcher.Read(ctx, props.botType, props.identifier, cfg)
+			if e == nil {
+				e = validatePluginConfig(cfg)
+			}
+			return e
 		}
 
 		// https://groups.google.com/forum/#!topic/Golang-Nuts/KB3_Yj3Ny4c
@@ -111,6 +149,9 @@ func buildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatche
 
 		// Pass the pointer to the newly created instance.
 		e := watcher.Read(ctx, props.botType, props.identifier, n.Interface())
+		if e == nil {
+			e = validatePluginConfig(n.Interface())
+		}
 		if e == nil {
 			// Replace the current value with updated value.
 			cfg = n.Elem().Interface()
@@ -133,6 +174,9 @@ func buildCommand(ctx context.Context, props *CommandProps, watcher ConfigWatche
 			value: cfg,
 			mutex: locker,
 		},
+		intent:           props.intent,
+		concurrencyLimit: props.concurrencyLimit,
+		pattern:          props.pattern,
 	}, nil
 }
 
@@ -147,6 +191,15 @@ func StripMessage(pattern *regexp.Regexp, input string) string {
 type Commands struct {
 	collection []Command
 	mutex      sync.RWMutex
+
+	// matchers is the index of collection that FindFirstMatched walks through. This is built on the first lookup after Append.
+	matchers []*commandMatcher
+	indexed  sync.Once
+
+	// prefixIndexed tells to build trie. unindexed holds the positions in matchers of the Commands that trie can not hold.
+	prefixIndexed bool
+	trie          *prefixTrie
+	unindexed     []int
 }
 
 // NewCommands creates and returns new Commands instance.
@@ -163,17 +216,20 @@ func (commands *Commands) Append(command Command) {
 	commands.mutex.Lock()
 	defer commands.mutex.Unlock()
 
+	// Let the next lookup rebuild the index.
+	commands.indexed = sync.Once{}
+
 	// See if command with the same identifier exists.
 	for i, cmd := range commands.collection {
 		if cmd.Identifier() == command.Identifier() {
-			logger.Infof("Replace old command in favor of newly appending one: %s.", command.Identifier())
+			moduleLogger().Info("Replace old command in favor of newly appending one", logging.F(logging.KeyCommandID, command.Identifier()))
 			commands.collection[i] = command
 			return
 		}
 	}
 
 	// Not stored, then append to the last.
-	logger.Infof("Append new command: %s.", command.Identifier())
+	moduleLogger().Info("Append new command", logging.F(logging.KeyCommandID, command.Identifier()))
 	commands.collection = append(commands.collection, command)
 }
 
@@ -182,13 +238,25 @@ func (commands *Commands) Append(command Command) {
 //
 // This check is run in the order of Command registration: Earlier the Commands.Append is called, the command is checked
 // earlier. So register important Command first.
+//
+// A PatternCommand is skipped without calling its Match method when the Input's message does not contain the literal prefix of its pattern,
+// so the regular expressions run only for the likely candidates. This lookup does not allocate memory as long as the Commands' Match methods do not.
 func (commands *Commands) FindFirstMatched(input Input) Command {
 	commands.mutex.RLock()
 	defer commands.mutex.RUnlock()
 
-	for _, command := range commands.collection {
-		if command.Match(input) {
-			return command
+	commands.indexed.Do(commands.buildIndex)
+	message := input.Message()
+	if commands.trie != nil {
+		return commands.findIndexed(input, message)
+	}
+
+	for _, matcher := range commands.matchers {
+		if matcher.excludes(message) {
+			continue
+		}
+		if matcher.command.Match(input) {
+			return matcher.command
 		}
 	}
 
@@ -250,12 +318,16 @@ func NewCommandPropsBuilder() *CommandPropsBuilder {
 // CommandProps is a designated non-serializable configuration struct to be used in Command construction.
 // This holds relatively complex set of Command construction arguments that should be treated as one in logical term.
 type CommandProps struct {
-	botType         BotType
-	identifier      string
-	config          CommandConfig
-	commandFunc     commandFunc
-	matchFunc       func(Input) bool
-	instructionFunc func(*HelpInput) string
+	botType          BotType
+	scope            *BotScope
+	identifier       string
+	config           CommandConfig
+	commandFunc      commandFunc
+	matchFunc        func(Input) bool
+	pattern          *regexp.Regexp
+	instructionFunc  func(*HelpInput) string
+	intent           string
+	concurrencyLimit *ConcurrencyLimit
 }
 
 // CommandPropsBuilder helps to construct CommandProps.
@@ -271,6 +343,13 @@ func (builder *CommandPropsBuilder) BotType(botType BotType) *CommandPropsBuilde
 	return builder
 }
 
+// Scope is a setter to register the Command to the Bots the given BotScope selects instead of a single BotType.
+// When both BotType and Scope are given, Scope takes precedence.
+func (builder *CommandPropsBuilder) Scope(scope *BotScope) *CommandPropsBuilder {
+	builder.props.scope = scope
+	return builder
+}
+
 // Identifier is a setter for Command identifier.
 func (builder *CommandPropsBuilder) Identifier(id string) *CommandPropsBuilder {
 	builder.props.identifier = id
@@ -280,8 +359,12 @@ func (builder *CommandPropsBuilder) Identifier(id string) *CommandPropsBuilder {
 // MatchPattern is a setter to provide command match pattern.
 // This regular expression is used to find matching command with given Input.
 //
+// The built Command satisfies PatternCommand, so the pattern's literal prefix such as ".echo" of `^\.echo` is used to skip the Command
+// without running the regular expression when an Input can not match.
+//
 // Use MatchFunc to set more customizable matching logic.
 func (builder *CommandPropsBuilder) MatchPattern(pattern *regexp.Regexp) *CommandPropsBuilder {
+	builder.props.pattern = pattern
 	builder.props.matchFunc = func(input Input) bool {
 		return pattern.MatchString(input.Message())
 	}
@@ -294,10 +377,22 @@ func (builder *CommandPropsBuilder) MatchPattern(pattern *regexp.Regexp) *Comman
 // MatchPattern may be used to specify a regular expression that is checked against user input, Input.Message();
 // MatchFunc can specify more customizable matching logic. e.g. only return true on specific sender's specific message on specific time range.
 func (builder *CommandPropsBuilder) MatchFunc(matchFunc func(Input) bool) *CommandPropsBuilder {
+	builder.props.pattern = nil
 	builder.props.matchFunc = matchFunc
 	return builder
 }
 
+// MatchIntent is a setter to provide the name of the intent that this Command handles.
+// When no Command matches an Input with its MatchPattern or MatchFunc, the Bot asks its IntentMatcher for the Input's intent
+// and executes the Command that handles the detected intent. See BotWithIntentMatcher.
+// The detected Intent and its entities are available in the command function with IntentFromContext.
+//
+// When neither MatchPattern nor MatchFunc is called, this Command is executed only for the intent.
+func (builder *CommandPropsBuilder) MatchIntent(name string) *CommandPropsBuilder {
+	builder.props.intent = name
+	return builder
+}
+
 // Func is a setter to provide command function that requires no configuration.
 // If ConfigurableFunc and Func are both called, later call overrides the previous one.
 func (builder *CommandPropsBuilder) Func(fn func(context.Context, Input) (*CommandResponse, error)) *CommandPropsBuilder {
@@ -345,7 +440,13 @@ func (builder *CommandPropsBuilder) InstructionFunc(fnc func(input *HelpInput) s
 
 // Build builds new CommandProps instance with provided values.
 func (builder *CommandPropsBuilder) Build() (*CommandProps, error) {
-	if builder.props.botType == "" ||
+	if builder.props.matchFunc == nil && builder.props.intent != "" {
+		builder.props.matchFunc = func(_ Input) bool {
+			return false
+		}
+	}
+
+	if (builder.props.botType == "" && builder.props.scope == nil) ||
 		builder.props.identifier == "" ||
 		builder.props.instructionFunc == nil ||
 		builder.props.matchFunc == nil ||
@@ -77,6 +77,36 @@ func TestCommandPropsBuilder_BotType(t *testing.T) {
 	}
 }
 
+func TestCommandPropsBuilder_Scope(t *testing.T) {
+	scope := AllBots()
+	builder := &CommandPropsBuilder{props: &CommandProps{}}
+
+	builder.Scope(scope)
+	if builder.props.scope != scope {
+		t.Error("Provided BotScope was not set.")
+	}
+}
+
+func TestCommandPropsBuilder_Build_WithScope(t *testing.T) {
+	props, err := (&CommandPropsBuilder{props: &CommandProps{}}).
+		Scope(AllBots()).
+		Identifier("ping").
+		MatchPattern(regexp.MustCompile(`^\.ping`)).
+		Instruction(".ping").
+		Func(func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return nil, nil
+		}).
+		Build()
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if props.scope == nil {
+		t.Error("BotScope is not set.")
+	}
+}
+
 func TestCommandPropsBuilder_Func(t *testing.T) {
 	wrappedFncCalled := false
 	builder := &CommandPropsBuilder{props: &CommandProps{}}
@@ -145,6 +175,43 @@ func TestCommandPropsBuilder_MatchFunc(t *testing.T) {
 	}
 }
 
+func TestCommandPropsBuilder_MatchIntent(t *testing.T) {
+	props, err := NewCommandPropsBuilder().
+		BotType("dummy").
+		Identifier("weather").
+		MatchIntent("ask_weather").
+		Instruction("Ask me the weather.").
+		Func(func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return nil, nil
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if props.intent != "ask_weather" {
+		t.Errorf("Unexpected intent is set: %s.", props.intent)
+	}
+
+	command, err := BuildCommand(context.TODO(), props, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if command.Match(&DummyInput{MessageValue: "How is the weather?"}) {
+		t.Error("Command without pattern must not match.")
+	}
+
+	intentCommand, ok := command.(IntentCommand)
+	if !ok {
+		t.Fatalf("Built command does not satisfy IntentCommand: %T.", command)
+	}
+
+	if intentCommand.IntentName() != "ask_weather" {
+		t.Errorf("Unexpected intent name is returned: %s.", intentCommand.IntentName())
+	}
+}
+
 func TestCommandPropsBuilder_Build(t *testing.T) {
 	builder := &CommandPropsBuilder{props: &CommandProps{}}
 	if _, err := builder.Build(); err == nil {
@@ -431,6 +498,35 @@ func TestStripMessage(t *testing.T) {
 	}
 }
 
+func TestBuildCommand(t *testing.T) {
+	type config struct {
+		Text string
+	}
+	props := NewCommandPropsBuilder().
+		BotType("botType").
+		Identifier("configurable").
+		MatchPattern(regexp.MustCompile(`^\.echo`)).
+		ConfigurableFunc(&config{Text: "default"}, func(_ context.Context, _ Input, cfg CommandConfig) (*CommandResponse, error) {
+			return &CommandResponse{Content: cfg.(*config).Text}, nil
+		}).
+		Instruction(".echo").
+		MustBuild()
+
+	command, err := BuildCommand(context.TODO(), props, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	res, err := command.Execute(context.TODO(), &DummyInput{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res.Content != "default" {
+		t.Errorf("Given config is not used: %#v.", res.Content)
+	}
+}
+
 func Test_buildCommand(t *testing.T) {
 	type config struct {
 		text string
@@ -0,0 +1,183 @@
+package sarah
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConcurrencyLimit limits the number of the concurrent executions of a Command. See ConcurrencyLimitedCommand.
+type ConcurrencyLimit struct {
+	// Max is the maximum number of the executions that run at the same time. 1 makes the Command a singleton.
+	// No limit is applied when this is not positive.
+	Max int
+
+	// Queue tells to wait for a running execution to finish instead of replying to the excess Input.
+	// The waiting Input occupies a worker til it is executed or the Bot stops.
+	Queue bool
+
+	// Reply returns the reply to the excess Input with the executions that are running.
+	// When this is nil, a reply such as "deploy is already running, triggered by C123|U123." is returned.
+	Reply func(input Input, running []*CommandExecution) string
+}
+
+// CommandExecution represents a running execution of a Command.
+type CommandExecution struct {
+	// SenderKey is the Input.SenderKey() of the Input that triggered the execution.
+	SenderKey string
+	StartedAt time.Time
+}
+
+// ConcurrencyLimitedCommand defines an optional interface that a Command may satisfy to limit its concurrent executions.
+// e.g. a deploy command should not run twice at the same time.
+// A Command built with CommandPropsBuilder.ConcurrencyLimit satisfies this.
+// The limit is applied per Bot, so a Command registered to multiple Bots with a BotScope runs up to the limit for each Bot.
+type ConcurrencyLimitedCommand interface {
+	Command
+
+	// ConcurrencyLimit returns the limit of the concurrent executions. nil means no limit.
+	ConcurrencyLimit() *ConcurrencyLimit
+}
+
+// ConcurrencyLimit is a setter to limit the number of the concurrent executions of the Command.
+//
+//  sarah.NewCommandPropsBuilder().
+//  	Identifier("deploy").
+//  	ConcurrencyLimit(&sarah.ConcurrencyLimit{Max: 1}).
+//  	...
+func (builder *CommandPropsBuilder) ConcurrencyLimit(limit *ConcurrencyLimit) *CommandPropsBuilder {
+	builder.props.concurrencyLimit = limit
+	return builder
+}
+
+// concurrencyLimiter keeps track of the running executions of a Command.
+type concurrencyLimiter struct {
+	limit   *ConcurrencyLimit
+	slots   chan struct{}
+	mutex   sync.Mutex
+	running []*CommandExecution
+}
+
+func newConcurrencyLimiter(limit *ConcurrencyLimit) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		limit: limit,
+		slots: make(chan struct{}, limit.Max),
+	}
+}
+
+// acquire takes a slot for the given Input and returns a function to release the slot when the execution finishes.
+// When no slot is available, this waits for one if the limit lets the Input queue.
+// Otherwise, a nil function is returned along with the running executions.
+func (l *concurrencyLimiter) acquire(ctx context.Context, input Input) (func(), []*CommandExecution, error) {
+	if l.limit.Queue {
+		select {
+		case l.slots <- struct{}{}:
+			// Got a slot.
+
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+
+		}
+	} else {
+		select {
+		case l.slots <- struct{}{}:
+			// Got a slot.
+
+		default:
+			l.mutex.Lock()
+			running := make([]*CommandExecution, len(l.running))
+			copy(running, l.running)
+			l.mutex.Unlock()
+			return nil, running, nil
+
+		}
+	}
+
+	execution := &CommandExecution{
+		SenderKey: input.SenderKey(),
+		StartedAt: time.Now(),
+	}
+	l.mutex.Lock()
+	l.running = append(l.running, execution)
+	l.mutex.Unlock()
+
+	release := func() {
+		l.mutex.Lock()
+		for i, e := range l.running {
+			if e == execution {
+				l.running = append(l.running[:i], l.running[i+1:]...)
+				break
+			}
+		}
+		l.mutex.Unlock()
+		<-l.slots
+	}
+	return release, nil, nil
+}
+
+// reply returns the reply to the Input that exceeds the limit.
+func (l *concurrencyLimiter) reply(id string, input Input, running []*CommandExecution) string {
+	if l.limit.Reply != nil {
+		return l.limit.Reply(input, running)
+	}
+
+	senders := make([]string, 0, len(running))
+	for _, e := range running {
+		senders = append(senders, e.SenderKey)
+	}
+	return fmt.Sprintf("%s is already running, triggered by %s.", id, strings.Join(senders, ", "))
+}
+
+// limiter returns the concurrencyLimiter of the given Command, or nil when the Command has no limit.
+// The limiter is shared among the rebuilt Commands with the same identifier so the limit is kept on a configuration update.
+func (m *managedComponents) limiter(botType BotType, command Command) *concurrencyLimiter {
+	limited, ok := command.(ConcurrencyLimitedCommand)
+	if !ok {
+		return nil
+	}
+	limit := limited.ConcurrencyLimit()
+	if limit == nil || limit.Max <= 0 {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.limiters == nil {
+		m.limiters = map[BotType]map[string]*concurrencyLimiter{}
+	}
+	if _, ok := m.limiters[botType]; !ok {
+		m.limiters[botType] = map[string]*concurrencyLimiter{}
+	}
+	l, ok := m.limiters[botType][command.Identifier()]
+	if !ok || l.limit != limit {
+		l = newConcurrencyLimiter(limit)
+		m.limiters[botType][command.Identifier()] = l
+	}
+	return l
+}
+
+// execute runs the underlying Command within its ConcurrencyLimit.
+func (c *managedCommand) execute(ctx context.Context, input Input) (*CommandResponse, error) {
+	if c.limiter == nil {
+		return c.Command.Execute(ctx, input)
+	}
+
+	release, running, err := c.limiter.acquire(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		contextLogger(ctx).Info("Skip command execution due to concurrency limit", logging.F(logging.KeyCommandID, c.Identifier()))
+		return &CommandResponse{
+			Content:     c.limiter.reply(c.Identifier(), input, running),
+			UserContext: nil,
+		}, nil
+	}
+	defer release()
+
+	return c.Command.Execute(ctx, input)
+}
@@ -0,0 +1,207 @@
+package sarah
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type DummyConcurrencyLimitedCommand struct {
+	DummyCommand
+	ConcurrencyLimitValue *ConcurrencyLimit
+}
+
+var _ ConcurrencyLimitedCommand = (*DummyConcurrencyLimitedCommand)(nil)
+
+func (command *DummyConcurrencyLimitedCommand) ConcurrencyLimit() *ConcurrencyLimit {
+	return command.ConcurrencyLimitValue
+}
+
+func TestCommandPropsBuilder_ConcurrencyLimit(t *testing.T) {
+	limit := &ConcurrencyLimit{Max: 1}
+	props := NewCommandPropsBuilder().
+		BotType("dummy").
+		Identifier("deploy").
+		MatchPattern(regexp.MustCompile(".")).
+		Func(func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return nil, nil
+		}).
+		Instruction("").
+		ConcurrencyLimit(limit).
+		MustBuild()
+
+	command, err := BuildCommand(context.TODO(), props, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	limited, ok := command.(ConcurrencyLimitedCommand)
+	if !ok {
+		t.Fatal("Built command must satisfy ConcurrencyLimitedCommand.")
+	}
+	if limited.ConcurrencyLimit() != limit {
+		t.Errorf("Given limit is not set: %#v.", limited.ConcurrencyLimit())
+	}
+}
+
+// runningCommand returns a Command that blocks til the returned channel is closed, and a channel that tells the execution is started.
+func runningCommand(limit *ConcurrencyLimit) (Command, chan struct{}, chan struct{}) {
+	started := make(chan struct{}, 10)
+	finish := make(chan struct{})
+	command := &DummyConcurrencyLimitedCommand{
+		DummyCommand: DummyCommand{
+			IdentifierValue: "deploy",
+			ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+				started <- struct{}{}
+				<-finish
+				return &CommandResponse{Content: "done"}, nil
+			},
+		},
+		ConcurrencyLimitValue: limit,
+	}
+	return command, started, finish
+}
+
+func Test_managedCommand_Execute_WithConcurrencyLimit(t *testing.T) {
+	SetupAndRun(func() {
+		command, started, finish := runningCommand(&ConcurrencyLimit{Max: 1})
+		managed := runnerStatus.components.command("dummy", command)
+
+		go func() {
+			_, _ = managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U1"})
+		}()
+		<-started
+
+		res, err := managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U2"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if res.Content != "deploy is already running, triggered by U1." {
+			t.Errorf("Unexpected reply is returned: %#v.", res.Content)
+		}
+
+		// The limit is kept when the Command is rebuilt.
+		managed = runnerStatus.components.command("dummy", command)
+		res, _ = managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U3"})
+		if res.Content != "deploy is already running, triggered by U1." {
+			t.Errorf("Unexpected reply is returned: %#v.", res.Content)
+		}
+
+		close(finish)
+		// Wait for the running execution to release its slot.
+		for i := 0; i < 100; i++ {
+			if len(runnerStatus.components.limiter("dummy", command).slots) == 0 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		res, err = managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U2"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if res.Content != "done" {
+			t.Errorf("Command must be executed after the running one finishes: %#v.", res.Content)
+		}
+	})
+}
+
+func Test_managedCommand_Execute_WithCustomReply(t *testing.T) {
+	SetupAndRun(func() {
+		command, started, finish := runningCommand(&ConcurrencyLimit{
+			Max: 1,
+			Reply: func(input Input, running []*CommandExecution) string {
+				return input.SenderKey() + " waits for " + running[0].SenderKey
+			},
+		})
+		defer close(finish)
+		managed := runnerStatus.components.command("dummy", command)
+
+		go func() {
+			_, _ = managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U1"})
+		}()
+		<-started
+
+		res, _ := managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U2"})
+		if res.Content != "U2 waits for U1" {
+			t.Errorf("Unexpected reply is returned: %#v.", res.Content)
+		}
+	})
+}
+
+func Test_managedCommand_Execute_WithQueue(t *testing.T) {
+	SetupAndRun(func() {
+		command, started, finish := runningCommand(&ConcurrencyLimit{Max: 1, Queue: true})
+		managed := runnerStatus.components.command("dummy", command)
+
+		go func() {
+			_, _ = managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U1"})
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := managed.Execute(ctx, &DummyInput{SenderKeyValue: "U2"})
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		done := make(chan *CommandResponse, 1)
+		go func() {
+			res, _ := managed.Execute(context.TODO(), &DummyInput{SenderKeyValue: "U3"})
+			done <- res
+		}()
+
+		select {
+		case <-started:
+			t.Fatal("Queued input must wait for the running execution.")
+
+		case <-time.After(10 * time.Millisecond):
+			// O.K.
+
+		}
+
+		close(finish)
+		select {
+		case res := <-done:
+			if res.Content != "done" {
+				t.Errorf("Unexpected response is returned: %#v.", res.Content)
+			}
+
+		case <-time.After(time.Second):
+			t.Error("Queued input is not executed.")
+
+		}
+	})
+}
+
+func Test_managedComponents_limiter(t *testing.T) {
+	m := &managedComponents{}
+
+	if m.limiter("dummy", &DummyCommand{IdentifierValue: "echo"}) != nil {
+		t.Error("Limiter must not be returned for a command without limit.")
+	}
+
+	unlimited := &DummyConcurrencyLimitedCommand{
+		DummyCommand:          DummyCommand{IdentifierValue: "echo"},
+		ConcurrencyLimitValue: &ConcurrencyLimit{Max: 0},
+	}
+	if m.limiter("dummy", unlimited) != nil {
+		t.Error("Limiter must not be returned for non-positive limit.")
+	}
+
+	limited := &DummyConcurrencyLimitedCommand{
+		DummyCommand:          DummyCommand{IdentifierValue: "deploy"},
+		ConcurrencyLimitValue: &ConcurrencyLimit{Max: 1},
+	}
+	l := m.limiter("dummy", limited)
+	if l == nil || m.limiter("dummy", limited) != l {
+		t.Error("Same limiter must be returned for the same command.")
+	}
+
+	m.removeBot("dummy")
+	if m.limiter("dummy", limited) == l {
+		t.Error("Limiter of stopped bot must not be kept.")
+	}
+}
@@ -0,0 +1,393 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrConfigGenerationNotFound is returned when the configuration generation to roll back to is not kept.
+var ErrConfigGenerationNotFound = errors.New("config generation is not found")
+
+// ConfigGeneration represents a configuration that a Command or a ScheduledTask was built with.
+// A new generation is recorded every time the Command or the ScheduledTask is successfully built from its CommandProps or ScheduledTaskProps.
+type ConfigGeneration struct {
+	// Generation is the sequential number of the configuration that starts from 1.
+	Generation int
+
+	// Config is a copy of the configuration. This must not be modified.
+	Config interface{}
+
+	// RollbackOf is the Generation that this generation is rolled back to, or zero when this is built from the ConfigWatcher.
+	RollbackOf int
+
+	Time time.Time
+}
+
+// ConfigChange represents a configuration value that differs from the previous generation.
+// Key is the dot-separated yaml key path of the value such as "api.timeout".
+// A Secret value is masked when it is printed or is encoded to JSON or YAML.
+type ConfigChange struct {
+	Key string
+	Old interface{}
+	New interface{}
+}
+
+// CommandConfigHistory returns the kept ConfigGenerations of the Command with the given identifier from the oldest one.
+// The last one is the configuration the Command currently runs with.
+func CommandConfigHistory(botType BotType, id string) ([]*ConfigGeneration, error) {
+	return runnerStatus.components.configHistory(botType, kindCommand, id)
+}
+
+// RollbackCommandConfig rebuilds the Command with the given identifier with the configuration of the given generation.
+// The configuration file is not touched, so the Command is rebuilt with the file again on its next update or on ReloadConfigs.
+// The rebuilt configuration is recorded as a new generation and ConfigReloaded is published just like a configuration update.
+func RollbackCommandConfig(botType BotType, id string, generation int) error {
+	return runnerStatus.components.rollbackConfig(botType, kindCommand, id, generation)
+}
+
+// ScheduledTaskConfigHistory returns the kept ConfigGenerations of the ScheduledTask with the given identifier from the oldest one.
+// The last one is the configuration the ScheduledTask currently runs with.
+func ScheduledTaskConfigHistory(botType BotType, id string) ([]*ConfigGeneration, error) {
+	return runnerStatus.components.configHistory(botType, kindScheduledTask, id)
+}
+
+// RollbackScheduledTaskConfig rebuilds and reschedules the ScheduledTask with the given identifier with the configuration of the given generation.
+// See RollbackCommandConfig for the details.
+func RollbackScheduledTaskConfig(botType BotType, id string, generation int) error {
+	return runnerStatus.components.rollbackConfig(botType, kindScheduledTask, id, generation)
+}
+
+// configHistory keeps the ConfigGenerations of a Command or a ScheduledTask.
+type configHistory struct {
+	generations []*ConfigGeneration
+	latest      int
+	rollback    func(*ConfigGeneration) error
+}
+
+// enableConfigHistory sets the number of the ConfigGenerations to keep for each Command and ScheduledTask.
+// The latest one is always kept to tell the changes on the next build.
+func (m *managedComponents) enableConfigHistory(size int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.configSize = size
+}
+
+// configRollback records the given function that rebuilds the Command or the ScheduledTask with the given ConfigGeneration.
+func (m *managedComponents) configRollback(botType BotType, kind string, id string, fnc func(*ConfigGeneration) error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.history(botType, kind, id).rollback = fnc
+}
+
+// recordConfig records a copy of the given configuration as a new generation,
+// and returns the generation along with the changes from the previous one.
+// Zero is returned when the Command or the ScheduledTask has no configuration.
+func (m *managedComponents) recordConfig(botType BotType, kind string, id string, config interface{}, rollbackOf int) (int, []*ConfigChange) {
+	if config == nil {
+		return 0, nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	h := m.history(botType, kind, id)
+	var changes []*ConfigChange
+	if len(h.generations) > 0 {
+		previous := h.generations[len(h.generations)-1].Config
+		changes = diffConfig("", reflect.ValueOf(previous), reflect.ValueOf(config))
+	}
+
+	size := m.configSize
+	if size < 1 {
+		size = 1
+	}
+	if len(h.generations) >= size {
+		h.generations = h.generations[len(h.generations)-size+1:]
+	}
+	h.latest++
+	h.generations = append(h.generations, &ConfigGeneration{
+		Generation: h.latest,
+		Config:     config,
+		RollbackOf: rollbackOf,
+		Time:       time.Now(),
+	})
+	return h.latest, changes
+}
+
+// history returns the configHistory of the given Command or ScheduledTask. The caller must hold the lock.
+func (m *managedComponents) history(botType BotType, kind string, id string) *configHistory {
+	if m.configs == nil {
+		m.configs = map[BotType]map[string]*configHistory{}
+	}
+	if _, ok := m.configs[botType]; !ok {
+		m.configs[botType] = map[string]*configHistory{}
+	}
+	h, ok := m.configs[botType][kind+":"+id]
+	if !ok {
+		h = &configHistory{}
+		m.configs[botType][kind+":"+id] = h
+	}
+	return h
+}
+
+func (m *managedComponents) configHistory(botType BotType, kind string, id string) ([]*ConfigGeneration, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	h, ok := m.configs[botType][kind+":"+id]
+	if !ok || len(h.generations) == 0 {
+		return nil, notFoundError(botType, kind, id)
+	}
+	generations := make([]*ConfigGeneration, len(h.generations))
+	copy(generations, h.generations)
+	return generations, nil
+}
+
+func (m *managedComponents) rollbackConfig(botType BotType, kind string, id string, generation int) error {
+	m.mutex.RLock()
+	h, ok := m.configs[botType][kind+":"+id]
+	var target *ConfigGeneration
+	var rollback func(*ConfigGeneration) error
+	if ok {
+		rollback = h.rollback
+		for _, g := range h.generations {
+			if g.Generation == generation {
+				target = g
+				break
+			}
+		}
+	}
+	m.mutex.RUnlock()
+
+	if rollback == nil {
+		return notFoundError(botType, kind, id)
+	}
+	if target == nil {
+		return fmt.Errorf("%w: %s:%s:%d", ErrConfigGenerationNotFound, botType, id, generation)
+	}
+
+	// Call the function without the lock since it records the new generation.
+	return rollback(target)
+}
+
+func notFoundError(botType BotType, kind string, id string) error {
+	if kind == kindScheduledTask {
+		return fmt.Errorf("%w: %s:%s", ErrScheduledTaskNotFound, botType, id)
+	}
+	return fmt.Errorf("%w: %s:%s", ErrCommandNotFound, botType, id)
+}
+
+// commandConfig returns a copy of the configuration the given Command is built with, or nil when the Command has no configuration.
+func commandConfig(command Command) interface{} {
+	c, ok := command.(*defaultCommand)
+	if !ok || c.configWrapper == nil {
+		return nil
+	}
+
+	c.configWrapper.mutex.RLock()
+	defer c.configWrapper.mutex.RUnlock()
+	return copyConfig(c.configWrapper.value)
+}
+
+// taskConfig returns a copy of the configuration the given ScheduledTask is built with, or nil when the ScheduledTask has no configuration.
+func taskConfig(task ScheduledTask) interface{} {
+	t, ok := task.(*scheduledTask)
+	if !ok || t.configWrapper == nil {
+		return nil
+	}
+
+	t.configWrapper.mutex.RLock()
+	defer t.configWrapper.mutex.RUnlock()
+	return copyConfig(t.configWrapper.value)
+}
+
+// generationWatcher is a ConfigWatcher that reads the configuration from a ConfigGeneration instead of the configuration file.
+type generationWatcher struct {
+	generation *ConfigGeneration
+}
+
+var _ ConfigWatcher = (*generationWatcher)(nil)
+
+func (w *generationWatcher) Read(_ context.Context, _ BotType, _ string, configPtr interface{}) error {
+	target := reflect.ValueOf(configPtr)
+	source := reflect.ValueOf(copyConfig(w.generation.Config))
+
+	switch {
+	case target.Kind() == reflect.Map && source.Type() == target.Type():
+		for _, key := range target.MapKeys() {
+			target.SetMapIndex(key, reflect.Value{})
+		}
+		for _, key := range source.MapKeys() {
+			target.SetMapIndex(key, source.MapIndex(key))
+		}
+		return nil
+
+	case target.Kind() == reflect.Ptr && source.Type() == target.Type():
+		target.Elem().Set(source.Elem())
+		return nil
+
+	case target.Kind() == reflect.Ptr && source.Type() == target.Elem().Type():
+		target.Elem().Set(source)
+		return nil
+
+	default:
+		return fmt.Errorf("config generation of %s can not be set to %T", source.Type(), configPtr)
+
+	}
+}
+
+func (w *generationWatcher) Watch(_ context.Context, _ BotType, _ string, _ func()) error {
+	return nil
+}
+
+func (w *generationWatcher) Unwatch(_ BotType) error {
+	return nil
+}
+
+// copyConfig returns a deep copy of the given configuration so the later updates do not affect the copy.
+// The unexported fields are copied as they are.
+func copyConfig(config interface{}) interface{} {
+	v := reflect.ValueOf(config)
+	if !v.IsValid() {
+		return nil
+	}
+	return copyValue(v, map[uintptr]reflect.Value{}).Interface()
+}
+
+func copyValue(v reflect.Value, copied map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if c, ok := copied[v.Pointer()]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copied[v.Pointer()] = c
+		c.Elem().Set(copyValue(v.Elem(), copied))
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < c.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(copyValue(v.Field(i), copied))
+			}
+		}
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i), copied))
+		}
+		return c
+
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i), copied))
+		}
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			c.SetMapIndex(key, copyValue(v.MapIndex(key), copied))
+		}
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem(), copied))
+		return c
+
+	default:
+		return v
+
+	}
+}
+
+// diffConfig returns the changes between the given configurations.
+// Structs and maps are compared field by field so the changes point to the values that actually differ.
+func diffConfig(key string, before reflect.Value, after reflect.Value) []*ConfigChange {
+	for before.IsValid() && after.IsValid() && before.Kind() == reflect.Ptr && after.Kind() == reflect.Ptr && !before.IsNil() && !after.IsNil() {
+		before, after = before.Elem(), after.Elem()
+	}
+
+	if before.IsValid() && after.IsValid() && before.Type() == after.Type() {
+		switch {
+		case before.Kind() == reflect.Struct && before.Type() != timeType:
+			var changes []*ConfigChange
+			for i := 0; i < before.NumField(); i++ {
+				field := before.Type().Field(i)
+				name, inline, ok := configKeyName(field)
+				if !ok {
+					continue
+				}
+				fieldKey := joinConfigKey(key, name)
+				if inline {
+					fieldKey = key
+				}
+				changes = append(changes, diffConfig(fieldKey, before.Field(i), after.Field(i))...)
+			}
+			return changes
+
+		case before.Kind() == reflect.Map && !before.IsNil() && !after.IsNil():
+			keys := map[string]reflect.Value{}
+			for _, k := range append(before.MapKeys(), after.MapKeys()...) {
+				keys[fmt.Sprint(k.Interface())] = k
+			}
+			names := make([]string, 0, len(keys))
+			for name := range keys {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			var changes []*ConfigChange
+			for _, name := range names {
+				changes = append(changes, diffConfig(joinConfigKey(key, name), before.MapIndex(keys[name]), after.MapIndex(keys[name]))...)
+			}
+			return changes
+
+		}
+	}
+
+	beforeValue, afterValue := configValue(before), configValue(after)
+	if reflect.DeepEqual(beforeValue, afterValue) {
+		return nil
+	}
+	return []*ConfigChange{{Key: key, Old: beforeValue, New: afterValue}}
+}
+
+func configValue(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func joinConfigKey(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return strings.Join([]string{parent, name}, ".")
+}
@@ -0,0 +1,280 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type historyConfig struct {
+	Token   Secret            `yaml:"token"`
+	Count   int               `yaml:"count"`
+	Tags    []string          `yaml:"tags"`
+	Labels  map[string]string `yaml:"labels"`
+	API     *historyAPIConfig `yaml:"api"`
+	Ignored string            `yaml:"-"`
+}
+
+type historyAPIConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type historyTaskConfig struct {
+	Spec string `yaml:"schedule"`
+}
+
+func (c historyTaskConfig) Schedule() string {
+	return c.Spec
+}
+
+func Test_copyConfig(t *testing.T) {
+	config := &historyConfig{
+		Count:  1,
+		Tags:   []string{"a"},
+		Labels: map[string]string{"env": "dev"},
+		API:    &historyAPIConfig{Timeout: time.Second},
+	}
+
+	copied := copyConfig(config).(*historyConfig)
+	if !reflect.DeepEqual(config, copied) {
+		t.Fatalf("Unexpected copy is returned: %#v.", copied)
+	}
+
+	config.Count = 2
+	config.Tags[0] = "b"
+	config.Labels["env"] = "prod"
+	config.API.Timeout = time.Minute
+
+	if copied.Count != 1 || copied.Tags[0] != "a" || copied.Labels["env"] != "dev" || copied.API.Timeout != time.Second {
+		t.Errorf("Copy is affected by the update: %#v.", copied)
+	}
+
+	if copyConfig(nil) != nil {
+		t.Error("nil must be returned for nil.")
+	}
+}
+
+func Test_diffConfig(t *testing.T) {
+	before := &historyConfig{
+		Token:   "old",
+		Count:   1,
+		Labels:  map[string]string{"env": "dev", "team": "a"},
+		API:     &historyAPIConfig{Timeout: time.Second},
+		Ignored: "a",
+	}
+	after := &historyConfig{
+		Token:   "new",
+		Count:   1,
+		Labels:  map[string]string{"env": "prod", "region": "jp"},
+		API:     &historyAPIConfig{Timeout: time.Minute},
+		Ignored: "b",
+	}
+
+	changes := diffConfig("", reflect.ValueOf(before), reflect.ValueOf(after))
+
+	expected := []*ConfigChange{
+		{Key: "token", Old: Secret("old"), New: Secret("new")},
+		{Key: "labels.env", Old: "dev", New: "prod"},
+		{Key: "labels.region", Old: nil, New: "jp"},
+		{Key: "labels.team", Old: "a", New: nil},
+		{Key: "api.timeout", Old: time.Second, New: time.Minute},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		for _, c := range changes {
+			t.Logf("%#v", c)
+		}
+		t.Errorf("Unexpected changes are returned.")
+	}
+
+	if changes := diffConfig("", reflect.ValueOf(before), reflect.ValueOf(before)); len(changes) != 0 {
+		t.Errorf("Unexpected changes are returned for the same configuration: %#v.", changes)
+	}
+}
+
+func Test_managedComponents_recordConfig(t *testing.T) {
+	m := &managedComponents{}
+	m.enableConfigHistory(2)
+
+	if generation, _ := m.recordConfig("dummy", kindCommand, "echo", nil, 0); generation != 0 {
+		t.Errorf("Generation must not be recorded without configuration: %d.", generation)
+	}
+
+	for i := 1; i <= 3; i++ {
+		generation, changes := m.recordConfig("dummy", kindCommand, "echo", &historyConfig{Count: i}, 0)
+		if generation != i {
+			t.Errorf("Unexpected generation is returned: %d.", generation)
+		}
+		if i > 1 && (len(changes) != 1 || changes[0].Key != "count") {
+			t.Errorf("Unexpected changes are returned: %#v.", changes)
+		}
+	}
+
+	generations, err := CommandConfigHistory("dummy", "echo")
+	if !errors.Is(err, ErrCommandNotFound) {
+		t.Errorf("History of other components must not be returned: %#v.", generations)
+	}
+
+	generations, err = m.configHistory("dummy", kindCommand, "echo")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(generations) != 2 || generations[0].Generation != 2 || generations[1].Generation != 3 {
+		t.Errorf("Unexpected generations are kept: %#v.", generations)
+	}
+
+	_, err = m.configHistory("dummy", kindScheduledTask, "echo")
+	if !errors.Is(err, ErrScheduledTaskNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	m.removeBot("dummy")
+	if _, err := m.configHistory("dummy", kindCommand, "echo"); err == nil {
+		t.Error("History of stopped bot must not be kept.")
+	}
+}
+
+func TestRollbackCommandConfig(t *testing.T) {
+	SetupAndRun(func() {
+		runnerStatus.components.enableConfigHistory(5)
+
+		var botType BotType = "dummy"
+		var count int
+		var update func()
+		watcher := &DummyConfigWatcher{
+			ReadFunc: func(_ context.Context, _ BotType, _ string, configPtr interface{}) error {
+				configPtr.(*historyConfig).Count = count
+				return nil
+			},
+			WatchFunc: func(_ context.Context, _ BotType, _ string, callback func()) error {
+				update = callback
+				return nil
+			},
+		}
+		config := &historyConfig{}
+		props := NewCommandPropsBuilder().
+			BotType(botType).
+			Identifier("echo").
+			MatchPattern(regexp.MustCompile(".")).
+			ConfigurableFunc(config, func(_ context.Context, _ Input, _ CommandConfig) (*CommandResponse, error) {
+				return nil, nil
+			}).
+			Instruction("").
+			MustBuild()
+		r := &runner{
+			configWatcher: watcher,
+			commandProps:  map[BotType][]*CommandProps{botType: {props}},
+		}
+		bus := NewEventBus()
+		var events []*ConfigReloaded
+		bus.Subscribe(func(e Event) {
+			if reloaded, ok := e.(*ConfigReloaded); ok {
+				events = append(events, reloaded)
+			}
+		})
+		bot := &DummyBot{
+			BotTypeValue:      botType,
+			AppendCommandFunc: func(_ Command) {},
+		}
+
+		count = 1
+		r.registerCommands(NewEventBusContext(context.TODO(), bus), bot)
+		count = 99
+		update()
+
+		if config.Count != 99 {
+			t.Fatalf("Configuration is not updated: %d.", config.Count)
+		}
+		if len(events) != 1 || events[0].Generation != 2 || len(events[0].Changes) != 1 {
+			t.Fatalf("Unexpected events are published: %#v.", events)
+		}
+		if change := events[0].Changes[0]; change.Key != "count" || change.Old != 1 || change.New != 99 {
+			t.Errorf("Unexpected change is given: %#v.", change)
+		}
+
+		err := RollbackCommandConfig(botType, "echo", 1)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if config.Count != 1 {
+			t.Errorf("Configuration is not rolled back: %d.", config.Count)
+		}
+		if len(events) != 2 || events[1].Generation != 3 || events[1].Changes[0].New != 1 {
+			t.Errorf("Unexpected events are published: %#v.", events[1])
+		}
+
+		generations, err := CommandConfigHistory(botType, "echo")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(generations) != 3 || generations[2].RollbackOf != 1 {
+			t.Errorf("Unexpected generations are kept: %#v.", generations)
+		}
+
+		err = RollbackCommandConfig(botType, "echo", 10)
+		if !errors.Is(err, ErrConfigGenerationNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		err = RollbackScheduledTaskConfig(botType, "echo", 1)
+		if !errors.Is(err, ErrScheduledTaskNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestRollbackScheduledTaskConfig(t *testing.T) {
+	SetupAndRun(func() {
+		runnerStatus.components.enableConfigHistory(2)
+
+		var botType BotType = "dummy"
+		var update func()
+		schedule := "@daily"
+		watcher := &DummyConfigWatcher{
+			ReadFunc: func(_ context.Context, _ BotType, _ string, configPtr interface{}) error {
+				configPtr.(*historyTaskConfig).Spec = schedule
+				return nil
+			},
+			WatchFunc: func(_ context.Context, _ BotType, _ string, callback func()) error {
+				update = callback
+				return nil
+			},
+		}
+		props := NewScheduledTaskPropsBuilder().
+			BotType(botType).
+			Identifier("alarm").
+			ConfigurableFunc(historyTaskConfig{}, func(_ context.Context, _ TaskConfig) ([]*ScheduledTaskResult, error) {
+				return nil, nil
+			}).
+			MustBuild()
+		var scheduled []string
+		r := &runner{
+			configWatcher:      watcher,
+			scheduledTaskProps: map[BotType][]*ScheduledTaskProps{botType: {props}},
+			scheduler: &DummyScheduler{
+				UpdateFunc: func(_ BotType, task ScheduledTask, _ func()) error {
+					scheduled = append(scheduled, task.Schedule())
+					return nil
+				},
+				RemoveFunc: func(_ BotType, _ string) {},
+			},
+		}
+
+		r.registerScheduledTasks(context.TODO(), &DummyBot{BotTypeValue: botType})
+		schedule = "INVALID"
+		update()
+
+		err := RollbackScheduledTaskConfig(botType, "alarm", 1)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		expected := []string{"@daily", "INVALID", "@daily"}
+		if !reflect.DeepEqual(scheduled, expected) {
+			t.Errorf("Unexpected schedules are given: %#v.", scheduled)
+		}
+	})
+}
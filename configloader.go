@@ -0,0 +1,437 @@
+package sarah
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigKeyError is returned when the value given to a configuration key is invalid.
+// Key is named as the source names it so the operator can find the offending setting as-is:
+// e.g. SARAH_WORKER_QUEUE_SIZE for an environment variable and -worker.queue_size for a flag.
+// ValidateConfig sets the yaml key path of the invalid struct to Key and leaves Value empty.
+type ConfigKeyError struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+// Error returns the stringified form of the error including the offending key.
+func (e *ConfigKeyError) Error() string {
+	switch {
+	case e.Key == "":
+		return e.Err.Error()
+
+	case e.Value == "":
+		return fmt.Sprintf("invalid %s: %s", e.Key, e.Err.Error())
+
+	default:
+		return fmt.Sprintf("invalid value %q for %s: %s", e.Value, e.Key, e.Err.Error())
+
+	}
+}
+
+// Unwrap returns the cause of the error.
+func (e *ConfigKeyError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigKeyErrors is an alias for a slice of ConfigKeyError.
+// LoadConfig returns this so all invalid keys can be fixed at once.
+type ConfigKeyErrors []*ConfigKeyError
+
+// Error returns the stringified form of all stored errors.
+func (e ConfigKeyErrors) Error() string {
+	var errs []string
+	for _, err := range e {
+		errs = append(errs, err.Error())
+	}
+	return strings.Join(errs, "\n")
+}
+
+// ConfigLoaderOption defines function signature that LoadConfig's functional option must satisfy.
+type ConfigLoaderOption func(*configLoader)
+
+// ConfigFromFile creates a ConfigLoaderOption that reads the given YAML or JSON file.
+// The file format is determined by the extension.
+func ConfigFromFile(path string) ConfigLoaderOption {
+	return func(loader *configLoader) {
+		loader.file = path
+	}
+}
+
+// ConfigFromEnv creates a ConfigLoaderOption that reads the environment variables with the given prefix.
+// See LoadConfigFromEnv for the naming rule.
+func ConfigFromEnv(prefix string) ConfigLoaderOption {
+	return func(loader *configLoader) {
+		loader.envPrefix = prefix
+		loader.lookupEnv = os.LookupEnv
+	}
+}
+
+// ConfigFromFlags creates a ConfigLoaderOption that applies the flags bound by BindConfigFlags.
+func ConfigFromFlags(flags *ConfigFlags) ConfigLoaderOption {
+	return func(loader *configLoader) {
+		loader.flags = flags
+	}
+}
+
+type configLoader struct {
+	file      string
+	envPrefix string
+	lookupEnv func(string) (string, bool)
+	flags     *ConfigFlags
+}
+
+// LoadConfig overrides the values of the given configuration struct with the given sources.
+// Pass a struct with default values such as the one NewConfig returns, so the precedence is flags > env > file > defaults
+// regardless of the order of the given options:
+//
+//  config := sarah.NewConfig()
+//  flags := sarah.BindConfigFlags(flag.CommandLine, config)
+//  flag.Parse()
+//
+//  err := sarah.LoadConfig(config, sarah.ConfigFromFile("config.yaml"), sarah.ConfigFromEnv("SARAH"), sarah.ConfigFromFlags(flags))
+//
+// Any struct including the adapter's and plugin's configuration is supported as long as the fields have yaml or json tags.
+// When any environment variable or flag has an invalid value, ConfigKeyErrors is returned.
+func LoadConfig(config interface{}, options ...ConfigLoaderOption) error {
+	loader := &configLoader{}
+	for _, opt := range options {
+		opt(loader)
+	}
+
+	if loader.file != "" {
+		err := loadConfigFile(loader.file, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	var errs ConfigKeyErrors
+	if loader.lookupEnv != nil {
+		errs = append(errs, loadConfigFromEnv(config, loader.envPrefix, loader.lookupEnv)...)
+	}
+
+	if loader.flags != nil {
+		errs = append(errs, loader.flags.apply(config)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// LoadConfigFromEnv overrides the values of the given configuration struct with the environment variables.
+// Each variable is named as the given prefix and the upper-cased yaml keys joined with underscores;
+// e.g. SARAH_WORKER_QUEUE_SIZE sets Config.Worker.QueueSize when the prefix is SARAH.
+// A string value is used as-is while other values are parsed as YAML such as "10", "true", "5s" or "[a, b]".
+// When any variable has an invalid value, ConfigKeyErrors is returned.
+func LoadConfigFromEnv(config interface{}, prefix string) error {
+	errs := loadConfigFromEnv(config, prefix, os.LookupEnv)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func loadConfigFromEnv(config interface{}, prefix string, lookupEnv func(string) (string, bool)) ConfigKeyErrors {
+	var errs ConfigKeyErrors
+	for _, path := range configKeys(reflect.TypeOf(config)) {
+		key := envKey(prefix, path)
+		value, ok := lookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		err := setConfigValue(reflect.ValueOf(config), path, value)
+		if err != nil {
+			errs = append(errs, &ConfigKeyError{Key: key, Value: value, Err: err})
+		}
+	}
+	return errs
+}
+
+func envKey(prefix string, path []string) string {
+	key := strings.ToUpper(strings.Join(path, "_"))
+	if prefix == "" {
+		return key
+	}
+	return strings.ToUpper(prefix) + "_" + key
+}
+
+// ConfigFlags holds the flag values bound by BindConfigFlags til LoadConfig applies them.
+type ConfigFlags struct {
+	values map[string]string
+}
+
+// BindConfigFlags defines a flag for each key of the given configuration struct.
+// Each flag is named as the yaml keys joined with dots such as -worker.queue_size.
+// The given struct is not modified on parse; pass the returned ConfigFlags to LoadConfig via ConfigFromFlags to apply the given flags.
+func BindConfigFlags(flagSet *flag.FlagSet, config interface{}) *ConfigFlags {
+	flags := &ConfigFlags{
+		values: map[string]string{},
+	}
+
+	for _, path := range configKeys(reflect.TypeOf(config)) {
+		name := strings.Join(path, ".")
+		flagSet.Var(&configFlagValue{name: name, values: flags.values}, name, fmt.Sprintf("overrides %s", name))
+	}
+
+	return flags
+}
+
+func (f *ConfigFlags) apply(config interface{}) ConfigKeyErrors {
+	var names []string
+	for name := range f.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs ConfigKeyErrors
+	for _, name := range names {
+		value := f.values[name]
+		err := setConfigValue(reflect.ValueOf(config), strings.Split(name, "."), value)
+		if err != nil {
+			errs = append(errs, &ConfigKeyError{Key: "-" + name, Value: value, Err: err})
+		}
+	}
+	return errs
+}
+
+// configFlagValue is a flag.Value that stashes the given value.
+type configFlagValue struct {
+	name   string
+	values map[string]string
+}
+
+var _ flag.Value = (*configFlagValue)(nil)
+
+func (v *configFlagValue) String() string {
+	if v.values == nil {
+		return ""
+	}
+	return v.values[v.name]
+}
+
+func (v *configFlagValue) Set(value string) error {
+	v.values[v.name] = value
+	return nil
+}
+
+func loadConfigFile(path string, config interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, config)
+
+	case ".json":
+		err = json.Unmarshal(b, config)
+
+	default:
+		return fmt.Errorf("unsupported config file extension: %s", ext)
+
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+// configKeys returns the key paths of the leaf fields of the given struct type.
+func configKeys(typ reflect.Type) [][]string {
+	var keys [][]string
+	var walk func(reflect.Type, []string, map[reflect.Type]bool)
+	walk = func(typ reflect.Type, path []string, visiting map[reflect.Type]bool) {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if visiting[typ] {
+			return
+		}
+		visiting[typ] = true
+		defer delete(visiting, typ)
+
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name, inline, ok := configKeyName(field)
+			if !ok {
+				continue
+			}
+
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+
+			if isConfigStruct(fieldType) {
+				if inline {
+					walk(fieldType, path, visiting)
+				} else {
+					walk(fieldType, append(append([]string{}, path...), name), visiting)
+				}
+				continue
+			}
+
+			if fieldType.Kind() == reflect.Interface || fieldType.Kind() == reflect.Func || fieldType.Kind() == reflect.Chan {
+				continue
+			}
+			keys = append(keys, append(append([]string{}, path...), name))
+		}
+	}
+
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Struct {
+		walk(typ, nil, map[reflect.Type]bool{})
+	}
+
+	return keys
+}
+
+// configKeyName returns the key name of the given field by its yaml tag, json tag or field name in this order.
+func configKeyName(field reflect.StructField) (string, bool, bool) {
+	if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+		// Unexported, except an embedded struct whose exported fields are still settable.
+		return "", false, false
+	}
+
+	tag, ok := field.Tag.Lookup("yaml")
+	if !ok {
+		tag, ok = field.Tag.Lookup("json")
+	}
+
+	if !ok || tag == "" {
+		return strings.ToLower(field.Name), field.Anonymous, true
+	}
+
+	parts := strings.Split(tag, ",")
+	if parts[0] == "-" {
+		return "", false, false
+	}
+
+	inline := false
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+
+	name := parts[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, inline || (field.Anonymous && parts[0] == ""), true
+}
+
+// isConfigStruct tells if the given type is a struct to be walked through rather than a leaf value such as time.Time.
+func isConfigStruct(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	ptr := reflect.PtrTo(typ)
+	return !ptr.Implements(textUnmarshalerType) && !ptr.Implements(yamlUnmarshalerType)
+}
+
+// setConfigValue sets the given raw value to the field at the given key path. A nil pointer on the path is initialized.
+func setConfigValue(v reflect.Value, path []string, raw string) error {
+	field := v
+	for _, key := range path {
+		field = indirectConfigValue(field)
+		if field.Kind() != reflect.Struct {
+			return fmt.Errorf("unknown key: %s", key)
+		}
+
+		index, ok := configFieldIndex(field.Type(), key)
+		if !ok {
+			return fmt.Errorf("unknown key: %s", key)
+		}
+
+		for _, i := range index {
+			field = indirectConfigValue(field).Field(i)
+		}
+	}
+	field = indirectConfigValue(field)
+
+	if field.Kind() == reflect.String {
+		field.SetString(raw)
+		return nil
+	}
+
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	// Parse into a fresh value so a partially parsed value does not remain on error.
+	parsed := reflect.New(field.Type())
+	err := yaml.Unmarshal([]byte(raw), parsed.Interface())
+	if err != nil {
+		return err
+	}
+	field.Set(parsed.Elem())
+
+	return nil
+}
+
+// indirectConfigValue dereferences the given pointer value and initializes it when it is nil.
+func indirectConfigValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// configFieldIndex returns the index sequence of the field that has the given key name, including the ones of the inline structs.
+func configFieldIndex(typ reflect.Type, key string) ([]int, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, inline, ok := configKeyName(field)
+		if !ok {
+			continue
+		}
+
+		if inline {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() != reflect.Struct {
+				continue
+			}
+			if index, ok := configFieldIndex(fieldType, key); ok {
+				return append([]int{i}, index...), true
+			}
+			continue
+		}
+
+		if name == key {
+			return []int{i}, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,211 @@
+package sarah
+
+import (
+	"errors"
+	"flag"
+	"github.com/oklahomer/go-sarah/v4/workers"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type nestedLoaderConfig struct {
+	Token    string        `yaml:"token"`
+	Interval time.Duration `yaml:"interval"`
+	Rooms    []string      `yaml:"rooms"`
+}
+
+type embeddedLoaderConfig struct {
+	Debug bool `yaml:"debug"`
+}
+
+type loaderConfig struct {
+	embeddedLoaderConfig `yaml:",inline"`
+	Name                 string              `yaml:"name"`
+	Nested               *nestedLoaderConfig `json:"nested"`
+	Skipped              string              `yaml:"-"`
+	Func                 func()              `yaml:"func"`
+	unexported           string
+}
+
+func TestConfigKeyError_Error(t *testing.T) {
+	err := &ConfigKeyError{Key: "SARAH_WORKER_QUEUE_SIZE", Value: "abc", Err: errors.New("not a number")}
+
+	if !strings.Contains(err.Error(), "SARAH_WORKER_QUEUE_SIZE") {
+		t.Errorf("Key is not included: %s.", err.Error())
+	}
+
+	if !errors.Is(err, err.Err) {
+		t.Error("Cause is not unwrapped.")
+	}
+}
+
+func Test_configKeys(t *testing.T) {
+	keys := configKeys(reflect.TypeOf(&loaderConfig{}))
+
+	var joined []string
+	for _, key := range keys {
+		joined = append(joined, strings.Join(key, "."))
+	}
+
+	expected := []string{"debug", "name", "nested.token", "nested.interval", "nested.rooms"}
+	if strings.Join(joined, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected keys are returned: %#v.", joined)
+	}
+}
+
+func Test_loadConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"BOT_DEBUG":           "true",
+		"BOT_NAME":            "sarah",
+		"BOT_NESTED_TOKEN":    "123",
+		"BOT_NESTED_INTERVAL": "5s",
+		"BOT_NESTED_ROOMS":    "[a, b]",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	config := &loaderConfig{}
+	errs := loadConfigFromEnv(config, "bot", lookupEnv)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error is returned: %s.", errs.Error())
+	}
+
+	if !config.Debug {
+		t.Error("Inline field is not set.")
+	}
+	if config.Name != "sarah" {
+		t.Errorf("Unexpected name is set: %s.", config.Name)
+	}
+	if config.Nested == nil {
+		t.Fatal("Nil pointer is not initialized.")
+	}
+	if config.Nested.Token != "123" {
+		t.Errorf("String value is not set as-is: %s.", config.Nested.Token)
+	}
+	if config.Nested.Interval != 5*time.Second {
+		t.Errorf("Unexpected interval is set: %s.", config.Nested.Interval)
+	}
+	if len(config.Nested.Rooms) != 2 || config.Nested.Rooms[1] != "b" {
+		t.Errorf("Unexpected rooms are set: %#v.", config.Nested.Rooms)
+	}
+}
+
+func Test_loadConfigFromEnv_Invalid(t *testing.T) {
+	env := map[string]string{
+		"SARAH_WORKER_QUEUE_SIZE": "-1",
+		"SARAH_WORKER_WORKER_NUM": "abc",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	config := NewConfig()
+	errs := loadConfigFromEnv(config, "SARAH", lookupEnv)
+	if len(errs) != 2 {
+		t.Fatalf("Unexpected errors are returned: %#v.", errs)
+	}
+
+	for _, err := range errs {
+		if _, ok := env[err.Key]; !ok {
+			t.Errorf("Offending key is not named: %s.", err.Error())
+		}
+	}
+
+	if config.Worker.QueueSize != workers.NewConfig().QueueSize {
+		t.Errorf("Invalid value must not be set: %d.", config.Worker.QueueSize)
+	}
+}
+
+func TestBindConfigFlags(t *testing.T) {
+	config := NewConfig()
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindConfigFlags(flagSet, config)
+
+	if flagSet.Lookup("worker.queue_size") == nil {
+		t.Fatal("Flag is not defined.")
+	}
+	if flagSet.Lookup("clock") != nil {
+		t.Error("Field with yaml:\"-\" must not be bound.")
+	}
+
+	err := flagSet.Parse([]string{"-worker.queue_size", "50", "-timezone", "UTC"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if config.Worker.QueueSize == 50 {
+		t.Fatal("Flags must not be applied on parse.")
+	}
+
+	errs := flags.apply(config)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error is returned: %s.", errs.Error())
+	}
+
+	if config.Worker.QueueSize != 50 || config.TimeZone != "UTC" {
+		t.Errorf("Flags are not applied: %#v.", config)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	_ = os.Setenv("SARAH_WORKER_QUEUE_SIZE", "40")
+	_ = os.Setenv("SARAH_WORKER_WORKER_NUM", "25")
+	defer func() {
+		_ = os.Unsetenv("SARAH_WORKER_QUEUE_SIZE")
+		_ = os.Unsetenv("SARAH_WORKER_WORKER_NUM")
+	}()
+
+	config := NewConfig()
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindConfigFlags(flagSet, config)
+	_ = flagSet.Parse([]string{"-worker.queue_size=50"})
+
+	err := LoadConfig(
+		config,
+		ConfigFromFlags(flags),
+		ConfigFromEnv("SARAH"),
+		ConfigFromFile(filepath.Join("testdata", "loadconfig", "config.yaml")),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if config.TimeZone != "Asia/Tokyo" {
+		t.Errorf("File value is not applied: %s.", config.TimeZone)
+	}
+	if config.Worker.WorkerNum != 25 {
+		t.Errorf("Env value must override file value: %d.", config.Worker.WorkerNum)
+	}
+	if config.Worker.QueueSize != 50 {
+		t.Errorf("Flag value must override env value: %d.", config.Worker.QueueSize)
+	}
+	if config.Worker.SuperviseInterval != workers.NewConfig().SuperviseInterval {
+		t.Errorf("Default value must remain: %s.", config.Worker.SuperviseInterval)
+	}
+}
+
+func TestLoadConfig_Error(t *testing.T) {
+	_ = os.Setenv("SARAH_WORKER_QUEUE_SIZE", "many")
+	defer func() {
+		_ = os.Unsetenv("SARAH_WORKER_QUEUE_SIZE")
+	}()
+
+	err := LoadConfig(NewConfig(), ConfigFromEnv("SARAH"))
+
+	errs, ok := err.(ConfigKeyErrors)
+	if !ok || len(errs) != 1 || errs[0].Key != "SARAH_WORKER_QUEUE_SIZE" {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	err = LoadConfig(NewConfig(), ConfigFromFile(filepath.Join("testdata", "loadconfig", "missing.yaml")))
+	if err == nil {
+		t.Error("Expected error is not returned for missing file.")
+	}
+}
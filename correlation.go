@@ -0,0 +1,42 @@
+package sarah
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"time"
+)
+
+type correlationIDKey struct{}
+
+// newCorrelationID generates a random identifier for an incoming Input.
+// This is a variable so tests can replace it with a deterministic one.
+var newCorrelationID = func() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		// The system's random source is unavailable. Fall back to a time-based value, which is unique enough for tracing.
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithCorrelationID returns a copy of the given context that carries the given correlation ID.
+// The Logger carried by the returned context attaches the ID to every entry with logging.KeyCorrelationID.
+//
+// go-sarah's core calls this for each Input when the Input is received, so a Command, Bot.SendMessage() and any log line
+// with the given context can refer to the same ID to trace one message's lifecycle.
+// The worker's log lines on the job execution also carry the ID as a job label.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, correlationIDKey{}, id)
+	return logging.NewContext(ctx, logging.FromContext(ctx).With(logging.F(logging.KeyCorrelationID, id)))
+}
+
+// CorrelationID returns the correlation ID carried by the given context.
+// An empty string is returned when the context carries none.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
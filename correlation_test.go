@@ -0,0 +1,49 @@
+package sarah
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"testing"
+)
+
+func Test_newCorrelationID(t *testing.T) {
+	id := newCorrelationID()
+	if len(id) != 16 {
+		t.Errorf("Unexpected ID is returned: %s.", id)
+	}
+
+	if newCorrelationID() == id {
+		t.Error("The same ID is returned twice.")
+	}
+}
+
+func TestWithCorrelationID(t *testing.T) {
+	var entry *logging.Entry
+	l := logging.NewLogger(&DummyLogHandler{
+		HandleFunc: func(e *logging.Entry) {
+			entry = e
+		},
+	})
+	ctx := logging.NewContext(context.Background(), l)
+
+	ctx = WithCorrelationID(ctx, "dummy")
+
+	if CorrelationID(ctx) != "dummy" {
+		t.Errorf("Unexpected ID is returned: %s.", CorrelationID(ctx))
+	}
+
+	logging.FromContext(ctx).Info("message")
+	if entry == nil {
+		t.Fatal("Logger is not carried by the context.")
+	}
+
+	if len(entry.Fields) != 1 || entry.Fields[0].Key != logging.KeyCorrelationID || entry.Fields[0].Value != "dummy" {
+		t.Errorf("Correlation ID is not attached: %#v.", entry.Fields)
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	if id := CorrelationID(context.Background()); id != "" {
+		t.Errorf("Unexpected ID is returned: %s.", id)
+	}
+}
@@ -0,0 +1,139 @@
+package sarah
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeadLetterNotFound is returned when the DeadLetter to replay or discard is not kept.
+var ErrDeadLetterNotFound = errors.New("dead letter is not found")
+
+// DeadLetter represents an Input that the Bot failed to respond to.
+// The DeadLetters are kept when RegisterDeadLetterQueue is called, and can be replayed with ReplayDeadLetter after the cause is fixed.
+type DeadLetter struct {
+	// ID is the correlation ID given to the Input on reception. The logs on the failed execution carry the same ID.
+	ID      string
+	BotType BotType
+	Input   Input
+	Err     error
+	Time    time.Time
+}
+
+// ListDeadLetters returns the kept DeadLetters from the oldest one.
+func ListDeadLetters() []*DeadLetter {
+	return runnerStatus.components.listDeadLetters()
+}
+
+// ReplayDeadLetter passes the Input of the DeadLetter with the given ID to the running Bot again, and forgets the DeadLetter.
+// The Input goes through the InputFilters and the Commands as if it is received now,
+// so the DeadLetter is kept again with a new ID when the Bot still fails to respond.
+func ReplayDeadLetter(id string) error {
+	return runnerStatus.components.replayDeadLetter(id)
+}
+
+// DiscardDeadLetter forgets the DeadLetter with the given ID without replaying it.
+func DiscardDeadLetter(id string) error {
+	return runnerStatus.components.discardDeadLetter(id)
+}
+
+// deadLetterQueue keeps the DeadLetters up to its size. The oldest one is dropped when a new one comes beyond the size.
+type deadLetterQueue struct {
+	size    int
+	letters []*DeadLetter
+}
+
+// enableDeadLetters enables the DeadLetters with the given size. The DeadLetters are not kept when the size is not positive.
+func (m *managedComponents) enableDeadLetters(size int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if size <= 0 {
+		m.deadLetters = nil
+		return
+	}
+	m.deadLetters = &deadLetterQueue{size: size}
+}
+
+// deadLetter keeps the given DeadLetter. false is returned when the DeadLetters are not enabled.
+func (m *managedComponents) deadLetter(letter *DeadLetter) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.deadLetters == nil {
+		return false
+	}
+	if len(m.deadLetters.letters) >= m.deadLetters.size {
+		m.deadLetters.letters = m.deadLetters.letters[len(m.deadLetters.letters)-m.deadLetters.size+1:]
+	}
+	m.deadLetters.letters = append(m.deadLetters.letters, letter)
+	return true
+}
+
+func (m *managedComponents) listDeadLetters() []*DeadLetter {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.deadLetters == nil {
+		return nil
+	}
+	letters := make([]*DeadLetter, len(m.deadLetters.letters))
+	copy(letters, m.deadLetters.letters)
+	return letters
+}
+
+func (m *managedComponents) replayDeadLetter(id string) error {
+	m.mutex.Lock()
+	letter, ok := m.findDeadLetter(id)
+	if !ok {
+		m.mutex.Unlock()
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+	receive, ok := m.receivers[letter.BotType]
+	if !ok {
+		// Keep the DeadLetter so it can be replayed when the Bot runs again.
+		m.mutex.Unlock()
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, letter.BotType)
+	}
+	m.removeDeadLetter(id)
+	m.mutex.Unlock()
+
+	// Call the function without the lock since a failure on the replay keeps a new DeadLetter.
+	receive(letter.Input)
+	return nil
+}
+
+func (m *managedComponents) discardDeadLetter(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.findDeadLetter(id); !ok {
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+	m.removeDeadLetter(id)
+	return nil
+}
+
+// findDeadLetter returns the DeadLetter with the given ID. The caller must hold the lock.
+func (m *managedComponents) findDeadLetter(id string) (*DeadLetter, bool) {
+	if m.deadLetters == nil {
+		return nil, false
+	}
+	for _, letter := range m.deadLetters.letters {
+		if letter.ID == id {
+			return letter, true
+		}
+	}
+	return nil, false
+}
+
+// removeDeadLetter forgets the DeadLetter with the given ID. The caller must hold the lock.
+func (m *managedComponents) removeDeadLetter(id string) {
+	letters := m.deadLetters.letters[:0]
+	for _, letter := range m.deadLetters.letters {
+		if letter.ID != id {
+			letters = append(letters, letter)
+		}
+	}
+	m.deadLetters.letters = letters
+}
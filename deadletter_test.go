@@ -0,0 +1,91 @@
+package sarah
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestListDeadLetters(t *testing.T) {
+	SetupAndRun(func() {
+		if runnerStatus.components.deadLetter(&DeadLetter{ID: "1"}) {
+			t.Error("DeadLetter must not be kept when the queue is not enabled.")
+		}
+		if len(ListDeadLetters()) != 0 {
+			t.Errorf("Unexpected dead letters are listed: %#v.", ListDeadLetters())
+		}
+
+		runnerStatus.components.enableDeadLetters(2)
+		now := time.Now()
+		for i, id := range []string{"1", "2", "3"} {
+			runnerStatus.components.deadLetter(&DeadLetter{ID: id, BotType: "dummy", Time: now.Add(time.Duration(i) * time.Second)})
+		}
+
+		letters := ListDeadLetters()
+		if len(letters) != 2 || letters[0].ID != "2" || letters[1].ID != "3" {
+			t.Errorf("The oldest dead letter must be dropped: %#v.", letters)
+		}
+	})
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	SetupAndRun(func() {
+		runnerStatus.components.enableDeadLetters(10)
+		input := &DummyInput{}
+		runnerStatus.components.deadLetter(&DeadLetter{ID: "1", BotType: "dummy", Input: input, Err: errors.New("error")})
+
+		err := ReplayDeadLetter("unknown")
+		if !errors.Is(err, ErrDeadLetterNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		err = ReplayDeadLetter("1")
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+		if len(ListDeadLetters()) != 1 {
+			t.Error("DeadLetter must be kept when the Bot is not running.")
+		}
+
+		var received []Input
+		runnerStatus.components.receiver("dummy", func(input Input) {
+			received = append(received, input)
+		})
+
+		err = ReplayDeadLetter("1")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(received) != 1 || received[0] != input {
+			t.Errorf("Input is not replayed: %#v.", received)
+		}
+		if len(ListDeadLetters()) != 0 {
+			t.Errorf("Replayed dead letter must be forgotten: %#v.", ListDeadLetters())
+		}
+
+		runnerStatus.components.removeBot("dummy")
+	})
+}
+
+func TestDiscardDeadLetter(t *testing.T) {
+	SetupAndRun(func() {
+		err := DiscardDeadLetter("1")
+		if !errors.Is(err, ErrDeadLetterNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		runnerStatus.components.enableDeadLetters(10)
+		runnerStatus.components.deadLetter(&DeadLetter{ID: "1", BotType: "dummy"})
+		runnerStatus.components.deadLetter(&DeadLetter{ID: "2", BotType: "dummy"})
+
+		err = DiscardDeadLetter("1")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		letters := ListDeadLetters()
+		if len(letters) != 1 || letters[0].ID != "2" {
+			t.Errorf("Unexpected dead letters are listed: %#v.", letters)
+		}
+	})
+}
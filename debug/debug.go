@@ -0,0 +1,284 @@
+/*
+Package debug provides an optional sarah.Command that helps administrators investigate a misbehaving bot in production.
+
+The command responds to below inputs:
+
+	.debug goroutines   a summary of the running goroutines' stack traces
+	.debug memstats     the memory allocator's statistics and the number of goroutines
+	.debug workers      the worker pool's statistics given by WithStatsProvider
+	.debug pprof start  starts a net/http/pprof server on Config.PprofAddress
+	.debug pprof stop   stops the pprof server
+
+Because the output exposes the process' internals, only the senders listed in Config.AdminKeys or allowed by WithAuthorizer
+can use the command. For other senders, the command does not match and is not listed in the help.
+
+	config := debug.NewConfig()
+	config.AdminKeys = []string{"C12345|U12345"} // Input.SenderKey() of the administrator
+	sarah.RegisterCommand(slack.SLACK, debug.NewCommand(config))
+*/
+package debug
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/workers"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"regexp"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identifier is the identifier of the Command this package provides.
+const Identifier = "debug"
+
+var matchPattern = regexp.MustCompile(`^\.debug(\s|$)`)
+
+// Config contains some configuration variables for the debug command.
+type Config struct {
+	// AdminKeys is the list of Input.SenderKey() values that are allowed to use the command.
+	AdminKeys []string `json:"admin_keys" yaml:"admin_keys"`
+
+	// PprofAddress is the address that the pprof server listens on.
+	// Keep this bound to the loopback interface unless the network is trusted.
+	PprofAddress string `json:"pprof_address" yaml:"pprof_address"`
+
+	// MaxOutputLength is the maximum length of a response. A longer response is truncated.
+	// Zero means no limit.
+	MaxOutputLength int `json:"max_output_length" yaml:"max_output_length"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+// When the Config's AdminKeys is left empty, no one can use the command unless WithAuthorizer is given.
+func NewConfig() *Config {
+	return &Config{
+		AdminKeys:       []string{},
+		PprofAddress:    "localhost:6060",
+		MaxOutputLength: 3000,
+	}
+}
+
+// StatsProvider defines an interface that provides the worker pool's statistics.
+// workers.Worker satisfies this interface.
+type StatsProvider interface {
+	Stats() *workers.Stats
+}
+
+// CommandOption defines a function signature that NewCommand's functional option must satisfy.
+type CommandOption func(*command)
+
+// WithAuthorizer creates a CommandOption that sets a sarah.Authorizer to judge if the sender of the given Input is an administrator.
+// This takes precedence over Config.AdminKeys.
+func WithAuthorizer(authorizer sarah.Authorizer) CommandOption {
+	return func(c *command) {
+		c.authorize = authorizer
+	}
+}
+
+// WithStatsProvider creates a CommandOption that sets the source of the worker pool's statistics.
+// To use this, build a worker with workers.Run and register it with sarah.RegisterWorker;
+// the worker that go-sarah's core builds by default is not exposed.
+func WithStatsProvider(provider StatsProvider) CommandOption {
+	return func(c *command) {
+		c.stats = provider
+	}
+}
+
+type command struct {
+	config    *Config
+	authorize sarah.Authorizer
+	stats     StatsProvider
+	server    *http.Server
+	mutex     sync.Mutex
+}
+
+var _ sarah.Command = (*command)(nil)
+
+// NewCommand creates and returns a new sarah.Command that provides the debugging features.
+func NewCommand(config *Config, options ...CommandOption) sarah.Command {
+	c := &command{
+		config: config,
+	}
+	c.authorize = sarah.SenderKeyAuthorizer(config.AdminKeys)
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// Identifier returns the command ID.
+func (c *command) Identifier() string {
+	return Identifier
+}
+
+// Instruction provides the input instruction only for the administrators.
+func (c *command) Instruction(input *sarah.HelpInput) string {
+	if input.OriginalInput == nil || !c.authorize(input.OriginalInput) {
+		return ""
+	}
+	return ".debug goroutines|memstats|workers|pprof start|pprof stop"
+}
+
+// Match checks if the input is a debug command sent by an administrator.
+func (c *command) Match(input sarah.Input) bool {
+	return matchPattern.Copy().MatchString(input.Message()) && c.authorize(input)
+}
+
+// Execute runs the given sub-command and returns its result.
+func (c *command) Execute(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	args := strings.Fields(sarah.StripMessage(matchPattern, input.Message()))
+	if len(args) == 0 {
+		return c.respond("Usage: " + c.Instruction(&sarah.HelpInput{OriginalInput: input})), nil
+	}
+
+	switch args[0] {
+	case "goroutines":
+		return c.respond(goroutines()), nil
+
+	case "memstats":
+		return c.respond(memStats()), nil
+
+	case "workers":
+		if c.stats == nil {
+			return c.respond("Worker statistics are not available."), nil
+		}
+		return c.respond(workerStats(c.stats.Stats())), nil
+
+	case "pprof":
+		if len(args) > 1 && args[1] == "stop" {
+			return c.respond(c.stopPprof()), nil
+		}
+		return c.respond(c.startPprof()), nil
+
+	default:
+		return c.respond(fmt.Sprintf("Unknown sub-command: %s", args[0])), nil
+
+	}
+}
+
+func (c *command) respond(text string) *sarah.CommandResponse {
+	if c.config.MaxOutputLength > 0 && len(text) > c.config.MaxOutputLength {
+		text = text[:c.config.MaxOutputLength] + "\n...(truncated)"
+	}
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}
+}
+
+func goroutines() string {
+	buf := &bytes.Buffer{}
+	// With debug=1, the goroutines with the same stack trace are grouped so the output stays readable in a chat.
+	_ = runtimepprof.Lookup("goroutine").WriteTo(buf, 1)
+	return buf.String()
+}
+
+func memStats() string {
+	stats := &runtime.MemStats{}
+	runtime.ReadMemStats(stats)
+
+	lines := []string{
+		fmt.Sprintf("Goroutines: %d", runtime.NumGoroutine()),
+		fmt.Sprintf("Alloc: %s", bytesString(stats.Alloc)),
+		fmt.Sprintf("TotalAlloc: %s", bytesString(stats.TotalAlloc)),
+		fmt.Sprintf("Sys: %s", bytesString(stats.Sys)),
+		fmt.Sprintf("HeapInuse: %s", bytesString(stats.HeapInuse)),
+		fmt.Sprintf("HeapObjects: %d", stats.HeapObjects),
+		fmt.Sprintf("NumGC: %d", stats.NumGC),
+		fmt.Sprintf("PauseTotal: %s", time.Duration(stats.PauseTotalNs)),
+	}
+	return strings.Join(lines, "\n")
+}
+
+func workerStats(stats *workers.Stats) string {
+	lines := []string{
+		fmt.Sprintf("Queue: %d/%d", stats.QueueSize, stats.QueueCapacity),
+		fmt.Sprintf("Workers: %d (active: %d)", stats.WorkerNum, stats.ActiveWorkers),
+		fmt.Sprintf("Processed: %d", stats.Processed),
+		fmt.Sprintf("Failed: %d", stats.Failed),
+		fmt.Sprintf("Expired: %d", stats.Expired),
+		fmt.Sprintf("Rejected: %d", stats.Rejected),
+		fmt.Sprintf("Dropped: %d", stats.Dropped),
+		fmt.Sprintf("Replaced: %d", stats.Replaced),
+	}
+	return strings.Join(lines, "\n")
+}
+
+func bytesString(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func (c *command) startPprof() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.server != nil {
+		return fmt.Sprintf("pprof server is already running on %s.", c.server.Addr)
+	}
+
+	listener, err := net.Listen("tcp", c.config.PprofAddress)
+	if err != nil {
+		return fmt.Sprintf("Failed to start pprof server: %s", err.Error())
+	}
+
+	// Use a dedicated ServeMux so the handlers are not exposed on http.DefaultServeMux.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	server := &http.Server{
+		Addr:    listener.Addr().String(),
+		Handler: mux,
+	}
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.GetLogger().Module("debug").Error("pprof server stopped unexpectedly", logging.Err(err))
+		}
+	}()
+	c.server = server
+
+	return fmt.Sprintf("pprof server is running on http://%s/debug/pprof/", server.Addr)
+}
+
+func (c *command) stopPprof() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.server == nil {
+		return "pprof server is not running."
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.server.Shutdown(ctx)
+	c.server = nil
+	if err != nil {
+		return fmt.Sprintf("Failed to stop pprof server gracefully: %s", err.Error())
+	}
+
+	return "pprof server is stopped."
+}
@@ -0,0 +1,262 @@
+package debug
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/workers"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+}
+
+var _ sarah.Input = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "dummy"
+}
+
+type DummyStatsProvider struct {
+	StatsFunc func() *workers.Stats
+}
+
+func (p *DummyStatsProvider) Stats() *workers.Stats {
+	return p.StatsFunc()
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.PprofAddress == "" {
+		t.Error("Default pprof address is not set.")
+	}
+
+	if len(config.AdminKeys) != 0 {
+		t.Errorf("No admin must be set by default: %#v.", config.AdminKeys)
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	provider := &DummyStatsProvider{}
+	cmd := NewCommand(NewConfig(), WithStatsProvider(provider), WithAuthorizer(func(_ sarah.Input) bool {
+		return true
+	}))
+
+	typed, ok := cmd.(*command)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", cmd)
+	}
+
+	if typed.stats != provider {
+		t.Error("Given StatsProvider is not set.")
+	}
+
+	if !typed.authorize(&DummyInput{}) {
+		t.Error("Given authorizer is not set.")
+	}
+
+	if cmd.Identifier() != Identifier {
+		t.Errorf("Unexpected identifier is returned: %s.", cmd.Identifier())
+	}
+}
+
+func TestCommand_Match(t *testing.T) {
+	config := NewConfig()
+	config.AdminKeys = []string{"admin"}
+	cmd := NewCommand(config)
+
+	tests := []struct {
+		input   *DummyInput
+		matched bool
+	}{
+		{
+			input:   &DummyInput{SenderKeyValue: "admin", MessageValue: ".debug memstats"},
+			matched: true,
+		},
+		{
+			input:   &DummyInput{SenderKeyValue: "admin", MessageValue: ".debug"},
+			matched: true,
+		},
+		{
+			input:   &DummyInput{SenderKeyValue: "admin", MessageValue: ".debugger"},
+			matched: false,
+		},
+		{
+			input:   &DummyInput{SenderKeyValue: "someone", MessageValue: ".debug memstats"},
+			matched: false,
+		},
+	}
+
+	for i, tt := range tests {
+		if cmd.Match(tt.input) != tt.matched {
+			t.Errorf("Unexpected result is returned on test #%d.", i)
+		}
+	}
+}
+
+func TestCommand_Instruction(t *testing.T) {
+	config := NewConfig()
+	config.AdminKeys = []string{"admin"}
+	cmd := NewCommand(config)
+
+	if cmd.Instruction(&sarah.HelpInput{OriginalInput: &DummyInput{SenderKeyValue: "admin"}}) == "" {
+		t.Error("Instruction must be returned for an administrator.")
+	}
+
+	if cmd.Instruction(&sarah.HelpInput{OriginalInput: &DummyInput{SenderKeyValue: "someone"}}) != "" {
+		t.Error("Instruction must not be returned for a non-administrator.")
+	}
+}
+
+func TestCommand_Execute(t *testing.T) {
+	config := NewConfig()
+	config.MaxOutputLength = 0
+	cmd := NewCommand(config, WithStatsProvider(&DummyStatsProvider{
+		StatsFunc: func() *workers.Stats {
+			return &workers.Stats{QueueSize: 3, QueueCapacity: 10}
+		},
+	}))
+
+	tests := []struct {
+		message  string
+		contains string
+	}{
+		{
+			message:  ".debug",
+			contains: "Usage",
+		},
+		{
+			message:  ".debug goroutines",
+			contains: "goroutine profile",
+		},
+		{
+			message:  ".debug memstats",
+			contains: "HeapObjects",
+		},
+		{
+			message:  ".debug workers",
+			contains: "Queue: 3/10",
+		},
+		{
+			message:  ".debug foo",
+			contains: "Unknown sub-command: foo",
+		},
+	}
+
+	for i, tt := range tests {
+		res, err := cmd.Execute(context.TODO(), &DummyInput{MessageValue: tt.message})
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+
+		text, ok := res.Content.(string)
+		if !ok {
+			t.Errorf("Unexpected content is returned on test #%d: %#v.", i, res.Content)
+			continue
+		}
+
+		if !strings.Contains(text, tt.contains) {
+			t.Errorf("Expected text is not contained on test #%d: %s.", i, text)
+		}
+	}
+}
+
+func TestCommand_Execute_WithoutStatsProvider(t *testing.T) {
+	cmd := NewCommand(NewConfig())
+
+	res, _ := cmd.Execute(context.TODO(), &DummyInput{MessageValue: ".debug workers"})
+
+	if res.Content != "Worker statistics are not available." {
+		t.Errorf("Unexpected content is returned: %#v.", res.Content)
+	}
+}
+
+func TestCommand_respond(t *testing.T) {
+	config := NewConfig()
+	config.MaxOutputLength = 5
+	c := NewCommand(config).(*command)
+
+	res := c.respond("1234567890")
+
+	if res.Content != "12345\n...(truncated)" {
+		t.Errorf("Long text is not truncated: %#v.", res.Content)
+	}
+}
+
+func TestCommand_pprof(t *testing.T) {
+	config := NewConfig()
+	config.PprofAddress = "127.0.0.1:0"
+	c := NewCommand(config).(*command)
+
+	res, _ := c.Execute(context.TODO(), &DummyInput{MessageValue: ".debug pprof start"})
+	if !strings.Contains(res.Content.(string), "is running on") {
+		t.Fatalf("Unexpected content is returned: %#v.", res.Content)
+	}
+
+	resp, err := http.Get("http://" + c.server.Addr + "/debug/pprof/cmdline")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code is returned: %d.", resp.StatusCode)
+	}
+
+	res, _ = c.Execute(context.TODO(), &DummyInput{MessageValue: ".debug pprof start"})
+	if !strings.Contains(res.Content.(string), "already running") {
+		t.Errorf("Unexpected content is returned: %#v.", res.Content)
+	}
+
+	res, _ = c.Execute(context.TODO(), &DummyInput{MessageValue: ".debug pprof stop"})
+	if res.Content != "pprof server is stopped." {
+		t.Errorf("Unexpected content is returned: %#v.", res.Content)
+	}
+
+	res, _ = c.Execute(context.TODO(), &DummyInput{MessageValue: ".debug pprof stop"})
+	if res.Content != "pprof server is not running." {
+		t.Errorf("Unexpected content is returned: %#v.", res.Content)
+	}
+}
+
+func Test_bytesString(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		str   string
+	}{
+		{
+			bytes: 512,
+			str:   "512 B",
+		},
+		{
+			bytes: 1536,
+			str:   "1.5 KiB",
+		},
+		{
+			bytes: 3 * 1024 * 1024,
+			str:   "3.0 MiB",
+		},
+	}
+
+	for i, tt := range tests {
+		if str := bytesString(tt.bytes); str != tt.str {
+			t.Errorf("Unexpected string is returned on test #%d: %s.", i, str)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"time"
+)
+
+// ErrDelayedSendingNotSupported is returned when the given context does not carry a Bot that satisfies DelayedSender.
+var ErrDelayedSendingNotSupported = errors.New("delayed message sending is not supported")
+
+// DelayedSender defines an optional interface that a Bot may implement to send a message later.
+// The Bot created by NewBot satisfies this interface and schedules the message with the Runner's scheduler.
+//
+// A scheduled message lives only in memory, so the message is not sent when the Bot stops before the scheduled time.
+type DelayedSender interface {
+	// SendMessageAt sends the given message at the given time.
+	// The message is sent immediately when the given time is already past.
+	SendMessageAt(ctx context.Context, output Output, at time.Time)
+
+	// SendMessageAfter sends the given message after the given duration.
+	SendMessageAfter(ctx context.Context, output Output, delay time.Duration)
+}
+
+type delayedSenderKey struct{}
+
+// newDelayedSenderContext returns a copy of the given context that carries the given DelayedSender.
+// The Bot created by NewBot passes such a context to Command.Execute so the Command can call SendMessageAt or SendMessageAfter.
+func newDelayedSenderContext(ctx context.Context, sender DelayedSender) context.Context {
+	return context.WithValue(ctx, delayedSenderKey{}, sender)
+}
+
+// SendMessageAt sends the given message at the given time via the Bot that executes the Command.
+// Pass the context given to Command.Execute or ContextualFunc so a Command can promise a later message:
+//
+//  func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//  	reminder := sarah.NewOutputMessage(input.ReplyTo(), "Time to stretch.")
+//  	err := sarah.SendMessageAfter(ctx, reminder, 10*time.Minute)
+//  	if err != nil {
+//  		return nil, err
+//  	}
+//  	return slack.NewResponse(input, "I'll remind you in 10 minutes.")
+//  }
+//
+// ErrDelayedSendingNotSupported is returned when the context does not carry a Bot that satisfies DelayedSender.
+func SendMessageAt(ctx context.Context, output Output, at time.Time) error {
+	sender, ok := ctx.Value(delayedSenderKey{}).(DelayedSender)
+	if !ok {
+		return ErrDelayedSendingNotSupported
+	}
+
+	sender.SendMessageAt(ctx, output, at)
+	return nil
+}
+
+// SendMessageAfter sends the given message after the given duration via the Bot that executes the Command.
+// See SendMessageAt for the detail.
+func SendMessageAfter(ctx context.Context, output Output, delay time.Duration) error {
+	return SendMessageAt(ctx, output, clock.FromContext(ctx).Now().Add(delay))
+}
+
+var _ DelayedSender = (*defaultBot)(nil)
+
+// SendMessageAt sends the given message at the given time.
+// The message is scheduled with the Runner's scheduler carried by the given context, which is the case for the context given to Bot.Run and Command.Execute.
+// Otherwise, a timer is set for the message with the clock.Clock carried by the given context.
+// The message is not sent when the given context is canceled by the scheduled time.
+func (bot *defaultBot) SendMessageAt(ctx context.Context, output Output, at time.Time) {
+	sendMessageAt(ctx, bot, output, at)
+}
+
+// sendMessageAt sends the given message via the given Bot at the given time. See defaultBot.SendMessageAt for the detail.
+func sendMessageAt(ctx context.Context, bot Bot, output Output, at time.Time) {
+	send := func() {
+		if ctx.Err() != nil {
+			contextLogger(ctx).Warn("Skip delayed message since the context is canceled")
+			return
+		}
+		_, _ = bot.SendMessage(ctx, output)
+	}
+
+	s := schedulerFromContext(ctx)
+	if s == nil {
+		c := clock.FromContext(ctx)
+		c.AfterFunc(at.Sub(c.Now()), send)
+		return
+	}
+
+	s.once(at, send)
+}
+
+// SendMessageAfter sends the given message after the given duration.
+// See SendMessageAt for the detail.
+func (bot *defaultBot) SendMessageAfter(ctx context.Context, output Output, delay time.Duration) {
+	bot.SendMessageAt(ctx, output, clock.FromContext(ctx).Now().Add(delay))
+}
@@ -0,0 +1,163 @@
+package sarah
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type DummyDelayedSender struct {
+	SendMessageAtFunc    func(context.Context, Output, time.Time)
+	SendMessageAfterFunc func(context.Context, Output, time.Duration)
+}
+
+var _ DelayedSender = (*DummyDelayedSender)(nil)
+
+func (s *DummyDelayedSender) SendMessageAt(ctx context.Context, output Output, at time.Time) {
+	s.SendMessageAtFunc(ctx, output, at)
+}
+
+func (s *DummyDelayedSender) SendMessageAfter(ctx context.Context, output Output, delay time.Duration) {
+	s.SendMessageAfterFunc(ctx, output, delay)
+}
+
+func TestSendMessageAt(t *testing.T) {
+	err := SendMessageAt(context.TODO(), NewOutputMessage("dummy", "message"), time.Now())
+	if err != ErrDelayedSendingNotSupported {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	var givenAt time.Time
+	sender := &DummyDelayedSender{
+		SendMessageAtFunc: func(_ context.Context, _ Output, at time.Time) {
+			givenAt = at
+		},
+	}
+	ctx := newDelayedSenderContext(context.TODO(), sender)
+
+	at := time.Now().Add(time.Hour)
+	err = SendMessageAt(ctx, NewOutputMessage("dummy", "message"), at)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !givenAt.Equal(at) {
+		t.Errorf("Unexpected time is given: %s.", givenAt)
+	}
+
+	err = SendMessageAfter(ctx, NewOutputMessage("dummy", "message"), time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if givenAt.Before(at) {
+		t.Errorf("Unexpected time is given: %s.", givenAt)
+	}
+}
+
+func TestDefaultBot_SendMessageAt(t *testing.T) {
+	sent := make(chan Output, 1)
+	bot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent <- output
+			return nil, nil
+		},
+	}
+
+	var scheduled func()
+	var givenAt time.Time
+	s := &DummyScheduler{
+		OnceFunc: func(at time.Time, fn func()) {
+			givenAt = at
+			scheduled = fn
+		},
+	}
+	ctx := withScheduler(context.Background(), s)
+
+	at := time.Now().Add(time.Hour)
+	bot.SendMessageAt(ctx, NewOutputMessage("dummy", "message"), at)
+
+	if !givenAt.Equal(at) {
+		t.Errorf("Unexpected time is given: %s.", givenAt)
+	}
+	if scheduled == nil {
+		t.Fatal("Message is not scheduled.")
+	}
+
+	scheduled()
+
+	select {
+	case output := <-sent:
+		if output.Content() != "message" {
+			t.Errorf("Unexpected content is sent: %#v.", output.Content())
+		}
+
+	default:
+		t.Error("Scheduled message is not sent.")
+
+	}
+}
+
+func TestDefaultBot_SendMessageAt_Canceled(t *testing.T) {
+	bot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
+			t.Error("Message must not be sent with a canceled context.")
+			return nil, nil
+		},
+	}
+
+	var scheduled func()
+	s := &DummyScheduler{
+		OnceFunc: func(_ time.Time, fn func()) {
+			scheduled = fn
+		},
+	}
+	ctx, cancel := context.WithCancel(withScheduler(context.Background(), s))
+
+	bot.SendMessageAt(ctx, NewOutputMessage("dummy", "message"), time.Now())
+	cancel()
+	scheduled()
+}
+
+func TestDefaultBot_SendMessageAfter_WithoutScheduler(t *testing.T) {
+	sent := make(chan Output, 1)
+	bot := &defaultBot{
+		sendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent <- output
+			return nil, nil
+		},
+	}
+
+	bot.SendMessageAfter(context.Background(), NewOutputMessage("dummy", "message"), 10*time.Millisecond)
+
+	select {
+	case <-sent:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Error("Delayed message is not sent.")
+
+	}
+}
+
+func TestDefaultBot_Respond_WithDelayedSender(t *testing.T) {
+	var err error
+	command := &DummyCommand{
+		MatchFunc: func(_ Input) bool {
+			return true
+		},
+		ExecuteFunc: func(ctx context.Context, _ Input) (*CommandResponse, error) {
+			err = SendMessageAfter(ctx, NewOutputMessage("dummy", "later"), time.Hour)
+			return nil, nil
+		},
+	}
+	bot := &defaultBot{
+		commands: &Commands{collection: []Command{command}},
+	}
+
+	_ = bot.Respond(withScheduler(context.Background(), &DummyScheduler{
+		OnceFunc: func(_ time.Time, _ func()) {},
+	}), &DummyInput{})
+
+	if err != nil {
+		t.Errorf("Command can not schedule a delayed message: %s.", err.Error())
+	}
+}
@@ -1,5 +1,105 @@
 package sarah
 
+import (
+	"errors"
+)
+
 // OutputDestination defines interface that every Bot/Adapter MUST satisfy to represent where the sending message is heading to,
 // which actually means empty interface.
 type OutputDestination interface{}
+
+// ThreadDestination is an OutputDestination that tells the message is sent as a reply in a thread.
+// go-sarah's core replies with this destination when the Input is sent in a thread,
+// or when BotWithReplyInThread is given and the Input tells its message ID.
+//
+// An Adapter for a chat service without threads can call BaseDestination to ignore the thread.
+type ThreadDestination struct {
+	// Destination is the original destination such as a channel.
+	Destination OutputDestination
+
+	// ThreadID is the identifier of the thread to reply in.
+	ThreadID string
+}
+
+// NewThreadDestination creates and returns a new ThreadDestination.
+func NewThreadDestination(destination OutputDestination, threadID string) *ThreadDestination {
+	return &ThreadDestination{
+		Destination: destination,
+		ThreadID:    threadID,
+	}
+}
+
+// PrivateDestination is an OutputDestination that tells the message must be visible only to the given user.
+// go-sarah's core sends a response with this destination when CommandResponse.Private is true.
+//
+// An Adapter should send the message in a way that only the user can see such as Slack's ephemeral message or a direct message.
+// When the Adapter can not do so, the Adapter must not send the message to the original destination since the content may be sensitive.
+type PrivateDestination struct {
+	// Destination is the original destination such as a channel.
+	Destination OutputDestination
+
+	// UserID is the identifier of the user who can see the message. See SenderIDInput.
+	UserID string
+}
+
+// ErrSenderNotIdentified is returned when a private response can not be sent because the Input does not tell its sender.
+// See SenderIDInput.
+var ErrSenderNotIdentified = errors.New("sender of the input can not be identified to send a private response")
+
+// NewPrivateDestination creates and returns a new PrivateDestination.
+func NewPrivateDestination(destination OutputDestination, userID string) *PrivateDestination {
+	return &PrivateDestination{
+		Destination: destination,
+		UserID:      userID,
+	}
+}
+
+// BaseDestination returns the destination wrapped by ThreadDestination or PrivateDestination,
+// or the given destination as-is when it is not wrapped.
+func BaseDestination(destination OutputDestination) OutputDestination {
+	for {
+		switch typed := destination.(type) {
+		case *ThreadDestination:
+			destination = typed.Destination
+
+		case *PrivateDestination:
+			destination = typed.Destination
+
+		default:
+			return destination
+
+		}
+	}
+}
+
+// ThreadID returns the thread ID when the given destination is or wraps ThreadDestination.
+// An empty string is returned otherwise.
+func ThreadID(destination OutputDestination) string {
+	switch typed := destination.(type) {
+	case *ThreadDestination:
+		return typed.ThreadID
+
+	case *PrivateDestination:
+		return ThreadID(typed.Destination)
+
+	default:
+		return ""
+
+	}
+}
+
+// PrivateUserID returns the user ID when the given destination is or wraps PrivateDestination.
+// An empty string is returned otherwise.
+func PrivateUserID(destination OutputDestination) string {
+	switch typed := destination.(type) {
+	case *PrivateDestination:
+		return typed.UserID
+
+	case *ThreadDestination:
+		return PrivateUserID(typed.Destination)
+
+	default:
+		return ""
+
+	}
+}
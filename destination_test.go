@@ -0,0 +1,103 @@
+package sarah
+
+import (
+	"testing"
+)
+
+func TestNewThreadDestination(t *testing.T) {
+	dest := NewThreadDestination("#general", "thread")
+
+	if dest.Destination != "#general" {
+		t.Errorf("Unexpected destination is set: %#v.", dest.Destination)
+	}
+
+	if dest.ThreadID != "thread" {
+		t.Errorf("Unexpected thread ID is set: %s.", dest.ThreadID)
+	}
+}
+
+func TestNewPrivateDestination(t *testing.T) {
+	dest := NewPrivateDestination("#general", "user")
+
+	if dest.Destination != "#general" {
+		t.Errorf("Unexpected destination is set: %#v.", dest.Destination)
+	}
+
+	if dest.UserID != "user" {
+		t.Errorf("Unexpected user ID is set: %s.", dest.UserID)
+	}
+}
+
+func TestBaseDestination(t *testing.T) {
+	if dest := BaseDestination(NewThreadDestination("#general", "thread")); dest != "#general" {
+		t.Errorf("Wrapped destination is not returned: %#v.", dest)
+	}
+
+	if dest := BaseDestination(NewPrivateDestination(NewThreadDestination("#general", "thread"), "user")); dest != "#general" {
+		t.Errorf("Wrapped destination is not returned: %#v.", dest)
+	}
+
+	if dest := BaseDestination("#random"); dest != "#random" {
+		t.Errorf("Given destination is not returned as-is: %#v.", dest)
+	}
+}
+
+func TestThreadID(t *testing.T) {
+	tests := []struct {
+		destination OutputDestination
+		threadID    string
+	}{
+		{
+			destination: "#general",
+			threadID:    "",
+		},
+		{
+			destination: NewThreadDestination("#general", "thread"),
+			threadID:    "thread",
+		},
+		{
+			destination: NewPrivateDestination(NewThreadDestination("#general", "thread"), "user"),
+			threadID:    "thread",
+		},
+		{
+			destination: NewPrivateDestination("#general", "user"),
+			threadID:    "",
+		},
+	}
+
+	for i, tt := range tests {
+		if id := ThreadID(tt.destination); id != tt.threadID {
+			t.Errorf("Unexpected thread ID is returned on test #%d: %s.", i, id)
+		}
+	}
+}
+
+func TestPrivateUserID(t *testing.T) {
+	tests := []struct {
+		destination OutputDestination
+		userID      string
+	}{
+		{
+			destination: "#general",
+			userID:      "",
+		},
+		{
+			destination: NewPrivateDestination("#general", "user"),
+			userID:      "user",
+		},
+		{
+			destination: NewThreadDestination(NewPrivateDestination("#general", "user"), "thread"),
+			userID:      "user",
+		},
+		{
+			destination: NewThreadDestination("#general", "thread"),
+			userID:      "",
+		},
+	}
+
+	for i, tt := range tests {
+		if id := PrivateUserID(tt.destination); id != tt.userID {
+			t.Errorf("Unexpected user ID is returned on test #%d: %s.", i, id)
+		}
+	}
+}
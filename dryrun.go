@@ -0,0 +1,103 @@
+package sarah
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"sync"
+	"time"
+)
+
+// DryRunRecord represents a message that the default Bot implementation did not send in dry-run mode.
+type DryRunRecord struct {
+	BotType     BotType
+	Destination OutputDestination
+	Content     interface{}
+	Time        time.Time
+}
+
+// DryRunRecorder receives the messages that are not sent in dry-run mode. See RegisterDryRun.
+// Record is called in the goroutine that sends the message, so this must be thread-safe and return quickly.
+type DryRunRecorder interface {
+	Record(ctx context.Context, record *DryRunRecord)
+}
+
+type dryRun struct {
+	recorder DryRunRecorder
+}
+
+type dryRunKey struct{}
+
+// withDryRun returns a copy of the given context that tells the Bot to run in dry-run mode.
+func withDryRun(ctx context.Context, d *dryRun) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, d)
+}
+
+// dryRunFromContext returns the dry-run setting carried by the given context.
+// false is returned when the Bot is not in dry-run mode.
+func dryRunFromContext(ctx context.Context) (*dryRun, bool) {
+	d, ok := ctx.Value(dryRunKey{}).(*dryRun)
+	return d, ok
+}
+
+// record logs the given message and passes it to the DryRunRecorder.
+func (d *dryRun) record(ctx context.Context, botType BotType, output Output) {
+	record := &DryRunRecord{
+		BotType:     botType,
+		Destination: output.Destination(),
+		Content:     output.Content(),
+		Time:        clock.FromContext(ctx).Now(),
+	}
+
+	contextLogger(ctx).Info(
+		"Dry run: message is not sent",
+		logging.F(logging.KeyBotType, botType),
+		logging.F(logging.KeyDestination, record.Destination),
+		logging.F("content", record.Content),
+	)
+
+	if d.recorder != nil {
+		d.recorder.Record(ctx, record)
+	}
+}
+
+// BufferedDryRunRecorder is a DryRunRecorder that keeps the latest DryRunRecords up to its size.
+type BufferedDryRunRecorder struct {
+	mutex   sync.RWMutex
+	size    int
+	records []*DryRunRecord
+}
+
+var _ DryRunRecorder = (*BufferedDryRunRecorder)(nil)
+
+// NewBufferedDryRunRecorder creates and returns a new BufferedDryRunRecorder that keeps up to the given number of records.
+// The oldest record is dropped when a new one comes beyond the size.
+func NewBufferedDryRunRecorder(size int) *BufferedDryRunRecorder {
+	return &BufferedDryRunRecorder{
+		size: size,
+	}
+}
+
+// Record keeps the given DryRunRecord.
+func (r *BufferedDryRunRecorder) Record(_ context.Context, record *DryRunRecord) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.size <= 0 {
+		return
+	}
+	if len(r.records) >= r.size {
+		r.records = r.records[len(r.records)-r.size+1:]
+	}
+	r.records = append(r.records, record)
+}
+
+// Records returns the kept DryRunRecords from the oldest one.
+func (r *BufferedDryRunRecorder) Records() []*DryRunRecord {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	records := make([]*DryRunRecord, len(r.records))
+	copy(records, r.records)
+	return records
+}
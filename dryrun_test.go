@@ -0,0 +1,135 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"testing"
+	"time"
+)
+
+func TestBufferedDryRunRecorder(t *testing.T) {
+	recorder := NewBufferedDryRunRecorder(2)
+	for _, content := range []string{"first", "second", "third"} {
+		recorder.Record(context.TODO(), &DryRunRecord{Content: content})
+	}
+
+	records := recorder.Records()
+	if len(records) != 2 || records[0].Content != "second" || records[1].Content != "third" {
+		t.Errorf("The oldest record must be dropped: %#v.", records)
+	}
+}
+
+func TestDefaultBot_SendMessage_DryRun(t *testing.T) {
+	bus := NewEventBus()
+	var events []Event
+	bus.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	recorder := NewBufferedDryRunRecorder(10)
+	ctx := clock.WithContext(NewEventBusContext(context.Background(), bus), clock.NewFake(now))
+	ctx = withDryRun(ctx, &dryRun{recorder: recorder})
+
+	bot := &defaultBot{
+		botType: "dummy",
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
+			t.Error("Adapter.SendMessage must not be called in dry-run mode.")
+			return nil, nil
+		},
+	}
+
+	result, err := bot.SendMessage(ctx, NewOutputMessage("#general", "hello"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if result.Destination != "#general" || !result.SentAt.Equal(now) {
+		t.Errorf("Unexpected result is returned: %#v.", result)
+	}
+
+	records := recorder.Records()
+	if len(records) != 1 {
+		t.Fatalf("Unexpected number of records are kept: %d.", len(records))
+	}
+	if records[0].BotType != "dummy" || records[0].Destination != "#general" || records[0].Content != "hello" || !records[0].Time.Equal(now) {
+		t.Errorf("Unexpected record is kept: %#v.", records[0])
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Unexpected number of events are published: %d.", len(events))
+	}
+	if _, ok := events[0].(*MessageSent); !ok {
+		t.Errorf("MessageSent is not published: %#v.", events[0])
+	}
+}
+
+func TestDefaultBot_SendMessages_DryRun(t *testing.T) {
+	recorder := NewBufferedDryRunRecorder(10)
+	ctx := withDryRun(context.Background(), &dryRun{recorder: recorder})
+
+	bot := &defaultBot{
+		botType: "dummy",
+		sendMessageFunc: func(_ context.Context, _ Output) (*SendResult, error) {
+			t.Error("Adapter.SendMessage must not be called in dry-run mode.")
+			return nil, nil
+		},
+		batchSender: &DummyBatchSender{
+			SendMessagesFunc: func(_ context.Context, _ []Output) ([]*SendResult, error) {
+				t.Error("BatchSender.SendMessages must not be called in dry-run mode.")
+				return nil, nil
+			},
+		},
+	}
+
+	results, err := bot.SendMessages(ctx, []Output{NewOutputMessage("#general", "hello"), NewOutputMessage("#random", "hi")})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(results) != 2 {
+		t.Errorf("Unexpected number of results are returned: %d.", len(results))
+	}
+	if len(recorder.Records()) != 2 {
+		t.Errorf("Unexpected number of records are kept: %d.", len(recorder.Records()))
+	}
+}
+
+func TestDefaultBot_MessageEditing_DryRun(t *testing.T) {
+	recorder := NewBufferedDryRunRecorder(10)
+	ctx := withDryRun(context.Background(), &dryRun{recorder: recorder})
+	editorErr := errors.New("MessageEditor must not be called in dry-run mode")
+	bot := &defaultBot{
+		botType: "dummy",
+		editor: &DummyMessageEditor{
+			SendMessageWithHandleFunc: func(_ context.Context, _ Output) (*MessageHandle, error) {
+				return nil, editorErr
+			},
+			UpdateMessageFunc: func(_ context.Context, _ *MessageHandle, _ interface{}) error {
+				return editorErr
+			},
+			DeleteMessageFunc: func(_ context.Context, _ *MessageHandle) error {
+				return editorErr
+			},
+		},
+	}
+
+	handle, err := bot.SendMessageWithHandle(ctx, NewOutputMessage("#general", "hello"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if handle.Destination != "#general" {
+		t.Errorf("Unexpected handle is returned: %#v.", handle)
+	}
+
+	if err := bot.UpdateMessage(ctx, handle, "updated"); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if err := bot.DeleteMessage(ctx, handle); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	records := recorder.Records()
+	if len(records) != 2 || records[1].Content != "updated" {
+		t.Errorf("Unexpected records are kept: %#v.", records)
+	}
+}
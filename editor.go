@@ -0,0 +1,36 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMessageEditingNotSupported is returned when the Bot or the Adapter does not support editing or deleting a sent message.
+var ErrMessageEditingNotSupported = errors.New("message editing is not supported")
+
+// MessageHandle identifies a message sent by MessageEditor.SendMessageWithHandle.
+// Pass this to MessageEditor.UpdateMessage or MessageEditor.DeleteMessage to modify the message later.
+type MessageHandle struct {
+	// Destination is the destination that the message was sent to.
+	Destination OutputDestination
+
+	// MessageID is the chat service specific identifier of the message such as Slack's timestamp.
+	MessageID string
+}
+
+// MessageEditor defines an optional interface that a Bot or an Adapter may implement to edit or delete a message after it is sent.
+// This is handy for a progress-style Command that keeps updating a single status message instead of sending many messages.
+//
+// The Bot created by NewBot satisfies this interface and delegates the operations to the Adapter.
+// When the Adapter does not satisfy this interface, each method returns ErrMessageEditingNotSupported.
+// To update a message from a Command, return StreamContent with StreamInPlace instead of calling these methods directly.
+type MessageEditor interface {
+	// SendMessageWithHandle sends the given message and returns the handle to the sent message.
+	SendMessageWithHandle(context.Context, Output) (*MessageHandle, error)
+
+	// UpdateMessage replaces the content of the message identified by the given handle.
+	UpdateMessage(ctx context.Context, handle *MessageHandle, content interface{}) error
+
+	// DeleteMessage deletes the message identified by the given handle.
+	DeleteMessage(ctx context.Context, handle *MessageHandle) error
+}
@@ -0,0 +1,25 @@
+package sarah
+
+import (
+	"context"
+)
+
+type DummyMessageEditor struct {
+	SendMessageWithHandleFunc func(context.Context, Output) (*MessageHandle, error)
+	UpdateMessageFunc         func(context.Context, *MessageHandle, interface{}) error
+	DeleteMessageFunc         func(context.Context, *MessageHandle) error
+}
+
+var _ MessageEditor = (*DummyMessageEditor)(nil)
+
+func (e *DummyMessageEditor) SendMessageWithHandle(ctx context.Context, output Output) (*MessageHandle, error) {
+	return e.SendMessageWithHandleFunc(ctx, output)
+}
+
+func (e *DummyMessageEditor) UpdateMessage(ctx context.Context, handle *MessageHandle, content interface{}) error {
+	return e.UpdateMessageFunc(ctx, handle, content)
+}
+
+func (e *DummyMessageEditor) DeleteMessage(ctx context.Context, handle *MessageHandle) error {
+	return e.DeleteMessageFunc(ctx, handle)
+}
@@ -1,9 +1,21 @@
 package sarah
 
 import (
+	"errors"
 	"fmt"
 )
 
+var (
+	// ErrNotAuthorized is a category of CommandError that tells the sender is not allowed to execute the Command.
+	ErrNotAuthorized = errors.New("not authorized")
+
+	// ErrBadArgs is a category of CommandError that tells the Input has invalid arguments for the Command.
+	ErrBadArgs = errors.New("bad arguments")
+
+	// ErrUpstreamFailure is a category of CommandError that tells an external service the Command depends on failed.
+	ErrUpstreamFailure = errors.New("upstream failure")
+)
+
 // BotNonContinuableError represents critical error that Bot can't continue its operation.
 // When Runner receives this, it must stop corresponding Bot, and should inform administrator by available mean.
 type BotNonContinuableError struct {
@@ -26,8 +38,9 @@ func NewBotNonContinuableError(errorContent string) error {
 //
 // Possible cure includes having more workers and/or more worker queue size,
 // but developers MUST aware that this modification may cause more concurrent Command.Execute and Bot.SendMessage operation.
-// With that said, increase workers by setting bigger number to worker.Config.WorkerNum to allow more concurrent executions and minimize the delay;
-// increase worker queue size by setting bigger number to worker.Config.QueueSize to allow delay and have same concurrent execution number.
+// With that said, increase workers by setting bigger number to workers.Config.WorkerNum to allow more concurrent executions and minimize the delay;
+// increase worker queue size by setting bigger number to workers.Config.QueueSize to allow delay and have same concurrent execution number.
+// workers.Config.OverflowPolicy also lets the worker pool block or drop such overflowing input instead of returning this error.
 type BlockedInputError struct {
 	ContinuationCount int
 }
@@ -43,3 +56,84 @@ func (e BlockedInputError) Error() string {
 func NewBlockedInputError(i int) error {
 	return &BlockedInputError{ContinuationCount: i}
 }
+
+// CommandError represents an error on Command execution that carries a message safe to show the sender, apart from the internal error.
+// When Command.Execute or ContextualFunc returns this, the Bot created by NewBot replies with UserMessage and the Runner logs Err.
+//
+//  user, err := client.FindUser(ctx, name)
+//  if err != nil {
+//  	return nil, sarah.NewCommandError(sarah.ErrUpstreamFailure, "The user directory is not available now.", err)
+//  }
+//
+// Category is one of ErrNotAuthorized, ErrBadArgs and ErrUpstreamFailure, or nil, and errors.Is reports whether CommandError belongs to the category.
+// The Runner does not keep the Input as a DeadLetter when the category is ErrNotAuthorized or ErrBadArgs since the Input is never handled successfully.
+type CommandError struct {
+	Category    error
+	UserMessage string
+	Err         error
+}
+
+// NewCommandError creates and returns a new CommandError.
+// When userMessage is empty, a generic message of the category is sent to the sender.
+func NewCommandError(category error, userMessage string, err error) *CommandError {
+	return &CommandError{
+		Category:    category,
+		UserMessage: userMessage,
+		Err:         err,
+	}
+}
+
+// Error returns the internal error with its category. This is not meant to be shown to the sender.
+func (e *CommandError) Error() string {
+	switch {
+	case e.Category == nil && e.Err == nil:
+		return "command error"
+
+	case e.Category == nil:
+		return e.Err.Error()
+
+	case e.Err == nil:
+		return e.Category.Error()
+
+	default:
+		return fmt.Sprintf("%s: %s", e.Category.Error(), e.Err.Error())
+
+	}
+}
+
+// Unwrap returns the internal error.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the given error is the category of this CommandError.
+func (e *CommandError) Is(target error) bool {
+	return e.Category != nil && e.Category == target
+}
+
+// Reply returns the message to show the sender. An empty string is returned when there is nothing to show.
+func (e *CommandError) Reply() string {
+	if e.UserMessage != "" {
+		return e.UserMessage
+	}
+
+	switch e.Category {
+	case ErrNotAuthorized:
+		return "Sorry, you are not allowed to do this."
+
+	case ErrBadArgs:
+		return "Sorry, the given arguments are not valid."
+
+	case ErrUpstreamFailure:
+		return "Sorry, a service I depend on is not available now. Please try again later."
+
+	default:
+		return ""
+
+	}
+}
+
+// isUserError tells if the given error is caused by the sender rather than by the Command or its dependencies.
+func isUserError(err error) bool {
+	return errors.Is(err, ErrNotAuthorized) || errors.Is(err, ErrBadArgs)
+}
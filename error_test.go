@@ -1,6 +1,8 @@
 package sarah
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"testing"
@@ -36,3 +38,63 @@ func TestBlockedInputError_Error(t *testing.T) {
 		t.Errorf("Returned string does not contain the count of error occurrence: %s.", err.Error())
 	}
 }
+
+func TestCommandError_Error(t *testing.T) {
+	tests := []struct {
+		err      *CommandError
+		expected string
+	}{
+		{
+			err:      NewCommandError(ErrUpstreamFailure, "", errors.New("timeout")),
+			expected: "upstream failure: timeout",
+		},
+		{
+			err:      NewCommandError(nil, "", errors.New("timeout")),
+			expected: "timeout",
+		},
+		{
+			err:      NewCommandError(ErrBadArgs, "Give a date.", nil),
+			expected: "bad arguments",
+		},
+	}
+
+	for i, tt := range tests {
+		if tt.err.Error() != tt.expected {
+			t.Errorf("Unexpected message is returned on test #%d: %s.", i, tt.err.Error())
+		}
+	}
+}
+
+func TestCommandError_Is(t *testing.T) {
+	internal := errors.New("timeout")
+	err := fmt.Errorf("wrapped: %w", NewCommandError(ErrUpstreamFailure, "", internal))
+
+	if !errors.Is(err, ErrUpstreamFailure) {
+		t.Error("CommandError must be its category.")
+	}
+	if errors.Is(err, ErrBadArgs) {
+		t.Error("CommandError must not be other category.")
+	}
+	if !errors.Is(err, internal) {
+		t.Error("CommandError must unwrap the internal error.")
+	}
+}
+
+func TestCommandError_Reply(t *testing.T) {
+	err := NewCommandError(ErrNotAuthorized, "Only admins can do this.", nil)
+	if err.Reply() != "Only admins can do this." {
+		t.Errorf("Given message is not returned: %s.", err.Reply())
+	}
+
+	for _, category := range []error{ErrNotAuthorized, ErrBadArgs, ErrUpstreamFailure} {
+		err := NewCommandError(category, "", nil)
+		if err.Reply() == "" {
+			t.Errorf("Generic message is not returned for %s.", category.Error())
+		}
+	}
+
+	err = NewCommandError(nil, "", errors.New("internal"))
+	if err.Reply() != "" {
+		t.Errorf("Internal error must not be replied: %s.", err.Reply())
+	}
+}
@@ -0,0 +1,174 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"strings"
+	"text/template"
+)
+
+// Error categories given to the templates of ErrorReplyConfig as ErrorReplyData.Category.
+const (
+	ErrorCategoryNotAuthorized   = "not_authorized"
+	ErrorCategoryBadArgs         = "bad_args"
+	ErrorCategoryUpstreamFailure = "upstream_failure"
+	ErrorCategoryTimeout         = "timeout"
+	ErrorCategoryInternal        = "internal"
+)
+
+// ErrorReplyConfig contains the text/templates of the replies sent when a Command or a ContextualFunc returns an error.
+// Each template is rendered with ErrorReplyData, and no reply is sent for the category when its template is empty.
+type ErrorReplyConfig struct {
+	// NotAuthorized is the template for the CommandError with ErrNotAuthorized.
+	NotAuthorized string `json:"not_authorized" yaml:"not_authorized"`
+
+	// BadArgs is the template for the CommandError with ErrBadArgs.
+	BadArgs string `json:"bad_args" yaml:"bad_args"`
+
+	// UpstreamFailure is the template for the CommandError with ErrUpstreamFailure.
+	UpstreamFailure string `json:"upstream_failure" yaml:"upstream_failure"`
+
+	// Timeout is the template for the error caused by the execution exceeding its deadline.
+	Timeout string `json:"timeout" yaml:"timeout"`
+
+	// Internal is the template for any other error.
+	Internal string `json:"internal" yaml:"internal"`
+}
+
+// NewErrorReplyConfig returns a pointer to ErrorReplyConfig with default setting.
+// The default templates give the correlation ID so the users can quote it when reporting the failure.
+func NewErrorReplyConfig() *ErrorReplyConfig {
+	return &ErrorReplyConfig{
+		NotAuthorized:   "{{.Message}}",
+		BadArgs:         "{{.Message}}",
+		UpstreamFailure: "{{.Message}} (ID: {{.CorrelationID}})",
+		Timeout:         "Sorry, your request timed out. Please try again later. (ID: {{.CorrelationID}})",
+		Internal:        "Sorry, I failed to handle your request. Please quote this ID when reporting: {{.CorrelationID}}",
+	}
+}
+
+// Validate checks that all templates can be parsed.
+func (c *ErrorReplyConfig) Validate() error {
+	var errs ConfigKeyErrors
+	for _, key := range []string{ErrorCategoryNotAuthorized, ErrorCategoryBadArgs, ErrorCategoryUpstreamFailure, ErrorCategoryTimeout, ErrorCategoryInternal} {
+		text := c.template(key)
+		if _, err := template.New(key).Parse(text); err != nil {
+			errs = append(errs, &ConfigKeyError{Key: key, Value: text, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *ErrorReplyConfig) template(category string) string {
+	switch category {
+	case ErrorCategoryNotAuthorized:
+		return c.NotAuthorized
+
+	case ErrorCategoryBadArgs:
+		return c.BadArgs
+
+	case ErrorCategoryUpstreamFailure:
+		return c.UpstreamFailure
+
+	case ErrorCategoryTimeout:
+		return c.Timeout
+
+	default:
+		return c.Internal
+
+	}
+}
+
+// ErrorReplyData is the data given to the templates of ErrorReplyConfig.
+type ErrorReplyData struct {
+	// Category is one of the ErrorCategory* constants.
+	Category string
+
+	// Message is CommandError.Reply of the returned CommandError. This is empty for other errors.
+	Message string
+
+	// CorrelationID is the ID given to the Input on reception. The logs on the failed execution carry the same ID.
+	CorrelationID string
+
+	BotType BotType
+}
+
+// BotWithErrorReply creates and returns DefaultBotOption to reply with the templates of the given ErrorReplyConfig when a Command fails.
+// Without this, the Bot replies only with CommandError.Reply and stays silent on other errors.
+//
+//  config := sarah.NewErrorReplyConfig()
+//  config.Internal = "Something went wrong. Tell #bot-support this ID: {{.CorrelationID}}"
+//  bot := sarah.NewBot(myAdapter, sarah.BotWithErrorReply(config))
+//
+// Call ErrorReplyConfig.Validate beforehand since an invalid template sends no reply.
+func BotWithErrorReply(config *ErrorReplyConfig) DefaultBotOption {
+	return func(bot *defaultBot) {
+		bot.errorReply = config
+	}
+}
+
+// errorCategory returns the ErrorCategory* constant of the given error.
+func errorCategory(err error) string {
+	switch {
+	case errors.Is(err, ErrNotAuthorized):
+		return ErrorCategoryNotAuthorized
+
+	case errors.Is(err, ErrBadArgs):
+		return ErrorCategoryBadArgs
+
+	case errors.Is(err, ErrUpstreamFailure):
+		return ErrorCategoryUpstreamFailure
+
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCategoryTimeout
+
+	default:
+		return ErrorCategoryInternal
+
+	}
+}
+
+// errorReplyText returns the reply to the sender for the given error. false is returned when nothing is to be sent.
+func errorReplyText(ctx context.Context, config *ErrorReplyConfig, botType BotType, err error) (string, bool) {
+	var message string
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) {
+		message = commandErr.Reply()
+	}
+
+	if config == nil {
+		return message, message != ""
+	}
+
+	category := errorCategory(err)
+	text := config.template(category)
+	if text == "" {
+		return "", false
+	}
+
+	tmpl, parseErr := template.New(category).Parse(text)
+	if parseErr != nil {
+		contextLogger(ctx).Error("Failed to parse error reply template", logging.F("category", category), logging.Err(parseErr))
+		return "", false
+	}
+
+	builder := &strings.Builder{}
+	execErr := tmpl.Execute(builder, &ErrorReplyData{
+		Category:      category,
+		Message:       message,
+		CorrelationID: CorrelationID(ctx),
+		BotType:       botType,
+	})
+	if execErr != nil {
+		contextLogger(ctx).Error("Failed to render error reply template", logging.F("category", category), logging.Err(execErr))
+		return "", false
+	}
+
+	reply := strings.TrimSpace(builder.String())
+	return reply, reply != ""
+}
@@ -0,0 +1,111 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorReplyConfig_Validate(t *testing.T) {
+	config := NewErrorReplyConfig()
+	err := config.Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	config.BadArgs = "{{.Message"
+	config.Internal = "{{end}}"
+	err = config.Validate()
+	errs, ok := err.(ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Unexpected error is returned: %#v.", err)
+	}
+	if len(errs) != 2 || errs[0].Key != ErrorCategoryBadArgs || errs[1].Key != ErrorCategoryInternal {
+		t.Errorf("Unexpected errors are returned: %s.", errs.Error())
+	}
+}
+
+func TestBotWithErrorReply(t *testing.T) {
+	bot := &defaultBot{}
+	config := NewErrorReplyConfig()
+
+	BotWithErrorReply(config)(bot)
+
+	if bot.errorReply != config {
+		t.Error("Option is not applied.")
+	}
+}
+
+func Test_errorCategory(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{
+			err:      NewCommandError(ErrNotAuthorized, "", nil),
+			expected: ErrorCategoryNotAuthorized,
+		},
+		{
+			err:      NewCommandError(ErrBadArgs, "", nil),
+			expected: ErrorCategoryBadArgs,
+		},
+		{
+			err:      fmt.Errorf("wrapped: %w", NewCommandError(ErrUpstreamFailure, "", nil)),
+			expected: ErrorCategoryUpstreamFailure,
+		},
+		{
+			err:      fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			expected: ErrorCategoryTimeout,
+		},
+		{
+			err:      errors.New("unexpected"),
+			expected: ErrorCategoryInternal,
+		},
+	}
+
+	for i, tt := range tests {
+		category := errorCategory(tt.err)
+		if category != tt.expected {
+			t.Errorf("Unexpected category is returned on test #%d: %s.", i, category)
+		}
+	}
+}
+
+func Test_errorReplyText(t *testing.T) {
+	ctx := WithCorrelationID(context.TODO(), "abc")
+
+	_, ok := errorReplyText(ctx, nil, "dummy", errors.New("internal"))
+	if ok {
+		t.Error("Nothing must be replied to an internal error without ErrorReplyConfig.")
+	}
+
+	reply, ok := errorReplyText(ctx, nil, "dummy", NewCommandError(ErrBadArgs, "Give a date.", nil))
+	if !ok || reply != "Give a date." {
+		t.Errorf("Unexpected reply is returned: %s.", reply)
+	}
+
+	config := NewErrorReplyConfig()
+	config.Internal = "{{.BotType}} failed on {{.Category}}: {{.CorrelationID}}"
+	reply, ok = errorReplyText(ctx, config, "dummy", errors.New("internal"))
+	if !ok || reply != "dummy failed on internal: abc" {
+		t.Errorf("Unexpected reply is returned: %s.", reply)
+	}
+
+	reply, ok = errorReplyText(ctx, config, "dummy", NewCommandError(ErrUpstreamFailure, "Weather API is down.", nil))
+	if !ok || reply != "Weather API is down. (ID: abc)" {
+		t.Errorf("Unexpected reply is returned: %s.", reply)
+	}
+
+	config.Timeout = ""
+	_, ok = errorReplyText(ctx, config, "dummy", context.DeadlineExceeded)
+	if ok {
+		t.Error("Nothing must be replied when the template is empty.")
+	}
+
+	config.Internal = "{{.Unknown}}"
+	_, ok = errorReplyText(ctx, config, "dummy", errors.New("internal"))
+	if ok {
+		t.Error("Nothing must be replied when the template fails.")
+	}
+}
@@ -0,0 +1,339 @@
+package sarah
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"sync"
+	"time"
+)
+
+// Event represents a notification on go-sarah's lifecycle that is published to EventBus.
+// A subscriber may use a type switch to handle the events of its interest:
+//
+//  sarah.RegisterEventSubscriber(func(e sarah.Event) {
+//  	switch ev := e.(type) {
+//  	case *sarah.CommandExecuted:
+//  		metrics.ObserveCommand(ev.BotType, ev.CommandID, ev.Elapsed)
+//
+//  	case *sarah.CommandFailed:
+//  		metrics.CountFailure(ev.BotType, ev.CommandID)
+//
+//  	}
+//  })
+type Event interface {
+	// OccurredAt returns the time when the event occurred.
+	OccurredAt() time.Time
+}
+
+// BotStarted is published when a Bot starts running.
+type BotStarted struct {
+	BotType BotType
+	Time    time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *BotStarted) OccurredAt() time.Time {
+	return e.Time
+}
+
+// BotStopped is published when a Bot stops running.
+type BotStopped struct {
+	BotType BotType
+	Time    time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *BotStopped) OccurredAt() time.Time {
+	return e.Time
+}
+
+// BotConnected is published by an Adapter when it establishes the connection to the service provider.
+// Outage is the duration since the connection was lost, and is zero on the initial connection.
+// Connection identifies the connection when the Adapter holds multiple connections such as one for each chat room.
+//
+//  sarah.RegisterEventSubscriber(func(e sarah.Event) {
+//  	if ev, ok := e.(*sarah.BotConnected); ok && ev.Outage > time.Minute {
+//  		go notifyOps(fmt.Sprintf("%s reconnected after %s outage", ev.BotType, ev.Outage))
+//  	}
+//  })
+type BotConnected struct {
+	BotType    BotType
+	Connection string
+	Outage     time.Duration
+	Time       time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *BotConnected) OccurredAt() time.Time {
+	return e.Time
+}
+
+// BotDisconnected is published by an Adapter when the connection to the service provider is closed.
+// Err tells why the connection is lost, and is nil when the connection is closed intentionally such as on shutdown.
+type BotDisconnected struct {
+	BotType    BotType
+	Connection string
+	Err        error
+	Time       time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *BotDisconnected) OccurredAt() time.Time {
+	return e.Time
+}
+
+// BotReconnecting is published by an Adapter before it tries to connect to the service provider again.
+// Attempt is the number of the attempt since the connection is lost, which starts from 1.
+type BotReconnecting struct {
+	BotType    BotType
+	Connection string
+	Attempt    uint
+	Time       time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *BotReconnecting) OccurredAt() time.Time {
+	return e.Time
+}
+
+// CommandExecuted is published when a Command is executed without an error.
+type CommandExecuted struct {
+	BotType       BotType
+	CommandID     string
+	CorrelationID string
+	Elapsed       time.Duration
+	Time          time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *CommandExecuted) OccurredAt() time.Time {
+	return e.Time
+}
+
+// CommandFailed is published when a Command's execution returns an error.
+type CommandFailed struct {
+	BotType       BotType
+	CommandID     string
+	CorrelationID string
+	Elapsed       time.Duration
+	Err           error
+	Time          time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *CommandFailed) OccurredAt() time.Time {
+	return e.Time
+}
+
+// TaskExecuted is published when a ScheduledTask is executed.
+// Err is set when the execution returns an error.
+type TaskExecuted struct {
+	BotType BotType
+	TaskID  string
+	Elapsed time.Duration
+	Err     error
+	Time    time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *TaskExecuted) OccurredAt() time.Time {
+	return e.Time
+}
+
+// MessageSent is published by the Bot created by NewBot when Adapter.SendMessage succeeds.
+type MessageSent struct {
+	BotType     BotType
+	Destination OutputDestination
+	MessageID   string
+	Time        time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *MessageSent) OccurredAt() time.Time {
+	return e.Time
+}
+
+// SendFailed is published by the Bot created by NewBot when Adapter.SendMessage returns an error.
+type SendFailed struct {
+	BotType     BotType
+	Destination OutputDestination
+	Err         error
+	Time        time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *SendFailed) OccurredAt() time.Time {
+	return e.Time
+}
+
+// MessageDeadLettered is published by Outbox when a message is given up after the retries or can not be queued.
+type MessageDeadLettered struct {
+	Destination OutputDestination
+	Err         error
+	Time        time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *MessageDeadLettered) OccurredAt() time.Time {
+	return e.Time
+}
+
+// InputDeadLettered is published when the Bot fails to respond to an Input and the Input is kept as a DeadLetter.
+// ID is the DeadLetter's ID to give to ReplayDeadLetter.
+type InputDeadLettered struct {
+	BotType BotType
+	ID      string
+	Err     error
+	Time    time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *InputDeadLettered) OccurredAt() time.Time {
+	return e.Time
+}
+
+// ConfigReloaded is published when a Command or a ScheduledTask is rebuilt on its configuration file update or on a rollback.
+// ID is the identifier of the Command or the ScheduledTask, and Err is set when the rebuild fails.
+// Generation is the ConfigGeneration the rebuilt one runs with, and Changes are the values that differ from the previous generation.
+// Both are left empty when the rebuild fails or when the Command or the ScheduledTask has no configuration.
+type ConfigReloaded struct {
+	BotType    BotType
+	ID         string
+	Generation int
+	Changes    []*ConfigChange
+	Err        error
+	Time       time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *ConfigReloaded) OccurredAt() time.Time {
+	return e.Time
+}
+
+// InputThrottled is published when an InputFilter drops an Input because the sender sends too many messages.
+type InputThrottled struct {
+	BotType   BotType
+	SenderKey string
+	Time      time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *InputThrottled) OccurredAt() time.Time {
+	return e.Time
+}
+
+// InputDropped is published when the input queue of a Bot drops an Input because the queue is full. See InputQueueConfig.
+// With InputQueueDropOldest, SenderKey is the sender of the dropped Input rather than the incoming one.
+type InputDropped struct {
+	BotType   BotType
+	SenderKey string
+	Policy    InputQueuePolicy
+	Time      time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *InputDropped) OccurredAt() time.Time {
+	return e.Time
+}
+
+// InputBlocked is published when an InputFilter drops an Input because the sender or the message is not allowed.
+// Err tells the reason.
+type InputBlocked struct {
+	BotType   BotType
+	SenderKey string
+	Err       error
+	Time      time.Time
+}
+
+// OccurredAt returns the time when the event occurred.
+func (e *InputBlocked) OccurredAt() time.Time {
+	return e.Time
+}
+
+// EventBus defines an interface that delivers published events to the subscribers.
+type EventBus interface {
+	// Subscribe registers the given function to receive the published events.
+	// The returned function removes the subscription.
+	Subscribe(fnc func(Event)) (unsubscribe func())
+
+	// Publish delivers the given event to the subscribers.
+	Publish(event Event)
+}
+
+type subscriber struct {
+	fnc func(Event)
+}
+
+type eventBus struct {
+	subscribers []*subscriber
+	mutex       sync.RWMutex
+}
+
+var _ EventBus = (*eventBus)(nil)
+
+// NewEventBus creates and returns a new EventBus.
+//
+// The returned EventBus calls the subscribers one by one in the goroutine that publishes the event,
+// so a subscriber must return quickly not to block the message handling.
+// Pass the event to another goroutine when a time-consuming operation such as an HTTP request is involved.
+// A panic in a subscriber is recovered and logged so other subscribers still receive the event.
+func NewEventBus() EventBus {
+	return &eventBus{}
+}
+
+func (b *eventBus) Subscribe(fnc func(Event)) func() {
+	s := &subscriber{fnc: fnc}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.subscribers = append(b.subscribers, s)
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		for i, stored := range b.subscribers {
+			if stored == s {
+				b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (b *eventBus) Publish(event Event) {
+	b.mutex.RLock()
+	subscribers := b.subscribers
+	b.mutex.RUnlock()
+
+	for _, s := range subscribers {
+		deliver(s.fnc, event)
+	}
+}
+
+func deliver(fnc func(Event), event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			moduleLogger().Error("Panic in event subscriber", logging.F("event", event), logging.F("panic", r))
+		}
+	}()
+	fnc(event)
+}
+
+type eventBusKey struct{}
+
+// NewEventBusContext returns a copy of the given context that carries the given EventBus.
+// go-sarah's core passes a context with the Runner's EventBus to Bot, so this is rarely needed except in tests.
+func NewEventBusContext(ctx context.Context, bus EventBus) context.Context {
+	return context.WithValue(ctx, eventBusKey{}, bus)
+}
+
+// PublishEvent publishes the given event to the EventBus of the Runner that the given context belongs to.
+// The context given to Bot.Run() and the succeeding operations carry the EventBus,
+// so a Bot or an Adapter implementation can publish an event such as BotStarted.
+// This does nothing when the context carries no EventBus.
+func PublishEvent(ctx context.Context, event Event) {
+	if bus, ok := ctx.Value(eventBusKey{}).(EventBus); ok {
+		bus.Publish(event)
+	}
+}
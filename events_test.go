@@ -0,0 +1,106 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewEventBus(t *testing.T) {
+	bus := NewEventBus()
+	if _, ok := bus.(*eventBus); !ok {
+		t.Errorf("Unexpected type is returned: %T.", bus)
+	}
+}
+
+func TestEventBus_Publish(t *testing.T) {
+	bus := NewEventBus()
+
+	var first []Event
+	unsubscribe := bus.Subscribe(func(e Event) {
+		first = append(first, e)
+	})
+	var second []Event
+	bus.Subscribe(func(e Event) {
+		second = append(second, e)
+	})
+
+	started := &BotStarted{BotType: "dummy", Time: time.Now()}
+	bus.Publish(started)
+
+	unsubscribe()
+	stopped := &BotStopped{BotType: "dummy", Time: time.Now()}
+	bus.Publish(stopped)
+
+	if len(first) != 1 || first[0] != started {
+		t.Errorf("Unexpected events are delivered to the unsubscribed function: %#v.", first)
+	}
+
+	if len(second) != 2 || second[0] != started || second[1] != stopped {
+		t.Errorf("Unexpected events are delivered: %#v.", second)
+	}
+}
+
+func TestEventBus_Publish_WithPanic(t *testing.T) {
+	bus := NewEventBus()
+	bus.Subscribe(func(_ Event) {
+		panic("PANIC!!")
+	})
+	delivered := false
+	bus.Subscribe(func(_ Event) {
+		delivered = true
+	})
+
+	bus.Publish(&BotStarted{BotType: "dummy", Time: time.Now()})
+
+	if !delivered {
+		t.Error("Event is not delivered to the subscriber after a panicking one.")
+	}
+}
+
+func TestPublishEvent(t *testing.T) {
+	// Nothing happens when the context carries no EventBus.
+	PublishEvent(context.TODO(), &BotStarted{})
+
+	bus := NewEventBus()
+	var events []Event
+	bus.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	event := &SendFailed{BotType: "dummy", Destination: "#general", Err: errors.New("dummy"), Time: time.Now()}
+	PublishEvent(NewEventBusContext(context.TODO(), bus), event)
+
+	if len(events) != 1 || events[0] != event {
+		t.Errorf("Given event is not published: %#v.", events)
+	}
+}
+
+func TestEvent_OccurredAt(t *testing.T) {
+	now := time.Now()
+	events := []Event{
+		&BotStarted{Time: now},
+		&BotStopped{Time: now},
+		&BotConnected{Time: now},
+		&BotDisconnected{Time: now},
+		&BotReconnecting{Time: now},
+		&CommandExecuted{Time: now},
+		&CommandFailed{Time: now},
+		&TaskExecuted{Time: now},
+		&MessageSent{Time: now},
+		&SendFailed{Time: now},
+		&MessageDeadLettered{Time: now},
+		&InputDeadLettered{Time: now},
+		&ConfigReloaded{Time: now},
+		&InputThrottled{Time: now},
+		&InputBlocked{Time: now},
+		&InputDropped{Time: now},
+	}
+
+	for _, e := range events {
+		if !e.OccurredAt().Equal(now) {
+			t.Errorf("Unexpected time is returned by %T: %s.", e, e.OccurredAt())
+		}
+	}
+}
@@ -0,0 +1,383 @@
+/*
+Package feed provides a factory of sarah.ScheduledTaskProps that watches RSS and Atom feeds and posts their new items.
+
+The task periodically fetches each configured feed, compares the items with the ones seen on the previous fetch, and posts only the new items.
+The IDs of the seen items are kept in Store, so an item is not posted again after a restart.
+On the very first fetch of a feed, the existing items are only recorded and nothing is posted to avoid flooding the destinations.
+
+	store, err := feed.NewFileStore("/var/lib/sarah/feeds")
+	if err != nil {
+		panic(err)
+	}
+	config := feed.NewConfig()
+	config.Feeds = []*feed.Source{{URL: "https://go.dev/blog/feed.atom", Destinations: []string{"C12345678"}}}
+	props, err := feed.NewScheduledTaskProps(slack.SLACK, "go_blog", config, store, feed.WithDestinationFunc(func(dest string) sarah.OutputDestination {
+		return event.ChannelID(dest)
+	}))
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterScheduledTaskProps(props)
+
+Use NewStore with a sarah.Store to keep the seen IDs in a database shared by multiple processes.
+
+Because the task is built with sarah.ScheduledTaskPropsBuilder.ConfigurableFunc, the schedule and the feeds can be updated by a configuration file named after the task identifier.
+*/
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxSeenItems is the maximum number of item IDs kept for each feed.
+// IDs of items that disappeared from the feed are kept up to this number so they are not posted again when they re-appear.
+const maxSeenItems = 500
+
+// maxSummaryLength is the maximum number of characters of the summary the default formatter posts.
+const maxSummaryLength = 300
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Source is a feed to watch.
+type Source struct {
+	// URL is the URL of the RSS or Atom feed.
+	URL string `json:"url" yaml:"url"`
+
+	// Title replaces the title of the feed in the posted message when given.
+	Title string `json:"title" yaml:"title"`
+
+	// Destinations are where the new items of the feed are posted.
+	// Config.Destinations are used when this is empty.
+	Destinations []string `json:"destinations" yaml:"destinations"`
+}
+
+// Config contains some configuration variables for the feed watcher task.
+type Config struct {
+	// TaskSchedule is the schedule of the feed watcher task in a form of cron spec such as "@every 15m".
+	TaskSchedule string `json:"schedule" yaml:"schedule"`
+
+	// Feeds are the feeds to watch.
+	Feeds []*Source `json:"feeds" yaml:"feeds"`
+
+	// Destinations are where the new items are posted when Source.Destinations is empty.
+	Destinations []string `json:"destinations" yaml:"destinations"`
+
+	// Timeout is the timeout to fetch each feed.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// MaxItems is the maximum number of new items posted for each feed on one execution.
+	// When more items are found, the newest ones are posted and the rest are only recorded as seen.
+	MaxItems int `json:"max_items" yaml:"max_items"`
+
+	// MaxResponseBytes is the maximum size of a feed document to read.
+	MaxResponseBytes int64 `json:"max_response_bytes" yaml:"max_response_bytes"`
+}
+
+var _ sarah.ScheduledConfig = (*Config)(nil)
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		TaskSchedule:     "@every 15m",
+		Feeds:            []*Source{},
+		Destinations:     []string{},
+		Timeout:          10 * time.Second,
+		MaxItems:         5,
+		MaxResponseBytes: 5 * 1024 * 1024,
+	}
+}
+
+// Schedule returns the schedule of the feed watcher task.
+func (c *Config) Schedule() string {
+	return c.TaskSchedule
+}
+
+// ApplyDefaults sets the default values to Timeout, MaxItems and MaxResponseBytes when they are not given.
+func (c *Config) ApplyDefaults() {
+	defaults := NewConfig()
+
+	if c.Timeout == 0 {
+		c.Timeout = defaults.Timeout
+	}
+
+	if c.MaxItems == 0 {
+		c.MaxItems = defaults.MaxItems
+	}
+
+	if c.MaxResponseBytes == 0 {
+		c.MaxResponseBytes = defaults.MaxResponseBytes
+	}
+}
+
+// Validate checks that the schedule is given and that every feed has a URL and at least one destination.
+func (c *Config) Validate() error {
+	var errs sarah.ConfigKeyErrors
+
+	if c.TaskSchedule == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "schedule", Err: errors.New("schedule is empty")})
+	}
+
+	for i, source := range c.Feeds {
+		key := fmt.Sprintf("feeds[%d]", i)
+		if source == nil {
+			errs = append(errs, &sarah.ConfigKeyError{Key: key, Err: errors.New("feed is empty")})
+			continue
+		}
+
+		if source.URL == "" {
+			errs = append(errs, &sarah.ConfigKeyError{Key: key + ".url", Err: errors.New("url is empty")})
+		}
+
+		if len(source.Destinations) == 0 && len(c.Destinations) == 0 {
+			errs = append(errs, &sarah.ConfigKeyError{Key: key + ".destinations", Err: errors.New("no destination is given for the feed or as default")})
+		}
+	}
+
+	if c.Timeout < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "timeout", Value: c.Timeout.String(), Err: errors.New("timeout must not be negative")})
+	}
+
+	if c.MaxItems < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "max_items", Value: fmt.Sprint(c.MaxItems), Err: errors.New("max_items must not be negative")})
+	}
+
+	if c.MaxResponseBytes < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "max_response_bytes", Value: fmt.Sprint(c.MaxResponseBytes), Err: errors.New("max_response_bytes must not be negative")})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Option defines function signature that NewScheduledTaskProps's functional option must satisfy.
+type Option func(*watcher)
+
+// WithHTTPClient creates an Option that replaces the default http.Client.
+// Config.Timeout still applies to each request.
+func WithHTTPClient(client *http.Client) Option {
+	return func(w *watcher) {
+		w.client = client
+	}
+}
+
+// WithDestinationFunc creates an Option that converts a destination in Config to sarah.OutputDestination the Bot's Adapter accepts.
+// By default, the string is used as is. Slack, for example, requires event.ChannelID instead.
+func WithDestinationFunc(fnc func(string) sarah.OutputDestination) Option {
+	return func(w *watcher) {
+		w.destination = fnc
+	}
+}
+
+// WithFormatter creates an Option that replaces how a new item is posted.
+// The returned value is sent as the content of sarah.ScheduledTaskResult.
+// By default, the item is posted as sarah.RichMessage with its title, link, and summary.
+func WithFormatter(fnc func(*Feed, *Item) interface{}) Option {
+	return func(w *watcher) {
+		w.format = fnc
+	}
+}
+
+// NewScheduledTaskProps creates and returns sarah.ScheduledTaskProps that watches the feeds in the given Config.
+// The IDs of the seen items are kept in the given Store.
+func NewScheduledTaskProps(botType sarah.BotType, id string, config *Config, store Store, options ...Option) (*sarah.ScheduledTaskProps, error) {
+	err := sarah.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		store:  store,
+		client: &http.Client{},
+		destination: func(dest string) sarah.OutputDestination {
+			return dest
+		},
+		format: format,
+	}
+	for _, opt := range options {
+		opt(w)
+	}
+
+	return sarah.NewScheduledTaskPropsBuilder().
+		BotType(botType).
+		Identifier(id).
+		ConfigurableFunc(config, func(ctx context.Context, c sarah.TaskConfig) ([]*sarah.ScheduledTaskResult, error) {
+			return w.execute(ctx, c.(*Config)), nil
+		}).
+		Build()
+}
+
+type watcher struct {
+	store       Store
+	client      *http.Client
+	destination func(string) sarah.OutputDestination
+	format      func(*Feed, *Item) interface{}
+}
+
+// execute checks all feeds and returns the results to post.
+// An error on one feed is logged and does not prevent others from being checked.
+func (w *watcher) execute(ctx context.Context, config *Config) []*sarah.ScheduledTaskResult {
+	var results []*sarah.ScheduledTaskResult
+	for _, source := range config.Feeds {
+		items, feed, err := w.check(ctx, config, source)
+		if err != nil {
+			logging.FromContext(ctx).Module("feed").Error("Failed to check feed", logging.F("url", source.URL), logging.Err(err))
+			continue
+		}
+
+		destinations := source.Destinations
+		if len(destinations) == 0 {
+			destinations = config.Destinations
+		}
+
+		for _, item := range items {
+			content := w.format(feed, item)
+			for _, dest := range destinations {
+				results = append(results, &sarah.ScheduledTaskResult{
+					Content:     content,
+					Destination: w.destination(dest),
+				})
+			}
+		}
+	}
+	return results
+}
+
+// check fetches the given feed and returns its new items in the order to post, which is the oldest first.
+func (w *watcher) check(ctx context.Context, config *Config, source *Source) ([]*Item, *Feed, error) {
+	feed, err := w.fetch(ctx, config, source.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if source.Title != "" {
+		feed.Title = source.Title
+	}
+
+	seenIDs, fetched, err := w.store.Load(source.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load seen items: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, id := range seenIDs {
+		seen[id] = true
+	}
+
+	var newItems []*Item
+	ids := make([]string, 0, len(feed.Items)+len(seenIDs))
+	current := map[string]bool{}
+	for _, item := range feed.Items {
+		if current[item.ID] {
+			continue
+		}
+		current[item.ID] = true
+		ids = append(ids, item.ID)
+
+		if fetched && !seen[item.ID] {
+			newItems = append(newItems, item)
+		}
+	}
+	for _, id := range seenIDs {
+		if !current[id] {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > maxSeenItems {
+		ids = ids[:maxSeenItems]
+	}
+
+	// Record the items before posting so a failure on sending does not cause the same items to be posted repeatedly.
+	err = w.store.Save(source.URL, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save seen items: %w", err)
+	}
+
+	// A feed lists the newest items first. Keep the newest ones and post them from the oldest.
+	if config.MaxItems > 0 && len(newItems) > config.MaxItems {
+		newItems = newItems[:config.MaxItems]
+	}
+	for i, j := 0, len(newItems)-1; i < j; i, j = i+1, j-1 {
+		newItems[i], newItems[j] = newItems[j], newItems[i]
+	}
+
+	return newItems, feed, nil
+}
+
+func (w *watcher) fetch(ctx context.Context, config *Config, url string) (*Feed, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	resp, err := w.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code is returned: %d", resp.StatusCode)
+	}
+
+	var reader io.Reader = resp.Body
+	if config.MaxResponseBytes > 0 {
+		reader = io.LimitReader(resp.Body, config.MaxResponseBytes)
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	feed, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	feed.URL = url
+	return feed, nil
+}
+
+// format is the default formatter that posts the item as sarah.RichMessage.
+func format(feed *Feed, item *Item) interface{} {
+	message := &sarah.RichMessage{
+		Title:    item.Title,
+		TitleURL: item.Link,
+		Text:     summarize(item.Summary),
+	}
+	if message.Title == "" {
+		message.Title = item.Link
+	}
+	if feed.Title != "" {
+		message.Fields = []*sarah.RichField{{Title: "Feed", Value: feed.Title, Short: true}}
+	}
+	return message
+}
+
+// summarize strips the HTML tags from the given summary and truncates it to maxSummaryLength characters.
+func summarize(summary string) string {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(summary, " "))
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) > maxSummaryLength {
+		return strings.TrimSpace(string(runes[:maxSummaryLength])) + "..."
+	}
+	return text
+}
@@ -0,0 +1,237 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type feedServer struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	guids  []string
+	status int
+}
+
+func newFeedServer(guids ...string) *feedServer {
+	s := &feedServer{guids: guids, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+
+		var items []string
+		for _, guid := range s.guids {
+			items = append(items, fmt.Sprintf("<item><title>Title %s</title><link>https://example.com/%s</link><guid>%s</guid></item>", guid, guid, guid))
+		}
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Example</title>%s</channel></rss>`, strings.Join(items, ""))
+	}))
+	return s
+}
+
+func (s *feedServer) set(guids ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.guids = guids
+}
+
+func titles(results []*sarah.ScheduledTaskResult) []string {
+	var titles []string
+	for _, res := range results {
+		titles = append(titles, fmt.Sprintf("%s>%s", res.Content.(*sarah.RichMessage).Title, res.Destination))
+	}
+	return titles
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.Schedule() == "" {
+		t.Error("Default schedule is not set.")
+	}
+	if config.Timeout <= 0 || config.MaxItems <= 0 || config.MaxResponseBytes <= 0 {
+		t.Errorf("Unexpected default values are set: %#v.", config)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := &Config{
+		Feeds: []*Source{
+			{URL: ""},
+			nil,
+			{URL: "https://example.com/feed", Destinations: []string{"#general"}},
+		},
+		Timeout: -1,
+	}
+
+	err := config.Validate()
+	errs, ok := err.(sarah.ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	keys := map[string]bool{}
+	for _, e := range errs {
+		keys[e.Key] = true
+	}
+	for _, key := range []string{"schedule", "feeds[0].url", "feeds[0].destinations", "feeds[1]", "timeout"} {
+		if !keys[key] {
+			t.Errorf("Error for %s is not returned.", key)
+		}
+	}
+	if keys["feeds[2].url"] || keys["feeds[2].destinations"] {
+		t.Error("Valid feed is reported as invalid.")
+	}
+
+	config.Destinations = []string{"#general"}
+	config.Feeds = []*Source{{URL: "https://example.com/feed"}}
+	config.TaskSchedule = "@every 1m"
+	config.Timeout = 0
+	err = config.Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestNewScheduledTaskProps(t *testing.T) {
+	config := NewConfig()
+	config.Feeds = []*Source{{URL: "https://example.com/feed", Destinations: []string{"#general"}}}
+
+	props, err := NewScheduledTaskProps("dummy", "feed", config, NewMemoryStore())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if props == nil {
+		t.Fatal("Expected props are not returned.")
+	}
+
+	config.Feeds = []*Source{{URL: ""}}
+	_, err = NewScheduledTaskProps("dummy", "feed", config, NewMemoryStore())
+	if err == nil {
+		t.Error("Expected error is not returned for invalid config.")
+	}
+}
+
+func TestWatcher_execute(t *testing.T) {
+	server := newFeedServer("1", "2")
+	defer server.Close()
+
+	config := NewConfig()
+	config.MaxItems = 2
+	config.Destinations = []string{"default"}
+	config.Feeds = []*Source{
+		{URL: server.URL},
+		{URL: server.URL + "/other", Title: "Other", Destinations: []string{"a", "b"}},
+	}
+
+	w := &watcher{
+		store:  NewMemoryStore(),
+		client: &http.Client{},
+		destination: func(dest string) sarah.OutputDestination {
+			return "#" + dest
+		},
+		format: format,
+	}
+
+	// The existing items are only recorded on the first fetch.
+	results := w.execute(context.TODO(), config)
+	if len(results) != 0 {
+		t.Fatalf("Nothing must be posted on the first fetch: %#v.", titles(results))
+	}
+
+	// New items are posted from the oldest, and only the newest ones up to MaxItems are posted.
+	server.set("5", "4", "3", "2", "1")
+	results = w.execute(context.TODO(), config)
+	expected := []string{
+		"Title 4>#default",
+		"Title 5>#default",
+		"Title 4>#a",
+		"Title 4>#b",
+		"Title 5>#a",
+		"Title 5>#b",
+	}
+	if strings.Join(titles(results), ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected results are returned: %#v.", titles(results))
+	}
+	if fields := results[2].Content.(*sarah.RichMessage).Fields; len(fields) != 1 || fields[0].Value != "Other" {
+		t.Errorf("Source.Title is not applied: %#v.", fields)
+	}
+
+	// Items that disappeared and re-appeared must not be posted again.
+	server.set("5")
+	_ = w.execute(context.TODO(), config)
+	server.set("5", "4", "3")
+	results = w.execute(context.TODO(), config)
+	if len(results) != 0 {
+		t.Errorf("Seen items must not be posted again: %#v.", titles(results))
+	}
+}
+
+func TestWatcher_execute_Error(t *testing.T) {
+	server := newFeedServer("1")
+	defer server.Close()
+	server.status = http.StatusInternalServerError
+
+	store := NewMemoryStore()
+	config := NewConfig()
+	config.Destinations = []string{"default"}
+	config.Feeds = []*Source{{URL: server.URL}}
+
+	w := &watcher{
+		store:  store,
+		client: &http.Client{},
+		destination: func(dest string) sarah.OutputDestination {
+			return dest
+		},
+		format: format,
+	}
+
+	buf := &bytes.Buffer{}
+	ctx := logging.NewContext(context.Background(), logging.NewLogger(logging.NewJSONHandler(buf)))
+	results := w.execute(ctx, config)
+	if len(results) != 0 {
+		t.Errorf("Nothing must be posted on error: %#v.", titles(results))
+	}
+	for _, expected := range []string{`"module":"feed"`, `"url":"` + server.URL + `"`, `"message":"Failed to check feed"`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s is not logged: %s.", expected, buf.String())
+		}
+	}
+
+	_, fetched, _ := store.Load(server.URL)
+	if fetched {
+		t.Error("Failed fetch must not be recorded.")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summary := summarize("<p>Hello,&amp;\n  <b>world</b></p>")
+	if summary != "Hello,& world" {
+		t.Errorf("Unexpected summary is returned: %s.", summary)
+	}
+
+	summary = summarize(strings.Repeat("a", maxSummaryLength+1))
+	if len([]rune(summary)) != maxSummaryLength+3 || !strings.HasSuffix(summary, "...") {
+		t.Errorf("Long summary is not truncated: %s.", summary)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	message := format(&Feed{}, &Item{Link: "https://example.com/1"}).(*sarah.RichMessage)
+	if message.Title != "https://example.com/1" || message.TitleURL != "https://example.com/1" {
+		t.Errorf("Link must be used as title when title is empty: %#v.", message)
+	}
+	if len(message.Fields) != 0 {
+		t.Errorf("Feed field must be omitted when the feed has no title: %#v.", message.Fields)
+	}
+}
@@ -0,0 +1,208 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Feed is a parsed RSS or Atom feed.
+type Feed struct {
+	// Title is the title of the feed.
+	Title string
+
+	// URL is the URL the feed is fetched from.
+	URL string
+
+	// Items are the entries of the feed in the order of the document, which typically is the newest first.
+	Items []*Item
+}
+
+// Item is an entry of a feed.
+type Item struct {
+	// ID identifies the entry in the feed. This is the guid of RSS or the id of Atom, and falls back to the link or the title.
+	ID string
+
+	Title string
+
+	Link string
+
+	// Summary is the description of RSS or the summary of Atom. This may contain HTML.
+	Summary string
+
+	// Published is the time the entry is published. This is zero when the time is not given or can not be parsed.
+	Published time.Time
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string     `xml:"title"`
+		Items []*rssItem `xml:"item"`
+	} `xml:"channel"`
+
+	// Items are the items of RSS 1.0, which are placed at the root instead of the channel.
+	Items []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"` // dc:date of RSS 1.0
+}
+
+type atomDocument struct {
+	Title   string       `xml:"title"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// Parse parses the given RSS 2.0, RSS 1.0 or Atom document.
+func Parse(body []byte) (*Feed, error) {
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss", "RDF":
+		doc := &rssDocument{}
+		err := xml.Unmarshal(body, doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSS: %w", err)
+		}
+		return doc.feed(), nil
+
+	case "feed":
+		doc := &atomDocument{}
+		err := xml.Unmarshal(body, doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Atom: %w", err)
+		}
+		return doc.feed(), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported root element: %s", root)
+
+	}
+}
+
+// rootElement returns the local name of the document's root element.
+func rootElement(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", errors.New("no root element is found")
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func (doc *rssDocument) feed() *Feed {
+	items := doc.Channel.Items
+	if len(items) == 0 {
+		items = doc.Items
+	}
+
+	feed := &Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+	for _, i := range items {
+		published := parseTime(i.PubDate)
+		if published.IsZero() {
+			published = parseTime(i.Date)
+		}
+
+		feed.Items = append(feed.Items, newItem(i.GUID, i.Title, i.Link, i.Description, published))
+	}
+	return feed
+}
+
+func (doc *atomDocument) feed() *Feed {
+	feed := &Feed{Title: strings.TrimSpace(doc.Title)}
+	for _, e := range doc.Entries {
+		var link string
+		for _, l := range e.Links {
+			// A link without rel is an alternate link.
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+
+		summary := e.Summary
+		if summary == "" {
+			summary = e.Content
+		}
+
+		published := parseTime(e.Published)
+		if published.IsZero() {
+			published = parseTime(e.Updated)
+		}
+
+		feed.Items = append(feed.Items, newItem(e.ID, e.Title, link, summary, published))
+	}
+	return feed
+}
+
+func newItem(id string, title string, link string, summary string, published time.Time) *Item {
+	item := &Item{
+		ID:        strings.TrimSpace(id),
+		Title:     strings.TrimSpace(title),
+		Link:      strings.TrimSpace(link),
+		Summary:   strings.TrimSpace(summary),
+		Published: published,
+	}
+
+	if item.ID == "" {
+		item.ID = item.Link
+	}
+	if item.ID == "" {
+		item.ID = item.Title
+	}
+
+	return item
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+func parseTime(str string) time.Time {
+	str = strings.TrimSpace(str)
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, str)
+		if err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
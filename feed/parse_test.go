@@ -0,0 +1,123 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		title     string
+		ids       []string
+		links     []string
+		published time.Time
+	}{
+		{
+			name: "RSS 2.0",
+			body: `<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <title>Example</title>
+    <item>
+      <title>Second</title>
+      <link>https://example.com/2</link>
+      <guid>urn:2</guid>
+      <description>&lt;p&gt;Hello&lt;/p&gt;</description>
+      <pubDate>Thu, 02 Jan 2020 15:04:05 +0000</pubDate>
+    </item>
+    <item>
+      <title>First</title>
+      <link>https://example.com/1</link>
+    </item>
+  </channel>
+</rss>`,
+			title:     "Example",
+			ids:       []string{"urn:2", "https://example.com/1"},
+			links:     []string{"https://example.com/2", "https://example.com/1"},
+			published: time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name: "RSS 1.0",
+			body: `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Example</title>
+  </channel>
+  <item>
+    <title>Only</title>
+    <link>https://example.com/only</link>
+    <dc:date>2020-01-02T15:04:05Z</dc:date>
+  </item>
+</rdf:RDF>`,
+			title:     "Example",
+			ids:       []string{"https://example.com/only"},
+			links:     []string{"https://example.com/only"},
+			published: time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			name: "Atom",
+			body: `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example</title>
+  <entry>
+    <id>tag:example.com,2020:1</id>
+    <title>Entry</title>
+    <link rel="self" href="https://example.com/self"/>
+    <link href="https://example.com/entry"/>
+    <content>Body</content>
+    <updated>2020-01-02T15:04:05Z</updated>
+  </entry>
+</feed>`,
+			title:     "Example",
+			ids:       []string{"tag:example.com,2020:1"},
+			links:     []string{"https://example.com/entry"},
+			published: time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := Parse([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+
+			if feed.Title != tt.title {
+				t.Errorf("Unexpected title is returned: %s.", feed.Title)
+			}
+
+			if len(feed.Items) != len(tt.ids) {
+				t.Fatalf("Unexpected number of items is returned: %d.", len(feed.Items))
+			}
+			for i, item := range feed.Items {
+				if item.ID != tt.ids[i] {
+					t.Errorf("Unexpected ID is returned: %s.", item.ID)
+				}
+				if item.Link != tt.links[i] {
+					t.Errorf("Unexpected link is returned: %s.", item.Link)
+				}
+			}
+
+			if !feed.Items[0].Published.Equal(tt.published) {
+				t.Errorf("Unexpected published time is returned: %s.", feed.Items[0].Published)
+			}
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	bodies := []string{
+		"",
+		"not xml",
+		`<?xml version="1.0"?><html><body></body></html>`,
+	}
+
+	for i, body := range bodies {
+		_, err := Parse([]byte(body))
+		if err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"sync"
+)
+
+// Store defines an interface that persists the IDs of the items already seen for each feed,
+// so an item is posted only once even after a restart.
+type Store interface {
+	// Load returns the IDs of the items seen for the feed with the given URL.
+	// false is returned when the feed has never been fetched.
+	Load(url string) ([]string, bool, error)
+
+	// Save replaces the IDs of the items seen for the feed with the given URL.
+	Save(url string, ids []string) error
+}
+
+// memoryStore is a Store that keeps the IDs in memory.
+type memoryStore struct {
+	seen  map[string][]string
+	mutex sync.RWMutex
+}
+
+var _ Store = (*memoryStore)(nil)
+
+// NewMemoryStore creates and returns a Store that keeps the IDs in memory.
+// The IDs are lost when the process stops, so the items in the feeds are seen as existing ones again on the next start.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		seen: map[string][]string{},
+	}
+}
+
+func (s *memoryStore) Load(url string) ([]string, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ids, ok := s.seen[url]
+	return append([]string{}, ids...), ok, nil
+}
+
+func (s *memoryStore) Save(url string, ids []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.seen[url] = append([]string{}, ids...)
+	return nil
+}
+
+// kvStore is a Store that stores the IDs of each feed as a JSON value in sarah.Store.
+type kvStore struct {
+	store sarah.Store
+}
+
+var _ Store = (*kvStore)(nil)
+
+// NewStore creates and returns a Store that stores the IDs of each feed as a JSON value in the given sarah.Store with the feed's URL as the key.
+// Give the sarah.Store namespaced with sarah.NewNamespacedStore so the keys do not conflict with other plugins':
+//
+//  store := feed.NewStore(sarah.NewNamespacedStore(redisStore, "feed"))
+func NewStore(store sarah.Store) Store {
+	return &kvStore{
+		store: store,
+	}
+}
+
+func (s *kvStore) Load(url string) ([]string, bool, error) {
+	b, err := s.store.Get(context.Background(), url)
+	if errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ids []string
+	err = json.Unmarshal(b, &ids)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse IDs of %s: %w", url, err)
+	}
+	return ids, true, nil
+}
+
+func (s *kvStore) Save(url string, ids []string) error {
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Set(context.Background(), url, b)
+}
+
+// NewFileStore creates and returns a Store that stores the IDs of each feed as a file in the given directory.
+// The directory is created when it does not exist.
+// This is a shorthand for NewStore with the sarah.Store sarah.NewFileStore returns.
+func NewFileStore(dir string) (Store, error) {
+	store, err := sarah.NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(store), nil
+}
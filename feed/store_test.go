@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, store Store) {
+	_, fetched, err := store.Load("https://example.com/feed")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if fetched {
+		t.Error("Unknown feed must not be reported as fetched.")
+	}
+
+	ids := []string{"a", "b"}
+	err = store.Save("https://example.com/feed", ids)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The stored IDs must not be affected by a later modification.
+	ids[0] = "modified"
+
+	loaded, fetched, err := store.Load("https://example.com/feed")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !fetched {
+		t.Error("Saved feed must be reported as fetched.")
+	}
+	if len(loaded) != 2 || loaded[0] != "a" || loaded[1] != "b" {
+		t.Errorf("Unexpected IDs are returned: %#v.", loaded)
+	}
+
+	err = store.Save("https://example.com/empty", []string{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	loaded, fetched, err = store.Load("https://example.com/empty")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !fetched || len(loaded) != 0 {
+		t.Errorf("Feed without items must be reported as fetched: %t, %#v.", fetched, loaded)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestStore(t *testing.T) {
+	testStore(t, NewStore(sarah.NewMemoryStore()))
+}
+
+func TestStore_Load_BrokenValue(t *testing.T) {
+	store := sarah.NewMemoryStore()
+	_ = store.Set(context.TODO(), "https://example.com/feed", []byte("{"))
+
+	_, _, err := NewStore(store).Load("https://example.com/feed")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feed")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	testStore(t, store)
+
+	// The IDs are persisted to the directory.
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	loaded, fetched, err := reopened.Load("https://example.com/feed")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !fetched || len(loaded) != 2 {
+		t.Errorf("Persisted IDs are not loaded: %t, %#v.", fetched, loaded)
+	}
+}
@@ -0,0 +1,44 @@
+package sarah
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// FileContent represents a file to be sent as an attachment.
+// A Command may return this as CommandResponse.Content to send a chart, a log or any other file
+// instead of pasting a long text in a message:
+//
+//  return &sarah.CommandResponse{
+//  	Content: &sarah.FileContent{
+//  		Reader:   bytes.NewReader(png),
+//  		FileName: "chart.png",
+//  		MIMEType: "image/png",
+//  		Comment:  "Here is the weekly chart.",
+//  	},
+//  }, nil
+//
+// Each Adapter reads the Reader once when the file is sent.
+// When the Reader also satisfies io.Closer, the Adapter closes it after the file is sent.
+type FileContent struct {
+	// Reader provides the file's content.
+	Reader io.Reader
+
+	// FileName is the name of the file displayed on the chat service.
+	FileName string
+
+	// MIMEType is the MIME type of the file such as "image/png" or "text/plain".
+	MIMEType string
+
+	// Comment is an optional message sent along with the file.
+	Comment string
+}
+
+// ReadAll reads the whole content from the Reader and closes the Reader if it satisfies io.Closer.
+// An Adapter implementation may use this when the chat service requires the content length on upload.
+func (f *FileContent) ReadAll() ([]byte, error) {
+	if closer, ok := f.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return ioutil.ReadAll(f.Reader)
+}
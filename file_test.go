@@ -0,0 +1,39 @@
+package sarah
+
+import (
+	"strings"
+	"testing"
+)
+
+type DummyReadCloser struct {
+	*strings.Reader
+	closed bool
+}
+
+func (r *DummyReadCloser) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestFileContent_ReadAll(t *testing.T) {
+	reader := &DummyReadCloser{Reader: strings.NewReader("content")}
+	file := &FileContent{
+		Reader:   reader,
+		FileName: "dummy.txt",
+		MIMEType: "text/plain",
+	}
+
+	content, err := file.ReadAll()
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if string(content) != "content" {
+		t.Errorf("Unexpected content is returned: %s.", string(content))
+	}
+
+	if !reader.closed {
+		t.Error("Reader is not closed.")
+	}
+}
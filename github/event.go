@@ -0,0 +1,209 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Supported GitHub event types given by X-GitHub-Event header.
+const (
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+	EventIssues      = "issues"
+)
+
+// Event is a GitHub webhook event converted to a platform-independent form.
+// This is given to the templates as the data.
+type Event struct {
+	// Type is the event type such as "push" and "pull_request".
+	Type string
+
+	// Action is the activity of a pull_request or issues event such as "opened" and "closed".
+	// A closed pull request that is merged has "merged" instead. This is empty for a push event.
+	Action string
+
+	// Repository is the full name of the repository such as "oklahomer/go-sarah".
+	Repository string
+
+	// Sender is the login name of the user who triggered the event.
+	Sender string
+
+	// Number is the number of the pull request or the issue.
+	Number int
+
+	// Title is the title of the pull request or the issue.
+	Title string
+
+	// URL is the web URL of the pull request or the issue, or the comparison URL of the pushed commits.
+	URL string
+
+	// Branch is the name of the pushed branch or tag.
+	Branch string
+
+	// Commits are the pushed commits.
+	Commits []*Commit
+}
+
+// Commit is a pushed commit.
+type Commit struct {
+	// ID is the full SHA of the commit.
+	ID string
+
+	// ShortID is the first seven characters of ID.
+	ShortID string
+
+	// Title is the first line of the commit message.
+	Title string
+
+	// Author is the name of the commit author.
+	Author string
+
+	URL string
+}
+
+type repository struct {
+	FullName string `json:"full_name"`
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+type pushPayload struct {
+	Ref        string      `json:"ref"`
+	Deleted    bool        `json:"deleted"`
+	Compare    string      `json:"compare"`
+	Repository *repository `json:"repository"`
+	Sender     *user       `json:"sender"`
+	Commits    []*struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+		URL     string `json:"url"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commits"`
+}
+
+type pullRequestPayload struct {
+	Action      string      `json:"action"`
+	Repository  *repository `json:"repository"`
+	Sender      *user       `json:"sender"`
+	PullRequest *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+	} `json:"pull_request"`
+}
+
+type issuesPayload struct {
+	Action     string      `json:"action"`
+	Repository *repository `json:"repository"`
+	Sender     *user       `json:"sender"`
+	Issue      *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+	} `json:"issue"`
+}
+
+// parseEvent converts the payload of the given event type to Event.
+// nil is returned without an error when the event has nothing to post such as a deleted branch.
+func parseEvent(eventType string, payload []byte) (*Event, error) {
+	switch eventType {
+	case EventPush:
+		p := &pushPayload{}
+		err := json.Unmarshal(payload, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s payload: %w", eventType, err)
+		}
+		if p.Repository == nil {
+			return nil, fmt.Errorf("repository is not given in %s payload", eventType)
+		}
+		if p.Deleted || len(p.Commits) == 0 {
+			return nil, nil
+		}
+
+		event := &Event{
+			Type:       eventType,
+			Repository: p.Repository.FullName,
+			Sender:     login(p.Sender),
+			URL:        p.Compare,
+			Branch:     strings.TrimPrefix(strings.TrimPrefix(p.Ref, "refs/heads/"), "refs/tags/"),
+		}
+		for _, c := range p.Commits {
+			event.Commits = append(event.Commits, &Commit{
+				ID:      c.ID,
+				ShortID: shorten(c.ID),
+				Title:   strings.SplitN(c.Message, "\n", 2)[0],
+				Author:  c.Author.Name,
+				URL:     c.URL,
+			})
+		}
+		return event, nil
+
+	case EventPullRequest:
+		p := &pullRequestPayload{}
+		err := json.Unmarshal(payload, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s payload: %w", eventType, err)
+		}
+		if p.Repository == nil || p.PullRequest == nil {
+			return nil, fmt.Errorf("repository or pull_request is not given in %s payload", eventType)
+		}
+
+		action := p.Action
+		if action == "closed" && p.PullRequest.Merged {
+			action = "merged"
+		}
+		return &Event{
+			Type:       eventType,
+			Action:     action,
+			Repository: p.Repository.FullName,
+			Sender:     login(p.Sender),
+			Number:     p.PullRequest.Number,
+			Title:      p.PullRequest.Title,
+			URL:        p.PullRequest.HTMLURL,
+		}, nil
+
+	case EventIssues:
+		p := &issuesPayload{}
+		err := json.Unmarshal(payload, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s payload: %w", eventType, err)
+		}
+		if p.Repository == nil || p.Issue == nil {
+			return nil, fmt.Errorf("repository or issue is not given in %s payload", eventType)
+		}
+
+		return &Event{
+			Type:       eventType,
+			Action:     p.Action,
+			Repository: p.Repository.FullName,
+			Sender:     login(p.Sender),
+			Number:     p.Issue.Number,
+			Title:      p.Issue.Title,
+			URL:        p.Issue.HTMLURL,
+		}, nil
+
+	default:
+		return nil, nil
+
+	}
+}
+
+func login(u *user) string {
+	if u == nil {
+		return ""
+	}
+	return u.Login
+}
+
+func shorten(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,129 @@
+package github
+
+import (
+	"testing"
+)
+
+func TestParseEvent_Push(t *testing.T) {
+	payload := `{
+  "ref": "refs/heads/main",
+  "compare": "https://github.com/oklahomer/go-sarah/compare/abc...def",
+  "repository": {"full_name": "oklahomer/go-sarah"},
+  "sender": {"login": "oklahomer"},
+  "commits": [
+    {"id": "0123456789abcdef", "message": "Fix bug\n\nDetails", "url": "https://github.com/c/1", "author": {"name": "Go Sarah"}}
+  ]
+}`
+
+	event, err := parseEvent(EventPush, []byte(payload))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if event.Repository != "oklahomer/go-sarah" || event.Sender != "oklahomer" || event.Branch != "main" {
+		t.Errorf("Unexpected event is returned: %#v.", event)
+	}
+	if len(event.Commits) != 1 {
+		t.Fatalf("Unexpected number of commits is returned: %d.", len(event.Commits))
+	}
+	commit := event.Commits[0]
+	if commit.ShortID != "0123456" || commit.Title != "Fix bug" || commit.Author != "Go Sarah" {
+		t.Errorf("Unexpected commit is returned: %#v.", commit)
+	}
+}
+
+func TestParseEvent_PushWithoutCommits(t *testing.T) {
+	payloads := []string{
+		`{"ref": "refs/heads/old", "deleted": true, "repository": {"full_name": "oklahomer/go-sarah"}, "commits": []}`,
+		`{"ref": "refs/tags/v1.0.0", "repository": {"full_name": "oklahomer/go-sarah"}, "commits": []}`,
+	}
+
+	for i, payload := range payloads {
+		event, err := parseEvent(EventPush, []byte(payload))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+		if event != nil {
+			t.Errorf("Event without commits must be ignored on test #%d: %#v.", i, event)
+		}
+	}
+}
+
+func TestParseEvent_PullRequest(t *testing.T) {
+	tests := []struct {
+		action   string
+		merged   bool
+		expected string
+	}{
+		{action: "opened", expected: "opened"},
+		{action: "closed", expected: "closed"},
+		{action: "closed", merged: true, expected: "merged"},
+	}
+
+	for i, tt := range tests {
+		payload := `{
+  "action": "` + tt.action + `",
+  "repository": {"full_name": "oklahomer/go-sarah"},
+  "sender": {"login": "oklahomer"},
+  "pull_request": {"number": 12, "title": "Add feature", "html_url": "https://github.com/pr/12", "merged": ` + map[bool]string{true: "true", false: "false"}[tt.merged] + `}
+}`
+		event, err := parseEvent(EventPullRequest, []byte(payload))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		if event.Action != tt.expected {
+			t.Errorf("Unexpected action is returned on test #%d: %s.", i, event.Action)
+		}
+		if event.Number != 12 || event.Title != "Add feature" || event.URL != "https://github.com/pr/12" {
+			t.Errorf("Unexpected event is returned on test #%d: %#v.", i, event)
+		}
+	}
+}
+
+func TestParseEvent_Issues(t *testing.T) {
+	payload := `{
+  "action": "opened",
+  "repository": {"full_name": "oklahomer/go-sarah"},
+  "sender": {"login": "oklahomer"},
+  "issue": {"number": 3, "title": "Bug", "html_url": "https://github.com/issue/3"}
+}`
+
+	event, err := parseEvent(EventIssues, []byte(payload))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if event.Type != EventIssues || event.Action != "opened" || event.Number != 3 || event.Title != "Bug" {
+		t.Errorf("Unexpected event is returned: %#v.", event)
+	}
+}
+
+func TestParseEvent_Error(t *testing.T) {
+	tests := []struct {
+		eventType string
+		payload   string
+	}{
+		{eventType: EventPush, payload: "{"},
+		{eventType: EventPush, payload: `{"commits": []}`},
+		{eventType: EventPullRequest, payload: `{"repository": {"full_name": "oklahomer/go-sarah"}}`},
+		{eventType: EventIssues, payload: `{"repository": {"full_name": "oklahomer/go-sarah"}}`},
+	}
+
+	for i, tt := range tests {
+		_, err := parseEvent(tt.eventType, []byte(tt.payload))
+		if err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		}
+	}
+}
+
+func TestParseEvent_Unsupported(t *testing.T) {
+	event, err := parseEvent("ping", []byte(`{"zen": "Keep it logically awesome."}`))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if event != nil {
+		t.Errorf("Unsupported event must be ignored: %#v.", event)
+	}
+}
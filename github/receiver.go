@@ -0,0 +1,365 @@
+/*
+Package github provides an HTTP receiver of GitHub webhooks that posts push, pull request, and issue events to chat.
+
+Receiver verifies the signature of each delivery with the webhook secret, converts the payload to Event,
+renders it with a template, and sends the result via the running Bot to the destinations routed for the repository.
+
+	config := github.NewConfig()
+	config.Secret = sarah.Secret(os.Getenv("GITHUB_WEBHOOK_SECRET"))
+	config.Routes = []*github.Route{
+		{Repository: "oklahomer/go-sarah", Destinations: []string{"C12345678"}},
+		{Repository: "oklahomer/*", Events: []string{"pull_request"}, Destinations: []string{"C87654321"}},
+	}
+	receiver, err := github.NewReceiver(slack.SLACK, config, github.WithDestinationFunc(func(dest string) sarah.OutputDestination {
+		return event.ChannelID(dest)
+	}))
+	if err != nil {
+		panic(err)
+	}
+	http.Handle("/github", receiver)
+
+The messages are rendered with the templates named github_push, github_pull_request, and github_issues.
+Pass templates.Set with WithTemplates to customize them with template files of the same names; Event is given as the data.
+
+When Config is read from a file, Secret may be a reference such as "env:GITHUB_WEBHOOK_SECRET"; resolve it with sarah.ResolveSecrets before calling NewReceiver.
+*/
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/templates"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// TemplatePrefix is prepended to the event type to form the name of the template that renders the event.
+const TemplatePrefix = "github_"
+
+// ErrInvalidSignature is returned when the signature of a delivery does not match the webhook secret.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+var defaultTemplates = map[string]string{
+	TemplatePrefix + EventPush: `[{{ .Repository }}] {{ .Sender }} pushed {{ len .Commits }} commit(s) to {{ .Branch }}: {{ .URL }}` +
+		`{{ range .Commits }}
+{{ .ShortID }} {{ .Title }} - {{ .Author }}{{ end }}`,
+	TemplatePrefix + EventPullRequest: `[{{ .Repository }}] {{ .Sender }} {{ .Action }} pull request #{{ .Number }}: {{ .Title }} {{ .URL }}`,
+	TemplatePrefix + EventIssues:      `[{{ .Repository }}] {{ .Sender }} {{ .Action }} issue #{{ .Number }}: {{ .Title }} {{ .URL }}`,
+}
+
+// sendMessage is a variable so tests can replace it.
+var sendMessage = sarah.SendMessage
+
+// Route defines where the events of the matching repositories are posted.
+type Route struct {
+	// Repository is the full name of the repository such as "oklahomer/go-sarah".
+	// "oklahomer/*" matches all repositories of the owner, and "*" matches any repository.
+	Repository string `json:"repository" yaml:"repository"`
+
+	// Events limits the event types to post. All supported events are posted when this is empty.
+	Events []string `json:"events" yaml:"events"`
+
+	// Destinations are where the events are posted.
+	Destinations []string `json:"destinations" yaml:"destinations"`
+}
+
+func (r *Route) match(event *Event) bool {
+	repository := strings.ToLower(r.Repository)
+	name := strings.ToLower(event.Repository)
+	switch {
+	case repository == "*", repository == name:
+		// O.K.
+
+	case strings.HasSuffix(repository, "/*") && strings.HasPrefix(name, strings.TrimSuffix(repository, "*")):
+		// O.K.
+
+	default:
+		return false
+
+	}
+
+	if len(r.Events) == 0 {
+		return true
+	}
+	for _, e := range r.Events {
+		if e == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Config contains some configuration variables for the GitHub webhook receiver.
+type Config struct {
+	// Secret is the webhook secret set on GitHub to sign the deliveries.
+	Secret sarah.Secret `json:"secret" yaml:"secret"`
+
+	// Routes define where the events are posted. An event is posted to the destinations of all matching routes.
+	Routes []*Route `json:"routes" yaml:"routes"`
+
+	// Actions are the activities of pull_request and issues events to post. Other activities such as "labeled" are ignored.
+	// A merged pull request has "merged" action instead of "closed".
+	Actions []string `json:"actions" yaml:"actions"`
+
+	// MaxPayloadBytes is the maximum size of a delivery to accept.
+	MaxPayloadBytes int64 `json:"max_payload_bytes" yaml:"max_payload_bytes"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		Secret:          "",
+		Routes:          []*Route{},
+		Actions:         []string{"opened", "closed", "reopened", "merged"},
+		MaxPayloadBytes: 5 * 1024 * 1024,
+	}
+}
+
+// ApplyDefaults sets the default values to Actions and MaxPayloadBytes when they are not given.
+func (c *Config) ApplyDefaults() {
+	defaults := NewConfig()
+
+	if len(c.Actions) == 0 {
+		c.Actions = defaults.Actions
+	}
+
+	if c.MaxPayloadBytes == 0 {
+		c.MaxPayloadBytes = defaults.MaxPayloadBytes
+	}
+}
+
+// Validate checks that the secret is given and that every route has a repository, known events, and at least one destination.
+func (c *Config) Validate() error {
+	var errs sarah.ConfigKeyErrors
+
+	if c.Secret == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "secret", Err: errors.New("secret is empty")})
+	}
+
+	for i, route := range c.Routes {
+		key := fmt.Sprintf("routes[%d]", i)
+		if route == nil {
+			errs = append(errs, &sarah.ConfigKeyError{Key: key, Err: errors.New("route is empty")})
+			continue
+		}
+
+		if route.Repository == "" {
+			errs = append(errs, &sarah.ConfigKeyError{Key: key + ".repository", Err: errors.New("repository is empty")})
+		}
+
+		for _, e := range route.Events {
+			if _, ok := defaultTemplates[TemplatePrefix+e]; !ok {
+				errs = append(errs, &sarah.ConfigKeyError{Key: key + ".events", Value: e, Err: errors.New("unsupported event")})
+			}
+		}
+
+		if len(route.Destinations) == 0 {
+			errs = append(errs, &sarah.ConfigKeyError{Key: key + ".destinations", Err: errors.New("destinations are empty")})
+		}
+	}
+
+	if c.MaxPayloadBytes < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "max_payload_bytes", Value: fmt.Sprint(c.MaxPayloadBytes), Err: errors.New("max_payload_bytes must not be negative")})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Option defines function signature that NewReceiver's functional option must satisfy.
+type Option func(*Receiver)
+
+// WithDestinationFunc creates an Option that converts a destination in Route to sarah.OutputDestination the Bot's Adapter accepts.
+// By default, the string is used as is. Slack, for example, requires event.ChannelID instead.
+func WithDestinationFunc(fnc func(string) sarah.OutputDestination) Option {
+	return func(r *Receiver) {
+		r.destination = fnc
+	}
+}
+
+// WithTemplates creates an Option that renders the events with the given templates.Set.
+// The default templates are added to the Set, so only the templates to customize need to be placed as files.
+func WithTemplates(set *templates.Set) Option {
+	return func(r *Receiver) {
+		for name, text := range defaultTemplates {
+			// The default templates are verified on the package initialization.
+			_ = set.Add(name, text)
+		}
+		r.render = set.Render
+	}
+}
+
+// Receiver is an http.Handler that receives GitHub webhooks and posts the events via the Bot.
+type Receiver struct {
+	botType     sarah.BotType
+	config      *Config
+	destination func(string) sarah.OutputDestination
+	render      func(string, interface{}) (string, error)
+}
+
+var _ http.Handler = (*Receiver)(nil)
+
+// NewReceiver validates the given Config and returns a Receiver that posts the events via the Bot with the given BotType.
+func NewReceiver(botType sarah.BotType, config *Config, options ...Option) (*Receiver, error) {
+	err := sarah.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Receiver{
+		botType: botType,
+		config:  config,
+		destination: func(dest string) sarah.OutputDestination {
+			return dest
+		},
+		render: renderDefault,
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+// ServeHTTP handles a delivery from GitHub.
+// A delivery with an invalid signature is rejected with 401, and 503 is returned when the Bot is not running so GitHub reports the failure.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Read one more byte than the limit to tell if the payload is too large.
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, r.config.MaxPayloadBytes+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > r.config.MaxPayloadBytes {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	log := logging.FromContext(req.Context()).Module("github").With(
+		logging.F(logging.KeyBotType, r.botType),
+		logging.F("delivery_id", req.Header.Get("X-GitHub-Delivery")),
+	)
+
+	err = verifySignature(r.config.Secret.Reveal(), req.Header.Get("X-Hub-Signature-256"), body)
+	if err != nil {
+		log.Warn("Rejected GitHub webhook delivery", logging.Err(err))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	event, err := parseEvent(req.Header.Get("X-GitHub-Event"), body)
+	if err != nil {
+		log.Warn("Failed to parse GitHub webhook delivery", logging.F("event", req.Header.Get("X-GitHub-Event")), logging.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = r.post(event)
+	if err != nil {
+		log.Error("Failed to post GitHub webhook delivery", logging.F("event", req.Header.Get("X-GitHub-Event")), logging.Err(err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// post sends the given event to the destinations of the matching routes.
+// Nothing is sent for nil, which represents an event to ignore.
+func (r *Receiver) post(event *Event) error {
+	if event == nil || !r.accepts(event) {
+		return nil
+	}
+
+	var destinations []string
+	seen := map[string]bool{}
+	for _, route := range r.config.Routes {
+		if !route.match(event) {
+			continue
+		}
+		for _, dest := range route.Destinations {
+			if !seen[dest] {
+				seen[dest] = true
+				destinations = append(destinations, dest)
+			}
+		}
+	}
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	text, err := r.render(TemplatePrefix+event.Type, event)
+	if err != nil {
+		return err
+	}
+
+	for _, dest := range destinations {
+		err := sendMessage(r.botType, sarah.NewOutputMessage(r.destination(dest), text))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// accepts tells if the activity of the event is one of Config.Actions. An event without an activity such as push is always accepted.
+func (r *Receiver) accepts(event *Event) bool {
+	if event.Action == "" {
+		return true
+	}
+	for _, action := range r.config.Actions {
+		if action == event.Action {
+			return true
+		}
+	}
+	return false
+}
+
+var builtinTemplate = func() *template.Template {
+	tmpl := template.New("github")
+	for name, text := range defaultTemplates {
+		template.Must(tmpl.New(name).Parse(text))
+	}
+	return tmpl
+}()
+
+func renderDefault(name string, data interface{}) (string, error) {
+	buf := &strings.Builder{}
+	err := builtinTemplate.ExecuteTemplate(buf, name, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// verifySignature verifies the X-Hub-Signature-256 header value with the webhook secret.
+// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+func verifySignature(secret string, signature string, body []byte) error {
+	if signature == "" {
+		return fmt.Errorf("%w: signature is not given", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,325 @@
+package github
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/templates"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const issuePayload = `{
+  "action": "opened",
+  "repository": {"full_name": "oklahomer/go-sarah"},
+  "sender": {"login": "oklahomer"},
+  "issue": {"number": 3, "title": "Bug", "html_url": "https://github.com/issue/3"}
+}`
+
+func sign(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newRequest(eventType string, body string, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/github", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-GitHub-Delivery", "delivery")
+	if signature != "" {
+		req.Header.Set("X-Hub-Signature-256", signature)
+	}
+	return req
+}
+
+type sentMessage struct {
+	botType     sarah.BotType
+	destination sarah.OutputDestination
+	content     interface{}
+}
+
+func stubSendMessage(err error) (*[]*sentMessage, func()) {
+	var sent []*sentMessage
+	original := sendMessage
+	sendMessage = func(botType sarah.BotType, output sarah.Output) error {
+		if err != nil {
+			return err
+		}
+		sent = append(sent, &sentMessage{botType: botType, destination: output.Destination(), content: output.Content()})
+		return nil
+	}
+	return &sent, func() {
+		sendMessage = original
+	}
+}
+
+func newTestConfig() *Config {
+	config := NewConfig()
+	config.Secret = "secret"
+	config.Routes = []*Route{
+		{Repository: "oklahomer/go-sarah", Destinations: []string{"a", "b"}},
+		{Repository: "Oklahomer/*", Events: []string{EventIssues}, Destinations: []string{"b", "c"}},
+		{Repository: "*", Events: []string{EventPush}, Destinations: []string{"d"}},
+		{Repository: "other/repo", Destinations: []string{"e"}},
+	}
+	return config
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := &Config{
+		Routes: []*Route{
+			{Events: []string{"unknown"}},
+			nil,
+		},
+		MaxPayloadBytes: -1,
+	}
+
+	err := config.Validate()
+	errs, ok := err.(sarah.ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	keys := map[string]bool{}
+	for _, e := range errs {
+		keys[e.Key] = true
+	}
+	for _, key := range []string{"secret", "routes[0].repository", "routes[0].events", "routes[0].destinations", "routes[1]", "max_payload_bytes"} {
+		if !keys[key] {
+			t.Errorf("Error for %s is not returned.", key)
+		}
+	}
+
+	err = newTestConfig().Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestNewReceiver(t *testing.T) {
+	_, err := NewReceiver("dummy", NewConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for config without secret.")
+	}
+
+	config := newTestConfig()
+	config.Actions = nil
+	receiver, err := NewReceiver("dummy", config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(receiver.config.Actions) == 0 {
+		t.Error("Default actions are not applied.")
+	}
+}
+
+func TestReceiver_ServeHTTP(t *testing.T) {
+	sent, reset := stubSendMessage(nil)
+	defer reset()
+
+	receiver, err := NewReceiver("dummy", newTestConfig(), WithDestinationFunc(func(dest string) sarah.OutputDestination {
+		return "#" + dest
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, newRequest(EventIssues, issuePayload, sign("secret", issuePayload)))
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("Unexpected status code is returned: %d.", recorder.Code)
+	}
+
+	var destinations []string
+	for _, message := range *sent {
+		if message.botType != "dummy" {
+			t.Errorf("Unexpected BotType is given: %s.", message.botType)
+		}
+		if message.content != "[oklahomer/go-sarah] oklahomer opened issue #3: Bug https://github.com/issue/3" {
+			t.Errorf("Unexpected content is sent: %s.", message.content)
+		}
+		destinations = append(destinations, fmt.Sprint(message.destination))
+	}
+	if strings.Join(destinations, ",") != "#a,#b,#c" {
+		t.Errorf("Unexpected destinations are given: %#v.", destinations)
+	}
+}
+
+func TestReceiver_ServeHTTP_Ignored(t *testing.T) {
+	sent, reset := stubSendMessage(nil)
+	defer reset()
+
+	config := newTestConfig()
+	config.Actions = []string{"closed"}
+	receiver, err := NewReceiver("dummy", config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	tests := []struct {
+		eventType string
+		payload   string
+	}{
+		{eventType: "ping", payload: `{"zen": "Keep it logically awesome."}`},
+		{eventType: EventIssues, payload: issuePayload},
+		{eventType: EventIssues, payload: strings.NewReplacer("oklahomer/go-sarah", "someone/else", "opened", "closed").Replace(issuePayload)},
+	}
+
+	for i, tt := range tests {
+		recorder := httptest.NewRecorder()
+		receiver.ServeHTTP(recorder, newRequest(tt.eventType, tt.payload, sign("secret", tt.payload)))
+
+		if recorder.Code != http.StatusNoContent {
+			t.Errorf("Unexpected status code is returned on test #%d: %d.", i, recorder.Code)
+		}
+	}
+
+	if len(*sent) != 0 {
+		t.Errorf("Nothing must be sent: %#v.", *sent)
+	}
+}
+
+func TestReceiver_ServeHTTP_Error(t *testing.T) {
+	_, reset := stubSendMessage(sarah.ErrBotNotRunning)
+	defer reset()
+
+	config := newTestConfig()
+	config.MaxPayloadBytes = 1024
+	receiver, err := NewReceiver("dummy", config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	large := strings.Repeat("a", 1025)
+	tests := []struct {
+		req      *http.Request
+		expected int
+	}{
+		{req: httptest.NewRequest(http.MethodGet, "/github", nil), expected: http.StatusMethodNotAllowed},
+		{req: newRequest(EventIssues, issuePayload, ""), expected: http.StatusUnauthorized},
+		{req: newRequest(EventIssues, issuePayload, sign("wrong", issuePayload)), expected: http.StatusUnauthorized},
+		{req: newRequest(EventIssues, large, sign("secret", large)), expected: http.StatusRequestEntityTooLarge},
+		{req: newRequest(EventIssues, "{", sign("secret", "{")), expected: http.StatusBadRequest},
+		{req: newRequest(EventIssues, issuePayload, sign("secret", issuePayload)), expected: http.StatusServiceUnavailable},
+	}
+
+	for i, tt := range tests {
+		recorder := httptest.NewRecorder()
+		receiver.ServeHTTP(recorder, tt.req)
+
+		if recorder.Code != tt.expected {
+			t.Errorf("Unexpected status code is returned on test #%d: %d.", i, recorder.Code)
+		}
+	}
+}
+
+func TestReceiver_ServeHTTP_Log(t *testing.T) {
+	receiver, err := NewReceiver("dummy", newTestConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	buf := &bytes.Buffer{}
+	req := newRequest(EventIssues, issuePayload, sign("wrong", issuePayload))
+	req = req.WithContext(logging.NewContext(req.Context(), logging.NewLogger(logging.NewJSONHandler(buf))))
+	receiver.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, expected := range []string{`"module":"github"`, `"bot_type":"dummy"`, `"delivery_id":"delivery"`, `"error":"invalid signature"`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s is not logged: %s.", expected, buf.String())
+		}
+	}
+}
+
+func TestWithTemplates(t *testing.T) {
+	sent, reset := stubSendMessage(nil)
+	defer reset()
+
+	dir, err := ioutil.TempDir("", "github")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "dummy"), 0700)
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s.", err.Error())
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "dummy", "github_issues.tmpl"), []byte("Issue {{ .Number }} {{ .Action }}"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write template: %s.", err.Error())
+	}
+
+	set, err := templates.NewSet("dummy", &templates.Config{Dir: dir, Extension: ".tmpl"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	receiver, err := NewReceiver("dummy", newTestConfig(), WithTemplates(set))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = receiver.post(&Event{Type: EventIssues, Action: "opened", Repository: "other/repo", Number: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(*sent) != 1 || (*sent)[0].content != "Issue 3 opened" {
+		t.Errorf("Template file is not used: %#v.", *sent)
+	}
+
+	// The default template is used when no file overrides it.
+	text, err := set.Render(TemplatePrefix+EventPullRequest, &Event{Repository: "other/repo", Action: "opened", Number: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !strings.HasPrefix(text, "[other/repo]") {
+		t.Errorf("Default template is not added: %s.", text)
+	}
+}
+
+func TestRenderDefault_Push(t *testing.T) {
+	event := &Event{
+		Type:       EventPush,
+		Repository: "oklahomer/go-sarah",
+		Sender:     "oklahomer",
+		Branch:     "main",
+		URL:        "https://github.com/compare",
+		Commits: []*Commit{
+			{ShortID: "0123456", Title: "Fix bug", Author: "Go Sarah"},
+		},
+	}
+
+	text, err := renderDefault(TemplatePrefix+EventPush, event)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := "[oklahomer/go-sarah] oklahomer pushed 1 commit(s) to main: https://github.com/compare\n0123456 Fix bug - Go Sarah"
+	if text != expected {
+		t.Errorf("Unexpected text is returned: %s.", text)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	err := verifySignature("secret", sign("secret", "body"), []byte("body"))
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = verifySignature("secret", sign("secret", "body"), []byte("tampered"))
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
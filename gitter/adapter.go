@@ -4,9 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/oklahomer/go-kasumi/logger"
-	"github.com/oklahomer/go-kasumi/retry"
 	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/adapterkit"
+	"github.com/oklahomer/go-sarah/v4/breaker"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/retry"
+	"io"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -14,6 +20,12 @@ const (
 	GITTER sarah.BotType = "gitter"
 )
 
+// moduleLogger returns the Logger carried by the given context with this package's module name.
+// go-sarah's Runner passes a context that carries its Logger to Adapter.Run and Adapter.SendMessage.
+func moduleLogger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("gitter")
+}
+
 // AdapterOption defines function signature that Adapter's functional option must satisfy.
 type AdapterOption func(adapter *Adapter)
 
@@ -22,14 +34,34 @@ type Adapter struct {
 	config          *Config
 	apiClient       APIClient
 	streamingClient StreamingClient
+	rooms           map[string]*roomConnection
+	runCtx          context.Context
+	enqueueInput    func(sarah.Input) error
+	notifyErr       func(error)
+	mutex           sync.Mutex
+}
+
+// roomConnection represents a running connection to a room.
+type roomConnection struct {
+	room   *Room
+	cancel context.CancelFunc
 }
 
 // NewAdapter creates and returns new Adapter instance.
 func NewAdapter(config *Config, options ...AdapterOption) (*Adapter, error) {
+	var clientOptions []RestAPIClientOption
+	if config.CircuitBreaker != nil {
+		b := breaker.NewBreaker(config.CircuitBreaker, breaker.WithStateChangeCallback(func(from breaker.State, to breaker.State) {
+			moduleLogger(context.Background()).Warn("Circuit breaker state for gitter REST API changed", logging.F("from", from), logging.F("to", to))
+		}))
+		clientOptions = append(clientOptions, WithCircuitBreaker(b))
+	}
+
 	adapter := &Adapter{
 		config:          config,
-		apiClient:       NewRestAPIClient(config.Token),
-		streamingClient: NewStreamingAPIClient(config.Token),
+		apiClient:       NewRestAPIClient(config.Token.Reveal(), clientOptions...),
+		streamingClient: NewStreamingAPIClient(config.Token.Reveal()),
+		rooms:           map[string]*roomConnection{},
 	}
 
 	for _, opt := range options {
@@ -39,106 +71,452 @@ func NewAdapter(config *Config, options ...AdapterOption) (*Adapter, error) {
 	return adapter, nil
 }
 
+var _ sarah.Verifier = (*Adapter)(nil)
+
+// Verify fetches the belonging rooms to check the token and the reachability of the REST API.
+// This satisfies sarah.Verifier so sarah.Verify() can report an invalid token before sarah.Run().
+func (adapter *Adapter) Verify(ctx context.Context) error {
+	_, err := adapter.apiClient.Rooms(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch rooms: %w", err)
+	}
+	return nil
+}
+
 // BotType returns gitter designated BotType.
 func (adapter *Adapter) BotType() sarah.BotType {
 	return GITTER
 }
 
 // Run fetches all belonging Room and connects to them.
+// The belonging rooms are fetched again every Config.RoomRefreshInterval to connect to the newly joined rooms
+// and to disconnect from the left rooms.
 func (adapter *Adapter) Run(ctx context.Context, enqueueInput func(sarah.Input) error, notifyErr func(error)) {
 	// Get belonging rooms.
 	var rooms *Rooms
-	err := retry.WithPolicy(adapter.config.RetryPolicy, func() (e error) {
+	err := retry.WithPolicyContext(ctx, adapter.retryPolicy(ctx, "fetch rooms"), func() (e error) {
 		rooms, e = adapter.apiClient.Rooms(ctx)
+
+		// Fail fast on invalid token since retrying never succeeds.
+		var authErr *AuthenticationError
+		if errors.As(e, &authErr) {
+			return retry.Permanent(e)
+		}
 		return e
 	})
+	if ctx.Err() != nil {
+		// The Bot is stopping.
+		return
+	}
 	if err != nil {
 		notifyErr(sarah.NewBotNonContinuableError(err.Error()))
 		return
 	}
 
+	adapter.mutex.Lock()
+	adapter.runCtx = ctx
+	adapter.enqueueInput = enqueueInput
+	adapter.notifyErr = notifyErr
+	adapter.mutex.Unlock()
+
 	// Connect to each room.
-	for _, room := range *rooms {
-		go adapter.runEachRoom(ctx, room, enqueueInput)
+	adapter.syncRooms(*rooms)
+
+	if adapter.config.RoomRefreshInterval <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(adapter.config.RoomRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			rooms, err := adapter.apiClient.Rooms(ctx)
+			if err != nil {
+				// Keep the current connections and try again on the next tick.
+				moduleLogger(ctx).Warn("Failed to refresh rooms", logging.Err(err))
+				continue
+			}
+			adapter.syncRooms(*rooms)
+
+		}
 	}
 }
 
+// syncRooms connects to the given rooms that are not connected yet and disconnects from the rooms that are not given.
+func (adapter *Adapter) syncRooms(rooms Rooms) {
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+
+	given := map[string]struct{}{}
+	for _, room := range rooms {
+		given[room.ID] = struct{}{}
+		adapter.connectRoom(room)
+	}
+
+	for id, conn := range adapter.rooms {
+		if _, ok := given[id]; !ok {
+			moduleLogger(adapter.runCtx).Info("Disconnecting from the left room", logging.F("room_id", id))
+			conn.cancel()
+			delete(adapter.rooms, id)
+		}
+	}
+}
+
+// connectRoom starts receiving messages from the given room unless already connected.
+// The mutex must be locked by the caller.
+func (adapter *Adapter) connectRoom(room *Room) {
+	if _, ok := adapter.rooms[room.ID]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(adapter.runCtx)
+	conn := &roomConnection{
+		room:   room,
+		cancel: cancel,
+	}
+	adapter.rooms[room.ID] = conn
+
+	go func() {
+		adapter.runEachRoom(ctx, room, adapter.enqueueInput)
+
+		// Forget the room so the next refresh can connect to the room again.
+		adapter.mutex.Lock()
+		defer adapter.mutex.Unlock()
+		if adapter.rooms[room.ID] == conn {
+			delete(adapter.rooms, room.ID)
+		}
+		cancel()
+	}()
+}
+
+// ErrNotRunning is returned when an operation requires the running Adapter.
+var ErrNotRunning = errors.New("adapter is not running")
+
+// JoinRoom joins the room with the given URI such as "gitterhq/sandbox" and starts receiving messages from the room.
+// The Adapter must be running.
+func (adapter *Adapter) JoinRoom(ctx context.Context, uri string) (*Room, error) {
+	adapter.mutex.Lock()
+	running := adapter.runCtx != nil && adapter.runCtx.Err() == nil
+	adapter.mutex.Unlock()
+	if !running {
+		return nil, ErrNotRunning
+	}
+
+	room, err := adapter.apiClient.JoinRoom(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+	adapter.connectRoom(room)
+
+	return room, nil
+}
+
+// LeaveRoom leaves the room with the given ID and stops receiving messages from the room.
+func (adapter *Adapter) LeaveRoom(ctx context.Context, roomID string) error {
+	err := adapter.apiClient.LeaveRoom(ctx, roomID)
+	if err != nil {
+		return err
+	}
+
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+	if conn, ok := adapter.rooms[roomID]; ok {
+		conn.cancel()
+		delete(adapter.rooms, roomID)
+	}
+
+	return nil
+}
+
 // SendMessage let Bot send message to gitter.
-func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) {
+// A message with sarah.PrivateDestination is sent to the one-to-one room with the recipient.
+// The returned sarah.SendResult carries the identifier and the sent time of the message.
+func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) (*sarah.SendResult, error) {
+	var text string
 	switch content := output.Content().(type) {
 	case string:
-		room, ok := output.Destination().(*Room)
-		if !ok {
-			logger.Errorf("Destination is not instance of Room. %#v.", output.Destination())
-			return
+		text = content
+
+	case *sarah.RichMessage:
+		text = richMarkdown(content)
+
+	case *sarah.Reaction:
+		// gitter's REST API provides no endpoint to add a reaction, so the emoji is sent as a message.
+		text = ":" + strings.Trim(content.Name, ":") + ":"
+
+	case *sarah.FileContent:
+		// gitter's REST API provides no endpoint to upload a file, so a text file is embedded as a code block.
+		var err error
+		text, err = fileMarkdown(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send file %s: %w", content.FileName, err)
 		}
-		_, err := adapter.apiClient.PostMessage(ctx, room, content)
-		logger.Errorf("Failed posting message to %s: %+v", room.ID, err)
 
 	default:
-		logger.Warnf("Unexpected output %#v", output)
+		return nil, fmt.Errorf("%w: %T", sarah.ErrUnsupportedContent, content)
 
 	}
+
+	// gitter adapter does not support threads, so a reply in a thread is sent to the room.
+	room, err := adapter.destinationRoom(ctx, output.Destination())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve destination room: %w", err)
+	}
+	message, err := adapter.apiClient.PostMessage(ctx, room, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed posting message to %s: %w", room.ID, err)
+	}
+
+	return &sarah.SendResult{
+		Destination: room,
+		MessageID:   message.ID,
+		SentAt:      message.SendTimeStamp.Time,
+	}, nil
 }
 
-func (adapter *Adapter) runEachRoom(ctx context.Context, room *Room, enqueueInput func(sarah.Input) error) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
+// ErrUnsupportedPrivateMessage was returned when a message is sent with sarah.PrivateDestination.
+//
+// Deprecated: A message with sarah.PrivateDestination is now sent to the one-to-one room with the recipient.
+var ErrUnsupportedPrivateMessage = errors.New("private message is not supported by gitter")
 
-		default:
-			logger.Infof("Connecting to room: %s", room.ID)
+// ErrUnsupportedFile is returned when a non-text file is sent; gitter's REST API provides no endpoint to upload a file.
+var ErrUnsupportedFile = errors.New("only a text file can be sent to gitter")
 
-			var conn Connection
-			err := retry.WithPolicy(adapter.config.RetryPolicy, func() (e error) {
-				conn, e = adapter.streamingClient.Connect(ctx, room)
-				return e
+// fileMarkdown renders the given text file as a code block.
+func fileMarkdown(file *sarah.FileContent) (string, error) {
+	if !isTextFile(file.MIMEType) {
+		if closer, ok := file.Reader.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFile, file.MIMEType)
+	}
+
+	content, err := file.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	var blocks []string
+	if file.Comment != "" {
+		blocks = append(blocks, file.Comment)
+	}
+	blocks = append(blocks, fmt.Sprintf("**%s**", file.FileName))
+	blocks = append(blocks, "```\n"+strings.TrimRight(string(content), "\n")+"\n```")
+
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+func isTextFile(mimeType string) bool {
+	mimeType = strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0])
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+
+	switch mimeType {
+	case "application/json", "application/xml", "application/x-yaml", "application/yaml":
+		return true
+
+	default:
+		return false
+
+	}
+}
+
+// richMarkdown renders the given sarah.RichMessage in gitter's markdown.
+// Color has no equivalent in gitter and is ignored.
+func richMarkdown(message *sarah.RichMessage) string {
+	var blocks []string
+	if message.Title != "" {
+		if message.TitleURL != "" {
+			blocks = append(blocks, fmt.Sprintf("**[%s](%s)**", message.Title, message.TitleURL))
+		} else {
+			blocks = append(blocks, fmt.Sprintf("**%s**", message.Title))
+		}
+	}
+
+	if message.Text != "" {
+		blocks = append(blocks, message.Text)
+	}
+
+	if len(message.Fields) > 0 {
+		var lines []string
+		for _, f := range message.Fields {
+			lines = append(lines, fmt.Sprintf("- **%s**: %s", f.Title, f.Value))
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	if message.ImageURL != "" {
+		blocks = append(blocks, fmt.Sprintf("![image](%s)", message.ImageURL))
+	}
+
+	// gitter has no interactive components, so only the link buttons are rendered.
+	var links []string
+	for _, b := range message.Buttons {
+		if b.Interactive() {
+			continue
+		}
+		links = append(links, fmt.Sprintf("[%s](%s)", b.Label, b.URL))
+	}
+	if len(links) > 0 {
+		blocks = append(blocks, strings.Join(links, " | "))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// retryPolicy returns a copy of the configured retry.Policy that logs each failed attempt of the given action.
+func (adapter *Adapter) retryPolicy(ctx context.Context, action string) *retry.Policy {
+	policy := *adapter.config.RetryPolicy
+	policy.OnRetry = func(attempt uint, err error, next time.Duration) {
+		moduleLogger(ctx).Warn("Failed to "+action+". Retrying", logging.F("attempt", attempt), logging.F("next", next), logging.Err(err))
+	}
+	return &policy
+}
+
+func (adapter *Adapter) runEachRoom(ctx context.Context, room *Room, enqueueInput func(sarah.Input) error) {
+	log := moduleLogger(ctx).With(logging.F("room_id", room.ID))
+	options := []adapterkit.StreamOption{
+		adapterkit.WithReconnectPolicy(adapter.config.ReconnectPolicy),
+		adapterkit.WithHeartbeatTimeout(adapter.config.HeartbeatTimeout),
+		adapterkit.WithStableDuration(stableConnectionDuration),
+		adapterkit.WithConnectionName(room.ID),
+		adapterkit.WithOutageNotification(adapter.config.OutageThreshold, func(err *adapterkit.OutageError) {
+			adapter.reportOutage(ctx, &RoomOutageError{
+				RoomID:   room.ID,
+				Since:    err.Since,
+				Failures: err.Failures,
+				Err:      err.Err,
 			})
+		}),
+	}
+	if adapter.config.BackfillLimit > 0 {
+		options = append(options, adapterkit.WithBackfill(func(ctx context.Context, last sarah.Input) ([]sarah.Input, error) {
+			return adapter.backfill(ctx, room, last)
+		}))
+	}
+
+	stream := adapterkit.NewStream(
+		func(ctx context.Context) (adapterkit.Connection, error) {
+			conn, err := adapter.streamingClient.Connect(ctx, room)
 			if err != nil {
-				logger.Warnf("Could not connect to room: %s. Error: %+v", room.ID, err)
-				return
+				return nil, err
 			}
+			return &streamConnection{conn: conn}, nil
+		},
+		options...,
+	)
+	stream.Run(logging.NewContext(ctx, log), enqueueInput)
+}
 
-			connErr := receiveMessageRecursive(conn, enqueueInput)
-			_ = conn.Close()
+// backfill fetches the messages posted in the given room after the last received message while the connection was down.
+// The returned messages are flagged as catch-up inputs.
+func (adapter *Adapter) backfill(ctx context.Context, room *Room, last sarah.Input) ([]sarah.Input, error) {
+	message, ok := last.(*RoomMessage)
+	if !ok || message.MessageID() == "" {
+		return nil, nil
+	}
 
-			// TODO: Intentional connection close such as context.cancel also comes here.
-			// It would be nice if we could detect such event to distinguish intentional behaviour and unintentional connection error.
-			// But, the truth is, given error is just a privately defined error instance given by http package.
-			// var errRequestCanceled = errors.New("net/http: request canceled")
-			// For now, let error log appear and proceed to next loop, select case with ctx.Done() will eventually return.
-			logger.Errorf("Disconnected from room %s: %+v", room.ID, connErr)
+	messages, err := adapter.apiClient.ChatMessages(ctx, room.ID, message.MessageID(), adapter.config.BackfillLimit)
+	if err != nil {
+		return nil, err
+	}
 
-		}
+	inputs := make([]sarah.Input, 0, len(messages))
+	for _, m := range messages {
+		input := NewRoomMessage(room, m)
+		input.CatchUp = true
+		inputs = append(inputs, input)
 	}
+	return inputs, nil
 }
 
-func receiveMessageRecursive(messageReceiver MessageReceiver, enqueueInput func(sarah.Input) error) error {
-	logger.Infof("Start receiving message")
-	for {
-		message, err := messageReceiver.Receive()
-
-		var malformedErr *MalformedPayloadError
-		if errors.Is(err, ErrEmptyPayload) {
-			// https://developer.gitter.im/docs/streaming-api
-			// Parsers must be tolerant of occasional extra newline characters placed between messages.
-			// These characters are sent as periodic "keep-alive" messages to tell clients and NAT firewalls
-			// that the connection is still alive during low message volume periods.
-			continue
+// ErrHeartbeatTimeout is returned when no data, including keep-alive newlines, is received within Config.HeartbeatTimeout.
+var ErrHeartbeatTimeout = adapterkit.ErrHeartbeatTimeout
 
-		} else if errors.As(err, &malformedErr) {
-			logger.Warnf("Skipping malformed input: %+v", err)
-			continue
+// streamConnection adapts Connection to adapterkit.Connection.
+type streamConnection struct {
+	conn Connection
+}
 
-		} else if err != nil {
-			// At this point, assume connection is unstable or is closed.
-			// Let caller proceed to reconnect or quit.
-			return fmt.Errorf("failed to receive input: %w", err)
+var _ adapterkit.HeartbeatReceiver = (*streamConnection)(nil)
 
-		}
+func (c *streamConnection) Receive() (interface{}, error) {
+	message, err := c.conn.Receive()
+
+	var malformedErr *MalformedPayloadError
+	if errors.Is(err, ErrEmptyPayload) {
+		// https://developer.gitter.im/docs/streaming-api
+		// Parsers must be tolerant of occasional extra newline characters placed between messages.
+		// These characters are sent as periodic "keep-alive" messages to tell clients and NAT firewalls
+		// that the connection is still alive during low message volume periods.
+		return nil, adapterkit.ErrKeepAlive
+
+	} else if errors.As(err, &malformedErr) {
+		return nil, &adapterkit.MalformedPayloadError{Err: err}
+
+	} else if err != nil {
+		return nil, err
+
+	}
+
+	return message, nil
+}
+
+func (c *streamConnection) Close() error {
+	return c.conn.Close()
+}
 
-		_ = enqueueInput(message)
+// LastReceived returns the time when the last data was received,
+// or the current time when the connection does not satisfy HeartbeatReceiver so the connection is never considered wedged.
+func (c *streamConnection) LastReceived() time.Time {
+	if receiver, ok := c.conn.(HeartbeatReceiver); ok {
+		return receiver.LastReceived()
+	}
+	return time.Now()
+}
+
+// stableConnectionDuration is the duration that a connection must last to be considered stable.
+const stableConnectionDuration = 1 * time.Minute
+
+// RoomOutageError is notified via the error notification function given to Adapter.Run when a room is unreachable
+// for Config.OutageThreshold or longer.
+// This does not stop the Bot; the Adapter keeps reconnecting to the room.
+// Handle this with sarah.RegisterBotErrorSupervisor to alert the administrator.
+type RoomOutageError struct {
+	RoomID   string
+	Since    time.Time
+	Failures uint
+	Err      error
+}
+
+// Error returns the stringified form of the error.
+func (e *RoomOutageError) Error() string {
+	return fmt.Sprintf("room %s is unreachable since %s after %d attempts: %s", e.RoomID, e.Since.Format(time.RFC3339), e.Failures, e.Err.Error())
+}
+
+// Unwrap returns the last connection error.
+func (e *RoomOutageError) Unwrap() error {
+	return e.Err
+}
+
+func (adapter *Adapter) reportOutage(ctx context.Context, err *RoomOutageError) {
+	moduleLogger(ctx).Error("Room is unreachable for a prolonged time", logging.F("room_id", err.RoomID), logging.F("since", err.Since), logging.Err(err.Err))
+
+	adapter.mutex.Lock()
+	notifyErr := adapter.notifyErr
+	adapter.mutex.Unlock()
+
+	if notifyErr != nil {
+		notifyErr(err)
 	}
 }
 
@@ -158,6 +536,18 @@ func NewResponse(content string, options ...RespOption) (*sarah.CommandResponse,
 	}, nil
 }
 
+// NewMarkdownResponse creates *sarah.CommandResponse that replies to the sender of the given input with the given markdown text.
+// The sender is mentioned at the beginning of the text so the sender is notified, unless the input is sent in a one-to-one room.
+func NewMarkdownResponse(input sarah.Input, markdown string, options ...RespOption) (*sarah.CommandResponse, error) {
+	if message, ok := input.(*RoomMessage); ok && !message.IsDirectMessage() {
+		if username := message.ReceivedMessage.FromUser.UserName; username != "" {
+			markdown = "@" + username + " " + markdown
+		}
+	}
+
+	return NewResponse(markdown, options...)
+}
+
 // RespWithNext sets given fnc as part of the response's *sarah.UserContext.
 // The next input from the same user will be passed to this fnc.
 // See sarah.UserContextStorage must be present or otherwise, fnc will be ignored.
@@ -192,6 +582,14 @@ type respOptions struct {
 type APIClient interface {
 	Rooms(context.Context) (*Rooms, error)
 	PostMessage(context.Context, *Room, string) (*Message, error)
+	JoinRoom(context.Context, string) (*Room, error)
+	LeaveRoom(context.Context, string) error
+	UpdateMessage(context.Context, *Room, string, string) (*Message, error)
+	DeleteMessage(context.Context, *Room, string) error
+	RoomUsers(context.Context, string, string) ([]*User, error)
+	UnreadItems(context.Context, string) (*UnreadItems, error)
+	MarkAsRead(context.Context, string, ...string) error
+	ChatMessages(context.Context, string, string, uint) ([]*Message, error)
 }
 
 // StreamingClient is an interface that HTTP Streaming client must satisfy.
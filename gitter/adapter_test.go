@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"github.com/oklahomer/go-kasumi/logger"
-	"github.com/oklahomer/go-kasumi/retry"
 	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/adapterkit"
+	"github.com/oklahomer/go-sarah/v4/retry"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,8 +32,16 @@ func TestMain(m *testing.M) {
 }
 
 type DummyAPIClient struct {
-	RoomsFunc       func(context.Context) (*Rooms, error)
-	PostMessageFunc func(context.Context, *Room, string) (*Message, error)
+	RoomsFunc         func(context.Context) (*Rooms, error)
+	PostMessageFunc   func(context.Context, *Room, string) (*Message, error)
+	JoinRoomFunc      func(context.Context, string) (*Room, error)
+	LeaveRoomFunc     func(context.Context, string) error
+	UpdateMessageFunc func(context.Context, *Room, string, string) (*Message, error)
+	DeleteMessageFunc func(context.Context, *Room, string) error
+	RoomUsersFunc     func(context.Context, string, string) ([]*User, error)
+	UnreadItemsFunc   func(context.Context, string) (*UnreadItems, error)
+	MarkAsReadFunc    func(context.Context, string, ...string) error
+	ChatMessagesFunc  func(context.Context, string, string, uint) ([]*Message, error)
 }
 
 func (c *DummyAPIClient) Rooms(ctx context.Context) (*Rooms, error) {
@@ -40,6 +52,38 @@ func (c *DummyAPIClient) PostMessage(ctx context.Context, room *Room, message st
 	return c.PostMessageFunc(ctx, room, message)
 }
 
+func (c *DummyAPIClient) JoinRoom(ctx context.Context, uri string) (*Room, error) {
+	return c.JoinRoomFunc(ctx, uri)
+}
+
+func (c *DummyAPIClient) LeaveRoom(ctx context.Context, roomID string) error {
+	return c.LeaveRoomFunc(ctx, roomID)
+}
+
+func (c *DummyAPIClient) UpdateMessage(ctx context.Context, room *Room, messageID string, text string) (*Message, error) {
+	return c.UpdateMessageFunc(ctx, room, messageID, text)
+}
+
+func (c *DummyAPIClient) DeleteMessage(ctx context.Context, room *Room, messageID string) error {
+	return c.DeleteMessageFunc(ctx, room, messageID)
+}
+
+func (c *DummyAPIClient) RoomUsers(ctx context.Context, roomID string, query string) ([]*User, error) {
+	return c.RoomUsersFunc(ctx, roomID, query)
+}
+
+func (c *DummyAPIClient) UnreadItems(ctx context.Context, roomID string) (*UnreadItems, error) {
+	return c.UnreadItemsFunc(ctx, roomID)
+}
+
+func (c *DummyAPIClient) MarkAsRead(ctx context.Context, roomID string, messageIDs ...string) error {
+	return c.MarkAsReadFunc(ctx, roomID, messageIDs...)
+}
+
+func (c *DummyAPIClient) ChatMessages(ctx context.Context, roomID string, afterID string, limit uint) ([]*Message, error) {
+	return c.ChatMessagesFunc(ctx, roomID, afterID, limit)
+}
+
 type DummyStreamingClient struct {
 	ConnectFunc func(context.Context, *Room) (Connection, error)
 }
@@ -61,6 +105,15 @@ func (c *DummyConnection) Close() error {
 	return c.CloseFunc()
 }
 
+type DummyHeartbeatConnection struct {
+	DummyConnection
+	LastReceivedFunc func() time.Time
+}
+
+func (c *DummyHeartbeatConnection) LastReceived() time.Time {
+	return c.LastReceivedFunc()
+}
+
 func TestNewAdapter(t *testing.T) {
 	config := NewConfig()
 	adapter, err := NewAdapter(config, func(_ *Adapter) {})
@@ -81,46 +134,98 @@ func TestAdapter_BotType(t *testing.T) {
 	}
 }
 
-func Test_receiveMessageRecursive(t *testing.T) {
-	type value struct {
-		message *RoomMessage
-		err     error
+func TestAdapter_Verify(t *testing.T) {
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			RoomsFunc: func(_ context.Context) (*Rooms, error) {
+				return &Rooms{}, nil
+			},
+		},
+	}
+	if err := adapter.Verify(context.TODO()); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
 	}
-	values := []value{
+
+	expected := &AuthenticationError{}
+	adapter.apiClient = &DummyAPIClient{
+		RoomsFunc: func(_ context.Context) (*Rooms, error) {
+			return nil, expected
+		},
+	}
+	err := adapter.Verify(context.TODO())
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func Test_streamConnection_Receive(t *testing.T) {
+	message := &RoomMessage{}
+	tests := []struct {
+		message  *RoomMessage
+		err      error
+		validate func(interface{}, error) bool
+	}{
 		{
-			message: &RoomMessage{},
-			err:     nil,
+			message: message,
+			validate: func(payload interface{}, err error) bool {
+				return err == nil && payload == message
+			},
 		},
 		{
-			message: nil,
-			err:     ErrEmptyPayload,
+			err: ErrEmptyPayload,
+			validate: func(_ interface{}, err error) bool {
+				return errors.Is(err, adapterkit.ErrKeepAlive)
+			},
 		},
 		{
-			message: nil,
-			err:     &MalformedPayloadError{},
+			err: NewMalformedPayloadError("invalid"),
+			validate: func(_ interface{}, err error) bool {
+				var malformedErr *adapterkit.MalformedPayloadError
+				return errors.As(err, &malformedErr)
+			},
 		},
 		{
-			message: nil,
-			err:     errors.New("random error"),
+			err: errors.New("random error"),
+			validate: func(_ interface{}, err error) bool {
+				var malformedErr *adapterkit.MalformedPayloadError
+				return err != nil && !errors.Is(err, adapterkit.ErrKeepAlive) && !errors.As(err, &malformedErr)
+			},
 		},
 	}
 
-	conn := &DummyConnection{
-		ReceiveFunc: func() (*RoomMessage, error) {
-			var v value
-			v, values = values[0], values[1:]
-			return v.message, v.err
+	for i, tt := range tests {
+		conn := &streamConnection{
+			conn: &DummyConnection{
+				ReceiveFunc: func() (*RoomMessage, error) {
+					return tt.message, tt.err
+				},
+			},
+		}
+
+		if !tt.validate(conn.Receive()) {
+			t.Errorf("Unexpected result is returned on test #%d.", i)
+		}
+	}
+}
+
+func Test_streamConnection_LastReceived(t *testing.T) {
+	lastReceived := time.Now().Add(-1 * time.Hour)
+	conn := &streamConnection{
+		conn: &DummyHeartbeatConnection{
+			LastReceivedFunc: func() time.Time {
+				return lastReceived
+			},
 		},
 	}
-	enqueueCnt := 0
-	enqueuer := func(_ sarah.Input) error {
-		enqueueCnt++
-		return nil
+
+	if !conn.LastReceived().Equal(lastReceived) {
+		t.Errorf("Unexpected time is returned: %s.", conn.LastReceived())
 	}
-	_ = receiveMessageRecursive(conn, enqueuer)
 
-	if enqueueCnt != 1 {
-		t.Errorf("Enqueued %d times. Should enqueue only if no error is returned.", enqueueCnt)
+	conn = &streamConnection{conn: &DummyConnection{}}
+	if time.Since(conn.LastReceived()) > time.Second {
+		t.Errorf("Connection without heartbeat support must not be considered wedged: %s.", conn.LastReceived())
 	}
 }
 
@@ -147,6 +252,9 @@ func TestAdapter_runEachRoom(t *testing.T) {
 			RetryPolicy: &retry.Policy{
 				Trial: 1,
 			},
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
 		},
 	}
 
@@ -176,20 +284,95 @@ func TestAdapter_runEachRoom(t *testing.T) {
 	}
 }
 
+func TestAdapter_runEachRoom_Backfill(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connected := 0
+	room := &Room{ID: "testID"}
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			ChatMessagesFunc: func(_ context.Context, roomID string, afterID string, limit uint) ([]*Message, error) {
+				if roomID != room.ID || afterID != "1" || limit != 10 {
+					t.Errorf("Unexpected arguments are given: %s, %s, %d.", roomID, afterID, limit)
+				}
+				return []*Message{{ID: "2"}}, nil
+			},
+		},
+		streamingClient: &DummyStreamingClient{
+			ConnectFunc: func(_ context.Context, _ *Room) (Connection, error) {
+				connected++
+				if connected > 2 {
+					cancel()
+					return nil, ctx.Err()
+				}
+
+				sent := connected != 1
+				return &DummyConnection{
+					ReceiveFunc: func() (*RoomMessage, error) {
+						if sent {
+							return nil, errors.New("connection is closed")
+						}
+						sent = true
+						return NewRoomMessage(room, &Message{ID: "1"}), nil
+					},
+					CloseFunc: func() error {
+						return nil
+					},
+				}, nil
+			},
+		},
+		config: &Config{
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
+			BackfillLimit: 10,
+		},
+	}
+
+	var inputs []*RoomMessage
+	adapter.runEachRoom(ctx, room, func(input sarah.Input) error {
+		inputs = append(inputs, input.(*RoomMessage))
+		return nil
+	})
+
+	if len(inputs) != 2 {
+		t.Fatalf("Unexpected number of inputs are enqueued: %d.", len(inputs))
+	}
+	if inputs[0].IsCatchUp() || inputs[0].MessageID() != "1" {
+		t.Errorf("Unexpected input is enqueued: %#v.", inputs[0])
+	}
+	if !inputs[1].IsCatchUp() || inputs[1].MessageID() != "2" || inputs[1].Room != room {
+		t.Errorf("Backfilled message is not enqueued as catch-up input: %#v.", inputs[1])
+	}
+}
+
 func TestAdapter_runEachRoom_ConnectionInitializationError(t *testing.T) {
-	var retryLimit uint = 1
-	connect := make(chan struct{}, retryLimit)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var intervals []time.Time
+	var notified error
 	adapter := &Adapter{
 		streamingClient: &DummyStreamingClient{
 			ConnectFunc: func(_ context.Context, _ *Room) (Connection, error) {
-				connect <- struct{}{}
+				intervals = append(intervals, time.Now())
+				if len(intervals) == 4 {
+					// Keep retrying til the context is canceled.
+					cancel()
+				}
 				return nil, errors.New("connection error")
 			},
 		},
 		config: &Config{
-			RetryPolicy: &retry.Policy{
-				Trial: 1,
+			ReconnectPolicy: &retry.Policy{
+				Interval:   10 * time.Millisecond,
+				Multiplier: 2,
 			},
+			OutageThreshold: 1 * time.Millisecond,
+		},
+		notifyErr: func(err error) {
+			notified = err
 		},
 	}
 
@@ -201,7 +384,7 @@ func TestAdapter_runEachRoom_ConnectionInitializationError(t *testing.T) {
 	room := &Room{
 		ID: "testID",
 	}
-	adapter.runEachRoom(context.TODO(), room, enqueuer) // No goroutine. Will end automatically.
+	adapter.runEachRoom(ctx, room, enqueuer) // No goroutine. Will end on the context cancellation.
 
 	select {
 	case <-queue:
@@ -209,6 +392,105 @@ func TestAdapter_runEachRoom_ConnectionInitializationError(t *testing.T) {
 	case <-time.NewTimer(10 * time.Millisecond).C:
 		// O.K.
 	}
+
+	if len(intervals) != 4 {
+		t.Fatalf("Unexpected number of connection attempts: %d.", len(intervals))
+	}
+
+	// The interval grows exponentially: 10ms, 20ms, 40ms.
+	if elapsed := intervals[3].Sub(intervals[2]); elapsed < 40*time.Millisecond {
+		t.Errorf("Interval is not backed off: %s.", elapsed)
+	}
+
+	outageErr, ok := notified.(*RoomOutageError)
+	if !ok {
+		t.Fatalf("Expected error is not notified: %#v.", notified)
+	}
+	if outageErr.RoomID != "testID" {
+		t.Errorf("Unexpected room ID is given: %s.", outageErr.RoomID)
+	}
+}
+
+func TestAdapter_runEachRoom_HeartbeatTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connected := 0
+	adapter := &Adapter{
+		config: &Config{
+			ReconnectPolicy:  &retry.Policy{Interval: 1 * time.Millisecond},
+			HeartbeatTimeout: 10 * time.Millisecond,
+		},
+		streamingClient: &DummyStreamingClient{
+			ConnectFunc: func(_ context.Context, _ *Room) (Connection, error) {
+				connected++
+				if connected > 1 {
+					// Reconnected after the wedged connection is closed.
+					cancel()
+					return nil, ctx.Err()
+				}
+
+				closed := make(chan struct{})
+				return &DummyHeartbeatConnection{
+					DummyConnection: DummyConnection{
+						ReceiveFunc: func() (*RoomMessage, error) {
+							// Block like a wedged connection until closed.
+							<-closed
+							return nil, errors.New("read on closed body")
+						},
+						CloseFunc: func() error {
+							select {
+							case <-closed:
+							default:
+								close(closed)
+							}
+							return nil
+						},
+					},
+					LastReceivedFunc: func() time.Time {
+						return time.Now().Add(-1 * time.Hour)
+					},
+				}, nil
+			},
+		},
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		adapter.runEachRoom(ctx, &Room{ID: "123"}, func(_ sarah.Input) error { return nil })
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("runEachRoom did not finish.")
+
+	}
+
+	if connected != 2 {
+		t.Errorf("Expected to reconnect once, but connected %d times.", connected)
+	}
+}
+
+func TestRoomOutageError(t *testing.T) {
+	cause := errors.New("connection error")
+	err := &RoomOutageError{
+		RoomID:   "testID",
+		Since:    time.Now(),
+		Failures: 3,
+		Err:      cause,
+	}
+
+	if !strings.Contains(err.Error(), "testID") {
+		t.Errorf("Room ID is not included: %s.", err.Error())
+	}
+
+	if !errors.Is(err, cause) {
+		t.Error("Cause is not wrapped.")
+	}
 }
 
 func TestAdapter_runEachRoom_ConnectionError(t *testing.T) {
@@ -245,6 +527,9 @@ func TestAdapter_runEachRoom_ConnectionError(t *testing.T) {
 			RetryPolicy: &retry.Policy{
 				Trial: 1,
 			},
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
 		},
 	}
 
@@ -278,6 +563,45 @@ func TestAdapter_runEachRoom_ConnectionError(t *testing.T) {
 	}
 }
 
+func TestAdapter_runEachRoom_Canceled(t *testing.T) {
+	connected := make(chan string, 10)
+	disconnected := make(chan string, 10)
+	adapter := &Adapter{
+		streamingClient: blockingConnector(connected, disconnected),
+		config: &Config{
+			RetryPolicy: &retry.Policy{
+				Trial: 1,
+			},
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		adapter.runEachRoom(ctx, &Room{ID: "testID"}, func(_ sarah.Input) error { return nil })
+		close(finished)
+	}()
+
+	<-connected
+	cancel()
+
+	select {
+	case <-finished:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("runEachRoom does not return on context cancellation.")
+
+	}
+
+	if len(connected) != 0 {
+		t.Error("Connection must not be re-established on context cancellation.")
+	}
+}
+
 func TestAdapter_Run(t *testing.T) {
 	givenRoom := make(chan string)
 	roomID := "dummy"
@@ -286,6 +610,9 @@ func TestAdapter_Run(t *testing.T) {
 			RetryPolicy: &retry.Policy{
 				Trial: 1,
 			},
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
 		},
 		apiClient: &DummyAPIClient{
 			RoomsFunc: func(_ context.Context) (*Rooms, error) {
@@ -302,10 +629,12 @@ func TestAdapter_Run(t *testing.T) {
 				return nil, errors.New("to be ignored")
 			},
 		},
+		rooms: map[string]*roomConnection{},
 	}
 
-	adapter.Run(context.TODO(), func(sarah.Input) error { return nil }, func(error) {})
-	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go adapter.Run(ctx, func(sarah.Input) error { return nil }, func(error) {})
 
 	select {
 	case id := <-givenRoom:
@@ -317,12 +646,190 @@ func TestAdapter_Run(t *testing.T) {
 	}
 }
 
+// blockingConnector returns a DummyStreamingClient whose Connection blocks til the connection's context is canceled.
+// Each connected room ID is sent to the given channel, and each disconnected room ID is sent to the other channel.
+func blockingConnector(connected chan<- string, disconnected chan<- string) *DummyStreamingClient {
+	return &DummyStreamingClient{
+		ConnectFunc: func(ctx context.Context, room *Room) (Connection, error) {
+			connected <- room.ID
+			return &DummyConnection{
+				ReceiveFunc: func() (*RoomMessage, error) {
+					<-ctx.Done()
+					disconnected <- room.ID
+					return nil, ctx.Err()
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}, nil
+		},
+	}
+}
+
+func TestAdapter_Run_RefreshRooms(t *testing.T) {
+	connected := make(chan string, 10)
+	disconnected := make(chan string, 10)
+	var mutex sync.Mutex
+	fetched := 0
+	adapter := &Adapter{
+		config: &Config{
+			RetryPolicy: &retry.Policy{
+				Trial: 1,
+			},
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
+			RoomRefreshInterval: 10 * time.Millisecond,
+		},
+		apiClient: &DummyAPIClient{
+			RoomsFunc: func(_ context.Context) (*Rooms, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				fetched++
+				if fetched == 1 {
+					return &Rooms{{ID: "left"}}, nil
+				}
+				return &Rooms{{ID: "joined"}}, nil
+			},
+		},
+		streamingClient: blockingConnector(connected, disconnected),
+		rooms:           map[string]*roomConnection{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go adapter.Run(ctx, func(sarah.Input) error { return nil }, func(error) {})
+
+	for _, expected := range []string{"left", "joined"} {
+		select {
+		case id := <-connected:
+			if id != expected {
+				t.Errorf("Unexpected room is connected: %s.", id)
+			}
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatalf("Room is not connected: %s.", expected)
+
+		}
+	}
+
+	select {
+	case id := <-disconnected:
+		if id != "left" {
+			t.Errorf("Unexpected room is disconnected: %s.", id)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Left room is not disconnected.")
+
+	}
+}
+
+func TestAdapter_JoinRoom(t *testing.T) {
+	connected := make(chan string, 1)
+	adapter := &Adapter{
+		config: &Config{
+			RetryPolicy: &retry.Policy{
+				Trial: 1,
+			},
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
+		},
+		apiClient: &DummyAPIClient{
+			JoinRoomFunc: func(_ context.Context, uri string) (*Room, error) {
+				if uri != "gitterhq/sandbox" {
+					t.Errorf("Unexpected URI is given: %s.", uri)
+				}
+				return &Room{ID: "sandbox"}, nil
+			},
+		},
+		streamingClient: blockingConnector(connected, make(chan string, 1)),
+		rooms:           map[string]*roomConnection{},
+	}
+
+	_, err := adapter.JoinRoom(context.TODO(), "gitterhq/sandbox")
+	if err != ErrNotRunning {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	adapter.runCtx = ctx
+	adapter.enqueueInput = func(sarah.Input) error { return nil }
+
+	room, err := adapter.JoinRoom(context.TODO(), "gitterhq/sandbox")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if room.ID != "sandbox" {
+		t.Errorf("Unexpected room is returned: %#v.", room)
+	}
+
+	select {
+	case id := <-connected:
+		if id != "sandbox" {
+			t.Errorf("Unexpected room is connected: %s.", id)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Joined room is not connected.")
+
+	}
+}
+
+func TestAdapter_LeaveRoom(t *testing.T) {
+	expectedErr := errors.New("expected")
+	var leaveErr error
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			LeaveRoomFunc: func(_ context.Context, roomID string) error {
+				if roomID != "sandbox" {
+					t.Errorf("Unexpected room ID is given: %s.", roomID)
+				}
+				return leaveErr
+			},
+		},
+		rooms: map[string]*roomConnection{},
+	}
+
+	canceled := false
+	adapter.rooms["sandbox"] = &roomConnection{
+		room:   &Room{ID: "sandbox"},
+		cancel: func() { canceled = true },
+	}
+
+	leaveErr = expectedErr
+	err := adapter.LeaveRoom(context.TODO(), "sandbox")
+	if err != expectedErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+	if canceled {
+		t.Error("Connection must be kept when leaving the room fails.")
+	}
+
+	leaveErr = nil
+	err = adapter.LeaveRoom(context.TODO(), "sandbox")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !canceled {
+		t.Error("Connection is not canceled.")
+	}
+	if _, ok := adapter.rooms["sandbox"]; ok {
+		t.Error("Left room is still stored.")
+	}
+}
+
 func TestAdapter_Run_RestAPIClientRoomsError(t *testing.T) {
 	adapter := &Adapter{
 		config: &Config{
 			RetryPolicy: &retry.Policy{
 				Trial: 1,
 			},
+			ReconnectPolicy: &retry.Policy{
+				Interval: 1 * time.Millisecond,
+			},
 		},
 		apiClient: &DummyAPIClient{
 			RoomsFunc: func(_ context.Context) (*Rooms, error) {
@@ -342,23 +849,312 @@ func TestAdapter_Run_RestAPIClientRoomsError(t *testing.T) {
 	}
 }
 
+func TestAdapter_Run_RestAPIClientAuthenticationError(t *testing.T) {
+	called := 0
+	adapter := &Adapter{
+		config: &Config{
+			RetryPolicy: &retry.Policy{
+				Trial: 3,
+			},
+		},
+		apiClient: &DummyAPIClient{
+			RoomsFunc: func(_ context.Context) (*Rooms, error) {
+				called++
+				return nil, &AuthenticationError{StatusCode: http.StatusUnauthorized}
+			},
+		},
+	}
+
+	var err error
+	notifyErr := func(e error) {
+		err = e
+	}
+	adapter.Run(context.TODO(), func(sarah.Input) error { return nil }, notifyErr)
+
+	if _, ok := err.(*sarah.BotNonContinuableError); !ok {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	if called != 1 {
+		t.Errorf("Rooms fetch should not be retried on authentication error: %d.", called)
+	}
+}
+
+func TestAdapter_Run_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	adapter := &Adapter{
+		config: &Config{
+			RetryPolicy: &retry.Policy{
+				Trial:    10,
+				Interval: 1 * time.Hour,
+			},
+		},
+		apiClient: &DummyAPIClient{
+			RoomsFunc: func(_ context.Context) (*Rooms, error) {
+				cancel()
+				return nil, errors.New("room fetch error")
+			},
+		},
+	}
+
+	notified := false
+	adapter.Run(ctx, func(sarah.Input) error { return nil }, func(error) { notified = true })
+
+	if notified {
+		t.Error("Error should not be notified when the context is canceled.")
+	}
+}
+
 func TestAdapter_SendMessage(t *testing.T) {
 	called := false
 	adapter := &Adapter{
 		apiClient: &DummyAPIClient{
 			PostMessageFunc: func(_ context.Context, _ *Room, _ string) (*Message, error) {
 				called = true
-				return nil, nil
+				return &Message{ID: "message"}, nil
 			},
 		},
 	}
 	output := sarah.NewOutputMessage(&Room{}, "text")
 
-	adapter.SendMessage(context.TODO(), output)
+	result, err := adapter.SendMessage(context.TODO(), output)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
 
 	if !called {
 		t.Error("APIClient.PostMessage is not called.")
 	}
+
+	if result.MessageID != "message" {
+		t.Errorf("Unexpected message ID is returned: %s.", result.MessageID)
+	}
+}
+
+func TestAdapter_SendMessage_WithUnsupportedContent(t *testing.T) {
+	adapter := &Adapter{}
+	output := sarah.NewOutputMessage(&Room{}, struct{}{})
+
+	_, err := adapter.SendMessage(context.TODO(), output)
+	if !errors.Is(err, sarah.ErrUnsupportedContent) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestAdapter_SendMessage_WithRichMessage(t *testing.T) {
+	var text string
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			PostMessageFunc: func(_ context.Context, _ *Room, t string) (*Message, error) {
+				text = t
+				return &Message{ID: "message"}, nil
+			},
+		},
+	}
+	output := sarah.NewOutputMessage(&Room{}, &sarah.RichMessage{Title: "title"})
+
+	adapter.SendMessage(context.TODO(), output)
+
+	if text != "**title**" {
+		t.Errorf("Unexpected text is sent: %s.", text)
+	}
+}
+
+func TestAdapter_SendMessage_WithReaction(t *testing.T) {
+	var text string
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			PostMessageFunc: func(_ context.Context, _ *Room, t string) (*Message, error) {
+				text = t
+				return &Message{ID: "message"}, nil
+			},
+		},
+	}
+	output := sarah.NewOutputMessage(&Room{}, &sarah.Reaction{Name: "+1", MessageID: "message"})
+
+	adapter.SendMessage(context.TODO(), output)
+
+	if text != ":+1:" {
+		t.Errorf("Unexpected text is sent: %s.", text)
+	}
+}
+
+func TestAdapter_SendMessage_WithPrivateDestination(t *testing.T) {
+	var postedRoom *Room
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			RoomUsersFunc: func(_ context.Context, roomID string, _ string) ([]*User, error) {
+				return []*User{{ID: "user", UserName: "oklahomer"}}, nil
+			},
+			JoinRoomFunc: func(_ context.Context, uri string) (*Room, error) {
+				if uri != "oklahomer" {
+					t.Errorf("Unexpected URI is given: %s.", uri)
+				}
+				return &Room{ID: "direct", OneToOne: true}, nil
+			},
+			PostMessageFunc: func(_ context.Context, room *Room, _ string) (*Message, error) {
+				postedRoom = room
+				return &Message{ID: "message"}, nil
+			},
+		},
+	}
+	output := sarah.NewOutputMessage(sarah.NewPrivateDestination(&Room{ID: "public"}, "user"), "secret")
+
+	adapter.SendMessage(context.TODO(), output)
+
+	if postedRoom == nil || postedRoom.ID != "direct" {
+		t.Errorf("Private message must be sent to the one-to-one room: %#v.", postedRoom)
+	}
+}
+
+func TestAdapter_SendMessage_WithPrivateDestination_UnknownUser(t *testing.T) {
+	called := false
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			RoomUsersFunc: func(_ context.Context, _ string, _ string) ([]*User, error) {
+				return []*User{}, nil
+			},
+			PostMessageFunc: func(_ context.Context, _ *Room, _ string) (*Message, error) {
+				called = true
+				return &Message{ID: "message"}, nil
+			},
+		},
+	}
+	output := sarah.NewOutputMessage(sarah.NewPrivateDestination(&Room{}, "user"), "secret")
+
+	_, err := adapter.SendMessage(context.TODO(), output)
+
+	if called {
+		t.Error("Private message must not be sent to the room.")
+	}
+
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestAdapter_SendMessage_WithFile(t *testing.T) {
+	called := false
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			PostMessageFunc: func(_ context.Context, _ *Room, _ string) (*Message, error) {
+				called = true
+				return &Message{ID: "message"}, nil
+			},
+		},
+	}
+	output := sarah.NewOutputMessage(&Room{}, &sarah.FileContent{
+		Reader:   strings.NewReader("content"),
+		FileName: "chart.png",
+		MIMEType: "image/png",
+	})
+
+	adapter.SendMessage(context.TODO(), output)
+
+	if called {
+		t.Error("APIClient.PostMessage is called with unsupported file.")
+	}
+}
+
+func Test_fileMarkdown(t *testing.T) {
+	tests := []struct {
+		file *sarah.FileContent
+		text string
+		err  bool
+	}{
+		{
+			file: &sarah.FileContent{
+				Reader:   strings.NewReader("line1\nline2\n"),
+				FileName: "dummy.log",
+				MIMEType: "text/plain; charset=utf-8",
+				Comment:  "Here is the log.",
+			},
+			text: "Here is the log.\n\n**dummy.log**\n\n```\nline1\nline2\n```",
+		},
+		{
+			file: &sarah.FileContent{
+				Reader:   strings.NewReader("{}"),
+				FileName: "dummy.json",
+				MIMEType: "application/json",
+			},
+			text: "**dummy.json**\n\n```\n{}\n```",
+		},
+		{
+			file: &sarah.FileContent{
+				Reader:   strings.NewReader(""),
+				FileName: "chart.png",
+				MIMEType: "image/png",
+			},
+			err: true,
+		},
+	}
+
+	for i, tt := range tests {
+		text, err := fileMarkdown(tt.file)
+		if tt.err {
+			if !errors.Is(err, ErrUnsupportedFile) {
+				t.Errorf("Expected error is not returned on test #%d: %#v.", i, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+
+		if text != tt.text {
+			t.Errorf("Unexpected text is returned on test #%d: %s.", i, text)
+		}
+	}
+}
+
+func Test_richMarkdown(t *testing.T) {
+	message := &sarah.RichMessage{
+		Title:    "Weather",
+		TitleURL: "https://example.com/",
+		Text:     "Sunny",
+		Fields: []*sarah.RichField{
+			{Title: "High", Value: "25", Short: true},
+			{Title: "Low", Value: "16", Short: true},
+		},
+		ImageURL: "https://example.com/sunny.png",
+		Buttons: []*sarah.RichButton{
+			{Label: "Details", URL: "https://example.com/details"},
+			{Label: "Weekly", URL: "https://example.com/weekly"},
+			{Label: "Subscribe", CallbackID: "subscribe", Value: "weather"},
+		},
+		Color: "#36a64f",
+	}
+
+	expected := "**[Weather](https://example.com/)**\n\n" +
+		"Sunny\n\n" +
+		"- **High**: 25\n- **Low**: 16\n\n" +
+		"![image](https://example.com/sunny.png)\n\n" +
+		"[Details](https://example.com/details) | [Weekly](https://example.com/weekly)"
+	if text := richMarkdown(message); text != expected {
+		t.Errorf("Unexpected markdown is returned: %s.", text)
+	}
+}
+
+func TestAdapter_SendMessage_WithThreadDestination(t *testing.T) {
+	room := &Room{ID: "room"}
+	var given *Room
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			PostMessageFunc: func(_ context.Context, r *Room, _ string) (*Message, error) {
+				given = r
+				return &Message{ID: "message"}, nil
+			},
+		},
+	}
+	output := sarah.NewOutputMessage(sarah.NewThreadDestination(room, "thread"), "text")
+
+	adapter.SendMessage(context.TODO(), output)
+
+	if given != room {
+		t.Errorf("Message is not sent to the room: %#v.", given)
+	}
 }
 
 func TestAdapter_SendMessage_InvalidDestinationError(t *testing.T) {
@@ -367,7 +1163,7 @@ func TestAdapter_SendMessage_InvalidDestinationError(t *testing.T) {
 		apiClient: &DummyAPIClient{
 			PostMessageFunc: func(_ context.Context, _ *Room, _ string) (*Message, error) {
 				called = true
-				return nil, nil
+				return &Message{ID: "message"}, nil
 			},
 		},
 	}
@@ -386,7 +1182,7 @@ func TestAdapter_SendMessage_InvalidContentTypeError(t *testing.T) {
 		apiClient: &DummyAPIClient{
 			PostMessageFunc: func(_ context.Context, _ *Room, _ string) (*Message, error) {
 				called = true
-				return nil, nil
+				return &Message{ID: "message"}, nil
 			},
 		},
 	}
@@ -433,6 +1229,48 @@ func TestNewResponse(t *testing.T) {
 	}
 }
 
+func TestNewMarkdownResponse(t *testing.T) {
+	tests := []struct {
+		input    sarah.Input
+		expected string
+	}{
+		{
+			input: &RoomMessage{
+				Room:            &Room{},
+				ReceivedMessage: &Message{FromUser: User{UserName: "oklahomer"}},
+			},
+			expected: "@oklahomer **done**",
+		},
+		{
+			input: &RoomMessage{
+				Room:            &Room{OneToOne: true},
+				ReceivedMessage: &Message{FromUser: User{UserName: "oklahomer"}},
+			},
+			expected: "**done**",
+		},
+		{
+			input: &RoomMessage{
+				Room:            &Room{},
+				ReceivedMessage: &Message{},
+			},
+			expected: "**done**",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			response, err := NewMarkdownResponse(tt.input, "**done**")
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+
+			if response.Content != tt.expected {
+				t.Errorf("Unexpected content is returned: %#v.", response.Content)
+			}
+		})
+	}
+}
+
 func TestRespWithNext(t *testing.T) {
 	options := &respOptions{}
 	next := func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
@@ -1,14 +1,38 @@
 package gitter
 
 import (
-	"github.com/oklahomer/go-kasumi/retry"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/breaker"
+	"github.com/oklahomer/go-sarah/v4/retry"
+	"strings"
 	"time"
 )
 
+// maxBackfillLimit is the maximum number of messages that gitter's REST API returns at once.
+const maxBackfillLimit = 100
+
 // Config contains some configuration variables for gitter Adapter.
+// When CircuitBreaker is nil, the REST API client sends requests without a circuit breaker.
+// ReconnectPolicy defines the interval to reconnect to a room. The reconnection is retried til the room is left or the Bot stops,
+// so only Interval, Multiplier, MaxInterval and Jitter are used. OutageThreshold is the duration for a room to be unreachable
+// before RoomOutageError is notified. Zero disables the notification.
+// RoomRefreshInterval is the interval to fetch the belonging rooms again to connect to the newly joined rooms
+// and to disconnect from the left rooms. Zero disables the refresh.
+// HeartbeatTimeout is the duration to wait for any data, including the periodic keep-alive newlines, before a wedged
+// connection is closed and reconnected. Zero disables the detection.
+// BackfillLimit is the maximum number of messages to fetch from each room after a reconnection so the messages posted
+// while the connection was down are passed to go-sarah's core as catch-up inputs. gitter limits the number to 100. Zero disables the backfill.
 type Config struct {
-	Token       string        `json:"token" yaml:"token"`
-	RetryPolicy *retry.Policy `json:"retry_policy" yaml:"retry_policy"`
+	Token               sarah.Secret    `json:"token" yaml:"token"`
+	RetryPolicy         *retry.Policy   `json:"retry_policy" yaml:"retry_policy"`
+	CircuitBreaker      *breaker.Config `json:"circuit_breaker" yaml:"circuit_breaker"`
+	ReconnectPolicy     *retry.Policy   `json:"reconnect_policy" yaml:"reconnect_policy"`
+	OutageThreshold     time.Duration   `json:"outage_threshold" yaml:"outage_threshold"`
+	RoomRefreshInterval time.Duration   `json:"room_refresh_interval" yaml:"room_refresh_interval"`
+	HeartbeatTimeout    time.Duration   `json:"heartbeat_timeout" yaml:"heartbeat_timeout"`
+	BackfillLimit       uint            `json:"backfill_limit" yaml:"backfill_limit"`
 }
 
 // NewConfig returns initialized Config struct with default settings.
@@ -18,8 +42,66 @@ func NewConfig() *Config {
 	return &Config{
 		Token: "",
 		RetryPolicy: &retry.Policy{
-			Trial:    10,
-			Interval: 500 * time.Millisecond,
+			Trial:          10,
+			Interval:       500 * time.Millisecond,
+			Multiplier:     2,
+			MaxInterval:    30 * time.Second,
+			MaxElapsedTime: 5 * time.Minute,
+			Jitter:         retry.JitterFull,
+		},
+		CircuitBreaker: breaker.NewConfig(),
+		ReconnectPolicy: &retry.Policy{
+			Interval:    500 * time.Millisecond,
+			Multiplier:  2,
+			MaxInterval: 1 * time.Minute,
+			Jitter:      retry.JitterFull,
 		},
+		OutageThreshold:     5 * time.Minute,
+		RoomRefreshInterval: 5 * time.Minute,
+		HeartbeatTimeout:    3 * time.Minute,
+		BackfillLimit:       100,
+	}
+}
+
+// ApplyDefaults sets the default RetryPolicy and ReconnectPolicy when they are not set.
+// CircuitBreaker is left nil since nil disables the circuit breaker.
+func (c *Config) ApplyDefaults() {
+	defaults := NewConfig()
+	if c.RetryPolicy == nil {
+		c.RetryPolicy = defaults.RetryPolicy
+	}
+	if c.ReconnectPolicy == nil {
+		c.ReconnectPolicy = defaults.ReconnectPolicy
+	}
+}
+
+// Validate checks the configuration values and returns an error that describes all invalid values.
+// CircuitBreaker is validated by its own Validate method.
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.Token == "" {
+		errs = append(errs, "token is empty")
+	}
+
+	if c.OutageThreshold < 0 {
+		errs = append(errs, "outage_threshold must not be negative")
+	}
+
+	if c.RoomRefreshInterval < 0 {
+		errs = append(errs, "room_refresh_interval must not be negative")
+	}
+
+	if c.HeartbeatTimeout < 0 {
+		errs = append(errs, "heartbeat_timeout must not be negative")
+	}
+
+	if c.BackfillLimit > maxBackfillLimit {
+		errs = append(errs, fmt.Sprintf("backfill_limit must not be greater than %d", maxBackfillLimit))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
 	}
+	return nil
 }
@@ -0,0 +1,41 @@
+package gitter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfig_ApplyDefaults(t *testing.T) {
+	config := &Config{}
+	config.ApplyDefaults()
+
+	if config.RetryPolicy == nil || config.ReconnectPolicy == nil {
+		t.Errorf("Default policies are not set: %#v.", config)
+	}
+
+	if config.CircuitBreaker != nil {
+		t.Error("CircuitBreaker must stay nil.")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := NewConfig()
+	config.Token = "dummy"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	config = NewConfig()
+	config.RoomRefreshInterval = -1
+	config.BackfillLimit = 101
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	for _, key := range []string{"token", "room_refresh_interval", "backfill_limit"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Error does not describe %s: %s.", key, err.Error())
+		}
+	}
+}
@@ -3,11 +3,13 @@ package gitter
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/oklahomer/go-sarah/v4"
 	"io"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,18 +29,55 @@ type Connection interface {
 	io.Closer
 }
 
+// HeartbeatReceiver defines an optional interface that a Connection may implement to tell when the last data is received.
+// The Connection returned by StreamingAPIClient satisfies this interface.
+// Adapter reconnects to the room when no data, including keep-alive newlines, is received within Config.HeartbeatTimeout.
+type HeartbeatReceiver interface {
+	// LastReceived returns the time when the last data was received, or the time when the connection was established.
+	LastReceived() time.Time
+}
+
 // connWrapper stashes connection per Room to utilize HTTP streaming API.
 type connWrapper struct {
-	Room       *Room
-	readCloser io.ReadCloser
+	lastReceived int64 // UnixNano; accessed atomically and placed first to be 64-bit aligned
+	Room         *Room
+	readCloser   io.ReadCloser
+	reader       *bufio.Reader
+	ctx          context.Context
+}
+
+// NewConnection creates and return new Connection instance.
+// The given context must be the one that the streaming request is sent with.
+func newConnWrapper(ctx context.Context, room *Room, readCloser io.ReadCloser) Connection {
+	conn := &connWrapper{
+		Room:         room,
+		readCloser:   readCloser,
+		ctx:          ctx,
+		lastReceived: time.Now().UnixNano(),
+	}
+	conn.reader = bufio.NewReader(&heartbeatReader{reader: readCloser, conn: conn})
+	return conn
+}
+
+var _ HeartbeatReceiver = (*connWrapper)(nil)
+
+// LastReceived returns the time when the last data was received.
+func (conn *connWrapper) LastReceived() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&conn.lastReceived))
 }
 
-// NewConnection creates and return new Connection instance
-func newConnWrapper(room *Room, readCloser io.ReadCloser) Connection {
-	return &connWrapper{
-		Room:       room,
-		readCloser: readCloser,
+// heartbeatReader records the time when the data is read from the underlying reader.
+type heartbeatReader struct {
+	reader io.Reader
+	conn   *connWrapper
+}
+
+func (r *heartbeatReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		atomic.StoreInt64(&r.conn.lastReceived, time.Now().UnixNano())
 	}
+	return n, err
 }
 
 // ReadLine read single line from its connection.
@@ -46,9 +85,14 @@ func newConnWrapper(room *Room, readCloser io.ReadCloser) Connection {
 func (conn *connWrapper) Receive() (*RoomMessage, error) {
 	// The document reads "The JSON stream returns messages as JSON objects that are delimited by carriage return (\r)"
 	// but seems like '\n' is given, instead. Weired.
-	reader := bufio.NewReader(conn.readCloser)
-	line, err := reader.ReadBytes('\n')
+	// The reader is kept for the connection so the data buffered beyond the line is not lost.
+	line, err := conn.reader.ReadBytes('\n')
 	if err != nil {
+		if conn.ctx.Err() != nil {
+			// The request is canceled. Return the context's error instead of the one given by http package,
+			// which is privately defined and is hard to distinguish from other connection errors.
+			return nil, conn.ctx.Err()
+		}
 		return nil, err
 	}
 
@@ -84,9 +128,11 @@ func decodePayload(payload []byte) (*Message, error) {
 }
 
 // RoomMessage stashes received Message and additional Room information.
+// CatchUp is true when the message was posted while the connection was down and is fetched after the reconnection.
 type RoomMessage struct {
 	Room            *Room
 	ReceivedMessage *Message
+	CatchUp         bool
 }
 
 // NewRoomMessage creates and returns new RoomMessage instance.
@@ -102,6 +148,11 @@ func (message *RoomMessage) SenderKey() string {
 	return fmt.Sprintf("%s|%s", message.Room.ID, message.ReceivedMessage.FromUser.ID)
 }
 
+// SenderID returns the ID of the user who sent the message.
+func (message *RoomMessage) SenderID() string {
+	return message.ReceivedMessage.FromUser.ID
+}
+
 // Message returns received text.
 func (message *RoomMessage) Message() string {
 	return message.ReceivedMessage.Text
@@ -117,6 +168,45 @@ func (message *RoomMessage) ReplyTo() sarah.OutputDestination {
 	return message.Room
 }
 
+// SenderDisplayName returns the display name of the sending user.
+func (message *RoomMessage) SenderDisplayName() string {
+	return message.ReceivedMessage.FromUser.DisplayName
+}
+
+// IsDirectMessage tells if the message is sent in a one-to-one room.
+func (message *RoomMessage) IsDirectMessage() bool {
+	return message.Room.OneToOne
+}
+
+// Mentions returns the screen names of the mentioned users.
+func (message *RoomMessage) Mentions() []string {
+	var names []string
+	for _, mention := range message.ReceivedMessage.Mentions {
+		names = append(names, mention.ScreenName)
+	}
+	return names
+}
+
+// MessageID returns the ID of the message.
+func (message *RoomMessage) MessageID() string {
+	if message.ReceivedMessage == nil {
+		return ""
+	}
+	return message.ReceivedMessage.ID
+}
+
+// IsCatchUp tells if the message is fetched after the reconnection instead of being received in real time.
+func (message *RoomMessage) IsCatchUp() bool {
+	return message.CatchUp
+}
+
+var _ sarah.SenderDisplayNameInput = (*RoomMessage)(nil)
+var _ sarah.DirectMessageInput = (*RoomMessage)(nil)
+var _ sarah.MentionInput = (*RoomMessage)(nil)
+var _ sarah.MessageIDInput = (*RoomMessage)(nil)
+var _ sarah.CatchUpInput = (*RoomMessage)(nil)
+var _ sarah.SenderIDInput = (*RoomMessage)(nil)
+
 // MalformedPayloadError represents an error that given JSON payload is not properly formatted.
 // e.g. required fields are not given, or payload is not a valid JSON string.
 type MalformedPayloadError struct {
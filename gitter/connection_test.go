@@ -1,6 +1,7 @@
 package gitter
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"strings"
@@ -47,10 +48,7 @@ func TestConnWrapper_Receive(t *testing.T) {
 		strings.NewReader(readLine + "\n"), // Delimiter
 		nil,
 	}
-	wrapper := &connWrapper{
-		readCloser: conn,
-		Room:       &Room{},
-	}
+	wrapper := newConnWrapper(context.TODO(), &Room{}, conn)
 
 	roomMessage, err := wrapper.Receive()
 	if err != nil {
@@ -62,6 +60,71 @@ func TestConnWrapper_Receive(t *testing.T) {
 	}
 }
 
+func TestConnWrapper_Receive_Buffered(t *testing.T) {
+	// Multiple messages and keep-alive newlines can be read at once.
+	conn := &DummyConn{
+		strings.NewReader("{\"text\":\"first\"}\n\n{\"text\":\"second\"}\n"),
+		nil,
+	}
+	wrapper := newConnWrapper(context.TODO(), &Room{}, conn)
+
+	first, err := wrapper.Receive()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if first.ReceivedMessage.Text != "first" {
+		t.Errorf("Unexpected message is returned: %#v.", first.ReceivedMessage)
+	}
+
+	_, err = wrapper.Receive()
+	if err != ErrEmptyPayload {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	second, err := wrapper.Receive()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if second.ReceivedMessage.Text != "second" {
+		t.Errorf("Unexpected message is returned: %#v.", second.ReceivedMessage)
+	}
+}
+
+func TestConnWrapper_LastReceived(t *testing.T) {
+	conn := &DummyConn{
+		strings.NewReader("\n"),
+		nil,
+	}
+	wrapper := newConnWrapper(context.TODO(), &Room{}, conn).(*connWrapper)
+
+	connectedAt := wrapper.LastReceived()
+	if connectedAt.IsZero() {
+		t.Fatal("Connection time is not set.")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	_, _ = wrapper.Receive()
+
+	if !wrapper.LastReceived().After(connectedAt) {
+		t.Errorf("Receiving time is not updated: %s.", wrapper.LastReceived())
+	}
+}
+
+func TestConnWrapper_Receive_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	wrapper := newConnWrapper(ctx, &Room{}, &DummyConn{
+		strings.NewReader(""), // Reading fails with io.EOF.
+		nil,
+	})
+
+	_, err := wrapper.Receive()
+	if err != context.Canceled {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
 func TestConnWrapper_Close(t *testing.T) {
 	expected := errors.New("close error")
 	conn := &DummyConn{
@@ -132,6 +195,23 @@ func TestRoomMessage_SenderKey(t *testing.T) {
 	}
 }
 
+func TestRoomMessage_SenderID(t *testing.T) {
+	message := &RoomMessage{
+		Room: &Room{
+			ID: "roomID",
+		},
+		ReceivedMessage: &Message{
+			FromUser: User{
+				ID: "userID",
+			},
+		},
+	}
+
+	if message.SenderID() != "userID" {
+		t.Errorf("Unexpected sender ID is returned: %s.", message.SenderID())
+	}
+}
+
 func TestRoomMessage_SentAt(t *testing.T) {
 	now := time.Now()
 	message := &RoomMessage{
@@ -146,3 +226,78 @@ func TestRoomMessage_SentAt(t *testing.T) {
 		t.Errorf("Expected TimeStamp is not returned: %s.", message.SentAt())
 	}
 }
+
+func TestRoomMessage_SenderDisplayName(t *testing.T) {
+	name := "Oklahomer"
+	message := &RoomMessage{
+		ReceivedMessage: &Message{
+			FromUser: User{
+				DisplayName: name,
+			},
+		},
+	}
+
+	if message.SenderDisplayName() != name {
+		t.Errorf("Expected display name is not returned: %s.", message.SenderDisplayName())
+	}
+}
+
+func TestRoomMessage_IsDirectMessage(t *testing.T) {
+	for _, oneToOne := range []bool{true, false} {
+		message := &RoomMessage{
+			Room: &Room{
+				OneToOne: oneToOne,
+			},
+		}
+
+		if message.IsDirectMessage() != oneToOne {
+			t.Errorf("Unexpected value is returned: %t.", message.IsDirectMessage())
+		}
+	}
+}
+
+func TestRoomMessage_MessageID(t *testing.T) {
+	message := &RoomMessage{ReceivedMessage: &Message{ID: "123"}}
+	if message.MessageID() != "123" {
+		t.Errorf("Unexpected ID is returned: %s.", message.MessageID())
+	}
+
+	if (&RoomMessage{}).MessageID() != "" {
+		t.Error("Empty ID must be returned without Message.")
+	}
+}
+
+func TestRoomMessage_IsCatchUp(t *testing.T) {
+	for _, catchUp := range []bool{true, false} {
+		message := &RoomMessage{CatchUp: catchUp}
+		if message.IsCatchUp() != catchUp {
+			t.Errorf("Unexpected value is returned: %t.", message.IsCatchUp())
+		}
+	}
+}
+
+func TestRoomMessage_Mentions(t *testing.T) {
+	message := &RoomMessage{
+		ReceivedMessage: &Message{
+			Mentions: []Mention{
+				{
+					ScreenName: "foo",
+					UserID:     "123",
+				},
+				{
+					ScreenName: "bar",
+					UserID:     "456",
+				},
+			},
+		},
+	}
+
+	mentions := message.Mentions()
+	if len(mentions) != 2 {
+		t.Fatalf("Unexpected number of mentions are returned: %d.", len(mentions))
+	}
+
+	if mentions[0] != "foo" || mentions[1] != "bar" {
+		t.Errorf("Unexpected mentions are returned: %#v.", mentions)
+	}
+}
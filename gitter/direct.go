@@ -0,0 +1,79 @@
+package gitter
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+)
+
+// OpenDirectRoom returns the one-to-one room with the given user.
+// gitter creates the room when the bot has never talked with the user.
+// When the Adapter is running, the Adapter starts receiving messages from the room so the user can reply to the bot.
+func (adapter *Adapter) OpenDirectRoom(ctx context.Context, user *User) (*Room, error) {
+	if room := adapter.connectedDirectRoom(user.ID); room != nil {
+		return room, nil
+	}
+
+	// Joining a room with a username as its URI opens the one-to-one room with the user.
+	room, err := adapter.apiClient.JoinRoom(ctx, user.UserName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open one-to-one room with %s: %w", user.UserName, err)
+	}
+	if room.User == nil {
+		room.User = user
+	}
+
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+	if adapter.runCtx != nil && adapter.runCtx.Err() == nil {
+		adapter.connectRoom(room)
+	}
+
+	return room, nil
+}
+
+// connectedDirectRoom returns the connected one-to-one room with the user with the given ID, or nil if none.
+func (adapter *Adapter) connectedDirectRoom(userID string) *Room {
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+
+	for _, conn := range adapter.rooms {
+		if conn.room.OneToOne && conn.room.User != nil && conn.room.User.ID == userID {
+			return conn.room
+		}
+	}
+
+	return nil
+}
+
+// destinationRoom returns the Room that a message with the given destination is posted to.
+// A message with sarah.PrivateDestination is posted to the one-to-one room with the recipient.
+// The recipient is looked up by ID among the users in the base room.
+func (adapter *Adapter) destinationRoom(ctx context.Context, destination sarah.OutputDestination) (*Room, error) {
+	room, ok := sarah.BaseDestination(destination).(*Room)
+	if !ok {
+		return nil, fmt.Errorf("destination is not instance of Room: %#v", destination)
+	}
+
+	userID := sarah.PrivateUserID(destination)
+	if userID == "" || room.OneToOne {
+		// A one-to-one room is already private.
+		return room, nil
+	}
+
+	if direct := adapter.connectedDirectRoom(userID); direct != nil {
+		return direct, nil
+	}
+
+	users, err := adapter.apiClient.RoomUsers(ctx, room.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.ID == userID {
+			return adapter.OpenDirectRoom(ctx, user)
+		}
+	}
+
+	return nil, fmt.Errorf("recipient %s is not in room %s: %w", userID, room.ID, ErrUserNotFound)
+}
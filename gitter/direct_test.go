@@ -0,0 +1,129 @@
+package gitter
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"testing"
+)
+
+func TestAdapter_OpenDirectRoom(t *testing.T) {
+	user := &User{ID: "user", UserName: "oklahomer"}
+	joined := 0
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			JoinRoomFunc: func(_ context.Context, uri string) (*Room, error) {
+				joined++
+				if uri != user.UserName {
+					t.Errorf("Unexpected URI is given: %s.", uri)
+				}
+				return &Room{ID: "direct", OneToOne: true}, nil
+			},
+		},
+		streamingClient: &DummyStreamingClient{
+			ConnectFunc: func(ctx context.Context, _ *Room) (Connection, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		},
+		config: &Config{
+			ReconnectPolicy: NewConfig().ReconnectPolicy,
+		},
+		rooms: map[string]*roomConnection{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	adapter.runCtx = ctx
+	adapter.enqueueInput = func(_ sarah.Input) error { return nil }
+
+	room, err := adapter.OpenDirectRoom(context.TODO(), user)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if room.ID != "direct" || room.User != user {
+		t.Errorf("Unexpected room is returned: %#v.", room)
+	}
+
+	// The connected room is reused.
+	room, err = adapter.OpenDirectRoom(context.TODO(), user)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if room.ID != "direct" {
+		t.Errorf("Unexpected room is returned: %#v.", room)
+	}
+	if joined != 1 {
+		t.Errorf("JoinRoom is expected to be called once, but was called %d times.", joined)
+	}
+}
+
+func TestAdapter_OpenDirectRoom_Error(t *testing.T) {
+	joinErr := errors.New("join error")
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			JoinRoomFunc: func(_ context.Context, _ string) (*Room, error) {
+				return nil, joinErr
+			},
+		},
+	}
+
+	_, err := adapter.OpenDirectRoom(context.TODO(), &User{ID: "user", UserName: "oklahomer"})
+
+	if !errors.Is(err, joinErr) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestAdapter_destinationRoom(t *testing.T) {
+	direct := &Room{ID: "direct", OneToOne: true, User: &User{ID: "user"}}
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			RoomUsersFunc: func(_ context.Context, _ string, _ string) ([]*User, error) {
+				t.Fatal("Room users must not be fetched.")
+				return nil, nil
+			},
+		},
+		rooms: map[string]*roomConnection{
+			direct.ID: {room: direct, cancel: func() {}},
+		},
+	}
+	public := &Room{ID: "public"}
+	oneToOne := &Room{ID: "another", OneToOne: true}
+
+	tests := []struct {
+		destination sarah.OutputDestination
+		expected    *Room
+	}{
+		{
+			destination: public,
+			expected:    public,
+		},
+		{
+			destination: sarah.NewThreadDestination(public, "thread"),
+			expected:    public,
+		},
+		{
+			destination: sarah.NewPrivateDestination(public, "user"),
+			expected:    direct,
+		},
+		{
+			destination: sarah.NewPrivateDestination(oneToOne, "other"),
+			expected:    oneToOne,
+		},
+	}
+
+	for _, tt := range tests {
+		room, err := adapter.destinationRoom(context.TODO(), tt.destination)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if room != tt.expected {
+			t.Errorf("Unexpected room is returned for %#v: %#v.", tt.destination, room)
+		}
+	}
+
+	_, err := adapter.destinationRoom(context.TODO(), "invalid")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
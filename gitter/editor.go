@@ -0,0 +1,122 @@
+package gitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"strings"
+)
+
+var _ sarah.MessageEditor = (*Adapter)(nil)
+
+// SendMessageWithHandle sends the given message and returns the handle to update or delete the message later.
+// The content must be a string or *sarah.RichMessage.
+func (adapter *Adapter) SendMessageWithHandle(ctx context.Context, output sarah.Output) (*sarah.MessageHandle, error) {
+	room, err := adapter.destinationRoom(ctx, output.Destination())
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := editableContent(output.Content())
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := adapter.apiClient.PostMessage(ctx, room, text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sarah.MessageHandle{
+		Destination: room,
+		MessageID:   message.ID,
+	}, nil
+}
+
+// UpdateMessage replaces the content of the message identified by the given handle.
+// The content must be a string or *sarah.RichMessage.
+func (adapter *Adapter) UpdateMessage(ctx context.Context, handle *sarah.MessageHandle, content interface{}) error {
+	room, err := adapter.destinationRoom(ctx, handle.Destination)
+	if err != nil {
+		return err
+	}
+
+	text, err := editableContent(content)
+	if err != nil {
+		return err
+	}
+
+	_, err = adapter.apiClient.UpdateMessage(ctx, room, handle.MessageID, text)
+	return err
+}
+
+// DeleteMessage deletes the message identified by the given handle.
+func (adapter *Adapter) DeleteMessage(ctx context.Context, handle *sarah.MessageHandle) error {
+	room, err := adapter.destinationRoom(ctx, handle.Destination)
+	if err != nil {
+		return err
+	}
+
+	return adapter.apiClient.DeleteMessage(ctx, room, handle.MessageID)
+}
+
+// editableContent converts the given content to the markdown text to post or update a message.
+func editableContent(content interface{}) (string, error) {
+	switch c := content.(type) {
+	case string:
+		return c, nil
+
+	case *sarah.RichMessage:
+		return richMarkdown(c), nil
+
+	default:
+		return "", fmt.Errorf("%w: %T", sarah.ErrMessageEditingNotSupported, content)
+
+	}
+}
+
+// ErrUserNotFound is returned when no user in the room has the given username.
+var ErrUserNotFound = errors.New("user not found")
+
+// RoomUsers fetches the users in the room with the given ID.
+func (adapter *Adapter) RoomUsers(ctx context.Context, roomID string) ([]*User, error) {
+	return adapter.apiClient.RoomUsers(ctx, roomID, "")
+}
+
+// FindUser fetches the user with the given username in the room with the given ID.
+// A leading "@" of the username is ignored, so a mention can be passed as-is.
+// ErrUserNotFound is returned when no user in the room has the username.
+func (adapter *Adapter) FindUser(ctx context.Context, roomID string, username string) (*User, error) {
+	username = strings.TrimPrefix(username, "@")
+	users, err := adapter.apiClient.RoomUsers(ctx, roomID, username)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		// The query matches display names and partial usernames, so look for the exact one.
+		if strings.EqualFold(user.UserName, username) {
+			return user, nil
+		}
+	}
+
+	return nil, ErrUserNotFound
+}
+
+// UnreadItems fetches the IDs of the messages in the room that are not yet read by the bot.
+func (adapter *Adapter) UnreadItems(ctx context.Context, roomID string) (*UnreadItems, error) {
+	return adapter.apiClient.UnreadItems(ctx, roomID)
+}
+
+// MarkAsRead marks the messages with the given IDs in the room as read by the bot.
+// A Command can mark the handled input as read as below:
+//
+//  message := input.(*gitter.RoomMessage)
+//  err := adapter.MarkAsRead(ctx, message.Room.ID, message.ReceivedMessage.ID)
+func (adapter *Adapter) MarkAsRead(ctx context.Context, roomID string, messageIDs ...string) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	return adapter.apiClient.MarkAsRead(ctx, roomID, messageIDs...)
+}
@@ -0,0 +1,182 @@
+package gitter
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"reflect"
+	"testing"
+)
+
+func TestAdapter_SendMessageWithHandle(t *testing.T) {
+	var text string
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			PostMessageFunc: func(_ context.Context, _ *Room, t string) (*Message, error) {
+				text = t
+				return &Message{ID: "456"}, nil
+			},
+		},
+	}
+	room := &Room{ID: "123"}
+
+	handle, err := adapter.SendMessageWithHandle(context.TODO(), sarah.NewOutputMessage(room, &sarah.RichMessage{Title: "title"}))
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if handle.MessageID != "456" || handle.Destination != room {
+		t.Errorf("Unexpected handle is returned: %#v.", handle)
+	}
+
+	if text != "**title**" {
+		t.Errorf("Unexpected text is sent: %s.", text)
+	}
+}
+
+func TestAdapter_SendMessageWithHandle_Error(t *testing.T) {
+	apiErr := errors.New("API error")
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			PostMessageFunc: func(_ context.Context, _ *Room, _ string) (*Message, error) {
+				return nil, apiErr
+			},
+			RoomUsersFunc: func(_ context.Context, _ string, _ string) ([]*User, error) {
+				return []*User{}, nil
+			},
+		},
+	}
+	room := &Room{ID: "123"}
+
+	tests := []struct {
+		output   sarah.Output
+		expected error
+	}{
+		{
+			output:   sarah.NewOutputMessage(sarah.NewPrivateDestination(room, "user"), "text"),
+			expected: ErrUserNotFound,
+		},
+		{
+			output:   sarah.NewOutputMessage(room, &sarah.Reaction{Name: "+1"}),
+			expected: sarah.ErrMessageEditingNotSupported,
+		},
+		{
+			output:   sarah.NewOutputMessage(room, "text"),
+			expected: apiErr,
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := adapter.SendMessageWithHandle(context.TODO(), tt.output)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("Unexpected error is returned: %#v.", err)
+		}
+	}
+}
+
+func TestAdapter_UpdateMessage(t *testing.T) {
+	room := &Room{ID: "123"}
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			UpdateMessageFunc: func(_ context.Context, r *Room, messageID string, text string) (*Message, error) {
+				if r != room {
+					t.Errorf("Unexpected room is given: %#v.", r)
+				}
+				if messageID != "456" {
+					t.Errorf("Unexpected message ID is given: %s.", messageID)
+				}
+				if text != "updated" {
+					t.Errorf("Unexpected text is given: %s.", text)
+				}
+				return &Message{}, nil
+			},
+		},
+	}
+
+	err := adapter.UpdateMessage(context.TODO(), &sarah.MessageHandle{Destination: room, MessageID: "456"}, "updated")
+
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestAdapter_DeleteMessage(t *testing.T) {
+	room := &Room{ID: "123"}
+	called := false
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			DeleteMessageFunc: func(_ context.Context, r *Room, messageID string) error {
+				called = true
+				if r != room || messageID != "456" {
+					t.Errorf("Unexpected arguments are given: %#v, %s.", r, messageID)
+				}
+				return nil
+			},
+		},
+	}
+
+	err := adapter.DeleteMessage(context.TODO(), &sarah.MessageHandle{Destination: room, MessageID: "456"})
+
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if !called {
+		t.Error("APIClient.DeleteMessage is not called.")
+	}
+}
+
+func TestAdapter_FindUser(t *testing.T) {
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			RoomUsersFunc: func(_ context.Context, roomID string, query string) ([]*User, error) {
+				if query != "okla" && query != "oklahomer" {
+					t.Errorf("Unexpected query is given: %s.", query)
+				}
+				return []*User{{ID: "1", UserName: "oklahomer"}, {ID: "2", UserName: "oklahomer2"}}, nil
+			},
+		},
+	}
+
+	user, err := adapter.FindUser(context.TODO(), "123", "@oklahomer")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if user.ID != "1" {
+		t.Errorf("Unexpected user is returned: %#v.", user)
+	}
+
+	_, err = adapter.FindUser(context.TODO(), "123", "okla")
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound is not returned: %#v.", err)
+	}
+}
+
+func TestAdapter_MarkAsRead(t *testing.T) {
+	var given []string
+	adapter := &Adapter{
+		apiClient: &DummyAPIClient{
+			MarkAsReadFunc: func(_ context.Context, _ string, messageIDs ...string) error {
+				given = messageIDs
+				return nil
+			},
+		},
+	}
+
+	err := adapter.MarkAsRead(context.TODO(), "123")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if given != nil {
+		t.Error("APIClient.MarkAsRead is called without message IDs.")
+	}
+
+	err = adapter.MarkAsRead(context.TODO(), "123", "1", "2")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if !reflect.DeepEqual(given, []string{"1", "2"}) {
+		t.Errorf("Unexpected message IDs are given: %#v.", given)
+	}
+}
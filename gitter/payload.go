@@ -48,6 +48,7 @@ type Room struct {
 	Topic          string    `json:"topic"`
 	URI            string    `json:"uri"`
 	OneToOne       bool      `json:"oneToOne"`
+	User           *User     `json:"user,omitempty"` // the other user of a one-to-one room
 	Users          []*User   `json:"users"`
 	UnreadItems    uint      `json:"unreadItems"`
 	Mentions       uint      `json:"mentions"`
@@ -1,13 +1,18 @@
 package gitter
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/oklahomer/go-sarah/v4/adapterkit"
+	"github.com/oklahomer/go-sarah/v4/breaker"
+	"io"
 	"net/http"
 	"net/url"
 	"path"
-	"strings"
+	"strconv"
+	"sync"
 )
 
 const (
@@ -20,23 +25,105 @@ type RoomsFetcher interface {
 	Rooms(context.Context) (*Rooms, error)
 }
 
+// AuthenticationError is returned when gitter rejects the request due to an invalid token or insufficient permission.
+// Retrying the same request is pointless in this case.
+type AuthenticationError struct {
+	StatusCode int
+}
+
+// Error returns the stringified form of the error.
+func (e *AuthenticationError) Error() string {
+	return fmt.Sprintf("request is rejected due to authentication failure: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// checkAuthentication returns *AuthenticationError when the response tells the authentication failure.
+func checkAuthentication(resp *http.Response) error {
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return &AuthenticationError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// RestAPIClientOption defines a function signature that RestAPIClient's functional option must satisfy.
+type RestAPIClientOption func(*RestAPIClient)
+
+// WithCircuitBreaker creates a RestAPIClientOption that protects each HTTP request with the given breaker.Breaker.
+// When gitter keeps failing, the succeeding requests fail immediately with breaker.ErrOpen instead of waiting for the timeout.
+func WithCircuitBreaker(b *breaker.Breaker) RestAPIClientOption {
+	return func(client *RestAPIClient) {
+		client.breaker = b
+	}
+}
+
+// WithHTTPClient creates a RestAPIClientOption that sends each HTTP request with the given adapterkit.HTTPClient.
+// This is handy to configure the retry policy, the rate limit or the hooks for logging and metrics.
+// When this option is given, WithCircuitBreaker is ignored, so set the breaker with adapterkit.WithCircuitBreaker instead.
+func WithHTTPClient(httpClient *adapterkit.HTTPClient) RestAPIClientOption {
+	return func(client *RestAPIClient) {
+		client.httpClient = httpClient
+	}
+}
+
 // RestAPIClient utilizes gitter REST API.
 type RestAPIClient struct {
 	token      string
 	apiVersion string
+	breaker    *breaker.Breaker
+	httpClient *adapterkit.HTTPClient
+	userID     string
+	mutex      sync.Mutex
 }
 
 // NewVersionSpecificRestAPIClient creates API client instance with given API version.
-func NewVersionSpecificRestAPIClient(token string, apiVersion string) *RestAPIClient {
-	return &RestAPIClient{
+func NewVersionSpecificRestAPIClient(token string, apiVersion string, options ...RestAPIClientOption) *RestAPIClient {
+	client := &RestAPIClient{
 		token:      token,
 		apiVersion: apiVersion,
+		breaker:    nil,
+	}
+
+	for _, opt := range options {
+		opt(client)
 	}
+
+	if client.httpClient == nil {
+		client.httpClient = newHTTPClient(client.breaker)
+	}
+
+	return client
+}
+
+func newHTTPClient(b *breaker.Breaker) *adapterkit.HTTPClient {
+	options := []adapterkit.HTTPClientOption{adapterkit.WithSpanName("gitter.rest_api")}
+	if b != nil {
+		options = append(options, adapterkit.WithCircuitBreaker(b))
+	}
+	return adapterkit.NewHTTPClient(options...)
 }
 
 // NewRestAPIClient creates and returns API client instance. Version is fixed to v1.
-func NewRestAPIClient(token string) *RestAPIClient {
-	return NewVersionSpecificRestAPIClient(token, "v1")
+func NewRestAPIClient(token string, options ...RestAPIClientOption) *RestAPIClient {
+	return NewVersionSpecificRestAPIClient(token, "v1", options...)
+}
+
+// do executes the given request with retrials and through the circuit breaker if any.
+func (client *RestAPIClient) do(req *http.Request) (*http.Response, error) {
+	httpClient := client.httpClient
+	if httpClient == nil {
+		httpClient = newHTTPClient(client.breaker)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("server error: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return resp, nil
 }
 
 func (client *RestAPIClient) buildEndpoint(resourceFragments []string) *url.URL {
@@ -48,27 +135,76 @@ func (client *RestAPIClient) buildEndpoint(resourceFragments []string) *url.URL
 
 // Get sends GET request with given path and parameters.
 func (client *RestAPIClient) Get(ctx context.Context, resourceFragments []string, intf interface{}) error {
+	return client.request(ctx, http.MethodGet, resourceFragments, nil, nil, intf)
+}
+
+// GetWithQuery sends GET request with given path and query parameters.
+func (client *RestAPIClient) GetWithQuery(ctx context.Context, resourceFragments []string, query url.Values, intf interface{}) error {
+	return client.request(ctx, http.MethodGet, resourceFragments, query, nil, intf)
+}
+
+// Post sends POST requests to gitter with given parameters.
+func (client *RestAPIClient) Post(ctx context.Context, resourceFragments []string, sendingPayload interface{}, responsePayload interface{}) error {
+	return client.request(ctx, http.MethodPost, resourceFragments, nil, sendingPayload, responsePayload)
+}
+
+// Put sends PUT requests to gitter with given parameters.
+func (client *RestAPIClient) Put(ctx context.Context, resourceFragments []string, sendingPayload interface{}, responsePayload interface{}) error {
+	return client.request(ctx, http.MethodPut, resourceFragments, nil, sendingPayload, responsePayload)
+}
+
+// Delete sends DELETE request to gitter with given path.
+// When responsePayload is nil, the response body is ignored since some endpoints respond with no content.
+func (client *RestAPIClient) Delete(ctx context.Context, resourceFragments []string, responsePayload interface{}) error {
+	return client.request(ctx, http.MethodDelete, resourceFragments, nil, nil, responsePayload)
+}
+
+func (client *RestAPIClient) request(ctx context.Context, method string, resourceFragments []string, query url.Values, sendingPayload interface{}, responsePayload interface{}) error {
+	var body io.Reader
+	if sendingPayload != nil {
+		reqBody, err := json.Marshal(sendingPayload)
+		if err != nil {
+			return fmt.Errorf("can not marshal given payload: %w", err)
+		}
+		body = bytes.NewReader(reqBody)
+	}
+
 	// Set up sending request
 	endpoint := client.buildEndpoint(resourceFragments)
-	req, err := http.NewRequest("GET", endpoint.String(), nil)
+	if len(query) > 0 {
+		endpoint.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequest(method, endpoint.String(), body)
 	if err != nil {
 		return fmt.Errorf("failed to construct HTTP request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+client.token)
 	req.Header.Set("Accept", "application/json")
-
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req = req.WithContext(ctx)
 
 	// Do request
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.do(req)
 	if err != nil {
 		return fmt.Errorf("failed executing HTTP request: %w", err)
 	}
 
 	defer resp.Body.Close()
 
+	// TODO check other status codes
+	err = checkAuthentication(resp)
+	if err != nil {
+		return err
+	}
+
+	if responsePayload == nil {
+		return nil
+	}
+
 	// Handle response
-	err = json.NewDecoder(resp.Body).Decode(&intf)
+	err = json.NewDecoder(resp.Body).Decode(&responsePayload)
 	if err != nil {
 		return fmt.Errorf("can not unmarshal given JSON structure: %w", err)
 	}
@@ -77,40 +213,70 @@ func (client *RestAPIClient) Get(ctx context.Context, resourceFragments []string
 	return nil
 }
 
-// Post sends POST requests to gitter with given parameters.
-func (client *RestAPIClient) Post(ctx context.Context, resourceFragments []string, sendingPayload interface{}, responsePayload interface{}) error {
-	reqBody, err := json.Marshal(sendingPayload)
-	if err != nil {
-		return fmt.Errorf("can not marshal given payload: %w", err)
+// Me fetches the user that the token belongs to.
+func (client *RestAPIClient) Me(ctx context.Context) (*User, error) {
+	user := &User{}
+	if err := client.Get(ctx, []string{"user", "me"}, user); err != nil {
+		return nil, err
 	}
+	return user, nil
+}
 
-	// Set up sending request
-	endpoint := client.buildEndpoint(resourceFragments)
-	req, err := http.NewRequest("POST", endpoint.String(), strings.NewReader(string(reqBody)))
+// myID returns the ID of the user that the token belongs to.
+// The ID is fetched only once and is cached since the ID never changes for the token.
+func (client *RestAPIClient) myID(ctx context.Context) (string, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.userID != "" {
+		return client.userID, nil
+	}
+
+	me, err := client.Me(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to construct HTTP request: %w", err)
+		return "", fmt.Errorf("failed to fetch user: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+client.token)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-	req = req.WithContext(ctx)
+	client.userID = me.ID
+
+	return client.userID, nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
+// JoinRoom joins the room with the given URI such as "gitterhq/sandbox" and returns the joined room.
+func (client *RestAPIClient) JoinRoom(ctx context.Context, uri string) (*Room, error) {
+	room := &Room{}
+	err := client.Post(ctx, []string{"rooms"}, &joiningRoom{URI: uri}, room)
 	if err != nil {
-		return fmt.Errorf("failed executing HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to join room: %w", err)
 	}
+	if room.ID == "" {
+		return nil, fmt.Errorf("failed to join room: %s", uri)
+	}
+	return room, nil
+}
 
-	defer resp.Body.Close()
+type joiningRoom struct {
+	URI string `json:"uri"`
+}
 
-	// TODO check status code
+type successResponse struct {
+	Success bool `json:"success"`
+}
 
-	// Handle response
-	err = json.NewDecoder(resp.Body).Decode(&responsePayload)
+// LeaveRoom removes the user that the token belongs to from the room with the given ID.
+func (client *RestAPIClient) LeaveRoom(ctx context.Context, roomID string) error {
+	userID, err := client.myID(ctx)
 	if err != nil {
-		return fmt.Errorf("can not unmarshal given JSON structure: %w", err)
+		return err
 	}
 
-	// Done
+	response := &successResponse{}
+	err = client.Delete(ctx, []string{"rooms", roomID, "users", userID}, response)
+	if err != nil {
+		return fmt.Errorf("failed to leave room: %w", err)
+	}
+	if !response.Success {
+		return fmt.Errorf("failed to leave room: %s", roomID)
+	}
 	return nil
 }
 
@@ -133,6 +299,102 @@ func (client *RestAPIClient) PostMessage(ctx context.Context, room *Room, text s
 	return message, nil
 }
 
+// UpdateMessage replaces the text of the message with the given ID.
+func (client *RestAPIClient) UpdateMessage(ctx context.Context, room *Room, messageID string, text string) (*Message, error) {
+	message := &Message{}
+	err := client.Put(ctx, []string{"rooms", room.ID, "chatMessages", messageID}, &PostingMessage{Text: text}, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update message: %w", err)
+	}
+	return message, nil
+}
+
+// DeleteMessage deletes the message with the given ID.
+func (client *RestAPIClient) DeleteMessage(ctx context.Context, room *Room, messageID string) error {
+	err := client.Delete(ctx, []string{"rooms", room.ID, "chatMessages", messageID}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+	return nil
+}
+
+// RoomUsers fetches the users in the room with the given ID.
+// When query is not empty, only the users whose username or display name matches the query are returned.
+func (client *RestAPIClient) RoomUsers(ctx context.Context, roomID string, query string) ([]*User, error) {
+	params := url.Values{}
+	if query != "" {
+		params.Set("q", query)
+	}
+
+	var users []*User
+	err := client.GetWithQuery(ctx, []string{"rooms", roomID, "users"}, params, &users)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch room users: %w", err)
+	}
+	return users, nil
+}
+
+// ChatMessages fetches the messages posted after the message with the given ID in the room, in the posted order.
+// At most the given number of messages are returned; gitter limits the number to 100.
+func (client *RestAPIClient) ChatMessages(ctx context.Context, roomID string, afterID string, limit uint) ([]*Message, error) {
+	params := url.Values{}
+	if afterID != "" {
+		params.Set("afterId", afterID)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.FormatUint(uint64(limit), 10))
+	}
+
+	var messages []*Message
+	err := client.GetWithQuery(ctx, []string{"rooms", roomID, "chatMessages"}, params, &messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chat messages: %w", err)
+	}
+	return messages, nil
+}
+
+// UnreadItems fetches the IDs of the unread messages in the room with the given ID for the user that the token belongs to.
+func (client *RestAPIClient) UnreadItems(ctx context.Context, roomID string) (*UnreadItems, error) {
+	userID, err := client.myID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := &UnreadItems{}
+	err = client.Get(ctx, []string{"user", userID, "rooms", roomID, "unreadItems"}, items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unread items: %w", err)
+	}
+	return items, nil
+}
+
+// MarkAsRead marks the messages with the given IDs in the room as read by the user that the token belongs to.
+func (client *RestAPIClient) MarkAsRead(ctx context.Context, roomID string, messageIDs ...string) error {
+	userID, err := client.myID(ctx)
+	if err != nil {
+		return err
+	}
+
+	response := &successResponse{}
+	err = client.Post(ctx, []string{"user", userID, "rooms", roomID, "unreadItems"}, &UnreadItems{Chat: messageIDs}, response)
+	if err != nil {
+		return fmt.Errorf("failed to mark messages as read: %w", err)
+	}
+	if !response.Success {
+		return fmt.Errorf("failed to mark messages as read in room %s", roomID)
+	}
+	return nil
+}
+
+// UnreadItems represents the unread messages of a user in a room.
+type UnreadItems struct {
+	// Chat is the IDs of the unread messages.
+	Chat []string `json:"chat"`
+
+	// Mention is the IDs of the unread messages that mention the user.
+	Mention []string `json:"mention,omitempty"`
+}
+
 // PostingMessage represents the sending message.
 // This can be marshaled and sent as JSON-styled payload.
 type PostingMessage struct {
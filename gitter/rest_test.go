@@ -3,11 +3,16 @@ package gitter
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/adapterkit"
+	"github.com/oklahomer/go-sarah/v4/breaker"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewRestAPIClient(t *testing.T) {
@@ -42,6 +47,53 @@ func TestNewVersionSpecificRestAPIClient(t *testing.T) {
 	}
 }
 
+func TestWithCircuitBreaker(t *testing.T) {
+	b := breaker.NewBreaker(breaker.NewConfig())
+	client := NewRestAPIClient("dummy", WithCircuitBreaker(b))
+
+	if client.breaker != b {
+		t.Errorf("Given breaker is not set: %#v.", client.breaker)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	httpClient := adapterkit.NewHTTPClient()
+	client := NewRestAPIClient("dummy", WithHTTPClient(httpClient))
+
+	if client.httpClient != httpClient {
+		t.Errorf("Given HTTP client is not set: %#v.", client.httpClient)
+	}
+}
+
+func TestRestAPIClient_Get_CircuitBreaker(t *testing.T) {
+	requested := 0
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		requested++
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+	defer resetClient()
+
+	b := breaker.NewBreaker(&breaker.Config{FailureThreshold: 1, ResetTimeout: time.Hour})
+	client := NewRestAPIClient("dummy", WithCircuitBreaker(b))
+
+	err := client.Get(context.TODO(), []string{"foo"}, &struct{}{})
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	err = client.Get(context.TODO(), []string{"foo"}, &struct{}{})
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if requested != 1 {
+		t.Errorf("Request should not be sent while the circuit is open: %d.", requested)
+	}
+}
+
 func TestRestAPIClient_buildEndPoint(t *testing.T) {
 	version := "v1"
 	client := &RestAPIClient{
@@ -119,6 +171,38 @@ func TestClient_Get_StatusError(t *testing.T) {
 	}
 }
 
+func TestClient_Get_AuthenticationError(t *testing.T) {
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"error":"Unauthorized"}`)),
+		}, nil
+	})
+	defer resetClient()
+
+	client := &RestAPIClient{
+		token:      "invalid",
+		apiVersion: "v1",
+	}
+	err := client.Get(context.TODO(), []string{"foo"}, &struct{}{})
+
+	var authErr *AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	if authErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Unexpected status code is set: %d.", authErr.StatusCode)
+	}
+}
+
+func TestAuthenticationError_Error(t *testing.T) {
+	err := &AuthenticationError{StatusCode: http.StatusForbidden}
+	if !strings.Contains(err.Error(), "403") {
+		t.Errorf("Status code is not included: %s.", err.Error())
+	}
+}
+
 func TestRestAPIClient_Post(t *testing.T) {
 	type PostResponseDummy struct {
 		OK bool
@@ -264,3 +348,328 @@ func switchHTTPClient(fnc roundTripFnc) func() {
 		http.DefaultClient = oldClient
 	}
 }
+
+func TestRestAPIClient_Delete(t *testing.T) {
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodDelete {
+			t.Fatalf("Unexpected request method: %s.", req.Method)
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"success":true}`)),
+		}, nil
+	})
+	defer resetClient()
+
+	client := NewRestAPIClient("dummy")
+	response := &struct {
+		Success bool `json:"success"`
+	}{}
+	err := client.Delete(context.TODO(), []string{"foo"}, response)
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if !response.Success {
+		t.Error("Expected value is not returned.")
+	}
+}
+
+func TestRestAPIClient_JoinRoom(t *testing.T) {
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPost || req.URL.Path != "/v1/rooms" {
+			t.Fatalf("Unexpected request: %s %s.", req.Method, req.URL.Path)
+		}
+
+		body, _ := ioutil.ReadAll(req.Body)
+		if string(body) != `{"uri":"gitterhq/sandbox"}` {
+			t.Errorf("Unexpected body is given: %s.", string(body))
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id":"123","uri":"gitterhq/sandbox"}`)),
+		}, nil
+	})
+	defer resetClient()
+
+	client := NewRestAPIClient("dummy")
+	room, err := client.JoinRoom(context.TODO(), "gitterhq/sandbox")
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if room.ID != "123" {
+		t.Errorf("Unexpected room is returned: %#v.", room)
+	}
+}
+
+func TestRestAPIClient_LeaveRoom(t *testing.T) {
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/v1/user/me":
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"user"}`)),
+			}, nil
+
+		case "/v1/rooms/123/users/user":
+			if req.Method != http.MethodDelete {
+				t.Errorf("Unexpected request method: %s.", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"success":true}`)),
+			}, nil
+
+		default:
+			t.Fatalf("Unexpected request: %s.", req.URL.Path)
+			return nil, nil
+
+		}
+	})
+	defer resetClient()
+
+	client := NewRestAPIClient("dummy")
+	err := client.LeaveRoom(context.TODO(), "123")
+
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestRestAPIClient_UpdateMessage(t *testing.T) {
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodPut || req.URL.Path != "/v1/rooms/123/chatMessages/456" {
+			t.Fatalf("Unexpected request: %s %s.", req.Method, req.URL.Path)
+		}
+
+		body, _ := ioutil.ReadAll(req.Body)
+		if string(body) != `{"text":"updated"}` {
+			t.Errorf("Unexpected body is given: %s.", string(body))
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id":"456","text":"updated"}`)),
+		}, nil
+	})
+	defer resetClient()
+
+	client := NewRestAPIClient("dummy")
+	message, err := client.UpdateMessage(context.TODO(), &Room{ID: "123"}, "456", "updated")
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if message.Text != "updated" {
+		t.Errorf("Unexpected message is returned: %#v.", message)
+	}
+}
+
+func TestRestAPIClient_DeleteMessage(t *testing.T) {
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodDelete || req.URL.Path != "/v1/rooms/123/chatMessages/456" {
+			t.Fatalf("Unexpected request: %s %s.", req.Method, req.URL.Path)
+		}
+
+		// Gitter responds with no content.
+		return &http.Response{
+			StatusCode: http.StatusNoContent,
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+	defer resetClient()
+
+	client := NewRestAPIClient("dummy")
+	err := client.DeleteMessage(context.TODO(), &Room{ID: "123"}, "456")
+
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestRestAPIClient_RoomUsers(t *testing.T) {
+	tests := []struct {
+		query    string
+		rawQuery string
+	}{
+		{
+			query:    "",
+			rawQuery: "",
+		},
+		{
+			query:    "oklahomer",
+			rawQuery: "q=oklahomer",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+				if req.Method != http.MethodGet || req.URL.Path != "/v1/rooms/123/users" {
+					t.Fatalf("Unexpected request: %s %s.", req.Method, req.URL.Path)
+				}
+
+				if req.URL.RawQuery != tt.rawQuery {
+					t.Errorf("Unexpected query is given: %s.", req.URL.RawQuery)
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`[{"id":"user","username":"oklahomer"}]`)),
+				}, nil
+			})
+			defer resetClient()
+
+			client := NewRestAPIClient("dummy")
+			users, err := client.RoomUsers(context.TODO(), "123", tt.query)
+
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+
+			if len(users) != 1 || users[0].UserName != "oklahomer" {
+				t.Errorf("Unexpected users are returned: %#v.", users)
+			}
+		})
+	}
+}
+
+func TestRestAPIClient_ChatMessages(t *testing.T) {
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet || req.URL.Path != "/v1/rooms/123/chatMessages" {
+			t.Fatalf("Unexpected request: %s %s.", req.Method, req.URL.Path)
+		}
+
+		if req.URL.RawQuery != "afterId=456&limit=10" {
+			t.Errorf("Unexpected query is given: %s.", req.URL.RawQuery)
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`[{"id":"457","text":"hello"},{"id":"458","text":"world"}]`)),
+		}, nil
+	})
+	defer resetClient()
+
+	client := NewRestAPIClient("dummy")
+	messages, err := client.ChatMessages(context.TODO(), "123", "456", 10)
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(messages) != 2 || messages[0].ID != "457" || messages[1].Text != "world" {
+		t.Errorf("Unexpected messages are returned: %#v.", messages)
+	}
+}
+
+func TestRestAPIClient_UnreadItems(t *testing.T) {
+	meCalled := 0
+	resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/v1/user/me":
+			meCalled++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"user"}`)),
+			}, nil
+
+		case "/v1/user/user/rooms/123/unreadItems":
+			if req.Method != http.MethodGet {
+				t.Errorf("Unexpected request method: %s.", req.Method)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"chat":["1","2"],"mention":["2"]}`)),
+			}, nil
+
+		default:
+			t.Fatalf("Unexpected request: %s.", req.URL.Path)
+			return nil, nil
+
+		}
+	})
+	defer resetClient()
+
+	client := NewRestAPIClient("dummy")
+	for i := 0; i < 2; i++ {
+		items, err := client.UnreadItems(context.TODO(), "123")
+
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if len(items.Chat) != 2 || len(items.Mention) != 1 {
+			t.Errorf("Unexpected items are returned: %#v.", items)
+		}
+	}
+
+	if meCalled != 1 {
+		t.Errorf("The user ID is expected to be cached, but user/me is called %d times.", meCalled)
+	}
+}
+
+func TestRestAPIClient_MarkAsRead(t *testing.T) {
+	tests := []struct {
+		response string
+		hasErr   bool
+	}{
+		{
+			response: `{"success":true}`,
+			hasErr:   false,
+		},
+		{
+			response: `{"success":false}`,
+			hasErr:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			resetClient := switchHTTPClient(func(req *http.Request) (*http.Response, error) {
+				switch req.URL.Path {
+				case "/v1/user/me":
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       ioutil.NopCloser(strings.NewReader(`{"id":"user"}`)),
+					}, nil
+
+				case "/v1/user/user/rooms/123/unreadItems":
+					if req.Method != http.MethodPost {
+						t.Errorf("Unexpected request method: %s.", req.Method)
+					}
+					body, _ := ioutil.ReadAll(req.Body)
+					if string(body) != `{"chat":["1","2"]}` {
+						t.Errorf("Unexpected body is given: %s.", string(body))
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       ioutil.NopCloser(strings.NewReader(tt.response)),
+					}, nil
+
+				default:
+					t.Fatalf("Unexpected request: %s.", req.URL.Path)
+					return nil, nil
+
+				}
+			})
+			defer resetClient()
+
+			client := NewRestAPIClient("dummy")
+			err := client.MarkAsRead(context.TODO(), "123", "1", "2")
+
+			if tt.hasErr && err == nil {
+				t.Error("Expected error is not returned.")
+			}
+			if !tt.hasErr && err != nil {
+				t.Errorf("Unexpected error is returned: %s.", err.Error())
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package gitter
 import (
 	"context"
 	"fmt"
+	"github.com/oklahomer/go-sarah/v4/tracing"
 	"net/http"
 	"net/url"
 )
@@ -54,6 +55,7 @@ func (client *StreamingAPIClient) Connect(ctx context.Context, room *Room) (Conn
 	req.Header.Set("Authorization", "Bearer "+client.token)
 	req.Header.Set("Accept", "application/json")
 	req = req.WithContext(ctx)
+	tracing.InjectHTTP(ctx, req)
 
 	// Do request
 	resp, err := http.DefaultClient.Do(req)
@@ -62,5 +64,5 @@ func (client *StreamingAPIClient) Connect(ctx context.Context, room *Room) (Conn
 		return nil, err
 	}
 
-	return newConnWrapper(room, resp.Body), nil
+	return newConnWrapper(ctx, room, resp.Body), nil
 }
@@ -3,7 +3,9 @@ module github.com/oklahomer/go-sarah/v4
 go 1.11
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/fsnotify/fsnotify v1.4.9
+	github.com/gorilla/websocket v1.4.2
 	github.com/oklahomer/go-kasumi v0.0.0-20210320022217-84d2c0ccb359
 	github.com/oklahomer/golack/v2 v2.0.0
 	github.com/patrickmn/go-cache v2.1.0+incompatible
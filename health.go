@@ -0,0 +1,177 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthChecker is an optional interface that a Bot, an Adapter, or a worker implementation may satisfy to report its health.
+// HealthCheck must return a non-nil error when the component is not working properly;
+// e.g. an Adapter's connection to the chat service is silently gone.
+//
+// go-sarah's core registers the components that satisfy this interface on sarah.Run() and calls them on each health check.
+// The default Bot implementation satisfies this interface and delegates the call to its Adapter when the Adapter satisfies this interface.
+type HealthChecker interface {
+	HealthCheck(context.Context) error
+}
+
+// healthCheckTimeout is the maximum time to wait for all health checks to return.
+const healthCheckTimeout = 5 * time.Second
+
+var (
+	// ErrRunnerNotRunning is reported when sarah.Run() is not called yet or the process is already stopped.
+	ErrRunnerNotRunning = errors.New("go-sarah's process is not running")
+
+	// ErrBotNotRunning is reported when a registered Bot is not running.
+	ErrBotNotRunning = errors.New("bot is not running")
+)
+
+// HealthReport represents the result of the health check.
+type HealthReport struct {
+	// Healthy is true when all checks pass.
+	Healthy bool `json:"healthy"`
+
+	// Checks contains the result of each check.
+	// The value is "ok" when the check passes; is an error message when it fails.
+	Checks map[string]string `json:"checks"`
+}
+
+type healthCheck struct {
+	name  string
+	check func(context.Context) error
+}
+
+type healthChecks struct {
+	checks []*healthCheck
+	mutex  sync.RWMutex
+}
+
+func (h *healthChecks) add(name string, check func(context.Context) error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.checks = append(h.checks, &healthCheck{
+		name:  name,
+		check: check,
+	})
+}
+
+func (h *healthChecks) run(ctx context.Context, report *HealthReport) {
+	h.mutex.RLock()
+	checks := make([]*healthCheck, len(h.checks))
+	copy(checks, h.checks)
+	h.mutex.RUnlock()
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan *result, len(checks))
+	for _, c := range checks {
+		go func(c *healthCheck) {
+			results <- &result{name: c.name, err: c.check(ctx)}
+		}(c)
+	}
+
+	for range checks {
+		select {
+		case res := <-results:
+			report.set(res.name, res.err)
+
+		case <-ctx.Done():
+			// Report the checks that did not return in time.
+			for _, c := range checks {
+				if _, ok := report.Checks[c.name]; !ok {
+					report.set(c.name, ctx.Err())
+				}
+			}
+			return
+
+		}
+	}
+}
+
+func (r *HealthReport) set(name string, err error) {
+	if err == nil {
+		r.Checks[name] = "ok"
+		return
+	}
+
+	r.Healthy = false
+	r.Checks[name] = err.Error()
+}
+
+// CheckHealth checks the health of go-sarah's process and returns the result.
+// In addition to the registered HealthChecker implementations, this checks if the Runner and all registered Bots are running.
+// Before sarah.Run() is called, the result is unhealthy with ErrRunnerNotRunning.
+func CheckHealth(ctx context.Context) *HealthReport {
+	report := &HealthReport{
+		Healthy: true,
+		Checks:  map[string]string{},
+	}
+
+	s := runnerStatus.snapshot()
+	if !s.Running {
+		report.set("runner", ErrRunnerNotRunning)
+	} else {
+		report.set("runner", nil)
+	}
+
+	for _, bot := range s.Bots {
+		if bot.Running {
+			report.set("bot:"+bot.Type.String(), nil)
+		} else {
+			report.set("bot:"+bot.Type.String(), ErrBotNotRunning)
+		}
+	}
+
+	runnerStatus.healthChecks.run(ctx, report)
+
+	return report
+}
+
+// NewHealthHandler creates and returns an http.Handler that exposes the health of go-sarah's process with below endpoints:
+//
+//   - /healthz responds with 503 when any running component is found to be broken; e.g. a Bot has stopped or an Adapter's connection is gone.
+//     Before sarah.Run() is called, this responds with 200 so a liveness probe does not kill the starting process.
+//   - /readyz responds with 503 unless sarah.Run() is called and all checks pass.
+//
+// Both endpoints respond with a JSON representation of HealthReport.
+// This can be served by a dedicated lightweight server or be mounted on an existing one:
+//
+//  go http.ListenAndServe(":8080", sarah.NewHealthHandler())
+//
+// Use /healthz for Kubernetes' liveness probe to restart a pod whose Bot is no longer working, and /readyz for the readiness probe.
+func NewHealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		serveHealth(w, req, true)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		serveHealth(w, req, false)
+	})
+	return mux
+}
+
+func serveHealth(w http.ResponseWriter, req *http.Request, liveness bool) {
+	ctx, cancel := context.WithTimeout(req.Context(), healthCheckTimeout)
+	defer cancel()
+
+	report := CheckHealth(ctx)
+	if liveness && !runnerStatus.started() {
+		// The process is still starting.
+		report.Healthy = true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,207 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckHealth(t *testing.T) {
+	SetupAndRun(func() {
+		report := CheckHealth(context.Background())
+		if report.Healthy {
+			t.Error("Health check must fail before sarah.Run() is called.")
+		}
+
+		if report.Checks["runner"] != ErrRunnerNotRunning.Error() {
+			t.Errorf("Unexpected runner state is reported: %s.", report.Checks["runner"])
+		}
+
+		_ = runnerStatus.start()
+		runnerStatus.addBot(&DummyBot{BotTypeValue: "dummy"})
+		runnerStatus.healthChecks.add("ok", func(_ context.Context) error {
+			return nil
+		})
+
+		report = CheckHealth(context.Background())
+		if !report.Healthy {
+			t.Errorf("Health check must pass: %#v.", report.Checks)
+		}
+
+		runnerStatus.healthChecks.add("broken", func(_ context.Context) error {
+			return errors.New("connection is gone")
+		})
+
+		report = CheckHealth(context.Background())
+		if report.Healthy {
+			t.Error("Health check must fail when any check fails.")
+		}
+
+		if report.Checks["broken"] != "connection is gone" {
+			t.Errorf("Unexpected result is reported: %#v.", report.Checks)
+		}
+
+		if report.Checks["ok"] != "ok" || report.Checks["bot:dummy"] != "ok" {
+			t.Errorf("Unexpected result is reported: %#v.", report.Checks)
+		}
+	})
+}
+
+func TestCheckHealth_BotStopped(t *testing.T) {
+	SetupAndRun(func() {
+		bot := &DummyBot{BotTypeValue: "dummy"}
+		_ = runnerStatus.start()
+		runnerStatus.addBot(bot)
+		runnerStatus.stopBot(bot)
+
+		report := CheckHealth(context.Background())
+		if report.Healthy {
+			t.Error("Health check must fail when a Bot is stopped.")
+		}
+
+		if report.Checks["bot:dummy"] != ErrBotNotRunning.Error() {
+			t.Errorf("Unexpected result is reported: %#v.", report.Checks)
+		}
+	})
+}
+
+func TestCheckHealth_Timeout(t *testing.T) {
+	SetupAndRun(func() {
+		_ = runnerStatus.start()
+		block := make(chan struct{})
+		defer close(block)
+		runnerStatus.healthChecks.add("slow", func(_ context.Context) error {
+			<-block
+			return nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		report := CheckHealth(ctx)
+
+		if report.Healthy {
+			t.Error("Health check must fail when a check does not return in time.")
+		}
+
+		if report.Checks["slow"] != context.DeadlineExceeded.Error() {
+			t.Errorf("Unexpected result is reported: %#v.", report.Checks)
+		}
+	})
+}
+
+func TestNewHealthHandler(t *testing.T) {
+	SetupAndRun(func() {
+		handler := NewHealthHandler()
+
+		tests := []struct {
+			path    string
+			started bool
+			status  int
+		}{
+			{
+				path:    "/healthz",
+				started: false,
+				status:  http.StatusOK,
+			},
+			{
+				path:    "/readyz",
+				started: false,
+				status:  http.StatusServiceUnavailable,
+			},
+			{
+				path:    "/healthz",
+				started: true,
+				status:  http.StatusOK,
+			},
+			{
+				path:    "/readyz",
+				started: true,
+				status:  http.StatusOK,
+			},
+		}
+
+		for i, tt := range tests {
+			if tt.started {
+				_ = runnerStatus.start()
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != tt.status {
+				t.Errorf("Unexpected status code is returned on test #%d: %d.", i, recorder.Code)
+			}
+
+			report := &HealthReport{}
+			err := json.Unmarshal(recorder.Body.Bytes(), report)
+			if err != nil {
+				t.Errorf("Unexpected response body is returned on test #%d: %s.", i, recorder.Body.String())
+			}
+		}
+	})
+}
+
+func Test_runner_registerHealthChecks(t *testing.T) {
+	SetupAndRun(func() {
+		r := &runner{
+			worker: &DummyWorker{},
+			scheduler: &taskScheduler{
+				done: make(chan struct{}),
+			},
+			bots: []Bot{
+				&defaultBot{
+					botType: "dummy",
+					healthCheckFunc: func(_ context.Context) error {
+						return nil
+					},
+				},
+				&DummyBot{BotTypeValue: "plain"},
+			},
+		}
+
+		r.registerHealthChecks()
+
+		var names []string
+		for _, c := range runnerStatus.healthChecks.checks {
+			names = append(names, c.name)
+		}
+
+		if len(names) != 2 || names[0] != "scheduler" || names[1] != "bot:dummy:health" {
+			t.Errorf("Unexpected checks are registered: %#v.", names)
+		}
+	})
+}
+
+func Test_taskScheduler_HealthCheck(t *testing.T) {
+	s := &taskScheduler{
+		done: make(chan struct{}),
+	}
+
+	if err := s.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	close(s.done)
+	if err := s.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestDefaultBot_HealthCheck(t *testing.T) {
+	bot := &defaultBot{}
+	if err := bot.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := errors.New("expected")
+	bot.healthCheckFunc = func(_ context.Context) error {
+		return expected
+	}
+	if err := bot.HealthCheck(context.Background()); err != expected {
+		t.Errorf("Unexpected error is returned: %#v.", err)
+	}
+}
@@ -0,0 +1,246 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"sync"
+	"time"
+)
+
+// ErrHistoryNotAvailable is returned by HistoryFromContext when the Bot is not set up with BotWithHistory.
+var ErrHistoryNotAvailable = errors.New("history is not available")
+
+// HistoryConfig contains some configuration variables for History.
+type HistoryConfig struct {
+	// Size is the maximum number of the messages kept for each destination. The oldest message is dropped when a new one exceeds this.
+	Size int `json:"size" yaml:"size"`
+
+	// TTL is the duration a message is kept. Give zero to keep the messages til they are dropped by Size.
+	TTL time.Duration `json:"ttl" yaml:"ttl"`
+}
+
+// NewHistoryConfig creates and returns new HistoryConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to overload default values.
+func NewHistoryConfig() *HistoryConfig {
+	return &HistoryConfig{
+		Size: 100,
+		TTL:  24 * time.Hour,
+	}
+}
+
+// ApplyDefaults sets the default value to Size when it is not given.
+func (c *HistoryConfig) ApplyDefaults() {
+	if c.Size == 0 {
+		c.Size = NewHistoryConfig().Size
+	}
+}
+
+// Validate checks that Size is positive and TTL is not negative.
+func (c *HistoryConfig) Validate() error {
+	var errs ConfigKeyErrors
+
+	if c.Size < 0 {
+		errs = append(errs, &ConfigKeyError{Key: "size", Value: fmt.Sprint(c.Size), Err: errors.New("size must be positive")})
+	}
+
+	if c.TTL < 0 {
+		errs = append(errs, &ConfigKeyError{Key: "ttl", Value: c.TTL.String(), Err: errors.New("ttl must not be negative")})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// HistoryEntry represents a message recorded by History.
+type HistoryEntry struct {
+	// ThreadID is the identifier of the thread the message is posted in, if any. See ThreadInput and ThreadDestination.
+	ThreadID string `json:"thread_id,omitempty"`
+
+	// SenderKey is the Input.SenderKey of the received message. This is empty for a message the Bot sent.
+	SenderKey string `json:"sender_key,omitempty"`
+
+	// SenderName is the name of the sender when the Input satisfies SenderDisplayNameInput.
+	SenderName string `json:"sender_name,omitempty"`
+
+	// Text is the text of the message. A RichMessage is recorded with its PlainText.
+	Text string `json:"text"`
+
+	// Outgoing tells the message is sent by the Bot.
+	Outgoing bool `json:"outgoing"`
+
+	// Time is when the message is sent or received.
+	Time time.Time `json:"time"`
+}
+
+// History records the recent messages the Bot receives and sends, and keeps them for each destination such as a chat room.
+// Give this to NewBot with BotWithHistory, and a Command obtains this with HistoryFromContext to refer to the recent conversation;
+// e.g. to summarize the last hour, to give the conversation to a language model as a context, or to moderate the messages.
+//
+// The messages are kept in memory by default. Give HistoryWithStore to persist them, so the history survives a restart and is shared among multiple processes.
+type History struct {
+	config *HistoryConfig
+	store  Store
+	mutex  sync.Mutex
+}
+
+// HistoryOption defines function that History's functional option must satisfy.
+type HistoryOption func(*History)
+
+// HistoryWithStore creates and returns HistoryOption to keep the messages in the given Store instead of memory.
+// The messages of each destination are stored as one JSON value, so the Store is read and written on every message.
+// When multiple Bots share the Store, give each Bot a Store namespaced by NewNamespacedStore to separate their histories.
+//
+//  store := sarah.NewNamespacedStore(redisStore, "slack", "history")
+//  history := sarah.NewHistory(sarah.NewHistoryConfig(), sarah.HistoryWithStore(store))
+//  bot := sarah.NewBot(myAdapter, sarah.BotWithHistory(history))
+func HistoryWithStore(store Store) HistoryOption {
+	return func(h *History) {
+		h.store = store
+	}
+}
+
+// NewHistory creates and returns a new History.
+func NewHistory(config *HistoryConfig, options ...HistoryOption) *History {
+	h := &History{
+		config: config,
+		store:  NewMemoryStore(),
+	}
+
+	for _, opt := range options {
+		opt(h)
+	}
+
+	return h
+}
+
+// Record appends the given message to the history of the given destination.
+// The messages beyond HistoryConfig.Size or older than HistoryConfig.TTL are dropped at this point.
+func (h *History) Record(ctx context.Context, destination OutputDestination, entry *HistoryEntry) error {
+	key := historyKey(destination)
+
+	// Lock so the concurrent records on this process do not overwrite each other.
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entries, err := h.load(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	entries = append(h.alive(ctx, entries), entry)
+	if h.config.Size > 0 && len(entries) > h.config.Size {
+		entries = entries[len(entries)-h.config.Size:]
+	}
+
+	value, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode history of %s: %w", key, err)
+	}
+
+	return h.store.Set(ctx, key, value)
+}
+
+// Recent returns the messages of the given destination since the given time, in chronological order.
+// Give zero time to get all kept messages.
+func (h *History) Recent(ctx context.Context, destination OutputDestination, since time.Time) ([]*HistoryEntry, error) {
+	entries, err := h.load(ctx, historyKey(destination))
+	if err != nil {
+		return nil, err
+	}
+
+	recent := []*HistoryEntry{}
+	for _, entry := range h.alive(ctx, entries) {
+		if entry.Time.Before(since) {
+			continue
+		}
+		recent = append(recent, entry)
+	}
+	return recent, nil
+}
+
+func (h *History) load(ctx context.Context, key string) ([]*HistoryEntry, error) {
+	value, err := h.store.Get(ctx, key)
+	if errors.Is(err, ErrStoreKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*HistoryEntry
+	err = json.Unmarshal(value, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode history of %s: %w", key, err)
+	}
+	return entries, nil
+}
+
+// alive returns the entries that are not expired.
+func (h *History) alive(ctx context.Context, entries []*HistoryEntry) []*HistoryEntry {
+	if h.config.TTL == 0 {
+		return entries
+	}
+
+	threshold := clock.FromContext(ctx).Now().Add(-h.config.TTL)
+	for i, entry := range entries {
+		if entry.Time.After(threshold) {
+			return entries[i:]
+		}
+	}
+	return nil
+}
+
+// historyKey returns the key of the history for the given destination.
+// A message in a thread is kept with the other messages of the same destination, and HistoryEntry.ThreadID tells the thread.
+func historyKey(destination OutputDestination) string {
+	return fmt.Sprint(BaseDestination(destination))
+}
+
+// historyText returns the text to record for the given content of a message.
+// false is returned when the content has no text form.
+func historyText(content interface{}) (string, bool) {
+	switch typed := content.(type) {
+	case string:
+		return typed, true
+
+	case *RichMessage:
+		return typed.PlainText(), true
+
+	default:
+		return "", false
+
+	}
+}
+
+type historyKeyType struct{}
+
+// withHistory returns a copy of the given context that carries the given History.
+func withHistory(ctx context.Context, history *History) context.Context {
+	return context.WithValue(ctx, historyKeyType{}, history)
+}
+
+// HistoryFromContext returns the History of the Bot that executes the Command.
+// Pass the context given to Command.Execute or ContextualFunc:
+//
+//  func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//  	history, err := sarah.HistoryFromContext(ctx)
+//  	if err != nil {
+//  		return nil, err
+//  	}
+//  	entries, err := history.Recent(ctx, input.ReplyTo(), time.Now().Add(-time.Hour))
+//  	...
+//  }
+//
+// ErrHistoryNotAvailable is returned when the Bot is not set up with BotWithHistory.
+func HistoryFromContext(ctx context.Context) (*History, error) {
+	history, ok := ctx.Value(historyKeyType{}).(*History)
+	if !ok {
+		return nil, ErrHistoryNotAvailable
+	}
+	return history, nil
+}
@@ -0,0 +1,104 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"testing"
+	"time"
+)
+
+func TestHistoryConfig_Validate(t *testing.T) {
+	config := &HistoryConfig{Size: -1, TTL: -1}
+
+	err := config.Validate()
+	errs, ok := err.(ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("Unexpected errors are returned: %s.", errs.Error())
+	}
+
+	err = NewHistoryConfig().Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestHistory(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	ctx := clock.WithContext(context.Background(), fake)
+
+	store := NewMemoryStore()
+	history := NewHistory(&HistoryConfig{Size: 3, TTL: time.Hour}, HistoryWithStore(store))
+
+	record := func(destination OutputDestination, text string, at time.Time) {
+		err := history.Record(ctx, destination, &HistoryEntry{Text: text, Time: at})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+	record("#general", "expired", now.Add(-2*time.Hour))
+	record("#general", "first", now.Add(-40*time.Minute))
+	record(NewThreadDestination("#general", "thread"), "second", now.Add(-30*time.Minute))
+	record("#general", "third", now.Add(-20*time.Minute))
+	record("#general", "fourth", now.Add(-10*time.Minute))
+	record("#random", "other", now)
+
+	entries, err := history.Recent(ctx, "#general", time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(entries) != 3 || entries[0].Text != "second" || entries[2].Text != "fourth" {
+		t.Errorf("Unexpected entries are returned: %#v.", entries)
+	}
+
+	entries, err = history.Recent(ctx, "#general", now.Add(-15*time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(entries) != 1 || entries[0].Text != "fourth" {
+		t.Errorf("Unexpected entries are returned: %#v.", entries)
+	}
+
+	// The entries older than the TTL are not returned even before the next record.
+	fake.Advance(45 * time.Minute)
+	entries, err = history.Recent(ctx, "#general", time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(entries) != 1 || entries[0].Text != "fourth" {
+		t.Errorf("Expired entries are returned: %#v.", entries)
+	}
+
+	keys, _ := store.List(ctx, "")
+	if len(keys) != 2 {
+		t.Errorf("History is not kept per destination: %#v.", keys)
+	}
+
+	entries, err = history.Recent(ctx, "#unknown", time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(entries) != 0 {
+		t.Errorf("Unexpected entries are returned: %#v.", entries)
+	}
+}
+
+func TestHistoryFromContext(t *testing.T) {
+	_, err := HistoryFromContext(context.TODO())
+	if !errors.Is(err, ErrHistoryNotAvailable) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	history := NewHistory(NewHistoryConfig())
+	given, err := HistoryFromContext(withHistory(context.TODO(), history))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if given != history {
+		t.Error("Unexpected History is returned.")
+	}
+}
@@ -0,0 +1,73 @@
+package sarah
+
+import (
+	"context"
+)
+
+// personNamespace is the namespace of the Store for the data that belongs to a person rather than to a Bot.
+const personNamespace = "person"
+
+// IdentityResolver maps the user with the given identifier on the Bot with the given BotType to the identifier of the person,
+// so the same person using multiple chat services can be treated as one.
+// An empty string is returned when the user is not linked to any person.
+// See the identity package for an implementation.
+type IdentityResolver func(ctx context.Context, botType BotType, userID string) (string, error)
+
+type identityKey struct{}
+
+// identity holds what is needed to resolve the person of a user on a Bot.
+type identity struct {
+	botType  BotType
+	resolver IdentityResolver
+
+	// store is the Store shared by all Bots. This is nil when no Store is registered.
+	store Store
+}
+
+// withIdentity returns a copy of the given context that carries the given identity.
+func withIdentity(ctx context.Context, id *identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// ResolveIdentity returns the identifier of the person that the user with the given identifier belongs to.
+// Pass the context given to Command.Execute, ContextualFunc or ScheduledTask's function.
+// A Command can compare the returned value instead of the platform account to grant a permission to a person:
+//
+//  func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//  	person, err := sarah.ResolveIdentity(ctx, sarah.UserID(input))
+//  	if err != nil {
+//  		return nil, err
+//  	}
+//  	if person == "" || !allowed[person] {
+//  		return slack.NewResponse(input, "You are not allowed to deploy.")
+//  	}
+//  	...
+//  }
+//
+// An empty string is returned when no IdentityResolver is registered or the user is not linked to any person.
+func ResolveIdentity(ctx context.Context, userID string) (string, error) {
+	id, ok := ctx.Value(identityKey{}).(*identity)
+	if !ok {
+		return "", nil
+	}
+	return id.resolver(ctx, id.botType, userID)
+}
+
+// personStore returns the Store for the person that the user with the given identifier belongs to, and the key for the person in the Store.
+// false is returned when the user is not linked to any person or the person's data can not be stored.
+func personStore(ctx context.Context, namespace string, userID string) (Store, string, bool, error) {
+	id, ok := ctx.Value(identityKey{}).(*identity)
+	if !ok || id.store == nil {
+		return nil, "", false, nil
+	}
+
+	person, err := id.resolver(ctx, id.botType, userID)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if person == "" {
+		return nil, "", false, nil
+	}
+
+	return NewNamespacedStore(id.store, personNamespace, namespace), person, true, nil
+}
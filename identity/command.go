@@ -0,0 +1,147 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"regexp"
+	"strings"
+)
+
+// Identifier is the identifier of the Command this package provides.
+const Identifier = "identity"
+
+var matchPattern = regexp.MustCompile(`^\.identity(\s|$)`)
+
+type command struct {
+	service *Service
+	botType sarah.BotType
+}
+
+var _ sarah.Command = (*command)(nil)
+
+// NewCommand creates and returns a new sarah.Command for the Bot with the given BotType.
+// The command responds to below inputs:
+//
+//  .identity link           issues a one-time code to link another account
+//  .identity verify <code>  links this account to the account the code is issued for
+//  .identity unlink         removes this account from the linked accounts
+//  .identity show           the linked accounts and email addresses
+//
+// The code and the linked accounts are sent privately with sarah.CommandResponse.Private,
+// since whoever verifies the code is linked to the account and gains the same permissions.
+// Register the command with Service.Register or sarah.RegisterCommand.
+func (s *Service) NewCommand(botType sarah.BotType) sarah.Command {
+	return &command{
+		service: s,
+		botType: botType,
+	}
+}
+
+// Identifier returns the command ID.
+func (c *command) Identifier() string {
+	return Identifier
+}
+
+// Instruction provides the input instruction.
+func (c *command) Instruction(_ *sarah.HelpInput) string {
+	return ".identity link|verify <code>|unlink|show to link your accounts on other chat services"
+}
+
+// Match checks if the input is an identity command.
+func (c *command) Match(input sarah.Input) bool {
+	return matchPattern.Copy().MatchString(input.Message())
+}
+
+// Execute runs the given sub-command and returns its result.
+func (c *command) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	account := &Account{
+		BotType: c.botType,
+		UserID:  sarah.UserID(input),
+	}
+
+	args := strings.Fields(sarah.StripMessage(matchPattern, input.Message()))
+	if len(args) == 0 {
+		return respond("Usage: " + c.Instruction(&sarah.HelpInput{OriginalInput: input})), nil
+	}
+
+	switch args[0] {
+	case "link":
+		code, err := c.service.IssueCode(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprintf("Send \".identity verify %s\" from your other account within %s. Do not share this code with others.", code, c.service.config.CodeTTL)
+		return &sarah.CommandResponse{
+			Content: text,
+			Private: true,
+		}, nil
+
+	case "verify":
+		if len(args) < 2 {
+			return respond("Usage: .identity verify <code>"), nil
+		}
+		person, err := c.service.Verify(ctx, account, args[1])
+		if errors.Is(err, ErrInvalidCode) {
+			return respond("The code is invalid or expired. Issue a new code with .identity link."), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return respond(fmt.Sprintf("Linked: %s", accounts(person))), nil
+
+	case "unlink":
+		err := c.service.Unlink(ctx, account)
+		if errors.Is(err, ErrNotLinked) {
+			return respond("This account is not linked."), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return respond("This account is unlinked."), nil
+
+	case "show":
+		id, err := c.service.Resolve(ctx, account.BotType, account.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			return respond("This account is not linked."), nil
+		}
+		person, err := c.service.Person(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if person == nil {
+			return respond("This account is not linked."), nil
+		}
+		text := fmt.Sprintf("Linked: %s", accounts(person))
+		if len(person.Emails) > 0 {
+			text += fmt.Sprintf("\nEmails: %s", strings.Join(person.Emails, ", "))
+		}
+		return &sarah.CommandResponse{
+			Content: text,
+			Private: true,
+		}, nil
+
+	default:
+		return respond("Usage: " + c.Instruction(&sarah.HelpInput{OriginalInput: input})), nil
+
+	}
+}
+
+func respond(text string) *sarah.CommandResponse {
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}
+}
+
+func accounts(person *Person) string {
+	names := make([]string, 0, len(person.Accounts))
+	for _, a := range person.Accounts {
+		names = append(names, a.String())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,101 @@
+package identity
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderIDValue string
+	MessageValue  string
+}
+
+var _ sarah.SenderIDInput = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return "channel|" + i.SenderIDValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "channel"
+}
+
+func (i *DummyInput) SenderID() string {
+	return i.SenderIDValue
+}
+
+func execute(t *testing.T, command sarah.Command, userID string, message string) *sarah.CommandResponse {
+	input := &DummyInput{SenderIDValue: userID, MessageValue: message}
+	if !command.Match(input) {
+		t.Fatalf("Command does not match: %s.", message)
+	}
+
+	res, err := command.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return res
+}
+
+func TestCommand_Execute(t *testing.T) {
+	s := NewService(sarah.NewMemoryStore(), NewConfig())
+	slack := s.NewCommand("slack")
+	gitter := s.NewCommand("gitter")
+
+	if slack.Identifier() != Identifier {
+		t.Errorf("Unexpected identifier is returned: %s.", slack.Identifier())
+	}
+
+	res := execute(t, slack, "U1", ".identity show")
+	if res.Content != "This account is not linked." {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+
+	res = execute(t, slack, "U1", ".identity link")
+	if !res.Private {
+		t.Error("Code must be sent privately.")
+	}
+	fields := strings.Fields(res.Content.(string))
+	code := strings.TrimSuffix(fields[3], `"`)
+
+	res = execute(t, gitter, "g1", ".identity verify wrong")
+	if !strings.HasPrefix(res.Content.(string), "The code is invalid") {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+
+	res = execute(t, gitter, "g1", ".identity verify "+strings.ToLower(code))
+	if res.Content != "Linked: slack:U1, gitter:g1" {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+
+	res = execute(t, gitter, "g1", ".identity show")
+	if res.Content != "Linked: slack:U1, gitter:g1" || !res.Private {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+
+	res = execute(t, slack, "U1", ".identity unlink")
+	if res.Content != "This account is unlinked." {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+
+	res = execute(t, slack, "U1", ".identity unlink")
+	if res.Content != "This account is not linked." {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+
+	res = execute(t, slack, "U1", ".identity")
+	if !strings.HasPrefix(res.Content.(string), "Usage: ") {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+}
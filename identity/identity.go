@@ -0,0 +1,437 @@
+/*
+Package identity provides a service that links the accounts of the same person across chat services, e.g. a Slack user, a Gitter user, and an email address.
+
+A person links the accounts by themselves with the Command this package provides:
+.identity link on one account issues a one-time code, and .identity verify <code> on another account links the two.
+Since the code is only told to the first account, the second account is verified to belong to the same person.
+
+	store, err := redisstore.New(client, redisstore.NewConfig())
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterStore(store)
+
+	service := identity.NewService(store, identity.NewConfig())
+	sarah.RegisterIdentityResolver(service.Resolve)
+	service.Register(slack.SLACK, gitter.GITTER)
+
+With the IdentityResolver registered, sarah.ResolveIdentity tells the person of a user so a Command can grant a permission to the person,
+and sarah.UserProfile is shared by the linked accounts so the preferences follow the person.
+Give the Service the same Store as sarah.RegisterStore so the links are shared by all Bots and processes.
+*/
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidCode is returned by Service.Verify when the given code is unknown, expired, or issued for the same account.
+	ErrInvalidCode = errors.New("code is invalid or expired")
+
+	// ErrNotLinked is returned by Service.Unlink when the given account is not linked to any person.
+	ErrNotLinked = errors.New("account is not linked")
+)
+
+// Config contains some configuration variables for the Service.
+type Config struct {
+	// CodeTTL is the duration a code issued by Service.IssueCode is valid.
+	CodeTTL time.Duration `json:"code_ttl" yaml:"code_ttl"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		CodeTTL: 10 * time.Minute,
+	}
+}
+
+// ApplyDefaults sets the default value to CodeTTL when it is not given.
+func (c *Config) ApplyDefaults() {
+	if c.CodeTTL == 0 {
+		c.CodeTTL = NewConfig().CodeTTL
+	}
+}
+
+// Validate checks that CodeTTL is positive.
+func (c *Config) Validate() error {
+	if c.CodeTTL < 0 {
+		return sarah.ConfigKeyErrors{&sarah.ConfigKeyError{Key: "code_ttl", Value: c.CodeTTL.String(), Err: errors.New("code_ttl must be positive")}}
+	}
+	return nil
+}
+
+// Account is a user on a chat service.
+type Account struct {
+	// BotType is the BotType of the Bot the user talks to.
+	BotType sarah.BotType `json:"bot_type"`
+
+	// UserID is the identifier of the user. See sarah.UserID.
+	UserID string `json:"user_id"`
+}
+
+// String returns a human-readable form of the Account such as "slack:U12345678".
+func (a *Account) String() string {
+	return fmt.Sprintf("%s:%s", a.BotType, a.UserID)
+}
+
+// Person is a human who owns the linked accounts and email addresses.
+type Person struct {
+	// ID is the identifier of the person that sarah.ResolveIdentity returns.
+	ID string `json:"id"`
+
+	// Accounts are the linked accounts.
+	Accounts []*Account `json:"accounts"`
+
+	// Emails are the linked email addresses.
+	Emails []string `json:"emails,omitempty"`
+}
+
+type code struct {
+	Account   *Account  `json:"account"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Service links the accounts of the same person and resolves the person of an account.
+type Service struct {
+	store  sarah.Store
+	config *Config
+
+	// mutex serializes the updates on this process since an update reads and writes multiple keys.
+	mutex sync.Mutex
+}
+
+// NewService creates and returns a new Service that stores the links in the given Store.
+// The keys are prefixed with "identity/", so the Store can be shared with other purposes.
+func NewService(store sarah.Store, config *Config) *Service {
+	return &Service{
+		store:  sarah.NewNamespacedStore(store, "identity"),
+		config: config,
+	}
+}
+
+// Register registers the Command that lets users link their accounts for the Bots with the given BotTypes.
+// Call this before sarah.Run.
+func (s *Service) Register(botTypes ...sarah.BotType) {
+	for _, botType := range botTypes {
+		sarah.RegisterCommand(botType, s.NewCommand(botType))
+	}
+}
+
+// Resolve returns the identifier of the person the given user belongs to, or an empty string when the user is not linked.
+// This satisfies sarah.IdentityResolver.
+func (s *Service) Resolve(ctx context.Context, botType sarah.BotType, userID string) (string, error) {
+	return s.get(ctx, accountKey(&Account{BotType: botType, UserID: userID}))
+}
+
+// ResolveEmail returns the identifier of the person the given email address belongs to, or an empty string when the address is not linked.
+func (s *Service) ResolveEmail(ctx context.Context, email string) (string, error) {
+	return s.get(ctx, emailKey(email))
+}
+
+// Person returns the Person with the given identifier. nil is returned when no such person exists.
+func (s *Service) Person(ctx context.Context, id string) (*Person, error) {
+	value, err := s.store.Get(ctx, personKey(id))
+	if errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	person := &Person{}
+	err = json.Unmarshal(value, person)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode person %s: %w", id, err)
+	}
+	return person, nil
+}
+
+// Link links the given accounts as the accounts of the same person.
+// When both accounts are already linked to different persons, the persons are merged into one.
+//
+// This does not verify that the accounts belong to the same person,
+// so call this only with trusted accounts, e.g. from an administrative tool. Users link their accounts with IssueCode and Verify.
+func (s *Service) Link(ctx context.Context, account *Account, other *Account) (*Person, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.link(ctx, accountKey(account), accountKey(other), func(p *Person) {
+		p.Accounts = appendAccount(p.Accounts, account)
+		p.Accounts = appendAccount(p.Accounts, other)
+	})
+}
+
+// LinkEmail links the given email address to the person of the given account.
+// Like Link, this does not verify the address, so call this only with an address the chat service has verified, e.g. the email in a Slack user's profile.
+func (s *Service) LinkEmail(ctx context.Context, account *Account, email string) (*Person, error) {
+	email = strings.ToLower(email)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.link(ctx, accountKey(account), emailKey(email), func(p *Person) {
+		p.Accounts = appendAccount(p.Accounts, account)
+		p.Emails = appendEmail(p.Emails, email)
+	})
+}
+
+// Unlink removes the given account from its person.
+// The person is removed along with the email addresses when no account is left.
+// ErrNotLinked is returned when the account is not linked.
+func (s *Service) Unlink(ctx context.Context, account *Account) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := accountKey(account)
+	id, err := s.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return ErrNotLinked
+	}
+
+	person, err := s.Person(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	if person == nil {
+		return nil
+	}
+
+	var accounts []*Account
+	for _, a := range person.Accounts {
+		if *a != *account {
+			accounts = append(accounts, a)
+		}
+	}
+	person.Accounts = accounts
+
+	if len(person.Accounts) > 0 {
+		return s.save(ctx, person)
+	}
+
+	for _, email := range person.Emails {
+		err = s.store.Delete(ctx, emailKey(email))
+		if err != nil {
+			return err
+		}
+	}
+	return s.store.Delete(ctx, personKey(person.ID))
+}
+
+// IssueCode issues a one-time code to link another account to the given account.
+// The code is valid for Config.CodeTTL and is consumed by Verify.
+func (s *Service) IssueCode(ctx context.Context, account *Account) (string, error) {
+	buf := make([]byte, 4)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	c := strings.ToUpper(hex.EncodeToString(buf))
+
+	value, err := json.Marshal(&code{
+		Account:   account,
+		ExpiresAt: clock.FromContext(ctx).Now().Add(s.config.CodeTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode code: %w", err)
+	}
+
+	err = s.store.Set(ctx, codeKey(c), value)
+	if err != nil {
+		return "", err
+	}
+	return c, nil
+}
+
+// Verify links the given account to the account the given code is issued for.
+// ErrInvalidCode is returned when the code is unknown, expired, or issued for the given account itself.
+func (s *Service) Verify(ctx context.Context, account *Account, c string) (*Person, error) {
+	key := codeKey(strings.ToUpper(c))
+	value, err := s.store.Get(ctx, key)
+	if errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		return nil, ErrInvalidCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	issued := &code{}
+	err = json.Unmarshal(value, issued)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode code: %w", err)
+	}
+
+	if *issued.Account == *account {
+		// Keep the code so the person can still verify from the other account.
+		return nil, ErrInvalidCode
+	}
+
+	// The code is consumed even when it is expired.
+	err = s.store.Delete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !clock.FromContext(ctx).Now().Before(issued.ExpiresAt) {
+		return nil, ErrInvalidCode
+	}
+
+	return s.Link(ctx, issued.Account, account)
+}
+
+// link links the subjects with the given keys to one person, merging the persons when they differ, and lets update add the subjects to the person.
+func (s *Service) link(ctx context.Context, key string, otherKey string, update func(*Person)) (*Person, error) {
+	persons := make([]*Person, 0, 2)
+	for _, k := range []string{key, otherKey} {
+		id, err := s.get(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			continue
+		}
+
+		p, err := s.Person(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil && (len(persons) == 0 || persons[0].ID != p.ID) {
+			persons = append(persons, p)
+		}
+	}
+
+	var person *Person
+	switch len(persons) {
+	case 0:
+		id, err := newID()
+		if err != nil {
+			return nil, err
+		}
+		person = &Person{ID: id}
+
+	case 1:
+		person = persons[0]
+
+	default:
+		// Merge the latter into the former.
+		person = persons[0]
+		merged := persons[1]
+		for _, a := range merged.Accounts {
+			person.Accounts = appendAccount(person.Accounts, a)
+		}
+		for _, e := range merged.Emails {
+			person.Emails = appendEmail(person.Emails, e)
+		}
+		err := s.store.Delete(ctx, personKey(merged.ID))
+		if err != nil {
+			return nil, err
+		}
+
+	}
+
+	update(person)
+	err := s.save(ctx, person)
+	if err != nil {
+		return nil, err
+	}
+	return person, nil
+}
+
+// save stores the given person and points the keys of its accounts and email addresses to the person.
+func (s *Service) save(ctx context.Context, person *Person) error {
+	value, err := json.Marshal(person)
+	if err != nil {
+		return fmt.Errorf("failed to encode person %s: %w", person.ID, err)
+	}
+
+	err = s.store.Set(ctx, personKey(person.ID), value)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(person.Accounts)+len(person.Emails))
+	for _, a := range person.Accounts {
+		keys = append(keys, accountKey(a))
+	}
+	for _, e := range person.Emails {
+		keys = append(keys, emailKey(e))
+	}
+	for _, key := range keys {
+		err = s.store.Set(ctx, key, []byte(person.ID))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) get(ctx context.Context, key string) (string, error) {
+	value, err := s.store.Get(ctx, key)
+	if errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func appendAccount(accounts []*Account, account *Account) []*Account {
+	for _, a := range accounts {
+		if *a == *account {
+			return accounts
+		}
+	}
+	return append(accounts, account)
+}
+
+func appendEmail(emails []string, email string) []string {
+	for _, e := range emails {
+		if e == email {
+			return emails
+		}
+	}
+	return append(emails, email)
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate identifier: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func accountKey(account *Account) string {
+	return fmt.Sprintf("account/%s/%s", account.BotType, account.UserID)
+}
+
+func emailKey(email string) string {
+	return "email/" + strings.ToLower(email)
+}
+
+func personKey(id string) string {
+	return "person/" + id
+}
+
+func codeKey(c string) string {
+	return "code/" + c
+}
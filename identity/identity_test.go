@@ -0,0 +1,173 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"testing"
+	"time"
+)
+
+var (
+	slackAccount  = &Account{BotType: "slack", UserID: "U1"}
+	gitterAccount = &Account{BotType: "gitter", UserID: "g1"}
+	lineAccount   = &Account{BotType: "line", UserID: "l1"}
+)
+
+func TestConfig_Validate(t *testing.T) {
+	err := (&Config{CodeTTL: -1}).Validate()
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	err = NewConfig().Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestService_Link(t *testing.T) {
+	ctx := context.TODO()
+	store := sarah.NewMemoryStore()
+	s := NewService(store, NewConfig())
+
+	id, err := s.Resolve(ctx, "slack", "U1")
+	if err != nil || id != "" {
+		t.Errorf("Unlinked account must not be resolved: %s, %#v.", id, err)
+	}
+
+	person, err := s.Link(ctx, slackAccount, gitterAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(person.Accounts) != 2 {
+		t.Errorf("Unexpected accounts are linked: %#v.", person.Accounts)
+	}
+
+	for _, a := range []*Account{slackAccount, gitterAccount} {
+		id, err = s.Resolve(ctx, a.BotType, a.UserID)
+		if err != nil || id != person.ID {
+			t.Errorf("Account %s is not resolved: %s, %#v.", a, id, err)
+		}
+	}
+
+	_, err = store.Get(ctx, "identity/person/"+person.ID)
+	if err != nil {
+		t.Errorf("Person is not stored with the namespace: %s.", err.Error())
+	}
+
+	// Linking the same accounts again changes nothing.
+	again, err := s.Link(ctx, gitterAccount, slackAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if again.ID != person.ID || len(again.Accounts) != 2 {
+		t.Errorf("Unexpected person is returned: %#v.", again)
+	}
+}
+
+func TestService_Link_Merge(t *testing.T) {
+	ctx := context.TODO()
+	s := NewService(sarah.NewMemoryStore(), NewConfig())
+
+	first, err := s.Link(ctx, slackAccount, gitterAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	second, err := s.LinkEmail(ctx, lineAccount, "Alice@Example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	merged, err := s.Link(ctx, gitterAccount, lineAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if merged.ID != first.ID || len(merged.Accounts) != 3 || len(merged.Emails) != 1 || merged.Emails[0] != "alice@example.com" {
+		t.Errorf("Persons are not merged: %#v.", merged)
+	}
+
+	removed, err := s.Person(ctx, second.ID)
+	if err != nil || removed != nil {
+		t.Errorf("Merged person must be removed: %#v, %#v.", removed, err)
+	}
+
+	id, err := s.ResolveEmail(ctx, "alice@example.com")
+	if err != nil || id != first.ID {
+		t.Errorf("Email is not resolved to the merged person: %s, %#v.", id, err)
+	}
+}
+
+func TestService_Unlink(t *testing.T) {
+	ctx := context.TODO()
+	s := NewService(sarah.NewMemoryStore(), NewConfig())
+
+	err := s.Unlink(ctx, slackAccount)
+	if !errors.Is(err, ErrNotLinked) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	person, _ := s.Link(ctx, slackAccount, gitterAccount)
+	_, _ = s.LinkEmail(ctx, slackAccount, "alice@example.com")
+
+	err = s.Unlink(ctx, slackAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if id, _ := s.Resolve(ctx, "slack", "U1"); id != "" {
+		t.Errorf("Unlinked account is still resolved: %s.", id)
+	}
+	rest, _ := s.Person(ctx, person.ID)
+	if rest == nil || len(rest.Accounts) != 1 || *rest.Accounts[0] != *gitterAccount {
+		t.Errorf("Unexpected person is left: %#v.", rest)
+	}
+
+	err = s.Unlink(ctx, gitterAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if p, _ := s.Person(ctx, person.ID); p != nil {
+		t.Errorf("Person without account must be removed: %#v.", p)
+	}
+	if id, _ := s.ResolveEmail(ctx, "alice@example.com"); id != "" {
+		t.Errorf("Email of removed person is still resolved: %s.", id)
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	ctx := clock.WithContext(context.Background(), fake)
+	s := NewService(sarah.NewMemoryStore(), NewConfig())
+
+	code, err := s.IssueCode(ctx, slackAccount)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_, err = s.Verify(ctx, slackAccount, code)
+	if !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Code must not be verified by the issuing account: %#v.", err)
+	}
+
+	person, err := s.Verify(ctx, gitterAccount, code)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(person.Accounts) != 2 {
+		t.Errorf("Accounts are not linked: %#v.", person.Accounts)
+	}
+
+	_, err = s.Verify(ctx, lineAccount, code)
+	if !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Code must be consumed: %#v.", err)
+	}
+
+	code, _ = s.IssueCode(ctx, slackAccount)
+	fake.Advance(NewConfig().CodeTTL)
+	_, err = s.Verify(ctx, lineAccount, code)
+	if !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("Expired code must not be verified: %#v.", err)
+	}
+}
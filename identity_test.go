@@ -0,0 +1,83 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveIdentity(t *testing.T) {
+	id, err := ResolveIdentity(context.TODO(), "user")
+	if err != nil || id != "" {
+		t.Errorf("Empty identity must be returned without resolver: %s, %#v.", id, err)
+	}
+
+	ctx := withIdentity(context.TODO(), &identity{
+		botType: "slack",
+		resolver: func(_ context.Context, botType BotType, userID string) (string, error) {
+			if botType != "slack" || userID != "user" {
+				return "", nil
+			}
+			return "person", nil
+		},
+	})
+
+	id, err = ResolveIdentity(ctx, "user")
+	if err != nil || id != "person" {
+		t.Errorf("Unexpected identity is returned: %s, %#v.", id, err)
+	}
+}
+
+func TestUserProfile_FollowsIdentity(t *testing.T) {
+	store := NewMemoryStore()
+	resolver := func(_ context.Context, _ BotType, userID string) (string, error) {
+		switch userID {
+		case "U1", "g1":
+			return "alice", nil
+
+		case "broken":
+			return "", errors.New("broken")
+
+		default:
+			return "", nil
+
+		}
+	}
+	botContext := func(botType BotType) context.Context {
+		ctx := WithStore(context.TODO(), NewNamespacedStore(store, botType.String()))
+		return withIdentity(ctx, &identity{botType: botType, resolver: resolver, store: store})
+	}
+
+	err := SaveUserProfile(botContext("slack"), "U1", &UserProfile{Locale: "ja-JP"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	profile, err := GetUserProfile(botContext("gitter"), "g1")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if profile.Locale != "ja-JP" {
+		t.Errorf("Profile does not follow the person: %#v.", profile)
+	}
+
+	_, err = store.Get(context.TODO(), "person/profile/alice")
+	if err != nil {
+		t.Errorf("Profile is not stored for the person: %s.", err.Error())
+	}
+
+	// A user not linked to any person has a profile for the Bot.
+	err = SaveUserProfile(botContext("slack"), "U2", &UserProfile{Locale: "en-US"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	_, err = store.Get(context.TODO(), "slack/profile/U2")
+	if err != nil {
+		t.Errorf("Profile is not stored for the Bot: %s.", err.Error())
+	}
+
+	_, err = GetUserProfile(botContext("slack"), "broken")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
@@ -1,6 +1,9 @@
 package sarah
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Input defines interface that each incoming message must satisfy.
 // Each Bot/Adapter implementation may define customized Input implementation for each messaging content.
@@ -33,6 +36,106 @@ type Input interface {
 	ReplyTo() OutputDestination
 }
 
+// SenderDisplayNameInput defines an optional interface that an Input implementation may satisfy to provide the sender's human-readable name.
+// Commands may type-assert a given Input to see if such information is available instead of parsing platform-specific payloads.
+//
+//  if named, ok := input.(sarah.SenderDisplayNameInput); ok {
+//    greeting = fmt.Sprintf("Hello, %s", named.SenderDisplayName())
+//  }
+type SenderDisplayNameInput interface {
+	Input
+
+	// SenderDisplayName returns the human-readable name of the sender.
+	SenderDisplayName() string
+}
+
+// SenderIDInput defines an optional interface that an Input implementation may satisfy to tell the identifier of the sender.
+// Unlike SenderKey, this returns the identifier of the user alone without the group/room identifier.
+// go-sarah's core uses this identifier to send a private response with PrivateDestination.
+type SenderIDInput interface {
+	Input
+
+	// SenderID returns the identifier of the sender. e.g. user ID for Slack.
+	SenderID() string
+}
+
+// DirectMessageInput defines an optional interface that an Input implementation may satisfy to tell if the input is sent in a one-to-one conversation.
+type DirectMessageInput interface {
+	Input
+
+	// IsDirectMessage returns true when the input is sent directly to the bot instead of a group or a chat room.
+	IsDirectMessage() bool
+}
+
+// ThreadInput defines an optional interface that an Input implementation may satisfy when the connecting chat service has the concept of message threads.
+type ThreadInput interface {
+	Input
+
+	// ThreadID returns the identifier of the thread that the input belongs to.
+	// This returns an empty string when the input is not sent in a thread.
+	ThreadID() string
+}
+
+// MessageIDInput defines an optional interface that an Input implementation may satisfy to tell the identifier of the message itself.
+// go-sarah's core uses this identifier as the thread ID to start a new thread when BotWithReplyInThread is given.
+type MessageIDInput interface {
+	Input
+
+	// MessageID returns the identifier of the message. e.g. the timestamp of the message for Slack.
+	MessageID() string
+}
+
+// MentionInput defines an optional interface that an Input implementation may satisfy to provide the mentioned users.
+type MentionInput interface {
+	Input
+
+	// Mentions returns the identifiers of the users mentioned in the input.
+	// The form of each identifier depends on the chat service. e.g. user ID for Slack, screen name for gitter.
+	Mentions() []string
+}
+
+// CatchUpInput defines an optional interface that an Input implementation may satisfy to tell if the input is a "catch-up" input.
+// A catch-up input is the message posted while the Adapter's connection was down, which the Adapter fetches after the reconnection
+// and passes to go-sarah's core as usual so the Commands issued during the disconnection are not lost.
+// Use IsCatchUp to see this in a Command since the input may be wrapped by HelpInput or AbortInput.
+type CatchUpInput interface {
+	Input
+
+	// IsCatchUp returns true when the input is fetched after the reconnection instead of being received in real time.
+	IsCatchUp() bool
+}
+
+// IsCatchUp tells if the given Input or the Input wrapped by HelpInput or AbortInput is a catch-up input.
+// A Command may check this to skip a time-sensitive operation for a message posted a while ago.
+func IsCatchUp(input Input) bool {
+	switch typed := input.(type) {
+	case *HelpInput:
+		input = typed.OriginalInput
+
+	case *AbortInput:
+		input = typed.OriginalInput
+
+	}
+
+	catchUp, ok := input.(CatchUpInput)
+	return ok && catchUp.IsCatchUp()
+}
+
+type receivedAtKey struct{}
+
+// withReceivedAt returns a copy of the given context that carries the time go-sarah's core received an Input.
+func withReceivedAt(ctx context.Context, receivedAt time.Time) context.Context {
+	return context.WithValue(ctx, receivedAtKey{}, receivedAt)
+}
+
+// InputReceivedAt returns the time go-sarah's core received the Input that the Command is executed for.
+// The Input is queued to the worker on reception, so the difference from the current time tells how long the Input waited in the queue.
+// This returns false when the given context is not derived from an Input's reception. e.g. a ScheduledTask's context.
+func InputReceivedAt(ctx context.Context) (time.Time, bool) {
+	receivedAt, ok := ctx.Value(receivedAtKey{}).(time.Time)
+	return receivedAt, ok
+}
+
 // NewHelpInput creates a new HelpInput instance with given user input and returns it.
 // This is Bot/Adapter's responsibility to receive an input from user, convert it to sarah.Input and see if the input requests for "help."
 // For example, a slack adapter may check if the given message is equal to :help: emoji.
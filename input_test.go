@@ -87,3 +87,47 @@ func TestNewAbortInput(t *testing.T) {
 		t.Errorf("Original Input value is not set: %#v", abortInput.OriginalInput)
 	}
 }
+
+type DummyCatchUpInput struct {
+	DummyInput
+	CatchUpValue bool
+}
+
+func (i *DummyCatchUpInput) IsCatchUp() bool {
+	return i.CatchUpValue
+}
+
+func TestIsCatchUp(t *testing.T) {
+	catchUp := &DummyCatchUpInput{CatchUpValue: true}
+	tests := []struct {
+		input    Input
+		expected bool
+	}{
+		{
+			input:    &DummyInput{},
+			expected: false,
+		},
+		{
+			input:    &DummyCatchUpInput{CatchUpValue: false},
+			expected: false,
+		},
+		{
+			input:    catchUp,
+			expected: true,
+		},
+		{
+			input:    NewHelpInput(catchUp),
+			expected: true,
+		},
+		{
+			input:    NewAbortInput(catchUp),
+			expected: true,
+		},
+	}
+
+	for i, tt := range tests {
+		if IsCatchUp(tt.input) != tt.expected {
+			t.Errorf("Unexpected result is returned on test #%d.", i)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+)
+
+var (
+	// ErrInputThrottled is returned by InputFilter when the sender sends too many messages.
+	ErrInputThrottled = errors.New("input is throttled")
+
+	// ErrInputBlocked is returned by InputFilter when the sender or the message is not allowed.
+	ErrInputBlocked = errors.New("input is blocked")
+)
+
+// InputFilter defines an interface that inspects each Input before the Input is handled.
+// Register an implementation with RegisterInputFilter to drop spam or abusive messages before any Command is matched.
+// See the inputfilter package for a configurable implementation.
+type InputFilter interface {
+	// FilterInput returns nil to let the Input be handled.
+	// To drop the Input, return an error that wraps ErrInputThrottled or ErrInputBlocked so the corresponding event, InputThrottled or InputBlocked, is published.
+	// Any other error also drops the Input and is reported as InputBlocked.
+	FilterInput(ctx context.Context, botType BotType, input Input) error
+}
+
+// filterInputs returns a function that passes the given Input to the given receiver only when all InputFilters let the Input through.
+// A dropped Input is not an error for the Bot, so nil is returned without calling the receiver.
+func filterInputs(botCtx context.Context, botType BotType, filters []InputFilter, receiver func(Input) error) func(Input) error {
+	if len(filters) == 0 {
+		return receiver
+	}
+
+	return func(input Input) error {
+		for _, filter := range filters {
+			err := filter.FilterInput(botCtx, botType, input)
+			if err == nil {
+				continue
+			}
+
+			contextLogger(botCtx).Debug(
+				"Drop filtered input",
+				logging.F(logging.KeyBotType, botType),
+				logging.F("sender_key", input.SenderKey()),
+				logging.Err(err),
+			)
+			now := clock.FromContext(botCtx).Now()
+			if errors.Is(err, ErrInputThrottled) {
+				PublishEvent(botCtx, &InputThrottled{
+					BotType:   botType,
+					SenderKey: input.SenderKey(),
+					Time:      now,
+				})
+			} else {
+				PublishEvent(botCtx, &InputBlocked{
+					BotType:   botType,
+					SenderKey: input.SenderKey(),
+					Err:       err,
+					Time:      now,
+				})
+			}
+			return nil
+		}
+
+		return receiver(input)
+	}
+}
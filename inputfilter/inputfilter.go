@@ -0,0 +1,182 @@
+/*
+Package inputfilter provides a sarah.InputFilter that drops spam and abusive messages before any Command is matched.
+
+The Filter drops an input when the sender is banned, the message matches a blocked pattern, or the sender sends more messages in a minute than allowed.
+sarah.InputThrottled or sarah.InputBlocked is published for each dropped input so the abuse can be monitored via sarah.RegisterEventSubscriber.
+
+	config := inputfilter.NewConfig()
+	config.BannedUsers = []string{"U12345678"}
+	config.BlockedPatterns = []string{`(?i)buy cheap`}
+	filter, err := inputfilter.New(config)
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterInputFilter(filter)
+*/
+package inputfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// window is the period that Config.MaxMessagesPerMinute is counted in.
+const window = time.Minute
+
+// Config contains some configuration variables for the Filter.
+type Config struct {
+	// MaxMessagesPerMinute is the number of messages a user can send in a minute to each Bot.
+	// Zero disables the rate limit.
+	MaxMessagesPerMinute int `json:"max_messages_per_minute" yaml:"max_messages_per_minute"`
+
+	// BannedUsers are the users whose messages are always dropped.
+	// Each element is compared with sarah.UserID and sarah.Input.SenderKey.
+	BannedUsers []string `json:"banned_users" yaml:"banned_users"`
+
+	// BlockedPatterns are the regular expressions of the messages to be dropped.
+	BlockedPatterns []string `json:"blocked_patterns" yaml:"blocked_patterns"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		MaxMessagesPerMinute: 20,
+		BannedUsers:          []string{},
+		BlockedPatterns:      []string{},
+	}
+}
+
+// Validate checks that MaxMessagesPerMinute is not negative and every BlockedPatterns element is a valid regular expression.
+func (c *Config) Validate() error {
+	var errs sarah.ConfigKeyErrors
+	if c.MaxMessagesPerMinute < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "max_messages_per_minute", Value: fmt.Sprint(c.MaxMessagesPerMinute), Err: errors.New("max_messages_per_minute must not be negative")})
+	}
+	for _, pattern := range c.BlockedPatterns {
+		_, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, &sarah.ConfigKeyError{Key: "blocked_patterns", Value: pattern, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// counter counts the messages a user sent in the current window.
+type counter struct {
+	start time.Time
+	count int
+}
+
+type counterKey struct {
+	botType sarah.BotType
+	userID  string
+}
+
+// Filter is a sarah.InputFilter that drops the inputs as configured with Config.
+type Filter struct {
+	config   *Config
+	banned   map[string]struct{}
+	patterns []*regexp.Regexp
+
+	mutex     sync.Mutex
+	counters  map[counterKey]*counter
+	lastSweep time.Time
+}
+
+var _ sarah.InputFilter = (*Filter)(nil)
+
+// New creates and returns a new Filter with the given Config.
+// An error is returned when the Config is invalid.
+func New(config *Config) (*Filter, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	banned := make(map[string]struct{}, len(config.BannedUsers))
+	for _, user := range config.BannedUsers {
+		banned[user] = struct{}{}
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(config.BlockedPatterns))
+	for _, pattern := range config.BlockedPatterns {
+		patterns = append(patterns, regexp.MustCompile(pattern))
+	}
+
+	return &Filter{
+		config:   config,
+		banned:   banned,
+		patterns: patterns,
+		counters: make(map[counterKey]*counter),
+	}, nil
+}
+
+// FilterInput checks the given sarah.Input against the banned users, the blocked patterns, and the rate limit in this order.
+// An error wrapping sarah.ErrInputBlocked or sarah.ErrInputThrottled is returned when the input must be dropped.
+func (f *Filter) FilterInput(ctx context.Context, botType sarah.BotType, input sarah.Input) error {
+	userID := sarah.UserID(input)
+	if f.isBanned(userID) || f.isBanned(input.SenderKey()) {
+		return fmt.Errorf("user %s is banned: %w", userID, sarah.ErrInputBlocked)
+	}
+
+	message := input.Message()
+	for _, pattern := range f.patterns {
+		if pattern.MatchString(message) {
+			return fmt.Errorf("message matches blocked pattern %s: %w", pattern.String(), sarah.ErrInputBlocked)
+		}
+	}
+
+	if f.config.MaxMessagesPerMinute == 0 {
+		return nil
+	}
+
+	now := clock.FromContext(ctx).Now()
+	key := counterKey{botType: botType, userID: userID}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.sweep(now)
+
+	c, ok := f.counters[key]
+	if !ok || !now.Before(c.start.Add(window)) {
+		f.counters[key] = &counter{start: now, count: 1}
+		return nil
+	}
+
+	if c.count >= f.config.MaxMessagesPerMinute {
+		return fmt.Errorf("user %s sent more than %d messages in a minute: %w", userID, f.config.MaxMessagesPerMinute, sarah.ErrInputThrottled)
+	}
+	c.count++
+	return nil
+}
+
+func (f *Filter) isBanned(user string) bool {
+	_, ok := f.banned[user]
+	return ok
+}
+
+// sweep removes the counters of the expired windows so the users who stopped sending messages do not stay in memory.
+// This runs at most once in a window. The caller must hold the lock.
+func (f *Filter) sweep(now time.Time) {
+	if now.Before(f.lastSweep.Add(window)) {
+		return
+	}
+	f.lastSweep = now
+
+	for key, c := range f.counters {
+		if !now.Before(c.start.Add(window)) {
+			delete(f.counters, key)
+		}
+	}
+}
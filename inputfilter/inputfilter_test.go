@@ -0,0 +1,184 @@
+package inputfilter
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderIDValue string
+	MessageValue  string
+}
+
+var _ sarah.SenderIDInput = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return "channel|" + i.SenderIDValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "channel"
+}
+
+func (i *DummyInput) SenderID() string {
+	return i.SenderIDValue
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.MaxMessagesPerMinute != 20 {
+		t.Errorf("Unexpected default value is set: %d.", config.MaxMessagesPerMinute)
+	}
+	if config.BannedUsers == nil || config.BlockedPatterns == nil {
+		t.Error("Slices must be initialized.")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	err := NewConfig().Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	config := &Config{
+		MaxMessagesPerMinute: -1,
+		BlockedPatterns:      []string{"valid", "(invalid"},
+	}
+	err = config.Validate()
+	errs, ok := err.(sarah.ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Unexpected error is returned: %#v.", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("Unexpected number of errors are returned: %d.", len(errs))
+	}
+	if errs[0].Key != "max_messages_per_minute" || errs[1].Key != "blocked_patterns" {
+		t.Errorf("Unexpected keys are returned: %s, %s.", errs[0].Key, errs[1].Key)
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(&Config{BlockedPatterns: []string{"(invalid"}})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	filter, err := New(NewConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if filter == nil {
+		t.Error("Filter is not returned.")
+	}
+}
+
+func TestFilter_FilterInput_Banned(t *testing.T) {
+	config := NewConfig()
+	config.BannedUsers = []string{"banned", "channel|banned_key"}
+	filter, err := New(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	for _, userID := range []string{"banned", "banned_key"} {
+		err = filter.FilterInput(context.TODO(), "dummy", &DummyInput{SenderIDValue: userID, MessageValue: "hello"})
+		if !errors.Is(err, sarah.ErrInputBlocked) {
+			t.Errorf("Expected error is not returned for %s: %#v.", userID, err)
+		}
+	}
+
+	err = filter.FilterInput(context.TODO(), "dummy", &DummyInput{SenderIDValue: "user", MessageValue: "hello"})
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestFilter_FilterInput_BlockedPattern(t *testing.T) {
+	config := NewConfig()
+	config.BlockedPatterns = []string{`(?i)buy cheap`}
+	filter, err := New(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = filter.FilterInput(context.TODO(), "dummy", &DummyInput{SenderIDValue: "user", MessageValue: "BUY CHEAP watches"})
+	if !errors.Is(err, sarah.ErrInputBlocked) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	err = filter.FilterInput(context.TODO(), "dummy", &DummyInput{SenderIDValue: "user", MessageValue: ".help"})
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestFilter_FilterInput_Throttled(t *testing.T) {
+	fake := clock.NewFake(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	ctx := clock.WithContext(context.Background(), fake)
+
+	filter, err := New(&Config{MaxMessagesPerMinute: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	input := &DummyInput{SenderIDValue: "user", MessageValue: "hello"}
+	for i := 0; i < 2; i++ {
+		err = filter.FilterInput(ctx, "dummy", input)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	err = filter.FilterInput(ctx, "dummy", input)
+	if !errors.Is(err, sarah.ErrInputThrottled) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	// Counted separately for each user and each Bot.
+	err = filter.FilterInput(ctx, "dummy", &DummyInput{SenderIDValue: "other", MessageValue: "hello"})
+	if err != nil {
+		t.Errorf("Unexpected error is returned for other user: %s.", err.Error())
+	}
+	err = filter.FilterInput(ctx, "another", input)
+	if err != nil {
+		t.Errorf("Unexpected error is returned for other bot: %s.", err.Error())
+	}
+
+	fake.Advance(time.Minute)
+	err = filter.FilterInput(ctx, "dummy", input)
+	if err != nil {
+		t.Errorf("Unexpected error is returned after the window: %s.", err.Error())
+	}
+	if len(filter.counters) != 1 {
+		t.Errorf("Expired counters are not swept: %d.", len(filter.counters))
+	}
+}
+
+func TestFilter_FilterInput_Unlimited(t *testing.T) {
+	filter, err := New(&Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	for i := 0; i < 100; i++ {
+		err = filter.FilterInput(context.TODO(), "dummy", &DummyInput{SenderIDValue: "user", MessageValue: "hello"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+	if len(filter.counters) != 0 {
+		t.Errorf("Counters must not be kept when unlimited: %d.", len(filter.counters))
+	}
+}
@@ -0,0 +1,157 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type DummyInputFilter struct {
+	FilterInputFunc func(context.Context, BotType, Input) error
+}
+
+var _ InputFilter = (*DummyInputFilter)(nil)
+
+func (f *DummyInputFilter) FilterInput(ctx context.Context, botType BotType, input Input) error {
+	return f.FilterInputFunc(ctx, botType, input)
+}
+
+func Test_filterInputs(t *testing.T) {
+	t.Run("no filter", func(t *testing.T) {
+		called := false
+		receiver := filterInputs(context.TODO(), "dummy", nil, func(_ Input) error {
+			called = true
+			return nil
+		})
+
+		err := receiver(&DummyInput{})
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+		if !called {
+			t.Error("Receiver is not called.")
+		}
+	})
+
+	tests := []struct {
+		err       error
+		received  bool
+		throttled bool
+		blocked   bool
+	}{
+		{
+			err:      nil,
+			received: true,
+		},
+		{
+			err:       fmt.Errorf("too many: %w", ErrInputThrottled),
+			throttled: true,
+		},
+		{
+			err:     fmt.Errorf("banned: %w", ErrInputBlocked),
+			blocked: true,
+		},
+		{
+			err:     errors.New("unknown"),
+			blocked: true,
+		},
+	}
+
+	for i, tt := range tests {
+		testNo := i + 1
+		t.Run(fmt.Sprintf("test no. %d", testNo), func(t *testing.T) {
+			bus := NewEventBus()
+			var events []Event
+			bus.Subscribe(func(e Event) {
+				events = append(events, e)
+			})
+			ctx := NewEventBusContext(context.TODO(), bus)
+
+			var givenBotType BotType
+			filter := &DummyInputFilter{
+				FilterInputFunc: func(_ context.Context, botType BotType, _ Input) error {
+					givenBotType = botType
+					return tt.err
+				},
+			}
+			received := false
+			receiver := filterInputs(ctx, "dummy", []InputFilter{filter}, func(_ Input) error {
+				received = true
+				return nil
+			})
+
+			err := receiver(&DummyInput{SenderKeyValue: "sender"})
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+
+			if givenBotType != "dummy" {
+				t.Errorf("Unexpected BotType is given: %s.", givenBotType)
+			}
+
+			if received != tt.received {
+				t.Errorf("Unexpected receiver call: %t.", received)
+			}
+
+			if tt.received {
+				if len(events) != 0 {
+					t.Errorf("Unexpected events are published: %#v.", events)
+				}
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("Unexpected number of events are published: %d.", len(events))
+			}
+
+			switch e := events[0].(type) {
+			case *InputThrottled:
+				if !tt.throttled {
+					t.Errorf("Unexpected event is published: %#v.", e)
+				}
+				if e.SenderKey != "sender" || e.BotType != "dummy" {
+					t.Errorf("Unexpected event values: %#v.", e)
+				}
+
+			case *InputBlocked:
+				if !tt.blocked {
+					t.Errorf("Unexpected event is published: %#v.", e)
+				}
+				if e.SenderKey != "sender" || e.BotType != "dummy" || e.Err != tt.err {
+					t.Errorf("Unexpected event values: %#v.", e)
+				}
+
+			default:
+				t.Errorf("Unexpected event is published: %#v.", e)
+
+			}
+		})
+	}
+}
+
+func Test_filterInputs_StopsAtFirstRejection(t *testing.T) {
+	var calls []string
+	first := &DummyInputFilter{
+		FilterInputFunc: func(_ context.Context, _ BotType, _ Input) error {
+			calls = append(calls, "first")
+			return ErrInputBlocked
+		},
+	}
+	second := &DummyInputFilter{
+		FilterInputFunc: func(_ context.Context, _ BotType, _ Input) error {
+			calls = append(calls, "second")
+			return nil
+		},
+	}
+	receiver := filterInputs(context.TODO(), "dummy", []InputFilter{first, second}, func(_ Input) error {
+		t.Error("Receiver must not be called.")
+		return nil
+	})
+
+	_ = receiver(&DummyInput{})
+
+	if len(calls) != 1 || calls[0] != "first" {
+		t.Errorf("Unexpected filter calls: %#v.", calls)
+	}
+}
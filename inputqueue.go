@@ -0,0 +1,365 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// InputQueuePolicy defines how the input queue of a Bot behaves when an Input comes while the queue is full.
+type InputQueuePolicy string
+
+const (
+	// InputQueueDropNewest drops the incoming Input and returns BlockedInputError to the Bot/Adapter.
+	InputQueueDropNewest InputQueuePolicy = "drop_newest"
+
+	// InputQueueDropOldest drops the oldest Input in the queue to make room for the incoming one.
+	InputQueueDropOldest InputQueuePolicy = "drop_oldest"
+
+	// InputQueueBlock blocks the Bot/Adapter til the queue has a room for the incoming Input.
+	// When InputQueueConfig.BlockTimeout is set and the Input still can not be queued within that period, the Input is dropped.
+	InputQueueBlock InputQueuePolicy = "block"
+)
+
+// InputQueueConfig contains some configuration variables for the queue that buffers the Inputs of a Bot before they are passed to the worker.
+//
+// Without the queue, the Bot/Adapter passes each Input to the worker in its receiving loop such as a WebSocket read loop.
+// When the worker's queue is full, the Input is rejected or, with workers.OverflowBlock, the loop is blocked and the chat service may disconnect the Bot.
+// The input queue lets the loop return right away; the Inputs wait in the queue while the worker is busy, and the policy tells what to do when the queue is full.
+type InputQueueConfig struct {
+	Size         uint             `json:"size" yaml:"size"`
+	Policy       InputQueuePolicy `json:"policy" yaml:"policy"`
+	BlockTimeout time.Duration    `json:"block_timeout" yaml:"block_timeout"`
+}
+
+// NewInputQueueConfig creates and returns new InputQueueConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewInputQueueConfig() *InputQueueConfig {
+	return &InputQueueConfig{
+		Size:         100,
+		Policy:       InputQueueDropNewest,
+		BlockTimeout: 0,
+	}
+}
+
+var _ ConfigDefaulter = (*InputQueueConfig)(nil)
+var _ ConfigValidator = (*InputQueueConfig)(nil)
+
+// ApplyDefaults sets InputQueueDropNewest when Policy is not set.
+func (c *InputQueueConfig) ApplyDefaults() {
+	if c.Policy == "" {
+		c.Policy = InputQueueDropNewest
+	}
+}
+
+// Validate checks if Size is positive and Policy is a known one.
+func (c *InputQueueConfig) Validate() error {
+	var errs ConfigKeyErrors
+	if c.Size == 0 {
+		errs = append(errs, &ConfigKeyError{Key: "size", Value: "0", Err: errors.New("size must be greater than zero")})
+	}
+
+	switch c.Policy {
+	case "", InputQueueDropNewest, InputQueueDropOldest, InputQueueBlock:
+		// O.K.
+
+	default:
+		errs = append(errs, &ConfigKeyError{Key: "policy", Value: string(c.Policy), Err: errors.New("unknown policy is given")})
+
+	}
+
+	if c.BlockTimeout < 0 {
+		errs = append(errs, &ConfigKeyError{Key: "block_timeout", Value: c.BlockTimeout.String(), Err: errors.New("block timeout must not be negative")})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// InputQueueStats represents the statistics of the input queue of a running Bot.
+type InputQueueStats struct {
+	BotType BotType
+	Size    int
+	Length  int
+
+	// HighWaterMark is the largest number of the Inputs that waited in the queue at the same time.
+	// A value close to Size tells the queue is too small for the bursts or the worker is too slow.
+	HighWaterMark int
+
+	Received uint64
+	Dropped  uint64
+}
+
+// ListInputQueues returns the statistics of the input queues of the running Bots sorted by BotType.
+// A Bot without an input queue is not included. See Config.InputQueue.
+func ListInputQueues() []*InputQueueStats {
+	return runnerStatus.components.listInputQueues()
+}
+
+// inputQueue buffers the Inputs of a Bot and passes them to the next receiver in its own goroutine.
+type inputQueue struct {
+	botType       BotType
+	config        *InputQueueConfig
+	inputs        chan Input
+	evict         sync.Mutex // Serializes the eviction of InputQueueDropOldest
+	received      uint64
+	dropped       uint64
+	highWaterMark int64
+	blocked       int64 // The number of the continuous drops to tell BlockedInputError.ContinuationCount
+}
+
+func newInputQueue(botType BotType, config *InputQueueConfig) *inputQueue {
+	return &inputQueue{
+		botType: botType,
+		config:  config,
+		inputs:  make(chan Input, config.Size),
+	}
+}
+
+// receiver returns a function that queues the given Input as the policy tells.
+func (q *inputQueue) receiver(ctx context.Context) func(Input) error {
+	return func(input Input) error {
+		atomic.AddUint64(&q.received, 1)
+
+		var queued bool
+		switch q.config.Policy {
+		case InputQueueDropOldest:
+			queued = q.pushDroppingOldest(ctx, input)
+
+		case InputQueueBlock:
+			queued = q.pushBlocking(ctx, input)
+
+		default:
+			select {
+			case q.inputs <- input:
+				queued = true
+
+			default:
+				// Full
+
+			}
+
+		}
+
+		if !queued {
+			q.drop(ctx, input)
+			return NewBlockedInputError(int(atomic.AddInt64(&q.blocked, 1)))
+		}
+
+		atomic.StoreInt64(&q.blocked, 0)
+		q.mark()
+		return nil
+	}
+}
+
+func (q *inputQueue) pushDroppingOldest(ctx context.Context, input Input) bool {
+	q.evict.Lock()
+	defer q.evict.Unlock()
+
+	for {
+		select {
+		case q.inputs <- input:
+			return true
+
+		default:
+			select {
+			case oldest := <-q.inputs:
+				q.drop(ctx, oldest)
+
+			default:
+				// The dispatcher took one in the meantime. Try again.
+
+			}
+
+		}
+	}
+}
+
+func (q *inputQueue) pushBlocking(ctx context.Context, input Input) bool {
+	var timeout <-chan time.Time
+	if q.config.BlockTimeout > 0 {
+		timer := clock.FromContext(ctx).NewTimer(q.config.BlockTimeout)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+
+	select {
+	case q.inputs <- input:
+		return true
+
+	case <-timeout:
+		return false
+
+	case <-ctx.Done():
+		return false
+
+	}
+}
+
+// mark updates the high-water mark with the current length of the queue.
+func (q *inputQueue) mark() {
+	length := int64(len(q.inputs))
+	for {
+		current := atomic.LoadInt64(&q.highWaterMark)
+		if length <= current || atomic.CompareAndSwapInt64(&q.highWaterMark, current, length) {
+			return
+		}
+	}
+}
+
+func (q *inputQueue) drop(ctx context.Context, input Input) {
+	atomic.AddUint64(&q.dropped, 1)
+	contextLogger(ctx).Warn(
+		"Drop input due to full input queue",
+		logging.F(logging.KeyBotType, q.botType),
+		logging.F("sender_key", input.SenderKey()),
+		logging.F("policy", q.config.Policy),
+	)
+	PublishEvent(ctx, &InputDropped{
+		BotType:   q.botType,
+		SenderKey: input.SenderKey(),
+		Policy:    q.config.Policy,
+		Time:      clock.FromContext(ctx).Now(),
+	})
+}
+
+// run passes the queued Inputs to the given receiver til the given context is canceled.
+// When the receiver rejects an Input because the worker is busy, the Input is passed again after a while so the Inputs wait in this queue instead.
+func (q *inputQueue) run(ctx context.Context, receive func(Input) error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case input := <-q.inputs:
+			q.dispatch(ctx, input, receive)
+
+		}
+	}
+}
+
+func (q *inputQueue) dispatch(ctx context.Context, input Input, receive func(Input) error) {
+	interval := 10 * time.Millisecond
+	for {
+		err := receive(input)
+		var blocked *BlockedInputError
+		if !errors.As(err, &blocked) {
+			if err != nil {
+				contextLogger(ctx).Error("Failed to handle queued input", logging.F("sender_key", input.SenderKey()), logging.Err(err))
+			}
+			return
+		}
+
+		timer := clock.FromContext(ctx).NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+
+		case <-timer.C():
+			if interval < time.Second {
+				interval *= 2
+			}
+
+		}
+	}
+}
+
+func (q *inputQueue) stats() *InputQueueStats {
+	return &InputQueueStats{
+		BotType:       q.botType,
+		Size:          cap(q.inputs),
+		Length:        len(q.inputs),
+		HighWaterMark: int(atomic.LoadInt64(&q.highWaterMark)),
+		Received:      atomic.LoadUint64(&q.received),
+		Dropped:       atomic.LoadUint64(&q.dropped),
+	}
+}
+
+// queueInputs returns a receiver that queues the Inputs with the input queue configured for the given Bot,
+// and starts passing the queued Inputs to the given receiver.
+// The given receiver is returned as-is when no input queue is configured.
+func (r *runner) queueInputs(botCtx context.Context, botType BotType, receive func(Input) error) func(Input) error {
+	config := r.inputQueueConfig(botType)
+	if config == nil {
+		return receive
+	}
+
+	q := newInputQueue(botType, config)
+	runnerStatus.components.inputQueue(botType, q)
+	go q.run(botCtx, receive)
+	return q.receiver(botCtx)
+}
+
+func (r *runner) inputQueueConfig(botType BotType) *InputQueueConfig {
+	if r.config == nil {
+		return nil
+	}
+	if config, ok := r.config.BotInputQueues[botType]; ok {
+		return config
+	}
+	return r.config.InputQueue
+}
+
+// inputQueue records the input queue of the Bot with the given BotType.
+func (m *managedComponents) inputQueue(botType BotType, q *inputQueue) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.inputQueues == nil {
+		m.inputQueues = map[BotType]*inputQueue{}
+	}
+	m.inputQueues[botType] = q
+}
+
+func (m *managedComponents) inputQueueStats(botType BotType) *InputQueueStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	q, ok := m.inputQueues[botType]
+	if !ok {
+		return nil
+	}
+	return q.stats()
+}
+
+func (m *managedComponents) listInputQueues() []*InputQueueStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var stats []*InputQueueStats
+	for _, q := range m.inputQueues {
+		stats = append(stats, q.stats())
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].BotType < stats[j].BotType
+	})
+	return stats
+}
+
+func validateBotInputQueues(queues map[BotType]*InputQueueConfig) ConfigKeyErrors {
+	var errs ConfigKeyErrors
+	for botType, config := range queues {
+		path := []string{"bot_input_queues", botType.String()}
+		if config == nil {
+			errs = append(errs, prefixConfigKeyErrors(path, errors.New("input queue is not given"))...)
+			continue
+		}
+		config.ApplyDefaults()
+		err := config.Validate()
+		if err != nil {
+			errs = append(errs, prefixConfigKeyErrors(path, err)...)
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Key < errs[j].Key
+	})
+	return errs
+}
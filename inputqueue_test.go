@@ -0,0 +1,248 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewInputQueueConfig(t *testing.T) {
+	config := NewInputQueueConfig()
+
+	if config.Size == 0 {
+		t.Error("Default size is not set.")
+	}
+	if config.Policy != InputQueueDropNewest {
+		t.Errorf("Unexpected default policy is set: %s.", config.Policy)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Default configuration must be valid: %s.", err.Error())
+	}
+}
+
+func TestInputQueueConfig_ApplyDefaults(t *testing.T) {
+	config := &InputQueueConfig{}
+	config.ApplyDefaults()
+
+	if config.Policy != InputQueueDropNewest {
+		t.Errorf("Default policy is not set: %s.", config.Policy)
+	}
+}
+
+func TestConfig_Validate_WithInputQueues(t *testing.T) {
+	config := &Config{
+		TimeZone:   time.UTC.String(),
+		InputQueue: &InputQueueConfig{Size: 0},
+		BotInputQueues: map[BotType]*InputQueueConfig{
+			"slack":  {Size: 10, Policy: "unknown", BlockTimeout: -1},
+			"gitter": nil,
+			"github": {Size: 10},
+		},
+	}
+
+	err := ValidateConfig(config)
+
+	var errs ConfigKeyErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+	expected := []string{"input_queue.size", "bot_input_queues.gitter", "bot_input_queues.slack.block_timeout", "bot_input_queues.slack.policy"}
+	if len(errs) != len(expected) {
+		t.Fatalf("Unexpected errors are returned: %s.", err.Error())
+	}
+	for i, key := range expected {
+		if errs[i].Key != key {
+			t.Errorf("Expected key %s is not returned: %s.", key, errs[i].Key)
+		}
+	}
+
+	if config.BotInputQueues["github"].Policy != InputQueueDropNewest {
+		t.Errorf("Default policy is not applied: %s.", config.BotInputQueues["github"].Policy)
+	}
+}
+
+func Test_inputQueue_receiver(t *testing.T) {
+	tests := []struct {
+		policy    InputQueuePolicy
+		err       bool
+		remaining []string
+	}{
+		{
+			policy:    InputQueueDropNewest,
+			err:       true,
+			remaining: []string{"U1", "U2"},
+		},
+		{
+			policy:    InputQueueDropOldest,
+			err:       false,
+			remaining: []string{"U2", "U3"},
+		},
+		{
+			policy:    InputQueueBlock,
+			err:       true,
+			remaining: []string{"U1", "U2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			bus := NewEventBus()
+			var dropped []string
+			bus.Subscribe(func(e Event) {
+				if typed, ok := e.(*InputDropped); ok {
+					if typed.Policy != tt.policy {
+						t.Errorf("Unexpected policy is given: %s.", typed.Policy)
+					}
+					dropped = append(dropped, typed.SenderKey)
+				}
+			})
+			ctx := NewEventBusContext(context.Background(), bus)
+
+			q := newInputQueue("dummy", &InputQueueConfig{Size: 2, Policy: tt.policy, BlockTimeout: 10 * time.Millisecond})
+			receive := q.receiver(ctx)
+
+			for _, key := range []string{"U1", "U2"} {
+				if err := receive(&DummyInput{SenderKeyValue: key}); err != nil {
+					t.Fatalf("Unexpected error is returned: %s.", err.Error())
+				}
+			}
+
+			err := receive(&DummyInput{SenderKeyValue: "U3"})
+			if tt.err {
+				var blocked *BlockedInputError
+				if !errors.As(err, &blocked) || blocked.ContinuationCount != 1 {
+					t.Errorf("Expected error is not returned: %#v.", err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error is returned: %s.", err.Error())
+			}
+
+			if len(dropped) != 1 {
+				t.Errorf("Unexpected drops: %#v.", dropped)
+			}
+
+			for _, key := range tt.remaining {
+				input := <-q.inputs
+				if input.SenderKey() != key {
+					t.Errorf("Unexpected input is queued: %s.", input.SenderKey())
+				}
+			}
+
+			stats := q.stats()
+			if stats.Size != 2 || stats.Length != 0 || stats.HighWaterMark != 2 || stats.Received != 3 || stats.Dropped != 1 {
+				t.Errorf("Unexpected stats are returned: %#v.", stats)
+			}
+		})
+	}
+}
+
+func Test_inputQueue_receiver_Block(t *testing.T) {
+	q := newInputQueue("dummy", &InputQueueConfig{Size: 1, Policy: InputQueueBlock})
+	receive := q.receiver(context.Background())
+	_ = receive(&DummyInput{SenderKeyValue: "U1"})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- receive(&DummyInput{SenderKeyValue: "U2"})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Input must wait for the room.")
+
+	case <-time.After(10 * time.Millisecond):
+		// O.K.
+
+	}
+
+	<-q.inputs
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+
+	case <-time.After(time.Second):
+		t.Error("Input is not queued.")
+
+	}
+}
+
+func Test_inputQueue_run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := newInputQueue("dummy", &InputQueueConfig{Size: 10, Policy: InputQueueDropNewest})
+	received := make(chan string, 10)
+	attempts := 0
+	go q.run(ctx, func(input Input) error {
+		attempts++
+		if attempts == 1 {
+			// The worker is busy for the first time.
+			return NewBlockedInputError(1)
+		}
+		received <- input.SenderKey()
+		return errors.New("ignored error")
+	})
+
+	receive := q.receiver(ctx)
+	_ = receive(&DummyInput{SenderKeyValue: "U1"})
+	_ = receive(&DummyInput{SenderKeyValue: "U2"})
+
+	for _, expected := range []string{"U1", "U2"} {
+		select {
+		case key := <-received:
+			if key != expected {
+				t.Errorf("Unexpected input is passed: %s.", key)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatal("Queued input is not passed.")
+
+		}
+	}
+}
+
+func Test_runner_queueInputs(t *testing.T) {
+	SetupAndRun(func() {
+		r := &runner{
+			config: &Config{
+				InputQueue: &InputQueueConfig{Size: 10},
+				BotInputQueues: map[BotType]*InputQueueConfig{
+					"slack": {Size: 20},
+				},
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		receive := func(_ Input) error {
+			return nil
+		}
+
+		_ = r.queueInputs(ctx, "gitter", receive)
+		_ = r.queueInputs(ctx, "slack", receive)
+
+		stats := ListInputQueues()
+		if len(stats) != 2 {
+			t.Fatalf("Unexpected stats are returned: %#v.", stats)
+		}
+		if stats[0].BotType != "gitter" || stats[0].Size != 10 {
+			t.Errorf("Global configuration is not applied: %#v.", stats[0])
+		}
+		if stats[1].BotType != "slack" || stats[1].Size != 20 {
+			t.Errorf("Bot configuration is not applied: %#v.", stats[1])
+		}
+
+		r.config = &Config{}
+		_ = r.queueInputs(ctx, "github", receive)
+		if runnerStatus.components.inputQueueStats("github") != nil {
+			t.Error("Input queue must not be set up without configuration.")
+		}
+
+		runnerStatus.components.removeBot("slack")
+		if len(ListInputQueues()) != 1 {
+			t.Error("Input queue of stopped bot must not be listed.")
+		}
+	})
+}
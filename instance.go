@@ -0,0 +1,86 @@
+package sarah
+
+import (
+	"reflect"
+)
+
+// instanceCommandProps returns a copy of the given CommandProps for the Bot instance with the given BotType.
+// The configuration value is also copied so each instance reads its own configuration into its own value.
+func instanceCommandProps(props *CommandProps, botType BotType) *CommandProps {
+	copied := *props
+	copied.botType = botType
+	copied.config = cloneConfig(props.config)
+	return &copied
+}
+
+// instanceScheduledTaskProps returns a copy of the given ScheduledTaskProps for the Bot instance with the given BotType.
+func instanceScheduledTaskProps(props *ScheduledTaskProps, botType BotType) *ScheduledTaskProps {
+	copied := *props
+	copied.botType = botType
+	copied.config = cloneConfig(props.config)
+	return &copied
+}
+
+// cloneConfig returns a deep copy of the given configuration value.
+// Pointers, maps and slices are copied so decoding into the copy does not modify the original;
+// the unexported fields of a struct are copied as-is since they can not be set via reflection.
+func cloneConfig(config interface{}) interface{} {
+	if config == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(config)).Interface()
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Elem().Type())
+		copied.Elem().Set(cloneValue(v.Elem()))
+		return copied
+
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return copied
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			copied.SetMapIndex(key, cloneValue(v.MapIndex(key)))
+		}
+		return copied
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return copied
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(cloneValue(v.Elem()))
+		return copied
+
+	default:
+		return v
+
+	}
+}
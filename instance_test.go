@@ -0,0 +1,78 @@
+package sarah
+
+import (
+	"testing"
+)
+
+type cloneableConfig struct {
+	Token    string
+	Nested   *cloneableConfig
+	Tags     []string
+	Channels map[string]string
+	private  *string
+}
+
+func Test_cloneConfig(t *testing.T) {
+	private := "private"
+	original := &cloneableConfig{
+		Token:    "token",
+		Nested:   &cloneableConfig{Token: "nested"},
+		Tags:     []string{"foo"},
+		Channels: map[string]string{"general": "C123"},
+		private:  &private,
+	}
+
+	cloned, ok := cloneConfig(original).(*cloneableConfig)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", cloneConfig(original))
+	}
+
+	if cloned == original || cloned.Nested == original.Nested {
+		t.Fatal("Pointers are not copied.")
+	}
+
+	cloned.Token = "updated"
+	cloned.Nested.Token = "updated"
+	cloned.Tags[0] = "updated"
+	cloned.Channels["general"] = "updated"
+
+	if original.Token != "token" || original.Nested.Token != "nested" || original.Tags[0] != "foo" || original.Channels["general"] != "C123" {
+		t.Errorf("Original value is modified: %#v.", original)
+	}
+
+	if cloned.private != original.private {
+		t.Error("Unexported field must be copied as-is.")
+	}
+
+	if cloneConfig(nil) != nil {
+		t.Error("nil must be returned for nil.")
+	}
+
+	value := cloneableConfig{Token: "value"}
+	if clonedValue, ok := cloneConfig(value).(cloneableConfig); !ok || clonedValue.Token != "value" {
+		t.Errorf("Unexpected value is returned: %#v.", cloneConfig(value))
+	}
+}
+
+func Test_instanceScheduledTaskProps(t *testing.T) {
+	props := &ScheduledTaskProps{
+		botType:    "slack",
+		identifier: "task",
+		schedule:   "@daily",
+		config:     &cloneableConfig{Token: "token"},
+	}
+
+	instance := instanceScheduledTaskProps(props, BotType("slack").Instance("a"))
+
+	if instance.botType != "slack/a" || instance.identifier != "task" || instance.schedule != "@daily" {
+		t.Errorf("Unexpected props are returned: %#v.", instance)
+	}
+
+	if instance.config == props.config {
+		t.Error("Config must be copied.")
+	}
+
+	if props.botType != "slack" {
+		t.Error("Original props are modified.")
+	}
+}
@@ -0,0 +1,96 @@
+package sarah
+
+import (
+	"context"
+)
+
+// Intent represents what a user means by a free-form sentence, as an intent name along with the entities extracted from the sentence.
+// e.g. "Book a table for 2 at 7pm" may be mapped to an intent named "book_table" with "people" and "time" entities.
+type Intent struct {
+	// Name is the name of the detected intent.
+	Name string
+
+	// Confidence is the score of the detection that the IntentMatcher reports, typically between 0 and 1.
+	Confidence float64
+
+	// Entities are the structured arguments extracted from the sentence.
+	Entities []*IntentEntity
+}
+
+// IntentEntity represents one entity extracted from a sentence.
+type IntentEntity struct {
+	// Name is the name of the entity such as "time" or "city."
+	Name string
+
+	// Value is the value of the entity. Some backends normalize this, so this may differ from the original text.
+	Value string
+}
+
+// Entity returns the value of the first entity with the given name.
+// An empty string is returned when no such entity is extracted.
+func (intent *Intent) Entity(name string) string {
+	for _, entity := range intent.Entities {
+		if entity.Name == name {
+			return entity.Value
+		}
+	}
+	return ""
+}
+
+// IntentMatcher defines an interface that maps a free-form sentence to an Intent.
+// An implementation typically calls an NLU backend such as Rasa or Dialogflow, or compares the sentence with example sentences locally.
+// The nlu package provides some implementations.
+type IntentMatcher interface {
+	// MatchIntent returns the Intent of the given Input.
+	// nil is returned when no intent is detected with enough confidence.
+	MatchIntent(ctx context.Context, input Input) (*Intent, error)
+}
+
+// IntentCommand defines an optional interface that a Command may satisfy to be executed when the IntentMatcher detects a particular intent.
+// A Command built with CommandPropsBuilder.MatchIntent satisfies this.
+type IntentCommand interface {
+	Command
+
+	// IntentName returns the name of the intent that this Command handles.
+	// An empty string means this Command does not handle any intent.
+	IntentName() string
+}
+
+type intentKey struct{}
+
+// WithIntent returns a copy of the given context that carries the given Intent.
+// go-sarah's core calls this before executing an IntentCommand so the Command can refer to the extracted entities with IntentFromContext.
+func WithIntent(ctx context.Context, intent *Intent) context.Context {
+	return context.WithValue(ctx, intentKey{}, intent)
+}
+
+// IntentFromContext returns the Intent carried by the given context.
+// This returns false when the Command is not executed for a detected intent.
+//
+//  intent, ok := sarah.IntentFromContext(ctx)
+//  if ok {
+//    city := intent.Entity("city")
+//  }
+func IntentFromContext(ctx context.Context) (*Intent, bool) {
+	intent, ok := ctx.Value(intentKey{}).(*Intent)
+	return intent, ok && intent != nil
+}
+
+// FindIntentMatched looks for the first IntentCommand that handles the intent with the given name.
+// Like FindFirstMatched, this check is run in the order of Command registration.
+func (commands *Commands) FindIntentMatched(name string) Command {
+	if name == "" {
+		return nil
+	}
+
+	commands.mutex.RLock()
+	defer commands.mutex.RUnlock()
+
+	for _, command := range commands.collection {
+		if c, ok := command.(IntentCommand); ok && c.IntentName() == name {
+			return command
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package sarah
+
+import (
+	"context"
+	"testing"
+)
+
+type DummyIntentMatcher struct {
+	MatchIntentFunc func(context.Context, Input) (*Intent, error)
+}
+
+func (m *DummyIntentMatcher) MatchIntent(ctx context.Context, input Input) (*Intent, error) {
+	return m.MatchIntentFunc(ctx, input)
+}
+
+type DummyIntentCommand struct {
+	DummyCommand
+	IntentNameValue string
+}
+
+func (command *DummyIntentCommand) IntentName() string {
+	return command.IntentNameValue
+}
+
+func TestIntent_Entity(t *testing.T) {
+	intent := &Intent{
+		Name: "book_table",
+		Entities: []*IntentEntity{
+			{Name: "people", Value: "2"},
+			{Name: "time", Value: "7pm"},
+			{Name: "time", Value: "8pm"},
+		},
+	}
+
+	if intent.Entity("time") != "7pm" {
+		t.Errorf("Unexpected value is returned: %s.", intent.Entity("time"))
+	}
+
+	if intent.Entity("missing") != "" {
+		t.Errorf("Unexpected value is returned: %s.", intent.Entity("missing"))
+	}
+}
+
+func TestIntentFromContext(t *testing.T) {
+	_, ok := IntentFromContext(context.TODO())
+	if ok {
+		t.Error("Intent must not be returned from a plain context.")
+	}
+
+	intent := &Intent{Name: "greet"}
+	given, ok := IntentFromContext(WithIntent(context.TODO(), intent))
+	if !ok {
+		t.Fatal("Intent is not returned.")
+	}
+
+	if given != intent {
+		t.Errorf("Unexpected intent is returned: %#v.", given)
+	}
+}
+
+func TestCommands_FindIntentMatched(t *testing.T) {
+	commands := NewCommands()
+	commands.Append(&DummyCommand{IdentifierValue: "plain"})
+	commands.Append(&DummyIntentCommand{DummyCommand: DummyCommand{IdentifierValue: "none"}})
+	commands.Append(&DummyIntentCommand{DummyCommand: DummyCommand{IdentifierValue: "greet"}, IntentNameValue: "greet"})
+
+	command := commands.FindIntentMatched("greet")
+	if command == nil || command.Identifier() != "greet" {
+		t.Errorf("Unexpected command is returned: %#v.", command)
+	}
+
+	if commands.FindIntentMatched("unknown") != nil {
+		t.Error("Command must not be returned for unknown intent.")
+	}
+
+	if commands.FindIntentMatched("") != nil {
+		t.Error("Command must not be returned for empty intent.")
+	}
+}
@@ -0,0 +1,282 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrStoreKeyNotFound is returned by Store.Get when the given key does not exist.
+	ErrStoreKeyNotFound = errors.New("key is not found in the store")
+
+	// ErrStoreNotAvailable is returned by StoreFromContext when no Store is registered with RegisterStore
+	// or the given context is not the one go-sarah's core passes to Command and ScheduledTask.
+	ErrStoreNotAvailable = errors.New("store is not available")
+)
+
+// storeNamespaceSeparator separates the namespaces and the key.
+const storeNamespaceSeparator = "/"
+
+// Store defines an interface of a key-value storage that plugins use to persist their states,
+// so each stateful plugin does not have to invent its own persistence.
+//
+// Register an implementation with RegisterStore, and obtain the Store for a plugin with StoreFromContext in Command.Execute or in ScheduledTask's function.
+// The keys are namespaced per Bot and per plugin, so a plugin can use any key without conflicting with other plugins or other Bots.
+// NewMemoryStore and NewFileStore provide in-memory and file implementations, and the sub-packages of kvstore provide SQL, bbolt and Redis implementations.
+type Store interface {
+	// Get returns the value of the given key. ErrStoreKeyNotFound is returned when the key does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the given value with the given key. An existing value is overwritten.
+	Set(ctx context.Context, key string, value []byte) error
+
+	// Delete removes the given key. No error is returned when the key does not exist.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys that start with the given prefix in ascending order.
+	// An empty prefix returns all keys.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// NewNamespacedStore returns a Store that prepends the given namespaces to the keys and passes them to the given Store.
+// The keys List returns are stripped of the namespaces.
+// go-sarah's core uses this to separate the keys per Bot and per plugin; see StoreFromContext.
+func NewNamespacedStore(store Store, namespaces ...string) Store {
+	prefix := strings.Join(namespaces, storeNamespaceSeparator) + storeNamespaceSeparator
+	if typed, ok := store.(*namespacedStore); ok {
+		// Flatten so the nested namespaces do not stack the wrappers.
+		return &namespacedStore{
+			store:  typed.store,
+			prefix: typed.prefix + prefix,
+		}
+	}
+
+	return &namespacedStore{
+		store:  store,
+		prefix: prefix,
+	}
+}
+
+type namespacedStore struct {
+	store  Store
+	prefix string
+}
+
+var _ Store = (*namespacedStore)(nil)
+
+func (s *namespacedStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.store.Get(ctx, s.prefix+key)
+}
+
+func (s *namespacedStore) Set(ctx context.Context, key string, value []byte) error {
+	return s.store.Set(ctx, s.prefix+key, value)
+}
+
+func (s *namespacedStore) Delete(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, s.prefix+key)
+}
+
+func (s *namespacedStore) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := s.store.List(ctx, s.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	stripped := make([]string, 0, len(keys))
+	for _, key := range keys {
+		stripped = append(stripped, strings.TrimPrefix(key, s.prefix))
+	}
+	return stripped, nil
+}
+
+// NewMemoryStore creates and returns a Store that keeps the values in memory.
+// The values are lost when the process stops, so this is suitable for development and tests.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		values: map[string][]byte{},
+	}
+}
+
+type memoryStore struct {
+	values map[string][]byte
+	mutex  sync.RWMutex
+}
+
+var _ Store = (*memoryStore)(nil)
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	value, ok := s.values[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStoreKeyNotFound, key)
+	}
+	// Copy so a caller's modification does not change the stored value.
+	return append([]byte{}, value...), nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values[key] = append([]byte{}, value...)
+	return nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.values, key)
+	return nil
+}
+
+func (s *memoryStore) List(_ context.Context, prefix string) ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	keys := []string{}
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// fileStoreExt is the extension of the files that fileStore stores the values in.
+const fileStoreExt = ".value"
+
+// NewFileStore creates and returns a Store that stores each value as a file in the given directory.
+// The directory is created when it does not exist.
+// A value is written to a temporary file first and then renamed, so a crash never leaves a broken value to be read.
+//
+// Each key is escaped to be the file name, so a key must be short enough for the file system's limit on a file name,
+// and keys that differ only in case conflict on a case-insensitive file system.
+// This suits a single Bot process; use the SQL or Redis implementation to share the values among multiple processes.
+func NewFileStore(dir string) (Store, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	return &fileStore{
+		dir: dir,
+	}, nil
+}
+
+type fileStore struct {
+	dir   string
+	mutex sync.RWMutex
+}
+
+var _ Store = (*fileStore)(nil)
+
+func (s *fileStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+fileStoreExt)
+}
+
+func (s *fileStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	value, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrStoreKeyNotFound, key)
+	}
+	return value, err
+}
+
+func (s *fileStore) Set(_ context.Context, key string, value []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := s.path(key)
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, value, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *fileStore) Delete(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := os.Remove(s.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) List(_ context.Context, prefix string) ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, file := range files {
+		// Skip the temporary files and the files that are not created by this Store.
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileStoreExt) {
+			continue
+		}
+
+		key, err := url.PathUnescape(strings.TrimSuffix(file.Name(), fileStoreExt))
+		if err != nil {
+			continue
+		}
+
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+type storeKey struct{}
+
+// WithStore returns a copy of the given context that carries the given Store.
+// go-sarah's core passes a context with the Store namespaced by the BotType to Bot, Command and ScheduledTask,
+// so this is mainly for a plugin's test to give a Store to the plugin.
+func WithStore(ctx context.Context, store Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// StoreFromContext returns the Store for the plugin with the given identifier.
+// Pass the context given to Command.Execute, ContextualFunc or ScheduledTask's function.
+// The returned Store is namespaced by the BotType and the given identifier, so the same key is separated per Bot and per plugin:
+//
+//  func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//  	store, err := sarah.StoreFromContext(ctx, "counter")
+//  	if err != nil {
+//  		return nil, err
+//  	}
+//  	err = store.Set(ctx, input.SenderKey(), []byte("1"))
+//  	...
+//  }
+//
+// ErrStoreNotAvailable is returned when no Store is registered with RegisterStore.
+func StoreFromContext(ctx context.Context, plugin string) (Store, error) {
+	store, ok := ctx.Value(storeKey{}).(Store)
+	if !ok {
+		return nil, ErrStoreNotAvailable
+	}
+	return NewNamespacedStore(store, plugin), nil
+}
@@ -0,0 +1,140 @@
+/*
+Package boltstore provides sarah.Store implementation that persists the values in a bbolt database file.
+
+bbolt locks the database file, so only one process can open the file at a time.
+This suits a single Bot process; use the SQL or Redis implementation to share the values among multiple processes.
+
+	db, err := bolt.Open("/var/lib/sarah/store.db", 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	store, err := boltstore.New(db, boltstore.NewConfig())
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterStore(store)
+
+This package is a separate Go module so the applications that do not use bbolt do not depend on it.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+*/
+package boltstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Config contains some configuration variables for the Store.
+type Config struct {
+	// Bucket is the name of the bucket to store the values. The bucket is created when it does not exist.
+	Bucket string `json:"bucket" yaml:"bucket"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		Bucket: "sarah_store",
+	}
+}
+
+// ApplyDefaults sets the default value to Bucket when it is not given.
+func (c *Config) ApplyDefaults() {
+	if c.Bucket == "" {
+		c.Bucket = NewConfig().Bucket
+	}
+}
+
+type store struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+var _ sarah.Store = (*store)(nil)
+
+// New creates and returns a sarah.Store that stores the values in the bucket of the given database.
+// The bucket is created at this point when it does not exist.
+func New(db *bolt.DB, config *Config) (sarah.Store, error) {
+	err := sarah.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := []byte(config.Bucket)
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bucket %s: %w", config.Bucket, err)
+	}
+
+	return &store{
+		db:     db,
+		bucket: bucket,
+	}, nil
+}
+
+func (s *store) Get(_ context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		stored := tx.Bucket(s.bucket).Get([]byte(key))
+		if stored == nil {
+			return fmt.Errorf("%w: %s", sarah.ErrStoreKeyNotFound, key)
+		}
+		// The returned slice is only valid during the transaction, so copy it.
+		value = append([]byte{}, stored...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (s *store) Set(_ context.Context, key string, value []byte) error {
+	if value == nil {
+		// bbolt does not distinguish a nil value from a missing key on Get, so store an empty value instead.
+		value = []byte{}
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(key), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *store) Delete(_ context.Context, key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *store) List(_ context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	p := []byte(prefix)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// The keys are sorted in byte order, so seek to the prefix and iterate til a key does not start with it.
+		c := tx.Bucket(s.bucket).Cursor()
+		for k, _ := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, _ = c.Next() {
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys with prefix %s: %w", prefix, err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,92 @@
+package boltstore
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	bolt "go.etcd.io/bbolt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newStore(t *testing.T) (sarah.Store, func()) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, "store.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	s, err := New(db, &Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	return s, func() {
+		_ = db.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s, finish := newStore(t)
+	defer finish()
+	ctx := context.TODO()
+
+	_, err := s.Get(ctx, "missing")
+	if !errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	for _, key := range []string{"bot/b", "bot/a", "bota", "other/a"} {
+		err = s.Set(ctx, key, []byte(key))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	value, err := s.Get(ctx, "bot/a")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(value) != "bot/a" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	err = s.Set(ctx, "empty", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	value, err = s.Get(ctx, "empty")
+	if err != nil || len(value) != 0 {
+		t.Errorf("Empty value is not stored: %#v, %#v.", value, err)
+	}
+
+	keys, err := s.List(ctx, "bot/")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Join(keys, ",") != "bot/a,bot/b" {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+
+	err = s.Delete(ctx, "bot/a")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	err = s.Delete(ctx, "bot/a")
+	if err != nil {
+		t.Errorf("Deleting missing key must not fail: %s.", err.Error())
+	}
+
+	_, err = s.Get(ctx, "bot/a")
+	if !errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
@@ -0,0 +1,23 @@
+module github.com/oklahomer/go-sarah/v4/kvstore/redisstore
+
+go 1.21
+
+require (
+	github.com/alicebob/miniredis/v2 v2.37.0
+	github.com/oklahomer/go-sarah/v4 v4.1.0
+	github.com/redis/go-redis/v9 v9.7.3
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/oklahomer/go-kasumi v0.0.0-20210320022217-84d2c0ccb359 // indirect
+	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
+	github.com/robfig/cron/v3 v3.0.1 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+// v4.1.0 is the first go-sarah release with the APIs this module uses. Until it is tagged,
+// this module builds against the local tree. Modules depending on this one ignore the replace below.
+replace github.com/oklahomer/go-sarah/v4 => ../../
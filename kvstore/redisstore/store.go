@@ -0,0 +1,155 @@
+/*
+Package redisstore provides sarah.Store implementation that persists the values in Redis.
+
+Multiple Bot processes can share the values since they are stored in Redis.
+Any client that satisfies redis.UniversalClient is accepted, so a standalone server, Redis Sentinel, and Redis Cluster are all supported.
+
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	store, err := redisstore.New(client, redisstore.NewConfig())
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterStore(store)
+
+This package is a separate Go module so the applications that do not use Redis do not depend on it.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+*/
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/redis/go-redis/v9"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// globEscaper escapes the special characters of SCAN's MATCH pattern so a prefix is compared literally.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Config contains some configuration variables for the Store.
+type Config struct {
+	// KeyPrefix is prepended to the keys to separate them from other applications' keys in the same database.
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+
+	// ScanCount is the hint of the number of keys to examine on each SCAN call on List.
+	ScanCount int64 `json:"scan_count" yaml:"scan_count"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		KeyPrefix: "sarah:",
+		ScanCount: 100,
+	}
+}
+
+// ApplyDefaults sets the default value to ScanCount when it is not given.
+// KeyPrefix is left as is, so an empty prefix can be used on purpose.
+func (c *Config) ApplyDefaults() {
+	if c.ScanCount == 0 {
+		c.ScanCount = NewConfig().ScanCount
+	}
+}
+
+// Validate checks that ScanCount is positive.
+func (c *Config) Validate() error {
+	if c.ScanCount < 0 {
+		return sarah.ConfigKeyErrors{&sarah.ConfigKeyError{Key: "scan_count", Value: fmt.Sprint(c.ScanCount), Err: errors.New("scan_count must be positive")}}
+	}
+	return nil
+}
+
+type store struct {
+	client    redis.UniversalClient
+	prefix    string
+	scanCount int64
+}
+
+var _ sarah.Store = (*store)(nil)
+
+// New creates and returns a sarah.Store that stores the values with the given Redis client.
+func New(client redis.UniversalClient, config *Config) (sarah.Store, error) {
+	err := sarah.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &store{
+		client:    client,
+		prefix:    config.KeyPrefix,
+		scanCount: config.ScanCount,
+	}, nil
+}
+
+func (s *store) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", sarah.ErrStoreKeyNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func (s *store) Set(ctx context.Context, key string, value []byte) error {
+	err := s.client.Set(ctx, s.prefix+key, value, 0).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *store) Delete(ctx context.Context, key string) error {
+	err := s.client.Del(ctx, s.prefix+key).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// List iterates the keys with SCAN instead of KEYS so a large database is not blocked.
+// With Redis Cluster, every master node is scanned since the keys are distributed among them.
+func (s *store) List(ctx context.Context, prefix string) ([]string, error) {
+	match := globEscaper.Replace(s.prefix+prefix) + "*"
+
+	var keys []string
+	var mutex sync.Mutex
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		iter := client.Scan(ctx, 0, match, s.scanCount).Iterator()
+		for iter.Next(ctx) {
+			mutex.Lock()
+			keys = append(keys, strings.TrimPrefix(iter.Val(), s.prefix))
+			mutex.Unlock()
+		}
+		return iter.Err()
+	}
+
+	var err error
+	if cluster, ok := s.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	} else {
+		err = scan(ctx, s.client)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys with prefix %s: %w", prefix, err)
+	}
+
+	// SCAN may return the same key more than once and in no particular order.
+	sort.Strings(keys)
+	unique := []string{}
+	for i, key := range keys {
+		if i > 0 && keys[i-1] == key {
+			continue
+		}
+		unique = append(unique, key)
+	}
+	return unique, nil
+}
@@ -0,0 +1,90 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	err := (&Config{ScanCount: -1}).Validate()
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	err = NewConfig().Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	s, err := New(client, NewConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	ctx := context.TODO()
+
+	_, err = s.Get(ctx, "missing")
+	if !errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	for _, key := range []string{"bot/b", "bot/a", "bota", "bot*/a", "other/a"} {
+		err = s.Set(ctx, key, []byte(key))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	stored, err := server.Get("sarah:bot/a")
+	if err != nil || stored != "bot/a" {
+		t.Errorf("Key is not prefixed: %#v.", err)
+	}
+
+	value, err := s.Get(ctx, "bot/a")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(value) != "bot/a" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	keys, err := s.List(ctx, "bot/")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Join(keys, ",") != "bot/a,bot/b" {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+
+	keys, err = s.List(ctx, "bot*")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Join(keys, ",") != "bot*/a" {
+		t.Errorf("Glob characters must be escaped: %#v.", keys)
+	}
+
+	err = s.Delete(ctx, "bot/a")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	err = s.Delete(ctx, "bot/a")
+	if err != nil {
+		t.Errorf("Deleting missing key must not fail: %s.", err.Error())
+	}
+
+	_, err = s.Get(ctx, "bot/a")
+	if !errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
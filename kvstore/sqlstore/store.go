@@ -0,0 +1,198 @@
+/*
+Package sqlstore provides sarah.Store implementation that persists the values in a relational database via database/sql.
+
+The values are stored in a table with the following columns.
+The table is not created by this package, so create one beforehand with the column types that suit the database:
+
+	CREATE TABLE sarah_store (
+		store_key   VARCHAR(255) NOT NULL PRIMARY KEY,
+		store_value BLOB NOT NULL
+	);
+
+Use BYTEA for the value column of PostgreSQL, and set Config.Placeholder to "$" since PostgreSQL's driver does not accept "?" as the placeholder:
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		panic(err)
+	}
+	config := sqlstore.NewConfig()
+	config.Placeholder = "$"
+	store, err := sqlstore.New(db, config)
+	if err != nil {
+		panic(err)
+	}
+	sarah.RegisterStore(store)
+
+Any driver works as long as it supports transactions and LIKE with ESCAPE clause.
+*/
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"regexp"
+	"strings"
+)
+
+var tablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// likeEscaper escapes the wildcard characters of LIKE so a prefix is compared literally. "!" is declared as the escape character in the query.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// Config contains some configuration variables for the Store.
+type Config struct {
+	// Table is the name of the table to store the values. A schema-qualified name such as "bot.sarah_store" is also accepted.
+	Table string `json:"table" yaml:"table"`
+
+	// Placeholder is the style of the query's placeholders: "?" for MySQL and SQLite, or "$" for PostgreSQL's $1, $2, and so on.
+	Placeholder string `json:"placeholder" yaml:"placeholder"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		Table:       "sarah_store",
+		Placeholder: "?",
+	}
+}
+
+// ApplyDefaults sets the default values to Table and Placeholder when they are not given.
+func (c *Config) ApplyDefaults() {
+	defaults := NewConfig()
+
+	if c.Table == "" {
+		c.Table = defaults.Table
+	}
+
+	if c.Placeholder == "" {
+		c.Placeholder = defaults.Placeholder
+	}
+}
+
+// Validate checks that Table is a valid identifier and Placeholder is a supported style.
+// Table is embedded in the queries, so only letters, digits, and underscores are allowed.
+func (c *Config) Validate() error {
+	var errs sarah.ConfigKeyErrors
+
+	if !tablePattern.MatchString(c.Table) {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "table", Value: c.Table, Err: errors.New("table must consist of letters, digits, and underscores")})
+	}
+
+	if c.Placeholder != "?" && c.Placeholder != "$" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "placeholder", Value: c.Placeholder, Err: errors.New(`placeholder must be "?" or "$"`)})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type store struct {
+	db          *sql.DB
+	selectQuery string
+	deleteQuery string
+	insertQuery string
+	listQuery   string
+}
+
+var _ sarah.Store = (*store)(nil)
+
+// New creates and returns a sarah.Store that stores the values in the table of the given database.
+func New(db *sql.DB, config *Config) (sarah.Store, error) {
+	err := sarah.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	bind := func(i int) string {
+		if config.Placeholder == "$" {
+			return fmt.Sprintf("$%d", i)
+		}
+		return "?"
+	}
+
+	return &store{
+		db:          db,
+		selectQuery: fmt.Sprintf("SELECT store_value FROM %s WHERE store_key = %s", config.Table, bind(1)),
+		deleteQuery: fmt.Sprintf("DELETE FROM %s WHERE store_key = %s", config.Table, bind(1)),
+		insertQuery: fmt.Sprintf("INSERT INTO %s (store_key, store_value) VALUES (%s, %s)", config.Table, bind(1), bind(2)),
+		listQuery:   fmt.Sprintf("SELECT store_key FROM %s WHERE store_key LIKE %s ESCAPE '!' ORDER BY store_key", config.Table, bind(1)),
+	}, nil
+}
+
+func (s *store) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRowContext(ctx, s.selectQuery, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", sarah.ErrStoreKeyNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// Set replaces the row in a transaction.
+// The dialects differ in the syntax of upsert, so the row is deleted and inserted instead.
+func (s *store) Set(ctx context.Context, key string, value []byte) error {
+	if value == nil {
+		// Store an empty value instead of NULL to satisfy the NOT NULL constraint.
+		value = []byte{}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction to set %s: %w", key, err)
+	}
+
+	_, err = tx.ExecContext(ctx, s.deleteQuery, key)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, s.insertQuery, key, value)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *store) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.deleteQuery, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *store) List(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.listQuery, likeEscaper.Replace(prefix)+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys with prefix %s: %w", prefix, err)
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys with prefix %s: %w", prefix, err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,141 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/oklahomer/go-sarah/v4"
+	"regexp"
+	"testing"
+)
+
+func newMock(t *testing.T, config *Config) (sarah.Store, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	s, err := New(db, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	return s, mock, func() {
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Errorf("Expectations are not met: %s.", err.Error())
+		}
+		_ = db.Close()
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := &Config{Table: "store; DROP TABLE users", Placeholder: ":"}
+
+	err := config.Validate()
+	errs, ok := err.(sarah.ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("Unexpected errors are returned: %s.", errs.Error())
+	}
+
+	for _, table := range []string{"sarah_store", "bot.sarah_store"} {
+		config := &Config{Table: table, Placeholder: "$"}
+		err = config.Validate()
+		if err != nil {
+			t.Errorf("Unexpected error is returned for %s: %s.", table, err.Error())
+		}
+	}
+}
+
+func TestStore_Get(t *testing.T) {
+	s, mock, finish := newMock(t, NewConfig())
+	defer finish()
+
+	query := regexp.QuoteMeta("SELECT store_value FROM sarah_store WHERE store_key = ?")
+	mock.ExpectQuery(query).WithArgs("key").WillReturnRows(sqlmock.NewRows([]string{"store_value"}).AddRow([]byte("value")))
+	mock.ExpectQuery(query).WithArgs("missing").WillReturnRows(sqlmock.NewRows([]string{"store_value"}))
+
+	value, err := s.Get(context.TODO(), "key")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(value) != "value" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	_, err = s.Get(context.TODO(), "missing")
+	if !errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestStore_Set(t *testing.T) {
+	config := NewConfig()
+	config.Placeholder = "$"
+	s, mock, finish := newMock(t, config)
+	defer finish()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sarah_store WHERE store_key = $1")).
+		WithArgs("key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sarah_store (store_key, store_value) VALUES ($1, $2)")).
+		WithArgs("key", []byte("value")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := s.Set(context.TODO(), "key", []byte("value"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestStore_Set_Rollback(t *testing.T) {
+	s, mock, finish := newMock(t, NewConfig())
+	defer finish()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT").WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	err := s.Set(context.TODO(), "key", nil)
+	if !errors.Is(err, sql.ErrConnDone) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	s, mock, finish := newMock(t, NewConfig())
+	defer finish()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sarah_store WHERE store_key = ?")).
+		WithArgs("key").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := s.Delete(context.TODO(), "key")
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	s, mock, finish := newMock(t, NewConfig())
+	defer finish()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT store_key FROM sarah_store WHERE store_key LIKE ? ESCAPE '!' ORDER BY store_key")).
+		WithArgs("bot/100!%!_!!/%").
+		WillReturnRows(sqlmock.NewRows([]string{"store_key"}).AddRow("bot/100%_!/a").AddRow("bot/100%_!/b"))
+
+	keys, err := s.List(context.TODO(), "bot/100%_!/")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(keys) != 2 || keys[0] != "bot/100%_!/a" || keys[1] != "bot/100%_!/b" {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+}
@@ -0,0 +1,181 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.TODO()
+
+	_, err := store.Get(ctx, "missing")
+	if !errors.Is(err, ErrStoreKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	value := []byte("value")
+	err = store.Set(ctx, "b", value)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The stored value must not be affected by a later modification.
+	value[0] = 'V'
+
+	stored, err := store.Get(ctx, "b")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(stored) != "value" {
+		t.Errorf("Unexpected value is returned: %s.", stored)
+	}
+
+	_ = store.Set(ctx, "a", nil)
+	_ = store.Set(ctx, "c", nil)
+	_ = store.Set(ctx, "x", nil)
+	keys, err := store.List(ctx, "")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Join(keys, ",") != "a,b,c,x" {
+		t.Errorf("Keys are not sorted: %#v.", keys)
+	}
+
+	err = store.Delete(ctx, "b")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	err = store.Delete(ctx, "b")
+	if err != nil {
+		t.Errorf("Deleting missing key must not fail: %s.", err.Error())
+	}
+
+	_, err = store.Get(ctx, "b")
+	if !errors.Is(err, ErrStoreKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestNewMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestNewFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(filepath.Join(dir, "nested"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	testStore(t, store)
+}
+
+func TestFileStore_NamespacedKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.TODO()
+	store, _ := NewFileStore(dir)
+	for _, key := range []string{"slack/poll/a", "slack/poll/../b", "slack/pollx", ".."} {
+		err = store.Set(ctx, key, []byte(key))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned for %s: %s.", key, err.Error())
+		}
+	}
+
+	keys, err := store.List(ctx, "slack/poll/")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Join(keys, ",") != "slack/poll/../b,slack/poll/a" {
+		t.Errorf("Unexpected keys are returned: %#v.", keys)
+	}
+
+	value, err := store.Get(ctx, "slack/poll/../b")
+	if err != nil || string(value) != "slack/poll/../b" {
+		t.Errorf("Unexpected value is returned: %s, %#v.", value, err)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 4 {
+		t.Errorf("Each key must be stored in a file directly under the directory: %d.", len(files))
+	}
+}
+
+func TestNewNamespacedStore(t *testing.T) {
+	ctx := context.TODO()
+	store := NewMemoryStore()
+	_ = store.Set(ctx, "slack/counter2/key", []byte("other"))
+
+	namespaced := NewNamespacedStore(NewNamespacedStore(store, "slack"), "counter")
+	err := namespaced.Set(ctx, "key", []byte("value"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	value, err := store.Get(ctx, "slack/counter/key")
+	if err != nil {
+		t.Fatalf("Key is not namespaced: %s.", err.Error())
+	}
+	if string(value) != "value" {
+		t.Errorf("Unexpected value is stored: %s.", value)
+	}
+
+	value, err = namespaced.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(value) != "value" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	keys, err := namespaced.List(ctx, "")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(keys) != 1 || keys[0] != "key" {
+		t.Errorf("Keys of other namespace are listed or not stripped: %#v.", keys)
+	}
+
+	err = namespaced.Delete(ctx, "key")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	_, err = store.Get(ctx, "slack/counter/key")
+	if !errors.Is(err, ErrStoreKeyNotFound) {
+		t.Errorf("Key is not deleted: %#v.", err)
+	}
+}
+
+func TestStoreFromContext(t *testing.T) {
+	_, err := StoreFromContext(context.TODO(), "plugin")
+	if !errors.Is(err, ErrStoreNotAvailable) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	store := NewMemoryStore()
+	ctx := WithStore(context.TODO(), store)
+	pluginStore, err := StoreFromContext(ctx, "plugin")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_ = pluginStore.Set(ctx, "key", []byte("value"))
+	_, err = store.Get(ctx, "plugin/key")
+	if err != nil {
+		t.Errorf("Key is not namespaced by plugin: %s.", err.Error())
+	}
+}
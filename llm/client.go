@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type chatRequest struct {
+	Model         string         `json:"model"`
+	Messages      []*Message     `json:"messages"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatChoice struct {
+	Message *Message `json:"message"`
+	Delta   *Message `json:"delta"`
+}
+
+type chatUsage struct {
+	TotalTokens int `json:"total_tokens"`
+}
+
+// chatResponse represents both a whole response and a chunk of a streamed response.
+type chatResponse struct {
+	Choices []*chatChoice `json:"choices"`
+	Usage   *chatUsage    `json:"usage"`
+}
+
+type errorResponse struct {
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// completion is the result of one call to the chat completions API.
+type completion struct {
+	content     string
+	totalTokens int
+}
+
+// complete calls the chat completions API with the given messages.
+// When onText is given, the response is streamed and onText is called with the text generated so far on every received delta.
+func (r *responder) complete(ctx context.Context, messages []*Message, onText func(string) error) (*completion, error) {
+	payload := &chatRequest{
+		Model:     r.config.Model,
+		Messages:  messages,
+		MaxTokens: r.config.MaxTokens,
+	}
+	if onText != nil {
+		payload.Stream = true
+		payload.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(r.config.Endpoint, "/") + "/chat/completions"
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if r.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.APIKey.Reveal())
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		errRes := &errorResponse{}
+		if json.Unmarshal(b, errRes) == nil && errRes.Error != nil && errRes.Error.Message != "" {
+			return nil, fmt.Errorf("unexpected status %d is returned: %s", resp.StatusCode, errRes.Error.Message)
+		}
+		return nil, fmt.Errorf("unexpected status %d is returned", resp.StatusCode)
+	}
+
+	if onText == nil {
+		chatRes := &chatResponse{}
+		err = json.NewDecoder(resp.Body).Decode(chatRes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		result := &completion{}
+		if len(chatRes.Choices) > 0 && chatRes.Choices[0].Message != nil {
+			result.content = strings.TrimSpace(chatRes.Choices[0].Message.Content)
+		}
+		if chatRes.Usage != nil {
+			result.totalTokens = chatRes.Usage.TotalTokens
+		}
+		return result, nil
+	}
+
+	return readStream(resp, onText)
+}
+
+// readStream reads the server-sent events of a streamed response til the "[DONE]" event arrives or the body ends.
+func readStream(resp *http.Response, onText func(string) error) (*completion, error) {
+	result := &completion{}
+	text := &strings.Builder{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// Comments, event names and blank separator lines.
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		chunk := &chatResponse{}
+		err := json.Unmarshal([]byte(data), chunk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode streamed chunk: %w", err)
+		}
+
+		if chunk.Usage != nil {
+			result.totalTokens = chunk.Usage.TotalTokens
+		}
+
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta == nil || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		text.WriteString(chunk.Choices[0].Delta.Content)
+		current := strings.TrimSpace(text.String())
+		if current == "" {
+			continue
+		}
+		err = onText(current)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read streamed response: %w", err)
+	}
+
+	result.content = strings.TrimSpace(text.String())
+	return result, nil
+}
@@ -0,0 +1,350 @@
+/*
+Package llm provides a Command that forwards inputs to a large language model with an OpenAI-compatible chat completions API.
+
+The Command is meant to be a fallback responder that handles inputs that match no other Command.
+It keeps each user's recent conversation as history, so the model can answer follow-up questions, and limits the number of tokens each user can consume in a given time window.
+
+	config := llm.NewConfig()
+	config.APIKey = sarah.Secret(os.Getenv("OPENAI_API_KEY"))
+	config.Model = "gpt-4o-mini"
+	config.SystemPrompt = "You are a helpful assistant in a team chat."
+	responder := llm.NewResponder(config)
+	bot := sarah.NewBot(adapter, sarah.BotWithFallbackCommand(responder))
+
+Any server that implements the same API, such as a local inference server, can be used by changing Config.Endpoint.
+*/
+package llm
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/patrickmn/go-cache"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identifier is the identifier of the Command that NewResponder returns.
+const Identifier = "llm"
+
+const (
+	roleSystem    = "system"
+	roleUser      = "user"
+	roleAssistant = "assistant"
+)
+
+// moduleLogger returns the Logger carried by the given context with this package's module name.
+// The prompts and the completions are never logged since they may contain sensitive text.
+func moduleLogger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("llm")
+}
+
+// Config contains some configuration variables for the responder.
+type Config struct {
+	// Endpoint is the base URL of the OpenAI-compatible API. "/chat/completions" is appended to this.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// APIKey is sent as a bearer token. Leave this empty for a server that requires no authentication.
+	APIKey sarah.Secret `json:"api_key" yaml:"api_key"`
+
+	// Model is the name of the model to use.
+	Model string `json:"model" yaml:"model"`
+
+	// SystemPrompt is given to the model at the beginning of every conversation when this is not empty.
+	SystemPrompt string `json:"system_prompt" yaml:"system_prompt"`
+
+	// HistorySize is the maximum number of past messages, including both the user's and the model's, that are sent to the model.
+	// Give zero to send no history.
+	HistorySize int `json:"history_size" yaml:"history_size"`
+
+	// History configures how long the conversation history is kept for each user.
+	History *sarah.CacheConfig `json:"history" yaml:"history"`
+
+	// MaxTokens is the maximum number of tokens the model generates for one response. Zero leaves this to the server's default.
+	MaxTokens int `json:"max_tokens" yaml:"max_tokens"`
+
+	// Stream lets the model's response be delivered as it is generated.
+	// The response is sent with sarah.StreamInPlace, so enable this only with an Adapter that supports message editing;
+	// otherwise, each partial response is sent as a new message.
+	Stream bool `json:"stream" yaml:"stream"`
+
+	// StreamInterval is the minimum interval between two consecutive updates of a streamed response.
+	StreamInterval time.Duration `json:"stream_interval" yaml:"stream_interval"`
+
+	// Timeout is the maximum duration of one request to the API including the time to read the whole response.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// TokenBudget is the maximum number of tokens each user can consume in BudgetWindow. Zero means no limit.
+	TokenBudget int `json:"token_budget" yaml:"token_budget"`
+
+	// BudgetWindow is the duration for which TokenBudget applies. A user's consumption is reset when the window passes.
+	BudgetWindow time.Duration `json:"budget_window" yaml:"budget_window"`
+
+	// BudgetExceededMessage is returned instead of calling the API when the user exceeds TokenBudget.
+	BudgetExceededMessage string `json:"budget_exceeded_message" yaml:"budget_exceeded_message"`
+}
+
+// NewConfig creates and returns new Config instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to overload default values.
+func NewConfig() *Config {
+	return &Config{
+		Endpoint:              "https://api.openai.com/v1",
+		HistorySize:           10,
+		History:               sarah.NewCacheConfig(),
+		MaxTokens:             512,
+		Stream:                false,
+		StreamInterval:        sarah.DefaultStreamInterval,
+		Timeout:               60 * time.Second,
+		TokenBudget:           0,
+		BudgetWindow:          24 * time.Hour,
+		BudgetExceededMessage: "You have used up your token budget. Please try again later.",
+	}
+}
+
+// Message represents one message of a conversation with the model.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// HistoryStore defines an interface that stores each user's conversation history.
+// The key is the Input.SenderKey of the user.
+type HistoryStore interface {
+	Get(key string) ([]*Message, error)
+	Set(key string, messages []*Message) error
+}
+
+type defaultHistoryStore struct {
+	cache *cache.Cache
+}
+
+// NewHistoryStore creates and returns new HistoryStore instance that keeps the history in memory.
+// Each user's history expires when the user sends no message for CacheConfig.ExpiresIn.
+func NewHistoryStore(config *sarah.CacheConfig) HistoryStore {
+	return &defaultHistoryStore{
+		cache: cache.New(config.ExpiresIn, config.CleanupInterval),
+	}
+}
+
+func (store *defaultHistoryStore) Get(key string) ([]*Message, error) {
+	val, ok := store.cache.Get(key)
+	if !ok {
+		return nil, nil
+	}
+	return val.([]*Message), nil
+}
+
+func (store *defaultHistoryStore) Set(key string, messages []*Message) error {
+	store.cache.Set(key, messages, cache.DefaultExpiration)
+	return nil
+}
+
+// Option defines a function signature that the responder's functional option must satisfy.
+type Option func(*responder)
+
+// WithHTTPClient sets the http.Client to call the API.
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *responder) {
+		r.client = client
+	}
+}
+
+// WithHistoryStore replaces the default in-memory HistoryStore.
+// Use this to share the history among multiple processes.
+func WithHistoryStore(store HistoryStore) Option {
+	return func(r *responder) {
+		r.history = store
+	}
+}
+
+// WithClock sets the clock.Clock that the token budget windows and the streamed response's update interval depend on.
+func WithClock(c clock.Clock) Option {
+	return func(r *responder) {
+		r.clock = c
+	}
+}
+
+type usage struct {
+	windowStart time.Time
+	tokens      int
+}
+
+type responder struct {
+	config  *Config
+	client  *http.Client
+	history HistoryStore
+	clock   clock.Clock
+	mutex   sync.Mutex
+	usages  map[string]*usage
+}
+
+var _ sarah.Command = (*responder)(nil)
+
+// NewResponder creates and returns new sarah.Command that answers any non-empty input with the model's response.
+// Register this with sarah.BotWithFallbackCommand so the model only answers inputs that match no other Command.
+func NewResponder(config *Config, options ...Option) sarah.Command {
+	r := &responder{
+		config: config,
+		client: http.DefaultClient,
+		clock:  clock.Real(),
+		usages: map[string]*usage{},
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	if r.history == nil {
+		historyConfig := config.History
+		if historyConfig == nil {
+			historyConfig = sarah.NewCacheConfig()
+		}
+		r.history = NewHistoryStore(historyConfig)
+	}
+
+	return r
+}
+
+func (r *responder) Identifier() string {
+	return Identifier
+}
+
+// Instruction returns an empty string so the responder does not appear in the help.
+func (r *responder) Instruction(_ *sarah.HelpInput) string {
+	return ""
+}
+
+func (r *responder) Match(input sarah.Input) bool {
+	return strings.TrimSpace(input.Message()) != ""
+}
+
+func (r *responder) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	key := input.SenderKey()
+	if r.exceedsBudget(key) {
+		return &sarah.CommandResponse{Content: r.config.BudgetExceededMessage}, nil
+	}
+
+	history, err := r.history.Get(key)
+	if err != nil {
+		// The model can still answer without the history.
+		moduleLogger(ctx).Error("Failed to get conversation history", logging.F("sender_key", key), logging.Err(err))
+	}
+
+	userMessage := &Message{Role: roleUser, Content: input.Message()}
+	var messages []*Message
+	if r.config.SystemPrompt != "" {
+		messages = append(messages, &Message{Role: roleSystem, Content: r.config.SystemPrompt})
+	}
+	messages = append(messages, history...)
+	messages = append(messages, userMessage)
+
+	if !r.config.Stream {
+		reqCtx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+
+		completion, err := r.complete(reqCtx, messages, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		r.finish(ctx, key, history, userMessage, messages, completion)
+		if completion.content == "" {
+			return nil, nil
+		}
+		return &sarah.CommandResponse{Content: completion.content}, nil
+	}
+
+	stream := sarah.NewStreamContentFunc(func(ctx context.Context, emit func(interface{}) error) error {
+		reqCtx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+
+		// Coalesce the deltas so the message is updated at most once in StreamInterval.
+		var lastEmitted time.Time
+		emitted := ""
+		completion, err := r.complete(reqCtx, messages, func(text string) error {
+			now := r.clock.Now()
+			if !lastEmitted.IsZero() && now.Sub(lastEmitted) < r.config.StreamInterval {
+				return nil
+			}
+			lastEmitted = now
+			emitted = text
+			return emit(text)
+		})
+		if err != nil {
+			return err
+		}
+
+		r.finish(ctx, key, history, userMessage, messages, completion)
+		if completion.content != "" && completion.content != emitted {
+			return emit(completion.content)
+		}
+		return nil
+	}, sarah.StreamInPlace(), sarah.StreamWithInterval(0))
+
+	return &sarah.CommandResponse{Content: stream}, nil
+}
+
+// finish records the consumed tokens and stores the conversation as the user's history.
+func (r *responder) finish(ctx context.Context, key string, history []*Message, userMessage *Message, messages []*Message, completion *completion) {
+	tokens := completion.totalTokens
+	if tokens == 0 {
+		// The server did not report the usage. Roughly estimate with four characters per token so the budget still works.
+		chars := len(completion.content)
+		for _, m := range messages {
+			chars += len(m.Content)
+		}
+		tokens = chars/4 + 1
+	}
+	r.consume(key, tokens)
+
+	if r.config.HistorySize <= 0 || completion.content == "" {
+		return
+	}
+
+	updated := make([]*Message, 0, len(history)+2)
+	updated = append(updated, history...)
+	updated = append(updated, userMessage, &Message{Role: roleAssistant, Content: completion.content})
+	if len(updated) > r.config.HistorySize {
+		updated = updated[len(updated)-r.config.HistorySize:]
+	}
+	err := r.history.Set(key, updated)
+	if err != nil {
+		moduleLogger(ctx).Error("Failed to store conversation history", logging.F("sender_key", key), logging.Err(err))
+	}
+}
+
+// exceedsBudget tells if the user has already consumed TokenBudget in the current window.
+func (r *responder) exceedsBudget(key string) bool {
+	if r.config.TokenBudget <= 0 {
+		return false
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, ok := r.usages[key]
+	if !ok || !r.clock.Now().Before(u.windowStart.Add(r.config.BudgetWindow)) {
+		return false
+	}
+	return u.tokens >= r.config.TokenBudget
+}
+
+func (r *responder) consume(key string, tokens int) {
+	if r.config.TokenBudget <= 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.clock.Now()
+	u, ok := r.usages[key]
+	if !ok || !now.Before(u.windowStart.Add(r.config.BudgetWindow)) {
+		u = &usage{windowStart: now}
+		r.usages[key] = u
+	}
+	u.tokens += tokens
+}
@@ -0,0 +1,380 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+	ReplyToValue   sarah.OutputDestination
+}
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+func decodeRequest(t *testing.T, r *http.Request) *chatRequest {
+	req := &chatRequest{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		t.Fatalf("Failed to decode request: %s.", err.Error())
+	}
+	return req
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+
+	if config.Endpoint != "https://api.openai.com/v1" {
+		t.Errorf("Unexpected default endpoint is set: %s.", config.Endpoint)
+	}
+
+	if config.HistorySize != 10 {
+		t.Errorf("Unexpected default history size is set: %d.", config.HistorySize)
+	}
+
+	if config.History == nil {
+		t.Error("Default history config is not set.")
+	}
+
+	if config.Stream {
+		t.Error("Stream must be disabled by default.")
+	}
+
+	if config.TokenBudget != 0 {
+		t.Errorf("Unexpected default token budget is set: %d.", config.TokenBudget)
+	}
+
+	if config.BudgetExceededMessage == "" {
+		t.Error("Default budget exceeded message is not set.")
+	}
+}
+
+func Test_responder_Match(t *testing.T) {
+	r := NewResponder(NewConfig())
+
+	if r.Identifier() != Identifier {
+		t.Errorf("Unexpected identifier is returned: %s.", r.Identifier())
+	}
+
+	if r.Instruction(&sarah.HelpInput{}) != "" {
+		t.Error("Instruction must be empty.")
+	}
+
+	if !r.Match(&DummyInput{MessageValue: "hello"}) {
+		t.Error("Non-empty input must match.")
+	}
+
+	if r.Match(&DummyInput{MessageValue: " \n"}) {
+		t.Error("Empty input must not match.")
+	}
+}
+
+func Test_responder_Execute(t *testing.T) {
+	var requests []*chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Unexpected header is given: %s.", r.Header.Get("Authorization"))
+		}
+
+		req := decodeRequest(t, r)
+		requests = append(requests, req)
+		_, _ = fmt.Fprintf(w, `{"choices": [{"message": {"role": "assistant", "content": "answer %d"}}], "usage": {"total_tokens": 10}}`, len(requests))
+	}))
+	defer server.Close()
+
+	config := NewConfig()
+	config.Endpoint = server.URL + "/v1/"
+	config.APIKey = "secret"
+	config.Model = "dummy"
+	config.SystemPrompt = "Be nice."
+	config.HistorySize = 2
+	r := NewResponder(config)
+
+	for i := 1; i <= 3; i++ {
+		res, err := r.Execute(context.TODO(), &DummyInput{SenderKeyValue: "alice", MessageValue: fmt.Sprintf("question %d", i)})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if res == nil || res.Content != fmt.Sprintf("answer %d", i) {
+			t.Errorf("Unexpected response is returned: %#v.", res)
+		}
+	}
+
+	// Each request has the system prompt, the last two messages of the history and the user's input.
+	contents := func(messages []*Message) string {
+		var s []string
+		for _, m := range messages {
+			s = append(s, m.Role+":"+m.Content)
+		}
+		return strings.Join(s, ",")
+	}
+	expected := []string{
+		"system:Be nice.,user:question 1",
+		"system:Be nice.,user:question 1,assistant:answer 1,user:question 2",
+		"system:Be nice.,user:question 2,assistant:answer 2,user:question 3",
+	}
+	for i, req := range requests {
+		if req.Model != "dummy" || req.Stream {
+			t.Errorf("Unexpected request is sent: %#v.", req)
+		}
+
+		if contents(req.Messages) != expected[i] {
+			t.Errorf("Unexpected messages are sent on request #%d: %s.", i, contents(req.Messages))
+		}
+	}
+}
+
+func Test_responder_Execute_WithStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := decodeRequest(t, r)
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("Unexpected request is sent: %#v.", req)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices": [{"delta": {"role": "assistant"}}]}`,
+			`{"choices": [{"delta": {"content": "Hello"}}]}`,
+			`{"choices": [{"delta": {"content": ", "}}]}`,
+			`{"choices": [{"delta": {"content": "world"}}]}`,
+			`{"choices": [], "usage": {"total_tokens": 100}}`,
+			`[DONE]`,
+		} {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+	defer server.Close()
+
+	config := NewConfig()
+	config.Endpoint = server.URL
+	config.Stream = true
+	config.StreamInterval = time.Hour
+	config.TokenBudget = 100
+	r := NewResponder(config, WithClock(clock.NewFake(time.Now())))
+
+	input := &DummyInput{SenderKeyValue: "alice", MessageValue: "greet"}
+	res, err := r.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	stream, ok := res.Content.(*sarah.StreamContent)
+	if !ok {
+		t.Fatalf("Unexpected content is returned: %#v.", res.Content)
+	}
+
+	var sent []interface{}
+	err = stream.Stream(context.TODO(), func(content interface{}) {
+		sent = append(sent, content)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The first delta is sent right away and the rest is coalesced til the stream ends.
+	if len(sent) != 2 || sent[0] != "Hello" || sent[1] != "Hello, world" {
+		t.Errorf("Unexpected contents are sent: %#v.", sent)
+	}
+
+	// The reported usage consumes the whole budget.
+	res, err = r.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res == nil || res.Content != config.BudgetExceededMessage {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+}
+
+func Test_responder_Execute_WithTokenBudget(t *testing.T) {
+	called := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "answer"}}], "usage": {"total_tokens": 60}}`))
+	}))
+	defer server.Close()
+
+	config := NewConfig()
+	config.Endpoint = server.URL
+	config.TokenBudget = 100
+	config.BudgetWindow = time.Hour
+	config.BudgetExceededMessage = "exceeded"
+	fake := clock.NewFake(time.Now())
+	r := NewResponder(config, WithClock(fake))
+
+	execute := func(key string) string {
+		res, err := r.Execute(context.TODO(), &DummyInput{SenderKeyValue: key, MessageValue: "question"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		return res.Content.(string)
+	}
+
+	// 60 tokens are consumed, then 120 tokens.
+	for i := 0; i < 2; i++ {
+		if content := execute("alice"); content != "answer" {
+			t.Errorf("Unexpected response is returned: %s.", content)
+		}
+	}
+
+	if content := execute("alice"); content != "exceeded" {
+		t.Errorf("Budget is not applied: %s.", content)
+	}
+
+	if content := execute("bob"); content != "answer" {
+		t.Errorf("Budget must be tracked per user: %s.", content)
+	}
+
+	fake.Advance(time.Hour)
+	if content := execute("alice"); content != "answer" {
+		t.Errorf("Budget is not reset: %s.", content)
+	}
+
+	if called != 4 {
+		t.Errorf("Unexpected number of requests are sent: %d.", called)
+	}
+}
+
+func Test_responder_Execute_WithError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error": {"message": "Rate limit reached"}}`))
+	}))
+	defer server.Close()
+
+	config := NewConfig()
+	config.Endpoint = server.URL
+	r := NewResponder(config)
+
+	_, err := r.Execute(context.TODO(), &DummyInput{MessageValue: "question"})
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	if !strings.Contains(err.Error(), "Rate limit reached") {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+type DummyHistoryStore struct {
+	GetFunc func(string) ([]*Message, error)
+	SetFunc func(string, []*Message) error
+}
+
+func (s *DummyHistoryStore) Get(key string) ([]*Message, error) {
+	return s.GetFunc(key)
+}
+
+func (s *DummyHistoryStore) Set(key string, messages []*Message) error {
+	return s.SetFunc(key, messages)
+}
+
+func TestWithHistoryStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := decodeRequest(t, r)
+		if len(req.Messages) != 2 || req.Messages[0].Content != "stored" {
+			t.Errorf("Stored history is not sent: %#v.", req.Messages)
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "answer"}}]}`))
+	}))
+	defer server.Close()
+
+	var stored []*Message
+	store := &DummyHistoryStore{
+		GetFunc: func(key string) ([]*Message, error) {
+			return []*Message{{Role: roleUser, Content: "stored"}}, nil
+		},
+		SetFunc: func(key string, messages []*Message) error {
+			stored = messages
+			return nil
+		},
+	}
+
+	config := NewConfig()
+	config.Endpoint = server.URL
+	r := NewResponder(config, WithHistoryStore(store))
+
+	_, err := r.Execute(context.TODO(), &DummyInput{MessageValue: "question"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(stored) != 3 || stored[2].Content != "answer" {
+		t.Errorf("Unexpected history is stored: %#v.", stored)
+	}
+}
+
+func TestWithHistoryStore_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "secret answer"}}]}`))
+	}))
+	defer server.Close()
+
+	store := &DummyHistoryStore{
+		GetFunc: func(key string) ([]*Message, error) {
+			return nil, fmt.Errorf("failed to get %s", key)
+		},
+		SetFunc: func(key string, messages []*Message) error {
+			return fmt.Errorf("failed to set %s", key)
+		},
+	}
+
+	config := NewConfig()
+	config.Endpoint = server.URL
+	r := NewResponder(config, WithHistoryStore(store))
+
+	buf := &bytes.Buffer{}
+	ctx := logging.NewContext(context.Background(), logging.NewLogger(logging.NewJSONHandler(buf)))
+	res, err := r.Execute(ctx, &DummyInput{SenderKeyValue: "U123", MessageValue: "secret question"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if res.Content != "secret answer" {
+		t.Errorf("Unexpected content is returned: %#v.", res.Content)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Unexpected log entries: %s.", buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"module":"llm"`) || !strings.Contains(line, `"sender_key":"U123"`) {
+			t.Errorf("Expected fields are not logged: %s.", line)
+		}
+		if strings.Contains(line, "secret") {
+			t.Errorf("Conversation must not be logged: %s.", line)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-kasumi/logger"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fieldValue converts the given value to a form that is safe to be encoded.
+// An error is converted to its message because most error implementations have no exported field to be encoded.
+func fieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+
+	case fmt.Stringer:
+		return v.String()
+
+	default:
+		return v
+
+	}
+}
+
+type jsonHandler struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// NewJSONHandler creates and returns a new Handler that writes each entry to the given io.Writer as a line of JSON.
+// Along with the attached fields, time, level, module and message keys are always written.
+// When a field has one of those keys, the field overrides the value.
+func NewJSONHandler(writer io.Writer) Handler {
+	return &jsonHandler{
+		writer: writer,
+	}
+}
+
+func (h *jsonHandler) Handle(entry *Entry) {
+	m := map[string]interface{}{
+		"time":    entry.Time.Format(time.RFC3339Nano),
+		"level":   entry.Level.String(),
+		"message": entry.Message,
+	}
+	if entry.Module != "" {
+		m["module"] = entry.Module
+	}
+	for _, f := range entry.Fields {
+		m[f.Key] = fieldValue(f.Value)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		// Fall back to the stringified values so the entry is not lost.
+		for k, v := range m {
+			m[k] = fmt.Sprintf("%+v", v)
+		}
+		b, _ = json.Marshal(m)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, _ = h.writer.Write(append(b, '\n'))
+}
+
+type textHandler struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// NewTextHandler creates and returns a new Handler that writes each entry to the given io.Writer as a human-readable line such as below:
+//
+//  2021-05-01T12:00:00Z WARN [runner] Failed to send alert bot_type=slack error="connection refused"
+func NewTextHandler(writer io.Writer) Handler {
+	return &textHandler{
+		writer: writer,
+	}
+}
+
+func (h *textHandler) Handle(entry *Entry) {
+	line := entry.Time.Format(time.RFC3339) + " " + strings.ToUpper(entry.Level.String()) + " " + format(entry) + "\n"
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, _ = io.WriteString(h.writer, line)
+}
+
+// format returns the module, message and fields in a human-readable form.
+func format(entry *Entry) string {
+	b := &strings.Builder{}
+	if entry.Module != "" {
+		b.WriteString("[" + entry.Module + "] ")
+	}
+	b.WriteString(entry.Message)
+	for _, f := range entry.Fields {
+		b.WriteString(" " + f.Key + "=")
+		str := fmt.Sprintf("%+v", fieldValue(f.Value))
+		if strings.ContainsAny(str, " \t\n\"=") {
+			str = fmt.Sprintf("%q", str)
+		}
+		b.WriteString(str)
+	}
+	return b.String()
+}
+
+type kasumiHandler struct{}
+
+// NewKasumiHandler creates and returns a new Handler that passes each entry to go-kasumi's logger.
+// This is the default Handler so the existing logger setting with logger.SetLogger keeps working.
+// The fields are appended to the message in a key=value form.
+func NewKasumiHandler() Handler {
+	return &kasumiHandler{}
+}
+
+func (h *kasumiHandler) Handle(entry *Entry) {
+	line := format(entry)
+	switch entry.Level {
+	case LevelDebug:
+		logger.Debug(line)
+
+	case LevelInfo:
+		logger.Info(line)
+
+	case LevelWarn:
+		logger.Warn(line)
+
+	default:
+		logger.Error(line)
+
+	}
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONHandler_Handle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewJSONHandler(buf)
+
+	handler.Handle(&Entry{
+		Time:    time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelWarn,
+		Module:  "sarah",
+		Message: "Failed to send alert",
+		Fields: []Field{
+			F(KeyBotType, "slack"),
+			F("count", 3),
+			Err(errors.New("connection refused")),
+		},
+	})
+
+	if !strings.HasSuffix(buf.String(), "\n") {
+		t.Errorf("Entry must be terminated by a new line: %s.", buf.String())
+	}
+
+	decoded := map[string]interface{}{}
+	err := json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := map[string]interface{}{
+		"time":     "2021-05-01T12:00:00Z",
+		"level":    "warn",
+		"module":   "sarah",
+		"message":  "Failed to send alert",
+		KeyBotType: "slack",
+		"count":    float64(3),
+		KeyError:   "connection refused",
+	}
+	for k, v := range expected {
+		if decoded[k] != v {
+			t.Errorf("Unexpected value is set for %s: %#v.", k, decoded[k])
+		}
+	}
+
+	if len(decoded) != len(expected) {
+		t.Errorf("Unexpected number of keys are written: %#v.", decoded)
+	}
+}
+
+func TestJSONHandler_Handle_UnsupportedValue(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewJSONHandler(buf)
+
+	handler.Handle(&Entry{
+		Level:   LevelInfo,
+		Message: "message",
+		Fields: []Field{
+			F("func", func() {}),
+		},
+	})
+
+	decoded := map[string]interface{}{}
+	err := json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if _, ok := decoded["func"].(string); !ok {
+		t.Errorf("Unsupported value must be stringified: %#v.", decoded["func"])
+	}
+}
+
+func TestTextHandler_Handle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewTextHandler(buf)
+
+	handler.Handle(&Entry{
+		Time:    time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelWarn,
+		Module:  "runner",
+		Message: "Failed to send alert",
+		Fields: []Field{
+			F(KeyBotType, "slack"),
+			Err(errors.New("connection refused")),
+		},
+	})
+
+	expected := "2021-05-01T12:00:00Z WARN [runner] Failed to send alert bot_type=slack error=\"connection refused\"\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected line is written: %s.", buf.String())
+	}
+}
@@ -0,0 +1,352 @@
+/*
+Package logging provides a structured logger with levels, fields and per-module level overrides.
+
+Unlike a printf-style logger, each log entry carries key-value pairs as Field so a Handler can ship the entry
+in a machine-readable form such as JSON.
+Loggers derived with Logger.With and Logger.Module carry the given fields and module name,
+so bot_type, command_id and destination are attached to every entry without repeating them in each message:
+
+	log := logging.GetLogger().Module("runner").With(logging.F(logging.KeyBotType, "slack"))
+	log.Info("Starting bot")
+	log.Error("Failed to send message", logging.F(logging.KeyDestination, channel), logging.Err(err))
+
+By default, the entries are passed to go-kasumi's logger so the output stays the same as the former printf-style logging.
+Use SetLogger with a Logger built from NewJSONHandler, NewTextHandler or a custom Handler to change the destination.
+Handlers for zap and logrus are provided by the zaphandler and logrushandler packages.
+They are separate Go modules so the applications that do not use those libraries do not depend on them:
+
+	logging.SetLogger(logging.NewLogger(zaphandler.New(zapLogger)))
+
+Any other logging library can be bridged by implementing Handler.
+With Go 1.21 or later, NewSlogHandler bridges the entries to log/slog.
+*/
+package logging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys of the fields that are commonly attached by go-sarah.
+const (
+	// KeyBotType is the key for the sarah.BotType that the entry relates to.
+	KeyBotType = "bot_type"
+
+	// KeyCommandID is the key for the identifier of the sarah.Command that the entry relates to.
+	KeyCommandID = "command_id"
+
+	// KeyTaskID is the key for the identifier of the sarah.ScheduledTask that the entry relates to.
+	KeyTaskID = "task_id"
+
+	// KeyCorrelationID is the key for the identifier that is generated for each incoming message to trace its lifecycle.
+	KeyCorrelationID = "correlation_id"
+
+	// KeyDestination is the key for the destination of the output message that the entry relates to.
+	KeyDestination = "destination"
+
+	// KeyError is the key for the error that the entry reports.
+	KeyError = "error"
+)
+
+// Level represents the severity of a log entry.
+type Level int
+
+const (
+	// LevelDebug is for detailed information that is only useful on debugging.
+	LevelDebug Level = iota
+
+	// LevelInfo is for informational messages on the normal operation.
+	LevelInfo
+
+	// LevelWarn is for unexpected but recoverable situations.
+	LevelWarn
+
+	// LevelError is for failures that require attention.
+	LevelError
+)
+
+// String returns a stringified form of the Level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+
+	case LevelInfo:
+		return "info"
+
+	case LevelWarn:
+		return "warn"
+
+	case LevelError:
+		return "error"
+
+	default:
+		return fmt.Sprintf("unknown(%d)", int(l))
+
+	}
+}
+
+// ParseLevel returns the Level corresponding to the given string.
+// The comparison is case-insensitive, and "warning" is accepted as an alias of "warn".
+func ParseLevel(str string) (Level, error) {
+	switch strings.ToLower(str) {
+	case "debug":
+		return LevelDebug, nil
+
+	case "info":
+		return LevelInfo, nil
+
+	case "warn", "warning":
+		return LevelWarn, nil
+
+	case "error":
+		return LevelError, nil
+
+	default:
+		return LevelDebug, fmt.Errorf("unknown log level: %s", str)
+
+	}
+}
+
+// MarshalText returns the stringified form of the Level so the level can be written in a configuration file.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText parses the given text with ParseLevel so the level can be read from a configuration file.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// Field represents a key-value pair attached to a log entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F creates and returns a new Field with the given key and value.
+func F(key string, value interface{}) Field {
+	return Field{
+		Key:   key,
+		Value: value,
+	}
+}
+
+// Err creates and returns a new Field that holds the given error with KeyError.
+func Err(err error) Field {
+	return F(KeyError, err)
+}
+
+// Entry represents a log entry passed to Handler.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Module  string
+	Message string
+	Fields  []Field
+}
+
+// Handler defines an interface that writes a log entry to its destination.
+// Logger calls Handle only when the entry's level is enabled, so Handler does not have to filter the entries.
+// Handle may be called from multiple goroutines at the same time.
+type Handler interface {
+	Handle(*Entry)
+}
+
+// Logger defines an interface of the structured logger.
+type Logger interface {
+	Debug(message string, fields ...Field)
+	Info(message string, fields ...Field)
+	Warn(message string, fields ...Field)
+	Error(message string, fields ...Field)
+
+	// Enabled tells if an entry with the given level is passed to the Handler.
+	// This is handy to skip an expensive preparation of the fields.
+	Enabled(Level) bool
+
+	// With returns a derived Logger that attaches the given fields to every entry.
+	// When a field with the same key is already attached, the given field replaces the old one.
+	With(fields ...Field) Logger
+
+	// Module returns a derived Logger with the given module name.
+	// The module name is used to look up the per-module level set by WithModuleLevel.
+	Module(name string) Logger
+}
+
+// LoggerOption defines a function signature that NewLogger's functional option must satisfy.
+type LoggerOption func(*levels)
+
+// WithLevel creates a LoggerOption that sets the minimum level of the entries to be passed to the Handler.
+// The default level is LevelDebug.
+func WithLevel(level Level) LoggerOption {
+	return func(l *levels) {
+		l.defaultLevel = level
+	}
+}
+
+// WithModuleLevel creates a LoggerOption that overrides the minimum level for the given module.
+// This is handy to enable the debug logs of a specific module while keeping others quiet.
+func WithModuleLevel(module string, level Level) LoggerOption {
+	return func(l *levels) {
+		l.modules[module] = level
+	}
+}
+
+// levels holds the default level and the per-module overrides shared among the derived loggers.
+type levels struct {
+	defaultLevel Level
+	modules      map[string]Level
+}
+
+func (l *levels) of(module string) Level {
+	if level, ok := l.modules[module]; ok {
+		return level
+	}
+	return l.defaultLevel
+}
+
+type defaultLogger struct {
+	handler Handler
+	levels  *levels
+	module  string
+	level   Level
+	fields  []Field
+	now     func() time.Time
+}
+
+var _ Logger = (*defaultLogger)(nil)
+
+// NewLogger creates and returns a new Logger that passes the entries to the given Handler.
+func NewLogger(handler Handler, options ...LoggerOption) Logger {
+	l := &levels{
+		defaultLevel: LevelDebug,
+		modules:      map[string]Level{},
+	}
+	for _, opt := range options {
+		opt(l)
+	}
+
+	return &defaultLogger{
+		handler: handler,
+		levels:  l,
+		level:   l.of(""),
+		now:     time.Now,
+	}
+}
+
+func (l *defaultLogger) Debug(message string, fields ...Field) {
+	l.log(LevelDebug, message, fields)
+}
+
+func (l *defaultLogger) Info(message string, fields ...Field) {
+	l.log(LevelInfo, message, fields)
+}
+
+func (l *defaultLogger) Warn(message string, fields ...Field) {
+	l.log(LevelWarn, message, fields)
+}
+
+func (l *defaultLogger) Error(message string, fields ...Field) {
+	l.log(LevelError, message, fields)
+}
+
+func (l *defaultLogger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *defaultLogger) With(fields ...Field) Logger {
+	derived := *l
+	derived.fields = make([]Field, 0, len(l.fields)+len(fields))
+	derived.fields = append(derived.fields, l.fields...)
+
+	// A field with an already attached key replaces the old one so a key appears only once in an entry.
+NEXT:
+	for _, f := range fields {
+		for i := range derived.fields {
+			if derived.fields[i].Key == f.Key {
+				derived.fields[i] = f
+				continue NEXT
+			}
+		}
+		derived.fields = append(derived.fields, f)
+	}
+
+	return &derived
+}
+
+func (l *defaultLogger) Module(name string) Logger {
+	derived := *l
+	derived.module = name
+	derived.level = l.levels.of(name)
+	return &derived
+}
+
+func (l *defaultLogger) log(level Level, message string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	all := fields
+	if len(l.fields) > 0 {
+		all = make([]Field, 0, len(l.fields)+len(fields))
+		all = append(all, l.fields...)
+		all = append(all, fields...)
+	}
+
+	message, all = redact(message, all)
+
+	l.handler.Handle(&Entry{
+		Time:    l.now(),
+		Level:   level,
+		Module:  l.module,
+		Message: message,
+		Fields:  all,
+	})
+}
+
+var (
+	current      = NewLogger(NewKasumiHandler())
+	currentMutex sync.RWMutex
+)
+
+// SetLogger replaces the package-level Logger that go-sarah and its sub-packages use.
+// Call this before sarah.NewRunner so the change takes effect on all components.
+func SetLogger(logger Logger) {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+
+	current = logger
+}
+
+// GetLogger returns the package-level Logger.
+func GetLogger() Logger {
+	currentMutex.RLock()
+	defer currentMutex.RUnlock()
+
+	return current
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of the given context that carries the given Logger.
+// go-sarah's core passes a context with a Logger to Bot and Adapter so they can log to the sink of the Runner they belong to.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the Logger carried by the given context.
+// When the context carries none, the package-level Logger returned by GetLogger is returned.
+func FromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return logger
+	}
+	return GetLogger()
+}
@@ -0,0 +1,316 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type DummyHandler struct {
+	HandleFunc func(*Entry)
+}
+
+func (h *DummyHandler) Handle(entry *Entry) {
+	h.HandleFunc(entry)
+}
+
+func TestLevel_String(t *testing.T) {
+	tests := []struct {
+		level Level
+		str   string
+	}{
+		{
+			level: LevelDebug,
+			str:   "debug",
+		},
+		{
+			level: LevelInfo,
+			str:   "info",
+		},
+		{
+			level: LevelWarn,
+			str:   "warn",
+		},
+		{
+			level: LevelError,
+			str:   "error",
+		},
+		{
+			level: Level(100),
+			str:   "unknown(100)",
+		},
+	}
+
+	for i, tt := range tests {
+		if tt.level.String() != tt.str {
+			t.Errorf("Unexpected string is returned on test #%d: %s.", i, tt.level.String())
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		str   string
+		level Level
+		err   bool
+	}{
+		{
+			str:   "DEBUG",
+			level: LevelDebug,
+		},
+		{
+			str:   "info",
+			level: LevelInfo,
+		},
+		{
+			str:   "warning",
+			level: LevelWarn,
+		},
+		{
+			str:   "Error",
+			level: LevelError,
+		},
+		{
+			str: "fatal",
+			err: true,
+		},
+	}
+
+	for i, tt := range tests {
+		level, err := ParseLevel(tt.str)
+		if tt.err {
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+
+		if level != tt.level {
+			t.Errorf("Unexpected level is returned on test #%d: %s.", i, level)
+		}
+	}
+}
+
+func TestLevel_UnmarshalText(t *testing.T) {
+	config := &struct {
+		Level Level `json:"level"`
+	}{}
+	err := json.Unmarshal([]byte(`{"level": "warn"}`), config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if config.Level != LevelWarn {
+		t.Errorf("Unexpected level is set: %s.", config.Level)
+	}
+
+	err = json.Unmarshal([]byte(`{"level": "unknown"}`), config)
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestErr(t *testing.T) {
+	err := errors.New("dummy")
+	field := Err(err)
+
+	if field.Key != KeyError {
+		t.Errorf("Unexpected key is set: %s.", field.Key)
+	}
+
+	if field.Value != err {
+		t.Errorf("Unexpected value is set: %#v.", field.Value)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	handler := &DummyHandler{}
+	logger := NewLogger(handler, WithLevel(LevelWarn), WithModuleLevel("workers", LevelDebug))
+
+	typed, ok := logger.(*defaultLogger)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", logger)
+	}
+
+	if typed.handler != handler {
+		t.Errorf("Given handler is not set: %#v.", typed.handler)
+	}
+
+	if typed.level != LevelWarn {
+		t.Errorf("Unexpected level is set: %s.", typed.level)
+	}
+
+	if typed.levels.modules["workers"] != LevelDebug {
+		t.Errorf("Module level is not set: %#v.", typed.levels.modules)
+	}
+}
+
+func TestDefaultLogger_log(t *testing.T) {
+	var entries []*Entry
+	handler := &DummyHandler{
+		HandleFunc: func(entry *Entry) {
+			entries = append(entries, entry)
+		},
+	}
+	now := time.Now()
+	logger := &defaultLogger{
+		handler: handler,
+		levels:  &levels{defaultLevel: LevelInfo, modules: map[string]Level{}},
+		level:   LevelInfo,
+		now: func() time.Time {
+			return now
+		},
+	}
+
+	logger.Debug("debug")
+	logger.Info("info", F("foo", "bar"))
+	logger.Warn("warn")
+	logger.Error("error")
+
+	if len(entries) != 3 {
+		t.Fatalf("Unexpected number of entries are handled: %d.", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != LevelInfo {
+		t.Errorf("Unexpected level is set: %s.", entry.Level)
+	}
+
+	if entry.Message != "info" {
+		t.Errorf("Unexpected message is set: %s.", entry.Message)
+	}
+
+	if !entry.Time.Equal(now) {
+		t.Errorf("Unexpected time is set: %s.", entry.Time)
+	}
+
+	if len(entry.Fields) != 1 || entry.Fields[0].Key != "foo" || entry.Fields[0].Value != "bar" {
+		t.Errorf("Unexpected fields are set: %#v.", entry.Fields)
+	}
+
+	if entries[1].Level != LevelWarn || entries[2].Level != LevelError {
+		t.Errorf("Unexpected levels are set: %s and %s.", entries[1].Level, entries[2].Level)
+	}
+}
+
+func TestDefaultLogger_With(t *testing.T) {
+	var entries []*Entry
+	handler := &DummyHandler{
+		HandleFunc: func(entry *Entry) {
+			entries = append(entries, entry)
+		},
+	}
+	logger := NewLogger(handler)
+
+	botLogger := logger.With(F(KeyBotType, "slack"))
+	botLogger.With(F(KeyCommandID, "echo")).Info("command", F(KeyDestination, "C123"))
+	botLogger.Info("bot")
+	logger.Info("plain")
+
+	if len(entries) != 3 {
+		t.Fatalf("Unexpected number of entries are handled: %d.", len(entries))
+	}
+
+	expected := [][]string{
+		{KeyBotType, KeyCommandID, KeyDestination},
+		{KeyBotType},
+		{},
+	}
+	for i, keys := range expected {
+		fields := entries[i].Fields
+		if len(fields) != len(keys) {
+			t.Errorf("Unexpected number of fields are set on entry #%d: %#v.", i, fields)
+			continue
+		}
+
+		for ii, key := range keys {
+			if fields[ii].Key != key {
+				t.Errorf("Unexpected key is set on entry #%d: %s.", i, fields[ii].Key)
+			}
+		}
+	}
+}
+
+func TestDefaultLogger_With_SameKey(t *testing.T) {
+	var entry *Entry
+	handler := &DummyHandler{
+		HandleFunc: func(e *Entry) {
+			entry = e
+		},
+	}
+
+	NewLogger(handler).With(F(KeyBotType, "old"), F("foo", "bar")).With(F(KeyBotType, "new")).Info("message")
+
+	if len(entry.Fields) != 2 {
+		t.Fatalf("Unexpected number of fields are set: %#v.", entry.Fields)
+	}
+
+	if entry.Fields[0].Key != KeyBotType || entry.Fields[0].Value != "new" {
+		t.Errorf("Field with the same key is not replaced: %#v.", entry.Fields[0])
+	}
+}
+
+func TestDefaultLogger_Module(t *testing.T) {
+	var entries []*Entry
+	handler := &DummyHandler{
+		HandleFunc: func(entry *Entry) {
+			entries = append(entries, entry)
+		},
+	}
+	logger := NewLogger(handler, WithLevel(LevelWarn), WithModuleLevel("workers", LevelDebug))
+
+	logger.Module("workers").Debug("workers")
+	logger.Module("sarah").Debug("sarah")
+	logger.Module("sarah").Warn("sarah")
+
+	if len(entries) != 2 {
+		t.Fatalf("Unexpected number of entries are handled: %d.", len(entries))
+	}
+
+	if entries[0].Module != "workers" || entries[0].Message != "workers" {
+		t.Errorf("Unexpected entry is handled: %#v.", entries[0])
+	}
+
+	if entries[1].Module != "sarah" || entries[1].Level != LevelWarn {
+		t.Errorf("Unexpected entry is handled: %#v.", entries[1])
+	}
+
+	if !logger.Module("workers").Enabled(LevelDebug) {
+		t.Error("Debug level must be enabled for workers module.")
+	}
+
+	if logger.Module("sarah").Enabled(LevelInfo) {
+		t.Error("Info level must not be enabled for sarah module.")
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	old := GetLogger()
+	defer SetLogger(old)
+
+	logger := NewLogger(&DummyHandler{})
+	SetLogger(logger)
+
+	if GetLogger() != logger {
+		t.Error("Given logger is not set.")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != GetLogger() {
+		t.Error("Package-level Logger must be returned when the context carries none.")
+	}
+
+	logger := NewLogger(&DummyHandler{})
+	ctx := NewContext(context.Background(), logger)
+	if FromContext(ctx) != logger {
+		t.Error("Logger carried by the context is not returned.")
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/oklahomer/go-sarah/v4/logging/logrushandler
+
+go 1.21
+
+require (
+	github.com/oklahomer/go-sarah/v4 v4.1.0
+	github.com/sirupsen/logrus v1.9.3
+)
+
+require (
+	github.com/oklahomer/go-kasumi v0.0.0-20210320022217-84d2c0ccb359 // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+)
+
+// v4.1.0 is the first go-sarah release with the APIs this module uses. Until it is tagged,
+// this module builds against the local tree. Modules depending on this one ignore the replace below.
+replace github.com/oklahomer/go-sarah/v4 => ../../
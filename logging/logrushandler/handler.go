@@ -0,0 +1,81 @@
+/*
+Package logrushandler provides logging.Handler implementation that passes each entry to logrus' *logrus.Logger.
+
+	logrusLogger := logrus.New()
+	logrusLogger.SetFormatter(&logrus.JSONFormatter{})
+
+	logging.SetLogger(logging.NewLogger(logrushandler.New(logrusLogger)))
+
+This package is a separate Go module so the applications that do not use logrus do not depend on it.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+*/
+package logrushandler
+
+import (
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/sirupsen/logrus"
+)
+
+type handler struct {
+	logger *logrus.Logger
+}
+
+// New creates and returns a new logging.Handler that passes each entry to the given *logrus.Logger.
+// The module name is passed as "module" field when it is set.
+func New(logger *logrus.Logger) logging.Handler {
+	return &handler{
+		logger: logger,
+	}
+}
+
+func (h *handler) Handle(entry *logging.Entry) {
+	level := logrusLevel(entry.Level)
+	if !h.logger.IsLevelEnabled(level) {
+		return
+	}
+
+	fields := make(logrus.Fields, len(entry.Fields)+1)
+	if entry.Module != "" {
+		fields["module"] = entry.Module
+	}
+	for _, f := range entry.Fields {
+		fields[f.Key] = fieldValue(f.Value)
+	}
+
+	h.logger.WithTime(entry.Time).WithFields(fields).Log(level, entry.Message)
+}
+
+// fieldValue converts the given value to a form that is safe to be encoded.
+// An error is converted to its message because most error implementations have no exported field to be encoded.
+func fieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+
+	case fmt.Stringer:
+		return v.String()
+
+	default:
+		return v
+
+	}
+}
+
+func logrusLevel(level logging.Level) logrus.Level {
+	switch level {
+	case logging.LevelDebug:
+		return logrus.DebugLevel
+
+	case logging.LevelInfo:
+		return logrus.InfoLevel
+
+	case logging.LevelWarn:
+		return logrus.WarnLevel
+
+	default:
+		return logrus.ErrorLevel
+
+	}
+}
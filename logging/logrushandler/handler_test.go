@@ -0,0 +1,130 @@
+package logrushandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/sirupsen/logrus"
+	"testing"
+	"time"
+)
+
+type stringer struct{}
+
+func (stringer) String() string {
+	return "stringer"
+}
+
+func newLogger(buf *bytes.Buffer) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339})
+	logger.SetLevel(logrus.InfoLevel)
+	return logger
+}
+
+func TestNew(t *testing.T) {
+	logger := logrus.New()
+	h := New(logger)
+
+	typed, ok := h.(*handler)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", h)
+	}
+
+	if typed.logger != logger {
+		t.Error("Given logger is not set.")
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := New(newLogger(buf))
+
+	h.Handle(&logging.Entry{
+		Time:    time.Now(),
+		Level:   logging.LevelDebug,
+		Message: "debug",
+	})
+	if buf.Len() != 0 {
+		t.Fatalf("Disabled level must not be written: %s.", buf.String())
+	}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h.Handle(&logging.Entry{
+		Time:    now,
+		Level:   logging.LevelWarn,
+		Module:  "sarah",
+		Message: "warn",
+		Fields: []logging.Field{
+			logging.F(logging.KeyBotType, "slack"),
+			logging.F("stringer", stringer{}),
+			logging.Err(errors.New("failure")),
+		},
+	})
+
+	decoded := map[string]interface{}{}
+	err := json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if decoded["level"] != "warning" {
+		t.Errorf("Unexpected level is written: %#v.", decoded["level"])
+	}
+
+	if decoded["msg"] != "warn" {
+		t.Errorf("Unexpected message is written: %#v.", decoded["msg"])
+	}
+
+	if decoded["time"] != now.Format(time.RFC3339) {
+		t.Errorf("Unexpected time is written: %#v.", decoded["time"])
+	}
+
+	if decoded["module"] != "sarah" {
+		t.Errorf("Unexpected module is written: %#v.", decoded["module"])
+	}
+
+	if decoded[logging.KeyBotType] != "slack" {
+		t.Errorf("Unexpected bot_type is written: %#v.", decoded[logging.KeyBotType])
+	}
+
+	if decoded["stringer"] != "stringer" {
+		t.Errorf("Unexpected stringer is written: %#v.", decoded["stringer"])
+	}
+
+	if decoded[logging.KeyError] != "failure" {
+		t.Errorf("Unexpected error is written: %#v.", decoded[logging.KeyError])
+	}
+}
+
+func TestLogrusLevel(t *testing.T) {
+	tests := []struct {
+		level    logging.Level
+		expected logrus.Level
+	}{
+		{
+			level:    logging.LevelDebug,
+			expected: logrus.DebugLevel,
+		},
+		{
+			level:    logging.LevelInfo,
+			expected: logrus.InfoLevel,
+		},
+		{
+			level:    logging.LevelWarn,
+			expected: logrus.WarnLevel,
+		},
+		{
+			level:    logging.LevelError,
+			expected: logrus.ErrorLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		if level := logrusLevel(tt.level); level != tt.expected {
+			t.Errorf("Unexpected level is returned for %s: %s.", tt.level, level)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// redactedValue is the placeholder that replaces a redacted value.
+const redactedValue = "[REDACTED]"
+
+var (
+	redactions      []string
+	redactReplacer  *strings.Replacer
+	redactionsMutex sync.RWMutex
+)
+
+// Redact registers the given values such as API tokens to be masked in every log entry.
+// A Logger built with NewLogger replaces the registered values in the message and in the stringified field values with "[REDACTED]"
+// before passing the entry to the Handler, so a secret embedded in an error message or a URL does not leak to the logs.
+// sarah.ResolveSecrets calls this with each resolved secret. An empty value is ignored.
+func Redact(values ...string) {
+	redactionsMutex.Lock()
+	defer redactionsMutex.Unlock()
+
+NEXT:
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, registered := range redactions {
+			if registered == value {
+				continue NEXT
+			}
+		}
+		redactions = append(redactions, value)
+	}
+
+	var pairs []string
+	for _, value := range redactions {
+		pairs = append(pairs, value, redactedValue)
+	}
+	redactReplacer = strings.NewReplacer(pairs...)
+}
+
+func getRedactReplacer() *strings.Replacer {
+	redactionsMutex.RLock()
+	defer redactionsMutex.RUnlock()
+
+	return redactReplacer
+}
+
+// redact returns the message and the fields with the registered values masked.
+// The given fields are left untouched; a field whose value contains a registered value is replaced with a copy that holds the masked string.
+func redact(message string, fields []Field) (string, []Field) {
+	replacer := getRedactReplacer()
+	if replacer == nil {
+		return message, fields
+	}
+
+	message = replacer.Replace(message)
+
+	var masked []Field
+	for i, f := range fields {
+		var str string
+		switch v := fieldValue(f.Value).(type) {
+		case string:
+			str = v
+
+		default:
+			str = fmt.Sprintf("%+v", v)
+
+		}
+
+		replaced := replacer.Replace(str)
+		if replaced == str {
+			continue
+		}
+
+		if masked == nil {
+			masked = make([]Field, len(fields))
+			copy(masked, fields)
+		}
+		masked[i] = F(f.Key, replaced)
+	}
+
+	if masked == nil {
+		return message, fields
+	}
+	return message, masked
+}
@@ -0,0 +1,57 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogHandler struct {
+	logger *slog.Logger
+}
+
+// NewSlogHandler creates and returns a new Handler that passes each entry to the given *slog.Logger.
+// The module name is passed as "module" attribute when it is set.
+func NewSlogHandler(logger *slog.Logger) Handler {
+	return &slogHandler{
+		logger: logger,
+	}
+}
+
+func (h *slogHandler) Handle(entry *Entry) {
+	level := slogLevel(entry.Level)
+	if !h.logger.Enabled(context.Background(), level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, len(entry.Fields)+1)
+	if entry.Module != "" {
+		attrs = append(attrs, slog.String("module", entry.Module))
+	}
+	for _, f := range entry.Fields {
+		attrs = append(attrs, slog.Any(f.Key, fieldValue(f.Value)))
+	}
+
+	record := slog.NewRecord(entry.Time, level, entry.Message, 0)
+	record.AddAttrs(attrs...)
+	_ = h.logger.Handler().Handle(context.Background(), record)
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+
+	case LevelInfo:
+		return slog.LevelInfo
+
+	case LevelWarn:
+		return slog.LevelWarn
+
+	default:
+		return slog.LevelError
+
+	}
+}
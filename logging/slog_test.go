@@ -0,0 +1,58 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler_Handle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := NewSlogHandler(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	handler.Handle(&Entry{
+		Time:    time.Now(),
+		Level:   LevelDebug,
+		Message: "debug",
+	})
+	if buf.Len() != 0 {
+		t.Fatalf("Disabled level must not be written: %s.", buf.String())
+	}
+
+	handler.Handle(&Entry{
+		Time:    time.Now(),
+		Level:   LevelError,
+		Module:  "sarah",
+		Message: "error",
+		Fields: []Field{
+			F(KeyBotType, "slack"),
+		},
+	})
+
+	decoded := map[string]interface{}{}
+	err := json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if decoded["level"] != "ERROR" {
+		t.Errorf("Unexpected level is written: %#v.", decoded["level"])
+	}
+
+	if decoded["msg"] != "error" {
+		t.Errorf("Unexpected message is written: %#v.", decoded["msg"])
+	}
+
+	if decoded["module"] != "sarah" {
+		t.Errorf("Unexpected module is written: %#v.", decoded["module"])
+	}
+
+	if decoded[KeyBotType] != "slack" {
+		t.Errorf("Unexpected bot_type is written: %#v.", decoded[KeyBotType])
+	}
+}
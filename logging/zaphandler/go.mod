@@ -0,0 +1,17 @@
+module github.com/oklahomer/go-sarah/v4/logging/zaphandler
+
+go 1.21
+
+require (
+	github.com/oklahomer/go-sarah/v4 v4.1.0
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/oklahomer/go-kasumi v0.0.0-20210320022217-84d2c0ccb359 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+)
+
+// v4.1.0 is the first go-sarah release with the APIs this module uses. Until it is tagged,
+// this module builds against the local tree. Modules depending on this one ignore the replace below.
+replace github.com/oklahomer/go-sarah/v4 => ../../
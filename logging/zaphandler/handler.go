@@ -0,0 +1,71 @@
+/*
+Package zaphandler provides logging.Handler implementation that passes each entry to zap's *zap.Logger.
+
+	zapLogger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+	defer zapLogger.Sync()
+
+	logging.SetLogger(logging.NewLogger(zaphandler.New(zapLogger)))
+
+This package is a separate Go module so the applications that do not use zap do not depend on it.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+*/
+package zaphandler
+
+import (
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type handler struct {
+	logger *zap.Logger
+}
+
+// New creates and returns a new logging.Handler that passes each entry to the given *zap.Logger.
+// The module name is passed as "module" field when it is set.
+func New(logger *zap.Logger) logging.Handler {
+	return &handler{
+		logger: logger,
+	}
+}
+
+func (h *handler) Handle(entry *logging.Entry) {
+	checked := h.logger.Check(zapLevel(entry.Level), entry.Message)
+	if checked == nil {
+		return
+	}
+
+	// The time of the entry is kept instead of the time this method is called.
+	checked.Time = entry.Time
+
+	fields := make([]zap.Field, 0, len(entry.Fields)+1)
+	if entry.Module != "" {
+		fields = append(fields, zap.String("module", entry.Module))
+	}
+	for _, f := range entry.Fields {
+		// zap.Any encodes error and fmt.Stringer with their messages.
+		fields = append(fields, zap.Any(f.Key, f.Value))
+	}
+	checked.Write(fields...)
+}
+
+func zapLevel(level logging.Level) zapcore.Level {
+	switch level {
+	case logging.LevelDebug:
+		return zapcore.DebugLevel
+
+	case logging.LevelInfo:
+		return zapcore.InfoLevel
+
+	case logging.LevelWarn:
+		return zapcore.WarnLevel
+
+	default:
+		return zapcore.ErrorLevel
+
+	}
+}
@@ -0,0 +1,120 @@
+package zaphandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"testing"
+	"time"
+)
+
+func newLogger(buf *bytes.Buffer) *zap.Logger {
+	config := zap.NewProductionEncoderConfig()
+	config.TimeKey = "time"
+	config.EncodeTime = zapcore.RFC3339TimeEncoder
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(config), zapcore.AddSync(buf), zapcore.InfoLevel)
+	return zap.New(core)
+}
+
+func TestNew(t *testing.T) {
+	logger := zap.NewNop()
+	h := New(logger)
+
+	typed, ok := h.(*handler)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", h)
+	}
+
+	if typed.logger != logger {
+		t.Error("Given logger is not set.")
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := New(newLogger(buf))
+
+	h.Handle(&logging.Entry{
+		Time:    time.Now(),
+		Level:   logging.LevelDebug,
+		Message: "debug",
+	})
+	if buf.Len() != 0 {
+		t.Fatalf("Disabled level must not be written: %s.", buf.String())
+	}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h.Handle(&logging.Entry{
+		Time:    now,
+		Level:   logging.LevelError,
+		Module:  "sarah",
+		Message: "error",
+		Fields: []logging.Field{
+			logging.F(logging.KeyBotType, "slack"),
+			logging.Err(errors.New("failure")),
+		},
+	})
+
+	decoded := map[string]interface{}{}
+	err := json.Unmarshal(buf.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if decoded["level"] != "error" {
+		t.Errorf("Unexpected level is written: %#v.", decoded["level"])
+	}
+
+	if decoded["msg"] != "error" {
+		t.Errorf("Unexpected message is written: %#v.", decoded["msg"])
+	}
+
+	if decoded["time"] != now.Format(time.RFC3339) {
+		t.Errorf("Unexpected time is written: %#v.", decoded["time"])
+	}
+
+	if decoded["module"] != "sarah" {
+		t.Errorf("Unexpected module is written: %#v.", decoded["module"])
+	}
+
+	if decoded[logging.KeyBotType] != "slack" {
+		t.Errorf("Unexpected bot_type is written: %#v.", decoded[logging.KeyBotType])
+	}
+
+	if decoded[logging.KeyError] != "failure" {
+		t.Errorf("Unexpected error is written: %#v.", decoded[logging.KeyError])
+	}
+}
+
+func TestZapLevel(t *testing.T) {
+	tests := []struct {
+		level    logging.Level
+		expected zapcore.Level
+	}{
+		{
+			level:    logging.LevelDebug,
+			expected: zapcore.DebugLevel,
+		},
+		{
+			level:    logging.LevelInfo,
+			expected: zapcore.InfoLevel,
+		},
+		{
+			level:    logging.LevelWarn,
+			expected: zapcore.WarnLevel,
+		},
+		{
+			level:    logging.LevelError,
+			expected: zapcore.ErrorLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		if level := zapLevel(tt.level); level != tt.expected {
+			t.Errorf("Unexpected level is returned for %s: %s.", tt.level, level)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+module github.com/oklahomer/go-sarah/v4/luascript
+
+go 1.21
+
+require (
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/oklahomer/go-sarah/v4 v4.1.0
+	github.com/yuin/gopher-lua v1.1.1
+)
+
+require (
+	github.com/oklahomer/go-kasumi v0.0.0-20210320022217-84d2c0ccb359 // indirect
+	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
+	github.com/robfig/cron/v3 v3.0.1 // indirect
+	golang.org/x/sys v0.0.0-20210507161434-a76c4d0a0096 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+// v4.1.0 is the first go-sarah release with the APIs this module uses. Until it is tagged,
+// this module builds against the local tree. Modules depending on this one ignore the replace below.
+replace github.com/oklahomer/go-sarah/v4 => ../
@@ -0,0 +1,424 @@
+/*
+Package luascript turns Lua scripts in a directory into sarah.Command implementations so trivial commands can be added without rebuilding the bot.
+
+Each *.lua file in Config.Dir becomes one sarah.Command whose identifier is the file name without the extension.
+A script declares the match pattern in Go's regular expression syntax, the instruction, and an execute function:
+
+	-- hello.lua
+	pattern = "^\\.hello"
+	instruction = "Input .hello to say hello."
+
+	function execute(input)
+	  -- input.message, input.sender_key and input.sent_at (UNIX time) are available.
+	  return "Hello, " .. input.sender_key
+	end
+
+A string returned by execute is sent back to where the input came from. Return nil to send nothing.
+
+Scripts run in a sandbox: only the base, table, string and math libraries are available,
+functions that access the file system or load other code such as dofile and require are removed,
+and each execution is canceled when Config.Timeout passes.
+Every execution runs in a fresh Lua state so no state leaks between executions.
+
+Loader.Run watches the directory and reloads a script when its file is updated.
+A removed script stops matching any input.
+A script added while running is not registered to the Bots til the process restarts.
+
+	loader, err := luascript.NewLoader(config)
+	if err != nil {
+		panic(err)
+	}
+	for _, command := range loader.Commands() {
+		sarah.RegisterScopedCommand(sarah.AllBots(), command)
+	}
+	go loader.Run(ctx)
+
+This package is a separate Go module so the applications that do not run Lua scripts do not depend on gopher-lua.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+*/
+package luascript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// moduleLogger returns the Logger carried by the given context with this package's module name.
+func moduleLogger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("luascript")
+}
+
+const (
+	scriptExt = ".lua"
+
+	patternGlobal     = "pattern"
+	instructionGlobal = "instruction"
+	executeGlobal     = "execute"
+)
+
+// removedGlobals are the functions of the base library that access the file system or load arbitrary code.
+var removedGlobals = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "print", "_printregs", "collectgarbage"}
+
+// Config contains some configuration variables for the scripted commands.
+type Config struct {
+	// Dir is the directory to read *.lua scripts from. Sub-directories are not read.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// Timeout is the maximum duration of a script execution including the top-level statements.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// NewConfig returns a new Config instance with default values.
+// Dir must be set before use.
+func NewConfig() *Config {
+	return &Config{
+		Dir:     "",
+		Timeout: 3 * time.Second,
+	}
+}
+
+// Loader reads the scripts and keeps the corresponding Commands up to date.
+type Loader struct {
+	config   *Config
+	mutex    sync.RWMutex
+	commands map[string]*scriptCommand
+}
+
+// NewLoader reads and compiles the scripts in Config.Dir and returns a new Loader.
+// An error is returned when any of the scripts fails to compile or lacks the pattern or the execute function.
+func NewLoader(config *Config) (*Loader, error) {
+	if config.Dir == "" {
+		return nil, errors.New("script directory is not given")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(config.Dir, "*"+scriptExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", config.Dir, err)
+	}
+
+	loader := &Loader{
+		config:   config,
+		commands: map[string]*scriptCommand{},
+	}
+	for _, path := range paths {
+		s, err := compileFile(path, config.Timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		id := scriptID(path)
+		loader.commands[id] = &scriptCommand{
+			id:      id,
+			timeout: config.Timeout,
+			script:  s,
+		}
+	}
+
+	return loader, nil
+}
+
+// Commands returns the Commands corresponding to the scripts in lexical order of their identifiers.
+// Pass them to sarah.RegisterCommand or sarah.RegisterScopedCommand.
+func (l *Loader) Commands() []sarah.Command {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	ids := make([]string, 0, len(l.commands))
+	for id := range l.commands {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	commands := make([]sarah.Command, 0, len(ids))
+	for _, id := range ids {
+		commands = append(commands, l.commands[id])
+	}
+	return commands
+}
+
+// Run watches Config.Dir and reloads the updated scripts til the given context is canceled.
+// When an updated script fails to compile, the error is logged and the previous version keeps running.
+func (l *Loader) Run(ctx context.Context) error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer fsWatcher.Close()
+
+	err = fsWatcher.Add(l.config.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", l.config.Dir, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event := <-fsWatcher.Events:
+			if filepath.Ext(event.Name) != scriptExt {
+				continue
+			}
+			l.reload(ctx, event.Name)
+
+		case err := <-fsWatcher.Errors:
+			moduleLogger(ctx).Error("Error on watching scripts", logging.F("dir", l.config.Dir), logging.Err(err))
+
+		}
+	}
+}
+
+// reload reflects the current state of the script file at the given path to the corresponding Command.
+func (l *Loader) reload(ctx context.Context, path string) {
+	id := scriptID(path)
+	log := moduleLogger(ctx).With(logging.F("path", path))
+
+	l.mutex.RLock()
+	command, ok := l.commands[id]
+	l.mutex.RUnlock()
+	if !ok {
+		if _, err := os.Stat(path); err == nil {
+			log.Warn("New script is found. Restart the process to register it")
+		}
+		return
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		log.Info("Script is removed. Disabling command", logging.F(logging.KeyCommandID, id))
+		command.replace(nil)
+		return
+	}
+
+	s, err := compileFile(path, l.config.Timeout)
+	if err != nil {
+		log.Error("Failed to reload script", logging.F(logging.KeyCommandID, id), logging.Err(err))
+		return
+	}
+
+	log.Info("Reloaded script", logging.F(logging.KeyCommandID, id))
+	command.replace(s)
+}
+
+// script is a compiled script and the values it declares.
+type script struct {
+	proto       *lua.FunctionProto
+	pattern     *regexp.Regexp
+	instruction string
+}
+
+// scriptCommand is a sarah.Command that executes a script.
+// The script is replaced when the file is reloaded, and is nil when the file is removed.
+type scriptCommand struct {
+	id      string
+	timeout time.Duration
+	mutex   sync.RWMutex
+	script  *script
+}
+
+var _ sarah.Command = (*scriptCommand)(nil)
+
+func (c *scriptCommand) Identifier() string {
+	return c.id
+}
+
+func (c *scriptCommand) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	s := c.current()
+	if s == nil {
+		return nil, nil
+	}
+
+	res, err := s.execute(ctx, c.timeout, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute script %s: %w", c.id, err)
+	}
+	if res == "" {
+		return nil, nil
+	}
+
+	return &sarah.CommandResponse{
+		Content:     res,
+		UserContext: nil,
+	}, nil
+}
+
+func (c *scriptCommand) Instruction(_ *sarah.HelpInput) string {
+	s := c.current()
+	if s == nil {
+		return ""
+	}
+	return s.instruction
+}
+
+func (c *scriptCommand) Match(input sarah.Input) bool {
+	s := c.current()
+	if s == nil {
+		return false
+	}
+	return s.pattern.MatchString(input.Message())
+}
+
+func (c *scriptCommand) current() *script {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.script
+}
+
+func (c *scriptCommand) replace(s *script) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.script = s
+}
+
+func scriptID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), scriptExt)
+}
+
+func compileFile(path string, timeout time.Duration) (*script, error) {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	s, err := compile(filepath.Base(path), string(source), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// compile compiles the given source and runs its top-level statements once to read the declared pattern and instruction.
+func compile(name string, source string, timeout time.Duration) (*script, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	state, err := load(ctx, proto)
+	if err != nil {
+		return nil, err
+	}
+	defer state.Close()
+
+	pattern, ok := state.GetGlobal(patternGlobal).(lua.LString)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("%s is not declared as a string", patternGlobal)
+	}
+
+	compiled, err := regexp.Compile(string(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", patternGlobal, err)
+	}
+
+	if _, ok := state.GetGlobal(executeGlobal).(*lua.LFunction); !ok {
+		return nil, fmt.Errorf("%s is not declared as a function", executeGlobal)
+	}
+
+	return &script{
+		proto:       proto,
+		pattern:     compiled,
+		instruction: lua.LVAsString(state.GetGlobal(instructionGlobal)),
+	}, nil
+}
+
+// execute calls the script's execute function with the given input in a new sandboxed state.
+func (s *script) execute(ctx context.Context, timeout time.Duration, input sarah.Input) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	state, err := load(ctx, s.proto)
+	if err != nil {
+		return "", err
+	}
+	defer state.Close()
+
+	arg := state.NewTable()
+	arg.RawSetString("message", lua.LString(input.Message()))
+	arg.RawSetString("sender_key", lua.LString(input.SenderKey()))
+	arg.RawSetString("sent_at", lua.LNumber(input.SentAt().Unix()))
+
+	err = state.CallByParam(lua.P{
+		Fn:      state.GetGlobal(executeGlobal),
+		NRet:    1,
+		Protect: true,
+	}, arg)
+	if err != nil {
+		return "", err
+	}
+
+	ret := state.Get(-1)
+	state.Pop(1)
+	if ret == lua.LNil {
+		return "", nil
+	}
+
+	str, ok := ret.(lua.LString)
+	if !ok {
+		return "", fmt.Errorf("%s returned %s instead of a string", executeGlobal, ret.Type().String())
+	}
+	return string(str), nil
+}
+
+// load creates a new sandboxed state and runs the top-level statements of the given script in it.
+// The returned state must be closed by the caller.
+func load(ctx context.Context, proto *lua.FunctionProto) (*lua.LState, error) {
+	state := newSandbox()
+	state.SetContext(ctx)
+
+	state.Push(state.NewFunctionFromProto(proto))
+	err := state.PCall(0, lua.MultRet, nil)
+	if err != nil {
+		state.Close()
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func newSandbox() *lua.LState {
+	state := lua.NewState(lua.Options{
+		SkipOpenLibs: true,
+	})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		state.Push(state.NewFunction(lib.open))
+		state.Push(lua.LString(lib.name))
+		state.Call(1, 0)
+	}
+
+	for _, name := range removedGlobals {
+		state.SetGlobal(name, lua.LNil)
+	}
+
+	return state
+}
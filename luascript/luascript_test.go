@@ -0,0 +1,316 @@
+package luascript
+
+import (
+	"bytes"
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+	ReplyToValue   sarah.OutputDestination
+}
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+const helloScript = `
+pattern = "^\\.hello"
+instruction = "Input .hello to say hello."
+
+function execute(input)
+  return "Hello, " .. input.sender_key .. ": " .. input.message
+end
+`
+
+func scriptDir(t *testing.T, scripts map[string]string) string {
+	dir, err := ioutil.TempDir("", "luascript")
+	if err != nil {
+		t.Fatalf("Failed to create a directory: %s.", err.Error())
+	}
+	for name, source := range scripts {
+		writeScript(t, dir, name, source)
+	}
+	return dir
+}
+
+func writeScript(t *testing.T, dir string, name string, source string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(source), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write a script: %s.", err.Error())
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+
+	if config.Dir != "" {
+		t.Errorf("Unexpected default directory is set: %s.", config.Dir)
+	}
+
+	if config.Timeout != 3*time.Second {
+		t.Errorf("Unexpected default timeout is set: %s.", config.Timeout)
+	}
+}
+
+func TestNewLoader(t *testing.T) {
+	dir := scriptDir(t, map[string]string{
+		"hello.lua": helloScript,
+		"bye.lua": `
+pattern = "^\\.bye"
+function execute(input)
+  return nil
+end
+`,
+		"README.md": "Not a script.",
+	})
+	defer os.RemoveAll(dir)
+
+	config := NewConfig()
+	config.Dir = dir
+	loader, err := NewLoader(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	commands := loader.Commands()
+	if len(commands) != 2 {
+		t.Fatalf("Unexpected number of commands are returned: %d.", len(commands))
+	}
+
+	if commands[0].Identifier() != "bye" || commands[1].Identifier() != "hello" {
+		t.Errorf("Unexpected commands are returned: %s and %s.", commands[0].Identifier(), commands[1].Identifier())
+	}
+
+	hello := commands[1]
+	if hello.Instruction(&sarah.HelpInput{}) != "Input .hello to say hello." {
+		t.Errorf("Unexpected instruction is returned: %s.", hello.Instruction(&sarah.HelpInput{}))
+	}
+
+	input := &DummyInput{SenderKeyValue: "alice", MessageValue: ".hello world"}
+	if !hello.Match(input) {
+		t.Fatal("Command must match.")
+	}
+
+	if hello.Match(&DummyInput{MessageValue: ".bye"}) {
+		t.Error("Command must not match.")
+	}
+
+	res, err := hello.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res == nil || res.Content != "Hello, alice: .hello world" {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+
+	res, err = commands[0].Execute(context.TODO(), &DummyInput{MessageValue: ".bye"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res != nil {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+}
+
+func TestNewLoader_WithInvalidScript(t *testing.T) {
+	tests := []string{
+		`pattern = `,
+		`function execute(input) return "" end`,
+		`pattern = "(" function execute(input) return "" end`,
+		`pattern = "^\\.foo"`,
+		`pattern = "^\\.foo" execute = "bar"`,
+		`while true do end`,
+	}
+
+	for i, tt := range tests {
+		func() {
+			dir := scriptDir(t, map[string]string{"invalid.lua": tt})
+			defer os.RemoveAll(dir)
+
+			config := NewConfig()
+			config.Dir = dir
+			config.Timeout = 100 * time.Millisecond
+			_, err := NewLoader(config)
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+		}()
+	}
+}
+
+func TestNewLoader_WithoutDir(t *testing.T) {
+	_, err := NewLoader(NewConfig())
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func Test_scriptCommand_Execute_Sandbox(t *testing.T) {
+	tests := []struct {
+		body   string
+		result string
+	}{
+		{
+			body:   `return tostring(io) .. tostring(os) .. tostring(dofile) .. tostring(require) .. tostring(load)`,
+			result: "nilnilnilnilnil",
+		},
+		{
+			body:   `return string.upper(input.message) .. math.floor(1.5) .. table.concat({"a", "b"})`,
+			result: ".SANDBOX1ab",
+		},
+	}
+
+	for i, tt := range tests {
+		s, err := compile("sandbox.lua", `pattern = "sandbox" function execute(input) `+tt.body+` end`, time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		result, err := s.execute(context.TODO(), time.Second, &DummyInput{MessageValue: ".sandbox"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		if result != tt.result {
+			t.Errorf("Unexpected result is returned on test #%d: %s.", i, result)
+		}
+	}
+}
+
+func Test_scriptCommand_Execute_WithError(t *testing.T) {
+	tests := []string{
+		`while true do end`,
+		`error("failure")`,
+		`return 1`,
+		`return io.open("/etc/passwd")`,
+	}
+
+	for i, tt := range tests {
+		s, err := compile("error.lua", `pattern = "error" function execute(input) `+tt+` end`, time.Second)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		command := &scriptCommand{id: "error", timeout: 100 * time.Millisecond, script: s}
+		_, err = command.Execute(context.TODO(), &DummyInput{})
+		if err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), "error") {
+			t.Errorf("Identifier is not included in the error: %s.", err.Error())
+		}
+	}
+}
+
+func TestLoader_reload(t *testing.T) {
+	dir := scriptDir(t, map[string]string{"hello.lua": helloScript})
+	defer os.RemoveAll(dir)
+
+	config := NewConfig()
+	config.Dir = dir
+	loader, err := NewLoader(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	command := loader.Commands()[0]
+	path := filepath.Join(dir, "hello.lua")
+	buf := &bytes.Buffer{}
+	ctx := logging.NewContext(context.Background(), logging.NewLogger(logging.NewJSONHandler(buf)))
+
+	// Broken script is ignored and the previous version keeps running.
+	writeScript(t, dir, "hello.lua", `pattern = `)
+	loader.reload(ctx, path)
+	if !command.Match(&DummyInput{MessageValue: ".hello"}) {
+		t.Error("Previous script must keep running.")
+	}
+	for _, expected := range []string{`"module":"luascript"`, `"level":"error"`, `"command_id":"hello"`, `"path":`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s is not logged: %s.", expected, buf.String())
+		}
+	}
+
+	writeScript(t, dir, "hello.lua", `pattern = "^\\.hi" function execute(input) return "Hi" end`)
+	loader.reload(ctx, path)
+	if !command.Match(&DummyInput{MessageValue: ".hi"}) {
+		t.Error("Updated script is not reloaded.")
+	}
+
+	_ = os.Remove(path)
+	loader.reload(ctx, path)
+	if command.Match(&DummyInput{MessageValue: ".hi"}) {
+		t.Error("Removed script must not match.")
+	}
+
+	if command.Instruction(&sarah.HelpInput{}) != "" {
+		t.Error("Removed script must not return instruction.")
+	}
+
+	res, err := command.Execute(context.TODO(), &DummyInput{MessageValue: ".hi"})
+	if err != nil || res != nil {
+		t.Errorf("Removed script must not be executed: %#v, %#v.", res, err)
+	}
+
+	// Script added while running is not registered.
+	writeScript(t, dir, "new.lua", helloScript)
+	loader.reload(ctx, filepath.Join(dir, "new.lua"))
+	if len(loader.Commands()) != 1 {
+		t.Errorf("Unexpected number of commands are returned: %d.", len(loader.Commands()))
+	}
+}
+
+func TestLoader_Run(t *testing.T) {
+	dir := scriptDir(t, map[string]string{"hello.lua": helloScript})
+	defer os.RemoveAll(dir)
+
+	config := NewConfig()
+	config.Dir = dir
+	loader, err := NewLoader(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	command := loader.Commands()[0]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = loader.Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	writeScript(t, dir, "hello.lua", `pattern = "^\\.hi" function execute(input) return "Hi" end`)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !command.Match(&DummyInput{MessageValue: ".hi"}) {
+		if time.Now().After(deadline) {
+			t.Fatal("Updated script is not reloaded.")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,193 @@
+package sarah
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxQueuedInputs is the number of Inputs a Bot keeps during maintenance when MaintenanceConfig.QueueInputs is true, or while paused by PauseBot.
+// Inputs beyond this are dropped so a long maintenance or pause does not exhaust the memory.
+const maxQueuedInputs = 1000
+
+// MaintenanceConfig contains some configuration variables for the maintenance mode started by StartMaintenance.
+type MaintenanceConfig struct {
+	// Message is the reply to the Input that matches a Command during maintenance.
+	// This is not sent when QueueInputs is true.
+	Message string `json:"message" yaml:"message"`
+
+	// QueueInputs tells the Bot to keep the Inputs that match Commands without any reply, and to handle them when the maintenance ends.
+	QueueInputs bool `json:"queue_inputs" yaml:"queue_inputs"`
+
+	// PauseScheduledTasks tells the Bot to skip the ScheduledTasks' scheduled runs during maintenance.
+	// The skipped runs are not executed later. RunScheduledTask still runs a ScheduledTask.
+	PauseScheduledTasks bool `json:"pause_scheduled_tasks" yaml:"pause_scheduled_tasks"`
+
+	// ExemptCommands are the identifiers of the Commands that keep working during maintenance.
+	// Give the identifier of the Command that ends the maintenance so an administrator can still use it.
+	ExemptCommands []string `json:"exempt_commands" yaml:"exempt_commands"`
+}
+
+// NewMaintenanceConfig returns a pointer to MaintenanceConfig with default setting.
+func NewMaintenanceConfig() *MaintenanceConfig {
+	return &MaintenanceConfig{
+		Message:             "Sorry, I am under maintenance. Please try again later.",
+		QueueInputs:         false,
+		PauseScheduledTasks: false,
+		ExemptCommands:      []string{},
+	}
+}
+
+// MaintenanceInfo represents the maintenance mode of a running Bot.
+type MaintenanceInfo struct {
+	BotType BotType
+	Config  *MaintenanceConfig
+	Since   time.Time
+
+	// Queued is the number of the Inputs kept to be handled when the maintenance ends.
+	Queued int
+}
+
+// StartMaintenance puts the running Bot with the given BotType into maintenance mode.
+// While in maintenance, an Input that matches a Command gets MaintenanceConfig.Message as a reply or is queued,
+// and the ScheduledTasks are paused when MaintenanceConfig.PauseScheduledTasks is true.
+// Calling this during maintenance replaces the MaintenanceConfig and keeps the queued Inputs.
+//
+// The maintenance mode ends with EndMaintenance, or when the Bot stops.
+func StartMaintenance(botType BotType, config *MaintenanceConfig) error {
+	return runnerStatus.components.startMaintenance(botType, config)
+}
+
+// EndMaintenance ends the maintenance mode of the Bot with the given BotType started by StartMaintenance.
+// The queued Inputs are handled in the received order.
+// Nothing happens when the Bot is not in maintenance.
+func EndMaintenance(botType BotType) error {
+	return runnerStatus.components.endMaintenance(botType)
+}
+
+// ListMaintenances returns the maintenance modes of the running Bots sorted by BotType.
+func ListMaintenances() []*MaintenanceInfo {
+	return runnerStatus.components.listMaintenances()
+}
+
+// maintenance is the state of a Bot in maintenance mode.
+type maintenance struct {
+	config *MaintenanceConfig
+	since  time.Time
+	queued []Input
+}
+
+func (m *maintenance) exempt(id string) bool {
+	for _, exempt := range m.config.ExemptCommands {
+		if exempt == id {
+			return true
+		}
+	}
+	return false
+}
+
+// receiver records the function that handles an Input on the running Bot so the queued Inputs can be handled after maintenance.
+func (m *managedComponents) receiver(botType BotType, receive func(Input)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.receivers == nil {
+		m.receivers = map[BotType]func(Input){}
+	}
+	m.receivers[botType] = receive
+}
+
+func (m *managedComponents) startMaintenance(botType BotType, config *MaintenanceConfig) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.receivers[botType]; !ok {
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, botType)
+	}
+
+	if m.maintenances == nil {
+		m.maintenances = map[BotType]*maintenance{}
+	}
+	if current, ok := m.maintenances[botType]; ok {
+		current.config = config
+		return nil
+	}
+	m.maintenances[botType] = &maintenance{
+		config: config,
+		since:  time.Now(),
+	}
+	return nil
+}
+
+func (m *managedComponents) endMaintenance(botType BotType) error {
+	m.mutex.Lock()
+	receive, ok := m.receivers[botType]
+	current, inMaintenance := m.maintenances[botType]
+	delete(m.maintenances, botType)
+	m.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, botType)
+	}
+	if !inMaintenance {
+		return nil
+	}
+
+	// Call the function without the lock since the Input goes through the Commands again.
+	for _, input := range current.queued {
+		receive(input)
+	}
+	return nil
+}
+
+// inMaintenance returns the maintenance of the Bot with the given BotType that the Command with the given identifier is subject to.
+func (m *managedComponents) inMaintenance(botType BotType, id string) (*MaintenanceConfig, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	current, ok := m.maintenances[botType]
+	if !ok || current.exempt(id) {
+		return nil, false
+	}
+	return current.config, true
+}
+
+// queue keeps the given Input til the maintenance ends. false is returned when the Input is not kept.
+func (m *managedComponents) queue(botType BotType, input Input) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	current, ok := m.maintenances[botType]
+	if !ok || len(current.queued) >= maxQueuedInputs {
+		return false
+	}
+	current.queued = append(current.queued, input)
+	return true
+}
+
+func (m *managedComponents) tasksPaused(botType BotType) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	current, ok := m.maintenances[botType]
+	return ok && current.config.PauseScheduledTasks
+}
+
+func (m *managedComponents) listMaintenances() []*MaintenanceInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var infos []*MaintenanceInfo
+	for botType, current := range m.maintenances {
+		infos = append(infos, &MaintenanceInfo{
+			BotType: botType,
+			Config:  current.config,
+			Since:   current.since,
+			Queued:  len(current.queued),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].BotType < infos[j].BotType
+	})
+	return infos
+}
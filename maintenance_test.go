@@ -0,0 +1,157 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewMaintenanceConfig(t *testing.T) {
+	config := NewMaintenanceConfig()
+	if config.Message == "" {
+		t.Error("Default message is not set.")
+	}
+	if config.QueueInputs || config.PauseScheduledTasks {
+		t.Errorf("Unexpected default values are set: %#v.", config)
+	}
+}
+
+func TestStartMaintenance(t *testing.T) {
+	SetupAndRun(func() {
+		err := StartMaintenance("dummy", NewMaintenanceConfig())
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		err = EndMaintenance("dummy")
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		var received []Input
+		runnerStatus.components.receiver("dummy", func(input Input) {
+			received = append(received, input)
+		})
+		executed := 0
+		newCommand := func(id string) Command {
+			return runnerStatus.components.command("dummy", &DummyCommand{
+				IdentifierValue: id,
+				ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+					executed++
+					return &CommandResponse{Content: "executed"}, nil
+				},
+			})
+		}
+		echo := newCommand("echo")
+		admin := newCommand("admin")
+
+		config := NewMaintenanceConfig()
+		config.PauseScheduledTasks = true
+		config.ExemptCommands = []string{"admin"}
+		err = StartMaintenance("dummy", config)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		res, err := echo.Execute(context.TODO(), &DummyInput{})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if res == nil || res.Content != config.Message {
+			t.Errorf("Maintenance message is not returned: %#v.", res)
+		}
+
+		res, err = admin.Execute(context.TODO(), &DummyInput{})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if res == nil || res.Content != "executed" {
+			t.Errorf("Exempt command is not executed: %#v.", res)
+		}
+		if executed != 1 {
+			t.Errorf("Unexpected number of executions: %d.", executed)
+		}
+
+		if !runnerStatus.components.tasksPaused("dummy") {
+			t.Error("Scheduled tasks must be paused.")
+		}
+
+		infos := ListMaintenances()
+		if len(infos) != 1 || infos[0].BotType != "dummy" || infos[0].Config != config {
+			t.Errorf("Unexpected maintenances are listed: %#v.", infos)
+		}
+
+		err = EndMaintenance("dummy")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if runnerStatus.components.tasksPaused("dummy") {
+			t.Error("Scheduled tasks must be resumed.")
+		}
+		if len(ListMaintenances()) != 0 {
+			t.Errorf("Ended maintenance must not be listed: %#v.", ListMaintenances())
+		}
+		if len(received) != 0 {
+			t.Errorf("Unexpected inputs are handled: %#v.", received)
+		}
+
+		_, _ = echo.Execute(context.TODO(), &DummyInput{})
+		if executed != 2 {
+			t.Errorf("Command must be executed after maintenance: %d.", executed)
+		}
+
+		runnerStatus.components.removeBot("dummy")
+	})
+}
+
+func TestStartMaintenance_QueueInputs(t *testing.T) {
+	SetupAndRun(func() {
+		var received []Input
+		runnerStatus.components.receiver("dummy", func(input Input) {
+			received = append(received, input)
+		})
+		command := runnerStatus.components.command("dummy", &DummyCommand{
+			IdentifierValue: "echo",
+			ExecuteFunc: func(_ context.Context, _ Input) (*CommandResponse, error) {
+				t.Error("Command must not be executed during maintenance.")
+				return nil, nil
+			},
+		})
+
+		config := NewMaintenanceConfig()
+		config.QueueInputs = true
+		err := StartMaintenance("dummy", config)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		first := &DummyInput{MessageValue: "first"}
+		second := &DummyInput{MessageValue: "second"}
+		for _, input := range []Input{first, second} {
+			res, err := command.Execute(context.TODO(), input)
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+			if res != nil {
+				t.Errorf("Queued input must not be replied: %#v.", res)
+			}
+		}
+
+		infos := ListMaintenances()
+		if len(infos) != 1 || infos[0].Queued != 2 {
+			t.Errorf("Unexpected maintenances are listed: %#v.", infos)
+		}
+
+		err = EndMaintenance("dummy")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if len(received) != 2 || received[0] != first || received[1] != second {
+			t.Errorf("Queued inputs are not handled in order: %#v.", received)
+		}
+
+		runnerStatus.components.removeBot("dummy")
+	})
+}
@@ -0,0 +1,460 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCommandNotFound is returned when the Command to control is not registered to any running Bot.
+	ErrCommandNotFound = errors.New("command is not found")
+
+	// ErrScheduledTaskNotFound is returned when the ScheduledTask to control is not scheduled for any running Bot.
+	ErrScheduledTaskNotFound = errors.New("scheduled task is not found")
+)
+
+// CommandInfo represents a Command registered to a running Bot.
+type CommandInfo struct {
+	BotType    BotType
+	Identifier string
+	Enabled    bool
+}
+
+// ScheduledTaskInfo represents a ScheduledTask scheduled for a running Bot.
+type ScheduledTaskInfo struct {
+	BotType    BotType
+	Identifier string
+	Schedule   string
+
+	// Next is the next time the ScheduledTask runs. This is zero when the next run time is unknown.
+	Next time.Time
+}
+
+// ListCommands returns the Commands registered to the running Bots sorted by BotType and identifier.
+// A Command registered with a BotScope appears once for each Bot.
+func ListCommands() []*CommandInfo {
+	return runnerStatus.components.listCommands()
+}
+
+// EnableCommand re-enables the Command that is disabled by DisableCommand.
+func EnableCommand(botType BotType, id string) error {
+	return runnerStatus.components.setCommandEnabled(botType, id, true)
+}
+
+// DisableCommand disables the Command with the given identifier registered to the Bot with the given BotType.
+// A disabled Command matches no Input and does not appear in the help til EnableCommand is called.
+// The state is kept when the Command is rebuilt on a configuration update, but is reset when the process restarts.
+func DisableCommand(botType BotType, id string) error {
+	return runnerStatus.components.setCommandEnabled(botType, id, false)
+}
+
+// ListScheduledTasks returns the ScheduledTasks scheduled for the running Bots sorted by BotType and identifier.
+func ListScheduledTasks() []*ScheduledTaskInfo {
+	return runnerStatus.components.listScheduledTasks()
+}
+
+// RunScheduledTask runs the ScheduledTask with the given identifier for the Bot with the given BotType right away.
+// The task runs in its own goroutine just like it does on its schedule, and its results are sent to their destinations.
+// The schedule is not affected.
+func RunScheduledTask(botType BotType, id string) error {
+	return runnerStatus.components.runScheduledTask(botType, id)
+}
+
+// AddScheduledTask schedules the given ScheduledTask for the running Bot with the given BotType at runtime.
+// A ScheduledTask with the same identifier is replaced.
+// Just like a registered ScheduledTask, the task is listed by ListScheduledTasks and can be run by RunScheduledTask.
+// To run the task only once, give a schedule built by ScheduleOnceAt.
+//
+// The task lives only in memory and is gone when the Bot stops.
+// A plugin that schedules tasks at runtime should persist them by itself and schedule them again on BotStarted event.
+func AddScheduledTask(botType BotType, task ScheduledTask) error {
+	return runnerStatus.components.addScheduledTask(botType, task)
+}
+
+// RemoveScheduledTask unschedules the ScheduledTask with the given identifier from the running Bot with the given BotType.
+// A ScheduledTask registered on the Runner's construction can also be removed til the Bot restarts or the task's configuration is reloaded.
+func RemoveScheduledTask(botType BotType, id string) error {
+	return runnerStatus.components.removeRuntimeScheduledTask(botType, id)
+}
+
+// SendMessage sends the given Output via the running Bot with the given BotType.
+// This lets a component that runs outside of the Bot's Input handling and ScheduledTasks, such as an HTTP receiver of external events, post a message.
+// The message is sent with the Bot's context, so it is not affected by the cancellation of the caller's context.
+// The error returned by Bot.SendMessage is returned when the message is not delivered.
+func SendMessage(botType BotType, output Output) error {
+	return runnerStatus.components.send(botType, output)
+}
+
+// ReloadConfigs reads the configurations of all Commands and ScheduledTasks built from CommandProps and ScheduledTaskProps again,
+// and rebuilds them just like a ConfigWatcher notifies configuration updates.
+// This is handy when the ConfigWatcher can not detect an update. e.g. a secret in an external secret store is rotated.
+// When any of them fails, an error that describes all failures is returned; the rest are still rebuilt.
+func ReloadConfigs() error {
+	return runnerStatus.components.reload()
+}
+
+// managedComponents keeps track of the Commands and the ScheduledTasks of the running Bots so they can be inspected and controlled at runtime.
+// The zero value is ready to use.
+type managedComponents struct {
+	commands     map[BotType]map[string]bool // Identifier to enabled state
+	tasks        map[BotType]map[string]*managedTask
+	reloaders    map[BotType]map[string]func() error
+	schedules    map[BotType]*botSchedule
+	senders      map[BotType]func(Output) error
+	receivers    map[BotType]func(Input)
+	maintenances map[BotType]*maintenance
+	paused       map[BotType][]Input // Inputs kept while the Bot is paused
+	deadLetters  *deadLetterQueue
+	configs      map[BotType]map[string]*configHistory
+	limiters     map[BotType]map[string]*concurrencyLimiter
+	inputQueues  map[BotType]*inputQueue
+	configSize   int // The number of the ConfigGenerations to keep
+	mutex        sync.RWMutex
+}
+
+// botSchedule holds the functions that schedule and unschedule a ScheduledTask for a running Bot.
+type botSchedule struct {
+	add    func(ScheduledTask) error
+	remove func(string)
+}
+
+type managedTask struct {
+	task ScheduledTask
+	run  func()
+	next func() time.Time
+}
+
+// command records the given Command and returns a Command that matches no Input while it is disabled,
+// and that runs within its ConcurrencyLimit.
+func (m *managedComponents) command(botType BotType, command Command) Command {
+	limiter := m.limiter(botType, command)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.commands == nil {
+		m.commands = map[BotType]map[string]bool{}
+	}
+	if _, ok := m.commands[botType]; !ok {
+		m.commands[botType] = map[string]bool{}
+	}
+	if _, ok := m.commands[botType][command.Identifier()]; !ok {
+		m.commands[botType][command.Identifier()] = true
+	}
+
+	return &managedCommand{
+		Command:    command,
+		botType:    botType,
+		components: m,
+		limiter:    limiter,
+	}
+}
+
+func (m *managedComponents) commandEnabled(botType BotType, id string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	enabled, ok := m.commands[botType][id]
+	return !ok || enabled
+}
+
+func (m *managedComponents) setCommandEnabled(botType BotType, id string, enabled bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.commands[botType][id]; !ok {
+		return fmt.Errorf("%w: %s:%s", ErrCommandNotFound, botType, id)
+	}
+	m.commands[botType][id] = enabled
+	return nil
+}
+
+func (m *managedComponents) listCommands() []*CommandInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var infos []*CommandInfo
+	for botType, commands := range m.commands {
+		for id, enabled := range commands {
+			infos = append(infos, &CommandInfo{
+				BotType:    botType,
+				Identifier: id,
+				Enabled:    enabled,
+			})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].BotType != infos[j].BotType {
+			return infos[i].BotType < infos[j].BotType
+		}
+		return infos[i].Identifier < infos[j].Identifier
+	})
+	return infos
+}
+
+// scheduledTask records the given ScheduledTask along with the function the scheduler runs and a function that returns the next run time.
+func (m *managedComponents) scheduledTask(botType BotType, task ScheduledTask, run func(), next func() time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.tasks == nil {
+		m.tasks = map[BotType]map[string]*managedTask{}
+	}
+	if _, ok := m.tasks[botType]; !ok {
+		m.tasks[botType] = map[string]*managedTask{}
+	}
+	m.tasks[botType][task.Identifier()] = &managedTask{
+		task: task,
+		run:  run,
+		next: next,
+	}
+}
+
+func (m *managedComponents) removeScheduledTask(botType BotType, id string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.tasks[botType], id)
+}
+
+func (m *managedComponents) listScheduledTasks() []*ScheduledTaskInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var infos []*ScheduledTaskInfo
+	for botType, tasks := range m.tasks {
+		for id, t := range tasks {
+			infos = append(infos, &ScheduledTaskInfo{
+				BotType:    botType,
+				Identifier: id,
+				Schedule:   t.task.Schedule(),
+				Next:       t.next(),
+			})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].BotType != infos[j].BotType {
+			return infos[i].BotType < infos[j].BotType
+		}
+		return infos[i].Identifier < infos[j].Identifier
+	})
+	return infos
+}
+
+func (m *managedComponents) runScheduledTask(botType BotType, id string) error {
+	m.mutex.RLock()
+	t, ok := m.tasks[botType][id]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s:%s", ErrScheduledTaskNotFound, botType, id)
+	}
+
+	go t.run()
+	return nil
+}
+
+// schedule records the given functions that schedule and unschedule a ScheduledTask for the Bot with the given BotType.
+func (m *managedComponents) schedule(botType BotType, add func(ScheduledTask) error, remove func(string)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.schedules == nil {
+		m.schedules = map[BotType]*botSchedule{}
+	}
+	m.schedules[botType] = &botSchedule{
+		add:    add,
+		remove: remove,
+	}
+}
+
+// sender records the function that sends an Output via the running Bot.
+func (m *managedComponents) sender(botType BotType, send func(Output) error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.senders == nil {
+		m.senders = map[BotType]func(Output) error{}
+	}
+	m.senders[botType] = send
+}
+
+func (m *managedComponents) send(botType BotType, output Output) error {
+	m.mutex.RLock()
+	send, ok := m.senders[botType]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, botType)
+	}
+
+	return send(output)
+}
+
+func (m *managedComponents) addScheduledTask(botType BotType, task ScheduledTask) error {
+	m.mutex.RLock()
+	s, ok := m.schedules[botType]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, botType)
+	}
+
+	// Call the function without the lock since it records the scheduled task.
+	return s.add(task)
+}
+
+func (m *managedComponents) removeRuntimeScheduledTask(botType BotType, id string) error {
+	m.mutex.RLock()
+	s, ok := m.schedules[botType]
+	_, scheduled := m.tasks[botType][id]
+	m.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, botType)
+	}
+	if !scheduled {
+		return fmt.Errorf("%w: %s:%s", ErrScheduledTaskNotFound, botType, id)
+	}
+
+	s.remove(id)
+	return nil
+}
+
+// reloader records the given function that rebuilds the Command or the ScheduledTask with the given identifier.
+// The kind tells the two apart since a Command and a ScheduledTask may share the same identifier.
+func (m *managedComponents) reloader(botType BotType, kind string, id string, fnc func() error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.reloaders == nil {
+		m.reloaders = map[BotType]map[string]func() error{}
+	}
+	if _, ok := m.reloaders[botType]; !ok {
+		m.reloaders[botType] = map[string]func() error{}
+	}
+	m.reloaders[botType][kind+":"+id] = fnc
+}
+
+func (m *managedComponents) reload() error {
+	type target struct {
+		botType BotType
+		id      string
+		fnc     func() error
+	}
+
+	m.mutex.RLock()
+	var targets []*target
+	for botType, reloaders := range m.reloaders {
+		for id, fnc := range reloaders {
+			targets = append(targets, &target{botType: botType, id: id, fnc: fnc})
+		}
+	}
+	m.mutex.RUnlock()
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].botType != targets[j].botType {
+			return targets[i].botType < targets[j].botType
+		}
+		return targets[i].id < targets[j].id
+	})
+
+	// Call the functions without the lock since they record the rebuilt components.
+	var failures []string
+	for _, t := range targets {
+		err := t.fnc()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s %s: %s", t.botType, t.id, err.Error()))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to reload configurations: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// removeBot forgets the components of the Bot with the given BotType. This is called when the Bot stops.
+func (m *managedComponents) removeBot(botType BotType) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.commands, botType)
+	delete(m.tasks, botType)
+	delete(m.reloaders, botType)
+	delete(m.schedules, botType)
+	delete(m.senders, botType)
+	delete(m.receivers, botType)
+	delete(m.maintenances, botType)
+	delete(m.paused, botType)
+	delete(m.configs, botType)
+	delete(m.limiters, botType)
+	delete(m.inputQueues, botType)
+}
+
+// managedCommand is a Command that matches no Input and hides its instruction while it is disabled with DisableCommand,
+// that does not execute while the Bot is in maintenance, and that does not exceed its ConcurrencyLimit.
+type managedCommand struct {
+	Command
+	botType    BotType
+	components *managedComponents
+	limiter    *concurrencyLimiter
+}
+
+var _ IntentCommand = (*managedCommand)(nil)
+var _ PatternCommand = (*managedCommand)(nil)
+
+func (c *managedCommand) Match(input Input) bool {
+	return c.components.commandEnabled(c.botType, c.Identifier()) && c.Command.Match(input)
+}
+
+// Execute runs the underlying Command unless the Bot is in maintenance.
+// During maintenance, the Input is queued or MaintenanceConfig.Message is returned instead.
+// When the Command satisfies ConcurrencyLimitedCommand, the excess Input waits or is replied as its ConcurrencyLimit tells.
+func (c *managedCommand) Execute(ctx context.Context, input Input) (*CommandResponse, error) {
+	config, ok := c.components.inMaintenance(c.botType, c.Identifier())
+	if !ok {
+		return c.execute(ctx, input)
+	}
+
+	if config.QueueInputs && c.components.queue(c.botType, input) {
+		contextLogger(ctx).Debug("Queue input during maintenance", logging.F(logging.KeyCommandID, c.Identifier()))
+		return nil, nil
+	}
+	return &CommandResponse{
+		Content:     config.Message,
+		UserContext: nil,
+	}, nil
+}
+
+func (c *managedCommand) Instruction(input *HelpInput) string {
+	if !c.components.commandEnabled(c.botType, c.Identifier()) {
+		return ""
+	}
+	return c.Command.Instruction(input)
+}
+
+// IntentName returns the underlying IntentCommand's intent name, or an empty string while the Command is disabled.
+func (c *managedCommand) IntentName() string {
+	intentCommand, ok := c.Command.(IntentCommand)
+	if !ok || !c.components.commandEnabled(c.botType, c.Identifier()) {
+		return ""
+	}
+	return intentCommand.IntentName()
+}
+
+// MatchPattern returns the underlying PatternCommand's pattern so Commands can skip this Command without calling Match.
+func (c *managedCommand) MatchPattern() *regexp.Regexp {
+	patternCommand, ok := c.Command.(PatternCommand)
+	if !ok {
+		return nil
+	}
+	return patternCommand.MatchPattern()
+}
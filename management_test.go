@@ -0,0 +1,223 @@
+package sarah
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisableCommand(t *testing.T) {
+	SetupAndRun(func() {
+		command := runnerStatus.components.command("dummy", &DummyIntentCommand{
+			DummyCommand: DummyCommand{
+				IdentifierValue: "echo",
+				MatchFunc: func(_ Input) bool {
+					return true
+				},
+				InstructionFunc: func(_ *HelpInput) string {
+					return ".echo"
+				},
+			},
+			IntentNameValue: "echo",
+		})
+
+		err := DisableCommand("dummy", "echo")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if command.Match(&DummyInput{}) {
+			t.Error("Disabled command must not match.")
+		}
+
+		if command.Instruction(&HelpInput{}) != "" {
+			t.Error("Disabled command must hide its instruction.")
+		}
+
+		if command.(IntentCommand).IntentName() != "" {
+			t.Error("Disabled command must not handle intent.")
+		}
+
+		infos := ListCommands()
+		if len(infos) != 1 || infos[0].BotType != "dummy" || infos[0].Identifier != "echo" || infos[0].Enabled {
+			t.Errorf("Unexpected commands are listed: %#v.", infos)
+		}
+
+		// The state is kept when the command is rebuilt.
+		command = runnerStatus.components.command("dummy", &DummyCommand{
+			IdentifierValue: "echo",
+			MatchFunc: func(_ Input) bool {
+				return true
+			},
+		})
+		if command.Match(&DummyInput{}) {
+			t.Error("Rebuilt command must stay disabled.")
+		}
+
+		err = EnableCommand("dummy", "echo")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if !command.Match(&DummyInput{}) {
+			t.Error("Enabled command must match.")
+		}
+
+		err = DisableCommand("dummy", "unknown")
+		if !errors.Is(err, ErrCommandNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		runnerStatus.components.removeBot("dummy")
+		if len(ListCommands()) != 0 {
+			t.Errorf("Commands of stopped bot must not be listed: %#v.", ListCommands())
+		}
+	})
+}
+
+func TestRunScheduledTask(t *testing.T) {
+	SetupAndRun(func() {
+		next := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		ran := make(chan struct{}, 1)
+		task := &DummyScheduledTask{IdentifierValue: "report", ScheduleValue: "@daily"}
+		runnerStatus.components.scheduledTask("dummy", task, func() { ran <- struct{}{} }, func() time.Time { return next })
+
+		infos := ListScheduledTasks()
+		if len(infos) != 1 || infos[0].Identifier != "report" || infos[0].Schedule != "@daily" || !infos[0].Next.Equal(next) {
+			t.Errorf("Unexpected tasks are listed: %#v.", infos)
+		}
+
+		err := RunScheduledTask("dummy", "report")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		select {
+		case <-ran:
+			// O.K.
+
+		case <-time.NewTimer(time.Second).C:
+			t.Error("Task did not run.")
+
+		}
+
+		err = RunScheduledTask("dummy", "unknown")
+		if !errors.Is(err, ErrScheduledTaskNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		runnerStatus.components.removeScheduledTask("dummy", "report")
+		if len(ListScheduledTasks()) != 0 {
+			t.Errorf("Removed task must not be listed: %#v.", ListScheduledTasks())
+		}
+	})
+}
+
+func TestAddScheduledTask(t *testing.T) {
+	SetupAndRun(func() {
+		task := &DummyScheduledTask{IdentifierValue: "reminder", ScheduleValue: "@daily"}
+
+		err := AddScheduledTask("dummy", task)
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		var removed []string
+		runnerStatus.components.schedule(
+			"dummy",
+			func(task ScheduledTask) error {
+				runnerStatus.components.scheduledTask("dummy", task, func() {}, func() time.Time { return time.Time{} })
+				return nil
+			},
+			func(id string) {
+				removed = append(removed, id)
+				runnerStatus.components.removeScheduledTask("dummy", id)
+			},
+		)
+
+		err = AddScheduledTask("dummy", task)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		infos := ListScheduledTasks()
+		if len(infos) != 1 || infos[0].Identifier != "reminder" {
+			t.Errorf("Added task is not listed: %#v.", infos)
+		}
+
+		err = RemoveScheduledTask("dummy", "reminder")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if len(removed) != 1 || removed[0] != "reminder" {
+			t.Errorf("Task is not removed: %#v.", removed)
+		}
+
+		err = RemoveScheduledTask("dummy", "reminder")
+		if !errors.Is(err, ErrScheduledTaskNotFound) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		runnerStatus.components.removeBot("dummy")
+		err = RemoveScheduledTask("dummy", "reminder")
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestSendMessage(t *testing.T) {
+	SetupAndRun(func() {
+		output := NewOutputMessage("#general", "Hello")
+
+		err := SendMessage("dummy", output)
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		var sent []Output
+		runnerStatus.components.sender("dummy", func(output Output) error {
+			sent = append(sent, output)
+			return nil
+		})
+
+		err = SendMessage("dummy", output)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(sent) != 1 || sent[0] != output {
+			t.Errorf("Output is not sent: %#v.", sent)
+		}
+
+		runnerStatus.components.removeBot("dummy")
+		err = SendMessage("dummy", output)
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestReloadConfigs(t *testing.T) {
+	SetupAndRun(func() {
+		var reloaded []string
+		runnerStatus.components.reloader("dummy", "command", "b", func() error {
+			reloaded = append(reloaded, "b")
+			return errors.New("broken config")
+		})
+		runnerStatus.components.reloader("dummy", "task", "a", func() error {
+			reloaded = append(reloaded, "a")
+			return nil
+		})
+
+		err := ReloadConfigs()
+		if err == nil || !strings.Contains(err.Error(), "dummy command:b: broken config") {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if strings.Join(reloaded, ",") != "b,a" {
+			t.Errorf("Unexpected reloads: %#v.", reloaded)
+		}
+	})
+}
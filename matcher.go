@@ -0,0 +1,236 @@
+package sarah
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+)
+
+// PatternCommand defines an optional interface that a Command may satisfy to tell the regular expression it matches against Input.Message().
+// A Command built with CommandPropsBuilder.MatchPattern satisfies this.
+//
+// Commands uses the pattern's literal prefix to skip the Command without running the regular expression
+// when the message can not match, which is the case for most of the messages in a busy chat room.
+// Match is still called for the rest, so the pattern must be the one that Match checks.
+type PatternCommand interface {
+	Command
+
+	// MatchPattern returns the regular expression that Match checks against Input.Message().
+	// nil means the Command matches with other logic and is always checked with Match.
+	MatchPattern() *regexp.Regexp
+}
+
+// commandMatcher holds a Command along with the pre-filter derived from its PatternCommand.MatchPattern.
+type commandMatcher struct {
+	command Command
+
+	// prefix is the literal string that any match of the pattern begins with.
+	prefix string
+
+	// anchored tells the pattern only matches at the beginning of the message, where the prefix must be found.
+	anchored bool
+}
+
+func newCommandMatcher(command Command) *commandMatcher {
+	matcher := &commandMatcher{
+		command: command,
+	}
+
+	patternCommand, ok := command.(PatternCommand)
+	if !ok {
+		return matcher
+	}
+	pattern := patternCommand.MatchPattern()
+	if pattern == nil {
+		return matcher
+	}
+
+	matcher.prefix, matcher.anchored = patternPrefix(pattern)
+	return matcher
+}
+
+// excludes tells if the given message certainly does not match the Command's pattern.
+// This neither runs the regular expression nor allocates memory.
+func (m *commandMatcher) excludes(message string) bool {
+	if m.prefix == "" {
+		return false
+	}
+
+	if m.anchored {
+		return !strings.HasPrefix(message, m.prefix)
+	}
+	return !strings.Contains(message, m.prefix)
+}
+
+// patternPrefix returns the literal string that any match of the given regular expression begins with,
+// and tells if the match is anchored to the beginning of the text as `^\.echo` is.
+// Unlike regexp.Regexp.LiteralPrefix, this also returns the prefix of an anchored pattern such as `^\.echo\b`.
+// An empty prefix is returned when the prefix can not be told, which only disables the pre-filter for the pattern.
+func patternPrefix(pattern *regexp.Regexp) (string, bool) {
+	re, err := syntax.Parse(pattern.String(), syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	prefix := &strings.Builder{}
+	anchored := false
+	var walk func(*syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				if !walk(sub) {
+					return false
+				}
+			}
+			return true
+
+		case syntax.OpCapture:
+			return walk(re.Sub[0])
+
+		case syntax.OpBeginText:
+			if prefix.Len() > 0 {
+				return false
+			}
+			anchored = true
+			return true
+
+		case syntax.OpEmptyMatch:
+			return true
+
+		case syntax.OpLiteral:
+			if re.Flags&syntax.FoldCase != 0 {
+				return false
+			}
+			prefix.WriteString(string(re.Rune))
+			return true
+
+		default:
+			return false
+
+		}
+	}
+	walk(re.Simplify())
+
+	return prefix.String(), anchored
+}
+
+// buildIndex builds the commandMatcher for each registered Command, and the prefixTrie when it is enabled.
+// This must be called with the lock held.
+func (commands *Commands) buildIndex() {
+	matchers := make([]*commandMatcher, 0, len(commands.collection))
+	for _, command := range commands.collection {
+		matchers = append(matchers, newCommandMatcher(command))
+	}
+	commands.matchers = matchers
+
+	commands.trie = nil
+	commands.unindexed = nil
+	if !commands.prefixIndexed {
+		return
+	}
+
+	commands.trie = newPrefixTrie()
+	for i, matcher := range matchers {
+		if matcher.anchored && matcher.prefix != "" {
+			commands.trie.insert(matcher.prefix, i)
+			continue
+		}
+		commands.unindexed = append(commands.unindexed, i)
+	}
+}
+
+// prefixTrie indexes the Commands with anchored patterns by their literal prefixes byte by byte.
+// See BotWithCommandPrefixIndex.
+type prefixTrie struct {
+	children map[byte]*prefixTrie
+
+	// positions are the positions in Commands.matchers of the Commands whose prefixes end at this node.
+	positions []int
+}
+
+func newPrefixTrie() *prefixTrie {
+	return &prefixTrie{
+		children: map[byte]*prefixTrie{},
+	}
+}
+
+func (t *prefixTrie) insert(prefix string, position int) {
+	node := t
+	for i := 0; i < len(prefix); i++ {
+		child, ok := node.children[prefix[i]]
+		if !ok {
+			child = newPrefixTrie()
+			node.children[prefix[i]] = child
+		}
+		node = child
+	}
+	node.positions = append(node.positions, position)
+}
+
+// collect appends the positions of the Commands whose prefixes the given message begins with, and returns the extended slice.
+// The cost depends on the length of the matching prefixes, not on the number of the indexed Commands.
+func (t *prefixTrie) collect(message string, positions []int) []int {
+	node := t
+	for i := 0; i < len(message); i++ {
+		child, ok := node.children[message[i]]
+		if !ok {
+			break
+		}
+		node = child
+		positions = append(positions, node.positions...)
+	}
+	return positions
+}
+
+// candidatePool pools the buffers to hold the candidate positions so the indexed lookup does not allocate memory per Input.
+var candidatePool = sync.Pool{
+	New: func() interface{} {
+		positions := make([]int, 0, 16)
+		return &positions
+	},
+}
+
+// findIndexed is the FindFirstMatched with the prefixTrie.
+// The candidates from the prefixTrie and the Commands that can not be indexed are checked in the order of registration,
+// so the result is always the same as the one without the index.
+// This must be called with the lock held.
+func (commands *Commands) findIndexed(input Input, message string) Command {
+	buf := candidatePool.Get().(*[]int)
+	candidates := commands.trie.collect(message, (*buf)[:0])
+	defer func() {
+		*buf = candidates[:0]
+		candidatePool.Put(buf)
+	}()
+
+	// The candidates are collected from the nodes of different depths. Sort them in the order of registration.
+	// The number of the candidates is usually small enough for the insertion sort.
+	for i := 1; i < len(candidates); i++ {
+		for j := i; j > 0 && candidates[j] < candidates[j-1]; j-- {
+			candidates[j], candidates[j-1] = candidates[j-1], candidates[j]
+		}
+	}
+
+	unindexed := commands.unindexed
+	for i, j := 0, 0; i < len(candidates) || j < len(unindexed); {
+		var position int
+		if j == len(unindexed) || (i < len(candidates) && candidates[i] < unindexed[j]) {
+			position = candidates[i]
+			i++
+		} else {
+			position = unindexed[j]
+			j++
+		}
+
+		matcher := commands.matchers[position]
+		if matcher.excludes(message) {
+			continue
+		}
+		if matcher.command.Match(input) {
+			return matcher.command
+		}
+	}
+
+	return nil
+}
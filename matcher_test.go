@@ -0,0 +1,345 @@
+package sarah
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type DummyPatternCommand struct {
+	DummyCommand
+	MatchPatternValue *regexp.Regexp
+}
+
+var _ PatternCommand = (*DummyPatternCommand)(nil)
+
+func (command *DummyPatternCommand) MatchPattern() *regexp.Regexp {
+	return command.MatchPatternValue
+}
+
+func Test_patternPrefix(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		prefix   string
+		anchored bool
+	}{
+		{pattern: `^\.echo`, prefix: ".echo", anchored: true},
+		{pattern: `^\.echo\b`, prefix: ".echo", anchored: true},
+		{pattern: `\A\.echo`, prefix: ".echo", anchored: true},
+		{pattern: `^(\.echo)\s+(.+)`, prefix: ".echo", anchored: true},
+		{pattern: `^\.(echo|ping)`, prefix: ".", anchored: true},
+		{pattern: `\.echo`, prefix: ".echo", anchored: false},
+		{pattern: `(?i)^\.echo`, prefix: "", anchored: true},
+		{pattern: `(?m)^\.echo`, prefix: "", anchored: false},
+		{pattern: `^\.echo|\.ping`, prefix: "", anchored: false},
+		{pattern: `.*`, prefix: "", anchored: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			prefix, anchored := patternPrefix(regexp.MustCompile(tt.pattern))
+			if prefix != tt.prefix {
+				t.Errorf("Expected prefix %q but got %q.", tt.prefix, prefix)
+			}
+			if anchored != tt.anchored {
+				t.Errorf("Expected %t but got %t.", tt.anchored, anchored)
+			}
+		})
+	}
+}
+
+func Test_commandMatcher_excludes(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		message  string
+		excludes bool
+	}{
+		{pattern: `^\.echo`, message: ".echo foo", excludes: false},
+		{pattern: `^\.echo`, message: "say .echo", excludes: true},
+		{pattern: `^\.echo`, message: "hello", excludes: true},
+		{pattern: `\.echo`, message: "say .echo", excludes: false},
+		{pattern: `\.echo`, message: "hello", excludes: true},
+		{pattern: `(?i)^\.echo`, message: ".ECHO", excludes: false},
+		{pattern: `^\.(echo|ping)`, message: ".ping", excludes: false},
+		{pattern: `^\.(echo|ping)`, message: "ping", excludes: true},
+		{pattern: `^\.echo\b`, message: "hello", excludes: true},
+		{pattern: `.*`, message: "hello", excludes: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.message, func(t *testing.T) {
+			pattern := regexp.MustCompile(tt.pattern)
+			matcher := newCommandMatcher(&DummyPatternCommand{MatchPatternValue: pattern})
+
+			excludes := matcher.excludes(tt.message)
+			if excludes != tt.excludes {
+				t.Errorf("Expected %t but got %t.", tt.excludes, excludes)
+			}
+			if excludes && pattern.MatchString(tt.message) {
+				t.Error("Matching message must not be excluded.")
+			}
+		})
+	}
+}
+
+func Test_commandMatcher_excludes_WithoutPattern(t *testing.T) {
+	commands := []Command{
+		&DummyCommand{},
+		&DummyPatternCommand{MatchPatternValue: nil},
+	}
+
+	for _, command := range commands {
+		if newCommandMatcher(command).excludes("hello") {
+			t.Errorf("Command without pattern must not be excluded: %#v.", command)
+		}
+	}
+}
+
+func TestCommandPropsBuilder_MatchPattern_PatternCommand(t *testing.T) {
+	pattern := regexp.MustCompile(`^\.echo`)
+	props := NewCommandPropsBuilder().
+		BotType("dummy").
+		Identifier("echo").
+		MatchPattern(pattern).
+		Func(func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return nil, nil
+		}).
+		Instruction(".echo foo").
+		MustBuild()
+
+	command, err := BuildCommand(context.TODO(), props, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	patternCommand, ok := command.(PatternCommand)
+	if !ok {
+		t.Fatalf("Built command does not satisfy PatternCommand: %T.", command)
+	}
+	if patternCommand.MatchPattern() != pattern {
+		t.Errorf("Unexpected pattern is returned: %s.", patternCommand.MatchPattern())
+	}
+
+	managed := &managedCommand{Command: command}
+	if managed.MatchPattern() != pattern {
+		t.Errorf("Unexpected pattern is returned by managedCommand: %s.", managed.MatchPattern())
+	}
+}
+
+func TestCommandPropsBuilder_MatchFunc_OverridesPattern(t *testing.T) {
+	builder := NewCommandPropsBuilder().
+		MatchPattern(regexp.MustCompile(`^\.echo`)).
+		MatchFunc(func(_ Input) bool {
+			return true
+		})
+
+	if builder.props.pattern != nil {
+		t.Error("Pattern must be cleared by MatchFunc.")
+	}
+}
+
+func TestCommands_FindFirstMatched_PreFilter(t *testing.T) {
+	called := 0
+	echo := &DummyPatternCommand{
+		DummyCommand: DummyCommand{
+			IdentifierValue: "echo",
+			MatchFunc: func(input Input) bool {
+				called++
+				return true
+			},
+		},
+		MatchPatternValue: regexp.MustCompile(`^\.echo`),
+	}
+	fallback := &DummyCommand{
+		IdentifierValue: "fallback",
+		MatchFunc: func(_ Input) bool {
+			return true
+		},
+	}
+	commands := NewCommands()
+	commands.Append(echo)
+	commands.Append(fallback)
+
+	matched := commands.FindFirstMatched(&DummyInput{MessageValue: "hello"})
+	if matched != fallback {
+		t.Errorf("Unexpected command is returned: %#v.", matched)
+	}
+	if called != 0 {
+		t.Error("Match must not be called for the excluded command.")
+	}
+
+	matched = commands.FindFirstMatched(&DummyInput{MessageValue: ".echo hello"})
+	if matched != echo {
+		t.Errorf("Unexpected command is returned: %#v.", matched)
+	}
+	if called != 1 {
+		t.Errorf("Match must be called for the candidate: %d.", called)
+	}
+
+	// The index must be rebuilt on Append.
+	ping := &DummyPatternCommand{
+		DummyCommand: DummyCommand{
+			IdentifierValue: "echo",
+			MatchFunc: func(_ Input) bool {
+				return true
+			},
+		},
+		MatchPatternValue: regexp.MustCompile(`^\.ping`),
+	}
+	commands.Append(ping)
+	matched = commands.FindFirstMatched(&DummyInput{MessageValue: ".ping"})
+	if matched != ping {
+		t.Errorf("Replaced command is not returned: %#v.", matched)
+	}
+}
+
+func Test_prefixTrie_collect(t *testing.T) {
+	trie := newPrefixTrie()
+	trie.insert(".", 0)
+	trie.insert(".echo", 1)
+	trie.insert(".e", 2)
+	trie.insert(".ping", 3)
+	trie.insert(".echo", 4)
+
+	tests := []struct {
+		message   string
+		positions []int
+	}{
+		{message: ".echo foo", positions: []int{0, 2, 1, 4}},
+		{message: ".ping", positions: []int{0, 3}},
+		{message: ".", positions: []int{0}},
+		{message: "hello", positions: nil},
+		{message: "", positions: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			positions := trie.collect(tt.message, nil)
+			if len(positions) != len(tt.positions) {
+				t.Fatalf("Unexpected positions are returned: %#v.", positions)
+			}
+			for i, p := range tt.positions {
+				if positions[i] != p {
+					t.Errorf("Unexpected positions are returned: %#v.", positions)
+				}
+			}
+		})
+	}
+}
+
+func TestCommands_FindFirstMatched_PrefixIndex(t *testing.T) {
+	patterns := []string{`^\.echo\b`, `^\.e`, `(?i)^\.ECHO`, `^\.(echo|ping)`, `\.ping`, `^\.ping$`, `^\.echo foo`, `^\.deploy\b`}
+	messages := []string{".echo", ".echo foo", ".ECHO", ".ping", "say .ping", ".pingpong", ".deploy app", ".deployment", "hello", "", "help"}
+
+	build := func(prefixIndexed bool, disabled string) *Commands {
+		components := &managedComponents{}
+		commands := NewCommands()
+		commands.prefixIndexed = prefixIndexed
+		for i, pattern := range patterns {
+			props := NewCommandPropsBuilder().
+				BotType("dummy").
+				Identifier(fmt.Sprintf("pattern%d", i)).
+				MatchPattern(regexp.MustCompile(pattern)).
+				Func(func(_ context.Context, _ Input) (*CommandResponse, error) {
+					return nil, nil
+				}).
+				Instruction("").
+				MustBuild()
+			command, _ := BuildCommand(context.TODO(), props, nil)
+			commands.Append(components.command("dummy", command))
+
+			if i == 3 {
+				// Not indexed, but must be checked in the order of registration.
+				commands.Append(&DummyCommand{
+					IdentifierValue: "func",
+					MatchFunc: func(input Input) bool {
+						return input.Message() == "hello" || input.Message() == ".pingpong"
+					},
+				})
+			}
+		}
+		if disabled != "" {
+			_ = components.setCommandEnabled("dummy", disabled, false)
+		}
+		return commands
+	}
+
+	identifier := func(command Command) string {
+		if command == nil {
+			return ""
+		}
+		return command.Identifier()
+	}
+
+	for _, disabled := range []string{"", "pattern0", "pattern3"} {
+		linear := build(false, disabled)
+		indexed := build(true, disabled)
+		for _, message := range messages {
+			input := &DummyInput{MessageValue: message}
+			expected := identifier(linear.FindFirstMatched(input))
+			actual := identifier(indexed.FindFirstMatched(input))
+			if expected != actual {
+				t.Errorf("Unexpected command is returned for %q with %q disabled: expected %q but got %q.", message, disabled, expected, actual)
+			}
+		}
+
+		if indexed.trie == nil || len(indexed.unindexed) != 3 {
+			t.Errorf("Unexpected index is built: %#v.", indexed.unindexed)
+		}
+	}
+}
+
+// benchmarkCommands returns Commands with the given number of Commands built with MatchPattern, and each of them is wrapped with managedCommand as runner does.
+func benchmarkCommands(n int) *Commands {
+	components := &managedComponents{}
+	commands := NewCommands()
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("command%d", i)
+		props := NewCommandPropsBuilder().
+			BotType("dummy").
+			Identifier(id).
+			MatchPattern(regexp.MustCompile(fmt.Sprintf(`^\.%s\b`, id))).
+			Func(func(_ context.Context, _ Input) (*CommandResponse, error) {
+				return nil, nil
+			}).
+			Instruction(fmt.Sprintf(".%s", id)).
+			MustBuild()
+		command, _ := BuildCommand(context.TODO(), props, nil)
+		commands.Append(components.command("dummy", command))
+	}
+	return commands
+}
+
+// BenchmarkCommands_FindFirstMatched measures the command lookup for each incoming message with and without BotWithCommandPrefixIndex.
+// Most of the messages in a chat room are conversations that match no Command, so 9 out of 10 messages are so in this benchmark.
+// This does not allocate memory per message, and the msgs/min metric is to be compared with the expected load such as 10,000 messages per minute.
+func BenchmarkCommands_FindFirstMatched(b *testing.B) {
+	for _, n := range []int{100, 1000} {
+		for _, prefixIndexed := range []bool{false, true} {
+			name := fmt.Sprintf("%d commands", n)
+			if prefixIndexed {
+				name += " with prefix index"
+			}
+			b.Run(name, func(b *testing.B) {
+				commands := benchmarkCommands(n)
+				commands.prefixIndexed = prefixIndexed
+				var inputs []Input
+				for i := 0; i < 10; i++ {
+					inputs = append(inputs, &DummyInput{MessageValue: fmt.Sprintf("Hi, how is the deployment of build #%d going?", i)})
+				}
+				inputs[0] = &DummyInput{MessageValue: fmt.Sprintf(".command%d foo", n-1)}
+				commands.FindFirstMatched(inputs[0]) // Build the index.
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				started := time.Now()
+				for i := 0; i < b.N; i++ {
+					commands.FindFirstMatched(inputs[i%len(inputs)])
+				}
+				b.StopTimer()
+				b.ReportMetric(float64(b.N)/time.Since(started).Minutes(), "msgs/min")
+			})
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Package nlu provides sarah.IntentMatcher implementations that map free-form sentences to intents.
+
+RasaMatcher asks a Rasa server to parse each sentence, and SimilarityMatcher compares the embedding of each sentence with those of example sentences locally.
+Any other backend such as Dialogflow can be plugged in by implementing sarah.IntentMatcher.
+
+	matcher := nlu.NewRasaMatcher(nlu.NewRasaConfig())
+	bot := sarah.NewBot(adapter, sarah.BotWithIntentMatcher(matcher))
+
+	props := sarah.NewCommandPropsBuilder().
+		BotType(slack.SLACK).
+		Identifier("weather").
+		MatchIntent("ask_weather").
+		Instruction("Ask me the weather of a city.").
+		Func(func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+			intent, _ := sarah.IntentFromContext(ctx)
+			return slack.NewResponse(input, forecast(intent.Entity("city")))
+		}).
+		MustBuild()
+*/
+package nlu
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RasaConfig contains some configuration variables for RasaMatcher.
+type RasaConfig struct {
+	// Endpoint is the base URL of the Rasa server. "/model/parse" is appended to this.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// Token is passed as the token query parameter when the server is started with --auth-token.
+	Token sarah.Secret `json:"token" yaml:"token"`
+
+	// Threshold is the minimum confidence for a detected intent to be returned.
+	Threshold float64 `json:"threshold" yaml:"threshold"`
+
+	// Timeout is the maximum duration of one request to the server.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+}
+
+// NewRasaConfig creates and returns new RasaConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to overload default values.
+func NewRasaConfig() *RasaConfig {
+	return &RasaConfig{
+		Endpoint:  "http://localhost:5005",
+		Threshold: 0.7,
+		Timeout:   10 * time.Second,
+	}
+}
+
+// RasaOption defines a function signature that RasaMatcher's functional option must satisfy.
+type RasaOption func(*RasaMatcher)
+
+// RasaWithHTTPClient sets the http.Client to call the Rasa server.
+func RasaWithHTTPClient(client *http.Client) RasaOption {
+	return func(matcher *RasaMatcher) {
+		matcher.client = client
+	}
+}
+
+// RasaMatcher is a sarah.IntentMatcher that asks a Rasa server's HTTP API to parse each sentence.
+type RasaMatcher struct {
+	config *RasaConfig
+	client *http.Client
+}
+
+var _ sarah.IntentMatcher = (*RasaMatcher)(nil)
+
+// NewRasaMatcher creates and returns new RasaMatcher instance.
+func NewRasaMatcher(config *RasaConfig, options ...RasaOption) *RasaMatcher {
+	matcher := &RasaMatcher{
+		config: config,
+		client: http.DefaultClient,
+	}
+
+	for _, opt := range options {
+		opt(matcher)
+	}
+
+	return matcher
+}
+
+type rasaParseResponse struct {
+	Intent *struct {
+		Name       string  `json:"name"`
+		Confidence float64 `json:"confidence"`
+	} `json:"intent"`
+	Entities []*struct {
+		Entity string      `json:"entity"`
+		Value  interface{} `json:"value"`
+	} `json:"entities"`
+}
+
+// MatchIntent sends the Input's message to the Rasa server and returns the detected Intent.
+// nil is returned when the confidence is lower than RasaConfig.Threshold.
+func (matcher *RasaMatcher) MatchIntent(ctx context.Context, input sarah.Input) (*sarah.Intent, error) {
+	text := strings.TrimSpace(input.Message())
+	if text == "" {
+		return nil, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(matcher.config.Endpoint, "/") + "/model/parse"
+	if matcher.config.Token != "" {
+		endpoint += "?token=" + url.QueryEscape(matcher.config.Token.Reveal())
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, matcher.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req = req.WithContext(reqCtx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := matcher.client.Do(req)
+	if err != nil {
+		// The error message may contain the token as part of the URL.
+		return nil, fmt.Errorf("failed to call Rasa server: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d is returned from Rasa server", resp.StatusCode)
+	}
+
+	parsed := &rasaParseResponse{}
+	err = json.NewDecoder(resp.Body).Decode(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if parsed.Intent == nil || parsed.Intent.Name == "" || parsed.Intent.Confidence < matcher.config.Threshold {
+		return nil, nil
+	}
+
+	intent := &sarah.Intent{
+		Name:       parsed.Intent.Name,
+		Confidence: parsed.Intent.Confidence,
+	}
+	for _, e := range parsed.Entities {
+		value, ok := e.Value.(string)
+		if !ok {
+			// Some extractors such as Duckling return a number or a structured value.
+			b, _ := json.Marshal(e.Value)
+			value = string(b)
+		}
+		intent.Entities = append(intent.Entities, &sarah.IntentEntity{Name: e.Entity, Value: value})
+	}
+
+	return intent, nil
+}
+
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
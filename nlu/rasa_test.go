@@ -0,0 +1,139 @@
+package nlu
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+	ReplyToValue   sarah.OutputDestination
+}
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+func TestNewRasaConfig(t *testing.T) {
+	config := NewRasaConfig()
+
+	if config.Endpoint != "http://localhost:5005" {
+		t.Errorf("Unexpected default endpoint is set: %s.", config.Endpoint)
+	}
+
+	if config.Threshold != 0.7 {
+		t.Errorf("Unexpected default threshold is set: %f.", config.Threshold)
+	}
+
+	if config.Timeout != 10*time.Second {
+		t.Errorf("Unexpected default timeout is set: %s.", config.Timeout)
+	}
+}
+
+func TestRasaMatcher_MatchIntent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/model/parse" {
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+		}
+
+		if r.URL.Query().Get("token") != "secret" {
+			t.Errorf("Unexpected token is given: %s.", r.URL.Query().Get("token"))
+		}
+
+		req := map[string]string{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req["text"] {
+		case "What is the weather in Tokyo tomorrow?":
+			_, _ = w.Write([]byte(`{
+  "intent": {"name": "ask_weather", "confidence": 0.95},
+  "entities": [
+    {"entity": "city", "value": "Tokyo"},
+    {"entity": "days", "value": 1}
+  ]
+}`))
+
+		default:
+			_, _ = w.Write([]byte(`{"intent": {"name": "greet", "confidence": 0.3}, "entities": []}`))
+
+		}
+	}))
+	defer server.Close()
+
+	config := NewRasaConfig()
+	config.Endpoint = server.URL + "/"
+	config.Token = "secret"
+	matcher := NewRasaMatcher(config, RasaWithHTTPClient(server.Client()))
+
+	intent, err := matcher.MatchIntent(context.TODO(), &DummyInput{MessageValue: "What is the weather in Tokyo tomorrow?"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if intent == nil || intent.Name != "ask_weather" || intent.Confidence != 0.95 {
+		t.Fatalf("Unexpected intent is returned: %#v.", intent)
+	}
+
+	if intent.Entity("city") != "Tokyo" || intent.Entity("days") != "1" {
+		t.Errorf("Unexpected entities are returned: %#v.", intent.Entities)
+	}
+
+	intent, err = matcher.MatchIntent(context.TODO(), &DummyInput{MessageValue: "Hmm"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if intent != nil {
+		t.Errorf("Intent with low confidence must not be returned: %#v.", intent)
+	}
+}
+
+func TestRasaMatcher_MatchIntent_WithError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	config := NewRasaConfig()
+	config.Endpoint = server.URL
+	matcher := NewRasaMatcher(config)
+
+	_, err := matcher.MatchIntent(context.TODO(), &DummyInput{MessageValue: "Hello"})
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	// The unreachable server's error must not expose the token in the URL.
+	config = NewRasaConfig()
+	config.Endpoint = "http://127.0.0.1:0"
+	config.Token = "secret"
+	matcher = NewRasaMatcher(config)
+
+	_, err = matcher.MatchIntent(context.TODO(), &DummyInput{MessageValue: "Hello"})
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Token is exposed in the error: %s.", err.Error())
+	}
+}
@@ -0,0 +1,159 @@
+package nlu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Embedder defines an interface that converts sentences to embedding vectors.
+// An implementation typically calls an embedding model, either hosted or running locally.
+type Embedder interface {
+	// Embed returns one vector for each given text in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// EmbedderFunc is a function that satisfies Embedder.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// Embed calls the function itself.
+func (fnc EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	return fnc(ctx, texts)
+}
+
+// SimilarityConfig contains some configuration variables for SimilarityMatcher.
+type SimilarityConfig struct {
+	// Examples maps each intent name to its example sentences.
+	Examples map[string][]string `json:"examples" yaml:"examples"`
+
+	// Threshold is the minimum cosine similarity between a sentence and an example for the example's intent to be returned.
+	Threshold float64 `json:"threshold" yaml:"threshold"`
+}
+
+// NewSimilarityConfig creates and returns new SimilarityConfig instance with default settings.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to overload default values.
+func NewSimilarityConfig() *SimilarityConfig {
+	return &SimilarityConfig{
+		Examples:  map[string][]string{},
+		Threshold: 0.8,
+	}
+}
+
+type example struct {
+	intent string
+	vector []float64
+}
+
+// SimilarityMatcher is a sarah.IntentMatcher that returns the intent of the example sentence most similar to the given sentence.
+// The examples' embeddings are calculated once on construction, so only the given sentence is embedded on each match.
+// This extracts no entity.
+type SimilarityMatcher struct {
+	embedder  Embedder
+	threshold float64
+	examples  []*example
+}
+
+var _ sarah.IntentMatcher = (*SimilarityMatcher)(nil)
+
+// NewSimilarityMatcher creates and returns new SimilarityMatcher instance.
+// This returns an error when no example is given or the Embedder fails to embed the examples.
+func NewSimilarityMatcher(ctx context.Context, embedder Embedder, config *SimilarityConfig) (*SimilarityMatcher, error) {
+	// Iterate over the intents in a stable order so the first registered example wins a tie consistently.
+	var intents []string
+	for intent := range config.Examples {
+		intents = append(intents, intent)
+	}
+	sort.Strings(intents)
+
+	var names []string
+	var texts []string
+	for _, intent := range intents {
+		for _, text := range config.Examples[intent] {
+			names = append(names, intent)
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil, errors.New("no example sentence is given")
+	}
+
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed examples: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("%d vectors are returned for %d examples", len(vectors), len(texts))
+	}
+
+	examples := make([]*example, len(texts))
+	for i := range texts {
+		examples[i] = &example{intent: names[i], vector: vectors[i]}
+	}
+
+	return &SimilarityMatcher{
+		embedder:  embedder,
+		threshold: config.Threshold,
+		examples:  examples,
+	}, nil
+}
+
+// MatchIntent embeds the Input's message and returns the intent of the most similar example.
+// The similarity is returned as Intent.Confidence.
+// nil is returned when no example is as similar as SimilarityConfig.Threshold.
+func (matcher *SimilarityMatcher) MatchIntent(ctx context.Context, input sarah.Input) (*sarah.Intent, error) {
+	text := strings.TrimSpace(input.Message())
+	if text == "" {
+		return nil, nil
+	}
+
+	vectors, err := matcher.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed input: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("%d vectors are returned for one input", len(vectors))
+	}
+
+	var best *example
+	bestScore := math.Inf(-1)
+	for _, e := range matcher.examples {
+		score := cosineSimilarity(vectors[0], e.vector)
+		if score > bestScore {
+			best = e
+			bestScore = score
+		}
+	}
+
+	if best == nil || bestScore < matcher.threshold {
+		return nil, nil
+	}
+
+	return &sarah.Intent{
+		Name:       best.intent,
+		Confidence: bestScore,
+	}, nil
+}
+
+// cosineSimilarity returns the cosine similarity of the given vectors.
+// Zero is returned when the lengths differ or either vector is a zero vector.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,140 @@
+package nlu
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// keywordEmbedder embeds a text as the occurrences of each keyword.
+func keywordEmbedder(keywords ...string) Embedder {
+	return EmbedderFunc(func(_ context.Context, texts []string) ([][]float64, error) {
+		vectors := make([][]float64, len(texts))
+		for i, text := range texts {
+			vector := make([]float64, len(keywords))
+			for j, keyword := range keywords {
+				vector[j] = float64(strings.Count(strings.ToLower(text), keyword))
+			}
+			vectors[i] = vector
+		}
+		return vectors, nil
+	})
+}
+
+func TestNewSimilarityConfig(t *testing.T) {
+	config := NewSimilarityConfig()
+
+	if config.Examples == nil {
+		t.Error("Examples must be initialized.")
+	}
+
+	if config.Threshold != 0.8 {
+		t.Errorf("Unexpected default threshold is set: %f.", config.Threshold)
+	}
+}
+
+func TestNewSimilarityMatcher_WithError(t *testing.T) {
+	_, err := NewSimilarityMatcher(context.TODO(), keywordEmbedder("weather"), NewSimilarityConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for empty examples.")
+	}
+
+	config := NewSimilarityConfig()
+	config.Examples["greet"] = []string{"Hello"}
+	failing := EmbedderFunc(func(_ context.Context, _ []string) ([][]float64, error) {
+		return nil, errors.New("embedding failure")
+	})
+	_, err = NewSimilarityMatcher(context.TODO(), failing, config)
+	if err == nil {
+		t.Error("Expected error is not returned for embedding failure.")
+	}
+}
+
+func TestSimilarityMatcher_MatchIntent(t *testing.T) {
+	config := NewSimilarityConfig()
+	config.Examples = map[string][]string{
+		"ask_weather": {"How is the weather?", "Will it rain?"},
+		"greet":       {"Hello", "Hi there"},
+	}
+	matcher, err := NewSimilarityMatcher(context.TODO(), keywordEmbedder("weather", "rain", "hello", "hi"), config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{
+			message:  "Tell me the weather",
+			expected: "ask_weather",
+		},
+		{
+			message:  "rain tomorrow?",
+			expected: "ask_weather",
+		},
+		{
+			message:  "hello!",
+			expected: "greet",
+		},
+		{
+			message:  "Deploy the app",
+			expected: "",
+		},
+		{
+			message:  " ",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		intent, err := matcher.MatchIntent(context.TODO(), &DummyInput{MessageValue: tt.message})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned for %s: %s.", tt.message, err.Error())
+		}
+
+		name := ""
+		if intent != nil {
+			name = intent.Name
+		}
+		if name != tt.expected {
+			t.Errorf("Unexpected intent is returned for %s: %#v.", tt.message, intent)
+		}
+	}
+}
+
+func Test_cosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a        []float64
+		b        []float64
+		expected float64
+	}{
+		{
+			a:        []float64{1, 0},
+			b:        []float64{2, 0},
+			expected: 1,
+		},
+		{
+			a:        []float64{1, 0},
+			b:        []float64{0, 1},
+			expected: 0,
+		},
+		{
+			a:        []float64{0, 0},
+			b:        []float64{1, 1},
+			expected: 0,
+		},
+		{
+			a:        []float64{1},
+			b:        []float64{1, 1},
+			expected: 0,
+		},
+	}
+
+	for i, tt := range tests {
+		if score := cosineSimilarity(tt.a, tt.b); score != tt.expected {
+			t.Errorf("Unexpected score is returned on test #%d: %f.", i, score)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Package ops provides small commands for basic liveness checks from a chat: ping, uptime and version.
+
+Call Register before sarah.Run to register the commands for all bots:
+
+	ops.Register()
+	err := sarah.Run(ctx, config)
+
+The commands respond to below inputs:
+
+	.ping     "pong" with the time the input waited in the worker queue and the time since the input was sent
+	.uptime   the time since the process started
+	.version  the version and the build information of the process
+
+Set Version at build time to tell which release is running:
+
+	go build -ldflags "-X github.com/oklahomer/go-sarah/v4/ops.Version=v1.2.3"
+*/
+package ops
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Version is the version of the process shown by the version command.
+// Set this with the -X linker flag. When this is empty, the main module's version in the build information is shown.
+var Version = ""
+
+// startedAt is the time this package is initialized, which is approximately the time the process started.
+var startedAt = time.Now()
+
+// readBuildInfo is a variable so tests can replace it.
+var readBuildInfo = debug.ReadBuildInfo
+
+// PingProps is a pre-built ping command properties for all bots.
+var PingProps = sarah.NewCommandPropsBuilder().
+	Scope(sarah.AllBots()).
+	Identifier("ping").
+	Instruction("Input .ping to see if the bot is responsive.").
+	MatchPattern(regexp.MustCompile(`^\.ping\s*$`)).
+	Func(ping).
+	MustBuild()
+
+// UptimeProps is a pre-built uptime command properties for all bots.
+var UptimeProps = sarah.NewCommandPropsBuilder().
+	Scope(sarah.AllBots()).
+	Identifier("uptime").
+	Instruction("Input .uptime to see how long the bot has been running.").
+	MatchPattern(regexp.MustCompile(`^\.uptime\s*$`)).
+	Func(uptime).
+	MustBuild()
+
+// VersionProps is a pre-built version command properties for all bots.
+var VersionProps = sarah.NewCommandPropsBuilder().
+	Scope(sarah.AllBots()).
+	Identifier("version").
+	Instruction("Input .version to see the running version.").
+	MatchPattern(regexp.MustCompile(`^\.version\s*$`)).
+	Func(version).
+	MustBuild()
+
+// Register registers the ping, uptime and version commands for all bots.
+func Register() {
+	sarah.RegisterCommandProps(PingProps)
+	sarah.RegisterCommandProps(UptimeProps)
+	sarah.RegisterCommandProps(VersionProps)
+}
+
+func respond(text string) *sarah.CommandResponse {
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}
+}
+
+func ping(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	now := clock.FromContext(ctx).Now()
+	lines := []string{"pong"}
+
+	if receivedAt, ok := sarah.InputReceivedAt(ctx); ok {
+		lines = append(lines, fmt.Sprintf("Queue latency: %s", now.Sub(receivedAt).Round(time.Microsecond)))
+	}
+
+	// The chat service's clock may differ from the bot's, so a negative duration is not shown.
+	if sentAt := input.SentAt(); !sentAt.IsZero() && !now.Before(sentAt) {
+		lines = append(lines, fmt.Sprintf("Since sent: %s", now.Sub(sentAt).Round(time.Millisecond)))
+	}
+
+	return respond(strings.Join(lines, "\n")), nil
+}
+
+func uptime(ctx context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+	elapsed := clock.FromContext(ctx).Now().Sub(startedAt).Round(time.Second)
+	return respond(fmt.Sprintf("Up for %s since %s", elapsed, startedAt.Format(time.RFC3339))), nil
+}
+
+func version(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+	return respond(VersionInfo()), nil
+}
+
+// VersionInfo returns the version and the build information of the process in a human-readable form.
+// The revision and the commit time are included when the binary is built with the VCS information.
+func VersionInfo() string {
+	v := Version
+	lines := []string{}
+	info, ok := readBuildInfo()
+	if ok {
+		if v == "" {
+			v = info.Main.Version
+		}
+		lines = append(lines, fmt.Sprintf("Module: %s", info.Main.Path))
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				lines = append(lines, fmt.Sprintf("Revision: %s", setting.Value))
+
+			case "vcs.time":
+				lines = append(lines, fmt.Sprintf("Committed at: %s", setting.Value))
+
+			case "vcs.modified":
+				if setting.Value == "true" {
+					lines = append(lines, "Modified: true")
+				}
+
+			}
+		}
+	}
+	if v == "" {
+		v = "unknown"
+	}
+
+	lines = append([]string{fmt.Sprintf("Version: %s", v)}, lines...)
+	lines = append(lines, fmt.Sprintf("Go: %s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,151 @@
+package ops
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+	ReplyToValue   sarah.OutputDestination
+}
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+func buildCommand(t *testing.T, props *sarah.CommandProps) sarah.Command {
+	command, err := sarah.BuildCommand(context.TODO(), props, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return command
+}
+
+func TestProps(t *testing.T) {
+	tests := []struct {
+		props   *sarah.CommandProps
+		message string
+	}{
+		{
+			props:   PingProps,
+			message: ".ping",
+		},
+		{
+			props:   UptimeProps,
+			message: ".uptime",
+		},
+		{
+			props:   VersionProps,
+			message: ".version",
+		},
+	}
+
+	for _, tt := range tests {
+		command := buildCommand(t, tt.props)
+
+		if !command.Match(&DummyInput{MessageValue: tt.message}) {
+			t.Errorf("%s must match.", tt.message)
+		}
+
+		if command.Match(&DummyInput{MessageValue: tt.message + " foo"}) {
+			t.Errorf("%s with an argument must not match.", tt.message)
+		}
+
+		if command.Instruction(&sarah.HelpInput{}) == "" {
+			t.Errorf("Instruction of %s is empty.", command.Identifier())
+		}
+	}
+}
+
+func Test_ping(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC)
+	ctx := clock.WithContext(context.TODO(), clock.NewFake(now))
+	command := buildCommand(t, PingProps)
+
+	res, err := command.Execute(ctx, &DummyInput{MessageValue: ".ping", SentAtValue: now.Add(-1500 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res.Content != "pong\nSince sent: 1.5s" {
+		t.Errorf("Unexpected response is returned: %#v.", res.Content)
+	}
+
+	// A sent time ahead of the bot's clock is not shown.
+	res, err = command.Execute(ctx, &DummyInput{MessageValue: ".ping", SentAtValue: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res.Content != "pong" {
+		t.Errorf("Unexpected response is returned: %#v.", res.Content)
+	}
+}
+
+func Test_uptime(t *testing.T) {
+	ctx := clock.WithContext(context.TODO(), clock.NewFake(startedAt.Add(90*time.Minute)))
+	res, err := buildCommand(t, UptimeProps).Execute(ctx, &DummyInput{MessageValue: ".uptime"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if !strings.HasPrefix(res.Content.(string), "Up for 1h30m0s since ") {
+		t.Errorf("Unexpected response is returned: %#v.", res.Content)
+	}
+}
+
+func TestVersionInfo(t *testing.T) {
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Path: "example.com/mybot", Version: "v1.0.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.modified", Value: "false"},
+			},
+		}, true
+	}
+	defer func() {
+		readBuildInfo = debug.ReadBuildInfo
+	}()
+
+	if !strings.HasPrefix(VersionInfo(), "Version: v1.0.0\nModule: example.com/mybot\nRevision: abc123\nGo: ") {
+		t.Errorf("Unexpected version info is returned: %s.", VersionInfo())
+	}
+
+	Version = "v2.0.0"
+	defer func() {
+		Version = ""
+	}()
+
+	if !strings.HasPrefix(VersionInfo(), "Version: v2.0.0\n") {
+		t.Errorf("Given version is not shown: %s.", VersionInfo())
+	}
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return nil, false
+	}
+	Version = ""
+	if !strings.HasPrefix(VersionInfo(), "Version: unknown\nGo: ") {
+		t.Errorf("Unexpected version info is returned: %s.", VersionInfo())
+	}
+}
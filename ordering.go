@@ -0,0 +1,98 @@
+package sarah
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BotWithOrderedSend creates and returns DefaultBotOption to send the messages to the same destination one by one
+// in the order Bot.SendMessage is called.
+//
+// Each Input is handled in its own worker job, so the replies to the rapid sequential Inputs may arrive in the different order,
+// and the parts of a long message split by BotWithMaxMessageLength may interleave with another reply.
+// With this, a call to Bot.SendMessage waits til the preceding calls for the same destination finish.
+// A thread shares the order with the destination it belongs to. See BaseDestination.
+//
+// The order is kept only within the process. BotWithOutbox already sends the messages one by one in the queued order.
+func BotWithOrderedSend(ordered bool) DefaultBotOption {
+	return func(bot *defaultBot) {
+		if ordered {
+			bot.sendQueue = newDestinationQueue()
+		} else {
+			bot.sendQueue = nil
+		}
+	}
+}
+
+// destinationQueue lets the callers for the same destination proceed one by one in the order they arrive.
+// This is safe with a nil receiver so the ordering can be disabled by leaving it nil.
+type destinationQueue struct {
+	mutex sync.Mutex
+
+	// waiting holds the channels of the callers waiting for their turn, keyed by the destination.
+	// The key exists while a caller is proceeding.
+	waiting map[string][]chan struct{}
+}
+
+func newDestinationQueue() *destinationQueue {
+	return &destinationQueue{
+		waiting: map[string][]chan struct{}{},
+	}
+}
+
+// acquire blocks til the preceding callers for the given destination call the returned function.
+// The returned function must be called when the caller finishes. An error is returned when the context is canceled while waiting.
+func (q *destinationQueue) acquire(ctx context.Context, destination OutputDestination) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	key := fmt.Sprint(BaseDestination(destination))
+	q.mutex.Lock()
+	waiting, ok := q.waiting[key]
+	if !ok {
+		q.waiting[key] = []chan struct{}{}
+		q.mutex.Unlock()
+		return func() { q.release(key) }, nil
+	}
+
+	turn := make(chan struct{})
+	q.waiting[key] = append(waiting, turn)
+	q.mutex.Unlock()
+
+	select {
+	case <-turn:
+		return func() { q.release(key) }, nil
+
+	case <-ctx.Done():
+		q.mutex.Lock()
+		for i, c := range q.waiting[key] {
+			if c == turn {
+				q.waiting[key] = append(q.waiting[key][:i], q.waiting[key][i+1:]...)
+				q.mutex.Unlock()
+				return nil, ctx.Err()
+			}
+		}
+		q.mutex.Unlock()
+
+		// The turn came at the same time. Pass it to the next caller.
+		q.release(key)
+		return nil, ctx.Err()
+
+	}
+}
+
+// release passes the turn to the next caller for the given key.
+func (q *destinationQueue) release(key string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	waiting := q.waiting[key]
+	if len(waiting) == 0 {
+		delete(q.waiting, key)
+		return
+	}
+	q.waiting[key] = waiting[1:]
+	close(waiting[0])
+}
@@ -0,0 +1,108 @@
+package sarah
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBotWithOrderedSend(t *testing.T) {
+	bot := &defaultBot{}
+
+	BotWithOrderedSend(true)(bot)
+	if bot.sendQueue == nil {
+		t.Fatal("Option is not applied.")
+	}
+
+	BotWithOrderedSend(false)(bot)
+	if bot.sendQueue != nil {
+		t.Error("Option is not applied.")
+	}
+}
+
+func Test_destinationQueue_acquire(t *testing.T) {
+	var nilQueue *destinationQueue
+	release, err := nilQueue.acquire(context.TODO(), "#general")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	release()
+
+	q := newDestinationQueue()
+	releaseFirst, err := q.acquire(context.TODO(), "#general")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// Another destination is not blocked.
+	releaseOther, err := q.acquire(context.TODO(), "#random")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	releaseOther()
+
+	order := make(chan int, 2)
+	for i := 1; i <= 2; i++ {
+		i := i
+		go func() {
+			// A thread waits for its channel.
+			release, err := q.acquire(context.TODO(), NewThreadDestination("#general", "thread"))
+			if err != nil {
+				t.Errorf("Unexpected error is returned: %s.", err.Error())
+				return
+			}
+			order <- i
+			release()
+		}()
+		// Let the goroutines wait in order.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case i := <-order:
+		t.Fatalf("Caller #%d must wait for the preceding caller.", i)
+
+	default:
+
+	}
+
+	releaseFirst()
+	for expected := 1; expected <= 2; expected++ {
+		select {
+		case i := <-order:
+			if i != expected {
+				t.Errorf("Unexpected order: %d.", i)
+			}
+
+		case <-time.NewTimer(time.Second).C:
+			t.Fatal("Waiting caller does not proceed.")
+
+		}
+	}
+}
+
+func Test_destinationQueue_acquire_Canceled(t *testing.T) {
+	q := newDestinationQueue()
+	release, err := q.acquire(context.TODO(), "#general")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = q.acquire(ctx, "#general")
+	if err != context.Canceled {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	release()
+	release, err = q.acquire(context.TODO(), "#general")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	release()
+
+	if len(q.waiting) != 0 {
+		t.Errorf("Released destination must be forgotten: %#v.", q.waiting)
+	}
+}
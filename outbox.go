@@ -0,0 +1,387 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/retry"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrOutboxFull is given to the dead-letter handler when a message can not be queued because the Outbox is full.
+var ErrOutboxFull = errors.New("outbox is full")
+
+// OutboxConfig contains some configuration variables for Outbox.
+type OutboxConfig struct {
+	// QueueSize is the number of messages the Outbox can hold while the delivery is delayed.
+	QueueSize uint `json:"queue_size" yaml:"queue_size"`
+
+	// RetryPolicy defines how a failed delivery is retried.
+	RetryPolicy *retry.Policy `json:"retry_policy" yaml:"retry_policy"`
+}
+
+// NewOutboxConfig returns a pointer to OutboxConfig with default setting.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewOutboxConfig() *OutboxConfig {
+	return &OutboxConfig{
+		QueueSize: 100,
+		RetryPolicy: &retry.Policy{
+			Trial:       5,
+			Interval:    time.Second,
+			Multiplier:  2,
+			MaxInterval: time.Minute,
+			Jitter:      retry.JitterEqual,
+		},
+	}
+}
+
+// OutboxEntry is a message waiting for its delivery in Outbox.
+type OutboxEntry struct {
+	// ID is the unique identifier of the entry.
+	ID string
+
+	// Output is the message to be sent.
+	Output Output
+
+	// CorrelationID is the correlation ID of the context that the message was sent with. See WithCorrelationID.
+	CorrelationID string
+
+	// EnqueuedAt is the time when the message was queued.
+	EnqueuedAt time.Time
+}
+
+// OutboxStore defines an interface that persists the queued messages so they survive a restart.
+// Outbox saves an entry when the entry is queued and deletes it when the delivery succeeds or is given up.
+// On Outbox.Run, the entries left in the store are loaded and delivered first.
+type OutboxStore interface {
+	// Save persists the given entry.
+	Save(*OutboxEntry) error
+
+	// Delete removes the entry with the given ID.
+	Delete(id string) error
+
+	// Load returns the persisted entries in the order of their EnqueuedAt.
+	Load() ([]*OutboxEntry, error)
+}
+
+// OutputCodec defines an interface that converts an Output to bytes and vice versa.
+// Since OutputDestination and the content are Adapter specific, an Adapter provides its own implementation to persist its messages.
+type OutputCodec interface {
+	// Encode converts the given Output to bytes.
+	// An error is returned when the Output can not be converted such as when the content is a function.
+	Encode(Output) ([]byte, error)
+
+	// Decode converts the given bytes back to an Output.
+	Decode([]byte) (Output, error)
+}
+
+// OutboxOption defines a function signature that NewOutbox's functional option must satisfy.
+type OutboxOption func(*Outbox)
+
+// OutboxWithStore creates an OutboxOption that sets the OutboxStore to persist the queued messages.
+func OutboxWithStore(store OutboxStore) OutboxOption {
+	return func(outbox *Outbox) {
+		outbox.store = store
+	}
+}
+
+// OutboxWithDeadLetterHandler creates an OutboxOption that sets the function to be called when a message can not be delivered.
+// The given error is the last delivery error or ErrOutboxFull.
+// The handler may store the message somewhere else for later investigation or notify the administrator.
+func OutboxWithDeadLetterHandler(handler func(context.Context, Output, error)) OutboxOption {
+	return func(outbox *Outbox) {
+		outbox.deadLetterHandler = handler
+	}
+}
+
+// Outbox is a queue of outgoing messages that retries a failed delivery with the configured retry.Policy.
+// Give this to NewBot with BotWithOutbox so Bot.SendMessage queues the message instead of sending it directly.
+// The messages are delivered one by one in the order they are queued, so a delivery being retried delays the succeeding messages.
+//
+// A delivery is considered failed when Adapter.SendMessage returns an error.
+// A message is not retried with an Adapter that sends messages asynchronously and does not wait for the result.
+//
+// A message that still fails after the retries or that can not be queued is passed to the dead-letter handler and published as MessageDeadLettered.
+// With OutboxStore, the messages left in the queue on shutdown are delivered on the next run.
+type Outbox struct {
+	config            *OutboxConfig
+	store             OutboxStore
+	deadLetterHandler func(context.Context, Output, error)
+	queue             chan *OutboxEntry
+	pending           map[string]struct{}
+	mutex             sync.Mutex
+}
+
+// NewOutbox creates and returns a new Outbox.
+func NewOutbox(config *OutboxConfig, options ...OutboxOption) *Outbox {
+	outbox := &Outbox{
+		config:            config,
+		store:             nil,
+		deadLetterHandler: nil,
+		queue:             make(chan *OutboxEntry, config.QueueSize),
+		pending:           map[string]struct{}{},
+	}
+
+	for _, opt := range options {
+		opt(outbox)
+	}
+
+	return outbox
+}
+
+// Enqueue adds the given message to the queue.
+// When the queue is full, the message is passed to the dead-letter handler with ErrOutboxFull.
+func (o *Outbox) Enqueue(ctx context.Context, output Output) {
+	entry := &OutboxEntry{
+		ID:            newCorrelationID(),
+		Output:        output,
+		CorrelationID: CorrelationID(ctx),
+		EnqueuedAt:    time.Now(),
+	}
+
+	// Persist before queuing so the delivery never precedes the persistence.
+	o.save(ctx, entry)
+
+	o.mutex.Lock()
+	o.pending[entry.ID] = struct{}{}
+	o.mutex.Unlock()
+
+	select {
+	case o.queue <- entry:
+		// Queued.
+
+	default:
+		o.done(ctx, entry)
+		o.deadLetter(ctx, output, ErrOutboxFull)
+
+	}
+}
+
+// save persists the given entry when OutboxStore is set.
+func (o *Outbox) save(ctx context.Context, entry *OutboxEntry) {
+	if o.store == nil {
+		return
+	}
+
+	err := o.store.Save(entry)
+	if err != nil {
+		contextLogger(ctx).Warn("Failed to persist outgoing message. The message is kept only in memory", logging.Err(err))
+	}
+}
+
+// Run delivers the queued messages with the given function til the given context is canceled.
+// The given function is typically Adapter.SendMessage.
+// go-sarah's core calls this when the Bot created with BotWithOutbox runs, so this is rarely called directly.
+func (o *Outbox) Run(ctx context.Context, send func(context.Context, Output) (*SendResult, error)) {
+	if o.store != nil {
+		entries, err := o.store.Load()
+		if err != nil {
+			contextLogger(ctx).Error("Failed to load persisted outgoing messages", logging.Err(err))
+		}
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return
+			}
+
+			o.mutex.Lock()
+			_, queued := o.pending[entry.ID]
+			o.mutex.Unlock()
+			if queued {
+				// Enqueued before Run is called. This is delivered from the queue.
+				continue
+			}
+
+			o.deliver(ctx, entry, send)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if n := len(o.queue); n > 0 && o.store == nil {
+				contextLogger(ctx).Warn("Outgoing messages are discarded on shutdown", logging.F("count", n))
+			}
+			return
+
+		case entry := <-o.queue:
+			o.deliver(ctx, entry, send)
+
+		}
+	}
+}
+
+func (o *Outbox) deliver(ctx context.Context, entry *OutboxEntry, send func(context.Context, Output) (*SendResult, error)) {
+	if entry.CorrelationID != "" {
+		ctx = WithCorrelationID(ctx, entry.CorrelationID)
+	}
+	log := contextLogger(ctx).With(logging.F(logging.KeyDestination, entry.Output.Destination()))
+
+	policy := *o.config.RetryPolicy
+	policy.OnRetry = func(attempt uint, err error, next time.Duration) {
+		log.Warn("Failed to send message. Retrying", logging.F("attempt", attempt), logging.F("next", next), logging.Err(err))
+	}
+
+	err := retry.WithPolicyContext(ctx, &policy, func() error {
+		_, err := send(ctx, entry.Output)
+		return err
+	})
+	if err != nil && ctx.Err() != nil {
+		// Shutting down. The entry is left in the store to be delivered on the next run.
+		return
+	}
+
+	o.done(ctx, entry)
+
+	if err != nil {
+		o.deadLetter(ctx, entry.Output, err)
+	}
+}
+
+// done removes the given entry from the pending entries and from the store.
+func (o *Outbox) done(ctx context.Context, entry *OutboxEntry) {
+	o.mutex.Lock()
+	delete(o.pending, entry.ID)
+	o.mutex.Unlock()
+
+	if o.store == nil {
+		return
+	}
+
+	err := o.store.Delete(entry.ID)
+	if err != nil {
+		contextLogger(ctx).Warn("Failed to delete outgoing message from the store", logging.Err(err))
+	}
+}
+
+func (o *Outbox) deadLetter(ctx context.Context, output Output, err error) {
+	contextLogger(ctx).Error("Failed to deliver message", logging.F(logging.KeyDestination, output.Destination()), logging.Err(err))
+
+	PublishEvent(ctx, &MessageDeadLettered{
+		Destination: output.Destination(),
+		Err:         err,
+		Time:        time.Now(),
+	})
+
+	if o.deadLetterHandler != nil {
+		o.deadLetterHandler(ctx, output, err)
+	}
+}
+
+// kvOutboxStore is an OutboxStore that stores each entry as a JSON value in Store.
+type kvOutboxStore struct {
+	store Store
+	codec OutputCodec
+}
+
+var _ OutboxStore = (*kvOutboxStore)(nil)
+
+type storedOutboxEntry struct {
+	ID            string          `json:"id"`
+	Output        json.RawMessage `json:"output"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	EnqueuedAt    time.Time       `json:"enqueued_at"`
+}
+
+// NewOutboxStore creates and returns an OutboxStore that stores each entry as a JSON value in the given Store with the entry's ID as the key.
+// Give the Store namespaced with NewNamespacedStore so the keys do not conflict with other components'.
+// The given OutputCodec converts the Output to be stored, so the codec must produce a valid JSON value.
+func NewOutboxStore(store Store, codec OutputCodec) OutboxStore {
+	return &kvOutboxStore{
+		store: store,
+		codec: codec,
+	}
+}
+
+// NewFileOutboxStore creates and returns an OutboxStore that stores each entry as a file in the given directory.
+// This is a shorthand for NewOutboxStore with the Store NewFileStore returns.
+func NewFileOutboxStore(dir string, codec OutputCodec) (OutboxStore, error) {
+	store, err := NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOutboxStore(store, codec), nil
+}
+
+func (s *kvOutboxStore) Save(entry *OutboxEntry) error {
+	output, err := s.codec.Encode(entry.Output)
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	b, err := json.Marshal(&storedOutboxEntry{
+		ID:            entry.ID,
+		Output:        output,
+		CorrelationID: entry.CorrelationID,
+		EnqueuedAt:    entry.EnqueuedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.store.Set(context.Background(), entry.ID, b)
+}
+
+func (s *kvOutboxStore) Delete(id string) error {
+	return s.store.Delete(context.Background(), id)
+}
+
+// Load skips an entry that can not be read or decoded so the rest of the entries are still delivered.
+// The skipped entry is left in the Store for investigation and is tried again on the next Load.
+func (s *kvOutboxStore) Load() ([]*OutboxEntry, error) {
+	ctx := context.Background()
+	ids, err := s.store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*OutboxEntry
+	for _, id := range ids {
+		entry, err := s.load(ctx, id)
+		if errors.Is(err, ErrStoreKeyNotFound) {
+			// Deleted after the listing.
+			continue
+		}
+		if err != nil {
+			contextLogger(ctx).Error("Skip persisted outgoing message that can not be loaded", logging.F("id", id), logging.Err(err))
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].EnqueuedAt.Before(entries[j].EnqueuedAt)
+	})
+
+	return entries, nil
+}
+
+func (s *kvOutboxStore) load(ctx context.Context, id string) (*OutboxEntry, error) {
+	b, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &storedOutboxEntry{}
+	err = json.Unmarshal(b, stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", id, err)
+	}
+
+	output, err := s.codec.Decode(stored.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode output in %s: %w", id, err)
+	}
+
+	return &OutboxEntry{
+		ID:            stored.ID,
+		Output:        output,
+		CorrelationID: stored.CorrelationID,
+		EnqueuedAt:    stored.EnqueuedAt,
+	}, nil
+}
@@ -0,0 +1,409 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/retry"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type DummyOutputCodec struct {
+	EncodeFunc func(Output) ([]byte, error)
+	DecodeFunc func([]byte) (Output, error)
+}
+
+var _ OutputCodec = (*DummyOutputCodec)(nil)
+
+func (c *DummyOutputCodec) Encode(output Output) ([]byte, error) {
+	return c.EncodeFunc(output)
+}
+
+func (c *DummyOutputCodec) Decode(b []byte) (Output, error) {
+	return c.DecodeFunc(b)
+}
+
+// stringOutputCodec is a DummyOutputCodec that handles string destination and string content.
+func stringOutputCodec() *DummyOutputCodec {
+	return &DummyOutputCodec{
+		EncodeFunc: func(output Output) ([]byte, error) {
+			return json.Marshal([]interface{}{output.Destination(), output.Content()})
+		},
+		DecodeFunc: func(b []byte) (Output, error) {
+			var stored []string
+			err := json.Unmarshal(b, &stored)
+			if err != nil {
+				return nil, err
+			}
+			return NewOutputMessage(stored[0], stored[1]), nil
+		},
+	}
+}
+
+type DummyOutboxStore struct {
+	SaveFunc   func(*OutboxEntry) error
+	DeleteFunc func(string) error
+	LoadFunc   func() ([]*OutboxEntry, error)
+}
+
+var _ OutboxStore = (*DummyOutboxStore)(nil)
+
+func (s *DummyOutboxStore) Save(entry *OutboxEntry) error {
+	return s.SaveFunc(entry)
+}
+
+func (s *DummyOutboxStore) Delete(id string) error {
+	return s.DeleteFunc(id)
+}
+
+func (s *DummyOutboxStore) Load() ([]*OutboxEntry, error) {
+	return s.LoadFunc()
+}
+
+func testOutboxConfig(trial uint) *OutboxConfig {
+	return &OutboxConfig{
+		QueueSize: 10,
+		RetryPolicy: &retry.Policy{
+			Trial:    trial,
+			Interval: time.Millisecond,
+		},
+	}
+}
+
+func TestNewOutboxConfig(t *testing.T) {
+	config := NewOutboxConfig()
+
+	if config.QueueSize == 0 {
+		t.Error("QueueSize is not set.")
+	}
+
+	if config.RetryPolicy == nil || config.RetryPolicy.Trial == 0 {
+		t.Errorf("RetryPolicy is not set: %#v.", config.RetryPolicy)
+	}
+}
+
+func TestNewOutbox(t *testing.T) {
+	store := &DummyOutboxStore{}
+	handler := func(_ context.Context, _ Output, _ error) {}
+	config := testOutboxConfig(1)
+
+	outbox := NewOutbox(config, OutboxWithStore(store), OutboxWithDeadLetterHandler(handler))
+
+	if outbox.config != config {
+		t.Errorf("Given config is not set: %#v.", outbox.config)
+	}
+
+	if outbox.store != store {
+		t.Errorf("Given store is not set: %#v.", outbox.store)
+	}
+
+	if outbox.deadLetterHandler == nil {
+		t.Error("Given handler is not set.")
+	}
+
+	if cap(outbox.queue) != int(config.QueueSize) {
+		t.Errorf("Unexpected queue size: %d.", cap(outbox.queue))
+	}
+}
+
+func TestOutbox_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sent := make(chan Output, 10)
+	attempts := 0
+	send := func(ctx context.Context, output Output) (*SendResult, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("temporary")
+		}
+		if CorrelationID(ctx) != "correlation" {
+			t.Errorf("Correlation ID is not carried: %s.", CorrelationID(ctx))
+		}
+		sent <- output
+		return &SendResult{Destination: output.Destination()}, nil
+	}
+
+	outbox := NewOutbox(testOutboxConfig(3), OutboxWithDeadLetterHandler(func(_ context.Context, _ Output, err error) {
+		t.Errorf("Dead-letter handler is called: %s.", err.Error())
+	}))
+	outbox.Enqueue(WithCorrelationID(ctx, "correlation"), NewOutputMessage("dest", "message"))
+
+	go outbox.Run(ctx, send)
+
+	select {
+	case output := <-sent:
+		if output.Content() != "message" {
+			t.Errorf("Unexpected content is sent: %#v.", output.Content())
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Message is not sent.")
+
+	}
+
+	if attempts != 2 {
+		t.Errorf("Unexpected number of attempts: %d.", attempts)
+	}
+}
+
+func TestOutbox_Run_DeadLetter(t *testing.T) {
+	bus := NewEventBus()
+	deadLettered := make(chan *MessageDeadLettered, 1)
+	bus.Subscribe(func(e Event) {
+		if ev, ok := e.(*MessageDeadLettered); ok {
+			deadLettered <- ev
+		}
+	})
+
+	ctx, cancel := context.WithCancel(NewEventBusContext(context.Background(), bus))
+	defer cancel()
+
+	expectedErr := errors.New("permanent")
+	attempts := 0
+	send := func(_ context.Context, _ Output) (*SendResult, error) {
+		attempts++
+		return nil, expectedErr
+	}
+
+	handled := make(chan error, 1)
+	outbox := NewOutbox(testOutboxConfig(3), OutboxWithDeadLetterHandler(func(_ context.Context, output Output, err error) {
+		handled <- err
+	}))
+	outbox.Enqueue(ctx, NewOutputMessage("dest", "message"))
+
+	go outbox.Run(ctx, send)
+
+	select {
+	case err := <-handled:
+		errs, ok := err.(*retry.Errors)
+		if !ok {
+			t.Fatalf("Unexpected error is given: %#v.", err)
+		}
+		if (*errs)[0] != expectedErr {
+			t.Errorf("Expected error is not given: %#v.", (*errs)[0])
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Dead-letter handler is not called.")
+
+	}
+
+	if attempts != 3 {
+		t.Errorf("Unexpected number of attempts: %d.", attempts)
+	}
+
+	select {
+	case ev := <-deadLettered:
+		if ev.Destination != "dest" {
+			t.Errorf("Unexpected destination is given: %#v.", ev.Destination)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("MessageDeadLettered is not published.")
+
+	}
+}
+
+func TestOutbox_Enqueue_Full(t *testing.T) {
+	var deleted []string
+	store := &DummyOutboxStore{
+		SaveFunc: func(_ *OutboxEntry) error {
+			return nil
+		},
+		DeleteFunc: func(id string) error {
+			deleted = append(deleted, id)
+			return nil
+		},
+	}
+
+	var givenErr error
+	config := testOutboxConfig(1)
+	config.QueueSize = 1
+	outbox := NewOutbox(config, OutboxWithStore(store), OutboxWithDeadLetterHandler(func(_ context.Context, _ Output, err error) {
+		givenErr = err
+	}))
+
+	outbox.Enqueue(context.TODO(), NewOutputMessage("dest", "first"))
+	outbox.Enqueue(context.TODO(), NewOutputMessage("dest", "second"))
+
+	if givenErr != ErrOutboxFull {
+		t.Errorf("Expected error is not given: %#v.", givenErr)
+	}
+
+	if len(deleted) != 1 {
+		t.Errorf("Rejected entry is not deleted from the store: %#v.", deleted)
+	}
+
+	if len(outbox.pending) != 1 {
+		t.Errorf("Unexpected number of pending entries: %d.", len(outbox.pending))
+	}
+}
+
+func TestOutbox_Run_WithStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileOutboxStore(dir, stringOutputCodec())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// Enqueue without running. This imitates the messages left on shutdown.
+	outbox := NewOutbox(testOutboxConfig(1), OutboxWithStore(store))
+	outbox.Enqueue(context.TODO(), NewOutputMessage("dest", "first"))
+	outbox.Enqueue(context.TODO(), NewOutputMessage("dest", "second"))
+
+	// Start another Outbox with the same store.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sent := make(chan Output, 10)
+	outbox = NewOutbox(testOutboxConfig(1), OutboxWithStore(store))
+	outbox.Enqueue(context.TODO(), NewOutputMessage("dest", "third"))
+	go outbox.Run(ctx, func(_ context.Context, output Output) (*SendResult, error) {
+		sent <- output
+		return nil, nil
+	})
+
+	for _, expected := range []string{"first", "second", "third"} {
+		select {
+		case output := <-sent:
+			if output.Content() != expected {
+				t.Errorf("Unexpected content is sent: %#v.", output.Content())
+			}
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatalf("Message is not sent: %s.", expected)
+
+		}
+	}
+
+	// Wait for the last entry to be deleted.
+	time.Sleep(10 * time.Millisecond)
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(entries) != 0 {
+		t.Errorf("Delivered entries are left in the store: %d.", len(entries))
+	}
+}
+
+func TestFileOutboxStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileOutboxStore(filepath.Join(dir, "nested"), stringOutputCodec())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	now := time.Now()
+	entries := []*OutboxEntry{
+		{ID: "b", Output: NewOutputMessage("dest", "later"), CorrelationID: "correlation", EnqueuedAt: now.Add(time.Second)},
+		{ID: "a", Output: NewOutputMessage("dest", "earlier"), EnqueuedAt: now},
+	}
+	for _, entry := range entries {
+		err = store.Save(entry)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("Unexpected number of entries are loaded: %d.", len(loaded))
+	}
+	if loaded[0].ID != "a" || loaded[0].Output.Content() != "earlier" {
+		t.Errorf("Entries are not sorted: %#v.", loaded[0])
+	}
+	if loaded[1].CorrelationID != "correlation" || loaded[1].Output.Destination() != "dest" {
+		t.Errorf("Unexpected entry is loaded: %#v.", loaded[1])
+	}
+
+	err = store.Delete("a")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	err = store.Delete("a")
+	if err != nil {
+		t.Errorf("Deleting a missing entry must not be an error: %s.", err.Error())
+	}
+
+	loaded, _ = store.Load()
+	if len(loaded) != 1 {
+		t.Errorf("Entry is not deleted: %d.", len(loaded))
+	}
+}
+
+// brokenGetStore is a Store that fails to get the given key.
+type brokenGetStore struct {
+	Store
+	key string
+}
+
+func (s *brokenGetStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if key == s.key {
+		return nil, errors.New("read error")
+	}
+	return s.Store.Get(ctx, key)
+}
+
+func TestNewOutboxStore_Load_BrokenEntry(t *testing.T) {
+	store := NewMemoryStore()
+	outboxStore := NewOutboxStore(&brokenGetStore{Store: store, key: "unreadable"}, stringOutputCodec())
+	err := outboxStore.Save(&OutboxEntry{ID: "valid", Output: NewOutputMessage("dest", "content")})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	_ = store.Set(context.TODO(), "broken", []byte("{"))
+	_ = store.Set(context.TODO(), "unreadable", []byte("{}"))
+
+	loaded, err := outboxStore.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(loaded) != 1 || loaded[0].ID != "valid" {
+		t.Errorf("Valid entry must be loaded regardless of the broken ones: %#v.", loaded)
+	}
+
+	// The broken entry is left for investigation.
+	_, err = store.Get(context.TODO(), "broken")
+	if err != nil {
+		t.Errorf("Broken entry must not be deleted: %s.", err.Error())
+	}
+}
+
+func TestFileOutboxStore_Save_EncodeError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	expectedErr := errors.New("unsupported")
+	store, _ := NewFileOutboxStore(dir, &DummyOutputCodec{
+		EncodeFunc: func(_ Output) ([]byte, error) {
+			return nil, expectedErr
+		},
+	})
+
+	err = store.Save(&OutboxEntry{ID: "a", Output: NewOutputMessage("dest", func() {})})
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
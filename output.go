@@ -1,5 +1,13 @@
 package sarah
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupportedContent is returned by Adapter.SendMessage when the Adapter does not know how to send the given content.
+var ErrUnsupportedContent = errors.New("content is not supported")
+
 // Output defines interface that each outgoing message must satisfy.
 type Output interface {
 	Destination() OutputDestination
@@ -32,3 +40,20 @@ func (output *OutputMessage) Destination() OutputDestination {
 func (output *OutputMessage) Content() interface{} {
 	return output.content
 }
+
+// SendResult is the receipt of a message that Adapter.SendMessage and Bot.SendMessage return on a successful delivery.
+type SendResult struct {
+	// Destination is the destination that the message was sent to.
+	Destination OutputDestination
+
+	// MessageID is the chat service specific identifier of the sent message such as Slack's timestamp.
+	// This is empty when the chat service does not tell the identifier.
+	MessageID string
+
+	// SentAt is the time when the message was sent.
+	SentAt time.Time
+
+	// Queued tells the message is not sent yet but queued to be delivered later by the Outbox. See BotWithOutbox.
+	// MessageID and SentAt are not set in this case; subscribe MessageSent and SendFailed to observe the delivery.
+	Queued bool
+}
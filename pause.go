@@ -0,0 +1,115 @@
+package sarah
+
+import (
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+)
+
+// ErrBotPaused is returned to the Bot by the function to pass an Input when the Bot is paused by PauseBot
+// and the Input can not be kept because the paused Bot already keeps too many Inputs.
+// The Input is dropped, so the Bot or the Adapter may tell the sender to try again later.
+var ErrBotPaused = errors.New("bot is paused")
+
+// PauseBot lets the running Bot with the given BotType stop handling the Inputs without closing its connection.
+// While paused, the Inputs are kept without being passed to any Command, and are handled in the received order on ResumeBot.
+// Up to 1,000 Inputs are kept, and the function given to Bot.Run returns ErrBotPaused for the Inputs beyond that.
+// ScheduledTasks keep running.
+// Unlike StartMaintenance, no reply is sent and every Input is kept regardless of whether it matches a Command.
+//
+// This is useful to migrate configurations or to respond to an incident. Since no Command is executed while paused,
+// call ResumeBot from somewhere else than the paused Bot such as a Command of another Bot or an HTTP endpoint.
+// Pausing a paused Bot does nothing. The kept Inputs are discarded when the Bot stops.
+func PauseBot(botType BotType) error {
+	return runnerStatus.components.pause(botType)
+}
+
+// ResumeBot lets the Bot with the given BotType paused by PauseBot handle the Inputs again.
+// The Inputs kept while paused are handled in the received order.
+// Nothing happens when the Bot is not paused.
+func ResumeBot(botType BotType) error {
+	return runnerStatus.components.resume(botType)
+}
+
+func (m *managedComponents) pause(botType BotType) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.receivers[botType]; !ok {
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, botType)
+	}
+
+	if m.paused == nil {
+		m.paused = map[BotType][]Input{}
+	}
+	if _, ok := m.paused[botType]; !ok {
+		m.paused[botType] = []Input{}
+	}
+	return nil
+}
+
+func (m *managedComponents) resume(botType BotType) error {
+	m.mutex.Lock()
+	receive, ok := m.receivers[botType]
+	kept, paused := m.paused[botType]
+	delete(m.paused, botType)
+	m.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrBotNotRunning, botType)
+	}
+	if !paused {
+		return nil
+	}
+
+	// Call the function without the lock since the Input goes through the Commands.
+	for _, input := range kept {
+		receive(input)
+	}
+	return nil
+}
+
+func (m *managedComponents) botPaused(botType BotType) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	_, ok := m.paused[botType]
+	return ok
+}
+
+// keepPaused keeps the given Input til the Bot is resumed. false is returned when the Bot is not paused.
+// ErrBotPaused is returned when the Input is not kept because maxQueuedInputs Inputs are already kept.
+func (m *managedComponents) keepPaused(botType BotType, input Input) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	kept, ok := m.paused[botType]
+	if !ok {
+		return false, nil
+	}
+	if len(kept) >= maxQueuedInputs {
+		return true, ErrBotPaused
+	}
+	m.paused[botType] = append(kept, input)
+	return true, nil
+}
+
+// pausable returns a function that passes the given Input to the given function unless the Bot is paused.
+// While the Bot is paused, the Input is kept til ResumeBot is called.
+func pausable(botType BotType, receiveInput func(Input) error) func(Input) error {
+	return func(input Input) error {
+		paused, err := runnerStatus.components.keepPaused(botType, input)
+		if !paused {
+			return receiveInput(input)
+		}
+
+		if err != nil {
+			moduleLogger().Warn(
+				"Drop input since the paused bot keeps too many inputs",
+				logging.F(logging.KeyBotType, botType),
+				logging.F("sender_key", input.SenderKey()),
+			)
+		}
+		return err
+	}
+}
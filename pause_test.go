@@ -0,0 +1,108 @@
+package sarah
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPauseBot(t *testing.T) {
+	SetupAndRun(func() {
+		err := PauseBot("dummy")
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		err = ResumeBot("dummy")
+		if !errors.Is(err, ErrBotNotRunning) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		runnerStatus.addBot(&DummyBot{BotTypeValue: "dummy"})
+		var received []Input
+		runnerStatus.components.receiver("dummy", func(input Input) {
+			received = append(received, input)
+		})
+		receiveInput := pausable("dummy", func(input Input) error {
+			received = append(received, input)
+			return nil
+		})
+
+		err = PauseBot("dummy")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		kept := &DummyInput{}
+		err = receiveInput(kept)
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(received) != 0 {
+			t.Errorf("Input must not be passed while paused: %#v.", received)
+		}
+		if status := CurrentStatus(); len(status.Bots) != 1 || !status.Bots[0].Paused {
+			t.Errorf("Paused state is not reported: %#v.", status)
+		}
+
+		err = ResumeBot("dummy")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(received) != 1 || received[0] != kept {
+			t.Errorf("Input kept while paused must be passed on resume: %#v.", received)
+		}
+
+		err = receiveInput(&DummyInput{})
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(received) != 2 {
+			t.Errorf("Input must be passed after resume: %#v.", received)
+		}
+		if CurrentStatus().Bots[0].Paused {
+			t.Error("Resumed Bot must not be reported as paused.")
+		}
+
+		runnerStatus.components.removeBot("dummy")
+	})
+}
+
+func TestPauseBot_TooManyInputs(t *testing.T) {
+	SetupAndRun(func() {
+		runnerStatus.addBot(&DummyBot{BotTypeValue: "dummy"})
+		received := 0
+		runnerStatus.components.receiver("dummy", func(_ Input) {
+			received++
+		})
+		receiveInput := pausable("dummy", func(_ Input) error {
+			return nil
+		})
+
+		err := PauseBot("dummy")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		for i := 0; i < maxQueuedInputs; i++ {
+			err = receiveInput(&DummyInput{})
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+		}
+
+		err = receiveInput(&DummyInput{})
+		if err != ErrBotPaused {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		err = ResumeBot("dummy")
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if received != maxQueuedInputs {
+			t.Errorf("Unexpected number of Inputs are passed on resume: %d.", received)
+		}
+
+		runnerStatus.components.removeBot("dummy")
+	})
+}
@@ -0,0 +1,194 @@
+/*
+Package pluginloader discovers Go plugin shared objects in a directory and registers the Commands and ScheduledTasks they expose.
+
+A plugin is a main package built with "go build -buildmode=plugin" that exposes one or both of the following functions:
+
+	// Returns the CommandProps to be registered via sarah.RegisterCommandProps.
+	func Props() []*sarah.CommandProps
+
+	// Returns the ScheduledTaskProps to be registered via sarah.RegisterScheduledTaskProps.
+	func ScheduledTaskProps() []*sarah.ScheduledTaskProps
+
+The host binary calls Load before sarah.Run so the Commands are distributed as .so files without recompiling the host binary:
+
+	config := pluginloader.NewConfig()
+	config.Dir = "/path/to/plugins"
+	err := pluginloader.Load(config)
+
+Go plugins are only supported on Linux, FreeBSD and macOS with cgo enabled.
+A plugin must be built with the same Go toolchain and the same versions of the shared packages, including go-sarah, as the host binary.
+Otherwise, opening the plugin fails.
+*/
+package pluginloader
+
+import (
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"path/filepath"
+	"plugin"
+)
+
+const (
+	// CommandPropsSymbol is the name of the function a plugin exposes to provide CommandProps.
+	// The function must be typed func() []*sarah.CommandProps.
+	CommandPropsSymbol = "Props"
+
+	// ScheduledTaskPropsSymbol is the name of the function a plugin exposes to provide ScheduledTaskProps.
+	// The function must be typed func() []*sarah.ScheduledTaskProps.
+	ScheduledTaskPropsSymbol = "ScheduledTaskProps"
+)
+
+// ErrNoKnownSymbol is returned when a plugin exposes none of CommandPropsSymbol and ScheduledTaskPropsSymbol.
+var ErrNoKnownSymbol = errors.New("none of the known symbols is exposed")
+
+// Config contains some configuration variables for the plugin discovery.
+type Config struct {
+	// Dir is the directory to scan for plugins. Sub-directories are not scanned.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// Pattern is the glob pattern of the plugin file names in Dir.
+	Pattern string `json:"pattern" yaml:"pattern"`
+}
+
+// NewConfig returns a new Config instance with default values.
+// Dir must be set before use.
+func NewConfig() *Config {
+	return &Config{
+		Dir:     "",
+		Pattern: "*.so",
+	}
+}
+
+// Plugin represents a loaded plugin and the props it exposes.
+type Plugin struct {
+	// Path is the path to the shared object.
+	Path string
+
+	// CommandProps is what the function named CommandPropsSymbol returns.
+	CommandProps []*sarah.CommandProps
+
+	// ScheduledTaskProps is what the function named ScheduledTaskPropsSymbol returns.
+	ScheduledTaskProps []*sarah.ScheduledTaskProps
+}
+
+// symbolLookuper is the part of *plugin.Plugin this package depends on.
+type symbolLookuper interface {
+	Lookup(symName string) (plugin.Symbol, error)
+}
+
+// openPlugin opens the shared object at the given path.
+// This is a variable so tests can replace it without building a real plugin.
+var openPlugin = func(path string) (symbolLookuper, error) {
+	return plugin.Open(path)
+}
+
+// Discover opens the plugins in the configured directory in lexical order of their file names and returns them.
+// An error is returned when any of the plugins can not be opened or exposes none of the known symbols.
+//
+// Use Load to also register the props. Use this when the host binary needs to pick the props to register.
+func Discover(config *Config) ([]*Plugin, error) {
+	if config.Dir == "" {
+		return nil, errors.New("plugin directory is not given")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(config.Dir, config.Pattern))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", config.Dir, err)
+	}
+
+	plugins := make([]*Plugin, 0, len(paths))
+	for _, path := range paths {
+		p, err := open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load plugin %s: %w", path, err)
+		}
+		plugins = append(plugins, p)
+	}
+
+	return plugins, nil
+}
+
+// Load discovers the plugins with Discover and registers their CommandProps and ScheduledTaskProps
+// via sarah.RegisterCommandProps and sarah.RegisterScheduledTaskProps.
+// Call this before sarah.Run.
+func Load(config *Config) error {
+	plugins, err := Discover(config)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range plugins {
+		for _, props := range p.CommandProps {
+			sarah.RegisterCommandProps(props)
+		}
+		for _, props := range p.ScheduledTaskProps {
+			sarah.RegisterScheduledTaskProps(props)
+		}
+		logging.GetLogger().Module("pluginloader").Info(
+			"Loaded plugin",
+			logging.F("path", p.Path),
+			logging.F("commands", len(p.CommandProps)),
+			logging.F("scheduled_tasks", len(p.ScheduledTaskProps)),
+		)
+	}
+
+	return nil
+}
+
+func open(path string) (*Plugin, error) {
+	lookuper, err := openPlugin(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plugin{
+		Path: path,
+	}
+	found := false
+
+	if symbol, err := lookuper.Lookup(CommandPropsSymbol); err == nil {
+		fnc, ok := symbol.(func() []*sarah.CommandProps)
+		if !ok {
+			return nil, fmt.Errorf("symbol %s is typed %T instead of func() []*sarah.CommandProps", CommandPropsSymbol, symbol)
+		}
+		p.CommandProps = nonNilCommandProps(fnc())
+		found = true
+	}
+
+	if symbol, err := lookuper.Lookup(ScheduledTaskPropsSymbol); err == nil {
+		fnc, ok := symbol.(func() []*sarah.ScheduledTaskProps)
+		if !ok {
+			return nil, fmt.Errorf("symbol %s is typed %T instead of func() []*sarah.ScheduledTaskProps", ScheduledTaskPropsSymbol, symbol)
+		}
+		p.ScheduledTaskProps = nonNilScheduledTaskProps(fnc())
+		found = true
+	}
+
+	if !found {
+		return nil, ErrNoKnownSymbol
+	}
+
+	return p, nil
+}
+
+func nonNilCommandProps(props []*sarah.CommandProps) []*sarah.CommandProps {
+	filtered := make([]*sarah.CommandProps, 0, len(props))
+	for _, p := range props {
+		if p != nil {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+func nonNilScheduledTaskProps(props []*sarah.ScheduledTaskProps) []*sarah.ScheduledTaskProps {
+	filtered := make([]*sarah.ScheduledTaskProps, 0, len(props))
+	for _, p := range props {
+		if p != nil {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,238 @@
+package pluginloader
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"plugin"
+	"regexp"
+	"testing"
+)
+
+type DummySymbolLookuper struct {
+	LookupFunc func(string) (plugin.Symbol, error)
+}
+
+func (l *DummySymbolLookuper) Lookup(symName string) (plugin.Symbol, error) {
+	return l.LookupFunc(symName)
+}
+
+func symbols(symbols map[string]plugin.Symbol) *DummySymbolLookuper {
+	return &DummySymbolLookuper{
+		LookupFunc: func(symName string) (plugin.Symbol, error) {
+			symbol, ok := symbols[symName]
+			if !ok {
+				return nil, errors.New("symbol not found")
+			}
+			return symbol, nil
+		},
+	}
+}
+
+func replaceOpenPlugin(fnc func(string) (symbolLookuper, error)) func() {
+	original := openPlugin
+	openPlugin = fnc
+	return func() {
+		openPlugin = original
+	}
+}
+
+func pluginDir(t *testing.T, names ...string) string {
+	dir, err := ioutil.TempDir("", "pluginloader")
+	if err != nil {
+		t.Fatalf("Failed to create a directory: %s.", err.Error())
+	}
+	for _, name := range names {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte{}, 0600)
+		if err != nil {
+			t.Fatalf("Failed to create a file: %s.", err.Error())
+		}
+	}
+	return dir
+}
+
+func commandProps(id string) *sarah.CommandProps {
+	return sarah.NewCommandPropsBuilder().
+		BotType("dummy").
+		Identifier(id).
+		MatchPattern(regexp.MustCompile(`^\.` + id)).
+		Instruction("." + id).
+		Func(func(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+			return nil, nil
+		}).
+		MustBuild()
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+
+	if config.Dir != "" {
+		t.Errorf("Unexpected default directory is set: %s.", config.Dir)
+	}
+
+	if config.Pattern != "*.so" {
+		t.Errorf("Unexpected default pattern is set: %s.", config.Pattern)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := pluginDir(t, "b.so", "a.so", "README.md")
+	defer os.RemoveAll(dir)
+
+	ping := commandProps("ping")
+	task := sarah.NewScheduledTaskPropsBuilder().
+		BotType("dummy").
+		Identifier("report").
+		Schedule("@daily").
+		Func(func(_ context.Context) ([]*sarah.ScheduledTaskResult, error) {
+			return nil, nil
+		}).
+		MustBuild()
+	opened := []string{}
+	defer replaceOpenPlugin(func(path string) (symbolLookuper, error) {
+		opened = append(opened, filepath.Base(path))
+		if filepath.Base(path) == "a.so" {
+			return symbols(map[string]plugin.Symbol{
+				CommandPropsSymbol: func() []*sarah.CommandProps {
+					return []*sarah.CommandProps{ping, nil}
+				},
+			}), nil
+		}
+		return symbols(map[string]plugin.Symbol{
+			ScheduledTaskPropsSymbol: func() []*sarah.ScheduledTaskProps {
+				return []*sarah.ScheduledTaskProps{task}
+			},
+		}), nil
+	})()
+
+	config := NewConfig()
+	config.Dir = dir
+	plugins, err := Discover(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(opened) != 2 || opened[0] != "a.so" || opened[1] != "b.so" {
+		t.Errorf("Unexpected files are opened: %#v.", opened)
+	}
+
+	if len(plugins) != 2 {
+		t.Fatalf("Unexpected number of plugins are returned: %d.", len(plugins))
+	}
+
+	if plugins[0].Path != filepath.Join(dir, "a.so") {
+		t.Errorf("Unexpected path is set: %s.", plugins[0].Path)
+	}
+
+	if len(plugins[0].CommandProps) != 1 || plugins[0].CommandProps[0] != ping {
+		t.Errorf("Unexpected CommandProps are returned: %#v.", plugins[0].CommandProps)
+	}
+
+	if len(plugins[1].ScheduledTaskProps) != 1 || plugins[1].ScheduledTaskProps[0] != task {
+		t.Errorf("Unexpected ScheduledTaskProps are returned: %#v.", plugins[1].ScheduledTaskProps)
+	}
+}
+
+func TestDiscover_WithoutDir(t *testing.T) {
+	_, err := Discover(NewConfig())
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestDiscover_WithError(t *testing.T) {
+	openErr := errors.New("plugin was built with a different version of package")
+	tests := []struct {
+		open func(string) (symbolLookuper, error)
+		err  error
+	}{
+		{
+			open: func(_ string) (symbolLookuper, error) {
+				return nil, openErr
+			},
+			err: openErr,
+		},
+		{
+			open: func(_ string) (symbolLookuper, error) {
+				return symbols(map[string]plugin.Symbol{}), nil
+			},
+			err: ErrNoKnownSymbol,
+		},
+		{
+			open: func(_ string) (symbolLookuper, error) {
+				return symbols(map[string]plugin.Symbol{
+					CommandPropsSymbol: func() []*sarah.ScheduledTaskProps { return nil },
+				}), nil
+			},
+			err: nil,
+		},
+		{
+			open: func(_ string) (symbolLookuper, error) {
+				return symbols(map[string]plugin.Symbol{
+					ScheduledTaskPropsSymbol: []*sarah.ScheduledTaskProps{},
+				}), nil
+			},
+			err: nil,
+		},
+	}
+
+	dir := pluginDir(t, "a.so")
+	defer os.RemoveAll(dir)
+	config := NewConfig()
+	config.Dir = dir
+
+	for i, tt := range tests {
+		func() {
+			defer replaceOpenPlugin(tt.open)()
+
+			_, err := Discover(config)
+			if err == nil {
+				t.Fatalf("Expected error is not returned on test #%d.", i)
+			}
+
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			}
+		}()
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := pluginDir(t, "a.so")
+	defer os.RemoveAll(dir)
+	looked := []string{}
+	defer replaceOpenPlugin(func(_ string) (symbolLookuper, error) {
+		return &DummySymbolLookuper{
+			LookupFunc: func(symName string) (plugin.Symbol, error) {
+				looked = append(looked, symName)
+				if symName == CommandPropsSymbol {
+					return func() []*sarah.CommandProps {
+						return []*sarah.CommandProps{commandProps("ping")}
+					}, nil
+				}
+				return nil, errors.New("symbol not found")
+			},
+		}, nil
+	})()
+
+	config := NewConfig()
+	config.Dir = dir
+	err := Load(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(looked) != 2 {
+		t.Errorf("Unexpected symbols are looked up: %#v.", looked)
+	}
+}
+
+func TestLoad_WithError(t *testing.T) {
+	err := Load(NewConfig())
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
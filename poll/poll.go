@@ -0,0 +1,378 @@
+/*
+Package poll provides a sarah.Command that lets users run polls in a chat.
+
+This is also a reference implementation of a plugin that uses interactive components:
+a poll is sent as sarah.RichMessage with one interactive sarah.RichButton for each option,
+and a click is handled by the Command built with sarah.NewCallbackCommand.
+The votes are kept in Store so they survive a restart.
+
+	store, err := poll.NewFileStore("/var/lib/sarah/polls")
+	if err != nil {
+		panic(err)
+	}
+	command := poll.NewCommand(poll.NewConfig(), store)
+	command.Register(sarah.AllBots())
+
+To keep the polls in a shared database such as Redis, wrap a sarah.Store with NewStore.
+
+The command responds to below inputs:
+
+	.poll "Lunch?" Sushi Ramen "Green curry"  creates a poll; quote a question or an option that contains spaces
+	.poll vote <id> <number>                  votes for the option; same as clicking its button
+	.poll show <id>                           the current results
+	.poll close <id>                          closes the poll and announces the results; only the creator can close
+
+A voter has only one vote, so voting again changes the choice.
+For a chat service that does not support interactive components, the options are listed in the text so users can vote with .poll vote.
+*/
+package poll
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// Identifier is the identifier of the Command this package provides.
+	Identifier = "poll"
+
+	// CallbackID is the callback ID of the buttons to vote.
+	CallbackID = "poll_vote"
+)
+
+var (
+	matchPattern = regexp.MustCompile(`^\.poll(\s|$)`)
+	idPattern    = regexp.MustCompile(`^[0-9a-f]{8}$`)
+)
+
+// Config contains some configuration variables for the poll command.
+type Config struct {
+	// MaxOptions is the maximum number of options one poll can have.
+	MaxOptions int `json:"max_options" yaml:"max_options"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		MaxOptions: 10,
+	}
+}
+
+// Command is a sarah.Command that creates, shows, and closes polls.
+// Register the Command returned by CallbackCommand along with this to accept the votes from the buttons. Register does both.
+type Command struct {
+	config *Config
+	store  Store
+
+	// mutex serializes the updates of the polls so concurrent votes are not lost.
+	mutex sync.Mutex
+}
+
+var _ sarah.Command = (*Command)(nil)
+
+// NewCommand creates and returns a new Command that keeps the polls in the given Store.
+func NewCommand(config *Config, store Store) *Command {
+	return &Command{
+		config: config,
+		store:  store,
+	}
+}
+
+// Register registers the Command and its CallbackCommand for the Bots in the given scope.
+// Call this before sarah.Run.
+func (c *Command) Register(scope *sarah.BotScope) {
+	sarah.RegisterScopedCommand(scope, c)
+	sarah.RegisterScopedCommand(scope, c.CallbackCommand())
+}
+
+// CallbackCommand returns a sarah.Command that counts a vote when a user clicks a button of a poll.
+func (c *Command) CallbackCommand() sarah.Command {
+	return sarah.NewCallbackCommand(CallbackID, func(_ context.Context, input *sarah.CallbackInput) (*sarah.CommandResponse, error) {
+		parts := strings.SplitN(input.Value, ":", 2)
+		if len(parts) != 2 {
+			return respond(fmt.Sprintf("Invalid vote: %s", input.Value)), nil
+		}
+		return respond(c.vote(input, parts[0], parts[1])), nil
+	})
+}
+
+// Identifier returns the command ID.
+func (c *Command) Identifier() string {
+	return Identifier
+}
+
+// Instruction provides the input instruction.
+func (c *Command) Instruction(_ *sarah.HelpInput) string {
+	return `.poll "<question>" <option> <option>..., .poll vote <id> <number>, .poll show <id>, .poll close <id>`
+}
+
+// Match checks if the input is a poll command.
+func (c *Command) Match(input sarah.Input) bool {
+	return matchPattern.Copy().MatchString(input.Message())
+}
+
+// Execute runs the given sub-command and returns its result.
+func (c *Command) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	text := sarah.StripMessage(matchPattern, input.Message())
+	args, err := splitArgs(text)
+	if err != nil {
+		return respond(err.Error()), nil
+	}
+	if len(args) == 0 {
+		return respond("Usage: " + c.Instruction(&sarah.HelpInput{OriginalInput: input})), nil
+	}
+
+	switch args[0] {
+	case "vote":
+		if len(args) != 3 {
+			return respond("Usage: .poll vote <id> <number>"), nil
+		}
+		return respond(c.vote(input, args[1], args[2])), nil
+
+	case "show":
+		if len(args) != 2 {
+			return respond("Usage: .poll show <id>"), nil
+		}
+		return respond(c.show(args[1])), nil
+
+	case "close":
+		if len(args) != 2 {
+			return respond("Usage: .poll close <id>"), nil
+		}
+		return respond(c.close(input, args[1])), nil
+
+	default:
+		return c.create(ctx, input, args[0], args[1:]), nil
+
+	}
+}
+
+func respond(text string) *sarah.CommandResponse {
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}
+}
+
+func (c *Command) create(ctx context.Context, input sarah.Input, question string, options []string) *sarah.CommandResponse {
+	if len(options) < 2 {
+		return respond("Give a question and at least two options.")
+	}
+	if len(options) > c.config.MaxOptions {
+		return respond(fmt.Sprintf("A poll can have up to %d options.", c.config.MaxOptions))
+	}
+
+	id, err := newID()
+	if err != nil {
+		return respond(fmt.Sprintf("Failed to create poll: %s", err.Error()))
+	}
+
+	p := &Poll{
+		ID:         id,
+		Question:   question,
+		Options:    options,
+		Votes:      map[string]int{},
+		CreatorKey: input.SenderKey(),
+		CreatedAt:  clock.FromContext(ctx).Now(),
+	}
+	err = c.store.Save(p)
+	if err != nil {
+		return respond(fmt.Sprintf("Failed to create poll: %s", err.Error()))
+	}
+
+	lines := []string{fmt.Sprintf("Poll %s. Click a button or input .poll vote %s <number> to vote.", id, id)}
+	var buttons []*sarah.RichButton
+	for i, option := range options {
+		label := fmt.Sprintf("%d. %s", i+1, option)
+		lines = append(lines, label)
+		buttons = append(buttons, &sarah.RichButton{
+			Label:      label,
+			CallbackID: CallbackID,
+			Value:      fmt.Sprintf("%s:%d", id, i+1),
+		})
+	}
+
+	return &sarah.CommandResponse{
+		Content: &sarah.RichMessage{
+			Title:   question,
+			Text:    strings.Join(lines, "\n"),
+			Buttons: buttons,
+		},
+		UserContext: nil,
+	}
+}
+
+// get returns the poll with the given ID or a message that tells why the poll is not available.
+func (c *Command) get(id string) (*Poll, string) {
+	// The ID is used as a part of a file name by the file-based Store, so only the generated form is accepted.
+	if !idPattern.MatchString(id) {
+		return nil, fmt.Sprintf("Poll %s is not found.", id)
+	}
+
+	p, err := c.store.Get(id)
+	if errors.Is(err, ErrPollNotFound) {
+		return nil, fmt.Sprintf("Poll %s is not found.", id)
+	}
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to get poll %s: %s", id, err.Error())
+	}
+	return p, ""
+}
+
+func (c *Command) vote(input sarah.Input, id string, number string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	p, msg := c.get(id)
+	if p == nil {
+		return msg
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(p.Options) {
+		return fmt.Sprintf("Choose a number from 1 to %d.", len(p.Options))
+	}
+
+	p.Votes[input.SenderKey()] = n - 1
+	err = c.store.Save(p)
+	if err != nil {
+		return fmt.Sprintf("Failed to vote: %s", err.Error())
+	}
+
+	return fmt.Sprintf("Your vote for %s is counted.", p.Options[n-1])
+}
+
+func (c *Command) show(id string) string {
+	p, msg := c.get(id)
+	if p == nil {
+		return msg
+	}
+
+	return results(p)
+}
+
+func (c *Command) close(input sarah.Input, id string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	p, msg := c.get(id)
+	if p == nil {
+		return msg
+	}
+
+	if p.CreatorKey != input.SenderKey() {
+		return "Only the creator can close the poll."
+	}
+
+	err := c.store.Delete(id)
+	if err != nil {
+		return fmt.Sprintf("Failed to close poll %s: %s", id, err.Error())
+	}
+
+	lines := []string{fmt.Sprintf("Poll %s is closed.", id), results(p)}
+	if winners := winners(p); len(winners) == 1 {
+		lines = append(lines, fmt.Sprintf("Winner: %s", winners[0]))
+	} else if len(winners) > 1 {
+		lines = append(lines, fmt.Sprintf("Tie: %s", strings.Join(winners, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tally returns the number of votes for each option.
+func tally(p *Poll) []int {
+	counts := make([]int, len(p.Options))
+	for _, i := range p.Votes {
+		if i >= 0 && i < len(counts) {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// results returns the vote counts of each option in a human-readable form.
+func results(p *Poll) string {
+	counts := tally(p)
+	lines := []string{fmt.Sprintf("%s (%d votes)", p.Question, len(p.Votes))}
+	for i, option := range p.Options {
+		percentage := 0
+		if len(p.Votes) > 0 {
+			percentage = counts[i] * 100 / len(p.Votes)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s: %d (%d%%)", i+1, option, counts[i], percentage))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// winners returns the options with the most votes, or nil when no one voted.
+func winners(p *Poll) []string {
+	counts := tally(p)
+	most := 0
+	var winners []string
+	for i, count := range counts {
+		switch {
+		case count == 0 || count < most:
+			continue
+
+		case count > most:
+			most = count
+			winners = []string{p.Options[i]}
+
+		default:
+			winners = append(winners, p.Options[i])
+
+		}
+	}
+	return winners
+}
+
+// splitArgs splits the given text by white spaces. A part enclosed in double quotes is kept as one argument.
+func splitArgs(text string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	quoted := false
+	inArg := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inArg = true
+
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		default:
+			current.WriteRune(r)
+			inArg = true
+
+		}
+	}
+	if quoted {
+		return nil, errors.New("a double quote is not closed")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 4)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
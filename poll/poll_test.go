@@ -0,0 +1,229 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+}
+
+var _ sarah.Input = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "dummy"
+}
+
+type DummyStore struct {
+	SaveFunc   func(*Poll) error
+	GetFunc    func(string) (*Poll, error)
+	DeleteFunc func(string) error
+}
+
+var _ Store = (*DummyStore)(nil)
+
+func (s *DummyStore) Save(poll *Poll) error {
+	return s.SaveFunc(poll)
+}
+
+func (s *DummyStore) Get(id string) (*Poll, error) {
+	return s.GetFunc(id)
+}
+
+func (s *DummyStore) Delete(id string) error {
+	return s.DeleteFunc(id)
+}
+
+func execute(t *testing.T, c *Command, senderKey string, message string) *sarah.CommandResponse {
+	res, err := c.Execute(context.TODO(), &DummyInput{SenderKeyValue: senderKey, MessageValue: message})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return res
+}
+
+func text(t *testing.T, c *Command, senderKey string, message string) string {
+	content := execute(t, c, senderKey, message).Content
+	str, ok := content.(string)
+	if !ok {
+		t.Fatalf("Unexpected content is returned: %#v.", content)
+	}
+	return str
+}
+
+func click(t *testing.T, c *Command, senderKey string, value string) string {
+	callback := c.CallbackCommand()
+	input := sarah.NewCallbackInput(CallbackID, value, senderKey, time.Now(), "dummy")
+	if !callback.Match(input) {
+		t.Fatal("Callback command must match the vote.")
+	}
+
+	res, err := callback.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return res.Content.(string)
+}
+
+func TestNewCommand(t *testing.T) {
+	c := NewCommand(NewConfig(), NewMemoryStore())
+
+	if c.Identifier() != Identifier {
+		t.Errorf("Unexpected identifier is returned: %s.", c.Identifier())
+	}
+
+	if !c.Match(&DummyInput{MessageValue: ".poll show 0123abcd"}) {
+		t.Error("Poll command must match.")
+	}
+
+	if c.Match(&DummyInput{MessageValue: ".polls"}) {
+		t.Error("Other command must not match.")
+	}
+}
+
+func TestCommand_Execute(t *testing.T) {
+	store := NewMemoryStore()
+	c := NewCommand(NewConfig(), store)
+
+	res := execute(t, c, "creator", `.poll "What for lunch?" Sushi Ramen "Green curry"`)
+	message, ok := res.Content.(*sarah.RichMessage)
+	if !ok {
+		t.Fatalf("Unexpected content is returned: %#v.", res.Content)
+	}
+	if message.Title != "What for lunch?" || len(message.Buttons) != 3 || !message.Interactive() {
+		t.Fatalf("Unexpected message is returned: %#v.", message)
+	}
+	if message.Buttons[2].Label != "3. Green curry" || message.Buttons[2].CallbackID != CallbackID {
+		t.Errorf("Unexpected button is returned: %#v.", message.Buttons[2])
+	}
+	if !strings.Contains(message.PlainText(), "3. Green curry") {
+		t.Errorf("Options must be listed in plain text: %s.", message.PlainText())
+	}
+
+	id := strings.SplitN(message.Buttons[0].Value, ":", 2)[0]
+
+	if str := click(t, c, "alice", message.Buttons[0].Value); str != "Your vote for Sushi is counted." {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+	click(t, c, "bob", message.Buttons[1].Value)
+	click(t, c, "carol", message.Buttons[1].Value)
+
+	// Voting again changes the choice.
+	if str := text(t, c, "carol", ".poll vote "+id+" 1"); str != "Your vote for Sushi is counted." {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+
+	if str := text(t, c, "carol", ".poll vote "+id+" 4"); str != "Choose a number from 1 to 3." {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+
+	expected := "What for lunch? (3 votes)\n1. Sushi: 2 (66%)\n2. Ramen: 1 (33%)\n3. Green curry: 0 (0%)"
+	if str := text(t, c, "alice", ".poll show "+id); str != expected {
+		t.Errorf("Unexpected results are returned: %s.", str)
+	}
+
+	if str := text(t, c, "alice", ".poll close "+id); str != "Only the creator can close the poll." {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+
+	if str := text(t, c, "creator", ".poll close "+id); str != "Poll "+id+" is closed.\n"+expected+"\nWinner: Sushi" {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+
+	if str := click(t, c, "alice", message.Buttons[0].Value); str != "Poll "+id+" is not found." {
+		t.Errorf("Closed poll must not accept votes: %s.", str)
+	}
+}
+
+func TestCommand_Execute_Invalid(t *testing.T) {
+	config := NewConfig()
+	config.MaxOptions = 2
+	c := NewCommand(config, NewMemoryStore())
+
+	tests := []struct {
+		message  string
+		response string
+	}{
+		{message: ".poll", response: "Usage: "},
+		{message: `.poll "Lunch? Sushi Ramen`, response: "a double quote is not closed"},
+		{message: ".poll Lunch? Sushi", response: "Give a question and at least two options."},
+		{message: ".poll Lunch? Sushi Ramen Curry", response: "A poll can have up to 2 options."},
+		{message: ".poll vote 0123abcd", response: "Usage: .poll vote <id> <number>"},
+		{message: ".poll show ../../etc/passwd", response: "Poll ../../etc/passwd is not found."},
+		{message: ".poll close 0123abcd", response: "Poll 0123abcd is not found."},
+	}
+
+	for _, tt := range tests {
+		if str := text(t, c, "sender", tt.message); !strings.HasPrefix(str, tt.response) {
+			t.Errorf("Unexpected response is returned for %s: %s.", tt.message, str)
+		}
+	}
+
+	if str := click(t, c, "sender", "broken"); str != "Invalid vote: broken" {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+}
+
+func TestCommand_Execute_StoreError(t *testing.T) {
+	c := NewCommand(NewConfig(), &DummyStore{
+		SaveFunc: func(_ *Poll) error {
+			return errors.New("unavailable")
+		},
+		GetFunc: func(_ string) (*Poll, error) {
+			return nil, errors.New("unavailable")
+		},
+	})
+
+	if str := text(t, c, "sender", ".poll Lunch? Sushi Ramen"); str != "Failed to create poll: unavailable" {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+
+	if str := text(t, c, "sender", ".poll show 0123abcd"); str != "Failed to get poll 0123abcd: unavailable" {
+		t.Errorf("Unexpected response is returned: %s.", str)
+	}
+}
+
+func TestWinners(t *testing.T) {
+	p := &Poll{
+		Options: []string{"Sushi", "Ramen", "Curry"},
+		Votes:   map[string]int{},
+	}
+	if w := winners(p); len(w) != 0 {
+		t.Errorf("No winner must be returned without votes: %#v.", w)
+	}
+
+	p.Votes = map[string]int{"a": 0, "b": 2}
+	if w := winners(p); len(w) != 2 || w[0] != "Sushi" || w[1] != "Curry" {
+		t.Errorf("Unexpected winners are returned: %#v.", w)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := splitArgs(`"What for lunch?"  Sushi "" "Green curry"`)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := []string{"What for lunch?", "Sushi", "", "Green curry"}
+	if strings.Join(args, "|") != strings.Join(expected, "|") {
+		t.Errorf("Unexpected args are returned: %#v.", args)
+	}
+}
@@ -0,0 +1,163 @@
+package poll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"sync"
+	"time"
+)
+
+// ErrPollNotFound is returned by Store when the poll with the given ID does not exist.
+var ErrPollNotFound = errors.New("poll is not found")
+
+// Poll represents an open poll.
+type Poll struct {
+	// ID is the unique identifier of the poll.
+	ID string `json:"id"`
+
+	// Question is what the poll asks.
+	Question string `json:"question"`
+
+	// Options are the choices of the poll.
+	Options []string `json:"options"`
+
+	// Votes maps each voter's Input.SenderKey() to the index of the chosen option.
+	// A voter has only one vote, so voting again changes the choice.
+	Votes map[string]int `json:"votes"`
+
+	// CreatorKey is the Input.SenderKey() of the user who created the poll. Only this user can close the poll.
+	CreatorKey string `json:"creator_key"`
+
+	// CreatedAt is the time when the poll was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store defines an interface that persists the open polls so the votes survive a restart.
+// A poll is saved on its creation and on every vote, and is deleted when the poll is closed.
+type Store interface {
+	// Save persists the given poll.
+	Save(*Poll) error
+
+	// Get returns the poll with the given ID. ErrPollNotFound is returned when the poll does not exist.
+	Get(id string) (*Poll, error)
+
+	// Delete removes the poll with the given ID.
+	Delete(id string) error
+}
+
+// memoryStore is a Store that keeps the polls in memory.
+type memoryStore struct {
+	polls map[string][]byte
+	mutex sync.Mutex
+}
+
+var _ Store = (*memoryStore)(nil)
+
+// NewMemoryStore creates and returns a Store that keeps the polls in memory.
+// The polls are lost when the process stops.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		polls: map[string][]byte{},
+	}
+}
+
+func (s *memoryStore) Save(poll *Poll) error {
+	// Keep the encoded form so a caller's later modification does not change the stored poll.
+	b, err := json.Marshal(poll)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.polls[poll.ID] = b
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (*Poll, error) {
+	s.mutex.Lock()
+	b, ok := s.polls[id]
+	s.mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPollNotFound, id)
+	}
+
+	poll := &Poll{}
+	err := json.Unmarshal(b, poll)
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.polls, id)
+	return nil
+}
+
+// kvStore is a Store that stores each poll as a JSON value in sarah.Store.
+type kvStore struct {
+	store sarah.Store
+}
+
+var _ Store = (*kvStore)(nil)
+
+// NewStore creates and returns a Store that stores each poll as a JSON value in the given sarah.Store with the poll's ID as the key.
+// Give the sarah.Store namespaced with sarah.NewNamespacedStore so the keys do not conflict with other plugins':
+//
+//  store := poll.NewStore(sarah.NewNamespacedStore(redisStore, "poll"))
+func NewStore(store sarah.Store) Store {
+	return &kvStore{
+		store: store,
+	}
+}
+
+func (s *kvStore) Save(poll *Poll) error {
+	b, err := json.Marshal(poll)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Set(context.Background(), poll.ID, b)
+}
+
+func (s *kvStore) Get(id string) (*Poll, error) {
+	b, err := s.store.Get(context.Background(), id)
+	if errors.Is(err, sarah.ErrStoreKeyNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrPollNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	poll := &Poll{}
+	err = json.Unmarshal(b, poll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse poll %s: %w", id, err)
+	}
+	return poll, nil
+}
+
+func (s *kvStore) Delete(id string) error {
+	return s.store.Delete(context.Background(), id)
+}
+
+// NewFileStore creates and returns a Store that stores each poll as a file in the given directory.
+// The directory is created when it does not exist.
+// This is a shorthand for NewStore with the sarah.Store sarah.NewFileStore returns.
+func NewFileStore(dir string) (Store, error) {
+	store, err := sarah.NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(store), nil
+}
@@ -0,0 +1,78 @@
+package poll
+
+import (
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, store Store) {
+	p := &Poll{
+		ID:         "0123abcd",
+		Question:   "Lunch?",
+		Options:    []string{"Sushi", "Ramen"},
+		Votes:      map[string]int{"voter": 1},
+		CreatorKey: "creator",
+		CreatedAt:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	err := store.Save(p)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The stored poll must not be affected by a later modification.
+	p.Votes["other"] = 0
+
+	stored, err := store.Get(p.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if stored.Question != "Lunch?" || len(stored.Options) != 2 || stored.CreatorKey != "creator" || !stored.CreatedAt.Equal(p.CreatedAt) {
+		t.Errorf("Unexpected poll is returned: %#v.", stored)
+	}
+	if len(stored.Votes) != 1 || stored.Votes["voter"] != 1 {
+		t.Errorf("Unexpected votes are returned: %#v.", stored.Votes)
+	}
+
+	err = store.Delete(p.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = store.Delete(p.ID)
+	if err != nil {
+		t.Errorf("Deleting unknown poll must not fail: %s.", err.Error())
+	}
+
+	_, err = store.Get(p.ID)
+	if !errors.Is(err, ErrPollNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestStore(t *testing.T) {
+	testStore(t, NewStore(sarah.NewMemoryStore()))
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poll")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(filepath.Join(dir, "nested"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	testStore(t, store)
+}
@@ -0,0 +1,161 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"time"
+)
+
+// profileNamespace is the namespace of the Store that UserProfiles are stored in. See StoreFromContext.
+const profileNamespace = "profile"
+
+// UserProfile represents the preferences of a user.
+// The profiles are stored in the Store registered with RegisterStore, keyed by the BotType and the user's identifier,
+// so the same person on different chat services has separate profiles.
+// When an IdentityResolver is registered with RegisterIdentityResolver and the user is linked to a person,
+// the profile is keyed by the person instead, so the preferences follow the person across the chat services.
+type UserProfile struct {
+	// Locale is the user's preferred language tag such as "en-US" or "ja-JP".
+	Locale string `json:"locale,omitempty"`
+
+	// TimeZone is the name of the user's time zone such as "Asia/Tokyo".
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// OptOuts are the kinds of notifications the user does not want to receive. The kinds are defined by each plugin.
+	OptOuts []string `json:"opt_outs,omitempty"`
+
+	// Attributes are any other preferences defined by plugins.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Location returns the time.Location of TimeZone.
+// nil is returned when TimeZone is empty or is not a valid time zone name.
+func (p *UserProfile) Location() *time.Location {
+	if p.TimeZone == "" {
+		return nil
+	}
+
+	location, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return nil
+	}
+	return location
+}
+
+// OptedOut tells if the user opted out of the given kind of notifications.
+func (p *UserProfile) OptedOut(kind string) bool {
+	for _, optOut := range p.OptOuts {
+		if optOut == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// UserID returns the identifier of the user that sent the given Input to look up the UserProfile.
+// This is SenderIDInput.SenderID when the Input satisfies the interface, or Input.SenderKey otherwise.
+func UserID(input Input) string {
+	if i, ok := input.(SenderIDInput); ok {
+		if id := i.SenderID(); id != "" {
+			return id
+		}
+	}
+	return input.SenderKey()
+}
+
+// GetUserProfile returns the UserProfile of the user with the given identifier.
+// Pass the context given to Command.Execute, ContextualFunc or ScheduledTask's function; see StoreFromContext.
+// An empty UserProfile is returned when the user has not saved any preference.
+// ErrStoreNotAvailable is returned when no Store is registered with RegisterStore.
+func GetUserProfile(ctx context.Context, userID string) (*UserProfile, error) {
+	store, key, err := profileStore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := store.Get(ctx, key)
+	if errors.Is(err, ErrStoreKeyNotFound) {
+		return &UserProfile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &UserProfile{}
+	err = json.Unmarshal(value, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode profile of %s: %w", userID, err)
+	}
+	return profile, nil
+}
+
+// SaveUserProfile stores the given UserProfile of the user with the given identifier.
+// An invalid TimeZone is refused so other plugins can rely on the stored value:
+//
+//  func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+//  	userID := sarah.UserID(input)
+//  	profile, err := sarah.GetUserProfile(ctx, userID)
+//  	if err != nil {
+//  		return nil, err
+//  	}
+//  	profile.TimeZone = "Asia/Tokyo"
+//  	err = sarah.SaveUserProfile(ctx, userID, profile)
+//  	...
+//  }
+func SaveUserProfile(ctx context.Context, userID string, profile *UserProfile) error {
+	if profile.TimeZone != "" {
+		_, err := time.LoadLocation(profile.TimeZone)
+		if err != nil {
+			return fmt.Errorf("invalid time zone %s: %w", profile.TimeZone, err)
+		}
+	}
+
+	store, key, err := profileStore(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to encode profile of %s: %w", userID, err)
+	}
+	return store.Set(ctx, key, value)
+}
+
+// profileStore returns the Store and the key of the UserProfile of the user with the given identifier.
+func profileStore(ctx context.Context, userID string) (Store, string, error) {
+	store, person, ok, err := personStore(ctx, profileNamespace, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve identity of %s: %w", userID, err)
+	}
+	if ok {
+		return store, person, nil
+	}
+
+	store, err = StoreFromContext(ctx, profileNamespace)
+	if err != nil {
+		return nil, "", err
+	}
+	return store, userID, nil
+}
+
+// UserLocation returns the time.Location of the user with the given identifier.
+// The given location is returned when the user has not set the time zone or the UserProfile can not be read,
+// so a plugin that interprets the time given by a user can simply call this to respect the user's preference.
+func UserLocation(ctx context.Context, userID string, fallback *time.Location) *time.Location {
+	profile, err := GetUserProfile(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, ErrStoreNotAvailable) {
+			contextLogger(ctx).Warn("Failed to get user profile", logging.F("user_id", userID), logging.Err(err))
+		}
+		return fallback
+	}
+
+	if location := profile.Location(); location != nil {
+		return location
+	}
+	return fallback
+}
@@ -0,0 +1,109 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUserProfile_Location(t *testing.T) {
+	if (&UserProfile{}).Location() != nil {
+		t.Error("Location must be nil without time zone.")
+	}
+
+	if (&UserProfile{TimeZone: "Invalid/Zone"}).Location() != nil {
+		t.Error("Location must be nil for invalid time zone.")
+	}
+
+	location := (&UserProfile{TimeZone: "Asia/Tokyo"}).Location()
+	if location == nil || location.String() != "Asia/Tokyo" {
+		t.Errorf("Unexpected location is returned: %s.", location)
+	}
+}
+
+func TestUserProfile_OptedOut(t *testing.T) {
+	profile := &UserProfile{OptOuts: []string{"standup"}}
+
+	if !profile.OptedOut("standup") {
+		t.Error("Opted out kind is not reported.")
+	}
+
+	if profile.OptedOut("poll") {
+		t.Error("Kind not opted out is reported.")
+	}
+}
+
+func TestUserID(t *testing.T) {
+	if id := UserID(&DummyInput{SenderKeyValue: "channel|user"}); id != "channel|user" {
+		t.Errorf("SenderKey must be used without SenderID: %s.", id)
+	}
+}
+
+func TestSaveUserProfile(t *testing.T) {
+	err := SaveUserProfile(context.TODO(), "user", &UserProfile{})
+	if !errors.Is(err, ErrStoreNotAvailable) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	store := NewMemoryStore()
+	ctx := WithStore(context.TODO(), store)
+
+	err = SaveUserProfile(ctx, "user", &UserProfile{TimeZone: "Invalid/Zone"})
+	if err == nil {
+		t.Error("Expected error is not returned for invalid time zone.")
+	}
+
+	profile, err := GetUserProfile(ctx, "user")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if profile.Locale != "" || profile.TimeZone != "" {
+		t.Errorf("Empty profile must be returned for unknown user: %#v.", profile)
+	}
+
+	profile.Locale = "ja-JP"
+	profile.TimeZone = "Asia/Tokyo"
+	profile.Attributes = map[string]string{"nickname": "Sarah"}
+	err = SaveUserProfile(ctx, "user", profile)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_, err = store.Get(ctx, "profile/user")
+	if err != nil {
+		t.Errorf("Profile is not stored in profile namespace: %s.", err.Error())
+	}
+
+	saved, err := GetUserProfile(ctx, "user")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if saved.Locale != "ja-JP" || saved.TimeZone != "Asia/Tokyo" || saved.Attributes["nickname"] != "Sarah" {
+		t.Errorf("Unexpected profile is returned: %#v.", saved)
+	}
+}
+
+func TestUserLocation(t *testing.T) {
+	fallback := time.UTC
+
+	if UserLocation(context.TODO(), "user", fallback) != fallback {
+		t.Error("Fallback must be returned without Store.")
+	}
+
+	store := NewMemoryStore()
+	ctx := WithStore(context.TODO(), store)
+	if UserLocation(ctx, "user", fallback) != fallback {
+		t.Error("Fallback must be returned without time zone.")
+	}
+
+	_ = store.Set(ctx, "profile/broken", []byte("{"))
+	if UserLocation(ctx, "broken", fallback) != fallback {
+		t.Error("Fallback must be returned for broken profile.")
+	}
+
+	_ = SaveUserProfile(ctx, "user", &UserProfile{TimeZone: "Asia/Tokyo"})
+	if location := UserLocation(ctx, "user", fallback); location.String() != "Asia/Tokyo" {
+		t.Errorf("Unexpected location is returned: %s.", location)
+	}
+}
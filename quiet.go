@@ -0,0 +1,180 @@
+package sarah
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// quietHoursLayout is the layout of QuietHours.Start and QuietHours.End.
+const quietHoursLayout = "15:04"
+
+// QuietHours represents a daily period during which the non-urgent results of ScheduledTasks are held.
+// The held results are sent when the period ends. See ScheduledTaskResult.Urgent to bypass.
+//
+// The period may go over midnight. e.g. Start "22:00" and End "08:00" hold the results from 22:00 to 08:00 of the next day.
+// When Start and End are the same, no result is held.
+type QuietHours struct {
+	// Start is the beginning of the period in "15:04" format.
+	Start string `json:"start" yaml:"start"`
+
+	// End is the end of the period in "15:04" format.
+	End string `json:"end" yaml:"end"`
+
+	// TimeZone is the name of the time zone that Start and End are in such as "Asia/Tokyo".
+	// The local time zone is used when this is empty.
+	TimeZone string `json:"timezone" yaml:"timezone"`
+}
+
+// Validate checks that Start and End are in "15:04" format and TimeZone is a valid time zone name.
+func (q *QuietHours) Validate() error {
+	var errs ConfigKeyErrors
+	if _, err := time.Parse(quietHoursLayout, q.Start); err != nil {
+		errs = append(errs, &ConfigKeyError{Key: "start", Value: q.Start, Err: err})
+	}
+	if _, err := time.Parse(quietHoursLayout, q.End); err != nil {
+		errs = append(errs, &ConfigKeyError{Key: "end", Value: q.End, Err: err})
+	}
+	if _, err := q.location(); err != nil {
+		errs = append(errs, &ConfigKeyError{Key: "timezone", Value: q.TimeZone, Err: err})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Until returns the time when the period ends if the given time is in the period.
+// false is returned when the given time is out of the period or QuietHours is invalid.
+func (q *QuietHours) Until(t time.Time) (time.Time, bool) {
+	start, err := time.Parse(quietHoursLayout, q.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse(quietHoursLayout, q.End)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc, err := q.location()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	t = t.In(loc)
+	at := func(clock time.Time, days int) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+days, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+	startAt := at(start, 0)
+	endAt := at(end, 0)
+
+	switch {
+	case startAt.Equal(endAt):
+		return time.Time{}, false
+
+	case startAt.Before(endAt):
+		// e.g. 12:00-13:00
+		if !t.Before(startAt) && t.Before(endAt) {
+			return endAt, true
+		}
+		return time.Time{}, false
+
+	default:
+		// e.g. 22:00-08:00, which goes over midnight.
+		if t.Before(endAt) {
+			return endAt, true
+		}
+		if !t.Before(startAt) {
+			return at(end, 1), true
+		}
+		return time.Time{}, false
+
+	}
+}
+
+func (q *QuietHours) location() (*time.Location, error) {
+	if q.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(q.TimeZone)
+}
+
+// QuietHoursConfig contains the QuietHours of a Bot and its destinations.
+type QuietHoursConfig struct {
+	// Default is the QuietHours of all destinations of the Bot. No result is held for a destination without QuietHours when this is nil.
+	Default *QuietHours `json:"default" yaml:"default"`
+
+	// Destinations are the QuietHours of the specific destinations, keyed by the string representation of the OutputDestination such as "C12345678".
+	// These take precedence over Default.
+	Destinations map[string]*QuietHours `json:"destinations" yaml:"destinations"`
+}
+
+// NewQuietHoursConfig returns a pointer to QuietHoursConfig with default setting, which holds no result.
+func NewQuietHoursConfig() *QuietHoursConfig {
+	return &QuietHoursConfig{
+		Default:      nil,
+		Destinations: map[string]*QuietHours{},
+	}
+}
+
+// Validate checks that all QuietHours are valid.
+func (c *QuietHoursConfig) Validate() error {
+	var errs ConfigKeyErrors
+	validate := func(prefix string, q *QuietHours) {
+		if q == nil {
+			return
+		}
+		err := q.Validate()
+		if keyErrs, ok := err.(ConfigKeyErrors); ok {
+			for _, e := range keyErrs {
+				errs = append(errs, &ConfigKeyError{Key: prefix + "." + e.Key, Value: e.Value, Err: e.Err})
+			}
+		}
+	}
+
+	validate("default", c.Default)
+	dests := make([]string, 0, len(c.Destinations))
+	for dest := range c.Destinations {
+		dests = append(dests, dest)
+	}
+	sort.Strings(dests)
+	for _, dest := range dests {
+		validate(fmt.Sprintf("destinations.%s", dest), c.Destinations[dest])
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// quietHours returns the QuietHours of the given destination. nil is returned when the destination has no QuietHours.
+// A thread or a private message shares the QuietHours of the destination it belongs to.
+func (c *QuietHoursConfig) quietHours(destination OutputDestination) *QuietHours {
+	if q, ok := c.Destinations[fmt.Sprint(BaseDestination(destination))]; ok {
+		return q
+	}
+	return c.Default
+}
+
+type quietHoursKey struct{}
+
+// withQuietHours returns a copy of the given context that carries the given QuietHoursConfig.
+func withQuietHours(ctx context.Context, config *QuietHoursConfig) context.Context {
+	return context.WithValue(ctx, quietHoursKey{}, config)
+}
+
+// heldUntil returns the time when the result for the given destination can be sent if the destination is in its QuietHours.
+func heldUntil(ctx context.Context, destination OutputDestination, now time.Time) (time.Time, bool) {
+	config, ok := ctx.Value(quietHoursKey{}).(*QuietHoursConfig)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	q := config.quietHours(destination)
+	if q == nil {
+		return time.Time{}, false
+	}
+	return q.Until(now)
+}
@@ -0,0 +1,220 @@
+package sarah
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQuietHours_Validate(t *testing.T) {
+	q := &QuietHours{Start: "22:00", End: "08:00", TimeZone: "Asia/Tokyo"}
+	err := q.Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	q = &QuietHours{Start: "25:00", End: "8am", TimeZone: "Invalid/Zone"}
+	err = q.Validate()
+	errs, ok := err.(ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Unexpected error is returned: %#v.", err)
+	}
+	if len(errs) != 3 || errs[0].Key != "start" || errs[1].Key != "end" || errs[2].Key != "timezone" {
+		t.Errorf("Unexpected errors are returned: %s.", errs.Error())
+	}
+}
+
+func TestQuietHours_Until(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	tests := []struct {
+		start string
+		end   string
+		now   time.Time
+		until time.Time
+		held  bool
+	}{
+		{
+			start: "22:00",
+			end:   "08:00",
+			now:   time.Date(2020, 1, 1, 23, 0, 0, 0, loc),
+			until: time.Date(2020, 1, 2, 8, 0, 0, 0, loc),
+			held:  true,
+		},
+		{
+			start: "22:00",
+			end:   "08:00",
+			now:   time.Date(2020, 1, 2, 7, 59, 0, 0, loc),
+			until: time.Date(2020, 1, 2, 8, 0, 0, 0, loc),
+			held:  true,
+		},
+		{
+			start: "22:00",
+			end:   "08:00",
+			now:   time.Date(2020, 1, 2, 8, 0, 0, 0, loc),
+			held:  false,
+		},
+		{
+			start: "22:00",
+			end:   "08:00",
+			// 22:30 in Tokyo given in UTC
+			now:   time.Date(2020, 1, 1, 13, 30, 0, 0, time.UTC),
+			until: time.Date(2020, 1, 2, 8, 0, 0, 0, loc),
+			held:  true,
+		},
+		{
+			start: "12:00",
+			end:   "13:00",
+			now:   time.Date(2020, 1, 1, 12, 0, 0, 0, loc),
+			until: time.Date(2020, 1, 1, 13, 0, 0, 0, loc),
+			held:  true,
+		},
+		{
+			start: "12:00",
+			end:   "13:00",
+			now:   time.Date(2020, 1, 1, 11, 0, 0, 0, loc),
+			held:  false,
+		},
+		{
+			start: "12:00",
+			end:   "12:00",
+			now:   time.Date(2020, 1, 1, 12, 0, 0, 0, loc),
+			held:  false,
+		},
+		{
+			start: "invalid",
+			end:   "12:00",
+			now:   time.Date(2020, 1, 1, 12, 0, 0, 0, loc),
+			held:  false,
+		},
+	}
+
+	for i, tt := range tests {
+		q := &QuietHours{Start: tt.start, End: tt.end, TimeZone: "Asia/Tokyo"}
+		until, held := q.Until(tt.now)
+		if held != tt.held {
+			t.Errorf("Unexpected result on test #%d: %t.", i, held)
+			continue
+		}
+		if held && !until.Equal(tt.until) {
+			t.Errorf("Unexpected time is returned on test #%d: %s.", i, until)
+		}
+	}
+}
+
+func TestQuietHoursConfig_Validate(t *testing.T) {
+	config := NewQuietHoursConfig()
+	err := config.Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	config.Default = &QuietHours{Start: "22:00", End: "08:00"}
+	config.Destinations["#b"] = &QuietHours{Start: "invalid", End: "08:00"}
+	config.Destinations["#a"] = &QuietHours{Start: "22:00", End: "invalid"}
+	err = config.Validate()
+	errs, ok := err.(ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Unexpected error is returned: %#v.", err)
+	}
+	if len(errs) != 2 || errs[0].Key != "destinations.#a.end" || errs[1].Key != "destinations.#b.start" {
+		t.Errorf("Unexpected errors are returned: %s.", errs.Error())
+	}
+}
+
+func Test_heldUntil(t *testing.T) {
+	now := time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC)
+	_, held := heldUntil(context.TODO(), "#general", now)
+	if held {
+		t.Error("Nothing must be held without QuietHoursConfig.")
+	}
+
+	config := NewQuietHoursConfig()
+	config.Default = &QuietHours{Start: "22:00", End: "08:00", TimeZone: "UTC"}
+	config.Destinations["#ops"] = &QuietHours{Start: "00:00", End: "00:00", TimeZone: "UTC"}
+	ctx := withQuietHours(context.TODO(), config)
+
+	until, held := heldUntil(ctx, NewThreadDestination("#general", "thread"), now)
+	if !held || !until.Equal(time.Date(2020, 1, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected result is returned: %s, %t.", until, held)
+	}
+
+	_, held = heldUntil(ctx, "#ops", now)
+	if held {
+		t.Error("The destination's QuietHours must take precedence.")
+	}
+}
+
+func Test_executeScheduledTask_WithQuietHours(t *testing.T) {
+	now := time.Date(2020, 1, 1, 23, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	config := NewQuietHoursConfig()
+	config.Default = &QuietHours{Start: "22:00", End: "08:00", TimeZone: "UTC"}
+	ctx := withQuietHours(clock.WithContext(context.Background(), fake), config)
+
+	sent := make(chan Output, 2)
+	bot := &DummyBot{
+		SendMessageFunc: func(_ context.Context, output Output) (*SendResult, error) {
+			sent <- output
+			return nil, nil
+		},
+		SendMessagesFunc: func(_ context.Context, outputs []Output) ([]*SendResult, error) {
+			for _, output := range outputs {
+				sent <- output
+			}
+			return make([]*SendResult, len(outputs)), nil
+		},
+	}
+	task := &scheduledTask{
+		identifier: "dummy",
+		taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+			return []*ScheduledTaskResult{
+				{Content: "report", Destination: "#general"},
+				{Content: "alert", Destination: "#general", Urgent: true},
+			}, nil
+		},
+		configWrapper: &taskConfigWrapper{
+			value: &DummyScheduledTaskConfig{},
+			mutex: &sync.RWMutex{},
+		},
+	}
+
+	executeScheduledTask(ctx, bot, task)
+
+	select {
+	case output := <-sent:
+		if output.Content() != "alert" {
+			t.Errorf("Unexpected output is sent during quiet hours: %#v.", output.Content())
+		}
+
+	default:
+		t.Fatal("Urgent result is not sent.")
+
+	}
+
+	select {
+	case output := <-sent:
+		t.Fatalf("Non-urgent result must be held: %#v.", output.Content())
+
+	default:
+
+	}
+
+	fake.Set(time.Date(2020, 1, 2, 8, 0, 0, 0, time.UTC))
+
+	select {
+	case output := <-sent:
+		if output.Content() != "report" {
+			t.Errorf("Unexpected output is sent: %#v.", output.Content())
+		}
+
+	case <-time.NewTimer(time.Second).C:
+		t.Error("Held result is not sent when the quiet hours end.")
+
+	}
+}
@@ -0,0 +1,32 @@
+package sarah
+
+// Reaction represents a reaction to a message such as an emoji.
+// A Command may return this as CommandResponse.Content for a lightweight acknowledgement instead of a text reply.
+// NewReactionResponse is a handy way to build a response that reacts to the given Input.
+//
+// An Adapter adds the reaction with the chat service's reaction feature.
+// An Adapter for a chat service without such a feature may send the emoji as a text message instead.
+type Reaction struct {
+	// Name is the name of the emoji without colons. e.g. "+1" or "white_check_mark".
+	Name string
+
+	// MessageID is the identifier of the message to react to. See MessageIDInput.
+	MessageID string
+}
+
+// NewReactionResponse creates and returns a new CommandResponse that reacts to the given Input with the given emoji.
+// The Input must satisfy MessageIDInput for an Adapter to identify the message;
+// otherwise, MessageID is left empty and the Adapter may fall back to a text message.
+func NewReactionResponse(input Input, name string) *CommandResponse {
+	reaction := &Reaction{
+		Name: name,
+	}
+	if messageInput, ok := input.(MessageIDInput); ok {
+		reaction.MessageID = messageInput.MessageID()
+	}
+
+	return &CommandResponse{
+		Content:     reaction,
+		UserContext: nil,
+	}
+}
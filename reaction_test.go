@@ -0,0 +1,39 @@
+package sarah
+
+import (
+	"testing"
+)
+
+func TestNewReactionResponse(t *testing.T) {
+	tests := []struct {
+		input     Input
+		messageID string
+	}{
+		{
+			input:     &DummyInput{},
+			messageID: "",
+		},
+		{
+			input:     &DummyThreadInput{MessageIDValue: "message"},
+			messageID: "message",
+		},
+	}
+
+	for i, tt := range tests {
+		res := NewReactionResponse(tt.input, "+1")
+
+		reaction, ok := res.Content.(*Reaction)
+		if !ok {
+			t.Errorf("Unexpected content is returned on test #%d: %#v.", i, res.Content)
+			continue
+		}
+
+		if reaction.Name != "+1" {
+			t.Errorf("Unexpected name is set on test #%d: %s.", i, reaction.Name)
+		}
+
+		if reaction.MessageID != tt.messageID {
+			t.Errorf("Unexpected message ID is set on test #%d: %s.", i, reaction.MessageID)
+		}
+	}
+}
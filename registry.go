@@ -0,0 +1,311 @@
+package sarah
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// defaultRegistry is the Registry that the package-level functions such as RegisterCommand and RegisterScheduledTaskProps register to.
+// The Runner uses this unless Config.Registry is set.
+var defaultRegistry = NewRegistry()
+
+// Registry holds a set of the Commands and the ScheduledTasks to run with.
+//
+// The package-level functions such as sarah.RegisterCommandProps register to the default Registry,
+// which is handy for the plugins that register themselves in their init functions.
+// An application that embeds go-sarah may instead build its own Registry and give it via Config.Registry,
+// so the plugin set does not depend on the imported packages and a test can set up the plugins without affecting others.
+//
+//  registry := sarah.NewRegistry()
+//  registry.RegisterCommandProps(echoProps)
+//  registry.RegisterScheduledTaskProps(alarmProps)
+//
+//  config := sarah.NewConfig()
+//  config.Registry = registry
+//  err := sarah.Run(ctx, config)
+//
+// Other components such as Bots and Alerters are still registered with the package-level functions.
+// Calls to its methods are thread-safe.
+type Registry struct {
+	options optionHolder
+}
+
+// NewRegistry creates and returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// RegisterCommand registers given sarah.Command to this Registry.
+func (reg *Registry) RegisterCommand(botType BotType, command Command, opts ...RegistrationOption) {
+	entry := newRegistration(kindCommand, botType, nil, command, opts)
+	reg.options.register(func(r *runner) {
+		r.registrations = append(r.registrations, entry)
+		commands, ok := r.commands[botType]
+		if !ok {
+			commands = []Command{}
+		}
+		r.commands[botType] = append(commands, command)
+	})
+}
+
+// RegisterScopedCommand registers given sarah.Command to the Bots the given sarah.BotScope selects.
+func (reg *Registry) RegisterScopedCommand(scope *BotScope, command Command, opts ...RegistrationOption) {
+	entry := newRegistration(kindCommand, "", scope, command, opts)
+	reg.options.register(func(r *runner) {
+		r.registrations = append(r.registrations, entry)
+		r.scopedCommands = append(r.scopedCommands, &scopedCommand{
+			scope:   scope,
+			command: command,
+		})
+	})
+}
+
+// RegisterCommandProps registers given sarah.CommandProps to build sarah.Command on sarah.Run().
+func (reg *Registry) RegisterCommandProps(props *CommandProps, opts ...RegistrationOption) {
+	entry := newRegistration(kindCommand, props.botType, props.scope, props, opts)
+	reg.options.register(func(r *runner) {
+		r.registrations = append(r.registrations, entry)
+		if props.scope != nil {
+			r.scopedCommandProps = append(r.scopedCommandProps, props)
+			return
+		}
+
+		stashed, ok := r.commandProps[props.botType]
+		if !ok {
+			stashed = []*CommandProps{}
+		}
+		r.commandProps[props.botType] = append(stashed, props)
+	})
+}
+
+// RegisterScheduledTask registers given sarah.ScheduledTask to this Registry.
+func (reg *Registry) RegisterScheduledTask(botType BotType, task ScheduledTask, opts ...RegistrationOption) {
+	entry := newRegistration(kindScheduledTask, botType, nil, task, opts)
+	reg.options.register(func(r *runner) {
+		r.registrations = append(r.registrations, entry)
+		tasks, ok := r.scheduledTasks[botType]
+		if !ok {
+			tasks = []ScheduledTask{}
+		}
+		r.scheduledTasks[botType] = append(tasks, task)
+	})
+}
+
+// RegisterScopedScheduledTask registers given sarah.ScheduledTask to be scheduled for the Bots the given sarah.BotScope selects.
+func (reg *Registry) RegisterScopedScheduledTask(scope *BotScope, task ScheduledTask, opts ...RegistrationOption) {
+	entry := newRegistration(kindScheduledTask, "", scope, task, opts)
+	reg.options.register(func(r *runner) {
+		r.registrations = append(r.registrations, entry)
+		r.scopedScheduledTasks = append(r.scopedScheduledTasks, &scopedScheduledTask{
+			scope: scope,
+			task:  task,
+		})
+	})
+}
+
+// RegisterScheduledTaskProps registers given sarah.ScheduledTaskProps to build sarah.ScheduledTask on sarah.Run().
+func (reg *Registry) RegisterScheduledTaskProps(props *ScheduledTaskProps, opts ...RegistrationOption) {
+	entry := newRegistration(kindScheduledTask, props.botType, props.scope, props, opts)
+	reg.options.register(func(r *runner) {
+		r.registrations = append(r.registrations, entry)
+		if props.scope != nil {
+			r.scopedScheduledTaskProps = append(r.scopedScheduledTaskProps, props)
+			return
+		}
+
+		stashed, ok := r.scheduledTaskProps[props.botType]
+		if !ok {
+			stashed = []*ScheduledTaskProps{}
+		}
+		r.scheduledTaskProps[props.botType] = append(stashed, props)
+	})
+}
+
+// apply registers the stashed Commands and ScheduledTasks to the given runner.
+func (reg *Registry) apply(r *runner) {
+	reg.options.apply(r)
+}
+
+const (
+	kindCommand       = "command"
+	kindScheduledTask = "scheduled task"
+)
+
+// RegistrationOption defines function signature that the registration functions' functional option must satisfy.
+type RegistrationOption func(*registration)
+
+// Override creates a RegistrationOption that replaces the Command or the ScheduledTask registered earlier with the same identifier.
+// Without this, registering the same identifier for the same Bot twice is reported as RegistrationConflictError on sarah.Run().
+// A Command replaces a CommandProps and vice versa since both build a Command for the Bot.
+//
+//  // Replace the ping command that a plugin registers with AllBots.
+//  sarah.RegisterCommandProps(customPingProps, sarah.Override())
+func Override() RegistrationOption {
+	return func(r *registration) {
+		r.override = true
+	}
+}
+
+// RegistrationConflictError is returned on sarah.Run() when the same identifier is registered more than once for a Bot without Override.
+type RegistrationConflictError struct {
+	// Kind is either "command" or "scheduled task".
+	Kind       string
+	BotType    BotType
+	Identifier string
+
+	// Sites are the source locations of the conflicting registrations in the registered order.
+	Sites []string
+}
+
+// Error returns the stringified form of the error.
+func (e *RegistrationConflictError) Error() string {
+	return fmt.Sprintf(
+		"%s %s for %s is registered more than once at %s; give sarah.Override to replace it intentionally",
+		e.Kind, e.Identifier, e.BotType, strings.Join(e.Sites, " and "),
+	)
+}
+
+// registration records a Command, a CommandProps, a ScheduledTask or a ScheduledTaskProps with where it is registered.
+type registration struct {
+	kind     string
+	botType  BotType
+	scope    *BotScope
+	entry    interface{}
+	site     string
+	override bool
+}
+
+func newRegistration(kind string, botType BotType, scope *BotScope, entry interface{}, opts []RegistrationOption) *registration {
+	r := &registration{
+		kind:    kind,
+		botType: botType,
+		scope:   scope,
+		entry:   entry,
+		site:    registrationSite(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// identifier returns the identifier of the registered entry.
+// This is called on sarah.Run() instead of on registration since a Command may not be ready to tell its identifier til then.
+func (r *registration) identifier() string {
+	switch typed := r.entry.(type) {
+	case *CommandProps:
+		return typed.identifier
+
+	case *ScheduledTaskProps:
+		return typed.identifier
+
+	case Command:
+		return typed.Identifier()
+
+	case ScheduledTask:
+		return typed.Identifier()
+
+	default:
+		return ""
+
+	}
+}
+
+// appliesTo tells if the registered entry is given to the Bot with the given BotType just like runner.botCommands selects.
+func (r *registration) appliesTo(botType BotType, groups map[string][]BotType) bool {
+	if r.scope != nil {
+		return r.scope.includes(botType, groups)
+	}
+	return r.botType == botType || (botType.InstanceID() != "" && r.botType == botType.Base())
+}
+
+// is tells if the given entry is the registered one.
+// A Command or a ScheduledTask implemented with an incomparable type is never considered the same.
+func (r *registration) is(entry interface{}) bool {
+	t := reflect.TypeOf(r.entry)
+	if t != reflect.TypeOf(entry) || !t.Comparable() {
+		return false
+	}
+	return r.entry == entry
+}
+
+// registrationSite returns the source location that called the registration function.
+func registrationSite() string {
+	pcs := make([]uintptr, 10)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isRegistrationFunc(frame.Function) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func isRegistrationFunc(name string) bool {
+	pkg := reflect.TypeOf(registration{}).PkgPath()
+	return strings.HasPrefix(name, pkg+".Register") ||
+		strings.HasPrefix(name, pkg+".(*Registry).Register") ||
+		strings.HasPrefix(name, pkg+".newRegistration")
+}
+
+// resolveRegistrations checks the registrations for each registered Bot and decides which one to use for each identifier.
+// RegistrationConflictError is returned when the same identifier is registered more than once without Override.
+func (r *runner) resolveRegistrations() error {
+	r.winners = map[BotType]map[string]*registration{}
+	for _, bot := range r.bots {
+		botType := bot.BotType()
+		registered := map[string][]*registration{}
+		var keys []string
+		for _, reg := range r.registrations {
+			if !reg.appliesTo(botType, r.botGroups) {
+				continue
+			}
+
+			key := reg.kind + ":" + reg.identifier()
+			if _, ok := registered[key]; !ok {
+				keys = append(keys, key)
+			}
+			registered[key] = append(registered[key], reg)
+		}
+
+		for _, key := range keys {
+			regs := registered[key]
+			if len(regs) == 1 {
+				continue
+			}
+
+			winner := regs[0]
+			sites := []string{winner.site}
+			for _, reg := range regs[1:] {
+				sites = append(sites, reg.site)
+				if !reg.override {
+					return &RegistrationConflictError{
+						Kind:       reg.kind,
+						BotType:    botType,
+						Identifier: reg.identifier(),
+						Sites:      sites,
+					}
+				}
+				winner = reg
+			}
+
+			if _, ok := r.winners[botType]; !ok {
+				r.winners[botType] = map[string]*registration{}
+			}
+			r.winners[botType][key] = winner
+		}
+	}
+	return nil
+}
+
+// replaced tells if the given entry for the given Bot is replaced by another registration with Override.
+func (r *runner) replaced(botType BotType, kind string, id string, entry interface{}) bool {
+	winner, ok := r.winners[botType][kind+":"+id]
+	return ok && !winner.is(entry)
+}
@@ -0,0 +1,161 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/workers"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	var botType BotType = "dummy"
+	command := &DummyCommand{}
+	task := &DummyScheduledTask{}
+	commandProps := &CommandProps{botType: botType}
+	taskProps := &ScheduledTaskProps{botType: botType}
+	scopedProps := &CommandProps{scope: AllBots()}
+
+	registry := NewRegistry()
+	registry.RegisterCommand(botType, command)
+	registry.RegisterScopedCommand(AllBots(), command)
+	registry.RegisterCommandProps(commandProps)
+	registry.RegisterCommandProps(scopedProps)
+	registry.RegisterScheduledTask(botType, task)
+	registry.RegisterScopedScheduledTask(AllBots(), task)
+	registry.RegisterScheduledTaskProps(taskProps)
+
+	r := &runner{
+		commands:           map[BotType][]Command{},
+		commandProps:       map[BotType][]*CommandProps{},
+		scheduledTasks:     map[BotType][]ScheduledTask{},
+		scheduledTaskProps: map[BotType][]*ScheduledTaskProps{},
+	}
+	registry.apply(r)
+
+	if len(r.commands[botType]) != 1 || r.commands[botType][0] != command {
+		t.Errorf("Given Command is not registered: %#v.", r.commands)
+	}
+	if len(r.scopedCommands) != 1 || r.scopedCommands[0].command != command {
+		t.Errorf("Given scoped Command is not registered: %#v.", r.scopedCommands)
+	}
+	if len(r.commandProps[botType]) != 1 || r.commandProps[botType][0] != commandProps {
+		t.Errorf("Given CommandProps is not registered: %#v.", r.commandProps)
+	}
+	if len(r.scopedCommandProps) != 1 || r.scopedCommandProps[0] != scopedProps {
+		t.Errorf("Given scoped CommandProps is not registered: %#v.", r.scopedCommandProps)
+	}
+	if len(r.scheduledTasks[botType]) != 1 || r.scheduledTasks[botType][0] != task {
+		t.Errorf("Given ScheduledTask is not registered: %#v.", r.scheduledTasks)
+	}
+	if len(r.scopedScheduledTasks) != 1 || r.scopedScheduledTasks[0].task != task {
+		t.Errorf("Given scoped ScheduledTask is not registered: %#v.", r.scopedScheduledTasks)
+	}
+	if len(r.scheduledTaskProps[botType]) != 1 || r.scheduledTaskProps[botType][0] != taskProps {
+		t.Errorf("Given ScheduledTaskProps is not registered: %#v.", r.scheduledTaskProps)
+	}
+}
+
+func Test_newRunner_WithRegistry(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		global := &DummyCommand{}
+		RegisterCommand(botType, global)
+
+		local := &DummyCommand{}
+		registry := NewRegistry()
+		registry.RegisterCommand(botType, local)
+
+		config := &Config{TimeZone: time.UTC.String(), Worker: workers.NewConfig(), Registry: registry}
+		r, err := newRunner(context.Background(), config)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		commands := r.botCommands(botType)
+		if len(commands) != 1 || commands[0] != local {
+			t.Errorf("Only the Commands in the given Registry must be used: %#v.", commands)
+		}
+
+		r, err = newRunner(context.Background(), &Config{TimeZone: time.UTC.String(), Worker: workers.NewConfig()})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		commands = r.botCommands(botType)
+		if len(commands) != 1 || commands[0] != global {
+			t.Errorf("The Commands in the default Registry must be used: %#v.", commands)
+		}
+	})
+}
+
+func Test_newRunner_WithRegistrationConflict(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		RegisterBot(&DummyBot{BotTypeValue: botType})
+		RegisterCommand(botType, &DummyCommand{IdentifierValue: "ping"})
+		RegisterCommandProps(&CommandProps{botType: botType, identifier: "ping"})
+
+		_, err := newRunner(context.Background(), &Config{TimeZone: time.UTC.String(), Worker: workers.NewConfig()})
+
+		var conflict *RegistrationConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("Expected error is not returned: %#v.", err)
+		}
+		if conflict.Kind != kindCommand || conflict.BotType != botType || conflict.Identifier != "ping" {
+			t.Errorf("Unexpected error is returned: %#v.", conflict)
+		}
+		if len(conflict.Sites) != 2 {
+			t.Fatalf("Unexpected number of sites are reported: %#v.", conflict.Sites)
+		}
+		for _, site := range conflict.Sites {
+			if !strings.Contains(site, "registry_test.go:") {
+				t.Errorf("Registration site is not reported: %s.", site)
+			}
+		}
+	})
+}
+
+func Test_newRunner_WithOverride(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		var other BotType = "other"
+		RegisterBot(&DummyBot{BotTypeValue: botType})
+		RegisterBot(&DummyBot{BotTypeValue: other})
+
+		shared := &DummyCommand{IdentifierValue: "ping"}
+		RegisterScopedCommand(AllBots(), shared)
+		custom := &DummyCommand{IdentifierValue: "ping"}
+		RegisterCommand(botType, custom, Override())
+
+		sharedTask := &ScheduledTaskProps{scope: AllBots(), identifier: "alarm"}
+		RegisterScheduledTaskProps(sharedTask)
+		customTask := &DummyScheduledTask{IdentifierValue: "alarm"}
+		RegisterScheduledTask(botType, customTask, Override())
+
+		r, err := newRunner(context.Background(), &Config{TimeZone: time.UTC.String(), Worker: workers.NewConfig()})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		commands := r.botCommands(botType)
+		if len(commands) != 1 || commands[0] != custom {
+			t.Errorf("Overriding Command must replace the scoped one: %#v.", commands)
+		}
+		commands = r.botCommands(other)
+		if len(commands) != 1 || commands[0] != shared {
+			t.Errorf("Scoped Command must be kept for other Bot: %#v.", commands)
+		}
+
+		if props := r.botScheduledTaskProps(botType); len(props) != 0 {
+			t.Errorf("Overridden ScheduledTaskProps must not be used: %#v.", props)
+		}
+		if tasks := r.botScheduledTasks(botType); len(tasks) != 1 || tasks[0] != customTask {
+			t.Errorf("Overriding ScheduledTask must be used: %#v.", tasks)
+		}
+		if props := r.botScheduledTaskProps(other); len(props) != 1 || props[0].identifier != "alarm" {
+			t.Errorf("Scoped ScheduledTaskProps must be kept for other Bot: %#v.", props)
+		}
+	})
+}
@@ -0,0 +1,487 @@
+/*
+Package reminder provides a sarah.Command that lets users set reminders from a chat.
+
+This is also a reference implementation of a stateful plugin that drives the scheduler at runtime:
+each reminder is persisted with Store, scheduled with sarah.AddScheduledTask, and scheduled again from the Store when the Bot starts.
+
+	store, err := reminder.NewFileStore("/var/lib/sarah/reminders/slack", slack.NewOutputCodec())
+	if err != nil {
+		panic(err)
+	}
+	command, err := reminder.NewCommand(slack.SLACK, reminder.NewConfig(), store)
+	if err != nil {
+		panic(err)
+	}
+	command.Register()
+
+NewStore builds a Store on top of a sarah.Store instead, so the reminders can live in the same database as other plugins' states.
+
+The command responds to below inputs:
+
+	.remind me in 2h to stretch                                 once after the given duration
+	.remind me at 15:00 to join the meeting                     once at the next given time
+	.remind me every weekday at 9:00 to check the dashboard     repeatedly on the given days
+	.remind #general every monday at 9:00 to share the plan     to the given channel; requires WithChannelResolver
+	.remind list                                                the reminders the sender set
+	.remind delete <id>                                         deletes the reminder the sender set
+
+The day of a recurring reminder is one of day, weekday, weekend, or the name of a day of the week such as monday or mon.
+*/
+package reminder
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identifier is the identifier of the Command this package provides.
+const Identifier = "remind"
+
+var (
+	matchPattern = regexp.MustCompile(`^\.remind(\s|$)`)
+	setPattern   = regexp.MustCompile(`(?i)^(me|#\S+)\s+(?:in\s+(\S+)|at\s+(\d{1,2}:\d{2})|every\s+(\S+)\s+at\s+(\d{1,2}:\d{2}))\s+(?:to\s+)?(.+)$`)
+)
+
+// moduleLogger returns the Logger carried by the given context with this package's module name.
+func moduleLogger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("reminder")
+}
+
+// These are variables so tests can replace them.
+var (
+	addScheduledTask    = sarah.AddScheduledTask
+	removeScheduledTask = sarah.RemoveScheduledTask
+)
+
+var days = map[string]string{
+	"day":       "*",
+	"weekday":   "1-5",
+	"weekend":   "0,6",
+	"sunday":    "0",
+	"sun":       "0",
+	"monday":    "1",
+	"mon":       "1",
+	"tuesday":   "2",
+	"tue":       "2",
+	"wednesday": "3",
+	"wed":       "3",
+	"thursday":  "4",
+	"thu":       "4",
+	"friday":    "5",
+	"fri":       "5",
+	"saturday":  "6",
+	"sat":       "6",
+}
+
+// Config contains some configuration variables for the reminder command.
+type Config struct {
+	// TimeZone is the name of the time zone to interpret the time of day given by users such as "Asia/Tokyo".
+	// The time zone in the sender's sarah.UserProfile takes precedence over this.
+	TimeZone string `json:"time_zone" yaml:"time_zone"`
+
+	// MaxRemindersPerUser is the maximum number of reminders one user can have at a time. Zero means no limit.
+	MaxRemindersPerUser int `json:"max_reminders_per_user" yaml:"max_reminders_per_user"`
+
+	// TimeFormat is the layout to show the time of a one-time reminder.
+	TimeFormat string `json:"time_format" yaml:"time_format"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		TimeZone:            "Local",
+		MaxRemindersPerUser: 20,
+		TimeFormat:          "2006-01-02 15:04 MST",
+	}
+}
+
+// CommandOption defines a function signature that NewCommand's functional option must satisfy.
+type CommandOption func(*Command)
+
+// WithChannelResolver creates a CommandOption that sets a function to convert the channel name given as "#channel" to the destination of the reminder.
+// Since the destination is Adapter specific, a reminder for a channel is refused without this option.
+func WithChannelResolver(fnc func(input sarah.Input, channel string) (sarah.OutputDestination, error)) CommandOption {
+	return func(c *Command) {
+		c.resolveChannel = fnc
+	}
+}
+
+// Command is a sarah.Command that sets, lists, and deletes reminders for the Bot with the given BotType.
+type Command struct {
+	botType        sarah.BotType
+	config         *Config
+	store          Store
+	location       *time.Location
+	resolveChannel func(sarah.Input, string) (sarah.OutputDestination, error)
+	reminders      map[string]*Reminder
+	mutex          sync.Mutex
+}
+
+var _ sarah.Command = (*Command)(nil)
+
+// NewCommand creates and returns a new Command for the Bot with the given BotType.
+// An error is returned when Config.TimeZone is not a valid time zone.
+func NewCommand(botType sarah.BotType, config *Config, store Store, options ...CommandOption) (*Command, error) {
+	location, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone %s: %w", config.TimeZone, err)
+	}
+
+	c := &Command{
+		botType:   botType,
+		config:    config,
+		store:     store,
+		location:  location,
+		reminders: map[string]*Reminder{},
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Register registers the Command and an event subscriber that calls Restore when the Bot starts.
+// Call this before sarah.Run.
+func (c *Command) Register() {
+	sarah.RegisterCommand(c.botType, c)
+	sarah.RegisterEventSubscriber(func(event sarah.Event) {
+		started, ok := event.(*sarah.BotStarted)
+		if !ok || started.BotType != c.botType {
+			return
+		}
+
+		err := c.Restore()
+		if err != nil {
+			moduleLogger(context.Background()).Error("Failed to restore reminders", logging.F(logging.KeyBotType, c.botType), logging.Err(err))
+		}
+	})
+}
+
+// Restore loads the reminders from the Store and schedules them for the Bot.
+// A one-time reminder whose time has passed while the Bot was not running is sent right away.
+func (c *Command) Restore() error {
+	stored, err := c.store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load reminders: %w", err)
+	}
+
+	var reminders []*Reminder
+	c.mutex.Lock()
+	c.reminders = map[string]*Reminder{}
+	for _, reminder := range stored {
+		if reminder.BotType != c.botType {
+			continue
+		}
+		c.reminders[reminder.ID] = reminder
+		reminders = append(reminders, reminder)
+	}
+	c.mutex.Unlock()
+
+	// Schedule without the lock since a past reminder is sent and forgotten right away.
+	var failures []string
+	for _, reminder := range reminders {
+		err := addScheduledTask(c.botType, &task{reminder: reminder, command: c})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", reminder.ID, err.Error()))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to schedule reminders: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Identifier returns the command ID.
+func (c *Command) Identifier() string {
+	return Identifier
+}
+
+// Instruction provides the input instruction.
+func (c *Command) Instruction(_ *sarah.HelpInput) string {
+	return ".remind me|#channel in <duration>|at <hh:mm>|every <day> at <hh:mm> to <message>, .remind list, .remind delete <id>"
+}
+
+// Match checks if the input is a reminder command.
+func (c *Command) Match(input sarah.Input) bool {
+	return matchPattern.Copy().MatchString(input.Message())
+}
+
+// Execute runs the given sub-command and returns its result.
+func (c *Command) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	text := strings.TrimSpace(sarah.StripMessage(matchPattern, input.Message()))
+	args := strings.Fields(text)
+	if len(args) == 0 {
+		return respond("Usage: " + c.Instruction(&sarah.HelpInput{OriginalInput: input})), nil
+	}
+
+	switch args[0] {
+	case "list":
+		return respond(c.list(input)), nil
+
+	case "delete":
+		if len(args) != 2 {
+			return respond("Usage: .remind delete <id>"), nil
+		}
+		return respond(c.delete(ctx, input, args[1])), nil
+
+	default:
+		return respond(c.set(ctx, input, text)), nil
+
+	}
+}
+
+func respond(text string) *sarah.CommandResponse {
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}
+}
+
+func (c *Command) set(ctx context.Context, input sarah.Input, text string) string {
+	match := setPattern.FindStringSubmatch(text)
+	if match == nil {
+		return "Usage: " + c.Instruction(&sarah.HelpInput{OriginalInput: input})
+	}
+
+	// Interpret the time of day in the sender's time zone when the sender has set one with sarah.SaveUserProfile.
+	location := sarah.UserLocation(ctx, sarah.UserID(input), c.location)
+	now := clock.FromContext(ctx).Now().In(location)
+	reminder := &Reminder{
+		BotType:   c.botType,
+		SenderKey: input.SenderKey(),
+		CreatedAt: now,
+	}
+
+	switch {
+	case match[2] != "":
+		d, err := time.ParseDuration(match[2])
+		if err != nil || d <= 0 {
+			return fmt.Sprintf("Invalid duration: %s", match[2])
+		}
+		at := now.Add(d)
+		reminder.Schedule = sarah.ScheduleOnceAt(at)
+		reminder.Description = "at " + at.Format(c.config.TimeFormat)
+
+	case match[3] != "":
+		hour, minute, err := parseClock(match[3])
+		if err != nil {
+			return err.Error()
+		}
+		at := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, location)
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		reminder.Schedule = sarah.ScheduleOnceAt(at)
+		reminder.Description = "at " + at.Format(c.config.TimeFormat)
+
+	default:
+		day := strings.TrimSuffix(strings.ToLower(match[4]), "s")
+		dow, ok := days[day]
+		if !ok {
+			return fmt.Sprintf("Unknown day: %s", match[4])
+		}
+		hour, minute, err := parseClock(match[5])
+		if err != nil {
+			return err.Error()
+		}
+		// Let the scheduler interpret the time of day in the sender's time zone regardless of the Runner's time zone.
+		reminder.Schedule = fmt.Sprintf("CRON_TZ=%s %d %d * * %s", location, minute, hour, dow)
+		reminder.Recurring = true
+		reminder.Description = fmt.Sprintf("every %s at %02d:%02d", day, hour, minute)
+
+	}
+
+	dest := input.ReplyTo()
+	if target := match[1]; strings.HasPrefix(target, "#") {
+		if c.resolveChannel == nil {
+			return "Reminding a channel is not supported."
+		}
+
+		var err error
+		dest, err = c.resolveChannel(input, strings.TrimPrefix(target, "#"))
+		if err != nil {
+			return fmt.Sprintf("Failed to find channel %s: %s", target, err.Error())
+		}
+	}
+	reminder.Output = sarah.NewOutputMessage(dest, match[6])
+
+	id, err := newID()
+	if err != nil {
+		return fmt.Sprintf("Failed to set reminder: %s", err.Error())
+	}
+	reminder.ID = id
+
+	err = c.add(ctx, reminder)
+	if err != nil {
+		return fmt.Sprintf("Failed to set reminder: %s", err.Error())
+	}
+
+	return fmt.Sprintf("Reminder %s is set %s.", reminder.ID, reminder.Description)
+}
+
+func (c *Command) add(ctx context.Context, reminder *Reminder) error {
+	c.mutex.Lock()
+	if limit := c.config.MaxRemindersPerUser; limit > 0 && c.count(reminder.SenderKey) >= limit {
+		c.mutex.Unlock()
+		return fmt.Errorf("you already have %d reminders", limit)
+	}
+	c.reminders[reminder.ID] = reminder
+	c.mutex.Unlock()
+
+	err := c.store.Save(reminder)
+	if err == nil {
+		err = addScheduledTask(c.botType, &task{reminder: reminder, command: c})
+	}
+	if err != nil {
+		c.forget(ctx, reminder.ID)
+		return err
+	}
+
+	return nil
+}
+
+// count returns the number of the reminders the given sender has. This must be called while c.mutex is locked.
+func (c *Command) count(senderKey string) int {
+	cnt := 0
+	for _, reminder := range c.reminders {
+		if reminder.SenderKey == senderKey {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// forget removes the reminder from the Store and the scheduler.
+func (c *Command) forget(ctx context.Context, id string) {
+	c.mutex.Lock()
+	delete(c.reminders, id)
+	c.mutex.Unlock()
+
+	log := moduleLogger(ctx).With(logging.F(logging.KeyBotType, c.botType), logging.F("reminder_id", id))
+	err := c.store.Delete(id)
+	if err != nil {
+		log.Error("Failed to delete reminder", logging.Err(err))
+	}
+
+	err = removeScheduledTask(c.botType, taskID(id))
+	if err != nil && !errors.Is(err, sarah.ErrScheduledTaskNotFound) {
+		log.Error("Failed to unschedule reminder", logging.Err(err))
+	}
+}
+
+func (c *Command) list(input sarah.Input) string {
+	c.mutex.Lock()
+	var reminders []*Reminder
+	for _, reminder := range c.reminders {
+		if reminder.SenderKey == input.SenderKey() {
+			reminders = append(reminders, reminder)
+		}
+	}
+	c.mutex.Unlock()
+
+	if len(reminders) == 0 {
+		return "No reminder is set."
+	}
+
+	sortReminders(reminders)
+	var lines []string
+	for _, reminder := range reminders {
+		lines = append(lines, fmt.Sprintf("%s: %s %v", reminder.ID, reminder.Description, reminder.Output.Content()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *Command) delete(ctx context.Context, input sarah.Input, id string) string {
+	c.mutex.Lock()
+	reminder, ok := c.reminders[id]
+	c.mutex.Unlock()
+
+	// Do not tell if the reminder exists when it belongs to another user.
+	if !ok || reminder.SenderKey != input.SenderKey() {
+		return fmt.Sprintf("Reminder %s is not found.", id)
+	}
+
+	c.forget(ctx, id)
+	return fmt.Sprintf("Reminder %s is deleted.", id)
+}
+
+// parseClock parses the time of day in the form of hh:mm.
+func parseClock(str string) (int, int, error) {
+	parts := strings.SplitN(str, ":", 2)
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid time: %s", str)
+	}
+
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid time: %s", str)
+	}
+
+	return hour, minute, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 4)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func taskID(id string) string {
+	return Identifier + "-" + id
+}
+
+// task is a sarah.ScheduledTask that sends a reminder.
+type task struct {
+	reminder *Reminder
+	command  *Command
+}
+
+var _ sarah.ScheduledTask = (*task)(nil)
+
+func (t *task) Identifier() string {
+	return taskID(t.reminder.ID)
+}
+
+func (t *task) Execute(ctx context.Context) ([]*sarah.ScheduledTaskResult, error) {
+	if !t.reminder.Recurring {
+		t.command.forget(ctx, t.reminder.ID)
+	}
+
+	content := t.reminder.Output.Content()
+	if text, ok := content.(string); ok {
+		content = "Reminder: " + text
+	}
+
+	return []*sarah.ScheduledTaskResult{
+		{
+			Content:     content,
+			Destination: t.reminder.Output.Destination(),
+		},
+	}, nil
+}
+
+func (t *task) DefaultDestination() sarah.OutputDestination {
+	return t.reminder.Output.Destination()
+}
+
+func (t *task) Schedule() string {
+	return t.reminder.Schedule
+}
@@ -0,0 +1,446 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+}
+
+var _ sarah.Input = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "dummy"
+}
+
+// stubScheduler replaces the functions to control the scheduler.
+// This returns the tasks scheduled so far and a function to restore the replaced functions.
+func stubScheduler(t *testing.T) (map[string]sarah.ScheduledTask, func()) {
+	tasks := map[string]sarah.ScheduledTask{}
+	addScheduledTask = func(botType sarah.BotType, task sarah.ScheduledTask) error {
+		if botType != "dummy" {
+			t.Errorf("Unexpected BotType is given: %s.", botType)
+		}
+		tasks[task.Identifier()] = task
+		return nil
+	}
+	removeScheduledTask = func(_ sarah.BotType, id string) error {
+		if _, ok := tasks[id]; !ok {
+			return sarah.ErrScheduledTaskNotFound
+		}
+		delete(tasks, id)
+		return nil
+	}
+	return tasks, func() {
+		addScheduledTask = sarah.AddScheduledTask
+		removeScheduledTask = sarah.RemoveScheduledTask
+	}
+}
+
+func newTestCommand(t *testing.T, options ...CommandOption) *Command {
+	config := NewConfig()
+	config.TimeZone = "UTC"
+	config.MaxRemindersPerUser = 2
+	config.TimeFormat = time.RFC3339
+	c, err := NewCommand("dummy", config, NewMemoryStore(), options...)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return c
+}
+
+func execute(t *testing.T, ctx context.Context, c *Command, senderKey string, message string) string {
+	res, err := c.Execute(ctx, &DummyInput{SenderKeyValue: senderKey, MessageValue: message})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return res.Content.(string)
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config.TimeZone != "Local" {
+		t.Errorf("Unexpected default time zone is set: %s.", config.TimeZone)
+	}
+
+	if config.MaxRemindersPerUser <= 0 {
+		t.Errorf("Unexpected default limit is set: %d.", config.MaxRemindersPerUser)
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	config := NewConfig()
+	config.TimeZone = "Invalid/Zone"
+	_, err := NewCommand("dummy", config, NewMemoryStore())
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	c := newTestCommand(t)
+	if c.Identifier() != Identifier {
+		t.Errorf("Unexpected identifier is returned: %s.", c.Identifier())
+	}
+
+	if c.Instruction(&sarah.HelpInput{}) == "" {
+		t.Error("Instruction must be provided.")
+	}
+}
+
+func TestCommand_Match(t *testing.T) {
+	c := newTestCommand(t)
+	tests := []struct {
+		message string
+		matched bool
+	}{
+		{message: ".remind me in 2h to stretch", matched: true},
+		{message: ".remind", matched: true},
+		{message: ".reminder", matched: false},
+		{message: "remind me", matched: false},
+	}
+
+	for _, tt := range tests {
+		if c.Match(&DummyInput{MessageValue: tt.message}) != tt.matched {
+			t.Errorf("Unexpected match result for %s.", tt.message)
+		}
+	}
+}
+
+func TestCommand_Execute_Set(t *testing.T) {
+	now := time.Date(2020, 1, 1, 16, 0, 0, 0, time.UTC)
+	ctx := clock.WithContext(context.Background(), clock.NewFake(now))
+
+	tests := []struct {
+		message     string
+		schedule    string
+		recurring   bool
+		description string
+		content     string
+	}{
+		{
+			message:     ".remind me in 2h to stretch",
+			schedule:    sarah.ScheduleOnceAt(now.Add(2 * time.Hour)),
+			description: "at 2020-01-01T18:00:00Z",
+			content:     "stretch",
+		},
+		{
+			message:     ".remind me at 15:00 to join the meeting",
+			schedule:    sarah.ScheduleOnceAt(time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC)),
+			description: "at 2020-01-02T15:00:00Z",
+			content:     "join the meeting",
+		},
+		{
+			message:     ".remind me every Weekdays at 9:05 check the dashboard",
+			schedule:    "CRON_TZ=UTC 5 9 * * 1-5",
+			recurring:   true,
+			description: "every weekday at 09:05",
+			content:     "check the dashboard",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			tasks, reset := stubScheduler(t)
+			defer reset()
+			c := newTestCommand(t)
+
+			text := execute(t, ctx, c, "sender", tt.message)
+			if !strings.HasPrefix(text, "Reminder ") || !strings.HasSuffix(text, tt.description+".") {
+				t.Errorf("Unexpected response is returned: %s.", text)
+			}
+
+			stored, _ := c.store.Load()
+			if len(stored) != 1 {
+				t.Fatalf("Reminder is not stored: %#v.", stored)
+			}
+			reminder := stored[0]
+			if reminder.Schedule != tt.schedule || reminder.Recurring != tt.recurring || reminder.SenderKey != "sender" {
+				t.Errorf("Unexpected reminder is stored: %#v.", reminder)
+			}
+			if reminder.Output.Content() != tt.content || reminder.Output.Destination() != "dummy" {
+				t.Errorf("Unexpected output is stored: %#v.", reminder.Output)
+			}
+
+			task, ok := tasks[taskID(reminder.ID)]
+			if !ok {
+				t.Fatalf("Reminder is not scheduled: %#v.", tasks)
+			}
+			if task.Schedule() != tt.schedule {
+				t.Errorf("Unexpected schedule is set: %s.", task.Schedule())
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_SetWithUserTimeZone(t *testing.T) {
+	now := time.Date(2020, 1, 1, 16, 0, 0, 0, time.UTC)
+	ctx := clock.WithContext(context.Background(), clock.NewFake(now))
+	ctx = sarah.WithStore(ctx, sarah.NewMemoryStore())
+
+	err := sarah.SaveUserProfile(ctx, "sender", &sarah.UserProfile{TimeZone: "Asia/Tokyo"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t)
+
+	_ = execute(t, ctx, c, "sender", ".remind me every day at 9:00 to check the dashboard")
+
+	stored, _ := c.store.Load()
+	if len(stored) != 1 {
+		t.Fatalf("Reminder is not stored: %#v.", stored)
+	}
+	if stored[0].Schedule != "CRON_TZ=Asia/Tokyo 0 9 * * *" {
+		t.Errorf("Time zone of the sender is not used: %s.", stored[0].Schedule)
+	}
+}
+
+func TestCommand_Execute_SetInvalid(t *testing.T) {
+	_, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		message  string
+		response string
+	}{
+		{message: ".remind me tomorrow", response: "Usage: "},
+		{message: ".remind me in -1h to stretch", response: "Invalid duration: -1h"},
+		{message: ".remind me at 25:00 to sleep", response: "invalid time: 25:00"},
+		{message: ".remind me every holiday at 9:00 to relax", response: "Unknown day: holiday"},
+		{message: ".remind #general in 1h to stretch", response: "Reminding a channel is not supported."},
+	}
+
+	for _, tt := range tests {
+		text := execute(t, ctx, c, "sender", tt.message)
+		if !strings.HasPrefix(text, tt.response) {
+			t.Errorf("Unexpected response is returned for %s: %s.", tt.message, text)
+		}
+	}
+
+	stored, _ := c.store.Load()
+	if len(stored) != 0 {
+		t.Errorf("Invalid reminder must not be stored: %#v.", stored)
+	}
+}
+
+func TestCommand_Execute_SetChannel(t *testing.T) {
+	_, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t, WithChannelResolver(func(_ sarah.Input, channel string) (sarah.OutputDestination, error) {
+		if channel != "general" {
+			return nil, errors.New("not found")
+		}
+		return "C12345", nil
+	}))
+	ctx := context.Background()
+
+	text := execute(t, ctx, c, "sender", ".remind #general every monday at 9:00 to share the plan")
+	if !strings.HasPrefix(text, "Reminder ") {
+		t.Fatalf("Unexpected response is returned: %s.", text)
+	}
+
+	stored, _ := c.store.Load()
+	if len(stored) != 1 || stored[0].Output.Destination() != "C12345" {
+		t.Errorf("Resolved destination is not stored: %#v.", stored)
+	}
+
+	text = execute(t, ctx, c, "sender", ".remind #random in 1h to stretch")
+	if text != "Failed to find channel #random: not found" {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+}
+
+func TestCommand_Execute_Limit(t *testing.T) {
+	_, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		execute(t, ctx, c, "sender", ".remind me in 1h to stretch")
+	}
+
+	text := execute(t, ctx, c, "sender", ".remind me in 1h to stretch")
+	if text != "Failed to set reminder: you already have 2 reminders" {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	text = execute(t, ctx, c, "other", ".remind me in 1h to stretch")
+	if !strings.HasPrefix(text, "Reminder ") {
+		t.Errorf("Other user's reminder must be set: %s.", text)
+	}
+}
+
+func TestCommand_Execute_ScheduleError(t *testing.T) {
+	_, reset := stubScheduler(t)
+	defer reset()
+	addScheduledTask = func(_ sarah.BotType, _ sarah.ScheduledTask) error {
+		return sarah.ErrBotNotRunning
+	}
+	c := newTestCommand(t)
+
+	text := execute(t, context.Background(), c, "sender", ".remind me in 1h to stretch")
+	if !strings.HasPrefix(text, "Failed to set reminder: ") {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	stored, _ := c.store.Load()
+	if len(stored) != 0 {
+		t.Errorf("Unscheduled reminder must not be stored: %#v.", stored)
+	}
+}
+
+func TestCommand_Execute_DeleteError(t *testing.T) {
+	_, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t)
+
+	text := execute(t, context.Background(), c, "sender", ".remind me in 1h to stretch")
+	id := strings.Fields(text)[1]
+
+	removeScheduledTask = func(_ sarah.BotType, _ string) error {
+		return sarah.ErrBotNotRunning
+	}
+	buf := &bytes.Buffer{}
+	ctx := logging.NewContext(context.Background(), logging.NewLogger(logging.NewJSONHandler(buf)))
+	text = execute(t, ctx, c, "sender", ".remind delete "+id)
+	if text != "Reminder "+id+" is deleted." {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	for _, expected := range []string{`"module":"reminder"`, `"bot_type":"dummy"`, `"reminder_id":"` + id + `"`, `"message":"Failed to unschedule reminder"`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s is not logged: %s.", expected, buf.String())
+		}
+	}
+}
+
+func TestCommand_Execute_ListAndDelete(t *testing.T) {
+	tasks, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t)
+	ctx := context.Background()
+
+	text := execute(t, ctx, c, "sender", ".remind list")
+	if text != "No reminder is set." {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	execute(t, ctx, c, "sender", ".remind me every day at 9:00 to stretch")
+	stored, _ := c.store.Load()
+	id := stored[0].ID
+
+	text = execute(t, ctx, c, "sender", ".remind list")
+	if text != id+": every day at 09:00 stretch" {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	text = execute(t, ctx, c, "other", ".remind list")
+	if text != "No reminder is set." {
+		t.Errorf("Other user's reminders must not be listed: %s.", text)
+	}
+
+	text = execute(t, ctx, c, "other", ".remind delete "+id)
+	if text != "Reminder "+id+" is not found." {
+		t.Errorf("Other user's reminder must not be deleted: %s.", text)
+	}
+
+	text = execute(t, ctx, c, "sender", ".remind delete "+id)
+	if text != "Reminder "+id+" is deleted." {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	stored, _ = c.store.Load()
+	if len(stored) != 0 {
+		t.Errorf("Deleted reminder is stored: %#v.", stored)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("Deleted reminder is scheduled: %#v.", tasks)
+	}
+
+	text = execute(t, ctx, c, "sender", ".remind delete")
+	if text != "Usage: .remind delete <id>" {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+}
+
+func TestTask_Execute(t *testing.T) {
+	tasks, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t)
+	ctx := context.Background()
+
+	execute(t, ctx, c, "sender", ".remind me in 1h to stretch")
+	execute(t, ctx, c, "sender", ".remind me every day at 9:00 to drink water")
+
+	for _, task := range tasks {
+		results, err := task.Execute(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(results) != 1 || results[0].Destination != "dummy" || !strings.HasPrefix(results[0].Content.(string), "Reminder: ") {
+			t.Errorf("Unexpected results are returned: %#v.", results)
+		}
+	}
+
+	stored, _ := c.store.Load()
+	if len(stored) != 1 || !stored[0].Recurring {
+		t.Errorf("Only recurring reminder must be kept: %#v.", stored)
+	}
+	if len(tasks) != 1 {
+		t.Errorf("Only recurring reminder must be scheduled: %#v.", tasks)
+	}
+}
+
+func TestCommand_Restore(t *testing.T) {
+	tasks, reset := stubScheduler(t)
+	defer reset()
+	c := newTestCommand(t)
+
+	reminders := []*Reminder{
+		{ID: "a", BotType: "dummy", SenderKey: "sender", Output: sarah.NewOutputMessage("dummy", "stretch"), Schedule: "@daily", Recurring: true},
+		{ID: "b", BotType: "other", SenderKey: "sender", Output: sarah.NewOutputMessage("dummy", "stretch"), Schedule: "@daily", Recurring: true},
+	}
+	for _, reminder := range reminders {
+		_ = c.store.Save(reminder)
+	}
+
+	err := c.Restore()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if _, ok := tasks[taskID("a")]; !ok || len(tasks) != 1 {
+		t.Errorf("Only the Bot's reminder must be scheduled: %#v.", tasks)
+	}
+
+	text := execute(t, context.Background(), c, "sender", ".remind list")
+	if !strings.HasPrefix(text, "a: ") {
+		t.Errorf("Restored reminder is not listed: %s.", text)
+	}
+}
@@ -0,0 +1,238 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reminder represents a reminder set by a user.
+type Reminder struct {
+	// ID is the unique identifier of the reminder.
+	ID string
+
+	// BotType is the type of the Bot that sends the reminder.
+	BotType sarah.BotType
+
+	// SenderKey is the Input.SenderKey() of the user who set the reminder. Only this user can delete the reminder.
+	SenderKey string
+
+	// Output is the message to be sent and its destination.
+	Output sarah.Output
+
+	// Schedule is the schedule of the reminder.
+	// This is either a cron spec for a recurring reminder or the value of sarah.ScheduleOnceAt for a one-time reminder.
+	Schedule string
+
+	// Recurring tells if the reminder is sent repeatedly on its Schedule.
+	Recurring bool
+
+	// Description is the human-readable form of the Schedule such as "every monday at 09:00".
+	Description string
+
+	// CreatedAt is the time when the reminder was set.
+	CreatedAt time.Time
+}
+
+// Store defines an interface that persists the reminders so they survive a restart.
+// A reminder is saved when a user sets it and deleted when the user deletes it or a one-time reminder is sent.
+// On the Bot's start, the reminders in the store are loaded and scheduled again.
+type Store interface {
+	// Save persists the given reminder.
+	Save(*Reminder) error
+
+	// Delete removes the reminder with the given ID.
+	Delete(id string) error
+
+	// Load returns the persisted reminders in the order of their CreatedAt.
+	Load() ([]*Reminder, error)
+}
+
+// memoryStore is a Store that keeps the reminders in memory.
+type memoryStore struct {
+	reminders map[string]*Reminder
+	mutex     sync.Mutex
+}
+
+var _ Store = (*memoryStore)(nil)
+
+// NewMemoryStore creates and returns a Store that keeps the reminders in memory.
+// The reminders survive the Bot's restart, but not the process's restart.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		reminders: map[string]*Reminder{},
+	}
+}
+
+func (s *memoryStore) Save(reminder *Reminder) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.reminders[reminder.ID] = reminder
+	return nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.reminders, id)
+	return nil
+}
+
+func (s *memoryStore) Load() ([]*Reminder, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var reminders []*Reminder
+	for _, reminder := range s.reminders {
+		reminders = append(reminders, reminder)
+	}
+	sortReminders(reminders)
+	return reminders, nil
+}
+
+// kvStore is a Store that stores each reminder as a JSON value in sarah.Store.
+type kvStore struct {
+	store sarah.Store
+	codec sarah.OutputCodec
+}
+
+var _ Store = (*kvStore)(nil)
+
+// NewStore creates and returns a Store that stores each reminder as a JSON value in the given sarah.Store with the reminder's ID as the key.
+// Give the sarah.Store namespaced with sarah.NewNamespacedStore so the keys do not conflict with other plugins':
+//
+//  store := reminder.NewStore(sarah.NewNamespacedStore(redisStore, "reminder", "slack"), slack.NewOutputCodec())
+//
+// The given sarah.OutputCodec converts the reminder's message to be stored, so the codec must produce a valid JSON value.
+func NewStore(store sarah.Store, codec sarah.OutputCodec) Store {
+	return &kvStore{
+		store: store,
+		codec: codec,
+	}
+}
+
+func (s *kvStore) Save(reminder *Reminder) error {
+	b, err := encode(s.codec, reminder)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Set(context.Background(), reminder.ID, b)
+}
+
+func (s *kvStore) Delete(id string) error {
+	return s.store.Delete(context.Background(), id)
+}
+
+func (s *kvStore) Load() ([]*Reminder, error) {
+	ctx := context.Background()
+	ids, err := s.store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []*Reminder
+	for _, id := range ids {
+		b, err := s.store.Get(ctx, id)
+		if errors.Is(err, sarah.ErrStoreKeyNotFound) {
+			// Deleted after the listing.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		reminder, err := decode(s.codec, b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", id, err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	sortReminders(reminders)
+	return reminders, nil
+}
+
+// storedReminder is the JSON form of Reminder that the stores persist.
+type storedReminder struct {
+	ID          string          `json:"id"`
+	BotType     sarah.BotType   `json:"bot_type"`
+	SenderKey   string          `json:"sender_key"`
+	Output      json.RawMessage `json:"output"`
+	Schedule    string          `json:"schedule"`
+	Recurring   bool            `json:"recurring"`
+	Description string          `json:"description"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// NewFileStore creates and returns a Store that stores each reminder as a file in the given directory.
+// The directory is created when it does not exist.
+// This is a shorthand for NewStore with the sarah.Store sarah.NewFileStore returns.
+// An Adapter such as slack provides its own sarah.OutputCodec.
+func NewFileStore(dir string, codec sarah.OutputCodec) (Store, error) {
+	store, err := sarah.NewFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(store, codec), nil
+}
+
+// encode converts the given reminder to the JSON form to be stored.
+func encode(codec sarah.OutputCodec, reminder *Reminder) ([]byte, error) {
+	output, err := codec.Encode(reminder.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	return json.Marshal(&storedReminder{
+		ID:          reminder.ID,
+		BotType:     reminder.BotType,
+		SenderKey:   reminder.SenderKey,
+		Output:      output,
+		Schedule:    reminder.Schedule,
+		Recurring:   reminder.Recurring,
+		Description: reminder.Description,
+		CreatedAt:   reminder.CreatedAt,
+	})
+}
+
+// decode converts the stored JSON form to a reminder.
+func decode(codec sarah.OutputCodec, b []byte) (*Reminder, error) {
+	stored := &storedReminder{}
+	err := json.Unmarshal(b, stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reminder: %w", err)
+	}
+
+	output, err := codec.Decode(stored.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+
+	return &Reminder{
+		ID:          stored.ID,
+		BotType:     stored.BotType,
+		SenderKey:   stored.SenderKey,
+		Output:      output,
+		Schedule:    stored.Schedule,
+		Recurring:   stored.Recurring,
+		Description: stored.Description,
+		CreatedAt:   stored.CreatedAt,
+	}, nil
+}
+
+func sortReminders(reminders []*Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].CreatedAt.Equal(reminders[j].CreatedAt) {
+			return reminders[i].CreatedAt.Before(reminders[j].CreatedAt)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+}
@@ -0,0 +1,158 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type DummyOutputCodec struct {
+	EncodeFunc func(sarah.Output) ([]byte, error)
+	DecodeFunc func([]byte) (sarah.Output, error)
+}
+
+var _ sarah.OutputCodec = (*DummyOutputCodec)(nil)
+
+func (c *DummyOutputCodec) Encode(output sarah.Output) ([]byte, error) {
+	return c.EncodeFunc(output)
+}
+
+func (c *DummyOutputCodec) Decode(b []byte) (sarah.Output, error) {
+	return c.DecodeFunc(b)
+}
+
+// stringOutputCodec is a DummyOutputCodec that handles string destination and string content.
+func stringOutputCodec() *DummyOutputCodec {
+	return &DummyOutputCodec{
+		EncodeFunc: func(output sarah.Output) ([]byte, error) {
+			return json.Marshal([]interface{}{output.Destination(), output.Content()})
+		},
+		DecodeFunc: func(b []byte) (sarah.Output, error) {
+			var stored []string
+			err := json.Unmarshal(b, &stored)
+			if err != nil {
+				return nil, err
+			}
+			return sarah.NewOutputMessage(stored[0], stored[1]), nil
+		},
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	now := time.Now()
+	reminders := []*Reminder{
+		{
+			ID:          "b",
+			BotType:     "dummy",
+			SenderKey:   "sender",
+			Output:      sarah.NewOutputMessage("dest", "later"),
+			Schedule:    "0 9 * * 1",
+			Recurring:   true,
+			Description: "every monday at 09:00",
+			CreatedAt:   now.Add(time.Second),
+		},
+		{
+			ID:        "a",
+			BotType:   "dummy",
+			Output:    sarah.NewOutputMessage("dest", "earlier"),
+			Schedule:  sarah.ScheduleOnceAt(now),
+			CreatedAt: now,
+		},
+	}
+	for _, reminder := range reminders {
+		err := store.Save(reminder)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("Unexpected number of reminders are loaded: %d.", len(loaded))
+	}
+	if loaded[0].ID != "a" || loaded[0].Output.Content() != "earlier" {
+		t.Errorf("Reminders are not sorted: %#v.", loaded[0])
+	}
+	b := loaded[1]
+	if b.BotType != "dummy" || b.SenderKey != "sender" || b.Output.Destination() != "dest" ||
+		b.Schedule != "0 9 * * 1" || !b.Recurring || b.Description != "every monday at 09:00" {
+		t.Errorf("Unexpected reminder is loaded: %#v.", b)
+	}
+
+	err = store.Delete("a")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = store.Delete("unknown")
+	if err != nil {
+		t.Errorf("Deleting unknown reminder must not fail: %s.", err.Error())
+	}
+
+	loaded, _ = store.Load()
+	if len(loaded) != 1 || loaded[0].ID != "b" {
+		t.Errorf("Deleted reminder is loaded: %#v.", loaded)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestStore(t *testing.T) {
+	testStore(t, NewStore(sarah.NewMemoryStore(), stringOutputCodec()))
+}
+
+func TestStore_Load_Error(t *testing.T) {
+	store := sarah.NewMemoryStore()
+	_ = store.Set(context.TODO(), "broken", []byte("{"))
+
+	_, err := NewStore(store, stringOutputCodec()).Load()
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reminder")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileStore(filepath.Join(dir, "nested"), stringOutputCodec())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	testStore(t, store)
+}
+
+func TestFileStore_Save_EncodeError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reminder")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	expectedErr := errors.New("unsupported")
+	store, _ := NewFileStore(dir, &DummyOutputCodec{
+		EncodeFunc: func(_ sarah.Output) ([]byte, error) {
+			return nil, expectedErr
+		},
+	})
+
+	err = store.Save(&Reminder{ID: "a", Output: sarah.NewOutputMessage("dest", func() {})})
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
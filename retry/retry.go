@@ -0,0 +1,266 @@
+/*
+Package retry provides helper functions to retry a given function with various retry policies.
+
+While a fixed interval is sufficient in some cases, a remote service that is temporarily down may be overwhelmed
+by multiple clients retrying at the same pace.
+Policy supports exponential backoff and jitter to spread the retrial timing.
+*/
+package retry
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors is an alias for a slice of errors that occurred during the retrials.
+// This implements the error interface so this can be returned as an error.
+type Errors []error
+
+// Error returns the stringified form of all stored errors.
+func (e *Errors) Error() string {
+	var errs []string
+	for _, err := range *e {
+		errs = append(errs, err.Error())
+	}
+	return strings.Join(errs, "\n")
+}
+
+// appendError appends the given error to the error list.
+func (e *Errors) appendError(err error) {
+	*e = append(*e, err)
+}
+
+// PermanentError wraps an error that is not worth retrying.
+// Use Permanent to construct one in the retried function.
+type PermanentError struct {
+	Err error
+}
+
+// Error returns the stringified form of the wrapped error.
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error so errors.Is and errors.As can inspect it.
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps the given error to tell the retry loop to stop immediately.
+// This is useful when the failure is not temporary such as an authentication error with an invalid token:
+//
+//  err := retry.WithPolicy(policy, func() error {
+//    resp, err := client.Do(req)
+//    if err != nil {
+//      return err
+//    }
+//    if resp.StatusCode == http.StatusUnauthorized {
+//      return retry.Permanent(errors.New("invalid token"))
+//    }
+//    return nil
+//  })
+//
+// When nil is given, nil is returned.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// Jitter defines how the randomness is added to the interval.
+type Jitter string
+
+const (
+	// JitterNone adds no randomness to the interval.
+	JitterNone Jitter = ""
+
+	// JitterFull picks a random interval between zero and the calculated interval.
+	JitterFull Jitter = "full"
+
+	// JitterEqual keeps half of the calculated interval and adds a random value between zero and the other half.
+	JitterEqual Jitter = "equal"
+)
+
+// Policy represents a retry policy.
+// The fields other than OnRetry can be configured via json.Unmarshal or yaml.Unmarshal.
+//
+// The first interval is Interval, and the succeeding intervals are multiplied by Multiplier up to MaxInterval.
+// When Multiplier is zero or one, the interval stays constant.
+// When MaxElapsedTime is set, the retrial stops once the elapsed time since the first trial exceeds this value even if the Trial count still remains.
+type Policy struct {
+	Trial          uint          `json:"trial" yaml:"trial"`
+	Interval       time.Duration `json:"interval" yaml:"interval"`
+	Multiplier     float64       `json:"multiplier" yaml:"multiplier"`
+	MaxInterval    time.Duration `json:"max_interval" yaml:"max_interval"`
+	MaxElapsedTime time.Duration `json:"max_elapsed_time" yaml:"max_elapsed_time"`
+	Jitter         Jitter        `json:"jitter" yaml:"jitter"`
+
+	// OnRetry is called when a trial fails and the next trial is scheduled.
+	// The given attempt starts from one and represents the number of the failed trial.
+	// This is handy to log each failure or to count the failures for monitoring purpose.
+	OnRetry func(attempt uint, err error, next time.Duration) `json:"-" yaml:"-"`
+
+	// Retryable is an optional predicate that tells if the given error is worth retrying.
+	// When this returns false, the retry loop stops immediately just like the error is wrapped by Permanent.
+	Retryable func(err error) bool `json:"-" yaml:"-"`
+}
+
+// permanent tells if the given error must not be retried, and returns the error to be returned to the caller.
+func (p *Policy) permanent(err error) (error, bool) {
+	var permanentErr *PermanentError
+	if errors.As(err, &permanentErr) {
+		return permanentErr.Err, true
+	}
+
+	if p.Retryable != nil && !p.Retryable(err) {
+		return err, true
+	}
+
+	return nil, false
+}
+
+// NextInterval calculates the interval to wait after the given number of failed attempts.
+// The attempt starts from one.
+func (p *Policy) NextInterval(attempt uint) time.Duration {
+	interval := float64(p.Interval)
+	if p.Multiplier > 1 && attempt > 1 {
+		interval *= math.Pow(p.Multiplier, float64(attempt-1))
+	}
+
+	if p.MaxInterval > 0 && interval > float64(p.MaxInterval) {
+		interval = float64(p.MaxInterval)
+	}
+
+	// Avoid overflow when the multiplied value gets too big without MaxInterval.
+	d := time.Duration(math.MaxInt64)
+	if interval < float64(math.MaxInt64) {
+		d = time.Duration(interval)
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	switch p.Jitter {
+	case JitterFull:
+		return time.Duration(randInt63n(int64(d)))
+
+	case JitterEqual:
+		half := d / 2
+		return half + time.Duration(randInt63n(int64(d-half)))
+
+	default:
+		return d
+
+	}
+}
+
+var (
+	random      = rand.New(rand.NewSource(time.Now().UnixNano()))
+	randomMutex sync.Mutex
+)
+
+// randInt63n returns a random number in [0,n) with the thread-safe random source.
+func randInt63n(n int64) int64 {
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+
+	return random.Int63n(n)
+}
+
+// WithPolicy calls the given function til it succeeds or the given Policy no longer allows another trial.
+// When all trials fail, the returned error is *Errors that contains each failure.
+// When the function returns an error wrapped by Permanent or an error that Policy.Retryable rejects,
+// the retrial stops immediately and the error is returned as-is without being wrapped by *Errors.
+func WithPolicy(policy *Policy, function func() error) error {
+	return WithPolicyContext(context.Background(), policy, function)
+}
+
+// WithPolicyContext is a context-aware variant of WithPolicy.
+// When the given context is canceled before a trial or while waiting for the next trial,
+// the retrial stops immediately and the context's error is returned.
+// The interval is measured with the clock.Clock carried by the given context so a test can advance the time without waiting.
+func WithPolicyContext(ctx context.Context, policy *Policy, function func() error) error {
+	c := clock.FromContext(ctx)
+	errs := &Errors{}
+	started := c.Now()
+	for i := uint(1); i <= policy.Trial; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err := function()
+		if err == nil {
+			return nil
+		}
+
+		if permanentErr, ok := policy.permanent(err); ok {
+			return permanentErr
+		}
+		errs.appendError(err)
+
+		if i == policy.Trial {
+			break
+		}
+
+		interval := policy.NextInterval(i)
+		if policy.MaxElapsedTime > 0 && c.Now().Sub(started)+interval > policy.MaxElapsedTime {
+			break
+		}
+
+		if policy.OnRetry != nil {
+			policy.OnRetry(i, err, interval)
+		}
+
+		timer := c.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+
+		case <-timer.C():
+			// Proceed to the next trial.
+
+		}
+	}
+
+	return errs
+}
+
+// Retry calls the given function til it succeeds or the trial count reaches the given number.
+func Retry(trial uint, function func() error) error {
+	return RetryInterval(trial, function, 0)
+}
+
+// RetryInterval calls the given function til it succeeds or the trial count reaches the given number.
+// Each retrial waits for the given interval.
+func RetryInterval(trial uint, function func() error, interval time.Duration) error {
+	return RetryIntervalContext(context.Background(), trial, function, interval)
+}
+
+// RetryIntervalContext is a context-aware variant of RetryInterval.
+// When the given context is canceled, the retrial stops immediately and the context's error is returned.
+func RetryIntervalContext(ctx context.Context, trial uint, function func() error, interval time.Duration) error {
+	return WithPolicyContext(ctx, &Policy{
+		Trial:    trial,
+		Interval: interval,
+	}, function)
+}
+
+// RetryBackOff calls the given function til it succeeds or the trial count reaches the given number.
+// The interval starts from the given value and doubles on each retrial with JitterFull applied.
+func RetryBackOff(trial uint, function func() error, interval time.Duration) error {
+	return WithPolicy(&Policy{
+		Trial:      trial,
+		Interval:   interval,
+		Multiplier: 2,
+		Jitter:     JitterFull,
+	}, function)
+}
@@ -0,0 +1,349 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"testing"
+	"time"
+)
+
+func TestErrors_Error(t *testing.T) {
+	errs := &Errors{}
+	errs.appendError(errors.New("first"))
+	errs.appendError(errors.New("second"))
+
+	if errs.Error() != "first\nsecond" {
+		t.Errorf("Unexpected error string is returned: %s.", errs.Error())
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Nil should be returned when nil is given.")
+	}
+
+	err := errors.New("dummy")
+	permanent := Permanent(err)
+
+	typed, ok := permanent.(*PermanentError)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", permanent)
+	}
+
+	if typed.Error() != err.Error() {
+		t.Errorf("Unexpected error string is returned: %s.", typed.Error())
+	}
+
+	if !errors.Is(permanent, err) {
+		t.Error("Given error is not wrapped.")
+	}
+}
+
+func TestPolicy_NextInterval(t *testing.T) {
+	t.Run("constant interval", func(t *testing.T) {
+		policy := &Policy{Interval: 100 * time.Millisecond}
+
+		for i := uint(1); i <= 3; i++ {
+			if policy.NextInterval(i) != 100*time.Millisecond {
+				t.Errorf("Unexpected interval is returned on %d: %s.", i, policy.NextInterval(i))
+			}
+		}
+	})
+
+	t.Run("exponential backoff", func(t *testing.T) {
+		policy := &Policy{
+			Interval:    100 * time.Millisecond,
+			Multiplier:  2,
+			MaxInterval: 300 * time.Millisecond,
+		}
+
+		expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+		for i, e := range expected {
+			interval := policy.NextInterval(uint(i + 1))
+			if interval != e {
+				t.Errorf("Expected %s on attempt %d, but was %s.", e, i+1, interval)
+			}
+		}
+	})
+
+	t.Run("full jitter", func(t *testing.T) {
+		policy := &Policy{
+			Interval: 100 * time.Millisecond,
+			Jitter:   JitterFull,
+		}
+
+		for i := 0; i < 100; i++ {
+			interval := policy.NextInterval(1)
+			if interval < 0 || interval > 100*time.Millisecond {
+				t.Fatalf("Interval is out of range: %s.", interval)
+			}
+		}
+	})
+
+	t.Run("equal jitter", func(t *testing.T) {
+		policy := &Policy{
+			Interval: 100 * time.Millisecond,
+			Jitter:   JitterEqual,
+		}
+
+		for i := 0; i < 100; i++ {
+			interval := policy.NextInterval(1)
+			if interval < 50*time.Millisecond || interval > 100*time.Millisecond {
+				t.Fatalf("Interval is out of range: %s.", interval)
+			}
+		}
+	})
+
+	t.Run("no overflow", func(t *testing.T) {
+		policy := &Policy{
+			Interval:   1 * time.Second,
+			Multiplier: 10,
+		}
+
+		if policy.NextInterval(100) <= 0 {
+			t.Errorf("Overflowed interval is returned: %s.", policy.NextInterval(100))
+		}
+	})
+}
+
+func TestWithPolicy(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		i := 0
+		err := WithPolicy(&Policy{Trial: 3}, func() error {
+			i++
+			if i < 2 {
+				return errors.New("error")
+			}
+			return nil
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if i != 2 {
+			t.Errorf("Unexpected number of trials: %d.", i)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		var attempts []uint
+		policy := &Policy{
+			Trial:    3,
+			Interval: 1 * time.Millisecond,
+			OnRetry: func(attempt uint, _ error, _ time.Duration) {
+				attempts = append(attempts, attempt)
+			},
+		}
+
+		err := WithPolicy(policy, func() error {
+			return errors.New("error")
+		})
+
+		errs, ok := err.(*Errors)
+		if !ok {
+			t.Fatalf("Unexpected error type is returned: %T.", err)
+		}
+
+		if len(*errs) != 3 {
+			t.Errorf("Unexpected number of errors: %d.", len(*errs))
+		}
+
+		if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+			t.Errorf("OnRetry is not called as expected: %v.", attempts)
+		}
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		i := 0
+		expected := errors.New("permanent")
+		err := WithPolicy(&Policy{Trial: 3}, func() error {
+			i++
+			return Permanent(expected)
+		})
+
+		if err != expected {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if i != 1 {
+			t.Errorf("Unexpected number of trials: %d.", i)
+		}
+	})
+
+	t.Run("non-retryable error", func(t *testing.T) {
+		i := 0
+		expected := errors.New("non-retryable")
+		policy := &Policy{
+			Trial: 3,
+			Retryable: func(err error) bool {
+				return err != expected
+			},
+		}
+
+		err := WithPolicy(policy, func() error {
+			i++
+			return expected
+		})
+
+		if err != expected {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if i != 1 {
+			t.Errorf("Unexpected number of trials: %d.", i)
+		}
+	})
+
+	t.Run("max elapsed time", func(t *testing.T) {
+		i := 0
+		policy := &Policy{
+			Trial:          10,
+			Interval:       50 * time.Millisecond,
+			MaxElapsedTime: 80 * time.Millisecond,
+		}
+
+		_ = WithPolicy(policy, func() error {
+			i++
+			return errors.New("error")
+		})
+
+		if i != 2 {
+			t.Errorf("Unexpected number of trials: %d.", i)
+		}
+	})
+}
+
+func TestWithPolicyContext(t *testing.T) {
+	t.Run("canceled before trial", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		called := false
+		err := WithPolicyContext(ctx, &Policy{Trial: 3}, func() error {
+			called = true
+			return nil
+		})
+
+		if err != context.Canceled {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if called {
+			t.Error("Function should not be called with canceled context.")
+		}
+	})
+
+	t.Run("canceled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		policy := &Policy{
+			Trial:    3,
+			Interval: 1 * time.Hour,
+		}
+
+		i := 0
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		err := WithPolicyContext(ctx, policy, func() error {
+			i++
+			return errors.New("error")
+		})
+
+		if err != context.Canceled {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if i != 1 {
+			t.Errorf("Unexpected number of trials: %d.", i)
+		}
+	})
+
+	t.Run("virtual time", func(t *testing.T) {
+		fake := clock.NewFake(time.Now())
+		ctx := clock.WithContext(context.Background(), fake)
+		policy := &Policy{
+			Trial:    3,
+			Interval: 1 * time.Hour,
+		}
+
+		go func() {
+			for i := 0; i < 2; i++ {
+				_ = fake.WaitTimers(context.Background(), 1)
+				fake.Advance(1 * time.Hour)
+			}
+		}()
+
+		i := 0
+		err := WithPolicyContext(ctx, policy, func() error {
+			i++
+			return errors.New("error")
+		})
+
+		if _, ok := err.(*Errors); !ok {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if i != 3 {
+			t.Errorf("Unexpected number of trials: %d.", i)
+		}
+	})
+}
+
+func TestRetryIntervalContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := RetryIntervalContext(ctx, 3, func() error {
+		return errors.New("error")
+	}, 1*time.Hour)
+
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	i := 0
+	err := Retry(3, func() error {
+		i++
+		return errors.New("error")
+	})
+
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	if i != 3 {
+		t.Errorf("Unexpected number of trials: %d.", i)
+	}
+}
+
+func TestRetryInterval(t *testing.T) {
+	started := time.Now()
+	_ = RetryInterval(3, func() error {
+		return errors.New("error")
+	}, 10*time.Millisecond)
+
+	if time.Since(started) < 20*time.Millisecond {
+		t.Errorf("Interval is not applied: %s.", time.Since(started))
+	}
+}
+
+func TestRetryBackOff(t *testing.T) {
+	i := 0
+	err := RetryBackOff(3, func() error {
+		i++
+		return nil
+	}, 10*time.Millisecond)
+
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if i != 1 {
+		t.Errorf("Unexpected number of trials: %d.", i)
+	}
+}
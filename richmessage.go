@@ -0,0 +1,149 @@
+package sarah
+
+import (
+	"strings"
+)
+
+// RichMessage is a platform-neutral representation of a formatted message.
+// A Command may return this as CommandResponse.Content instead of a string or an Adapter-specific struct,
+// and each Adapter renders the message with its native format; e.g. Slack's message attachment or gitter's markdown.
+// An Adapter that does not support a formatted message may send the result of PlainText instead.
+//
+//  return &sarah.CommandResponse{
+//  	Content: &sarah.RichMessage{
+//  		Title: "Weather forecast",
+//  		Text:  "Sunny all day long.",
+//  		Fields: []*sarah.RichField{
+//  			{Title: "High", Value: "25°C", Short: true},
+//  			{Title: "Low", Value: "16°C", Short: true},
+//  		},
+//  		Buttons: []*sarah.RichButton{
+//  			{Label: "Details", URL: "https://example.com/forecast"},
+//  		},
+//  		Color: "#36a64f",
+//  	},
+//  }, nil
+type RichMessage struct {
+	// Title is the heading of the message.
+	Title string
+
+	// TitleURL is the URL that the Title links to.
+	TitleURL string
+
+	// Text is the main body of the message.
+	Text string
+
+	// Fields are key-value pairs displayed in a table-like form where supported.
+	Fields []*RichField
+
+	// ImageURL is the URL of an image to display with the message.
+	ImageURL string
+
+	// Buttons are links or interactive buttons to display with the message.
+	Buttons []*RichButton
+
+	// Menus are interactive select menus to display with the message.
+	Menus []*RichMenu
+
+	// Color is the accent color of the message in a form of a hex color code such as "#36a64f".
+	// An Adapter ignores this when the platform has no such concept.
+	Color string
+}
+
+// RichField represents a key-value pair displayed in RichMessage.
+type RichField struct {
+	Title string
+
+	Value string
+
+	// Short tells the value is short enough to be displayed side-by-side with other short fields.
+	Short bool
+}
+
+// RichButton represents a button that opens the given URL, or an interactive button with a callback ID.
+// When the interactive button is clicked, an Adapter that supports interactive components passes CallbackInput
+// with the CallbackID and the Value to go-sarah's core. See NewCallbackCommand for the handling.
+// An Adapter without such support ignores the interactive button.
+type RichButton struct {
+	Label string
+
+	URL string
+
+	CallbackID string
+
+	Value string
+}
+
+// Interactive tells if the button is an interactive one with a callback ID.
+func (b *RichButton) Interactive() bool {
+	return b.CallbackID != ""
+}
+
+// RichMenu represents an interactive select menu.
+// When an option is selected, an Adapter that supports interactive components passes CallbackInput
+// with the CallbackID and the selected option's Value to go-sarah's core.
+// An Adapter without such support ignores the menu.
+type RichMenu struct {
+	CallbackID string
+
+	Placeholder string
+
+	Options []*RichMenuOption
+}
+
+// RichMenuOption represents an option of RichMenu.
+type RichMenuOption struct {
+	Label string
+
+	Value string
+}
+
+// Interactive tells if the message contains any interactive component that requires the Adapter's support.
+func (m *RichMessage) Interactive() bool {
+	if len(m.Menus) > 0 {
+		return true
+	}
+
+	for _, b := range m.Buttons {
+		if b.Interactive() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PlainText returns a plain text form of the message for an Adapter that supports no formatted message.
+// This is also handy for a fallback text to be displayed on notifications.
+func (m *RichMessage) PlainText() string {
+	var lines []string
+	if m.Title != "" {
+		if m.TitleURL != "" {
+			lines = append(lines, m.Title+" ("+m.TitleURL+")")
+		} else {
+			lines = append(lines, m.Title)
+		}
+	}
+
+	if m.Text != "" {
+		lines = append(lines, m.Text)
+	}
+
+	for _, f := range m.Fields {
+		lines = append(lines, f.Title+": "+f.Value)
+	}
+
+	if m.ImageURL != "" {
+		lines = append(lines, m.ImageURL)
+	}
+
+	for _, b := range m.Buttons {
+		if b.Interactive() {
+			// An interactive button can not be clicked in a plain text.
+			continue
+		}
+		lines = append(lines, b.Label+": "+b.URL)
+	}
+
+	return strings.Join(lines, "\n")
+}
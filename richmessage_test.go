@@ -0,0 +1,99 @@
+package sarah
+
+import (
+	"testing"
+)
+
+func TestRichMessage_PlainText(t *testing.T) {
+	tests := []struct {
+		message *RichMessage
+		text    string
+	}{
+		{
+			message: &RichMessage{},
+			text:    "",
+		},
+		{
+			message: &RichMessage{
+				Title: "Weather",
+				Text:  "Sunny",
+			},
+			text: "Weather\nSunny",
+		},
+		{
+			message: &RichMessage{
+				Title:    "Weather",
+				TitleURL: "https://example.com/",
+				Text:     "Sunny",
+				Fields: []*RichField{
+					{Title: "High", Value: "25", Short: true},
+					{Title: "Low", Value: "16", Short: true},
+				},
+				ImageURL: "https://example.com/sunny.png",
+				Buttons: []*RichButton{
+					{Label: "Details", URL: "https://example.com/details"},
+				},
+				Color: "#36a64f",
+			},
+			text: "Weather (https://example.com/)\nSunny\nHigh: 25\nLow: 16\nhttps://example.com/sunny.png\nDetails: https://example.com/details",
+		},
+		{
+			message: &RichMessage{
+				Text: "Deploy?",
+				Buttons: []*RichButton{
+					{Label: "Yes", CallbackID: "deploy", Value: "yes"},
+				},
+			},
+			text: "Deploy?",
+		},
+	}
+
+	for i, tt := range tests {
+		if text := tt.message.PlainText(); text != tt.text {
+			t.Errorf("Unexpected text is returned on test #%d: %s.", i, text)
+		}
+	}
+}
+
+func TestRichMessage_Interactive(t *testing.T) {
+	tests := []struct {
+		message     *RichMessage
+		interactive bool
+	}{
+		{
+			message:     &RichMessage{},
+			interactive: false,
+		},
+		{
+			message: &RichMessage{
+				Buttons: []*RichButton{
+					{Label: "Details", URL: "https://example.com/details"},
+				},
+			},
+			interactive: false,
+		},
+		{
+			message: &RichMessage{
+				Buttons: []*RichButton{
+					{Label: "Details", URL: "https://example.com/details"},
+					{Label: "Yes", CallbackID: "deploy", Value: "yes"},
+				},
+			},
+			interactive: true,
+		},
+		{
+			message: &RichMessage{
+				Menus: []*RichMenu{
+					{CallbackID: "env", Options: []*RichMenuOption{{Label: "Production", Value: "prod"}}},
+				},
+			},
+			interactive: true,
+		},
+	}
+
+	for i, tt := range tests {
+		if interactive := tt.message.Interactive(); interactive != tt.interactive {
+			t.Errorf("Unexpected value is returned on test #%d: %t.", i, interactive)
+		}
+	}
+}
@@ -3,19 +3,55 @@ package sarah
 import (
 	"context"
 	"fmt"
-	"github.com/oklahomer/go-kasumi/logger"
 	"github.com/oklahomer/go-kasumi/worker"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/tracing"
+	"github.com/oklahomer/go-sarah/v4/workers"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var options = &optionHolder{}
 
+// moduleLogger returns the Logger for this package.
+// This is called on each logging so the Logger replaced by logging.SetLogger takes effect.
+func moduleLogger() logging.Logger {
+	return logging.GetLogger().Module("sarah")
+}
+
+// contextLogger returns the Logger carried by the given context with this package's module name.
+// The context passed to each Bot carries the Logger registered via RegisterLogger.
+func contextLogger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("sarah")
+}
+
 // Config contains some basic configuration variables for go-sarah.
 type Config struct {
 	TimeZone string `json:"timezone" yaml:"timezone"`
+
+	// Worker is the configuration of the worker pool that go-sarah's core runs when no worker is given via RegisterWorker.
+	Worker *workers.Config `json:"worker" yaml:"worker"`
+
+	// Clock is the source of the current time and timers for the scheduled tasks, delayed messages and retrials.
+	// The clock.Clock returned by clock.Real is used when this is nil.
+	// Set clock.Fake in a test to run the scheduled tasks by advancing the virtual time instead of waiting.
+	// The default UserContextStorage needs UserContextStorageWithClock to share the same clock.
+	Clock clock.Clock `json:"-" yaml:"-"`
+
+	// Registry is the set of the Commands and the ScheduledTasks to run with.
+	// The default Registry that sarah.RegisterCommand and other package-level functions register to is used when this is nil.
+	Registry *Registry `json:"-" yaml:"-"`
+
+	// InputQueue is the configuration of the queue that buffers the Inputs of each Bot before they are passed to the worker.
+	// The Inputs are passed to the worker in the Bot's receiving loop when this is nil. See InputQueueConfig.
+	InputQueue *InputQueueConfig `json:"input_queue" yaml:"input_queue"`
+
+	// BotInputQueues overrides InputQueue for the Bots with the given BotTypes.
+	BotInputQueues map[BotType]*InputQueueConfig `json:"bot_input_queues" yaml:"bot_input_queues"`
 }
 
 // NewConfig creates and returns new Config instance with default settings.
@@ -23,9 +59,41 @@ type Config struct {
 func NewConfig() *Config {
 	return &Config{
 		TimeZone: time.Now().Location().String(),
+		Worker:   workers.NewConfig(),
+	}
+}
+
+var _ ConfigDefaulter = (*Config)(nil)
+var _ ConfigValidator = (*Config)(nil)
+
+// ApplyDefaults sets the local time zone and the default worker configuration when they are not set.
+func (c *Config) ApplyDefaults() {
+	if c.TimeZone == "" {
+		c.TimeZone = time.Now().Location().String()
+	}
+
+	if c.Worker == nil {
+		c.Worker = workers.NewConfig()
 	}
 }
 
+// Validate checks if TimeZone is a valid time zone name and each of BotInputQueues is valid.
+// Worker and InputQueue are validated by their own Validate methods.
+func (c *Config) Validate() error {
+	var errs ConfigKeyErrors
+	_, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		errs = append(errs, &ConfigKeyError{Key: "timezone", Value: c.TimeZone, Err: err})
+	}
+
+	errs = append(errs, validateBotInputQueues(c.BotInputQueues)...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 // optionHolder is a struct that stashes given options before go-sarah's initialization.
 // This was formally called RunnerOptions and was provided publicly, but is now private in favor of https://github.com/oklahomer/go-sarah/issues/72
 // Calls to its methods are thread-safe.
@@ -61,6 +129,7 @@ func RegisterAlerter(alerter Alerter) {
 // RegisterBot registers given sarah.Bot implementation to be run on sarah.Run().
 // This may be called multiple times to register as many bot instances as wanted.
 // When a Bot with same sarah.BotType is already registered, this returns error on sarah.Run().
+// To run multiple Bots of the same Adapter type, give each Bot a distinct instance ID with BotWithInstanceID.
 func RegisterBot(bot Bot) {
 	options.register(func(r *runner) {
 		r.bots = append(r.bots, bot)
@@ -69,49 +138,47 @@ func RegisterBot(bot Bot) {
 
 // RegisterCommand registers given sarah.Command.
 // On sarah.Run(), Commands are registered to corresponding bot via Bot.AppendCommand().
-func RegisterCommand(botType BotType, command Command) {
-	options.register(func(r *runner) {
-		commands, ok := r.commands[botType]
-		if !ok {
-			commands = []Command{}
-		}
-		r.commands[botType] = append(commands, command)
-	})
+func RegisterCommand(botType BotType, command Command, opts ...RegistrationOption) {
+	defaultRegistry.RegisterCommand(botType, command, opts...)
+}
+
+// RegisterScopedCommand registers given sarah.Command to the Bots the given sarah.BotScope selects.
+// Use this to share a common Command such as a ping command among multiple Bots.
+func RegisterScopedCommand(scope *BotScope, command Command, opts ...RegistrationOption) {
+	defaultRegistry.RegisterScopedCommand(scope, command, opts...)
 }
 
 // RegisterCommandProps registers given sarah.CommandProps to build sarah.Command on sarah.Run().
 // This props is re-used when configuration file is updated and a corresponding sarah.Command needs to be re-built.
-func RegisterCommandProps(props *CommandProps) {
-	options.register(func(r *runner) {
-		stashed, ok := r.commandProps[props.botType]
-		if !ok {
-			stashed = []*CommandProps{}
-		}
-		r.commandProps[props.botType] = append(stashed, props)
-	})
+// When the props is built with CommandPropsBuilder.Scope, a sarah.Command is built for each Bot the scope selects.
+func RegisterCommandProps(props *CommandProps, opts ...RegistrationOption) {
+	defaultRegistry.RegisterCommandProps(props, opts...)
 }
 
 // RegisterScheduledTask registers given sarah.ScheduledTask.
 // On sarah.Run(), schedule is set for this task.
-func RegisterScheduledTask(botType BotType, task ScheduledTask) {
-	options.register(func(r *runner) {
-		tasks, ok := r.scheduledTasks[botType]
-		if !ok {
-			tasks = []ScheduledTask{}
-		}
-		r.scheduledTasks[botType] = append(tasks, task)
-	})
+func RegisterScheduledTask(botType BotType, task ScheduledTask, opts ...RegistrationOption) {
+	defaultRegistry.RegisterScheduledTask(botType, task, opts...)
+}
+
+// RegisterScopedScheduledTask registers given sarah.ScheduledTask to be scheduled for the Bots the given sarah.BotScope selects.
+func RegisterScopedScheduledTask(scope *BotScope, task ScheduledTask, opts ...RegistrationOption) {
+	defaultRegistry.RegisterScopedScheduledTask(scope, task, opts...)
 }
 
 // RegisterScheduledTaskProps registers given sarah.ScheduledTaskProps to build sarah.ScheduledTask on sarah.Run().
 // This props is re-used when configuration file is updated and a corresponding sarah.ScheduledTask needs to be re-built.
-func RegisterScheduledTaskProps(props *ScheduledTaskProps) {
+// When the props is built with ScheduledTaskPropsBuilder.Scope, a sarah.ScheduledTask is built for each Bot the scope selects.
+func RegisterScheduledTaskProps(props *ScheduledTaskProps, opts ...RegistrationOption) {
+	defaultRegistry.RegisterScheduledTaskProps(props, opts...)
+}
+
+// RegisterBotGroup defines a named group of Bots so sarah.BotGroup can select them.
+// This may be called multiple times with the same name to add more members.
+// A base BotType such as "slack" makes all of its instances members of the group.
+func RegisterBotGroup(name string, botTypes ...BotType) {
 	options.register(func(r *runner) {
-		stashed, ok := r.scheduledTaskProps[props.botType]
-		if !ok {
-			stashed = []*ScheduledTaskProps{}
-		}
-		r.scheduledTaskProps[props.botType] = append(stashed, props)
+		r.botGroups[name] = append(r.botGroups[name], botTypes...)
 	})
 }
 
@@ -122,14 +189,180 @@ func RegisterConfigWatcher(watcher ConfigWatcher) {
 	})
 }
 
-// RegisterWorker registers given workers.Worker implementation.
+// RegisterSecretResolver registers given SecretResolver implementation.
+// The configurations of CommandProps and ScheduledTaskProps are passed to ResolveSecrets every time ConfigWatcher reads them,
+// so a Secret field can hold a reference such as "vault:secret/data/sarah#api_key" and a rotated secret is picked up on the configuration update.
+func RegisterSecretResolver(resolver SecretResolver) {
+	options.register(func(r *runner) {
+		r.secretResolver = resolver
+	})
+}
+
+// RegisterWorker registers given worker.Worker implementation.
 // When this is not called, a worker instance with default setting is used.
+// An instance returned by workers.Run() can be passed to customize the worker pool's behavior.
 func RegisterWorker(worker worker.Worker) {
 	options.register(func(r *runner) {
 		r.worker = worker
 	})
 }
 
+// RegisterInputKeyFunc registers a function that returns a key of the given Input to serialize the Input handling.
+// Inputs with the same key are handled one at a time in the received order while inputs with different keys are still handled concurrently.
+// This requires the registered worker to support workers.Job; the default worker does.
+//
+// A typical key is the combination of the BotType and the destination to reply to, so messages in the same conversation are handled in order:
+//
+//  sarah.RegisterInputKeyFunc(func(input sarah.Input) string {
+//    return fmt.Sprintf("%s:%s", botType, input.ReplyTo())
+//  })
+//
+// When the function returns an empty string, the Input is handled without serialization.
+func RegisterInputKeyFunc(fnc func(Input) string) {
+	options.register(func(r *runner) {
+		r.inputKey = fnc
+	})
+}
+
+// RegisterInputFilter registers an InputFilter that inspects each Input before the Input is handled.
+// Multiple InputFilters can be registered and are applied in the registration order til one of them drops the Input.
+// The InputFilter is applied before the Input is queued, so spam does not occupy the workers.
+//
+//  filter, err := inputfilter.New(inputfilter.NewConfig())
+//  if err != nil {
+//  	panic(err)
+//  }
+//  sarah.RegisterInputFilter(filter)
+func RegisterInputFilter(filter InputFilter) {
+	options.register(func(r *runner) {
+		r.inputFilters = append(r.inputFilters, filter)
+	})
+}
+
+// RegisterLogger registers a logging.Logger that this Runner and its belonging components log to.
+// When this is not called, the package-level Logger returned by logging.GetLogger() is used.
+//
+// The Logger is passed to the default worker and is carried by the context given to Bot.Run() with the BotType attached as a field.
+// A Bot or Adapter implementation can obtain the Logger with logging.FromContext() to log to the same sink.
+func RegisterLogger(logger logging.Logger) {
+	options.register(func(r *runner) {
+		r.logger = logger
+	})
+}
+
+// RegisterTracer registers a tracing.Tracer that this Runner and its belonging components trace with.
+// When this is not called, the package-level Tracer returned by tracing.GetTracer() is used, which is a no-op one by default.
+//
+// The Tracer is carried by the context given to Bot.Run() so a Bot or Adapter implementation can start spans with tracing.Start().
+// See the tracing package for the spans go-sarah's core starts and for how to bridge to OpenTelemetry.
+func RegisterTracer(tracer tracing.Tracer) {
+	options.register(func(r *runner) {
+		r.tracer = tracer
+	})
+}
+
+// RegisterEventBus registers an EventBus that this Runner publishes its lifecycle events to.
+// When this is not called, a new one created by NewEventBus() is used.
+// Register a custom implementation to, for example, deliver the events asynchronously or to an external message broker.
+func RegisterEventBus(bus EventBus) {
+	options.register(func(r *runner) {
+		r.eventBus = bus
+	})
+}
+
+// RegisterEventSubscriber registers a function that receives the events published by this Runner and its belonging components.
+// See Event for the available events.
+//
+// This is handy to build custom monitoring, analytics or reactive behavior without modifying go-sarah's core.
+// The function is called in the goroutine that publishes the event, so it must return quickly.
+func RegisterEventSubscriber(fnc func(Event)) {
+	options.register(func(r *runner) {
+		r.eventSubscribers = append(r.eventSubscribers, fnc)
+	})
+}
+
+// RegisterQuietHours registers the QuietHours of the Bot with the given BotType and its destinations.
+// During the QuietHours of a destination, the ScheduledTaskResults for the destination are held and sent when the QuietHours end,
+// unless ScheduledTaskResult.Urgent is true.
+//
+//  config := sarah.NewQuietHoursConfig()
+//  config.Default = &sarah.QuietHours{Start: "22:00", End: "08:00", TimeZone: "Asia/Tokyo"}
+//  config.Destinations["C12345678"] = &sarah.QuietHours{Start: "18:00", End: "09:00", TimeZone: "America/New_York"}
+//  sarah.RegisterQuietHours(slack.SLACK, config)
+//
+// Call QuietHoursConfig.Validate beforehand since an invalid QuietHours holds no result.
+// The held results live only in memory just like the messages sent by SendMessageAt.
+func RegisterQuietHours(botType BotType, config *QuietHoursConfig) {
+	options.register(func(r *runner) {
+		if r.quietHours == nil {
+			r.quietHours = make(map[BotType]*QuietHoursConfig)
+		}
+		r.quietHours[botType] = config
+	})
+}
+
+// RegisterStore registers a Store that plugins use to persist their states.
+// The Store is carried by the context given to Bot.Run(), Command.Execute and ScheduledTask's function,
+// and a plugin obtains the Store namespaced by the BotType and its identifier with StoreFromContext.
+func RegisterStore(store Store) {
+	options.register(func(r *runner) {
+		r.store = store
+	})
+}
+
+// RegisterIdentityResolver registers an IdentityResolver.
+// With this, ResolveIdentity tells the person of a user and the UserProfile of a linked user is shared by all linked accounts.
+// The UserProfiles of the persons are stored in the Store registered with RegisterStore.
+func RegisterIdentityResolver(resolver IdentityResolver) {
+	options.register(func(r *runner) {
+		r.identityResolver = resolver
+	})
+}
+
+// RegisterDeadLetterQueue lets go-sarah keep the Inputs that Bots fail to respond to, instead of only logging the errors.
+// Up to the given number of the latest DeadLetters are kept, and administrators can inspect them with ListDeadLetters
+// and replay them with ReplayDeadLetter after fixing the cause. The admin package provides the sub-commands to do so from a chat.
+//
+// The DeadLetters live only in memory since an Input is not always serializable.
+func RegisterDeadLetterQueue(size int) {
+	options.register(func(r *runner) {
+		r.deadLetterSize = size
+	})
+}
+
+// RegisterConfigHistory lets go-sarah keep up to the given number of the latest configuration generations
+// for each Command and ScheduledTask built from CommandProps and ScheduledTaskProps.
+// When a bad configuration is pushed, administrators can inspect the generations with CommandConfigHistory and ScheduledTaskConfigHistory
+// and revert to a working one with RollbackCommandConfig and RollbackScheduledTaskConfig without touching the configuration files.
+// The admin package provides the sub-commands to do so from a chat.
+//
+// Regardless of this setting, the latest generation is kept so ConfigReloaded can tell the changes.
+// The generations live only in memory and are gone when the Bot stops.
+func RegisterConfigHistory(size int) {
+	options.register(func(r *runner) {
+		r.configHistorySize = size
+	})
+}
+
+// RegisterDryRun lets the Runner run in dry-run mode.
+// The Runner sets up the Bots, the Commands and the scheduled tasks as usual and the Bots receive the Inputs from the chat services,
+// but the default Bot implementation passes the messages to the given DryRunRecorder instead of sending them via its Adapter.
+// Each message is also logged with the BotType and the destination, so the results of the scheduled tasks can be reviewed in the logs.
+// The given DryRunRecorder can be nil to only log the messages.
+//
+// This is handy to try new configuration files against the production-like settings on a staging environment.
+//
+//  recorder := sarah.NewBufferedDryRunRecorder(100)
+//  sarah.RegisterDryRun(recorder)
+//
+// MessageSent is still published as if the messages are sent.
+// The messages sent by a plugin through an Adapter-specific feature are not covered.
+func RegisterDryRun(recorder DryRunRecorder) {
+	options.register(func(r *runner) {
+		r.dryRun = &dryRun{recorder: recorder}
+	})
+}
+
 // RegisterBotErrorSupervisor registers a given supervising function that is called when a Bot escalates an error.
 // This function judges if the given error is worth being notified to administrators and if the Bot should stop.
 // A developer may return *SupervisionDirective to tell such order.
@@ -160,6 +393,7 @@ func RegisterBotErrorSupervisor(fnc func(BotType, error) *SupervisionDirective)
 // Run is a non-blocking function that starts running go-sarah's process with pre-registered options.
 // Workers, schedulers and other required resources for bot interaction starts running on this function call.
 // This returns error when bot interaction cannot start; No error is returned when process starts successfully.
+// The given Config is validated with ValidateConfig beforehand, so all invalid values are reported at once.
 //
 // Refer to ctx.Done() or sarah.CurrentStatus() to reference current running status.
 //
@@ -168,7 +402,12 @@ func RegisterBotErrorSupervisor(fnc func(BotType, error) *SupervisionDirective)
 // the critical state is notified to administrators via registered sarah.Alerter.
 // This is recommended to register multiple sarah.Alerter implementations to make sure critical states are notified.
 func Run(ctx context.Context, config *Config) error {
-	err := runnerStatus.start()
+	err := ValidateConfig(config)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	err = runnerStatus.start()
 	if err != nil {
 		return fmt.Errorf("failed to start bot process: %w", err)
 	}
@@ -177,64 +416,157 @@ func Run(ctx context.Context, config *Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to start bot process: %w", err)
 	}
+	runner.registerHealthChecks()
+	runnerStatus.components.enableDeadLetters(runner.deadLetterSize)
+	runnerStatus.components.enableConfigHistory(runner.configHistorySize)
 	go runner.run(ctx)
 
 	return nil
 }
 
 func newRunner(ctx context.Context, config *Config) (*runner, error) {
-	loc, err := time.LoadLocation(config.TimeZone)
+	r, loc, err := prepareRunner(config)
 	if err != nil {
-		return nil, fmt.Errorf(`given timezone "%s" cannot be converted to time.Location: %w`, config.TimeZone, err)
+		return nil, err
 	}
+	r.scheduler = runScheduler(ctx, loc, r.clock)
 
-	r := &runner{
-		config:             config,
-		bots:               []Bot{},
-		worker:             nil,
-		configWatcher:      &nullConfigWatcher{},
-		commands:           make(map[BotType][]Command),
-		commandProps:       make(map[BotType][]*CommandProps),
-		scheduledTasks:     make(map[BotType][]ScheduledTask),
-		scheduledTaskProps: make(map[BotType][]*ScheduledTaskProps),
-		alerters:           &alerters{},
-		scheduler:          runScheduler(ctx, loc),
-		superviseError:     nil,
+	if r.eventBus == nil {
+		r.eventBus = NewEventBus()
+	}
+	for _, fnc := range r.eventSubscribers {
+		r.eventBus.Subscribe(fnc)
 	}
-
-	options.apply(r)
 
 	if r.worker == nil {
 		// When the jobs are CPU-intensive, the number of workers can be equal to the number of CPUs.
 		// However, in general, bot interaction involves more IO-intensive jobs such as calling an external Weather API
-		// on user request. With such a premise, this setting expects up to a hundred jobs can work concurrently.
+		// on user request. With such a premise, workers.NewConfig() expects up to a hundred jobs can work concurrently.
 		//
 		// The queue size is set to ten, which is relatively small.
-		// Instead of having a bigger queue size to allow more latency, messages will soon be ignored when the worker is busy.
+		// Instead of having a bigger queue size to allow more latency, messages will soon be rejected when the worker is busy.
 		// Users usually do not expect to have belated responses.
 		//
-		// To customize the setting, provide a worker.Worker implementation with RegisterWorker().
-		workerConfig := worker.NewConfig()
-		workerConfig.WorkerNum = 100
-		workerConfig.QueueSize = 10
-		r.worker = worker.Run(ctx, worker.NewConfig())
+		// To customize the setting, set Config.Worker or provide a worker.Worker implementation with RegisterWorker().
+		// workers.Run() is a handy way to build one with a different workers.Config including its overflow policy.
+		// Config.Worker is never nil here since Run applies Config.ApplyDefaults via ValidateConfig beforehand.
+		var workerOptions []workers.WorkerOption
+		if r.logger != nil {
+			workerOptions = append(workerOptions, workers.WithLogger(r.logger))
+		}
+		r.worker, err = workers.Run(ctx, config.Worker, workerOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run default worker: %w", err)
+		}
 	}
 
 	return r, nil
 }
 
+// prepareRunner builds a runner with the registered options without starting any of its components.
+// The scheduler, the EventBus and the worker are set up by newRunner.
+func prepareRunner(config *Config) (*runner, *time.Location, error) {
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`given timezone "%s" cannot be converted to time.Location: %w`, config.TimeZone, err)
+	}
+
+	c := config.Clock
+	if c == nil {
+		c = clock.Real()
+	}
+
+	r := &runner{
+		config:                   config,
+		bots:                     []Bot{},
+		worker:                   nil,
+		configWatcher:            &nullConfigWatcher{},
+		secretResolver:           nil,
+		commands:                 make(map[BotType][]Command),
+		commandProps:             make(map[BotType][]*CommandProps),
+		scheduledTasks:           make(map[BotType][]ScheduledTask),
+		scheduledTaskProps:       make(map[BotType][]*ScheduledTaskProps),
+		botGroups:                make(map[string][]BotType),
+		scopedCommands:           nil,
+		scopedCommandProps:       nil,
+		scopedScheduledTasks:     nil,
+		scopedScheduledTaskProps: nil,
+		alerters:                 &alerters{},
+		clock:                    c,
+		scheduler:                nil,
+		superviseError:           nil,
+		inputKey:                 nil,
+		inputFilters:             nil,
+		quietHours:               nil,
+		logger:                   nil,
+		tracer:                   nil,
+		eventBus:                 nil,
+		eventSubscribers:         nil,
+	}
+
+	options.apply(r)
+	if config.Registry != nil {
+		config.Registry.apply(r)
+	} else {
+		defaultRegistry.apply(r)
+	}
+
+	registered := map[BotType]bool{}
+	for _, bot := range r.bots {
+		if registered[bot.BotType()] {
+			return nil, nil, fmt.Errorf("duplicated BotType is registered: %s; give each bot a distinct instance ID with BotWithInstanceID", bot.BotType())
+		}
+		registered[bot.BotType()] = true
+	}
+
+	err = r.resolveRegistrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.secretResolver != nil {
+		r.configWatcher = &secretResolvingWatcher{
+			ConfigWatcher: r.configWatcher,
+			resolver:      r.secretResolver,
+		}
+	}
+
+	return r, loc, nil
+}
+
 type runner struct {
-	config             *Config
-	bots               []Bot
-	worker             worker.Worker
-	configWatcher      ConfigWatcher
-	commands           map[BotType][]Command
-	commandProps       map[BotType][]*CommandProps
-	scheduledTasks     map[BotType][]ScheduledTask
-	scheduledTaskProps map[BotType][]*ScheduledTaskProps
-	alerters           *alerters
-	scheduler          scheduler
-	superviseError     func(BotType, error) *SupervisionDirective
+	config                   *Config
+	bots                     []Bot
+	worker                   worker.Worker
+	configWatcher            ConfigWatcher
+	secretResolver           SecretResolver
+	commands                 map[BotType][]Command
+	commandProps             map[BotType][]*CommandProps
+	scheduledTasks           map[BotType][]ScheduledTask
+	scheduledTaskProps       map[BotType][]*ScheduledTaskProps
+	botGroups                map[string][]BotType
+	scopedCommands           []*scopedCommand
+	scopedCommandProps       []*CommandProps
+	scopedScheduledTasks     []*scopedScheduledTask
+	scopedScheduledTaskProps []*ScheduledTaskProps
+	alerters                 *alerters
+	clock                    clock.Clock
+	scheduler                scheduler
+	superviseError           func(BotType, error) *SupervisionDirective
+	inputKey                 func(Input) string
+	inputFilters             []InputFilter
+	quietHours               map[BotType]*QuietHoursConfig
+	logger                   logging.Logger
+	tracer                   tracing.Tracer
+	eventBus                 EventBus
+	eventSubscribers         []func(Event)
+	store                    Store
+	identityResolver         IdentityResolver
+	deadLetterSize           int
+	configHistorySize        int
+	dryRun                   *dryRun
+	registrations            []*registration
+	winners                  map[BotType]map[string]*registration
 }
 
 // SupervisionDirective tells go-sarah's core how to react when a Bot escalates an error.
@@ -251,32 +583,100 @@ type SupervisionDirective struct {
 	AlertingErr error
 }
 
+// botCommands returns the Commands for the given BotType.
+// The Commands registered with a BotScope that selects the Bot come first.
+// For a Bot instance, the Commands registered with the base BotType are shared and the ones registered with the instance's BotType follow.
 func (r *runner) botCommands(botType BotType) []Command {
-	if commands, ok := r.commands[botType]; ok {
-		return commands
+	commands := []Command{}
+	for _, c := range r.scopedCommands {
+		if c.scope.includes(botType, r.botGroups) {
+			commands = append(commands, c.command)
+		}
 	}
-	return []Command{}
+	if botType.InstanceID() != "" {
+		commands = append(commands, r.commands[botType.Base()]...)
+	}
+	commands = append(commands, r.commands[botType]...)
+
+	// Drop the ones replaced by a registration with Override.
+	filtered := commands[:0]
+	for _, c := range commands {
+		if !r.replaced(botType, kindCommand, c.Identifier(), c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
 }
 
+// botCommandProps returns the CommandProps for the given BotType.
+// The CommandProps registered with a BotScope and those registered with the base BotType for a Bot instance are copied
+// so each Bot reads its own configuration.
 func (r *runner) botCommandProps(botType BotType) []*CommandProps {
-	if props, ok := r.commandProps[botType]; ok {
-		return props
+	props := []*CommandProps{}
+	for _, p := range r.scopedCommandProps {
+		if p.scope.includes(botType, r.botGroups) && !r.replaced(botType, kindCommand, p.identifier, p) {
+			props = append(props, instanceCommandProps(p, botType))
+		}
+	}
+	if botType.InstanceID() != "" {
+		for _, p := range r.commandProps[botType.Base()] {
+			if !r.replaced(botType, kindCommand, p.identifier, p) {
+				props = append(props, instanceCommandProps(p, botType))
+			}
+		}
+	}
+	for _, p := range r.commandProps[botType] {
+		if !r.replaced(botType, kindCommand, p.identifier, p) {
+			props = append(props, p)
+		}
 	}
-	return []*CommandProps{}
+	return props
 }
 
+// botScheduledTaskProps returns the ScheduledTaskProps for the given BotType just like botCommandProps does.
 func (r *runner) botScheduledTaskProps(botType BotType) []*ScheduledTaskProps {
-	if props, ok := r.scheduledTaskProps[botType]; ok {
-		return props
+	props := []*ScheduledTaskProps{}
+	for _, p := range r.scopedScheduledTaskProps {
+		if p.scope.includes(botType, r.botGroups) && !r.replaced(botType, kindScheduledTask, p.identifier, p) {
+			props = append(props, instanceScheduledTaskProps(p, botType))
+		}
 	}
-	return []*ScheduledTaskProps{}
+	if botType.InstanceID() != "" {
+		for _, p := range r.scheduledTaskProps[botType.Base()] {
+			if !r.replaced(botType, kindScheduledTask, p.identifier, p) {
+				props = append(props, instanceScheduledTaskProps(p, botType))
+			}
+		}
+	}
+	for _, p := range r.scheduledTaskProps[botType] {
+		if !r.replaced(botType, kindScheduledTask, p.identifier, p) {
+			props = append(props, p)
+		}
+	}
+	return props
 }
 
+// botScheduledTasks returns the ScheduledTasks for the given BotType just like botCommands does.
+// A ScheduledTask registered with the base BotType is scheduled for each instance.
 func (r *runner) botScheduledTasks(botType BotType) []ScheduledTask {
-	if tasks, ok := r.scheduledTasks[botType]; ok {
-		return tasks
+	tasks := []ScheduledTask{}
+	for _, t := range r.scopedScheduledTasks {
+		if t.scope.includes(botType, r.botGroups) {
+			tasks = append(tasks, t.task)
+		}
 	}
-	return []ScheduledTask{}
+	if botType.InstanceID() != "" {
+		tasks = append(tasks, r.scheduledTasks[botType.Base()]...)
+	}
+	tasks = append(tasks, r.scheduledTasks[botType]...)
+
+	filtered := tasks[:0]
+	for _, t := range tasks {
+		if !r.replaced(botType, kindScheduledTask, t.Identifier(), t) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
 }
 
 func (r *runner) run(ctx context.Context) {
@@ -298,23 +698,49 @@ func (r *runner) run(ctx context.Context) {
 	wg.Wait()
 }
 
-func unsubscribeConfigWatcher(watcher ConfigWatcher, botType BotType) {
+// registerHealthChecks registers the components that satisfy HealthChecker so CheckHealth can call them.
+func (r *runner) registerHealthChecks() {
+	if checker, ok := r.worker.(HealthChecker); ok {
+		runnerStatus.healthChecks.add("worker", checker.HealthCheck)
+	}
+
+	if checker, ok := r.scheduler.(HealthChecker); ok {
+		runnerStatus.healthChecks.add("scheduler", checker.HealthCheck)
+	}
+
+	for _, bot := range r.bots {
+		if checker, ok := bot.(HealthChecker); ok {
+			runnerStatus.healthChecks.add("bot:"+bot.BotType().String()+":health", checker.HealthCheck)
+		}
+	}
+}
+
+func unsubscribeConfigWatcher(botCtx context.Context, watcher ConfigWatcher, botType BotType) {
+	log := contextLogger(botCtx).With(logging.F(logging.KeyBotType, botType))
 	defer func() {
 		if r := recover(); r != nil {
-			logger.Errorf("Failed to unsubscribe ConfigWatcher for %s: %+v", botType, r)
+			log.Error("Failed to unsubscribe ConfigWatcher", logging.F("panic", r))
 		}
 	}()
 	err := watcher.Unwatch(botType)
 	if err != nil {
-		logger.Errorf("Failed to unsubscribe ConfigWatcher for %s: %+v", botType, err)
+		log.Error("Failed to unsubscribe ConfigWatcher", logging.Err(err))
 	}
 }
 
+// baseLogger returns the Logger registered via RegisterLogger, or the package-level Logger when none is registered.
+func (r *runner) baseLogger() logging.Logger {
+	if r.logger != nil {
+		return r.logger
+	}
+	return logging.GetLogger()
+}
+
 // runBot runs given Bot implementation in a blocking manner.
 // This returns when bot stops.
 func (r *runner) runBot(runnerCtx context.Context, bot Bot) {
-	logger.Infof("Starting %s", bot.BotType())
 	botCtx, errNotifier := r.superviseBot(runnerCtx, bot.BotType())
+	contextLogger(botCtx).Info("Starting bot")
 
 	// Build commands with stashed CommandProps.
 	r.registerCommands(botCtx, bot)
@@ -322,7 +748,28 @@ func (r *runner) runBot(runnerCtx context.Context, bot Bot) {
 	// Register scheduled tasks.
 	r.registerScheduledTasks(botCtx, bot)
 
-	inputReceiver := setupInputReceiver(botCtx, bot, r.worker)
+	runnerStatus.components.sender(bot.BotType(), func(output Output) error {
+		_, err := bot.SendMessage(botCtx, output)
+		return err
+	})
+
+	inputReceiver := setupInputReceiver(botCtx, bot, r.worker, r.inputKey)
+	inputReceiver = r.queueInputs(botCtx, bot.BotType(), inputReceiver)
+	inputReceiver = filterInputs(botCtx, bot.BotType(), r.inputFilters, inputReceiver)
+
+	// Let EndMaintenance handle the Inputs queued during maintenance.
+	runnerStatus.components.receiver(bot.BotType(), func(input Input) {
+		err := inputReceiver(input)
+		if err != nil {
+			contextLogger(botCtx).Error("Failed to handle queued input", logging.F("sender_key", input.SenderKey()), logging.Err(err))
+		}
+	})
+
+	PublishEvent(botCtx, &BotStarted{BotType: bot.BotType(), Time: time.Now()})
+	defer func() {
+		runnerStatus.components.removeBot(bot.BotType())
+		PublishEvent(botCtx, &BotStopped{BotType: bot.BotType(), Time: time.Now()})
+	}()
 
 	// Run Bot in a panic-proof manner
 	func() {
@@ -350,24 +797,59 @@ func (r *runner) runBot(runnerCtx context.Context, bot Bot) {
 			errNotifier(NewBotNonContinuableError(fmt.Sprintf("shutdown bot: %s", bot.BotType())))
 		}()
 
-		bot.Run(botCtx, inputReceiver, errNotifier)
-		unsubscribeConfigWatcher(r.configWatcher, bot.BotType())
+		// Let PauseBot stop handling the Inputs without closing the connection.
+		bot.Run(botCtx, pausable(bot.BotType(), inputReceiver), errNotifier)
+		unsubscribeConfigWatcher(botCtx, r.configWatcher, bot.BotType())
 	}()
 }
 
 func (r *runner) superviseBot(runnerCtx context.Context, botType BotType) (context.Context, func(error)) {
 	botCtx, cancel := context.WithCancel(runnerCtx)
 
+	// Let the Bot and its belonging components log with the BotType.
+	botLogger := r.baseLogger().With(logging.F(logging.KeyBotType, botType))
+	botCtx = logging.NewContext(botCtx, botLogger)
+	botCtx = WithBotType(botCtx, botType)
+	if r.tracer != nil {
+		botCtx = tracing.NewContext(botCtx, r.tracer)
+	}
+	if r.eventBus != nil {
+		botCtx = NewEventBusContext(botCtx, r.eventBus)
+	}
+	if r.clock != nil {
+		botCtx = clock.WithContext(botCtx, r.clock)
+	}
+	if r.scheduler != nil {
+		botCtx = withScheduler(botCtx, r.scheduler)
+	}
+	if config, ok := r.quietHours[botType]; ok {
+		botCtx = withQuietHours(botCtx, config)
+	}
+	if r.dryRun != nil {
+		botCtx = withDryRun(botCtx, r.dryRun)
+	}
+	if r.store != nil {
+		botCtx = WithStore(botCtx, NewNamespacedStore(r.store, botType.String()))
+	}
+	if r.identityResolver != nil {
+		botCtx = withIdentity(botCtx, &identity{
+			botType:  botType,
+			resolver: r.identityResolver,
+			store:    r.store,
+		})
+	}
+	log := botLogger.Module("sarah")
+
 	sendAlert := func(err error) {
 		e := r.alerters.alertAll(runnerCtx, botType, err)
 		if e != nil {
-			logger.Errorf("Failed to send alert for %s: %+v", botType, e)
+			log.Error("Failed to send alert", logging.Err(e))
 		}
 	}
 
 	stopBot := func() {
 		cancel()
-		logger.Infof("Stop supervising bot's critical error due to its context cancellation: %s.", botType)
+		log.Info("Stop supervising bot's critical error due to its context cancellation")
 	}
 
 	// A function that receives an escalated error from Bot.
@@ -376,7 +858,7 @@ func (r *runner) superviseBot(runnerCtx context.Context, botType BotType) (conte
 	handleError := func(err error) {
 		switch err.(type) {
 		case *BotNonContinuableError:
-			logger.Errorf("Stop unrecoverable bot. BotType: %s. Error: %+v", botType, err)
+			log.Error("Stop unrecoverable bot", logging.Err(err))
 
 			stopBot()
 
@@ -390,7 +872,7 @@ func (r *runner) superviseBot(runnerCtx context.Context, botType BotType) (conte
 				}
 
 				if directive.StopBot {
-					logger.Errorf("Stop bot due to given directive. BotType: %s. Reason: %+v", botType, err)
+					log.Error("Stop bot due to given directive", logging.Err(err))
 					stopBot()
 				}
 
@@ -421,95 +903,169 @@ func (r *runner) superviseBot(runnerCtx context.Context, botType BotType) (conte
 
 func (r *runner) registerCommands(botCtx context.Context, bot Bot) {
 	props := r.botCommandProps(bot.BotType())
+	log := contextLogger(botCtx).With(logging.F(logging.KeyBotType, bot.BotType()))
 
-	reg := func(p *CommandProps) {
-		command, err := buildCommand(botCtx, p, r.configWatcher)
+	// reg builds the command with the configuration the given watcher reads, and records the configuration as a new generation.
+	reg := func(p *CommandProps, watcher ConfigWatcher, rollbackOf int) (int, []*ConfigChange, error) {
+		command, err := buildCommand(botCtx, p, watcher)
 		if err != nil {
-			logger.Errorf("Failed to build command %#v: %+v", p, err)
-			return
+			log.Error("Failed to build command", logging.F(logging.KeyCommandID, p.identifier), logging.Err(err))
+			return 0, nil, err
+		}
+		bot.AppendCommand(runnerStatus.components.command(bot.BotType(), command))
+		generation, changes := runnerStatus.components.recordConfig(bot.BotType(), kindCommand, p.identifier, commandConfig(command), rollbackOf)
+		return generation, changes, nil
+	}
+
+	reload := func(p *CommandProps) func() error {
+		return func() error {
+			log.Info("Updating command", logging.F(logging.KeyCommandID, p.identifier))
+			generation, changes, err := reg(p, r.configWatcher, 0)
+			PublishEvent(botCtx, &ConfigReloaded{BotType: bot.BotType(), ID: p.identifier, Generation: generation, Changes: changes, Err: err, Time: time.Now()})
+			return err
 		}
-		bot.AppendCommand(command)
 	}
 
-	callback := func(p *CommandProps) func() {
-		return func() {
-			logger.Infof("Updating command: %s", p.identifier)
-			reg(p)
+	rollback := func(p *CommandProps) func(*ConfigGeneration) error {
+		return func(g *ConfigGeneration) error {
+			log.Info("Rolling back command configuration", logging.F(logging.KeyCommandID, p.identifier), logging.F("generation", g.Generation))
+			generation, changes, err := reg(p, &generationWatcher{generation: g}, g.Generation)
+			PublishEvent(botCtx, &ConfigReloaded{BotType: bot.BotType(), ID: p.identifier, Generation: generation, Changes: changes, Err: err, Time: time.Now()})
+			return err
 		}
 	}
 
 	for _, p := range props {
-		reg(p)
-		err := r.configWatcher.Watch(botCtx, bot.BotType(), p.identifier, callback(p))
+		_, _, _ = reg(p, r.configWatcher, 0)
+		runnerStatus.components.configRollback(bot.BotType(), kindCommand, p.identifier, rollback(p))
+		// ReloadConfigs can rebuild the command even if the configuration is not watched.
+		fnc := reload(p)
+		runnerStatus.components.reloader(bot.BotType(), "command", p.identifier, fnc)
+		err := r.configWatcher.Watch(botCtx, bot.BotType(), p.identifier, func() { _ = fnc() })
 		if err != nil {
-			logger.Errorf("Failed to subscribe configuration for command %s: %+v", p.identifier, err)
+			log.Error("Failed to subscribe configuration for command", logging.F(logging.KeyCommandID, p.identifier), logging.Err(err))
 			continue
 		}
 	}
 
 	for _, command := range r.botCommands(bot.BotType()) {
-		bot.AppendCommand(command)
+		bot.AppendCommand(runnerStatus.components.command(bot.BotType(), command))
 	}
 }
 
 func (r *runner) registerScheduledTasks(botCtx context.Context, bot Bot) {
-	reg := func(p *ScheduledTaskProps) {
-		r.scheduler.remove(bot.BotType(), p.identifier)
+	log := contextLogger(botCtx).With(logging.F(logging.KeyBotType, bot.BotType()))
+	schedule := func(task ScheduledTask) error {
+		fn := func() {
+			executeScheduledTask(botCtx, bot, task)
+		}
+		scheduled := func() {
+			if runnerStatus.components.tasksPaused(bot.BotType()) {
+				log.Info("Skip scheduled task during maintenance", logging.F(logging.KeyTaskID, task.Identifier()))
+				return
+			}
+			fn()
+		}
+		err := r.scheduler.update(bot.BotType(), task, scheduled)
+		if err != nil {
+			log.Error("Failed to schedule a task", logging.F(logging.KeyTaskID, task.Identifier()), logging.Err(err))
+			return err
+		}
+
+		next := func() time.Time {
+			return r.scheduler.next(bot.BotType(), task.Identifier())
+		}
+		runnerStatus.components.scheduledTask(bot.BotType(), task, fn, next)
+		return nil
+	}
+
+	unschedule := func(id string) {
+		r.scheduler.remove(bot.BotType(), id)
+		runnerStatus.components.removeScheduledTask(bot.BotType(), id)
+	}
 
-		task, err := buildScheduledTask(botCtx, p, r.configWatcher)
+	// reg builds and schedules the task with the configuration the given watcher reads, and records the configuration as a new generation.
+	reg := func(p *ScheduledTaskProps, watcher ConfigWatcher, rollbackOf int) (int, []*ConfigChange, error) {
+		unschedule(p.identifier)
+
+		task, err := buildScheduledTask(botCtx, p, watcher)
 		if err != nil {
-			logger.Errorf("Failed to build scheduled task %s: %+v", p.identifier, err)
-			return
+			log.Error("Failed to build scheduled task", logging.F(logging.KeyTaskID, p.identifier), logging.Err(err))
+			return 0, nil, err
 		}
 
-		err = r.scheduler.update(bot.BotType(), task, func() {
-			executeScheduledTask(botCtx, bot, task)
-		})
+		err = schedule(task)
 		if err != nil {
-			logger.Errorf("Failed to schedule a task. ID: %s: %+v", task.Identifier(), err)
+			return 0, nil, err
 		}
+		generation, changes := runnerStatus.components.recordConfig(bot.BotType(), kindScheduledTask, p.identifier, taskConfig(task), rollbackOf)
+		return generation, changes, nil
 	}
 
-	callback := func(p *ScheduledTaskProps) func() {
-		return func() {
-			logger.Infof("Updating scheduled task: %s", p.identifier)
-			reg(p)
+	reload := func(p *ScheduledTaskProps) func() error {
+		return func() error {
+			log.Info("Updating scheduled task", logging.F(logging.KeyTaskID, p.identifier))
+			generation, changes, err := reg(p, r.configWatcher, 0)
+			PublishEvent(botCtx, &ConfigReloaded{BotType: bot.BotType(), ID: p.identifier, Generation: generation, Changes: changes, Err: err, Time: time.Now()})
+			return err
+		}
+	}
+
+	rollback := func(p *ScheduledTaskProps) func(*ConfigGeneration) error {
+		return func(g *ConfigGeneration) error {
+			log.Info("Rolling back scheduled task configuration", logging.F(logging.KeyTaskID, p.identifier), logging.F("generation", g.Generation))
+			generation, changes, err := reg(p, &generationWatcher{generation: g}, g.Generation)
+			PublishEvent(botCtx, &ConfigReloaded{BotType: bot.BotType(), ID: p.identifier, Generation: generation, Changes: changes, Err: err, Time: time.Now()})
+			return err
 		}
 	}
 
 	for _, p := range r.botScheduledTaskProps(bot.BotType()) {
-		reg(p)
-		err := r.configWatcher.Watch(botCtx, bot.BotType(), p.identifier, callback(p))
+		_, _, _ = reg(p, r.configWatcher, 0)
+		runnerStatus.components.configRollback(bot.BotType(), kindScheduledTask, p.identifier, rollback(p))
+		fnc := reload(p)
+		runnerStatus.components.reloader(bot.BotType(), "task", p.identifier, fnc)
+		err := r.configWatcher.Watch(botCtx, bot.BotType(), p.identifier, func() { _ = fnc() })
 		if err != nil {
-			logger.Errorf("Failed to subscribe configuration for scheduled task %s: %+v", p.identifier, err)
+			log.Error("Failed to subscribe configuration for scheduled task", logging.F(logging.KeyTaskID, p.identifier), logging.Err(err))
 			continue
 		}
 	}
 
 	for _, task := range r.botScheduledTasks(bot.BotType()) {
 		if task.Schedule() == "" {
-			logger.Errorf("Failed to schedule a task. ID: %s. Reason: %s.", task.Identifier(), "No schedule given.")
+			log.Error("Failed to schedule a task due to missing schedule", logging.F(logging.KeyTaskID, task.Identifier()))
 			continue
 		}
 
-		err := r.scheduler.update(bot.BotType(), task, func() {
-			executeScheduledTask(botCtx, bot, task)
-		})
-		if err != nil {
-			logger.Errorf("Failed to schedule a task. id: %s: %+v", task.Identifier(), err)
-		}
+		_ = schedule(task)
 	}
+
+	// Let AddScheduledTask and RemoveScheduledTask control the tasks at runtime.
+	runnerStatus.components.schedule(bot.BotType(), schedule, unschedule)
 }
 
 func executeScheduledTask(ctx context.Context, bot Bot, task ScheduledTask) {
+	// Let the task and the delivery of its results log with the task ID. This tells which task a message comes from in dry-run mode.
+	ctx = logging.NewContext(ctx, logging.FromContext(ctx).With(logging.F(logging.KeyTaskID, task.Identifier())))
+	log := contextLogger(ctx).With(logging.F(logging.KeyBotType, bot.BotType()))
+	started := time.Now()
 	results, err := task.Execute(ctx)
+	PublishEvent(ctx, &TaskExecuted{
+		BotType: bot.BotType(),
+		TaskID:  task.Identifier(),
+		Elapsed: time.Since(started),
+		Err:     err,
+		Time:    time.Now(),
+	})
 	if err != nil {
-		logger.Errorf("Error on scheduled task: %s", task.Identifier())
+		log.Error("Error on scheduled task", logging.Err(err))
 		return
 	} else if results == nil {
 		return
 	}
 
+	var messages []Output
 	for _, res := range results {
 		// The destination returned by task execution has higher priority.
 		// e.g. RSS Reader's task searches for stored feed/destination set, and returns which destination to send.
@@ -520,35 +1076,125 @@ func executeScheduledTask(ctx context.Context, bot Bot, task ScheduledTask) {
 			// e.g. Weather forecast task always sends weather information to #goodmorning room.
 			presetDest := task.DefaultDestination()
 			if presetDest == nil {
-				logger.Errorf("Task was completed, but destination was not set: %s.", task.Identifier())
+				log.Error("Task was completed, but destination was not set")
 				continue
 			}
 			dest = presetDest
 		}
 
 		message := NewOutputMessage(dest, res.Content)
-		bot.SendMessage(ctx, message)
+		if !res.Urgent {
+			if until, held := heldUntil(ctx, dest, clock.FromContext(ctx).Now()); held {
+				log.Debug("Hold scheduled task's result during quiet hours", logging.F(logging.KeyDestination, dest), logging.F("until", until))
+				sendMessageAt(ctx, bot, message, until)
+				continue
+			}
+		}
+
+		log.Debug("Send scheduled task's result", logging.F(logging.KeyDestination, dest))
+		messages = append(messages, message)
+	}
+
+	if len(messages) > 0 {
+		// Send the results at once so the Adapter can save the round-trips.
+		_, _ = bot.SendMessages(ctx, messages)
 	}
 }
 
-func setupInputReceiver(botCtx context.Context, bot Bot, wkr worker.Worker) func(Input) error {
-	continuousEnqueueErrCnt := 0
+// jobEnqueuer is an optional interface that a worker.Worker implementation may satisfy to receive prioritized jobs.
+type jobEnqueuer interface {
+	EnqueueJob(*workers.Job) error
+}
+
+func setupInputReceiver(botCtx context.Context, bot Bot, wkr worker.Worker, inputKey func(Input) string) func(Input) error {
+	// The returned function may be called concurrently; e.g. by the Bot's goroutines and by EndMaintenance that passes the queued Inputs.
+	var continuousEnqueueErrCnt int64
 	return func(input Input) error {
-		err := wkr.Enqueue(func() {
-			err := bot.Respond(botCtx, input)
-			if err != nil {
-				logger.Errorf("Error on message handling. Input: %#v. Error: %+v", input, err)
+		// Generate an ID on reception so the logs on the asynchronous execution can be linked to this Input.
+		id := newCorrelationID()
+		ctx := WithCorrelationID(botCtx, id)
+		ctx = withReceivedAt(ctx, clock.FromContext(botCtx).Now())
+		ctx, span := tracing.Start(
+			ctx,
+			"sarah.receive_input",
+			tracing.A(logging.KeyBotType, bot.BotType().String()),
+			tracing.A(logging.KeyCorrelationID, id),
+		)
+
+		job := func() {
+			defer span.End()
+			err := bot.Respond(ctx, input)
+			if err != nil && isUserError(err) {
+				// The Input is rejected for the sender's fault, and the sender is already told so by the Bot.
+				contextLogger(ctx).Info(
+					"Input is rejected by command",
+					logging.F(logging.KeyBotType, bot.BotType()),
+					logging.F("sender_key", input.SenderKey()),
+					logging.Err(err),
+				)
+			} else if err != nil {
+				span.RecordError(err)
+				contextLogger(ctx).Error(
+					"Error on message handling",
+					logging.F(logging.KeyBotType, bot.BotType()),
+					logging.F(logging.KeyDestination, input.ReplyTo()),
+					logging.F("sender_key", input.SenderKey()),
+					logging.Err(err),
+				)
+
+				letter := &DeadLetter{
+					ID:      id,
+					BotType: bot.BotType(),
+					Input:   input,
+					Err:     err,
+					Time:    clock.FromContext(ctx).Now(),
+				}
+				if runnerStatus.components.deadLetter(letter) {
+					PublishEvent(ctx, &InputDeadLettered{BotType: letter.BotType, ID: id, Err: err, Time: letter.Time})
+				}
 			}
-		})
+		}
+
+		var err error
+		if prioritized, ok := wkr.(jobEnqueuer); ok {
+			var key string
+			if inputKey != nil {
+				key = inputKey(input)
+			}
+
+			// Let user interaction precede other jobs such as scheduled task's message sending.
+			// The job is skipped when the Bot is already stopped by the time the job is dequeued.
+			err = prioritized.EnqueueJob(&workers.Job{
+				Func:     job,
+				Priority: workers.PriorityHigh,
+				Context:  ctx,
+				Name:     "respond",
+				Labels: map[string]string{
+					logging.KeyBotType:       bot.BotType().String(),
+					logging.KeyCorrelationID: id,
+				},
+				Key: key,
+				// The job may be skipped after the successful enqueue when it expires in the queue or is dropped on overflow.
+				// End the span there since the job never gets to end it.
+				OnSkip: func(err error) {
+					span.RecordError(err)
+					span.End()
+				},
+			})
+		} else {
+			err = wkr.Enqueue(job)
+		}
 
 		if err == nil {
-			continuousEnqueueErrCnt = 0
+			atomic.StoreInt64(&continuousEnqueueErrCnt, 0)
 			return nil
 
 		}
 
-		continuousEnqueueErrCnt++
+		span.RecordError(err)
+		span.End()
+		cnt := atomic.AddInt64(&continuousEnqueueErrCnt, 1)
 		// Could not send because probably the workers are too busy or the runner context is already canceled.
-		return NewBlockedInputError(continuousEnqueueErrCnt)
+		return NewBlockedInputError(int(cnt))
 	}
 }
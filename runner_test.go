@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/oklahomer/go-kasumi/logger"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/tracing"
+	"github.com/oklahomer/go-sarah/v4/workers"
 	"io/ioutil"
 	"log"
 	"os"
@@ -33,6 +37,7 @@ func SetupAndRun(fnc func()) {
 	// Initialize package variables
 	runnerStatus = &status{}
 	options = &optionHolder{}
+	defaultRegistry = NewRegistry()
 
 	fnc()
 }
@@ -63,6 +68,14 @@ func (w *DummyWorker) Enqueue(fnc func()) error {
 	return w.EnqueueFunc(fnc)
 }
 
+type DummyLogHandler struct {
+	HandleFunc func(*logging.Entry)
+}
+
+func (h *DummyLogHandler) Handle(entry *logging.Entry) {
+	h.HandleFunc(entry)
+}
+
 func TestNewConfig(t *testing.T) {
 	config := NewConfig()
 	if config == nil {
@@ -157,7 +170,7 @@ func TestRegisterCommand(t *testing.T) {
 			commands: map[BotType][]Command{},
 		}
 
-		for _, v := range options.stashed {
+		for _, v := range defaultRegistry.options.stashed {
 			v(r)
 		}
 
@@ -182,7 +195,7 @@ func TestRegisterCommandProps(t *testing.T) {
 			commandProps: map[BotType][]*CommandProps{},
 		}
 
-		for _, v := range options.stashed {
+		for _, v := range defaultRegistry.options.stashed {
 			v(r)
 		}
 
@@ -196,6 +209,51 @@ func TestRegisterCommandProps(t *testing.T) {
 	})
 }
 
+func TestRegisterScopedCommand(t *testing.T) {
+	SetupAndRun(func() {
+		scope := AllBots()
+		command := &DummyCommand{}
+		RegisterScopedCommand(scope, command)
+		r := &runner{}
+
+		for _, v := range defaultRegistry.options.stashed {
+			v(r)
+		}
+
+		if len(r.scopedCommands) != 1 {
+			t.Fatalf("Expected number of Command is not registered: %d.", len(r.scopedCommands))
+		}
+
+		if r.scopedCommands[0].scope != scope || r.scopedCommands[0].command != command {
+			t.Errorf("Given Command is not registered: %#v.", r.scopedCommands[0])
+		}
+	})
+}
+
+func TestRegisterCommandProps_WithScope(t *testing.T) {
+	SetupAndRun(func() {
+		props := &CommandProps{
+			scope: AllBots(),
+		}
+		RegisterCommandProps(props)
+		r := &runner{
+			commandProps: map[BotType][]*CommandProps{},
+		}
+
+		for _, v := range defaultRegistry.options.stashed {
+			v(r)
+		}
+
+		if len(r.scopedCommandProps) != 1 || r.scopedCommandProps[0] != props {
+			t.Errorf("Given CommandProps is not registered: %#v.", r.scopedCommandProps)
+		}
+
+		if len(r.commandProps) != 0 {
+			t.Errorf("Scoped CommandProps must not be registered with a BotType: %#v.", r.commandProps)
+		}
+	})
+}
+
 func TestRegisterScheduledTask(t *testing.T) {
 	SetupAndRun(func() {
 		var botType BotType = "dummy"
@@ -205,7 +263,7 @@ func TestRegisterScheduledTask(t *testing.T) {
 			scheduledTasks: map[BotType][]ScheduledTask{},
 		}
 
-		for _, v := range options.stashed {
+		for _, v := range defaultRegistry.options.stashed {
 			v(r)
 		}
 
@@ -230,7 +288,7 @@ func TestRegisterScheduledTaskProps(t *testing.T) {
 			scheduledTaskProps: map[BotType][]*ScheduledTaskProps{},
 		}
 
-		for _, v := range options.stashed {
+		for _, v := range defaultRegistry.options.stashed {
 			v(r)
 		}
 
@@ -244,6 +302,70 @@ func TestRegisterScheduledTaskProps(t *testing.T) {
 	})
 }
 
+func TestRegisterScopedScheduledTask(t *testing.T) {
+	SetupAndRun(func() {
+		scope := BotGroup("internal")
+		task := &DummyScheduledTask{}
+		RegisterScopedScheduledTask(scope, task)
+		r := &runner{}
+
+		for _, v := range defaultRegistry.options.stashed {
+			v(r)
+		}
+
+		if len(r.scopedScheduledTasks) != 1 {
+			t.Fatalf("Expected number of ScheduledTask is not registered: %d.", len(r.scopedScheduledTasks))
+		}
+
+		if r.scopedScheduledTasks[0].scope != scope || r.scopedScheduledTasks[0].task != task {
+			t.Errorf("Given ScheduledTask is not registered: %#v.", r.scopedScheduledTasks[0])
+		}
+	})
+}
+
+func TestRegisterScheduledTaskProps_WithScope(t *testing.T) {
+	SetupAndRun(func() {
+		props := &ScheduledTaskProps{
+			scope: AllBots(),
+		}
+		RegisterScheduledTaskProps(props)
+		r := &runner{
+			scheduledTaskProps: map[BotType][]*ScheduledTaskProps{},
+		}
+
+		for _, v := range defaultRegistry.options.stashed {
+			v(r)
+		}
+
+		if len(r.scopedScheduledTaskProps) != 1 || r.scopedScheduledTaskProps[0] != props {
+			t.Errorf("Given ScheduledTaskProps is not registered: %#v.", r.scopedScheduledTaskProps)
+		}
+
+		if len(r.scheduledTaskProps) != 0 {
+			t.Errorf("Scoped ScheduledTaskProps must not be registered with a BotType: %#v.", r.scheduledTaskProps)
+		}
+	})
+}
+
+func TestRegisterBotGroup(t *testing.T) {
+	SetupAndRun(func() {
+		RegisterBotGroup("internal", "slack")
+		RegisterBotGroup("internal", "gitter")
+		r := &runner{
+			botGroups: map[string][]BotType{},
+		}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		members := r.botGroups["internal"]
+		if len(members) != 2 || members[0] != "slack" || members[1] != "gitter" {
+			t.Errorf("Unexpected group members are registered: %#v.", members)
+		}
+	})
+}
+
 func TestRegisterConfigWatcher(t *testing.T) {
 	SetupAndRun(func() {
 		watcher := &DummyConfigWatcher{}
@@ -264,6 +386,22 @@ func TestRegisterConfigWatcher(t *testing.T) {
 	})
 }
 
+func TestRegisterSecretResolver(t *testing.T) {
+	SetupAndRun(func() {
+		resolver := &DummySecretResolver{}
+		RegisterSecretResolver(resolver)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.secretResolver != resolver {
+			t.Error("Given SecretResolver is not set.")
+		}
+	})
+}
+
 func TestRegisterWorker(t *testing.T) {
 	SetupAndRun(func() {
 		worker := &DummyWorker{}
@@ -283,6 +421,344 @@ func TestRegisterWorker(t *testing.T) {
 	})
 }
 
+func TestRegisterInputKeyFunc(t *testing.T) {
+	SetupAndRun(func() {
+		fnc := func(_ Input) string {
+			return "dummy"
+		}
+		RegisterInputKeyFunc(fnc)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.inputKey == nil {
+			t.Fatal("Function is not set.")
+		}
+
+		if r.inputKey(&DummyInput{}) != "dummy" {
+			t.Error("Given function is not set.")
+		}
+	})
+}
+
+func TestRegisterInputFilter(t *testing.T) {
+	SetupAndRun(func() {
+		first := &DummyInputFilter{}
+		second := &DummyInputFilter{}
+		RegisterInputFilter(first)
+		RegisterInputFilter(second)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if len(r.inputFilters) != 2 {
+			t.Fatalf("Unexpected number of filters are set: %d.", len(r.inputFilters))
+		}
+
+		if r.inputFilters[0] != first || r.inputFilters[1] != second {
+			t.Error("Filters are not set in the registration order.")
+		}
+	})
+}
+
+func TestRegisterLogger(t *testing.T) {
+	SetupAndRun(func() {
+		l := logging.NewLogger(&DummyLogHandler{})
+		RegisterLogger(l)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.logger != l {
+			t.Error("Given Logger is not set.")
+		}
+	})
+}
+
+func TestRegisterTracer(t *testing.T) {
+	SetupAndRun(func() {
+		tracer := tracing.NewNoopTracer()
+		RegisterTracer(tracer)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.tracer != tracer {
+			t.Error("Given Tracer is not set.")
+		}
+	})
+}
+
+func TestRegisterEventBus(t *testing.T) {
+	SetupAndRun(func() {
+		bus := NewEventBus()
+		RegisterEventBus(bus)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.eventBus != bus {
+			t.Error("Given EventBus is not set.")
+		}
+	})
+}
+
+func TestRegisterEventSubscriber(t *testing.T) {
+	SetupAndRun(func() {
+		var events []Event
+		RegisterEventSubscriber(func(e Event) {
+			events = append(events, e)
+		})
+
+		r, err := newRunner(context.TODO(), NewConfig())
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if len(r.eventSubscribers) != 1 {
+			t.Fatalf("Unexpected number of subscribers are set: %d.", len(r.eventSubscribers))
+		}
+
+		event := &BotStarted{BotType: "dummy", Time: time.Now()}
+		r.eventBus.Publish(event)
+		if len(events) != 1 || events[0] != event {
+			t.Errorf("Given subscriber is not subscribed to the default EventBus: %#v.", events)
+		}
+	})
+}
+
+func Test_runner_runBot_WithEventBus(t *testing.T) {
+	var mutex sync.Mutex
+	var events []Event
+	bus := NewEventBus()
+	bus.Subscribe(func(e Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, e)
+	})
+	r := &runner{
+		configWatcher: &nullConfigWatcher{},
+		alerters:      &alerters{},
+		eventBus:      bus,
+	}
+	bot := &DummyBot{
+		BotTypeValue: "dummy",
+		RunFunc: func(ctx context.Context, _ func(Input) error, _ func(error)) {
+			PublishEvent(ctx, &SendFailed{BotType: "dummy", Time: time.Now()})
+		},
+	}
+
+	r.runBot(context.TODO(), bot)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(events) != 3 {
+		t.Fatalf("Unexpected number of events are published: %#v.", events)
+	}
+
+	if _, ok := events[0].(*BotStarted); !ok {
+		t.Errorf("BotStarted is not published first: %#v.", events[0])
+	}
+
+	if _, ok := events[1].(*SendFailed); !ok {
+		t.Errorf("EventBus is not carried by the context given to Bot.Run: %#v.", events[1])
+	}
+
+	if _, ok := events[2].(*BotStopped); !ok {
+		t.Errorf("BotStopped is not published last: %#v.", events[2])
+	}
+}
+
+func Test_runner_superviseBot_WithLogger(t *testing.T) {
+	var entries []*logging.Entry
+	r := &runner{
+		alerters: &alerters{},
+		logger: logging.NewLogger(&DummyLogHandler{
+			HandleFunc: func(entry *logging.Entry) {
+				entries = append(entries, entry)
+			},
+		}),
+	}
+
+	botCtx, _ := r.superviseBot(context.Background(), "DummyBotType")
+	logging.FromContext(botCtx).Info("dummy")
+
+	if len(entries) != 1 {
+		t.Fatalf("Registered Logger is not carried by the context: %d entries are handled.", len(entries))
+	}
+
+	fields := entries[0].Fields
+	if len(fields) != 1 || fields[0].Key != logging.KeyBotType || fields[0].Value != BotType("DummyBotType") {
+		t.Errorf("BotType is not attached: %#v.", fields)
+	}
+}
+
+func Test_runner_superviseBot_WithScheduler(t *testing.T) {
+	s := &DummyScheduler{}
+	r := &runner{
+		alerters:  &alerters{},
+		scheduler: s,
+	}
+
+	botCtx, _ := r.superviseBot(context.Background(), "DummyBotType")
+
+	if schedulerFromContext(botCtx) != s {
+		t.Error("Scheduler is not carried by the context.")
+	}
+}
+
+func Test_runner_superviseBot_WithDryRun(t *testing.T) {
+	r := &runner{
+		alerters: &alerters{},
+		dryRun:   &dryRun{},
+	}
+
+	botCtx, _ := r.superviseBot(context.Background(), "DummyBotType")
+
+	if d, ok := dryRunFromContext(botCtx); !ok || d != r.dryRun {
+		t.Error("Dry-run setting is not carried by the context.")
+	}
+}
+
+func Test_runner_superviseBot_WithStore(t *testing.T) {
+	store := NewMemoryStore()
+	r := &runner{
+		alerters: &alerters{},
+		store:    store,
+	}
+
+	botCtx, _ := r.superviseBot(context.Background(), "DummyBotType")
+
+	pluginStore, err := StoreFromContext(botCtx, "plugin")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = pluginStore.Set(botCtx, "key", []byte("value"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	value, err := store.Get(botCtx, "DummyBotType/plugin/key")
+	if err != nil {
+		t.Fatalf("Value is not namespaced by BotType and plugin: %s.", err.Error())
+	}
+	if string(value) != "value" {
+		t.Errorf("Unexpected value is stored: %s.", value)
+	}
+}
+
+func TestRegisterStore(t *testing.T) {
+	SetupAndRun(func() {
+		store := NewMemoryStore()
+		RegisterStore(store)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.store != store {
+			t.Error("Given Store is not set.")
+		}
+	})
+}
+
+func TestRegisterDeadLetterQueue(t *testing.T) {
+	SetupAndRun(func() {
+		RegisterDeadLetterQueue(10)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.deadLetterSize != 10 {
+			t.Errorf("Given size is not set: %d.", r.deadLetterSize)
+		}
+	})
+}
+
+func TestRegisterConfigHistory(t *testing.T) {
+	SetupAndRun(func() {
+		RegisterConfigHistory(5)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.configHistorySize != 5 {
+			t.Errorf("Given size is not set: %d.", r.configHistorySize)
+		}
+	})
+}
+
+func TestRegisterDryRun(t *testing.T) {
+	SetupAndRun(func() {
+		recorder := NewBufferedDryRunRecorder(10)
+		RegisterDryRun(recorder)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.dryRun == nil || r.dryRun.recorder != recorder {
+			t.Error("Given DryRunRecorder is not set.")
+		}
+	})
+}
+
+func TestRegisterQuietHours(t *testing.T) {
+	SetupAndRun(func() {
+		config := NewQuietHoursConfig()
+		RegisterQuietHours("dummy", config)
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.quietHours["dummy"] != config {
+			t.Error("Given QuietHoursConfig is not set.")
+		}
+	})
+}
+
+func TestRegisterIdentityResolver(t *testing.T) {
+	SetupAndRun(func() {
+		RegisterIdentityResolver(func(_ context.Context, _ BotType, _ string) (string, error) {
+			return "person", nil
+		})
+		r := &runner{}
+
+		for _, v := range options.stashed {
+			v(r)
+		}
+
+		if r.identityResolver == nil {
+			t.Fatal("Given IdentityResolver is not set.")
+		}
+
+		ctx, _ := r.superviseBot(context.TODO(), "dummy")
+		id, err := ResolveIdentity(ctx, "user")
+		if err != nil || id != "person" {
+			t.Errorf("IdentityResolver is not given to the Bot's context: %s, %#v.", id, err)
+		}
+	})
+}
+
 func TestRegisterBotErrorSupervisor(t *testing.T) {
 	SetupAndRun(func() {
 		supervisor := func(_ BotType, _ error) *SupervisionDirective {
@@ -342,6 +818,7 @@ func Test_newRunner(t *testing.T) {
 	SetupAndRun(func() {
 		config := &Config{
 			TimeZone: time.UTC.String(),
+			Worker:   workers.NewConfig(),
 		}
 
 		r, e := newRunner(context.Background(), config)
@@ -380,6 +857,150 @@ func Test_newRunner_WithTimeZoneError(t *testing.T) {
 	})
 }
 
+func Test_newRunner_WithDuplicatedBotType(t *testing.T) {
+	SetupAndRun(func() {
+		RegisterBot(&DummyBot{BotTypeValue: "slack"})
+		RegisterBot(&DummyBot{BotTypeValue: "slack"})
+
+		_, e := newRunner(context.Background(), NewConfig())
+		if e == nil {
+			t.Fatal("Expected error is not returned.")
+		}
+	})
+}
+
+func Test_newRunner_WithInstances(t *testing.T) {
+	SetupAndRun(func() {
+		RegisterBot(&DummyBot{BotTypeValue: BotType("slack").Instance("a")})
+		RegisterBot(&DummyBot{BotTypeValue: BotType("slack").Instance("b")})
+
+		_, e := newRunner(context.Background(), NewConfig())
+		if e != nil {
+			t.Fatalf("Unexpected error is returned: %s.", e.Error())
+		}
+	})
+}
+
+func Test_runner_botCommandProps_Instance(t *testing.T) {
+	var botType BotType = "slack"
+	shared := &CommandProps{
+		botType:    botType,
+		identifier: "shared",
+		config:     &struct{ Token string }{Token: "default"},
+	}
+	scoped := &CommandProps{
+		botType:    botType.Instance("a"),
+		identifier: "scoped",
+	}
+	sharedCommand := &DummyCommand{IdentifierValue: "sharedCommand"}
+	scopedCommand := &DummyCommand{IdentifierValue: "scopedCommand"}
+	r := &runner{
+		commandProps: map[BotType][]*CommandProps{
+			botType:               {shared},
+			botType.Instance("a"): {scoped},
+		},
+		commands: map[BotType][]Command{
+			botType:               {sharedCommand},
+			botType.Instance("b"): {scopedCommand},
+		},
+	}
+
+	props := r.botCommandProps(botType.Instance("a"))
+	if len(props) != 2 {
+		t.Fatalf("Unexpected number of CommandProps are returned: %d.", len(props))
+	}
+
+	if props[0].identifier != "shared" || props[0].botType != botType.Instance("a") {
+		t.Errorf("Shared CommandProps is not scoped to the instance: %#v.", props[0])
+	}
+
+	if props[0] == shared || props[0].config == shared.config {
+		t.Error("Shared CommandProps and its config must be copied.")
+	}
+
+	if props[1] != scoped {
+		t.Errorf("Scoped CommandProps is not returned: %#v.", props[1])
+	}
+
+	if props := r.botCommandProps(botType); len(props) != 1 || props[0] != shared {
+		t.Errorf("Unexpected CommandProps are returned for the base BotType: %#v.", props)
+	}
+
+	commands := r.botCommands(botType.Instance("b"))
+	if len(commands) != 2 || commands[0] != sharedCommand || commands[1] != scopedCommand {
+		t.Errorf("Unexpected Commands are returned: %#v.", commands)
+	}
+
+	if commands := r.botCommands(botType.Instance("a")); len(commands) != 1 {
+		t.Errorf("Command scoped to another instance is returned: %#v.", commands)
+	}
+}
+
+func Test_runner_botCommands_Scoped(t *testing.T) {
+	var botType BotType = "slack"
+	ping := &CommandProps{
+		scope:      AllBots().Except("gitter"),
+		identifier: "ping",
+		config:     &struct{ Token string }{Token: "default"},
+	}
+	deploy := &CommandProps{
+		scope:      BotGroup("internal"),
+		identifier: "deploy",
+	}
+	help := &DummyCommand{IdentifierValue: "help"}
+	task := &DummyScheduledTask{IdentifierValue: "task"}
+	taskProps := &ScheduledTaskProps{
+		scope:      AllBots(),
+		identifier: "report",
+	}
+	r := &runner{
+		botGroups: map[string][]BotType{
+			"internal": {botType.Instance("staff")},
+		},
+		scopedCommands:           []*scopedCommand{{scope: AllBots(), command: help}},
+		scopedCommandProps:       []*CommandProps{ping, deploy},
+		scopedScheduledTasks:     []*scopedScheduledTask{{scope: BotGroup("internal"), task: task}},
+		scopedScheduledTaskProps: []*ScheduledTaskProps{taskProps},
+	}
+
+	props := r.botCommandProps(botType.Instance("staff"))
+	if len(props) != 2 {
+		t.Fatalf("Unexpected number of CommandProps are returned: %d.", len(props))
+	}
+
+	if props[0].identifier != "ping" || props[0].botType != botType.Instance("staff") {
+		t.Errorf("Scoped CommandProps is not copied for the Bot: %#v.", props[0])
+	}
+
+	if props[0] == ping || props[0].config == ping.config {
+		t.Error("Scoped CommandProps and its config must be copied.")
+	}
+
+	if props[1].identifier != "deploy" {
+		t.Errorf("CommandProps for the group is not returned: %#v.", props[1])
+	}
+
+	if props := r.botCommandProps("gitter"); len(props) != 0 {
+		t.Errorf("Excluded Bot must not receive CommandProps: %#v.", props)
+	}
+
+	if commands := r.botCommands("gitter"); len(commands) != 1 || commands[0] != help {
+		t.Errorf("Unexpected Commands are returned: %#v.", commands)
+	}
+
+	if tasks := r.botScheduledTasks(botType.Instance("staff")); len(tasks) != 1 || tasks[0] != task {
+		t.Errorf("Unexpected ScheduledTasks are returned: %#v.", tasks)
+	}
+
+	if tasks := r.botScheduledTasks("gitter"); len(tasks) != 0 {
+		t.Errorf("ScheduledTask for the group is returned for a non-member: %#v.", tasks)
+	}
+
+	if props := r.botScheduledTaskProps("gitter"); len(props) != 1 || props[0].botType != "gitter" {
+		t.Errorf("Unexpected ScheduledTaskProps are returned: %#v.", props)
+	}
+}
+
 func Test_runner_run(t *testing.T) {
 	SetupAndRun(func() {
 		var botType BotType = "myBot"
@@ -818,8 +1439,9 @@ func Test_executeScheduledTask(t *testing.T) {
 		}
 
 		var sendingOutput []Output
-		dummyBot := &DummyBot{SendMessageFunc: func(_ context.Context, output Output) {
-			sendingOutput = append(sendingOutput, output)
+		dummyBot := &DummyBot{SendMessagesFunc: func(_ context.Context, outputs []Output) ([]*SendResult, error) {
+			sendingOutput = append(sendingOutput, outputs...)
+			return make([]*SendResult, len(outputs)), nil
 		}}
 
 		for _, testSet := range testSets {
@@ -838,6 +1460,34 @@ func Test_executeScheduledTask(t *testing.T) {
 			executeScheduledTask(context.TODO(), dummyBot, task)
 		}
 
+		var events []Event
+		bus := NewEventBus()
+		bus.Subscribe(func(e Event) {
+			events = append(events, e)
+		})
+		expectedErr := errors.New("dummy")
+		task := &scheduledTask{
+			identifier: "dummy",
+			taskFunc: func(_ context.Context, _ ...TaskConfig) ([]*ScheduledTaskResult, error) {
+				return nil, expectedErr
+			},
+			configWrapper: &taskConfigWrapper{
+				value: &DummyScheduledTaskConfig{},
+				mutex: &sync.RWMutex{},
+			},
+		}
+		executeScheduledTask(NewEventBusContext(context.TODO(), bus), dummyBot, task)
+		if len(events) != 1 {
+			t.Fatalf("Unexpected number of events are published: %d.", len(events))
+		}
+		executed, ok := events[0].(*TaskExecuted)
+		if !ok {
+			t.Fatalf("Unexpected event is published: %#v.", events[0])
+		}
+		if executed.TaskID != "dummy" || executed.Err != expectedErr {
+			t.Errorf("Unexpected event is published: %#v.", executed)
+		}
+
 		if len(sendingOutput) != 2 {
 			t.Fatalf("Expecting sending method to be called twice, but was called %d time(s).", len(sendingOutput))
 		}
@@ -868,7 +1518,7 @@ func Test_setupInputReceiver(t *testing.T) {
 			},
 		}
 
-		receiveInput := setupInputReceiver(context.TODO(), bot, worker)
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, nil)
 		if err := receiveInput(&DummyInput{}); err != nil {
 			t.Errorf("Error should not be returned at this point: %s.", err.Error())
 		}
@@ -882,6 +1532,247 @@ func Test_setupInputReceiver(t *testing.T) {
 	})
 }
 
+type DummyJobWorker struct {
+	DummyWorker
+	EnqueueJobFunc func(*workers.Job) error
+}
+
+func (w *DummyJobWorker) EnqueueJob(job *workers.Job) error {
+	return w.EnqueueJobFunc(job)
+}
+
+func Test_setupInputReceiver_WithPriority(t *testing.T) {
+	SetupAndRun(func() {
+		var priority workers.Priority
+		var key string
+		worker := &DummyJobWorker{
+			EnqueueJobFunc: func(job *workers.Job) error {
+				priority = job.Priority
+				key = job.Key
+				return nil
+			},
+		}
+		inputKey := func(_ Input) string {
+			return "dummy"
+		}
+
+		receiveInput := setupInputReceiver(context.TODO(), &DummyBot{BotTypeValue: "DUMMY"}, worker, inputKey)
+		if err := receiveInput(&DummyInput{}); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if priority != workers.PriorityHigh {
+			t.Errorf("Unexpected priority is given: %s.", priority)
+		}
+
+		if key != "dummy" {
+			t.Errorf("Unexpected key is given: %s.", key)
+		}
+	})
+}
+
+func Test_setupInputReceiver_SpanEnd(t *testing.T) {
+	tests := []struct {
+		name    string
+		enqueue func(*workers.Job) error
+		err     error
+	}{
+		{
+			name: "executed",
+			enqueue: func(job *workers.Job) error {
+				job.Func()
+				return nil
+			},
+		},
+		{
+			name: "enqueue error",
+			enqueue: func(_ *workers.Job) error {
+				return workers.ErrQueueOverflow
+			},
+			err: workers.ErrQueueOverflow,
+		},
+		{
+			name: "skipped",
+			enqueue: func(job *workers.Job) error {
+				job.OnSkip(context.Canceled)
+				return nil
+			},
+			err: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetupAndRun(func() {
+				var spans []*DummySpan
+				ctx := tracing.NewContext(context.TODO(), spanRecorder(&spans))
+				worker := &DummyJobWorker{
+					EnqueueJobFunc: tt.enqueue,
+				}
+				bot := &DummyBot{
+					BotTypeValue: "DUMMY",
+					RespondFunc: func(_ context.Context, _ Input) error {
+						return nil
+					},
+				}
+
+				receiveInput := setupInputReceiver(ctx, bot, worker, nil)
+				_ = receiveInput(&DummyInput{})
+
+				if len(spans) != 1 {
+					t.Fatalf("Unexpected number of spans are started: %d.", len(spans))
+				}
+
+				if !spans[0].Ended {
+					t.Error("Span is not ended.")
+				}
+
+				if spans[0].Err != tt.err {
+					t.Errorf("Unexpected error is recorded: %#v.", spans[0].Err)
+				}
+			})
+		})
+	}
+}
+
+func Test_setupInputReceiver_WithCorrelationID(t *testing.T) {
+	SetupAndRun(func() {
+		var label string
+		var id string
+		worker := &DummyJobWorker{
+			EnqueueJobFunc: func(job *workers.Job) error {
+				label = job.Labels[logging.KeyCorrelationID]
+				job.Func()
+				return nil
+			},
+		}
+		bot := &DummyBot{
+			BotTypeValue: "DUMMY",
+			RespondFunc: func(ctx context.Context, _ Input) error {
+				id = CorrelationID(ctx)
+				return nil
+			},
+		}
+
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, nil)
+		if err := receiveInput(&DummyInput{}); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if id == "" {
+			t.Fatal("Correlation ID is not passed to Bot.Respond.")
+		}
+
+		if label != id {
+			t.Errorf("Correlation ID is not given as a job label: %s.", label)
+		}
+	})
+}
+
+func Test_setupInputReceiver_WithReceivedAt(t *testing.T) {
+	SetupAndRun(func() {
+		now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		var receivedAt time.Time
+		worker := &DummyWorker{
+			EnqueueFunc: func(fnc func()) error {
+				fnc()
+				return nil
+			},
+		}
+		bot := &DummyBot{
+			BotTypeValue: "DUMMY",
+			RespondFunc: func(ctx context.Context, _ Input) error {
+				receivedAt, _ = InputReceivedAt(ctx)
+				return nil
+			},
+		}
+
+		botCtx := clock.WithContext(context.TODO(), clock.NewFake(now))
+		receiveInput := setupInputReceiver(botCtx, bot, worker, nil)
+		if err := receiveInput(&DummyInput{}); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if !receivedAt.Equal(now) {
+			t.Errorf("Unexpected reception time is passed to Bot.Respond: %s.", receivedAt)
+		}
+	})
+}
+
+func Test_setupInputReceiver_WithDeadLetter(t *testing.T) {
+	SetupAndRun(func() {
+		runnerStatus.components.enableDeadLetters(10)
+
+		var published []Event
+		bus := NewEventBus()
+		bus.Subscribe(func(e Event) {
+			published = append(published, e)
+		})
+		worker := &DummyWorker{
+			EnqueueFunc: func(fnc func()) error {
+				fnc()
+				return nil
+			},
+		}
+		respondErr := errors.New("respond error")
+		bot := &DummyBot{
+			BotTypeValue: "DUMMY",
+			RespondFunc: func(_ context.Context, _ Input) error {
+				return respondErr
+			},
+		}
+
+		input := &DummyInput{}
+		receiveInput := setupInputReceiver(NewEventBusContext(context.TODO(), bus), bot, worker, nil)
+		if err := receiveInput(input); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		letters := ListDeadLetters()
+		if len(letters) != 1 {
+			t.Fatalf("Unexpected number of dead letters: %d.", len(letters))
+		}
+		if letters[0].BotType != "DUMMY" || letters[0].Input != input || letters[0].Err != respondErr || letters[0].ID == "" {
+			t.Errorf("Unexpected dead letter is kept: %#v.", letters[0])
+		}
+
+		if len(published) != 1 {
+			t.Fatalf("Unexpected number of events: %d.", len(published))
+		}
+		if e, ok := published[0].(*InputDeadLettered); !ok || e.ID != letters[0].ID {
+			t.Errorf("Unexpected event is published: %#v.", published[0])
+		}
+	})
+}
+
+func Test_setupInputReceiver_WithUserError(t *testing.T) {
+	SetupAndRun(func() {
+		runnerStatus.components.enableDeadLetters(10)
+
+		worker := &DummyWorker{
+			EnqueueFunc: func(fnc func()) error {
+				fnc()
+				return nil
+			},
+		}
+		bot := &DummyBot{
+			BotTypeValue: "DUMMY",
+			RespondFunc: func(_ context.Context, _ Input) error {
+				return NewCommandError(ErrBadArgs, "", nil)
+			},
+		}
+
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, nil)
+		if err := receiveInput(&DummyInput{}); err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if len(ListDeadLetters()) != 0 {
+			t.Errorf("Input rejected for the sender's fault must not be kept: %#v.", ListDeadLetters())
+		}
+	})
+}
+
 func Test_setupInputReceiver_BlockedInputError(t *testing.T) {
 	SetupAndRun(func() {
 		bot := &DummyBot{}
@@ -891,7 +1782,7 @@ func Test_setupInputReceiver_BlockedInputError(t *testing.T) {
 			},
 		}
 
-		receiveInput := setupInputReceiver(context.TODO(), bot, worker)
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, nil)
 		err := receiveInput(&DummyInput{})
 		if err == nil {
 			t.Fatal("Expected error is not returned.")
@@ -903,6 +1794,39 @@ func Test_setupInputReceiver_BlockedInputError(t *testing.T) {
 	})
 }
 
+func Test_setupInputReceiver_BlockedInputError_Concurrent(t *testing.T) {
+	SetupAndRun(func() {
+		bot := &DummyBot{}
+		worker := &DummyWorker{
+			EnqueueFunc: func(fnc func()) error {
+				return errors.New("any error should result in BlockedInputError")
+			},
+		}
+
+		// The Bot and EndMaintenance may pass Inputs at the same time.
+		receiveInput := setupInputReceiver(context.TODO(), bot, worker, nil)
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = receiveInput(&DummyInput{})
+			}()
+		}
+		wg.Wait()
+
+		err := receiveInput(&DummyInput{})
+		blocked, ok := err.(*BlockedInputError)
+		if !ok {
+			t.Fatalf("Expected error type is not returned: %T.", err)
+		}
+
+		if blocked.ContinuationCount != 11 {
+			t.Errorf("Unexpected continuation count is returned: %d.", blocked.ContinuationCount)
+		}
+	})
+}
+
 func Test_registerCommands(t *testing.T) {
 	SetupAndRun(func() {
 		tests := []struct {
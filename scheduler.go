@@ -2,121 +2,301 @@ package sarah
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/oklahomer/go-kasumi/logger"
+	"github.com/oklahomer/go-sarah/v4/clock"
 	"github.com/robfig/cron/v3"
+	"strings"
+	"sync"
 	"time"
 )
 
 type scheduler interface {
 	remove(BotType, string)
 	update(BotType, ScheduledTask, func()) error
+	once(time.Time, func())
+	next(BotType, string) time.Time
 }
 
+type schedulerKey struct{}
+
+// withScheduler returns a copy of the given context that carries the given scheduler.
+// go-sarah's core passes a context with the Runner's scheduler to Bot so the Bot can schedule a delayed message.
+func withScheduler(ctx context.Context, s scheduler) context.Context {
+	return context.WithValue(ctx, schedulerKey{}, s)
+}
+
+// schedulerFromContext returns the scheduler carried by the given context or nil.
+func schedulerFromContext(ctx context.Context) scheduler {
+	s, _ := ctx.Value(schedulerKey{}).(scheduler)
+	return s
+}
+
+// taskScheduler runs the registered jobs on their cron schedules.
+// The schedules are parsed by github.com/robfig/cron/v3 while the timing is measured with clock.Clock,
+// so a test can run the jobs by advancing clock.Fake instead of waiting.
 type taskScheduler struct {
-	cron         *cron.Cron
-	removingTask chan *removingTask
-	updatingTask chan *updatingTask
+	clock    clock.Clock
+	location *time.Location
+	parser   cron.Parser
+	mutex    sync.Mutex
+	lastID   int
+	entries  map[int]*scheduleEntry
+	tasks    map[BotType]map[string]int
+	wake     chan struct{}
+	done     chan struct{}
+}
+
+// scheduleEntry is a registered job with its next activation time.
+type scheduleEntry struct {
+	schedule cron.Schedule
+	next     time.Time
+	fn       func()
+}
+
+// HealthCheck returns an error when the scheduler is no longer running.
+func (s *taskScheduler) HealthCheck(_ context.Context) error {
+	select {
+	case <-s.done:
+		return errors.New("scheduler is stopped")
+
+	default:
+		return nil
+
+	}
 }
 
 func (s *taskScheduler) remove(botType BotType, taskID string) {
-	remove := &removingTask{
-		botType: botType,
-		taskID:  taskID,
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.removeTask(botType, taskID)
+}
+
+// removeTask removes the registered task. This must be called while s.mutex is locked.
+func (s *taskScheduler) removeTask(botType BotType, taskID string) {
+	botTasks, ok := s.tasks[botType]
+	if !ok {
+		// Task is not registered for the given bot
+		return
+	}
+
+	id, ok := botTasks[taskID]
+	if !ok {
+		// Given task is not registered
+		return
 	}
-	s.removingTask <- remove
+
+	delete(botTasks, taskID)
+	delete(s.entries, id)
 }
 
 func (s *taskScheduler) update(botType BotType, task ScheduledTask, fn func()) error {
-	add := &updatingTask{
-		botType: botType,
-		task:    task,
-		fn:      fn,
-		err:     make(chan error, 1),
+	if task.Schedule() == "" {
+		return fmt.Errorf("empty schedule is given for %s", task.Identifier())
 	}
-	s.updatingTask <- add
 
-	return <-add.err
+	schedule, err := s.parse(task.Schedule())
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.removeTask(botType, task.Identifier())
+	if _, ok := s.tasks[botType]; !ok {
+		s.tasks[botType] = make(map[string]int)
+	}
+	s.tasks[botType][task.Identifier()] = s.addEntry(schedule, fn)
+	s.mutex.Unlock()
+
+	s.notify()
+	return nil
 }
 
-type removingTask struct {
-	botType BotType
-	taskID  string
+// parse parses the given schedule.
+// In addition to the cron spec, onceDescriptor followed by an RFC 3339 time is parsed as a schedule that activates the job only once.
+func (s *taskScheduler) parse(spec string) (cron.Schedule, error) {
+	if strings.HasPrefix(spec, onceDescriptor) {
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(strings.TrimPrefix(spec, onceDescriptor)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse one-time schedule %s: %w", spec, err)
+		}
+		return &onceSchedule{at: at}, nil
+	}
+
+	return s.parser.Parse(spec)
 }
 
-type updatingTask struct {
-	botType BotType
-	task    ScheduledTask
-	fn      func()
-	err     chan error
+// next returns the next activation time of the registered task, or the zero time when the task is not registered.
+func (s *taskScheduler) next(botType BotType, taskID string) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id, ok := s.tasks[botType][taskID]
+	if !ok {
+		return time.Time{}
+	}
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return time.Time{}
+	}
+	return entry.next
 }
 
-func runScheduler(ctx context.Context, location *time.Location) scheduler {
-	c := cron.New(cron.WithLocation(location))
-	// TODO set logger
-	//c.ErrorLog = log.New(...)
+// once schedules the given function to run once at the given time.
+// The function runs immediately when the given time is already past.
+func (s *taskScheduler) once(at time.Time, fn func()) {
+	s.mutex.Lock()
+	s.addEntry(&onceSchedule{at: at}, fn)
+	s.mutex.Unlock()
 
-	c.Start()
+	s.notify()
+}
 
-	s := &taskScheduler{
-		cron:         c,
-		removingTask: make(chan *removingTask, 1),
-		updatingTask: make(chan *updatingTask, 1),
+// addEntry registers the given job and returns its ID. This must be called while s.mutex is locked.
+func (s *taskScheduler) addEntry(schedule cron.Schedule, fn func()) int {
+	s.lastID++
+	s.entries[s.lastID] = &scheduleEntry{
+		schedule: schedule,
+		next:     schedule.Next(s.clock.Now().In(s.location)),
+		fn:       fn,
 	}
+	return s.lastID
+}
 
-	go s.receiveEvent(ctx)
+// notify lets the scheduling loop recalculate the next activation time.
+func (s *taskScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+		// Notified.
 
-	return s
+	default:
+		// The loop is already notified.
+
+	}
 }
 
-func (s *taskScheduler) receiveEvent(ctx context.Context) {
-	schedule := make(map[BotType]map[string]cron.EntryID)
-	removeFunc := func(botType BotType, taskID string) {
-		botSchedule, ok := schedule[botType]
-		if !ok {
-			// Task is not registered for the given bot
-			return
-		}
+// entryCount returns the number of the registered jobs.
+func (s *taskScheduler) entryCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-		storedID, ok := botSchedule[taskID]
-		if !ok {
-			// Given task is not registered
-			return
-		}
+	return len(s.entries)
+}
+
+// onceSchedule is a cron.Schedule that activates the job only once.
+type onceSchedule struct {
+	at    time.Time
+	fired bool
+}
 
-		delete(botSchedule, taskID)
-		s.cron.Remove(storedID)
+var _ cron.Schedule = (*onceSchedule)(nil)
+
+// Next returns the scheduled time on the first call and the zero time afterwards so the job never runs again.
+// taskScheduler calls this while its lock is held.
+func (s *onceSchedule) Next(t time.Time) time.Time {
+	if s.fired {
+		return time.Time{}
+	}
+	s.fired = true
+
+	if s.at.Before(t) {
+		return t
 	}
+	return s.at
+}
+
+// newScheduleParser returns the parser of the cron specs.
+func newScheduleParser() cron.Parser {
+	// Same as the parser that cron.New uses by default.
+	return cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+}
+
+// verifySchedule returns an error when the given schedule cannot be registered to the scheduler.
+func verifySchedule(spec string) error {
+	if spec == "" {
+		return errors.New("empty schedule is given")
+	}
+
+	s := &taskScheduler{parser: newScheduleParser()}
+	_, err := s.parse(spec)
+	return err
+}
+
+func runScheduler(ctx context.Context, location *time.Location, c clock.Clock) scheduler {
+	s := &taskScheduler{
+		clock:    c,
+		location: location,
+		parser:   newScheduleParser(),
+		entries:  make(map[int]*scheduleEntry),
+		tasks:    make(map[BotType]map[string]int),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	go s.run(ctx)
+
+	return s
+}
+
+// run activates the due jobs and waits til the next activation time or a change of the registered jobs.
+func (s *taskScheduler) run(ctx context.Context) {
+	defer close(s.done)
 
 	for {
+		next := s.activate(s.clock.Now().In(s.location))
+
+		var timer clock.Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = s.clock.NewTimer(next.Sub(s.clock.Now()))
+			fire = timer.C()
+		}
+
 		select {
 		case <-ctx.Done():
-			logger.Info("Stop cron jobs due to context cancellation.")
-			s.cron.Stop()
+			if timer != nil {
+				timer.Stop()
+			}
+			moduleLogger().Info("Stop cron jobs due to context cancellation")
 			return
 
-		case remove := <-s.removingTask:
-			removeFunc(remove.botType, remove.taskID)
+		case <-fire:
+			// Activate the due jobs.
 
-		case add := <-s.updatingTask:
-			if add.task.Schedule() == "" {
-				add.err <- fmt.Errorf("empty schedule is given for %s", add.task.Identifier())
-				continue
+		case <-s.wake:
+			// Registered jobs are changed. Recalculate the next activation time.
+			if timer != nil {
+				timer.Stop()
 			}
 
-			removeFunc(add.botType, add.task.Identifier())
+		}
+	}
+}
+
+// activate runs the jobs that are due at the given time in their own goroutines, and returns the earliest next activation time.
+// A job with no more activation is removed.
+func (s *taskScheduler) activate(now time.Time) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-			id, err := s.cron.AddFunc(add.task.Schedule(), add.fn)
-			if err != nil {
-				add.err <- err
-				break
-			}
+	var earliest time.Time
+	for id, entry := range s.entries {
+		if !entry.next.After(now) {
+			go entry.fn()
+			entry.next = entry.schedule.Next(now)
+		}
 
-			if _, ok := schedule[add.botType]; !ok {
-				schedule[add.botType] = make(map[string]cron.EntryID)
-			}
-			schedule[add.botType][add.task.Identifier()] = id
-			add.err <- nil
+		if entry.next.IsZero() {
+			delete(s.entries, id)
+			continue
+		}
+
+		if earliest.IsZero() || entry.next.Before(earliest) {
+			earliest = entry.next
 		}
 	}
+
+	return earliest
 }
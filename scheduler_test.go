@@ -2,6 +2,7 @@ package sarah
 
 import (
 	"context"
+	"github.com/oklahomer/go-sarah/v4/clock"
 	"testing"
 	"time"
 )
@@ -9,6 +10,8 @@ import (
 type DummyScheduler struct {
 	RemoveFunc func(BotType, string)
 	UpdateFunc func(BotType, ScheduledTask, func()) error
+	OnceFunc   func(time.Time, func())
+	NextFunc   func(BotType, string) time.Time
 }
 
 func (s *DummyScheduler) remove(botType BotType, taskID string) {
@@ -19,22 +22,25 @@ func (s *DummyScheduler) update(botType BotType, task ScheduledTask, fn func())
 	return s.UpdateFunc(botType, task, fn)
 }
 
+func (s *DummyScheduler) once(at time.Time, fn func()) {
+	s.OnceFunc(at, fn)
+}
+
+func (s *DummyScheduler) next(botType BotType, taskID string) time.Time {
+	return s.NextFunc(botType, taskID)
+}
+
 func Test_runScheduler(t *testing.T) {
 	rootCtx := context.Background()
 	ctx, cancel := context.WithCancel(rootCtx)
 	defer cancel()
-	scheduler := runScheduler(ctx, time.UTC)
+	scheduler := runScheduler(ctx, time.UTC, clock.Real())
 
 	if scheduler == nil {
 		t.Fatal("scheduler is nil")
 	}
 
-	c := scheduler.(*taskScheduler).cron
-	if c == nil {
-		t.Fatal("Internal cron instance is not initialized")
-	}
-
-	loc := c.Location()
+	loc := scheduler.(*taskScheduler).location
 	if loc != time.UTC {
 		t.Errorf("Assigned time.Location is not set: %s.", loc.String())
 	}
@@ -44,7 +50,7 @@ func TestTaskScheduler_updateAndRemove(t *testing.T) {
 	rootCtx := context.Background()
 	ctx, cancel := context.WithCancel(rootCtx)
 	defer cancel()
-	scheduler := runScheduler(ctx, time.Local)
+	scheduler := runScheduler(ctx, time.Local, clock.Real())
 
 	taskID := "id"
 	task := &scheduledTask{
@@ -65,7 +71,7 @@ func TestTaskScheduler_updateAndRemove(t *testing.T) {
 		t.Fatalf("Error is returned on valid schedule value: %s", err.Error())
 	}
 	time.Sleep(10 * time.Millisecond)
-	jobCnt := len(scheduler.(*taskScheduler).cron.Entries())
+	jobCnt := scheduler.(*taskScheduler).entryCount()
 	if jobCnt != 1 {
 		t.Fatalf("1 job is expected: %d.", jobCnt)
 	}
@@ -77,17 +83,61 @@ func TestTaskScheduler_updateAndRemove(t *testing.T) {
 	// Remove a registered job
 	scheduler.remove(storedBotType, taskID)
 	time.Sleep(10 * time.Millisecond)
-	jobCnt = len(scheduler.(*taskScheduler).cron.Entries())
+	jobCnt = scheduler.(*taskScheduler).entryCount()
 	if jobCnt != 0 {
 		t.Fatalf("0 job is expected: %d.", jobCnt)
 	}
 }
 
+func TestTaskScheduler_next(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	scheduler := runScheduler(ctx, time.UTC, clock.NewFake(now))
+
+	if !scheduler.next("dummy", "hourly").IsZero() {
+		t.Error("Zero time must be returned for unregistered task.")
+	}
+
+	err := scheduler.update("dummy", &DummyScheduledTask{IdentifierValue: "hourly", ScheduleValue: "@hourly"}, func() {})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	next := scheduler.next("dummy", "hourly")
+	if !next.Equal(time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next time is returned: %s.", next)
+	}
+}
+
+func TestTaskScheduler_updateWithOnceSchedule(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	scheduler := runScheduler(ctx, time.UTC, clock.NewFake(now))
+
+	at := now.Add(90 * time.Minute)
+	err := scheduler.update("dummy", &DummyScheduledTask{IdentifierValue: "once", ScheduleValue: ScheduleOnceAt(at)}, func() {})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	next := scheduler.next("dummy", "once")
+	if !next.Equal(at) {
+		t.Errorf("Unexpected next time is returned: %s.", next)
+	}
+
+	err = scheduler.update("dummy", &DummyScheduledTask{IdentifierValue: "broken", ScheduleValue: "@at tomorrow"}, func() {})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
 func TestTaskScheduler_updateWithEmptySchedule(t *testing.T) {
 	rootCtx := context.Background()
 	ctx, cancel := context.WithCancel(rootCtx)
 	defer cancel()
-	scheduler := runScheduler(ctx, time.Local)
+	scheduler := runScheduler(ctx, time.Local, clock.Real())
 
 	err := scheduler.update("dummy", &DummyScheduledTask{}, func() {})
 
@@ -95,3 +145,99 @@ func TestTaskScheduler_updateWithEmptySchedule(t *testing.T) {
 		t.Error("Expected error is not returned.")
 	}
 }
+
+func TestTaskScheduler_once(t *testing.T) {
+	rootCtx := context.Background()
+	ctx, cancel := context.WithCancel(rootCtx)
+	defer cancel()
+	scheduler := runScheduler(ctx, time.Local, clock.Real())
+
+	fired := make(chan time.Time, 2)
+	scheduler.once(time.Now().Add(-1*time.Minute), func() {
+		fired <- time.Now()
+	})
+	scheduler.once(time.Now().Add(50*time.Millisecond), func() {
+		fired <- time.Now()
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-fired:
+			// O.K.
+
+		case <-time.NewTimer(3 * time.Second).C:
+			t.Fatalf("Scheduled function #%d is not called.", i)
+
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	jobCnt := scheduler.(*taskScheduler).entryCount()
+	if jobCnt != 0 {
+		t.Errorf("Executed job is not removed: %d.", jobCnt)
+	}
+}
+
+func TestTaskScheduler_WithFakeClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := clock.NewFake(time.Date(2021, 1, 1, 8, 30, 0, 0, time.UTC))
+	scheduler := runScheduler(ctx, time.UTC, fake)
+
+	fired := make(chan struct{}, 10)
+	task := &scheduledTask{
+		identifier: "hourly",
+		schedule:   "@hourly",
+	}
+	err := scheduler.update("dummy", task, func() {
+		fired <- struct{}{}
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer waitCancel()
+	if err := fake.WaitTimers(waitCtx, 1); err != nil {
+		t.Fatal("Scheduler does not wait for the next activation.")
+	}
+
+	select {
+	case <-fired:
+		t.Fatal("Task is executed before the scheduled time.")
+
+	default:
+		// O.K.
+
+	}
+
+	fake.Advance(30 * time.Minute)
+	select {
+	case <-fired:
+		// O.K.
+
+	case <-time.After(3 * time.Second):
+		t.Fatal("Task is not executed on the scheduled time.")
+
+	}
+}
+
+func TestOnceSchedule_Next(t *testing.T) {
+	now := time.Now()
+	at := now.Add(time.Hour)
+	schedule := &onceSchedule{at: at}
+
+	if next := schedule.Next(now); !next.Equal(at) {
+		t.Errorf("Unexpected time is returned: %s.", next)
+	}
+
+	if next := schedule.Next(at); !next.IsZero() {
+		t.Errorf("Zero time must be returned after the first call: %s.", next)
+	}
+
+	past := &onceSchedule{at: now.Add(-1 * time.Hour)}
+	if next := past.Next(now); !next.Equal(now) {
+		t.Errorf("Given time must be returned for a past schedule: %s.", next)
+	}
+}
@@ -0,0 +1,356 @@
+package sarah
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDialect is the JSON Schema version that ConfigSchema produces.
+const jsonSchemaDialect = "http://json-schema.org/draft-07/schema#"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// ConfigSchemaProvider is an optional interface that a configuration struct may implement to provide its own JSON Schema
+// instead of the one ConfigSchema derives from the struct.
+type ConfigSchemaProvider interface {
+	ConfigSchema() map[string]interface{}
+}
+
+// ConfigSchema returns the JSON Schema of the given configuration struct such as a CommandConfig or a TaskConfig.
+// Ops can validate a YAML or JSON configuration file with the schema before the deployment.
+//
+// The schema is derived from the struct with reflection.
+// Each field is named after its yaml or json tag just as ConfigLoader and ConfigWatcher read the files, and a field tagged with "-" is skipped.
+// The current non-zero values of the given struct, which are usually the defaults set by its constructor, are given as the defaults except for Secret.
+// Additionally, below struct tags are recognized:
+//
+//   - description gives the description of the field.
+//
+//   - jsonschema gives the comma-separated options: "required" to mark the field required,
+//     "enum=a|b" to list the allowed values, and "minimum=0" and "maximum=10" to limit a number.
+//
+//     type Config struct {
+//     Token   sarah.Secret  `yaml:"token" jsonschema:"required" description:"API token"`
+//     Unit    string        `yaml:"unit" jsonschema:"enum=metric|imperial"`
+//     Timeout time.Duration `yaml:"timeout" description:"Timeout of an API call such as 5s"`
+//     }
+//
+// The struct does not accept the keys that are not defined, so a typo in the file is reported.
+// Implement ConfigSchemaProvider when the derived schema is not sufficient.
+func ConfigSchema(config interface{}) map[string]interface{} {
+	var schema map[string]interface{}
+	if provider, ok := config.(ConfigSchemaProvider); ok {
+		schema = map[string]interface{}{}
+		for key, value := range provider.ConfigSchema() {
+			schema[key] = value
+		}
+	} else {
+		v := reflect.ValueOf(config)
+		if !v.IsValid() {
+			schema = map[string]interface{}{}
+		} else {
+			schema = valueSchema(v.Type(), v, map[reflect.Type]bool{})
+		}
+	}
+
+	schema["$schema"] = jsonSchemaDialect
+	return schema
+}
+
+// valueSchema returns the JSON Schema of the given type.
+// v holds the current value to give the defaults, and is invalid when the value is not available.
+func valueSchema(t reflect.Type, v reflect.Value, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsValid() && !v.IsNil() {
+			v = v.Elem()
+		} else {
+			v = reflect.Value{}
+		}
+	}
+
+	schema := map[string]interface{}{}
+	switch {
+	case t == durationType:
+		schema["type"] = []string{"string", "integer"}
+		if v.IsValid() && v.Int() != 0 {
+			schema["default"] = time.Duration(v.Int()).String()
+		}
+		return schema
+
+	case t == timeType:
+		schema["type"] = "string"
+		schema["format"] = "date-time"
+		return schema
+
+	case t == secretType:
+		// Never expose the credential as a default.
+		schema["type"] = "string"
+		return schema
+
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		schema["type"] = "boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+		schema["minimum"] = 0
+
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+
+	case reflect.String:
+		schema["type"] = "string"
+
+	case reflect.Slice, reflect.Array:
+		schema["type"] = "array"
+		schema["items"] = valueSchema(t.Elem(), reflect.Value{}, visiting)
+		return schema
+
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = valueSchema(t.Elem(), reflect.Value{}, visiting)
+		return schema
+
+	case reflect.Struct:
+		return structSchema(t, v, visiting)
+
+	case reflect.Interface:
+		if v.IsValid() && !v.IsNil() {
+			return valueSchema(v.Elem().Type(), v.Elem(), visiting)
+		}
+		// Any value is accepted.
+		return schema
+
+	default:
+		return schema
+
+	}
+
+	if v.IsValid() && v.CanInterface() && !isZeroValue(v) {
+		schema["default"] = v.Interface()
+	}
+	return schema
+}
+
+func structSchema(t reflect.Type, v reflect.Value, visiting map[reflect.Type]bool) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type": "object",
+	}
+	if visiting[t] {
+		// A recursive struct. Leave the nested one unconstrained.
+		return schema
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline, ok := configKeyName(field)
+		if !ok {
+			continue
+		}
+
+		var fieldValue reflect.Value
+		if v.IsValid() {
+			fieldValue = v.Field(i)
+		}
+		fieldSchema := valueSchema(field.Type, fieldValue, visiting)
+
+		if inline {
+			// The fields of an embedded struct are placed at the same level.
+			if nested, ok := fieldSchema["properties"].(map[string]interface{}); ok {
+				for key, value := range nested {
+					properties[key] = value
+				}
+			}
+			if nested, ok := fieldSchema["required"].([]string); ok {
+				required = append(required, nested...)
+			}
+			continue
+		}
+
+		if description, ok := field.Tag.Lookup("description"); ok {
+			fieldSchema["description"] = description
+		}
+		if applySchemaTag(fieldSchema, field.Tag.Get("jsonschema")) {
+			required = append(required, name)
+		}
+		properties[name] = fieldSchema
+	}
+
+	schema["properties"] = properties
+	schema["additionalProperties"] = false
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applySchemaTag applies the options given by the jsonschema struct tag, and tells if the field is required.
+func applySchemaTag(schema map[string]interface{}, tag string) bool {
+	required := false
+	for _, option := range strings.Split(tag, ",") {
+		key, value := option, ""
+		if i := strings.Index(option, "="); i >= 0 {
+			key, value = option[:i], option[i+1:]
+		}
+
+		switch key {
+		case "required":
+			required = true
+
+		case "enum":
+			var enum []interface{}
+			for _, e := range strings.Split(value, "|") {
+				enum = append(enum, schemaValue(schema, e))
+			}
+			schema["enum"] = enum
+
+		case "minimum", "maximum":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				schema[key] = n
+			}
+
+		}
+	}
+	return required
+}
+
+// schemaValue converts the given string to the type of the given schema.
+func schemaValue(schema map[string]interface{}, value string) interface{} {
+	switch schema["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+
+	}
+	return value
+}
+
+func isZeroValue(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// PluginConfigSchema is the JSON Schema of a registered CommandProps' or ScheduledTaskProps' configuration.
+type PluginConfigSchema struct {
+	// Kind is either "command" or "scheduled task".
+	Kind       string                 `json:"kind"`
+	BotType    BotType                `json:"bot_type"`
+	Identifier string                 `json:"identifier"`
+	Schema     map[string]interface{} `json:"schema"`
+}
+
+// PluginConfigSchemas returns the JSON Schemas of the configurations of the registered CommandProps and ScheduledTaskProps for each registered Bot.
+// Each schema corresponds to the configuration file that the ConfigWatcher reads with the BotType and the identifier.
+// The plugins without a configuration are not included.
+//
+// This sets up the registered components without running them just as sarah.Verify() does,
+// so a command line option of the application can dump the schemas and exit:
+//
+//  if *dumpSchemas {
+//  	schemas, err := sarah.PluginConfigSchemas(config)
+//  	// Handle the error
+//  	_ = json.NewEncoder(os.Stdout).Encode(schemas)
+//  	return
+//  }
+//
+// See NewConfigSchemaHandler to serve the schemas over HTTP.
+func PluginConfigSchemas(config *Config) ([]*PluginConfigSchema, error) {
+	r, _, err := prepareRunner(config)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := []*PluginConfigSchema{}
+	for _, bot := range r.bots {
+		botType := bot.BotType()
+		for _, p := range r.botCommandProps(botType) {
+			if p.config == nil {
+				continue
+			}
+			schemas = append(schemas, &PluginConfigSchema{
+				Kind:       kindCommand,
+				BotType:    botType,
+				Identifier: p.identifier,
+				Schema:     ConfigSchema(p.config),
+			})
+		}
+
+		for _, p := range r.botScheduledTaskProps(botType) {
+			if p.config == nil {
+				continue
+			}
+			schemas = append(schemas, &PluginConfigSchema{
+				Kind:       kindScheduledTask,
+				BotType:    botType,
+				Identifier: p.identifier,
+				Schema:     ConfigSchema(p.config),
+			})
+		}
+	}
+	return schemas, nil
+}
+
+// NewConfigSchemaHandler creates and returns an http.Handler that responds with the JSON representation of PluginConfigSchemas.
+// With "bot_type" and "identifier" query parameters, this responds with the schema of the specified plugin only,
+// which can be directly given to a JSON Schema validator:
+//
+//  curl "http://localhost:8080/schemas?bot_type=slack&identifier=weather" > weather.schema.json
+//
+// This can be mounted on an existing server along with NewHealthHandler:
+//
+//  http.Handle("/schemas", sarah.NewConfigSchemaHandler(config))
+func NewConfigSchemaHandler(config *Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		schemas, err := PluginConfigSchemas(config)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var response interface{} = schemas
+		query := req.URL.Query()
+		if id := query.Get("identifier"); id != "" {
+			response = nil
+			for _, s := range schemas {
+				if s.Identifier == id && s.BotType.String() == query.Get("bot_type") {
+					response = s.Schema
+					break
+				}
+			}
+			if response == nil {
+				http.NotFound(w, req)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
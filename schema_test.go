@@ -0,0 +1,245 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type DummySchemaProvider struct{}
+
+func (*DummySchemaProvider) ConfigSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+
+func TestConfigSchema(t *testing.T) {
+	type Nested struct {
+		Enabled bool `yaml:"enabled"`
+	}
+	type Embedded struct {
+		Region string `yaml:"region" jsonschema:"required"`
+	}
+	type Config struct {
+		Embedded `yaml:",inline"`
+		Token    Secret            `yaml:"token" jsonschema:"required" description:"API token"`
+		Unit     string            `yaml:"unit" jsonschema:"enum=metric|imperial"`
+		Retry    uint              `json:"retry" jsonschema:"maximum=5"`
+		Level    int               `yaml:"level" jsonschema:"enum=1|2"`
+		Ratio    float64           `yaml:"ratio"`
+		Timeout  time.Duration     `yaml:"timeout"`
+		Since    time.Time         `yaml:"since"`
+		Tags     []string          `yaml:"tags"`
+		Labels   map[string]string `yaml:"labels"`
+		Nested   *Nested           `yaml:"nested"`
+		Any      interface{}       `yaml:"any"`
+		Ignored  string            `yaml:"-"`
+	}
+
+	schema := ConfigSchema(&Config{
+		Token:   "secret",
+		Unit:    "metric",
+		Timeout: 5 * time.Second,
+	})
+
+	if schema["$schema"] != jsonSchemaDialect {
+		t.Errorf("Unexpected $schema is given: %#v.", schema["$schema"])
+	}
+	if schema["type"] != "object" || schema["additionalProperties"] != false {
+		t.Errorf("Unexpected object schema is returned: %#v.", schema)
+	}
+	if !reflect.DeepEqual(schema["required"], []string{"region", "token"}) {
+		t.Errorf("Unexpected required fields are returned: %#v.", schema["required"])
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	expected := map[string]map[string]interface{}{
+		"region":  {"type": "string"},
+		"token":   {"type": "string", "description": "API token"},
+		"unit":    {"type": "string", "default": "metric", "enum": []interface{}{"metric", "imperial"}},
+		"retry":   {"type": "integer", "minimum": 0, "maximum": float64(5)},
+		"level":   {"type": "integer", "enum": []interface{}{int64(1), int64(2)}},
+		"ratio":   {"type": "number"},
+		"timeout": {"type": []string{"string", "integer"}, "default": "5s"},
+		"since":   {"type": "string", "format": "date-time"},
+		"tags":    {"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"labels":  {"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+		"nested": {
+			"type":                 "object",
+			"properties":           map[string]interface{}{"enabled": map[string]interface{}{"type": "boolean"}},
+			"additionalProperties": false,
+		},
+		"any": {},
+	}
+	if len(properties) != len(expected) {
+		t.Errorf("Unexpected properties are returned: %#v.", properties)
+	}
+	for name, e := range expected {
+		if !reflect.DeepEqual(properties[name], e) {
+			t.Errorf("Unexpected schema is returned for %s: %#v.", name, properties[name])
+		}
+	}
+}
+
+func TestConfigSchema_WithProvider(t *testing.T) {
+	schema := ConfigSchema(&DummySchemaProvider{})
+
+	expected := map[string]interface{}{"$schema": jsonSchemaDialect, "type": "object"}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("Unexpected schema is returned: %#v.", schema)
+	}
+}
+
+func TestConfigSchema_WithRecursiveStruct(t *testing.T) {
+	type Node struct {
+		Name     string  `yaml:"name"`
+		Children []*Node `yaml:"children"`
+	}
+
+	schema := ConfigSchema(&Node{})
+
+	children := schema["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	if !reflect.DeepEqual(children["items"], map[string]interface{}{"type": "object"}) {
+		t.Errorf("Unexpected schema is returned for the recursive field: %#v.", children)
+	}
+}
+
+func registerSchemaPlugins(botType BotType) {
+	RegisterBot(&DummyBot{BotTypeValue: botType})
+	RegisterCommandProps(NewCommandPropsBuilder().
+		BotType(botType).
+		Identifier("weather").
+		MatchPattern(regexp.MustCompile(".")).
+		ConfigurableFunc(&struct {
+			Token Secret `yaml:"token"`
+		}{}, func(_ context.Context, _ Input, _ CommandConfig) (*CommandResponse, error) {
+			return nil, nil
+		}).
+		Instruction("").
+		MustBuild())
+	RegisterCommandProps(NewCommandPropsBuilder().
+		BotType(botType).
+		Identifier("echo").
+		MatchPattern(regexp.MustCompile(".")).
+		Func(func(_ context.Context, _ Input) (*CommandResponse, error) {
+			return nil, nil
+		}).
+		Instruction("").
+		MustBuild())
+	RegisterScheduledTaskProps(NewScheduledTaskPropsBuilder().
+		BotType(botType).
+		Identifier("alarm").
+		Schedule("@daily").
+		ConfigurableFunc(&struct {
+			Count int `yaml:"count"`
+		}{}, func(_ context.Context, _ TaskConfig) ([]*ScheduledTaskResult, error) {
+			return nil, nil
+		}).
+		MustBuild())
+}
+
+func TestPluginConfigSchemas(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		registerSchemaPlugins(botType)
+
+		schemas, err := PluginConfigSchemas(&Config{TimeZone: time.UTC.String()})
+
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		if len(schemas) != 2 {
+			t.Fatalf("Unexpected number of schemas are returned: %d.", len(schemas))
+		}
+		if schemas[0].Kind != kindCommand || schemas[0].BotType != botType || schemas[0].Identifier != "weather" {
+			t.Errorf("Unexpected command schema is returned: %#v.", schemas[0])
+		}
+		if schemas[1].Kind != kindScheduledTask || schemas[1].BotType != botType || schemas[1].Identifier != "alarm" {
+			t.Errorf("Unexpected task schema is returned: %#v.", schemas[1])
+		}
+		if _, ok := schemas[1].Schema["properties"].(map[string]interface{})["count"]; !ok {
+			t.Errorf("Expected property is not given: %#v.", schemas[1].Schema)
+		}
+	})
+}
+
+func TestPluginConfigSchemas_WithInvalidConfig(t *testing.T) {
+	SetupAndRun(func() {
+		_, err := PluginConfigSchemas(&Config{TimeZone: "INVALID"})
+
+		if err == nil {
+			t.Error("Expected error is not returned.")
+		}
+	})
+}
+
+func TestNewConfigSchemaHandler(t *testing.T) {
+	tests := []struct {
+		path   string
+		config *Config
+		status int
+	}{
+		{
+			path:   "/schemas",
+			config: &Config{TimeZone: time.UTC.String()},
+			status: http.StatusOK,
+		},
+		{
+			path:   "/schemas?bot_type=dummy&identifier=alarm",
+			config: &Config{TimeZone: time.UTC.String()},
+			status: http.StatusOK,
+		},
+		{
+			path:   "/schemas?bot_type=dummy&identifier=unknown",
+			config: &Config{TimeZone: time.UTC.String()},
+			status: http.StatusNotFound,
+		},
+		{
+			path:   "/schemas",
+			config: &Config{TimeZone: "INVALID"},
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			SetupAndRun(func() {
+				registerSchemaPlugins("dummy")
+
+				recorder := httptest.NewRecorder()
+				NewConfigSchemaHandler(tt.config).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+				if recorder.Code != tt.status {
+					t.Fatalf("Unexpected status is returned on test #%d: %d.", i, recorder.Code)
+				}
+				if tt.status != http.StatusOK {
+					return
+				}
+
+				var body interface{}
+				if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+					t.Fatalf("Unexpected error is returned: %s.", err.Error())
+				}
+				switch typed := body.(type) {
+				case []interface{}:
+					if len(typed) != 2 {
+						t.Errorf("Unexpected schemas are returned: %#v.", typed)
+					}
+
+				case map[string]interface{}:
+					if typed["$schema"] != jsonSchemaDialect {
+						t.Errorf("Unexpected schema is returned: %#v.", typed)
+					}
+
+				default:
+					t.Errorf("Unexpected response is returned: %#v.", body)
+
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package sarah
+
+// BotScope selects the Bots that a Command or a ScheduledTask is registered to.
+// While RegisterCommand and CommandPropsBuilder.BotType tie a registration to one BotType,
+// a BotScope lets a common utility such as a help or a ping command be registered once and shared by multiple Bots.
+//
+// Use AllBots to select every registered Bot or BotGroup to select the Bots grouped by RegisterBotGroup.
+// BotScope.Except excludes specific Bots from the selection.
+//
+//  sarah.RegisterBotGroup("internal", slack.SLACK.Instance("staff"), gitter.GITTER)
+//  sarah.RegisterScopedCommand(sarah.AllBots().Except(gitter.GITTER), pingCommand)
+//  sarah.RegisterScopedCommand(sarah.BotGroup("internal"), deployCommand)
+type BotScope struct {
+	all      bool
+	groups   []string
+	excluded []BotType
+}
+
+// AllBots returns a BotScope that selects every registered Bot.
+func AllBots() *BotScope {
+	return &BotScope{
+		all: true,
+	}
+}
+
+// BotGroup returns a BotScope that selects the Bots belonging to any of the given groups.
+// Define a group and its member Bots with RegisterBotGroup.
+func BotGroup(names ...string) *BotScope {
+	return &BotScope{
+		groups: names,
+	}
+}
+
+// Except returns a BotScope that additionally excludes the Bots with the given BotTypes.
+// A base BotType such as "slack" excludes all of its instances while an instance-scoped BotType such as "slack/workspace-a" excludes only that instance.
+func (scope *BotScope) Except(botTypes ...BotType) *BotScope {
+	excluded := make([]BotType, 0, len(scope.excluded)+len(botTypes))
+	excluded = append(excluded, scope.excluded...)
+	return &BotScope{
+		all:      scope.all,
+		groups:   scope.groups,
+		excluded: append(excluded, botTypes...),
+	}
+}
+
+// includes tells if the Bot with the given BotType is selected by this scope.
+// groups maps each group name to its member BotTypes.
+func (scope *BotScope) includes(botType BotType, groups map[string][]BotType) bool {
+	for _, excluded := range scope.excluded {
+		if coversBotType(excluded, botType) {
+			return false
+		}
+	}
+
+	if scope.all {
+		return true
+	}
+
+	for _, name := range scope.groups {
+		for _, member := range groups[name] {
+			if coversBotType(member, botType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// coversBotType tells if the given BotType in a scope definition covers the BotType of a Bot.
+// A base BotType covers its instances.
+func coversBotType(defined BotType, botType BotType) bool {
+	return defined == botType || (defined.InstanceID() == "" && defined == botType.Base())
+}
+
+// scopedCommand is a Command registered with RegisterScopedCommand.
+type scopedCommand struct {
+	scope   *BotScope
+	command Command
+}
+
+// scopedScheduledTask is a ScheduledTask registered with RegisterScopedScheduledTask.
+type scopedScheduledTask struct {
+	scope *BotScope
+	task  ScheduledTask
+}
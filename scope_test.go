@@ -0,0 +1,111 @@
+package sarah
+
+import (
+	"testing"
+)
+
+func TestAllBots(t *testing.T) {
+	scope := AllBots()
+
+	for _, botType := range []BotType{"slack", BotType("slack").Instance("a"), "gitter"} {
+		if !scope.includes(botType, nil) {
+			t.Errorf("%s must be included.", botType)
+		}
+	}
+}
+
+func TestBotGroup(t *testing.T) {
+	groups := map[string][]BotType{
+		"internal": {"gitter", BotType("slack").Instance("staff")},
+		"public":   {"slack"},
+	}
+
+	tests := []struct {
+		scope    *BotScope
+		botType  BotType
+		included bool
+	}{
+		{
+			scope:    BotGroup("internal"),
+			botType:  "gitter",
+			included: true,
+		},
+		{
+			scope:    BotGroup("internal"),
+			botType:  BotType("slack").Instance("staff"),
+			included: true,
+		},
+		{
+			scope:    BotGroup("internal"),
+			botType:  BotType("slack").Instance("guest"),
+			included: false,
+		},
+		{
+			scope:    BotGroup("public"),
+			botType:  BotType("slack").Instance("guest"),
+			included: true,
+		},
+		{
+			scope:    BotGroup("internal", "public"),
+			botType:  "slack",
+			included: true,
+		},
+		{
+			scope:    BotGroup("undefined"),
+			botType:  "slack",
+			included: false,
+		},
+	}
+
+	for i, tt := range tests {
+		if tt.scope.includes(tt.botType, groups) != tt.included {
+			t.Errorf("Unexpected result is returned for %s on test #%d.", tt.botType, i)
+		}
+	}
+}
+
+func TestBotScope_Except(t *testing.T) {
+	original := AllBots()
+	scope := original.Except("gitter", BotType("slack").Instance("a"))
+
+	tests := []struct {
+		botType  BotType
+		included bool
+	}{
+		{
+			botType:  "gitter",
+			included: false,
+		},
+		{
+			botType:  BotType("gitter").Instance("a"),
+			included: false,
+		},
+		{
+			botType:  BotType("slack").Instance("a"),
+			included: false,
+		},
+		{
+			botType:  BotType("slack").Instance("b"),
+			included: true,
+		},
+		{
+			botType:  "slack",
+			included: true,
+		},
+	}
+
+	for _, tt := range tests {
+		if scope.includes(tt.botType, nil) != tt.included {
+			t.Errorf("Unexpected result is returned for %s.", tt.botType)
+		}
+	}
+
+	if len(original.excluded) != 0 {
+		t.Error("Original BotScope must not be modified.")
+	}
+
+	accumulated := scope.Except("slack")
+	if accumulated.includes("gitter", nil) || accumulated.includes(BotType("slack").Instance("b"), nil) {
+		t.Error("Exclusions must accumulate.")
+	}
+}
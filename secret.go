@@ -0,0 +1,148 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"reflect"
+	"strings"
+)
+
+// redacted is the placeholder that is shown instead of a Secret's value.
+const redacted = "[REDACTED]"
+
+// Secret is a string that holds a credential such as an API token.
+// The value is masked when the Secret is printed with fmt, or is encoded to JSON or YAML, so a dump of a configuration struct with %v or %#v does not leak the credential.
+// Call Reveal to get the actual value.
+//
+// A configuration value may be a reference to the credential such as "env:SLACK_TOKEN" instead of the credential itself.
+// ResolveSecrets replaces such references with the values that SecretResolver resolves.
+type Secret string
+
+// String returns a masked form of the Secret. An empty Secret stays empty so a missing credential can still be told from the logs.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString returns a masked form of the Secret for %#v.
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+// MarshalJSON returns a masked form of the Secret.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// MarshalYAML returns a masked form of the Secret.
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// Reveal returns the actual value of the Secret.
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// SecretResolver defines an interface that resolves a reference to a secret such as "env:SLACK_TOKEN" or "vault:secret/data/sarah#slack_token".
+// The secrets package provides the implementations for environment variables, files and HashiCorp Vault.
+type SecretResolver interface {
+	// ResolveSecret returns the secret that the given reference points to.
+	ResolveSecret(ctx context.Context, ref string) (string, error)
+}
+
+// ResolveSecrets walks through the given configuration struct and replaces the value of each Secret field with the value resolved by the given SecretResolver.
+// The resolved values are also passed to logging.Redact so they are masked in the log entries even when they are embedded in an error message.
+// Call this at startup for the Adapter's configuration before the Adapter is created:
+//
+//  config := slack.NewConfig()
+//  _ = yaml.Unmarshal(body, config) // token: "vault:secret/data/sarah#slack_token"
+//  err := sarah.ResolveSecrets(ctx, config, secrets.NewResolver(secrets.WithResolver("vault", vaultResolver)))
+//
+// The configurations of CommandProps and ScheduledTaskProps are resolved on every (re)load when SecretResolver is registered with RegisterSecretResolver.
+// When any Secret fails to be resolved, ConfigKeyErrors is returned and each ConfigKeyError.Key is the yaml key path of the Secret.
+func ResolveSecrets(ctx context.Context, config interface{}, resolver SecretResolver) error {
+	errs := resolveSecrets(ctx, reflect.ValueOf(config), nil, resolver, map[visitedConfig]bool{})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+func resolveSecrets(ctx context.Context, v reflect.Value, path []string, resolver SecretResolver, visited map[visitedConfig]bool) ConfigKeyErrors {
+	if !v.IsValid() {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == secretType {
+		if !v.CanSet() || v.String() == "" {
+			return nil
+		}
+
+		resolved, err := resolver.ResolveSecret(ctx, v.String())
+		if err != nil {
+			return ConfigKeyErrors{&ConfigKeyError{Key: strings.Join(path, "."), Err: err}}
+		}
+		logging.Redact(resolved)
+		v.SetString(resolved)
+		return nil
+	}
+
+	if v.Kind() != reflect.Struct || !v.CanAddr() {
+		return nil
+	}
+
+	key := visitedConfig{pointer: v.Addr().Pointer(), typ: v.Type()}
+	if visited[key] {
+		return nil
+	}
+	visited[key] = true
+
+	var errs ConfigKeyErrors
+	for i := 0; i < v.NumField(); i++ {
+		name, inline, ok := configKeyName(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		fieldPath := path
+		if !inline {
+			fieldPath = append(append([]string{}, path...), name)
+		}
+		errs = append(errs, resolveSecrets(ctx, v.Field(i), fieldPath, resolver, visited)...)
+	}
+
+	return errs
+}
+
+// secretResolvingWatcher wraps ConfigWatcher to resolve the Secrets in the configuration on every read.
+type secretResolvingWatcher struct {
+	ConfigWatcher
+	resolver SecretResolver
+}
+
+func (w *secretResolvingWatcher) Read(ctx context.Context, botType BotType, id string, configPtr interface{}) error {
+	err := w.ConfigWatcher.Read(ctx, botType, id, configPtr)
+	if err != nil {
+		return err
+	}
+
+	err = ResolveSecrets(ctx, configPtr, w.resolver)
+	if err != nil {
+		return fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package sarah
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"strings"
+	"testing"
+)
+
+type DummySecretResolver struct {
+	ResolveSecretFunc func(context.Context, string) (string, error)
+}
+
+func (r *DummySecretResolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	return r.ResolveSecretFunc(ctx, ref)
+}
+
+type secretConfig struct {
+	Token    Secret `yaml:"token"`
+	Empty    Secret `yaml:"empty"`
+	Nested   *nestedSecretConfig
+	Ignored  Secret `yaml:"-"`
+	embedded `yaml:",inline"`
+}
+
+type nestedSecretConfig struct {
+	APIKey Secret `yaml:"api_key"`
+}
+
+type embedded struct {
+	Password Secret `yaml:"password"`
+}
+
+func TestSecret_Format(t *testing.T) {
+	config := &secretConfig{
+		Token: "xoxb-secret",
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		str := fmt.Sprintf(format, config)
+		if strings.Contains(str, "xoxb-secret") {
+			t.Errorf("Secret is revealed with %s: %s.", format, str)
+		}
+	}
+
+	if config.Token.Reveal() != "xoxb-secret" {
+		t.Errorf("Unexpected value is revealed: %s.", config.Token.Reveal())
+	}
+
+	if config.Empty.String() != "" {
+		t.Errorf("Empty Secret must stay empty: %s.", config.Empty.String())
+	}
+}
+
+func TestSecret_Marshal(t *testing.T) {
+	config := &secretConfig{
+		Token: "xoxb-secret",
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Contains(string(b), "xoxb-secret") {
+		t.Errorf("Secret is revealed in JSON: %s.", string(b))
+	}
+
+	b, err = yaml.Marshal(config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Contains(string(b), "xoxb-secret") {
+		t.Errorf("Secret is revealed in YAML: %s.", string(b))
+	}
+
+	decoded := &secretConfig{}
+	err = yaml.Unmarshal([]byte("token: xoxb-secret"), decoded)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if decoded.Token.Reveal() != "xoxb-secret" {
+		t.Errorf("Secret is not decoded: %s.", decoded.Token.Reveal())
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	config := &secretConfig{
+		Token:   "env:TOKEN",
+		Nested:  &nestedSecretConfig{APIKey: "env:API_KEY"},
+		Ignored: "env:IGNORED",
+		embedded: embedded{
+			Password: "env:PASSWORD",
+		},
+	}
+	var refs []string
+	resolver := &DummySecretResolver{
+		ResolveSecretFunc: func(_ context.Context, ref string) (string, error) {
+			refs = append(refs, ref)
+			return "dummy-" + strings.ToLower(strings.TrimPrefix(ref, "env:")), nil
+		},
+	}
+
+	err := ResolveSecrets(context.TODO(), config, resolver)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(refs) != 3 {
+		t.Errorf("Unexpected references are resolved: %v.", refs)
+	}
+
+	if config.Token.Reveal() != "dummy-token" || config.Nested.APIKey.Reveal() != "dummy-api_key" || config.Password.Reveal() != "dummy-password" {
+		t.Errorf("Secrets are not resolved: %s, %s, %s.", config.Token.Reveal(), config.Nested.APIKey.Reveal(), config.Password.Reveal())
+	}
+
+	if config.Ignored.Reveal() != "env:IGNORED" {
+		t.Errorf("Ignored field must not be resolved: %s.", config.Ignored.Reveal())
+	}
+}
+
+func TestResolveSecrets_Error(t *testing.T) {
+	config := &secretConfig{
+		Token:  "env:TOKEN",
+		Nested: &nestedSecretConfig{APIKey: "env:API_KEY"},
+	}
+	notFound := errors.New("not found")
+	resolver := &DummySecretResolver{
+		ResolveSecretFunc: func(_ context.Context, ref string) (string, error) {
+			if ref == "env:API_KEY" {
+				return "", notFound
+			}
+			return "dummy-token", nil
+		},
+	}
+
+	err := ResolveSecrets(context.TODO(), config, resolver)
+
+	errs, ok := err.(ConfigKeyErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	if errs[0].Key != "nested.api_key" || !errors.Is(errs[0], notFound) {
+		t.Errorf("Unexpected error is returned: %#v.", errs[0])
+	}
+}
+
+func TestSecretResolvingWatcher_Read(t *testing.T) {
+	watcher := &secretResolvingWatcher{
+		ConfigWatcher: &DummyConfigWatcher{
+			ReadFunc: func(_ context.Context, _ BotType, _ string, configPtr interface{}) error {
+				return yaml.Unmarshal([]byte("token: env:TOKEN"), configPtr)
+			},
+		},
+		resolver: &DummySecretResolver{
+			ResolveSecretFunc: func(_ context.Context, _ string) (string, error) {
+				return "dummy-resolved", nil
+			},
+		},
+	}
+
+	config := &secretConfig{}
+	err := watcher.Read(context.TODO(), "dummy", "id", config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if config.Token.Reveal() != "dummy-resolved" {
+		t.Errorf("Secret is not resolved: %s.", config.Token.Reveal())
+	}
+}
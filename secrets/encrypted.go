@@ -0,0 +1,205 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EncryptedScheme is the scheme of an encrypted value such as "enc:default:AbCd...".
+// WithKeyProvider registers the decrypting resolver for this scheme.
+const EncryptedScheme = "enc"
+
+// ErrKeyNotFound is returned by KeyProvider when no key is stored with the given ID.
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyProvider defines an interface that provides the key to decrypt the encrypted values.
+// A key is an AES key of 16, 24 or 32 bytes; GenerateKey returns a 32 bytes key for AES-256.
+type KeyProvider interface {
+	// Key returns the key with the given ID. ErrKeyNotFound must be returned when no key is stored.
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// KeyProviderFunc is a function that satisfies KeyProvider interface.
+type KeyProviderFunc func(ctx context.Context, id string) ([]byte, error)
+
+// Key calls the function itself.
+func (f KeyProviderFunc) Key(ctx context.Context, id string) ([]byte, error) {
+	return f(ctx, id)
+}
+
+// NewStaticKeyProvider creates and returns a new KeyProvider that returns the given keys.
+func NewStaticKeyProvider(keys map[string][]byte) KeyProvider {
+	return KeyProviderFunc(func(_ context.Context, id string) ([]byte, error) {
+		key, ok := keys[id]
+		if !ok {
+			return nil, fmt.Errorf("key %s is not given: %w", id, ErrKeyNotFound)
+		}
+		return key, nil
+	})
+}
+
+// NewEnvKeyProvider creates and returns a new KeyProvider that reads the base64-encoded key from the environment variable.
+// The variable name is the given prefix followed by the upper-cased key ID, e.g. "SARAH_KEY_DEFAULT" for the key "default" with the prefix "SARAH_KEY_".
+func NewEnvKeyProvider(prefix string) KeyProvider {
+	return KeyProviderFunc(func(_ context.Context, id string) ([]byte, error) {
+		name := prefix + strings.ToUpper(id)
+		encoded, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set: %w", name, ErrKeyNotFound)
+		}
+		return decodeKey(encoded)
+	})
+}
+
+// NewFileKeyProvider creates and returns a new KeyProvider that reads the base64-encoded key from the file named after the key ID in the given directory.
+// This suits a key mounted as a Kubernetes Secret.
+func NewFileKeyProvider(dir string) KeyProvider {
+	return KeyProviderFunc(func(_ context.Context, id string) ([]byte, error) {
+		if id != filepath.Base(id) {
+			return nil, fmt.Errorf("invalid key ID: %s", id)
+		}
+
+		path := filepath.Join(dir, id)
+		b, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file %s does not exist: %w", path, ErrKeyNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return decodeKey(string(b))
+	})
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+	return key, nil
+}
+
+// WithKeyProvider creates a ResolverOption that registers the resolver returned by NewDecryptResolver for EncryptedScheme.
+func WithKeyProvider(provider KeyProvider) ResolverOption {
+	return WithResolver(EncryptedScheme, NewDecryptResolver(provider))
+}
+
+// NewDecryptResolver creates and returns a new sarah.SecretResolver that decrypts a value encrypted by Encrypt.
+// A reference is formed as "<key ID>:<base64-encoded nonce and ciphertext>", which is what Encrypt returns without "enc:" prefix.
+// The value is decrypted with AES-GCM and the key that the given KeyProvider returns, so a plugin configuration file can be committed
+// with its API key encrypted, and the key is kept out of the repository:
+//
+//  # config/slack/weather.yaml
+//  api_key: "enc:default:0vTq...=="
+//
+// Since the key ID is part of the value, a key can be rotated by encrypting the values with a new key ID while the old key is still provided.
+func NewDecryptResolver(provider KeyProvider) sarah.SecretResolver {
+	return &decryptResolver{
+		provider: provider,
+	}
+}
+
+type decryptResolver struct {
+	provider KeyProvider
+}
+
+var _ sarah.SecretResolver = (*decryptResolver)(nil)
+
+func (r *decryptResolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	i := strings.Index(ref, ":")
+	if i < 0 {
+		return "", errors.New(`invalid encrypted value: the value must be formed as "enc:<key ID>:<ciphertext>"`)
+	}
+	keyID, encoded := ref[:i], ref[i+1:]
+
+	key, err := r.provider.Key(ctx, keyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get key %s: %w", keyID, err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+
+	if len(b) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	nonce, ciphertext := b[:aead.NonceSize()], b[aead.NonceSize():]
+
+	// The key ID is authenticated so the value can not be moved to another key ID.
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value with key %s: %w", keyID, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Encrypt encrypts the given plaintext with the given key and returns the value to be written in the configuration file.
+// The returned value is formed as "enc:<key ID>:<base64-encoded nonce and ciphertext>" and is decrypted by the resolver that NewDecryptResolver returns.
+// A tiny program such as below can be used to encrypt a value:
+//
+//  key, _ := base64.StdEncoding.DecodeString(os.Getenv("SARAH_KEY_DEFAULT"))
+//  value, _ := secrets.Encrypt(key, "default", os.Args[1])
+//  fmt.Println(value)
+func Encrypt(key []byte, keyID string, plaintext string) (string, error) {
+	if keyID == "" || strings.Contains(keyID, ":") {
+		return "", fmt.Errorf("invalid key ID: %s", keyID)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(keyID))
+	return EncryptedScheme + ":" + keyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// GenerateKey generates and returns a new random 32 bytes key for AES-256.
+// Encode the key with base64 to pass it to NewEnvKeyProvider or NewFileKeyProvider.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	_, err := io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize AES-GCM: %w", err)
+	}
+
+	return aead, nil
+}
@@ -0,0 +1,214 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	value, err := Encrypt(key, "default", "dummyAPIKey")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if !strings.HasPrefix(value, "enc:default:") || strings.Contains(value, "dummyAPIKey") {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	another, _ := Encrypt(key, "default", "dummyAPIKey")
+	if value == another {
+		t.Error("The same ciphertext is returned for the same plaintext.")
+	}
+
+	for _, keyID := range []string{"", "invalid:id"} {
+		_, err := Encrypt(key, keyID, "dummyAPIKey")
+		if err == nil {
+			t.Errorf("Expected error is not returned for key ID %q.", keyID)
+		}
+	}
+
+	_, err = Encrypt([]byte("short"), "default", "dummyAPIKey")
+	if err == nil {
+		t.Error("Expected error is not returned for an invalid key.")
+	}
+}
+
+func TestDecryptResolver_ResolveSecret(t *testing.T) {
+	key, _ := GenerateKey()
+	oldKey, _ := GenerateKey()
+	provider := NewStaticKeyProvider(map[string][]byte{
+		"default": key,
+		"old":     oldKey,
+	})
+	r := NewResolver(WithKeyProvider(provider))
+
+	encrypted, _ := Encrypt(key, "default", "dummyAPIKey")
+	encryptedWithOld, _ := Encrypt(oldKey, "old", "oldAPIKey")
+	encryptedWithUnknown, _ := Encrypt(key, "unknown", "dummyAPIKey")
+
+	tests := []struct {
+		ref      string
+		value    string
+		notFound bool
+		hasErr   bool
+	}{
+		{
+			ref:   encrypted,
+			value: "dummyAPIKey",
+		},
+		{
+			ref:   encryptedWithOld,
+			value: "oldAPIKey",
+		},
+		{
+			ref:      encryptedWithUnknown,
+			notFound: true,
+		},
+		{
+			// The key ID is swapped.
+			ref:    strings.Replace(encryptedWithOld, "enc:old:", "enc:default:", 1),
+			hasErr: true,
+		},
+		{
+			// The ciphertext is tampered.
+			ref:    encrypted[:len(encrypted)-4] + "AAA=",
+			hasErr: true,
+		},
+		{
+			ref:    "enc:default:" + base64.StdEncoding.EncodeToString([]byte("short")),
+			hasErr: true,
+		},
+		{
+			ref:    "enc:invalid",
+			hasErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		value, err := r.ResolveSecret(context.TODO(), tt.ref)
+
+		switch {
+		case tt.notFound:
+			if !errors.Is(err, ErrKeyNotFound) {
+				t.Errorf("Expected error is not returned on test #%d: %#v.", i, err)
+			}
+
+		case tt.hasErr:
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+
+		default:
+			if err != nil {
+				t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+				continue
+			}
+			if value != tt.value {
+				t.Errorf("Unexpected value is returned on test #%d: %s.", i, value)
+			}
+
+		}
+	}
+}
+
+func TestDecryptResolver_PluginConfig(t *testing.T) {
+	key, _ := GenerateKey()
+	encrypted, _ := Encrypt(key, "default", "dummyAPIKey")
+	body := "api_key: " + encrypted + "\nlocation: tokyo\n"
+
+	config := &struct {
+		APIKey   sarah.Secret `yaml:"api_key"`
+		Location string       `yaml:"location"`
+	}{}
+	err := yaml.Unmarshal([]byte(body), config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	r := NewResolver(WithKeyProvider(NewStaticKeyProvider(map[string][]byte{"default": key})))
+	err = sarah.ResolveSecrets(context.TODO(), config, r)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if config.APIKey.Reveal() != "dummyAPIKey" {
+		t.Errorf("Encrypted value is not decrypted: %s.", config.APIKey.Reveal())
+	}
+}
+
+func TestNewEnvKeyProvider(t *testing.T) {
+	key, _ := GenerateKey()
+	_ = os.Setenv("SARAH_DUMMY_KEY_DEFAULT", base64.StdEncoding.EncodeToString(key))
+	_ = os.Setenv("SARAH_DUMMY_KEY_BROKEN", "not base64")
+	defer func() {
+		_ = os.Unsetenv("SARAH_DUMMY_KEY_DEFAULT")
+		_ = os.Unsetenv("SARAH_DUMMY_KEY_BROKEN")
+	}()
+
+	provider := NewEnvKeyProvider("SARAH_DUMMY_KEY_")
+
+	given, err := provider.Key(context.TODO(), "default")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(given) != string(key) {
+		t.Error("Unexpected key is returned.")
+	}
+
+	_, err = provider.Key(context.TODO(), "unknown")
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	_, err = provider.Key(context.TODO(), "broken")
+	if err == nil || errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestNewFileKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	key, _ := GenerateKey()
+	err = ioutil.WriteFile(filepath.Join(dir, "default"), []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write file: %s.", err.Error())
+	}
+
+	provider := NewFileKeyProvider(dir)
+
+	given, err := provider.Key(context.TODO(), "default")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(given) != string(key) {
+		t.Error("Unexpected key is returned.")
+	}
+
+	_, err = provider.Key(context.TODO(), "unknown")
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	_, err = provider.Key(context.TODO(), "../default")
+	if err == nil {
+		t.Error("Expected error is not returned for a key ID with a path.")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Package secrets provides sarah.SecretResolver implementations that read the secrets from environment variables, files and HashiCorp Vault.
+
+NewResolver returns a sarah.SecretResolver that dispatches a reference to the resolver registered for its scheme.
+A value without a registered scheme is returned as-is so a credential written directly in the configuration keeps working:
+
+	vaultResolver := secrets.NewVaultResolver(secrets.NewVaultConfig())
+	resolver := secrets.NewResolver(secrets.WithResolver("vault", vaultResolver))
+
+	// token: "env:SLACK_TOKEN"                            ... Reads environment variable SLACK_TOKEN.
+	// token: "file:/run/secrets/slack_token"             ... Reads the file content.
+	// token: "vault:secret/data/sarah#slack_token"       ... Reads slack_token field of the Vault secret.
+	err := sarah.ResolveSecrets(ctx, slackConfig, resolver)
+
+	// Resolve the secrets in the configurations of Commands and ScheduledTasks on every (re)load.
+	sarah.RegisterSecretResolver(resolver)
+
+A plugin configuration file can also hold an encrypted value so the file can be committed safely.
+Encrypt returns a value such as "enc:default:0vTq...==", and the resolver registered with WithKeyProvider decrypts it with the key that KeyProvider returns.
+As with other references, the field must be typed sarah.Secret to be resolved:
+
+	resolver := secrets.NewResolver(secrets.WithKeyProvider(secrets.NewEnvKeyProvider("SARAH_KEY_")))
+	sarah.RegisterSecretResolver(resolver)
+*/
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ErrSecretNotFound is returned when the referenced secret does not exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// ResolverOption defines function signature that NewResolver's functional option must satisfy.
+type ResolverOption func(*resolver)
+
+// WithResolver creates a ResolverOption that registers the given sarah.SecretResolver for the given scheme.
+// A reference "<scheme>:<rest>" is passed to the resolver without the "<scheme>:" prefix.
+// This replaces the default resolver when "env" or "file" is given.
+func WithResolver(scheme string, r sarah.SecretResolver) ResolverOption {
+	return func(res *resolver) {
+		res.resolvers[scheme] = r
+	}
+}
+
+// NewResolver creates and returns a new sarah.SecretResolver that dispatches a reference to the resolver registered for the reference's scheme.
+// The resolvers returned by NewEnvResolver and NewFileResolver are registered for "env" and "file" schemes by default.
+// A reference without a registered scheme is returned as-is.
+func NewResolver(options ...ResolverOption) sarah.SecretResolver {
+	r := &resolver{
+		resolvers: map[string]sarah.SecretResolver{
+			"env":  NewEnvResolver(),
+			"file": NewFileResolver(),
+		},
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+type resolver struct {
+	resolvers map[string]sarah.SecretResolver
+}
+
+var _ sarah.SecretResolver = (*resolver)(nil)
+
+func (r *resolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	i := strings.Index(ref, ":")
+	if i < 0 {
+		return ref, nil
+	}
+
+	res, ok := r.resolvers[ref[:i]]
+	if !ok {
+		// Not a reference but a secret that happens to contain a colon.
+		return ref, nil
+	}
+
+	return res.ResolveSecret(ctx, ref[i+1:])
+}
+
+// NewEnvResolver creates and returns a new sarah.SecretResolver that reads the environment variable with the given name.
+// ErrSecretNotFound is returned when the variable is not set.
+func NewEnvResolver() sarah.SecretResolver {
+	return &envResolver{}
+}
+
+type envResolver struct{}
+
+var _ sarah.SecretResolver = (*envResolver)(nil)
+
+func (*envResolver) ResolveSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set: %w", name, ErrSecretNotFound)
+	}
+	return value, nil
+}
+
+// NewFileResolver creates and returns a new sarah.SecretResolver that reads the file at the given path such as a mounted Kubernetes Secret or a Docker secret.
+// The trailing newline is trimmed. ErrSecretNotFound is returned when the file does not exist.
+func NewFileResolver() sarah.SecretResolver {
+	return &fileResolver{}
+}
+
+type fileResolver struct{}
+
+var _ sarah.SecretResolver = (*fileResolver)(nil)
+
+func (*fileResolver) ResolveSecret(_ context.Context, path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("file %s does not exist: %w", path, ErrSecretNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type DummySecretResolver struct {
+	ResolveSecretFunc func(context.Context, string) (string, error)
+}
+
+func (r *DummySecretResolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	return r.ResolveSecretFunc(ctx, ref)
+}
+
+func TestNewResolver(t *testing.T) {
+	var given string
+	vault := &DummySecretResolver{
+		ResolveSecretFunc: func(_ context.Context, ref string) (string, error) {
+			given = ref
+			return "fromVault", nil
+		},
+	}
+	r := NewResolver(WithResolver("vault", vault))
+
+	value, err := r.ResolveSecret(context.TODO(), "vault:secret/data/sarah#token")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if value != "fromVault" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+	if given != "secret/data/sarah#token" {
+		t.Errorf("Scheme is not trimmed: %s.", given)
+	}
+
+	for _, literal := range []string{"xoxb-literal", "unknown:value"} {
+		value, err := r.ResolveSecret(context.TODO(), literal)
+		if err != nil {
+			t.Errorf("Unexpected error is returned for %s: %s.", literal, err.Error())
+		}
+		if value != literal {
+			t.Errorf("Literal value must be returned as-is: %s.", value)
+		}
+	}
+}
+
+func TestEnvResolver_ResolveSecret(t *testing.T) {
+	_ = os.Setenv("SARAH_DUMMY_SECRET", "envSecret")
+	defer os.Unsetenv("SARAH_DUMMY_SECRET")
+
+	r := NewResolver()
+
+	value, err := r.ResolveSecret(context.TODO(), "env:SARAH_DUMMY_SECRET")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if value != "envSecret" {
+		t.Errorf("Unexpected value is returned: %s.", value)
+	}
+
+	_, err = r.ResolveSecret(context.TODO(), "env:SARAH_UNKNOWN_SECRET")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestFileResolver_ResolveSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	err = ioutil.WriteFile(path, []byte("fileSecret\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write file: %s.", err.Error())
+	}
+
+	r := NewResolver()
+
+	value, err := r.ResolveSecret(context.TODO(), "file:"+path)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if value != "fileSecret" {
+		t.Errorf("Unexpected value is returned: %q.", value)
+	}
+
+	_, err = r.ResolveSecret(context.TODO(), "file:"+filepath.Join(dir, "unknown"))
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
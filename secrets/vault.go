@@ -0,0 +1,145 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultConfig contains some configuration variables for HashiCorp Vault SecretResolver.
+type VaultConfig struct {
+	// Address is the base URL of Vault such as "https://vault.example.com:8200".
+	Address string `json:"address" yaml:"address"`
+
+	// Token is the Vault token sent as X-Vault-Token header.
+	Token sarah.Secret `json:"token" yaml:"token"`
+
+	// Namespace is sent as X-Vault-Namespace header when set. This is only used by Vault Enterprise.
+	Namespace string `json:"namespace" yaml:"namespace"`
+
+	// RequestTimeout is the timeout of each request to Vault.
+	RequestTimeout time.Duration `json:"request_timeout" yaml:"request_timeout"`
+}
+
+// NewVaultConfig returns VaultConfig with default settings.
+// Address and Token are read from VAULT_ADDR and VAULT_TOKEN environment variables just like Vault's CLI does.
+func NewVaultConfig() *VaultConfig {
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		address = "http://127.0.0.1:8200"
+	}
+
+	return &VaultConfig{
+		Address:        address,
+		Token:          sarah.Secret(os.Getenv("VAULT_TOKEN")),
+		Namespace:      os.Getenv("VAULT_NAMESPACE"),
+		RequestTimeout: 5 * time.Second,
+	}
+}
+
+// VaultResolverOption defines function signature that NewVaultResolver's functional option must satisfy.
+type VaultResolverOption func(*vaultResolver)
+
+// WithVaultHTTPClient creates a VaultResolverOption that replaces the default http.Client.
+// Use this to set up TLS client certificates.
+func WithVaultHTTPClient(client *http.Client) VaultResolverOption {
+	return func(r *vaultResolver) {
+		r.client = client
+	}
+}
+
+// NewVaultResolver creates and returns a new sarah.SecretResolver that reads a secret from HashiCorp Vault.
+// A reference is formed as "<path>#<field>" such as "secret/data/sarah#slack_token" where the path is the API path without "/v1/" prefix.
+// Both KV secrets engine version 1 and version 2 are supported; for version 2, the path contains "data/" as the API path does.
+func NewVaultResolver(config *VaultConfig, options ...VaultResolverOption) sarah.SecretResolver {
+	r := &vaultResolver{
+		config: config,
+		client: &http.Client{Timeout: config.RequestTimeout},
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+type vaultResolver struct {
+	config *VaultConfig
+	client *http.Client
+}
+
+var _ sarah.SecretResolver = (*vaultResolver)(nil)
+
+func (r *vaultResolver) ResolveSecret(ctx context.Context, ref string) (string, error) {
+	i := strings.LastIndex(ref, "#")
+	if i < 0 {
+		return "", fmt.Errorf(`field is not specified in %s: the reference must be formed as "<path>#<field>"`, ref)
+	}
+	path, field := strings.TrimPrefix(ref[:i], "/"), ref[i+1:]
+
+	endpoint := strings.TrimSuffix(r.config.Address, "/") + "/v1/" + path
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request for %s: %w", path, err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", r.config.Token.Reveal())
+	if r.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.config.Namespace)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Proceed.
+
+	case http.StatusNotFound:
+		return "", fmt.Errorf("secret %s does not exist: %w", path, ErrSecretNotFound)
+
+	default:
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("unexpected response from Vault for %s: status=%d body=%s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+
+	}
+
+	decoded := &struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(decoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode Vault response for %s: %w", path, err)
+	}
+
+	data := decoded.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			// KV secrets engine version 2 wraps the secret with its metadata.
+			data = nested
+		}
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field %s does not exist in %s: %w", field, path, ErrSecretNotFound)
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %s in %s is not a string", field, path)
+	}
+
+	return str, nil
+}
@@ -0,0 +1,141 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewVaultConfig(t *testing.T) {
+	_ = os.Setenv("VAULT_ADDR", "https://vault.example.com")
+	_ = os.Setenv("VAULT_TOKEN", "dummyToken")
+	defer func() {
+		_ = os.Unsetenv("VAULT_ADDR")
+		_ = os.Unsetenv("VAULT_TOKEN")
+	}()
+
+	config := NewVaultConfig()
+
+	if config.Address != "https://vault.example.com" {
+		t.Errorf("Address is not read from the environment variable: %s.", config.Address)
+	}
+
+	if config.Token.Reveal() != "dummyToken" {
+		t.Errorf("Token is not read from the environment variable: %s.", config.Token.Reveal())
+	}
+
+	if config.RequestTimeout <= 0 {
+		t.Errorf("Default timeout is not set: %#v.", config)
+	}
+}
+
+func TestVaultResolver_ResolveSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "dummyToken" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/sarah":
+			// KV version 2
+			_, _ = fmt.Fprint(w, `{"data":{"data":{"slack_token":"kv2Secret","port":8080},"metadata":{"version":3}}}`)
+
+		case "/v1/kv/sarah":
+			// KV version 1
+			_, _ = fmt.Fprint(w, `{"data":{"slack_token":"kv1Secret"}}`)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"errors":[]}`)
+
+		}
+	}))
+	defer server.Close()
+
+	config := NewVaultConfig()
+	config.Address = server.URL
+	config.Token = "dummyToken"
+	r := NewVaultResolver(config, WithVaultHTTPClient(server.Client()))
+
+	tests := []struct {
+		ref      string
+		value    string
+		notFound bool
+		hasErr   bool
+	}{
+		{
+			ref:   "secret/data/sarah#slack_token",
+			value: "kv2Secret",
+		},
+		{
+			ref:   "/kv/sarah#slack_token",
+			value: "kv1Secret",
+		},
+		{
+			ref:      "secret/data/sarah#unknown",
+			notFound: true,
+		},
+		{
+			ref:      "secret/data/unknown#slack_token",
+			notFound: true,
+		},
+		{
+			ref:    "secret/data/sarah#port",
+			hasErr: true,
+		},
+		{
+			ref:    "secret/data/sarah",
+			hasErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		value, err := r.ResolveSecret(context.TODO(), tt.ref)
+
+		switch {
+		case tt.notFound:
+			if !errors.Is(err, ErrSecretNotFound) {
+				t.Errorf("Expected error is not returned on test #%d: %#v.", i, err)
+			}
+
+		case tt.hasErr:
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+
+		default:
+			if err != nil {
+				t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+				continue
+			}
+			if value != tt.value {
+				t.Errorf("Unexpected value is returned on test #%d: %s.", i, value)
+			}
+
+		}
+	}
+}
+
+func TestVaultResolver_ResolveSecret_Forbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"errors":["permission denied"]}`)
+	}))
+	defer server.Close()
+
+	config := NewVaultConfig()
+	config.Address = server.URL
+	r := NewVaultResolver(config)
+
+	_, err := r.ResolveSecret(context.TODO(), "secret/data/sarah#slack_token")
+	if err == nil || !strings.Contains(err.Error(), "status=403") {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
@@ -0,0 +1,352 @@
+/*
+Package shellexec provides a factory that turns a declarative definition into a sarah.Command that runs an executable,
+so the scripts that used to wrap shell commands can be migrated without writing Go code.
+
+The definitions are typically written in a YAML file and loaded with LoadCommands:
+
+	commands:
+	  - identifier: uptime
+	    pattern: "^\\.uptime"
+	    instruction: "Input .uptime to see the server uptime."
+	    executable: /usr/bin/uptime
+	  - identifier: deploy
+	    pattern: "^\\.deploy\\s+(\\w+)$"
+	    instruction: "Input .deploy <service> to deploy the service."
+	    executable: /opt/bin/deploy
+	    args: ["--service", "{{ index .Matches 1 }}", "--requested-by", "{{ .SenderID }}"]
+	    timeout: 1m
+	    allowed_users: ["U0123", "U0456"]
+
+The executable is run directly without a shell, so the user input never gets interpreted by a shell
+even when it contains characters such as ";" or "$(...)".
+Each argument is a text/template rendered with Input, so a rendered argument is passed to the executable as one argument as-is.
+Still, the executable receives user input; make sure it treats the arguments as untrusted values.
+
+The stdout and the stderr are returned as the response.
+Each output is truncated at Definition.MaxOutputBytes, and the process is killed when Definition.Timeout passes.
+When Definition.AllowedUsers is given, the Command neither matches nor shows its instruction to other users.
+The users are compared by sarah.UserID, which is the user ID such as "U0123" for the slack and gitter adapters regardless of the channel or the room,
+and is the sender key for an adapter whose Input does not satisfy sarah.SenderIDInput.
+
+	commands, err := shellexec.LoadCommands("/path/to/commands.yaml")
+	if err != nil {
+		panic(err)
+	}
+	for _, command := range commands {
+		sarah.RegisterScopedCommand(sarah.AllBots(), command)
+	}
+*/
+package shellexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"os/exec"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultTimeout        = 10 * time.Second
+	defaultMaxOutputBytes = 4096
+)
+
+// Config is the root of the definition file LoadCommands reads.
+type Config struct {
+	Commands []*Definition `json:"commands" yaml:"commands"`
+}
+
+// Definition defines a Command that runs an executable.
+type Definition struct {
+	// Identifier is the Command identifier.
+	Identifier string `json:"identifier" yaml:"identifier"`
+
+	// Pattern is the regular expression an input must match. The submatches are available to Args as .Matches.
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// Instruction is the instruction of the Command.
+	Instruction string `json:"instruction" yaml:"instruction"`
+
+	// Executable is the path to the executable. This is not interpreted by a shell.
+	Executable string `json:"executable" yaml:"executable"`
+
+	// Args are the text/template of the arguments. Input is given to each template.
+	Args []string `json:"args" yaml:"args"`
+
+	// Dir is the working directory of the process. The current directory is used when empty.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// Timeout is the maximum duration of the process. The process is killed when this passes.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// MaxOutputBytes is the maximum size of the stdout and the stderr each. The rest of the output is discarded.
+	MaxOutputBytes int `json:"max_output_bytes" yaml:"max_output_bytes"`
+
+	// AllowedUsers are the IDs of the users who can run the Command as sarah.UserID returns. Everyone can run the Command when empty.
+	AllowedUsers []string `json:"allowed_users" yaml:"allowed_users"`
+}
+
+// NewDefinition returns a new Definition instance with default values.
+// Identifier, Pattern and Executable must be set before use.
+func NewDefinition() *Definition {
+	return &Definition{
+		Identifier:     "",
+		Pattern:        "",
+		Instruction:    "",
+		Executable:     "",
+		Args:           []string{},
+		Dir:            "",
+		Timeout:        defaultTimeout,
+		MaxOutputBytes: defaultMaxOutputBytes,
+		AllowedUsers:   []string{},
+	}
+}
+
+var _ sarah.ConfigDefaulter = (*Definition)(nil)
+var _ sarah.ConfigValidator = (*Definition)(nil)
+
+// ApplyDefaults sets the default values to Timeout and MaxOutputBytes when they are not given.
+func (d *Definition) ApplyDefaults() {
+	if d.Timeout == 0 {
+		d.Timeout = defaultTimeout
+	}
+
+	if d.MaxOutputBytes == 0 {
+		d.MaxOutputBytes = defaultMaxOutputBytes
+	}
+}
+
+// Validate checks the required fields and compiles the pattern and the templates.
+func (d *Definition) Validate() error {
+	var errs sarah.ConfigKeyErrors
+
+	if d.Identifier == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "identifier", Err: errors.New("identifier is empty")})
+	}
+
+	if d.Pattern == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "pattern", Err: errors.New("pattern is empty")})
+	} else if _, err := regexp.Compile(d.Pattern); err != nil {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "pattern", Value: d.Pattern, Err: err})
+	}
+
+	if d.Executable == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "executable", Err: errors.New("executable is empty")})
+	}
+
+	if _, err := parseArgs(d.Args); err != nil {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "args", Err: err})
+	}
+
+	if d.Timeout < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "timeout", Value: d.Timeout.String(), Err: errors.New("timeout must not be negative")})
+	}
+
+	if d.MaxOutputBytes < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "max_output_bytes", Value: fmt.Sprint(d.MaxOutputBytes), Err: errors.New("max_output_bytes must not be negative")})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Input is given to the templates of Definition.Args.
+type Input struct {
+	// Message is the whole input message.
+	Message string
+
+	// SenderKey is the sender key of the input.
+	SenderKey string
+
+	// SenderID is the ID of the user who sent the input as sarah.UserID returns.
+	SenderID string
+
+	// Matches are the submatches of Definition.Pattern. Matches[0] is the whole match.
+	Matches []string
+}
+
+// LoadCommands reads the definitions from the given YAML or JSON file and returns the corresponding Commands.
+// An error is returned when the file can not be read or any of the definitions is invalid.
+func LoadCommands(path string) ([]sarah.Command, error) {
+	config := &Config{}
+	err := sarah.LoadConfig(config, sarah.ConfigFromFile(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	commands := make([]sarah.Command, 0, len(config.Commands))
+	for i, d := range config.Commands {
+		command, err := NewCommand(d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command #%d in %s: %w", i, path, err)
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+// NewCommand validates the given Definition and returns the corresponding Command.
+func NewCommand(d *Definition) (sarah.Command, error) {
+	err := sarah.ValidateConfig(d)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := parseArgs(d.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := map[string]bool{}
+	for _, user := range d.AllowedUsers {
+		allowed[user] = true
+	}
+
+	return &command{
+		definition: d,
+		pattern:    regexp.MustCompile(d.Pattern),
+		args:       args,
+		allowed:    allowed,
+	}, nil
+}
+
+func parseArgs(args []string) ([]*template.Template, error) {
+	templates := make([]*template.Template, 0, len(args))
+	for i, arg := range args {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for argument #%d: %w", i, err)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+type command struct {
+	definition *Definition
+	pattern    *regexp.Regexp
+	args       []*template.Template
+	allowed    map[string]bool
+}
+
+var _ sarah.Command = (*command)(nil)
+
+func (c *command) Identifier() string {
+	return c.definition.Identifier
+}
+
+func (c *command) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	data := &Input{
+		Message:   input.Message(),
+		SenderKey: input.SenderKey(),
+		SenderID:  sarah.UserID(input),
+		Matches:   c.pattern.FindStringSubmatch(input.Message()),
+	}
+
+	args := make([]string, 0, len(c.args))
+	for _, tmpl := range c.args {
+		buf := &bytes.Buffer{}
+		err := tmpl.Execute(buf, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render argument for %s: %w", c.definition.Identifier, err)
+		}
+		args = append(args, buf.String())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.definition.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: c.definition.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: c.definition.MaxOutputBytes}
+	cmd := exec.CommandContext(ctx, c.definition.Executable, args...)
+	cmd.Dir = c.definition.Dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s is killed after %s: %w", c.definition.Identifier, c.definition.Timeout, ctx.Err())
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		// The process could not start.
+		return nil, fmt.Errorf("failed to run %s: %w", c.definition.Identifier, err)
+	}
+
+	var outputs []string
+	for _, output := range []*limitedBuffer{stdout, stderr} {
+		if str := output.String(); str != "" {
+			outputs = append(outputs, str)
+		}
+	}
+	if exitErr != nil {
+		outputs = append(outputs, fmt.Sprintf("(exit status %d)", exitErr.ExitCode()))
+	}
+
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	return &sarah.CommandResponse{
+		Content:     strings.Join(outputs, "\n"),
+		UserContext: nil,
+	}, nil
+}
+
+func (c *command) Instruction(input *sarah.HelpInput) string {
+	// HelpInput does not tell the sender's ID, so refer to the original one.
+	var original sarah.Input = input
+	if input.OriginalInput != nil {
+		original = input.OriginalInput
+	}
+
+	if !c.isAllowed(original) {
+		return ""
+	}
+	return c.definition.Instruction
+}
+
+func (c *command) Match(input sarah.Input) bool {
+	return c.isAllowed(input) && c.pattern.MatchString(input.Message())
+}
+
+func (c *command) isAllowed(input sarah.Input) bool {
+	return len(c.allowed) == 0 || c.allowed[sarah.UserID(input)]
+}
+
+// limitedBuffer keeps up to limit bytes and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if rest := b.limit - b.buf.Len(); rest < len(p) {
+		b.truncated = true
+		if rest > 0 {
+			b.buf.Write(p[:rest])
+		}
+		// Pretend to have written everything so the process is not blocked or killed by a short write.
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// String returns the kept output without the trailing newline. "(truncated)" is appended when the output is truncated.
+func (b *limitedBuffer) String() string {
+	str := strings.TrimRight(b.buf.String(), "\n")
+	if b.truncated {
+		str += "...(truncated)"
+	}
+	return str
+}
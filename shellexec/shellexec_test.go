@@ -0,0 +1,408 @@
+package shellexec
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/gitter"
+	"github.com/oklahomer/go-sarah/v4/slack"
+	"github.com/oklahomer/golack/v2/event"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+	ReplyToValue   sarah.OutputDestination
+}
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+func lookPath(t *testing.T, name string) string {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		t.Skipf("%s is not available.", name)
+	}
+	return path
+}
+
+func TestNewDefinition(t *testing.T) {
+	d := NewDefinition()
+
+	if d.Timeout != defaultTimeout {
+		t.Errorf("Unexpected default timeout is set: %s.", d.Timeout)
+	}
+
+	if d.MaxOutputBytes != defaultMaxOutputBytes {
+		t.Errorf("Unexpected default output limit is set: %d.", d.MaxOutputBytes)
+	}
+}
+
+func TestDefinition_ApplyDefaults(t *testing.T) {
+	d := &Definition{}
+	d.ApplyDefaults()
+
+	if d.Timeout != defaultTimeout || d.MaxOutputBytes != defaultMaxOutputBytes {
+		t.Errorf("Defaults are not applied: %#v.", d)
+	}
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	d := &Definition{
+		Pattern:        "(",
+		Args:           []string{"{{ .Message "},
+		Timeout:        -1,
+		MaxOutputBytes: -1,
+	}
+
+	err := d.Validate()
+	var errs sarah.ConfigKeyErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	keys := []string{}
+	for _, e := range errs {
+		keys = append(keys, e.Key)
+	}
+	if strings.Join(keys, ",") != "identifier,pattern,executable,args,timeout,max_output_bytes" {
+		t.Errorf("Unexpected keys are reported: %s.", strings.Join(keys, ","))
+	}
+
+	d = NewDefinition()
+	d.Identifier = "echo"
+	d.Pattern = "^\\.echo"
+	d.Executable = "echo"
+	if err := d.Validate(); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	echo := lookPath(t, "echo")
+	d := NewDefinition()
+	d.Identifier = "echo"
+	d.Pattern = `^\.echo\s+(.+)$`
+	d.Instruction = "Input .echo <message>."
+	d.Executable = echo
+	d.Args = []string{"{{ .SenderKey }}:", "{{ index .Matches 1 }}"}
+
+	command, err := NewCommand(d)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if command.Identifier() != "echo" {
+		t.Errorf("Unexpected identifier is returned: %s.", command.Identifier())
+	}
+
+	if command.Instruction(&sarah.HelpInput{}) != "Input .echo <message>." {
+		t.Errorf("Unexpected instruction is returned: %s.", command.Instruction(&sarah.HelpInput{}))
+	}
+
+	input := &DummyInput{SenderKeyValue: "alice", MessageValue: ".echo hello; rm -rf $(pwd)"}
+	if !command.Match(input) {
+		t.Fatal("Command must match.")
+	}
+
+	res, err := command.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The input must be passed as-is without being interpreted by a shell.
+	if res == nil || res.Content != "alice: hello; rm -rf $(pwd)" {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+}
+
+func TestNewCommand_WithInvalidDefinition(t *testing.T) {
+	_, err := NewCommand(&Definition{})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func Test_command_AllowedUsers(t *testing.T) {
+	d := NewDefinition()
+	d.Identifier = "restart"
+	d.Pattern = `^\.restart`
+	d.Instruction = "Input .restart."
+	d.Executable = "true"
+	d.AllowedUsers = []string{"alice"}
+
+	command, err := NewCommand(d)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if !command.Match(&DummyInput{SenderKeyValue: "alice", MessageValue: ".restart"}) {
+		t.Error("Allowed user must be able to run the command.")
+	}
+
+	if command.Match(&DummyInput{SenderKeyValue: "bob", MessageValue: ".restart"}) {
+		t.Error("Other user must not be able to run the command.")
+	}
+
+	if command.Instruction(&sarah.HelpInput{}) != "" {
+		t.Error("Instruction must be hidden from other user.")
+	}
+}
+
+func Test_command_AllowedUsers_AdapterInputs(t *testing.T) {
+	d := NewDefinition()
+	d.Identifier = "restart"
+	d.Pattern = `^\.restart`
+	d.Instruction = "Input .restart."
+	d.Executable = "true"
+	d.AllowedUsers = []string{"U0123"}
+
+	command, err := NewCommand(d)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	slackInput := func(channelID string, userID string) sarah.Input {
+		input, err := slack.EventToInput(&event.Message{
+			ChannelID: event.ChannelID(channelID),
+			UserID:    event.UserID(userID),
+			Text:      ".restart",
+			TimeStamp: &event.TimeStamp{Time: time.Now(), OriginalValue: "1355517523.000005"},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+		return input
+	}
+	gitterInput := func(roomID string, userID string) sarah.Input {
+		return gitter.NewRoomMessage(&gitter.Room{ID: roomID}, &gitter.Message{
+			Text:     ".restart",
+			FromUser: gitter.User{ID: userID},
+		})
+	}
+
+	tests := []struct {
+		input   sarah.Input
+		allowed bool
+	}{
+		{
+			// The sender key is "C001|U0123", but the user is allowed in any channel.
+			input:   slackInput("C001", "U0123"),
+			allowed: true,
+		},
+		{
+			input:   slackInput("C002", "U0123"),
+			allowed: true,
+		},
+		{
+			input:   slackInput("C001", "U0456"),
+			allowed: false,
+		},
+		{
+			input:   sarah.NewHelpInput(slackInput("C001", "U0123")),
+			allowed: true,
+		},
+		{
+			input:   gitterInput("room", "U0123"),
+			allowed: true,
+		},
+		{
+			input:   gitterInput("room", "U0456"),
+			allowed: false,
+		},
+	}
+
+	for i, tt := range tests {
+		if help, ok := tt.input.(*sarah.HelpInput); ok {
+			shown := command.Instruction(help) != ""
+			if shown != tt.allowed {
+				t.Errorf("Unexpected instruction visibility on test #%d: %t.", i, shown)
+			}
+			continue
+		}
+
+		matched := command.Match(tt.input)
+		if matched != tt.allowed {
+			t.Errorf("Unexpected result is returned on test #%d for %s: %t.", i, tt.input.SenderKey(), matched)
+		}
+	}
+}
+
+func Test_command_Execute(t *testing.T) {
+	sh := lookPath(t, "sh")
+	tests := []struct {
+		script   string
+		limit    int
+		expected string
+	}{
+		{
+			script:   "echo out; echo err >&2",
+			expected: "out\nerr",
+		},
+		{
+			script:   "echo failure >&2; exit 3",
+			expected: "failure\n(exit status 3)",
+		},
+		{
+			script:   "echo 0123456789",
+			limit:    4,
+			expected: "0123...(truncated)",
+		},
+		{
+			script:   "true",
+			expected: "",
+		},
+	}
+
+	for i, tt := range tests {
+		d := NewDefinition()
+		d.Identifier = "sh"
+		d.Pattern = ".*"
+		d.Executable = sh
+		d.Args = []string{"-c", tt.script}
+		d.MaxOutputBytes = tt.limit
+
+		command, err := NewCommand(d)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		res, err := command.Execute(context.TODO(), &DummyInput{})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		if tt.expected == "" {
+			if res != nil {
+				t.Errorf("Unexpected response is returned on test #%d: %#v.", i, res)
+			}
+			continue
+		}
+
+		if res == nil || res.Content != tt.expected {
+			t.Errorf("Unexpected response is returned on test #%d: %#v.", i, res)
+		}
+	}
+}
+
+func Test_command_Execute_WithTimeout(t *testing.T) {
+	sleep := lookPath(t, "sleep")
+	d := NewDefinition()
+	d.Identifier = "sleep"
+	d.Pattern = ".*"
+	d.Executable = sleep
+	d.Args = []string{"10"}
+	d.Timeout = 100 * time.Millisecond
+
+	command, err := NewCommand(d)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	started := time.Now()
+	_, err = command.Execute(context.TODO(), &DummyInput{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if time.Since(started) > 5*time.Second {
+		t.Error("Process is not killed on timeout.")
+	}
+}
+
+func Test_command_Execute_WithStartError(t *testing.T) {
+	d := NewDefinition()
+	d.Identifier = "missing"
+	d.Pattern = ".*"
+	d.Executable = "/path/to/missing/executable"
+
+	command, err := NewCommand(d)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_, err = command.Execute(context.TODO(), &DummyInput{})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestLoadCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shellexec")
+	if err != nil {
+		t.Fatalf("Failed to create a directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "commands.yaml")
+	body := `
+commands:
+  - identifier: uptime
+    pattern: "^\\.uptime"
+    executable: uptime
+  - identifier: deploy
+    pattern: "^\\.deploy\\s+(\\w+)$"
+    executable: /opt/bin/deploy
+    args: ["--service", "{{ index .Matches 1 }}"]
+    timeout: 1m
+    allowed_users: ["alice"]
+`
+	err = ioutil.WriteFile(path, []byte(body), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write a file: %s.", err.Error())
+	}
+
+	commands, err := LoadCommands(path)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(commands) != 2 {
+		t.Fatalf("Unexpected number of commands are returned: %d.", len(commands))
+	}
+
+	deploy, ok := commands[1].(*command)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", commands[1])
+	}
+
+	if deploy.definition.Timeout != time.Minute || deploy.definition.MaxOutputBytes != defaultMaxOutputBytes || !deploy.allowed["alice"] {
+		t.Errorf("Unexpected definition is loaded: %#v.", deploy.definition)
+	}
+
+	err = ioutil.WriteFile(path, []byte("commands:\n  - identifier: broken\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write a file: %s.", err.Error())
+	}
+
+	_, err = LoadCommands(path)
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	_, err = LoadCommands(filepath.Join(dir, "missing.yaml"))
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
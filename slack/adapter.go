@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/oklahomer/go-kasumi/logger"
 	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
 	"github.com/oklahomer/golack/v2"
 	"github.com/oklahomer/golack/v2/event"
 	"github.com/oklahomer/golack/v2/eventsapi"
 	"github.com/oklahomer/golack/v2/rtmapi"
 	"github.com/oklahomer/golack/v2/webapi"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,6 +25,12 @@ const (
 // ErrNonSupportedEvent is returned when given event is not supported by this adapter.
 var ErrNonSupportedEvent = errors.New("event not supported")
 
+// moduleLogger returns the Logger carried by the given context with this package's module name.
+// go-sarah's Runner passes a context that carries its Logger to Adapter.Run and Adapter.SendMessage.
+func moduleLogger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("slack")
+}
+
 // AdapterOption defines function signature that Adapter's functional option must satisfy.
 type AdapterOption func(adapter *Adapter)
 
@@ -46,6 +55,7 @@ func WithEventsPayloadHandler(fnc func(context.Context, *Config, *eventsapi.Even
 				config:        adapter.config,
 				client:        adapter.client,
 				handlePayload: fnc,
+				connection:    adapter.connection,
 			}
 		}
 	}
@@ -110,6 +120,8 @@ func WithRTMPayloadHandler(fnc func(context.Context, *Config, rtmapi.DecodedPayl
 				config:        adapter.config,
 				client:        adapter.client,
 				handlePayload: fnc,
+				connection:    adapter.connection,
+				backfill:      adapter.backfiller,
 			}
 		}
 	}
@@ -130,12 +142,29 @@ type Adapter struct {
 	config                    *Config
 	client                    SlackClient
 	apiSpecificAdapterBuilder func(config *Config, client SlackClient) apiSpecificAdapter
+	connection                *connectionState
+	fileUploader              FileUploader
+	reactor                   Reactor
+	blockPoster               BlockPoster
+	messageModifier           MessageModifier
+	ephemeralPoster           EphemeralPoster
+	responseURLPoster         ResponseURLPoster
+	socketModeClient          SocketModeClient
+	directMessageOpener       DirectMessageOpener
+	directoryFetcher          DirectoryFetcher
+	directory                 *Directory
+	historyFetcher            HistoryFetcher
+	authTester                AuthTester
+	backfiller                *backfiller
+	eventsPayloadHandler      func(context.Context, *Config, *eventsapi.EventWrapper, func(sarah.Input) error)
+	enqueueInput              atomic.Value
 }
 
 // NewAdapter creates new Adapter with given *Config and zero or more AdapterOption.
 func NewAdapter(config *Config, options ...AdapterOption) (*Adapter, error) {
 	adapter := &Adapter{
-		config: config,
+		config:     config,
+		connection: &connectionState{},
 	}
 
 	for _, opt := range options {
@@ -150,8 +179,8 @@ func NewAdapter(config *Config, options ...AdapterOption) (*Adapter, error) {
 		}
 
 		golackConfig := golack.NewConfig()
-		golackConfig.Token = config.Token
-		golackConfig.AppSecret = config.AppSecret
+		golackConfig.Token = config.Token.Reveal()
+		golackConfig.AppSecret = config.AppSecret.Reveal()
 		golackConfig.ListenPort = config.ListenPort
 		if config.RequestTimeout != 0 {
 			golackConfig.RequestTimeout = config.RequestTimeout
@@ -160,13 +189,126 @@ func NewAdapter(config *Config, options ...AdapterOption) (*Adapter, error) {
 		adapter.client = golack.New(golackConfig)
 	}
 
+	// See if FileUploader, Reactor, BlockPoster and MessageModifier are set by their corresponding options.
+	// If not, use the given client when it has the capability, or call Web API by this package's implementation.
+	webAPI := newWebAPIClient(config.Token.Reveal(), config.RequestTimeout)
+	if adapter.fileUploader == nil {
+		if uploader, ok := adapter.client.(FileUploader); ok {
+			adapter.fileUploader = uploader
+		} else {
+			adapter.fileUploader = webAPI
+		}
+	}
+	if adapter.reactor == nil {
+		if reactor, ok := adapter.client.(Reactor); ok {
+			adapter.reactor = reactor
+		} else {
+			adapter.reactor = webAPI
+		}
+	}
+	if adapter.blockPoster == nil {
+		if poster, ok := adapter.client.(BlockPoster); ok {
+			adapter.blockPoster = poster
+		} else {
+			adapter.blockPoster = webAPI
+		}
+	}
+	if adapter.messageModifier == nil {
+		if modifier, ok := adapter.client.(MessageModifier); ok {
+			adapter.messageModifier = modifier
+		} else {
+			adapter.messageModifier = webAPI
+		}
+	}
+	if adapter.ephemeralPoster == nil {
+		if poster, ok := adapter.client.(EphemeralPoster); ok {
+			adapter.ephemeralPoster = poster
+		} else {
+			adapter.ephemeralPoster = webAPI
+		}
+	}
+	if adapter.responseURLPoster == nil {
+		if poster, ok := adapter.client.(ResponseURLPoster); ok {
+			adapter.responseURLPoster = poster
+		} else {
+			adapter.responseURLPoster = webAPI
+		}
+	}
+
+	if adapter.directMessageOpener == nil {
+		if opener, ok := adapter.client.(DirectMessageOpener); ok {
+			adapter.directMessageOpener = opener
+		} else {
+			adapter.directMessageOpener = webAPI
+		}
+	}
+	if adapter.directoryFetcher == nil {
+		if fetcher, ok := adapter.client.(DirectoryFetcher); ok {
+			adapter.directoryFetcher = fetcher
+		} else {
+			adapter.directoryFetcher = webAPI
+		}
+	}
+	adapter.directory = newDirectory(adapter.directoryFetcher, config.DirectoryTTL)
+	if adapter.historyFetcher == nil {
+		if fetcher, ok := adapter.client.(HistoryFetcher); ok {
+			adapter.historyFetcher = fetcher
+		} else {
+			adapter.historyFetcher = webAPI
+		}
+	}
+	adapter.backfiller = newBackfiller(adapter.historyFetcher, config.BackfillLimit)
+	if adapter.authTester == nil {
+		if tester, ok := adapter.client.(AuthTester); ok {
+			adapter.authTester = tester
+		} else {
+			adapter.authTester = webAPI
+		}
+	}
+
+	if adapter.socketModeClient == nil {
+		if client, ok := adapter.client.(SocketModeClient); ok {
+			adapter.socketModeClient = client
+		} else {
+			// Socket Mode connection is opened with the app-level token instead of the bot token.
+			adapter.socketModeClient = newWebAPIClient(config.AppToken.Reveal(), config.RequestTimeout)
+		}
+	}
+
 	if adapter.apiSpecificAdapterBuilder == nil {
-		return nil, errors.New("RTM or Events API configuration must be applied with WithRTMPayloadHandler or WithEventsPayloadHandler")
+		// No payload handler is given. Use the default one for the configured connection mode.
+		switch config.ConnectionMode {
+		case RTMMode:
+			WithRTMPayloadHandler(DefaultRTMPayloadHandler)(adapter)
+
+		case EventsAPIMode:
+			WithEventsPayloadHandler(DefaultEventsPayloadHandler)(adapter)
+
+		case SocketMode:
+			WithSocketModePayloadHandler(DefaultEventsPayloadHandler)(adapter)
+
+		case EventsHTTPMode:
+			WithEventsHTTPHandler(DefaultEventsPayloadHandler)(adapter)
+
+		case "":
+			return nil, errors.New("RTM, Events API or Socket Mode configuration must be applied with Config.ConnectionMode, WithRTMPayloadHandler, WithEventsPayloadHandler, WithEventsHTTPHandler or WithSocketModePayloadHandler")
+
+		default:
+			return nil, fmt.Errorf("unknown connection mode: %s", config.ConnectionMode)
+
+		}
 	}
 
 	return adapter, nil
 }
 
+var _ sarah.MessageLengthLimiter = (*Adapter)(nil)
+
+// MaxMessageLength returns MaxMessageLength so go-sarah's core splits a longer text message into multiple messages.
+func (adapter *Adapter) MaxMessageLength() int {
+	return MaxMessageLength
+}
+
 // BotType returns BotType of this particular instance.
 func (adapter *Adapter) BotType() sarah.BotType {
 	return SLACK
@@ -181,15 +323,110 @@ func (adapter *Adapter) BotType() sarah.BotType {
 // When critical situation such as reconnection trial fails for specified times, this critical situation is notified to go-sarah's core via 3rd argument function, notifyErr.
 // go-sarah cancels this Bot/Adapter and related resources when BotNonContinuableError is given to this function.
 func (adapter *Adapter) Run(ctx context.Context, enqueueInput func(sarah.Input) error, notifyErr func(error)) {
+	// Keep the function so InteractionHandler can pass the interactions while the Adapter is running.
+	adapter.enqueueInput.Store(enqueueInput)
+	defer adapter.enqueueInput.Store((func(sarah.Input) error)(nil))
+
 	adapter.apiSpecificAdapterBuilder(adapter.config, adapter.client).run(ctx, enqueueInput, notifyErr)
 }
 
+// inputReceiver returns the function given to Run, or nil when the Adapter is not running.
+func (adapter *Adapter) inputReceiver() func(sarah.Input) error {
+	fnc, _ := adapter.enqueueInput.Load().(func(sarah.Input) error)
+	return fnc
+}
+
+// HealthCheck returns an error when the adapter is not connected to Slack.
+// With RTM API and Socket Mode, this reports the WebSocket connection's state; with Events API, this reports the state of the HTTP server that receives events.
+// With WithEventsHTTPHandler, this reports if the Adapter is running since the HTTP server is not managed by the Adapter.
+// This satisfies sarah.HealthChecker so go-sarah's health check can detect a silently dead connection.
+func (adapter *Adapter) HealthCheck(_ context.Context) error {
+	if !adapter.connection.connected() {
+		return errors.New("not connected to Slack")
+	}
+	return nil
+}
+
+// connectionState holds the state of the connection to Slack.
+// Calls to its methods are thread-safe, and are safe with a nil receiver so an apiSpecificAdapter can be built without it.
+type connectionState struct {
+	state int32
+}
+
+func (c *connectionState) set(connected bool) {
+	if c == nil {
+		return
+	}
+
+	var state int32
+	if connected {
+		state = 1
+	}
+	atomic.StoreInt32(&c.state, state)
+}
+
+func (c *connectionState) connected() bool {
+	if c == nil {
+		return false
+	}
+	return atomic.LoadInt32(&c.state) == 1
+}
+
+// connectionEvents publishes sarah.BotConnected, sarah.BotDisconnected and sarah.BotReconnecting
+// with the outage duration and the number of the reconnection attempts.
+// This is not thread-safe, so use one in the goroutine that manages the connection.
+type connectionEvents struct {
+	lostAt   time.Time
+	attempts uint
+}
+
+// reconnecting publishes sarah.BotReconnecting when the connection has been lost.
+func (e *connectionEvents) reconnecting(ctx context.Context) {
+	if e.lostAt.IsZero() {
+		return
+	}
+
+	e.attempts++
+	sarah.PublishEvent(ctx, &sarah.BotReconnecting{
+		BotType: sarah.BotTypeFromContext(ctx),
+		Attempt: e.attempts,
+		Time:    time.Now(),
+	})
+}
+
+func (e *connectionEvents) connected(ctx context.Context) {
+	connected := &sarah.BotConnected{
+		BotType: sarah.BotTypeFromContext(ctx),
+		Time:    time.Now(),
+	}
+	if !e.lostAt.IsZero() {
+		connected.Outage = connected.Time.Sub(e.lostAt)
+	}
+	sarah.PublishEvent(ctx, connected)
+	e.lostAt = time.Time{}
+	e.attempts = 0
+}
+
+// disconnected publishes sarah.BotDisconnected. Give nil when the connection is closed intentionally.
+func (e *connectionEvents) disconnected(ctx context.Context, err error) {
+	disconnected := &sarah.BotDisconnected{
+		BotType: sarah.BotTypeFromContext(ctx),
+		Err:     err,
+		Time:    time.Now(),
+	}
+	sarah.PublishEvent(ctx, disconnected)
+	if err != nil {
+		e.lostAt = disconnected.Time
+	}
+}
+
 // nonBlockSignal tries to send signal to given channel.
 // If no goroutine is listening to the channel or is working on a task triggered by previous signal, this method skips
 // signalling rather than blocks til somebody is ready to read channel.
 //
 // For signalling purpose, empty struct{} should be used.
 // http://peter.bourgon.org/go-in-production/
+//
 //  "Use struct{} as a sentinel value, rather than bool or interface{}. For example, (snip) a signal channel is chan struct{}.
 //  It unambiguously signals an explicit lack of information."
 func nonBlockSignal(id string, target chan<- struct{}) {
@@ -199,31 +436,42 @@ func nonBlockSignal(id string, target chan<- struct{}) {
 
 	default:
 		// couldn't send because no goroutine is receiving channel or is busy.
-		logger.Debugf("Not sending signal to channel: %s", id)
+		moduleLogger(context.Background()).Debug("Not sending signal to channel", logging.F("channel_id", id))
 
 	}
 }
 
 // SendMessage let Bot send message to Slack.
-func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) {
+// The returned sarah.SendResult carries the timestamp of the message as its MessageID when Slack tells one.
+func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) (*sarah.SendResult, error) {
+	// sarah.ThreadDestination tells the message is a reply in the thread,
+	// and sarah.PrivateDestination tells the message must be an ephemeral message that only the user can see.
+	destination := sarah.BaseDestination(output.Destination())
+	threadID := sarah.ThreadID(output.Destination())
+	privateUserID := sarah.PrivateUserID(output.Destination())
+
+	if slashCommand, ok := destination.(*SlashCommandDestination); ok {
+		return adapter.respondToSlashCommand(ctx, output, slashCommand)
+	}
+
 	var message *webapi.PostMessage
 	switch content := output.Content().(type) {
 	case *webapi.PostMessage:
+		// The message is already built by NewResponse or by the developer, so its thread setting is respected.
+		threadID = ""
 		message = content
 
 	case string:
-		channel, ok := output.Destination().(event.ChannelID)
+		channel, ok := destination.(event.ChannelID)
 		if !ok {
-			logger.Errorf("Destination is not instance of Channel. %#v.", output.Destination())
-			return
+			return nil, fmt.Errorf("destination is not instance of Channel: %#v", destination)
 		}
 		message = webapi.NewPostMessage(channel, content)
 
 	case *sarah.CommandHelps:
-		channelID, ok := output.Destination().(event.ChannelID)
+		channelID, ok := destination.(event.ChannelID)
 		if !ok {
-			logger.Errorf("Destination is not instance of Channel. %#v.", output.Destination())
-			return
+			return nil, fmt.Errorf("destination is not instance of Channel: %#v", destination)
 		}
 
 		var fields []*webapi.AttachmentField
@@ -244,19 +492,153 @@ func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) {
 		}
 		message = webapi.NewPostMessage(channelID, "").WithAttachments(attachments)
 
+	case *sarah.FileContent:
+		channelID, ok := destination.(event.ChannelID)
+		if !ok {
+			return nil, fmt.Errorf("destination is not instance of Channel: %#v", destination)
+		}
+
+		if privateUserID != "" {
+			// Slack has no way to share a file only with a user in a channel. Send it in the direct message instead.
+			dm, err := adapter.directMessageOpener.OpenDirectMessage(ctx, privateUserID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open direct message to send file privately: %w", err)
+			}
+			channelID = dm
+			threadID = ""
+		}
+
+		err := adapter.fileUploader.UploadFile(ctx, channelID, threadID, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload file %s: %w", content.FileName, err)
+		}
+		return &sarah.SendResult{Destination: output.Destination()}, nil
+
+	case *sarah.Reaction:
+		channelID, ok := destination.(event.ChannelID)
+		if !ok {
+			return nil, fmt.Errorf("destination is not instance of Channel: %#v", destination)
+		}
+
+		if content.MessageID == "" {
+			// The message to react to is unknown. Send the emoji as a message instead.
+			message = webapi.NewPostMessage(channelID, ":"+strings.Trim(content.Name, ":")+":")
+			break
+		}
+
+		err := adapter.reactor.AddReaction(ctx, channelID, content.MessageID, content.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add reaction %s: %w", content.Name, err)
+		}
+		return &sarah.SendResult{Destination: output.Destination()}, nil
+
+	case *sarah.RichMessage:
+		channelID, ok := destination.(event.ChannelID)
+		if !ok {
+			return nil, fmt.Errorf("destination is not instance of Channel: %#v", destination)
+		}
+		if content.Interactive() {
+			// Interactive components are only available with Block Kit.
+			return adapter.postBlocks(ctx, output, channelID, threadID, privateUserID, content.PlainText(), richBlocks(content))
+		}
+		message = webapi.NewPostMessage(channelID, "").WithAttachments([]*webapi.MessageAttachment{richAttachment(content)})
+
+	case *BlockMessage:
+		channelID, ok := destination.(event.ChannelID)
+		if !ok {
+			return nil, fmt.Errorf("destination is not instance of Channel: %#v", destination)
+		}
+
+		return adapter.postBlocks(ctx, output, channelID, threadID, privateUserID, content.Text, content.blocks())
+
 	default:
-		logger.Warnf("Unexpected output %#v", output)
-		return
+		return nil, fmt.Errorf("%w: %T", sarah.ErrUnsupportedContent, content)
+	}
+
+	if threadID != "" {
+		message.WithThreadTimeStamp(threadID)
+	}
+
+	if privateUserID != "" {
+		err := adapter.ephemeralPoster.PostEphemeral(ctx, privateUserID, message, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to post ephemeral message: %w", err)
+		}
+		return &sarah.SendResult{Destination: output.Destination()}, nil
 	}
 
 	resp, err := adapter.client.PostMessage(ctx, message)
 	if err != nil {
-		logger.Errorf("Something went wrong with Web API posting: %+v. %+v", err, message)
-		return
+		return nil, fmt.Errorf("failed to post message: %w", err)
 	}
 
 	if !resp.OK {
-		logger.Errorf("Failed to post message %#v: %s", message, resp.Error)
+		return nil, fmt.Errorf("failed to post message: %s", resp.Error)
+	}
+
+	return &sarah.SendResult{Destination: output.Destination()}, nil
+}
+
+// postBlocks posts the given Block Kit blocks, or posts them as an ephemeral message when the private user ID is given.
+func (adapter *Adapter) postBlocks(ctx context.Context, output sarah.Output, channelID event.ChannelID, threadID string, privateUserID string, text string, blocks []interface{}) (*sarah.SendResult, error) {
+	if privateUserID != "" {
+		message := webapi.NewPostMessage(channelID, text)
+		if threadID != "" {
+			message.WithThreadTimeStamp(threadID)
+		}
+		err := adapter.ephemeralPoster.PostEphemeral(ctx, privateUserID, message, blocks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to post ephemeral message: %w", err)
+		}
+		return &sarah.SendResult{Destination: output.Destination()}, nil
+	}
+
+	ts, err := adapter.blockPoster.PostBlocks(ctx, channelID, threadID, text, blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post message: %w", err)
+	}
+	return &sarah.SendResult{
+		Destination: output.Destination(),
+		MessageID:   ts,
+	}, nil
+}
+
+// richAttachment renders the given sarah.RichMessage as a message attachment.
+// Buttons are rendered as links in the text since a message attachment can not carry Block Kit's buttons.
+// See richBlocks for a message with interactive components.
+func richAttachment(message *sarah.RichMessage) *webapi.MessageAttachment {
+	text := message.Text
+	var links []string
+	for _, b := range message.Buttons {
+		if b.Interactive() {
+			continue
+		}
+		links = append(links, fmt.Sprintf("<%s|%s>", b.URL, b.Label))
+	}
+	if len(links) > 0 {
+		if text != "" {
+			text += "\n"
+		}
+		text += strings.Join(links, " | ")
+	}
+
+	var fields []*webapi.AttachmentField
+	for _, f := range message.Fields {
+		fields = append(fields, &webapi.AttachmentField{
+			Title: f.Title,
+			Value: f.Value,
+			Short: f.Short,
+		})
+	}
+
+	return &webapi.MessageAttachment{
+		Fallback:  message.PlainText(),
+		Color:     message.Color,
+		Title:     message.Title,
+		TitleLink: message.TitleURL,
+		Text:      text,
+		Fields:    fields,
+		ImageURL:  message.ImageURL,
 	}
 }
 
@@ -269,6 +651,8 @@ type Input struct {
 	timestamp       *event.TimeStamp
 	threadTimeStamp *event.TimeStamp
 	channelID       event.ChannelID
+	userID          event.UserID
+	catchUp         bool
 }
 
 // SenderKey returns string representing message sender.
@@ -291,6 +675,62 @@ func (i *Input) ReplyTo() sarah.OutputDestination {
 	return i.channelID
 }
 
+// ThreadID returns the timestamp of the thread's parent message when the input is sent in a thread.
+// An empty string is returned otherwise.
+func (i *Input) ThreadID() string {
+	if !IsThreadMessage(i) {
+		return ""
+	}
+
+	return i.threadTimeStamp.String()
+}
+
+// MessageID returns the timestamp of the message, which Slack uses as the identifier of the message.
+// A reply to this message with the timestamp as its thread_ts starts a new thread.
+func (i *Input) MessageID() string {
+	if i.timestamp == nil {
+		return ""
+	}
+	return i.timestamp.String()
+}
+
+// SenderID returns the ID of the user who sent the message.
+func (i *Input) SenderID() string {
+	return i.userID.String()
+}
+
+// IsDirectMessage tells if the input is sent in a direct message channel.
+// Slack assigns IDs prefixed with "D" to direct message channels.
+func (i *Input) IsDirectMessage() bool {
+	return strings.HasPrefix(i.channelID.String(), "D")
+}
+
+// Mentions returns the IDs of the users mentioned in the message.
+func (i *Input) Mentions() []string {
+	var userIDs []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(i.text, -1) {
+		userIDs = append(userIDs, match[1])
+	}
+	return userIDs
+}
+
+// IsCatchUp tells if the message is fetched after the reconnection instead of being received in real time.
+// See Config.BackfillLimit.
+func (i *Input) IsCatchUp() bool {
+	return i.catchUp
+}
+
+// mentionPattern matches Slack-styled user mentions such as <@U024BE7LH> and <@U024BE7LH|bob>.
+// https://api.slack.com/reference/surfaces/formatting#mentioning-users
+var mentionPattern = regexp.MustCompile(`<@([UW][A-Z0-9]+)(?:\|[^>]*)?>`)
+
+var _ sarah.ThreadInput = (*Input)(nil)
+var _ sarah.MessageIDInput = (*Input)(nil)
+var _ sarah.DirectMessageInput = (*Input)(nil)
+var _ sarah.MentionInput = (*Input)(nil)
+var _ sarah.SenderIDInput = (*Input)(nil)
+var _ sarah.CatchUpInput = (*Input)(nil)
+
 // EventToInput converts given event payload to *Input.
 func EventToInput(e interface{}) (sarah.Input, error) {
 	switch typed := e.(type) {
@@ -302,6 +742,7 @@ func EventToInput(e interface{}) (sarah.Input, error) {
 			timestamp:       typed.TimeStamp,
 			threadTimeStamp: typed.ThreadTimeStamp,
 			channelID:       typed.ChannelID,
+			userID:          typed.UserID,
 		}, nil
 
 	case *event.ChannelMessage:
@@ -312,6 +753,7 @@ func EventToInput(e interface{}) (sarah.Input, error) {
 			timestamp:       typed.TimeStamp,
 			threadTimeStamp: typed.ThreadTimeStamp,
 			channelID:       typed.ChannelID,
+			userID:          typed.UserID,
 		}, nil
 
 	default:
@@ -15,6 +15,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -129,6 +130,14 @@ func TestNewAdapter(t *testing.T) {
 		if adapter.client == nil {
 			t.Error("Golack client instance is not set.")
 		}
+
+		if _, ok := adapter.fileUploader.(*webAPIClient); !ok {
+			t.Errorf("Default FileUploader is not set: %#v.", adapter.fileUploader)
+		}
+
+		if _, ok := adapter.reactor.(*webAPIClient); !ok {
+			t.Errorf("Default Reactor is not set: %#v.", adapter.reactor)
+		}
 	})
 
 	t.Run("Missing config or SlackClient", func(t *testing.T) {
@@ -160,6 +169,58 @@ func TestNewAdapter(t *testing.T) {
 		}
 	})
 
+	t.Run("Connection mode", func(t *testing.T) {
+		tests := []struct {
+			mode     ConnectionMode
+			expected interface{}
+		}{
+			{
+				mode:     RTMMode,
+				expected: &rtmAPIAdapter{},
+			},
+			{
+				mode:     EventsAPIMode,
+				expected: &eventsAPIAdapter{},
+			},
+			{
+				mode:     EventsHTTPMode,
+				expected: &eventsHTTPAdapter{},
+			},
+			{
+				mode:     SocketMode,
+				expected: &socketModeAdapter{},
+			},
+		}
+
+		for _, tt := range tests {
+			config := &Config{
+				Token:          "dummy",
+				ConnectionMode: tt.mode,
+			}
+			adapter, err := NewAdapter(config)
+			if err != nil {
+				t.Fatalf("Unexpected error is returned for %s: %s.", tt.mode, err.Error())
+			}
+
+			built := adapter.apiSpecificAdapterBuilder(config, adapter.client)
+			if reflect.TypeOf(built) != reflect.TypeOf(tt.expected) {
+				t.Errorf("Unexpected apiSpecificAdapter is built for %s: %T.", tt.mode, built)
+			}
+		}
+	})
+
+	t.Run("Unknown connection mode", func(t *testing.T) {
+		config := &Config{
+			Token:          "dummy",
+			ConnectionMode: "unknown",
+		}
+		_, err := NewAdapter(config)
+
+		if err == nil {
+			t.Error("Expected error is not returned")
+		}
+	})
+
 	t.Run("With SlackClient", func(t *testing.T) {
 		config := &Config{}
 		client := &DummyClient{}
@@ -184,6 +245,14 @@ func TestNewAdapter(t *testing.T) {
 	})
 }
 
+func TestAdapter_MaxMessageLength(t *testing.T) {
+	adapter := &Adapter{}
+
+	if adapter.MaxMessageLength() != MaxMessageLength {
+		t.Errorf("Unexpected length is returned: %d.", adapter.MaxMessageLength())
+	}
+}
+
 func TestAdapter_BotType(t *testing.T) {
 	adapter := &Adapter{}
 
@@ -192,6 +261,26 @@ func TestAdapter_BotType(t *testing.T) {
 	}
 }
 
+func TestAdapter_HealthCheck(t *testing.T) {
+	adapter := &Adapter{
+		connection: &connectionState{},
+	}
+
+	if err := adapter.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected error is not returned before connecting.")
+	}
+
+	adapter.connection.set(true)
+	if err := adapter.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	adapter.connection.set(false)
+	if err := adapter.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected error is not returned after disconnection.")
+	}
+}
+
 func TestAdapter_Run(t *testing.T) {
 	called := false
 	adapter := &Adapter{
@@ -213,6 +302,46 @@ func TestAdapter_Run(t *testing.T) {
 	}
 }
 
+func Test_richAttachment(t *testing.T) {
+	message := &sarah.RichMessage{
+		Title:    "Weather",
+		TitleURL: "https://example.com/",
+		Text:     "Sunny",
+		Fields: []*sarah.RichField{
+			{Title: "High", Value: "25", Short: true},
+		},
+		ImageURL: "https://example.com/sunny.png",
+		Buttons: []*sarah.RichButton{
+			{Label: "Details", URL: "https://example.com/details"},
+			{Label: "Weekly", URL: "https://example.com/weekly"},
+			{Label: "Subscribe", CallbackID: "subscribe", Value: "weather"},
+		},
+		Color: "#36a64f",
+	}
+
+	attachment := richAttachment(message)
+
+	if attachment.Title != message.Title || attachment.TitleLink != message.TitleURL {
+		t.Errorf("Unexpected title is set: %#v.", attachment)
+	}
+
+	if attachment.Text != "Sunny\n<https://example.com/details|Details> | <https://example.com/weekly|Weekly>" {
+		t.Errorf("Unexpected text is set: %s.", attachment.Text)
+	}
+
+	if len(attachment.Fields) != 1 || attachment.Fields[0].Title != "High" || !attachment.Fields[0].Short {
+		t.Errorf("Unexpected fields are set: %#v.", attachment.Fields)
+	}
+
+	if attachment.Color != message.Color || attachment.ImageURL != message.ImageURL {
+		t.Errorf("Unexpected color or image is set: %#v.", attachment)
+	}
+
+	if attachment.Fallback != message.PlainText() {
+		t.Errorf("Unexpected fallback is set: %s.", attachment.Fallback)
+	}
+}
+
 func TestAdapter_SendMessage(t *testing.T) {
 	t.Run("Regular message", func(t *testing.T) {
 		tests := []struct {
@@ -266,6 +395,267 @@ func TestAdapter_SendMessage(t *testing.T) {
 		}
 	})
 
+	t.Run("File", func(t *testing.T) {
+		var channel event.ChannelID
+		adapter := &Adapter{
+			fileUploader: &DummyFileUploader{
+				UploadFileFunc: func(_ context.Context, c event.ChannelID, _ string, _ *sarah.FileContent) error {
+					channel = c
+					return nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(channelID, &sarah.FileContent{Reader: strings.NewReader("content")})
+		adapter.SendMessage(context.TODO(), output)
+
+		if channel != channelID {
+			t.Errorf("Unexpected channel is given: %s.", channel)
+		}
+	})
+
+	t.Run("Reaction", func(t *testing.T) {
+		var timestamp string
+		adapter := &Adapter{
+			reactor: &DummyReactor{
+				AddReactionFunc: func(_ context.Context, _ event.ChannelID, ts string, _ string) error {
+					timestamp = ts
+					return nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(channelID, &sarah.Reaction{Name: "+1", MessageID: "1355517536.000001"})
+		adapter.SendMessage(context.TODO(), output)
+
+		if timestamp != "1355517536.000001" {
+			t.Errorf("Unexpected timestamp is given: %s.", timestamp)
+		}
+	})
+
+	t.Run("Reaction without message ID", func(t *testing.T) {
+		var message *webapi.PostMessage
+		adapter := &Adapter{
+			client: &DummyClient{
+				PostMessageFunc: func(_ context.Context, m *webapi.PostMessage) (*webapi.APIResponse, error) {
+					message = m
+					return &webapi.APIResponse{OK: true}, nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(channelID, &sarah.Reaction{Name: "+1"})
+		adapter.SendMessage(context.TODO(), output)
+
+		if message == nil || message.Text != ":+1:" {
+			t.Errorf("Emoji is not sent as a message: %#v.", message)
+		}
+	})
+
+	t.Run("Thread reply", func(t *testing.T) {
+		var message *webapi.PostMessage
+		adapter := &Adapter{
+			client: &DummyClient{
+				PostMessageFunc: func(_ context.Context, m *webapi.PostMessage) (*webapi.APIResponse, error) {
+					message = m
+					return &webapi.APIResponse{OK: true}, nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(sarah.NewThreadDestination(channelID, "1355517536.000001"), "text")
+		adapter.SendMessage(context.TODO(), output)
+
+		if message == nil {
+			t.Fatal("Client.PostMessage is not called.")
+		}
+
+		if message.Channel != channelID || message.ThreadTimeStamp != "1355517536.000001" {
+			t.Errorf("Unexpected message is sent: %#v.", message)
+		}
+	})
+
+	t.Run("Private message", func(t *testing.T) {
+		var userID string
+		var message *webapi.PostMessage
+		adapter := &Adapter{
+			client: &DummyClient{
+				PostMessageFunc: func(_ context.Context, _ *webapi.PostMessage) (*webapi.APIResponse, error) {
+					t.Error("Private message must not be posted to the channel.")
+					return &webapi.APIResponse{OK: true}, nil
+				},
+			},
+			ephemeralPoster: &DummyEphemeralPoster{
+				PostEphemeralFunc: func(_ context.Context, u string, m *webapi.PostMessage, _ []interface{}) error {
+					userID = u
+					message = m
+					return nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		destination := sarah.NewPrivateDestination(sarah.NewThreadDestination(channelID, "1355517536.000001"), "U024BE7LH")
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(destination, "secret"))
+
+		if message == nil {
+			t.Fatal("EphemeralPoster.PostEphemeral is not called.")
+		}
+
+		if userID != "U024BE7LH" {
+			t.Errorf("Unexpected user ID is given: %s.", userID)
+		}
+
+		if message.Channel != channelID || message.Text != "secret" || message.ThreadTimeStamp != "1355517536.000001" {
+			t.Errorf("Unexpected message is sent: %#v.", message)
+		}
+	})
+
+	t.Run("Private file", func(t *testing.T) {
+		var uploadedChannel event.ChannelID
+		var uploadedThread string
+		adapter := &Adapter{
+			directMessageOpener: &DummyDirectMessageOpener{
+				OpenDirectMessageFunc: func(_ context.Context, userID string) (event.ChannelID, error) {
+					if userID != "U024BE7LH" {
+						t.Errorf("Unexpected user ID is given: %s.", userID)
+					}
+					return "D123", nil
+				},
+			},
+			fileUploader: &DummyFileUploader{
+				UploadFileFunc: func(_ context.Context, c event.ChannelID, threadTimeStamp string, _ *sarah.FileContent) error {
+					uploadedChannel = c
+					uploadedThread = threadTimeStamp
+					return nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		destination := sarah.NewPrivateDestination(sarah.NewThreadDestination(channelID, "1355517536.000001"), "U024BE7LH")
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(destination, &sarah.FileContent{FileName: "secret.txt"}))
+
+		if uploadedChannel != "D123" || uploadedThread != "" {
+			t.Errorf("File is not sent to the direct message: %s, %s.", uploadedChannel, uploadedThread)
+		}
+	})
+
+	t.Run("Private file without direct message", func(t *testing.T) {
+		adapter := &Adapter{
+			directMessageOpener: &DummyDirectMessageOpener{
+				OpenDirectMessageFunc: func(_ context.Context, _ string) (event.ChannelID, error) {
+					return "", errors.New("missing_scope")
+				},
+			},
+			fileUploader: &DummyFileUploader{
+				UploadFileFunc: func(_ context.Context, _ event.ChannelID, _ string, _ *sarah.FileContent) error {
+					t.Error("File must not be uploaded.")
+					return nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		destination := sarah.NewPrivateDestination(channelID, "U024BE7LH")
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(destination, &sarah.FileContent{FileName: "secret.txt"}))
+	})
+
+	t.Run("Rich message", func(t *testing.T) {
+		var message *webapi.PostMessage
+		adapter := &Adapter{
+			client: &DummyClient{
+				PostMessageFunc: func(_ context.Context, m *webapi.PostMessage) (*webapi.APIResponse, error) {
+					message = m
+					return &webapi.APIResponse{OK: true}, nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(channelID, &sarah.RichMessage{Title: "title"})
+		adapter.SendMessage(context.TODO(), output)
+
+		if message == nil {
+			t.Fatal("Client.PostMessage is not called.")
+		}
+
+		if message.Channel != channelID || len(message.Attachments) != 1 || message.Attachments[0].Title != "title" {
+			t.Errorf("Unexpected message is sent: %#v.", message)
+		}
+	})
+
+	t.Run("Interactive rich message", func(t *testing.T) {
+		var blocks []interface{}
+		var text string
+		adapter := &Adapter{
+			blockPoster: &DummyBlockPoster{
+				PostBlocksFunc: func(_ context.Context, _ event.ChannelID, _ string, t string, b []interface{}) (string, error) {
+					text = t
+					blocks = b
+					return "1355517523.000005", nil
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(channelID, &sarah.RichMessage{
+			Text:    "Deploy?",
+			Buttons: []*sarah.RichButton{{Label: "Yes", CallbackID: "deploy", Value: "yes"}},
+		})
+		result, err := adapter.SendMessage(context.TODO(), output)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if result.MessageID != "1355517523.000005" {
+			t.Errorf("Unexpected message ID is returned: %s.", result.MessageID)
+		}
+
+		if text != "Deploy?" {
+			t.Errorf("Unexpected fallback text is given: %s.", text)
+		}
+
+		if len(blocks) != 2 {
+			t.Errorf("Unexpected blocks are given: %#v.", blocks)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		expectedErr := errors.New("error")
+		adapter := &Adapter{
+			client: &DummyClient{
+				PostMessageFunc: func(_ context.Context, _ *webapi.PostMessage) (*webapi.APIResponse, error) {
+					return nil, expectedErr
+				},
+			},
+		}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(channelID, "test")
+		_, err := adapter.SendMessage(context.TODO(), output)
+
+		if !errors.Is(err, expectedErr) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("Unsupported content", func(t *testing.T) {
+		adapter := &Adapter{}
+
+		var channelID event.ChannelID = "channelID"
+		output := sarah.NewOutputMessage(channelID, struct{}{})
+		_, err := adapter.SendMessage(context.TODO(), output)
+
+		if !errors.Is(err, sarah.ErrUnsupportedContent) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
 	t.Run("String message", func(t *testing.T) {
 		called := false
 		adapter := &Adapter{
@@ -648,6 +1038,52 @@ func TestIsThreadMessage(t *testing.T) {
 	}
 }
 
+func Test_connectionEvents(t *testing.T) {
+	var published []sarah.Event
+	bus := sarah.NewEventBus()
+	bus.Subscribe(func(e sarah.Event) {
+		published = append(published, e)
+	})
+	ctx := sarah.NewEventBusContext(sarah.WithBotType(context.TODO(), SLACK), bus)
+
+	events := &connectionEvents{}
+	events.reconnecting(ctx)
+	if len(published) != 0 {
+		t.Fatalf("BotReconnecting must not be published before the connection is lost: %#v.", published)
+	}
+
+	events.connected(ctx)
+	events.disconnected(ctx, errors.New("connection is closed"))
+	events.reconnecting(ctx)
+	events.reconnecting(ctx)
+	events.connected(ctx)
+	events.disconnected(ctx, nil)
+
+	if len(published) != 6 {
+		t.Fatalf("Unexpected number of events are published: %d.", len(published))
+	}
+
+	if connected, ok := published[0].(*sarah.BotConnected); !ok || connected.BotType != SLACK || connected.Outage != 0 {
+		t.Errorf("Unexpected event is published: %#v.", published[0])
+	}
+
+	if disconnected, ok := published[1].(*sarah.BotDisconnected); !ok || disconnected.Err == nil {
+		t.Errorf("Unexpected event is published: %#v.", published[1])
+	}
+
+	if reconnecting, ok := published[3].(*sarah.BotReconnecting); !ok || reconnecting.Attempt != 2 {
+		t.Errorf("Unexpected event is published: %#v.", published[3])
+	}
+
+	if connected, ok := published[4].(*sarah.BotConnected); !ok || connected.Outage <= 0 {
+		t.Errorf("Unexpected event is published: %#v.", published[4])
+	}
+
+	if disconnected, ok := published[5].(*sarah.BotDisconnected); !ok || disconnected.Err != nil {
+		t.Errorf("Unexpected event is published: %#v.", published[5])
+	}
+}
+
 func Test_nonBlockSignal(t *testing.T) {
 	// Prepare a channel with a buffer of 1.
 	target := make(chan struct{}, 1)
@@ -661,3 +1097,88 @@ func Test_nonBlockSignal(t *testing.T) {
 		t.Errorf("The target channel should have exactly one signal: %d", len(target))
 	}
 }
+
+func TestInput_ThreadID(t *testing.T) {
+	parent := &event.TimeStamp{
+		OriginalValue: "1355517536.000001",
+	}
+
+	nonThread := &Input{
+		timestamp: parent,
+	}
+	if nonThread.ThreadID() != "" {
+		t.Errorf("Empty thread ID is expected: %s.", nonThread.ThreadID())
+	}
+
+	reply := &Input{
+		threadTimeStamp: parent,
+		timestamp: &event.TimeStamp{
+			OriginalValue: "1355517999.000001",
+		},
+	}
+	if reply.ThreadID() != parent.OriginalValue {
+		t.Errorf("Unexpected thread ID is returned: %s.", reply.ThreadID())
+	}
+}
+
+func TestInput_MessageID(t *testing.T) {
+	input := &Input{
+		timestamp: &event.TimeStamp{
+			OriginalValue: "1355517536.000001",
+		},
+	}
+	if input.MessageID() != "1355517536.000001" {
+		t.Errorf("Unexpected message ID is returned: %s.", input.MessageID())
+	}
+
+	if (&Input{}).MessageID() != "" {
+		t.Error("Empty message ID is expected when timestamp is not given.")
+	}
+}
+
+func TestInput_SenderID(t *testing.T) {
+	input := &Input{userID: "U024BE7LH"}
+	if input.SenderID() != "U024BE7LH" {
+		t.Errorf("Unexpected sender ID is returned: %s.", input.SenderID())
+	}
+}
+
+func TestInput_IsDirectMessage(t *testing.T) {
+	tests := []struct {
+		channelID event.ChannelID
+		expected  bool
+	}{
+		{
+			channelID: "D024BE91L",
+			expected:  true,
+		},
+		{
+			channelID: "C024BE91L",
+			expected:  false,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			input := &Input{channelID: tt.channelID}
+			if input.IsDirectMessage() != tt.expected {
+				t.Errorf("Unexpected value is returned: %t.", input.IsDirectMessage())
+			}
+		})
+	}
+}
+
+func TestInput_Mentions(t *testing.T) {
+	input := &Input{
+		text: "Hello, <@U024BE7LH> and <@W123ABC|bob>. <#C024BE91L|general> is not a user.",
+	}
+
+	mentions := input.Mentions()
+	if len(mentions) != 2 {
+		t.Fatalf("Unexpected number of mentions are returned: %#v.", mentions)
+	}
+
+	if mentions[0] != "U024BE7LH" || mentions[1] != "W123ABC" {
+		t.Errorf("Unexpected mentions are returned: %#v.", mentions)
+	}
+}
@@ -0,0 +1,201 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/golack/v2/event"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// maxBackfillLimit is the maximum number of messages that conversations.history method returns at once.
+const maxBackfillLimit = 999
+
+// HistoryFetcher defines an interface that fetches the messages posted in a channel.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it to backfill the messages posted while the connection was down.
+// Otherwise, Adapter fetches the messages with Config.Token by itself.
+type HistoryFetcher interface {
+	// FetchHistory returns the messages posted in the given channel after the given timestamp in the posted order.
+	// At most the given number of the latest messages are returned.
+	FetchHistory(ctx context.Context, channel event.ChannelID, oldest string, limit uint) ([]*event.Message, error)
+}
+
+// WithHistoryFetcher creates an AdapterOption that sets the HistoryFetcher to backfill the messages posted while the connection was down.
+func WithHistoryFetcher(fetcher HistoryFetcher) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.historyFetcher = fetcher
+	}
+}
+
+var _ HistoryFetcher = (*webAPIClient)(nil)
+
+type conversationsHistoryResponse struct {
+	webAPIResponse
+	Messages []struct {
+		Type            string           `json:"type"`
+		SubType         string           `json:"subtype"`
+		BotID           string           `json:"bot_id"`
+		UserID          event.UserID     `json:"user"`
+		Text            string           `json:"text"`
+		TimeStamp       *event.TimeStamp `json:"ts"`
+		ThreadTimeStamp *event.TimeStamp `json:"thread_ts"`
+	} `json:"messages"`
+}
+
+// FetchHistory fetches the messages posted by users with conversations.history method.
+// The messages with a subtype such as a bot's message or a channel join are excluded, and so are the replies in threads
+// since conversations.history does not return them.
+// The token requires channels:history, groups:history, im:history or mpim:history scope depending on the channel type.
+func (c *webAPIClient) FetchHistory(ctx context.Context, channel event.ChannelID, oldest string, limit uint) ([]*event.Message, error) {
+	params := url.Values{
+		"channel": []string{channel.String()},
+		"oldest":  []string{oldest},
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.FormatUint(uint64(limit), 10))
+	}
+
+	response := &conversationsHistoryResponse{}
+	err := c.call(ctx, "conversations.history", params, response)
+	if err != nil {
+		return nil, err
+	}
+	if !response.OK {
+		return nil, fmt.Errorf("failed to fetch history: %s", response.Error)
+	}
+
+	// The messages are returned from the latest one.
+	var messages []*event.Message
+	for i := len(response.Messages) - 1; i >= 0; i-- {
+		m := response.Messages[i]
+		if m.Type != "message" || m.SubType != "" || m.BotID != "" || m.TimeStamp == nil {
+			continue
+		}
+		messages = append(messages, &event.Message{
+			ChannelID:       channel,
+			UserID:          m.UserID,
+			Text:            m.Text,
+			TimeStamp:       m.TimeStamp,
+			ThreadTimeStamp: m.ThreadTimeStamp,
+		})
+	}
+	return messages, nil
+}
+
+// backfiller keeps the last received message of each channel, and fetches the messages posted after them on reconnection.
+// Calls to its methods are thread-safe, and are safe with a nil receiver so the backfill can be disabled by leaving it nil.
+type backfiller struct {
+	fetcher  HistoryFetcher
+	limit    uint
+	mutex    sync.Mutex
+	lastSeen map[event.ChannelID]*event.TimeStamp
+
+	// backfilled holds the messages passed by the latest backfill so the same messages received in real time are skipped.
+	backfilled map[string]struct{}
+}
+
+func newBackfiller(fetcher HistoryFetcher, limit uint) *backfiller {
+	if limit == 0 {
+		return nil
+	}
+
+	return &backfiller{
+		fetcher:    fetcher,
+		limit:      limit,
+		lastSeen:   map[event.ChannelID]*event.TimeStamp{},
+		backfilled: map[string]struct{}{},
+	}
+}
+
+// track returns a function that records each Input as the last received message of its channel and passes it to the given function.
+// An Input that is already passed by the backfill is skipped.
+func (b *backfiller) track(enqueueInput func(sarah.Input) error) func(sarah.Input) error {
+	if b == nil {
+		return enqueueInput
+	}
+
+	return func(input sarah.Input) error {
+		if i, ok := unwrapInput(input); ok && i.timestamp != nil && !b.see(i) {
+			return nil
+		}
+		return enqueueInput(input)
+	}
+}
+
+// see records the given Input. false is returned when the Input is received in real time after being passed by the backfill.
+func (b *backfiller) see(input *Input) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := fmt.Sprintf("%s|%s", input.channelID, input.timestamp)
+	if input.catchUp {
+		b.backfilled[key] = struct{}{}
+	} else if _, ok := b.backfilled[key]; ok {
+		return false
+	}
+
+	if last, ok := b.lastSeen[input.channelID]; !ok || input.timestamp.Time.After(last.Time) {
+		b.lastSeen[input.channelID] = input.timestamp
+	}
+	return true
+}
+
+// fetch fetches the messages posted after the last received message of each channel and passes each of them to the given function.
+// Only the channels that any message has been received from are backfilled.
+func (b *backfiller) fetch(ctx context.Context, handle func(*event.Message)) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	b.backfilled = map[string]struct{}{}
+	lastSeen := make(map[event.ChannelID]*event.TimeStamp, len(b.lastSeen))
+	for channel, timestamp := range b.lastSeen {
+		lastSeen[channel] = timestamp
+	}
+	b.mutex.Unlock()
+
+	for channel, timestamp := range lastSeen {
+		messages, err := b.fetcher.FetchHistory(ctx, channel, timestamp.String(), b.limit)
+		if err != nil {
+			moduleLogger(ctx).Warn("Failed to backfill missed messages", logging.F("channel_id", channel), logging.Err(err))
+			continue
+		}
+
+		if len(messages) > 0 {
+			moduleLogger(ctx).Info("Backfilling missed messages", logging.F("channel_id", channel), logging.F("count", len(messages)))
+		}
+		for _, message := range messages {
+			handle(message)
+		}
+	}
+}
+
+// catchUp returns a function that flags each Input as a catch-up input and passes it to the given function.
+// The payload handler converts the backfilled message to Input and passes it to the returned function.
+func catchUp(enqueueInput func(sarah.Input) error) func(sarah.Input) error {
+	return func(input sarah.Input) error {
+		if i, ok := unwrapInput(input); ok {
+			i.catchUp = true
+		}
+		return enqueueInput(input)
+	}
+}
+
+// unwrapInput returns *Input that the given Input is or wraps.
+func unwrapInput(input sarah.Input) (*Input, bool) {
+	switch typed := input.(type) {
+	case *sarah.HelpInput:
+		input = typed.OriginalInput
+
+	case *sarah.AbortInput:
+		input = typed.OriginalInput
+
+	}
+
+	i, ok := input.(*Input)
+	return i, ok
+}
@@ -0,0 +1,167 @@
+package slack
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type DummyHistoryFetcher struct {
+	FetchHistoryFunc func(context.Context, event.ChannelID, string, uint) ([]*event.Message, error)
+}
+
+var _ HistoryFetcher = (*DummyHistoryFetcher)(nil)
+
+func (f *DummyHistoryFetcher) FetchHistory(ctx context.Context, channel event.ChannelID, oldest string, limit uint) ([]*event.Message, error) {
+	return f.FetchHistoryFunc(ctx, channel, oldest, limit)
+}
+
+func TestWithHistoryFetcher(t *testing.T) {
+	fetcher := &DummyHistoryFetcher{}
+	adapter := &Adapter{}
+
+	WithHistoryFetcher(fetcher)(adapter)
+
+	if adapter.historyFetcher != fetcher {
+		t.Error("Given HistoryFetcher is not set.")
+	}
+}
+
+func Test_webAPIClient_FetchHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path != "/conversations.history" {
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+		}
+		if r.Form.Get("channel") != "C123" || r.Form.Get("oldest") != "1355517523.000005" || r.Form.Get("limit") != "10" {
+			t.Errorf("Unexpected parameters are given: %#v.", r.Form)
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "messages": [
+			{"type": "message", "user": "U123", "text": "second", "ts": "1355517525.000001"},
+			{"type": "message", "subtype": "channel_join", "user": "U456", "text": "joined", "ts": "1355517524.000002"},
+			{"type": "message", "bot_id": "B123", "text": "bot", "ts": "1355517524.000001"},
+			{"type": "message", "user": "U123", "text": "first", "ts": "1355517523.000006"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	messages, err := client.FetchHistory(context.TODO(), "C123", "1355517523.000005", 10)
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Unexpected number of messages are returned: %d.", len(messages))
+	}
+	if messages[0].Text != "first" || messages[1].Text != "second" {
+		t.Errorf("Messages are not returned in the posted order: %s, %s.", messages[0].Text, messages[1].Text)
+	}
+	if messages[0].ChannelID != "C123" || messages[0].TimeStamp.String() != "1355517523.000006" {
+		t.Errorf("Unexpected message is returned: %#v.", messages[0])
+	}
+}
+
+func Test_webAPIClient_FetchHistory_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	_, err := client.FetchHistory(context.TODO(), "C123", "1355517523.000005", 10)
+
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func Test_newBackfiller(t *testing.T) {
+	if newBackfiller(&DummyHistoryFetcher{}, 0) != nil {
+		t.Error("Backfill must be disabled with zero limit.")
+	}
+
+	// A nil backfiller passes the Inputs as-is and fetches nothing.
+	var b *backfiller
+	called := false
+	_ = b.track(func(_ sarah.Input) error {
+		called = true
+		return nil
+	})(&Input{})
+	if !called {
+		t.Error("Input is not passed.")
+	}
+	b.fetch(context.TODO(), func(_ *event.Message) {
+		t.Error("Nothing must be fetched.")
+	})
+}
+
+func Test_backfiller(t *testing.T) {
+	newInput := func(ts string, sec int64) *Input {
+		return &Input{
+			channelID: "C123",
+			timestamp: &event.TimeStamp{Time: time.Unix(sec, 0), OriginalValue: ts},
+		}
+	}
+	b := newBackfiller(&DummyHistoryFetcher{
+		FetchHistoryFunc: func(_ context.Context, channel event.ChannelID, oldest string, limit uint) ([]*event.Message, error) {
+			if channel != "C123" || oldest != "2.000000" || limit != 10 {
+				t.Errorf("Unexpected arguments are given: %s, %s, %d.", channel, oldest, limit)
+			}
+			return []*event.Message{
+				{ChannelID: "C123", TimeStamp: &event.TimeStamp{Time: time.Unix(3, 0), OriginalValue: "3.000000"}},
+			}, nil
+		},
+	}, 10)
+
+	var enqueued []*Input
+	enqueueInput := b.track(func(input sarah.Input) error {
+		enqueued = append(enqueued, input.(*Input))
+		return nil
+	})
+
+	_ = enqueueInput(newInput("2.000000", 2))
+	// An older message does not rewind the last received message.
+	_ = enqueueInput(newInput("1.000000", 1))
+
+	b.fetch(context.TODO(), func(message *event.Message) {
+		input, _ := EventToInput(message)
+		_ = catchUp(enqueueInput)(input)
+	})
+
+	// The backfilled message is received in real time.
+	_ = enqueueInput(newInput("3.000000", 3))
+	_ = enqueueInput(newInput("4.000000", 4))
+
+	if len(enqueued) != 4 {
+		t.Fatalf("Unexpected number of inputs are enqueued: %d.", len(enqueued))
+	}
+	if !enqueued[2].IsCatchUp() || enqueued[2].MessageID() != "3.000000" {
+		t.Errorf("Backfilled message is not enqueued as catch-up input: %#v.", enqueued[2])
+	}
+	if enqueued[3].IsCatchUp() || enqueued[3].MessageID() != "4.000000" {
+		t.Errorf("Unexpected input is enqueued: %#v.", enqueued[3])
+	}
+}
+
+func Test_catchUp(t *testing.T) {
+	input := &Input{timestamp: &event.TimeStamp{}}
+	var given sarah.Input
+	_ = catchUp(func(i sarah.Input) error {
+		given = i
+		return nil
+	})(sarah.NewHelpInput(input))
+
+	if !input.IsCatchUp() {
+		t.Error("Wrapped Input is not flagged.")
+	}
+	if !sarah.IsCatchUp(given) {
+		t.Error("Given Input is not a catch-up input.")
+	}
+}
@@ -0,0 +1,374 @@
+package slack
+
+import (
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v4"
+)
+
+// Block represents a Block Kit layout block such as SectionBlock and ActionsBlock.
+// https://api.slack.com/reference/block-kit/blocks
+type Block interface {
+	// BlockType returns the type of the block such as "section".
+	BlockType() string
+}
+
+// BlockElement represents an element that is placed in ActionsBlock or as SectionBlock's accessory.
+// https://api.slack.com/reference/block-kit/block-elements
+type BlockElement interface {
+	// ElementType returns the type of the element such as "button".
+	ElementType() string
+}
+
+// ContextElement represents an element that ContextBlock can contain, which is TextObject or ImageElement.
+type ContextElement interface {
+	contextElement()
+}
+
+// withType marshals the given value with the given type as its "type" field.
+func withType(typeName string, value interface{}) ([]byte, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	typeField, _ := json.Marshal(typeName)
+	if string(b) == "{}" {
+		return []byte(`{"type":` + string(typeField) + `}`), nil
+	}
+	return append([]byte(`{"type":`+string(typeField)+`,`), b[1:]...), nil
+}
+
+// TextObject represents a text composition object.
+// https://api.slack.com/reference/block-kit/composition-objects#text
+type TextObject struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// PlainText creates a plain_text TextObject with the given text.
+func PlainText(text string) *TextObject {
+	return &TextObject{
+		Type: "plain_text",
+		Text: text,
+	}
+}
+
+// Markdown creates a mrkdwn TextObject with the given text.
+func Markdown(text string) *TextObject {
+	return &TextObject{
+		Type: "mrkdwn",
+		Text: text,
+	}
+}
+
+func (*TextObject) contextElement() {}
+
+// SectionBlock displays a text, fields, and an optional accessory element.
+// https://api.slack.com/reference/block-kit/blocks#section
+type SectionBlock struct {
+	BlockID   string        `json:"block_id,omitempty"`
+	Text      *TextObject   `json:"text,omitempty"`
+	Fields    []*TextObject `json:"fields,omitempty"`
+	Accessory BlockElement  `json:"accessory,omitempty"`
+}
+
+var _ Block = (*SectionBlock)(nil)
+
+// BlockType returns "section".
+func (b *SectionBlock) BlockType() string {
+	return "section"
+}
+
+// MarshalJSON converts the block to JSON with its type.
+func (b *SectionBlock) MarshalJSON() ([]byte, error) {
+	type alias SectionBlock
+	return withType(b.BlockType(), (*alias)(b))
+}
+
+// DividerBlock displays a horizontal line.
+// https://api.slack.com/reference/block-kit/blocks#divider
+type DividerBlock struct {
+	BlockID string `json:"block_id,omitempty"`
+}
+
+var _ Block = (*DividerBlock)(nil)
+
+// BlockType returns "divider".
+func (b *DividerBlock) BlockType() string {
+	return "divider"
+}
+
+// MarshalJSON converts the block to JSON with its type.
+func (b *DividerBlock) MarshalJSON() ([]byte, error) {
+	type alias DividerBlock
+	return withType(b.BlockType(), (*alias)(b))
+}
+
+// HeaderBlock displays a larger plain text.
+// https://api.slack.com/reference/block-kit/blocks#header
+type HeaderBlock struct {
+	BlockID string      `json:"block_id,omitempty"`
+	Text    *TextObject `json:"text"`
+}
+
+var _ Block = (*HeaderBlock)(nil)
+
+// BlockType returns "header".
+func (b *HeaderBlock) BlockType() string {
+	return "header"
+}
+
+// MarshalJSON converts the block to JSON with its type.
+func (b *HeaderBlock) MarshalJSON() ([]byte, error) {
+	type alias HeaderBlock
+	return withType(b.BlockType(), (*alias)(b))
+}
+
+// ContextBlock displays small texts and images as supplemental information.
+// https://api.slack.com/reference/block-kit/blocks#context
+type ContextBlock struct {
+	BlockID  string           `json:"block_id,omitempty"`
+	Elements []ContextElement `json:"elements"`
+}
+
+var _ Block = (*ContextBlock)(nil)
+
+// BlockType returns "context".
+func (b *ContextBlock) BlockType() string {
+	return "context"
+}
+
+// MarshalJSON converts the block to JSON with its type.
+func (b *ContextBlock) MarshalJSON() ([]byte, error) {
+	type alias ContextBlock
+	return withType(b.BlockType(), (*alias)(b))
+}
+
+// ImageBlock displays an image.
+// https://api.slack.com/reference/block-kit/blocks#image
+type ImageBlock struct {
+	BlockID  string      `json:"block_id,omitempty"`
+	ImageURL string      `json:"image_url"`
+	AltText  string      `json:"alt_text"`
+	Title    *TextObject `json:"title,omitempty"`
+}
+
+var _ Block = (*ImageBlock)(nil)
+
+// BlockType returns "image".
+func (b *ImageBlock) BlockType() string {
+	return "image"
+}
+
+// MarshalJSON converts the block to JSON with its type.
+func (b *ImageBlock) MarshalJSON() ([]byte, error) {
+	type alias ImageBlock
+	return withType(b.BlockType(), (*alias)(b))
+}
+
+// ActionsBlock displays interactive elements such as buttons.
+// https://api.slack.com/reference/block-kit/blocks#actions
+type ActionsBlock struct {
+	BlockID  string         `json:"block_id,omitempty"`
+	Elements []BlockElement `json:"elements"`
+}
+
+var _ Block = (*ActionsBlock)(nil)
+
+// BlockType returns "actions".
+func (b *ActionsBlock) BlockType() string {
+	return "actions"
+}
+
+// MarshalJSON converts the block to JSON with its type.
+func (b *ActionsBlock) MarshalJSON() ([]byte, error) {
+	type alias ActionsBlock
+	return withType(b.BlockType(), (*alias)(b))
+}
+
+// ButtonStyle defines the color scheme of a button.
+type ButtonStyle string
+
+const (
+	// ButtonDefault is the default style of a button.
+	ButtonDefault ButtonStyle = ""
+
+	// ButtonPrimary gives a green button for an affirmation action.
+	ButtonPrimary ButtonStyle = "primary"
+
+	// ButtonDanger gives a red button for a destructive action.
+	ButtonDanger ButtonStyle = "danger"
+)
+
+// ButtonElement represents a button.
+// When the button has ActionID, a click is passed to go-sarah's core as sarah.CallbackInput with the ActionID as its CallbackID
+// and with Value as its Value. Otherwise, set URL to open a web page.
+// https://api.slack.com/reference/block-kit/block-elements#button
+type ButtonElement struct {
+	Text     *TextObject `json:"text"`
+	ActionID string      `json:"action_id,omitempty"`
+	Value    string      `json:"value,omitempty"`
+	URL      string      `json:"url,omitempty"`
+	Style    ButtonStyle `json:"style,omitempty"`
+}
+
+var _ BlockElement = (*ButtonElement)(nil)
+
+// NewButton creates a ButtonElement that passes the given value to the sarah.CallbackFunc registered with the given callbackID.
+func NewButton(callbackID string, label string, value string) *ButtonElement {
+	return &ButtonElement{
+		Text:     PlainText(label),
+		ActionID: callbackID,
+		Value:    value,
+	}
+}
+
+// NewLinkButton creates a ButtonElement that opens the given URL.
+func NewLinkButton(label string, url string) *ButtonElement {
+	return &ButtonElement{
+		Text: PlainText(label),
+		URL:  url,
+	}
+}
+
+// WithStyle sets the given style and returns the button itself.
+func (e *ButtonElement) WithStyle(style ButtonStyle) *ButtonElement {
+	e.Style = style
+	return e
+}
+
+// ElementType returns "button".
+func (e *ButtonElement) ElementType() string {
+	return "button"
+}
+
+// MarshalJSON converts the element to JSON with its type.
+func (e *ButtonElement) MarshalJSON() ([]byte, error) {
+	type alias ButtonElement
+	return withType(e.ElementType(), (*alias)(e))
+}
+
+// ImageElement represents a small image that is placed in ContextBlock or as SectionBlock's accessory.
+// https://api.slack.com/reference/block-kit/block-elements#image
+type ImageElement struct {
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+var _ BlockElement = (*ImageElement)(nil)
+
+// ElementType returns "image".
+func (e *ImageElement) ElementType() string {
+	return "image"
+}
+
+// MarshalJSON converts the element to JSON with its type.
+func (e *ImageElement) MarshalJSON() ([]byte, error) {
+	type alias ImageElement
+	return withType(e.ElementType(), (*alias)(e))
+}
+
+func (*ImageElement) contextElement() {}
+
+// BlockMessage is a message that consists of Block Kit blocks.
+// Set this as sarah.CommandResponse's Content, or use NewBlockResponse, to send a message with modern Slack UI.
+// Build the message with the fluent methods as below:
+//
+//  message := slack.NewBlockMessage("Deployment is ready").
+//  	Header("Deployment").
+//  	Section(slack.Markdown("*api-server* is ready to deploy.")).
+//  	Fields(slack.Markdown("*Env*\nproduction"), slack.Markdown("*Version*\nv1.2.3")).
+//  	Divider().
+//  	Actions(
+//  		slack.NewButton("deploy", "Deploy", "v1.2.3").WithStyle(slack.ButtonPrimary),
+//  		slack.NewButton("deploy", "Cancel", "cancel"),
+//  	).
+//  	Context(slack.Markdown("Requested by <@U024BE7LH>"))
+//
+// Text is displayed in notifications and in clients that can not render blocks.
+type BlockMessage struct {
+	Text   string
+	Blocks []Block
+}
+
+// NewBlockMessage creates a BlockMessage with the given text for notifications.
+func NewBlockMessage(text string) *BlockMessage {
+	return &BlockMessage{
+		Text: text,
+	}
+}
+
+// Add appends the given blocks and returns the message itself.
+func (m *BlockMessage) Add(blocks ...Block) *BlockMessage {
+	m.Blocks = append(m.Blocks, blocks...)
+	return m
+}
+
+// Header appends a HeaderBlock with the given text.
+func (m *BlockMessage) Header(text string) *BlockMessage {
+	return m.Add(&HeaderBlock{Text: PlainText(text)})
+}
+
+// Section appends a SectionBlock with the given text.
+func (m *BlockMessage) Section(text *TextObject) *BlockMessage {
+	return m.Add(&SectionBlock{Text: text})
+}
+
+// Fields appends a SectionBlock with the given fields, which are displayed in two columns.
+func (m *BlockMessage) Fields(fields ...*TextObject) *BlockMessage {
+	return m.Add(&SectionBlock{Fields: fields})
+}
+
+// Divider appends a DividerBlock.
+func (m *BlockMessage) Divider() *BlockMessage {
+	return m.Add(&DividerBlock{})
+}
+
+// Context appends a ContextBlock with the given elements.
+func (m *BlockMessage) Context(elements ...ContextElement) *BlockMessage {
+	return m.Add(&ContextBlock{Elements: elements})
+}
+
+// Image appends an ImageBlock with the given image.
+func (m *BlockMessage) Image(imageURL string, altText string) *BlockMessage {
+	return m.Add(&ImageBlock{ImageURL: imageURL, AltText: altText})
+}
+
+// Actions appends an ActionsBlock with the given buttons.
+// Buttons may share a callback ID; each action_id is suffixed with its position since action_id must be unique in a block.
+func (m *BlockMessage) Actions(buttons ...*ButtonElement) *BlockMessage {
+	var elements []BlockElement
+	for i, b := range buttons {
+		button := *b
+		if button.ActionID != "" {
+			button.ActionID = actionID(button.ActionID, i)
+		}
+		elements = append(elements, &button)
+	}
+	return m.Add(&ActionsBlock{Elements: elements})
+}
+
+// blocks returns the blocks in the form that BlockPoster accepts.
+func (m *BlockMessage) blocks() []interface{} {
+	var blocks []interface{}
+	for _, b := range m.Blocks {
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+// NewBlockResponse creates *sarah.CommandResponse with the given BlockMessage.
+// Among RespOption, only RespWithNext and RespWithNextSerializable are applied.
+// The response is sent in the thread when the input is sent in a thread.
+func NewBlockResponse(message *BlockMessage, options ...RespOption) *sarah.CommandResponse {
+	stash := &respOptions{}
+	for _, opt := range options {
+		opt(stash)
+	}
+
+	return &sarah.CommandResponse{
+		Content:     message,
+		UserContext: stash.userContext,
+	}
+}
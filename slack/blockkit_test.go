@@ -0,0 +1,173 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"github.com/oklahomer/golack/v2/webapi"
+	"testing"
+)
+
+func TestBlockMessage_MarshalJSON(t *testing.T) {
+	message := NewBlockMessage("Deployment is ready").
+		Header("Deployment").
+		Section(Markdown("*api-server* is ready.")).
+		Fields(Markdown("*Env*"), PlainText("production")).
+		Divider().
+		Image("https://example.com/graph.png", "graph").
+		Context(Markdown("Requested by <@U024BE7LH>"), &ImageElement{ImageURL: "https://example.com/icon.png", AltText: "icon"}).
+		Actions(
+			NewButton("deploy", "Deploy", "yes").WithStyle(ButtonPrimary),
+			NewButton("deploy", "Cancel", "no"),
+			NewLinkButton("Logs", "https://example.com/logs"),
+		)
+
+	b, err := json.Marshal(message.Blocks)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := `[` +
+		`{"type":"header","text":{"type":"plain_text","text":"Deployment"}},` +
+		`{"type":"section","text":{"type":"mrkdwn","text":"*api-server* is ready."}},` +
+		`{"type":"section","fields":[{"type":"mrkdwn","text":"*Env*"},{"type":"plain_text","text":"production"}]},` +
+		`{"type":"divider"},` +
+		`{"type":"image","image_url":"https://example.com/graph.png","alt_text":"graph"},` +
+		`{"type":"context","elements":[{"type":"mrkdwn","text":"Requested by \u003c@U024BE7LH\u003e"},{"type":"image","image_url":"https://example.com/icon.png","alt_text":"icon"}]},` +
+		`{"type":"actions","elements":[` +
+		`{"type":"button","text":{"type":"plain_text","text":"Deploy"},"action_id":"deploy#0","value":"yes","style":"primary"},` +
+		`{"type":"button","text":{"type":"plain_text","text":"Cancel"},"action_id":"deploy#1","value":"no"},` +
+		`{"type":"button","text":{"type":"plain_text","text":"Logs"},"url":"https://example.com/logs"}` +
+		`]}` +
+		`]`
+	if string(b) != expected {
+		t.Errorf("Unexpected JSON is returned: %s.", string(b))
+	}
+}
+
+func TestSectionBlock_MarshalJSON(t *testing.T) {
+	block := &SectionBlock{
+		BlockID:   "summary",
+		Text:      PlainText("Approve?"),
+		Accessory: NewButton("approve", "Approve", "yes"),
+	}
+
+	b, err := json.Marshal(block)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := `{"type":"section","block_id":"summary","text":{"type":"plain_text","text":"Approve?"},` +
+		`"accessory":{"type":"button","text":{"type":"plain_text","text":"Approve"},"action_id":"approve","value":"yes"}}`
+	if string(b) != expected {
+		t.Errorf("Unexpected JSON is returned: %s.", string(b))
+	}
+}
+
+func TestBlockMessage_Actions(t *testing.T) {
+	button := NewButton("vote", "Yes", "yes")
+	message := NewBlockMessage("Vote").Actions(button)
+
+	if button.ActionID != "vote" {
+		t.Errorf("Given button must not be modified: %s.", button.ActionID)
+	}
+
+	elements := message.Blocks[0].(*ActionsBlock).Elements
+	if callbackID(elements[0].(*ButtonElement).ActionID) != "vote" {
+		t.Errorf("Callback ID can not be restored: %s.", elements[0].(*ButtonElement).ActionID)
+	}
+}
+
+func TestNewBlockResponse(t *testing.T) {
+	message := NewBlockMessage("Vote").Section(PlainText("Vote"))
+	response := NewBlockResponse(message, RespWithNextSerializable(&sarah.SerializableArgument{FuncIdentifier: "vote"}))
+
+	if response.Content != message {
+		t.Errorf("Given message is not set: %#v.", response.Content)
+	}
+
+	if response.UserContext == nil {
+		t.Error("UserContext is not set.")
+	}
+}
+
+func TestAdapter_SendMessage_BlockMessage(t *testing.T) {
+	message := NewBlockMessage("Vote").Section(PlainText("Vote"))
+
+	t.Run("Public", func(t *testing.T) {
+		var givenThread string
+		var givenText string
+		var givenBlocks []interface{}
+		adapter := &Adapter{
+			blockPoster: &DummyBlockPoster{
+				PostBlocksFunc: func(_ context.Context, _ event.ChannelID, threadTimeStamp string, text string, blocks []interface{}) (string, error) {
+					givenThread = threadTimeStamp
+					givenText = text
+					givenBlocks = blocks
+					return "1355517536.000002", nil
+				},
+			},
+		}
+
+		destination := sarah.NewThreadDestination(event.ChannelID("C123"), "1355517536.000001")
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(destination, message))
+
+		if givenThread != "1355517536.000001" {
+			t.Errorf("Unexpected thread is given: %s.", givenThread)
+		}
+
+		if givenText != "Vote" {
+			t.Errorf("Unexpected text is given: %s.", givenText)
+		}
+
+		if len(givenBlocks) != 1 || givenBlocks[0] != message.Blocks[0] {
+			t.Errorf("Unexpected blocks are given: %#v.", givenBlocks)
+		}
+	})
+
+	t.Run("Private", func(t *testing.T) {
+		var givenUser string
+		var givenMessage *webapi.PostMessage
+		var givenBlocks []interface{}
+		adapter := &Adapter{
+			ephemeralPoster: &DummyEphemeralPoster{
+				PostEphemeralFunc: func(_ context.Context, userID string, m *webapi.PostMessage, blocks []interface{}) error {
+					givenUser = userID
+					givenMessage = m
+					givenBlocks = blocks
+					return nil
+				},
+			},
+		}
+
+		destination := sarah.NewPrivateDestination(event.ChannelID("C123"), "U024BE7LH")
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(destination, message))
+
+		if givenUser != "U024BE7LH" {
+			t.Errorf("Unexpected user is given: %s.", givenUser)
+		}
+
+		if givenMessage == nil || givenMessage.Channel != "C123" || givenMessage.Text != "Vote" {
+			t.Errorf("Unexpected message is given: %#v.", givenMessage)
+		}
+
+		if len(givenBlocks) != 1 {
+			t.Errorf("Unexpected blocks are given: %#v.", givenBlocks)
+		}
+	})
+}
+
+func Test_editableContent_BlockMessage(t *testing.T) {
+	message := NewBlockMessage("Vote").Divider()
+
+	text, blocks, err := editableContent(message)
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if text != "Vote" || len(blocks) != 1 {
+		t.Errorf("Unexpected content is returned: %s, %#v.", text, blocks)
+	}
+}
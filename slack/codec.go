@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+)
+
+// OutputCodec converts a message sent via Adapter to JSON and vice versa so the message can be persisted by sarah.OutboxStore.
+// This supports a text message and *sarah.RichMessage sent to a channel, in a thread, privately, or as a response to a slash command.
+//
+//  store, err := sarah.NewFileOutboxStore("/var/lib/sarah/outbox/slack", slack.NewOutputCodec())
+//  outbox := sarah.NewOutbox(sarah.NewOutboxConfig(), sarah.OutboxWithStore(store))
+type OutputCodec struct{}
+
+var _ sarah.OutputCodec = (*OutputCodec)(nil)
+
+// NewOutputCodec creates and returns a new OutputCodec.
+func NewOutputCodec() *OutputCodec {
+	return &OutputCodec{}
+}
+
+type storedOutput struct {
+	Channel     event.ChannelID    `json:"channel"`
+	ResponseURL string             `json:"response_url,omitempty"`
+	ThreadID    string             `json:"thread_id,omitempty"`
+	UserID      string             `json:"user_id,omitempty"`
+	Text        *string            `json:"text,omitempty"`
+	Rich        *sarah.RichMessage `json:"rich,omitempty"`
+}
+
+// Encode converts the given Output to JSON.
+func (c *OutputCodec) Encode(output sarah.Output) ([]byte, error) {
+	stored := &storedOutput{
+		ThreadID: sarah.ThreadID(output.Destination()),
+		UserID:   sarah.PrivateUserID(output.Destination()),
+	}
+	switch destination := sarah.BaseDestination(output.Destination()).(type) {
+	case event.ChannelID:
+		stored.Channel = destination
+
+	case *SlashCommandDestination:
+		stored.Channel = destination.Channel
+		stored.ResponseURL = destination.ResponseURL
+
+	default:
+		return nil, fmt.Errorf("destination is not instance of Channel: %#v", output.Destination())
+
+	}
+
+	switch content := output.Content().(type) {
+	case string:
+		stored.Text = &content
+
+	case *sarah.RichMessage:
+		stored.Rich = content
+
+	default:
+		return nil, fmt.Errorf("unsupported content type: %T", content)
+
+	}
+
+	return json.Marshal(stored)
+}
+
+// Decode converts the given JSON back to an Output.
+func (c *OutputCodec) Decode(b []byte) (sarah.Output, error) {
+	stored := &storedOutput{}
+	err := json.Unmarshal(b, stored)
+	if err != nil {
+		return nil, err
+	}
+
+	var destination sarah.OutputDestination = stored.Channel
+	if stored.ResponseURL != "" {
+		destination = &SlashCommandDestination{
+			Channel:     stored.Channel,
+			ResponseURL: stored.ResponseURL,
+		}
+	}
+	if stored.ThreadID != "" {
+		destination = sarah.NewThreadDestination(destination, stored.ThreadID)
+	}
+	if stored.UserID != "" {
+		destination = sarah.NewPrivateDestination(destination, stored.UserID)
+	}
+
+	switch {
+	case stored.Text != nil:
+		return sarah.NewOutputMessage(destination, *stored.Text), nil
+
+	case stored.Rich != nil:
+		return sarah.NewOutputMessage(destination, stored.Rich), nil
+
+	default:
+		return nil, fmt.Errorf("no content is stored: %s", string(b))
+
+	}
+}
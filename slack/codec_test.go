@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"testing"
+)
+
+func TestOutputCodec(t *testing.T) {
+	channelID := event.ChannelID("C123")
+	outputs := []sarah.Output{
+		sarah.NewOutputMessage(channelID, "text"),
+		sarah.NewOutputMessage(sarah.NewThreadDestination(channelID, "1355517523.000005"), "text"),
+		sarah.NewOutputMessage(sarah.NewPrivateDestination(sarah.NewThreadDestination(channelID, "1355517523.000005"), "U123"), "text"),
+		sarah.NewOutputMessage(channelID, &sarah.RichMessage{Title: "title", Text: "text"}),
+	}
+
+	codec := NewOutputCodec()
+	for i, output := range outputs {
+		b, err := codec.Encode(output)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		decoded, err := codec.Decode(b)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+
+		if sarah.BaseDestination(decoded.Destination()) != channelID {
+			t.Errorf("Unexpected destination is decoded on test #%d: %#v.", i, decoded.Destination())
+		}
+
+		if sarah.ThreadID(decoded.Destination()) != sarah.ThreadID(output.Destination()) {
+			t.Errorf("Unexpected thread is decoded on test #%d: %#v.", i, decoded.Destination())
+		}
+
+		if sarah.PrivateUserID(decoded.Destination()) != sarah.PrivateUserID(output.Destination()) {
+			t.Errorf("Unexpected user is decoded on test #%d: %#v.", i, decoded.Destination())
+		}
+
+		switch content := decoded.Content().(type) {
+		case string:
+			if content != output.Content() {
+				t.Errorf("Unexpected content is decoded on test #%d: %#v.", i, content)
+			}
+
+		case *sarah.RichMessage:
+			if content.Title != "title" || content.Text != "text" {
+				t.Errorf("Unexpected content is decoded on test #%d: %#v.", i, content)
+			}
+
+		default:
+			t.Errorf("Unexpected content is decoded on test #%d: %#v.", i, content)
+
+		}
+	}
+}
+
+func TestOutputCodec_SlashCommand(t *testing.T) {
+	destination := &SlashCommandDestination{Channel: "C123", ResponseURL: "https://hooks.slack.com/commands/T123/456/abc"}
+	output := sarah.NewOutputMessage(sarah.NewPrivateDestination(destination, "U123"), "text")
+
+	codec := NewOutputCodec()
+	b, err := codec.Encode(output)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	decoded, err := codec.Decode(b)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	base, ok := sarah.BaseDestination(decoded.Destination()).(*SlashCommandDestination)
+	if !ok || *base != *destination {
+		t.Errorf("Unexpected destination is decoded: %#v.", decoded.Destination())
+	}
+
+	if sarah.PrivateUserID(decoded.Destination()) != "U123" {
+		t.Errorf("Unexpected user is decoded: %#v.", decoded.Destination())
+	}
+}
+
+func TestOutputCodec_Encode_Unsupported(t *testing.T) {
+	codec := NewOutputCodec()
+
+	_, err := codec.Encode(sarah.NewOutputMessage("C123", "text"))
+	if err == nil {
+		t.Error("Expected error is not returned for an unsupported destination.")
+	}
+
+	_, err = codec.Encode(sarah.NewOutputMessage(event.ChannelID("C123"), struct{}{}))
+	if err == nil {
+		t.Error("Expected error is not returned for an unsupported content.")
+	}
+}
+
+func TestOutputCodec_Decode_Error(t *testing.T) {
+	codec := NewOutputCodec()
+
+	_, err := codec.Decode([]byte("invalid"))
+	if err == nil {
+		t.Error("Expected error is not returned for an invalid JSON.")
+	}
+
+	_, err = codec.Decode([]byte(`{"channel":"C123"}`))
+	if err == nil {
+		t.Error("Expected error is not returned for a JSON without content.")
+	}
+}
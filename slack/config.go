@@ -1,21 +1,56 @@
 package slack
 
 import (
+	"errors"
+	"fmt"
 	"github.com/oklahomer/go-kasumi/retry"
+	"github.com/oklahomer/go-sarah/v4"
+	"strings"
 	"time"
 )
 
+// ConnectionMode defines how Adapter receives events from Slack.
+type ConnectionMode string
+
+const (
+	// RTMMode receives events over RTM API's WebSocket connection.
+	RTMMode ConnectionMode = "rtm"
+
+	// EventsAPIMode receives events by running an HTTP server that Slack's Events API sends events to.
+	EventsAPIMode ConnectionMode = "events_api"
+
+	// EventsHTTPMode receives events with the http.Handler that Adapter.EventsHandler returns.
+	// The handler is mounted on a server that the developer runs, so this suits a serverless platform.
+	EventsHTTPMode ConnectionMode = "events_http"
+
+	// SocketMode receives events over Socket Mode's WebSocket connection.
+	// Unlike EventsAPIMode, this requires no public endpoint, so a Bot behind a firewall can receive events.
+	// Config.AppToken must be set to open the connection.
+	SocketMode ConnectionMode = "socket_mode"
+)
+
 // Config contains some configuration variables for slack Adapter.
+// ConnectionMode decides the default payload handler when none of WithRTMPayloadHandler, WithEventsPayloadHandler,
+// WithEventsHTTPHandler and WithSocketModePayloadHandler is given. AppToken is the app-level token that starts with "xapp-" to use Socket Mode.
+// AppSecret is the signing secret to verify the requests from Slack; EventsAPIMode, EventsHTTPMode and the http.Handlers such as SlashCommandHandler require this.
+// DirectoryTTL is the duration to keep the users and channels that Adapter.Directory caches.
+// BackfillLimit is the maximum number of messages to fetch from each channel after an RTM API or Socket Mode connection is re-established
+// so the messages posted while the connection was down are passed to go-sarah's core as catch-up inputs.
+// Only the channels that any message has been received from are backfilled. Zero disables the backfill.
 type Config struct {
-	Token            string        `json:"token" yaml:"token"`
-	AppSecret        string        `json:"app_secret" yaml:"app_secret"`
-	ListenPort       int           `json:"listen_port" yaml:"listen_port"`
-	HelpCommand      string        `json:"help_command" yaml:"help_command"`
-	AbortCommand     string        `json:"abort_command" yaml:"abort_command"`
-	SendingQueueSize uint          `json:"sending_queue_size" yaml:"sending_queue_size"`
-	RequestTimeout   time.Duration `json:"request_timeout" yaml:"request_timeout"`
-	PingInterval     time.Duration `json:"ping_interval" yaml:"ping_interval"`
-	RetryPolicy      *retry.Policy `json:"retry_policy" yaml:"retry_policy"`
+	Token            sarah.Secret   `json:"token" yaml:"token"`
+	AppToken         sarah.Secret   `json:"app_token" yaml:"app_token"`
+	ConnectionMode   ConnectionMode `json:"connection_mode" yaml:"connection_mode"`
+	AppSecret        sarah.Secret   `json:"app_secret" yaml:"app_secret"`
+	ListenPort       int            `json:"listen_port" yaml:"listen_port"`
+	HelpCommand      string         `json:"help_command" yaml:"help_command"`
+	AbortCommand     string         `json:"abort_command" yaml:"abort_command"`
+	SendingQueueSize uint           `json:"sending_queue_size" yaml:"sending_queue_size"`
+	RequestTimeout   time.Duration  `json:"request_timeout" yaml:"request_timeout"`
+	PingInterval     time.Duration  `json:"ping_interval" yaml:"ping_interval"`
+	RetryPolicy      *retry.Policy  `json:"retry_policy" yaml:"retry_policy"`
+	DirectoryTTL     time.Duration  `json:"directory_ttl" yaml:"directory_ttl"`
+	BackfillLimit    uint           `json:"backfill_limit" yaml:"backfill_limit"`
 }
 
 // NewConfig returns initialized Config struct with default settings.
@@ -24,6 +59,8 @@ type Config struct {
 func NewConfig() *Config {
 	return &Config{
 		Token:            "",
+		AppToken:         "",
+		ConnectionMode:   "",
 		AppSecret:        "",
 		ListenPort:       8080,
 		HelpCommand:      ".help",
@@ -35,5 +72,68 @@ func NewConfig() *Config {
 			Trial:    10,
 			Interval: 500 * time.Millisecond,
 		},
+		DirectoryTTL:  1 * time.Hour,
+		BackfillLimit: 100,
+	}
+}
+
+// ApplyDefaults sets the default RetryPolicy when it is not set.
+func (c *Config) ApplyDefaults() {
+	if c.RetryPolicy == nil {
+		c.RetryPolicy = NewConfig().RetryPolicy
+	}
+}
+
+// Validate checks the configuration values and returns an error that describes all invalid values.
+// Token is required even when the Slack client is given via WithSlackClient, so skip this in such a case.
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.Token == "" {
+		errs = append(errs, "token is empty")
+	}
+
+	switch c.ConnectionMode {
+	case "", RTMMode, EventsAPIMode, EventsHTTPMode:
+		// O.K.
+
+	case SocketMode:
+		if c.AppToken == "" {
+			errs = append(errs, "app_token is empty while socket_mode requires one")
+		}
+
+	default:
+		errs = append(errs, fmt.Sprintf("unknown connection_mode: %s", c.ConnectionMode))
+
+	}
+
+	if c.ConnectionMode == RTMMode && c.PingInterval <= 0 {
+		errs = append(errs, "ping_interval must be greater than zero")
+	}
+
+	// Anyone could forge events to the HTTP endpoint without the signing secret.
+	if (c.ConnectionMode == EventsAPIMode || c.ConnectionMode == EventsHTTPMode) && c.AppSecret == "" {
+		errs = append(errs, fmt.Sprintf("app_secret is empty while %s requires one", c.ConnectionMode))
+	}
+
+	if c.ConnectionMode == EventsAPIMode && (c.ListenPort <= 0 || c.ListenPort > 65535) {
+		errs = append(errs, fmt.Sprintf("listen_port must be between 1 and 65535: %d", c.ListenPort))
+	}
+
+	if c.RequestTimeout < 0 {
+		errs = append(errs, "request_timeout must not be negative")
+	}
+
+	if c.DirectoryTTL < 0 {
+		errs = append(errs, "directory_ttl must not be negative")
+	}
+
+	if c.BackfillLimit > maxBackfillLimit {
+		errs = append(errs, fmt.Sprintf("backfill_limit must not be greater than %d", maxBackfillLimit))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
 	}
+	return nil
 }
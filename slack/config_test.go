@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v2"
+	"strings"
 	"testing"
 )
 
@@ -31,8 +32,8 @@ func TestConfigUnmarshalYaml(t *testing.T) {
 		t.Fatalf("error on parsing given YAML structure: %s. %s.", string(yamlBytes), err.Error())
 	}
 
-	if config.Token != token {
-		t.Errorf("given token was not set: %s.", config.Token)
+	if config.Token.Reveal() != token {
+		t.Errorf("given token was not set: %s.", config.Token.Reveal())
 	}
 
 	if config.SendingQueueSize != queueSize {
@@ -52,11 +53,111 @@ func TestConfigUnmarshalJson(t *testing.T) {
 		t.Fatalf("error on parsing given JSON structure: %s. %s.", string(jsonBytes), err.Error())
 	}
 
-	if config.Token != token {
-		t.Errorf("given token was not set: %s.", config.Token)
+	if config.Token.Reveal() != token {
+		t.Errorf("given token was not set: %s.", config.Token.Reveal())
 	}
 
 	if config.SendingQueueSize != queueSize {
 		t.Errorf("queue size is not updated with given value: %d.", config.SendingQueueSize)
 	}
 }
+
+func TestConfig_ApplyDefaults(t *testing.T) {
+	config := &Config{}
+	config.ApplyDefaults()
+
+	if config.RetryPolicy == nil {
+		t.Error("Default RetryPolicy is not set.")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		config func(*Config)
+		errs   []string
+	}{
+		{
+			config: func(c *Config) {
+				c.Token = "xoxb-dummy"
+				c.ConnectionMode = RTMMode
+			},
+		},
+		{
+			config: func(c *Config) {
+				c.ConnectionMode = SocketMode
+			},
+			errs: []string{"token", "app_token"},
+		},
+		{
+			config: func(c *Config) {
+				c.Token = "xoxb-dummy"
+				c.ConnectionMode = RTMMode
+				c.PingInterval = 0
+			},
+			errs: []string{"ping_interval"},
+		},
+		{
+			config: func(c *Config) {
+				c.Token = "xoxb-dummy"
+				c.ConnectionMode = EventsAPIMode
+				c.AppSecret = "secret"
+				c.ListenPort = 0
+				c.RequestTimeout = -1
+			},
+			errs: []string{"listen_port", "request_timeout"},
+		},
+		{
+			config: func(c *Config) {
+				c.Token = "xoxb-dummy"
+				c.ConnectionMode = EventsHTTPMode
+			},
+			errs: []string{"app_secret"},
+		},
+		{
+			config: func(c *Config) {
+				c.Token = "xoxb-dummy"
+				c.ConnectionMode = EventsHTTPMode
+				c.AppSecret = "secret"
+			},
+		},
+		{
+			config: func(c *Config) {
+				c.Token = "xoxb-dummy"
+				c.ConnectionMode = "unknown"
+			},
+			errs: []string{"connection_mode"},
+		},
+		{
+			config: func(c *Config) {
+				c.Token = "xoxb-dummy"
+				c.ConnectionMode = SocketMode
+				c.AppToken = "xapp-dummy"
+				c.BackfillLimit = 1000
+			},
+			errs: []string{"backfill_limit"},
+		},
+	}
+
+	for i, tt := range tests {
+		config := NewConfig()
+		tt.config(config)
+		err := config.Validate()
+
+		if len(tt.errs) == 0 {
+			if err != nil {
+				t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+			continue
+		}
+		for _, expected := range tt.errs {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("Error does not describe %s on test #%d: %s.", expected, i, err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/golack/v2/event"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserInfo represents a Slack user that Directory caches.
+type UserInfo struct {
+	ID          event.UserID
+	Name        string
+	DisplayName string
+	RealName    string
+	Email       string
+	IsBot       bool
+}
+
+// PreferredName returns the name that Slack displays for the user.
+func (u *UserInfo) PreferredName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.RealName != "" {
+		return u.RealName
+	}
+	return u.Name
+}
+
+// ChannelInfo represents a Slack channel that Directory caches.
+type ChannelInfo struct {
+	ID        event.ChannelID
+	Name      string
+	IsPrivate bool
+}
+
+// DirectoryFetcher defines an interface that fetches all users and channels of the workspace.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it.
+// Otherwise, Adapter calls users.list and conversations.list with Config.Token by itself.
+type DirectoryFetcher interface {
+	FetchUsers(ctx context.Context) ([]*UserInfo, error)
+	FetchChannels(ctx context.Context) ([]*ChannelInfo, error)
+}
+
+// WithDirectoryFetcher creates an AdapterOption that sets the DirectoryFetcher for Adapter.Directory.
+func WithDirectoryFetcher(fetcher DirectoryFetcher) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.directoryFetcher = fetcher
+	}
+}
+
+var (
+	// ErrUserNotFound is returned when Directory has no corresponding user.
+	ErrUserNotFound = errors.New("user is not found")
+
+	// ErrChannelNotFound is returned when Directory has no corresponding channel.
+	ErrChannelNotFound = errors.New("channel is not found")
+)
+
+// directoryMissRefreshInterval is the minimum interval to refresh the cache on a lookup of an unknown user or channel.
+// This lets a newly joined user be found before the TTL expires without letting repeated misses exhaust the rate limit.
+const directoryMissRefreshInterval = 1 * time.Minute
+
+// Directory caches the users and the channels of the workspace so commands can resolve IDs and human-readable names
+// without calling Slack's Web API each time.
+// The whole directory is fetched at the first lookup, and is fetched again when the cache is older than Config.DirectoryTTL.
+// Obtain the instance with Adapter.Directory:
+//
+//  directory := slackAdapter.Directory()
+//  user, err := directory.User(ctx, "U024BE7LH")
+//  text := directory.ResolveMentions(ctx, input.Message()) // "<@U024BE7LH> hi" becomes "@bob hi"
+type Directory struct {
+	fetcher DirectoryFetcher
+	ttl     time.Duration
+
+	// refreshing serializes refreshes so concurrent lookups do not fetch the same directory at once.
+	refreshing sync.Mutex
+
+	mutex     sync.RWMutex
+	users     map[event.UserID]*UserInfo
+	channels  map[event.ChannelID]*ChannelInfo
+	fetchedAt time.Time
+}
+
+func newDirectory(fetcher DirectoryFetcher, ttl time.Duration) *Directory {
+	return &Directory{
+		fetcher: fetcher,
+		ttl:     ttl,
+	}
+}
+
+// Refresh fetches the users and the channels regardless of the cache's age.
+func (d *Directory) Refresh(ctx context.Context) error {
+	d.refreshing.Lock()
+	defer d.refreshing.Unlock()
+	return d.refresh(ctx)
+}
+
+func (d *Directory) refresh(ctx context.Context) error {
+	users, err := d.fetcher.FetchUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch users: %w", err)
+	}
+
+	channels, err := d.fetcher.FetchChannels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch channels: %w", err)
+	}
+
+	userMap := make(map[event.UserID]*UserInfo, len(users))
+	for _, u := range users {
+		userMap[u.ID] = u
+	}
+	channelMap := make(map[event.ChannelID]*ChannelInfo, len(channels))
+	for _, c := range channels {
+		channelMap[c.ID] = c
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.users = userMap
+	d.channels = channelMap
+	d.fetchedAt = time.Now()
+	return nil
+}
+
+func (d *Directory) age() time.Duration {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.fetchedAt.IsZero() {
+		return -1
+	}
+	return time.Since(d.fetchedAt)
+}
+
+// ensureFresh refreshes the cache when it is not fetched yet or is older than the given duration.
+func (d *Directory) ensureFresh(ctx context.Context, maxAge time.Duration) error {
+	if age := d.age(); age >= 0 && age <= maxAge {
+		return nil
+	}
+
+	d.refreshing.Lock()
+	defer d.refreshing.Unlock()
+
+	// Another goroutine may have refreshed while waiting for the lock.
+	age := d.age()
+	if age >= 0 && age <= maxAge {
+		return nil
+	}
+
+	err := d.refresh(ctx)
+	if err != nil && age >= 0 {
+		// Stale entries are still better than nothing while Slack is unavailable or the rate limit is exceeded.
+		moduleLogger(ctx).Warn("Failed to refresh directory. Use the cached one", logging.Err(err))
+		return nil
+	}
+	return err
+}
+
+// lookup finds an entry with the given function. On a miss, the cache is refreshed once and the lookup is retried.
+func (d *Directory) lookup(ctx context.Context, find func() bool) (bool, error) {
+	err := d.ensureFresh(ctx, d.ttl)
+	if err != nil {
+		return false, err
+	}
+
+	d.mutex.RLock()
+	found := find()
+	d.mutex.RUnlock()
+	if found {
+		return true, nil
+	}
+
+	err = d.ensureFresh(ctx, directoryMissRefreshInterval)
+	if err != nil {
+		return false, err
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return find(), nil
+}
+
+// User returns the user with the given ID.
+func (d *Directory) User(ctx context.Context, id event.UserID) (*UserInfo, error) {
+	var user *UserInfo
+	found, err := d.lookup(ctx, func() bool {
+		user = d.users[id]
+		return user != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UserByName returns the user with the given name or display name. A leading "@" is ignored.
+func (d *Directory) UserByName(ctx context.Context, name string) (*UserInfo, error) {
+	name = strings.TrimPrefix(name, "@")
+	return d.findUser(ctx, func(u *UserInfo) bool {
+		return u.Name == name || u.DisplayName == name
+	})
+}
+
+// UserByEmail returns the user with the given email address.
+// The email address is only available when the token has users:read.email scope.
+func (d *Directory) UserByEmail(ctx context.Context, email string) (*UserInfo, error) {
+	return d.findUser(ctx, func(u *UserInfo) bool {
+		return u.Email != "" && strings.EqualFold(u.Email, email)
+	})
+}
+
+func (d *Directory) findUser(ctx context.Context, match func(*UserInfo) bool) (*UserInfo, error) {
+	var user *UserInfo
+	found, err := d.lookup(ctx, func() bool {
+		for _, u := range d.users {
+			if match(u) {
+				user = u
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Channel returns the channel with the given ID.
+func (d *Directory) Channel(ctx context.Context, id event.ChannelID) (*ChannelInfo, error) {
+	var channel *ChannelInfo
+	found, err := d.lookup(ctx, func() bool {
+		channel = d.channels[id]
+		return channel != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrChannelNotFound
+	}
+	return channel, nil
+}
+
+// ChannelByName returns the channel with the given name. A leading "#" is ignored.
+func (d *Directory) ChannelByName(ctx context.Context, name string) (*ChannelInfo, error) {
+	name = strings.TrimPrefix(name, "#")
+	return d.findChannel(ctx, func(c *ChannelInfo) bool {
+		return c.Name == name
+	})
+}
+
+func (d *Directory) findChannel(ctx context.Context, match func(*ChannelInfo) bool) (*ChannelInfo, error) {
+	var channel *ChannelInfo
+	found, err := d.lookup(ctx, func() bool {
+		for _, c := range d.channels {
+			if match(c) {
+				channel = c
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrChannelNotFound
+	}
+	return channel, nil
+}
+
+// ResolveMentions replaces the user mentions in the given text, such as <@U024BE7LH>, with the users' names such as @bob.
+// A mention of an unknown user is left as-is.
+func (d *Directory) ResolveMentions(ctx context.Context, text string) string {
+	return mentionPattern.ReplaceAllStringFunc(text, func(mention string) string {
+		id := mentionPattern.FindStringSubmatch(mention)[1]
+		user, err := d.User(ctx, event.UserID(id))
+		if err != nil {
+			return mention
+		}
+		return "@" + user.PreferredName()
+	})
+}
+
+// Directory returns the Directory that caches the users and the channels of the workspace.
+func (adapter *Adapter) Directory() *Directory {
+	return adapter.directory
+}
+
+var _ DirectoryFetcher = (*webAPIClient)(nil)
+
+type usersListResponse struct {
+	webAPIResponse
+	Members []struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Deleted bool   `json:"deleted"`
+		IsBot   bool   `json:"is_bot"`
+		Profile struct {
+			DisplayName string `json:"display_name"`
+			RealName    string `json:"real_name"`
+			Email       string `json:"email"`
+		} `json:"profile"`
+	} `json:"members"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+// FetchUsers fetches all active users with users.list method.
+func (c *webAPIClient) FetchUsers(ctx context.Context) ([]*UserInfo, error) {
+	var users []*UserInfo
+	cursor := ""
+	for {
+		params := url.Values{"limit": []string{"200"}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		response := &usersListResponse{}
+		err := c.call(ctx, "users.list", params, response)
+		if err != nil {
+			return nil, err
+		}
+		if !response.OK {
+			return nil, fmt.Errorf("failed to list users: %s", response.Error)
+		}
+
+		for _, m := range response.Members {
+			if m.Deleted {
+				continue
+			}
+			users = append(users, &UserInfo{
+				ID:          event.UserID(m.ID),
+				Name:        m.Name,
+				DisplayName: m.Profile.DisplayName,
+				RealName:    m.Profile.RealName,
+				Email:       m.Profile.Email,
+				IsBot:       m.IsBot,
+			})
+		}
+
+		cursor = response.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return users, nil
+		}
+	}
+}
+
+type conversationsListResponse struct {
+	webAPIResponse
+	Channels []struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		IsPrivate bool   `json:"is_private"`
+	} `json:"channels"`
+	ResponseMetadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+// FetchChannels fetches all public and private channels that the token can see with conversations.list method.
+func (c *webAPIClient) FetchChannels(ctx context.Context) ([]*ChannelInfo, error) {
+	var channels []*ChannelInfo
+	cursor := ""
+	for {
+		params := url.Values{
+			"limit":            []string{"200"},
+			"types":            []string{"public_channel,private_channel"},
+			"exclude_archived": []string{"true"},
+		}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		response := &conversationsListResponse{}
+		err := c.call(ctx, "conversations.list", params, response)
+		if err != nil {
+			return nil, err
+		}
+		if !response.OK {
+			return nil, fmt.Errorf("failed to list channels: %s", response.Error)
+		}
+
+		for _, ch := range response.Channels {
+			channels = append(channels, &ChannelInfo{
+				ID:        event.ChannelID(ch.ID),
+				Name:      ch.Name,
+				IsPrivate: ch.IsPrivate,
+			})
+		}
+
+		cursor = response.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return channels, nil
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/golack/v2/event"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type DummyDirectoryFetcher struct {
+	FetchUsersFunc    func(context.Context) ([]*UserInfo, error)
+	FetchChannelsFunc func(context.Context) ([]*ChannelInfo, error)
+}
+
+var _ DirectoryFetcher = (*DummyDirectoryFetcher)(nil)
+
+func (f *DummyDirectoryFetcher) FetchUsers(ctx context.Context) ([]*UserInfo, error) {
+	return f.FetchUsersFunc(ctx)
+}
+
+func (f *DummyDirectoryFetcher) FetchChannels(ctx context.Context) ([]*ChannelInfo, error) {
+	return f.FetchChannelsFunc(ctx)
+}
+
+// countingFetcher returns a DummyDirectoryFetcher with fixed entries and a function to return how many times the users are fetched.
+func countingFetcher() (*DummyDirectoryFetcher, func() int) {
+	var mutex sync.Mutex
+	count := 0
+	fetcher := &DummyDirectoryFetcher{
+		FetchUsersFunc: func(_ context.Context) ([]*UserInfo, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			count++
+			return []*UserInfo{
+				{ID: "U1", Name: "bob", DisplayName: "Bobby", Email: "bob@example.com"},
+				{ID: "U2", Name: "alice", RealName: "Alice Smith"},
+			}, nil
+		},
+		FetchChannelsFunc: func(_ context.Context) ([]*ChannelInfo, error) {
+			return []*ChannelInfo{
+				{ID: "C1", Name: "general"},
+			}, nil
+		},
+	}
+	return fetcher, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return count
+	}
+}
+
+func TestWithDirectoryFetcher(t *testing.T) {
+	fetcher := &DummyDirectoryFetcher{}
+	adapter := &Adapter{}
+
+	WithDirectoryFetcher(fetcher)(adapter)
+
+	if adapter.directoryFetcher != fetcher {
+		t.Errorf("Expected DirectoryFetcher is not set: %#v.", adapter.directoryFetcher)
+	}
+}
+
+func TestUserInfo_PreferredName(t *testing.T) {
+	tests := []struct {
+		user     *UserInfo
+		expected string
+	}{
+		{user: &UserInfo{Name: "bob", DisplayName: "Bobby", RealName: "Bob Smith"}, expected: "Bobby"},
+		{user: &UserInfo{Name: "bob", RealName: "Bob Smith"}, expected: "Bob Smith"},
+		{user: &UserInfo{Name: "bob"}, expected: "bob"},
+	}
+
+	for i, tt := range tests {
+		if name := tt.user.PreferredName(); name != tt.expected {
+			t.Errorf("Unexpected name is returned on test #%d: %s.", i, name)
+		}
+	}
+}
+
+func TestDirectory_Lookup(t *testing.T) {
+	fetcher, fetched := countingFetcher()
+	directory := newDirectory(fetcher, time.Hour)
+	ctx := context.TODO()
+
+	user, err := directory.User(ctx, "U1")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if user.Name != "bob" {
+		t.Errorf("Unexpected user is returned: %#v.", user)
+	}
+
+	user, err = directory.UserByName(ctx, "@Bobby")
+	if err != nil || user.ID != "U1" {
+		t.Errorf("User is not found by display name: %#v, %#v.", user, err)
+	}
+
+	user, err = directory.UserByEmail(ctx, "BOB@example.com")
+	if err != nil || user.ID != "U1" {
+		t.Errorf("User is not found by email: %#v, %#v.", user, err)
+	}
+
+	channel, err := directory.Channel(ctx, "C1")
+	if err != nil || channel.Name != "general" {
+		t.Errorf("Channel is not found: %#v, %#v.", channel, err)
+	}
+
+	channel, err = directory.ChannelByName(ctx, "#general")
+	if err != nil || channel.ID != "C1" {
+		t.Errorf("Channel is not found by name: %#v, %#v.", channel, err)
+	}
+
+	if fetched() != 1 {
+		t.Errorf("Directory must be fetched once, but was fetched %d times.", fetched())
+	}
+
+	_, err = directory.User(ctx, "U999")
+	if err != ErrUserNotFound {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	_, err = directory.ChannelByName(ctx, "random")
+	if err != ErrChannelNotFound {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if fetched() != 1 {
+		t.Errorf("Directory must not be fetched again right after the previous fetch, but was fetched %d times.", fetched())
+	}
+}
+
+func TestDirectory_Lookup_Expired(t *testing.T) {
+	fetcher, fetched := countingFetcher()
+	directory := newDirectory(fetcher, time.Hour)
+	ctx := context.TODO()
+
+	_, _ = directory.User(ctx, "U1")
+	directory.fetchedAt = time.Now().Add(-2 * time.Hour)
+	_, _ = directory.User(ctx, "U1")
+
+	if fetched() != 2 {
+		t.Errorf("Expired directory is not fetched again: %d.", fetched())
+	}
+}
+
+func TestDirectory_Lookup_Miss(t *testing.T) {
+	fetcher, fetched := countingFetcher()
+	directory := newDirectory(fetcher, time.Hour)
+	ctx := context.TODO()
+
+	_, _ = directory.User(ctx, "U1")
+	directory.fetchedAt = time.Now().Add(-2 * directoryMissRefreshInterval)
+
+	_, _ = directory.User(ctx, "U1")
+	if fetched() != 1 {
+		t.Errorf("Directory must not be fetched on a hit: %d.", fetched())
+	}
+
+	_, _ = directory.User(ctx, "U999")
+	if fetched() != 2 {
+		t.Errorf("Directory is not fetched on a miss: %d.", fetched())
+	}
+}
+
+func TestDirectory_Lookup_Error(t *testing.T) {
+	fetchErr := errors.New("rate limited")
+	fail := false
+	fetcher := &DummyDirectoryFetcher{
+		FetchUsersFunc: func(_ context.Context) ([]*UserInfo, error) {
+			if fail {
+				return nil, fetchErr
+			}
+			return []*UserInfo{{ID: "U1", Name: "bob"}}, nil
+		},
+		FetchChannelsFunc: func(_ context.Context) ([]*ChannelInfo, error) {
+			return nil, nil
+		},
+	}
+	directory := newDirectory(fetcher, time.Hour)
+	ctx := context.TODO()
+
+	fail = true
+	_, err := directory.User(ctx, "U1")
+	if !errors.Is(err, fetchErr) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	fail = false
+	_ = directory.Refresh(ctx)
+	directory.fetchedAt = time.Now().Add(-2 * time.Hour)
+
+	fail = true
+	user, err := directory.User(ctx, "U1")
+	if err != nil {
+		t.Fatalf("Stale entry must be returned: %s.", err.Error())
+	}
+	if user.Name != "bob" {
+		t.Errorf("Unexpected user is returned: %#v.", user)
+	}
+}
+
+func TestDirectory_ResolveMentions(t *testing.T) {
+	fetcher, _ := countingFetcher()
+	directory := newDirectory(fetcher, time.Hour)
+
+	text := directory.ResolveMentions(context.TODO(), "<@U1> and <@U2|alice> and <@U999>")
+
+	if text != "@Bobby and @Alice Smith and <@U999>" {
+		t.Errorf("Unexpected text is returned: %s.", text)
+	}
+}
+
+func Test_webAPIClient_FetchUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users.list" {
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+		}
+		_ = r.ParseForm()
+		if r.Form.Get("cursor") == "" {
+			_, _ = w.Write([]byte(`{"ok": true, "members": [{"id": "U1", "name": "bob", "profile": {"display_name": "Bobby", "email": "bob@example.com"}}], "response_metadata": {"next_cursor": "next"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "members": [{"id": "U2", "name": "gone", "deleted": true}, {"id": "U3", "name": "bot", "is_bot": true}], "response_metadata": {"next_cursor": ""}}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	users, err := client.FetchUsers(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("Unexpected users are returned: %#v.", users)
+	}
+
+	if users[0].ID != event.UserID("U1") || users[0].DisplayName != "Bobby" || users[0].Email != "bob@example.com" {
+		t.Errorf("Unexpected user is returned: %#v.", users[0])
+	}
+
+	if !users[1].IsBot {
+		t.Errorf("Bot user is not marked: %#v.", users[1])
+	}
+}
+
+func Test_webAPIClient_FetchChannels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/conversations.list" {
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C1", "name": "general"}, {"id": "G1", "name": "secret", "is_private": true}]}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	channels, err := client.FetchChannels(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(channels) != 2 || channels[0].Name != "general" || !channels[1].IsPrivate {
+		t.Errorf("Unexpected channels are returned: %#v.", channels)
+	}
+}
+
+func Test_webAPIClient_FetchUsers_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok": false, "error": "missing_scope"}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	_, err := client.FetchUsers(context.TODO())
+
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
@@ -0,0 +1,147 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"net/url"
+)
+
+// MessageModifier defines an interface that updates or deletes a posted message.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it to satisfy sarah.MessageEditor.
+// Otherwise, Adapter calls Web API with Config.Token by itself.
+type MessageModifier interface {
+	UpdateChatMessage(ctx context.Context, channel event.ChannelID, timeStamp string, text string, blocks []interface{}) error
+	DeleteChatMessage(ctx context.Context, channel event.ChannelID, timeStamp string) error
+}
+
+// WithMessageModifier creates an AdapterOption that sets the MessageModifier to update or delete a posted message.
+func WithMessageModifier(modifier MessageModifier) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.messageModifier = modifier
+	}
+}
+
+type messageResponse struct {
+	webAPIResponse
+	TimeStamp string `json:"ts"`
+}
+
+// UpdateChatMessage updates the message with chat.update method.
+func (c *webAPIClient) UpdateChatMessage(ctx context.Context, channel event.ChannelID, timeStamp string, text string, blocks []interface{}) error {
+	params := url.Values{
+		"channel": []string{channel.String()},
+		"ts":      []string{timeStamp},
+		"text":    []string{text},
+	}
+	err := setBlocks(params, blocks)
+	if err != nil {
+		return err
+	}
+
+	response := &webAPIResponse{}
+	err = c.call(ctx, "chat.update", params, response)
+	if err != nil {
+		return err
+	}
+	if !response.OK {
+		return fmt.Errorf("failed to update message: %s", response.Error)
+	}
+
+	return nil
+}
+
+// DeleteChatMessage deletes the message with chat.delete method.
+func (c *webAPIClient) DeleteChatMessage(ctx context.Context, channel event.ChannelID, timeStamp string) error {
+	response := &webAPIResponse{}
+	err := c.call(ctx, "chat.delete", url.Values{
+		"channel": []string{channel.String()},
+		"ts":      []string{timeStamp},
+	}, response)
+	if err != nil {
+		return err
+	}
+	if !response.OK {
+		return fmt.Errorf("failed to delete message: %s", response.Error)
+	}
+
+	return nil
+}
+
+var _ sarah.MessageEditor = (*Adapter)(nil)
+
+// SendMessageWithHandle sends the given message and returns the handle to update or delete the message later.
+// The content must be a string, *sarah.RichMessage, or *BlockMessage.
+func (adapter *Adapter) SendMessageWithHandle(ctx context.Context, output sarah.Output) (*sarah.MessageHandle, error) {
+	channelID, ok := sarah.BaseDestination(output.Destination()).(event.ChannelID)
+	if !ok {
+		return nil, fmt.Errorf("destination is not instance of Channel: %#v", output.Destination())
+	}
+
+	if sarah.PrivateUserID(output.Destination()) != "" {
+		// An ephemeral message can not be updated or deleted via Web API.
+		return nil, sarah.ErrMessageEditingNotSupported
+	}
+
+	threadID := sarah.ThreadID(output.Destination())
+
+	text, blocks, err := editableContent(output.Content())
+	if err != nil {
+		return nil, err
+	}
+
+	ts, err := adapter.blockPoster.PostBlocks(ctx, channelID, threadID, text, blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sarah.MessageHandle{
+		Destination: output.Destination(),
+		MessageID:   ts,
+	}, nil
+}
+
+// UpdateMessage replaces the content of the message identified by the given handle.
+// The content must be a string, *sarah.RichMessage, or *BlockMessage.
+func (adapter *Adapter) UpdateMessage(ctx context.Context, handle *sarah.MessageHandle, content interface{}) error {
+	channelID, ok := sarah.BaseDestination(handle.Destination).(event.ChannelID)
+	if !ok {
+		return fmt.Errorf("destination is not instance of Channel: %#v", handle.Destination)
+	}
+
+	text, blocks, err := editableContent(content)
+	if err != nil {
+		return err
+	}
+
+	return adapter.messageModifier.UpdateChatMessage(ctx, channelID, handle.MessageID, text, blocks)
+}
+
+// DeleteMessage deletes the message identified by the given handle.
+func (adapter *Adapter) DeleteMessage(ctx context.Context, handle *sarah.MessageHandle) error {
+	channelID, ok := sarah.BaseDestination(handle.Destination).(event.ChannelID)
+	if !ok {
+		return fmt.Errorf("destination is not instance of Channel: %#v", handle.Destination)
+	}
+
+	return adapter.messageModifier.DeleteChatMessage(ctx, channelID, handle.MessageID)
+}
+
+// editableContent converts the given content to the text and the blocks to post or update a message.
+func editableContent(content interface{}) (string, []interface{}, error) {
+	switch c := content.(type) {
+	case string:
+		return c, nil, nil
+
+	case *sarah.RichMessage:
+		return c.PlainText(), richBlocks(c), nil
+
+	case *BlockMessage:
+		return c.Text, c.blocks(), nil
+
+	default:
+		return "", nil, fmt.Errorf("%w: %T", sarah.ErrMessageEditingNotSupported, content)
+
+	}
+}
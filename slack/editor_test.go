@@ -0,0 +1,257 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type DummyMessageModifier struct {
+	UpdateChatMessageFunc func(context.Context, event.ChannelID, string, string, []interface{}) error
+	DeleteChatMessageFunc func(context.Context, event.ChannelID, string) error
+}
+
+var _ MessageModifier = (*DummyMessageModifier)(nil)
+
+func (m *DummyMessageModifier) UpdateChatMessage(ctx context.Context, channel event.ChannelID, timeStamp string, text string, blocks []interface{}) error {
+	return m.UpdateChatMessageFunc(ctx, channel, timeStamp, text, blocks)
+}
+
+func (m *DummyMessageModifier) DeleteChatMessage(ctx context.Context, channel event.ChannelID, timeStamp string) error {
+	return m.DeleteChatMessageFunc(ctx, channel, timeStamp)
+}
+
+func TestWithMessageModifier(t *testing.T) {
+	modifier := &DummyMessageModifier{}
+	adapter := &Adapter{}
+
+	WithMessageModifier(modifier)(adapter)
+
+	if adapter.messageModifier != modifier {
+		t.Error("Given MessageModifier is not set.")
+	}
+}
+
+func Test_webAPIClient_UpdateChatMessage(t *testing.T) {
+	tests := []struct {
+		body string
+		err  bool
+	}{
+		{
+			body: `{"ok": true}`,
+			err:  false,
+		},
+		{
+			body: `{"ok": false, "error": "message_not_found"}`,
+			err:  true,
+		},
+	}
+
+	for i, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			if r.URL.Path != "/chat.update" {
+				t.Errorf("Unexpected path is requested on test #%d: %s.", i, r.URL.Path)
+			}
+			if r.Form.Get("channel") != "C123" || r.Form.Get("ts") != "1355517536.000001" || r.Form.Get("text") != "updated" {
+				t.Errorf("Unexpected parameters are given on test #%d: %#v.", i, r.Form)
+			}
+			if _, ok := r.Form["blocks"]; ok {
+				t.Errorf("Blocks must not be given on test #%d.", i)
+			}
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		client := newWebAPIClient("token", time.Second)
+		client.endpoint = server.URL + "/"
+		err := client.UpdateChatMessage(context.TODO(), "C123", "1355517536.000001", "updated", nil)
+		server.Close()
+
+		if tt.err && err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		} else if !tt.err && err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+	}
+}
+
+func Test_webAPIClient_DeleteChatMessage(t *testing.T) {
+	tests := []struct {
+		body string
+		err  bool
+	}{
+		{
+			body: `{"ok": true}`,
+			err:  false,
+		},
+		{
+			body: `{"ok": false, "error": "cant_delete_message"}`,
+			err:  true,
+		},
+	}
+
+	for i, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			if r.URL.Path != "/chat.delete" {
+				t.Errorf("Unexpected path is requested on test #%d: %s.", i, r.URL.Path)
+			}
+			if r.Form.Get("channel") != "C123" || r.Form.Get("ts") != "1355517536.000001" {
+				t.Errorf("Unexpected parameters are given on test #%d: %#v.", i, r.Form)
+			}
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		client := newWebAPIClient("token", time.Second)
+		client.endpoint = server.URL + "/"
+		err := client.DeleteChatMessage(context.TODO(), "C123", "1355517536.000001")
+		server.Close()
+
+		if tt.err && err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		} else if !tt.err && err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+	}
+}
+
+func TestAdapter_SendMessageWithHandle(t *testing.T) {
+	t.Run("Text in thread", func(t *testing.T) {
+		var givenChannel event.ChannelID
+		var givenThread string
+		adapter := &Adapter{
+			blockPoster: &DummyBlockPoster{
+				PostBlocksFunc: func(_ context.Context, channel event.ChannelID, threadTimeStamp string, text string, blocks []interface{}) (string, error) {
+					givenChannel = channel
+					givenThread = threadTimeStamp
+					if text != "progress" || blocks != nil {
+						t.Errorf("Unexpected content is given: %s, %#v.", text, blocks)
+					}
+					return "1355517536.000002", nil
+				},
+			},
+		}
+
+		destination := sarah.NewThreadDestination(event.ChannelID("C123"), "1355517536.000001")
+		handle, err := adapter.SendMessageWithHandle(context.TODO(), sarah.NewOutputMessage(destination, "progress"))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if givenChannel != "C123" || givenThread != "1355517536.000001" {
+			t.Errorf("Unexpected destination is given: %s, %s.", givenChannel, givenThread)
+		}
+
+		if handle.MessageID != "1355517536.000002" || handle.Destination != destination {
+			t.Errorf("Unexpected handle is returned: %#v.", handle)
+		}
+	})
+
+	t.Run("Rich message", func(t *testing.T) {
+		var blocks []interface{}
+		adapter := &Adapter{
+			blockPoster: &DummyBlockPoster{
+				PostBlocksFunc: func(_ context.Context, _ event.ChannelID, _ string, _ string, b []interface{}) (string, error) {
+					blocks = b
+					return "1355517536.000002", nil
+				},
+			},
+		}
+
+		output := sarah.NewOutputMessage(event.ChannelID("C123"), &sarah.RichMessage{Text: "progress"})
+		_, err := adapter.SendMessageWithHandle(context.TODO(), output)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if len(blocks) != 1 {
+			t.Errorf("Unexpected blocks are given: %#v.", blocks)
+		}
+	})
+
+	t.Run("Unsupported content", func(t *testing.T) {
+		adapter := &Adapter{}
+
+		_, err := adapter.SendMessageWithHandle(context.TODO(), sarah.NewOutputMessage(event.ChannelID("C123"), 1))
+		if err == nil {
+			t.Error("Expected error is not returned.")
+		}
+	})
+
+	t.Run("Post error", func(t *testing.T) {
+		expectedErr := errors.New("error")
+		adapter := &Adapter{
+			blockPoster: &DummyBlockPoster{
+				PostBlocksFunc: func(_ context.Context, _ event.ChannelID, _ string, _ string, _ []interface{}) (string, error) {
+					return "", expectedErr
+				},
+			},
+		}
+
+		_, err := adapter.SendMessageWithHandle(context.TODO(), sarah.NewOutputMessage(event.ChannelID("C123"), "progress"))
+		if err != expectedErr {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestAdapter_UpdateMessage(t *testing.T) {
+	var givenChannel event.ChannelID
+	var givenTimeStamp string
+	var givenText string
+	adapter := &Adapter{
+		messageModifier: &DummyMessageModifier{
+			UpdateChatMessageFunc: func(_ context.Context, channel event.ChannelID, timeStamp string, text string, _ []interface{}) error {
+				givenChannel = channel
+				givenTimeStamp = timeStamp
+				givenText = text
+				return nil
+			},
+		},
+	}
+	handle := &sarah.MessageHandle{
+		Destination: sarah.NewThreadDestination(event.ChannelID("C123"), "1355517536.000001"),
+		MessageID:   "1355517536.000002",
+	}
+
+	err := adapter.UpdateMessage(context.TODO(), handle, "done")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if givenChannel != "C123" || givenTimeStamp != "1355517536.000002" || givenText != "done" {
+		t.Errorf("Unexpected arguments are given: %s, %s, %s.", givenChannel, givenTimeStamp, givenText)
+	}
+}
+
+func TestAdapter_DeleteMessage(t *testing.T) {
+	var givenChannel event.ChannelID
+	var givenTimeStamp string
+	adapter := &Adapter{
+		messageModifier: &DummyMessageModifier{
+			DeleteChatMessageFunc: func(_ context.Context, channel event.ChannelID, timeStamp string) error {
+				givenChannel = channel
+				givenTimeStamp = timeStamp
+				return nil
+			},
+		},
+	}
+	handle := &sarah.MessageHandle{
+		Destination: event.ChannelID("C123"),
+		MessageID:   "1355517536.000002",
+	}
+
+	err := adapter.DeleteMessage(context.TODO(), handle)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if givenChannel != "C123" || givenTimeStamp != "1355517536.000002" {
+		t.Errorf("Unexpected arguments are given: %s, %s.", givenChannel, givenTimeStamp)
+	}
+}
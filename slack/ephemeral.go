@@ -0,0 +1,64 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/golack/v2/webapi"
+	"net/url"
+)
+
+// ErrPrivateFileNotSupported was returned when a file is sent with sarah.PrivateDestination.
+//
+// Deprecated: Adapter now sends such a file to the direct message channel with the user. See DirectMessageOpener.
+var ErrPrivateFileNotSupported = errors.New("file can not be sent privately")
+
+// EphemeralPoster defines an interface that posts an ephemeral message, which is visible only to the given user.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it to send a message with sarah.PrivateDestination.
+// Otherwise, Adapter posts the message with Config.Token by itself.
+type EphemeralPoster interface {
+	PostEphemeral(ctx context.Context, userID string, message *webapi.PostMessage, blocks []interface{}) error
+}
+
+// WithEphemeralPoster creates an AdapterOption that sets the EphemeralPoster to send a private message.
+func WithEphemeralPoster(poster EphemeralPoster) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.ephemeralPoster = poster
+	}
+}
+
+// PostEphemeral posts the given message as an ephemeral message with chat.postEphemeral method.
+// The message's channel, text, attachments and thread timestamp are sent along with the given blocks.
+func (c *webAPIClient) PostEphemeral(ctx context.Context, userID string, message *webapi.PostMessage, blocks []interface{}) error {
+	params := url.Values{
+		"channel": []string{message.Channel.String()},
+		"user":    []string{userID},
+		"text":    []string{message.Text},
+	}
+	if len(message.Attachments) > 0 {
+		attachments, err := json.Marshal(message.Attachments)
+		if err != nil {
+			return fmt.Errorf("failed to encode attachments: %w", err)
+		}
+		params.Set("attachments", string(attachments))
+	}
+	err := setBlocks(params, blocks)
+	if err != nil {
+		return err
+	}
+	if message.ThreadTimeStamp != "" {
+		params.Set("thread_ts", message.ThreadTimeStamp)
+	}
+
+	response := &webAPIResponse{}
+	err = c.call(ctx, "chat.postEphemeral", params, response)
+	if err != nil {
+		return err
+	}
+	if !response.OK {
+		return fmt.Errorf("failed to post ephemeral message: %s", response.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package slack
+
+import (
+	"context"
+	"github.com/oklahomer/golack/v2/webapi"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type DummyEphemeralPoster struct {
+	PostEphemeralFunc func(context.Context, string, *webapi.PostMessage, []interface{}) error
+}
+
+var _ EphemeralPoster = (*DummyEphemeralPoster)(nil)
+
+func (p *DummyEphemeralPoster) PostEphemeral(ctx context.Context, userID string, message *webapi.PostMessage, blocks []interface{}) error {
+	return p.PostEphemeralFunc(ctx, userID, message, blocks)
+}
+
+func TestWithEphemeralPoster(t *testing.T) {
+	poster := &DummyEphemeralPoster{}
+	adapter := &Adapter{}
+
+	WithEphemeralPoster(poster)(adapter)
+
+	if adapter.ephemeralPoster != poster {
+		t.Error("Given EphemeralPoster is not set.")
+	}
+}
+
+func Test_webAPIClient_PostEphemeral(t *testing.T) {
+	tests := []struct {
+		body string
+		err  bool
+	}{
+		{
+			body: `{"ok": true}`,
+			err:  false,
+		},
+		{
+			body: `{"ok": false, "error": "user_not_in_channel"}`,
+			err:  true,
+		},
+	}
+
+	for i, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			if r.URL.Path != "/chat.postEphemeral" {
+				t.Errorf("Unexpected path is requested on test #%d: %s.", i, r.URL.Path)
+			}
+			if r.Form.Get("channel") != "C123" || r.Form.Get("user") != "U123" || r.Form.Get("text") != "secret" || r.Form.Get("thread_ts") != "1355517536.000001" {
+				t.Errorf("Unexpected parameters are given on test #%d: %#v.", i, r.Form)
+			}
+			if r.Form.Get("attachments") != `[{"fallback":"fallback","title":"title"}]` {
+				t.Errorf("Unexpected attachments are given on test #%d: %s.", i, r.Form.Get("attachments"))
+			}
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		client := newWebAPIClient("token", time.Second)
+		client.endpoint = server.URL + "/"
+		message := webapi.NewPostMessage("C123", "secret").
+			WithAttachments([]*webapi.MessageAttachment{{Fallback: "fallback", Title: "title"}}).
+			WithThreadTimeStamp("1355517536.000001")
+		err := client.PostEphemeral(context.TODO(), "U123", message, nil)
+		server.Close()
+
+		if tt.err && err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		} else if !tt.err && err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+	}
+}
@@ -2,8 +2,9 @@ package slack
 
 import (
 	"context"
-	"github.com/oklahomer/go-kasumi/logger"
+	"fmt"
 	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
 	"github.com/oklahomer/golack/v2/eventsapi"
 	"net/http"
 	"strings"
@@ -13,6 +14,7 @@ type eventsAPIAdapter struct {
 	config        *Config
 	client        SlackClient
 	handlePayload func(context.Context, *Config, *eventsapi.EventWrapper, func(sarah.Input) error)
+	connection    *connectionState
 }
 
 var _ apiSpecificAdapter = (*eventsAPIAdapter)(nil)
@@ -22,6 +24,8 @@ func (e *eventsAPIAdapter) run(ctx context.Context, enqueueInput func(sarah.Inpu
 		e.handlePayload(ctx, e.config, wrapper, enqueueInput)
 	})
 	errChan := e.client.RunServer(ctx, receiver)
+	e.connection.set(true)
+	defer e.connection.set(false)
 
 	select {
 	case <-ctx.Done():
@@ -44,15 +48,15 @@ func (e *eventsAPIAdapter) run(ctx context.Context, enqueueInput func(sarah.Inpu
 //
 //   myHandler := func(_ context.Context, _ config *Config, _ *eventsapi.EventWrapper, _ func(sarah.Input) error)
 //   slackAdapter, _ := slack.NewAdapter(slackConfig, slack.WithEventsPayloadHandler(myHandler))
-func DefaultEventsPayloadHandler(_ context.Context, config *Config, payload *eventsapi.EventWrapper, enqueueInput func(input sarah.Input) error) {
+func DefaultEventsPayloadHandler(ctx context.Context, config *Config, payload *eventsapi.EventWrapper, enqueueInput func(input sarah.Input) error) {
 	input, err := EventToInput(payload.Event)
 	if err == ErrNonSupportedEvent {
-		logger.Debugf("Event given, but no corresponding action is defined. %#v", payload)
+		moduleLogger(ctx).Debug("Event given, but no corresponding action is defined", logging.F("payload", fmt.Sprintf("%#v", payload)))
 		return
 	}
 
 	if err != nil {
-		logger.Errorf("Failed to convert %T event: %s", payload.Event, err.Error())
+		moduleLogger(ctx).Error("Failed to convert event", logging.F("event_type", fmt.Sprintf("%T", payload.Event)), logging.Err(err))
 		return
 	}
 
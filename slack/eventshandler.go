@@ -0,0 +1,170 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/golack/v2/eventsapi"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithEventsHTTPHandler creates an AdapterOption with the given function to handle incoming Events API payloads
+// received by the http.Handler that EventsHandler returns.
+// Unlike WithEventsPayloadHandler, Adapter does not run its own HTTP server. Mount the handler on an existing server
+// or on a serverless platform's HTTP entry point and set the URL as the Request URL of the Slack App's Event Subscriptions:
+//
+//  slackAdapter, _ := slack.NewAdapter(slackConfig, slack.WithEventsHTTPHandler(slack.DefaultEventsPayloadHandler))
+//  http.Handle("/slack/events", slackAdapter.EventsHandler())
+//
+// Adapter.Run blocks til the context is canceled while the handler passes the events to go-sarah's core.
+func WithEventsHTTPHandler(fnc func(context.Context, *Config, *eventsapi.EventWrapper, func(sarah.Input) error)) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.eventsPayloadHandler = fnc
+		adapter.apiSpecificAdapterBuilder = func(config *Config, client SlackClient) apiSpecificAdapter {
+			return &eventsHTTPAdapter{
+				connection: adapter.connection,
+			}
+		}
+	}
+}
+
+// eventsHTTPAdapter waits while the http.Handler returned by Adapter.EventsHandler receives events.
+type eventsHTTPAdapter struct {
+	connection *connectionState
+}
+
+var _ apiSpecificAdapter = (*eventsHTTPAdapter)(nil)
+
+func (e *eventsHTTPAdapter) run(ctx context.Context, _ func(sarah.Input) error, _ func(error)) {
+	// The handler can receive events as long as the Adapter is running.
+	e.connection.set(true)
+	defer e.connection.set(false)
+
+	<-ctx.Done()
+}
+
+// eventsRequest represents the part of Events API's request body that EventsHandler refers to.
+// https://api.slack.com/apis/connections/events-api#receiving-events
+type eventsRequest struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	EventID   string `json:"event_id"`
+}
+
+// EventsHandler returns an http.Handler that receives Events API's requests.
+// Each request is verified with Config.AppSecret, the signing secret, and every request is rejected with 401 when the secret is not set.
+// The url_verification challenge is answered so the URL can be registered as the Request URL.
+//
+// Slack retries the delivery when no response is returned within three seconds, and tells the retry with X-Slack-Retry-Num header.
+// The handler responds immediately and skips an event that is already received, so the same event is not handled twice.
+// When the Adapter is not running, the handler responds with 503 so Slack delivers the event again later.
+//
+// Each event is passed to the function given to WithEventsHTTPHandler, or to DefaultEventsPayloadHandler when the option is not given.
+func (adapter *Adapter) EventsHandler() http.Handler {
+	handlePayload := adapter.eventsPayloadHandler
+	if handlePayload == nil {
+		handlePayload = DefaultEventsPayloadHandler
+	}
+	received := newEventIDSet(eventIDRetention)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		log := moduleLogger(req.Context())
+
+		body, status, err := adapter.readVerifiedBody(req)
+		if err != nil {
+			log.Warn("Failed to verify event request", logging.Err(err))
+			w.WriteHeader(status)
+			return
+		}
+
+		request := &eventsRequest{}
+		err = json.Unmarshal(body, request)
+		if err != nil {
+			log.Warn("Failed to decode event request", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch request.Type {
+		case "url_verification":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(request.Challenge))
+			return
+
+		case "event_callback":
+			// Handle below.
+
+		default:
+			log.Debug("Request given, but no corresponding action is defined", logging.F("type", request.Type))
+			w.WriteHeader(http.StatusOK)
+			return
+
+		}
+
+		if retry := req.Header.Get("X-Slack-Retry-Num"); retry != "" {
+			log.Info("Event is redelivered", logging.F("event_id", request.EventID), logging.F("retry", retry), logging.F("reason", req.Header.Get("X-Slack-Retry-Reason")))
+		}
+
+		enqueueInput := adapter.inputReceiver()
+		if enqueueInput == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if request.EventID != "" && !received.add(request.EventID, time.Now()) {
+			log.Debug("Skip the event that is already received", logging.F("event_id", request.EventID))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		wrapper, err := decodeEventsAPIPayload(body)
+		if err != nil {
+			log.Warn("Ignore malformed event", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Slack requires a response within three seconds, so the input is handled asynchronously by go-sarah's core.
+		handlePayload(req.Context(), adapter.config, wrapper, enqueueInput)
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// eventIDRetention is the duration to remember a received event ID.
+// Slack retries a failed delivery three times in about an hour at most.
+const eventIDRetention = 1 * time.Hour
+
+// eventIDSet remembers the received event IDs for the given retention to detect a redelivered event.
+type eventIDSet struct {
+	retention time.Duration
+	mutex     sync.Mutex
+	ids       map[string]time.Time
+}
+
+func newEventIDSet(retention time.Duration) *eventIDSet {
+	return &eventIDSet{
+		retention: retention,
+		ids:       map[string]time.Time{},
+	}
+}
+
+// add adds the given ID and returns true, or returns false when the ID is already added.
+func (s *eventIDSet) add(id string, now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for seen, at := range s.ids {
+		if now.Sub(at) > s.retention {
+			delete(s.ids, seen)
+		}
+	}
+
+	if _, ok := s.ids[id]; ok {
+		return false
+	}
+	s.ids[id] = now
+	return true
+}
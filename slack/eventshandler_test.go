@@ -0,0 +1,234 @@
+package slack
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/eventsapi"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithEventsHTTPHandler(t *testing.T) {
+	called := false
+	fnc := func(_ context.Context, _ *Config, _ *eventsapi.EventWrapper, _ func(sarah.Input) error) {
+		called = true
+	}
+	adapter := &Adapter{}
+
+	WithEventsHTTPHandler(fnc)(adapter)
+
+	if adapter.apiSpecificAdapterBuilder == nil {
+		t.Fatal("apiSpecificAdapterBuilder is not set.")
+	}
+
+	if _, ok := adapter.apiSpecificAdapterBuilder(nil, nil).(*eventsHTTPAdapter); !ok {
+		t.Error("eventsHTTPAdapter could not be built.")
+	}
+
+	adapter.eventsPayloadHandler(context.TODO(), nil, nil, nil)
+	if !called {
+		t.Error("Given function is not set.")
+	}
+}
+
+func Test_eventsHTTPAdapter_run(t *testing.T) {
+	adapter := &eventsHTTPAdapter{connection: &connectionState{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		adapter.run(ctx, func(_ sarah.Input) error { return nil }, func(_ error) {})
+		close(finished)
+	}()
+
+	for !adapter.connection.connected() {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-finished:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("run did not return on context cancellation.")
+
+	}
+
+	if adapter.connection.connected() {
+		t.Error("Connection state is not reset.")
+	}
+}
+
+func TestAdapter_EventsHandler(t *testing.T) {
+	config := NewConfig()
+	config.AppSecret = "secret"
+	messageBody := `{
+		"type": "event_callback",
+		"event_id": "Ev123",
+		"event": {"type": "message", "channel": "C123", "user": "U123", "text": "hello", "ts": "1355517523.000005"}
+	}`
+
+	// runningAdapter returns an Adapter that behaves as if it is running, and a function to stop it.
+	runningAdapter := func(enqueueInput func(sarah.Input) error) (*Adapter, func()) {
+		adapter, err := NewAdapter(config, WithSlackClient(&DummyClient{}), WithEventsHTTPHandler(DefaultEventsPayloadHandler))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			adapter.Run(ctx, enqueueInput, func(error) {})
+			close(stopped)
+		}()
+
+		// Wait until the Adapter runs.
+		for adapter.inputReceiver() == nil {
+			time.Sleep(time.Millisecond)
+		}
+
+		return adapter, func() {
+			cancel()
+			<-stopped
+		}
+	}
+
+	t.Run("URL verification", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		body := `{"type": "url_verification", "token": "token", "challenge": "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`
+		adapter.EventsHandler().ServeHTTP(recorder, newSignedRequest("secret", body))
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+
+		if recorder.Body.String() != "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P" {
+			t.Errorf("Unexpected challenge is returned: %s.", recorder.Body.String())
+		}
+	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.EventsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(messageBody)))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("No signing secret", func(t *testing.T) {
+		adapter := &Adapter{config: NewConfig()}
+
+		recorder := httptest.NewRecorder()
+		adapter.EventsHandler().ServeHTTP(recorder, newSignedRequest("", messageBody))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Too large body", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.EventsHandler().ServeHTTP(recorder, newSignedRequest("secret", strings.Repeat(" ", maxRequestBytes+1)))
+
+		if recorder.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Invalid payload", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.EventsHandler().ServeHTTP(recorder, newSignedRequest("secret", "invalid"))
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Not running", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.EventsHandler().ServeHTTP(recorder, newSignedRequest("secret", messageBody))
+
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Enqueue", func(t *testing.T) {
+		var inputs []sarah.Input
+		adapter, stop := runningAdapter(func(input sarah.Input) error {
+			inputs = append(inputs, input)
+			return nil
+		})
+
+		recorder := httptest.NewRecorder()
+		adapter.EventsHandler().ServeHTTP(recorder, newSignedRequest("secret", messageBody))
+		stop()
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Unexpected status is returned: %d.", recorder.Code)
+		}
+
+		if len(inputs) != 1 || inputs[0].Message() != "hello" {
+			t.Errorf("Unexpected inputs are enqueued: %#v.", inputs)
+		}
+	})
+
+	t.Run("Redelivered event", func(t *testing.T) {
+		var inputs []sarah.Input
+		adapter, stop := runningAdapter(func(input sarah.Input) error {
+			inputs = append(inputs, input)
+			return nil
+		})
+		handler := adapter.EventsHandler()
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, newSignedRequest("secret", messageBody))
+
+		retried := newSignedRequest("secret", messageBody)
+		retried.Header.Set("X-Slack-Retry-Num", "1")
+		retried.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+		retriedRecorder := httptest.NewRecorder()
+		handler.ServeHTTP(retriedRecorder, retried)
+		stop()
+
+		if recorder.Code != http.StatusOK || retriedRecorder.Code != http.StatusOK {
+			t.Errorf("Unexpected status is returned: %d, %d.", recorder.Code, retriedRecorder.Code)
+		}
+
+		if len(inputs) != 1 {
+			t.Errorf("Redelivered event must be skipped, but %d inputs are enqueued.", len(inputs))
+		}
+	})
+}
+
+func Test_eventIDSet_add(t *testing.T) {
+	set := newEventIDSet(time.Minute)
+	now := time.Now()
+
+	if !set.add("Ev1", now) {
+		t.Error("New ID is not added.")
+	}
+
+	if set.add("Ev1", now.Add(30*time.Second)) {
+		t.Error("Duplicated ID is added.")
+	}
+
+	if !set.add("Ev1", now.Add(2*time.Minute)) {
+		t.Error("Expired ID is not added again.")
+	}
+}
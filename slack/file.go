@@ -0,0 +1,169 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+)
+
+// FileUploader defines an interface that uploads a file to Slack.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it to send sarah.FileContent.
+// Otherwise, Adapter uploads the file with Config.Token by itself.
+type FileUploader interface {
+	UploadFile(ctx context.Context, channel event.ChannelID, threadTimeStamp string, file *sarah.FileContent) error
+}
+
+// WithFileUploader creates an AdapterOption that sets the FileUploader to send sarah.FileContent.
+func WithFileUploader(uploader FileUploader) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.fileUploader = uploader
+	}
+}
+
+type uploadURLResponse struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error"`
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+// UploadFile uploads a file with Slack's external upload flow:
+// files.getUploadURLExternal to get an upload URL, a POST request to the URL with the content,
+// and files.completeUploadExternal to share the file in the channel.
+// When the file's MIMEType is empty, the type is guessed from the file name or the content.
+// The token requires files:write scope.
+func (c *webAPIClient) UploadFile(ctx context.Context, channel event.ChannelID, threadTimeStamp string, file *sarah.FileContent) error {
+	content, err := file.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	uploadURL := &uploadURLResponse{}
+	err = c.call(ctx, "files.getUploadURLExternal", url.Values{
+		"filename": []string{file.FileName},
+		"length":   []string{strconv.Itoa(len(content))},
+	}, uploadURL)
+	if err != nil {
+		return err
+	}
+	if !uploadURL.OK {
+		return fmt.Errorf("failed to get upload URL: %s", uploadURL.Error)
+	}
+
+	err = c.upload(ctx, uploadURL.UploadURL, detectMIMEType(file, content), content)
+	if err != nil {
+		return err
+	}
+
+	files, _ := json.Marshal([]map[string]string{{"id": uploadURL.FileID, "title": file.FileName}})
+	params := url.Values{
+		"files":      []string{string(files)},
+		"channel_id": []string{channel.String()},
+	}
+	if file.Comment != "" {
+		params.Set("initial_comment", file.Comment)
+	}
+	if threadTimeStamp != "" {
+		params.Set("thread_ts", threadTimeStamp)
+	}
+	complete := &webAPIResponse{}
+	err = c.call(ctx, "files.completeUploadExternal", params, complete)
+	if err != nil {
+		return err
+	}
+	if !complete.OK {
+		return fmt.Errorf("failed to complete upload: %s", complete.Error)
+	}
+
+	return nil
+}
+
+func (c *webAPIClient) upload(ctx context.Context, uploadURL string, mimeType string, content []byte) error {
+	if uploadURL == "" {
+		return errors.New("upload URL is not given")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uploadURL, bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to build upload request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	if mimeType != "" {
+		req.Header.Set("Content-Type", mimeType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code on file upload: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// detectMIMEType returns the file's MIMEType, or guesses the type from the file name's extension or the content.
+func detectMIMEType(file *sarah.FileContent, content []byte) string {
+	if file.MIMEType != "" {
+		return file.MIMEType
+	}
+
+	if t := mime.TypeByExtension(filepath.Ext(file.FileName)); t != "" {
+		return t
+	}
+
+	return http.DetectContentType(content)
+}
+
+// DirectMessageOpener defines an interface that opens a direct message channel with a user.
+// Adapter sends sarah.FileContent with sarah.PrivateDestination to the direct message channel since Slack has no way to
+// share a file only with a user in a channel.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it.
+// Otherwise, Adapter opens the channel with Config.Token by itself.
+type DirectMessageOpener interface {
+	OpenDirectMessage(ctx context.Context, userID string) (event.ChannelID, error)
+}
+
+// WithDirectMessageOpener creates an AdapterOption that sets the DirectMessageOpener to send a file privately.
+func WithDirectMessageOpener(opener DirectMessageOpener) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.directMessageOpener = opener
+	}
+}
+
+var _ DirectMessageOpener = (*webAPIClient)(nil)
+
+type conversationsOpenResponse struct {
+	webAPIResponse
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+}
+
+// OpenDirectMessage opens a direct message channel with the given user with conversations.open method,
+// or returns the existing one.
+// The token requires im:write scope.
+func (c *webAPIClient) OpenDirectMessage(ctx context.Context, userID string) (event.ChannelID, error) {
+	response := &conversationsOpenResponse{}
+	err := c.call(ctx, "conversations.open", url.Values{"users": []string{userID}}, response)
+	if err != nil {
+		return "", err
+	}
+	if !response.OK {
+		return "", fmt.Errorf("failed to open direct message: %s", response.Error)
+	}
+
+	return event.ChannelID(response.Channel.ID), nil
+}
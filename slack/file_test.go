@@ -0,0 +1,196 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyFileUploader struct {
+	UploadFileFunc func(context.Context, event.ChannelID, string, *sarah.FileContent) error
+}
+
+var _ FileUploader = (*DummyFileUploader)(nil)
+
+func (u *DummyFileUploader) UploadFile(ctx context.Context, channel event.ChannelID, threadTimeStamp string, file *sarah.FileContent) error {
+	return u.UploadFileFunc(ctx, channel, threadTimeStamp, file)
+}
+
+type DummyDirectMessageOpener struct {
+	OpenDirectMessageFunc func(context.Context, string) (event.ChannelID, error)
+}
+
+var _ DirectMessageOpener = (*DummyDirectMessageOpener)(nil)
+
+func (o *DummyDirectMessageOpener) OpenDirectMessage(ctx context.Context, userID string) (event.ChannelID, error) {
+	return o.OpenDirectMessageFunc(ctx, userID)
+}
+
+func TestWithFileUploader(t *testing.T) {
+	uploader := &DummyFileUploader{}
+	adapter := &Adapter{}
+
+	WithFileUploader(uploader)(adapter)
+
+	if adapter.fileUploader != uploader {
+		t.Error("Given FileUploader is not set.")
+	}
+}
+
+func Test_webAPIClient_UploadFile(t *testing.T) {
+	var uploaded string
+	var completeParams map[string][]string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/upload" && r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Token is not given: %s.", r.Header.Get("Authorization"))
+		}
+
+		switch r.URL.Path {
+		case "/files.getUploadURLExternal":
+			_ = r.ParseForm()
+			if r.Form.Get("filename") != "dummy.txt" || r.Form.Get("length") != "7" {
+				t.Errorf("Unexpected parameters are given: %#v.", r.Form)
+			}
+			_ = json.NewEncoder(w).Encode(&uploadURLResponse{OK: true, UploadURL: server.URL + "/upload", FileID: "F123"})
+
+		case "/upload":
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded = string(body)
+
+		case "/files.completeUploadExternal":
+			_ = r.ParseForm()
+			completeParams = r.Form
+			_ = json.NewEncoder(w).Encode(&webAPIResponse{OK: true})
+
+		default:
+			t.Errorf("Unexpected request is made: %s.", r.URL.Path)
+
+		}
+	}))
+	defer server.Close()
+
+	uploader := newWebAPIClient("token", time.Second)
+	uploader.endpoint = server.URL + "/"
+	file := &sarah.FileContent{
+		Reader:   strings.NewReader("content"),
+		FileName: "dummy.txt",
+		MIMEType: "text/plain",
+		Comment:  "comment",
+	}
+
+	err := uploader.UploadFile(context.TODO(), "C123", "1234.5678", file)
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if uploaded != "content" {
+		t.Errorf("Unexpected content is uploaded: %s.", uploaded)
+	}
+
+	if completeParams["channel_id"][0] != "C123" || completeParams["initial_comment"][0] != "comment" || completeParams["thread_ts"][0] != "1234.5678" {
+		t.Errorf("Unexpected parameters are given: %#v.", completeParams)
+	}
+
+	if !strings.Contains(completeParams["files"][0], `"id":"F123"`) {
+		t.Errorf("File ID is not given: %s.", completeParams["files"][0])
+	}
+}
+
+func Test_webAPIClient_UploadFile_WithError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&uploadURLResponse{OK: false, Error: "not_authed"})
+	}))
+	defer server.Close()
+
+	uploader := newWebAPIClient("token", time.Second)
+	uploader.endpoint = server.URL + "/"
+	file := &sarah.FileContent{
+		Reader:   strings.NewReader("content"),
+		FileName: "dummy.txt",
+	}
+
+	err := uploader.UploadFile(context.TODO(), "C123", "", file)
+
+	if err == nil || !strings.Contains(err.Error(), "not_authed") {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func Test_detectMIMEType(t *testing.T) {
+	tests := []struct {
+		file     *sarah.FileContent
+		content  []byte
+		expected string
+	}{
+		{file: &sarah.FileContent{FileName: "report.csv", MIMEType: "text/csv; charset=utf-8"}, content: []byte("a,b"), expected: "text/csv; charset=utf-8"},
+		{file: &sarah.FileContent{FileName: "chart.png"}, content: []byte("dummy"), expected: "image/png"},
+		{file: &sarah.FileContent{FileName: "output"}, content: []byte("plain log"), expected: "text/plain; charset=utf-8"},
+	}
+
+	for i, tt := range tests {
+		if mimeType := detectMIMEType(tt.file, tt.content); mimeType != tt.expected {
+			t.Errorf("Unexpected MIME type is returned on test #%d: %s.", i, mimeType)
+		}
+	}
+}
+
+func TestWithDirectMessageOpener(t *testing.T) {
+	opener := &DummyDirectMessageOpener{}
+	adapter := &Adapter{}
+
+	WithDirectMessageOpener(opener)(adapter)
+
+	if adapter.directMessageOpener != opener {
+		t.Error("Given DirectMessageOpener is not set.")
+	}
+}
+
+func Test_webAPIClient_OpenDirectMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/conversations.open" {
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+		}
+		_ = r.ParseForm()
+		if r.Form.Get("users") != "U123" {
+			t.Errorf("Unexpected user is given: %s.", r.Form.Get("users"))
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "D123"}}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	channel, err := client.OpenDirectMessage(context.TODO(), "U123")
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if channel != "D123" {
+		t.Errorf("Unexpected channel is returned: %s.", channel)
+	}
+}
+
+func Test_webAPIClient_OpenDirectMessage_WithError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok": false, "error": "user_not_found"}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	_, err := client.OpenDirectMessage(context.TODO(), "U123")
+
+	if err == nil || !strings.Contains(err.Error(), "user_not_found") {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
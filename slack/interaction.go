@@ -0,0 +1,371 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/golack/v2/event"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlockPoster defines an interface that posts a message with Block Kit blocks.
+// Adapter uses this to send sarah.RichMessage with interactive components since message attachments can not carry them.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it.
+// Otherwise, Adapter posts the message with Config.Token by itself.
+type BlockPoster interface {
+	// PostBlocks posts a message and returns the timestamp that identifies the posted message.
+	PostBlocks(ctx context.Context, channel event.ChannelID, threadTimeStamp string, text string, blocks []interface{}) (string, error)
+}
+
+// WithBlockPoster creates an AdapterOption that sets the BlockPoster to send sarah.RichMessage with interactive components.
+func WithBlockPoster(poster BlockPoster) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.blockPoster = poster
+	}
+}
+
+// PostBlocks posts a message with the given blocks with chat.postMessage method.
+// The text is displayed in notifications, and is displayed as the message body when no block is given.
+func (c *webAPIClient) PostBlocks(ctx context.Context, channel event.ChannelID, threadTimeStamp string, text string, blocks []interface{}) (string, error) {
+	params := url.Values{
+		"channel": []string{channel.String()},
+		"text":    []string{text},
+	}
+	err := setBlocks(params, blocks)
+	if err != nil {
+		return "", err
+	}
+	if threadTimeStamp != "" {
+		params.Set("thread_ts", threadTimeStamp)
+	}
+
+	response := &messageResponse{}
+	err = c.call(ctx, "chat.postMessage", params, response)
+	if err != nil {
+		return "", err
+	}
+	if !response.OK {
+		return "", fmt.Errorf("failed to post message: %s", response.Error)
+	}
+
+	return response.TimeStamp, nil
+}
+
+func setBlocks(params url.Values, blocks []interface{}) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(blocks)
+	if err != nil {
+		return fmt.Errorf("failed to encode blocks: %w", err)
+	}
+	params.Set("blocks", string(encoded))
+	return nil
+}
+
+// actionID builds a Block Kit action_id from the given callback ID.
+// The index is appended because action_id must be unique in a block while multiple buttons may share one callback ID.
+func actionID(callbackID string, index int) string {
+	return fmt.Sprintf("%s#%d", callbackID, index)
+}
+
+// callbackID extracts the callback ID from the action_id built by actionID.
+func callbackID(actionID string) string {
+	i := strings.LastIndex(actionID, "#")
+	if i < 0 {
+		return actionID
+	}
+
+	if _, err := strconv.Atoi(actionID[i+1:]); err != nil {
+		return actionID
+	}
+
+	return actionID[:i]
+}
+
+func plainText(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "plain_text",
+		"text": text,
+	}
+}
+
+func markdownSection(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "section",
+		"text": map[string]interface{}{
+			"type": "mrkdwn",
+			"text": text,
+		},
+	}
+}
+
+// richBlocks renders the given sarah.RichMessage as Block Kit blocks.
+// Block Kit has no concept of the accent color, so Color is ignored.
+func richBlocks(message *sarah.RichMessage) []interface{} {
+	var blocks []interface{}
+	if message.Title != "" {
+		if message.TitleURL != "" {
+			blocks = append(blocks, markdownSection(fmt.Sprintf("*<%s|%s>*", message.TitleURL, message.Title)))
+		} else {
+			blocks = append(blocks, markdownSection(fmt.Sprintf("*%s*", message.Title)))
+		}
+	}
+
+	if message.Text != "" {
+		blocks = append(blocks, markdownSection(message.Text))
+	}
+
+	if len(message.Fields) > 0 {
+		var fields []interface{}
+		for _, f := range message.Fields {
+			fields = append(fields, map[string]interface{}{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s*\n%s", f.Title, f.Value),
+			})
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":   "section",
+			"fields": fields,
+		})
+	}
+
+	if message.ImageURL != "" {
+		alt := message.Title
+		if alt == "" {
+			alt = "image"
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":      "image",
+			"image_url": message.ImageURL,
+			"alt_text":  alt,
+		})
+	}
+
+	var elements []interface{}
+	for i, b := range message.Buttons {
+		button := map[string]interface{}{
+			"type": "button",
+			"text": plainText(b.Label),
+		}
+		if b.Interactive() {
+			button["action_id"] = actionID(b.CallbackID, i)
+			button["value"] = b.Value
+		} else {
+			button["url"] = b.URL
+		}
+		elements = append(elements, button)
+	}
+	for i, m := range message.Menus {
+		var options []interface{}
+		for _, o := range m.Options {
+			options = append(options, map[string]interface{}{
+				"text":  plainText(o.Label),
+				"value": o.Value,
+			})
+		}
+		menu := map[string]interface{}{
+			"type":      "static_select",
+			"action_id": actionID(m.CallbackID, len(message.Buttons)+i),
+			"options":   options,
+		}
+		if m.Placeholder != "" {
+			menu["placeholder"] = plainText(m.Placeholder)
+		}
+		elements = append(elements, menu)
+	}
+	if len(elements) > 0 {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "actions",
+			"elements": elements,
+		})
+	}
+
+	return blocks
+}
+
+// interactionPayload represents the part of the block_actions payload that Adapter refers to.
+// https://api.slack.com/reference/interaction-payloads/block-actions
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Container struct {
+		ThreadTimeStamp string `json:"thread_ts"`
+	} `json:"container"`
+	Actions []*interactionAction `json:"actions"`
+}
+
+type interactionAction struct {
+	ActionID       string `json:"action_id"`
+	Value          string `json:"value"`
+	SelectedOption *struct {
+		Value string `json:"value"`
+	} `json:"selected_option"`
+	ActionTimeStamp string `json:"action_ts"`
+}
+
+// ErrInvalidSignature is returned when the request's signature does not match with the one calculated with Config.AppSecret.
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// maxRequestBytes is the maximum size of a request body that the http.Handlers read.
+// Slack's payloads are far smaller than this, so a larger body is rejected without being read entirely.
+const maxRequestBytes = 1024 * 1024
+
+// InteractionHandler returns an http.Handler that receives the payloads of the interactive components.
+// Mount the handler on a server and set the URL as the Request URL of the Slack App's Interactivity setting:
+//
+//  go http.ListenAndServe(":8081", slackAdapter.InteractionHandler())
+//
+// Each interaction is converted to sarah.CallbackInput and is passed to go-sarah's core while the Adapter is running.
+// Each request is verified with Config.AppSecret, the signing secret, and every request is rejected with 401 when the secret is not set.
+func (adapter *Adapter) InteractionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		log := moduleLogger(req.Context())
+
+		body, status, err := adapter.readVerifiedBody(req)
+		if err != nil {
+			log.Warn("Failed to verify interaction request", logging.Err(err))
+			w.WriteHeader(status)
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		payload := &interactionPayload{}
+		err = json.Unmarshal([]byte(form.Get("payload")), payload)
+		if err != nil {
+			log.Warn("Failed to decode interaction payload", logging.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		enqueueInput := adapter.inputReceiver()
+		if enqueueInput == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		for _, input := range payloadToCallbackInputs(payload) {
+			err := enqueueInput(input)
+			if err != nil {
+				log.Error("Failed to enqueue interaction", logging.F("callback_id", input.CallbackID), logging.Err(err))
+			}
+		}
+
+		// Slack requires a response within three seconds, so the input is handled asynchronously.
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func payloadToCallbackInputs(payload *interactionPayload) []*sarah.CallbackInput {
+	if payload.Type != "block_actions" {
+		return nil
+	}
+
+	var destination sarah.OutputDestination = event.ChannelID(payload.Channel.ID)
+	if payload.Container.ThreadTimeStamp != "" {
+		destination = sarah.NewThreadDestination(destination, payload.Container.ThreadTimeStamp)
+	}
+
+	var inputs []*sarah.CallbackInput
+	for _, action := range payload.Actions {
+		value := action.Value
+		if action.SelectedOption != nil {
+			value = action.SelectedOption.Value
+		}
+
+		input := sarah.NewCallbackInput(
+			callbackID(action.ActionID),
+			value,
+			fmt.Sprintf("%s|%s", payload.Channel.ID, payload.User.ID),
+			parseActionTimeStamp(action.ActionTimeStamp),
+			destination,
+		)
+		input.Event = payload
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+func parseActionTimeStamp(ts string) time.Time {
+	f, err := strconv.ParseFloat(ts, 64)
+	if err != nil {
+		return time.Now()
+	}
+
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second)))
+}
+
+// readVerifiedBody reads the request body and verifies the request with Config.AppSecret.
+// Anyone could forge a request without the signing secret, so the request is rejected when Config.AppSecret is not set.
+// When an error is returned, the returned status is the one to respond with.
+func (adapter *Adapter) readVerifiedBody(req *http.Request) ([]byte, int, error) {
+	// Read one more byte than the limit to tell if the body is too large.
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxRequestBytes+1))
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(body) > maxRequestBytes {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", maxRequestBytes)
+	}
+
+	if adapter.config == nil || adapter.config.AppSecret == "" {
+		return nil, http.StatusUnauthorized, fmt.Errorf("%w: app_secret is not set", ErrInvalidSignature)
+	}
+
+	err = verifySignature(adapter.config.AppSecret.Reveal(), req.Header, body, time.Now())
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+
+	return body, 0, nil
+}
+
+// verifySignature verifies the request with the signing secret.
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func verifySignature(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+	}
+
+	// Reject an old request to prevent a replay attack.
+	if math.Abs(now.Sub(time.Unix(unix, 0)).Seconds()) > 5*60 {
+		return fmt.Errorf("%w: timestamp is too old", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + ts + ":"))
+	_, _ = mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,400 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyBlockPoster struct {
+	PostBlocksFunc func(context.Context, event.ChannelID, string, string, []interface{}) (string, error)
+}
+
+var _ BlockPoster = (*DummyBlockPoster)(nil)
+
+func (p *DummyBlockPoster) PostBlocks(ctx context.Context, channel event.ChannelID, threadTimeStamp string, text string, blocks []interface{}) (string, error) {
+	return p.PostBlocksFunc(ctx, channel, threadTimeStamp, text, blocks)
+}
+
+func TestWithBlockPoster(t *testing.T) {
+	poster := &DummyBlockPoster{}
+	adapter := &Adapter{}
+
+	WithBlockPoster(poster)(adapter)
+
+	if adapter.blockPoster != poster {
+		t.Error("Given BlockPoster is not set.")
+	}
+}
+
+func Test_webAPIClient_PostBlocks(t *testing.T) {
+	tests := []struct {
+		body string
+		err  bool
+	}{
+		{
+			body: `{"ok": true, "ts": "1355517536.000002"}`,
+			err:  false,
+		},
+		{
+			body: `{"ok": false, "error": "invalid_blocks"}`,
+			err:  true,
+		},
+	}
+
+	for i, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			if r.URL.Path != "/chat.postMessage" {
+				t.Errorf("Unexpected path is requested on test #%d: %s.", i, r.URL.Path)
+			}
+			if r.Form.Get("channel") != "C123" || r.Form.Get("text") != "fallback" || r.Form.Get("thread_ts") != "1355517536.000001" {
+				t.Errorf("Unexpected parameters are given on test #%d: %#v.", i, r.Form)
+			}
+			if r.Form.Get("blocks") != `[{"type":"divider"}]` {
+				t.Errorf("Unexpected blocks are given on test #%d: %s.", i, r.Form.Get("blocks"))
+			}
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		client := newWebAPIClient("token", time.Second)
+		client.endpoint = server.URL + "/"
+		blocks := []interface{}{map[string]interface{}{"type": "divider"}}
+		ts, err := client.PostBlocks(context.TODO(), "C123", "1355517536.000001", "fallback", blocks)
+		server.Close()
+
+		if tt.err && err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		} else if !tt.err && err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		} else if !tt.err && ts != "1355517536.000002" {
+			t.Errorf("Unexpected timestamp is returned on test #%d: %s.", i, ts)
+		}
+	}
+}
+
+func Test_callbackID(t *testing.T) {
+	tests := []struct {
+		actionID   string
+		callbackID string
+	}{
+		{
+			actionID:   actionID("deploy", 0),
+			callbackID: "deploy",
+		},
+		{
+			actionID:   actionID("issue#12", 3),
+			callbackID: "issue#12",
+		},
+		{
+			actionID:   "deploy",
+			callbackID: "deploy",
+		},
+		{
+			actionID:   "issue#abc",
+			callbackID: "issue#abc",
+		},
+	}
+
+	for i, tt := range tests {
+		if id := callbackID(tt.actionID); id != tt.callbackID {
+			t.Errorf("Unexpected callback ID is returned on test #%d: %s.", i, id)
+		}
+	}
+}
+
+func Test_richBlocks(t *testing.T) {
+	message := &sarah.RichMessage{
+		Title:    "Deploy",
+		TitleURL: "https://example.com/",
+		Text:     "Ready to deploy.",
+		Fields: []*sarah.RichField{
+			{Title: "Version", Value: "v1.2.3"},
+		},
+		ImageURL: "https://example.com/graph.png",
+		Buttons: []*sarah.RichButton{
+			{Label: "Yes", CallbackID: "deploy", Value: "yes"},
+			{Label: "Details", URL: "https://example.com/details"},
+		},
+		Menus: []*sarah.RichMenu{
+			{
+				CallbackID:  "env",
+				Placeholder: "Environment",
+				Options: []*sarah.RichMenuOption{
+					{Label: "Production", Value: "prod"},
+				},
+			},
+		},
+	}
+
+	encoded, err := json.Marshal(richBlocks(message))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := `[` +
+		`{"text":{"text":"*\u003chttps://example.com/|Deploy\u003e*","type":"mrkdwn"},"type":"section"},` +
+		`{"text":{"text":"Ready to deploy.","type":"mrkdwn"},"type":"section"},` +
+		`{"fields":[{"text":"*Version*\nv1.2.3","type":"mrkdwn"}],"type":"section"},` +
+		`{"alt_text":"Deploy","image_url":"https://example.com/graph.png","type":"image"},` +
+		`{"elements":[` +
+		`{"action_id":"deploy#0","text":{"text":"Yes","type":"plain_text"},"type":"button","value":"yes"},` +
+		`{"text":{"text":"Details","type":"plain_text"},"type":"button","url":"https://example.com/details"},` +
+		`{"action_id":"env#2","options":[{"text":{"text":"Production","type":"plain_text"},"value":"prod"}],"placeholder":{"text":"Environment","type":"plain_text"},"type":"static_select"}` +
+		`],"type":"actions"}` +
+		`]`
+	if string(encoded) != expected {
+		t.Errorf("Unexpected blocks are returned: %s.", string(encoded))
+	}
+}
+
+func Test_verifySignature(t *testing.T) {
+	now := time.Now()
+	body := []byte("payload=%7B%7D")
+	sign := func(secret string, ts time.Time) http.Header {
+		header := http.Header{}
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		_, _ = mac.Write([]byte("v0:" + timestamp + ":" + string(body)))
+		header.Set("X-Slack-Request-Timestamp", timestamp)
+		header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return header
+	}
+
+	tests := []struct {
+		header http.Header
+		err    bool
+	}{
+		{
+			header: sign("secret", now),
+			err:    false,
+		},
+		{
+			header: sign("wrong", now),
+			err:    true,
+		},
+		{
+			header: sign("secret", now.Add(-10*time.Minute)),
+			err:    true,
+		},
+		{
+			header: http.Header{},
+			err:    true,
+		},
+	}
+
+	for i, tt := range tests {
+		err := verifySignature("secret", tt.header, body, now)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Expected error is not returned on test #%d: %#v.", i, err)
+			}
+		} else if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+	}
+}
+
+// newSignedRequest returns a request that is signed with the given secret just like Slack does.
+func newSignedRequest(secret string, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + ts + ":" + body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestAdapter_readVerifiedBody(t *testing.T) {
+	config := NewConfig()
+	config.AppSecret = "secret"
+	signed := &Adapter{config: config}
+	unsigned := &Adapter{config: NewConfig()}
+
+	tests := []struct {
+		adapter *Adapter
+		req     *http.Request
+		status  int
+	}{
+		{
+			adapter: signed,
+			req:     newSignedRequest("secret", "payload=%7B%7D"),
+			status:  0,
+		},
+		{
+			adapter: signed,
+			req:     httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload=%7B%7D")),
+			status:  http.StatusUnauthorized,
+		},
+		{
+			// A request must not be accepted without the signing secret even when the request is signed.
+			adapter: unsigned,
+			req:     newSignedRequest("", "payload=%7B%7D"),
+			status:  http.StatusUnauthorized,
+		},
+		{
+			adapter: signed,
+			req:     newSignedRequest("secret", strings.Repeat("a", maxRequestBytes+1)),
+			status:  http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for i, tt := range tests {
+		body, status, err := tt.adapter.readVerifiedBody(tt.req)
+		if tt.status == 0 {
+			if err != nil {
+				t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			} else if string(body) != "payload=%7B%7D" {
+				t.Errorf("Unexpected body is returned on test #%d: %s.", i, string(body))
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		}
+		if status != tt.status {
+			t.Errorf("Unexpected status is returned on test #%d: %d.", i, status)
+		}
+	}
+}
+
+func TestAdapter_InteractionHandler(t *testing.T) {
+	payload := `{
+		"type": "block_actions",
+		"user": {"id": "U123"},
+		"channel": {"id": "C123"},
+		"container": {"thread_ts": "1355517536.000001"},
+		"actions": [
+			{"action_id": "deploy#0", "value": "yes", "action_ts": "1548426417.840180"},
+			{"action_id": "env#1", "selected_option": {"value": "prod"}, "action_ts": "1548426417.840180"}
+		]
+	}`
+	body := url.Values{"payload": []string{payload}}.Encode()
+
+	config := NewConfig()
+	config.AppSecret = "secret"
+
+	t.Run("Not running", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.InteractionHandler().ServeHTTP(recorder, newSignedRequest("secret", body))
+
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.InteractionHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("No signing secret", func(t *testing.T) {
+		adapter := &Adapter{config: NewConfig()}
+
+		recorder := httptest.NewRecorder()
+		adapter.InteractionHandler().ServeHTTP(recorder, newSignedRequest("", body))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Invalid payload", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.InteractionHandler().ServeHTTP(recorder, newSignedRequest("secret", "payload=invalid"))
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Enqueue", func(t *testing.T) {
+		var inputs []*sarah.CallbackInput
+		adapter := &Adapter{
+			config: config,
+			apiSpecificAdapterBuilder: func(_ *Config, _ SlackClient) apiSpecificAdapter {
+				return &DummyApiSpecificAdapter{
+					RunFunc: func(ctx context.Context, _ func(sarah.Input) error, _ func(error)) {
+						<-ctx.Done()
+					},
+				}
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			adapter.Run(ctx, func(input sarah.Input) error {
+				inputs = append(inputs, input.(*sarah.CallbackInput))
+				return nil
+			}, func(error) {})
+			close(stopped)
+		}()
+
+		// Wait until the Adapter runs.
+		for adapter.inputReceiver() == nil {
+			time.Sleep(time.Millisecond)
+		}
+
+		recorder := httptest.NewRecorder()
+		adapter.InteractionHandler().ServeHTTP(recorder, newSignedRequest("secret", body))
+		cancel()
+		<-stopped
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Unexpected status is returned: %d.", recorder.Code)
+		}
+
+		if len(inputs) != 2 {
+			t.Fatalf("Unexpected number of inputs are enqueued: %d.", len(inputs))
+		}
+
+		if inputs[0].CallbackID != "deploy" || inputs[0].Value != "yes" {
+			t.Errorf("Unexpected input is enqueued: %#v.", inputs[0])
+		}
+
+		if inputs[1].CallbackID != "env" || inputs[1].Value != "prod" {
+			t.Errorf("Unexpected input is enqueued: %#v.", inputs[1])
+		}
+
+		if inputs[0].SenderKey() != "C123|U123" {
+			t.Errorf("Unexpected sender key is set: %s.", inputs[0].SenderKey())
+		}
+
+		if inputs[0].SentAt().Unix() != 1548426417 {
+			t.Errorf("Unexpected time is set: %s.", inputs[0].SentAt())
+		}
+
+		destination, ok := inputs[0].ReplyTo().(*sarah.ThreadDestination)
+		if !ok || destination.ThreadID != "1355517536.000001" || destination.Destination != event.ChannelID("C123") {
+			t.Errorf("Unexpected destination is set: %#v.", inputs[0].ReplyTo())
+		}
+
+		if adapter.inputReceiver() != nil {
+			t.Error("Function must be cleared after Adapter stops.")
+		}
+	})
+}
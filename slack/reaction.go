@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/golack/v2/event"
+	"net/url"
+	"strings"
+)
+
+// Reactor defines an interface that adds a reaction to a message on Slack.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it to send sarah.Reaction.
+// Otherwise, Adapter adds the reaction with Config.Token by itself.
+type Reactor interface {
+	AddReaction(ctx context.Context, channel event.ChannelID, timestamp string, name string) error
+}
+
+// WithReactor creates an AdapterOption that sets the Reactor to send sarah.Reaction.
+func WithReactor(reactor Reactor) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.reactor = reactor
+	}
+}
+
+// AddReaction adds a reaction to the message with reactions.add method.
+// The token requires reactions:write scope.
+func (c *webAPIClient) AddReaction(ctx context.Context, channel event.ChannelID, timestamp string, name string) error {
+	response := &webAPIResponse{}
+	err := c.call(ctx, "reactions.add", url.Values{
+		"channel":   []string{channel.String()},
+		"timestamp": []string{timestamp},
+		"name":      []string{strings.Trim(name, ":")},
+	}, response)
+	if err != nil {
+		return err
+	}
+
+	// Adding the same reaction twice is not worth reporting.
+	if !response.OK && response.Error != "already_reacted" {
+		return fmt.Errorf("failed to add reaction: %s", response.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package slack
+
+import (
+	"context"
+	"github.com/oklahomer/golack/v2/event"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type DummyReactor struct {
+	AddReactionFunc func(context.Context, event.ChannelID, string, string) error
+}
+
+var _ Reactor = (*DummyReactor)(nil)
+
+func (r *DummyReactor) AddReaction(ctx context.Context, channel event.ChannelID, timestamp string, name string) error {
+	return r.AddReactionFunc(ctx, channel, timestamp, name)
+}
+
+func TestWithReactor(t *testing.T) {
+	reactor := &DummyReactor{}
+	adapter := &Adapter{}
+
+	WithReactor(reactor)(adapter)
+
+	if adapter.reactor != reactor {
+		t.Error("Given Reactor is not set.")
+	}
+}
+
+func Test_webAPIClient_AddReaction(t *testing.T) {
+	tests := []struct {
+		body string
+		err  bool
+	}{
+		{
+			body: `{"ok": true}`,
+			err:  false,
+		},
+		{
+			body: `{"ok": false, "error": "already_reacted"}`,
+			err:  false,
+		},
+		{
+			body: `{"ok": false, "error": "invalid_name"}`,
+			err:  true,
+		},
+	}
+
+	for i, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = r.ParseForm()
+			if r.URL.Path != "/reactions.add" {
+				t.Errorf("Unexpected path is requested on test #%d: %s.", i, r.URL.Path)
+			}
+			if r.Form.Get("channel") != "C123" || r.Form.Get("timestamp") != "1355517536.000001" || r.Form.Get("name") != "+1" {
+				t.Errorf("Unexpected parameters are given on test #%d: %#v.", i, r.Form)
+			}
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		client := newWebAPIClient("token", time.Second)
+		client.endpoint = server.URL + "/"
+		err := client.AddReaction(context.TODO(), "C123", "1355517536.000001", ":+1:")
+		server.Close()
+
+		if tt.err && err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		} else if !tt.err && err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+		}
+	}
+}
@@ -3,9 +3,9 @@ package slack
 import (
 	"context"
 	"fmt"
-	"github.com/oklahomer/go-kasumi/logger"
 	"github.com/oklahomer/go-kasumi/retry"
 	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
 	"github.com/oklahomer/golack/v2/event"
 	"github.com/oklahomer/golack/v2/rtmapi"
 	"strings"
@@ -18,12 +18,19 @@ type rtmAPIAdapter struct {
 	config        *Config
 	client        SlackClient
 	handlePayload func(context.Context, *Config, rtmapi.DecodedPayload, func(sarah.Input) error)
+	connection    *connectionState
+	backfill      *backfiller
 }
 
 var _ apiSpecificAdapter = (*rtmAPIAdapter)(nil)
 
 func (r *rtmAPIAdapter) run(ctx context.Context, enqueueInput func(sarah.Input) error, notifyErr func(error)) {
+	enqueueInput = r.backfill.track(enqueueInput)
+
+	reconnected := false
+	events := &connectionEvents{}
 	for {
+		events.reconnecting(ctx)
 		conn, err := r.connect(ctx)
 		if err != nil {
 			// Failed to establish WebSocket connection with max retrials.
@@ -32,6 +39,17 @@ func (r *rtmAPIAdapter) run(ctx context.Context, enqueueInput func(sarah.Input)
 			return
 		}
 
+		r.connection.set(true)
+		events.connected(ctx)
+
+		// Backfill the messages posted while the connection was down before receiving the new ones.
+		if reconnected {
+			r.backfill.fetch(ctx, func(message *event.Message) {
+				r.handlePayload(ctx, r.config, message, catchUp(enqueueInput))
+			})
+		}
+		reconnected = true
+
 		// Create connection specific context so each connection-scoped goroutine can receive connection closing message and eventually return.
 		connCtx, connCancel := context.WithCancel(ctx)
 
@@ -53,13 +71,15 @@ func (r *rtmAPIAdapter) run(ctx context.Context, enqueueInput func(sarah.Input)
 		// close current connection and do some cleanup
 		_ = conn.Close()
 		connCancel()
+		r.connection.set(false)
+		events.disconnected(ctx, connErr)
 		if connErr == nil {
 			// Connection is intentionally closed by caller.
 			// No more interaction follows.
 			return
 		}
 
-		logger.Errorf("Will try re-connection due to previous connection's fatal state: %+v", connErr)
+		moduleLogger(ctx).Error("Will try re-connection due to previous connection's fatal state", logging.Err(connErr))
 	}
 }
 
@@ -76,7 +96,7 @@ func (r *rtmAPIAdapter) receivePayload(connCtx context.Context, payloadReceiver
 	for {
 		select {
 		case <-connCtx.Done():
-			logger.Info("Stop receiving payload due to context cancel")
+			moduleLogger(connCtx).Info("Stop receiving payload due to context cancel")
 			return
 
 		default:
@@ -91,16 +111,16 @@ func (r *rtmAPIAdapter) receivePayload(connCtx context.Context, payloadReceiver
 				// O.K. Do nothing and proceed to the payload handling
 
 			case *event.MalformedPayloadError:
-				logger.Warnf("Ignore malformed payload: %+v", err)
+				moduleLogger(connCtx).Warn("Ignore malformed payload", logging.Err(err))
 				continue
 
 			case *rtmapi.UnexpectedMessageTypeError:
-				logger.Warnf("Ignore a payload with unexpected message type: %+v", err)
+				moduleLogger(connCtx).Warn("Ignore a payload with unexpected message type", logging.Err(err))
 				continue
 
 			default:
 				// Connection might not be stable or is closed already.
-				logger.Infof("Try ping caused by error: %+v", err)
+				moduleLogger(connCtx).Info("Try ping caused by error", logging.Err(err))
 				nonBlockSignal(pingSignalChannelID, tryPing)
 				continue
 			}
@@ -127,7 +147,7 @@ func (r *rtmAPIAdapter) superviseConnection(connCtx context.Context, payloadSend
 			nonBlockSignal(pingSignalChannelID, tryPing)
 
 		case <-tryPing:
-			logger.Debug("Send ping")
+			moduleLogger(connCtx).Debug("Send ping")
 			err := payloadSender.Ping()
 			if err != nil {
 				return fmt.Errorf("error on ping: %w", err)
@@ -139,33 +159,37 @@ func (r *rtmAPIAdapter) superviseConnection(connCtx context.Context, payloadSend
 // DefaultRTMPayloadHandler receives incoming events, convert them to sarah.Input and then pass them to enqueueInput.
 // To replace this default behavior, define a function with the same signature and replace this.
 //
-//   myHandler := func(_ context.Context, config *Config, _ rtmapi.DecodedPayload, _ func(sarah.Input) error)
-//   slackAdapter, _ := slack.NewAdapter(slackConfig, slack.WithRTMPayloadHandler(myHandler))
-func DefaultRTMPayloadHandler(_ context.Context, config *Config, payload rtmapi.DecodedPayload, enqueueInput func(sarah.Input) error) {
+//  myHandler := func(_ context.Context, config *Config, _ rtmapi.DecodedPayload, _ func(sarah.Input) error)
+//  slackAdapter, _ := slack.NewAdapter(slackConfig, slack.WithRTMPayloadHandler(myHandler))
+func DefaultRTMPayloadHandler(ctx context.Context, config *Config, payload rtmapi.DecodedPayload, enqueueInput func(sarah.Input) error) {
+	log := moduleLogger(ctx)
 	switch p := payload.(type) {
 	case *rtmapi.OKReply:
-		logger.Debugf("Successfully sent. ID: %d. Text: %s.", p.ReplyTo, p.Text)
+		log.Debug("Successfully sent", logging.F("reply_to", p.ReplyTo), logging.F("text", p.Text))
 
 	case *rtmapi.NGReply:
-		logger.Errorf(
-			"Something was wrong with previous message sending. id: %d. error code: %d. error message: %s.",
-			p.ReplyTo, p.Error.Code, p.Error.Message)
+		log.Error(
+			"Something was wrong with previous message sending",
+			logging.F("reply_to", p.ReplyTo),
+			logging.F("error_code", p.Error.Code),
+			logging.F("error_message", p.Error.Message),
+		)
 
 	case *rtmapi.Pong:
-		logger.Debug("Pong message received.")
+		log.Debug("Pong message received")
 
 	case *event.Hello:
-		logger.Debugf("Successfully connected.")
+		log.Debug("Successfully connected")
 
 	default:
 		input, err := EventToInput(p)
 		if err == ErrNonSupportedEvent {
-			logger.Debugf("Event given, but no corresponding action is defined. %#v", payload)
+			log.Debug("Event given, but no corresponding action is defined", logging.F("payload", fmt.Sprintf("%#v", payload)))
 			return
 		}
 
 		if err != nil {
-			logger.Errorf("Failed to convert %T event: %s", p, err.Error())
+			log.Error("Failed to convert event", logging.F("event_type", fmt.Sprintf("%T", p)), logging.Err(err))
 			return
 		}
 
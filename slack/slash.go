@@ -0,0 +1,262 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/golack/v2/event"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ResponseURLPoster defines an interface that sends a response to a slash command with its response_url.
+// A response_url accepts up to five responses within 30 minutes after the command is invoked,
+// so a Command can respond long after Slack's three-seconds deadline for the HTTP response.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it.
+// Otherwise, Adapter posts the response by itself.
+type ResponseURLPoster interface {
+	// PostResponseURL sends the response. An ephemeral response is visible only to the user who invoked the command.
+	PostResponseURL(ctx context.Context, responseURL string, ephemeral bool, text string, blocks []interface{}) error
+}
+
+// WithResponseURLPoster creates an AdapterOption that sets the ResponseURLPoster to respond to a slash command.
+func WithResponseURLPoster(poster ResponseURLPoster) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.responseURLPoster = poster
+	}
+}
+
+var _ ResponseURLPoster = (*webAPIClient)(nil)
+
+// PostResponseURL posts the response to the given response_url.
+// No token is required since the URL itself authorizes the response.
+func (c *webAPIClient) PostResponseURL(ctx context.Context, responseURL string, ephemeral bool, text string, blocks []interface{}) error {
+	responseType := "in_channel"
+	if ephemeral {
+		responseType = "ephemeral"
+	}
+	b, err := json.Marshal(&struct {
+		ResponseType string        `json:"response_type"`
+		Text         string        `json:"text"`
+		Blocks       []interface{} `json:"blocks,omitempty"`
+	}{
+		ResponseType: responseType,
+		Text:         text,
+		Blocks:       blocks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, responseURL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to build response request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code on response_url: %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// SlashCommandDestination is a sarah.OutputDestination that tells the message is a response to a slash command.
+// Adapter sends a string, *sarah.RichMessage or *BlockMessage to the ResponseURL, and sends other contents to the Channel.
+// Wrap this with sarah.PrivateDestination to send an ephemeral response.
+type SlashCommandDestination struct {
+	Channel     event.ChannelID
+	ResponseURL string
+}
+
+// SlashCommandInput is a sarah.Input that represents an invocation of a slash command.
+// Its Message is the command followed by the text, e.g. "/deploy production", so a sarah.Command can match against
+// the invocation just like a message.
+// The reply is sent to the command's response_url.
+type SlashCommandInput struct {
+	Command     string
+	Text        string
+	ChannelID   event.ChannelID
+	UserID      event.UserID
+	UserName    string
+	TeamID      string
+	ResponseURL string
+	TriggerID   string
+	receivedAt  time.Time
+}
+
+var _ sarah.SenderIDInput = (*SlashCommandInput)(nil)
+var _ sarah.SenderDisplayNameInput = (*SlashCommandInput)(nil)
+var _ sarah.DirectMessageInput = (*SlashCommandInput)(nil)
+
+// SenderKey returns string representing the user who invoked the command.
+func (i *SlashCommandInput) SenderKey() string {
+	return fmt.Sprintf("%s|%s", i.ChannelID.String(), i.UserID.String())
+}
+
+// Message returns the command and its text.
+func (i *SlashCommandInput) Message() string {
+	if i.Text == "" {
+		return i.Command
+	}
+	return i.Command + " " + i.Text
+}
+
+// SentAt returns the time when the invocation is received since Slack does not tell the time of the invocation.
+func (i *SlashCommandInput) SentAt() time.Time {
+	return i.receivedAt
+}
+
+// ReplyTo returns *SlashCommandDestination to respond with the command's response_url.
+func (i *SlashCommandInput) ReplyTo() sarah.OutputDestination {
+	return &SlashCommandDestination{
+		Channel:     i.ChannelID,
+		ResponseURL: i.ResponseURL,
+	}
+}
+
+// SenderID returns the ID of the user who invoked the command.
+func (i *SlashCommandInput) SenderID() string {
+	return i.UserID.String()
+}
+
+// SenderDisplayName returns the name of the user who invoked the command.
+func (i *SlashCommandInput) SenderDisplayName() string {
+	return i.UserName
+}
+
+// IsDirectMessage tells if the command is invoked in a direct message channel.
+func (i *SlashCommandInput) IsDirectMessage() bool {
+	return strings.HasPrefix(i.ChannelID.String(), "D")
+}
+
+// slashCommandPayload represents the payload of a slash command invocation.
+// https://api.slack.com/interactivity/slash-commands#app_command_handling
+type slashCommandPayload struct {
+	Command     string `json:"command"`
+	Text        string `json:"text"`
+	ChannelID   string `json:"channel_id"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	TeamID      string `json:"team_id"`
+	ResponseURL string `json:"response_url"`
+	TriggerID   string `json:"trigger_id"`
+}
+
+func (p *slashCommandPayload) toInput(receivedAt time.Time) *SlashCommandInput {
+	return &SlashCommandInput{
+		Command:     p.Command,
+		Text:        strings.TrimSpace(p.Text),
+		ChannelID:   event.ChannelID(p.ChannelID),
+		UserID:      event.UserID(p.UserID),
+		UserName:    p.UserName,
+		TeamID:      p.TeamID,
+		ResponseURL: p.ResponseURL,
+		TriggerID:   p.TriggerID,
+		receivedAt:  receivedAt,
+	}
+}
+
+// SlashCommandHandler returns an http.Handler that receives slash command invocations.
+// Mount the handler on a server and set the URL as the Request URL of each slash command in the Slack App's setting:
+//
+//  http.Handle("/slack/commands", slackAdapter.SlashCommandHandler())
+//
+// Each invocation is converted to *SlashCommandInput and is passed to go-sarah's core while the Adapter is running,
+// so the registered sarah.Command that matches with the invocation, e.g. "/deploy production", is executed.
+// The handler responds immediately and the Command's response is sent later with the response_url.
+// Each request is verified with Config.AppSecret, the signing secret, and every request is rejected with 401 when the secret is not set;
+// otherwise anyone could execute the Commands as any user.
+func (adapter *Adapter) SlashCommandHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		log := moduleLogger(req.Context())
+
+		body, status, err := adapter.readVerifiedBody(req)
+		if err != nil {
+			log.Warn("Failed to verify slash command request", logging.Err(err))
+			w.WriteHeader(status)
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil || form.Get("command") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		enqueueInput := adapter.inputReceiver()
+		if enqueueInput == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		payload := &slashCommandPayload{
+			Command:     form.Get("command"),
+			Text:        form.Get("text"),
+			ChannelID:   form.Get("channel_id"),
+			UserID:      form.Get("user_id"),
+			UserName:    form.Get("user_name"),
+			TeamID:      form.Get("team_id"),
+			ResponseURL: form.Get("response_url"),
+			TriggerID:   form.Get("trigger_id"),
+		}
+		err = enqueueInput(payload.toInput(time.Now()))
+		if err != nil {
+			log.Error("Failed to enqueue slash command", logging.F("command", payload.Command), logging.Err(err))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		// Slack requires a response within three seconds, so the input is handled asynchronously.
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// respondToSlashCommand sends the given output with the destination's response_url.
+// A content that a response_url can not carry is sent to the channel instead.
+func (adapter *Adapter) respondToSlashCommand(ctx context.Context, output sarah.Output, destination *SlashCommandDestination) (*sarah.SendResult, error) {
+	privateUserID := sarah.PrivateUserID(output.Destination())
+
+	var text string
+	var blocks []interface{}
+	switch content := output.Content().(type) {
+	case string:
+		text = content
+
+	case *sarah.RichMessage:
+		text = content.PlainText()
+		blocks = richBlocks(content)
+
+	case *BlockMessage:
+		text = content.Text
+		blocks = content.blocks()
+
+	default:
+		var channel sarah.OutputDestination = destination.Channel
+		if privateUserID != "" {
+			channel = sarah.NewPrivateDestination(channel, privateUserID)
+		}
+		return adapter.SendMessage(ctx, sarah.NewOutputMessage(channel, output.Content()))
+
+	}
+
+	err := adapter.responseURLPoster.PostResponseURL(ctx, destination.ResponseURL, privateUserID != "", text, blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to respond to slash command: %w", err)
+	}
+	return &sarah.SendResult{Destination: output.Destination()}, nil
+}
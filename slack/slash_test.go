@@ -0,0 +1,336 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/oklahomer/go-kasumi/retry"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"github.com/oklahomer/golack/v2/webapi"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyResponseURLPoster struct {
+	PostResponseURLFunc func(context.Context, string, bool, string, []interface{}) error
+}
+
+var _ ResponseURLPoster = (*DummyResponseURLPoster)(nil)
+
+func (p *DummyResponseURLPoster) PostResponseURL(ctx context.Context, responseURL string, ephemeral bool, text string, blocks []interface{}) error {
+	return p.PostResponseURLFunc(ctx, responseURL, ephemeral, text, blocks)
+}
+
+func TestWithResponseURLPoster(t *testing.T) {
+	poster := &DummyResponseURLPoster{}
+	adapter := &Adapter{}
+
+	WithResponseURLPoster(poster)(adapter)
+
+	if adapter.responseURLPoster != poster {
+		t.Errorf("Expected ResponseURLPoster is not set: %#v.", adapter.responseURLPoster)
+	}
+}
+
+func Test_webAPIClient_PostResponseURL(t *testing.T) {
+	var given map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type is given: %s.", r.Header.Get("Content-Type"))
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &given)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("", time.Second)
+	err := client.PostResponseURL(context.TODO(), server.URL, true, "hello", []interface{}{&DividerBlock{}})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if given["response_type"] != "ephemeral" || given["text"] != "hello" {
+		t.Errorf("Unexpected response is posted: %#v.", given)
+	}
+
+	if blocks, ok := given["blocks"].([]interface{}); !ok || len(blocks) != 1 {
+		t.Errorf("Unexpected blocks are posted: %#v.", given["blocks"])
+	}
+}
+
+func Test_webAPIClient_PostResponseURL_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("expired_url"))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("", time.Second)
+	err := client.PostResponseURL(context.TODO(), server.URL, false, "hello", nil)
+
+	if err == nil || !strings.Contains(err.Error(), "expired_url") {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestSlashCommandInput(t *testing.T) {
+	receivedAt := time.Now()
+	payload := &slashCommandPayload{
+		Command:     "/deploy",
+		Text:        " production ",
+		ChannelID:   "C123",
+		UserID:      "U123",
+		UserName:    "bob",
+		ResponseURL: "https://hooks.slack.com/commands/T123/456/abc",
+	}
+	input := payload.toInput(receivedAt)
+
+	if input.Message() != "/deploy production" {
+		t.Errorf("Unexpected message is returned: %s.", input.Message())
+	}
+
+	if input.SenderKey() != "C123|U123" {
+		t.Errorf("Unexpected sender key is returned: %s.", input.SenderKey())
+	}
+
+	if input.SenderID() != "U123" || input.SenderDisplayName() != "bob" {
+		t.Errorf("Unexpected sender is returned: %s, %s.", input.SenderID(), input.SenderDisplayName())
+	}
+
+	if !input.SentAt().Equal(receivedAt) {
+		t.Errorf("Unexpected time is returned: %s.", input.SentAt())
+	}
+
+	if input.IsDirectMessage() {
+		t.Error("Command in a channel is treated as a direct message.")
+	}
+
+	destination, ok := input.ReplyTo().(*SlashCommandDestination)
+	if !ok || destination.Channel != "C123" || destination.ResponseURL != payload.ResponseURL {
+		t.Errorf("Unexpected destination is returned: %#v.", input.ReplyTo())
+	}
+
+	payload.Text = ""
+	if payload.toInput(receivedAt).Message() != "/deploy" {
+		t.Errorf("Unexpected message is returned: %s.", payload.toInput(receivedAt).Message())
+	}
+}
+
+func TestAdapter_SlashCommandHandler(t *testing.T) {
+	body := url.Values{
+		"command":      []string{"/deploy"},
+		"text":         []string{"production"},
+		"channel_id":   []string{"C123"},
+		"user_id":      []string{"U123"},
+		"response_url": []string{"https://hooks.slack.com/commands/T123/456/abc"},
+	}.Encode()
+	config := NewConfig()
+	config.AppSecret = "secret"
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.SlashCommandHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("No signing secret", func(t *testing.T) {
+		adapter := &Adapter{config: NewConfig()}
+		adapter.enqueueInput.Store(func(input sarah.Input) error {
+			t.Errorf("Unsigned input must not be enqueued: %#v.", input)
+			return nil
+		})
+
+		recorder := httptest.NewRecorder()
+		adapter.SlashCommandHandler().ServeHTTP(recorder, newSignedRequest("", body))
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Too large body", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.SlashCommandHandler().ServeHTTP(recorder, newSignedRequest("secret", body+"&text="+strings.Repeat("a", maxRequestBytes)))
+
+		if recorder.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Invalid payload", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.SlashCommandHandler().ServeHTTP(recorder, newSignedRequest("secret", "text=foo"))
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Not running", func(t *testing.T) {
+		adapter := &Adapter{config: config}
+
+		recorder := httptest.NewRecorder()
+		adapter.SlashCommandHandler().ServeHTTP(recorder, newSignedRequest("secret", body))
+
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Errorf("Unexpected status is returned: %d.", recorder.Code)
+		}
+	})
+
+	t.Run("Enqueue", func(t *testing.T) {
+		var inputs []sarah.Input
+		adapter := &Adapter{config: config}
+		adapter.enqueueInput.Store(func(input sarah.Input) error {
+			inputs = append(inputs, input)
+			return nil
+		})
+
+		recorder := httptest.NewRecorder()
+		adapter.SlashCommandHandler().ServeHTTP(recorder, newSignedRequest("secret", body))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Unexpected status is returned: %d.", recorder.Code)
+		}
+
+		if len(inputs) != 1 || inputs[0].Message() != "/deploy production" {
+			t.Errorf("Unexpected inputs are enqueued: %#v.", inputs)
+		}
+	})
+}
+
+func TestAdapter_SendMessage_SlashCommand(t *testing.T) {
+	destination := &SlashCommandDestination{Channel: "C123", ResponseURL: "https://hooks.slack.com/commands/T123/456/abc"}
+
+	t.Run("Public", func(t *testing.T) {
+		var givenURL string
+		var givenEphemeral bool
+		var givenText string
+		adapter := &Adapter{
+			responseURLPoster: &DummyResponseURLPoster{
+				PostResponseURLFunc: func(_ context.Context, responseURL string, ephemeral bool, text string, _ []interface{}) error {
+					givenURL = responseURL
+					givenEphemeral = ephemeral
+					givenText = text
+					return nil
+				},
+			},
+		}
+
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(destination, "deployed"))
+
+		if givenURL != destination.ResponseURL {
+			t.Errorf("Unexpected URL is given: %s.", givenURL)
+		}
+
+		if givenEphemeral {
+			t.Error("Public response is sent as an ephemeral response.")
+		}
+
+		if givenText != "deployed" {
+			t.Errorf("Unexpected text is given: %s.", givenText)
+		}
+	})
+
+	t.Run("Private block message", func(t *testing.T) {
+		var givenEphemeral bool
+		var givenBlocks []interface{}
+		adapter := &Adapter{
+			responseURLPoster: &DummyResponseURLPoster{
+				PostResponseURLFunc: func(_ context.Context, _ string, ephemeral bool, _ string, blocks []interface{}) error {
+					givenEphemeral = ephemeral
+					givenBlocks = blocks
+					return nil
+				},
+			},
+		}
+
+		message := NewBlockMessage("deployed").Divider()
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(sarah.NewPrivateDestination(destination, "U123"), message))
+
+		if !givenEphemeral {
+			t.Error("Private response is not sent as an ephemeral response.")
+		}
+
+		if len(givenBlocks) != 1 {
+			t.Errorf("Unexpected blocks are given: %#v.", givenBlocks)
+		}
+	})
+
+	t.Run("Unsupported content", func(t *testing.T) {
+		var message *webapi.PostMessage
+		adapter := &Adapter{
+			client: &DummyClient{
+				PostMessageFunc: func(_ context.Context, m *webapi.PostMessage) (*webapi.APIResponse, error) {
+					message = m
+					return &webapi.APIResponse{OK: true}, nil
+				},
+			},
+			responseURLPoster: &DummyResponseURLPoster{
+				PostResponseURLFunc: func(_ context.Context, _ string, _ bool, _ string, _ []interface{}) error {
+					t.Error("Unsupported content must not be sent with response_url.")
+					return nil
+				},
+			},
+		}
+
+		adapter.SendMessage(context.TODO(), sarah.NewOutputMessage(destination, &sarah.CommandHelps{}))
+
+		if message == nil || message.Channel != "C123" {
+			t.Errorf("Message is not sent to the channel: %#v.", message)
+		}
+	})
+}
+
+func Test_socketModeAdapter_run_SlashCommand(t *testing.T) {
+	conn := newDummySocketConnection(
+		`{"type": "slash_commands", "envelope_id": "env1", "payload": {"command": "/deploy", "text": "production", "channel_id": "C123", "user_id": "U123", "response_url": "https://hooks.slack.com/commands/T123/456/abc"}}`,
+	)
+	adapter := &socketModeAdapter{
+		config: &Config{RetryPolicy: &retry.Policy{Trial: 1}},
+		client: &DummySocketModeClient{
+			OpenSocketFunc: func(_ context.Context) (SocketConnection, error) {
+				return conn, nil
+			},
+		},
+		handlePayload: DefaultEventsPayloadHandler,
+		connection:    &connectionState{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	incoming := make(chan sarah.Input, 1)
+	go adapter.run(ctx, func(input sarah.Input) error {
+		incoming <- input
+		return nil
+	}, func(_ error) {})
+
+	select {
+	case input := <-incoming:
+		typed, ok := input.(*SlashCommandInput)
+		if !ok {
+			t.Fatalf("Unexpected input is given: %#v.", input)
+		}
+		if typed.Message() != "/deploy production" || typed.ChannelID != event.ChannelID("C123") {
+			t.Errorf("Unexpected input is given: %#v.", typed)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Input is not given.")
+
+	}
+}
@@ -0,0 +1,328 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/oklahomer/go-kasumi/retry"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/golack/v2/event"
+	"github.com/oklahomer/golack/v2/eventsapi"
+	"net/url"
+	"time"
+)
+
+// SocketModeClient defines an interface that opens a Socket Mode connection.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it.
+// Otherwise, Adapter calls apps.connections.open with Config.AppToken and connects to the returned URL by itself.
+type SocketModeClient interface {
+	OpenSocket(ctx context.Context) (SocketConnection, error)
+}
+
+// SocketConnection defines an interface that reads and writes Socket Mode's JSON messages.
+type SocketConnection interface {
+	// ReadMessage blocks til a message arrives and returns the message.
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage sends the given message.
+	WriteMessage([]byte) error
+
+	// Close closes the connection. A blocking ReadMessage returns an error.
+	Close() error
+}
+
+// WithSocketModeClient creates an AdapterOption that sets the SocketModeClient to open a Socket Mode connection.
+func WithSocketModeClient(client SocketModeClient) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.socketModeClient = client
+	}
+}
+
+// WithSocketModePayloadHandler creates an AdapterOption with the given function to handle incoming Events API payloads over Socket Mode.
+// Socket Mode delivers the same payloads as Events API does, so DefaultEventsPayloadHandler or a handler written for
+// WithEventsPayloadHandler can be used as-is:
+//
+//  slackConfig := slack.NewConfig()
+//  slackConfig.AppToken = "xapp-XXXXXXX"
+//  slackAdapter, _ := slack.NewAdapter(slackConfig, slack.WithSocketModePayloadHandler(slack.DefaultEventsPayloadHandler))
+//
+// Interactions with the interactive components and slash command invocations are also received over the connection
+// and are passed to go-sarah's core just like InteractionHandler and SlashCommandHandler do, so no public endpoint is required.
+func WithSocketModePayloadHandler(fnc func(context.Context, *Config, *eventsapi.EventWrapper, func(sarah.Input) error)) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.apiSpecificAdapterBuilder = func(config *Config, client SlackClient) apiSpecificAdapter {
+			return &socketModeAdapter{
+				config:        adapter.config,
+				client:        adapter.socketModeClient,
+				handlePayload: fnc,
+				connection:    adapter.connection,
+			}
+		}
+	}
+}
+
+type socketModeAdapter struct {
+	config        *Config
+	client        SocketModeClient
+	handlePayload func(context.Context, *Config, *eventsapi.EventWrapper, func(sarah.Input) error)
+	connection    *connectionState
+	backfill      *backfiller
+}
+
+var _ apiSpecificAdapter = (*socketModeAdapter)(nil)
+
+// errDisconnectRequested is returned when Slack requests to reconnect, which happens periodically.
+var errDisconnectRequested = errors.New("disconnect is requested by Slack")
+
+func (s *socketModeAdapter) run(ctx context.Context, enqueueInput func(sarah.Input) error, notifyErr func(error)) {
+	enqueueInput = s.backfill.track(enqueueInput)
+
+	// Backfill the missed messages after an unexpected disconnection.
+	// Slack keeps delivering events during the periodic reconnection, so nothing is fetched in that case.
+	backfill := false
+	events := &connectionEvents{}
+	for {
+		events.reconnecting(ctx)
+		conn, err := s.connect(ctx)
+		if ctx.Err() != nil {
+			// The Bot is stopping.
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		if err != nil {
+			// Failed to establish WebSocket connection with max retrials.
+			// Notify the unrecoverable state and give up.
+			notifyErr(sarah.NewBotNonContinuableError(err.Error()))
+			return
+		}
+
+		s.connection.set(true)
+		events.connected(ctx)
+		if backfill {
+			s.backfill.fetch(ctx, func(message *event.Message) {
+				s.handlePayload(ctx, s.config, &eventsapi.EventWrapper{Event: message}, catchUp(enqueueInput))
+			})
+		}
+		connErr := s.receivePayload(ctx, conn, enqueueInput)
+		_ = conn.Close()
+		s.connection.set(false)
+
+		if ctx.Err() != nil {
+			// Connection is intentionally closed by caller.
+			events.disconnected(ctx, nil)
+			return
+		}
+		events.disconnected(ctx, connErr)
+
+		backfill = connErr != errDisconnectRequested
+		if connErr == errDisconnectRequested {
+			moduleLogger(ctx).Info("Reconnecting as requested by Slack")
+		} else {
+			moduleLogger(ctx).Error("Will try re-connection due to previous connection's fatal state", logging.Err(connErr))
+		}
+	}
+}
+
+func (s *socketModeAdapter) connect(ctx context.Context) (SocketConnection, error) {
+	var conn SocketConnection
+	err := retry.WithPolicy(s.config.RetryPolicy, func() (e error) {
+		if ctx.Err() != nil {
+			// Do not retry for a stopping Bot.
+			return nil
+		}
+		conn, e = s.client.OpenSocket(ctx)
+		return e
+	})
+	return conn, err
+}
+
+// socketModeEnvelope represents a message sent over Socket Mode connection.
+// https://api.slack.com/apis/connections/socket-implement
+type socketModeEnvelope struct {
+	Type       string          `json:"type"`
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload"`
+	Reason     string          `json:"reason"`
+}
+
+// receivePayload reads messages til the connection is closed, the context is canceled, or Slack requests to reconnect.
+func (s *socketModeAdapter) receivePayload(ctx context.Context, conn SocketConnection, enqueueInput func(sarah.Input) error) error {
+	// Close the connection on cancellation to let the blocking ReadMessage return.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+
+		case <-done:
+			// O.K.
+
+		}
+	}()
+
+	log := moduleLogger(ctx)
+	for {
+		b, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+
+		envelope := &socketModeEnvelope{}
+		err = json.Unmarshal(b, envelope)
+		if err != nil {
+			log.Warn("Ignore malformed payload", logging.Err(err))
+			continue
+		}
+
+		if envelope.EnvelopeID != "" {
+			// Acknowledge first since Slack retries the delivery when no acknowledgement is given within three seconds.
+			ack, _ := json.Marshal(map[string]string{"envelope_id": envelope.EnvelopeID})
+			err := conn.WriteMessage(ack)
+			if err != nil {
+				return fmt.Errorf("failed to acknowledge envelope: %w", err)
+			}
+		}
+
+		switch envelope.Type {
+		case "hello":
+			log.Debug("Successfully connected")
+
+		case "disconnect":
+			log.Debug("Disconnect is requested", logging.F("reason", envelope.Reason))
+			return errDisconnectRequested
+
+		case "events_api":
+			wrapper, err := decodeEventsAPIPayload(envelope.Payload)
+			if err != nil {
+				log.Warn("Ignore malformed event", logging.Err(err))
+				continue
+			}
+			s.handlePayload(ctx, s.config, wrapper, enqueueInput)
+
+		case "interactive":
+			payload := &interactionPayload{}
+			err := json.Unmarshal(envelope.Payload, payload)
+			if err != nil {
+				log.Warn("Ignore malformed interaction", logging.Err(err))
+				continue
+			}
+			for _, input := range payloadToCallbackInputs(payload) {
+				err := enqueueInput(input)
+				if err != nil {
+					log.Error("Failed to enqueue interaction", logging.F("callback_id", input.CallbackID), logging.Err(err))
+				}
+			}
+
+		case "slash_commands":
+			payload := &slashCommandPayload{}
+			err := json.Unmarshal(envelope.Payload, payload)
+			if err != nil {
+				log.Warn("Ignore malformed slash command", logging.Err(err))
+				continue
+			}
+			err = enqueueInput(payload.toInput(time.Now()))
+			if err != nil {
+				log.Error("Failed to enqueue slash command", logging.F("command", payload.Command), logging.Err(err))
+			}
+
+		default:
+			log.Debug("Envelope given, but no corresponding action is defined", logging.F("type", envelope.Type))
+
+		}
+	}
+}
+
+// decodeEventsAPIPayload converts the event_callback payload to the same form that Events API's payload handler receives.
+// A message event is decoded to *event.Message so EventToInput can convert it to Input.
+// Other events are left as json.RawMessage so a customized payload handler can decode them.
+func decodeEventsAPIPayload(payload json.RawMessage) (*eventsapi.EventWrapper, error) {
+	callback := &struct {
+		Event json.RawMessage `json:"event"`
+	}{}
+	err := json.Unmarshal(payload, callback)
+	if err != nil {
+		return nil, event.NewMalformedPayloadError(err.Error())
+	}
+
+	typed := &struct {
+		Type    string `json:"type"`
+		SubType string `json:"subtype"`
+	}{}
+	err = json.Unmarshal(callback.Event, typed)
+	if err != nil {
+		return nil, event.NewMalformedPayloadError(err.Error())
+	}
+
+	var ev interface{} = callback.Event
+	if typed.Type == "message" && typed.SubType == "" {
+		message := &event.Message{}
+		err := json.Unmarshal(callback.Event, message)
+		if err != nil {
+			return nil, event.NewMalformedPayloadError(err.Error())
+		}
+		ev = message
+	}
+
+	return &eventsapi.EventWrapper{
+		Event: ev,
+	}, nil
+}
+
+type connectionsOpenResponse struct {
+	webAPIResponse
+	URL string `json:"url"`
+}
+
+// ErrAppTokenRequired is returned when Socket Mode connection is opened without Config.AppToken.
+var ErrAppTokenRequired = errors.New("app-level token is required for Socket Mode")
+
+var _ SocketModeClient = (*webAPIClient)(nil)
+
+// OpenSocket opens a Socket Mode connection with apps.connections.open method.
+// The client must be created with the app-level token.
+func (c *webAPIClient) OpenSocket(ctx context.Context) (SocketConnection, error) {
+	if c.token == "" {
+		return nil, ErrAppTokenRequired
+	}
+
+	response := &connectionsOpenResponse{}
+	err := c.call(ctx, "apps.connections.open", url.Values{}, response)
+	if err != nil {
+		return nil, err
+	}
+	if !response.OK {
+		return nil, fmt.Errorf("failed to open connection: %s", response.Error)
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, response.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", response.URL, err)
+	}
+
+	return &socketConnection{conn: conn}, nil
+}
+
+// socketConnection wraps *websocket.Conn to satisfy SocketConnection.
+// Ping frames from Slack are answered by the websocket package's default handler.
+type socketConnection struct {
+	conn *websocket.Conn
+}
+
+func (c *socketConnection) ReadMessage() ([]byte, error) {
+	_, b, err := c.conn.ReadMessage()
+	return b, err
+}
+
+func (c *socketConnection) WriteMessage(b []byte) error {
+	return c.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+func (c *socketConnection) Close() error {
+	return c.conn.Close()
+}
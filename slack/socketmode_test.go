@@ -0,0 +1,450 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/oklahomer/go-kasumi/retry"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/golack/v2/event"
+	"github.com/oklahomer/golack/v2/eventsapi"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type DummySocketModeClient struct {
+	OpenSocketFunc func(context.Context) (SocketConnection, error)
+}
+
+func (c *DummySocketModeClient) OpenSocket(ctx context.Context) (SocketConnection, error) {
+	return c.OpenSocketFunc(ctx)
+}
+
+// DummySocketConnection returns the given messages in order, and then blocks til closed.
+type DummySocketConnection struct {
+	messages chan []byte
+	closed   chan struct{}
+	once     sync.Once
+	mutex    sync.Mutex
+	written  []string
+}
+
+func newDummySocketConnection(messages ...string) *DummySocketConnection {
+	conn := &DummySocketConnection{
+		messages: make(chan []byte, len(messages)),
+		closed:   make(chan struct{}),
+	}
+	for _, m := range messages {
+		conn.messages <- []byte(m)
+	}
+	return conn
+}
+
+func (c *DummySocketConnection) ReadMessage() ([]byte, error) {
+	select {
+	case m := <-c.messages:
+		return m, nil
+
+	case <-c.closed:
+		return nil, errors.New("connection is closed")
+
+	}
+}
+
+func (c *DummySocketConnection) WriteMessage(b []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.written = append(c.written, string(b))
+	return nil
+}
+
+func (c *DummySocketConnection) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+func (c *DummySocketConnection) writtenMessages() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string{}, c.written...)
+}
+
+func TestWithSocketModeClient(t *testing.T) {
+	client := &DummySocketModeClient{}
+	adapter := &Adapter{}
+
+	WithSocketModeClient(client)(adapter)
+
+	if adapter.socketModeClient != client {
+		t.Errorf("Expected SocketModeClient is not set: %#v.", adapter.socketModeClient)
+	}
+}
+
+func TestWithSocketModePayloadHandler(t *testing.T) {
+	fnc := func(_ context.Context, _ *Config, _ *eventsapi.EventWrapper, _ func(sarah.Input) error) {}
+	opt := WithSocketModePayloadHandler(fnc)
+	adapter := &Adapter{}
+
+	opt(adapter)
+
+	if adapter.apiSpecificAdapterBuilder == nil {
+		t.Fatal("apiSpecificAdapterBuilder is not set.")
+	}
+
+	if _, ok := adapter.apiSpecificAdapterBuilder(nil, nil).(*socketModeAdapter); !ok {
+		t.Error("socketModeAdapter could not be built.")
+	}
+}
+
+func Test_socketModeAdapter_run(t *testing.T) {
+	t.Run("Receive event and interaction", func(t *testing.T) {
+		conn := newDummySocketConnection(
+			`{"type": "hello"}`,
+			`{"type": "events_api", "envelope_id": "env1", "payload": {"type": "event_callback", "event": {"type": "message", "channel": "C123", "user": "U123", "text": "hello", "ts": "1355517523.000005"}}}`,
+			`{"type": "interactive", "envelope_id": "env2", "payload": {"type": "block_actions", "user": {"id": "U123"}, "channel": {"id": "C123"}, "actions": [{"action_id": "vote#0", "value": "yes"}]}}`,
+		)
+		adapter := &socketModeAdapter{
+			config: &Config{RetryPolicy: &retry.Policy{Trial: 1}},
+			client: &DummySocketModeClient{
+				OpenSocketFunc: func(_ context.Context) (SocketConnection, error) {
+					return conn, nil
+				},
+			},
+			handlePayload: DefaultEventsPayloadHandler,
+			connection:    &connectionState{},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		incoming := make(chan sarah.Input, 2)
+		finished := make(chan struct{})
+		go func() {
+			adapter.run(ctx, func(input sarah.Input) error {
+				incoming <- input
+				return nil
+			}, func(err error) {
+				t.Errorf("Unexpected error is notified: %s.", err.Error())
+			})
+			close(finished)
+		}()
+
+		for i := 0; i < 2; i++ {
+			select {
+			case input := <-incoming:
+				switch typed := input.(type) {
+				case *Input:
+					if typed.Message() != "hello" {
+						t.Errorf("Unexpected message is given: %s.", typed.Message())
+					}
+
+				case *sarah.CallbackInput:
+					if typed.CallbackID != "vote" || typed.Value != "yes" {
+						t.Errorf("Unexpected callback is given: %#v.", typed)
+					}
+
+				default:
+					t.Errorf("Unexpected input is given: %#v.", input)
+
+				}
+
+			case <-time.NewTimer(1 * time.Second).C:
+				t.Fatal("Input is not given.")
+
+			}
+		}
+
+		if !adapter.connection.connected() {
+			t.Error("Connection state is not set.")
+		}
+
+		cancel()
+		select {
+		case <-finished:
+			// O.K.
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("run did not return on context cancellation.")
+
+		}
+
+		written := conn.writtenMessages()
+		if len(written) != 2 || written[0] != `{"envelope_id":"env1"}` || written[1] != `{"envelope_id":"env2"}` {
+			t.Errorf("Envelopes are not acknowledged: %#v.", written)
+		}
+
+		if adapter.connection.connected() {
+			t.Error("Connection state is not reset.")
+		}
+	})
+
+	t.Run("Reconnect on disconnect request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		opened := 0
+		adapter := &socketModeAdapter{
+			config: &Config{RetryPolicy: &retry.Policy{Trial: 1}},
+			client: &DummySocketModeClient{
+				OpenSocketFunc: func(_ context.Context) (SocketConnection, error) {
+					opened++
+					if opened > 1 {
+						cancel()
+						return newDummySocketConnection(), nil
+					}
+					return newDummySocketConnection(`{"type": "disconnect", "reason": "refresh_requested"}`), nil
+				},
+			},
+			handlePayload: DefaultEventsPayloadHandler,
+		}
+
+		finished := make(chan struct{})
+		go func() {
+			adapter.run(ctx, func(_ sarah.Input) error { return nil }, func(err error) {
+				t.Errorf("Unexpected error is notified: %s.", err.Error())
+			})
+			close(finished)
+		}()
+
+		select {
+		case <-finished:
+			// O.K.
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("run did not return.")
+
+		}
+
+		if opened != 2 {
+			t.Errorf("Expected to reconnect once, but connected %d times.", opened)
+		}
+	})
+
+	t.Run("Backfill after unexpected disconnection", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first := newDummySocketConnection(
+			`{"type": "events_api", "envelope_id": "env1", "payload": {"type": "event_callback", "event": {"type": "message", "channel": "C123", "user": "U123", "text": "hello", "ts": "1355517523.000005"}}}`,
+		)
+		second := newDummySocketConnection(
+			`{"type": "events_api", "envelope_id": "env2", "payload": {"type": "event_callback", "event": {"type": "message", "channel": "C123", "user": "U123", "text": "missed", "ts": "1355517524.000001"}}}`,
+			`{"type": "events_api", "envelope_id": "env3", "payload": {"type": "event_callback", "event": {"type": "message", "channel": "C123", "user": "U123", "text": "new", "ts": "1355517525.000001"}}}`,
+		)
+		connections := []*DummySocketConnection{first, second}
+		adapter := &socketModeAdapter{
+			config: &Config{RetryPolicy: &retry.Policy{Trial: 1}},
+			client: &DummySocketModeClient{
+				OpenSocketFunc: func(_ context.Context) (SocketConnection, error) {
+					conn := connections[0]
+					connections = connections[1:]
+					return conn, nil
+				},
+			},
+			handlePayload: DefaultEventsPayloadHandler,
+			backfill: newBackfiller(&DummyHistoryFetcher{
+				FetchHistoryFunc: func(_ context.Context, channel event.ChannelID, oldest string, _ uint) ([]*event.Message, error) {
+					if channel != "C123" || oldest != "1355517523.000005" {
+						t.Errorf("Unexpected arguments are given: %s, %s.", channel, oldest)
+					}
+					return []*event.Message{
+						{ChannelID: "C123", UserID: "U123", Text: "missed", TimeStamp: &event.TimeStamp{OriginalValue: "1355517524.000001"}},
+					}, nil
+				},
+			}, 10),
+		}
+
+		incoming := make(chan *Input, 3)
+		go adapter.run(ctx, func(input sarah.Input) error {
+			if input.Message() == "hello" {
+				// Drop the connection unexpectedly.
+				_ = first.Close()
+			}
+			incoming <- input.(*Input)
+			return nil
+		}, func(err error) {
+			t.Errorf("Unexpected error is notified: %s.", err.Error())
+		})
+
+		var inputs []*Input
+		for len(inputs) < 3 {
+			select {
+			case input := <-incoming:
+				inputs = append(inputs, input)
+
+			case <-time.NewTimer(1 * time.Second).C:
+				t.Fatalf("Input is not given: %d.", len(inputs))
+
+			}
+		}
+
+		if inputs[0].Message() != "hello" || inputs[0].IsCatchUp() {
+			t.Errorf("Unexpected input is given: %#v.", inputs[0])
+		}
+		if inputs[1].Message() != "missed" || !inputs[1].IsCatchUp() {
+			t.Errorf("Backfilled message is not given as catch-up input: %#v.", inputs[1])
+		}
+		// The duplicate of the backfilled message is skipped.
+		if inputs[2].Message() != "new" || inputs[2].IsCatchUp() {
+			t.Errorf("Unexpected input is given: %#v.", inputs[2])
+		}
+	})
+
+	t.Run("Connection error", func(t *testing.T) {
+		adapter := &socketModeAdapter{
+			config: &Config{RetryPolicy: &retry.Policy{Trial: 1}},
+			client: &DummySocketModeClient{
+				OpenSocketFunc: func(_ context.Context) (SocketConnection, error) {
+					return nil, errors.New("connection error")
+				},
+			},
+			handlePayload: DefaultEventsPayloadHandler,
+		}
+
+		var notified error
+		adapter.run(context.Background(), func(_ sarah.Input) error { return nil }, func(err error) {
+			notified = err
+		})
+
+		var target *sarah.BotNonContinuableError
+		if !errors.As(notified, &target) {
+			t.Errorf("Expected error is not notified: %#v.", notified)
+		}
+	})
+}
+
+func Test_decodeEventsAPIPayload(t *testing.T) {
+	t.Run("Message", func(t *testing.T) {
+		wrapper, err := decodeEventsAPIPayload(json.RawMessage(`{"type": "event_callback", "event": {"type": "message", "channel": "C123", "user": "U123", "text": "hello", "ts": "1355517523.000005"}}`))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		message, ok := wrapper.Event.(*event.Message)
+		if !ok {
+			t.Fatalf("Unexpected event is returned: %#v.", wrapper.Event)
+		}
+
+		if message.ChannelID != "C123" || message.UserID != "U123" || message.Text != "hello" {
+			t.Errorf("Unexpected message is returned: %#v.", message)
+		}
+	})
+
+	t.Run("Other event", func(t *testing.T) {
+		raw := `{"type": "reaction_added", "user": "U123"}`
+		wrapper, err := decodeEventsAPIPayload(json.RawMessage(`{"type": "event_callback", "event": ` + raw + `}`))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		ev, ok := wrapper.Event.(json.RawMessage)
+		if !ok || string(ev) != raw {
+			t.Errorf("Unexpected event is returned: %#v.", wrapper.Event)
+		}
+
+		_, err = EventToInput(wrapper.Event)
+		if err != ErrNonSupportedEvent {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("Malformed payload", func(t *testing.T) {
+		_, err := decodeEventsAPIPayload(json.RawMessage(`{"event": "invalid"}`))
+
+		var target *event.MalformedPayloadError
+		if !errors.As(err, &target) {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func Test_webAPIClient_OpenSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps.connections.open":
+			if r.Header.Get("Authorization") != "Bearer xapp-token" {
+				t.Errorf("App-level token is not given: %s.", r.Header.Get("Authorization"))
+			}
+			wsURL := "ws" + strings.TrimPrefix("http://"+r.Host, "http") + "/socket"
+			_, _ = w.Write([]byte(`{"ok": true, "url": "` + wsURL + `"}`))
+
+		case "/socket":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("Failed to upgrade: %s.", err.Error())
+				return
+			}
+			defer conn.Close()
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "hello"}`))
+			_, b, _ := conn.ReadMessage()
+			_ = conn.WriteMessage(websocket.TextMessage, b)
+
+		default:
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+
+		}
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("xapp-token", time.Second)
+	client.endpoint = server.URL + "/"
+	conn, err := client.OpenSocket(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	defer conn.Close()
+
+	b, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(b) != `{"type": "hello"}` {
+		t.Errorf("Unexpected message is read: %s.", string(b))
+	}
+
+	err = conn.WriteMessage([]byte(`{"envelope_id":"env"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	b, err = conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(b) != `{"envelope_id":"env"}` {
+		t.Errorf("Unexpected message is echoed: %s.", string(b))
+	}
+}
+
+func Test_webAPIClient_OpenSocket_Error(t *testing.T) {
+	t.Run("No app-level token", func(t *testing.T) {
+		client := newWebAPIClient("", time.Second)
+
+		_, err := client.OpenSocket(context.TODO())
+
+		if err != ErrAppTokenRequired {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("Error response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+		}))
+		defer server.Close()
+
+		client := newWebAPIClient("xapp-token", time.Second)
+		client.endpoint = server.URL + "/"
+		_, err := client.OpenSocket(context.TODO())
+
+		if err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
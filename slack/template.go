@@ -0,0 +1,40 @@
+package slack
+
+import (
+	"strings"
+)
+
+// MaxMessageLength is the maximum number of characters chat.postMessage accepts as a message text.
+// A longer text is truncated by Slack.
+const MaxMessageLength = 40000
+
+// TemplateFormatter renders Slack's notations for the template functions provided by templates package.
+// This satisfies templates.Formatter.
+//
+//  set, err := templates.NewSet(slack.SLACK, config, templates.WithFormatter(slack.NewTemplateFormatter()))
+type TemplateFormatter struct{}
+
+// NewTemplateFormatter creates and returns a new TemplateFormatter.
+func NewTemplateFormatter() *TemplateFormatter {
+	return &TemplateFormatter{}
+}
+
+// Mention returns Slack's mention notation such as <@U024BE7LH>.
+func (f *TemplateFormatter) Mention(userID string) string {
+	return "<@" + userID + ">"
+}
+
+// Code returns the given text surrounded with backquotes.
+func (f *TemplateFormatter) Code(text string) string {
+	return "`" + text + "`"
+}
+
+// CodeBlock returns the given text surrounded with triple backquotes.
+func (f *TemplateFormatter) CodeBlock(text string) string {
+	return "```\n" + strings.TrimSuffix(text, "\n") + "\n```"
+}
+
+// MaxLength returns MaxMessageLength.
+func (f *TemplateFormatter) MaxLength() int {
+	return MaxMessageLength
+}
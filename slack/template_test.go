@@ -0,0 +1,25 @@
+package slack
+
+import (
+	"testing"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	formatter := NewTemplateFormatter()
+
+	if mention := formatter.Mention("U024BE7LH"); mention != "<@U024BE7LH>" {
+		t.Errorf("Unexpected mention is returned: %s.", mention)
+	}
+
+	if code := formatter.Code("go test"); code != "`go test`" {
+		t.Errorf("Unexpected code is returned: %s.", code)
+	}
+
+	if block := formatter.CodeBlock("line\n"); block != "```\nline\n```" {
+		t.Errorf("Unexpected code block is returned: %s.", block)
+	}
+
+	if formatter.MaxLength() != MaxMessageLength {
+		t.Errorf("Unexpected max length is returned: %d.", formatter.MaxLength())
+	}
+}
@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+)
+
+// AuthTester defines an interface that checks the validity of the token.
+// When the SlackClient given to WithSlackClient satisfies this interface, Adapter uses it to verify its settings.
+// Otherwise, Adapter calls auth.test method with Config.Token by itself.
+type AuthTester interface {
+	TestAuth(ctx context.Context) error
+}
+
+// WithAuthTester creates an AdapterOption that sets the AuthTester to verify the token on sarah.Verify().
+func WithAuthTester(tester AuthTester) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.authTester = tester
+	}
+}
+
+var _ AuthTester = (*webAPIClient)(nil)
+
+// TestAuth calls auth.test method and returns an error when the token is invalid or Slack is not reachable.
+func (c *webAPIClient) TestAuth(ctx context.Context) error {
+	response := &webAPIResponse{}
+	err := c.call(ctx, "auth.test", nil, response)
+	if err != nil {
+		return err
+	}
+	if !response.OK {
+		return fmt.Errorf("failed to test auth: %s", response.Error)
+	}
+	return nil
+}
+
+var _ sarah.Verifier = (*Adapter)(nil)
+
+// Verify checks the bot token with auth.test method.
+// This satisfies sarah.Verifier so sarah.Verify() can report an invalid token before sarah.Run().
+func (adapter *Adapter) Verify(ctx context.Context) error {
+	return adapter.authTester.TestAuth(ctx)
+}
@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type DummyAuthTester struct {
+	TestAuthFunc func(context.Context) error
+}
+
+var _ AuthTester = (*DummyAuthTester)(nil)
+
+func (t *DummyAuthTester) TestAuth(ctx context.Context) error {
+	return t.TestAuthFunc(ctx)
+}
+
+func TestWithAuthTester(t *testing.T) {
+	tester := &DummyAuthTester{}
+	adapter := &Adapter{}
+
+	WithAuthTester(tester)(adapter)
+
+	if adapter.authTester != tester {
+		t.Error("Given AuthTester is not set.")
+	}
+}
+
+func Test_webAPIClient_TestAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		hasErr   bool
+	}{
+		{
+			name:     "Valid token",
+			response: `{"ok": true, "user_id": "U123"}`,
+			hasErr:   false,
+		},
+		{
+			name:     "Invalid token",
+			response: `{"ok": false, "error": "invalid_auth"}`,
+			hasErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/auth.test" {
+					t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+				}
+				if r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("Unexpected authorization header is given: %s.", r.Header.Get("Authorization"))
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := newWebAPIClient("token", time.Second)
+			client.endpoint = server.URL + "/"
+			err := client.TestAuth(context.TODO())
+
+			if tt.hasErr && err == nil {
+				t.Error("Expected error is not returned.")
+			}
+			if !tt.hasErr && err != nil {
+				t.Errorf("Unexpected error is returned: %s.", err.Error())
+			}
+		})
+	}
+}
+
+func TestAdapter_Verify(t *testing.T) {
+	expected := errors.New("invalid_auth")
+	adapter := &Adapter{
+		authTester: &DummyAuthTester{
+			TestAuthFunc: func(_ context.Context) error {
+				return expected
+			},
+		},
+	}
+
+	err := adapter.Verify(context.TODO())
+
+	if err != expected {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
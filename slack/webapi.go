@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/adapterkit"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WebAPIEndpoint is the base URL of Slack's Web API.
+const WebAPIEndpoint = "https://slack.com/api/"
+
+// webAPIClient calls Slack's Web API methods that golack does not cover such as file uploads and reactions.
+// A request rejected by the rate limit is retried after the duration that Slack tells with Retry-After header.
+type webAPIClient struct {
+	token      string
+	endpoint   string
+	httpClient *adapterkit.HTTPClient
+}
+
+var _ FileUploader = (*webAPIClient)(nil)
+
+var _ Reactor = (*webAPIClient)(nil)
+
+var _ BlockPoster = (*webAPIClient)(nil)
+
+var _ MessageModifier = (*webAPIClient)(nil)
+
+var _ EphemeralPoster = (*webAPIClient)(nil)
+
+func newWebAPIClient(token string, timeout time.Duration) *webAPIClient {
+	return &webAPIClient{
+		token:    token,
+		endpoint: WebAPIEndpoint,
+		httpClient: adapterkit.NewHTTPClient(
+			adapterkit.WithBaseClient(&http.Client{}),
+			adapterkit.WithTimeout(timeout),
+			adapterkit.WithSpanName("slack.web_api"),
+		),
+	}
+}
+
+// webAPIResponse is the common part of the Web API's responses.
+type webAPIResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (c *webAPIClient) call(ctx context.Context, method string, params url.Values, response interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", method, err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code on %s: %d", method, resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(response)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+
+	return nil
+}
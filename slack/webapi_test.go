@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_newWebAPIClient(t *testing.T) {
+	client := newWebAPIClient("token", 3*time.Second)
+
+	if client.token != "token" {
+		t.Errorf("Unexpected token is set: %s.", client.token)
+	}
+
+	if client.endpoint != WebAPIEndpoint {
+		t.Errorf("Unexpected endpoint is set: %s.", client.endpoint)
+	}
+
+	if client.httpClient == nil {
+		t.Error("HTTP client is not set.")
+	}
+}
+
+func Test_webAPIClient_call(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		err    bool
+	}{
+		{
+			status: http.StatusOK,
+			body:   `{"ok": true}`,
+			err:    false,
+		},
+		{
+			status: http.StatusInternalServerError,
+			body:   "",
+			err:    true,
+		},
+		{
+			status: http.StatusOK,
+			body:   "invalid",
+			err:    true,
+		},
+	}
+
+	for i, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api.test" {
+				t.Errorf("Unexpected path is requested on test #%d: %s.", i, r.URL.Path)
+			}
+			w.WriteHeader(tt.status)
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		client := newWebAPIClient("token", time.Second)
+		client.endpoint = server.URL + "/"
+		response := &webAPIResponse{}
+		err := client.call(context.TODO(), "api.test", url.Values{}, response)
+		server.Close()
+
+		if tt.err {
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+
+		if !response.OK {
+			t.Errorf("Response is not decoded on test #%d.", i)
+		}
+	}
+}
+
+func Test_webAPIClient_call_RateLimited(t *testing.T) {
+	requested := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested++
+		if requested == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	client := newWebAPIClient("token", time.Second)
+	client.endpoint = server.URL + "/"
+	response := &webAPIResponse{}
+	err := client.call(context.TODO(), "api.test", url.Values{}, response)
+
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if requested != 2 || !response.OK {
+		t.Errorf("Rate limited request is not retried: %d.", requested)
+	}
+}
@@ -0,0 +1,163 @@
+package sarah
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MessageLengthLimiter defines an optional interface that an Adapter may implement to tell the maximum length of a message.
+// The Bot created by NewBot splits a longer text message into multiple messages with SplitMessage before passing them to Adapter.SendMessage.
+type MessageLengthLimiter interface {
+	// MaxMessageLength returns the maximum number of characters a message can contain.
+	MaxMessageLength() int
+}
+
+// codeFence is the markdown notation that starts and ends a code block.
+const codeFence = "```"
+
+// SplitMessage splits the given text into chunks so that each chunk contains up to the given number of characters.
+// The text is split at line breaks where possible, and at whitespaces or at the limit when a line is too long.
+// A code block split into multiple chunks is closed at the end of a chunk and reopened at the beginning of the next chunk,
+// and each chunk is annotated with its position such as "(1/3)".
+//
+// The text is returned as-is when the limit is zero or less, or when the text is short enough.
+func SplitMessage(text string, limit int) []string {
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	// Reserve room for the annotation. The number of chunks is unknown until the text is split,
+	// so split again with a larger reservation when the number of digits is underestimated.
+	total := 9
+	for {
+		reserved := utf8.RuneCountInString(annotation(total, total))
+		if limit-reserved < minChunkLength {
+			// The limit is too small to annotate.
+			return splitChunks(text, limit)
+		}
+
+		chunks := splitChunks(text, limit-reserved)
+		if len(chunks) > total {
+			total = total*10 + 9
+			continue
+		}
+
+		for i := range chunks {
+			chunks[i] += annotation(i+1, len(chunks))
+		}
+		return chunks
+	}
+}
+
+// minChunkLength is the minimum length of a chunk to annotate and to handle code blocks.
+const minChunkLength = 16
+
+func annotation(i, total int) string {
+	return fmt.Sprintf("\n(%d/%d)", i, total)
+}
+
+func splitChunks(text string, limit int) []string {
+	handleFence := strings.Contains(text, codeFence) && limit >= minChunkLength
+	available := limit
+	if handleFence {
+		// Reserve room to close the code block.
+		available -= utf8.RuneCountInString("\n" + codeFence)
+	}
+
+	var chunks []string
+	var lines []string // Lines of the current chunk
+	length := 0        // Number of characters in lines
+	prefix := 0        // Number of lines that reopen the code block
+	fence := ""        // The line that opened the current code block
+	openedAt := -1     // Index of the line in lines that opened the current code block
+
+	flush := func() {
+		closing := fence != ""
+		if closing && openedAt == len(lines)-1 && openedAt >= prefix {
+			// The code block is opened at the end of the chunk. Move the opening line to the next chunk.
+			lines = lines[:openedAt]
+			closing = false
+		}
+
+		chunk := strings.TrimRightFunc(strings.Join(lines, ""), unicode.IsSpace)
+		if closing {
+			chunk += "\n" + codeFence
+		}
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+
+		lines = nil
+		length = 0
+		prefix = 0
+		openedAt = -1
+		if fence != "" {
+			// Reopen the code block with the same language notation.
+			lines = append(lines, fence+"\n")
+			length = utf8.RuneCountInString(fence) + 1
+			prefix = 1
+			openedAt = 0
+		}
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		lineLength := utf8.RuneCountInString(line)
+		if length+lineLength > available && len(lines) > prefix {
+			flush()
+		}
+
+		for length+lineLength > available {
+			// The line is too long. Split at a whitespace if possible.
+			room := available - length
+			if room < 1 {
+				room = 1
+			}
+			runes := []rune(line)
+			cut := room
+			// A whitespace right after the room can be the separator since trailing whitespaces are trimmed.
+			if i := lastSpace(runes[:room+1]); i > 0 {
+				cut = i + 1
+			}
+			lines = append(lines, string(runes[:cut]))
+			line = string(runes[cut:])
+			lineLength -= cut
+			flush()
+		}
+
+		lines = append(lines, line)
+		length += lineLength
+
+		if handleFence {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, codeFence) {
+				if fence == "" {
+					fence = trimmed
+					openedAt = len(lines) - 1
+				} else {
+					fence = ""
+					openedAt = -1
+				}
+			}
+		}
+	}
+
+	if len(lines) > prefix {
+		chunk := strings.TrimRightFunc(strings.Join(lines, ""), unicode.IsSpace)
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	return chunks
+}
+
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,110 @@
+package sarah
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		text     string
+		limit    int
+		expected []string
+	}{
+		{
+			text:     "short",
+			limit:    0,
+			expected: []string{"short"},
+		},
+		{
+			text:     "short",
+			limit:    10,
+			expected: []string{"short"},
+		},
+		{
+			text:  "first line\nsecond line\nthird line",
+			limit: 30,
+			expected: []string{
+				"first line\nsecond line\n(1/2)",
+				"third line\n(2/2)",
+			},
+		},
+		{
+			text:  "Result:\n```go\nfmt.Println(1)\nfmt.Println(2)\n```\ndone",
+			limit: 36,
+			expected: []string{
+				"Result:\n(1/4)",
+				"```go\nfmt.Println(1)\n```\n(2/4)",
+				"```go\nfmt.Println(2)\n```\n(3/4)",
+				"done\n(4/4)",
+			},
+		},
+		{
+			text:  "one two three four five six seven eight nine ten",
+			limit: 24,
+			expected: []string{
+				"one two three four\n(1/3)",
+				"five six seven\n(2/3)",
+				"eight nine ten\n(3/3)",
+			},
+		},
+		{
+			text:     "abcdefghij",
+			limit:    4,
+			expected: []string{"abcd", "efgh", "ij"},
+		},
+	}
+
+	for i, tt := range tests {
+		chunks := SplitMessage(tt.text, tt.limit)
+
+		if len(chunks) != len(tt.expected) {
+			t.Errorf("Unexpected number of chunks are returned on test #%d: %#v.", i, chunks)
+			continue
+		}
+
+		for j, chunk := range chunks {
+			if chunk != tt.expected[j] {
+				t.Errorf("Unexpected chunk is returned on test #%d: %q.", i, chunk)
+			}
+
+			if tt.limit > 0 && utf8.RuneCountInString(chunk) > tt.limit {
+				t.Errorf("Chunk exceeds the limit on test #%d: %q.", i, chunk)
+			}
+		}
+	}
+}
+
+func TestSplitMessage_ManyChunks(t *testing.T) {
+	text := strings.Repeat("line\n", 100)
+
+	chunks := SplitMessage(text, 30)
+
+	for i, chunk := range chunks {
+		if utf8.RuneCountInString(chunk) > 30 {
+			t.Errorf("Chunk #%d exceeds the limit: %q.", i, chunk)
+		}
+	}
+
+	// Two-digit annotation is reserved once the number of chunks exceeds nine.
+	if len(chunks) < 10 {
+		t.Fatalf("Unexpected number of chunks are returned: %d.", len(chunks))
+	}
+
+	last := fmt.Sprintf("(%d/%d)", len(chunks), len(chunks))
+	if !strings.HasSuffix(chunks[len(chunks)-1], last) {
+		t.Errorf("Unexpected annotation is given: %q.", chunks[len(chunks)-1])
+	}
+}
+
+type DummyMessageLengthLimiter struct {
+	MaxMessageLengthValue int
+}
+
+var _ MessageLengthLimiter = (*DummyMessageLengthLimiter)(nil)
+
+func (l *DummyMessageLengthLimiter) MaxMessageLength() int {
+	return l.MaxMessageLengthValue
+}
@@ -0,0 +1,451 @@
+/*
+Package standup provides a reference plugin that runs a recurring questionnaire such as a daily standup meeting.
+
+On the configured schedule, Standup sends a direct message to each participant to start a round.
+A participant replies .standup to the Bot, and then answers the questions one by one.
+The answers are collected with the conversational context, sarah.UserContext, so the participant simply types the answer for each question.
+When all participants answer or Config.Deadline passes, the compiled summary is posted to the configured destinations.
+
+	config := standup.NewConfig()
+	config.TaskSchedule = "CRON_TZ=Asia/Tokyo 0 10 * * 1-5"
+	config.Participants = []string{"U12345678", "U87654321"}
+	config.Destinations = []string{"C12345678"}
+	s, err := standup.New(slack.SLACK, "standup", config, standup.WithDestinationFunc(func(dest string) sarah.OutputDestination {
+		return event.ChannelID(dest)
+	}))
+	if err != nil {
+		panic(err)
+	}
+	s.Register()
+
+A participant is identified by sarah.SenderIDInput, e.g. the user ID for Slack, so Config.Participants must list such identifiers.
+The identifiers are also the destinations of the direct messages.
+Since the answers are collected in the conversational context, the Bot must be set up with sarah.UserContextStorage;
+a participant who leaves the conversation longer than its expiration starts over with .standup.
+
+The rounds are kept in memory, so the round in progress is lost when the process restarts. Only one Standup can be registered for each Bot.
+*/
+package standup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var matchPattern = regexp.MustCompile(`^\.standup(\s|$)`)
+
+// These are variables so tests can replace them.
+var (
+	addScheduledTask    = sarah.AddScheduledTask
+	removeScheduledTask = sarah.RemoveScheduledTask
+	sendMessage         = sarah.SendMessage
+)
+
+// Config contains some configuration variables for Standup.
+type Config struct {
+	// TaskSchedule is the schedule to start a round in a form of cron spec such as "0 10 * * 1-5".
+	TaskSchedule string `json:"schedule" yaml:"schedule"`
+
+	// Participants are the identifiers of the users to ask. See sarah.SenderIDInput.
+	Participants []string `json:"participants" yaml:"participants"`
+
+	// Questions are asked in this order.
+	Questions []string `json:"questions" yaml:"questions"`
+
+	// Destinations are where the summary is posted.
+	Destinations []string `json:"destinations" yaml:"destinations"`
+
+	// Deadline is the duration from the start of a round til the summary is posted regardless of the missing answers.
+	Deadline time.Duration `json:"deadline" yaml:"deadline"`
+}
+
+var _ sarah.ScheduledConfig = (*Config)(nil)
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		TaskSchedule: "0 10 * * 1-5",
+		Participants: []string{},
+		Questions: []string{
+			"What did you do yesterday?",
+			"What will you do today?",
+			"Is anything blocking you?",
+		},
+		Destinations: []string{},
+		Deadline:     2 * time.Hour,
+	}
+}
+
+// Schedule returns the schedule to start a round.
+func (c *Config) Schedule() string {
+	return c.TaskSchedule
+}
+
+// ApplyDefaults sets the default values to Questions and Deadline when they are not given.
+func (c *Config) ApplyDefaults() {
+	defaults := NewConfig()
+
+	if len(c.Questions) == 0 {
+		c.Questions = defaults.Questions
+	}
+
+	if c.Deadline == 0 {
+		c.Deadline = defaults.Deadline
+	}
+}
+
+// Validate checks that the schedule, the participants, and the destinations are given.
+func (c *Config) Validate() error {
+	var errs sarah.ConfigKeyErrors
+
+	if c.TaskSchedule == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "schedule", Err: errors.New("schedule is empty")})
+	}
+
+	if len(c.Participants) == 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "participants", Err: errors.New("participants are empty")})
+	}
+
+	if len(c.Destinations) == 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "destinations", Err: errors.New("destinations are empty")})
+	}
+
+	if c.Deadline < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "deadline", Value: c.Deadline.String(), Err: errors.New("deadline must not be negative")})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Option defines function signature that New's functional option must satisfy.
+type Option func(*Standup)
+
+// WithDestinationFunc creates an Option that converts a participant or a destination in Config to sarah.OutputDestination the Bot's Adapter accepts.
+// By default, the string is used as is. Slack, for example, requires event.ChannelID instead.
+func WithDestinationFunc(fnc func(string) sarah.OutputDestination) Option {
+	return func(s *Standup) {
+		s.destination = fnc
+	}
+}
+
+// Standup is a sarah.Command that collects the answers of a round.
+// The ScheduledTaskProps returned by ScheduledTaskProps starts the rounds. Register registers both.
+type Standup struct {
+	botType     sarah.BotType
+	id          string
+	props       *sarah.ScheduledTaskProps
+	destination func(string) sarah.OutputDestination
+	round       *round
+	mutex       sync.Mutex
+}
+
+var _ sarah.Command = (*Standup)(nil)
+
+// round is a questionnaire started on the schedule.
+// The configuration values are copied on the start, so a configuration update does not affect the round in progress.
+type round struct {
+	startedAt    time.Time
+	participants []string
+	questions    []string
+	destinations []string
+	answers      map[string][]string
+	names        map[string]string
+}
+
+// New creates and returns a new Standup for the Bot with the given BotType.
+// The given id is the identifier of both the Command and the ScheduledTask, and the name of the configuration file to reload Config.
+func New(botType sarah.BotType, id string, config *Config, options ...Option) (*Standup, error) {
+	err := sarah.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Standup{
+		botType: botType,
+		id:      id,
+		destination: func(dest string) sarah.OutputDestination {
+			return dest
+		},
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	s.props, err = sarah.NewScheduledTaskPropsBuilder().
+		BotType(botType).
+		Identifier(id).
+		ConfigurableFunc(config, func(ctx context.Context, c sarah.TaskConfig) ([]*sarah.ScheduledTaskResult, error) {
+			return s.start(ctx, c.(*Config)), nil
+		}).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Register registers the Command and the ScheduledTaskProps for the Bot.
+// Call this before sarah.Run.
+func (s *Standup) Register() {
+	sarah.RegisterCommand(s.botType, s)
+	sarah.RegisterScheduledTaskProps(s.props)
+}
+
+// ScheduledTaskProps returns the sarah.ScheduledTaskProps that starts a round on the configured schedule.
+func (s *Standup) ScheduledTaskProps() *sarah.ScheduledTaskProps {
+	return s.props
+}
+
+// Identifier returns the command ID.
+func (s *Standup) Identifier() string {
+	return s.id
+}
+
+// Instruction provides the input instruction.
+func (s *Standup) Instruction(_ *sarah.HelpInput) string {
+	return ".standup to answer the standup questions while a round is open"
+}
+
+// Match checks if the input is a standup command.
+func (s *Standup) Match(input sarah.Input) bool {
+	return matchPattern.Copy().MatchString(input.Message())
+}
+
+// Execute asks the first question of the open round and continues the conversation to collect the answers.
+func (s *Standup) Execute(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	s.mutex.Lock()
+	r := s.round
+	s.mutex.Unlock()
+
+	if r == nil {
+		return respond("No standup is open now."), nil
+	}
+
+	participant := participantID(input)
+	if !contains(r.participants, participant) {
+		return respond("You are not a participant of this standup."), nil
+	}
+
+	return s.ask(r, nil), nil
+}
+
+func respond(text string) *sarah.CommandResponse {
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}
+}
+
+// ask returns the next question with the conversational context that receives the answer.
+func (s *Standup) ask(r *round, answers []string) *sarah.CommandResponse {
+	i := len(answers)
+	question := fmt.Sprintf("(%d/%d) %s", i+1, len(r.questions), r.questions[i])
+	if i == 0 {
+		question += "\nInput .cancel to stop answering."
+	}
+
+	return &sarah.CommandResponse{
+		Content: question,
+		UserContext: sarah.NewUserContext(func(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+			answer := strings.TrimSpace(input.Message())
+			if answer == ".cancel" {
+				return respond("Canceled. Input .standup to start over."), nil
+			}
+
+			// Copy so the answers of a previous step are not shared with another branch of the conversation.
+			next := append(append([]string{}, answers...), answer)
+			if len(next) < len(r.questions) {
+				return s.ask(r, next), nil
+			}
+
+			return respond(s.record(ctx, r, input, next)), nil
+		}),
+	}
+}
+
+// record stores the answers of the participant and posts the summary when all participants have answered.
+func (s *Standup) record(ctx context.Context, r *round, input sarah.Input, answers []string) string {
+	participant := participantID(input)
+
+	s.mutex.Lock()
+	if s.round != r {
+		s.mutex.Unlock()
+		return "This standup is already closed."
+	}
+	r.answers[participant] = answers
+	if named, ok := input.(sarah.SenderDisplayNameInput); ok {
+		r.names[participant] = named.SenderDisplayName()
+	}
+	complete := len(r.answers) == len(r.participants)
+	if complete {
+		s.round = nil
+	}
+	s.mutex.Unlock()
+
+	if !complete {
+		return "Thanks! The summary is posted when everyone answers."
+	}
+
+	err := removeScheduledTask(s.botType, s.summaryTaskID())
+	if err != nil && !errors.Is(err, sarah.ErrScheduledTaskNotFound) {
+		s.logger(ctx).Error("Failed to unschedule standup summary", logging.Err(err))
+	}
+
+	for _, res := range s.summarize(r) {
+		err := sendMessage(s.botType, sarah.NewOutputMessage(res.Destination, res.Content))
+		if err != nil {
+			s.logger(ctx).Error("Failed to post standup summary", logging.F(logging.KeyDestination, res.Destination), logging.Err(err))
+		}
+	}
+	return "Thanks! Everyone has answered, so the summary is posted."
+}
+
+// start starts a new round and returns the messages to the participants.
+// The round in progress, if any, is closed and its summary is posted first.
+func (s *Standup) start(ctx context.Context, config *Config) []*sarah.ScheduledTaskResult {
+	now := clock.FromContext(ctx).Now()
+	r := &round{
+		startedAt:    now,
+		participants: append([]string{}, config.Participants...),
+		questions:    append([]string{}, config.Questions...),
+		destinations: append([]string{}, config.Destinations...),
+		answers:      map[string][]string{},
+		names:        map[string]string{},
+	}
+
+	s.mutex.Lock()
+	previous := s.round
+	s.round = r
+	s.mutex.Unlock()
+
+	var results []*sarah.ScheduledTaskResult
+	if previous != nil {
+		results = append(results, s.summarize(previous)...)
+	}
+
+	// The summary task of the previous round, if any, is replaced since the identifier is the same.
+	deadline := now.Add(config.Deadline)
+	err := addScheduledTask(s.botType, &summaryTask{standup: s, round: r, at: deadline})
+	if err != nil {
+		s.logger(ctx).Error("Failed to schedule standup summary", logging.F("deadline", deadline), logging.Err(err))
+	}
+
+	text := fmt.Sprintf("It's time for the standup. Input .standup to answer %d questions by %s.", len(r.questions), deadline.Format("15:04 MST"))
+	for _, participant := range r.participants {
+		results = append(results, &sarah.ScheduledTaskResult{
+			Content:     text,
+			Destination: s.destination(participant),
+		})
+	}
+	return results
+}
+
+// summarize returns the summary of the given round for each destination.
+func (s *Standup) summarize(r *round) []*sarah.ScheduledTaskResult {
+	lines := []string{fmt.Sprintf("Standup summary of %s", r.startedAt.Format("2006-01-02"))}
+
+	var missing []string
+	for _, participant := range r.participants {
+		answers, ok := r.answers[participant]
+		if !ok {
+			missing = append(missing, participant)
+			continue
+		}
+
+		name := participant
+		if n, ok := r.names[participant]; ok && n != "" {
+			name = n
+		}
+		lines = append(lines, "", name)
+		for i, question := range r.questions {
+			lines = append(lines, fmt.Sprintf("- %s %s", question, answers[i]))
+		}
+	}
+
+	if len(missing) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Not answered: %s", strings.Join(missing, ", ")))
+	}
+
+	text := strings.Join(lines, "\n")
+	var results []*sarah.ScheduledTaskResult
+	for _, dest := range r.destinations {
+		results = append(results, &sarah.ScheduledTaskResult{
+			Content:     text,
+			Destination: s.destination(dest),
+		})
+	}
+	return results
+}
+
+// logger returns the Logger carried by the given context with this package's module name and the fields to identify this Standup.
+func (s *Standup) logger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("standup").With(logging.F(logging.KeyBotType, s.botType), logging.F("standup_id", s.id))
+}
+
+func (s *Standup) summaryTaskID() string {
+	return s.id + "-summary"
+}
+
+// summaryTask is a sarah.ScheduledTask that closes the round and posts the summary on the deadline.
+type summaryTask struct {
+	standup *Standup
+	round   *round
+	at      time.Time
+}
+
+var _ sarah.ScheduledTask = (*summaryTask)(nil)
+
+func (t *summaryTask) Identifier() string {
+	return t.standup.summaryTaskID()
+}
+
+func (t *summaryTask) Execute(_ context.Context) ([]*sarah.ScheduledTaskResult, error) {
+	s := t.standup
+	s.mutex.Lock()
+	if s.round != t.round {
+		// The round is already closed because everyone answered or a new round started.
+		s.mutex.Unlock()
+		return nil, nil
+	}
+	s.round = nil
+	s.mutex.Unlock()
+
+	return s.summarize(t.round), nil
+}
+
+func (t *summaryTask) DefaultDestination() sarah.OutputDestination {
+	return nil
+}
+
+func (t *summaryTask) Schedule() string {
+	return sarah.ScheduleOnceAt(t.at)
+}
+
+// participantID returns the identifier of the sender to compare with Config.Participants.
+func participantID(input sarah.Input) string {
+	if i, ok := input.(sarah.SenderIDInput); ok {
+		return i.SenderID()
+	}
+	return input.SenderKey()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
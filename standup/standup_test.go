@@ -0,0 +1,374 @@
+package standup
+
+import (
+	"bytes"
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/clock"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderIDValue   string
+	SenderNameValue string
+	MessageValue    string
+}
+
+var _ sarah.SenderIDInput = (*DummyInput)(nil)
+var _ sarah.SenderDisplayNameInput = (*DummyInput)(nil)
+
+func (i *DummyInput) SenderKey() string {
+	return "channel|" + i.SenderIDValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return time.Now()
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return "dummy"
+}
+
+func (i *DummyInput) SenderID() string {
+	return i.SenderIDValue
+}
+
+func (i *DummyInput) SenderDisplayName() string {
+	return i.SenderNameValue
+}
+
+type stub struct {
+	tasks   map[string]sarah.ScheduledTask
+	removed []string
+	sent    []sarah.Output
+}
+
+// stubRuntime replaces the functions to control the scheduler and to send messages.
+// This returns the recorded calls and a function to restore the replaced functions.
+func stubRuntime(t *testing.T) (*stub, func()) {
+	s := &stub{tasks: map[string]sarah.ScheduledTask{}}
+	addScheduledTask = func(botType sarah.BotType, task sarah.ScheduledTask) error {
+		if botType != "dummy" {
+			t.Errorf("Unexpected BotType is given: %s.", botType)
+		}
+		s.tasks[task.Identifier()] = task
+		return nil
+	}
+	removeScheduledTask = func(_ sarah.BotType, id string) error {
+		s.removed = append(s.removed, id)
+		delete(s.tasks, id)
+		return nil
+	}
+	sendMessage = func(_ sarah.BotType, output sarah.Output) error {
+		s.sent = append(s.sent, output)
+		return nil
+	}
+	return s, func() {
+		addScheduledTask = sarah.AddScheduledTask
+		removeScheduledTask = sarah.RemoveScheduledTask
+		sendMessage = sarah.SendMessage
+	}
+}
+
+func newTestConfig() *Config {
+	config := NewConfig()
+	config.Participants = []string{"alice", "bob"}
+	config.Questions = []string{"Yesterday?", "Today?"}
+	config.Destinations = []string{"general"}
+	config.Deadline = time.Hour
+	return config
+}
+
+func newTestStandup(t *testing.T) *Standup {
+	s, err := New("dummy", "standup", newTestConfig(), WithDestinationFunc(func(dest string) sarah.OutputDestination {
+		return "#" + dest
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	return s
+}
+
+func testContext() context.Context {
+	now := time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)
+	return clock.WithContext(context.Background(), clock.NewFake(now))
+}
+
+// answer starts the conversation and answers all questions. This returns the response to the last answer.
+func answer(t *testing.T, s *Standup, input *DummyInput, answers ...string) string {
+	input.MessageValue = ".standup"
+	res, err := s.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	for _, a := range answers {
+		if res.UserContext == nil {
+			t.Fatalf("Conversation is not continued: %v.", res.Content)
+		}
+		input.MessageValue = a
+		res, err = res.UserContext.Next(context.TODO(), input)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	if res.UserContext != nil {
+		t.Errorf("Conversation must end after the last answer: %v.", res.Content)
+	}
+	return res.Content.(string)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	config := &Config{Deadline: -1}
+
+	err := config.Validate()
+	errs, ok := err.(sarah.ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+	if len(errs) != 4 {
+		t.Errorf("Unexpected errors are returned: %s.", errs.Error())
+	}
+
+	err = newTestConfig().Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("dummy", "standup", NewConfig())
+	if err == nil {
+		t.Error("Expected error is not returned for config without participants.")
+	}
+
+	s := newTestStandup(t)
+	if s.Identifier() != "standup" {
+		t.Errorf("Unexpected identifier is returned: %s.", s.Identifier())
+	}
+	if s.ScheduledTaskProps() == nil {
+		t.Error("ScheduledTaskProps is not built.")
+	}
+}
+
+func TestStandup_Match(t *testing.T) {
+	s := newTestStandup(t)
+
+	tests := []struct {
+		message  string
+		expected bool
+	}{
+		{message: ".standup", expected: true},
+		{message: ".standup now", expected: true},
+		{message: ".standups", expected: false},
+		{message: "standup", expected: false},
+	}
+
+	for _, tt := range tests {
+		if s.Match(&DummyInput{MessageValue: tt.message}) != tt.expected {
+			t.Errorf("Unexpected result for %s.", tt.message)
+		}
+	}
+}
+
+func TestStandup_Execute_NotOpen(t *testing.T) {
+	s := newTestStandup(t)
+
+	res, err := s.Execute(context.TODO(), &DummyInput{SenderIDValue: "alice", MessageValue: ".standup"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if res.Content != "No standup is open now." || res.UserContext != nil {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+}
+
+func TestStandup_Round(t *testing.T) {
+	stubbed, reset := stubRuntime(t)
+	defer reset()
+
+	s := newTestStandup(t)
+	results := s.start(testContext(), newTestConfig())
+
+	if len(results) != 2 {
+		t.Fatalf("Unexpected number of results is returned: %d.", len(results))
+	}
+	for i, participant := range []string{"#alice", "#bob"} {
+		if results[i].Destination != participant {
+			t.Errorf("Unexpected destination is given: %s.", results[i].Destination)
+		}
+		if !strings.Contains(results[i].Content.(string), "11:00 UTC") {
+			t.Errorf("Deadline is not told: %s.", results[i].Content)
+		}
+	}
+
+	task, ok := stubbed.tasks["standup-summary"]
+	if !ok {
+		t.Fatalf("Summary task is not scheduled: %#v.", stubbed.tasks)
+	}
+	if task.Schedule() != sarah.ScheduleOnceAt(time.Date(2020, 1, 2, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected schedule is set: %s.", task.Schedule())
+	}
+
+	res, err := s.Execute(context.TODO(), &DummyInput{SenderIDValue: "carol", MessageValue: ".standup"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if res.Content != "You are not a participant of this standup." {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+
+	text := answer(t, s, &DummyInput{SenderIDValue: "alice", SenderNameValue: "Alice"}, "Coding", "Reviewing")
+	if !strings.HasPrefix(text, "Thanks!") || len(stubbed.sent) != 0 {
+		t.Errorf("Summary must wait for everyone: %s.", text)
+	}
+
+	// Answering again replaces the previous answers.
+	answer(t, s, &DummyInput{SenderIDValue: "alice", SenderNameValue: "Alice"}, "Coding", "Testing")
+	answer(t, s, &DummyInput{SenderIDValue: "bob"}, "Meeting", "Writing")
+
+	if len(stubbed.removed) != 1 || stubbed.removed[0] != "standup-summary" {
+		t.Errorf("Summary task is not unscheduled: %#v.", stubbed.removed)
+	}
+	if len(stubbed.sent) != 1 {
+		t.Fatalf("Summary is not sent: %#v.", stubbed.sent)
+	}
+
+	expected := strings.Join([]string{
+		"Standup summary of 2020-01-02",
+		"",
+		"Alice",
+		"- Yesterday? Coding",
+		"- Today? Testing",
+		"",
+		"bob",
+		"- Yesterday? Meeting",
+		"- Today? Writing",
+	}, "\n")
+	if stubbed.sent[0].Content() != expected {
+		t.Errorf("Unexpected summary is sent: %s.", stubbed.sent[0].Content())
+	}
+	if stubbed.sent[0].Destination() != "#general" {
+		t.Errorf("Unexpected destination is given: %s.", stubbed.sent[0].Destination())
+	}
+
+	res, err = s.Execute(context.TODO(), &DummyInput{SenderIDValue: "alice", MessageValue: ".standup"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if res.Content != "No standup is open now." {
+		t.Errorf("Round must be closed: %v.", res.Content)
+	}
+}
+
+func TestStandup_ScheduleError(t *testing.T) {
+	_, reset := stubRuntime(t)
+	defer reset()
+	addScheduledTask = func(_ sarah.BotType, _ sarah.ScheduledTask) error {
+		return sarah.ErrBotNotRunning
+	}
+
+	buf := &bytes.Buffer{}
+	ctx := logging.NewContext(testContext(), logging.NewLogger(logging.NewJSONHandler(buf)))
+	s := newTestStandup(t)
+	results := s.start(ctx, newTestConfig())
+	if len(results) != 2 {
+		t.Errorf("Participants must be asked even when the summary is not scheduled: %d.", len(results))
+	}
+
+	for _, expected := range []string{`"module":"standup"`, `"bot_type":"dummy"`, `"standup_id":"standup"`, `"message":"Failed to schedule standup summary"`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %s is not logged: %s.", expected, buf.String())
+		}
+	}
+}
+
+func TestStandup_Deadline(t *testing.T) {
+	stubbed, reset := stubRuntime(t)
+	defer reset()
+
+	s := newTestStandup(t)
+	_ = s.start(testContext(), newTestConfig())
+
+	input := &DummyInput{SenderIDValue: "alice", MessageValue: ".standup"}
+	res, err := s.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	results, err := stubbed.tasks["standup-summary"].Execute(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(results) != 1 || !strings.HasSuffix(results[0].Content.(string), "Not answered: alice, bob") {
+		t.Errorf("Unexpected summary is returned: %#v.", results)
+	}
+
+	// An answer after the deadline is not recorded.
+	input.MessageValue = "Coding"
+	res, err = res.UserContext.Next(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	input.MessageValue = "Reviewing"
+	res, err = res.UserContext.Next(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if res.Content != "This standup is already closed." {
+		t.Errorf("Unexpected response is returned: %v.", res.Content)
+	}
+
+	// The summary task of a closed round posts nothing.
+	results, err = stubbed.tasks["standup-summary"].Execute(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if len(results) != 0 {
+		t.Errorf("Closed round must not be summarized again: %#v.", results)
+	}
+}
+
+func TestStandup_StartWhileOpen(t *testing.T) {
+	_, reset := stubRuntime(t)
+	defer reset()
+
+	s := newTestStandup(t)
+	_ = s.start(testContext(), newTestConfig())
+	answer(t, s, &DummyInput{SenderIDValue: "alice"}, "Coding", "Reviewing")
+
+	results := s.start(testContext(), newTestConfig())
+	if len(results) != 3 {
+		t.Fatalf("Unexpected number of results is returned: %d.", len(results))
+	}
+	summary := results[0].Content.(string)
+	if !strings.Contains(summary, "- Today? Reviewing") || !strings.HasSuffix(summary, "Not answered: bob") {
+		t.Errorf("Previous round is not summarized: %s.", summary)
+	}
+}
+
+func TestStandup_Cancel(t *testing.T) {
+	_, reset := stubRuntime(t)
+	defer reset()
+
+	s := newTestStandup(t)
+	_ = s.start(testContext(), newTestConfig())
+
+	text := answer(t, s, &DummyInput{SenderIDValue: "alice"}, ".cancel")
+	if !strings.HasPrefix(text, "Canceled.") {
+		t.Errorf("Unexpected response is returned: %s.", text)
+	}
+
+	summary := s.summarize(s.round)[0].Content.(string)
+	if !strings.HasSuffix(summary, "Not answered: alice, bob") {
+		t.Errorf("Canceled answers must not be recorded: %s.", summary)
+	}
+}
@@ -2,7 +2,7 @@ package sarah
 
 import (
 	"errors"
-	"github.com/oklahomer/go-kasumi/logger"
+	"github.com/oklahomer/go-sarah/v4/logging"
 	"sync"
 )
 
@@ -33,12 +33,28 @@ type Status struct {
 type BotStatus struct {
 	Type    BotType
 	Running bool
+
+	// Paused tells if the Bot is paused by PauseBot.
+	Paused bool
+
+	// InputQueue is the statistics of the Bot's input queue. This is nil when no input queue is configured.
+	InputQueue *InputQueueStats
 }
 
 type status struct {
-	bots     []*botStatus
-	finished chan struct{}
-	mutex    sync.RWMutex
+	bots         []*botStatus
+	finished     chan struct{}
+	healthChecks healthChecks
+	components   managedComponents
+	mutex        sync.RWMutex
+}
+
+// started tells if status.start() is already called.
+func (s *status) started() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.finished != nil
 }
 
 func (s *status) running() bool {
@@ -103,8 +119,10 @@ func (s *status) snapshot() Status {
 	var bots []BotStatus
 	for _, botStatus := range s.bots {
 		bs := BotStatus{
-			Type:    botStatus.botType,
-			Running: botStatus.running(),
+			Type:       botStatus.botType,
+			Running:    botStatus.running(),
+			Paused:     s.components.botPaused(botStatus.botType),
+			InputQueue: s.components.inputQueueStats(botStatus.botType),
 		}
 		bots = append(bots, bs)
 	}
@@ -121,7 +139,7 @@ func (s *status) stop() {
 			// Comes here when channel is already closed.
 			// stop() is not expected to be called multiple times,
 			// but recover here to avoid panic.
-			logger.Warn("Multiple status.stop() calls occurred.")
+			moduleLogger().Warn("Multiple status.stop() calls occurred")
 		}
 	}()
 
@@ -151,7 +169,7 @@ func (bs *botStatus) stop() {
 			// Comes here when channel is already closed.
 			// stop() is not expected to be called multiple times,
 			// but recover here to avoid panic.
-			logger.Warnf("Multiple botStatus.stop() calls for %s occurred.", bs.botType)
+			moduleLogger().Warn("Multiple botStatus.stop() calls occurred", logging.F(logging.KeyBotType, bs.botType))
 		}
 	}()
 
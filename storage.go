@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/oklahomer/go-sarah/v4/clock"
 	"github.com/patrickmn/go-cache"
 	"time"
 )
@@ -78,14 +79,41 @@ type UserContextStorage interface {
 // defaultUserContextStorage is the default implementation of UserContexts.
 // This stores user contexts in-memory.
 type defaultUserContextStorage struct {
-	cache *cache.Cache
+	cache     *cache.Cache
+	expiresIn time.Duration
+	clock     clock.Clock
+}
+
+// UserContextStorageOption defines function signature that defaultUserContextStorage's functional option must satisfy.
+type UserContextStorageOption func(*defaultUserContextStorage)
+
+// UserContextStorageWithClock creates a UserContextStorageOption that replaces the clock.Clock to judge the expiration of the stored contexts.
+// Pass clock.Fake in a test to let the stored context expire without waiting for CacheConfig.ExpiresIn.
+func UserContextStorageWithClock(c clock.Clock) UserContextStorageOption {
+	return func(storage *defaultUserContextStorage) {
+		storage.clock = c
+	}
+}
+
+// storedUserContext is the cached form of UserContext with its expiration time given by the storage's clock.Clock.
+type storedUserContext struct {
+	userContext *UserContext
+	expiresAt   time.Time
 }
 
 // NewUserContextStorage creates and returns new defaultUserContextStorage instance to store users' conversational contexts.
-func NewUserContextStorage(config *CacheConfig) UserContextStorage {
-	return &defaultUserContextStorage{
-		cache: cache.New(config.ExpiresIn, config.CleanupInterval),
+func NewUserContextStorage(config *CacheConfig, options ...UserContextStorageOption) UserContextStorage {
+	storage := &defaultUserContextStorage{
+		cache:     cache.New(config.ExpiresIn, config.CleanupInterval),
+		expiresIn: config.ExpiresIn,
+		clock:     clock.Real(),
+	}
+
+	for _, opt := range options {
+		opt(storage)
 	}
+
+	return storage
 }
 
 // Get searches for user's stored state with given user key, and return it if any found.
@@ -96,8 +124,12 @@ func (storage *defaultUserContextStorage) Get(key string) (ContextualFunc, error
 	}
 
 	switch v := val.(type) {
-	case *UserContext:
-		return v.Next, nil
+	case *storedUserContext:
+		if storage.expiresIn > 0 && !storage.clock.Now().Before(v.expiresAt) {
+			storage.cache.Delete(key)
+			return nil, nil
+		}
+		return v.userContext.Next, nil
 
 	default:
 		return nil, fmt.Errorf("cached value has illegal type of %T", v)
@@ -119,7 +151,11 @@ func (storage *defaultUserContextStorage) Set(key string, userContext *UserConte
 		return errors.New("required UserContext.Next is not set. defaultUserContextStorage only supports in-memory ContextualFunc cache")
 	}
 
-	storage.cache.Set(key, userContext, cache.DefaultExpiration)
+	stored := &storedUserContext{
+		userContext: userContext,
+		expiresAt:   storage.clock.Now().Add(storage.expiresIn),
+	}
+	storage.cache.Set(key, stored, cache.DefaultExpiration)
 	return nil
 }
 
@@ -2,6 +2,7 @@ package sarah
 
 import (
 	"context"
+	"github.com/oklahomer/go-sarah/v4/clock"
 	"github.com/patrickmn/go-cache"
 	"testing"
 	"time"
@@ -37,9 +38,29 @@ func TestNewUserContextStorage(t *testing.T) {
 	}
 }
 
+func TestUserContextStorageWithClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	storage := NewUserContextStorage(NewCacheConfig(), UserContextStorageWithClock(fake))
+
+	key := "myKey"
+	_ = storage.Set(key, NewUserContext(func(ctx context.Context, input Input) (*CommandResponse, error) { return nil, nil }))
+
+	fake.Advance(NewCacheConfig().ExpiresIn - time.Second)
+	if val, _ := storage.Get(key); val == nil {
+		t.Fatal("Stored value is not returned before the expiration.")
+	}
+
+	fake.Advance(time.Second)
+	if val, _ := storage.Get(key); val != nil {
+		t.Error("Expired value is returned.")
+	}
+}
+
 func TestDefaultUserContextStorage_Set_WithEmptyNext(t *testing.T) {
 	storage := &defaultUserContextStorage{
-		cache: cache.New(3*time.Minute, 10*time.Minute),
+		cache:     cache.New(3*time.Minute, 10*time.Minute),
+		expiresIn: 3 * time.Minute,
+		clock:     clock.Real(),
 	}
 
 	err := storage.Set("key", &UserContext{})
@@ -51,7 +72,9 @@ func TestDefaultUserContextStorage_Set_WithEmptyNext(t *testing.T) {
 
 func TestDefaultUserContextStorage_CRUD(t *testing.T) {
 	storage := &defaultUserContextStorage{
-		cache: cache.New(3*time.Minute, 10*time.Minute),
+		cache:     cache.New(3*time.Minute, 10*time.Minute),
+		expiresIn: 3 * time.Minute,
+		clock:     clock.Real(),
 	}
 
 	key := "myKey"
@@ -0,0 +1,205 @@
+package sarah
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultStreamInterval is the default minimum interval between two consecutive messages sent from one StreamContent.
+// Chat services typically apply rate limits per channel, so flooding a channel with partial responses should be avoided.
+const DefaultStreamInterval = 1 * time.Second
+
+// StreamContent represents a content that is delivered incrementally rather than as one single message.
+// When a Command returns this as CommandResponse.Content, the Bot sends each emitted content to the input's sender as it arrives.
+// This is useful for long-running operations such as deployment or text generation that should report their progress.
+//
+// Use NewStreamContent to build one with a channel, or NewStreamContentFunc to build one with a callback function.
+type StreamContent struct {
+	stream   func(context.Context, func(interface{}) error) error
+	interval time.Duration
+	inPlace  bool
+}
+
+// StreamOption defines a function signature that StreamContent's functional option must satisfy.
+type StreamOption func(*StreamContent)
+
+// StreamWithInterval sets the minimum interval between two consecutive messages.
+// When contents are emitted faster than this interval, only the latest content is sent once per interval so the chat service is not flooded,
+// and the intermediate contents are skipped. The last emitted content is always sent.
+// Give zero to send each content as soon as it arrives without skipping any.
+func StreamWithInterval(interval time.Duration) StreamOption {
+	return func(content *StreamContent) {
+		content.interval = interval
+	}
+}
+
+// StreamInPlace makes the Bot update one single message with each emitted content instead of sending a new message for each.
+// This is effective only when the Bot satisfies MessageEditor and the Adapter supports editing;
+// otherwise, each content is sent as a new message as usual.
+func StreamInPlace() StreamOption {
+	return func(content *StreamContent) {
+		content.inPlace = true
+	}
+}
+
+// NewStreamContent creates a new StreamContent that sends each value received from the given channel.
+// The stream ends when the channel is closed, so the producer MUST close the channel when the operation finishes.
+//
+//  contents := make(chan interface{})
+//  go func() {
+//    defer close(contents)
+//    contents <- "Deployment started."
+//    // Do something
+//    contents <- "Deployment finished."
+//  }()
+//  return &sarah.CommandResponse{Content: sarah.NewStreamContent(contents)}, nil
+func NewStreamContent(contents <-chan interface{}, options ...StreamOption) *StreamContent {
+	return NewStreamContentFunc(func(ctx context.Context, emit func(interface{}) error) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case content, ok := <-contents:
+				if !ok {
+					return nil
+				}
+
+				err := emit(content)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}, options...)
+}
+
+// NewStreamContentFunc creates a new StreamContent with the given function.
+// The function is called by the Bot with a function, emit, that sends the given content to the input's sender.
+// The stream ends when the function returns.
+// emit returns an error when the context is canceled; the function should stop and return such an error as soon as possible.
+//
+//  stream := sarah.NewStreamContentFunc(func(ctx context.Context, emit func(interface{}) error) error {
+//    for _, step := range steps {
+//      err := emit(fmt.Sprintf("Running %s", step))
+//      if err != nil {
+//        return err
+//      }
+//      // Do something
+//    }
+//    return nil
+//  })
+func NewStreamContentFunc(fnc func(context.Context, func(interface{}) error) error, options ...StreamOption) *StreamContent {
+	content := &StreamContent{
+		stream:   fnc,
+		interval: DefaultStreamInterval,
+	}
+
+	for _, opt := range options {
+		opt(content)
+	}
+
+	return content
+}
+
+// Stream starts the underlying operation and passes each emitted content to send.
+// With a positive interval, emitted contents are coalesced so send is called at most once per interval with the latest content,
+// and the underlying operation is never blocked by the interval nor by send.
+// This blocks til the underlying operation finishes and the last emitted content is passed to send, or the given context is canceled.
+func (s *StreamContent) Stream(ctx context.Context, send func(interface{})) error {
+	if s.interval <= 0 {
+		return s.stream(ctx, func(content interface{}) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			default:
+				send(content)
+				return nil
+
+			}
+		})
+	}
+
+	var mutex sync.Mutex
+	var latest interface{}
+	pending := false
+	take := func() (interface{}, bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		content, ok := latest, pending
+		latest, pending = nil, false
+		return content, ok
+	}
+
+	notify := make(chan struct{}, 1)
+	done := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+
+		var lastSent time.Time
+		finished := false
+		for !finished {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-notify:
+				// O.K.
+
+			case <-done:
+				finished = true
+
+			}
+
+			if !lastSent.IsZero() {
+				wait := s.interval - time.Since(lastSent)
+				if wait > 0 {
+					timer := time.NewTimer(wait)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return
+
+					case <-timer.C:
+						// O.K.
+
+					}
+				}
+			}
+
+			// Take the content after the wait so the contents emitted in the meantime are coalesced to the latest one.
+			content, ok := take()
+			if !ok {
+				continue
+			}
+			send(content)
+			lastSent = time.Now()
+		}
+	}()
+
+	emit := func(content interface{}) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		mutex.Lock()
+		latest, pending = content, true
+		mutex.Unlock()
+
+		select {
+		case notify <- struct{}{}:
+		default:
+			// The sender is already notified and picks up this content.
+		}
+		return nil
+	}
+
+	err := s.stream(ctx, emit)
+	close(done)
+	<-flushed
+	return err
+}
@@ -0,0 +1,203 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStreamWithInterval(t *testing.T) {
+	content := &StreamContent{}
+	interval := 3 * time.Second
+
+	StreamWithInterval(interval)(content)
+
+	if content.interval != interval {
+		t.Errorf("Expected interval is not set: %s.", content.interval)
+	}
+}
+
+func TestStreamInPlace(t *testing.T) {
+	content := &StreamContent{}
+
+	StreamInPlace()(content)
+
+	if !content.inPlace {
+		t.Error("Expected flag is not set.")
+	}
+}
+
+func TestNewStreamContentFunc(t *testing.T) {
+	content := NewStreamContentFunc(func(_ context.Context, _ func(interface{}) error) error {
+		return nil
+	})
+
+	if content.interval != DefaultStreamInterval {
+		t.Errorf("Default interval is not set: %s.", content.interval)
+	}
+
+	if content.stream == nil {
+		t.Error("Given function is not set.")
+	}
+}
+
+func TestStreamContent_Stream(t *testing.T) {
+	t.Run("channel", func(t *testing.T) {
+		contents := make(chan interface{}, 3)
+		contents <- "foo"
+		contents <- "bar"
+		contents <- "buzz"
+		close(contents)
+
+		stream := NewStreamContent(contents, StreamWithInterval(0))
+
+		var sent []interface{}
+		err := stream.Stream(context.TODO(), func(c interface{}) {
+			sent = append(sent, c)
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if len(sent) != 3 {
+			t.Fatalf("Unexpected number of contents are sent: %d.", len(sent))
+		}
+
+		if sent[0] != "foo" || sent[2] != "buzz" {
+			t.Errorf("Contents are not sent in order: %#v.", sent)
+		}
+	})
+
+	t.Run("coalesced", func(t *testing.T) {
+		interval := 50 * time.Millisecond
+		var produced time.Duration
+		stream := NewStreamContentFunc(func(_ context.Context, emit func(interface{}) error) error {
+			started := time.Now()
+			defer func() {
+				produced = time.Since(started)
+			}()
+
+			for i := 0; i < 3; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+				time.Sleep(interval / 10)
+			}
+			return nil
+		}, StreamWithInterval(interval))
+
+		var sent []interface{}
+		var sentAt []time.Time
+		err := stream.Stream(context.TODO(), func(content interface{}) {
+			sent = append(sent, content)
+			sentAt = append(sentAt, time.Now())
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if produced >= interval {
+			t.Errorf("Producer is blocked: %s.", produced)
+		}
+
+		if len(sent) != 2 || sent[1] != 2 {
+			t.Fatalf("Contents are not coalesced to the latest one: %#v.", sent)
+		}
+
+		if diff := sentAt[1].Sub(sentAt[0]); diff < interval {
+			t.Errorf("Contents are sent without enough interval: %s.", diff)
+		}
+	})
+
+	t.Run("slow sender", func(t *testing.T) {
+		interval := 10 * time.Millisecond
+		block := make(chan struct{})
+		var produced time.Duration
+		stream := NewStreamContentFunc(func(_ context.Context, emit func(interface{}) error) error {
+			started := time.Now()
+			for i := 0; i < 5; i++ {
+				if err := emit(i); err != nil {
+					return err
+				}
+			}
+			produced = time.Since(started)
+			close(block)
+			return nil
+		}, StreamWithInterval(interval))
+
+		var sent []interface{}
+		err := stream.Stream(context.TODO(), func(content interface{}) {
+			// The producer must not wait for this to return.
+			<-block
+			sent = append(sent, content)
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if produced >= interval {
+			t.Errorf("Producer is blocked by sender: %s.", produced)
+		}
+
+		if len(sent) == 0 || sent[len(sent)-1] != 4 {
+			t.Errorf("Last content is not sent: %#v.", sent)
+		}
+	})
+
+	t.Run("canceled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		sent := make(chan interface{}, 2)
+		stream := NewStreamContentFunc(func(ctx context.Context, emit func(interface{}) error) error {
+			_ = emit("first")
+			<-sent
+			_ = emit("second")
+			cancel()
+			return ctx.Err()
+		}, StreamWithInterval(time.Hour))
+
+		err := stream.Stream(ctx, func(content interface{}) {
+			sent <- content
+		})
+
+		if err != context.Canceled {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if len(sent) != 0 {
+			t.Errorf("Content must not be sent after context cancellation: %#v.", <-sent)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		expected := errors.New("dummy")
+		stream := NewStreamContentFunc(func(_ context.Context, _ func(interface{}) error) error {
+			return expected
+		})
+
+		err := stream.Stream(context.TODO(), func(_ interface{}) {})
+
+		if err != expected {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		contents := make(chan interface{})
+		stream := NewStreamContent(contents)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := stream.Stream(ctx, func(_ interface{}) {
+			t.Error("Content must not be sent after context cancellation.")
+		})
+
+		if err != context.Canceled {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
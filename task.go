@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 )
 
 var (
@@ -20,6 +21,9 @@ var (
 type ScheduledTaskResult struct {
 	Content     interface{}
 	Destination OutputDestination
+
+	// Urgent tells the Bot to send the result even when the destination is in its QuietHours. See RegisterQuietHours.
+	Urgent bool
 }
 
 // taskFunc is a function type that represents scheduled task.
@@ -40,6 +44,17 @@ type DestinatedConfig interface {
 	DefaultDestination() OutputDestination
 }
 
+// onceDescriptor is the prefix of the schedule that runs a ScheduledTask only once. See ScheduleOnceAt.
+const onceDescriptor = "@at "
+
+// ScheduleOnceAt returns a schedule that runs a ScheduledTask only once at the given time.
+// The time is formatted in RFC 3339, so the fraction of a second is truncated.
+// A ScheduledTask with this schedule runs right away when the time is already past on scheduling.
+// This is handy to schedule a ScheduledTask at runtime with AddScheduledTask.
+func ScheduleOnceAt(at time.Time) string {
+	return onceDescriptor + at.Format(time.RFC3339)
+}
+
 // ScheduledTask defines interface that all scheduled task MUST satisfy.
 // As long as a struct satisfies this interface, the struct can be registered as ScheduledTask via Runner.RegisterScheduledTask.
 type ScheduledTask interface {
@@ -125,7 +140,11 @@ func buildScheduledTask(ctx context.Context, props *ScheduledTaskProps, watcher
 
 		rv := reflect.ValueOf(cfg)
 		if rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Map {
-			return watcher.Read(ctx, props.botType, props.identifier, cfg)
+			e := watcher.Read(ctx, props.botType, props.identifier, cfg)
+			if e == nil {
+				e = validatePluginConfig(cfg)
+			}
+			return e
 		}
 
 		// https://groups.google.com/forum/#!topic/Golang-Nuts/KB3_Yj3Ny4c
@@ -138,6 +157,9 @@ func buildScheduledTask(ctx context.Context, props *ScheduledTaskProps, watcher
 
 		// Pass the pointer to the newly created instance
 		e := watcher.Read(ctx, props.botType, props.identifier, n.Interface())
+		if e == nil {
+			e = validatePluginConfig(n.Interface())
+		}
 		if e == nil {
 			cfg = n.Elem().Interface()
 		}
@@ -186,6 +208,7 @@ func buildScheduledTask(ctx context.Context, props *ScheduledTaskProps, watcher
 // This holds relatively complex set of ScheduledTask construction arguments that should be treated as one in logical term.
 type ScheduledTaskProps struct {
 	botType            BotType
+	scope              *BotScope
 	identifier         string
 	taskFunc           taskFunc
 	schedule           string
@@ -213,6 +236,13 @@ func (builder *ScheduledTaskPropsBuilder) BotType(botType BotType) *ScheduledTas
 	return builder
 }
 
+// Scope sets the BotScope to schedule this task for the Bots it selects instead of a single BotType.
+// When both BotType and Scope are given, Scope takes precedence.
+func (builder *ScheduledTaskPropsBuilder) Scope(scope *BotScope) *ScheduledTaskPropsBuilder {
+	builder.props.scope = scope
+	return builder
+}
+
 // Identifier sets unique ID of this task.
 // This is used to identify re-configure tasks and replace old ones.
 func (builder *ScheduledTaskPropsBuilder) Identifier(id string) *ScheduledTaskPropsBuilder {
@@ -259,7 +289,7 @@ func (builder *ScheduledTaskPropsBuilder) ConfigurableFunc(config TaskConfig, fn
 
 // Build builds new ScheduledProps instance with provided values.
 func (builder *ScheduledTaskPropsBuilder) Build() (*ScheduledTaskProps, error) {
-	if builder.props.botType == "" ||
+	if (builder.props.botType == "" && builder.props.scope == nil) ||
 		builder.props.identifier == "" ||
 		builder.props.taskFunc == nil {
 
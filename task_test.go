@@ -62,6 +62,25 @@ func TestScheduledTaskPropsBuilder_BotType(t *testing.T) {
 	}
 }
 
+func TestScheduledTaskPropsBuilder_Scope(t *testing.T) {
+	scope := BotGroup("internal")
+	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
+	builder.Scope(scope)
+
+	if builder.props.scope != scope {
+		t.Error("Provided BotScope was not set.")
+	}
+
+	builder.Identifier("scheduled").
+		Schedule("@hourly").
+		Func(func(_ context.Context) ([]*ScheduledTaskResult, error) {
+			return nil, nil
+		})
+	if _, err := builder.Build(); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
 func TestScheduledTaskPropsBuilder_Identifier(t *testing.T) {
 	id := "overWhelmedWithTasks"
 	builder := &ScheduledTaskPropsBuilder{props: &ScheduledTaskProps{}}
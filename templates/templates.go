@@ -0,0 +1,265 @@
+/*
+Package templates provides a helper to author command responses and scheduled reports as text/template templates.
+
+Templates are loaded per Bot from the plugin configuration directory; the files with Config.Extension in the directory
+named after the BotType form a Set. Given a directory structure below, the Set for Slack contains "weather" and "report" templates.
+
+	plugins/
+	├── slack/
+	│   ├── weather.yaml
+	│   ├── weather.tmpl
+	│   └── report.tmpl
+	└── gitter/
+	    └── weather.tmpl
+
+Besides the text/template's built-in functions, below functions are available in the templates.
+The output of mention, code and codeBlock depends on the Set's Formatter so one template can be shared among platforms.
+
+	mention     {{ mention .UserID }} renders a mention to the user
+	code        {{ code .Command }} renders an inline code
+	codeBlock   {{ codeBlock .Log }} renders a code block
+	truncate    {{ truncate 100 .Description }} truncates the text to the given number of characters
+	join        {{ join .Names ", " }} concatenates the elements with the separator
+
+A Command can render its response as below:
+
+	set, _ := templates.NewSet(slack.SLACK, templates.NewConfig(), templates.WithFormatter(slack.NewTemplateFormatter()))
+	text, err := set.Render("weather", forecast)
+*/
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Config contains some configuration variables for the template Set.
+type Config struct {
+	// Dir is the plugin configuration directory. The templates are loaded from the sub-directory named after the BotType.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// Extension is the file extension of the template files.
+	Extension string `json:"extension" yaml:"extension"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		Dir:       "plugins",
+		Extension: ".tmpl",
+	}
+}
+
+// Formatter defines an interface that renders platform-specific notations for the template functions.
+type Formatter interface {
+	// Mention returns a mention to the user with the given ID.
+	Mention(userID string) string
+
+	// Code returns the given text as an inline code.
+	Code(text string) string
+
+	// CodeBlock returns the given text as a code block.
+	CodeBlock(text string) string
+
+	// MaxLength returns the maximum number of characters a message can contain. Zero means no limit.
+	MaxLength() int
+}
+
+// MarkdownFormatter is a Formatter that renders markdown notations.
+// This is used when no Formatter is given to NewSet.
+type MarkdownFormatter struct {
+	// Limit is the value MaxLength returns.
+	Limit int
+}
+
+var _ Formatter = (*MarkdownFormatter)(nil)
+
+// Mention returns the user ID prefixed with "@".
+func (f *MarkdownFormatter) Mention(userID string) string {
+	return "@" + userID
+}
+
+// Code returns the given text surrounded with backquotes.
+func (f *MarkdownFormatter) Code(text string) string {
+	return "`" + text + "`"
+}
+
+// CodeBlock returns the given text surrounded with triple backquotes.
+func (f *MarkdownFormatter) CodeBlock(text string) string {
+	return "```\n" + strings.TrimSuffix(text, "\n") + "\n```"
+}
+
+// MaxLength returns the configured Limit.
+func (f *MarkdownFormatter) MaxLength() int {
+	return f.Limit
+}
+
+// SetOption defines a function signature that NewSet's functional option must satisfy.
+type SetOption func(*Set)
+
+// WithFormatter creates a SetOption that sets the Formatter for the platform-specific notations.
+func WithFormatter(formatter Formatter) SetOption {
+	return func(set *Set) {
+		set.formatter = formatter
+	}
+}
+
+// WithFuncs creates a SetOption that adds the given functions to the templates.
+// A function with the same name as a built-in one overrides the built-in.
+func WithFuncs(funcs template.FuncMap) SetOption {
+	return func(set *Set) {
+		for name, fnc := range funcs {
+			set.funcs[name] = fnc
+		}
+	}
+}
+
+// Set is a set of templates for a Bot.
+// Calls to its methods are thread-safe.
+type Set struct {
+	botType   sarah.BotType
+	config    *Config
+	formatter Formatter
+	funcs     template.FuncMap
+	added     map[string]string
+	template  *template.Template
+	mutex     sync.RWMutex
+}
+
+// NewSet creates a new Set for the given BotType and loads the templates.
+// An error is returned when any of the template files can not be parsed.
+func NewSet(botType sarah.BotType, config *Config, options ...SetOption) (*Set, error) {
+	set := &Set{
+		botType:   botType,
+		config:    config,
+		formatter: &MarkdownFormatter{},
+		funcs:     template.FuncMap{},
+		added:     map[string]string{},
+	}
+
+	for _, opt := range options {
+		opt(set)
+	}
+
+	err := set.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+func (s *Set) builtinFuncs() template.FuncMap {
+	return template.FuncMap{
+		"mention":   s.formatter.Mention,
+		"code":      s.formatter.Code,
+		"codeBlock": s.formatter.CodeBlock,
+		"truncate":  Truncate,
+		"join": func(elems []string, sep string) string {
+			return strings.Join(elems, sep)
+		},
+	}
+}
+
+// Reload loads the template files again.
+// Call this when the template files are updated. On an error, the Set keeps the previously loaded templates.
+func (s *Set) Reload() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.load()
+}
+
+// Add parses the given text as a template with the given name and adds it to the Set.
+// This is handy to provide a default template in the code or to test a template.
+// A template file with the same name takes precedence over the added one.
+func (s *Set) Add(name string, text string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.added[name] = text
+	err := s.load()
+	if err != nil {
+		delete(s.added, name)
+		return err
+	}
+
+	return nil
+}
+
+// load builds the templates from the added texts and the template files.
+// The mutex must be locked by the caller.
+func (s *Set) load() error {
+	funcs := s.builtinFuncs()
+	for name, fnc := range s.funcs {
+		funcs[name] = fnc
+	}
+	tmpl := template.New(s.botType.String()).Funcs(funcs)
+
+	for name, text := range s.added {
+		_, err := tmpl.New(name).Parse(text)
+		if err != nil {
+			return fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+	}
+
+	pattern := filepath.Join(s.config.Dir, s.botType.String(), "*"+s.config.Extension)
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to search template files with %s: %w", pattern, err)
+	}
+	if len(files) > 0 {
+		tmpl, err = tmpl.ParseFiles(files...)
+		if err != nil {
+			return fmt.Errorf("failed to parse template files: %w", err)
+		}
+	}
+
+	// A new template is built on every load, so the template being executed in Render is never modified.
+	s.template = tmpl
+
+	return nil
+}
+
+// Render executes the template with the given name and returns the result.
+// The name may omit the file extension; "weather" and "weather.tmpl" refer to the same template.
+// The result is truncated to the Formatter's MaxLength.
+func (s *Set) Render(name string, data interface{}) (string, error) {
+	s.mutex.RLock()
+	// Look for the template file first so the file takes precedence over the one added with the same name.
+	tmpl := s.template.Lookup(name + s.config.Extension)
+	if tmpl == nil {
+		tmpl = s.template.Lookup(name)
+	}
+	s.mutex.RUnlock()
+
+	if tmpl == nil {
+		return "", fmt.Errorf("template %s is not found for %s", name, s.botType)
+	}
+
+	buf := &bytes.Buffer{}
+	err := tmpl.Execute(buf, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+
+	return Truncate(s.formatter.MaxLength(), buf.String()), nil
+}
+
+// Truncate truncates the given text to the given number of characters, not bytes.
+// The last character is replaced with an ellipsis when the text is truncated.
+// The text is returned as-is when the limit is zero or less.
+func Truncate(limit int, text string) string {
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,243 @@
+package templates
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+type DummyFormatter struct {
+	MaxLengthValue int
+}
+
+var _ Formatter = (*DummyFormatter)(nil)
+
+func (f *DummyFormatter) Mention(userID string) string {
+	return "<@" + userID + ">"
+}
+
+func (f *DummyFormatter) Code(text string) string {
+	return "[" + text + "]"
+}
+
+func (f *DummyFormatter) CodeBlock(text string) string {
+	return "[[" + text + "]]"
+}
+
+func (f *DummyFormatter) MaxLength() int {
+	return f.MaxLengthValue
+}
+
+func testConfig() *Config {
+	config := NewConfig()
+	config.Dir = filepath.Join("..", "testdata", "templates")
+	return config
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+
+	if config.Dir == "" {
+		t.Error("Default directory is not set.")
+	}
+
+	if config.Extension != ".tmpl" {
+		t.Errorf("Unexpected extension is set: %s.", config.Extension)
+	}
+}
+
+func TestMarkdownFormatter(t *testing.T) {
+	formatter := &MarkdownFormatter{Limit: 10}
+
+	if mention := formatter.Mention("oklahomer"); mention != "@oklahomer" {
+		t.Errorf("Unexpected mention is returned: %s.", mention)
+	}
+
+	if code := formatter.Code("go test"); code != "`go test`" {
+		t.Errorf("Unexpected code is returned: %s.", code)
+	}
+
+	if block := formatter.CodeBlock("line\n"); block != "```\nline\n```" {
+		t.Errorf("Unexpected code block is returned: %s.", block)
+	}
+
+	if formatter.MaxLength() != 10 {
+		t.Errorf("Unexpected max length is returned: %d.", formatter.MaxLength())
+	}
+}
+
+func TestNewSet(t *testing.T) {
+	t.Run("Load files", func(t *testing.T) {
+		set, err := NewSet("dummy", testConfig(), WithFormatter(&DummyFormatter{}))
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		text, err := set.Render("greeting", map[string]string{"UserID": "U123", "Log": "log"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if text != "Hello, <@U123>.\n[[log]]" {
+			t.Errorf("Unexpected text is rendered: %s.", text)
+		}
+	})
+
+	t.Run("No file", func(t *testing.T) {
+		_, err := NewSet("missing", testConfig())
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+	})
+
+	t.Run("Broken file", func(t *testing.T) {
+		_, err := NewSet("broken", testConfig())
+		if err == nil {
+			t.Error("Expected error is not returned.")
+		}
+	})
+}
+
+func TestWithFuncs(t *testing.T) {
+	set, err := NewSet("missing", testConfig(), WithFuncs(template.FuncMap{
+		"upper":   strings.ToUpper,
+		"mention": func(s string) string { return "!" + s },
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = set.Add("custom", "{{ upper .Name }} {{ mention .Name }}")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	text, err := set.Render("custom", map[string]string{"Name": "sarah"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if text != "SARAH !sarah" {
+		t.Errorf("Unexpected text is rendered: %s.", text)
+	}
+}
+
+func TestSet_Add(t *testing.T) {
+	set, err := NewSet("dummy", testConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = set.Add("broken", "{{ .Broken ")
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	err = set.Add("greeting", "Overridden")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The template file takes precedence.
+	text, err := set.Render("greeting", map[string]string{"UserID": "U123", "Log": "log"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if strings.Contains(text, "Overridden") {
+		t.Errorf("Template file is overridden: %s.", text)
+	}
+
+	// The added template is kept on reload.
+	err = set.Add("farewell", "Bye, {{ code .Name }}.")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	err = set.Reload()
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	text, err = set.Render("farewell", map[string]string{"Name": "sarah"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if text != "Bye, `sarah`." {
+		t.Errorf("Unexpected text is rendered: %s.", text)
+	}
+}
+
+func TestSet_Render(t *testing.T) {
+	set, err := NewSet("missing", testConfig(), WithFormatter(&DummyFormatter{MaxLengthValue: 5}))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_ = set.Add("long", "{{ .Text }}")
+	_ = set.Add("failing", "{{ .Fail }}")
+
+	t.Run("Truncated", func(t *testing.T) {
+		text, err := set.Render("long", map[string]string{"Text": "abcdefghij"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if text != "abcd…" {
+			t.Errorf("Unexpected text is rendered: %s.", text)
+		}
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := set.Render("unknown", nil)
+		if err == nil {
+			t.Error("Expected error is not returned.")
+		}
+	})
+
+	t.Run("Execution error", func(t *testing.T) {
+		_, err := set.Render("failing", &struct{}{})
+		if err == nil {
+			t.Error("Expected error is not returned.")
+		}
+
+		var execErr template.ExecError
+		if !errors.As(err, &execErr) {
+			t.Errorf("Unexpected error is returned: %#v.", err)
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		limit    int
+		text     string
+		expected string
+	}{
+		{
+			limit:    0,
+			text:     "abc",
+			expected: "abc",
+		},
+		{
+			limit:    3,
+			text:     "abc",
+			expected: "abc",
+		},
+		{
+			limit:    3,
+			text:     "abcd",
+			expected: "ab…",
+		},
+		{
+			limit:    2,
+			text:     "日本語",
+			expected: "日…",
+		},
+	}
+
+	for i, tt := range tests {
+		if text := Truncate(tt.limit, tt.text); text != tt.expected {
+			t.Errorf("Unexpected text is returned on test #%d: %s.", i, text)
+		}
+	}
+}
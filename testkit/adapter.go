@@ -0,0 +1,285 @@
+/*
+Package testkit provides scriptable test doubles of sarah.Adapter and sarah.Bot
+so developers can unit test their plugins and Runner wiring without connecting to a live chat service.
+
+To test a plugin with go-sarah's default Bot implementation, wrap Adapter with sarah.NewBot,
+push synthetic inputs and inspect the sent messages:
+
+	adapter := testkit.NewAdapter("test")
+	sarah.RegisterBot(sarah.NewBot(adapter))
+	sarah.RegisterCommand("test", myCommand)
+	go sarah.Run(ctx, sarah.NewConfig())
+
+	_ = adapter.PushMessage(ctx, "user1", ".hello")
+	outputs, err := adapter.WaitSent(ctx, 1)
+
+Bot is handy to test how Runner wires commands and scheduled tasks without involving the default Bot implementation.
+
+CommandHarness checks a single Command against a table of inputs and expected responses including golden files,
+while Scenario checks a multi-step conversation with go-sarah's default Bot implementation.
+
+RecordingAdapter records the real traffic of a production Adapter,
+and ReplayAdapter feeds the recorded inputs back through Runner to catch regressions against production-shaped traffic.
+*/
+package testkit
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"sync"
+	"time"
+)
+
+// ErrNotRunning is returned when Adapter or Bot is not run by Runner and hence can not receive an input or an error.
+var ErrNotRunning = errors.New("adapter is not running")
+
+// Input is a sarah.Input implementation with fixed values.
+// Use NewInput to construct one, or construct one directly to set arbitrary values.
+type Input struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+	ReplyToValue   sarah.OutputDestination
+}
+
+var _ sarah.Input = (*Input)(nil)
+
+// NewInput creates a new Input with the given sender key and message.
+// The sender key is also used as the reply destination.
+func NewInput(senderKey string, message string) *Input {
+	return &Input{
+		SenderKeyValue: senderKey,
+		MessageValue:   message,
+		SentAtValue:    time.Now(),
+		ReplyToValue:   senderKey,
+	}
+}
+
+// SenderKey returns the sender key.
+func (i *Input) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+// Message returns the message.
+func (i *Input) Message() string {
+	return i.MessageValue
+}
+
+// SentAt returns the timestamp.
+func (i *Input) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+// ReplyTo returns the reply destination.
+func (i *Input) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+// AdapterOption defines function signature that Adapter's functional option must satisfy.
+type AdapterOption func(*Adapter)
+
+// WithSendMessageFunc creates an AdapterOption that sets a function to be called on each SendMessage.
+// The Output is recorded regardless of this function, and the function's return values are returned by SendMessage.
+// This is handy to simulate a slow or failing chat service or to assert each Output as soon as it is sent.
+func WithSendMessageFunc(fnc func(context.Context, sarah.Output) (*sarah.SendResult, error)) AdapterOption {
+	return func(adapter *Adapter) {
+		adapter.sendMessageFunc = fnc
+	}
+}
+
+type pushedInput struct {
+	input  sarah.Input
+	result chan error
+}
+
+// Adapter is a scriptable sarah.Adapter implementation.
+// Push inputs and errors to simulate a chat service, and inspect the outputs that go-sarah sent via SendMessage.
+// Use NewAdapter to construct one.
+type Adapter struct {
+	botType         sarah.BotType
+	sendMessageFunc func(context.Context, sarah.Output) (*sarah.SendResult, error)
+	inputs          chan *pushedInput
+	errs            chan error
+	running         chan struct{}
+	runOnce         sync.Once
+
+	mutex   sync.Mutex
+	outputs []sarah.Output
+	// sent is closed and replaced on each SendMessage so WaitSent can wait for the next Output.
+	sent chan struct{}
+}
+
+var _ sarah.Adapter = (*Adapter)(nil)
+var _ sarah.BatchSender = (*Adapter)(nil)
+
+// NewAdapter creates a new Adapter with the given sarah.BotType and zero or more AdapterOption.
+func NewAdapter(botType sarah.BotType, options ...AdapterOption) *Adapter {
+	adapter := &Adapter{
+		botType: botType,
+		inputs:  make(chan *pushedInput),
+		errs:    make(chan error),
+		running: make(chan struct{}),
+		sent:    make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(adapter)
+	}
+
+	return adapter
+}
+
+// BotType returns the sarah.BotType given to NewAdapter.
+func (adapter *Adapter) BotType() sarah.BotType {
+	return adapter.botType
+}
+
+// Run passes the pushed inputs and errors to go-sarah's core til the given context is canceled.
+func (adapter *Adapter) Run(ctx context.Context, enqueueInput func(sarah.Input) error, notifyErr func(error)) {
+	adapter.runOnce.Do(func() {
+		close(adapter.running)
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case pushed := <-adapter.inputs:
+			pushed.result <- enqueueInput(pushed.input)
+
+		case err := <-adapter.errs:
+			notifyErr(err)
+
+		}
+	}
+}
+
+// SendMessage records the given Output.
+// The result of the function given with WithSendMessageFunc is returned if any.
+func (adapter *Adapter) SendMessage(ctx context.Context, output sarah.Output) (*sarah.SendResult, error) {
+	result := &sarah.SendResult{Destination: output.Destination()}
+	var err error
+	if adapter.sendMessageFunc != nil {
+		result, err = adapter.sendMessageFunc(ctx, output)
+	}
+
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+	adapter.outputs = append(adapter.outputs, output)
+	close(adapter.sent)
+	adapter.sent = make(chan struct{})
+
+	return result, err
+}
+
+// SendMessages records the given Outputs by passing each of them to SendMessage, so Adapter also serves as sarah.BatchSender.
+// The first error is returned after all Outputs are passed, and the result of a failed Output is nil.
+func (adapter *Adapter) SendMessages(ctx context.Context, outputs []sarah.Output) ([]*sarah.SendResult, error) {
+	results := make([]*sarah.SendResult, len(outputs))
+	var firstErr error
+	for i, output := range outputs {
+		result, err := adapter.SendMessage(ctx, output)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		results[i] = result
+	}
+	return results, firstErr
+}
+
+// WaitRunning blocks til Runner calls Run or the given context is canceled.
+func (adapter *Adapter) WaitRunning(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-adapter.running:
+		return nil
+
+	}
+}
+
+// Push passes the given input to go-sarah's core as if the chat service sent it.
+// This blocks til Run receives the input, and returns the error that go-sarah's core returned on the enqueue
+// such as *sarah.BlockedInputError.
+// Note that the input is processed asynchronously, so use WaitSent to wait for the response.
+func (adapter *Adapter) Push(ctx context.Context, input sarah.Input) error {
+	pushed := &pushedInput{
+		input:  input,
+		result: make(chan error, 1),
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case adapter.inputs <- pushed:
+		return <-pushed.result
+
+	}
+}
+
+// PushMessage is a shorthand for Push with an Input that NewInput creates.
+func (adapter *Adapter) PushMessage(ctx context.Context, senderKey string, message string) error {
+	return adapter.Push(ctx, NewInput(senderKey, message))
+}
+
+// NotifyError passes the given error to go-sarah's core as if the Adapter encountered the error.
+// Give sarah.NewBotNonContinuableError to simulate a critical state so Runner stops the Bot.
+// This blocks til Run receives the error.
+func (adapter *Adapter) NotifyError(ctx context.Context, err error) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case adapter.errs <- err:
+		return nil
+
+	}
+}
+
+// Sent returns the copy of the recorded outputs in the order of sending.
+func (adapter *Adapter) Sent() []sarah.Output {
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+
+	outputs := make([]sarah.Output, len(adapter.outputs))
+	copy(outputs, adapter.outputs)
+	return outputs
+}
+
+// WaitSent blocks til the given number of outputs are recorded in total or the given context is canceled.
+// The recorded outputs are returned in either case.
+func (adapter *Adapter) WaitSent(ctx context.Context, count int) ([]sarah.Output, error) {
+	for {
+		adapter.mutex.Lock()
+		sent := adapter.sent
+		recorded := len(adapter.outputs)
+		adapter.mutex.Unlock()
+
+		if recorded >= count {
+			return adapter.Sent(), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return adapter.Sent(), ctx.Err()
+
+		case <-sent:
+			// Check the number again.
+
+		}
+	}
+}
+
+// Reset discards the recorded outputs.
+func (adapter *Adapter) Reset() {
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+	adapter.outputs = nil
+}
@@ -0,0 +1,272 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type DummyCommand struct {
+	IdentifierValue string
+	ExecuteFunc     func(context.Context, sarah.Input) (*sarah.CommandResponse, error)
+	MatchFunc       func(sarah.Input) bool
+}
+
+func (command *DummyCommand) Identifier() string {
+	return command.IdentifierValue
+}
+
+func (command *DummyCommand) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	return command.ExecuteFunc(ctx, input)
+}
+
+func (command *DummyCommand) Instruction(_ *sarah.HelpInput) string {
+	return ""
+}
+
+func (command *DummyCommand) Match(input sarah.Input) bool {
+	return command.MatchFunc(input)
+}
+
+func TestNewInput(t *testing.T) {
+	input := NewInput("user1", "hello")
+
+	if input.SenderKey() != "user1" {
+		t.Errorf("Unexpected sender key is returned: %s.", input.SenderKey())
+	}
+
+	if input.Message() != "hello" {
+		t.Errorf("Unexpected message is returned: %s.", input.Message())
+	}
+
+	if input.SentAt().IsZero() {
+		t.Error("Timestamp is not set.")
+	}
+
+	if input.ReplyTo() != "user1" {
+		t.Errorf("Unexpected destination is returned: %#v.", input.ReplyTo())
+	}
+}
+
+func TestNewAdapter(t *testing.T) {
+	called := false
+	adapter := NewAdapter("test", WithSendMessageFunc(func(_ context.Context, output sarah.Output) (*sarah.SendResult, error) {
+		called = true
+		return &sarah.SendResult{Destination: output.Destination(), MessageID: "message"}, nil
+	}))
+
+	if adapter.BotType() != "test" {
+		t.Errorf("Unexpected BotType is returned: %s.", adapter.BotType())
+	}
+
+	result, err := adapter.SendMessage(context.TODO(), sarah.NewOutputMessage("user1", "hello"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if !called {
+		t.Error("Given function is not called.")
+	}
+	if result.MessageID != "message" {
+		t.Errorf("Unexpected result is returned: %#v.", result)
+	}
+}
+
+func TestAdapter_Push(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	adapter := NewAdapter("test")
+	enqueueErr := sarah.NewBlockedInputError(1)
+	var given []sarah.Input
+	var notified error
+	finished := make(chan struct{})
+	go func() {
+		adapter.Run(ctx, func(input sarah.Input) error {
+			given = append(given, input)
+			if len(given) > 1 {
+				return enqueueErr
+			}
+			return nil
+		}, func(err error) {
+			notified = err
+		})
+		close(finished)
+	}()
+
+	err := adapter.WaitRunning(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = adapter.PushMessage(ctx, "user1", "hello")
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = adapter.PushMessage(ctx, "user1", "hello again")
+	if err != enqueueErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	criticalErr := sarah.NewBotNonContinuableError("critical")
+	err = adapter.NotifyError(ctx, criticalErr)
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	cancel()
+	<-finished
+
+	if len(given) != 2 || given[0].Message() != "hello" {
+		t.Errorf("Unexpected inputs are given: %#v.", given)
+	}
+
+	if notified != criticalErr {
+		t.Errorf("Expected error is not notified: %#v.", notified)
+	}
+}
+
+func TestAdapter_Push_NotRunning(t *testing.T) {
+	adapter := NewAdapter("test")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := adapter.PushMessage(ctx, "user1", "hello")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	err = adapter.NotifyError(ctx, errors.New("error"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestAdapter_WaitSent(t *testing.T) {
+	adapter := NewAdapter("test")
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			adapter.SendMessage(context.TODO(), sarah.NewOutputMessage("user1", i))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	outputs, err := adapter.WaitSent(ctx, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(outputs) != 3 || outputs[2].Content() != 2 {
+		t.Errorf("Unexpected outputs are returned: %#v.", outputs)
+	}
+
+	adapter.Reset()
+	if len(adapter.Sent()) != 0 {
+		t.Errorf("Outputs are not discarded: %#v.", adapter.Sent())
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = adapter.WaitSent(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestAdapter_SendMessages(t *testing.T) {
+	sendErr := errors.New("dummy")
+	adapter := NewAdapter("test", WithSendMessageFunc(func(_ context.Context, output sarah.Output) (*sarah.SendResult, error) {
+		if output.Content() == "fail" {
+			return nil, sendErr
+		}
+		return &sarah.SendResult{MessageID: output.Content().(string)}, nil
+	}))
+
+	results, err := adapter.SendMessages(context.TODO(), []sarah.Output{
+		sarah.NewOutputMessage("user1", "fail"),
+		sarah.NewOutputMessage("user1", "ok"),
+	})
+	if err != sendErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+	if len(results) != 2 || results[0] != nil || results[1].MessageID != "ok" {
+		t.Errorf("Unexpected results are returned: %#v.", results)
+	}
+	if len(adapter.Sent()) != 2 {
+		t.Errorf("All outputs must be recorded: %#v.", adapter.Sent())
+	}
+}
+
+func TestAdapter_Runner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	adapter := NewAdapter("testkit")
+	sarah.RegisterBot(sarah.NewBot(adapter))
+	sarah.RegisterCommandProps(
+		sarah.NewCommandPropsBuilder().
+			BotType("testkit").
+			Identifier("hello").
+			MatchPattern(regexp.MustCompile(`^\.hello`)).
+			Func(func(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+				return &sarah.CommandResponse{Content: "Hello!"}, nil
+			}).
+			Instruction(".hello").
+			MustBuild(),
+	)
+
+	err := sarah.Run(ctx, sarah.NewConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 1*time.Second)
+	defer waitCancel()
+
+	err = adapter.WaitRunning(waitCtx)
+	if err != nil {
+		t.Fatalf("Adapter is not run: %s.", err.Error())
+	}
+
+	err = adapter.PushMessage(waitCtx, "user1", ".hello")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	outputs, err := adapter.WaitSent(waitCtx, 1)
+	if err != nil {
+		t.Fatalf("Response is not sent: %s.", err.Error())
+	}
+
+	if outputs[0].Content() != "Hello!" || outputs[0].Destination() != "user1" {
+		t.Errorf("Unexpected output is sent: %#v.", outputs[0])
+	}
+
+	// Simulate a critical error so Runner stops the Bot.
+	err = adapter.NotifyError(waitCtx, sarah.NewBotNonContinuableError("critical"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	for {
+		status := sarah.CurrentStatus()
+		if len(status.Bots) == 1 && !status.Bots[0].Running {
+			break
+		}
+
+		select {
+		case <-waitCtx.Done():
+			t.Fatal("Bot is not stopped on the non-continuable error.")
+
+		case <-time.After(5 * time.Millisecond):
+			// Check again.
+
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package testkit
+
+import (
+	"context"
+	"github.com/oklahomer/go-sarah/v4"
+	"sync"
+)
+
+// BotOption defines function signature that Bot's functional option must satisfy.
+type BotOption func(*Bot)
+
+// WithRespondFunc creates a BotOption that replaces the default behavior of Bot.Respond.
+// Return an error to simulate a failure of the input handling.
+func WithRespondFunc(fnc func(context.Context, sarah.Input) error) BotOption {
+	return func(bot *Bot) {
+		bot.respondFunc = fnc
+	}
+}
+
+// WithBotSendMessageFunc creates a BotOption that sets a function to be called on each SendMessage.
+// See WithSendMessageFunc for details.
+func WithBotSendMessageFunc(fnc func(context.Context, sarah.Output) (*sarah.SendResult, error)) BotOption {
+	return func(bot *Bot) {
+		bot.Adapter.sendMessageFunc = fnc
+	}
+}
+
+// Bot is a scriptable sarah.Bot implementation that records the appended commands and the received inputs.
+// Inputs and errors are pushed and outputs are inspected in the same way as Adapter.
+// Use NewBot to construct one.
+//
+// By default, Respond executes the first appended sarah.Command that matches the input and sends its response to Input.ReplyTo.
+// Unlike the default Bot implementation, conversational contexts and other advanced features are not supported.
+// Wrap Adapter with sarah.NewBot when those features matter.
+type Bot struct {
+	*Adapter
+	respondFunc func(context.Context, sarah.Input) error
+
+	mutex    sync.Mutex
+	commands []sarah.Command
+	inputs   []sarah.Input
+}
+
+var _ sarah.Bot = (*Bot)(nil)
+
+// NewBot creates a new Bot with the given sarah.BotType and zero or more BotOption.
+func NewBot(botType sarah.BotType, options ...BotOption) *Bot {
+	bot := &Bot{
+		Adapter: NewAdapter(botType),
+	}
+
+	for _, opt := range options {
+		opt(bot)
+	}
+
+	return bot
+}
+
+// AppendCommand records the given sarah.Command.
+// A command with the same identifier replaces the old one just like the default Bot implementation does.
+func (bot *Bot) AppendCommand(command sarah.Command) {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
+	for i, c := range bot.commands {
+		if c.Identifier() == command.Identifier() {
+			bot.commands[i] = command
+			return
+		}
+	}
+	bot.commands = append(bot.commands, command)
+}
+
+// Commands returns the copy of the appended commands.
+func (bot *Bot) Commands() []sarah.Command {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
+	commands := make([]sarah.Command, len(bot.commands))
+	copy(commands, bot.commands)
+	return commands
+}
+
+// Respond records the given input and handles the input.
+func (bot *Bot) Respond(ctx context.Context, input sarah.Input) error {
+	bot.mutex.Lock()
+	bot.inputs = append(bot.inputs, input)
+	bot.mutex.Unlock()
+
+	if bot.respondFunc != nil {
+		return bot.respondFunc(ctx, input)
+	}
+
+	for _, command := range bot.Commands() {
+		if !command.Match(input) {
+			continue
+		}
+
+		res, err := command.Execute(ctx, input)
+		if err != nil {
+			return err
+		}
+		if res != nil && res.Content != nil {
+			_, err = bot.SendMessage(ctx, sarah.NewOutputMessage(input.ReplyTo(), res.Content))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Received returns the copy of the inputs given to Respond in the order of reception.
+func (bot *Bot) Received() []sarah.Input {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+
+	inputs := make([]sarah.Input, len(bot.inputs))
+	copy(inputs, bot.inputs)
+	return inputs
+}
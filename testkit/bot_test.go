@@ -0,0 +1,111 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"testing"
+)
+
+func TestNewBot(t *testing.T) {
+	respondErr := errors.New("respond error")
+	sent := false
+	bot := NewBot(
+		"test",
+		WithRespondFunc(func(_ context.Context, _ sarah.Input) error {
+			return respondErr
+		}),
+		WithBotSendMessageFunc(func(_ context.Context, _ sarah.Output) (*sarah.SendResult, error) {
+			sent = true
+			return nil, nil
+		}),
+	)
+
+	if bot.BotType() != "test" {
+		t.Errorf("Unexpected BotType is returned: %s.", bot.BotType())
+	}
+
+	err := bot.Respond(context.TODO(), NewInput("user1", "hello"))
+	if err != respondErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	bot.SendMessage(context.TODO(), sarah.NewOutputMessage("user1", "hello"))
+	if !sent {
+		t.Error("Given function is not called.")
+	}
+}
+
+func TestBot_AppendCommand(t *testing.T) {
+	bot := NewBot("test")
+	bot.AppendCommand(&DummyCommand{IdentifierValue: "foo"})
+	bot.AppendCommand(&DummyCommand{IdentifierValue: "bar"})
+	replacement := &DummyCommand{IdentifierValue: "foo"}
+	bot.AppendCommand(replacement)
+
+	commands := bot.Commands()
+	if len(commands) != 2 {
+		t.Fatalf("Unexpected commands are stored: %#v.", commands)
+	}
+
+	if commands[0] != replacement {
+		t.Errorf("Command with the same identifier is not replaced: %#v.", commands[0])
+	}
+}
+
+func TestBot_Respond(t *testing.T) {
+	bot := NewBot("test")
+	bot.AppendCommand(&DummyCommand{
+		IdentifierValue: "unmatched",
+		MatchFunc: func(_ sarah.Input) bool {
+			return false
+		},
+	})
+	bot.AppendCommand(&DummyCommand{
+		IdentifierValue: "echo",
+		MatchFunc: func(_ sarah.Input) bool {
+			return true
+		},
+		ExecuteFunc: func(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+			return &sarah.CommandResponse{Content: input.Message()}, nil
+		},
+	})
+
+	err := bot.Respond(context.TODO(), NewInput("user1", "hello"))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(bot.Received()) != 1 {
+		t.Errorf("Input is not recorded: %#v.", bot.Received())
+	}
+
+	outputs := bot.Sent()
+	if len(outputs) != 1 || outputs[0].Content() != "hello" || outputs[0].Destination() != "user1" {
+		t.Errorf("Unexpected outputs are sent: %#v.", outputs)
+	}
+}
+
+func TestBot_Respond_Error(t *testing.T) {
+	executeErr := errors.New("execute error")
+	bot := NewBot("test")
+	bot.AppendCommand(&DummyCommand{
+		IdentifierValue: "failing",
+		MatchFunc: func(_ sarah.Input) bool {
+			return true
+		},
+		ExecuteFunc: func(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+			return nil, executeErr
+		},
+	})
+
+	err := bot.Respond(context.TODO(), NewInput("user1", "hello"))
+
+	if err != executeErr {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if len(bot.Sent()) != 0 {
+		t.Errorf("Nothing should be sent: %#v.", bot.Sent())
+	}
+}
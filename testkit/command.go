@@ -0,0 +1,290 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv is the name of the environment variable that lets CommandHarness rewrite the golden files with the actual responses.
+//
+//  SARAH_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "SARAH_UPDATE_GOLDEN"
+
+// CommandCase represents a single test case that CommandHarness checks.
+type CommandCase struct {
+	// Name is used as the subtest name. The message is used when this is empty.
+	Name string
+
+	// Message is the user input. NewInput creates the sarah.Input with this message unless Input is set.
+	Message string
+
+	// Input is the user input to be given. This takes precedence over Message.
+	Input sarah.Input
+
+	// Unmatched tells the Command must not match the input. Execution is skipped in this case.
+	Unmatched bool
+
+	// Response is the expected content of the response. This is compared with reflect.DeepEqual.
+	// Leave this nil to skip the comparison.
+	Response interface{}
+
+	// Golden is the file name under the golden directory that contains the expected content of the response.
+	// A string content is compared as-is; other content is compared in the indented JSON form.
+	// This is handy for a rich response such as Slack's Block Kit message. Leave this empty to skip the comparison.
+	Golden string
+
+	// Err tells the execution must return an error.
+	Err bool
+
+	// Check is an optional function to make further assertions.
+	Check func(t *testing.T, res *sarah.CommandResponse, err error)
+}
+
+// HarnessOption defines function signature that CommandHarness's functional option must satisfy.
+type HarnessOption func(*CommandHarness)
+
+// WithConfigFile creates a HarnessOption that reads the configuration of the configurable Command from the given YAML or JSON file.
+// The file format is determined by the extension just like the file-based sarah.ConfigWatcher.
+func WithConfigFile(path string) HarnessOption {
+	return func(h *CommandHarness) {
+		h.configFile = path
+	}
+}
+
+// WithGoldenDir creates a HarnessOption that sets the directory of the golden files. The default is testdata/golden.
+func WithGoldenDir(dir string) HarnessOption {
+	return func(h *CommandHarness) {
+		h.goldenDir = dir
+	}
+}
+
+// WithUpdateGolden creates a HarnessOption that tells whether to rewrite the golden files with the actual responses.
+// By default, the golden files are rewritten only when the environment variable named UpdateGoldenEnv is set.
+func WithUpdateGolden(update bool) HarnessOption {
+	return func(h *CommandHarness) {
+		h.updateGolden = update
+	}
+}
+
+// CommandHarness executes a sarah.Command built from sarah.CommandProps against a table of CommandCase.
+// Use NewCommandHarness to construct one, or RunCommandCases to do everything at once:
+//
+//  testkit.RunCommandCases(t, hello.SlackProps, []*testkit.CommandCase{
+//    {Message: ".hello", Response: "Hello!"},
+//    {Message: ".hello blocks", Golden: "hello_blocks.json"},
+//    {Message: "hello", Unmatched: true},
+//  }, testkit.WithConfigFile("testdata/hello.yaml"))
+type CommandHarness struct {
+	command      sarah.Command
+	configFile   string
+	goldenDir    string
+	updateGolden bool
+}
+
+// NewCommandHarness builds the sarah.Command from the given sarah.CommandProps and creates a new CommandHarness.
+func NewCommandHarness(ctx context.Context, props *sarah.CommandProps, options ...HarnessOption) (*CommandHarness, error) {
+	h := &CommandHarness{
+		goldenDir:    filepath.Join("testdata", "golden"),
+		updateGolden: os.Getenv(UpdateGoldenEnv) != "",
+	}
+
+	for _, opt := range options {
+		opt(h)
+	}
+
+	var watcher sarah.ConfigWatcher
+	if h.configFile != "" {
+		watcher = &configFileReader{path: h.configFile}
+	}
+
+	command, err := sarah.BuildCommand(ctx, props, watcher)
+	if err != nil {
+		return nil, err
+	}
+	h.command = command
+
+	return h, nil
+}
+
+// RunCommandCases builds a CommandHarness and runs the given cases. The test fails immediately when the Command can not be built.
+func RunCommandCases(t *testing.T, props *sarah.CommandProps, cases []*CommandCase, options ...HarnessOption) {
+	t.Helper()
+
+	h, err := NewCommandHarness(context.Background(), props, options...)
+	if err != nil {
+		t.Fatalf("Failed to build command: %s.", err.Error())
+	}
+	h.Run(t, cases...)
+}
+
+// Command returns the built sarah.Command.
+func (h *CommandHarness) Command() sarah.Command {
+	return h.command
+}
+
+// Run runs each CommandCase as a subtest.
+func (h *CommandHarness) Run(t *testing.T, cases ...*CommandCase) {
+	t.Helper()
+
+	for i, c := range cases {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("%d:%s", i, c.Message)
+		}
+
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Helper()
+
+			result := h.verify(context.Background(), c)
+			for _, failure := range result.failures {
+				t.Error(failure)
+			}
+			if c.Check != nil {
+				c.Check(t, result.res, result.err)
+			}
+		})
+	}
+}
+
+type caseResult struct {
+	res      *sarah.CommandResponse
+	err      error
+	failures []string
+}
+
+// verify executes the given CommandCase and returns the result with the failure messages.
+func (h *CommandHarness) verify(ctx context.Context, c *CommandCase) *caseResult {
+	input := c.Input
+	if input == nil {
+		input = NewInput("testkit", c.Message)
+	}
+
+	result := &caseResult{}
+	if h.command.Match(input) == c.Unmatched {
+		if c.Unmatched {
+			result.failures = append(result.failures, fmt.Sprintf("Command matches the input unexpectedly: %s.", input.Message()))
+		} else {
+			result.failures = append(result.failures, fmt.Sprintf("Command does not match the input: %s.", input.Message()))
+		}
+		return result
+	}
+	if c.Unmatched {
+		return result
+	}
+
+	result.res, result.err = h.command.Execute(ctx, input)
+	if c.Err {
+		if result.err == nil {
+			result.failures = append(result.failures, "Expected error is not returned.")
+		}
+		return result
+	}
+	if result.err != nil {
+		result.failures = append(result.failures, fmt.Sprintf("Unexpected error is returned: %s.", result.err.Error()))
+		return result
+	}
+
+	var content interface{}
+	if result.res != nil {
+		content = result.res.Content
+	}
+
+	if c.Response != nil && !reflect.DeepEqual(c.Response, content) {
+		result.failures = append(result.failures, fmt.Sprintf("Unexpected response is returned.\nexpected: %#v\nactual:   %#v", c.Response, content))
+	}
+
+	if c.Golden != "" {
+		if failure := h.compareGolden(c.Golden, content); failure != "" {
+			result.failures = append(result.failures, failure)
+		}
+	}
+
+	return result
+}
+
+func (h *CommandHarness) compareGolden(name string, content interface{}) string {
+	actual, err := goldenBytes(content)
+	if err != nil {
+		return fmt.Sprintf("Failed to serialize response: %s.", err.Error())
+	}
+
+	path := filepath.Join(h.goldenDir, name)
+	if h.updateGolden {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, actual, 0644)
+		}
+		if err != nil {
+			return fmt.Sprintf("Failed to update golden file %s: %s.", path, err.Error())
+		}
+		return ""
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("Failed to read golden file %s: %s. Set %s to create one.", path, err.Error(), UpdateGoldenEnv)
+	}
+
+	if !bytes.Equal(bytes.TrimSpace(expected), bytes.TrimSpace(actual)) {
+		return fmt.Sprintf("Response does not match golden file %s.\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+	}
+
+	return ""
+}
+
+func goldenBytes(content interface{}) ([]byte, error) {
+	if str, ok := content.(string); ok {
+		return []byte(str + "\n"), nil
+	}
+
+	b, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// configFileReader is a sarah.ConfigWatcher that reads the configuration from a single file.
+type configFileReader struct {
+	path string
+}
+
+var _ sarah.ConfigWatcher = (*configFileReader)(nil)
+
+func (r *configFileReader) Read(_ context.Context, _ sarah.BotType, _ string, configPtr interface{}) error {
+	b, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", r.path, err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(r.path)); ext {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(b, configPtr)
+
+	case ".json":
+		return json.Unmarshal(b, configPtr)
+
+	default:
+		return fmt.Errorf("unsupported config file extension: %s", ext)
+
+	}
+}
+
+func (r *configFileReader) Watch(_ context.Context, _ sarah.BotType, _ string, _ func()) error {
+	return nil
+}
+
+func (r *configFileReader) Unwatch(_ sarah.BotType) error {
+	return nil
+}
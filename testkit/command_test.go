@@ -0,0 +1,159 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type echoConfig struct {
+	Prefix string `json:"prefix" yaml:"prefix"`
+}
+
+type richContent struct {
+	Title string   `json:"title"`
+	Items []string `json:"items"`
+}
+
+var echoPattern = regexp.MustCompile(`^\.echo`)
+
+func echoProps() *sarah.CommandProps {
+	return sarah.NewCommandPropsBuilder().
+		BotType("test").
+		Identifier("echo").
+		MatchPattern(echoPattern).
+		ConfigurableFunc(&echoConfig{Prefix: ""}, func(_ context.Context, input sarah.Input, cfg sarah.CommandConfig) (*sarah.CommandResponse, error) {
+			text := sarah.StripMessage(echoPattern, input.Message())
+			switch text {
+			case "":
+				return nil, errors.New("nothing to echo")
+
+			case "rich":
+				return &sarah.CommandResponse{Content: &richContent{Title: "hello", Items: []string{"a", "b"}}}, nil
+
+			default:
+				return &sarah.CommandResponse{Content: cfg.(*echoConfig).Prefix + text}, nil
+
+			}
+		}).
+		Instruction(".echo foo").
+		MustBuild()
+}
+
+func TestRunCommandCases(t *testing.T) {
+	checked := false
+	RunCommandCases(t, echoProps(), []*CommandCase{
+		{Message: ".echo foo", Response: "echo: foo"},
+		{Message: ".echo rich", Golden: "rich.json"},
+		{Message: ".echo", Err: true},
+		{Message: "echo foo", Unmatched: true},
+		{
+			Name:    "check",
+			Message: ".echo bar",
+			Check: func(t *testing.T, res *sarah.CommandResponse, err error) {
+				checked = true
+				if err != nil || res.Content != "echo: bar" {
+					t.Errorf("Unexpected result is given: %#v, %#v.", res, err)
+				}
+			},
+		},
+	}, WithConfigFile(filepath.Join("testdata", "echo.yaml")))
+
+	if !checked {
+		t.Error("Check function is not called.")
+	}
+}
+
+func TestNewCommandHarness_ConfigError(t *testing.T) {
+	_, err := NewCommandHarness(context.TODO(), echoProps(), WithConfigFile(filepath.Join("testdata", "echo.txt")))
+
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestCommandHarness_verify(t *testing.T) {
+	h, err := NewCommandHarness(context.TODO(), echoProps(), WithGoldenDir("testdata/golden"), WithUpdateGolden(false))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if h.Command().Identifier() != "echo" {
+		t.Errorf("Unexpected command is built: %s.", h.Command().Identifier())
+	}
+
+	tests := []struct {
+		c       *CommandCase
+		failure string
+	}{
+		{
+			c:       &CommandCase{Message: ".echo foo", Response: "bar"},
+			failure: "Unexpected response",
+		},
+		{
+			c:       &CommandCase{Message: "echo foo"},
+			failure: "does not match",
+		},
+		{
+			c:       &CommandCase{Message: ".echo foo", Unmatched: true},
+			failure: "matches the input unexpectedly",
+		},
+		{
+			c:       &CommandCase{Message: ".echo"},
+			failure: "Unexpected error",
+		},
+		{
+			c:       &CommandCase{Message: ".echo foo", Err: true},
+			failure: "Expected error is not returned",
+		},
+		{
+			c:       &CommandCase{Message: ".echo foo", Golden: "rich.json"},
+			failure: "does not match golden file",
+		},
+		{
+			c:       &CommandCase{Message: ".echo foo", Golden: "missing.json"},
+			failure: "Failed to read golden file",
+		},
+	}
+
+	for i, tt := range tests {
+		result := h.verify(context.TODO(), tt.c)
+
+		if len(result.failures) != 1 || !strings.Contains(result.failures[0], tt.failure) {
+			t.Errorf("Expected failure is not returned on test #%d: %#v.", i, result.failures)
+		}
+	}
+}
+
+func TestCommandHarness_UpdateGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testkit")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	h, err := NewCommandHarness(context.TODO(), echoProps(), WithGoldenDir(dir), WithUpdateGolden(true))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	result := h.verify(context.TODO(), &CommandCase{Message: ".echo foo", Golden: "nested/foo.txt"})
+	if len(result.failures) != 0 {
+		t.Fatalf("Unexpected failures are returned: %#v.", result.failures)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "nested", "foo.txt"))
+	if err != nil {
+		t.Fatalf("Golden file is not written: %s.", err.Error())
+	}
+
+	if string(b) != "foo\n" {
+		t.Errorf("Unexpected content is written: %s.", string(b))
+	}
+}
@@ -0,0 +1,359 @@
+package testkit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RecordKind tells whether a Record is an incoming Input or an outgoing Output.
+type RecordKind string
+
+const (
+	// RecordInput is the kind of the Record that represents an Input received from the chat service.
+	RecordInput RecordKind = "input"
+
+	// RecordOutput is the kind of the Record that represents an Output sent to the chat service.
+	RecordOutput RecordKind = "output"
+)
+
+// RecordedValue is the serialized form of a value such as an Output's content.
+// Type is the value's Go type such as "string" or "*sarah.RichMessage", and Value is its JSON form.
+type RecordedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// RecordedDestination is the serialized form of a sarah.OutputDestination.
+// The replayed Input replies to this destination, so an Output sent on replay can be compared with the recorded one.
+type RecordedDestination struct {
+	RecordedValue
+	ThreadID string `json:"thread_id,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+}
+
+// Record is a single line of the recording.
+type Record struct {
+	Kind       RecordKind `json:"kind"`
+	RecordedAt time.Time  `json:"recorded_at"`
+
+	// SenderKey, Message and SentAt are set for RecordInput.
+	SenderKey string    `json:"sender_key,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+
+	// Destination is the Input's ReplyTo for RecordInput, and the Output's Destination for RecordOutput.
+	Destination *RecordedDestination `json:"destination,omitempty"`
+
+	// Content is set for RecordOutput.
+	Content *RecordedValue `json:"content,omitempty"`
+}
+
+func encodeValue(value interface{}) *RecordedValue {
+	b, err := json.Marshal(value)
+	if err != nil {
+		// Keep the readable form so the recording is still comparable.
+		b, _ = json.Marshal(fmt.Sprintf("%+v", value))
+	}
+	return &RecordedValue{
+		Type:  fmt.Sprintf("%T", value),
+		Value: b,
+	}
+}
+
+func encodeDestination(destination sarah.OutputDestination) *RecordedDestination {
+	encoded := &RecordedDestination{
+		ThreadID: sarah.ThreadID(destination),
+		UserID:   sarah.PrivateUserID(destination),
+	}
+	switch base := sarah.BaseDestination(destination).(type) {
+	case *RecordedDestination:
+		// Sent on replay. Keep the originally recorded form.
+		encoded.RecordedValue = base.RecordedValue
+
+	default:
+		encoded.RecordedValue = *encodeValue(base)
+
+	}
+	return encoded
+}
+
+// replyTo restores the sarah.OutputDestination to reply to the replayed Input.
+func (d *RecordedDestination) replyTo() sarah.OutputDestination {
+	var destination sarah.OutputDestination = &RecordedDestination{RecordedValue: d.RecordedValue}
+	if d.ThreadID != "" {
+		destination = sarah.NewThreadDestination(destination, d.ThreadID)
+	}
+	if d.UserID != "" {
+		destination = sarah.NewPrivateDestination(destination, d.UserID)
+	}
+	return destination
+}
+
+func newInputRecord(input sarah.Input) *Record {
+	return &Record{
+		Kind:        RecordInput,
+		RecordedAt:  time.Now(),
+		SenderKey:   input.SenderKey(),
+		Message:     input.Message(),
+		SentAt:      input.SentAt(),
+		Destination: encodeDestination(input.ReplyTo()),
+	}
+}
+
+func newOutputRecord(output sarah.Output) *Record {
+	return &Record{
+		Kind:        RecordOutput,
+		RecordedAt:  time.Now(),
+		Destination: encodeDestination(output.Destination()),
+		Content:     encodeValue(output.Content()),
+	}
+}
+
+// key returns the comparable form of the Output record.
+func (r *Record) key() string {
+	b, _ := json.Marshal(&struct {
+		Destination *RecordedDestination `json:"destination"`
+		Content     *RecordedValue       `json:"content"`
+	}{
+		Destination: r.Destination,
+		Content:     r.Content,
+	})
+	return string(b)
+}
+
+// RecordingAdapter is a sarah.Adapter that wraps a real Adapter and records its traffic.
+// Every Input that the wrapped Adapter receives and every Output sent via the wrapped Adapter is written as a line of JSON.
+// Use NewRecordingAdapter to construct one.
+//
+//  f, _ := os.Create("traffic.jsonl")
+//  defer f.Close()
+//  sarah.RegisterBot(sarah.NewBot(testkit.NewRecordingAdapter(slackAdapter, f)))
+//
+// Be aware that the recording contains the users' messages as-is. Treat the file as sensitive data.
+type RecordingAdapter struct {
+	adapter sarah.Adapter
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	onError func(error)
+}
+
+var _ sarah.Adapter = (*RecordingAdapter)(nil)
+
+// RecorderOption defines function signature that RecordingAdapter's functional option must satisfy.
+type RecorderOption func(*RecordingAdapter)
+
+// WithRecordErrorHandler creates a RecorderOption that sets a function to be called when a record can not be written.
+// By default, such an error is ignored so the recording does not affect the bot.
+func WithRecordErrorHandler(fnc func(error)) RecorderOption {
+	return func(adapter *RecordingAdapter) {
+		adapter.onError = fnc
+	}
+}
+
+// NewRecordingAdapter creates a new RecordingAdapter that wraps the given sarah.Adapter and writes the records to the given io.Writer.
+func NewRecordingAdapter(adapter sarah.Adapter, w io.Writer, options ...RecorderOption) *RecordingAdapter {
+	recorder := &RecordingAdapter{
+		adapter: adapter,
+		encoder: json.NewEncoder(w),
+		onError: func(_ error) {},
+	}
+
+	for _, opt := range options {
+		opt(recorder)
+	}
+
+	return recorder
+}
+
+// BotType returns the wrapped Adapter's sarah.BotType.
+func (adapter *RecordingAdapter) BotType() sarah.BotType {
+	return adapter.adapter.BotType()
+}
+
+// Run runs the wrapped Adapter and records each Input.
+func (adapter *RecordingAdapter) Run(ctx context.Context, enqueueInput func(sarah.Input) error, notifyErr func(error)) {
+	adapter.adapter.Run(ctx, func(input sarah.Input) error {
+		adapter.write(newInputRecord(input))
+		return enqueueInput(input)
+	}, notifyErr)
+}
+
+// SendMessage records the given Output and sends it via the wrapped Adapter.
+func (adapter *RecordingAdapter) SendMessage(ctx context.Context, output sarah.Output) (*sarah.SendResult, error) {
+	adapter.write(newOutputRecord(output))
+	return adapter.adapter.SendMessage(ctx, output)
+}
+
+func (adapter *RecordingAdapter) write(record *Record) {
+	adapter.mutex.Lock()
+	defer adapter.mutex.Unlock()
+
+	err := adapter.encoder.Encode(record)
+	if err != nil {
+		adapter.onError(fmt.Errorf("failed to write record: %w", err))
+	}
+}
+
+// Recording is the series of the records that RecordingAdapter wrote.
+type Recording struct {
+	Records []*Record
+}
+
+// LoadRecording reads the records that RecordingAdapter wrote.
+func LoadRecording(r io.Reader) (*Recording, error) {
+	recording := &Recording{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		record := &Record{}
+		err := json.Unmarshal(scanner.Bytes(), record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse record at line %d: %w", line, err)
+		}
+		recording.Records = append(recording.Records, record)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	return recording, nil
+}
+
+// Inputs returns the recorded inputs in the order of reception.
+// Each Input replies to the recorded destination, so go-sarah's core sends the responses there.
+// Note that only the values available via sarah.Input are recorded;
+// the chat-service-specific values such as the sender's user ID are not restored.
+func (r *Recording) Inputs() []sarah.Input {
+	var inputs []sarah.Input
+	for _, record := range r.Records {
+		if record.Kind != RecordInput {
+			continue
+		}
+
+		var replyTo sarah.OutputDestination
+		if record.Destination != nil {
+			replyTo = record.Destination.replyTo()
+		}
+		inputs = append(inputs, &Input{
+			SenderKeyValue: record.SenderKey,
+			MessageValue:   record.Message,
+			SentAtValue:    record.SentAt,
+			ReplyToValue:   replyTo,
+		})
+	}
+	return inputs
+}
+
+// Outputs returns the recorded output records in the order of sending.
+func (r *Recording) Outputs() []*Record {
+	var outputs []*Record
+	for _, record := range r.Records {
+		if record.Kind == RecordOutput {
+			outputs = append(outputs, record)
+		}
+	}
+	return outputs
+}
+
+// CompareOutputs compares the given outputs sent on replay with the recorded ones, and returns the description of each difference.
+// The order is ignored since go-sarah's core handles inputs concurrently. An empty slice means no regression.
+func (r *Recording) CompareOutputs(outputs []sarah.Output) []string {
+	recorded := map[string]int{}
+	for _, record := range r.Outputs() {
+		recorded[record.key()]++
+	}
+
+	replayed := map[string]int{}
+	for _, output := range outputs {
+		replayed[newOutputRecord(output).key()]++
+	}
+
+	var diffs []string
+	for key, count := range recorded {
+		if missing := count - replayed[key]; missing > 0 {
+			diffs = append(diffs, fmt.Sprintf("missing %d time(s): %s", missing, key))
+		}
+	}
+	for key, count := range replayed {
+		if extra := count - recorded[key]; extra > 0 {
+			diffs = append(diffs, fmt.Sprintf("unexpected %d time(s): %s", extra, key))
+		}
+	}
+	sort.Strings(diffs)
+
+	return diffs
+}
+
+// ReplayAdapter is a sarah.Adapter that feeds the recorded inputs to go-sarah's core and records the responses.
+// Register this with sarah.NewBot and the commands under test, then compare the responses with the recording:
+//
+//  recording, _ := testkit.LoadRecording(f)
+//  adapter := testkit.NewReplayAdapter(slack.SLACK, recording)
+//  sarah.RegisterBot(sarah.NewBot(adapter))
+//  _ = sarah.Run(ctx, sarah.NewConfig())
+//
+//  outputs, _ := adapter.WaitSent(ctx, len(recording.Outputs()))
+//  for _, diff := range recording.CompareOutputs(outputs) {
+//    t.Error(diff)
+//  }
+//
+// The sent outputs are inspected with the methods of the embedded Adapter.
+type ReplayAdapter struct {
+	*Adapter
+	recording *Recording
+	replayed  chan struct{}
+}
+
+var _ sarah.Adapter = (*ReplayAdapter)(nil)
+
+// NewReplayAdapter creates a new ReplayAdapter with the given sarah.BotType and Recording.
+func NewReplayAdapter(botType sarah.BotType, recording *Recording, options ...AdapterOption) *ReplayAdapter {
+	return &ReplayAdapter{
+		Adapter:   NewAdapter(botType, options...),
+		recording: recording,
+		replayed:  make(chan struct{}),
+	}
+}
+
+// Run feeds the recorded inputs in the recorded order, and then accepts the pushed inputs and errors til the context is canceled.
+// An input that go-sarah's core rejects with *sarah.BlockedInputError is fed again after a short pause.
+func (adapter *ReplayAdapter) Run(ctx context.Context, enqueueInput func(sarah.Input) error, notifyErr func(error)) {
+	for _, input := range adapter.recording.Inputs() {
+		for {
+			err := enqueueInput(input)
+			if _, ok := err.(*sarah.BlockedInputError); !ok {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-time.After(10 * time.Millisecond):
+				// Try again.
+
+			}
+		}
+	}
+	close(adapter.replayed)
+
+	adapter.Adapter.Run(ctx, enqueueInput, notifyErr)
+}
+
+// Replayed returns a channel that is closed when all recorded inputs are fed.
+func (adapter *ReplayAdapter) Replayed() <-chan struct{} {
+	return adapter.replayed
+}
@@ -0,0 +1,208 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordingAdapter(t *testing.T) {
+	wrapped := NewAdapter("test")
+	buf := &bytes.Buffer{}
+	adapter := NewRecordingAdapter(wrapped, buf)
+
+	if adapter.BotType() != "test" {
+		t.Errorf("Unexpected BotType is returned: %s.", adapter.BotType())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	enqueued := make(chan sarah.Input, 1)
+	go adapter.Run(ctx, func(input sarah.Input) error {
+		enqueued <- input
+		return nil
+	}, func(_ error) {})
+
+	input := &Input{
+		SenderKeyValue: "user1",
+		MessageValue:   ".echo hello",
+		SentAtValue:    time.Now(),
+		ReplyToValue:   sarah.NewThreadDestination("channel1", "thread1"),
+	}
+	err := wrapped.Push(ctx, input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if <-enqueued != input {
+		t.Error("Input is not passed to the given function.")
+	}
+
+	output := sarah.NewOutputMessage(input.ReplyTo(), map[string]string{"text": "hello"})
+	adapter.SendMessage(ctx, output)
+	if len(wrapped.Sent()) != 1 {
+		t.Errorf("Output is not sent via the wrapped Adapter: %#v.", wrapped.Sent())
+	}
+
+	recording, err := LoadRecording(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	inputs := recording.Inputs()
+	if len(inputs) != 1 {
+		t.Fatalf("Unexpected inputs are loaded: %#v.", inputs)
+	}
+	if inputs[0].SenderKey() != "user1" || inputs[0].Message() != ".echo hello" || !inputs[0].SentAt().Equal(input.SentAt()) {
+		t.Errorf("Unexpected input is loaded: %#v.", inputs[0])
+	}
+	if sarah.ThreadID(inputs[0].ReplyTo()) != "thread1" {
+		t.Errorf("Thread ID is not restored: %#v.", inputs[0].ReplyTo())
+	}
+
+	if len(recording.Outputs()) != 1 {
+		t.Fatalf("Unexpected outputs are loaded: %#v.", recording.Outputs())
+	}
+
+	// Responses to the replayed input should be identical to the recorded one.
+	replayed := sarah.NewOutputMessage(inputs[0].ReplyTo(), map[string]string{"text": "hello"})
+	diffs := recording.CompareOutputs([]sarah.Output{replayed})
+	if len(diffs) != 0 {
+		t.Errorf("Unexpected differences are returned: %#v.", diffs)
+	}
+}
+
+func TestRecordingAdapter_WriteError(t *testing.T) {
+	var recordErr error
+	adapter := NewRecordingAdapter(NewAdapter("test"), &failingWriter{}, WithRecordErrorHandler(func(err error) {
+		recordErr = err
+	}))
+
+	adapter.SendMessage(context.TODO(), sarah.NewOutputMessage("channel1", "hello"))
+
+	if recordErr == nil {
+		t.Error("Expected error is not passed.")
+	}
+}
+
+type failingWriter struct{}
+
+func (w *failingWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func TestLoadRecording(t *testing.T) {
+	tests := []struct {
+		lines string
+		count int
+		err   bool
+	}{
+		{
+			lines: `{"kind":"input","sender_key":"user1","message":"hello","destination":{"type":"string","value":"\"user1\""}}` + "\n\n" +
+				`{"kind":"output","destination":{"type":"string","value":"\"user1\""},"content":{"type":"string","value":"\"hi\""}}`,
+			count: 2,
+		},
+		{
+			lines: "{invalid",
+			err:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		recording, err := LoadRecording(strings.NewReader(tt.lines))
+		if tt.err {
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+		if len(recording.Records) != tt.count {
+			t.Errorf("Unexpected records are loaded on test #%d: %#v.", i, recording.Records)
+		}
+	}
+}
+
+func TestRecording_CompareOutputs(t *testing.T) {
+	recording := &Recording{
+		Records: []*Record{
+			newOutputRecord(sarah.NewOutputMessage("channel1", "hello")),
+			newOutputRecord(sarah.NewOutputMessage("channel1", "hello")),
+			newOutputRecord(sarah.NewOutputMessage("channel2", "bye")),
+		},
+	}
+
+	// Order does not matter.
+	diffs := recording.CompareOutputs([]sarah.Output{
+		sarah.NewOutputMessage("channel2", "bye"),
+		sarah.NewOutputMessage("channel1", "hello"),
+		sarah.NewOutputMessage("channel1", "hello"),
+	})
+	if len(diffs) != 0 {
+		t.Errorf("Unexpected differences are returned: %#v.", diffs)
+	}
+
+	diffs = recording.CompareOutputs([]sarah.Output{
+		sarah.NewOutputMessage("channel1", "hello"),
+		sarah.NewOutputMessage("channel2", "changed"),
+	})
+	if len(diffs) != 3 {
+		t.Fatalf("Unexpected differences are returned: %#v.", diffs)
+	}
+	if !strings.HasPrefix(diffs[0], "missing 1 time(s)") || !strings.Contains(diffs[0], "hello") {
+		t.Errorf("Unexpected difference is returned: %s.", diffs[0])
+	}
+	if !strings.HasPrefix(diffs[2], "unexpected 1 time(s)") || !strings.Contains(diffs[2], "changed") {
+		t.Errorf("Unexpected difference is returned: %s.", diffs[2])
+	}
+}
+
+func TestReplayAdapter_Run(t *testing.T) {
+	recording := &Recording{
+		Records: []*Record{
+			newInputRecord(NewInput("user1", "first")),
+			newOutputRecord(sarah.NewOutputMessage("user1", "ignored")),
+			newInputRecord(NewInput("user1", "second")),
+		},
+	}
+	adapter := NewReplayAdapter("test", recording)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var messages []string
+	blocked := false
+	go adapter.Run(ctx, func(input sarah.Input) error {
+		if !blocked {
+			blocked = true
+			return &sarah.BlockedInputError{ContinuationCount: 1}
+		}
+		messages = append(messages, input.Message())
+		return nil
+	}, func(_ error) {})
+
+	select {
+	case <-adapter.Replayed():
+		// O.K.
+
+	case <-time.After(1 * time.Second):
+		t.Fatal("Recorded inputs are not replayed.")
+
+	}
+
+	if len(messages) != 2 || messages[0] != "first" || messages[1] != "second" {
+		t.Errorf("Unexpected inputs are enqueued: %#v.", messages)
+	}
+
+	err := adapter.WaitRunning(ctx)
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
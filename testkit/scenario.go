@@ -0,0 +1,353 @@
+package testkit
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ScenarioOption defines function signature that Scenario's functional option must satisfy.
+type ScenarioOption func(*Scenario)
+
+// WithScenarioCommandProps creates a ScenarioOption that builds the Commands from the given sarah.CommandProps and appends them to the Bot.
+// The configurable Command uses the configuration value given to sarah.CommandPropsBuilder.ConfigurableFunc.
+func WithScenarioCommandProps(props ...*sarah.CommandProps) ScenarioOption {
+	return func(s *Scenario) {
+		s.commandProps = append(s.commandProps, props...)
+	}
+}
+
+// WithScenarioCommands creates a ScenarioOption that appends the given Commands to the Bot.
+func WithScenarioCommands(commands ...sarah.Command) ScenarioOption {
+	return func(s *Scenario) {
+		s.commands = append(s.commands, commands...)
+	}
+}
+
+// WithSender creates a ScenarioOption that sets the sender key of the user inputs. The default is "testkit".
+func WithSender(senderKey string) ScenarioOption {
+	return func(s *Scenario) {
+		s.senderKey = senderKey
+	}
+}
+
+// WithContextTTL creates a ScenarioOption that sets the duration that a stored sarah.UserContext lives.
+// The default is the same as sarah.NewCacheConfig's ExpiresIn.
+func WithContextTTL(ttl time.Duration) ScenarioOption {
+	return func(s *Scenario) {
+		s.storage.ttl = ttl
+	}
+}
+
+// WithBotOptions creates a ScenarioOption that passes the given sarah.DefaultBotOption to sarah.NewBot.
+// Passing sarah.BotWithStorage replaces the Scenario's storage, and hence disables Advance and the context assertions.
+func WithBotOptions(options ...sarah.DefaultBotOption) ScenarioOption {
+	return func(s *Scenario) {
+		s.botOptions = append(s.botOptions, options...)
+	}
+}
+
+// Scenario tests a multi-step conversation with go-sarah's default Bot implementation.
+// Each step sends a user message and asserts the replies and the conversational context that the step left.
+// The stored sarah.UserContext expires based on the Scenario's own clock, so Advance lets the test check the expiration without waiting:
+//
+//  testkit.NewScenario(t, "slack", testkit.WithScenarioCommandProps(order.Props)).
+//    Say(".order").
+//    ExpectReply("What size?").
+//    ExpectInContext().
+//    Say("large").
+//    ExpectReplyContains("Confirm").
+//    Advance(5 * time.Minute).
+//    ExpectNoContext().
+//    Say("yes").
+//    ExpectNoReply()
+//
+// Each assertion marks the test as failed and lets the following steps run, just like testing.T.Error.
+type Scenario struct {
+	t            *testing.T
+	ctx          context.Context
+	adapter      *Adapter
+	bot          sarah.Bot
+	storage      *scenarioStorage
+	senderKey    string
+	commandProps []*sarah.CommandProps
+	commands     []sarah.Command
+	botOptions   []sarah.DefaultBotOption
+
+	// step describes the latest user input for the failure messages.
+	step string
+	// replies are the outputs sent on the latest step that are not yet consumed by ExpectReply and its variants.
+	replies []sarah.Output
+	err     error
+}
+
+// NewScenario creates a new Scenario with the given sarah.BotType and zero or more ScenarioOption.
+// The test fails immediately when a Command can not be built.
+func NewScenario(t *testing.T, botType sarah.BotType, options ...ScenarioOption) *Scenario {
+	t.Helper()
+
+	s := &Scenario{
+		t:         t,
+		ctx:       context.Background(),
+		adapter:   NewAdapter(botType),
+		senderKey: "testkit",
+		storage: &scenarioStorage{
+			ttl:     sarah.NewCacheConfig().ExpiresIn,
+			now:     time.Now(),
+			entries: map[string]*scenarioEntry{},
+		},
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	botOptions := append([]sarah.DefaultBotOption{sarah.BotWithStorage(s.storage)}, s.botOptions...)
+	s.bot = sarah.NewBot(s.adapter, botOptions...)
+
+	for _, props := range s.commandProps {
+		command, err := sarah.BuildCommand(s.ctx, props, nil)
+		if err != nil {
+			t.Fatalf("Failed to build command: %s.", err.Error())
+		}
+		s.bot.AppendCommand(command)
+	}
+	for _, command := range s.commands {
+		s.bot.AppendCommand(command)
+	}
+
+	return s
+}
+
+// Say sends the given message as the user's input.
+func (s *Scenario) Say(message string) *Scenario {
+	s.t.Helper()
+
+	return s.SayInput(&Input{
+		SenderKeyValue: s.senderKey,
+		MessageValue:   message,
+		SentAtValue:    s.storage.currentTime(),
+		ReplyToValue:   s.senderKey,
+	})
+}
+
+// Abort sends sarah.AbortInput to leave the current conversational context.
+func (s *Scenario) Abort() *Scenario {
+	s.t.Helper()
+
+	return s.SayInput(sarah.NewAbortInput(NewInput(s.senderKey, ".abort")))
+}
+
+// Help sends sarah.HelpInput to ask for the list of the commands.
+func (s *Scenario) Help() *Scenario {
+	s.t.Helper()
+
+	return s.SayInput(sarah.NewHelpInput(NewInput(s.senderKey, ".help")))
+}
+
+// SayInput sends the given input. Unconsumed replies of the previous step are discarded.
+func (s *Scenario) SayInput(input sarah.Input) *Scenario {
+	s.t.Helper()
+
+	s.step = input.Message()
+	before := len(s.adapter.Sent())
+	s.err = s.bot.Respond(s.ctx, input)
+	s.replies = s.adapter.Sent()[before:]
+
+	return s
+}
+
+// Advance moves the Scenario's clock forward by the given duration so the stored sarah.UserContext may expire.
+func (s *Scenario) Advance(d time.Duration) *Scenario {
+	s.storage.advance(d)
+	return s
+}
+
+// ExpectReply asserts the next reply's content equals to the given value with reflect.DeepEqual.
+func (s *Scenario) ExpectReply(content interface{}) *Scenario {
+	s.t.Helper()
+
+	reply, ok := s.nextReply()
+	if !ok {
+		return s
+	}
+
+	if !reflect.DeepEqual(reply.Content(), content) {
+		s.t.Errorf("Unexpected reply is sent on %q.\nexpected: %#v\nactual:   %#v", s.step, content, reply.Content())
+	}
+	return s
+}
+
+// ExpectReplyContains asserts the next reply is a string that contains the given substring.
+func (s *Scenario) ExpectReplyContains(substr string) *Scenario {
+	s.t.Helper()
+
+	reply, ok := s.nextReply()
+	if !ok {
+		return s
+	}
+
+	text, isString := reply.Content().(string)
+	if !isString || !strings.Contains(text, substr) {
+		s.t.Errorf("Reply on %q does not contain %q: %#v.", s.step, substr, reply.Content())
+	}
+	return s
+}
+
+// ExpectReplyFunc passes the next reply to the given function for further assertions.
+func (s *Scenario) ExpectReplyFunc(fnc func(t *testing.T, reply sarah.Output)) *Scenario {
+	s.t.Helper()
+
+	reply, ok := s.nextReply()
+	if !ok {
+		return s
+	}
+
+	fnc(s.t, reply)
+	return s
+}
+
+// ExpectNoReply asserts no more reply is sent on the latest step.
+func (s *Scenario) ExpectNoReply() *Scenario {
+	s.t.Helper()
+
+	if len(s.replies) > 0 {
+		s.t.Errorf("Unexpected reply is sent on %q: %#v.", s.step, s.replies[0].Content())
+	}
+	return s
+}
+
+// ExpectError asserts the latest step returned an error.
+func (s *Scenario) ExpectError() *Scenario {
+	s.t.Helper()
+
+	if s.err == nil {
+		s.t.Errorf("Expected error is not returned on %q.", s.step)
+	}
+	return s
+}
+
+// ExpectInContext asserts the user is in the middle of a conversation, which means the next input is given to the stored sarah.UserContext.
+func (s *Scenario) ExpectInContext() *Scenario {
+	s.t.Helper()
+
+	if s.storage.get(s.senderKey) == nil {
+		s.t.Errorf("User is not in conversational context after %q.", s.step)
+	}
+	return s
+}
+
+// ExpectNoContext asserts the user is not in the middle of a conversation.
+func (s *Scenario) ExpectNoContext() *Scenario {
+	s.t.Helper()
+
+	if s.storage.get(s.senderKey) != nil {
+		s.t.Errorf("User is unexpectedly in conversational context after %q.", s.step)
+	}
+	return s
+}
+
+// UserContext returns the stored sarah.UserContext of the user, or nil when there is none or it is expired.
+func (s *Scenario) UserContext() *sarah.UserContext {
+	return s.storage.get(s.senderKey)
+}
+
+func (s *Scenario) nextReply() (sarah.Output, bool) {
+	s.t.Helper()
+
+	if s.err != nil {
+		s.t.Errorf("Unexpected error is returned on %q: %s.", s.step, s.err.Error())
+	}
+
+	if len(s.replies) == 0 {
+		s.t.Errorf("Expected reply is not sent on %q.", s.step)
+		return nil, false
+	}
+
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply, true
+}
+
+type scenarioEntry struct {
+	userContext *sarah.UserContext
+	expiresAt   time.Time
+}
+
+// scenarioStorage is a sarah.UserContextStorage implementation that expires the stored contexts with a controllable clock.
+type scenarioStorage struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	now     time.Time
+	entries map[string]*scenarioEntry
+}
+
+var _ sarah.UserContextStorage = (*scenarioStorage)(nil)
+
+func (s *scenarioStorage) Get(key string) (sarah.ContextualFunc, error) {
+	userContext := s.get(key)
+	if userContext == nil {
+		return nil, nil
+	}
+	return userContext.Next, nil
+}
+
+func (s *scenarioStorage) Set(key string, userContext *sarah.UserContext) error {
+	if userContext.Next == nil {
+		return fmt.Errorf("UserContext.Next is not set for %s", key)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[key] = &scenarioEntry{
+		userContext: userContext,
+		expiresAt:   s.now.Add(s.ttl),
+	}
+	return nil
+}
+
+func (s *scenarioStorage) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *scenarioStorage) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = map[string]*scenarioEntry{}
+	return nil
+}
+
+func (s *scenarioStorage) get(key string) *sarah.UserContext {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !s.now.Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry.userContext
+}
+
+func (s *scenarioStorage) advance(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *scenarioStorage) currentTime() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.now
+}
@@ -0,0 +1,101 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func orderProps() *sarah.CommandProps {
+	var confirm sarah.ContextualFunc = func(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+		if input.Message() != "yes" {
+			return nil, errors.New("not confirmed")
+		}
+		return &sarah.CommandResponse{Content: "Ordered."}, nil
+	}
+	var size sarah.ContextualFunc = func(_ context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+		return &sarah.CommandResponse{
+			Content:     "Confirm " + input.Message() + "?",
+			UserContext: sarah.NewUserContext(confirm),
+		}, nil
+	}
+
+	return sarah.NewCommandPropsBuilder().
+		BotType("test").
+		Identifier("order").
+		MatchPattern(regexp.MustCompile(`^\.order`)).
+		Func(func(_ context.Context, _ sarah.Input) (*sarah.CommandResponse, error) {
+			return &sarah.CommandResponse{
+				Content:     "What size?",
+				UserContext: sarah.NewUserContext(size),
+			}, nil
+		}).
+		Instruction(".order").
+		MustBuild()
+}
+
+func TestScenario(t *testing.T) {
+	NewScenario(t, "test", WithScenarioCommandProps(orderProps()), WithSender("user1")).
+		Say(".order").
+		ExpectReply("What size?").
+		ExpectNoReply().
+		ExpectInContext().
+		Say("large").
+		ExpectReplyContains("large").
+		ExpectInContext().
+		Say("yes").
+		ExpectReplyFunc(func(t *testing.T, reply sarah.Output) {
+			if reply.Destination() != "user1" {
+				t.Errorf("Unexpected destination is set: %#v.", reply.Destination())
+			}
+		}).
+		ExpectNoContext()
+}
+
+func TestScenario_Advance(t *testing.T) {
+	s := NewScenario(t, "test", WithScenarioCommandProps(orderProps()), WithContextTTL(1*time.Minute)).
+		Say(".order").
+		ExpectReply("What size?").
+		Advance(59 * time.Second).
+		ExpectInContext().
+		Advance(1 * time.Second).
+		ExpectNoContext().
+		Say("large").
+		ExpectNoReply()
+
+	if s.UserContext() != nil {
+		t.Errorf("Expired context is returned: %#v.", s.UserContext())
+	}
+}
+
+func TestScenario_Abort(t *testing.T) {
+	NewScenario(t, "test", WithScenarioCommandProps(orderProps())).
+		Say(".order").
+		ExpectInContext().
+		Abort().
+		ExpectNoReply().
+		ExpectNoContext()
+}
+
+func TestScenario_Error(t *testing.T) {
+	NewScenario(t, "test", WithScenarioCommandProps(orderProps())).
+		Say(".order").
+		Say("small").
+		Say("no").
+		ExpectError().
+		ExpectNoContext()
+}
+
+func TestScenario_Help(t *testing.T) {
+	NewScenario(t, "test", WithScenarioCommands(&DummyCommand{IdentifierValue: "dummy"}), WithScenarioCommandProps(orderProps())).
+		Help().
+		ExpectReplyFunc(func(t *testing.T, reply sarah.Output) {
+			helps, ok := reply.Content().(*sarah.CommandHelps)
+			if !ok || len(*helps) != 1 {
+				t.Errorf("Unexpected helps are sent: %#v.", reply.Content())
+			}
+		})
+}
@@ -0,0 +1,126 @@
+/*
+Package otel provides tracing.Tracer implementation that bridges go-sarah's spans to OpenTelemetry.
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	defer provider.Shutdown(context.Background())
+
+	sarah.RegisterTracer(otel.New(provider.Tracer("github.com/oklahomer/go-sarah")))
+
+Because the span is stored in the context by OpenTelemetry itself, spans started by other instrumented libraries
+in a Command become children of the go-sarah's spans.
+The trace context is propagated to the outgoing HTTP requests with the global TextMapPropagator by default.
+
+This package is a separate Go module so the applications that do not use OpenTelemetry do not depend on it.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+*/
+package otel
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/tracing"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+type tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ tracing.Tracer = (*tracer)(nil)
+
+var _ tracing.Propagator = (*tracer)(nil)
+
+// Option defines a function signature that New accepts to customize the Tracer.
+type Option func(*tracer)
+
+// WithPropagator creates and returns an Option that replaces the global TextMapPropagator
+// with the given one to inject the trace context to the outgoing HTTP requests.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(t *tracer) {
+		t.propagator = propagator
+	}
+}
+
+// New creates and returns a new tracing.Tracer that starts spans with the given OpenTelemetry's trace.Tracer.
+// The returned Tracer also satisfies tracing.Propagator.
+func New(t trace.Tracer, options ...Option) tracing.Tracer {
+	tr := &tracer{
+		tracer: t,
+	}
+	for _, opt := range options {
+		opt(tr)
+	}
+	return tr
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithAttributes(attributes(attrs)...))
+	return ctx, &span{span: s}
+}
+
+func (t *tracer) Inject(ctx context.Context, header http.Header) {
+	propagator := t.propagator
+	if propagator == nil {
+		// Refer to the global one on each call so the propagator set after New is still respected.
+		propagator = otelapi.GetTextMapPropagator()
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+type span struct {
+	span trace.Span
+}
+
+var _ tracing.Span = (*span)(nil)
+
+func (s *span) SetAttributes(attrs ...tracing.Attribute) {
+	s.span.SetAttributes(attributes(attrs)...)
+}
+
+func (s *span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *span) End() {
+	s.span.End()
+}
+
+// attributes converts the given tracing.Attribute to OpenTelemetry's attribute.KeyValue.
+// Values of the types that OpenTelemetry natively supports are kept as they are, while others are stringified.
+func attributes(attrs []tracing.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(a.Key, v))
+
+		case bool:
+			kvs = append(kvs, attribute.Bool(a.Key, v))
+
+		case int:
+			kvs = append(kvs, attribute.Int(a.Key, v))
+
+		case int64:
+			kvs = append(kvs, attribute.Int64(a.Key, v))
+
+		case float64:
+			kvs = append(kvs, attribute.Float64(a.Key, v))
+
+		case fmt.Stringer:
+			kvs = append(kvs, attribute.String(a.Key, v.String()))
+
+		default:
+			kvs = append(kvs, attribute.String(a.Key, fmt.Sprint(v)))
+
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,88 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type botType string
+
+func (b botType) String() string {
+	return string(b)
+}
+
+func newTracer(options ...Option) (tracing.Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return New(provider.Tracer("test"), options...), recorder
+}
+
+func TestTracer_Start(t *testing.T) {
+	tracer, recorder := newTracer()
+
+	ctx, parent := tracer.Start(context.TODO(), "parent", tracing.A("bot_type", botType("slack")), tracing.A("count", 1))
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttributes(tracing.A("timeout", time.Second))
+	child.RecordError(errors.New("failure"))
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Unexpected number of spans are ended: %d.", len(spans))
+	}
+
+	c, p := spans[0], spans[1]
+	if p.Name() != "parent" || c.Name() != "child" {
+		t.Fatalf("Unexpected spans are ended: %s and %s.", c.Name(), p.Name())
+	}
+
+	if c.Parent().SpanID() != p.SpanContext().SpanID() {
+		t.Error("Span started with the returned context is not a child.")
+	}
+
+	expected := map[attribute.Key]attribute.Value{
+		"bot_type": attribute.StringValue("slack"),
+		"count":    attribute.IntValue(1),
+	}
+	for _, kv := range p.Attributes() {
+		if kv.Value != expected[kv.Key] {
+			t.Errorf("Unexpected attribute is set: %s=%s.", kv.Key, kv.Value.Emit())
+		}
+	}
+
+	if len(c.Attributes()) != 1 || c.Attributes()[0].Value.AsString() != "1s" {
+		t.Errorf("Unexpected attributes are set: %#v.", c.Attributes())
+	}
+
+	if c.Status().Code != codes.Error || c.Status().Description != "failure" {
+		t.Errorf("Unexpected status is set: %#v.", c.Status())
+	}
+
+	if len(c.Events()) != 1 || c.Events()[0].Name != "exception" {
+		t.Errorf("Error is not recorded as an event: %#v.", c.Events())
+	}
+}
+
+func TestTracer_Inject(t *testing.T) {
+	tracer, _ := newTracer(WithPropagator(propagation.TraceContext{}))
+
+	ctx, span := tracer.Start(context.TODO(), "request")
+	defer span.End()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	tracing.InjectHTTP(tracing.NewContext(ctx, tracer), req)
+
+	if req.Header.Get("traceparent") == "" {
+		t.Error("Trace context is not injected.")
+	}
+}
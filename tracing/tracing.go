@@ -0,0 +1,143 @@
+/*
+Package tracing provides a small tracing abstraction that go-sarah uses to instrument its message handling pipeline.
+
+The core starts a span on each stage of the pipeline with the Tracer carried by the context:
+
+	sarah.receive_input     from the reception of an Input to the end of its handling
+	sarah.execute_command   the execution of the matched Command
+	sarah.execute_context   the execution of the stored UserContext
+	sarah.send_message      a call to Bot.SendMessage
+
+Adapters also start spans on their outgoing HTTP requests and call InjectHTTP so the trace context is propagated.
+
+By default, the Tracer is a no-op one so tracing costs nothing.
+To analyze the latency with OpenTelemetry compatible backends such as Jaeger or Tempo,
+pass the Tracer provided by the tracing/otel package to sarah.RegisterTracer or SetTracer:
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	sarah.RegisterTracer(otel.New(provider.Tracer("github.com/oklahomer/go-sarah")))
+
+The tracing/otel package is a separate Go module so the applications that do not use OpenTelemetry do not depend on it.
+Because the span is stored in the context by OpenTelemetry itself, spans started by other instrumented libraries
+in a Command become children of the go-sarah's spans.
+*/
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Attribute represents a key-value pair attached to a Span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// A creates and returns a new Attribute with the given key and value.
+func A(key string, value interface{}) Attribute {
+	return Attribute{
+		Key:   key,
+		Value: value,
+	}
+}
+
+// Span represents a single operation within a trace.
+type Span interface {
+	// SetAttributes attaches the given attributes to the span.
+	SetAttributes(attrs ...Attribute)
+
+	// RecordError records the given error and marks the span as failed.
+	RecordError(err error)
+
+	// End completes the span.
+	End()
+}
+
+// Tracer defines an interface that starts a Span.
+type Tracer interface {
+	// Start starts a new Span with the given name and returns a copy of the given context that carries the span,
+	// so the span started with the returned context becomes a child of the returned span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Propagator is an optional interface that a Tracer implementation may satisfy to propagate the trace context to a remote service.
+type Propagator interface {
+	// Inject writes the trace context carried by the given context to the given HTTP header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+type noopTracer struct{}
+
+type noopSpan struct{}
+
+var _ Tracer = (*noopTracer)(nil)
+
+var _ Span = (*noopSpan)(nil)
+
+// NewNoopTracer creates and returns a Tracer that does nothing.
+func NewNoopTracer() Tracer {
+	return &noopTracer{}
+}
+
+func (*noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, &noopSpan{}
+}
+
+func (*noopSpan) SetAttributes(_ ...Attribute) {}
+
+func (*noopSpan) RecordError(_ error) {}
+
+func (*noopSpan) End() {}
+
+var (
+	current      = NewNoopTracer()
+	currentMutex sync.RWMutex
+)
+
+// SetTracer replaces the package-level Tracer.
+func SetTracer(tracer Tracer) {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+
+	current = tracer
+}
+
+// GetTracer returns the package-level Tracer.
+func GetTracer() Tracer {
+	currentMutex.RLock()
+	defer currentMutex.RUnlock()
+
+	return current
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of the given context that carries the given Tracer.
+// go-sarah's core passes a context with a Tracer to Bot and Adapter so they can trace with the Tracer of the Runner they belong to.
+func NewContext(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, contextKey{}, tracer)
+}
+
+// FromContext returns the Tracer carried by the given context.
+// When the context carries none, the package-level Tracer returned by GetTracer is returned.
+func FromContext(ctx context.Context) Tracer {
+	if tracer, ok := ctx.Value(contextKey{}).(Tracer); ok {
+		return tracer
+	}
+	return GetTracer()
+}
+
+// Start starts a new Span with the Tracer carried by the given context.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return FromContext(ctx).Start(ctx, name, attrs...)
+}
+
+// InjectHTTP writes the trace context carried by the given context to the given request's header.
+// This does nothing when the Tracer carried by the context does not satisfy Propagator.
+func InjectHTTP(ctx context.Context, req *http.Request) {
+	if propagator, ok := FromContext(ctx).(Propagator); ok {
+		propagator.Inject(ctx, req.Header)
+	}
+}
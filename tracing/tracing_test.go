@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type DummyTracer struct {
+	StartFunc  func(context.Context, string, ...Attribute) (context.Context, Span)
+	InjectFunc func(context.Context, http.Header)
+}
+
+func (t *DummyTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return t.StartFunc(ctx, name, attrs...)
+}
+
+func (t *DummyTracer) Inject(ctx context.Context, header http.Header) {
+	t.InjectFunc(ctx, header)
+}
+
+func TestA(t *testing.T) {
+	attr := A("key", "value")
+
+	if attr.Key != "key" {
+		t.Errorf("Unexpected key is set: %s.", attr.Key)
+	}
+
+	if attr.Value != "value" {
+		t.Errorf("Unexpected value is set: %#v.", attr.Value)
+	}
+}
+
+func TestNewNoopTracer(t *testing.T) {
+	ctx := context.Background()
+	tracer := NewNoopTracer()
+
+	spanCtx, span := tracer.Start(ctx, "dummy", A("key", "value"))
+	if spanCtx != ctx {
+		t.Error("Given context must be returned as-is.")
+	}
+
+	// Make sure the calls do not panic.
+	span.SetAttributes(A("key", "value"))
+	span.RecordError(errors.New("dummy"))
+	span.End()
+}
+
+func TestSetTracer(t *testing.T) {
+	old := GetTracer()
+	defer SetTracer(old)
+
+	tracer := &DummyTracer{}
+	SetTracer(tracer)
+
+	if GetTracer() != tracer {
+		t.Error("Given Tracer is not set.")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) != GetTracer() {
+		t.Error("Package-level Tracer must be returned when the context carries none.")
+	}
+
+	tracer := &DummyTracer{}
+	ctx := NewContext(context.Background(), tracer)
+	if FromContext(ctx) != tracer {
+		t.Error("Tracer carried by the context is not returned.")
+	}
+}
+
+func TestStart(t *testing.T) {
+	var name string
+	tracer := &DummyTracer{
+		StartFunc: func(ctx context.Context, n string, _ ...Attribute) (context.Context, Span) {
+			name = n
+			return ctx, &noopSpan{}
+		},
+	}
+	ctx := NewContext(context.Background(), tracer)
+
+	_, _ = Start(ctx, "dummy")
+
+	if name != "dummy" {
+		t.Errorf("Tracer carried by the context is not used: %s.", name)
+	}
+}
+
+func TestInjectHTTP(t *testing.T) {
+	tracer := &DummyTracer{
+		InjectFunc: func(_ context.Context, header http.Header) {
+			header.Set("traceparent", "dummy")
+		},
+	}
+	ctx := NewContext(context.Background(), tracer)
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+
+	InjectHTTP(ctx, req)
+
+	if req.Header.Get("traceparent") != "dummy" {
+		t.Errorf("Trace context is not injected: %#v.", req.Header)
+	}
+
+	// Tracer without Propagator implementation does nothing.
+	noop, _ := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	InjectHTTP(context.Background(), noop)
+	if len(noop.Header) != 0 {
+		t.Errorf("Unexpected header is set: %#v.", noop.Header)
+	}
+}
@@ -0,0 +1,151 @@
+package sarah
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConfigDefaulter is an optional interface that a configuration struct may implement to fill the unset values with the defaults.
+// Only the values whose zero value can not be a valid setting, such as a nil pointer or an empty time zone, should be filled;
+// a zero value that may be a typo such as zero queue size should be left for Validate to report.
+type ConfigDefaulter interface {
+	ApplyDefaults()
+}
+
+// ConfigValidator is an optional interface that a configuration struct may implement to check its values.
+// Validate should report all invalid values at once instead of returning on the first one.
+// The nested configuration structs are validated by their own Validate methods, so this only needs to check the struct's own fields.
+type ConfigValidator interface {
+	Validate() error
+}
+
+// ValidateConfig applies the defaults and validates the given configuration struct and all configuration structs nested in it.
+// For each struct, ConfigDefaulter.ApplyDefaults is called before its nested structs are visited and ConfigValidator.Validate is called after.
+// Call this at startup with the application's whole configuration so all invalid values are reported at once:
+//
+//  config := &myConfig{Runner: sarah.NewConfig(), Slack: slack.NewConfig()}
+//  _ = yaml.Unmarshal(body, config)
+//  err := sarah.ValidateConfig(config)
+//  // e.g. "invalid slack: token is empty\ninvalid runner.worker: QueueSize must be greater than zero"
+//
+// When any struct is invalid, ConfigKeyErrors is returned and each ConfigKeyError.Key is the yaml key path of the struct.
+// Run calls this with the given Config, and the configurations of CommandProps and ScheduledTaskProps are validated on every (re)load.
+func ValidateConfig(config interface{}) error {
+	errs := validateConfig(reflect.ValueOf(config), nil, map[visitedConfig]bool{})
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validatePluginConfig validates the configuration read for CommandProps or ScheduledTaskProps.
+func validatePluginConfig(config interface{}) error {
+	err := ValidateConfig(config)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// visitedConfig identifies a visited struct. The type is included since an embedded struct shares the address with its parent.
+type visitedConfig struct {
+	pointer uintptr
+	typ     reflect.Type
+}
+
+func validateConfig(v reflect.Value, path []string, visited map[visitedConfig]bool) ConfigKeyErrors {
+	if !v.IsValid() {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var target interface{}
+	if v.CanAddr() {
+		key := visitedConfig{pointer: v.Addr().Pointer(), typ: v.Type()}
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+		target = v.Addr().Interface()
+	} else if v.CanInterface() {
+		// A map or a struct given by value. Methods with pointer receivers are not available.
+		target = v.Interface()
+	}
+
+	if defaulter, ok := target.(ConfigDefaulter); ok {
+		defaulter.ApplyDefaults()
+	}
+
+	var errs ConfigKeyErrors
+	if v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			name, inline, ok := configKeyName(v.Type().Field(i))
+			if !ok {
+				continue
+			}
+
+			fieldType := v.Type().Field(i).Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() != reflect.Struct && fieldType.Kind() != reflect.Interface {
+				continue
+			}
+
+			fieldPath := path
+			if !inline {
+				fieldPath = append(append([]string{}, path...), name)
+			}
+			errs = append(errs, validateConfig(v.Field(i), fieldPath, visited)...)
+		}
+	}
+
+	if validator, ok := target.(ConfigValidator); ok {
+		err := validator.Validate()
+		if err != nil {
+			errs = append(errs, prefixConfigKeyErrors(path, err)...)
+		}
+	}
+
+	return errs
+}
+
+func prefixConfigKeyErrors(path []string, err error) ConfigKeyErrors {
+	prefix := strings.Join(path, ".")
+	withPrefix := func(key string) string {
+		switch {
+		case prefix == "":
+			return key
+
+		case key == "":
+			return prefix
+
+		default:
+			return prefix + "." + key
+
+		}
+	}
+
+	switch typed := err.(type) {
+	case ConfigKeyErrors:
+		var errs ConfigKeyErrors
+		for _, e := range typed {
+			errs = append(errs, &ConfigKeyError{Key: withPrefix(e.Key), Value: e.Value, Err: e.Err})
+		}
+		return errs
+
+	case *ConfigKeyError:
+		return ConfigKeyErrors{&ConfigKeyError{Key: withPrefix(typed.Key), Value: typed.Value, Err: typed.Err}}
+
+	default:
+		return ConfigKeyErrors{&ConfigKeyError{Key: prefix, Err: err}}
+
+	}
+}
@@ -0,0 +1,134 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4/workers"
+	"strings"
+	"testing"
+)
+
+type DummyValidatableConfig struct {
+	ValidateFunc func() error
+	Defaulted    bool
+}
+
+func (c *DummyValidatableConfig) ApplyDefaults() {
+	c.Defaulted = true
+}
+
+func (c *DummyValidatableConfig) Validate() error {
+	return c.ValidateFunc()
+}
+
+type embeddedValidatableConfig struct {
+	DummyValidatableConfig `yaml:",inline"`
+}
+
+type applicationConfig struct {
+	Runner   *Config                 `yaml:"runner"`
+	Plugin   *DummyValidatableConfig `yaml:"plugin"`
+	Embedded embeddedValidatableConfig
+	Ignored  *DummyValidatableConfig `yaml:"-"`
+}
+
+func TestValidateConfig(t *testing.T) {
+	invalid := errors.New("api_key is empty")
+	config := &applicationConfig{
+		Runner: &Config{TimeZone: "INVALID"},
+		Plugin: &DummyValidatableConfig{
+			ValidateFunc: func() error {
+				return invalid
+			},
+		},
+		Embedded: embeddedValidatableConfig{
+			DummyValidatableConfig{
+				ValidateFunc: func() error {
+					return nil
+				},
+			},
+		},
+		Ignored: &DummyValidatableConfig{
+			ValidateFunc: func() error {
+				return errors.New("must not be called")
+			},
+		},
+	}
+
+	err := ValidateConfig(config)
+
+	errs, ok := err.(ConfigKeyErrors)
+	if !ok {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("Unexpected number of errors are returned: %s.", errs.Error())
+	}
+
+	if errs[0].Key != "runner.timezone" || errs[0].Value != "INVALID" {
+		t.Errorf("Unexpected error is returned for the runner: %#v.", errs[0])
+	}
+
+	if errs[1].Key != "plugin" || !errors.Is(errs[1], invalid) {
+		t.Errorf("Unexpected error is returned for the plugin: %#v.", errs[1])
+	}
+
+	if config.Runner.Worker == nil {
+		t.Error("Default value is not applied to the runner config.")
+	}
+
+	if !config.Plugin.Defaulted || !config.Embedded.Defaulted {
+		t.Error("ApplyDefaults is not called.")
+	}
+
+	if config.Ignored.Defaulted {
+		t.Error("Ignored field must not be visited.")
+	}
+}
+
+func TestValidateConfig_Nested(t *testing.T) {
+	config := NewConfig()
+	config.Worker.QueueSize = 0
+
+	err := ValidateConfig(config)
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	if !strings.HasPrefix(err.Error(), "invalid worker: ") || !strings.Contains(err.Error(), workers.ErrInvalidQueueSize.Error()) {
+		t.Errorf("Error does not name the offending key: %s.", err.Error())
+	}
+}
+
+func TestValidateConfig_Valid(t *testing.T) {
+	if err := ValidateConfig(NewConfig()); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if err := ValidateConfig(map[string]string{"key": "value"}); err != nil {
+		t.Errorf("Unexpected error is returned for a map: %s.", err.Error())
+	}
+}
+
+func TestBuildCommand_InvalidConfig(t *testing.T) {
+	props := &CommandProps{
+		botType:    "dummy",
+		identifier: "invalid",
+		config: &DummyValidatableConfig{
+			ValidateFunc: func() error {
+				return errors.New("invalid")
+			},
+		},
+	}
+	watcher := &DummyConfigWatcher{
+		ReadFunc: func(_ context.Context, _ BotType, _ string, _ interface{}) error {
+			return nil
+		},
+	}
+
+	_, err := BuildCommand(context.TODO(), props, watcher)
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
@@ -0,0 +1,131 @@
+package sarah
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"strings"
+)
+
+// Verifier is an optional interface that a Bot or an Adapter may satisfy to check its settings before sarah.Run().
+// Verify should make a lightweight call to the chat service such as an authentication test,
+// so an invalid credential or an unreachable endpoint is reported on deployment instead of on the first message.
+//
+// The default Bot implementation satisfies this interface and delegates the call to its Adapter when the Adapter satisfies this interface.
+type Verifier interface {
+	Verify(context.Context) error
+}
+
+// VerificationError represents a failed check of sarah.Verify().
+type VerificationError struct {
+	// Target is the checked item such as "config", "bot:slack", "command:slack:echo" or "task:slack:alarm".
+	Target string
+	Err    error
+}
+
+// Error returns the stringified form of the error.
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Target, e.Err.Error())
+}
+
+// Unwrap returns the cause of the error.
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// VerificationErrors is an alias for a slice of VerificationError.
+// sarah.Verify() returns this so all problems can be fixed at once.
+type VerificationErrors []*VerificationError
+
+// Error returns the stringified form of all stored errors.
+func (e VerificationErrors) Error() string {
+	var errs []string
+	for _, err := range e {
+		errs = append(errs, err.Error())
+	}
+	return strings.Join(errs, "\n")
+}
+
+// Verify checks the given Config and the registered components without running them.
+// Call this on deployment before sarah.Run() so a misconfiguration is caught before the first message comes.
+//
+// This validates the Config, asks each registered Bot satisfying Verifier to check its credentials and reachability,
+// reads and validates the configurations of the registered CommandProps and ScheduledTaskProps with the registered ConfigWatcher,
+// and parses the schedules of the scheduled tasks.
+// VerificationErrors is returned when any of them fails.
+//
+//  err := sarah.Verify(ctx, config)
+//  if err != nil {
+//  	log.Fatalf("Invalid setup:\n%s", err.Error())
+//  }
+//  err = sarah.Run(ctx, config)
+func Verify(ctx context.Context, config *Config) error {
+	err := ValidateConfig(config)
+	if err != nil {
+		return VerificationErrors{{Target: "config", Err: err}}
+	}
+
+	r, _, err := prepareRunner(config)
+	if err != nil {
+		return VerificationErrors{{Target: "config", Err: err}}
+	}
+
+	var errs VerificationErrors
+	for _, bot := range r.bots {
+		errs = append(errs, r.verifyBot(ctx, bot)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// verifyBot checks the given Bot and its belonging commands and scheduled tasks.
+func (r *runner) verifyBot(ctx context.Context, bot Bot) VerificationErrors {
+	botType := bot.BotType()
+	botCtx := logging.NewContext(ctx, r.baseLogger().With(logging.F(logging.KeyBotType, botType)))
+	botCtx = WithBotType(botCtx, botType)
+
+	var errs VerificationErrors
+	fail := func(kind string, id string, err error) {
+		target := kind + ":" + botType.String()
+		if id != "" {
+			target += ":" + id
+		}
+		errs = append(errs, &VerificationError{Target: target, Err: err})
+	}
+
+	if verifier, ok := bot.(Verifier); ok {
+		err := verifier.Verify(botCtx)
+		if err != nil {
+			fail("bot", "", err)
+		}
+	}
+
+	for _, p := range r.botCommandProps(botType) {
+		_, err := buildCommand(botCtx, p, r.configWatcher)
+		if err != nil {
+			fail("command", p.identifier, err)
+		}
+	}
+
+	for _, p := range r.botScheduledTaskProps(botType) {
+		task, err := buildScheduledTask(botCtx, p, r.configWatcher)
+		if err == nil {
+			err = verifySchedule(task.Schedule())
+		}
+		if err != nil {
+			fail("task", p.identifier, err)
+		}
+	}
+
+	for _, task := range r.botScheduledTasks(botType) {
+		err := verifySchedule(task.Schedule())
+		if err != nil {
+			fail("task", task.Identifier(), err)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,145 @@
+package sarah
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type DummyVerifiableBot struct {
+	*DummyBot
+	VerifyFunc func(context.Context) error
+}
+
+func (bot *DummyVerifiableBot) Verify(ctx context.Context) error {
+	return bot.VerifyFunc(ctx)
+}
+
+func TestVerificationErrors_Error(t *testing.T) {
+	errs := VerificationErrors{
+		{Target: "bot:slack", Err: errors.New("invalid_auth")},
+		{Target: "task:slack:alarm", Err: errors.New("invalid schedule")},
+	}
+
+	expected := "bot:slack: invalid_auth\ntask:slack:alarm: invalid schedule"
+	if errs.Error() != expected {
+		t.Errorf("Unexpected error message is returned: %s.", errs.Error())
+	}
+}
+
+func TestVerify(t *testing.T) {
+	SetupAndRun(func() {
+		var botType BotType = "dummy"
+		RegisterBot(&DummyVerifiableBot{
+			DummyBot: &DummyBot{BotTypeValue: botType},
+			VerifyFunc: func(ctx context.Context) error {
+				if BotTypeFromContext(ctx) != botType {
+					t.Errorf("BotType is not given: %s.", BotTypeFromContext(ctx))
+				}
+				return errors.New("invalid_auth")
+			},
+		})
+		RegisterConfigWatcher(&DummyConfigWatcher{
+			ReadFunc: func(_ context.Context, _ BotType, id string, _ interface{}) error {
+				if id == "broken" {
+					return errors.New("config error")
+				}
+				return nil
+			},
+		})
+		RegisterCommandProps(NewCommandPropsBuilder().
+			BotType(botType).
+			Identifier("broken").
+			MatchPattern(regexp.MustCompile(".")).
+			ConfigurableFunc(&struct{}{}, func(_ context.Context, _ Input, _ CommandConfig) (*CommandResponse, error) {
+				return nil, nil
+			}).
+			Instruction("").
+			MustBuild())
+		RegisterCommandProps(NewCommandPropsBuilder().
+			BotType(botType).
+			Identifier("fine").
+			MatchPattern(regexp.MustCompile(".")).
+			ConfigurableFunc(&struct{}{}, func(_ context.Context, _ Input, _ CommandConfig) (*CommandResponse, error) {
+				return nil, nil
+			}).
+			Instruction("").
+			MustBuild())
+		RegisterScheduledTaskProps(NewScheduledTaskPropsBuilder().
+			BotType(botType).
+			Identifier("props").
+			Schedule("INVALID").
+			Func(func(_ context.Context) ([]*ScheduledTaskResult, error) {
+				return nil, nil
+			}).
+			MustBuild())
+		RegisterScheduledTask(botType, &DummyScheduledTask{IdentifierValue: "task", ScheduleValue: "@daily"})
+
+		err := Verify(context.TODO(), &Config{TimeZone: time.UTC.String()})
+
+		var errs VerificationErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("Expected error is not returned: %#v.", err)
+		}
+
+		var targets []string
+		for _, e := range errs {
+			targets = append(targets, e.Target)
+		}
+		expected := []string{"bot:dummy", "command:dummy:broken", "task:dummy:props"}
+		if len(targets) != len(expected) {
+			t.Fatalf("Unexpected errors are returned: %s.", err.Error())
+		}
+		for i, target := range expected {
+			if targets[i] != target {
+				t.Errorf("Expected target %s is not returned: %s.", target, targets[i])
+			}
+		}
+
+		if CurrentStatus().Running {
+			t.Error("Verify must not run the Runner.")
+		}
+	})
+}
+
+func TestVerify_WithInvalidConfig(t *testing.T) {
+	SetupAndRun(func() {
+		err := Verify(context.TODO(), &Config{TimeZone: "INVALID"})
+
+		var errs VerificationErrors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Target != "config" {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestDefaultBot_Verify(t *testing.T) {
+	bot := &defaultBot{}
+	if err := bot.Verify(context.Background()); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	expected := errors.New("invalid_auth")
+	bot.verifyFunc = func(_ context.Context) error {
+		return expected
+	}
+	if err := bot.Verify(context.Background()); err != expected {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func Test_verifySchedule(t *testing.T) {
+	for _, spec := range []string{"@daily", "0 9 * * 1-5", "@at 2026-01-02T15:04:05Z"} {
+		if err := verifySchedule(spec); err != nil {
+			t.Errorf("Unexpected error is returned for %s: %s.", spec, err.Error())
+		}
+	}
+
+	for _, spec := range []string{"", "INVALID", "@at tomorrow"} {
+		if err := verifySchedule(spec); err == nil {
+			t.Errorf("Expected error is not returned for %s.", spec)
+		}
+	}
+}
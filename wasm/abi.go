@@ -0,0 +1,147 @@
+/*
+Package wasm runs WebAssembly modules as sarah.Command implementations so untrusted or third-party plugins run inside the bot process
+with no access to the host, a limited amount of memory and a limited execution time.
+
+The runtime is built on wazero, a WebAssembly runtime written in pure Go, so no cgo is required.
+This package is a separate Go module so the bots that do not run WebAssembly do not depend on wazero.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+
+Each *.wasm file in Config.Dir becomes one sarah.Command. A module implements the following ABI:
+
+	// The linear memory the host reads from and writes to.
+	(export "memory" (memory 1))
+
+	// Returns a pointer to a buffer of the given size the host writes a payload to.
+	(func (export "sarah_alloc") (param $size i32) (result i32))
+
+	// Returns the manifest in JSON. See below.
+	(func (export "sarah_manifest") (result i64))
+
+	// Receives the input in JSON and returns the response text. A zero length tells the host to send nothing.
+	(func (export "sarah_execute") (param $ptr i32) (param $len i32) (result i64))
+
+A returned i64 packs the pointer to the returned bytes in its upper 32 bits and the length in its lower 32 bits.
+The manifest declares the match pattern in Go's regular expression syntax and the instruction.
+The identifier defaults to the file name without the extension:
+
+	{"identifier": "hello", "pattern": "^\\.hello", "instruction": "Input .hello to say hello."}
+
+The input passed to sarah_execute is as below. sent_at is a UNIX time:
+
+	{"message": ".hello", "sender_key": "U123", "sent_at": 1600000000}
+
+A new module instance is created for every execution so no state leaks between executions.
+Only WASI is provided to the module when Config.WASI is enabled, and even then no file system, environment variable or argument is exposed.
+The execution is aborted when Config.Timeout passes, and the memory can not grow beyond Config.MemoryLimitPages.
+
+	runtime, err := wasm.NewRuntime(ctx, config)
+	if err != nil {
+		panic(err)
+	}
+	defer runtime.Close(ctx)
+	for _, command := range runtime.Commands() {
+		sarah.RegisterScopedCommand(sarah.AllBots(), command)
+	}
+*/
+package wasm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"regexp"
+	"time"
+)
+
+const (
+	moduleExt = ".wasm"
+
+	memoryExport   = "memory"
+	allocExport    = "sarah_alloc"
+	manifestExport = "sarah_manifest"
+	executeExport  = "sarah_execute"
+)
+
+// requiredFunctions are the functions a module must export.
+var requiredFunctions = []string{allocExport, manifestExport, executeExport}
+
+// Config contains some configuration variables for the WebAssembly runtime.
+type Config struct {
+	// Dir is the directory to read *.wasm modules from. Sub-directories are not read.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// Timeout is the maximum duration of a module execution.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// MemoryLimitPages is the maximum number of 64KiB pages a module can allocate.
+	MemoryLimitPages uint32 `json:"memory_limit_pages" yaml:"memory_limit_pages"`
+
+	// WASI tells if WASI is provided to the modules.
+	// Modules compiled with TinyGo or Rust's wasm32-wasi target require this even when they do not use any WASI function.
+	WASI bool `json:"wasi" yaml:"wasi"`
+}
+
+// NewConfig returns a new Config instance with default values.
+// Dir must be set before use.
+func NewConfig() *Config {
+	return &Config{
+		Dir:              "",
+		Timeout:          3 * time.Second,
+		MemoryLimitPages: 256, // 16MiB
+		WASI:             true,
+	}
+}
+
+// manifest is what a module's sarah_manifest function returns.
+type manifest struct {
+	Identifier  string `json:"identifier"`
+	Pattern     string `json:"pattern"`
+	Instruction string `json:"instruction"`
+}
+
+// input is what the host passes to a module's sarah_execute function.
+type input struct {
+	Message   string `json:"message"`
+	SenderKey string `json:"sender_key"`
+	SentAt    int64  `json:"sent_at"`
+}
+
+func newInput(i sarah.Input) *input {
+	return &input{
+		Message:   i.Message(),
+		SenderKey: i.SenderKey(),
+		SentAt:    i.SentAt().Unix(),
+	}
+}
+
+// parseManifest parses the given manifest and returns it with the compiled pattern.
+// The given defaultID is used when the manifest does not declare an identifier.
+func parseManifest(data []byte, defaultID string) (*manifest, *regexp.Regexp, error) {
+	m := &manifest{}
+	err := json.Unmarshal(data, m)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if m.Identifier == "" {
+		m.Identifier = defaultID
+	}
+
+	if m.Pattern == "" {
+		return nil, nil, errors.New("pattern is not declared in manifest")
+	}
+
+	pattern, err := regexp.Compile(m.Pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid pattern in manifest: %w", err)
+	}
+
+	return m, pattern, nil
+}
+
+// unpack splits a value returned by a module into the pointer and the length of the returned bytes.
+func unpack(packed uint64) (uint32, uint32) {
+	return uint32(packed >> 32), uint32(packed)
+}
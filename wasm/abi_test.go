@@ -0,0 +1,109 @@
+package wasm
+
+import (
+	"github.com/oklahomer/go-sarah/v4"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+}
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return nil
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+
+	if config.Dir != "" {
+		t.Errorf("Unexpected default directory is set: %s.", config.Dir)
+	}
+
+	if config.Timeout != 3*time.Second {
+		t.Errorf("Unexpected default timeout is set: %s.", config.Timeout)
+	}
+
+	if config.MemoryLimitPages != 256 {
+		t.Errorf("Unexpected default memory limit is set: %d.", config.MemoryLimitPages)
+	}
+
+	if !config.WASI {
+		t.Error("WASI must be enabled by default.")
+	}
+}
+
+func Test_newInput(t *testing.T) {
+	sentAt := time.Unix(1600000000, 0)
+	i := newInput(&DummyInput{
+		SenderKeyValue: "U123",
+		MessageValue:   ".hello",
+		SentAtValue:    sentAt,
+	})
+
+	if i.Message != ".hello" || i.SenderKey != "U123" || i.SentAt != 1600000000 {
+		t.Errorf("Unexpected input is returned: %#v.", i)
+	}
+}
+
+func Test_parseManifest(t *testing.T) {
+	m, pattern, err := parseManifest([]byte(`{"identifier": "greet", "pattern": "^\\.hello", "instruction": "Input .hello to say hello."}`), "hello")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if m.Identifier != "greet" || m.Instruction != "Input .hello to say hello." {
+		t.Errorf("Unexpected manifest is returned: %#v.", m)
+	}
+
+	if !pattern.MatchString(".hello world") {
+		t.Error("Pattern is not compiled.")
+	}
+
+	m, _, err = parseManifest([]byte(`{"pattern": "^\\.hello"}`), "hello")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if m.Identifier != "hello" {
+		t.Errorf("Default identifier is not set: %s.", m.Identifier)
+	}
+}
+
+func Test_parseManifest_WithError(t *testing.T) {
+	tests := []string{
+		``,
+		`{"identifier": "hello"}`,
+		`{"pattern": "("}`,
+	}
+
+	for i, tt := range tests {
+		_, _, err := parseManifest([]byte(tt), "hello")
+		if err == nil {
+			t.Errorf("Expected error is not returned on test #%d.", i)
+		}
+	}
+}
+
+func Test_unpack(t *testing.T) {
+	ptr, size := unpack(uint64(1024)<<32 | uint64(12))
+
+	if ptr != 1024 || size != 12 {
+		t.Errorf("Unexpected values are returned: %d and %d.", ptr, size)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/oklahomer/go-sarah/v4/wasm
+
+go 1.21
+
+require (
+	github.com/oklahomer/go-sarah/v4 v4.1.0
+	github.com/tetratelabs/wazero v1.8.2
+)
+
+require (
+	github.com/oklahomer/go-kasumi v0.0.0-20210320022217-84d2c0ccb359 // indirect
+	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
+	github.com/robfig/cron/v3 v3.0.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+// v4.1.0 is the first go-sarah release with the APIs this module uses. Until it is tagged,
+// this module builds against the local tree. Modules depending on this one ignore the replace below.
+replace github.com/oklahomer/go-sarah/v4 => ../
@@ -0,0 +1,234 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Runtime compiles the modules and provides the corresponding Commands.
+type Runtime struct {
+	runtime  wazero.Runtime
+	commands []*moduleCommand
+}
+
+// NewRuntime compiles the modules in Config.Dir and returns a new Runtime.
+// An error is returned when any of the modules fails to compile, lacks any of the required exports or returns an invalid manifest.
+//
+// Call Runtime.Close to release the resources when the Commands are no longer used.
+func NewRuntime(ctx context.Context, config *Config) (*Runtime, error) {
+	if config.Dir == "" {
+		return nil, errors.New("module directory is not given")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(config.Dir, "*"+moduleExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", config.Dir, err)
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(config.MemoryLimitPages).
+		WithCloseOnContextDone(true) // Abort the execution on timeout even when the module is in an infinite loop.
+	r := &Runtime{
+		runtime:  wazero.NewRuntimeWithConfig(ctx, runtimeConfig),
+		commands: []*moduleCommand{},
+	}
+
+	if config.WASI {
+		_, err = wasi_snapshot_preview1.Instantiate(ctx, r.runtime)
+		if err != nil {
+			_ = r.Close(ctx)
+			return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+		}
+	}
+
+	for _, path := range paths {
+		command, err := r.compile(ctx, path, config)
+		if err != nil {
+			_ = r.Close(ctx)
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		r.commands = append(r.commands, command)
+	}
+
+	return r, nil
+}
+
+// Commands returns the Commands corresponding to the modules in lexical order of their file names.
+// Pass them to sarah.RegisterCommand or sarah.RegisterScopedCommand.
+func (r *Runtime) Commands() []sarah.Command {
+	commands := make([]sarah.Command, 0, len(r.commands))
+	for _, command := range r.commands {
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// Close releases the compiled modules and the running module instances.
+// The Commands return errors after this call.
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.runtime.Close(ctx)
+}
+
+func (r *Runtime) compile(ctx context.Context, path string, config *Config) (*moduleCommand, error) {
+	binary, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := r.runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := compiled.ExportedMemories()[memoryExport]; !ok {
+		return nil, fmt.Errorf("%s is not exported", memoryExport)
+	}
+	exported := compiled.ExportedFunctions()
+	for _, name := range requiredFunctions {
+		if _, ok := exported[name]; !ok {
+			return nil, fmt.Errorf("%s is not exported", name)
+		}
+	}
+
+	command := &moduleCommand{
+		runtime:  r.runtime,
+		compiled: compiled,
+		config:   config,
+	}
+
+	data, err := command.call(ctx, manifestExport, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m, pattern, err := parseManifest(data, strings.TrimSuffix(filepath.Base(path), moduleExt))
+	if err != nil {
+		return nil, err
+	}
+	command.manifest = m
+	command.pattern = pattern
+
+	return command, nil
+}
+
+// moduleCommand is a sarah.Command that executes a module.
+type moduleCommand struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	config   *Config
+	manifest *manifest
+	pattern  *regexp.Regexp
+}
+
+var _ sarah.Command = (*moduleCommand)(nil)
+
+func (c *moduleCommand) Identifier() string {
+	return c.manifest.Identifier
+}
+
+func (c *moduleCommand) Execute(ctx context.Context, i sarah.Input) (*sarah.CommandResponse, error) {
+	payload, err := json.Marshal(newInput(i))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input: %w", err)
+	}
+
+	res, err := c.call(ctx, executeExport, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute module %s: %w", c.manifest.Identifier, err)
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return &sarah.CommandResponse{
+		Content:     string(res),
+		UserContext: nil,
+	}, nil
+}
+
+func (c *moduleCommand) Instruction(_ *sarah.HelpInput) string {
+	return c.manifest.Instruction
+}
+
+func (c *moduleCommand) Match(i sarah.Input) bool {
+	return c.pattern.MatchString(i.Message())
+}
+
+// call instantiates the module and calls the exported function with the given name in the new instance.
+// When a payload is given, the payload is written to the buffer sarah_alloc returns and its pointer and length are passed to the function.
+// The bytes the function returns are copied before the instance is closed.
+func (c *moduleCommand) call(ctx context.Context, name string, payload []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	// An empty name lets multiple instances of the same module run concurrently.
+	// Reactor modules built with TinyGo or Rust initialize themselves in _initialize; it is skipped when not exported.
+	moduleConfig := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize")
+	mod, err := c.runtime.InstantiateModule(ctx, c.compiled, moduleConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate: %w", err)
+	}
+	defer mod.Close(context.Background())
+
+	params := []uint64{}
+	if payload != nil {
+		ptr, err := write(ctx, mod, payload)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, uint64(ptr), uint64(len(payload)))
+	}
+
+	results, err := mod.ExportedFunction(name).Call(ctx, params...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s is aborted: %w", name, ctx.Err())
+		}
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("%s returned %d values instead of 1", name, len(results))
+	}
+
+	ptr, size := unpack(results[0])
+	if size == 0 {
+		return nil, nil
+	}
+
+	data, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("%s returned an out of range memory region", name)
+	}
+
+	// The memory is released on close, so copy the bytes.
+	return append([]byte{}, data...), nil
+}
+
+// write writes the given payload to the buffer the module's sarah_alloc returns, and returns the pointer to the buffer.
+func write(ctx context.Context, mod api.Module, payload []byte) (uint32, error) {
+	results, err := mod.ExportedFunction(allocExport).Call(ctx, uint64(len(payload)))
+	if err != nil {
+		return 0, fmt.Errorf("%s failed: %w", allocExport, err)
+	}
+	if len(results) != 1 {
+		return 0, fmt.Errorf("%s returned %d values instead of 1", allocExport, len(results))
+	}
+
+	ptr := uint32(results[0])
+	if !mod.Memory().Write(ptr, payload) {
+		return 0, fmt.Errorf("%s returned an out of range memory region", allocExport)
+	}
+	return ptr, nil
+}
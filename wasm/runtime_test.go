@@ -0,0 +1,216 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The modules in testdata/modules are the assembled forms of the *.wat files next to them.
+func newTestRuntime(t *testing.T, memoryLimitPages uint32) *Runtime {
+	config := &Config{
+		Dir:              filepath.Join("testdata", "modules"),
+		Timeout:          500 * time.Millisecond,
+		MemoryLimitPages: memoryLimitPages,
+		WASI:             false,
+	}
+
+	r, err := NewRuntime(context.TODO(), config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	t.Cleanup(func() {
+		_ = r.Close(context.TODO())
+	})
+
+	return r
+}
+
+func findCommand(t *testing.T, r *Runtime, id string) *moduleCommand {
+	for _, command := range r.Commands() {
+		if command.Identifier() == id {
+			return command.(*moduleCommand)
+		}
+	}
+
+	t.Fatalf("Command is not found: %s.", id)
+	return nil
+}
+
+func TestNewRuntime(t *testing.T) {
+	r := newTestRuntime(t, 256)
+
+	commands := r.Commands()
+	if len(commands) != 3 {
+		t.Fatalf("Unexpected number of commands are returned: %d.", len(commands))
+	}
+
+	for i, id := range []string{"echo", "grow", "loop"} {
+		if commands[i].Identifier() != id {
+			t.Errorf("Unexpected identifier is returned at %d: %s.", i, commands[i].Identifier())
+		}
+	}
+
+	instruction := commands[0].Instruction(nil)
+	if instruction != "Input .echo to echo the input." {
+		t.Errorf("Unexpected instruction is returned: %s.", instruction)
+	}
+}
+
+func TestNewRuntime_WithoutDir(t *testing.T) {
+	_, err := NewRuntime(context.TODO(), NewConfig())
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestNewRuntime_MemoryLimit(t *testing.T) {
+	config := &Config{
+		Dir:              filepath.Join("testdata", "modules"),
+		Timeout:          500 * time.Millisecond,
+		MemoryLimitPages: 0, // Smaller than the one page each module declares.
+		WASI:             false,
+	}
+
+	_, err := NewRuntime(context.TODO(), config)
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestModuleCommand_Match(t *testing.T) {
+	r := newTestRuntime(t, 256)
+	command := findCommand(t, r, "echo")
+
+	tests := []struct {
+		message string
+		matched bool
+	}{
+		{
+			message: ".echo hello",
+			matched: true,
+		},
+		{
+			message: "echo hello",
+			matched: false,
+		},
+		{
+			message: ".loop",
+			matched: false,
+		},
+	}
+
+	for i, tt := range tests {
+		matched := command.Match(&DummyInput{MessageValue: tt.message})
+		if matched != tt.matched {
+			t.Errorf("Unexpected result is returned on test #%d: %t.", i, matched)
+		}
+	}
+}
+
+func TestModuleCommand_Execute(t *testing.T) {
+	r := newTestRuntime(t, 256)
+	command := findCommand(t, r, "echo")
+
+	input := &DummyInput{
+		SenderKeyValue: "U123",
+		MessageValue:   ".echo hello",
+		SentAtValue:    time.Unix(1600000000, 0),
+	}
+	res, err := command.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res == nil {
+		t.Fatal("Expected response is not returned.")
+	}
+
+	expected := `{"message":".echo hello","sender_key":"U123","sent_at":1600000000}`
+	if res.Content != expected {
+		t.Errorf("Unexpected content is returned: %#v.", res.Content)
+	}
+}
+
+func TestModuleCommand_Execute_MemoryLimit(t *testing.T) {
+	t.Run("within limit", func(t *testing.T) {
+		r := newTestRuntime(t, 32)
+		command := findCommand(t, r, "grow")
+
+		res, err := command.Execute(context.TODO(), &DummyInput{MessageValue: ".grow"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if res != nil {
+			t.Errorf("Unexpected response is returned: %#v.", res)
+		}
+	})
+
+	t.Run("beyond limit", func(t *testing.T) {
+		r := newTestRuntime(t, 8)
+		command := findCommand(t, r, "grow")
+
+		_, err := command.Execute(context.TODO(), &DummyInput{MessageValue: ".grow"})
+		if err == nil {
+			t.Error("Expected error is not returned.")
+		}
+	})
+}
+
+func TestModuleCommand_Execute_Timeout(t *testing.T) {
+	r := newTestRuntime(t, 256)
+	command := findCommand(t, r, "loop")
+
+	started := time.Now()
+	_, err := command.Execute(context.TODO(), &DummyInput{MessageValue: ".loop"})
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Execution is not aborted on timeout: %s.", elapsed)
+	}
+}
+
+func TestModuleCommand_Execute_Canceled(t *testing.T) {
+	r := newTestRuntime(t, 256)
+	command := findCommand(t, r, "loop")
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := command.Execute(ctx, &DummyInput{MessageValue: ".loop"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error is not returned: %v.", err)
+	}
+}
+
+func TestRuntime_Close(t *testing.T) {
+	r := newTestRuntime(t, 256)
+	command := findCommand(t, r, "echo")
+
+	err := r.Close(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_, err = command.Execute(context.TODO(), &DummyInput{MessageValue: ".echo hello"})
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	if !strings.Contains(err.Error(), "echo") {
+		t.Errorf("Error does not tell the module: %s.", err.Error())
+	}
+}
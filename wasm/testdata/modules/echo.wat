@@ -0,0 +1,15 @@
+;; echo responds with the input JSON as it is.
+(module
+  (memory (export "memory") 1)
+  (data (i32.const 0) "{\"identifier\":\"echo\",\"pattern\":\"^\\\\.echo\",\"instruction\":\"Input .echo to echo the input.\"}")
+
+  (func (export "sarah_alloc") (param $size i32) (result i32)
+    (i32.const 1024))
+
+  (func (export "sarah_manifest") (result i64)
+    (i64.const 89)) ;; The pointer 0 and the length 89.
+
+  (func (export "sarah_execute") (param $ptr i32) (param $len i32) (result i64)
+    (i64.or
+      (i64.shl (i64.extend_i32_u (local.get $ptr)) (i64.const 32))
+      (i64.extend_i32_u (local.get $len)))))
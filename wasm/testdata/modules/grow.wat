@@ -0,0 +1,15 @@
+;; grow grows its memory by 16 pages and traps when the memory can not grow.
+(module
+  (memory (export "memory") 1)
+  (data (i32.const 0) "{\"identifier\":\"grow\",\"pattern\":\"^\\\\.grow\",\"instruction\":\"Input .grow to grow the memory.\"}")
+
+  (func (export "sarah_alloc") (param $size i32) (result i32)
+    (i32.const 1024))
+
+  (func (export "sarah_manifest") (result i64)
+    (i64.const 90)) ;; The pointer 0 and the length 90.
+
+  (func (export "sarah_execute") (param $ptr i32) (param $len i32) (result i64)
+    (if (i32.eq (memory.grow (i32.const 16)) (i32.const -1))
+      (then unreachable))
+    (i64.const 0)))
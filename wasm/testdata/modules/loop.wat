@@ -0,0 +1,15 @@
+;; loop never returns from sarah_execute.
+(module
+  (memory (export "memory") 1)
+  (data (i32.const 0) "{\"identifier\":\"loop\",\"pattern\":\"^\\\\.loop\",\"instruction\":\"Input .loop to run forever.\"}")
+
+  (func (export "sarah_alloc") (param $size i32) (result i32)
+    (i32.const 1024))
+
+  (func (export "sarah_manifest") (result i64)
+    (i64.const 86)) ;; The pointer 0 and the length 86.
+
+  (func (export "sarah_execute") (param $ptr i32) (param $len i32) (result i64)
+    (loop $forever
+      (br $forever))
+    (i64.const 0)))
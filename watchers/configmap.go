@@ -0,0 +1,122 @@
+package watchers
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// configMapDataDir is the name of the symlink Kubernetes swaps to update a mounted ConfigMap atomically.
+const configMapDataDir = "..data"
+
+// NewConfigMapSource creates and returns a new ConfigSource that reads the files in the given directory.
+// Mount one Kubernetes ConfigMap per BotType under the base directory so a key "slack/echo" is read from "<baseDir>/slack/echo.yaml".
+// Files with .yaml, .yml and .json extensions are read just like NewFileWatcher does.
+//
+// When a mounted ConfigMap is updated, Kubernetes writes the new files to a new directory and swaps the "..data" symlink to point to it,
+// so none of the configuration files itself is written.
+// This source detects the swap and notifies all configurations in the directory.
+// A plain write to a configuration file is also notified so the source can be used with a regular directory.
+func NewConfigMapSource(baseDir string) ConfigSource {
+	return &configMapSource{
+		baseDir: baseDir,
+	}
+}
+
+type configMapSource struct {
+	baseDir string
+}
+
+var _ ConfigSource = (*configMapSource)(nil)
+
+func (s *configMapSource) Get(_ context.Context, key string) ([]byte, error) {
+	dir, id := filepath.Split(key)
+	file := findPluginConfigFile(filepath.Join(s.baseDir, dir), id)
+	if file == nil {
+		return nil, ErrConfigNotFound
+	}
+
+	return ioutil.ReadFile(file.absPath)
+}
+
+func (s *configMapSource) Watch(ctx context.Context, prefix string, notify func(key string)) error {
+	dir := filepath.Join(s.baseDir, prefix)
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer fsWatcher.Close()
+
+	err = fsWatcher.Add(dir)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", dir, err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return fmt.Errorf("file watcher for %s is closed", dir)
+			}
+
+			if event.Op&fsnotify.Write != fsnotify.Write && event.Op&fsnotify.Create != fsnotify.Create {
+				continue
+			}
+
+			if filepath.Base(event.Name) == configMapDataDir {
+				// The ConfigMap is updated. Every configuration file may have changed.
+				moduleLogger(ctx).Info("Received ConfigMap update", logging.F("dir", dir))
+				for _, id := range configFileIDs(ctx, dir) {
+					notify(prefix + id)
+				}
+				continue
+			}
+
+			file, err := plainPathToFile(event.Name)
+			if err != nil {
+				// Irrelevant file such as Kubernetes' timestamped data directory.
+				continue
+			}
+			notify(prefix + file.id)
+
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return fmt.Errorf("file watcher for %s is closed", dir)
+			}
+			return fmt.Errorf("failed to subscribe to %s: %w", dir, err)
+
+		}
+	}
+}
+
+// configFileIDs returns the IDs of the configuration files located in the given directory.
+func configFileIDs(ctx context.Context, dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		moduleLogger(ctx).Warn("Failed to read directory", logging.F("dir", dir), logging.Err(err))
+		return nil
+	}
+
+	var ids []string
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+
+		file, err := plainPathToFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, file.id)
+	}
+
+	return ids
+}
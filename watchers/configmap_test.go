@@ -0,0 +1,150 @@
+package watchers
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// setupConfigMapDir lays out the files as Kubernetes does for a mounted ConfigMap.
+func setupConfigMapDir(t *testing.T) string {
+	baseDir, err := ioutil.TempDir("", "configmap")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s.", err.Error())
+	}
+
+	dir := filepath.Join(baseDir, "slack")
+	err = os.Mkdir(dir, 0755)
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s.", err.Error())
+	}
+
+	swapConfigMapData(t, dir, "..2026_01", "token: foo")
+
+	err = os.Symlink(filepath.Join(configMapDataDir, "echo.yaml"), filepath.Join(dir, "echo.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to create symlink: %s.", err.Error())
+	}
+
+	return baseDir
+}
+
+// swapConfigMapData updates the ConfigMap data by swapping the ..data symlink just like Kubernetes does.
+func swapConfigMapData(t *testing.T, dir string, version string, body string) {
+	err := os.Mkdir(filepath.Join(dir, version), 0755)
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s.", err.Error())
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, version, "echo.yaml"), []byte(body), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write file: %s.", err.Error())
+	}
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	err = os.Symlink(version, tmp)
+	if err != nil {
+		t.Fatalf("Failed to create symlink: %s.", err.Error())
+	}
+
+	err = os.Rename(tmp, filepath.Join(dir, configMapDataDir))
+	if err != nil {
+		t.Fatalf("Failed to swap symlink: %s.", err.Error())
+	}
+}
+
+func TestConfigMapSource_Get(t *testing.T) {
+	baseDir := setupConfigMapDir(t)
+	defer os.RemoveAll(baseDir)
+
+	source := NewConfigMapSource(baseDir)
+
+	b, err := source.Get(context.TODO(), "slack/echo")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(b) != "token: foo" {
+		t.Errorf("Unexpected value is returned: %s.", string(b))
+	}
+
+	_, err = source.Get(context.TODO(), "slack/unknown")
+	if !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestConfigMapSource_Watch(t *testing.T) {
+	baseDir := setupConfigMapDir(t)
+	defer os.RemoveAll(baseDir)
+
+	source := NewConfigMapSource(baseDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notified := make(chan string, 10)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- source.Watch(ctx, "slack/", func(key string) {
+			notified <- key
+		})
+	}()
+
+	// The subscription starts asynchronously, so keep updating til the change is notified.
+	dir := filepath.Join(baseDir, "slack")
+	timeout := time.After(3 * time.Second)
+	for i := 2; ; i++ {
+		swapConfigMapData(t, dir, "..2026_0"+strconv.Itoa(i), "token: bar")
+
+		select {
+		case key := <-notified:
+			if key != "slack/echo" {
+				t.Errorf("Unexpected key is notified: %s.", key)
+			}
+
+		case <-time.After(100 * time.Millisecond):
+			continue
+
+		case <-timeout:
+			t.Fatal("ConfigMap update is not notified.")
+
+		}
+		break
+	}
+
+	b, err := source.Get(context.TODO(), "slack/echo")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(b) != "token: bar" {
+		t.Errorf("Updated value is not returned: %s.", string(b))
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Unexpected error is returned: %#v.", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Watch does not return on context cancellation.")
+
+	}
+}
+
+func Test_configFileIDs(t *testing.T) {
+	baseDir := setupConfigMapDir(t)
+	defer os.RemoveAll(baseDir)
+
+	ids := configFileIDs(context.Background(), filepath.Join(baseDir, "slack"))
+
+	if len(ids) != 1 || ids[0] != "echo" {
+		t.Errorf("Unexpected IDs are returned: %v.", ids)
+	}
+}
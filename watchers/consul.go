@@ -0,0 +1,206 @@
+package watchers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConsulConfig contains some configuration variables for Consul KV ConfigSource.
+type ConsulConfig struct {
+	// Address is the base URL of Consul HTTP API such as "http://127.0.0.1:8500".
+	Address string `json:"address" yaml:"address"`
+
+	// KeyPrefix is prepended to the keys so a key "slack/echo" is stored as "<KeyPrefix>slack/echo".
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+
+	// Token is an ACL token sent as X-Consul-Token header.
+	Token sarah.Secret `json:"token" yaml:"token"`
+
+	// Datacenter specifies the datacenter to query. Leave empty to query the agent's datacenter.
+	Datacenter string `json:"datacenter" yaml:"datacenter"`
+
+	// WaitTime is the maximum duration a blocking query waits for a change.
+	WaitTime time.Duration `json:"wait_time" yaml:"wait_time"`
+}
+
+// NewConsulConfig returns ConsulConfig with default settings.
+func NewConsulConfig() *ConsulConfig {
+	return &ConsulConfig{
+		Address:   "http://127.0.0.1:8500",
+		KeyPrefix: "sarah/",
+		WaitTime:  5 * time.Minute,
+	}
+}
+
+// ConsulSourceOption defines function signature that NewConsulSource's functional option must satisfy.
+type ConsulSourceOption func(*consulSource)
+
+// WithConsulHTTPClient creates a ConsulSourceOption that replaces the default http.Client.
+// Timeout of the given client must be longer than ConsulConfig.WaitTime or a blocking query never succeeds.
+func WithConsulHTTPClient(client *http.Client) ConsulSourceOption {
+	return func(s *consulSource) {
+		s.client = client
+	}
+}
+
+// NewConsulSource creates and returns a new ConfigSource that reads the configurations from Consul KV store.
+// Changes are subscribed with Consul's blocking queries on the BotType's key prefix.
+func NewConsulSource(config *ConsulConfig, options ...ConsulSourceOption) ConfigSource {
+	s := &consulSource{
+		config: config,
+		client: &http.Client{},
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+type consulSource struct {
+	config *ConsulConfig
+	client *http.Client
+}
+
+var _ ConfigSource = (*consulSource)(nil)
+
+type consulKVPair struct {
+	Key         string `json:"Key"`
+	ModifyIndex uint64 `json:"ModifyIndex"`
+}
+
+func (s *consulSource) Get(ctx context.Context, key string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("raw", "true")
+
+	resp, err := s.get(ctx, s.config.KeyPrefix+key, query)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+
+	case http.StatusNotFound:
+		return nil, ErrConfigNotFound
+
+	default:
+		return nil, consulStatusError(resp)
+
+	}
+}
+
+func (s *consulSource) Watch(ctx context.Context, prefix string, notify func(key string)) error {
+	var index uint64
+	var pairs map[string]uint64
+	for {
+		query := url.Values{}
+		query.Set("recurse", "true")
+		if pairs != nil {
+			// Block til any key under the prefix is modified. The first request only fetches the current state.
+			query.Set("index", strconv.FormatUint(index, 10))
+			query.Set("wait", fmt.Sprintf("%ds", int64(s.config.WaitTime/time.Second)))
+		}
+
+		resp, err := s.get(ctx, s.config.KeyPrefix+prefix, query)
+		if err != nil {
+			return err
+		}
+
+		current, err := decodeConsulKVPairs(resp)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		newIndex, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid X-Consul-Index header: %w", err)
+		}
+		if newIndex < index {
+			// The index went backwards, e.g. the cluster is restored from a snapshot. Start over.
+			newIndex = 0
+		}
+		index = newIndex
+
+		if pairs != nil {
+			for key, modified := range current {
+				if pairs[key] != modified {
+					notify(strings.TrimPrefix(key, s.config.KeyPrefix))
+				}
+			}
+			for key := range pairs {
+				if _, ok := current[key]; !ok {
+					notify(strings.TrimPrefix(key, s.config.KeyPrefix))
+				}
+			}
+		}
+		pairs = current
+	}
+}
+
+func (s *consulSource) get(ctx context.Context, key string, query url.Values) (*http.Response, error) {
+	if s.config.Datacenter != "" {
+		query.Set("dc", s.config.Datacenter)
+	}
+
+	endpoint := strings.TrimSuffix(s.config.Address, "/") + "/v1/kv/" + key + "?" + query.Encode()
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", key, err)
+	}
+	req = req.WithContext(ctx)
+
+	if s.config.Token != "" {
+		req.Header.Set("X-Consul-Token", s.config.Token.Reveal())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", key, err)
+	}
+
+	return resp, nil
+}
+
+func decodeConsulKVPairs(resp *http.Response) (map[string]uint64, error) {
+	pairs := map[string]uint64{}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var decoded []*consulKVPair
+		err := json.NewDecoder(resp.Body).Decode(&decoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode Consul response: %w", err)
+		}
+
+		for _, pair := range decoded {
+			pairs[pair.Key] = pair.ModifyIndex
+		}
+		return pairs, nil
+
+	case http.StatusNotFound:
+		// No key is stored under the prefix yet.
+		return pairs, nil
+
+	default:
+		return nil, consulStatusError(resp)
+
+	}
+}
+
+func consulStatusError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected response from Consul: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,165 @@
+package watchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewConsulConfig(t *testing.T) {
+	config := NewConsulConfig()
+
+	if config.Address == "" || config.KeyPrefix == "" {
+		t.Errorf("Default value is not set: %#v.", config)
+	}
+
+	if config.WaitTime <= 0 {
+		t.Errorf("Default wait time is not set: %#v.", config)
+	}
+}
+
+func TestConsulSource_Get(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "secret" {
+			t.Errorf("Token is not sent: %s.", r.Header.Get("X-Consul-Token"))
+		}
+
+		if r.URL.Query().Get("raw") == "" || r.URL.Query().Get("dc") != "dc1" {
+			t.Errorf("Unexpected query is given: %s.", r.URL.RawQuery)
+		}
+
+		switch r.URL.Path {
+		case "/v1/kv/sarah/slack/echo":
+			_, _ = w.Write([]byte("token: foo"))
+
+		case "/v1/kv/sarah/slack/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+
+		}
+	}))
+	defer server.Close()
+
+	config := NewConsulConfig()
+	config.Address = server.URL
+	config.Token = "secret"
+	config.Datacenter = "dc1"
+	source := NewConsulSource(config, WithConsulHTTPClient(server.Client()))
+
+	b, err := source.Get(context.TODO(), "slack/echo")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(b) != "token: foo" {
+		t.Errorf("Unexpected value is returned: %s.", string(b))
+	}
+
+	_, err = source.Get(context.TODO(), "slack/unknown")
+	if !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	_, err = source.Get(context.TODO(), "slack/broken")
+	if err == nil || errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestConsulSource_Watch(t *testing.T) {
+	// Each response is returned per request to emulate the blocking queries.
+	responses := []struct {
+		index string
+		body  string
+	}{
+		{
+			index: "10",
+			body:  `[{"Key":"sarah/slack/echo","ModifyIndex":5},{"Key":"sarah/slack/hello","ModifyIndex":10}]`,
+		},
+		{
+			index: "12",
+			body:  `[{"Key":"sarah/slack/echo","ModifyIndex":12},{"Key":"sarah/slack/hello","ModifyIndex":10}]`,
+		},
+		{
+			index: "13",
+			body:  `[{"Key":"sarah/slack/echo","ModifyIndex":12}]`,
+		},
+	}
+
+	var mutex sync.Mutex
+	var indexes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/sarah/slack/" || r.URL.Query().Get("recurse") == "" {
+			t.Errorf("Unexpected request is given: %s.", r.URL.String())
+		}
+
+		mutex.Lock()
+		i := len(indexes)
+		indexes = append(indexes, r.URL.Query().Get("index"))
+		mutex.Unlock()
+
+		if i >= len(responses) {
+			<-r.Context().Done()
+			return
+		}
+
+		w.Header().Set("X-Consul-Index", responses[i].index)
+		_, _ = fmt.Fprint(w, responses[i].body)
+	}))
+	defer server.Close()
+
+	config := NewConsulConfig()
+	config.Address = server.URL
+	config.WaitTime = time.Second
+	source := NewConsulSource(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notified := make(chan string, 10)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- source.Watch(ctx, "slack/", func(key string) {
+			notified <- key
+		})
+	}()
+
+	for _, expected := range []string{"slack/echo", "slack/hello"} {
+		select {
+		case key := <-notified:
+			if key != expected {
+				t.Errorf("Unexpected key is notified: %s.", key)
+			}
+
+		case <-time.After(time.Second):
+			t.Fatalf("Change of %s is not notified.", expected)
+
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Unexpected error is returned: %#v.", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Watch does not return on context cancellation.")
+
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	expectedIndexes := []string{"", "10", "12"}
+	for i, index := range expectedIndexes {
+		if i >= len(indexes) || indexes[i] != index {
+			t.Errorf("Unexpected index is given: %v.", indexes)
+			break
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package watchers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// EtcdConfig contains some configuration variables for etcd ConfigSource.
+type EtcdConfig struct {
+	// Endpoint is the base URL of etcd's gRPC gateway such as "http://127.0.0.1:2379".
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// KeyPrefix is prepended to the keys so a key "slack/echo" is stored as "<KeyPrefix>slack/echo".
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+
+	// Username and Password are used to authenticate when etcd's authentication is enabled.
+	Username string       `json:"username" yaml:"username"`
+	Password sarah.Secret `json:"password" yaml:"password"`
+}
+
+// NewEtcdConfig returns EtcdConfig with default settings.
+func NewEtcdConfig() *EtcdConfig {
+	return &EtcdConfig{
+		Endpoint:  "http://127.0.0.1:2379",
+		KeyPrefix: "/sarah/",
+	}
+}
+
+// EtcdSourceOption defines function signature that NewEtcdSource's functional option must satisfy.
+type EtcdSourceOption func(*etcdSource)
+
+// WithEtcdHTTPClient creates an EtcdSourceOption that replaces the default http.Client.
+// Use this to set up TLS client certificates. The client must not set Timeout since the watch request is a long-lived stream.
+func WithEtcdHTTPClient(client *http.Client) EtcdSourceOption {
+	return func(s *etcdSource) {
+		s.client = client
+	}
+}
+
+// NewEtcdSource creates and returns a new ConfigSource that reads the configurations from etcd v3.
+// This talks to etcd's JSON gRPC gateway so no etcd client library is required; changes are subscribed with a watch stream on the BotType's key prefix.
+func NewEtcdSource(config *EtcdConfig, options ...EtcdSourceOption) ConfigSource {
+	s := &etcdSource{
+		config: config,
+		client: &http.Client{},
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+type etcdSource struct {
+	config *EtcdConfig
+	client *http.Client
+}
+
+var _ ConfigSource = (*etcdSource)(nil)
+
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdRangeResponse struct {
+	Kvs []*etcdKeyValue `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result *struct {
+		Created  bool `json:"created"`
+		Canceled bool `json:"canceled"`
+		Events   []*struct {
+			Kv *etcdKeyValue `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *etcdError `json:"error"`
+}
+
+type etcdError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *etcdError) Error() string {
+	return fmt.Sprintf("etcd error: code=%d message=%s", e.Code, e.Message)
+}
+
+func (s *etcdSource) Get(ctx context.Context, key string) ([]byte, error) {
+	// []byte fields are encoded in base64 as the gateway expects.
+	request := struct {
+		Key []byte `json:"key"`
+	}{
+		Key: []byte(s.config.KeyPrefix + key),
+	}
+
+	resp, err := s.post(ctx, "/v3/kv/range", request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	decoded := &etcdRangeResponse{}
+	err = json.NewDecoder(resp.Body).Decode(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+
+	if len(decoded.Kvs) == 0 {
+		return nil, ErrConfigNotFound
+	}
+
+	return decoded.Kvs[0].Value, nil
+}
+
+func (s *etcdSource) Watch(ctx context.Context, prefix string, notify func(key string)) error {
+	key := []byte(s.config.KeyPrefix + prefix)
+	request := struct {
+		CreateRequest interface{} `json:"create_request"`
+	}{
+		CreateRequest: struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+		}{
+			Key:      key,
+			RangeEnd: etcdPrefixEnd(key),
+		},
+	}
+
+	resp, err := s.post(ctx, "/v3/watch", request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The gateway streams a JSON object per watch response til the request is canceled.
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		decoded := &etcdWatchResponse{}
+		err := decoder.Decode(decoded)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read etcd watch stream: %w", err)
+		}
+
+		if decoded.Error != nil {
+			return decoded.Error
+		}
+
+		if decoded.Result == nil {
+			continue
+		}
+
+		if decoded.Result.Canceled {
+			return errors.New("etcd watch is canceled by the server")
+		}
+
+		for _, event := range decoded.Result.Events {
+			if event.Kv == nil {
+				continue
+			}
+			notify(strings.TrimPrefix(string(event.Kv.Key), s.config.KeyPrefix))
+		}
+	}
+}
+
+func (s *etcdSource) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	var token string
+	if s.config.Username != "" {
+		var err error
+		token, err = s.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := s.do(ctx, path, body, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, etcdStatusError(resp)
+	}
+
+	return resp, nil
+}
+
+// authenticate retrieves a token to be sent in the Authorization header.
+func (s *etcdSource) authenticate(ctx context.Context) (string, error) {
+	request := struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}{
+		Name:     s.config.Username,
+		Password: s.config.Password.Reveal(),
+	}
+
+	resp, err := s.do(ctx, "/v3/auth/authenticate", request, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to authenticate: %w", etcdStatusError(resp))
+	}
+
+	decoded := &struct {
+		Token string `json:"token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(decoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode etcd authentication response: %w", err)
+	}
+
+	return decoded.Token, nil
+}
+
+func (s *etcdSource) do(ctx context.Context, path string, body interface{}, token string) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request for %s: %w", path, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(s.config.Endpoint, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", path, err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", path, err)
+	}
+
+	return resp, nil
+}
+
+// etcdPrefixEnd returns the range end to fetch all keys with the given prefix.
+func etcdPrefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	// The prefix consists of 0xff only. Fetch all keys greater than or equal to the prefix.
+	return []byte{0}
+}
+
+func etcdStatusError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("unexpected response from etcd: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,184 @@
+package watchers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewEtcdConfig(t *testing.T) {
+	config := NewEtcdConfig()
+
+	if config.Endpoint == "" || config.KeyPrefix == "" {
+		t.Errorf("Default value is not set: %#v.", config)
+	}
+}
+
+func TestEtcdSource_Get(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/auth/authenticate":
+			_, _ = fmt.Fprint(w, `{"token":"dummyToken"}`)
+
+		case "/v3/kv/range":
+			if r.Header.Get("Authorization") != "dummyToken" {
+				t.Errorf("Token is not sent: %s.", r.Header.Get("Authorization"))
+			}
+
+			req := &struct {
+				Key []byte `json:"key"`
+			}{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+
+			if string(req.Key) != "/sarah/slack/echo" {
+				_, _ = fmt.Fprint(w, `{"header":{}}`)
+				return
+			}
+			value := base64.StdEncoding.EncodeToString([]byte("token: foo"))
+			_, _ = fmt.Fprintf(w, `{"header":{},"kvs":[{"key":"L3NhcmFoL3NsYWNrL2VjaG8=","value":"%s","mod_revision":"5"}],"count":"1"}`, value)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+
+		}
+	}))
+	defer server.Close()
+
+	config := NewEtcdConfig()
+	config.Endpoint = server.URL
+	config.Username = "user"
+	config.Password = "password"
+	source := NewEtcdSource(config, WithEtcdHTTPClient(server.Client()))
+
+	b, err := source.Get(context.TODO(), "slack/echo")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	if string(b) != "token: foo" {
+		t.Errorf("Unexpected value is returned: %s.", string(b))
+	}
+
+	_, err = source.Get(context.TODO(), "slack/unknown")
+	if !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestEtcdSource_Watch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/watch" {
+			t.Errorf("Unexpected path is requested: %s.", r.URL.Path)
+		}
+
+		req := &struct {
+			CreateRequest struct {
+				Key      []byte `json:"key"`
+				RangeEnd []byte `json:"range_end"`
+			} `json:"create_request"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(req)
+
+		if string(req.CreateRequest.Key) != "/sarah/slack/" || string(req.CreateRequest.RangeEnd) != "/sarah/slack0" {
+			t.Errorf("Unexpected range is requested: %s - %s.", req.CreateRequest.Key, req.CreateRequest.RangeEnd)
+		}
+
+		key := base64.StdEncoding.EncodeToString([]byte("/sarah/slack/echo"))
+		_, _ = fmt.Fprint(w, `{"result":{"header":{},"created":true}}`+"\n")
+		_, _ = fmt.Fprintf(w, `{"result":{"header":{},"events":[{"type":"PUT","kv":{"key":"%s"}}]}}`+"\n", key)
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	config := NewEtcdConfig()
+	config.Endpoint = server.URL
+	source := NewEtcdSource(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notified := make(chan string, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- source.Watch(ctx, "slack/", func(key string) {
+			notified <- key
+		})
+	}()
+
+	select {
+	case key := <-notified:
+		if key != "slack/echo" {
+			t.Errorf("Unexpected key is notified: %s.", key)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Change is not notified.")
+
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Unexpected error is returned: %#v.", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Watch does not return on context cancellation.")
+
+	}
+}
+
+func TestEtcdSource_Watch_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"error":{"grpc_code":16,"http_code":401,"code":16,"message":"invalid auth token"}}`)
+	}))
+	defer server.Close()
+
+	config := NewEtcdConfig()
+	config.Endpoint = server.URL
+	source := NewEtcdSource(config)
+
+	err := source.Watch(context.TODO(), "slack/", func(string) {})
+
+	var etcdErr *etcdError
+	if !errors.As(err, &etcdErr) {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+	if etcdErr.Code != 16 {
+		t.Errorf("Unexpected code is returned: %d.", etcdErr.Code)
+	}
+}
+
+func Test_etcdPrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix []byte
+		end    []byte
+	}{
+		{
+			prefix: []byte("/sarah/"),
+			end:    []byte("/sarah0"),
+		},
+		{
+			prefix: []byte{'a', 0xff},
+			end:    []byte{'b'},
+		},
+		{
+			prefix: []byte{0xff},
+			end:    []byte{0},
+		},
+	}
+
+	for i, tt := range tests {
+		end := etcdPrefixEnd(tt.prefix)
+		if !bytes.Equal(end, tt.end) {
+			t.Errorf("Unexpected range end is returned on test #%d: %v.", i, end)
+		}
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/fsnotify/fsnotify"
-	"github.com/oklahomer/go-kasumi/logger"
 	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
 	"gopkg.in/yaml.v2"
 	"os"
 	"path/filepath"
@@ -123,6 +123,7 @@ func (w *fileWatcher) Unwatch(botType sarah.BotType) (err error) {
 
 func (w *fileWatcher) run(ctx context.Context, events <-chan fsnotify.Event, errs <-chan error) {
 	subscriptions := map[string][]*subscription{}
+	log := moduleLogger(ctx)
 
 OP:
 	for {
@@ -130,9 +131,9 @@ OP:
 		case <-ctx.Done():
 			err := w.fsWatcher.Close()
 			if err == nil {
-				logger.Info("Stop subscribing to file system event due to context cancel.")
+				log.Info("Stop subscribing to file system event due to context cancel")
 			} else {
-				logger.Warnf("Error on subscription cancellation: %+v", err)
+				log.Warn("Error on subscription cancellation", logging.Err(err))
 			}
 
 			// Explicitly close unsubscribeGroup to make sure enqueueing does not block forever, but panics instead.
@@ -145,14 +146,14 @@ OP:
 		case event := <-events:
 			switch {
 			case event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create:
-				logger.Infof("Received %s event for %s.", event.Op.String(), event.Name)
+				log.Info("Received file system event", logging.F("op", event.Op.String()), logging.F("file", event.Name))
 
 				configFile, err := plainPathToFile(event.Name)
 				if errors.Is(err, errUnableToDetermineConfigFileFormat) || errors.Is(err, errUnsupportedConfigFileFormat) {
 					// Irrelevant file is updated
 					continue OP
 				} else if err != nil {
-					logger.Warnf("Failed to locate %s: %+v", event.Name, err)
+					log.Warn("Failed to locate file", logging.F("file", event.Name), logging.Err(err))
 					continue OP
 				}
 
@@ -171,12 +172,12 @@ OP:
 
 			default:
 				// Do nothing
-				logger.Debugf("Received %s event for %s.", event.Op.String(), event.Name)
+				log.Debug("Received file system event", logging.F("op", event.Op.String()), logging.F("file", event.Name))
 
 			}
 
 		case subscribe := <-w.subscribe:
-			logger.Infof("Start subscribing to %s", subscribe.absDir)
+			log.Info("Start subscribing to directory", logging.F(logging.KeyBotType, subscribe.botType), logging.F("dir", subscribe.absDir))
 
 			err := w.fsWatcher.Add(subscribe.absDir)
 			if err != nil {
@@ -198,7 +199,7 @@ OP:
 			subscribe.initErr <- nil
 
 		case botType := <-w.unsubscribe:
-			logger.Infof("Stop subscribing config files for %s", botType)
+			log.Info("Stop subscribing config files", logging.F(logging.KeyBotType, botType))
 
 			for dir, subscribeDirs := range subscriptions {
 				// Exclude all watches that are tied to given group, and stash those should be kept.
@@ -221,7 +222,7 @@ OP:
 			}
 
 		case err := <-errs:
-			logger.Errorf("Error on subscribing to directory change: %+v", err)
+			log.Error("Error on subscribing to directory change", logging.Err(err))
 
 		}
 	}
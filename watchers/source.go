@@ -0,0 +1,195 @@
+package watchers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"gopkg.in/yaml.v2"
+	"strings"
+	"sync"
+	"time"
+)
+
+// moduleLogger returns the Logger carried by the given context with this package's module name.
+func moduleLogger(ctx context.Context) logging.Logger {
+	return logging.FromContext(ctx).Module("watcher")
+}
+
+// ErrConfigNotFound is returned by ConfigSource.Get when no configuration is stored with the given key.
+var ErrConfigNotFound = errors.New("configuration not found")
+
+// ConfigSource defines an interface of a store that holds the configuration documents of Commands and ScheduledTasks.
+// NewSourceWatcher wraps a ConfigSource to provide sarah.ConfigWatcher, so the configuration can be stored in etcd, Consul KV,
+// a Kubernetes ConfigMap or any other store and the changes propagate to the running Bots without redeploying.
+//
+// A key is formed as "<BotType>/<ID>" such as "slack/echo". How the key is mapped to the store's own path is up to each implementation.
+type ConfigSource interface {
+	// Get returns the configuration document stored with the given key.
+	// The document is a YAML or JSON document. ErrConfigNotFound must be returned when nothing is stored.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Watch subscribes to the changes of the documents whose keys start with the given prefix, and calls the given function with the changed key.
+	// This blocks til the given context is canceled or the subscription fails.
+	Watch(ctx context.Context, prefix string, notify func(key string)) error
+}
+
+// SourceWatcherOption defines function signature that NewSourceWatcher's functional option must satisfy.
+type SourceWatcherOption func(*sourceWatcher)
+
+// WithWatchRetryInterval creates a SourceWatcherOption that sets the interval to subscribe to ConfigSource again after ConfigSource.Watch fails.
+// The default is 5 seconds.
+func WithWatchRetryInterval(interval time.Duration) SourceWatcherOption {
+	return func(w *sourceWatcher) {
+		w.retryInterval = interval
+	}
+}
+
+// NewSourceWatcher creates and returns a new sarah.ConfigWatcher implementation that reads and subscribes to the given ConfigSource.
+// The subscriptions stop when the given context is canceled.
+func NewSourceWatcher(ctx context.Context, source ConfigSource, options ...SourceWatcherOption) sarah.ConfigWatcher {
+	w := &sourceWatcher{
+		ctx:           ctx,
+		source:        source,
+		retryInterval: 5 * time.Second,
+		watches:       map[sarah.BotType]*botWatch{},
+	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	return w
+}
+
+type sourceWatcher struct {
+	ctx           context.Context
+	source        ConfigSource
+	retryInterval time.Duration
+	mutex         sync.Mutex
+	watches       map[sarah.BotType]*botWatch
+}
+
+type botWatch struct {
+	cancel    context.CancelFunc
+	callbacks map[string]func()
+}
+
+var _ sarah.ConfigWatcher = (*sourceWatcher)(nil)
+
+func (w *sourceWatcher) Read(ctx context.Context, botType sarah.BotType, id string, configPtr interface{}) error {
+	key := sourceKey(botType, id)
+	b, err := w.source.Get(ctx, key)
+	if errors.Is(err, ErrConfigNotFound) {
+		return &sarah.ConfigNotFoundError{
+			BotType: botType,
+			ID:      id,
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read configuration for %s: %w", key, err)
+	}
+
+	return decodeConfig(b, configPtr)
+}
+
+func (w *sourceWatcher) Watch(_ context.Context, botType sarah.BotType, id string, callback func()) error {
+	if w.ctx.Err() != nil {
+		return sarah.ErrWatcherNotRunning
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	watch, ok := w.watches[botType]
+	if ok {
+		if _, subscribing := watch.callbacks[id]; subscribing {
+			return sarah.ErrAlreadySubscribing
+		}
+		watch.callbacks[id] = callback
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	w.watches[botType] = &botWatch{
+		cancel:    cancel,
+		callbacks: map[string]func(){id: callback},
+	}
+	go w.subscribe(ctx, botType)
+
+	return nil
+}
+
+func (w *sourceWatcher) Unwatch(botType sarah.BotType) error {
+	if w.ctx.Err() != nil {
+		return sarah.ErrWatcherNotRunning
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	watch, ok := w.watches[botType]
+	if !ok {
+		return nil
+	}
+
+	moduleLogger(w.ctx).Info("Stop subscribing configurations", logging.F(logging.KeyBotType, botType))
+	watch.cancel()
+	delete(w.watches, botType)
+
+	return nil
+}
+
+// subscribe keeps subscribing to the ConfigSource til the given context is canceled.
+func (w *sourceWatcher) subscribe(ctx context.Context, botType sarah.BotType) {
+	prefix := sourceKey(botType, "")
+	log := moduleLogger(ctx).With(logging.F(logging.KeyBotType, botType))
+	notify := func(key string) {
+		id := strings.TrimPrefix(key, prefix)
+
+		w.mutex.Lock()
+		var callback func()
+		if watch, ok := w.watches[botType]; ok {
+			callback = watch.callbacks[id]
+		}
+		w.mutex.Unlock()
+
+		if callback != nil {
+			log.Info("Received configuration change", logging.F("key", key))
+			callback()
+		}
+	}
+
+	for {
+		log.Info("Start subscribing configurations", logging.F("prefix", prefix))
+		err := w.source.Watch(ctx, prefix, notify)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn("Failed to subscribe configurations", logging.F("prefix", prefix), logging.F("retry_interval", w.retryInterval), logging.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-time.After(w.retryInterval):
+			// Subscribe again.
+
+		}
+	}
+}
+
+func sourceKey(botType sarah.BotType, id string) string {
+	return strings.ToLower(botType.String()) + "/" + id
+}
+
+// decodeConfig decodes the given document in JSON when it looks like a JSON object, or in YAML otherwise.
+func decodeConfig(b []byte, configPtr interface{}) error {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return json.Unmarshal(b, configPtr)
+	}
+	return yaml.Unmarshal(b, configPtr)
+}
@@ -0,0 +1,184 @@
+package watchers
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"testing"
+	"time"
+)
+
+type DummyConfigSource struct {
+	GetFunc   func(context.Context, string) ([]byte, error)
+	WatchFunc func(context.Context, string, func(string)) error
+}
+
+func (s *DummyConfigSource) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.GetFunc(ctx, key)
+}
+
+func (s *DummyConfigSource) Watch(ctx context.Context, prefix string, notify func(string)) error {
+	return s.WatchFunc(ctx, prefix, notify)
+}
+
+func TestSourceWatcher_Read(t *testing.T) {
+	tests := []struct {
+		body      string
+		err       error
+		expectErr bool
+		notFound  bool
+	}{
+		{
+			body: `{"token": "foo"}`,
+		},
+		{
+			body: "token: foo",
+		},
+		{
+			err:      ErrConfigNotFound,
+			notFound: true,
+		},
+		{
+			err:       errors.New("connection refused"),
+			expectErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		source := &DummyConfigSource{
+			GetFunc: func(_ context.Context, key string) ([]byte, error) {
+				if key != "dummy/echo" {
+					t.Errorf("Unexpected key is given: %s.", key)
+				}
+				return []byte(tt.body), tt.err
+			},
+		}
+		w := NewSourceWatcher(context.Background(), source)
+
+		config := &struct {
+			Token string `json:"token" yaml:"token"`
+		}{}
+		err := w.Read(context.TODO(), "DUMMY", "echo", config)
+
+		if tt.notFound {
+			var notFound *sarah.ConfigNotFoundError
+			if !errors.As(err, &notFound) {
+				t.Errorf("Expected ConfigNotFoundError is not returned on test #%d: %#v.", i, err)
+			}
+			continue
+		}
+
+		if tt.expectErr {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected error is not returned on test #%d: %#v.", i, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			continue
+		}
+
+		if config.Token != "foo" {
+			t.Errorf("Configuration is not decoded on test #%d: %#v.", i, config)
+		}
+	}
+}
+
+func TestSourceWatcher_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notifyFunc := make(chan func(string), 1)
+	prefixes := make(chan string, 1)
+	source := &DummyConfigSource{
+		WatchFunc: func(ctx context.Context, prefix string, notify func(string)) error {
+			prefixes <- prefix
+			notifyFunc <- notify
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	w := NewSourceWatcher(ctx, source)
+
+	called := make(chan string, 2)
+	err := w.Watch(context.TODO(), "dummy", "echo", func() { called <- "echo" })
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = w.Watch(context.TODO(), "dummy", "hello", func() { called <- "hello" })
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = w.Watch(context.TODO(), "dummy", "echo", func() {})
+	if !errors.Is(err, sarah.ErrAlreadySubscribing) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if prefix := <-prefixes; prefix != "dummy/" {
+		t.Errorf("Unexpected prefix is given: %s.", prefix)
+	}
+
+	notify := <-notifyFunc
+	notify("dummy/unknown")
+	notify("dummy/hello")
+
+	select {
+	case id := <-called:
+		if id != "hello" {
+			t.Errorf("Unexpected callback is called: %s.", id)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Callback is not called.")
+
+	}
+
+	err = w.Unwatch("dummy")
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	notify("dummy/echo")
+	if len(called) != 0 {
+		t.Error("Callback is called after Unwatch.")
+	}
+
+	cancel()
+	if err := w.Unwatch("dummy"); !errors.Is(err, sarah.ErrWatcherNotRunning) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestSourceWatcher_Watch_Retry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := make(chan struct{}, 3)
+	source := &DummyConfigSource{
+		WatchFunc: func(_ context.Context, _ string, _ func(string)) error {
+			attempts <- struct{}{}
+			return errors.New("connection refused")
+		},
+	}
+	w := NewSourceWatcher(ctx, source, WithWatchRetryInterval(time.Millisecond))
+
+	err := w.Watch(context.TODO(), "dummy", "echo", func() {})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-attempts:
+			// O.K.
+
+		case <-time.After(time.Second):
+			t.Fatal("Subscription is not retried.")
+
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathStep is one step of a parsed JSONPath.
+// A step with wildcard selects all elements of an array or all values of an object.
+type pathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parsePath parses a subset of JSONPath: the root "$" followed by ".key", "['key']", "[index]" and "[*]" or ".*" steps.
+func parsePath(path string) ([]*pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path must start with $: %s", path)
+	}
+
+	var steps []*pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in path: %s", path)
+			}
+			rest = rest[end:]
+
+			if key == "*" {
+				steps = append(steps, &pathStep{wildcard: true})
+			} else {
+				steps = append(steps, &pathStep{key: key})
+			}
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in path: %s", path)
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+
+			switch {
+			case selector == "*":
+				steps = append(steps, &pathStep{wildcard: true})
+
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				steps = append(steps, &pathStep{key: selector[1 : len(selector)-1]})
+
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("invalid selector %s in path: %s", selector, path)
+				}
+				steps = append(steps, &pathStep{index: index, isIndex: true})
+
+			}
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q in path: %s", rest[0], path)
+
+		}
+	}
+
+	return steps, nil
+}
+
+// evaluatePath returns the value the given steps select in the decoded JSON document.
+// When the steps include a wildcard, a slice of the selected values is returned.
+// nil is returned when nothing is selected.
+func evaluatePath(steps []*pathStep, document interface{}) interface{} {
+	values := []interface{}{document}
+	multiple := false
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range values {
+			next = append(next, step.selectFrom(value)...)
+		}
+		values = next
+		multiple = multiple || step.wildcard
+	}
+
+	if multiple {
+		if values == nil {
+			return []interface{}{}
+		}
+		return values
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+func (step *pathStep) selectFrom(value interface{}) []interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if step.wildcard {
+			// Iterate over the keys in a stable order.
+			keys := make([]string, 0, len(typed))
+			for key := range typed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			selected := make([]interface{}, 0, len(keys))
+			for _, key := range keys {
+				selected = append(selected, typed[key])
+			}
+			return selected
+		}
+
+		if v, ok := typed[step.key]; ok && !step.isIndex {
+			return []interface{}{v}
+		}
+
+	case []interface{}:
+		if step.wildcard {
+			return typed
+		}
+
+		if step.isIndex {
+			index := step.index
+			if index < 0 {
+				index += len(typed)
+			}
+			if index >= 0 && index < len(typed) {
+				return []interface{}{typed[index]}
+			}
+		}
+
+	}
+
+	return nil
+}
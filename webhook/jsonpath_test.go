@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_parsePath_WithError(t *testing.T) {
+	tests := []string{
+		"",
+		"ticket.title",
+		"$.",
+		"$.items[0",
+		"$.items[foo]",
+		"$items",
+	}
+
+	for _, tt := range tests {
+		if _, err := parsePath(tt); err == nil {
+			t.Errorf("Expected error is not returned for %s.", tt)
+		}
+	}
+}
+
+func Test_evaluatePath(t *testing.T) {
+	var document interface{}
+	err := json.Unmarshal([]byte(`{
+  "ticket": {
+    "title": "Broken build",
+    "id": 123,
+    "assignees": [{"name": "alice"}, {"name": "bob"}],
+    "labels": {"b": "bug", "a": "urgent"},
+    "odd key": true
+  }
+}`), &document)
+	if err != nil {
+		t.Fatalf("Failed to prepare document: %s.", err.Error())
+	}
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{
+			path:     "$",
+			expected: document,
+		},
+		{
+			path:     "$.ticket.title",
+			expected: "Broken build",
+		},
+		{
+			path:     "$.ticket.id",
+			expected: float64(123),
+		},
+		{
+			path:     "$['ticket'][\"odd key\"]",
+			expected: true,
+		},
+		{
+			path:     "$.ticket.assignees[1].name",
+			expected: "bob",
+		},
+		{
+			path:     "$.ticket.assignees[-1].name",
+			expected: "bob",
+		},
+		{
+			path:     "$.ticket.assignees[*].name",
+			expected: []interface{}{"alice", "bob"},
+		},
+		{
+			path:     "$.ticket.labels.*",
+			expected: []interface{}{"urgent", "bug"},
+		},
+		{
+			path:     "$.ticket.missing",
+			expected: nil,
+		},
+		{
+			path:     "$.ticket.assignees[5].name",
+			expected: nil,
+		},
+		{
+			path:     "$.ticket.missing[*]",
+			expected: []interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		steps, err := parsePath(tt.path)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned for %s: %s.", tt.path, err.Error())
+		}
+
+		value := evaluatePath(steps, document)
+		if !reflect.DeepEqual(value, tt.expected) {
+			t.Errorf("Unexpected value is returned for %s: %#v.", tt.path, value)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+/*
+Package webhook provides a factory that turns a declarative definition into a sarah.Command that calls an HTTP endpoint,
+so simple integrations with internal REST services need no Go code.
+
+The definitions are typically written in a YAML file and loaded with LoadCommands:
+
+	commands:
+	  - identifier: ticket
+	    pattern: "^\\.ticket\\s+(\\d+)$"
+	    instruction: "Input .ticket <id> to see the ticket."
+	    method: GET
+	    url: "https://tracker.example.com/api/tickets/{{ index .Matches 1 | urlquery }}"
+	    headers:
+	      Authorization: "Bearer {{ env \"TRACKER_TOKEN\" }}"
+	    response:
+	      fields:
+	        title: "$.ticket.title"
+	        assignees: "$.ticket.assignees[*].name"
+	      template: "{{ .title }} (assigned to {{ join .assignees \", \" }})"
+	  - identifier: note
+	    pattern: "^\\.note\\s+(.+)$"
+	    method: POST
+	    url: "https://notes.example.com/api/notes"
+	    body: '{"author": {{ json .SenderKey }}, "text": {{ json (index .Matches 1) }}}'
+
+The URL, the header values and the body are text/template rendered with Input.
+In addition to the builtin functions such as urlquery, json returns the JSON literal of the given value so the user input is safely embedded in a JSON payload,
+and env returns the value of the given environment variable so a credential does not have to be written in the file.
+
+Each Response.Fields value is a JSONPath that picks a value from the JSON response.
+A subset of JSONPath is supported: "$" followed by ".key", "['key']", "[index]" and "[*]" or ".*" steps.
+A path with a wildcard results in a list. Response.Template renders the picked values, and join concatenates a list.
+When no Response.Template is given, the response body is returned as-is.
+
+A response with a non-2xx status code is treated as an error.
+
+	commands, err := webhook.LoadCommands("/path/to/webhooks.yaml")
+	if err != nil {
+		panic(err)
+	}
+	for _, command := range commands {
+		sarah.RegisterScopedCommand(sarah.AllBots(), command)
+	}
+*/
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultMaxResponseBytes = 1 << 20 // 1MiB
+)
+
+// Config is the root of the definition file LoadCommands reads.
+type Config struct {
+	Commands []*Definition `json:"commands" yaml:"commands"`
+}
+
+// Definition defines a Command that calls an HTTP endpoint.
+type Definition struct {
+	// Identifier is the Command identifier.
+	Identifier string `json:"identifier" yaml:"identifier"`
+
+	// Pattern is the regular expression an input must match. The submatches are available to the templates as .Matches.
+	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// Instruction is the instruction of the Command.
+	Instruction string `json:"instruction" yaml:"instruction"`
+
+	// Method is the HTTP method. GET is used when empty.
+	Method string `json:"method" yaml:"method"`
+
+	// URL is the text/template of the request URL.
+	URL string `json:"url" yaml:"url"`
+
+	// Headers are the request headers. Each value is a text/template.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+
+	// Body is the text/template of the request body. No body is sent when empty.
+	Body string `json:"body" yaml:"body"`
+
+	// ContentType is the Content-Type of the request body. This is set only when Body is given.
+	ContentType string `json:"content_type" yaml:"content_type"`
+
+	// Timeout is the maximum duration of the request.
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// MaxResponseBytes is the maximum size of the response body to read. The rest is discarded.
+	MaxResponseBytes int64 `json:"max_response_bytes" yaml:"max_response_bytes"`
+
+	// Response defines how the response is turned into a text.
+	Response *ResponseDefinition `json:"response" yaml:"response"`
+}
+
+// ResponseDefinition defines how a JSON response is turned into a text.
+type ResponseDefinition struct {
+	// Fields maps a name to the JSONPath of the value in the response. The values are available to Template by the names.
+	Fields map[string]string `json:"fields" yaml:"fields"`
+
+	// Template is the text/template to render the Fields with. The response body is returned as-is when empty.
+	Template string `json:"template" yaml:"template"`
+}
+
+// NewDefinition returns a new Definition instance with default values.
+// Identifier, Pattern and URL must be set before use.
+func NewDefinition() *Definition {
+	return &Definition{
+		Identifier:       "",
+		Pattern:          "",
+		Instruction:      "",
+		Method:           http.MethodGet,
+		URL:              "",
+		Headers:          map[string]string{},
+		Body:             "",
+		ContentType:      "application/json",
+		Timeout:          defaultTimeout,
+		MaxResponseBytes: defaultMaxResponseBytes,
+		Response:         nil,
+	}
+}
+
+var _ sarah.ConfigDefaulter = (*Definition)(nil)
+var _ sarah.ConfigValidator = (*Definition)(nil)
+
+// ApplyDefaults sets the default values to Method, ContentType, Timeout and MaxResponseBytes when they are not given.
+func (d *Definition) ApplyDefaults() {
+	if d.Method == "" {
+		d.Method = http.MethodGet
+	}
+
+	if d.ContentType == "" {
+		d.ContentType = "application/json"
+	}
+
+	if d.Timeout == 0 {
+		d.Timeout = defaultTimeout
+	}
+
+	if d.MaxResponseBytes == 0 {
+		d.MaxResponseBytes = defaultMaxResponseBytes
+	}
+}
+
+// Validate checks the required fields and compiles the pattern, the templates and the JSONPaths.
+func (d *Definition) Validate() error {
+	var errs sarah.ConfigKeyErrors
+
+	if d.Identifier == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "identifier", Err: errors.New("identifier is empty")})
+	}
+
+	if d.Pattern == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "pattern", Err: errors.New("pattern is empty")})
+	} else if _, err := regexp.Compile(d.Pattern); err != nil {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "pattern", Value: d.Pattern, Err: err})
+	}
+
+	if d.URL == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "url", Err: errors.New("url is empty")})
+	} else if _, err := parseTemplate("url", d.URL); err != nil {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "url", Err: err})
+	}
+
+	for _, name := range sortedKeys(d.Headers) {
+		if _, err := parseTemplate(name, d.Headers[name]); err != nil {
+			errs = append(errs, &sarah.ConfigKeyError{Key: "headers." + name, Err: err})
+		}
+	}
+
+	if _, err := parseTemplate("body", d.Body); err != nil {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "body", Err: err})
+	}
+
+	if d.Timeout < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "timeout", Value: d.Timeout.String(), Err: errors.New("timeout must not be negative")})
+	}
+
+	if d.MaxResponseBytes < 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "max_response_bytes", Value: fmt.Sprint(d.MaxResponseBytes), Err: errors.New("max_response_bytes must not be negative")})
+	}
+
+	if d.Response != nil {
+		for _, name := range sortedKeys(d.Response.Fields) {
+			path := d.Response.Fields[name]
+			if _, err := parsePath(path); err != nil {
+				errs = append(errs, &sarah.ConfigKeyError{Key: "response.fields." + name, Value: path, Err: err})
+			}
+		}
+
+		if _, err := parseTemplate("response", d.Response.Template); err != nil {
+			errs = append(errs, &sarah.ConfigKeyError{Key: "response.template", Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Input is given to the templates of Definition.URL, Definition.Headers and Definition.Body.
+type Input struct {
+	// Message is the whole input message.
+	Message string
+
+	// SenderKey is the sender key of the input.
+	SenderKey string
+
+	// Matches are the submatches of Definition.Pattern. Matches[0] is the whole match.
+	Matches []string
+}
+
+// Option defines function signature that LoadCommands's and NewCommand's functional option must satisfy.
+type Option func(*command)
+
+// WithHTTPClient creates an Option that replaces the default http.Client.
+// Definition.Timeout still applies to each request.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *command) {
+		c.client = client
+	}
+}
+
+// LoadCommands reads the definitions from the given YAML or JSON file and returns the corresponding Commands.
+// An error is returned when the file can not be read or any of the definitions is invalid.
+func LoadCommands(path string, options ...Option) ([]sarah.Command, error) {
+	config := &Config{}
+	err := sarah.LoadConfig(config, sarah.ConfigFromFile(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	commands := make([]sarah.Command, 0, len(config.Commands))
+	for i, d := range config.Commands {
+		command, err := NewCommand(d, options...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command #%d in %s: %w", i, path, err)
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+// NewCommand validates the given Definition and returns the corresponding Command.
+func NewCommand(d *Definition, options ...Option) (sarah.Command, error) {
+	err := sarah.ValidateConfig(d)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &command{
+		definition: d,
+		pattern:    regexp.MustCompile(d.Pattern),
+		headers:    map[string]*template.Template{},
+		fields:     map[string][]*pathStep{},
+		client:     &http.Client{},
+	}
+
+	// Validate has already checked the templates and the paths.
+	c.url, _ = parseTemplate("url", d.URL)
+	c.body, _ = parseTemplate("body", d.Body)
+	for name, value := range d.Headers {
+		c.headers[name], _ = parseTemplate(name, value)
+	}
+	if d.Response != nil {
+		for name, path := range d.Response.Fields {
+			c.fields[name], _ = parsePath(path)
+		}
+		c.response, _ = parseTemplate("response", d.Response.Template)
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+var funcs = template.FuncMap{
+	"json": jsonLiteral,
+	"env":  os.Getenv,
+	"join": join,
+}
+
+func parseTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+}
+
+// jsonLiteral returns the JSON literal of the given value.
+// Unlike json.Marshal, characters such as "&" and "<" are kept as-is since the payload is not embedded in HTML.
+func jsonLiteral(v interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// join concatenates the string forms of the elements of a list picked by a JSONPath with a wildcard.
+func join(values interface{}, separator string) string {
+	list, ok := values.([]interface{})
+	if !ok {
+		if values == nil {
+			return ""
+		}
+		return fmt.Sprint(values)
+	}
+
+	strs := make([]string, 0, len(list))
+	for _, v := range list {
+		strs = append(strs, fmt.Sprint(v))
+	}
+	return strings.Join(strs, separator)
+}
+
+type command struct {
+	definition *Definition
+	pattern    *regexp.Regexp
+	url        *template.Template
+	headers    map[string]*template.Template
+	body       *template.Template
+	fields     map[string][]*pathStep
+	response   *template.Template
+	client     *http.Client
+}
+
+var _ sarah.Command = (*command)(nil)
+
+func (c *command) Identifier() string {
+	return c.definition.Identifier
+}
+
+func (c *command) Execute(ctx context.Context, input sarah.Input) (*sarah.CommandResponse, error) {
+	req, err := c.buildRequest(&Input{
+		Message:   input.Message(),
+		SenderKey: input.SenderKey(),
+		Matches:   c.pattern.FindStringSubmatch(input.Message()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", c.definition.Identifier, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.definition.Timeout)
+	defer cancel()
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", c.definition.Identifier, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.definition.MaxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for %s: %w", c.definition.Identifier, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code is returned for %s: %d", c.definition.Identifier, resp.StatusCode)
+	}
+
+	text, err := c.render(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render response for %s: %w", c.definition.Identifier, err)
+	}
+	if text == "" {
+		return nil, nil
+	}
+
+	return &sarah.CommandResponse{
+		Content:     text,
+		UserContext: nil,
+	}, nil
+}
+
+func (c *command) Instruction(_ *sarah.HelpInput) string {
+	return c.definition.Instruction
+}
+
+func (c *command) Match(input sarah.Input) bool {
+	return c.pattern.MatchString(input.Message())
+}
+
+func (c *command) buildRequest(data *Input) (*http.Request, error) {
+	url, err := execute(c.url, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if c.definition.Body != "" {
+		rendered, err := execute(c.body, data)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(rendered)
+	}
+
+	req, err := http.NewRequest(c.definition.Method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, tmpl := range c.headers {
+		value, err := execute(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", c.definition.ContentType)
+	}
+
+	return req, nil
+}
+
+// render turns the response body into a text with Response.Fields and Response.Template.
+func (c *command) render(body []byte) (string, error) {
+	if c.response == nil || c.definition.Response.Template == "" {
+		return strings.TrimSpace(string(body)), nil
+	}
+
+	var document interface{}
+	err := json.Unmarshal(body, &document)
+	if err != nil {
+		return "", fmt.Errorf("response is not a valid JSON: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	for name, steps := range c.fields {
+		value := evaluatePath(steps, document)
+		if value == nil {
+			// Render an empty string instead of "<no value>".
+			value = ""
+		}
+		values[name] = value
+	}
+
+	return execute(c.response, values)
+}
+
+func execute(tmpl *template.Template, data interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	err := tmpl.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
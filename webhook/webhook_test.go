@@ -0,0 +1,318 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"github.com/oklahomer/go-sarah/v4"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DummyInput struct {
+	SenderKeyValue string
+	MessageValue   string
+	SentAtValue    time.Time
+	ReplyToValue   sarah.OutputDestination
+}
+
+func (i *DummyInput) SenderKey() string {
+	return i.SenderKeyValue
+}
+
+func (i *DummyInput) Message() string {
+	return i.MessageValue
+}
+
+func (i *DummyInput) SentAt() time.Time {
+	return i.SentAtValue
+}
+
+func (i *DummyInput) ReplyTo() sarah.OutputDestination {
+	return i.ReplyToValue
+}
+
+func TestNewDefinition(t *testing.T) {
+	d := NewDefinition()
+
+	if d.Method != http.MethodGet {
+		t.Errorf("Unexpected default method is set: %s.", d.Method)
+	}
+
+	if d.ContentType != "application/json" {
+		t.Errorf("Unexpected default content type is set: %s.", d.ContentType)
+	}
+
+	if d.Timeout != defaultTimeout {
+		t.Errorf("Unexpected default timeout is set: %s.", d.Timeout)
+	}
+
+	if d.MaxResponseBytes != defaultMaxResponseBytes {
+		t.Errorf("Unexpected default response limit is set: %d.", d.MaxResponseBytes)
+	}
+}
+
+func TestDefinition_ApplyDefaults(t *testing.T) {
+	d := &Definition{}
+	d.ApplyDefaults()
+
+	if d.Method != http.MethodGet || d.ContentType != "application/json" || d.Timeout != defaultTimeout || d.MaxResponseBytes != defaultMaxResponseBytes {
+		t.Errorf("Defaults are not applied: %#v.", d)
+	}
+}
+
+func TestDefinition_Validate(t *testing.T) {
+	d := &Definition{
+		Pattern: "(",
+		Body:    "{{ .Message ",
+		Response: &ResponseDefinition{
+			Fields:   map[string]string{"title": "title"},
+			Template: "{{ .title ",
+		},
+	}
+
+	err := d.Validate()
+	var errs sarah.ConfigKeyErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected error is not returned: %#v.", err)
+	}
+
+	keys := []string{}
+	for _, e := range errs {
+		keys = append(keys, e.Key)
+	}
+	if strings.Join(keys, ",") != "identifier,pattern,url,body,response.fields.title,response.template" {
+		t.Errorf("Unexpected keys are reported: %s.", strings.Join(keys, ","))
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	_ = os.Setenv("WEBHOOK_TEST_TOKEN", "secret")
+	defer os.Unsetenv("WEBHOOK_TEST_TOKEN")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Unexpected method is used: %s.", r.Method)
+		}
+
+		if r.URL.Path != "/tickets/123" || r.URL.Query().Get("q") != "a b&c" {
+			t.Errorf("Unexpected URL is requested: %s.", r.URL.String())
+		}
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Unexpected header is given: %s.", r.Header.Get("Authorization"))
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type is given: %s.", r.Header.Get("Content-Type"))
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != `{"user": "alice", "text": "a b&c"}` {
+			t.Errorf("Unexpected body is given: %s.", string(body))
+		}
+
+		_, _ = w.Write([]byte(`{"ticket": {"title": "Broken build", "assignees": [{"name": "alice"}, {"name": "bob"}]}}`))
+	}))
+	defer server.Close()
+
+	d := NewDefinition()
+	d.Identifier = "ticket"
+	d.Pattern = `^\.ticket\s+(\d+)\s+(.+)$`
+	d.Instruction = "Input .ticket <id> <query>."
+	d.Method = http.MethodPost
+	d.URL = server.URL + "/tickets/{{ index .Matches 1 }}?q={{ index .Matches 2 | urlquery }}"
+	d.Headers = map[string]string{"Authorization": `Bearer {{ env "WEBHOOK_TEST_TOKEN" }}`}
+	d.Body = `{"user": {{ json .SenderKey }}, "text": {{ json (index .Matches 2) }}}`
+	d.Response = &ResponseDefinition{
+		Fields: map[string]string{
+			"title":     "$.ticket.title",
+			"assignees": "$.ticket.assignees[*].name",
+			"missing":   "$.ticket.missing",
+		},
+		Template: `{{ .title }} (assigned to {{ join .assignees ", " }}){{ .missing }}`,
+	}
+
+	command, err := NewCommand(d)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if command.Identifier() != "ticket" {
+		t.Errorf("Unexpected identifier is returned: %s.", command.Identifier())
+	}
+
+	if command.Instruction(&sarah.HelpInput{}) != "Input .ticket <id> <query>." {
+		t.Errorf("Unexpected instruction is returned: %s.", command.Instruction(&sarah.HelpInput{}))
+	}
+
+	input := &DummyInput{SenderKeyValue: "alice", MessageValue: ".ticket 123 a b&c"}
+	if !command.Match(input) {
+		t.Fatal("Command must match.")
+	}
+
+	res, err := command.Execute(context.TODO(), input)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res == nil || res.Content != "Broken build (assigned to alice, bob)" {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+}
+
+func Test_command_Execute_WithoutResponseTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != http.NoBody && r.ContentLength != 0 {
+			t.Error("Body must not be sent.")
+		}
+		_, _ = w.Write([]byte("pong\n"))
+	}))
+	defer server.Close()
+
+	d := NewDefinition()
+	d.Identifier = "ping"
+	d.Pattern = `^\.ping`
+	d.URL = server.URL
+
+	var requested bool
+	client := &http.Client{
+		Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			requested = true
+			return http.DefaultTransport.RoundTrip(r)
+		}),
+	}
+	command, err := NewCommand(d, WithHTTPClient(client))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	res, err := command.Execute(context.TODO(), &DummyInput{MessageValue: ".ping"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if res == nil || res.Content != "pong" {
+		t.Errorf("Unexpected response is returned: %#v.", res)
+	}
+
+	if !requested {
+		t.Error("Given http.Client is not used.")
+	}
+}
+
+func Test_command_Execute_WithError(t *testing.T) {
+	tests := []struct {
+		handler  http.HandlerFunc
+		response *ResponseDefinition
+		timeout  time.Duration
+	}{
+		{
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+		{
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("not a JSON"))
+			},
+			response: &ResponseDefinition{Template: "{{ .title }}"},
+		},
+		{
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(500 * time.Millisecond)
+			},
+			timeout: 50 * time.Millisecond,
+		},
+	}
+
+	for i, tt := range tests {
+		func() {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			d := NewDefinition()
+			d.Identifier = "failure"
+			d.Pattern = ".*"
+			d.URL = server.URL
+			d.Response = tt.response
+			if tt.timeout > 0 {
+				d.Timeout = tt.timeout
+			}
+
+			command, err := NewCommand(d)
+			if err != nil {
+				t.Fatalf("Unexpected error is returned on test #%d: %s.", i, err.Error())
+			}
+
+			_, err = command.Execute(context.TODO(), &DummyInput{})
+			if err == nil {
+				t.Errorf("Expected error is not returned on test #%d.", i)
+			}
+		}()
+	}
+}
+
+func TestLoadCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook")
+	if err != nil {
+		t.Fatalf("Failed to create a directory: %s.", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "webhooks.yaml")
+	body := `
+commands:
+  - identifier: ticket
+    pattern: "^\\.ticket\\s+(\\d+)$"
+    url: "https://tracker.example.com/api/tickets/{{ index .Matches 1 }}"
+    timeout: 1m
+    response:
+      fields:
+        title: "$.ticket.title"
+      template: "{{ .title }}"
+`
+	err = ioutil.WriteFile(path, []byte(body), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write a file: %s.", err.Error())
+	}
+
+	commands, err := LoadCommands(path)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if len(commands) != 1 {
+		t.Fatalf("Unexpected number of commands are returned: %d.", len(commands))
+	}
+
+	ticket, ok := commands[0].(*command)
+	if !ok {
+		t.Fatalf("Unexpected type is returned: %T.", commands[0])
+	}
+
+	if ticket.definition.Method != http.MethodGet || ticket.definition.Timeout != time.Minute || len(ticket.fields) != 1 {
+		t.Errorf("Unexpected definition is loaded: %#v.", ticket.definition)
+	}
+
+	err = ioutil.WriteFile(path, []byte("commands:\n  - identifier: broken\n"), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write a file: %s.", err.Error())
+	}
+
+	_, err = LoadCommands(path)
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,10 @@
+/*
+Package workers provides a worker pool implementation that satisfies the worker interface required by sarah.RegisterWorker.
+
+In addition to the basic job execution, this provides some operational features such as statistics reporting
+so the administrators can monitor the pool's saturation.
+
+The job queue is abstracted by Queue interface.
+The in-memory queue is used by default, and a remote backend such as the one the redisqueue package provides can be plugged in with WithQueue.
+*/
+package workers
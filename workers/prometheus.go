@@ -0,0 +1,206 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// PrometheusReporter is a Reporter implementation that exposes the latest reported Stats in Prometheus' text-based exposition format.
+// This also implements http.Handler, so the instance can be directly registered to an HTTP server as a scraping endpoint.
+//
+//  reporter := workers.NewPrometheusReporter("mybot")
+//  wkr, _ := workers.Run(ctx, workers.NewConfig(), workers.WithReporter(reporter))
+//  http.Handle("/metrics", reporter)
+//
+// The saturation of the job queue can be monitored by comparing {namespace}_worker_queue_length with {namespace}_worker_queue_capacity.
+type PrometheusReporter struct {
+	namespace string
+	stats     *Stats
+	mutex     sync.RWMutex
+}
+
+var _ Reporter = (*PrometheusReporter)(nil)
+var _ http.Handler = (*PrometheusReporter)(nil)
+
+// NewPrometheusReporter creates and returns a new PrometheusReporter instance.
+// The given namespace is used as a prefix of each metric name.
+func NewPrometheusReporter(namespace string) *PrometheusReporter {
+	return &PrometheusReporter{
+		namespace: namespace,
+		stats:     &Stats{},
+	}
+}
+
+// Report receives the latest Stats and stashes it til the next scraping.
+func (r *PrometheusReporter) Report(_ context.Context, stats *Stats) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.stats = stats
+}
+
+// ServeHTTP writes the latest Stats in Prometheus' text-based exposition format.
+func (r *PrometheusReporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = w.Write(r.exposition())
+}
+
+func (r *PrometheusReporter) exposition() []byte {
+	r.mutex.RLock()
+	stats := *r.stats
+	r.mutex.RUnlock()
+
+	metrics := []struct {
+		name       string
+		metricType string
+		help       string
+		value      interface{}
+	}{
+		{
+			name:       "worker_queue_length",
+			metricType: "gauge",
+			help:       "Number of jobs waiting in the queue.",
+			value:      stats.QueueSize,
+		},
+		{
+			name:       "worker_queue_capacity",
+			metricType: "gauge",
+			help:       "Maximum number of jobs the queue can hold.",
+			value:      stats.QueueCapacity,
+		},
+		{
+			name:       "worker_workers",
+			metricType: "gauge",
+			help:       "Number of running workers.",
+			value:      stats.WorkerNum,
+		},
+		{
+			name:       "worker_active_workers",
+			metricType: "gauge",
+			help:       "Number of workers executing a job.",
+			value:      stats.ActiveWorkers,
+		},
+		{
+			name:       "worker_jobs_processed_total",
+			metricType: "counter",
+			help:       "Number of finished jobs.",
+			value:      stats.Processed,
+		},
+		{
+			name:       "worker_jobs_failed_total",
+			metricType: "counter",
+			help:       "Number of panicked jobs.",
+			value:      stats.Failed,
+		},
+		{
+			name:       "worker_jobs_expired_total",
+			metricType: "counter",
+			help:       "Number of jobs skipped due to context cancellation or deadline.",
+			value:      stats.Expired,
+		},
+		{
+			name:       "worker_jobs_rejected_total",
+			metricType: "counter",
+			help:       "Number of jobs rejected due to queue overflow.",
+			value:      stats.Rejected,
+		},
+		{
+			name:       "worker_jobs_dropped_total",
+			metricType: "counter",
+			help:       "Number of jobs dropped due to queue overflow.",
+			value:      stats.Dropped,
+		},
+		{
+			name:       "worker_replaced_workers_total",
+			metricType: "counter",
+			help:       "Number of workers that unexpectedly stopped and were replaced.",
+			value:      stats.Replaced,
+		},
+		{
+			name:       "worker_job_latency_average_seconds",
+			metricType: "gauge",
+			help:       "Average job execution time in the latest reporting interval.",
+			value:      stats.AverageLatency.Seconds(),
+		},
+		{
+			name:       "worker_job_latency_max_seconds",
+			metricType: "gauge",
+			help:       "Longest job execution time in the latest reporting interval.",
+			value:      stats.MaxLatency.Seconds(),
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	for _, m := range metrics {
+		name := r.metricName(m.name)
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, m.help)
+		fmt.Fprintf(buf, "# TYPE %s %s\n", name, m.metricType)
+		fmt.Fprintf(buf, "%s %v\n", name, m.value)
+	}
+
+	if len(stats.Jobs) == 0 {
+		return buf.Bytes()
+	}
+
+	// Named jobs' statistics are exposed with a "job_name" label.
+	// "job" is not used since Prometheus attaches its own "job" label to every scraped metric.
+	names := make([]string, 0, len(stats.Jobs))
+	for name := range stats.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	jobMetrics := []struct {
+		name       string
+		metricType string
+		help       string
+		value      func(*JobStats) interface{}
+	}{
+		{
+			name:       "worker_named_jobs_processed_total",
+			metricType: "counter",
+			help:       "Number of finished jobs per job name.",
+			value:      func(s *JobStats) interface{} { return s.Processed },
+		},
+		{
+			name:       "worker_named_jobs_failed_total",
+			metricType: "counter",
+			help:       "Number of panicked jobs per job name.",
+			value:      func(s *JobStats) interface{} { return s.Failed },
+		},
+		{
+			name:       "worker_named_jobs_expired_total",
+			metricType: "counter",
+			help:       "Number of expired jobs per job name.",
+			value:      func(s *JobStats) interface{} { return s.Expired },
+		},
+		{
+			name:       "worker_named_job_latency_seconds_sum",
+			metricType: "counter",
+			help:       "Total job execution time per job name.",
+			value:      func(s *JobStats) interface{} { return s.TotalLatency.Seconds() },
+		},
+	}
+	for _, m := range jobMetrics {
+		name := r.metricName(m.name)
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, m.help)
+		fmt.Fprintf(buf, "# TYPE %s %s\n", name, m.metricType)
+		for _, jobName := range names {
+			fmt.Fprintf(buf, "%s{job_name=%s} %v\n", name, strconv.Quote(jobName), m.value(stats.Jobs[jobName]))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func (r *PrometheusReporter) metricName(name string) string {
+	if r.namespace == "" {
+		return name
+	}
+	return fmt.Sprintf("%s_%s", r.namespace, name)
+}
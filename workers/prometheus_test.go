@@ -0,0 +1,72 @@
+package workers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPrometheusReporter(t *testing.T) {
+	reporter := NewPrometheusReporter("dummy")
+
+	if reporter.namespace != "dummy" {
+		t.Errorf("Expected namespace is not set: %s.", reporter.namespace)
+	}
+
+	if reporter.stats == nil {
+		t.Error("Initial stats is not set.")
+	}
+}
+
+func TestPrometheusReporter_Report(t *testing.T) {
+	reporter := NewPrometheusReporter("dummy")
+	stats := &Stats{}
+
+	reporter.Report(context.TODO(), stats)
+
+	if reporter.stats != stats {
+		t.Errorf("Given stats is not stashed: %#v.", reporter.stats)
+	}
+}
+
+func TestPrometheusReporter_ServeHTTP(t *testing.T) {
+	reporter := NewPrometheusReporter("mybot")
+	reporter.Report(context.TODO(), &Stats{
+		QueueSize:      7,
+		QueueCapacity:  10,
+		Processed:      100,
+		Failed:         2,
+		AverageLatency: 1500 * time.Millisecond,
+		Jobs: map[string]*JobStats{
+			"greeting": {Processed: 3, TotalLatency: 2 * time.Second},
+		},
+	})
+
+	recorder := httptest.NewRecorder()
+	reporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := recorder.Body.String()
+	expected := []string{
+		"# TYPE mybot_worker_queue_length gauge",
+		"mybot_worker_queue_length 7\n",
+		"mybot_worker_queue_capacity 10\n",
+		"# TYPE mybot_worker_jobs_processed_total counter",
+		"mybot_worker_jobs_processed_total 100\n",
+		"mybot_worker_jobs_failed_total 2\n",
+		"mybot_worker_job_latency_average_seconds 1.5\n",
+		"# TYPE mybot_worker_named_jobs_processed_total counter",
+		"mybot_worker_named_jobs_processed_total{job_name=\"greeting\"} 3\n",
+		"mybot_worker_named_job_latency_seconds_sum{job_name=\"greeting\"} 2\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(body, e) {
+			t.Errorf("Expected line is not contained: %s.\n%s", e, body)
+		}
+	}
+
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Unexpected Content-Type is set: %s.", recorder.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,153 @@
+package workers
+
+import (
+	"context"
+)
+
+// Queue defines an interface that a job queue backend must satisfy.
+// By default, the worker pool uses the in-memory queue returned by NewMemoryQueue.
+// Use WithQueue to replace the backend.
+//
+// A remote backend such as Redis or NATS can implement this interface to share jobs among multiple Runner instances
+// and to keep jobs across a process crash.
+// Because Job.Func is a Go function that can not be serialized, such a backend is responsible for
+// encoding the job as a message with its name, labels and payload, and for rebuilding Job.Func from the message on Dequeue;
+// Job.Name is a good key to look up the function to rebuild.
+// The redisqueue package provides such a backend with Redis.
+type Queue interface {
+	// Enqueue puts the given Job in the queue without blocking.
+	// ErrQueueOverflow must be returned when the queue is full so the worker pool can apply Config.OverflowPolicy.
+	Enqueue(*Job) error
+
+	// Dequeue returns the waiting Job with the highest priority.
+	// This blocks til any job is available or the given context is canceled.
+	// When the context is canceled, the context's error must be returned.
+	Dequeue(context.Context) (*Job, error)
+
+	// Len returns the number of waiting jobs.
+	Len() int
+
+	// Cap returns the maximum number of jobs the queue can hold.
+	Cap() int
+}
+
+// BlockingQueue is an optional interface that a Queue implementation may satisfy to support OverflowBlock efficiently.
+// When the Queue does not satisfy this, the worker pool periodically retries Queue.Enqueue til the job is enqueued.
+type BlockingQueue interface {
+	Queue
+
+	// EnqueueWait puts the given Job in the queue.
+	// This blocks til the queue has a room for the job or the given context is canceled.
+	// When the context is canceled, the context's error must be returned.
+	EnqueueWait(context.Context, *Job) error
+}
+
+// WithQueue creates a WorkerOption that replaces the default in-memory queue with the given Queue.
+// Config.QueueSize is ignored when this option is given.
+func WithQueue(queue Queue) WorkerOption {
+	return func(w *worker) {
+		w.queue = queue
+	}
+}
+
+// memoryQueue is the default Queue implementation that holds a channel for each Priority.
+type memoryQueue struct {
+	high   chan *Job
+	normal chan *Job
+	low    chan *Job
+}
+
+var _ BlockingQueue = (*memoryQueue)(nil)
+
+// NewMemoryQueue creates and returns a new in-memory Queue.
+// Jobs are queued in separate channels per Priority, and each channel can hold up to the given size of jobs.
+// Therefore a large batch of low priority jobs does not fill the queue for high priority ones.
+func NewMemoryQueue(size uint) Queue {
+	return &memoryQueue{
+		high:   make(chan *Job, size),
+		normal: make(chan *Job, size),
+		low:    make(chan *Job, size),
+	}
+}
+
+func (q *memoryQueue) channel(priority Priority) chan *Job {
+	switch {
+	case priority >= PriorityHigh:
+		return q.high
+
+	case priority <= PriorityLow:
+		return q.low
+
+	default:
+		return q.normal
+
+	}
+}
+
+func (q *memoryQueue) Enqueue(job *Job) error {
+	select {
+	case q.channel(job.Priority) <- job:
+		return nil
+
+	default:
+		return ErrQueueOverflow
+
+	}
+}
+
+func (q *memoryQueue) EnqueueWait(ctx context.Context, job *Job) error {
+	select {
+	case q.channel(job.Priority) <- job:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+
+	}
+}
+
+func (q *memoryQueue) Dequeue(ctx context.Context) (*Job, error) {
+	select {
+	case job := <-q.high:
+		return job, nil
+
+	default:
+		// No high priority job is waiting.
+
+	}
+
+	select {
+	case job := <-q.high:
+		return job, nil
+
+	case job := <-q.normal:
+		return job, nil
+
+	default:
+		// Neither high nor normal priority job is waiting.
+
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case job := <-q.high:
+		return job, nil
+
+	case job := <-q.normal:
+		return job, nil
+
+	case job := <-q.low:
+		return job, nil
+
+	}
+}
+
+func (q *memoryQueue) Len() int {
+	return len(q.high) + len(q.normal) + len(q.low)
+}
+
+func (q *memoryQueue) Cap() int {
+	return cap(q.high) + cap(q.normal) + cap(q.low)
+}
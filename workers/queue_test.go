@@ -0,0 +1,222 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type DummyQueue struct {
+	EnqueueFunc func(*Job) error
+	DequeueFunc func(context.Context) (*Job, error)
+	LenFunc     func() int
+	CapFunc     func() int
+}
+
+func (q *DummyQueue) Enqueue(job *Job) error {
+	return q.EnqueueFunc(job)
+}
+
+func (q *DummyQueue) Dequeue(ctx context.Context) (*Job, error) {
+	return q.DequeueFunc(ctx)
+}
+
+func (q *DummyQueue) Len() int {
+	return q.LenFunc()
+}
+
+func (q *DummyQueue) Cap() int {
+	return q.CapFunc()
+}
+
+func TestWithQueue(t *testing.T) {
+	queue := &DummyQueue{}
+	w := &worker{}
+
+	WithQueue(queue)(w)
+
+	if w.queue != queue {
+		t.Errorf("Expected Queue is not set: %#v.", w.queue)
+	}
+}
+
+func TestRun_WithQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan *Job, 1)
+	queue := &DummyQueue{
+		EnqueueFunc: func(job *Job) error {
+			jobs <- job
+			return nil
+		},
+		DequeueFunc: func(ctx context.Context) (*Job, error) {
+			select {
+			case job := <-jobs:
+				return job, nil
+
+			case <-ctx.Done():
+				return nil, ctx.Err()
+
+			}
+		},
+		LenFunc: func() int { return len(jobs) },
+		CapFunc: func() int { return cap(jobs) },
+	}
+
+	// QueueSize is not required when the Queue is given.
+	wkr, err := Run(ctx, &Config{WorkerNum: 1}, WithQueue(queue))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	executed := make(chan struct{})
+	_ = wkr.Enqueue(func() { close(executed) })
+	select {
+	case <-executed:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Enqueued job is not executed.")
+
+	}
+}
+
+func TestWorker_Enqueue_NonBlockingQueue(t *testing.T) {
+	mutex := &sync.Mutex{}
+	full := true
+	queue := &DummyQueue{
+		EnqueueFunc: func(_ *Job) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if full {
+				return ErrQueueOverflow
+			}
+			return nil
+		},
+	}
+	w := &worker{
+		config: &Config{OverflowPolicy: OverflowBlock},
+		queue:  queue,
+		ctx:    context.Background(),
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		defer mutex.Unlock()
+		full = false
+	}()
+
+	err := w.Enqueue(func() {})
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestWorker_run_DequeueError(t *testing.T) {
+	oldInterval := dequeueRetryInterval
+	defer func() {
+		dequeueRetryInterval = oldInterval
+	}()
+	dequeueRetryInterval = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	called := 0
+	executed := make(chan struct{})
+	queue := &DummyQueue{
+		DequeueFunc: func(ctx context.Context) (*Job, error) {
+			called++
+			switch called {
+			case 1:
+				return nil, errors.New("temporary error")
+
+			case 2:
+				return &Job{Func: func() { close(executed) }}, nil
+
+			default:
+				<-ctx.Done()
+				return nil, ctx.Err()
+
+			}
+		},
+	}
+
+	_, err := Run(ctx, &Config{WorkerNum: 1}, WithQueue(queue))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	select {
+	case <-executed:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Worker stopped serving after dequeue error.")
+
+	}
+}
+
+func TestNewMemoryQueue(t *testing.T) {
+	queue := NewMemoryQueue(2)
+
+	if queue.Cap() != 6 {
+		t.Errorf("Unexpected capacity is returned: %d.", queue.Cap())
+	}
+
+	if queue.Len() != 0 {
+		t.Errorf("Unexpected length is returned: %d.", queue.Len())
+	}
+}
+
+func TestMemoryQueue_Dequeue(t *testing.T) {
+	t.Run("priority order", func(t *testing.T) {
+		queue := NewMemoryQueue(3)
+
+		_ = queue.Enqueue(&Job{Func: func() {}, Priority: PriorityLow})
+		_ = queue.Enqueue(&Job{Func: func() {}, Priority: PriorityNormal})
+		_ = queue.Enqueue(&Job{Func: func() {}, Priority: PriorityHigh})
+		_ = queue.Enqueue(&Job{Func: func() {}, Priority: PriorityNormal})
+
+		expected := []Priority{PriorityHigh, PriorityNormal, PriorityNormal, PriorityLow}
+		for i, e := range expected {
+			job, err := queue.Dequeue(context.TODO())
+			if err != nil {
+				t.Fatalf("Unexpected error is returned on %d: %s.", i, err.Error())
+			}
+
+			if job.Priority != e {
+				t.Errorf("Expected %s priority on %d, but was %s.", e, i, job.Priority)
+			}
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		queue := NewMemoryQueue(1)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := queue.Dequeue(ctx)
+		if err != context.Canceled {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestMemoryQueue_EnqueueWait(t *testing.T) {
+	queue := NewMemoryQueue(1)
+	_ = queue.Enqueue(&Job{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := queue.(BlockingQueue).EnqueueWait(ctx, &Job{})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
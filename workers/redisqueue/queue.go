@@ -0,0 +1,382 @@
+/*
+Package redisqueue provides workers.Queue implementation that keeps the waiting jobs in Redis.
+
+Multiple Bot processes can share the jobs, and the jobs survive a process crash since they are kept in Redis.
+Any client that satisfies redis.UniversalClient is accepted, so a standalone server, Redis Sentinel, and Redis Cluster are all supported.
+
+Because workers.Job.Func can not be sent to Redis, only the job's name, priority, labels and key are stored.
+Each process registers the function to execute for each job name to a Registry,
+and the function registered with the dequeued job's name is executed with the job's labels:
+
+	registry := redisqueue.NewRegistry()
+	registry.Register("send_report", func(labels map[string]string) {
+		sendReport(labels["channel"])
+	})
+
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	queue, err := redisqueue.New(client, registry, redisqueue.NewConfig())
+	if err != nil {
+		panic(err)
+	}
+	worker, err := workers.Run(ctx, workers.NewConfig(), workers.WithQueue(queue))
+	if err != nil {
+		panic(err)
+	}
+	err = worker.EnqueueJob(&workers.Job{Name: "send_report", Labels: map[string]string{"channel": "general"}})
+
+A job with a name that is not registered is rejected with ErrUnregisteredJob, so jobs with closures such as the ones sarah's Runner enqueues can not be queued.
+Run a separate worker pool with this Queue for the registered jobs.
+
+A job is delivered at least once. A dequeued job is moved to a processing list of Config.ConsumerID and is removed from there when the job finishes or is skipped.
+When the process crashes while executing a job, New puts the job left in the processing list back to the queue so the job is executed again.
+Make the registered functions idempotent, or tolerate the duplicated execution.
+
+This package is a separate Go module so the applications that do not use Redis do not depend on it.
+It requires go-sarah v4.1.0, the first release that includes the APIs this package uses;
+an application cannot use this module with an older go-sarah release.
+*/
+package redisqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"github.com/oklahomer/go-sarah/v4/workers"
+	"github.com/redis/go-redis/v9"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnregisteredJob is returned when a job with a name that is not registered to the Registry is enqueued or dequeued.
+var ErrUnregisteredJob = errors.New("job is not registered")
+
+// enqueueScript pushes the job only when the list has a room for it so the length check and the push are atomic.
+var enqueueScript = redis.NewScript(`
+if redis.call("LLEN", KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("RPUSH", KEYS[1], ARGV[1])
+return 1
+`)
+
+// dequeueScript moves the first job of the list with the highest priority to the processing list, so the job is not lost when the process crashes before the job finishes.
+// KEYS[1] is the processing list and the rest are the lists in the priority order.
+var dequeueScript = redis.NewScript(`
+for i = 2, #KEYS do
+	local value = redis.call("LPOP", KEYS[i])
+	if value then
+		redis.call("RPUSH", KEYS[1], value)
+		return value
+	end
+end
+return false
+`)
+
+// Config contains some configuration variables for the Queue.
+type Config struct {
+	// KeyPrefix is prepended to the keys of the lists that hold the jobs.
+	// The default value is surrounded by braces so all lists are stored in the same hash slot with Redis Cluster;
+	// a script can not access keys in different slots.
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+
+	// Size is the maximum number of jobs each Priority's list can hold.
+	Size uint `json:"size" yaml:"size"`
+
+	// ConsumerID identifies this process among the processes that share the lists.
+	// The jobs being executed are kept in the processing list for this ID, and New puts the ones left by a crash back to the queue.
+	// Give each process a unique ID that stays the same across restarts such as a StatefulSet's pod name;
+	// the jobs left by a process are not recovered til a process with the same ID starts.
+	// The host name is used when this is empty.
+	ConsumerID string `json:"consumer_id" yaml:"consumer_id"`
+
+	// PollInterval is the interval to check the lists while no job is waiting.
+	PollInterval time.Duration `json:"poll_interval" yaml:"poll_interval"`
+}
+
+// NewConfig returns a pointer to Config with default setting.
+func NewConfig() *Config {
+	return &Config{
+		KeyPrefix:    "{sarah:queue}:",
+		Size:         1000,
+		ConsumerID:   "",
+		PollInterval: 100 * time.Millisecond,
+	}
+}
+
+// ApplyDefaults sets the default values to ConsumerID and PollInterval when they are not given.
+// KeyPrefix is left as is, so an empty prefix can be used on purpose.
+func (c *Config) ApplyDefaults() {
+	if c.ConsumerID == "" {
+		c.ConsumerID, _ = os.Hostname()
+	}
+	if c.PollInterval == 0 {
+		c.PollInterval = NewConfig().PollInterval
+	}
+}
+
+// Validate checks that Size and PollInterval are positive and ConsumerID is given.
+func (c *Config) Validate() error {
+	var errs sarah.ConfigKeyErrors
+	if c.Size == 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "size", Value: fmt.Sprint(c.Size), Err: errors.New("size must be greater than zero")})
+	}
+	if c.ConsumerID == "" {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "consumer_id", Value: c.ConsumerID, Err: errors.New("consumer_id must not be empty")})
+	}
+	if c.PollInterval <= 0 {
+		errs = append(errs, &sarah.ConfigKeyError{Key: "poll_interval", Value: c.PollInterval.String(), Err: errors.New("poll_interval must be greater than zero")})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Registry holds the functions to execute for the job names.
+type Registry struct {
+	funcs map[string]func(labels map[string]string)
+	mutex sync.RWMutex
+}
+
+// NewRegistry creates and returns a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		funcs: map[string]func(labels map[string]string){},
+	}
+}
+
+// Register registers the function to execute for the jobs with the given name.
+// The function receives the job's labels.
+// When a function is already registered with the same name, the function is replaced.
+func (r *Registry) Register(name string, fnc func(labels map[string]string)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.funcs[name] = fnc
+}
+
+func (r *Registry) lookup(name string) (func(labels map[string]string), bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	fnc, ok := r.funcs[name]
+	return fnc, ok
+}
+
+// message is what is stored in Redis for each job.
+type message struct {
+	Name     string            `json:"name"`
+	Priority workers.Priority  `json:"priority"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Key      string            `json:"key,omitempty"`
+}
+
+type queue struct {
+	client       redis.UniversalClient
+	registry     *Registry
+	high         string
+	normal       string
+	low          string
+	processing   string
+	size         uint
+	pollInterval time.Duration
+}
+
+var _ workers.Queue = (*queue)(nil)
+
+// New creates and returns a workers.Queue that keeps the jobs with the given Redis client.
+// Jobs are kept in separate lists per workers.Priority just like workers.NewMemoryQueue does.
+//
+// Job.Func and Job.Context of an enqueued job are not stored.
+// Job.Context is still checked on workers.Worker.EnqueueJob, but a job is never skipped due to its context once the job is enqueued.
+//
+// The jobs left in the processing list of Config.ConsumerID are put back to the head of their lists before this returns.
+func New(client redis.UniversalClient, registry *Registry, config *Config) (workers.Queue, error) {
+	err := sarah.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &queue{
+		client:       client,
+		registry:     registry,
+		high:         config.KeyPrefix + "high",
+		normal:       config.KeyPrefix + "normal",
+		low:          config.KeyPrefix + "low",
+		processing:   config.KeyPrefix + "processing:" + config.ConsumerID,
+		size:         config.Size,
+		pollInterval: config.PollInterval,
+	}
+
+	err = q.recover(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// recover puts the jobs left in the processing list back to the head of their lists in the dequeued order.
+func (q *queue) recover(ctx context.Context) error {
+	values, err := q.client.LRange(ctx, q.processing, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read processing jobs: %w", err)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// Push from the last one so the first dequeued job comes first again.
+		for i := len(values) - 1; i >= 0; i-- {
+			key := q.normal
+			m := &message{}
+			if json.Unmarshal([]byte(values[i]), m) == nil {
+				key = q.key(m.Priority)
+			}
+			// A broken message is put back as is so Dequeue reports and discards it.
+			pipe.LPush(ctx, key, values[i])
+		}
+		pipe.Del(ctx, q.processing)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to recover processing jobs: %w", err)
+	}
+	return nil
+}
+
+func (q *queue) key(priority workers.Priority) string {
+	switch {
+	case priority >= workers.PriorityHigh:
+		return q.high
+
+	case priority <= workers.PriorityLow:
+		return q.low
+
+	default:
+		return q.normal
+
+	}
+}
+
+func (q *queue) Enqueue(job *workers.Job) error {
+	if _, ok := q.registry.lookup(job.Name); !ok {
+		return fmt.Errorf("%w: %s", ErrUnregisteredJob, job.Name)
+	}
+
+	b, err := json.Marshal(&message{
+		Name:     job.Name,
+		Priority: job.Priority,
+		Labels:   job.Labels,
+		Key:      job.Key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", job.Name, err)
+	}
+
+	pushed, err := enqueueScript.Run(context.Background(), q.client, []string{q.key(job.Priority)}, b, q.size).Int()
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job %s: %w", job.Name, err)
+	}
+	if pushed == 0 {
+		return workers.ErrQueueOverflow
+	}
+	return nil
+}
+
+// Dequeue checks the lists in the priority order, so a job with higher priority is returned first.
+// The lists are checked on every Config.PollInterval til a job is enqueued or the given context is canceled.
+// When the dequeued job's name is not registered, the job is discarded and an error wrapping ErrUnregisteredJob is returned.
+func (q *queue) Dequeue(ctx context.Context) (*workers.Job, error) {
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// The script is not canceled with ctx since the canceled call may still move a job to the processing list on Redis' side.
+		value, err := dequeueScript.Run(context.Background(), q.client, []string{q.processing, q.high, q.normal, q.low}).Text()
+		if errors.Is(err, redis.Nil) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+
+			case <-time.After(q.pollInterval):
+				continue
+
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to dequeue job: %w", err)
+		}
+
+		return q.decode(value)
+	}
+}
+
+func (q *queue) decode(value string) (*workers.Job, error) {
+	m := &message{}
+	err := json.Unmarshal([]byte(value), m)
+	if err != nil {
+		q.ack(value)
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+
+	fnc, ok := q.registry.lookup(m.Name)
+	if !ok {
+		q.ack(value)
+		return nil, fmt.Errorf("%w: %s", ErrUnregisteredJob, m.Name)
+	}
+
+	labels := m.Labels
+	return &workers.Job{
+		Func: func() {
+			defer q.ack(value)
+			fnc(labels)
+		},
+		Priority: m.Priority,
+		Name:     m.Name,
+		Labels:   m.Labels,
+		Key:      m.Key,
+		// A skipped job is not executed again.
+		OnSkip: func(_ error) {
+			q.ack(value)
+		},
+	}, nil
+}
+
+// ack removes the finished job from the processing list.
+// When this fails, the job is executed again after the process restarts.
+func (q *queue) ack(value string) {
+	err := q.client.LRem(context.Background(), q.processing, 1, value).Err()
+	if err != nil {
+		logging.GetLogger().Module("redisqueue").Error("Failed to remove finished job from processing list", logging.F("list", q.processing), logging.Err(err))
+	}
+}
+
+// Len returns zero when the lengths can not be fetched since workers.Queue does not allow returning an error.
+func (q *queue) Len() int {
+	ctx := context.Background()
+	pipe := q.client.Pipeline()
+	cmds := []*redis.IntCmd{
+		pipe.LLen(ctx, q.high),
+		pipe.LLen(ctx, q.normal),
+		pipe.LLen(ctx, q.low),
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0
+	}
+
+	var l int64
+	for _, cmd := range cmds {
+		l += cmd.Val()
+	}
+	return int(l)
+}
+
+func (q *queue) Cap() int {
+	return int(q.size) * 3
+}
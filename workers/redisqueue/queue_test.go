@@ -0,0 +1,397 @@
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/oklahomer/go-sarah/v4"
+	"github.com/oklahomer/go-sarah/v4/workers"
+	"github.com/redis/go-redis/v9"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestQueue(t *testing.T, registry *Registry, config *Config) (workers.Queue, *redis.Client) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	q, err := New(client, registry, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	return q, client
+}
+
+func TestConfig_Validate(t *testing.T) {
+	err := (&Config{Size: 0, ConsumerID: "", PollInterval: -1}).Validate()
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	if errs, ok := err.(sarah.ConfigKeyErrors); !ok || len(errs) != 3 {
+		t.Errorf("Unexpected number of errors are returned: %s.", err.Error())
+	}
+
+	config := NewConfig()
+	config.ConsumerID = "worker-0"
+	err = config.Validate()
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+}
+
+func TestConfig_ApplyDefaults(t *testing.T) {
+	config := &Config{}
+	config.ApplyDefaults()
+
+	if config.PollInterval != NewConfig().PollInterval {
+		t.Errorf("Unexpected poll interval is set: %s.", config.PollInterval)
+	}
+
+	hostname, _ := os.Hostname()
+	if config.ConsumerID != hostname {
+		t.Errorf("Unexpected consumer ID is set: %s.", config.ConsumerID)
+	}
+
+	if config.KeyPrefix != "" {
+		t.Errorf("KeyPrefix must be left as is: %s.", config.KeyPrefix)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(nil, NewRegistry(), &Config{})
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+}
+
+func TestQueue(t *testing.T) {
+	labels := make(chan map[string]string, 1)
+	registry := NewRegistry()
+	registry.Register("report", func(l map[string]string) {
+		labels <- l
+	})
+	q, _ := newTestQueue(t, registry, NewConfig())
+
+	err := q.Enqueue(&workers.Job{
+		Name:     "report",
+		Priority: workers.PriorityHigh,
+		Labels:   map[string]string{"channel": "general"},
+		Key:      "general",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if q.Len() != 1 {
+		t.Errorf("Unexpected length is returned: %d.", q.Len())
+	}
+
+	job, err := q.Dequeue(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if job.Name != "report" || job.Priority != workers.PriorityHigh || job.Key != "general" || job.Labels["channel"] != "general" {
+		t.Errorf("Unexpected job is returned: %#v.", job)
+	}
+
+	job.Func()
+	l := <-labels
+	if l["channel"] != "general" {
+		t.Errorf("Registered function is not called with the labels: %#v.", l)
+	}
+
+	if q.Len() != 0 {
+		t.Errorf("Unexpected length is returned: %d.", q.Len())
+	}
+}
+
+func TestQueue_Dequeue_Processing(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("report", func(_ map[string]string) {})
+	config := NewConfig()
+	config.ConsumerID = "worker-0"
+	q, client := newTestQueue(t, registry, config)
+	processing := config.KeyPrefix + "processing:worker-0"
+
+	for i := 0; i < 2; i++ {
+		err := q.Enqueue(&workers.Job{Name: "report"})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	executed, err := q.Dequeue(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	skipped, err := q.Dequeue(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if l := client.LLen(context.TODO(), processing).Val(); l != 2 {
+		t.Fatalf("Dequeued jobs must be kept in the processing list: %d.", l)
+	}
+
+	executed.Func()
+	skipped.OnSkip(workers.ErrQueueOverflow)
+
+	if l := client.LLen(context.TODO(), processing).Val(); l != 0 {
+		t.Errorf("Finished jobs must be removed from the processing list: %d.", l)
+	}
+}
+
+func TestNew_Recover(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	registry := NewRegistry()
+	registry.Register("report", func(_ map[string]string) {})
+	config := NewConfig()
+	config.ConsumerID = "worker-0"
+
+	crashed, err := New(client, registry, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	for _, job := range []*workers.Job{
+		{Name: "report", Key: "first"},
+		{Name: "report", Key: "second"},
+		{Name: "report", Key: "high", Priority: workers.PriorityHigh},
+	} {
+		err := crashed.Enqueue(job)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	// The process crashes while executing the dequeued jobs.
+	for i := 0; i < 3; i++ {
+		_, err := crashed.Dequeue(context.TODO())
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+	err = crashed.Enqueue(&workers.Job{Name: "report", Key: "third"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	restarted, err := New(client, registry, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if restarted.Len() != 4 {
+		t.Errorf("Unexpected length is returned: %d.", restarted.Len())
+	}
+
+	for _, expected := range []string{"high", "first", "second", "third"} {
+		job, err := restarted.Dequeue(context.TODO())
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if job.Key != expected {
+			t.Errorf("Expected job is not returned: %s.", job.Key)
+		}
+	}
+}
+
+func TestNew_Recover_OtherConsumer(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	registry := NewRegistry()
+	registry.Register("report", func(_ map[string]string) {})
+	config := NewConfig()
+	config.ConsumerID = "worker-0"
+
+	running, err := New(client, registry, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	err = running.Enqueue(&workers.Job{Name: "report"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	_, err = running.Dequeue(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// The job being executed by another process must not be put back.
+	other := NewConfig()
+	other.ConsumerID = "worker-1"
+	q, err := New(client, registry, other)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if q.Len() != 0 {
+		t.Errorf("Unexpected length is returned: %d.", q.Len())
+	}
+}
+
+func TestQueue_Enqueue_Unregistered(t *testing.T) {
+	q, _ := newTestQueue(t, NewRegistry(), NewConfig())
+
+	err := q.Enqueue(&workers.Job{Name: "unknown", Func: func() {}})
+	if !errors.Is(err, ErrUnregisteredJob) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestQueue_Enqueue_Overflow(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("report", func(_ map[string]string) {})
+	config := NewConfig()
+	config.Size = 1
+	q, _ := newTestQueue(t, registry, config)
+
+	err := q.Enqueue(&workers.Job{Name: "report"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = q.Enqueue(&workers.Job{Name: "report"})
+	if err != workers.ErrQueueOverflow {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	// Each Priority has its own list.
+	err = q.Enqueue(&workers.Job{Name: "report", Priority: workers.PriorityHigh})
+	if err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	if q.Cap() != 3 {
+		t.Errorf("Unexpected capacity is returned: %d.", q.Cap())
+	}
+}
+
+func TestQueue_Dequeue_Priority(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("report", func(_ map[string]string) {})
+	q, _ := newTestQueue(t, registry, NewConfig())
+
+	for _, priority := range []workers.Priority{workers.PriorityLow, workers.PriorityNormal, workers.PriorityHigh} {
+		err := q.Enqueue(&workers.Job{Name: "report", Priority: priority})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+
+	for _, expected := range []workers.Priority{workers.PriorityHigh, workers.PriorityNormal, workers.PriorityLow} {
+		job, err := q.Dequeue(context.TODO())
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if job.Priority != expected {
+			t.Errorf("Expected %s job is not returned: %s.", expected, job.Priority)
+		}
+	}
+}
+
+func TestQueue_Dequeue_Unregistered(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	// Another process registers the job while this process does not.
+	registry := NewRegistry()
+	registry.Register("report", func(_ map[string]string) {})
+	producer, err := New(client, registry, NewConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	consumer, err := New(client, NewRegistry(), NewConfig())
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = producer.Enqueue(&workers.Job{Name: "report"})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_, err = consumer.Dequeue(context.TODO())
+	if !errors.Is(err, ErrUnregisteredJob) {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestQueue_Dequeue_BrokenMessage(t *testing.T) {
+	config := NewConfig()
+	config.ConsumerID = "worker-0"
+	q, client := newTestQueue(t, NewRegistry(), config)
+	client.RPush(context.TODO(), config.KeyPrefix+"normal", "{broken")
+
+	_, err := q.Dequeue(context.TODO())
+	if err == nil {
+		t.Error("Expected error is not returned.")
+	}
+
+	// The broken message is discarded instead of being recovered on the next start.
+	if l := client.LLen(context.TODO(), config.KeyPrefix+"processing:worker-0").Val(); l != 0 {
+		t.Errorf("Broken message must be removed from the processing list: %d.", l)
+	}
+}
+
+func TestQueue_Dequeue_Canceled(t *testing.T) {
+	q, _ := newTestQueue(t, NewRegistry(), NewConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := q.Dequeue(ctx)
+	if err != context.Canceled {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+}
+
+func TestQueue_Worker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executed := make(chan string, 1)
+	registry := NewRegistry()
+	registry.Register("report", func(labels map[string]string) {
+		executed <- labels["channel"]
+	})
+	q, _ := newTestQueue(t, registry, NewConfig())
+
+	wkr, err := workers.Run(ctx, &workers.Config{WorkerNum: 1}, workers.WithQueue(q))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	err = wkr.EnqueueJob(&workers.Job{Name: "report", Labels: map[string]string{"channel": "general"}})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	select {
+	case channel := <-executed:
+		if channel != "general" {
+			t.Errorf("Unexpected label is passed: %s.", channel)
+		}
+
+	case <-time.NewTimer(3 * time.Second).C:
+		t.Fatal("Enqueued job is not executed.")
+
+	}
+}
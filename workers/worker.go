@@ -0,0 +1,1073 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInvalidWorkerNum is returned when the given Config does not allow any worker to run.
+	ErrInvalidWorkerNum = errors.New("WorkerNum must be greater than zero")
+
+	// ErrInvalidMaxWorkerNum is returned when the given Config has smaller MaxWorkerNum than WorkerNum.
+	ErrInvalidMaxWorkerNum = errors.New("MaxWorkerNum must be zero or equal to or greater than WorkerNum")
+
+	// ErrInvalidQueueSize is returned when the given Config does not allow any job to be queued.
+	ErrInvalidQueueSize = errors.New("QueueSize must be greater than zero")
+
+	// ErrQueueOverflow is returned when a job can not be enqueued because the queue is full.
+	ErrQueueOverflow = errors.New("job queue is full")
+
+	// ErrJobExpired is returned when a job is enqueued with an already canceled or expired context.
+	ErrJobExpired = errors.New("job is already expired")
+
+	// ErrWorkerNotRunning is returned when a job is enqueued after the worker's context is canceled.
+	ErrWorkerNotRunning = errors.New("worker is not running")
+)
+
+// OverflowPolicy defines how the worker pool behaves when a job is enqueued while the queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowReject rejects the overflowing job and returns ErrQueueOverflow to the caller.
+	// The caller can judge how to handle the job; sarah's Runner returns sarah.BlockedInputError to the Bot/Adapter.
+	OverflowReject OverflowPolicy = "reject"
+
+	// OverflowDrop drops the overflowing job with a log and returns no error to the caller.
+	OverflowDrop OverflowPolicy = "drop"
+
+	// OverflowBlock blocks the caller til the queue has a room for the job.
+	// When Config.BlockTimeout is set and the job still can not be enqueued within that period, the job is rejected with ErrQueueOverflow.
+	// Be aware that blocking may stall the caller such as Adapter's payload receiving loop.
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// Priority represents the priority of a Job.
+// When multiple jobs are waiting, the one with higher priority is executed first.
+type Priority int
+
+const (
+	// PriorityLow is meant for housekeeping jobs that can be delayed.
+	PriorityLow Priority = -1
+
+	// PriorityNormal is the default priority and is the zero value of Priority; jobs enqueued via Worker.Enqueue have this priority.
+	// Sending messages from a scheduled task typically falls into this.
+	PriorityNormal Priority = 0
+
+	// PriorityHigh is meant for interactive jobs such as responding to a user input.
+	// sarah's Runner enqueues each Input with this priority when the worker supports EnqueueJob.
+	PriorityHigh Priority = 1
+)
+
+// String returns a stringified form of the Priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+
+	case PriorityNormal:
+		return "normal"
+
+	case PriorityHigh:
+		return "high"
+
+	default:
+		return fmt.Sprintf("unknown(%d)", int(p))
+
+	}
+}
+
+// Job represents a job to be executed by the worker pool.
+type Job struct {
+	// Func is the function to be executed.
+	Func func()
+
+	// Priority is the priority of this job.
+	Priority Priority
+
+	// Context is an optional context that tells if the job is still worth running.
+	// When this is canceled or its deadline is exceeded while the job is waiting in the queue, the job is skipped and counted as expired.
+	// e.g. A reply to a message that was sent ten minutes ago may not be useful anymore:
+	//
+	//  ctx, cancel := context.WithDeadline(context.Background(), input.SentAt().Add(10*time.Minute))
+	//  err := wkr.EnqueueJob(&workers.Job{Func: func() { defer cancel(); reply(ctx) }, Context: ctx})
+	//
+	// Func may also refer to the same context to stop the execution when the context is canceled in the middle of the execution.
+	Context context.Context
+
+	// Name is an optional name of this job.
+	// The name appears in the logs and panic reports, and the statistics are aggregated per name in Stats.Jobs.
+	// Keep the variety of names small; e.g. use "weather_command" instead of including the user input in the name.
+	Name string
+
+	// Labels is an optional set of key-value pairs that describe this job such as the bot type or the destination.
+	// Unlike Name, labels only appear in the logs and panic reports.
+	Labels map[string]string
+
+	// Key is an optional key to serialize the execution.
+	// Jobs with the same non-empty key are executed one at a time in the dequeued order,
+	// while jobs with different keys still run concurrently.
+	// e.g. Use the chat room's ID so messages in the same conversation are processed in order.
+	// Jobs waiting for a busy key count toward the queue's size, and Config.OverflowPolicy applies when too many of them wait.
+	Key string
+
+	// OnSkip is an optional function that is called when the job is accepted by EnqueueJob but Func is never executed;
+	// the job is skipped because Context expired while waiting in the queue, or the job is dropped due to queue overflow.
+	// Use this to release what Func would release at the end of its execution such as a tracing span.
+	OnSkip func(err error)
+}
+
+// String returns a human-readable description of the job such as "weather_command{bot_type=slack}".
+func (j *Job) String() string {
+	name := j.Name
+	if name == "" {
+		name = "unnamed"
+	}
+
+	if len(j.Labels) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(j.Labels))
+	for k := range j.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, j.Labels[k]))
+	}
+
+	return fmt.Sprintf("%s{%s}", name, strings.Join(pairs, ","))
+}
+
+// fields returns the log fields that identify the job.
+// The labels are attached as they are so the job's bot_type label appears as the common bot_type field.
+func (j *Job) fields() []logging.Field {
+	fields := []logging.Field{
+		logging.F("job", j.String()),
+		logging.F("priority", j.Priority),
+	}
+	for k, v := range j.Labels {
+		fields = append(fields, logging.F(k, v))
+	}
+	return fields
+}
+
+// skip calls OnSkip, if any, to tell that Func is not going to be executed.
+func (j *Job) skip(err error) {
+	if j.OnSkip != nil {
+		j.OnSkip(err)
+	}
+}
+
+// expired tells if the job's context is already canceled or its deadline is exceeded.
+func (j *Job) expired() bool {
+	return j.Context != nil && j.Context.Err() != nil
+}
+
+// Config contains some configuration variables for the worker pool.
+//
+// QueueSize is passed to NewMemoryQueue to build the default in-memory queue.
+//
+// When MaxWorkerNum is greater than WorkerNum, the worker pool scales between the two numbers on every ScaleInterval.
+// WorkerNum is then treated as the minimum number of workers.
+// The pool grows when jobs are waiting in the queue or when the average job latency exceeds ScaleUpLatency,
+// and shrinks when the queue is empty and some workers are idle.
+type Config struct {
+	WorkerNum         uint           `json:"worker_num" yaml:"worker_num"`
+	MaxWorkerNum      uint           `json:"max_worker_num" yaml:"max_worker_num"`
+	ScaleInterval     time.Duration  `json:"scale_interval" yaml:"scale_interval"`
+	ScaleUpLatency    time.Duration  `json:"scale_up_latency" yaml:"scale_up_latency"`
+	QueueSize         uint           `json:"queue_size" yaml:"queue_size"`
+	SuperviseInterval time.Duration  `json:"supervise_interval" yaml:"supervise_interval"`
+	OverflowPolicy    OverflowPolicy `json:"overflow_policy" yaml:"overflow_policy"`
+	BlockTimeout      time.Duration  `json:"block_timeout" yaml:"block_timeout"`
+	SlowJobThreshold  time.Duration  `json:"slow_job_threshold" yaml:"slow_job_threshold"`
+}
+
+// NewConfig returns a Config instance with default configuration values.
+// Use json.Unmarshal, yaml.Unmarshal, or manual manipulation to override default values.
+func NewConfig() *Config {
+	return &Config{
+		WorkerNum:         100,
+		MaxWorkerNum:      0,
+		ScaleInterval:     5 * time.Second,
+		ScaleUpLatency:    0,
+		QueueSize:         10,
+		SuperviseInterval: 60 * time.Second,
+		OverflowPolicy:    OverflowReject,
+		BlockTimeout:      0,
+		SlowJobThreshold:  0,
+	}
+}
+
+// ApplyDefaults sets OverflowReject when OverflowPolicy is not set.
+func (c *Config) ApplyDefaults() {
+	if c.OverflowPolicy == "" {
+		c.OverflowPolicy = OverflowReject
+	}
+}
+
+// Validate checks the configuration values and returns an error that describes all invalid values.
+// Run returns the first one of the same errors, while this reports all of them at once.
+// QueueSize is always required since Validate can not tell if a Queue is given via WithQueue.
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.WorkerNum == 0 {
+		errs = append(errs, ErrInvalidWorkerNum.Error())
+	}
+
+	if c.MaxWorkerNum != 0 && c.MaxWorkerNum < c.WorkerNum {
+		errs = append(errs, ErrInvalidMaxWorkerNum.Error())
+	}
+
+	if c.QueueSize == 0 {
+		errs = append(errs, ErrInvalidQueueSize.Error())
+	}
+
+	switch c.OverflowPolicy {
+	case "", OverflowReject, OverflowDrop, OverflowBlock:
+		// O.K.
+
+	default:
+		errs = append(errs, fmt.Sprintf("unknown overflow policy is given: %s", c.OverflowPolicy))
+
+	}
+
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{name: "ScaleInterval", value: c.ScaleInterval},
+		{name: "ScaleUpLatency", value: c.ScaleUpLatency},
+		{name: "SuperviseInterval", value: c.SuperviseInterval},
+		{name: "BlockTimeout", value: c.BlockTimeout},
+		{name: "SlowJobThreshold", value: c.SlowJobThreshold},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			errs = append(errs, fmt.Sprintf("%s must not be negative", d.name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Stats represents a snapshot of the worker pool's statistics.
+type Stats struct {
+	// ReportTime is the time when this snapshot is taken.
+	ReportTime time.Time
+	// QueueSize is the number of jobs waiting in the queue including all priorities.
+	// This also includes the jobs that are dequeued but are waiting for another job with the same Job.Key to finish.
+	QueueSize int
+	// QueueCapacity is the maximum number of jobs the queue can hold including all priorities.
+	// The jobs waiting for a busy Job.Key share this capacity.
+	// Compare this with QueueSize to monitor the queue saturation.
+	QueueCapacity int
+	// WorkerNum is the number of running workers.
+	WorkerNum int
+	// ActiveWorkers is the number of workers executing a job at this moment.
+	ActiveWorkers int
+	// Processed is the cumulative number of finished jobs including failed ones.
+	Processed uint64
+	// Failed is the cumulative number of jobs that panicked.
+	Failed uint64
+	// Expired is the cumulative number of jobs that are skipped because their contexts were canceled before the execution.
+	Expired uint64
+	// Rejected is the cumulative number of jobs that are rejected due to queue overflow.
+	Rejected uint64
+	// Dropped is the cumulative number of jobs that are dropped due to queue overflow.
+	Dropped uint64
+	// Replaced is the cumulative number of workers that unexpectedly stopped and were replaced by new ones.
+	Replaced uint64
+	// AverageLatency is the average execution time of the jobs finished since the previous report.
+	AverageLatency time.Duration
+	// MaxLatency is the longest execution time of the jobs finished since the previous report.
+	MaxLatency time.Duration
+	// Jobs is the cumulative statistics of the named jobs keyed by Job.Name.
+	Jobs map[string]*JobStats
+}
+
+// JobStats represents the cumulative statistics of the jobs with the same name.
+type JobStats struct {
+	// Processed is the number of finished jobs including failed ones.
+	Processed uint64
+	// Failed is the number of jobs that panicked.
+	Failed uint64
+	// Expired is the number of jobs that are skipped due to their contexts' cancellation.
+	Expired uint64
+	// TotalLatency is the sum of the execution time.
+	// Divide this by Processed to get the average latency.
+	TotalLatency time.Duration
+}
+
+// Reporter defines an interface that receives the worker pool's statistics.
+// The registered Reporter is called on every Config.SuperviseInterval.
+type Reporter interface {
+	Report(context.Context, *Stats)
+}
+
+// WorkerOption defines a function signature that Run's functional option must satisfy.
+type WorkerOption func(*worker)
+
+// WithReporter creates a WorkerOption that registers the given Reporter.
+func WithReporter(reporter Reporter) WorkerOption {
+	return func(w *worker) {
+		w.reporter = reporter
+	}
+}
+
+// PanicHandler defines a function signature that is called when a job panics.
+// The recovered value and the stack trace of the panicking goroutine are passed along with the Job.
+// The worker keeps serving other jobs after the handler returns.
+type PanicHandler func(job *Job, recovered interface{}, stack []byte)
+
+// WithPanicHandler creates a WorkerOption that registers the given PanicHandler.
+// This is a handy place to report a panic to an external service or to sarah.Alerter.
+func WithPanicHandler(handler PanicHandler) WorkerOption {
+	return func(w *worker) {
+		w.panicHandler = handler
+	}
+}
+
+// WithLogger creates a WorkerOption that sets the logging.Logger to log to.
+// When this is not given, the package-level Logger returned by logging.GetLogger is used.
+func WithLogger(logger logging.Logger) WorkerOption {
+	return func(w *worker) {
+		w.logger = logger
+	}
+}
+
+// Worker defines an interface that the worker pool satisfies.
+// This satisfies the interface that sarah.RegisterWorker requires, so the instance can be passed as below:
+//
+//  wkr, err := workers.Run(ctx, workers.NewConfig())
+//  if err != nil {
+//    panic(err)
+//  }
+//  sarah.RegisterWorker(wkr)
+type Worker interface {
+	// Enqueue puts the given job in the queue with PriorityNormal.
+	// When the queue is full, the job is handled as Config.OverflowPolicy specifies.
+	Enqueue(func()) error
+
+	// EnqueueJob puts the given Job in the queue that corresponds to its Priority.
+	// When the queue is full, the job is handled as Config.OverflowPolicy specifies.
+	EnqueueJob(*Job) error
+
+	// Stats returns the current snapshot of the worker pool's statistics.
+	Stats() *Stats
+}
+
+// serializer keeps track of the keys of the executing jobs to serialize the execution of the jobs with the same key.
+// The stashed jobs share the bound with the queue so a busy key does not hold jobs beyond the queue's capacity.
+type serializer struct {
+	mutex   sync.Mutex
+	pending map[string][]*Job
+	stashed int
+	// room returns the number of jobs that can be held in addition to the ones in the queue.
+	room func() int
+}
+
+func newSerializer(room func() int) *serializer {
+	return &serializer{
+		pending: make(map[string][]*Job),
+		room:    room,
+	}
+}
+
+// acquire tells if the caller can execute the given job.
+// When a job with the same key is being executed, the given job is stashed til the executing worker picks it up on release.
+// ErrQueueOverflow is returned when the job can not be stashed because the queue and the stashed jobs reached the queue's capacity.
+func (s *serializer) acquire(job *Job) (bool, error) {
+	if job.Key == "" {
+		return true, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pending, ok := s.pending[job.Key]
+	if ok {
+		if s.stashed >= s.room() {
+			return false, ErrQueueOverflow
+		}
+		s.pending[job.Key] = append(pending, job)
+		s.stashed++
+		return false, nil
+	}
+
+	// Mark the key as being executed.
+	s.pending[job.Key] = []*Job{}
+	return true, nil
+}
+
+// release returns the next job with the given key so the caller can continue its execution.
+// When no job is stashed, nil is returned and the key is released.
+func (s *serializer) release(key string) *Job {
+	if key == "" {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pending := s.pending[key]
+	if len(pending) == 0 {
+		delete(s.pending, key)
+		return nil
+	}
+
+	s.pending[key] = pending[1:]
+	s.stashed--
+	return pending[0]
+}
+
+// len returns the number of the stashed jobs.
+func (s *serializer) len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.stashed
+}
+
+// jobStatsRecorder records statistics per job name.
+type jobStatsRecorder struct {
+	mutex sync.Mutex
+	stats map[string]*JobStats
+}
+
+func newJobStatsRecorder() *jobStatsRecorder {
+	return &jobStatsRecorder{
+		stats: make(map[string]*JobStats),
+	}
+}
+
+func (r *jobStatsRecorder) record(name string, fnc func(*JobStats)) {
+	if name == "" {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stats, ok := r.stats[name]
+	if !ok {
+		stats = &JobStats{}
+		r.stats[name] = stats
+	}
+	fnc(stats)
+}
+
+// snapshot returns a copy of the current statistics so the caller can read them without a lock.
+func (r *jobStatsRecorder) snapshot() map[string]*JobStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	snapshot := make(map[string]*JobStats, len(r.stats))
+	for name, stats := range r.stats {
+		copied := *stats
+		snapshot[name] = &copied
+	}
+	return snapshot
+}
+
+type latencyRecorder struct {
+	mutex sync.Mutex
+	total time.Duration
+	max   time.Duration
+	count int64
+}
+
+func (r *latencyRecorder) record(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.total += d
+	r.count++
+	if d > r.max {
+		r.max = d
+	}
+}
+
+// flush returns the average and the max latency since the previous call and resets the recorded values.
+func (r *latencyRecorder) flush() (time.Duration, time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var avg time.Duration
+	if r.count > 0 {
+		avg = r.total / time.Duration(r.count)
+	}
+	max := r.max
+
+	r.total = 0
+	r.max = 0
+	r.count = 0
+
+	return avg, max
+}
+
+// The statuses of a worker that the autoscaler refers to on scale-in.
+const (
+	workerBusy int32 = iota
+	workerIdle
+	workerRetiring
+)
+
+// workerState holds what the autoscaler needs to stop a specific worker.
+type workerState struct {
+	cancel context.CancelFunc
+	// status is one of workerBusy, workerIdle and workerRetiring, and is accessed atomically.
+	status int32
+}
+
+type worker struct {
+	// 64-bit values that are accessed atomically come first to guarantee the alignment on 32-bit platforms.
+	// https://golang.org/pkg/sync/atomic/#pkg-note-BUG
+	processed    uint64
+	failed       uint64
+	expired      uint64
+	rejected     uint64
+	dropped      uint64
+	replaced     uint64
+	workerNum    int64
+	active       int64
+	lastID       uint64
+	config       *Config
+	queue        Queue
+	states       map[uint]*workerState
+	statesMutex  sync.Mutex
+	lost         chan uint
+	ctx          context.Context
+	reporter     Reporter
+	panicHandler PanicHandler
+	logger       logging.Logger
+	latency      *latencyRecorder
+	jobStats     *jobStatsRecorder
+	serializer   *serializer
+	// scaleLatency is recorded separately from latency so that the autoscaler and the supervisor can flush values on their own intervals.
+	scaleLatency *latencyRecorder
+}
+
+var _ Worker = (*worker)(nil)
+
+// log returns the Logger for this worker pool.
+// When no Logger is given by WithLogger, this is resolved on each call so the Logger replaced by logging.SetLogger takes effect.
+func (w *worker) log() logging.Logger {
+	logger := w.logger
+	if logger == nil {
+		logger = logging.GetLogger()
+	}
+	return logger.Module("workers")
+}
+
+// jobLogger returns the Logger with the fields that identify the worker and the job.
+func (w *worker) jobLogger(id uint, job *Job) logging.Logger {
+	return w.log().With(logging.F("worker_id", id)).With(job.fields()...)
+}
+
+// Run creates and runs a new worker pool with the given Config.
+// The workers and the supervising goroutine keep running til the given context is canceled.
+func Run(ctx context.Context, config *Config, options ...WorkerOption) (Worker, error) {
+	if config.WorkerNum == 0 {
+		return nil, ErrInvalidWorkerNum
+	}
+
+	if config.MaxWorkerNum != 0 && config.MaxWorkerNum < config.WorkerNum {
+		return nil, ErrInvalidMaxWorkerNum
+	}
+
+	switch config.OverflowPolicy {
+	case "", OverflowReject, OverflowDrop, OverflowBlock:
+		// O.K.
+
+	default:
+		return nil, fmt.Errorf("unknown overflow policy is given: %s", config.OverflowPolicy)
+
+	}
+
+	w := &worker{
+		config:       config,
+		queue:        nil,
+		states:       make(map[uint]*workerState),
+		lost:         make(chan uint),
+		ctx:          ctx,
+		reporter:     nil,
+		latency:      &latencyRecorder{},
+		scaleLatency: &latencyRecorder{},
+		jobStats:     newJobStatsRecorder(),
+		serializer:   nil,
+	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	if w.queue == nil {
+		if config.QueueSize == 0 {
+			return nil, ErrInvalidQueueSize
+		}
+		w.queue = NewMemoryQueue(config.QueueSize)
+	}
+
+	w.serializer = newSerializer(func() int {
+		return w.queue.Cap() - w.queue.Len()
+	})
+
+	go w.replenish(ctx)
+	w.spawn(ctx, config.WorkerNum)
+
+	if config.SuperviseInterval > 0 {
+		go w.supervise(ctx)
+	}
+
+	if config.MaxWorkerNum > config.WorkerNum && config.ScaleInterval > 0 {
+		go w.autoscale(ctx)
+	}
+
+	return w, nil
+}
+
+func (w *worker) Enqueue(fnc func()) error {
+	return w.EnqueueJob(&Job{
+		Func:     fnc,
+		Priority: PriorityNormal,
+	})
+}
+
+func (w *worker) EnqueueJob(job *Job) error {
+	if job.expired() {
+		atomic.AddUint64(&w.expired, 1)
+		w.jobStats.record(job.Name, func(stats *JobStats) {
+			stats.Expired++
+		})
+		return ErrJobExpired
+	}
+
+	if w.ctx.Err() != nil {
+		return ErrWorkerNotRunning
+	}
+
+	err := w.queue.Enqueue(job)
+	if err != ErrQueueOverflow {
+		return err
+	}
+
+	switch w.config.OverflowPolicy {
+	case OverflowBlock:
+		ctx := w.ctx
+		if w.config.BlockTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, w.config.BlockTimeout)
+			defer cancel()
+		}
+
+		err := w.enqueueWait(ctx, job)
+		if err == nil {
+			return nil
+		}
+
+		if w.ctx.Err() != nil {
+			return ErrWorkerNotRunning
+		}
+
+		if err == context.DeadlineExceeded {
+			atomic.AddUint64(&w.rejected, 1)
+			return ErrQueueOverflow
+		}
+
+		return err
+
+	case OverflowDrop:
+		atomic.AddUint64(&w.dropped, 1)
+		w.log().With(job.fields()...).Warn("Drop a job due to queue overflow", logging.F("queue_capacity", w.queue.Cap()))
+		job.skip(ErrQueueOverflow)
+		return nil
+
+	default:
+		atomic.AddUint64(&w.rejected, 1)
+		return ErrQueueOverflow
+
+	}
+}
+
+// enqueueWaitInterval is the interval to retry Queue.Enqueue when the Queue does not satisfy BlockingQueue.
+var enqueueWaitInterval = 10 * time.Millisecond
+
+func (w *worker) enqueueWait(ctx context.Context, job *Job) error {
+	if blocking, ok := w.queue.(BlockingQueue); ok {
+		return blocking.EnqueueWait(ctx, job)
+	}
+
+	ticker := time.NewTicker(enqueueWaitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			err := w.queue.Enqueue(job)
+			if err != ErrQueueOverflow {
+				return err
+			}
+
+		}
+	}
+}
+
+// HealthCheck returns an error when the worker pool is stopped or has no running worker.
+// This satisfies sarah.HealthChecker so go-sarah's health check reports the pool's state.
+func (w *worker) HealthCheck(_ context.Context) error {
+	if w.ctx.Err() != nil {
+		return ErrWorkerNotRunning
+	}
+
+	if atomic.LoadInt64(&w.workerNum) <= 0 {
+		return errors.New("no worker is running")
+	}
+
+	return nil
+}
+
+func (w *worker) Stats() *Stats {
+	return &Stats{
+		ReportTime:    time.Now(),
+		QueueSize:     w.queue.Len() + w.serializer.len(),
+		QueueCapacity: w.queue.Cap(),
+		WorkerNum:     int(atomic.LoadInt64(&w.workerNum)),
+		ActiveWorkers: int(atomic.LoadInt64(&w.active)),
+		Processed:     atomic.LoadUint64(&w.processed),
+		Failed:        atomic.LoadUint64(&w.failed),
+		Expired:       atomic.LoadUint64(&w.expired),
+		Rejected:      atomic.LoadUint64(&w.rejected),
+		Dropped:       atomic.LoadUint64(&w.dropped),
+		Replaced:      atomic.LoadUint64(&w.replaced),
+		Jobs:          w.jobStats.snapshot(),
+	}
+}
+
+func (w *worker) spawn(ctx context.Context, num uint) {
+	for i := uint(0); i < num; i++ {
+		// Increment here instead of in run() so the autoscaler sees the updated number right after spawning.
+		atomic.AddInt64(&w.workerNum, 1)
+		id := uint(atomic.AddUint64(&w.lastID, 1))
+
+		// Each worker has its own context so the autoscaler can stop a specific worker on scale-in.
+		workerCtx, cancel := context.WithCancel(ctx)
+		state := &workerState{cancel: cancel, status: workerBusy}
+		w.statesMutex.Lock()
+		w.states[id] = state
+		w.statesMutex.Unlock()
+
+		go w.run(ctx, workerCtx, id, state)
+	}
+}
+
+func (w *worker) run(ctx context.Context, workerCtx context.Context, id uint, state *workerState) {
+	stopped := false
+	defer func() {
+		atomic.AddInt64(&w.workerNum, -1)
+
+		state.cancel()
+		w.statesMutex.Lock()
+		delete(w.states, id)
+		w.statesMutex.Unlock()
+
+		if stopped {
+			return
+		}
+
+		// A job panic is recovered in execute(), but the goroutine may still exit when runtime.Goexit is called in a job.
+		// Let replenish() know so the pool capacity does not silently shrink.
+		select {
+		case w.lost <- id:
+		case <-ctx.Done():
+		}
+	}()
+
+	log := w.log().With(logging.F("worker_id", id))
+	log.Debug("Start worker")
+	for {
+		// The autoscaler only retires a worker that is waiting for a job.
+		if !atomic.CompareAndSwapInt32(&state.status, workerBusy, workerIdle) {
+			stopped = true
+			log.Debug("Stop worker due to scale-in")
+			return
+		}
+
+		job, err := w.queue.Dequeue(workerCtx)
+		// When this worker is retired while a job is being dequeued, the status stays workerRetiring.
+		// The dequeued job is still executed so it is not lost, and the worker stops on the next iteration.
+		atomic.CompareAndSwapInt32(&state.status, workerIdle, workerBusy)
+		if err != nil {
+			if ctx.Err() != nil {
+				stopped = true
+				log.Debug("Stop worker")
+				return
+			}
+
+			if workerCtx.Err() != nil {
+				stopped = true
+				log.Debug("Stop worker due to scale-in")
+				return
+			}
+
+			// A remote Queue backend may fail temporarily.
+			log.Error("Failed to dequeue a job", logging.Err(err))
+			time.Sleep(dequeueRetryInterval)
+			continue
+		}
+
+		// When another worker is executing a job with the same key, the job is passed to that worker.
+		if !w.acquire(ctx, job) {
+			continue
+		}
+
+		for job != nil {
+			w.execute(id, job)
+			job = w.serializer.release(job.Key)
+		}
+	}
+}
+
+// acquire tells if the caller can execute the given job right away.
+// When another worker is executing a job with the same key, the job is stashed so that worker executes it next.
+// When too many jobs are stashed, the job is handled as Config.OverflowPolicy specifies just like an overflow on EnqueueJob.
+func (w *worker) acquire(ctx context.Context, job *Job) bool {
+	acquired, err := w.serializer.acquire(job)
+	if err != ErrQueueOverflow {
+		return acquired
+	}
+
+	switch w.config.OverflowPolicy {
+	case OverflowBlock:
+		if w.config.BlockTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, w.config.BlockTimeout)
+			defer cancel()
+		}
+
+		// A stashed job is always picked up by the worker that executes a job with the same key, so a room is made eventually.
+		ticker := time.NewTicker(enqueueWaitInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					atomic.AddUint64(&w.rejected, 1)
+					w.log().With(job.fields()...).Warn("Reject a job waiting for a busy key due to overflow", logging.F("queue_capacity", w.queue.Cap()))
+					job.skip(ErrQueueOverflow)
+					return false
+				}
+
+				job.skip(ErrWorkerNotRunning)
+				return false
+
+			case <-ticker.C:
+				acquired, err = w.serializer.acquire(job)
+				if err != ErrQueueOverflow {
+					return acquired
+				}
+
+			}
+		}
+
+	case OverflowDrop:
+		atomic.AddUint64(&w.dropped, 1)
+		w.log().With(job.fields()...).Warn("Drop a job waiting for a busy key due to overflow", logging.F("queue_capacity", w.queue.Cap()))
+		job.skip(ErrQueueOverflow)
+		return false
+
+	default:
+		// The job is already accepted by EnqueueJob, so the caller can not be told with an error.
+		atomic.AddUint64(&w.rejected, 1)
+		w.log().With(job.fields()...).Warn("Reject a job waiting for a busy key due to overflow", logging.F("queue_capacity", w.queue.Cap()))
+		job.skip(ErrQueueOverflow)
+		return false
+
+	}
+}
+
+// dequeueRetryInterval is the interval to wait before retrying Queue.Dequeue when the Queue returns an error.
+var dequeueRetryInterval = 1 * time.Second
+
+// replenish spawns a new worker when any running worker unexpectedly stops.
+func (w *worker) replenish(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case id := <-w.lost:
+			atomic.AddUint64(&w.replaced, 1)
+			w.log().Error("Worker unexpectedly stopped. Spawn a replacement", logging.F("worker_id", id))
+			w.spawn(ctx, 1)
+
+		}
+	}
+}
+
+func (w *worker) execute(id uint, job *Job) {
+	if job.expired() {
+		atomic.AddUint64(&w.expired, 1)
+		w.jobStats.record(job.Name, func(stats *JobStats) {
+			stats.Expired++
+		})
+		w.jobLogger(id, job).Warn("Skip an expired job", logging.Err(job.Context.Err()))
+		job.skip(job.Context.Err())
+		return
+	}
+
+	atomic.AddInt64(&w.active, 1)
+	started := time.Now()
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			atomic.AddUint64(&w.failed, 1)
+			stack := debug.Stack()
+			w.jobLogger(id, job).Error("Panic on job execution", logging.F("panic", r), logging.F("stack", string(stack)))
+			if w.panicHandler != nil {
+				w.handlePanic(job, r, stack)
+			}
+		}
+
+		elapsed := time.Since(started)
+		if w.config.SlowJobThreshold > 0 && elapsed > w.config.SlowJobThreshold {
+			w.jobLogger(id, job).Warn("Slow job execution", logging.F("elapsed", elapsed))
+		}
+
+		w.latency.record(elapsed)
+		w.scaleLatency.record(elapsed)
+		w.jobStats.record(job.Name, func(stats *JobStats) {
+			stats.Processed++
+			stats.TotalLatency += elapsed
+			if r != nil {
+				stats.Failed++
+			}
+		})
+		atomic.AddUint64(&w.processed, 1)
+		atomic.AddInt64(&w.active, -1)
+	}()
+
+	job.Func()
+}
+
+func (w *worker) handlePanic(job *Job, recovered interface{}, stack []byte) {
+	defer func() {
+		// Panicking in a deferred function is not recovered by the caller, which results in the process termination.
+		if r := recover(); r != nil {
+			w.log().With(job.fields()...).Error("Panic on panic handler", logging.F("panic", r))
+		}
+	}()
+
+	w.panicHandler(job, recovered, stack)
+}
+
+func (w *worker) supervise(ctx context.Context) {
+	ticker := time.NewTicker(w.config.SuperviseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			stats := w.Stats()
+			stats.AverageLatency, stats.MaxLatency = w.latency.flush()
+			if w.reporter != nil {
+				go w.reporter.Report(ctx, stats)
+			}
+
+		}
+	}
+}
+
+func (w *worker) autoscale(ctx context.Context) {
+	ticker := time.NewTicker(w.config.ScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			w.scale(ctx)
+
+		}
+	}
+}
+
+func (w *worker) scale(ctx context.Context) {
+	current := uint(atomic.LoadInt64(&w.workerNum))
+	active := uint(atomic.LoadInt64(&w.active))
+	depth := uint(w.queue.Len())
+	avg, _ := w.scaleLatency.flush()
+
+	min := w.config.WorkerNum
+	max := w.config.MaxWorkerNum
+
+	slow := w.config.ScaleUpLatency > 0 && avg > w.config.ScaleUpLatency
+	switch {
+	case current < max && (depth > 0 || slow):
+		// Add as many workers as the waiting jobs so the queue can be consumed on the next tick.
+		num := depth
+		if num == 0 {
+			num = 1
+		}
+		if current+num > max {
+			num = max - current
+		}
+		w.log().Info("Scale out workers", logging.F("from", current), logging.F("to", current+num), logging.F("queue_size", depth), logging.F("average_latency", avg))
+		w.spawn(ctx, num)
+
+	case current > min && depth == 0 && active < current:
+		// Stop half of the idle workers at a time to avoid flapping.
+		// A worker that is executing a job at this moment is never stopped.
+		num := (current - active) / 2
+		if num == 0 {
+			num = 1
+		}
+		if current-num < min {
+			num = current - min
+		}
+
+		w.statesMutex.Lock()
+		stopped := uint(0)
+		for id, state := range w.states {
+			if stopped >= num {
+				break
+			}
+			if !atomic.CompareAndSwapInt32(&state.status, workerIdle, workerRetiring) {
+				continue
+			}
+			state.cancel()
+			delete(w.states, id)
+			stopped++
+		}
+		w.statesMutex.Unlock()
+
+		if stopped > 0 {
+			w.log().Info("Scale in workers", logging.F("from", current), logging.F("to", current-stopped))
+		}
+
+	}
+}
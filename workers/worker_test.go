@@ -0,0 +1,1013 @@
+package workers
+
+import (
+	"context"
+	"github.com/oklahomer/go-kasumi/logger"
+	"github.com/oklahomer/go-sarah/v4/logging"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	oldLogger := logger.GetLogger()
+	defer logger.SetLogger(oldLogger)
+
+	// Suppress log output in test by default
+	l := log.New(ioutil.Discard, "dummyLog", 0)
+	logger.SetLogger(logger.NewWithStandardLogger(l))
+
+	code := m.Run()
+
+	os.Exit(code)
+}
+
+type DummyReporter struct {
+	ReportFunc func(context.Context, *Stats)
+}
+
+func (r *DummyReporter) Report(ctx context.Context, stats *Stats) {
+	r.ReportFunc(ctx, stats)
+}
+
+func TestNewConfig(t *testing.T) {
+	config := NewConfig()
+	if config == nil {
+		t.Fatal("Expected *Config is not returned.")
+	}
+
+	if config.WorkerNum == 0 {
+		t.Error("Default WorkerNum is not set.")
+	}
+}
+
+func TestConfig_ApplyDefaults(t *testing.T) {
+	config := &Config{}
+	config.ApplyDefaults()
+
+	if config.OverflowPolicy != OverflowReject {
+		t.Errorf("Default OverflowPolicy is not set: %s.", config.OverflowPolicy)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := NewConfig().Validate(); err != nil {
+		t.Errorf("Unexpected error is returned for the default config: %s.", err.Error())
+	}
+
+	config := NewConfig()
+	config.WorkerNum = 0
+	config.QueueSize = 0
+	config.OverflowPolicy = "unknown"
+	config.BlockTimeout = -1
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Expected error is not returned.")
+	}
+
+	for _, expected := range []string{ErrInvalidWorkerNum.Error(), ErrInvalidQueueSize.Error(), "unknown", "BlockTimeout"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Error does not describe %q: %s.", expected, err.Error())
+		}
+	}
+}
+
+func TestWithReporter(t *testing.T) {
+	reporter := &DummyReporter{}
+	w := &worker{}
+
+	WithReporter(reporter)(w)
+
+	if w.reporter != reporter {
+		t.Errorf("Expected Reporter is not set: %#v.", w.reporter)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Run("invalid worker num", func(t *testing.T) {
+		config := &Config{
+			WorkerNum: 0,
+			QueueSize: 1,
+		}
+
+		_, err := Run(context.TODO(), config)
+		if err != ErrInvalidWorkerNum {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("invalid max worker num", func(t *testing.T) {
+		config := &Config{
+			WorkerNum:    2,
+			MaxWorkerNum: 1,
+			QueueSize:    1,
+		}
+
+		_, err := Run(context.TODO(), config)
+		if err != ErrInvalidMaxWorkerNum {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("invalid queue size", func(t *testing.T) {
+		config := &Config{
+			WorkerNum: 1,
+			QueueSize: 0,
+		}
+
+		_, err := Run(context.TODO(), config)
+		if err != ErrInvalidQueueSize {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("invalid overflow policy", func(t *testing.T) {
+		config := &Config{
+			WorkerNum:      1,
+			QueueSize:      1,
+			OverflowPolicy: "unknown",
+		}
+
+		_, err := Run(context.TODO(), config)
+		if err == nil {
+			t.Error("Expected error is not returned.")
+		}
+	})
+
+	t.Run("valid config", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		config := &Config{
+			WorkerNum: 3,
+			QueueSize: 5,
+		}
+		wkr, err := Run(ctx, config)
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		typed, ok := wkr.(*worker)
+		if !ok {
+			t.Fatalf("Unexpected type is returned: %T.", wkr)
+		}
+
+		if typed.queue.Cap() != int(config.QueueSize)*3 {
+			t.Errorf("Unexpected queue size is set: %d.", typed.queue.Cap())
+		}
+	})
+}
+
+func TestWorker_Enqueue(t *testing.T) {
+	t.Run("successful execution", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		wkr, _ := Run(ctx, &Config{WorkerNum: 1, QueueSize: 1})
+
+		done := make(chan struct{})
+		err := wkr.Enqueue(func() {
+			close(done)
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		select {
+		case <-done:
+			// O.K.
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("Enqueued job is not executed.")
+
+		}
+	})
+
+	t.Run("queue overflow", func(t *testing.T) {
+		w := &worker{
+			config: &Config{OverflowPolicy: OverflowReject},
+			queue:  NewMemoryQueue(1),
+			ctx:    context.Background(),
+		}
+
+		_ = w.Enqueue(func() {})
+		err := w.Enqueue(func() {})
+
+		if err != ErrQueueOverflow {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if w.rejected != 1 {
+			t.Errorf("Rejection is not counted: %d.", w.rejected)
+		}
+	})
+
+	t.Run("drop on overflow", func(t *testing.T) {
+		w := &worker{
+			config: &Config{OverflowPolicy: OverflowDrop},
+			queue:  NewMemoryQueue(1),
+			ctx:    context.Background(),
+		}
+
+		_ = w.Enqueue(func() {})
+		var skipped error
+		err := w.EnqueueJob(&Job{
+			Func: func() {},
+			OnSkip: func(err error) {
+				skipped = err
+			},
+		})
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		if w.dropped != 1 {
+			t.Errorf("Drop is not counted: %d.", w.dropped)
+		}
+
+		if skipped != ErrQueueOverflow {
+			t.Errorf("OnSkip is not called with expected error: %#v.", skipped)
+		}
+	})
+
+	t.Run("block til timeout", func(t *testing.T) {
+		w := &worker{
+			config: &Config{
+				OverflowPolicy: OverflowBlock,
+				BlockTimeout:   10 * time.Millisecond,
+			},
+			queue: NewMemoryQueue(1),
+			ctx:   context.Background(),
+		}
+
+		_ = w.Enqueue(func() {})
+		err := w.Enqueue(func() {})
+
+		if err != ErrQueueOverflow {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+
+	t.Run("block til dequeue", func(t *testing.T) {
+		w := &worker{
+			config: &Config{
+				OverflowPolicy: OverflowBlock,
+			},
+			queue: NewMemoryQueue(1),
+			ctx:   context.Background(),
+		}
+
+		_ = w.Enqueue(func() {})
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_, _ = w.queue.Dequeue(context.TODO())
+		}()
+		err := w.Enqueue(func() {})
+
+		if err != nil {
+			t.Errorf("Unexpected error is returned: %s.", err.Error())
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		wkr, _ := Run(ctx, &Config{WorkerNum: 1, QueueSize: 1})
+		cancel()
+
+		err := wkr.Enqueue(func() {})
+
+		if err != ErrWorkerNotRunning {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestWorker_EnqueueJob(t *testing.T) {
+	t.Run("separate queue per priority", func(t *testing.T) {
+		w := &worker{
+			config: &Config{OverflowPolicy: OverflowReject},
+			queue:  NewMemoryQueue(1),
+			ctx:    context.Background(),
+		}
+
+		for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+			err := w.EnqueueJob(&Job{Func: func() {}, Priority: p})
+			if err != nil {
+				t.Errorf("Unexpected error is returned for %s priority: %s.", p, err.Error())
+			}
+		}
+
+		err := w.EnqueueJob(&Job{Func: func() {}, Priority: PriorityLow})
+		if err != ErrQueueOverflow {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+	})
+}
+
+func TestWorker_EnqueueJob_Expiration(t *testing.T) {
+	t.Run("expired on enqueue", func(t *testing.T) {
+		w := &worker{
+			config:     &Config{OverflowPolicy: OverflowReject},
+			queue:      NewMemoryQueue(1),
+			ctx:        context.Background(),
+			jobStats:   newJobStatsRecorder(),
+			serializer: newSerializer(func() int { return 1 }),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := w.EnqueueJob(&Job{Func: func() {}, Context: ctx})
+		if err != ErrJobExpired {
+			t.Errorf("Expected error is not returned: %#v.", err)
+		}
+
+		if w.Stats().Expired != 1 {
+			t.Errorf("Expiration is not counted: %d.", w.Stats().Expired)
+		}
+	})
+
+	t.Run("expired in queue", func(t *testing.T) {
+		w := &worker{
+			config:       &Config{OverflowPolicy: OverflowReject},
+			queue:        NewMemoryQueue(1),
+			ctx:          context.Background(),
+			latency:      &latencyRecorder{},
+			scaleLatency: &latencyRecorder{},
+			jobStats:     newJobStatsRecorder(),
+			serializer:   newSerializer(func() int { return 1 }),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		executed := false
+		var skipped error
+		err := w.EnqueueJob(&Job{
+			Func: func() {
+				executed = true
+			},
+			Context: ctx,
+			OnSkip: func(err error) {
+				skipped = err
+			},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+
+		cancel()
+		job, _ := w.queue.Dequeue(context.TODO())
+		w.execute(1, job)
+
+		if executed {
+			t.Error("Expired job is executed.")
+		}
+
+		if skipped != context.Canceled {
+			t.Errorf("OnSkip is not called with expected error: %#v.", skipped)
+		}
+
+		stats := w.Stats()
+		if stats.Expired != 1 {
+			t.Errorf("Expiration is not counted: %d.", stats.Expired)
+		}
+
+		if stats.Processed != 0 {
+			t.Errorf("Expired job is counted as processed: %d.", stats.Processed)
+		}
+	})
+}
+
+func TestJob_String(t *testing.T) {
+	tests := []struct {
+		job      *Job
+		expected string
+	}{
+		{
+			job:      &Job{},
+			expected: "unnamed",
+		},
+		{
+			job:      &Job{Name: "greeting"},
+			expected: "greeting",
+		},
+		{
+			job: &Job{
+				Name: "greeting",
+				Labels: map[string]string{
+					"room":     "general",
+					"bot_type": "slack",
+				},
+			},
+			expected: "greeting{bot_type=slack,room=general}",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.job.String() != tt.expected {
+			t.Errorf("Unexpected string is returned: %s.", tt.job.String())
+		}
+	}
+}
+
+func TestWorker_execute_NamedJob(t *testing.T) {
+	w := &worker{
+		config:       &Config{SlowJobThreshold: 1 * time.Nanosecond},
+		queue:        NewMemoryQueue(1),
+		latency:      &latencyRecorder{},
+		scaleLatency: &latencyRecorder{},
+		jobStats:     newJobStatsRecorder(),
+		serializer:   newSerializer(func() int { return 1 }),
+	}
+
+	w.execute(1, &Job{Name: "greeting", Func: func() {}})
+	w.execute(1, &Job{Name: "greeting", Func: func() { panic("dummy") }})
+	w.execute(1, &Job{Func: func() {}})
+
+	stats := w.Stats().Jobs
+	if len(stats) != 1 {
+		t.Fatalf("Unexpected number of named job stats: %d.", len(stats))
+	}
+
+	greeting, ok := stats["greeting"]
+	if !ok {
+		t.Fatal("Stats for the named job is not recorded.")
+	}
+
+	if greeting.Processed != 2 {
+		t.Errorf("Unexpected number of processed jobs: %d.", greeting.Processed)
+	}
+
+	if greeting.Failed != 1 {
+		t.Errorf("Unexpected number of failed jobs: %d.", greeting.Failed)
+	}
+}
+
+func TestPriority_String(t *testing.T) {
+	tests := []struct {
+		priority Priority
+		expected string
+	}{
+		{priority: PriorityLow, expected: "low"},
+		{priority: PriorityNormal, expected: "normal"},
+		{priority: PriorityHigh, expected: "high"},
+		{priority: Priority(100), expected: "unknown(100)"},
+	}
+
+	for _, tt := range tests {
+		if tt.priority.String() != tt.expected {
+			t.Errorf("Unexpected string is returned: %s.", tt.priority.String())
+		}
+	}
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	handler := func(_ *Job, _ interface{}, _ []byte) {}
+	w := &worker{}
+
+	WithPanicHandler(handler)(w)
+
+	if w.panicHandler == nil {
+		t.Error("Expected PanicHandler is not set.")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	l := logging.NewLogger(logging.NewTextHandler(ioutil.Discard))
+	w := &worker{}
+
+	WithLogger(l)(w)
+
+	if w.logger != l {
+		t.Error("Expected Logger is not set.")
+	}
+}
+
+func TestWorker_PanicIsolation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type panicked struct {
+		job       *Job
+		recovered interface{}
+	}
+	handled := make(chan *panicked, 1)
+	handler := func(job *Job, recovered interface{}, stack []byte) {
+		if len(stack) == 0 {
+			t.Error("Stack trace is not given.")
+		}
+		handled <- &panicked{job: job, recovered: recovered}
+		panic("panic on handler should also be recovered")
+	}
+
+	wkr, err := Run(ctx, &Config{WorkerNum: 1, QueueSize: 1}, WithPanicHandler(handler))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	_ = wkr.EnqueueJob(&Job{Name: "panic", Func: func() { panic("dummy") }})
+	select {
+	case p := <-handled:
+		if p.job.Name != "panic" || p.recovered != "dummy" {
+			t.Errorf("Unexpected values are passed: %#v.", p)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("PanicHandler is not called.")
+
+	}
+
+	// The only worker should keep serving.
+	executed := make(chan struct{})
+	_ = wkr.Enqueue(func() { close(executed) })
+	select {
+	case <-executed:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Worker stopped serving after panic.")
+
+	}
+}
+
+func TestWorker_replenish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wkr, err := Run(ctx, &Config{WorkerNum: 1, QueueSize: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	// Stop the only worker goroutine.
+	_ = wkr.Enqueue(runtime.Goexit)
+
+	executed := make(chan struct{})
+	_ = wkr.Enqueue(func() { close(executed) })
+	select {
+	case <-executed:
+		// O.K.
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Replacement worker is not spawned.")
+
+	}
+
+	if wkr.Stats().Replaced != 1 {
+		t.Errorf("Replacement is not counted: %d.", wkr.Stats().Replaced)
+	}
+}
+
+func TestWorker_HealthCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &worker{
+		ctx:       ctx,
+		workerNum: 1,
+	}
+
+	if err := w.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	atomic.StoreInt64(&w.workerNum, 0)
+	if err := w.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected error is not returned when no worker is running.")
+	}
+
+	cancel()
+	if err := w.HealthCheck(context.Background()); err != ErrWorkerNotRunning {
+		t.Errorf("Unexpected error is returned: %#v.", err)
+	}
+}
+
+func TestWorker_Stats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wkr, _ := Run(ctx, &Config{WorkerNum: 2, QueueSize: 3})
+
+	finished := make(chan struct{}, 2)
+	_ = wkr.Enqueue(func() {
+		finished <- struct{}{}
+	})
+	_ = wkr.Enqueue(func() {
+		defer func() {
+			finished <- struct{}{}
+		}()
+		panic("dummy")
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-finished:
+			// O.K.
+
+		case <-time.NewTimer(1 * time.Second).C:
+			t.Fatal("Enqueued job is not executed.")
+
+		}
+	}
+
+	// Wait til statistics are recorded after the job execution.
+	time.Sleep(100 * time.Millisecond)
+
+	stats := wkr.Stats()
+	if stats.Processed != 2 {
+		t.Errorf("Unexpected number of processed jobs: %d.", stats.Processed)
+	}
+
+	if stats.Failed != 1 {
+		t.Errorf("Unexpected number of failed jobs: %d.", stats.Failed)
+	}
+
+	if stats.WorkerNum != 2 {
+		t.Errorf("Unexpected number of workers: %d.", stats.WorkerNum)
+	}
+
+	if stats.QueueCapacity != 9 {
+		t.Errorf("Unexpected queue capacity: %d.", stats.QueueCapacity)
+	}
+}
+
+func TestWorker_supervise(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reported := make(chan *Stats, 1)
+	reporter := &DummyReporter{
+		ReportFunc: func(_ context.Context, stats *Stats) {
+			select {
+			case reported <- stats:
+			default:
+			}
+		},
+	}
+
+	config := &Config{
+		WorkerNum:         1,
+		QueueSize:         1,
+		SuperviseInterval: 10 * time.Millisecond,
+	}
+	_, err := Run(ctx, config, WithReporter(reporter))
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	select {
+	case stats := <-reported:
+		if stats.QueueCapacity != 3 {
+			t.Errorf("Unexpected stats is reported: %#v.", stats)
+		}
+
+	case <-time.NewTimer(1 * time.Second).C:
+		t.Fatal("Stats is not reported.")
+
+	}
+}
+
+func TestWorker_scale(t *testing.T) {
+	waitWorkerNum := func(t *testing.T, w *worker, expected int64) {
+		for i := 0; i < 100; i++ {
+			if atomic.LoadInt64(&w.workerNum) == expected {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Unexpected number of workers: %d.", atomic.LoadInt64(&w.workerNum))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := &Config{
+		WorkerNum:    1,
+		MaxWorkerNum: 3,
+		QueueSize:    5,
+	}
+	wkr, err := Run(ctx, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	w := wkr.(*worker)
+
+	// Occupy the only worker and let the rest of the jobs wait in the queue.
+	block := make(chan struct{})
+	started := make(chan struct{}, 5)
+	for i := 0; i < 5; i++ {
+		_ = w.Enqueue(func() {
+			started <- struct{}{}
+			<-block
+		})
+	}
+	<-started
+
+	w.scale(ctx)
+	waitWorkerNum(t, w, 3)
+
+	close(block)
+	for i := 1; i < 5; i++ {
+		<-started
+	}
+	for i := 0; i < 100 && atomic.LoadInt64(&w.active) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	w.scale(ctx)
+	waitWorkerNum(t, w, 2)
+
+	w.scale(ctx)
+	waitWorkerNum(t, w, 1)
+
+	// Never go below the minimum.
+	w.scale(ctx)
+	time.Sleep(10 * time.Millisecond)
+	waitWorkerNum(t, w, 1)
+}
+
+func TestWorker_scale_IdleOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := &Config{
+		WorkerNum:    1,
+		MaxWorkerNum: 3,
+		QueueSize:    5,
+	}
+	wkr, err := Run(ctx, config)
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+	w := wkr.(*worker)
+	w.spawn(ctx, 2)
+
+	// Occupy two of the three workers.
+	block := make(chan struct{})
+	defer close(block)
+	started := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		_ = w.Enqueue(func() {
+			started <- struct{}{}
+			<-block
+		})
+	}
+	<-started
+	<-started
+
+	// Wait til the remaining worker starts waiting for a job.
+	idle := func() bool {
+		w.statesMutex.Lock()
+		defer w.statesMutex.Unlock()
+		for _, state := range w.states {
+			if atomic.LoadInt32(&state.status) == workerIdle {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < 100 && !idle(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	w.scale(ctx)
+
+	for i := 0; i < 100 && atomic.LoadInt64(&w.workerNum) != 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if num := atomic.LoadInt64(&w.workerNum); num != 2 {
+		t.Fatalf("Idle worker is not stopped: %d.", num)
+	}
+
+	w.statesMutex.Lock()
+	defer w.statesMutex.Unlock()
+	for id, state := range w.states {
+		if atomic.LoadInt32(&state.status) != workerBusy {
+			t.Errorf("Busy worker is retired: %d.", id)
+		}
+	}
+}
+
+func TestWorker_SerializeByKey(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wkr, err := Run(ctx, &Config{WorkerNum: 3, QueueSize: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error is returned: %s.", err.Error())
+	}
+
+	mutex := &sync.Mutex{}
+	var executed []int
+	running := int32(0)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		i := i
+		wg.Add(1)
+		err := wkr.EnqueueJob(&Job{
+			Key: "room",
+			Func: func() {
+				defer wg.Done()
+				if atomic.AddInt32(&running, 1) > 1 {
+					t.Error("Jobs with the same key run concurrently.")
+				}
+				time.Sleep(5 * time.Millisecond)
+				mutex.Lock()
+				executed = append(executed, i)
+				mutex.Unlock()
+				atomic.AddInt32(&running, -1)
+			},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error is returned: %s.", err.Error())
+		}
+	}
+	wg.Wait()
+
+	for i, v := range executed {
+		if i != v {
+			t.Fatalf("Jobs are not executed in order: %v.", executed)
+		}
+	}
+}
+
+func TestSerializer(t *testing.T) {
+	s := newSerializer(func() int { return 1 })
+
+	if acquired, _ := s.acquire(&Job{}); !acquired {
+		t.Error("Job without key should always be executable.")
+	}
+
+	first := &Job{Key: "room"}
+	second := &Job{Key: "room"}
+	third := &Job{Key: "room"}
+	if acquired, _ := s.acquire(first); !acquired {
+		t.Error("First job should be executable.")
+	}
+
+	if acquired, err := s.acquire(second); acquired || err != nil {
+		t.Error("Second job should be stashed.")
+	}
+
+	if _, err := s.acquire(third); err != ErrQueueOverflow {
+		t.Errorf("Expected error is not returned: %#v.", err)
+	}
+
+	if s.len() != 1 {
+		t.Errorf("Unexpected number of stashed jobs is returned: %d.", s.len())
+	}
+
+	if s.release("room") != second {
+		t.Error("Stashed job is not returned.")
+	}
+
+	if s.len() != 0 {
+		t.Errorf("Unexpected number of stashed jobs is returned: %d.", s.len())
+	}
+
+	if s.release("room") != nil {
+		t.Error("Nil should be returned when no job is stashed.")
+	}
+
+	if _, ok := s.pending["room"]; ok {
+		t.Error("Key is not released.")
+	}
+}
+
+func TestWorker_SerializeByKey_Overflow(t *testing.T) {
+	tests := []struct {
+		policy   OverflowPolicy
+		rejected uint64
+		dropped  uint64
+	}{
+		{
+			policy:   OverflowReject,
+			rejected: 1,
+		},
+		{
+			policy:  OverflowDrop,
+			dropped: 1,
+		},
+		{
+			policy: OverflowBlock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			wkr, err := Run(ctx, &Config{WorkerNum: 2, QueueSize: 1, OverflowPolicy: tt.policy})
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+
+			// The first job holds the key while the following jobs wait for it.
+			started := make(chan struct{})
+			proceed := make(chan struct{})
+			err = wkr.EnqueueJob(&Job{
+				Key: "room",
+				Func: func() {
+					close(started)
+					<-proceed
+				},
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error is returned: %s.", err.Error())
+			}
+			<-started
+
+			// The memory queue with the size of 1 holds 3 jobs in total, so the 4th job overflows.
+			executed := make(chan struct{}, 4)
+			skipped := make(chan error, 4)
+			for i := 0; i < 4; i++ {
+				err := wkr.EnqueueJob(&Job{
+					Key: "room",
+					Func: func() {
+						executed <- struct{}{}
+					},
+					OnSkip: func(err error) {
+						skipped <- err
+					},
+				})
+				if err != nil {
+					t.Fatalf("Unexpected error is returned: %s.", err.Error())
+				}
+
+				// Let the idle worker dequeue the job before the next one is enqueued.
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			stats := wkr.Stats()
+			if stats.QueueSize != 3 {
+				t.Errorf("Unexpected queue size is returned: %d.", stats.QueueSize)
+			}
+			if stats.QueueCapacity != 3 {
+				t.Errorf("Unexpected queue capacity is returned: %d.", stats.QueueCapacity)
+			}
+
+			if tt.policy != OverflowBlock {
+				select {
+				case err := <-skipped:
+					if err != ErrQueueOverflow {
+						t.Errorf("Unexpected error is passed: %#v.", err)
+					}
+
+				case <-time.NewTimer(time.Second).C:
+					t.Fatal("Overflowing job is not skipped.")
+
+				}
+			}
+			close(proceed)
+
+			expected := 4
+			if tt.policy != OverflowBlock {
+				expected = 3
+			}
+			for i := 0; i < expected; i++ {
+				select {
+				case <-executed:
+					// O.K.
+
+				case <-time.NewTimer(time.Second).C:
+					t.Fatal("Job is not executed.")
+
+				}
+			}
+
+			stats = wkr.Stats()
+			if stats.Rejected != tt.rejected {
+				t.Errorf("Unexpected number of rejected jobs: %d.", stats.Rejected)
+			}
+			if stats.Dropped != tt.dropped {
+				t.Errorf("Unexpected number of dropped jobs: %d.", stats.Dropped)
+			}
+		})
+	}
+}
+
+func TestLatencyRecorder(t *testing.T) {
+	recorder := &latencyRecorder{}
+	recorder.record(1 * time.Second)
+	recorder.record(3 * time.Second)
+
+	avg, max := recorder.flush()
+	if avg != 2*time.Second {
+		t.Errorf("Unexpected average latency is returned: %s.", avg)
+	}
+
+	if max != 3*time.Second {
+		t.Errorf("Unexpected max latency is returned: %s.", max)
+	}
+
+	avg, max = recorder.flush()
+	if avg != 0 || max != 0 {
+		t.Errorf("Recorded values are not reset: %s, %s.", avg, max)
+	}
+}